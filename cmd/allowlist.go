@@ -1,26 +1,59 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/berbyte/sinkzone/internal/subscription"
 	"github.com/spf13/cobra"
 )
 
+var allowlistBreak bool
+
+// allowlistReport is printed by 'allowlist list --output json'
+type allowlistReport struct {
+	Path          string               `json:"path"` // File the domains are stored in
+	Domains       []string             `json:"domains"`
+	Subscriptions []subscriptionReport `json:"subscriptions"`
+}
+
 var allowlistCmd = &cobra.Command{
-	Use:   "allowlist [add/remove/list] [domain]",
+	Use:   "allowlist [add/remove/list/edit/undo/subscribe/unsubscribe/enable/disable/update] [domain|url]",
 	Short: "Manage the allowlist",
 	Long: `Add, remove, or list domains from the allowlist — the list of domains permitted during focus mode.
 
-During focus sessions, all DNS requests are blocked except for domains in your allowlist. You can use 'sinkzone allowlist add <domain>' to permit access, 'remove <domain>' to revoke it, or 'list' to see all allowed domains.
+During focus sessions, all DNS requests are blocked except for domains in your allowlist. You can use 'sinkzone allowlist add <domain>' to permit access, 'remove <domain>' to revoke it, or 'list' to see all allowed domains. 'edit' opens the allowlist in $VISUAL or $EDITOR, checks every entry when you save, and applies the changes to a running resolver right away.
+
+'undo' restores the allowlist as it was before the last change made through sinkzone: an add or remove, a batch of domains allowed or removed at once (such as marked rows in the TUI or 'sinkzone suggestions allow --all'), or an edit. The last 20 changes are kept in allowlist-journal.json next to allowlist.txt, so running 'undo' again goes back one more change; an undo can't itself be undone. The TUI undoes with u on the Monitoring and Allowlist tabs.
 
 Wildcard patterns are supported:
   * "*github*" matches any domain containing "github"
   * "*.example.com" matches all subdomains of example.com
   * "api.*.com" matches api.anydomain.com
 
+'subscribe <url>' follows a hosted community allowlist, such as a list of the domains a tool or a course needs, in any format 'sinkzone blocklist import' reads. It is downloaded into ~/.sinkzone/allowlists and its URL saved under allowlist_subscriptions in sinkzone.yaml; a running resolver downloads it again every subscription_refresh (default 24h), only when it changed. 'update' downloads the subscribed lists right away ('sinkzone lists status' shows what changed), 'disable <url>' and 'enable <url>' stop and resume using one, and 'unsubscribe <url>' stops following it. Subscribed allowlists extend allowlist.txt, so profiles without the default allowlist leave them out too.
+
+Lists are merged in this order: your blocklist always blocks; your allowlist lets a domain through even when a subscribed blocklist has it; subscribed blocklists block in every session; and subscribed allowlists allow what's left.
+
+'add -' and 'remove -' read the domains from standard input, one per line, skipping blank lines and # comments, e.g. 'cat domains.txt | sinkzone allowlist add -'. They change the allowlist in one write, which 'undo' reverts at once, add nothing unless every entry is valid, and apply the change to a running resolver right away.
+
+Add --break to manage break domains instead: they stay blocked while you work and are only allowed during breaks started with 'sinkzone focus break'. Break domains are stored in sinkzone.yaml under break_domains.
+
 Monitor DNS requests first to discover which domains are needed for your work.`,
-	Args: cobra.MinimumNArgs(1),
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeAllowlistArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		command := args[0]
 
@@ -29,20 +62,140 @@ Monitor DNS requests first to discover which domains are needed for your work.`,
 			if len(args) < 2 {
 				return fmt.Errorf("domain required for 'add' command")
 			}
+			if allowlistBreak {
+				return addBreakDomain(args[1])
+			}
+			if args[1] == "-" {
+				cmd.SilenceUsage = true
+				return addStdinToAllowlist(os.Stdin)
+			}
 			return addToAllowlist(args[1])
 		case "remove":
 			if len(args) < 2 {
 				return fmt.Errorf("domain required for 'remove' command")
 			}
+			if allowlistBreak {
+				return removeBreakDomain(args[1])
+			}
+			if args[1] == "-" {
+				cmd.SilenceUsage = true
+				return removeStdinFromAllowlist(os.Stdin)
+			}
 			return removeFromAllowlist(args[1])
 		case "list":
+			if allowlistBreak {
+				return listBreakDomains()
+			}
 			return listAllowlist()
+		case "edit":
+			cmd.SilenceUsage = true
+			if allowlistBreak {
+				return fmt.Errorf("break domains are stored in %s; edit break_domains there", config.GetConfigPath())
+			}
+			return editAllowlist()
+		case "undo":
+			cmd.SilenceUsage = true
+			if allowlistBreak {
+				return fmt.Errorf("break domains are stored in %s; undo works on the allowlist only", config.GetConfigPath())
+			}
+			return undoAllowlist()
+		case "subscribe", "unsubscribe", "enable", "disable", "update":
+			if allowlistBreak {
+				return fmt.Errorf("break domains can't be subscribed to; subscribe without --break")
+			}
+			url := ""
+			if command != "update" {
+				if len(args) < 2 {
+					return fmt.Errorf("url required for '%s' command", command)
+				}
+				url = args[1]
+			}
+			cmd.SilenceUsage = true
+			return runSubscriptionCommand(subscription.Allowlist, command, url)
 		default:
-			return fmt.Errorf("unknown command: %s. Use 'add', 'remove', or 'list'", command)
+			return fmt.Errorf("unknown command: %s. Use 'add', 'remove', 'list', 'edit', 'undo', 'subscribe', 'unsubscribe', 'enable', 'disable', or 'update'", command)
 		}
 	},
 }
 
+func init() {
+	allowlistCmd.Flags().BoolVar(&allowlistBreak, "break", false, "Manage break domains (allowed only during focus breaks)")
+}
+
+// completeAllowlistArgs completes the subcommand, then the domain: allowlisted domains for
+// remove, and recently queried domains not yet allowed (blocked first) for add; or the
+// subscribed URLs for unsubscribe, enable, and disable. Only completions starting with
+// toComplete are returned, and nothing is printed when the resolver can't be reached.
+func completeAllowlistArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var completions []string
+	switch {
+	case len(args) == 0:
+		completions = append([]string{"add", "remove", "list", "edit", "undo"}, subscriptionCommands...)
+	case len(args) == 1 && args[0] == "remove":
+		completions = allowedDomains()
+	case len(args) == 1 && args[0] == "add":
+		completions = recentDomains(allowedDomains())
+	case len(args) == 1 && slices.Contains([]string{"unsubscribe", "enable", "disable"}, args[0]):
+		completions, _ = completeSubscriptionURLs(subscription.Allowlist, args[0])
+	}
+	return withPrefix(completions, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// withPrefix returns the completions starting with what has been typed so far
+func withPrefix(completions []string, prefix string) []string {
+	var matching []string
+	for _, completion := range completions {
+		if strings.HasPrefix(completion, prefix) {
+			matching = append(matching, completion)
+		}
+	}
+	return matching
+}
+
+// allowedDomains returns the allowlist, or the break domains with --break, for completion
+func allowedDomains() []string {
+	if allowlistBreak {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil
+		}
+		return cfg.BreakDomains
+	}
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return nil
+	}
+	domains, err := manager.List()
+	if err != nil {
+		return nil
+	}
+	return domains
+}
+
+// recentDomains returns domains recently seen by the resolver, blocked ones first and
+// newest first within each group, skipping those in exclude
+func recentDomains(exclude []string) []string {
+	// Completion has no --api-url flag, so it asks the resolver at the default API URL
+	queries, err := api.NewClient(config.DefaultAPIURL()).GetQueries(0)
+	if err != nil {
+		return nil
+	}
+	sort.SliceStable(queries, func(i, j int) bool {
+		if queries[i].Blocked != queries[j].Blocked {
+			return queries[i].Blocked
+		}
+		return queries[i].Timestamp.After(queries[j].Timestamp)
+	})
+
+	domains := make([]string, 0, len(queries))
+	for _, query := range queries {
+		if !slices.Contains(exclude, query.Domain) && !slices.Contains(domains, query.Domain) {
+			domains = append(domains, query.Domain)
+		}
+	}
+	return domains
+}
+
 func addToAllowlist(domain string) error {
 	manager, err := allowlist.NewManager()
 	if err != nil {
@@ -53,11 +206,66 @@ func addToAllowlist(domain string) error {
 		return err
 	}
 
-	fmt.Printf("Domain '%s' added to allowlist.\n", domain)
-	fmt.Printf("Note: Allowlist changes take effect when you start a new focus session.\n")
+	fmt.Println(i18n.T("Domain '%s' added to allowlist.", domain))
+	fmt.Println(i18n.T("Note: Allowlist changes take effect when you start a new focus session."))
 	return nil
 }
 
+// addStdinToAllowlist adds the domains read from standard input, one per line, in one
+// write once every one of them is valid, then asks a running resolver to reload the allowlist
+func addStdinToAllowlist(stdin io.Reader) error {
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("failed to read domains: %w", err)
+	}
+	if lineErrors := allowlist.Validate(string(data)); len(lineErrors) > 0 {
+		for _, lineError := range lineErrors {
+			fmt.Fprintf(os.Stderr, "  %s\n", lineError)
+		}
+		return fmt.Errorf("%d invalid entries; nothing added", len(lineErrors))
+	}
+	domains, err := parseDomains(strings.NewReader(string(data)))
+	if err != nil {
+		return err
+	}
+
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create allowlist manager: %w", err)
+	}
+	added, err := manager.AddAll(domains)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("Added %d domains to the allowlist (%d already listed).", len(added), len(domains)-len(added)))
+	if len(added) == 0 {
+		return nil
+	}
+	return reloadAllowlist()
+}
+
+// removeStdinFromAllowlist removes the domains read from standard input, one per line, in one
+// write, then asks a running resolver to reload the allowlist
+func removeStdinFromAllowlist(stdin io.Reader) error {
+	domains, err := parseDomains(stdin)
+	if err != nil {
+		return err
+	}
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create allowlist manager: %w", err)
+	}
+	removed, err := manager.RemoveAll(domains)
+	if err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("Removed %d domains from the allowlist (%d not listed).", len(removed), len(domains)-len(removed)))
+	if len(removed) == 0 {
+		return nil
+	}
+	return reloadAllowlist()
+}
+
 func removeFromAllowlist(domain string) error {
 	manager, err := allowlist.NewManager()
 	if err != nil {
@@ -68,8 +276,8 @@ func removeFromAllowlist(domain string) error {
 		return err
 	}
 
-	fmt.Printf("Domain '%s' removed from allowlist.\n", domain)
-	fmt.Printf("Note: Allowlist changes take effect when you start a new focus session.\n")
+	fmt.Println(i18n.T("Domain '%s' removed from allowlist.", domain))
+	fmt.Println(i18n.T("Note: Allowlist changes take effect when you start a new focus session."))
 	return nil
 }
 
@@ -84,13 +292,225 @@ func listAllowlist() error {
 		return fmt.Errorf("failed to list allowlist: %w", err)
 	}
 
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	subscriptions := subscriptionReports(subscription.Allowlist, cfg)
+
+	if jsonOutput() {
+		if domains == nil {
+			domains = []string{}
+		}
+		return printJSON(allowlistReport{Path: manager.GetPath(), Domains: domains, Subscriptions: subscriptions})
+	}
+
 	if len(domains) == 0 {
-		fmt.Println("Allowlist is empty.")
+		fmt.Println(i18n.T("Allowlist is empty."))
+	} else {
+		fmt.Println(i18n.T("Allowlist (%d domains):", len(domains)))
+		for i, domain := range domains {
+			fmt.Printf("  %d. %s\n", i+1, domain)
+		}
+	}
+
+	printSubscriptions(subscription.Allowlist, subscriptions)
+	return nil
+}
+
+// editAllowlist opens a copy of the allowlist in the user's editor and saves it once every
+// entry is valid, then asks a running resolver to reload it
+func editAllowlist() error {
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create allowlist manager: %w", err)
+	}
+
+	content, err := manager.Read()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(content, allowlist.Template) {
+		content = allowlist.Template + content
+	}
+
+	tmp, err := os.CreateTemp("", "sinkzone-allowlist-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if err := os.Remove(tmpPath); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", tmpPath, err)
+		}
+	}()
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write temporary file: %w", err)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		if err := runEditor(tmpPath); err != nil {
+			return err
+		}
+
+		// #nosec G304 -- tmpPath is the temporary file created above
+		data, err := os.ReadFile(tmpPath)
+		if err != nil {
+			return fmt.Errorf("failed to read edited allowlist: %w", err)
+		}
+		content = string(data)
+
+		lineErrors := allowlist.Validate(content)
+		if len(lineErrors) == 0 {
+			break
+		}
+		fmt.Println(i18n.T("The allowlist has %d invalid entries:", len(lineErrors)))
+		for _, lineError := range lineErrors {
+			fmt.Printf("  %s\n", lineError)
+		}
+		fmt.Print(i18n.T("Edit again? [Y/n] "))
+		answer, _ := reader.ReadString('\n')
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer == "n" || answer == "no" {
+			return fmt.Errorf("allowlist not saved")
+		}
+	}
+
+	if err := manager.Save(content); err != nil {
+		return err
+	}
+	fmt.Println(i18n.T("Allowlist saved to %s.", manager.GetPath()))
+	return reloadAllowlist()
+}
+
+// undoAllowlist restores the allowlist from before its last recorded change, then asks a
+// running resolver to reload it
+func undoAllowlist() error {
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create allowlist manager: %w", err)
+	}
+
+	change, err := manager.Undo()
+	if errors.Is(err, allowlist.ErrNothingToUndo) {
+		fmt.Println(i18n.T("Nothing to undo."))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to undo: %w", err)
+	}
+	fmt.Println(i18n.T("Undid '%s' from %s.", change.Operation, change.Time.Local().Format("2006-01-02 15:04")))
+	return reloadAllowlist()
+}
+
+// reloadAllowlist asks a running resolver to reread the allowlist after sinkzone replaced it
+func reloadAllowlist() error {
+	// edit and undo have no --api-url flag, so it asks the resolver at the default API URL
+	client := api.NewClient(config.DefaultAPIURL())
+	if err := client.HealthCheck(); err != nil {
+		fmt.Println(i18n.T("Note: The resolver is not running; the allowlist applies when it starts."))
+		return nil
+	}
+	if err := client.ReloadAllowlist(); err != nil {
+		return fmt.Errorf("allowlist saved, but the resolver could not reload it: %w", err)
+	}
+	fmt.Println(i18n.T("Resolver reloaded the allowlist."))
+	return nil
+}
+
+// runEditor opens path in $VISUAL or $EDITOR, falling back to notepad on Windows and vi elsewhere
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		} else {
+			editor = "vi"
+		}
+	}
+
+	// The editor may include arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	// #nosec G204 -- the editor is chosen by the user running this command
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run editor %q: %w", editor, err)
+	}
+	return nil
+}
+
+func addBreakDomain(domain string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if slices.Contains(cfg.BreakDomains, domain) {
+		return fmt.Errorf("domain '%s' is already a break domain", domain)
+	}
+	cfg.BreakDomains = append(cfg.BreakDomains, domain)
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Println(i18n.T("Domain '%s' added to break domains.", domain))
+	fmt.Println(i18n.T("Note: Restart the resolver to apply break domain changes."))
+	return nil
+}
+
+func removeBreakDomain(domain string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	index := slices.Index(cfg.BreakDomains, domain)
+	if index < 0 {
+		return fmt.Errorf("domain '%s' is not a break domain", domain)
+	}
+	cfg.BreakDomains = slices.Delete(cfg.BreakDomains, index, index+1)
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Println(i18n.T("Domain '%s' removed from break domains.", domain))
+	fmt.Println(i18n.T("Note: Restart the resolver to apply break domain changes."))
+	return nil
+}
+
+func listBreakDomains() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if jsonOutput() {
+		domains := cfg.BreakDomains
+		if domains == nil {
+			domains = []string{}
+		}
+		return printJSON(allowlistReport{Path: config.GetConfigPath(), Domains: domains})
+	}
+
+	if len(cfg.BreakDomains) == 0 {
+		fmt.Println(i18n.T("No break domains configured."))
 		return nil
 	}
 
-	fmt.Printf("Allowlist (%d domains):\n", len(domains))
-	for i, domain := range domains {
+	fmt.Println(i18n.T("Break domains (%d domains):", len(cfg.BreakDomains)))
+	for i, domain := range cfg.BreakDomains {
 		fmt.Printf("  %d. %s\n", i+1, domain)
 	}
 
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+func TestCompleteAllowlistArgs(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com", "gitlab.com", "*.golang.org"}})
+	for _, name := range []string{"gist.github.com", "github.com", "example.com"} {
+		r.Query(t, name, dns.TypeA)
+	}
+
+	tests := []struct {
+		name        string
+		apiURL      string
+		args        []string
+		toComplete  string
+		completions []string
+	}{
+		{"subcommands", r.APIURL, nil, "re", []string{"remove"}},
+		{"allowlisted domains", r.APIURL, []string{"remove"}, "git", []string{"github.com", "gitlab.com"}},
+		{"every allowlisted domain", r.APIURL, []string{"remove"}, "", []string{"github.com", "gitlab.com", "*.golang.org"}},
+		{"recent domains not yet allowed, newest first", r.APIURL, []string{"add"}, "", []string{"example.com", "gist.github.com"}},
+		{"recent domains by prefix", r.APIURL, []string{"add"}, "gi", []string{"gist.github.com"}},
+		{"nothing after the domain", r.APIURL, []string{"add", "example.com"}, "", nil},
+		{"resolver down", "http://127.0.0.1:1", []string{"add"}, "", nil},
+		{"resolver down still completes remove", "http://127.0.0.1:1", []string{"remove"}, "gitl", []string{"gitlab.com"}},
+	}
+	for _, test := range tests {
+		t.Setenv(config.APIURLEnv, test.apiURL)
+		var completions []string
+		var directive cobra.ShellCompDirective
+		stderr, _ := captureFile(t, &os.Stderr, func() error {
+			stdout, _ := captureStdout(t, func() error {
+				completions, directive = completeAllowlistArgs(allowlistCmd, test.args, test.toComplete)
+				return nil
+			})
+			if len(stdout) > 0 {
+				t.Errorf("%s: expected nothing on stdout, got %q", test.name, stdout)
+			}
+			return nil
+		})
+		if len(stderr) > 0 {
+			t.Errorf("%s: expected nothing on stderr, got %q", test.name, stderr)
+		}
+		if !slices.Equal(completions, test.completions) || directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("%s: expected %v, got %v (directive %d)", test.name, test.completions, completions, directive)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/backup"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup [create/restore] <file.tar.gz>",
+	Short: "Back up or restore sinkzone's config, lists, and focus history",
+	Long: `Save or restore everything sinkzone keeps: sinkzone.yaml (with profiles, schedules, and every other setting), the allowlist, the blocklist, and the focus history in state.json (daily focus time, session labels, and queued sessions).
+
+  sinkzone backup create sinkzone-backup.tar.gz    Save a backup
+  sinkzone backup restore sinkzone-backup.tar.gz   Restore it, here or on another machine
+
+The archive records the sinkzone release and config version that wrote it. A config from an older sinkzone is upgraded when restored, like any old config file; a backup from a newer sinkzone is refused. The query log and downloaded list subscriptions aren't included: subscriptions are downloaded again with 'sinkzone blocklist update' and 'sinkzone allowlist update'.
+
+Before restoring, the current files are saved to a pre-restore backup in the data directory (~/.sinkzone/ on macOS and Linux), so a restore can be undone by restoring that file. Restoring keeps a running focus session; a running resolver picks up the restored lists and config right away.`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeBackupArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "create":
+			cmd.SilenceUsage = true
+			return createBackup(args[1])
+		case "restore":
+			cmd.SilenceUsage = true
+			return restoreBackup(args[1])
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'create' or 'restore'", args[0])
+		}
+	},
+}
+
+// completeBackupArgs completes the subcommand, then archive files
+func completeBackupArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return []string{"create", "restore"}, cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) == 1 {
+		return []string{"tar.gz"}, cobra.ShellCompDirectiveFilterFileExt
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func createBackup(path string) error {
+	manifest, err := writeBackup(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backup of %s saved to %s.\n", strings.Join(manifest.Files, ", "), path)
+	return nil
+}
+
+// writeBackup saves a backup to path, removing the file again if it fails
+func writeBackup(path string) (*backup.Manifest, error) {
+	// #nosec G304 -- path is the archive named by the user
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	manifest, err := backup.Create(file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write backup file: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return manifest, nil
+}
+
+func restoreBackup(path string) error {
+	// #nosec G304 -- path is the archive named by the user
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open backup file: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	// Check the archive before touching the current files
+	if _, _, err := backup.Read(file); err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read backup file: %w", err)
+	}
+
+	undoPath := filepath.Join(config.GetDataDir(), fmt.Sprintf("pre-restore-%s.tar.gz", time.Now().Format("20060102-150405")))
+	if err := os.MkdirAll(filepath.Dir(undoPath), 0750); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if _, err := writeBackup(undoPath); err != nil {
+		return fmt.Errorf("failed to save the current files before restoring: %w", err)
+	}
+
+	manifest, err := backup.Restore(file)
+	if err != nil {
+		return fmt.Errorf("%w (the previous files are in %s)", err, undoPath)
+	}
+	fmt.Printf("Restored %s from a backup made %s by sinkzone %s.\n", strings.Join(manifest.Files, ", "), manifest.Created.Local().Format("2006-01-02 15:04"), manifest.Version)
+	fmt.Printf("The previous files were saved to %s.\n", undoPath)
+
+	// The backup command has no --api-url flag, so it uses the default API URL
+	client := api.NewClient(config.DefaultAPIURL())
+	if err := client.HealthCheck(); err != nil {
+		return nil
+	}
+	if err := client.ReloadAllowlist(); err != nil {
+		fmt.Printf("Warning: the resolver could not reload the restored lists: %v\n", err)
+	}
+	return nil
+}
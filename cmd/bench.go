@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+// benchTick is how often bench hands out the queries due since the last tick
+const benchTick = 10 * time.Millisecond
+
+var (
+	benchQPS      int
+	benchDuration time.Duration
+	benchServer   string
+	benchDomains  []string
+	benchWorkers  int
+	benchTimeout  time.Duration
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Load test a running resolver and report its latency",
+	Long: `Sends DNS queries to a running resolver at a steady rate and reports how many were answered and the latency percentiles of the answers.
+
+  sinkzone bench                                  1000 queries per second for 10s
+  sinkzone bench --qps 2000 --duration 30s
+  sinkzone bench --server 192.168.1.2:53 --domain example.com --domain example.org
+
+Queries go to the local resolver at dns_listen unless --server is given, and cycle through the --domain list. After the first round most are answered from the cache, so the numbers show the resolver's own overhead; use many domains, or set cache.size to 0, to measure the upstreams as well. The queries are recorded like any others, and a rate_limit may refuse them.
+
+Each of --workers sends one query at a time. When all are waiting for answers, queries due are skipped rather than sent late; raise --workers if bench reports skipped queries. Press Ctrl+C to stop early and report what was measured.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if benchQPS <= 0 || benchWorkers <= 0 || benchDuration <= 0 || benchTimeout <= 0 {
+			return fmt.Errorf("--qps, --workers, --duration, and --timeout must be greater than 0")
+		}
+		if len(benchDomains) == 0 {
+			return fmt.Errorf("at least one --domain is required")
+		}
+		cmd.SilenceUsage = true
+
+		server := benchServer
+		if server == "" {
+			server = localResolverAddr()
+		}
+		if !jsonOutput() {
+			fmt.Printf("Sending %d queries per second to %s for %s...\n", benchQPS, server, benchDuration)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+		defer stop()
+		report := runBench(ctx, server)
+		if jsonOutput() {
+			return printJSON(report)
+		}
+		printBenchReport(report)
+		if report.Answered == 0 {
+			return fmt.Errorf("no queries were answered; is the resolver running at %s?", server)
+		}
+		return nil
+	},
+}
+
+func init() {
+	benchCmd.Flags().IntVar(&benchQPS, "qps", 1000, "Queries sent per second")
+	benchCmd.Flags().DurationVar(&benchDuration, "duration", 10*time.Second, "How long to send queries")
+	benchCmd.Flags().StringVar(&benchServer, "server", "", "Address of the resolver, e.g. 127.0.0.1:53 (default: the local resolver at dns_listen)")
+	benchCmd.Flags().StringSliceVar(&benchDomains, "domain", []string{"example.com", "example.org", "example.net"}, "Domain to query (repeat for several)")
+	benchCmd.Flags().IntVar(&benchWorkers, "workers", 64, "Queries in flight at once")
+	benchCmd.Flags().DurationVar(&benchTimeout, "timeout", 2*time.Second, "How long to wait for each answer")
+}
+
+// localResolverAddr returns where the local resolver answers, after dns_listen
+func localResolverAddr() string {
+	port := "53"
+	if cfg, err := config.Load(); err == nil {
+		if listen, err := cfg.GetDNSListen(); err == nil {
+			host, listenPort, _ := net.SplitHostPort(listen)
+			if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+				return listen
+			}
+			port = listenPort
+		}
+	}
+	return net.JoinHostPort(sysdns.LocalResolver, port)
+}
+
+// benchReport is the outcome of 'sinkzone bench'
+type benchReport struct {
+	Server    string         `json:"server"`
+	Duration  float64        `json:"duration_seconds"` // Time spent sending, shorter than --duration when stopped early
+	TargetQPS int            `json:"target_qps"`
+	Sent      int            `json:"sent"`
+	Skipped   int            `json:"skipped"` // Due while every worker was waiting for an answer
+	Answered  int            `json:"answered"`
+	Errors    int            `json:"errors"` // Timeouts and other failed exchanges
+	QPS       float64        `json:"qps"`    // Answers per second
+	Rcodes    map[string]int `json:"rcodes"`
+	Latency   benchLatency   `json:"latency_ms"`
+}
+
+// benchLatency sums up the time to an answer in milliseconds
+type benchLatency struct {
+	Min     float64 `json:"min"`
+	Average float64 `json:"average"`
+	P50     float64 `json:"p50"`
+	P90     float64 `json:"p90"`
+	P95     float64 `json:"p95"`
+	P99     float64 `json:"p99"`
+	Max     float64 `json:"max"`
+}
+
+// runBench sends queries to server at --qps until --duration passes or ctx is cancelled
+func runBench(ctx context.Context, server string) *benchReport {
+	report := &benchReport{Server: server, TargetQPS: benchQPS, Rcodes: map[string]int{}}
+	var (
+		mu        sync.Mutex
+		latencies []time.Duration
+		wg        sync.WaitGroup
+	)
+	jobs := make(chan string, benchWorkers)
+	for i := 0; i < benchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client := &dns.Client{Timeout: benchTimeout}
+			for domain := range jobs {
+				msg := new(dns.Msg)
+				msg.SetQuestion(dns.Fqdn(domain), dns.TypeA)
+				start := time.Now()
+				resp, _, err := client.Exchange(msg, server)
+				elapsed := time.Since(start)
+
+				mu.Lock()
+				if err != nil {
+					report.Errors++
+				} else {
+					report.Answered++
+					report.Rcodes[dns.RcodeToString[resp.Rcode]]++
+					latencies = append(latencies, elapsed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Hand out the queries due so far at every tick; those no worker is free for are skipped
+	start := time.Now()
+	deadline := time.NewTimer(benchDuration)
+	defer deadline.Stop()
+	ticker := time.NewTicker(benchTick)
+	defer ticker.Stop()
+	next := 0
+send:
+	for {
+		select {
+		case <-ctx.Done():
+			break send
+		case <-deadline.C:
+			break send
+		case now := <-ticker.C:
+			due := int(now.Sub(start).Seconds()*float64(benchQPS)) - report.Sent - report.Skipped
+			for ; due > 0; due-- {
+				select {
+				case jobs <- benchDomains[next%len(benchDomains)]:
+					report.Sent++
+					next++
+				default:
+					report.Skipped++
+				}
+			}
+		}
+	}
+	report.Duration = time.Since(start).Seconds()
+	close(jobs)
+	wg.Wait()
+
+	if report.Duration > 0 {
+		report.QPS = float64(report.Answered) / report.Duration
+	}
+	report.Latency = summarizeLatencies(latencies)
+	return report
+}
+
+// summarizeLatencies returns the spread of latencies, using nearest-rank percentiles
+func summarizeLatencies(latencies []time.Duration) benchLatency {
+	if len(latencies) == 0 {
+		return benchLatency{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	percentile := func(p float64) float64 {
+		rank := int(p/100*float64(len(latencies))+0.999999) - 1
+		return ms(latencies[max(0, min(rank, len(latencies)-1))])
+	}
+	var total time.Duration
+	for _, latency := range latencies {
+		total += latency
+	}
+	return benchLatency{
+		Min:     ms(latencies[0]),
+		Average: ms(total / time.Duration(len(latencies))),
+		P50:     percentile(50),
+		P90:     percentile(90),
+		P95:     percentile(95),
+		P99:     percentile(99),
+		Max:     ms(latencies[len(latencies)-1]),
+	}
+}
+
+func printBenchReport(report *benchReport) {
+	fmt.Printf("\nSent %d queries in %.1fs (%d/s target), %d answered (%.0f/s), %d failed\n",
+		report.Sent, report.Duration, report.TargetQPS, report.Answered, report.QPS, report.Errors)
+	if report.Skipped > 0 {
+		fmt.Printf("Skipped %d queries while all %d workers were waiting; raise --workers to reach the target\n", report.Skipped, benchWorkers)
+	}
+	if len(report.Rcodes) > 0 {
+		rcodes := make([]string, 0, len(report.Rcodes))
+		for rcode, count := range report.Rcodes {
+			rcodes = append(rcodes, fmt.Sprintf("%s %d", rcode, count))
+		}
+		sort.Strings(rcodes)
+		fmt.Printf("Responses: %s\n", strings.Join(rcodes, ", "))
+	}
+	if report.Answered > 0 {
+		l := report.Latency
+		fmt.Printf("Latency: min %.2fms, avg %.2fms, p50 %.2fms, p90 %.2fms, p95 %.2fms, p99 %.2fms, max %.2fms\n",
+			l.Min, l.Average, l.P50, l.P90, l.P95, l.P99, l.Max)
+	}
+}
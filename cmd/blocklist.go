@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/blocklist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/spf13/cobra"
+)
+
+// blocklistReport is printed by 'blocklist list --output json'
+type blocklistReport struct {
+	Path          string               `json:"path"` // File the domains are stored in
+	Domains       []string             `json:"domains"`
+	Subscriptions []subscriptionReport `json:"subscriptions"`
+}
+
+var blocklistCmd = &cobra.Command{
+	Use:   "blocklist [add/remove/list/import/subscribe/unsubscribe/enable/disable/update] [domain|file|url]",
+	Short: "Manage the blocklist",
+	Long: `Add, remove, or list domains on the blocklist — domains that are blocked in every focus session, whatever its intensity and even when they match the allowlist. A 'soft' session blocks only these domains.
+
+The blocklist is stored in ~/.sinkzone/blocklist.txt and accepts the same wildcard patterns as the allowlist. 'import <file>' adds every domain from a file (or '-' for standard input) in plain, hosts-file ("0.0.0.0 example.com"), or Adblock ("||example.com^") format, or from a Pi-hole domain export: the blacklist.exact.json of a v5 teleporter backup, or the JSON of the v6 API's /api/domains, or from the export of another blocker: a Cold Turkey block or settings export, or a Freedom blocklist CSV. Sites of Cold Turkey and Freedom are imported with their subdomains (example.com and *.example.com), and rules for a path are skipped, as DNS can't block them alone. From Pi-hole only enabled deny entries are imported, and domains the blocklist already blocks, also through a wildcard such as *.example.com, are left out. Pi-hole's gravity (its downloaded lists) is best followed with 'subscribe' to the same list URLs, or exported with sqlite3 /etc/pihole/gravity.db "SELECT domain FROM gravity" and imported.
+
+'subscribe <url>' follows a hosted community blocklist: it is downloaded into ~/.sinkzone/blocklists and its URL saved under blocklist_subscriptions in sinkzone.yaml. A running resolver downloads subscribed lists again every subscription_refresh (default 24h), only transferring lists that changed (ETag and Last-Modified); 'update' downloads them right away, and 'sinkzone lists status' shows when each last changed and by how many domains. 'disable <url>' stops using a list without forgetting it, 'enable <url>' uses it again, and 'unsubscribe <url>' stops following it.
+
+Your own entries take precedence over subscriptions: the blocklist always blocks, and a domain on your allowlist is let through even when a subscribed blocklist has it (see 'sinkzone allowlist').
+
+Changes are applied to a running resolver right away.`,
+	Args:              cobra.MinimumNArgs(1),
+	ValidArgsFunction: completeBlocklistArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := args[0]
+
+		argument := func(name string) (string, error) {
+			if len(args) < 2 {
+				return "", fmt.Errorf("%s required for '%s' command", name, command)
+			}
+			cmd.SilenceUsage = true
+			return args[1], nil
+		}
+
+		switch command {
+		case "add":
+			domain, err := argument("domain")
+			if err != nil {
+				return err
+			}
+			return addToBlocklist(domain)
+		case "remove":
+			domain, err := argument("domain")
+			if err != nil {
+				return err
+			}
+			return removeFromBlocklist(domain)
+		case "list":
+			cmd.SilenceUsage = true
+			return listBlocklist()
+		case "import":
+			path, err := argument("file")
+			if err != nil {
+				return err
+			}
+			return importBlocklist(path)
+		case "subscribe", "unsubscribe", "enable", "disable":
+			url, err := argument("url")
+			if err != nil {
+				return err
+			}
+			return runSubscriptionCommand(subscription.Blocklist, command, url)
+		case "update":
+			cmd.SilenceUsage = true
+			return runSubscriptionCommand(subscription.Blocklist, command, "")
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'add', 'remove', 'list', 'import', 'subscribe', 'unsubscribe', 'enable', 'disable', or 'update'", command)
+		}
+	},
+}
+
+// completeBlocklistArgs completes the subcommand, then blocklisted domains for remove,
+// recently queried domains for add, subscribed URLs for unsubscribe, enable, and disable,
+// and files for import
+func completeBlocklistArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return append([]string{"add", "remove", "list", "import"}, subscriptionCommands...), cobra.ShellCompDirectiveNoFileComp
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	switch args[0] {
+	case "remove":
+		domains, _ := blocklist.NewManager().List()
+		return domains, cobra.ShellCompDirectiveNoFileComp
+	case "add":
+		domains, _ := blocklist.NewManager().List()
+		return recentDomains(domains), cobra.ShellCompDirectiveNoFileComp
+	case "unsubscribe", "enable", "disable":
+		return completeSubscriptionURLs(subscription.Blocklist, args[0])
+	case "import":
+		return nil, cobra.ShellCompDirectiveDefault
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func addToBlocklist(domain string) error {
+	if err := allowlist.ValidatePattern(domain); err != nil {
+		return fmt.Errorf("invalid domain '%s': %w", domain, err)
+	}
+	if err := blocklist.NewManager().Add(domain); err != nil {
+		return err
+	}
+
+	fmt.Printf("Domain '%s' added to blocklist.\n", domain)
+	reloadList("blocklist")
+	return nil
+}
+
+func removeFromBlocklist(domain string) error {
+	if err := blocklist.NewManager().Remove(domain); err != nil {
+		return err
+	}
+
+	fmt.Printf("Domain '%s' removed from blocklist.\n", domain)
+	reloadList("blocklist")
+	return nil
+}
+
+func listBlocklist() error {
+	manager := blocklist.NewManager()
+	domains, err := manager.List()
+	if err != nil {
+		return fmt.Errorf("failed to list blocklist: %w", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	subscriptions := subscriptionReports(subscription.Blocklist, cfg)
+
+	if jsonOutput() {
+		if domains == nil {
+			domains = []string{}
+		}
+		return printJSON(blocklistReport{Path: manager.GetPath(), Domains: domains, Subscriptions: subscriptions})
+	}
+
+	if len(domains) == 0 {
+		fmt.Println("Blocklist is empty.")
+	} else {
+		fmt.Printf("Blocklist (%d domains):\n", len(domains))
+		for i, domain := range domains {
+			fmt.Printf("  %d. %s\n", i+1, domain)
+		}
+	}
+
+	printSubscriptions(subscription.Blocklist, subscriptions)
+	return nil
+}
+
+// importBlocklist adds every domain of a list file, or of standard input for "-"
+func importBlocklist(path string) error {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		// #nosec G304 -- the file is chosen by the user running this command
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Printf("Warning: failed to close %s: %v\n", path, err)
+			}
+		}()
+		reader = file
+	}
+
+	domains, skipped, err := blocklist.Parse(reader)
+	if err != nil {
+		return err
+	}
+	if len(domains) == 0 {
+		return fmt.Errorf("no domains found in %s", path)
+	}
+
+	manager := blocklist.NewManager()
+	existing, err := manager.List()
+	if err != nil {
+		return fmt.Errorf("failed to list blocklist: %w", err)
+	}
+	added, err := manager.AddAll(blocklist.Dedupe(domains, existing))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d domains to the blocklist (%d already blocked", len(added), len(domains)-len(added))
+	if skipped > 0 {
+		fmt.Printf(", %d lines skipped", skipped)
+	}
+	fmt.Println(").")
+	if len(added) > 0 {
+		reloadList("blocklist")
+	}
+	return nil
+}
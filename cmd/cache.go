@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var cacheAPIURL string
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache [stats/flush]",
+	Short: "Show or flush the resolver's DNS cache",
+	Long: `Shows how well the resolver's cache of upstream answers is working, or empties it.
+
+  sinkzone cache                 Show the size and hit, miss, and eviction counts
+  sinkzone cache flush           Drop every cached answer
+
+Flush the cache after a network change, e.g. joining a VPN whose DNS answers differ, so stale answers aren't served until their TTL runs out. The cache holds up to cache.size answers; when it is full, the least recently used answer makes room. Counts start over when the resolver restarts or cache.size changes.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"stats", "flush"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "stats"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		if command != "stats" && command != "flush" {
+			return fmt.Errorf("unknown command: %s. Use 'stats' or 'flush'", command)
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(cacheAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+		if command == "flush" {
+			return flushCache(client)
+		}
+		return showCacheStats(client)
+	},
+}
+
+func init() {
+	cacheCmd.Flags().StringVar(&cacheAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+}
+
+func showCacheStats(client *api.Client) error {
+	stats, err := client.GetCacheStats()
+	if err != nil {
+		return fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(stats)
+	}
+
+	if !stats.Enabled {
+		fmt.Println("Cache: off (cache.size is 0)")
+		return nil
+	}
+	fmt.Printf("Cache: %d of %d answers\n", stats.Entries, stats.Size)
+	fmt.Printf("Hits: %d, misses: %d (%.1f%% hit ratio)\n", stats.Hits, stats.Misses, stats.HitRatio()*100)
+	fmt.Printf("Evicted: %d, expired: %d\n", stats.Evictions, stats.Expirations)
+	if stats.Stale > 0 {
+		fmt.Printf("Stale answers served while the upstreams were down: %d\n", stats.Stale)
+	}
+	return nil
+}
+
+func flushCache(client *api.Client) error {
+	flushed, err := client.FlushCache()
+	if err != nil {
+		return fmt.Errorf("failed to flush cache: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(api.CacheFlushResponse{Flushed: flushed})
+	}
+	fmt.Printf("Flushed %d cached answers\n", flushed)
+	return nil
+}
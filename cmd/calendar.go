@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/calendar"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+// calendarLoginTimeout is how long 'calendar login' waits for the browser to come back
+const calendarLoginTimeout = 5 * time.Minute
+
+var calendarCmd = &cobra.Command{
+	Use:   "calendar [login/logout/list]",
+	Short: "Connect Google Calendar to start focus mode during your events",
+	Long: `Logs in to Google Calendar, so the resolver starts focus mode during the events of google_calendar in sinkzone.yaml, and lists the calendars it can read.
+
+  sinkzone calendar login     Allow sinkzone to read your calendars, in the browser
+  sinkzone calendar list      Show the calendars of the account, for google_calendar.calendars
+  sinkzone calendar logout    Delete the kept token
+
+Google only lets apps in that were registered, so create an OAuth client of the Desktop app type in a Google Cloud project with the Google Calendar API enabled, and set its ID and secret:
+
+  sinkzone config set google_calendar.client_id 1234-abcd.apps.googleusercontent.com
+  sinkzone config set google_calendar.client_secret GOCSPX-...
+
+The login opens Google in the browser, which then comes back to sinkzone on this machine; only read access to calendars is asked for. The token is kept in google-token.json in the sinkzone directory, readable only by you, and the resolver renews it as needed. Without google_calendar.title, every busy event that isn't all day starts focus mode; with it, the events whose title matches the regular expression do. Focus time events of Google Calendar always do. Restart the resolver to apply changes to google_calendar.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"login", "logout", "list"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "login", "logout", "list":
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'login', 'logout', or 'list'", args[0])
+		}
+		cmd.SilenceUsage = true
+
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		if cfg.GoogleCalendar == nil || cfg.GoogleCalendar.ClientID == "" {
+			return fmt.Errorf("no Google OAuth client; set google_calendar.client_id and google_calendar.client_secret first (see 'sinkzone calendar --help')")
+		}
+		client, err := calendar.NewGoogleClient(cfg.GoogleCalendar)
+		if err != nil {
+			return err
+		}
+
+		switch args[0] {
+		case "login":
+			return calendarLogin(client)
+		case "logout":
+			if err := client.Logout(); err != nil {
+				return err
+			}
+			fmt.Println("Logged out of Google Calendar.")
+			return nil
+		}
+		return listGoogleCalendars(client)
+	},
+}
+
+func calendarLogin(client *calendar.GoogleClient) error {
+	ctx, cancel := context.WithTimeout(context.Background(), calendarLoginTimeout)
+	defer cancel()
+
+	err := client.Login(ctx, func(authURL string) {
+		fmt.Printf("Log in to Google in the browser. If it doesn't open, open this URL on this machine:\n\n  %s\n\nWaiting for the login...\n", authURL)
+		openBrowser(authURL)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to log in to Google Calendar: %w", err)
+	}
+	fmt.Println("Logged in to Google Calendar. Restart the resolver if it runs, so it reads your events.")
+	return nil
+}
+
+func listGoogleCalendars(client *calendar.GoogleClient) error {
+	calendars, err := client.Calendars()
+	if errors.Is(err, calendar.ErrNotLoggedIn) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list calendars: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(calendars)
+	}
+
+	fmt.Printf("Calendars (%d):\n", len(calendars))
+	for _, entry := range calendars {
+		fmt.Printf("  %s", entry.Name)
+		if entry.Primary {
+			fmt.Print("  (primary)")
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// openBrowser opens a URL in the default browser, if the platform has a way to
+func openBrowser(url string) {
+	var cmd *exec.Cmd
+	// #nosec G204 -- the URL is passed as an argument, not through a shell
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	// The URL is printed as well, so a missing opener doesn't matter
+	_ = cmd.Start()
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/certs"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	certHosts  []string
+	certClient string
+	certDir    string
+	certDays   int
+	certForce  bool
+)
+
+var certCmd = &cobra.Command{
+	Use:   "cert [generate]",
+	Short: "Create certificates for mutual TLS with a remote resolver",
+	Long: `Creates a private CA and the certificates that let the CLI and TUI on one machine administer a resolver on another, e.g. a home server, over HTTPS with client certificates (mutual TLS).
+
+  sinkzone cert generate --host nas.local --client laptop
+
+Run it on the resolver's machine. It writes ca.crt and ca.key, server.crt and server.key for the hosts given with --host (localhost, 127.0.0.1, and ::1 are always included), and <client>.crt and <client>.key to the certs directory of the data directory (~/.sinkzone/certs/ on macOS and Linux), then prints the settings for both machines. Existing files are kept unless --force is given, so running it again with another --client signs another client with the same CA.
+
+Copy ca.crt, <client>.crt, and <client>.key to the certs directory of the client machine. Keep ca.key on the resolver's machine: whoever has it can sign certificates the API accepts.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"generate"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if args[0] != "generate" {
+			return fmt.Errorf("unknown command: %s. Use 'generate'", args[0])
+		}
+		if certDays <= 0 {
+			return fmt.Errorf("invalid --days %d: must be at least 1", certDays)
+		}
+		cmd.SilenceUsage = true
+		return generateCerts()
+	},
+}
+
+func init() {
+	certCmd.Flags().StringSliceVar(&certHosts, "host", nil, "Name or IP address clients reach the resolver at, e.g. nas.local (repeatable)")
+	certCmd.Flags().StringVar(&certClient, "client", "client", "Name of the client certificate, e.g. laptop")
+	certCmd.Flags().StringVar(&certDir, "dir", "", "Directory the certificates are written to (default: certs in the data directory)")
+	certCmd.Flags().IntVar(&certDays, "days", int(certs.DefaultValidity/(24*time.Hour)), "How many days the certificates are valid")
+	certCmd.Flags().BoolVar(&certForce, "force", false, "Replace existing certificates, including the CA")
+}
+
+func generateCerts() error {
+	dir := certDir
+	if dir == "" {
+		dir = config.GetCertsDir()
+	}
+	result, err := certs.Generate(certs.Options{
+		Dir:      dir,
+		Hosts:    certHosts,
+		Client:   certClient,
+		Validity: time.Duration(certDays) * 24 * time.Hour,
+		Force:    certForce,
+	})
+	if err != nil {
+		return err
+	}
+	if len(result.Written) > 0 {
+		fmt.Printf("Wrote %s to %s.\n", strings.Join(result.Written, ", "), dir)
+	}
+	if len(result.Kept) > 0 {
+		fmt.Printf("Kept the existing %s (use --force to replace them).\n", strings.Join(result.Kept, ", "))
+	}
+
+	// Paths under the data directory are written relative to it, so the same settings
+	// work on both machines
+	path := func(name string) string {
+		full := filepath.Join(dir, name)
+		if rel, err := filepath.Rel(config.GetDataDir(), full); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+		return full
+	}
+	clientCert, clientKey := certs.ClientFiles(certClient)
+	host := "<host>"
+	if len(certHosts) > 0 {
+		host = certHosts[0]
+	}
+
+	fmt.Printf("\nOn the resolver's machine, add to sinkzone.yaml:\n\n")
+	fmt.Printf("api_listen: 0.0.0.0:8080\napi_tls:\n  cert: %s\n  key: %s\n  client_ca: %s\n", path(certs.ServerFile), path(certs.ServerKeyFile), path(certs.CAFile))
+	fmt.Printf("\nCopy %s, %s, and %s to the client machine, then add to its sinkzone.yaml:\n\n", certs.CAFile, clientCert, clientKey)
+	fmt.Printf("api_client_tls:\n  ca: %s\n  cert: %s\n  key: %s\n", path(certs.CAFile), path(clientCert), path(clientKey))
+	fmt.Printf("\nand point the CLI and TUI at the resolver:\n\n  export %s=https://%s:8080\n", config.APIURLEnv, host)
+	return nil
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkAPIURL string
+	checkBatch  string
+)
+
+var checkCmd = &cobra.Command{
+	Use:   "check [domain...]",
+	Short: "Check whether domains are blocked right now, for scripts",
+	Long: `Asks the resolver whether each domain is blocked right now, as a query for it would be answered, and prints one line per domain:
+
+  blocked     reddit.com  not on the allowlist
+  allowed     github.com
+
+The line holds the status, the domain, and why it is blocked or let through, separated by tabs. The status is allowed, blocked, or would-block: blocked, but let through during a dry run, the grace period, or a captive portal passthrough. With --output json each line is a JSON object instead.
+
+  sinkzone check reddit.com github.com
+  sinkzone check --batch domains.txt
+  cat domains.txt | sinkzone check --batch -
+
+--batch reads the domains from a file, one per line, or from standard input for '-'; blank lines and lines starting with # are skipped.
+
+The exit code tells scripts the result without parsing the output:
+
+  0  every domain resolves (allowed or would-block)
+  1  an error, such as a bad flag or a domain the resolver refused
+  2  at least one domain is blocked
+  3  the resolver can't be reached
+
+Every other command that talks to the resolver also exits with 3 when it can't reach it. For example, a pre-commit hook can refuse docs that link to a domain blocked during focus sessions:
+
+  grep -ohE 'https?://[^/")]+' docs/*.md | cut -d/ -f3 | sort -u | sinkzone check --batch - >/dev/null`,
+	ValidArgsFunction: completeCheckArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if checkBatch == "" && len(args) == 0 {
+			return fmt.Errorf("give domains to check, or --batch with a file or - for standard input")
+		}
+		if checkBatch != "" && len(args) > 0 {
+			return fmt.Errorf("give domains either as arguments or with --batch, not both")
+		}
+		cmd.SilenceUsage = true
+
+		domains := args
+		if checkBatch != "" {
+			var err error
+			if domains, err = readDomains(checkBatch); err != nil {
+				return err
+			}
+		}
+
+		client := api.NewClient(checkAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+
+		blocked, err := checkDomains(client, domains)
+		if err != nil {
+			return err
+		}
+		if blocked {
+			cmd.SilenceErrors = true
+			return &exitError{code: ExitBlocked}
+		}
+		return nil
+	},
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&checkAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	checkCmd.Flags().StringVar(&checkBatch, "batch", "", "Read the domains from this file, one per line, or from standard input for -")
+}
+
+// completeCheckArgs completes recently queried domains, blocked first, that aren't given yet
+func completeCheckArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	return recentDomains(args), cobra.ShellCompDirectiveNoFileComp
+}
+
+// checkDomains prints the decision for each domain as soon as it has it, so a script reading
+// the output sees each line right away, and reports whether any of them is blocked
+func checkDomains(client *api.Client, domains []string) (bool, error) {
+	encoder := json.NewEncoder(os.Stdout)
+	blocked := false
+	for _, domain := range domains {
+		decision, err := client.GetDecision(domain)
+		if err != nil {
+			return blocked, fmt.Errorf("failed to check %s: %w", domain, err)
+		}
+		blocked = blocked || decision.Blocked
+
+		if !jsonOutput() {
+			fmt.Printf("%s\t%s\t%s\n", decisionStatus(decision), decision.Domain, decision.Reason)
+			continue
+		}
+		if err := encoder.Encode(decision); err != nil {
+			return blocked, fmt.Errorf("failed to encode result: %w", err)
+		}
+	}
+	return blocked, nil
+}
+
+// decisionStatus names the answer to a domain in the output of 'sinkzone check'
+func decisionStatus(decision *api.Decision) string {
+	switch {
+	case decision.Blocked:
+		return "blocked"
+	case decision.WouldBlock:
+		return "would-block"
+	default:
+		return "allowed"
+	}
+}
+
+// readDomains reads domains one per line from the file at path, or from standard input for
+// "-", skipping blank lines and # comments
+func readDomains(path string) ([]string, error) {
+	var reader io.Reader = os.Stdin
+	if path != "-" {
+		// #nosec G304 -- the file is chosen by the user running this command
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Printf("Warning: failed to close %s: %v\n", path, err)
+			}
+		}()
+		reader = file
+	}
+
+	return parseDomains(reader)
+}
+
+// parseDomains reads domains one per line, skipping blank lines and # comments
+func parseDomains(reader io.Reader) ([]string, error) {
+	var domains []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read domains: %w", err)
+	}
+	return domains, nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		code int
+	}{
+		{nil, ExitOK},
+		{errors.New("unknown flag: --bogus"), ExitError},
+		{&exitError{code: ExitBlocked}, ExitBlocked},
+		{resolverDownError(errors.New("connection refused")), ExitResolverDown},
+		{fmt.Errorf("failed to start focus mode: %w", resolverDownError(errors.New("connection refused"))), ExitResolverDown},
+	}
+
+	for _, test := range tests {
+		if code := ExitCode(test.err); code != test.code {
+			t.Errorf("%v: expected exit code %d, got %d", test.err, test.code, code)
+		}
+	}
+}
+
+func TestCheckExitCodes(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com"}})
+	r.Focus(t, time.Hour)
+
+	tests := []struct {
+		name    string
+		apiURL  string
+		domains []string
+		code    int
+	}{
+		{"allowed", r.APIURL, []string{"github.com"}, ExitOK},
+		{"blocked", r.APIURL, []string{"github.com", "reddit.com"}, ExitBlocked},
+		{"no domains", r.APIURL, nil, ExitError},
+		{"resolver down", "http://127.0.0.1:1", []string{"github.com"}, ExitResolverDown},
+	}
+
+	for _, test := range tests {
+		checkAPIURL, checkBatch = test.apiURL, ""
+		err := checkCmd.RunE(checkCmd, test.domains)
+		if code := ExitCode(err); code != test.code {
+			t.Errorf("%s: expected exit code %d, got %d (%v)", test.name, test.code, code, err)
+		}
+	}
+}
+
+func TestParseDomains(t *testing.T) {
+	input := "github.com\n\n  # work\n*.golang.org  \n\t\n#reddit.com\nnews.ycombinator.com"
+	domains, err := parseDomains(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"github.com", "*.golang.org", "news.ycombinator.com"}
+	if !slices.Equal(domains, expected) {
+		t.Errorf("expected %v, got %v", expected, domains)
+	}
+}
+
+func TestAllowlistStdin(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.SystemConfigEnv, "none")
+	t.Setenv(config.APIURLEnv, "http://127.0.0.1:1")
+
+	tests := []struct {
+		name      string
+		add       bool
+		input     string
+		allowlist []string
+		fails     bool
+	}{
+		{"add with blanks and comments", true, "github.com\n\n# docs\n*.golang.org\n", []string{"github.com", "*.golang.org"}, false},
+		{"add skips listed domains", true, "github.com\n  \npkg.go.dev\n", []string{"github.com", "*.golang.org", "pkg.go.dev"}, false},
+		{"add nothing when a line is invalid", true, "example.com\nnot a domain\n", []string{"github.com", "*.golang.org", "pkg.go.dev"}, true},
+		{"remove with blanks and comments", false, "# done\n\ngithub.com\nunlisted.com\n", []string{"*.golang.org", "pkg.go.dev"}, false},
+	}
+
+	for _, test := range tests {
+		var err error
+		if test.add {
+			err = addStdinToAllowlist(strings.NewReader(test.input))
+		} else {
+			err = removeStdinFromAllowlist(strings.NewReader(test.input))
+		}
+		if (err != nil) != test.fails {
+			t.Fatalf("%s: expected failure %v, got %v", test.name, test.fails, err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "allowlist.txt"))
+		if err != nil {
+			t.Fatalf("%s: failed to read the allowlist: %v", test.name, err)
+		}
+		allowlist, _ := parseDomains(strings.NewReader(string(data)))
+		if !slices.Equal(allowlist, test.allowlist) {
+			t.Errorf("%s: expected the allowlist %v, got %v", test.name, test.allowlist, allowlist)
+		}
+	}
+}
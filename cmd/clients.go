@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"sort"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/dns"
+	"github.com/spf13/cobra"
+)
+
+var (
+	clientsAPIURL string
+	clientsByMAC  bool
+)
+
+var clientsCmd = &cobra.Command{
+	Use:   "clients [name/remove] [address] [name]",
+	Short: "List, name, and unname clients in client_names",
+	Long: `Lists the names given to clients in client_names, names a client by its IP or MAC address, or removes a name. Named clients are shown by name in sinkzone monitor, sinkzone queries, sinkzone devices, stats, and the TUI.
+
+  sinkzone clients                                    List the named clients
+  sinkzone clients name 192.168.1.23 kids-ipad        Name a client by its IP address
+  sinkzone clients name 192.168.1.23 kids-ipad --mac  Name the device at that address by its MAC address
+  sinkzone clients remove kids-ipad                   Remove a name, given by address or by name
+
+With --mac the name goes to the MAC address the neighbor (ARP) table has for the IP address, so the device keeps it when DHCP hands it another address; the device must have sent traffic on the local network recently. Use it for devices on the network a resolver serves with --lan. Names of IPv6 clients and clients on other networks need their IP address.
+
+Changes go through the running resolver, which saves them to sinkzone.yaml and applies them right away (this needs an admin-scoped token when api_tokens are set). When it isn't running, sinkzone.yaml is edited directly.`,
+	Args:      cobra.RangeArgs(0, 3),
+	ValidArgs: []string{"name", "remove"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "list"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		switch {
+		case command == "list" && len(args) <= 1:
+		case command == "name" && len(args) == 3:
+		case command == "remove" && len(args) == 2:
+		case command == "name":
+			return fmt.Errorf("usage: sinkzone clients name <address> <name> [--mac]")
+		case command == "remove":
+			return fmt.Errorf("usage: sinkzone clients remove <address|name>")
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'list', 'name <address> <name>', or 'remove <address|name>'", command)
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(clientsAPIURL)
+		running := client.HealthCheck() == nil
+		switch command {
+		case "name":
+			return nameClient(client, running, args[1], args[2])
+		case "remove":
+			return unnameClient(client, running, args[1])
+		}
+		return listClientNames(client, running)
+	},
+}
+
+func init() {
+	clientsCmd.Flags().StringVar(&clientsAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	clientsCmd.Flags().BoolVar(&clientsByMAC, "mac", false, "Name the device by the MAC address the neighbor table has for the IP address")
+}
+
+func listClientNames(client *api.Client, running bool) error {
+	var names []api.ClientName
+	if running {
+		var err error
+		if names, err = client.GetClientNames(); err != nil {
+			return fmt.Errorf("failed to list client names: %w", err)
+		}
+	} else {
+		cfg, err := config.Load()
+		if err != nil {
+			return fmt.Errorf("failed to load config: %w", err)
+		}
+		names = clientNameList(cfg)
+	}
+	if jsonOutput() {
+		return printJSON(names)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No clients are named. Name one with 'sinkzone clients name <address> <name>'.")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Printf("%-40s %s\n", name.Address, name.Name)
+	}
+	return nil
+}
+
+func nameClient(client *api.Client, running bool, address, name string) error {
+	var saved api.ClientName
+	if running {
+		named, err := client.SetClientName(address, api.ClientNameRequest{Name: name, ByMAC: clientsByMAC})
+		if err != nil {
+			return fmt.Errorf("failed to name %s: %w", address, err)
+		}
+		saved = *named
+	} else {
+		var err error
+		if _, saved, err = saveClientName(address, name, clientsByMAC); err != nil {
+			return err
+		}
+	}
+	if jsonOutput() {
+		return printJSON(saved)
+	}
+	fmt.Printf("Named %s %s\n", saved.Address, saved.Name)
+	return nil
+}
+
+func unnameClient(client *api.Client, running bool, target string) error {
+	var removed []api.ClientName
+	var err error
+	if running {
+		if removed, err = client.RemoveClientName(target); err != nil {
+			return fmt.Errorf("failed to remove the name of %s: %w", target, err)
+		}
+	} else if _, removed, err = removeClientName(target); err != nil {
+		return err
+	}
+	if jsonOutput() {
+		return printJSON(removed)
+	}
+	for _, name := range removed {
+		fmt.Printf("Removed the name %s of %s\n", name.Name, name.Address)
+	}
+	return nil
+}
+
+// clientNameList returns the valid entries of client_names, sorted by address
+func clientNameList(cfg *config.Config) []api.ClientName {
+	names, _ := cfg.GetClientNames()
+	list := make([]api.ClientName, 0, len(names))
+	for address, name := range names {
+		list = append(list, api.ClientName{Address: address, Name: name})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list
+}
+
+// saveClientName names a client in sinkzone.yaml, with byMAC by the MAC address the
+// neighbor table has for its IP address, and returns the saved config and entry
+func saveClientName(address, name string, byMAC bool) (*config.Config, api.ClientName, error) {
+	if byMAC {
+		if ip := net.ParseIP(address); ip != nil {
+			mac := dns.NeighborMAC(address)
+			if mac == "" {
+				return nil, api.ClientName{}, fmt.Errorf("the neighbor table has no MAC address for %s; the device must be on the local network and have sent traffic recently", address)
+			}
+			address = mac
+		}
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, api.ClientName{}, fmt.Errorf("failed to load config: %w", err)
+	}
+	key, err := cfg.SetClientName(address, name)
+	if err != nil {
+		return nil, api.ClientName{}, err
+	}
+	if err := cfg.ValidateServer(); err != nil {
+		return nil, api.ClientName{}, err
+	}
+	if err := config.Save(cfg); err != nil {
+		return nil, api.ClientName{}, fmt.Errorf("failed to save config: %w", err)
+	}
+	return cfg, api.ClientName{Address: key, Name: cfg.ClientNames[key]}, nil
+}
+
+// removeClientName removes a client, given by address or name, from client_names in
+// sinkzone.yaml and returns the saved config and the entries removed
+func removeClientName(target string) (*config.Config, []api.ClientName, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	names := maps.Clone(cfg.ClientNames)
+	removed := make([]api.ClientName, 0, 1)
+	for _, address := range cfg.RemoveClientName(target) {
+		removed = append(removed, api.ClientName{Address: address, Name: names[address]})
+	}
+	if len(removed) == 0 {
+		return nil, nil, fmt.Errorf("%w: %s", api.ErrUnknownClient, target)
+	}
+	if err := config.Save(cfg); err != nil {
+		return nil, nil, fmt.Errorf("failed to save config: %w", err)
+	}
+	return cfg, removed, nil
+}
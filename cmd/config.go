@@ -2,112 +2,322 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
+	"github.com/berbyte/sinkzone/internal/allowlist"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/berbyte/sinkzone/internal/tui"
 	"github.com/spf13/cobra"
 )
 
+// configEntry is printed by 'config list --output json' and 'config get --output json'
+type configEntry struct {
+	Key         string   `json:"key"`
+	Value       []string `json:"value"`
+	List        bool     `json:"list"`
+	Description string   `json:"description"`
+	Env         string   `json:"env,omitempty"`    // Environment variable overriding the file value
+	System      string   `json:"system,omitempty"` // System-wide config file the value is inherited from
+}
+
 var configCmd = &cobra.Command{
-	Use:   "config [get/set] [key] [value]",
+	Use:   "config [list/get/set/add/remove/add-upstream/remove-upstream/schema] [key] [value]",
 	Short: "Manage configuration",
-	Long:  `Manage sinkzone configuration. Currently supports setting resolver IP addresses.`,
-	Args:  cobra.ExactArgs(3),
+	Long: `Read and change the settings in sinkzone.yaml.
+
+  sinkzone config list                      Show every setting and its value
+  sinkzone config get daily_goal            Show one setting
+  sinkzone config set daily_goal 4h         Change a setting ("" unsets it)
+  sinkzone config add upstream 9.9.9.9      Add a value to a list setting
+  sinkzone config remove upstream 8.8.8.8   Remove a value from a list setting
+  sinkzone config add-upstream 9.9.9.9      Same as 'config add upstream' (also remove-upstream)
+  sinkzone config schema                    Print a JSON Schema of sinkzone.yaml for editors
+
+Values are checked before the file is written: upstream nameservers must be IP addresses (optionally with a port) or udp://, tcp://, tls://, or https:// URLs, durations must parse, and so on. 'set' on a list setting replaces the whole list with comma-separated values. Nested settings use dots, e.g. calendar.url or tui.refresh. Profiles are managed with 'sinkzone profile'; process triggers, client names, and the keymap are edited in the file itself.
+
+'schema' prints a JSON Schema of every setting. Save it and point your editor at it, e.g. with yaml.schemas in VS Code's YAML extension, for completion and checks while editing sinkzone.yaml.
+
+Setting 'pin' stores a salted hash of the PIN. Once set, disabling, pausing, or shortening an active focus session requires the PIN. Use an empty value ("") to remove it.
+
+Every key can also be overridden for one process with an environment variable: SINKZONE_ followed by the key in upper case, with dots replaced by underscores (e.g. SINKZONE_BLOCK_RESPONSE=refused or SINKZONE_CACHE_SIZE=0; list keys take comma-separated values). Overrides are shown in 'config list' and never written to the file. SINKZONE_CONFIG picks the config file like --config, SINKZONE_CONFIG_DIR moves all data files, and SINKZONE_API_URL is the default --api-url.
+
+Settings in the system-wide config file (/etc/sinkzone/sinkzone.yaml, or sinkzone\sinkzone.yaml in ProgramData on Windows) apply to every user and service. A key set in the user's file replaces the system value (lists as a whole, sections key by key), and environment variables override both. Only the user's file is written; clearing a key there falls back to the system value. SINKZONE_SYSTEM_CONFIG moves the system file, or turns it off with "none".
+
+A running resolver applies changes to the upstream nameservers, cache, rate and concurrency limits, blocking, query log, client names, and log level and format within seconds; restart it to apply the rest.`,
+	Args:              cobra.RangeArgs(1, 3),
+	ValidArgsFunction: completeConfigArgs,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// add-upstream and remove-upstream are aliases of 'add upstream' and 'remove upstream'
+		if verb, ok := strings.CutSuffix(args[0], "-upstream"); ok && (verb == "add" || verb == "remove") {
+			if len(args) != 2 {
+				return fmt.Errorf("usage: sinkzone config %s <nameserver>", args[0])
+			}
+			args = []string{verb, "upstream", args[1]}
+		}
 		command := args[0]
-		key := args[1]
-		value := args[2]
 
 		switch command {
-		case "set":
-			return setConfig(key, value)
+		case "list":
+			if len(args) > 1 {
+				return fmt.Errorf("'list' takes no arguments")
+			}
+			return listConfig()
 		case "get":
-			return getConfig(key)
+			if len(args) != 2 {
+				return fmt.Errorf("usage: sinkzone config get <key>")
+			}
+			return getConfig(args[1])
+		case "set", "add", "remove":
+			if len(args) != 3 {
+				return fmt.Errorf("usage: sinkzone config %s <key> <value>", command)
+			}
+			cmd.SilenceUsage = true
+			if args[1] == "pin" {
+				if command != "set" {
+					return fmt.Errorf("pin is not a list; use 'set' instead")
+				}
+				return setPIN(args[2])
+			}
+			return changeConfig(command, args[1], args[2])
+		case "schema":
+			if len(args) > 1 {
+				return fmt.Errorf("'schema' takes no arguments")
+			}
+			return printJSON(config.Schema())
 		default:
-			return fmt.Errorf("unknown command: %s. Use 'set'", command)
+			return fmt.Errorf("unknown command: %s. Use 'list', 'get', 'set', 'add', 'remove', 'add-upstream', 'remove-upstream', or 'schema'", command)
 		}
 	},
 }
 
-func setConfig(key, value string) error {
-	// Load existing config
+// completeConfigArgs completes the subcommand, then the key; add and remove only offer list keys
+func completeConfigArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return []string{"list", "get", "set", "add", "remove", "add-upstream", "remove-upstream", "schema"}, cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		if args[0] == "remove-upstream" {
+			if cfg, err := config.Load(); err == nil {
+				return cfg.UpstreamNameservers, cobra.ShellCompDirectiveNoFileComp
+			}
+		}
+		if strings.HasSuffix(args[0], "-upstream") || args[0] == "schema" || args[0] == "list" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		var names []string
+		for _, key := range config.Keys() {
+			if key.List || (args[0] != "add" && args[0] != "remove") {
+				names = append(names, key.Name)
+			}
+		}
+		if args[0] == "set" || args[0] == "get" {
+			names = append(names, "pin")
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	case 2:
+		if args[0] == "remove" {
+			if key, err := config.LookupKey(args[1]); err == nil {
+				if cfg, err := config.Load(); err == nil {
+					return key.Get(cfg), cobra.ShellCompDirectiveNoFileComp
+				}
+			}
+		}
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+func listConfig() error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	switch key {
-	case "resolver":
-		// Validate IP address
-		if !isValidIP(value) {
-			return fmt.Errorf("invalid IP address: %s", value)
-		}
+	entries := make([]configEntry, 0, len(config.Keys())+1)
+	for _, key := range config.Keys() {
+		entries = append(entries, newConfigEntry(&key, cfg))
+	}
+	entries = append(entries, pinEntry(cfg))
 
-		// Update resolver in config
-		if len(cfg.UpstreamNameservers) == 0 {
-			cfg.UpstreamNameservers = []string{value}
-		} else {
-			cfg.UpstreamNameservers[0] = value
+	if jsonOutput() {
+		return printJSON(entries)
+	}
+
+	fmt.Println(i18n.T("Config file: %s", config.GetConfigPath()))
+	if path := cfg.SystemConfigPath(); path != "" {
+		fmt.Println(i18n.T("System config: %s", path))
+	}
+	width := 0
+	for _, entry := range entries {
+		width = max(width, len(entry.Key))
+	}
+	for _, entry := range entries {
+		value := formatConfigValue(entry.Value)
+		if entry.Env != "" {
+			value += i18n.T(" (from %s)", entry.Env)
+		} else if entry.System != "" {
+			value += i18n.T(" (from %s)", entry.System)
 		}
+		fmt.Printf("  %-*s  %s\n", width, entry.Key, value)
+	}
+	return nil
+}
+
+func getConfig(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
 
-		// Save config
-		if err := config.Save(cfg); err != nil {
-			return fmt.Errorf("failed to save config: %w", err)
+	var entry configEntry
+	if name == "pin" {
+		entry = pinEntry(cfg)
+	} else {
+		key, err := config.LookupKey(name)
+		if err != nil {
+			return err
 		}
+		entry = newConfigEntry(key, cfg)
+	}
 
-		fmt.Printf("Primary resolver set to: %s\n", value)
+	if jsonOutput() {
+		return printJSON(entry)
+	}
+	if entry.List {
+		for _, value := range entry.Value {
+			fmt.Println(value)
+		}
 		return nil
-
-	default:
-		return fmt.Errorf("unknown config key: %s. Use 'resolver'", key)
 	}
+	fmt.Println(formatConfigValue(entry.Value))
+	return nil
 }
 
-func getConfig(key string) error {
-	// Load existing config
+// changeConfig applies set, add, or remove to a key, checks the result, and saves it
+func changeConfig(command, name, value string) error {
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	key, err := config.LookupKey(name)
+	if err != nil {
+		return err
+	}
 
-	switch key {
-	case "resolver":
-		if len(cfg.UpstreamNameservers) > 0 {
-			fmt.Printf("Primary resolver: %s\n", cfg.UpstreamNameservers[0])
-		} else {
-			fmt.Println("No resolver configured")
-		}
-		return nil
+	switch command {
+	case "set":
+		err = key.Set(cfg, value)
+	case "add":
+		err = key.Add(cfg, value)
+	case "remove":
+		err = key.Remove(cfg, value)
+	}
+	if err != nil {
+		return err
+	}
+	if err := validateConfigKey(key.Name, cfg, value); err != nil {
+		return err
+	}
 
-	default:
-		return fmt.Errorf("unknown config key: %s. Use 'resolver'", key)
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
 	}
-}
 
-func isValidIP(ip string) bool {
-	// Basic IP validation
-	parts := strings.Split(ip, ".")
-	if len(parts) != 4 {
-		return false
+	fmt.Printf("%s = %s\n", key.Name, formatConfigValue(key.Get(cfg)))
+	if env := cfg.OverriddenBy(key.Name); env != "" {
+		fmt.Printf("Note: %s is set and overrides this value while it stays set.\n", env)
+	}
+	if saved, err := config.Load(); err == nil && saved.FromSystem(key.Name) {
+		fmt.Printf("Note: %s still applies its value from %s.\n", key.Name, saved.SystemConfigPath())
 	}
+	if key.Live {
+		fmt.Println("Note: A running resolver applies the change within seconds.")
+	} else {
+		fmt.Println("Note: Restart the resolver for the change to take effect.")
+	}
+	return nil
+}
 
-	for _, part := range parts {
-		if len(part) == 0 || len(part) > 3 {
-			return false
+// validateConfigKey runs the checks that live outside the config package: allowlist
+// patterns for break and ignored domains, and the language and theme the TUI would reject on startup
+func validateConfigKey(name string, cfg *config.Config, value string) error {
+	switch {
+	case name == "break_domains":
+		for _, domain := range cfg.BreakDomains {
+			if err := allowlist.ValidatePattern(domain); err != nil {
+				return fmt.Errorf("invalid break domain %q: %w", domain, err)
+			}
 		}
-		for _, char := range part {
-			if char < '0' || char > '9' {
-				return false
+	case name == "ignore_domains":
+		for _, domain := range cfg.IgnoreDomains {
+			if err := allowlist.ValidatePattern(domain); err != nil {
+				return fmt.Errorf("invalid ignored domain %q: %w", domain, err)
 			}
 		}
-		num := 0
-		for _, char := range part {
-			num = num*10 + int(char-'0')
+	case name == "language":
+		if cfg.Language != "" && !slices.Contains(i18n.Supported(), cfg.Language) {
+			return fmt.Errorf("unsupported language %q (use %s)", value, strings.Join(i18n.Supported(), " or "))
 		}
-		if num > 255 {
-			return false
+	case strings.HasPrefix(name, "theme."):
+		return tui.ValidateTheme(cfg.Theme)
+	}
+	return nil
+}
+
+func setPIN(value string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if value == "" {
+		cfg.FocusPINHash = ""
+	} else {
+		hash, err := config.HashPIN(value)
+		if err != nil {
+			return err
 		}
+		cfg.FocusPINHash = hash
+	}
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	return true
+	if value == "" {
+		fmt.Println("Focus PIN removed.")
+	} else {
+		fmt.Println("Focus PIN set.")
+	}
+	fmt.Println("Note: Restart the resolver for the change to take effect.")
+	return nil
+}
+
+func newConfigEntry(key *config.Key, cfg *config.Config) configEntry {
+	value := key.Get(cfg)
+	if value == nil {
+		value = []string{}
+	}
+	entry := configEntry{Key: key.Name, Value: value, List: key.List, Description: key.Description, Env: cfg.OverriddenBy(key.Name)}
+	if cfg.FromSystem(key.Name) {
+		entry.System = cfg.SystemConfigPath()
+	}
+	return entry
+}
+
+// pinEntry reports whether a focus PIN is set, never the hash itself
+func pinEntry(cfg *config.Config) configEntry {
+	entry := configEntry{Key: "pin", Value: []string{}, Description: "PIN required to disable or loosen focus mode (only its hash is stored)"}
+	if cfg.FocusPINHash != "" {
+		entry.Value = []string{"set"}
+	}
+	return entry
+}
+
+func formatConfigValue(value []string) string {
+	if len(value) == 0 {
+		return "(not set)"
+	}
+	return strings.Join(value, ", ")
 }
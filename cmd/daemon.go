@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/logs"
+)
+
+// daemonEnv marks the detached child started by 'sinkzone resolver --daemon'
+const daemonEnv = "SINKZONE_DAEMON"
+
+// daemonStartupWait is how long --daemon watches the child for an early exit (e.g. the port is taken)
+const daemonStartupWait = 2 * time.Second
+
+// startResolverDaemon re-executes the resolver detached from the terminal, logging to a file.
+// The child records its PID in the PID file like a foreground resolver.
+func startResolverDaemon() error {
+	// Fail here rather than in the background where nobody sees it
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := validateResolverConfig(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	dnsAddr, _, err := resolverListen(cfg)
+	if err != nil {
+		return err
+	}
+	if err := config.CheckPortPrivileges(listenPort(dnsAddr)); err != nil {
+		return err
+	}
+	if pid := runningResolverPID(); pid != 0 {
+		return fmt.Errorf("resolver is already running (PID %d). Stop it with 'sinkzone resolver stop'", pid)
+	}
+
+	logPath, err := getResolverLogPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0750); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	// #nosec G304 -- logPath is a hardcoded path from user home directory
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer func() {
+		if closeErr := logFile.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close log file: %v\n", closeErr)
+		}
+	}()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the sinkzone executable: %w", err)
+	}
+
+	// #nosec G204 -- re-executes this binary with the resolver's own flags
+	child := exec.Command(executable, daemonArgs()...)
+	child.Env = append(os.Environ(), daemonEnv+"=1")
+	child.Stdout = logFile
+	child.Stderr = logFile
+	child.SysProcAttr = detachedProcAttr()
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start resolver in the background: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return fmt.Errorf("resolver exited during startup (%v). See %s", err, logPath)
+	case <-time.After(daemonStartupWait):
+	}
+
+	fmt.Printf("Resolver started in the background (PID %d).\n", child.Process.Pid)
+	fmt.Printf("Logs: %s\n", logPath)
+	fmt.Println("Stop it with: sinkzone resolver stop")
+	return nil
+}
+
+// daemonArgs returns the arguments the background resolver is started with: the resolver's
+// own flags without --daemon, so the child runs in the foreground of its detached session.
+// Ports are only passed on when given, so dns_listen and api_listen apply otherwise.
+func daemonArgs() []string {
+	args := []string{"resolver"}
+	if portSet {
+		args = append(args, "--port", port)
+	}
+	if apiPortSet {
+		args = append(args, "--api-port", apiPort)
+	}
+	if apiAddrSet {
+		args = append(args, "--api-addr", resolverAPIAddr)
+	}
+	if configFile != "" {
+		args = append(args, "--config", configFile)
+	}
+	if level := logs.CurrentLevel(); level != logs.LevelInfo {
+		args = append(args, "--log-level", level.String())
+	}
+	if logFormat != "" {
+		args = append(args, "--log-format", logFormat)
+	}
+	if resolverPprof != "" {
+		args = append(args, "--debug-pprof="+resolverPprof)
+	}
+	return args
+}
+
+// runningResolverPID returns the PID of the running resolver, or 0 if none is running
+func runningResolverPID() int {
+	pidFile, err := getPIDFilePath()
+	if err != nil {
+		return 0
+	}
+	// #nosec G304 -- pidFile is a hardcoded path from user home directory
+	pidData, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
+	if err != nil || pid <= 0 || !processRunning(pid) {
+		return 0
+	}
+	return pid
+}
+
+// getResolverLogPath returns the log file of a background resolver: log_file when
+// configured, otherwise resolver.log next to the PID file
+func getResolverLogPath() (string, error) {
+	if cfg, err := config.Load(); err == nil && cfg.LogFile != "" {
+		return cfg.LogFile, nil
+	}
+	pidFile, err := getPIDFilePath()
+	if err != nil {
+		return "", fmt.Errorf("failed to get PID file path: %w", err)
+	}
+	return filepath.Join(filepath.Dir(pidFile), "resolver.log"), nil
+}
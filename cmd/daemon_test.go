@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/logs"
+)
+
+func TestDaemonArgs(t *testing.T) {
+	saved := []string{port, apiPort, resolverAPIAddr, configFile, logFormat, resolverPprof}
+	savedSet := []bool{portSet, apiPortSet, apiAddrSet, resolverDaemon}
+	level := logs.CurrentLevel()
+	t.Cleanup(func() {
+		port, apiPort, resolverAPIAddr, configFile, logFormat, resolverPprof = saved[0], saved[1], saved[2], saved[3], saved[4], saved[5]
+		portSet, apiPortSet, apiAddrSet, resolverDaemon = savedSet[0], savedSet[1], savedSet[2], savedSet[3]
+		logs.SetLevel(level)
+	})
+
+	tests := []struct {
+		name  string
+		setup func()
+		args  []string
+	}{
+		{"defaults", func() {}, []string{"resolver"}},
+		{"ports only when given", func() {
+			port, apiPort = "53", "8080"
+			portSet = true
+		}, []string{"resolver", "--port", "53"}},
+		{"every flag", func() {
+			port, apiPort, resolverAPIAddr = "5353", "9090", "0.0.0.0"
+			portSet, apiPortSet, apiAddrSet = true, true, true
+			configFile, logFormat, resolverPprof = "/etc/sinkzone.yaml", "json", defaultPprofAddr
+			logs.SetLevel(logs.LevelDebug)
+		}, []string{"resolver", "--port", "5353", "--api-port", "9090", "--api-addr", "0.0.0.0", "--config", "/etc/sinkzone.yaml", "--log-level", "debug", "--log-format", "json", "--debug-pprof=" + defaultPprofAddr}},
+	}
+	for _, test := range tests {
+		port, apiPort, resolverAPIAddr, configFile, logFormat, resolverPprof = "", "", "", "", "", ""
+		portSet, apiPortSet, apiAddrSet = false, false, false
+		logs.SetLevel(logs.LevelInfo)
+		resolverDaemon = true
+		test.setup()
+
+		args := daemonArgs()
+		if !slices.Equal(args, test.args) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.args, args)
+		}
+		if slices.Contains(args, "--daemon") {
+			t.Errorf("%s: expected --daemon to be dropped, got %v", test.name, args)
+		}
+	}
+}
+
+func TestRunningResolverPID(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.SystemConfigEnv, "none")
+	pidFile := filepath.Join(dir, "resolver.pid")
+
+	// A process that has exited, whose PID is no longer running
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		pid     int
+	}{
+		{"running", strconv.Itoa(os.Getpid()) + "\n", os.Getpid()},
+		{"exited", strconv.Itoa(exited.Process.Pid), 0},
+		{"not a number", "sinkzone", 0},
+		{"negative", "-1", 0},
+		{"empty", "", 0},
+	}
+	if pid := runningResolverPID(); pid != 0 {
+		t.Errorf("expected no PID without a PID file, got %d", pid)
+	}
+	for _, test := range tests {
+		if err := os.WriteFile(pidFile, []byte(test.content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if pid := runningResolverPID(); pid != test.pid {
+			t.Errorf("%s: expected PID %d, got %d", test.name, test.pid, pid)
+		}
+	}
+}
+
+func TestGetResolverLogPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.SystemConfigEnv, "none")
+
+	if path, err := getResolverLogPath(); err != nil || path != filepath.Join(dir, "resolver.log") {
+		t.Errorf("expected resolver.log next to the PID file, got %s (%v)", path, err)
+	}
+
+	logFile := filepath.Join(dir, "logs", "sinkzone.log")
+	if err := config.Save(&config.Config{LogFile: logFile}); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := getResolverLogPath(); err != nil || path != logFile {
+		t.Errorf("expected log_file to be used, got %s (%v)", path, err)
+	}
+}
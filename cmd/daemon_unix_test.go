@@ -0,0 +1,11 @@
+//go:build !windows
+
+package cmd
+
+import "testing"
+
+func TestDetachedProcAttr(t *testing.T) {
+	if attr := detachedProcAttr(); attr == nil || !attr.Setsid {
+		t.Errorf("expected the background resolver to start a new session, got %+v", attr)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build windows
+
+package cmd
+
+import "syscall"
+
+// detachedProcess is the DETACHED_PROCESS creation flag: the child gets no console
+const detachedProcess = 0x00000008
+
+// detachedProcAttr starts the background resolver without a console, in its own process group
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package cmd
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestDetachedProcAttr(t *testing.T) {
+	attr := detachedProcAttr()
+	if attr == nil || attr.CreationFlags&detachedProcess == 0 || attr.CreationFlags&syscall.CREATE_NEW_PROCESS_GROUP == 0 || !attr.HideWindow {
+		t.Errorf("expected the background resolver to start without a console in its own process group, got %+v", attr)
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	devicesAPIURL  string
+	deviceFocusFor time.Duration
+	deviceFocusPIN string
+)
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices [show/focus] [device] [on/off/follow]",
+	Short: "List the devices using the resolver and set their focus",
+	Long: `Lists the devices that sent queries since the resolver started, shows the dashboard of one, or keeps one in focus or out of it.
+
+  sinkzone devices                            List the devices, most recently seen first
+  sinkzone devices show kids-tablet           Most queried and blocked domains, and the newest queries
+  sinkzone devices focus kids-tablet on       Block the device as in a focus session, whether or not one runs
+  sinkzone devices focus laptop off --for 2h  Never block the device for 2 hours
+  sinkzone devices focus kids-tablet follow   Block the device only during focus sessions again
+
+A device is known by its MAC address where the neighbor table has one (with lan, or when client_names names a MAC address) and by its address otherwise; give it by either, or by its name in client_names. An account of this machine, told apart with the users section of sinkzone.yaml, is the device user:<name>, e.g. user:sam. Overrides are saved in state.json and outlast restarts. Taking a device out of focus, with off or by letting an on device follow again, needs the focus PIN when one is set (--pin or SINKZONE_PIN).
+
+Run the resolver with --lan (or lan: true) to serve a whole network: DNS and the API bind all interfaces, the API requires api_tokens, and queries record the MAC address of each device.`,
+	Args:      cobra.RangeArgs(0, 3),
+	ValidArgs: []string{"show", "focus"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "list"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		switch {
+		case command == "list" && len(args) <= 1:
+		case command == "show" && len(args) == 2:
+		case command == "focus" && len(args) == 3:
+			if _, err := config.ParseDeviceFocusMode(args[2]); err != nil {
+				return err
+			}
+		case command == "show":
+			return fmt.Errorf("usage: sinkzone devices show <device>")
+		case command == "focus":
+			return fmt.Errorf("usage: sinkzone devices focus <device> on|off|follow")
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'list', 'show <device>', or 'focus <device> on|off|follow'", command)
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(devicesAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+		switch command {
+		case "show":
+			return showDevice(client, args[1])
+		case "focus":
+			return setDeviceFocus(client, args[1], args[2])
+		}
+		return listDevices(client)
+	},
+}
+
+func init() {
+	devicesCmd.Flags().StringVar(&devicesAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	devicesCmd.Flags().DurationVar(&deviceFocusFor, "for", 0, "How long 'focus on' or 'focus off' lasts, e.g. 2h (default: until changed)")
+	devicesCmd.Flags().StringVar(&deviceFocusPIN, "pin", "", "Focus PIN, required to take a device out of focus (or set SINKZONE_PIN)")
+}
+
+func listDevices(client *api.Client) error {
+	devices, err := client.GetDevices()
+	if err != nil {
+		return fmt.Errorf("failed to list devices: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(devices)
+	}
+
+	if len(devices) == 0 {
+		fmt.Println("No device has sent a query yet")
+		return nil
+	}
+	for _, device := range devices {
+		fmt.Printf("%-24s %-17s %6d queries, %5d blocked  %s\n", device.Label(), device.MAC, device.Queries, device.Blocked, deviceFocusText(device))
+	}
+	return nil
+}
+
+func showDevice(client *api.Client, name string) error {
+	detail, err := client.GetDevice(name)
+	if err != nil {
+		return fmt.Errorf("failed to get device %s: %w", name, err)
+	}
+	if jsonOutput() {
+		return printJSON(detail)
+	}
+
+	fmt.Printf("Device: %s\n", detail.Label())
+	if detail.Address != "" {
+		fmt.Printf("Address: %s\n", detail.Address)
+	}
+	if detail.MAC != "" {
+		fmt.Printf("MAC address: %s\n", detail.MAC)
+	}
+	if detail.LastSeen != nil {
+		fmt.Printf("Last seen: %s\n", detail.LastSeen.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Queries: %d, blocked: %d\n", detail.Queries, detail.Blocked)
+	fmt.Printf("Focus: %s\n", deviceFocusText(detail.Device))
+	printCounts("Top domains", detail.TopDomains, false)
+	printCounts("Top blocked", detail.TopBlocked, true)
+
+	fmt.Println("\nNewest queries:")
+	if len(detail.Recent) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, query := range detail.Recent {
+		status := "allowed"
+		if query.Blocked {
+			status = "blocked"
+		}
+		fmt.Printf("  %s  %-40s %s\n", query.Timestamp.Local().Format("15:04:05"), query.Domain, status)
+	}
+	return nil
+}
+
+func setDeviceFocus(client *api.Client, name, mode string) error {
+	req := api.DeviceFocusRequest{Mode: mode, PIN: deviceFocusPIN}
+	if req.PIN == "" {
+		req.PIN = getFocusPIN()
+	}
+	if deviceFocusFor > 0 {
+		req.Duration = deviceFocusFor.String()
+	}
+	device, err := client.SetDeviceFocus(name, req)
+	if err != nil {
+		return fmt.Errorf("failed to set the focus of %s: %w", name, err)
+	}
+	if jsonOutput() {
+		return printJSON(device)
+	}
+	fmt.Printf("%s: %s\n", device.Label(), deviceFocusText(*device))
+	return nil
+}
+
+// deviceFocusText describes whether a device is blocked and why
+func deviceFocusText(device api.Device) string {
+	var text string
+	switch device.FocusMode {
+	case api.DeviceFocusOn:
+		text = "kept in focus"
+	case api.DeviceFocusOff:
+		text = "kept out of focus"
+	default:
+		text = "follows the focus session"
+	}
+	if device.FocusUntil != nil {
+		text += " until " + device.FocusUntil.Local().Format("15:04")
+	}
+	if device.Focus {
+		return text + " (blocking)"
+	}
+	return text
+}
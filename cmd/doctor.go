@@ -0,0 +1,266 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	sinkdns "github.com/berbyte/sinkzone/internal/dns"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+	"github.com/berbyte/sinkzone/internal/version"
+	"github.com/miekg/dns"
+	"github.com/spf13/cobra"
+)
+
+// Results of a doctor check
+const (
+	checkOK   = "OK"
+	checkWarn = "WARN"
+	checkFail = "FAIL"
+)
+
+// doctorProbeTimeout bounds each test query sent by doctor
+const doctorProbeTimeout = 3 * time.Second
+
+var doctorAPIURL string
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check that sinkzone is set up and working",
+	Long: `Runs a series of checks and suggests a fix for each one that fails:
+
+- The config and allowlist files can be read
+- allowlist.txt and state.json weren't changed outside sinkzone
+- The resolver process is running
+- The API is answering
+- The DNS port is bound by sinkzone and answers queries
+- The system DNS points at the local resolver
+- The upstream nameservers can be reached
+
+Exits with an error when a check fails, so it can be used in scripts.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		if failed := runDoctor(); failed > 0 {
+			return fmt.Errorf("%d check(s) failed", failed)
+		}
+		fmt.Println("\nAll checks passed.")
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().StringVar(&doctorAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+}
+
+// runDoctor prints the result of every check and returns how many failed
+func runDoctor() int {
+	failed := 0
+	report := func(status, name, detail, fix string) {
+		fmt.Printf("[%-4s] %s: %s\n", status, name, detail)
+		if status != checkOK && fix != "" {
+			fmt.Printf("       Fix: %s\n", fix)
+		}
+		if status == checkFail {
+			failed++
+		}
+	}
+
+	// Config and allowlist files
+	cfg, err := config.Load()
+	if err != nil {
+		report(checkFail, "Config", err.Error(), fmt.Sprintf("check the syntax and permissions of %s, or move it aside to start from the defaults", config.GetConfigPath()))
+		cfg = &config.Config{UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1"}}
+	} else {
+		report(checkOK, "Config", config.GetConfigPath(), "")
+	}
+
+	if manager, err := allowlist.NewManager(); err != nil {
+		report(checkFail, "Allowlist", err.Error(), "make sure the home directory is set")
+	} else if domains, err := manager.List(); err != nil {
+		report(checkFail, "Allowlist", err.Error(), fmt.Sprintf("check the permissions of %s", manager.GetPath()))
+	} else {
+		report(checkOK, "Allowlist", fmt.Sprintf("%d domain(s) in %s", len(domains), manager.GetPath()), "")
+	}
+	checkFileIntegrity(report)
+
+	// Resolver process and API
+	if pid := runningResolverPID(); pid != 0 {
+		report(checkOK, "Resolver process", fmt.Sprintf("running (PID %d)", pid), "")
+	} else {
+		report(checkFail, "Resolver process", "not running", "start it with 'sudo sinkzone resolver --daemon', or 'sudo sinkzone service install' to run it permanently")
+	}
+
+	client := api.NewClient(doctorAPIURL)
+	var health *api.ResolverHealth
+	if err := client.HealthCheck(); err != nil {
+		report(checkFail, "API", fmt.Sprintf("not answering at %s", doctorAPIURL), "make sure the resolver is running and --api-url matches its --api-port")
+	} else if health, err = client.GetHealth(); err != nil {
+		report(checkWarn, "API", fmt.Sprintf("answering at %s, but health is unavailable: %v", doctorAPIURL, err), "upgrade the resolver to match this sinkzone version")
+	} else if resolver, err := client.GetVersion(); err == nil && (resolver.Version != version.Get().Version || resolver.Commit != version.Get().Commit) {
+		report(checkWarn, "API", fmt.Sprintf("answering at %s, but the resolver runs %s", doctorAPIURL, resolver), "restart the resolver to run this version of sinkzone")
+	} else {
+		report(checkOK, "API", fmt.Sprintf("answering at %s", doctorAPIURL), "")
+	}
+
+	// DNS port
+	port := "53"
+	if health != nil && health.DNSPort != "" {
+		port = health.DNSPort
+	}
+	probeErr := probeDNS(net.JoinHostPort(sysdns.LocalResolver, port))
+	switch {
+	case health != nil && !health.DNSListening:
+		owner := findPortOwner(port)
+		report(checkFail, "DNS port", fmt.Sprintf("sinkzone could not bind port %s%s", port, usedBy(owner)), portOwnerFix(owner, port))
+	case health != nil && probeErr != nil:
+		report(checkFail, "DNS port", fmt.Sprintf("port %s is bound by sinkzone but a test query failed: %v", port, probeErr), "check the resolver log for errors")
+	case health != nil:
+		report(checkOK, "DNS port", fmt.Sprintf("port %s is bound by sinkzone and answering", port), "")
+	case probeErr == nil:
+		owner := findPortOwner(port)
+		report(checkFail, "DNS port", fmt.Sprintf("something answers on port %s%s, but the sinkzone API is not reachable to confirm it is sinkzone", port, usedBy(owner)), portOwnerFix(owner, port))
+	default:
+		report(checkFail, "DNS port", fmt.Sprintf("nothing answers on port %s", port), "start the resolver (see above)")
+	}
+
+	// System DNS
+	servers, err := sysdns.SystemResolvers()
+	var bypass []string
+	for _, server := range servers {
+		if !sysdns.IsLocal(server) {
+			bypass = append(bypass, server)
+		}
+	}
+	setupFix, bypassFix := "run 'sudo sinkzone setup'", "run 'sudo sinkzone setup --undo' and then 'sudo sinkzone setup', or remove the other servers by hand"
+	if _, statErr := os.Stat(config.GetDNSBackupPath()); statErr == nil && runtime.GOOS == "darwin" {
+		// Setup was run, and a DHCP renewal or a VPN replaced its servers since
+		setupFix = "run 'sudo sinkzone setup --repair', or 'sudo sinkzone setup --watch' to repair it automatically"
+		bypassFix = "run 'sudo sinkzone setup --repair'; servers a VPN app pushes itself must be changed in its settings"
+	}
+	switch {
+	case err != nil:
+		report(checkWarn, "System DNS", fmt.Sprintf("could not read the system DNS settings: %v", err), "check the DNS settings by hand; they should point at 127.0.0.1")
+	case len(servers) == 0:
+		report(checkFail, "System DNS", "no DNS servers configured", "run 'sudo sinkzone setup'")
+	case len(bypass) == len(servers):
+		report(checkFail, "System DNS", fmt.Sprintf("points at %s, not at sinkzone", strings.Join(servers, ", ")), setupFix)
+	case len(bypass) > 0:
+		report(checkFail, "System DNS", fmt.Sprintf("also uses %s, which bypasses blocking", strings.Join(bypass, ", ")), bypassFix)
+	case port != "53" && sysdns.ForwardPort() == port:
+		report(checkOK, "System DNS", fmt.Sprintf("points at %s, and systemd-resolved forwards to sinkzone on port %s (dns_fallback_port)", strings.Join(servers, ", "), port), "")
+	case port != "53":
+		report(checkFail, "System DNS", fmt.Sprintf("points at the local resolver, but it listens on port %s and system DNS only uses port 53", port), "restart the resolver with '--port 53'")
+	default:
+		report(checkOK, "System DNS", fmt.Sprintf("points at %s", strings.Join(servers, ", ")), "")
+	}
+
+	// Upstream nameservers
+	upstreams, err := cfg.GetUpstreams()
+	forwarder := sinkdns.NewForwarder(upstreams, doctorProbeTimeout)
+	defer forwarder.Close()
+	var unreachable []string
+	for _, upstream := range upstreams {
+		if err := probeUpstream(forwarder, upstream); err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s (%v)", upstream, err))
+		}
+	}
+	switch {
+	case err != nil:
+		report(checkFail, "Upstreams", err.Error(), "fix upstream_nameservers with 'sinkzone config set upstream <ip>,<url>'")
+	case len(upstreams) == 0:
+		report(checkFail, "Upstreams", "none configured", "add one with 'sinkzone config add upstream <ip>'")
+	case len(unreachable) == len(upstreams):
+		report(checkFail, "Upstreams", "none reachable: "+strings.Join(unreachable, "; "), "check the network connection, or change the upstreams with 'sinkzone config set upstream <ip>,<ip>'")
+	case len(unreachable) > 0:
+		report(checkWarn, "Upstreams", "unreachable: "+strings.Join(unreachable, "; "), "remove the unreachable ones with 'sinkzone config remove upstream <ip>'")
+	default:
+		report(checkOK, "Upstreams", fmt.Sprintf("%d reachable", len(upstreams)), "")
+	}
+
+	return failed
+}
+
+// probeDNS sends a test query and reports whether any answer came back
+// checkFileIntegrity reports whether allowlist.txt and state.json match the checksums
+// sinkzone recorded when it last wrote them
+func checkFileIntegrity(report func(status, name, detail, fix string)) {
+	var changed []string
+	for _, path := range []string{filepath.Join(config.GetDataDir(), "allowlist.txt"), config.GetStatePath()} {
+		err := config.VerifyChecksum(path)
+		switch {
+		case errors.Is(err, config.ErrNoChecksum), errors.Is(err, config.ErrChecksumMismatch):
+			changed = append(changed, filepath.Base(path))
+		case err != nil:
+			report(checkWarn, "File integrity", err.Error(), fmt.Sprintf("check the permissions of %s", path))
+			return
+		}
+	}
+	if len(changed) > 0 {
+		report(checkWarn, "File integrity", fmt.Sprintf("%s changed outside sinkzone", strings.Join(changed, " and ")),
+			"review the changes, then save allowlist.txt with 'sinkzone allowlist edit' and restart the resolver to record new checksums")
+		return
+	}
+	report(checkOK, "File integrity", "allowlist.txt and state.json match their checksums", "")
+}
+
+func probeDNS(addr string) error {
+	client := &dns.Client{Timeout: doctorProbeTimeout}
+	if _, _, err := client.Exchange(probeQuery(), addr); err != nil {
+		return err
+	}
+	return nil
+}
+
+// probeUpstream sends a test query to an upstream over its protocol
+func probeUpstream(forwarder *sinkdns.Forwarder, upstream config.Upstream) error {
+	if _, _, err := forwarder.Exchange(probeQuery(), upstream); err != nil {
+		return err
+	}
+	return nil
+}
+
+func probeQuery() *dns.Msg {
+	msg := new(dns.Msg)
+	msg.SetQuestion(".", dns.TypeNS)
+	return msg
+}
+
+// findPortOwner returns the program holding a UDP port, or nil when it can't be found
+func findPortOwner(port string) *sysdns.PortOwner {
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return nil
+	}
+	owner, _ := sysdns.FindPortOwner(n)
+	return owner
+}
+
+// usedBy names the program holding a port for a check's detail
+func usedBy(owner *sysdns.PortOwner) string {
+	if owner == nil {
+		return ""
+	}
+	return " (used by " + owner.String() + ")"
+}
+
+// portConflictFix suggests how to find and free the DNS port
+func portConflictFix(port string) string {
+	switch runtime.GOOS {
+	case "windows":
+		return fmt.Sprintf("find the program using port %s with 'netstat -ano | findstr :%s' and stop it", port, port)
+	case "linux":
+		return fmt.Sprintf("find the program using port %s with 'sudo ss -lunp sport = :%s'; if it is systemd-resolved's stub listener, set dns_listen to 127.0.0.1:53", port, port)
+	default:
+		return fmt.Sprintf("find the program using port %s with 'sudo lsof -i :%s' and stop it", port, port)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Exit codes of sinkzone, for shell scripts and hooks
+const (
+	ExitOK           = 0
+	ExitError        = 1 // Anything not covered below, such as a bad flag
+	ExitBlocked      = 2 // 'sinkzone check': at least one domain is blocked
+	ExitResolverDown = 3 // The resolver API can't be reached
+)
+
+// exitError ends sinkzone with code; err is printed unless it is nil
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return ""
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// resolverDownError explains that the resolver API didn't answer, and exits with
+// ExitResolverDown
+func resolverDownError(err error) error {
+	return &exitError{code: ExitResolverDown, err: config.AdminError(err, "failed to connect to resolver API")}
+}
+
+// ExitCode returns the status sinkzone exits with after Execute returned err
+func ExitCode(err error) int {
+	var exit *exitError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &exit):
+		return exit.code
+	default:
+		return ExitError
+	}
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/blocklist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/spf13/cobra"
+)
+
+// Formats accepted by 'export --format'
+const exportHosts = "hosts"
+
+var (
+	exportFormat  string
+	exportAddress string
+	exportFile    string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the blocklist for devices without sinkzone",
+	Long: `Writes the blocked domains as a hosts file, so routers, phones, and other devices that can't run sinkzone can reuse the ruleset:
+
+  sinkzone export --format hosts > hosts.txt
+  sinkzone export --format hosts --file /tmp/hosts --address 127.0.0.1
+
+The export holds the blocklist (~/.sinkzone/blocklist.txt) and the enabled subscribed blocklists as last downloaded, without the subscribed domains your allowlist overrides, each domain mapped to --address (default 0.0.0.0), sorted and without duplicates. Hosts files can't express wildcards, so entries such as *.example.com are left out and counted in the header. Only blocked domains are exported: the allowlist needs a resolver to apply it.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if exportFormat != exportHosts {
+			return fmt.Errorf("unknown format: %s. Use 'hosts'", exportFormat)
+		}
+		if net.ParseIP(exportAddress) == nil {
+			return fmt.Errorf("invalid --address %q: must be an IP address, e.g. 0.0.0.0", exportAddress)
+		}
+		cmd.SilenceUsage = true
+		return exportRules()
+	},
+}
+
+func init() {
+	exportCmd.Flags().StringVar(&exportFormat, "format", exportHosts, "Format of the export: hosts")
+	exportCmd.Flags().StringVar(&exportAddress, "address", "0.0.0.0", "Address blocked domains are mapped to")
+	exportCmd.Flags().StringVar(&exportFile, "file", "", "Write the export to a file instead of standard output")
+}
+
+func exportRules() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return err
+	}
+	allowed, err := manager.List()
+	if err != nil {
+		return fmt.Errorf("failed to list allowlist: %w", err)
+	}
+	entries, err := blocklist.Entries(subscription.Blocklist.CachedLists(cfg.BlocklistSubscriptions), allowed)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if exportFile != "" {
+		// #nosec G304 -- the file is chosen by the user running this command
+		file, err := os.Create(exportFile)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", exportFile, err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close %s: %v\n", exportFile, err)
+			}
+		}()
+		w = file
+	}
+
+	written, wildcards, err := blocklist.WriteHosts(w, entries, exportAddress)
+	if err != nil {
+		return err
+	}
+	// Standard output holds the export, so the summary goes to standard error
+	fmt.Fprintf(os.Stderr, "Exported %d blocked domains", written)
+	if wildcards > 0 {
+		fmt.Fprintf(os.Stderr, " (%d wildcard entries left out)", wildcards)
+	}
+	fmt.Fprintln(os.Stderr, ".")
+	return nil
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var extensionAPIURL string
+
+var extensionCmd = &cobra.Command{
+	Use:   "extension [pending/approve] [code]",
+	Short: "Pair browser extensions with the resolver",
+	Long: `Lists browser extensions waiting to be paired, or approves one, which gives it an API token of its own.
+
+  sinkzone extension pending          List the codes waiting for approval
+  sinkzone extension approve K7QF-2MXA  Approve the extension showing this code
+
+A browser extension asks the resolver whether the domain of a tab is blocked (GET /api/extension/decision) and lets it through for a while with one click (POST /api/extension/allow, like 'sinkzone focus snooze'). When api_tokens are set, it pairs first: it starts a pairing with POST /api/extension/pair and shows the code it gets, and once the code is approved here it collects its token from GET /api/extension/pair/{id}. The token is saved to api_tokens in sinkzone.yaml as extension-<name>, with only the extension scope; remove it there to unpair. Codes expire after 5 minutes. Without api_tokens the API needs no token, and pairing answers "open".`,
+	Args:      cobra.RangeArgs(0, 2),
+	ValidArgs: []string{"pending", "approve"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "pending"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		switch {
+		case command == "pending" && len(args) <= 1:
+		case command == "approve" && len(args) == 2:
+		case command == "approve":
+			return fmt.Errorf("usage: sinkzone extension approve <code>")
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'pending' or 'approve <code>'", command)
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(extensionAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+		if command == "approve" {
+			return approvePairing(client, args[1])
+		}
+		return listPairings(client)
+	},
+}
+
+func init() {
+	extensionCmd.Flags().StringVar(&extensionAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+}
+
+func listPairings(client *api.Client) error {
+	pairings, err := client.ListPairings()
+	if err != nil {
+		return fmt.Errorf("failed to list pairings: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(pairings)
+	}
+
+	if len(pairings) == 0 {
+		fmt.Println("No browser extension is waiting to be paired")
+		return nil
+	}
+	for _, pairing := range pairings {
+		fmt.Printf("%s  %s (expires in %s)\n", pairing.Code, pairing.Name, time.Until(*pairing.ExpiresAt).Round(time.Second))
+	}
+	return nil
+}
+
+func approvePairing(client *api.Client, code string) error {
+	pairing, err := client.ApprovePairing(code)
+	if err != nil {
+		return fmt.Errorf("failed to approve %s: %w", code, err)
+	}
+	if jsonOutput() {
+		return printJSON(pairing)
+	}
+	fmt.Printf("Approved %s: the extension now has the API token %s\n", pairing.Code, pairing.Name)
+	return nil
+}
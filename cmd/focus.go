@@ -2,18 +2,31 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/berbyte/sinkzone/internal/api"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/focus"
 	"github.com/spf13/cobra"
 )
 
 var (
-	focusEnable   bool
-	focusDisable  bool
-	focusDuration string
-	focusAPIURL   string
+	focusEnable    bool
+	focusDisable   bool
+	focusDuration  string
+	focusUntil     string
+	focusAPIURL    string
+	focusPauseFor  string
+	focusProfile   string
+	focusIntensity string
+	focusLabel     string
+	focusPIN       string
+	focusDryRun    bool
+
+	focusWatchLine     bool
+	focusWatchOnce     bool
+	focusWatchInterval time.Duration
 )
 
 var focusCmd = &cobra.Command{
@@ -21,18 +34,86 @@ var focusCmd = &cobra.Command{
 	Short: "Manage focus mode",
 	Long: `Enables or disables focus mode, which blocks all non-allowlisted domains.
 
-Focus mode is the core productivity feature in Sinkzone. When enabled, only DNS requests to domains on your allowlist will be resolved — everything else is silently blocked.`,
-	Args: cobra.MaximumNArgs(1),
+Focus mode is the core productivity feature in Sinkzone. When enabled, only DNS requests to domains on your allowlist will be resolved — everything else is silently blocked.
+
+Profiles defined in sinkzone.yaml bundle their own allowlist and default duration. Select one with 'sinkzone focus --enable --profile deep-work', or make one the default with 'sinkzone profile use deep-work' (--profile "" then picks the default allowlist).
+
+Use --until 17:30 instead of --duration to focus until a wall-clock time (a time that has passed today means tomorrow). The end time stays correct across daylight saving changes.
+
+Tag a session with --label "thesis writing" to break focus time down by project in 'sinkzone status' and the stats.
+
+Choose how strictly a session blocks with --intensity: 'soft' only blocks domains on the blocklist (see 'sinkzone blocklist'), 'normal' blocks everything not allowlisted, and 'hard' additionally blocks domains first seen during the session, even if they match an allowlist wildcard. The default comes from focus_intensity in sinkzone.yaml.
+
+Add --dry-run to try out an allowlist first: the resolver logs and records every query it would block, but still resolves everything. 'sinkzone status' and 'sinkzone monitor' show what would have been blocked, without the session counting as focus time.
+
+If a focus PIN is configured ('sinkzone config set pin <pin>'), disabling, pausing, snoozing a domain, shortening, or switching the profile of an active session requires it via --pin or the SINKZONE_PIN environment variable.
+
+If focus_disable_delay is set in sinkzone.yaml, 'sinkzone focus --disable' queues the disable instead: focus mode stays active until the delay has passed.
+
+Use 'sinkzone focus watch' to keep a live countdown of the remaining focus time in a corner terminal. Add --line for a single-line display, or --once to print one line and exit (handy for status bars such as tmux or i3blocks).
+
+Use 'sinkzone focus at 14:00 --duration 2h' to queue a session for later (a time that has passed today means tomorrow; use 2006-01-02T15:04 for other days). The resolver starts it automatically. List queued sessions with 'sinkzone focus scheduled' and remove one with 'sinkzone focus cancel <id>'.
+
+Use 'sinkzone focus pause --for 5m' for legitimate interruptions. Blocking is lifted for the pause window and the remaining focus time is preserved; the session resumes automatically when the pause expires, or immediately with 'sinkzone focus resume'.
+
+Use 'sinkzone focus snooze docs.python.org 10m' to let a single blocked domain through for a while without editing the allowlist. The resolver revokes the exception when it expires or the session ends.
+
+Use 'sinkzone focus break --for 10m' for a structured break: only the break domains ('sinkzone allowlist add <domain> --break') are unblocked on top of the allowlist, and the session resumes just like after a pause.`,
+	Args: cobra.MaximumNArgs(3),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if !cmd.Flags().Changed("profile") {
+			focusProfile = activeProfile()
+		}
+
 		// Handle subcommands
 		if len(args) > 0 {
 			switch args[0] {
+			case "at":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: sinkzone focus at <HH:MM> [--duration 2h]")
+				}
+				return scheduleFocusSession(args[1])
+			case "scheduled":
+				return listScheduledSessions()
+			case "cancel":
+				if len(args) != 2 {
+					return fmt.Errorf("usage: sinkzone focus cancel <session-id>")
+				}
+				return cancelScheduledSession(args[1])
 			case "start":
-				return enableFocusMode(1 * time.Hour)
+				return enableFocusMode(defaultFocusDuration(), focusProfile)
+			case "pause":
+				duration, err := time.ParseDuration(focusPauseFor)
+				if err != nil || duration <= 0 {
+					return fmt.Errorf("invalid pause duration: %s", focusPauseFor)
+				}
+				return pauseFocusMode(duration, false)
+			case "break":
+				duration, err := time.ParseDuration(focusPauseFor)
+				if err != nil || duration <= 0 {
+					return fmt.Errorf("invalid break duration: %s", focusPauseFor)
+				}
+				return pauseFocusMode(duration, true)
+			case "snooze":
+				if len(args) != 3 {
+					return fmt.Errorf("usage: sinkzone focus snooze <domain> <duration>")
+				}
+				duration, err := time.ParseDuration(args[2])
+				if err != nil || duration <= 0 {
+					return fmt.Errorf("invalid snooze duration: %s", args[2])
+				}
+				return snoozeDomain(args[1], duration)
+			case "resume":
+				return resumeFocusMode()
+			case "watch":
+				return watchFocusMode()
 			default:
 				return fmt.Errorf("unknown command: %s", args[0])
 			}
 		}
+		if len(args) > 1 {
+			return fmt.Errorf("unexpected argument: %s", args[1])
+		}
 
 		// Handle flags
 		if focusDisable {
@@ -40,7 +121,7 @@ Focus mode is the core productivity feature in Sinkzone. When enabled, only DNS
 		}
 
 		if focusEnable {
-			duration := 1 * time.Hour // Default 1 hour
+			duration := defaultFocusDuration()
 			if focusDuration != "" {
 				var err error
 				duration, err = time.ParseDuration(focusDuration)
@@ -48,7 +129,7 @@ Focus mode is the core productivity feature in Sinkzone. When enabled, only DNS
 					return fmt.Errorf("invalid duration format: %w", err)
 				}
 			}
-			return enableFocusMode(duration)
+			return enableFocusMode(duration, focusProfile)
 		}
 
 		// If no args or flags, show help
@@ -60,26 +141,123 @@ func init() {
 	focusCmd.Flags().BoolVar(&focusEnable, "enable", false, "Enable focus mode")
 	focusCmd.Flags().BoolVar(&focusDisable, "disable", false, "Disable focus mode")
 	focusCmd.Flags().StringVar(&focusDuration, "duration", "", "Duration for focus mode (e.g., '1h', '30m')")
-	focusCmd.Flags().StringVar(&focusAPIURL, "api-url", "http://127.0.0.1:8080", "URL of the resolver API")
+	focusCmd.Flags().StringVar(&focusUntil, "until", "", "Focus until a local time instead of for a duration (e.g., '17:30')")
+	focusCmd.Flags().StringVar(&focusAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	focusCmd.Flags().StringVar(&focusPauseFor, "for", "5m", "How long to pause focus mode (used with 'pause' and 'break')")
+	focusCmd.Flags().StringVar(&focusProfile, "profile", "", "Focus profile to use (default: the active profile, see 'sinkzone profile use')")
+	focusCmd.Flags().StringVar(&focusLabel, "label", "", "Project label the session's focus time is attributed to in stats")
+	focusCmd.Flags().BoolVar(&focusDryRun, "dry-run", false, "Record what would be blocked without blocking anything")
+	focusCmd.Flags().StringVar(&focusIntensity, "intensity", "", "Blocking intensity: soft, normal, or hard (default from focus_intensity)")
+	focusCmd.Flags().BoolVar(&focusWatchLine, "line", false, "Render a single-line countdown (used with 'watch')")
+	focusCmd.Flags().BoolVar(&focusWatchOnce, "once", false, "Print the countdown line once and exit (used with 'watch')")
+	focusCmd.Flags().DurationVar(&focusWatchInterval, "interval", time.Second, "Refresh interval (used with 'watch')")
+	focusCmd.Flags().StringVar(&focusPIN, "pin", "", "Focus PIN, required to disable or loosen an active session (or set SINKZONE_PIN)")
+}
+
+// getFocusPIN returns the PIN from --pin, falling back to the SINKZONE_PIN environment variable
+func getFocusPIN() string {
+	if focusPIN != "" {
+		return focusPIN
+	}
+	return os.Getenv("SINKZONE_PIN")
 }
 
-func enableFocusMode(duration time.Duration) error {
+// activeProfile returns the profile chosen with 'sinkzone profile use', if any
+func activeProfile() string {
+	cfg, err := config.Load()
+	if err != nil {
+		return ""
+	}
+	return cfg.ActiveProfile
+}
+
+// defaultFocusDuration returns the session length used when --duration is not given.
+// With a profile that has a duration, 0 lets the resolver apply the profile's own default.
+func defaultFocusDuration() time.Duration {
+	if focusProfile != "" {
+		if cfg, err := config.Load(); err != nil {
+			return 0
+		} else if profile, err := cfg.GetProfile(focusProfile); err != nil || profile.Duration != "" {
+			return 0
+		}
+	}
+	return 1 * time.Hour // Default 1 hour
+}
+
+func enableFocusMode(duration time.Duration, profile string) error {
 	// Create API client
 	client := api.NewClient(focusAPIURL)
 
 	// Try to connect to API
 	if err := client.HealthCheck(); err != nil {
-		return config.AdminError(err, "failed to connect to resolver API")
+		return resolverDownError(err)
+	}
+
+	if focusIntensity != "" {
+		if _, err := config.ParseIntensity(focusIntensity); err != nil {
+			return err
+		}
+	}
+
+	var until string
+	if focusUntil != "" {
+		if focusDuration != "" {
+			return fmt.Errorf("use either --duration or --until, not both")
+		}
+		at, err := api.ParseWallClock(focusUntil, time.Now())
+		if err != nil {
+			return err
+		}
+		// Send an absolute time so the resolver's time zone doesn't matter
+		until = at.Format(time.RFC3339)
+		duration = 0
+	}
+
+	req := api.FocusRequest{
+		Enabled:   true,
+		Until:     until,
+		Profile:   profile,
+		Intensity: focusIntensity,
+		Label:     focusLabel,
+		DryRun:    focusDryRun,
+		PIN:       getFocusPIN(),
+	}
+	if duration > 0 {
+		req.Duration = duration.String()
 	}
 
 	// Set focus mode via API
-	if err := client.SetFocusMode(true, duration.String()); err != nil {
+	if err := client.SetFocusModeWithOptions(req); err != nil {
 		return fmt.Errorf("failed to enable focus mode: %w", err)
 	}
 
-	endTime := time.Now().Add(duration)
-	fmt.Printf("Focus mode activated for %s (until %s)\n", duration, endTime.Format("15:04:05"))
-	fmt.Printf("DNS resolver will block non-allowlisted domains immediately.\n")
+	// Report what the resolver actually applied (profiles may supply the duration)
+	state, err := client.GetFocusMode()
+	if err != nil {
+		return fmt.Errorf("failed to get focus mode state: %w", err)
+	}
+
+	if state.Profile != "" {
+		fmt.Printf("Using profile: %s\n", state.Profile)
+	}
+	if state.Intensity != "" {
+		fmt.Printf("Intensity: %s\n", state.Intensity)
+	}
+	if state.Label != "" {
+		fmt.Printf("Label: %s\n", state.Label)
+	}
+	if state.EndTime != nil {
+		fmt.Printf("Focus mode activated for %s (until %s)\n", time.Until(*state.EndTime).Round(time.Minute), state.EndTime.Format("15:04:05"))
+	} else {
+		fmt.Printf("Focus mode activated (no expiration)\n")
+	}
+	if state.DryRun {
+		fmt.Printf("Dry run: nothing is blocked; queries that would be are logged and shown in 'sinkzone status'.\n")
+	} else if state.GraceUntil != nil {
+		fmt.Printf("Grace period: non-allowlisted domains are logged until %s, then blocked.\n", state.GraceUntil.Format("15:04:05"))
+	} else {
+		fmt.Printf("DNS resolver will block non-allowlisted domains immediately.\n")
+	}
 	return nil
 }
 
@@ -89,14 +267,254 @@ func disableFocusMode() error {
 
 	// Try to connect to API
 	if err := client.HealthCheck(); err != nil {
-		return config.AdminError(err, "failed to connect to resolver API")
+		return resolverDownError(err)
 	}
 
 	// Set focus mode via API
-	if err := client.SetFocusMode(false, ""); err != nil {
+	if err := client.SetFocusModeWithOptions(api.FocusRequest{Enabled: false, PIN: getFocusPIN()}); err != nil {
 		return fmt.Errorf("failed to disable focus mode: %w", err)
 	}
 
+	// A disable delay keeps focus mode active for a while
+	state, err := client.GetFocusMode()
+	if err != nil {
+		return fmt.Errorf("failed to get focus mode state: %w", err)
+	}
+	if state.DisableAt != nil {
+		fmt.Printf("Disable queued. Focus mode stays active until %s (in %s).\n", state.DisableAt.Format("15:04:05"), time.Until(*state.DisableAt).Round(time.Second))
+		fmt.Printf("Run 'sinkzone focus --enable' to cancel and keep focusing.\n")
+		return nil
+	}
+
 	fmt.Printf("Focus mode disabled. All domains will be allowed.\n")
 	return nil
 }
+
+func pauseFocusMode(duration time.Duration, isBreak bool) error {
+	// Create API client
+	client := api.NewClient(focusAPIURL)
+
+	// Try to connect to API
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	req := api.PauseRequest{Duration: duration.String(), PIN: getFocusPIN(), Break: isBreak}
+	if err := client.PauseFocusModeWithOptions(req); err != nil {
+		return fmt.Errorf("failed to pause focus mode: %w", err)
+	}
+
+	resumeTime := time.Now().Add(duration)
+	if isBreak {
+		fmt.Printf("Break started for %s (focus resumes at %s)\n", duration, resumeTime.Format("15:04:05"))
+		fmt.Printf("Break domains are allowed; everything else stays blocked.\n")
+	} else {
+		fmt.Printf("Focus mode paused for %s (resumes at %s)\n", duration, resumeTime.Format("15:04:05"))
+	}
+	fmt.Printf("Remaining focus time is preserved. Use 'sinkzone focus resume' to resume early.\n")
+	return nil
+}
+
+func resumeFocusMode() error {
+	// Create API client
+	client := api.NewClient(focusAPIURL)
+
+	// Try to connect to API
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	if err := client.ResumeFocusMode(); err != nil {
+		return fmt.Errorf("failed to resume focus mode: %w", err)
+	}
+
+	fmt.Printf("Focus mode resumed. Non-allowlisted domains are blocked again.\n")
+	return nil
+}
+
+func snoozeDomain(domain string, duration time.Duration) error {
+	client := api.NewClient(focusAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	snooze, err := client.SnoozeDomain(api.SnoozeRequest{
+		Domain:   domain,
+		Duration: duration.String(),
+		PIN:      getFocusPIN(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to snooze %s: %w", domain, err)
+	}
+
+	fmt.Printf("%s is allowed until %s, then blocked again.\n", snooze.Domain, snooze.Until.Format("15:04:05"))
+	return nil
+}
+
+func scheduleFocusSession(at string) error {
+	start, err := api.ParseWallClock(at, time.Now())
+	if err != nil {
+		return err
+	}
+
+	duration := time.Hour
+	if focusDuration != "" {
+		duration, err = time.ParseDuration(focusDuration)
+		if err != nil {
+			return fmt.Errorf("invalid duration format: %w", err)
+		}
+	}
+
+	client := api.NewClient(focusAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	session, err := client.ScheduleFocusSession(api.ScheduledSession{
+		Start:    start,
+		Duration: duration.String(),
+		Profile:  focusProfile,
+		Label:    focusLabel,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to schedule focus session: %w", err)
+	}
+
+	fmt.Printf("Focus session %s scheduled for %s (%s)\n", session.ID, session.Start.Format("Mon Jan 2 15:04"), session.Duration)
+	if session.Profile != "" {
+		fmt.Printf("Profile: %s\n", session.Profile)
+	}
+	if session.Label != "" {
+		fmt.Printf("Label: %s\n", session.Label)
+	}
+	return nil
+}
+
+func listScheduledSessions() error {
+	client := api.NewClient(focusAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	sessions, err := client.GetScheduledSessions()
+	if err != nil {
+		return fmt.Errorf("failed to get scheduled sessions: %w", err)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Println("No focus sessions scheduled.")
+		return nil
+	}
+
+	fmt.Printf("%-10s %-18s %-10s %-15s %s\n", "ID", "Start", "Duration", "Profile", "Label")
+	for _, session := range sessions {
+		fmt.Printf("%-10s %-18s %-10s %-15s %s\n", session.ID, session.Start.Format("Mon Jan 2 15:04"), session.Duration, session.Profile, session.Label)
+	}
+	return nil
+}
+
+func cancelScheduledSession(id string) error {
+	client := api.NewClient(focusAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	if err := client.CancelScheduledSession(id); err != nil {
+		return fmt.Errorf("failed to cancel focus session: %w", err)
+	}
+
+	fmt.Printf("Focus session %s cancelled.\n", id)
+	return nil
+}
+
+func watchFocusMode() error {
+	client := api.NewClient(focusAPIURL)
+
+	if focusWatchInterval <= 0 {
+		return fmt.Errorf("invalid interval: %s", focusWatchInterval)
+	}
+
+	// Piped output gets one line per update so status bars can consume it
+	interactive := isTerminal(os.Stdout)
+
+	for {
+		line, state := focusWatchStatus(client)
+
+		switch {
+		case focusWatchOnce:
+			fmt.Println(line)
+			return nil
+		case focusWatchLine && interactive:
+			fmt.Printf("\r\033[K%s", line)
+		case focusWatchLine || !interactive:
+			fmt.Println(line)
+		default:
+			fmt.Print("\033[H\033[2J")
+			fmt.Print(renderFocusWatchScreen(line, state))
+		}
+
+		time.Sleep(focusWatchInterval)
+	}
+}
+
+// focusWatchStatus fetches the focus state and formats it as a single status line
+func focusWatchStatus(client *api.Client) (string, *api.FocusModeState) {
+	state, err := client.GetFocusMode()
+	if err != nil {
+		return "sinkzone: resolver unreachable", nil
+	}
+
+	if !state.Enabled {
+		return "Focus: off", state
+	}
+
+	var status string
+	switch {
+	case state.Paused && state.PausedUntil != nil:
+		status = fmt.Sprintf("Focus: paused %s", focus.Countdown(time.Until(*state.PausedUntil)))
+	case state.EndTime != nil:
+		status = fmt.Sprintf("Focus: %s left", focus.Countdown(time.Until(*state.EndTime)))
+	default:
+		status = "Focus: on"
+	}
+
+	if state.Profile != "" {
+		status += fmt.Sprintf(" [%s]", state.Profile)
+	}
+
+	status += fmt.Sprintf(" | %d blocked", state.Blocked)
+	if state.LastBlocked != "" {
+		status += fmt.Sprintf(" (last: %s)", state.LastBlocked)
+	}
+
+	return status, state
+}
+
+// renderFocusWatchScreen renders the full-screen countdown view
+func renderFocusWatchScreen(line string, state *api.FocusModeState) string {
+	var countdown string
+	switch {
+	case state == nil:
+		countdown = "--:--"
+	case !state.Enabled:
+		countdown = "OFF"
+	case state.Paused && state.PausedUntil != nil:
+		countdown = "PAUSED " + focus.Countdown(time.Until(*state.PausedUntil))
+	case state.EndTime != nil:
+		countdown = focus.Countdown(time.Until(*state.EndTime))
+	default:
+		countdown = "ON"
+	}
+
+	return fmt.Sprintf("\n  SINKZONE FOCUS\n\n  %s\n\n  %s\n\n  Updated %s | Ctrl+C to exit\n",
+		countdown, line, time.Now().Format("15:04:05"))
+}
+
+// isTerminal reports whether the file is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+)
+
+func TestFocusWatchStatus(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com"}})
+
+	steps := []struct {
+		name  string
+		setup func() error
+		line  string
+	}{
+		{"off", func() error { return nil }, `^Focus: off$`},
+		{"on", func() error {
+			r.Focus(t, 2*time.Hour)
+			r.Blocked(t, "example.com")
+			return nil
+		}, `^Focus: 1:59:5\d left \| 1 blocked \(last: example\.com\)$`},
+		{"paused", func() error { return r.Client.PauseFocusMode("5m", "") }, `^Focus: paused 04:5\d \| 1 blocked \(last: example\.com\)$`},
+	}
+
+	for _, step := range steps {
+		if err := step.setup(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		line, state := focusWatchStatus(r.Client)
+		if state == nil || !regexp.MustCompile(step.line).MatchString(line) {
+			t.Errorf("%s: expected a line matching %s, got %q", step.name, step.line, line)
+		}
+	}
+
+	if line, state := focusWatchStatus(api.NewClient("http://127.0.0.1:1")); state != nil || line != "sinkzone: resolver unreachable" {
+		t.Errorf("expected the resolver to be reported unreachable, got %q", line)
+	}
+}
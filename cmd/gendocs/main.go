@@ -0,0 +1,98 @@
+// Command gendocs generates the man pages embedded in the binary and the markdown command
+// reference from the cobra command tree. Run it with 'go generate ./cmd'.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berbyte/sinkzone/cmd"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+func main() {
+	manDir := flag.String("man", "manpages", "Directory the man pages are written to")
+	markdownDir := flag.String("markdown", filepath.Join("..", "docs", "cli"), "Directory the markdown reference is written to")
+	flag.Parse()
+
+	if err := generate(*manDir, *markdownDir); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+}
+
+func generate(manDir, markdownDir string) error {
+	root := cmd.Root()
+	// Keep the output identical between runs so regenerating only shows real changes
+	root.DisableAutoGenTag = true
+	prepareHelp(root)
+
+	for _, dir := range []string{manDir, markdownDir} {
+		// Remove pages of commands that no longer exist
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to clean %s: %w", dir, err)
+		}
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+
+	header := &doc.GenManHeader{
+		Title:   "SINKZONE",
+		Section: "1",
+		Source:  "Sinkzone",
+		Manual:  "Sinkzone Manual",
+	}
+	if err := doc.GenManTree(root, header, manDir); err != nil {
+		return fmt.Errorf("failed to generate man pages: %w", err)
+	}
+	if err := doc.GenMarkdownTree(root, markdownDir); err != nil {
+		return fmt.Errorf("failed to generate markdown docs: %w", err)
+	}
+	return trimTrailingNewlines(markdownDir)
+}
+
+// trimTrailingNewlines leaves each markdown file ending in a single newline, as the
+// end-of-file pre-commit hook expects
+func trimTrailingNewlines(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.md"))
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+	for _, path := range paths {
+		// #nosec G304 -- path was just generated in the output directory
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		content = append(bytes.TrimRight(content, "\n"), '\n')
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// prepareHelp turns the plain-text help into the markdown cobra/doc expects: indented
+// example lines become code blocks, and placeholders like <domain> are escaped so they
+// aren't dropped as HTML tags
+func prepareHelp(command *cobra.Command) {
+	lines := strings.Split(command.Long, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, "  ") {
+			lines[i] = "  " + line
+		} else {
+			lines[i] = strings.NewReplacer("<", `\<`, ">", `\>`).Replace(line)
+		}
+	}
+	command.Long = strings.Join(lines, "\n")
+
+	for _, sub := range command.Commands() {
+		prepareHelp(sub)
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/spf13/cobra"
+)
+
+// listStatusReport is a subscribed list in the output of 'lists status --output json'
+type listStatusReport struct {
+	List       string     `json:"list"` // allowlist or blocklist
+	URL        string     `json:"url"`
+	Enabled    bool       `json:"enabled"`
+	Domains    int        `json:"domains"`               // Domains in the last download
+	Checked    *time.Time `json:"checked,omitempty"`     // Last download, or check that found the list unchanged
+	Updated    *time.Time `json:"updated,omitempty"`     // Last download that changed the domains
+	Added      int        `json:"added"`                 // Domains the last change added
+	Removed    int        `json:"removed"`               // Domains the last change removed
+	Failures   int        `json:"failures,omitempty"`    // Failed downloads in a row
+	LastError  string     `json:"last_error,omitempty"`  // Why the last download failed
+	NextUpdate *time.Time `json:"next_update,omitempty"` // When a running resolver downloads it again; unset while updates are off
+}
+
+var listsCmd = &cobra.Command{
+	Use:   "lists [status]",
+	Short: "Show when subscribed lists were last updated and what changed",
+	Long: `Shows the allowlists and blocklists followed with 'sinkzone allowlist subscribe' and 'sinkzone blocklist subscribe': how many domains each has, when it last changed and how many domains that added and removed, when it was last checked, and when a running resolver downloads it again.
+
+A running resolver downloads each enabled list again once it is subscription_refresh old (default 24h), plus a random delay of up to a tenth of that (at most 2h), so lists and machines don't all ask the servers at once; the next update shown is the earliest. A list that fails to download keeps its previous copy and is tried again 15 minutes later, then after twice as long with each failure in a row, up to a day; the failures and the last error are shown until a download succeeds. 'sinkzone blocklist update' and 'sinkzone allowlist update' download them right away.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"status"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) > 0 && args[0] != "status" {
+			return fmt.Errorf("unknown command: %s. Use 'status'", args[0])
+		}
+		cmd.SilenceUsage = true
+		return showListsStatus()
+	},
+}
+
+func showListsStatus() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	refresh, err := cfg.GetSubscriptionRefresh()
+	if err != nil {
+		return err
+	}
+
+	reports := []listStatusReport{}
+	for _, list := range subscription.Lists {
+		for _, s := range *list.Subscriptions(cfg) {
+			reports = append(reports, listStatus(list, s, refresh))
+		}
+	}
+	if jsonOutput() {
+		return printJSON(reports)
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No subscribed lists. Add one with 'sinkzone blocklist subscribe <url>' or 'sinkzone allowlist subscribe <url>'.")
+		return nil
+	}
+	if refresh > 0 {
+		every := strings.TrimSuffix(strings.TrimSuffix(refresh.String(), "0s"), "0m")
+		fmt.Printf("Subscribed lists (%d), updated every %s:\n", len(reports), every)
+	} else {
+		fmt.Printf("Subscribed lists (%d), updated only with 'update' (subscription_refresh is 0):\n", len(reports))
+	}
+	for _, report := range reports {
+		state := ""
+		if !report.Enabled {
+			state = " (disabled)"
+		}
+		fmt.Printf("\n  %s %s%s\n", report.List, report.URL, state)
+		switch {
+		case report.Checked == nil:
+			fmt.Printf("    Not downloaded yet\n")
+		case report.Updated == nil:
+			fmt.Printf("    %d domains, checked %s\n", report.Domains, report.Checked.Format("2006-01-02 15:04"))
+		default:
+			fmt.Printf("    %d domains, changed %s (%d added, %d removed), checked %s\n", report.Domains,
+				report.Updated.Format("2006-01-02 15:04"), report.Added, report.Removed, report.Checked.Format("2006-01-02 15:04"))
+		}
+		if report.Failures > 0 {
+			downloads := "download"
+			if report.Failures > 1 {
+				downloads = "downloads in a row"
+			}
+			fmt.Printf("    %d failed %s: %s\n", report.Failures, downloads, report.LastError)
+		}
+		if report.NextUpdate != nil {
+			label := "Next update"
+			if report.Failures > 0 {
+				label = "Next attempt"
+			}
+			fmt.Printf("    %s after %s\n", label, report.NextUpdate.Format("2006-01-02 15:04"))
+		}
+	}
+	return nil
+}
+
+// listStatus describes a subscribed list from its cached download and recorded status;
+// lists downloaded before their status was recorded fall back to the cache
+func listStatus(list subscription.List, s config.Subscription, refresh time.Duration) listStatusReport {
+	report := listStatusReport{List: string(list), URL: s.URL, Enabled: !s.Disabled}
+	status, _ := list.Status(s.URL)
+	if count, checked, err := list.Cached(s.URL); err == nil {
+		report.Domains = count
+		report.Checked = &checked
+	}
+	if !status.Checked.IsZero() {
+		report.Checked = &status.Checked
+	}
+	if !status.Updated.IsZero() {
+		report.Updated = &status.Updated
+		report.Added, report.Removed = status.Added, status.Removed
+	}
+	report.Failures, report.LastError = status.Failures, status.LastError
+
+	switch {
+	case !report.Enabled || refresh == 0:
+	case status.Failures > 0:
+		next := status.RetryAt()
+		report.NextUpdate = &next
+	case report.Checked != nil:
+		next := report.Checked.Add(refresh)
+		report.NextUpdate = &next
+	}
+	return report
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsLevel  string
+	logsFollow bool
+	logsSince  time.Duration
+	logsLines  int
+	logsFile   string
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the resolver log",
+	Long: `Shows the log of a resolver started with --daemon or as a service (resolver.log next to the PID file), so you don't have to find it yourself.
+
+The level of a line is inferred from its wording: lines starting with "Error" are errors, lines starting with "Warning" or reporting a failure are warnings, and lines starting with "Debug" (written by a resolver started with --verbose) are debug details. JSON lines, written with --log-format json, carry their level.
+
+On Linux the sinkzone service logs to the journal instead; use 'journalctl -u sinkzone'. With log_output: syslog in sinkzone.yaml the resolver logs to the system log, which this command doesn't read.
+
+Examples:
+  sinkzone logs
+  sinkzone logs --level warn --since 10m
+  sinkzone logs --follow
+  sinkzone logs -n 0 --file /var/log/sinkzone.log`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return showLogs()
+	},
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsLevel, "level", "info", "Minimum level to show: debug, info, warn, or error")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new lines as they are written")
+	logsCmd.Flags().DurationVar(&logsSince, "since", 0, "Only show lines from this long ago (e.g. 10m, 2h)")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 50, "Number of lines to show; 0 shows all")
+	logsCmd.Flags().StringVar(&logsFile, "file", "", "Log file to read (default: resolver.log next to the PID file)")
+}
+
+func showLogs() error {
+	level, err := logs.ParseLevel(logsLevel)
+	if err != nil {
+		return err
+	}
+	filter := logs.Filter{MinLevel: level}
+	if logsSince > 0 {
+		filter.Since = time.Now().Add(-logsSince)
+	}
+
+	path := logsFile
+	if path == "" {
+		if cfg, err := config.Load(); err == nil {
+			if output, _ := cfg.GetLogOutput(); output == logs.OutputSyslog {
+				return fmt.Errorf("the resolver logs to the system log (log_output: syslog). %s", syslogHint())
+			}
+		}
+		if path, err = getResolverLogPath(); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		hint := "The resolver writes it when started with --daemon or as a service; in the foreground it logs to the terminal."
+		if runtime.GOOS == "linux" {
+			hint += " The sinkzone service logs to the journal: 'journalctl -u sinkzone'."
+		}
+		return fmt.Errorf("no log file at %s. %s", path, hint)
+	}
+
+	entries, offset, err := logs.Read(path, filter, logsLines)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		fmt.Println(entry.Line)
+	}
+	if !logsFollow {
+		return nil
+	}
+
+	stop := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		close(stop)
+	}()
+
+	return logs.Follow(path, offset, filter, stop, func(entry logs.Entry) {
+		fmt.Println(entry.Line)
+	})
+}
+
+// syslogHint says where to read the system log on this platform
+func syslogHint() string {
+	switch runtime.GOOS {
+	case "windows":
+		return "Open the Application log in the Event Viewer, source sinkzone."
+	case "darwin":
+		return `Read it with: log show --predicate 'process == "sinkzone"'`
+	default:
+		return "Read it with 'journalctl -t sinkzone', or in /var/log/syslog."
+	}
+}
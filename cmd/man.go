@@ -1,144 +1,137 @@
 package cmd
 
 import (
+	"embed"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/spf13/cobra"
 )
 
+// The man pages are generated from the command tree by ./gendocs, which also writes the
+// markdown reference in docs/cli. Rerun it after changing any command's help.
+//
+//go:generate go run ./gendocs
+
+//go:embed manpages/*.1
+var manPages embed.FS
+
 var manCmd = &cobra.Command{
-	Use:   "man",
+	Use:   "man [command]",
 	Short: "Show the manual page",
-	Long:  `Display the manual page for sinkzone. This shows detailed documentation and usage examples.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// Try to find the man page file
-		manPagePath := "docs/sinkzone.1"
+	Long: `Display the manual page for sinkzone, or for one of its commands (e.g. 'sinkzone man focus').
 
-		// Check if the file exists
-		if _, err := os.Stat(manPagePath); os.IsNotExist(err) {
-			// Try to find it relative to the executable
-			execPath, err := os.Executable()
-			if err == nil {
-				execDir := filepath.Dir(execPath)
-				manPagePath = filepath.Join(execDir, "docs", "sinkzone.1")
-			}
+The pages are generated from the command help and built into the binary, so they always match the installed version. They are shown with the system's man command when there is one, and as plain text otherwise.`,
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		parent := rootCmd
+		if found, _, err := rootCmd.Find(args); err == nil {
+			parent = found
 		}
-
-		// First try to use the system's man command
-		// Note: manPagePath is a hardcoded path, so this is safe from command injection
-		if manPath, err := exec.LookPath("man"); err == nil {
-			// #nosec G204 -- manPagePath is a hardcoded path, safe from command injection
-			cmd := exec.Command(manPath, manPagePath)
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			if err := cmd.Run(); err == nil {
-				return nil
+		var names []string
+		for _, sub := range parent.Commands() {
+			if sub.IsAvailableCommand() {
+				names = append(names, sub.Name())
 			}
 		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _, err := rootCmd.Find(args)
+		if err != nil || (len(args) > 0 && target == rootCmd) {
+			return fmt.Errorf("no manual page for: %s", strings.Join(args, " "))
+		}
+		cmd.SilenceUsage = true
 
-		// Fallback: read and display formatted content
-		content, err := os.ReadFile(manPagePath)
+		// Page names follow the command path, e.g. sinkzone-focus.1
+		name := strings.ReplaceAll(target.CommandPath(), " ", "-") + ".1"
+		content, err := fs.ReadFile(manPages, "manpages/"+name)
 		if err != nil {
-			return fmt.Errorf("failed to read man page: %w\nMan page not found at: %s", err, manPagePath)
+			return fmt.Errorf("no manual page for: %s", target.CommandPath())
 		}
 
-		// Display formatted content
+		if err := showWithMan(name, content); err == nil {
+			return nil
+		}
 		fmt.Print(formatManPage(string(content)))
 		return nil
 	},
 }
 
-// formatManPage converts troff markup to readable text
-func formatManPage(content string) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+// showWithMan displays a page with the system's man command, via a temporary file
+func showWithMan(name string, content []byte) error {
+	if runtime.GOOS == "windows" {
+		return fmt.Errorf("man is not available on Windows")
+	}
+	manPath, err := exec.LookPath("man")
+	if err != nil {
+		return fmt.Errorf("man not found: %w", err)
+	}
 
-		// Skip empty lines
-		if line == "" {
-			result = append(result, "")
-			continue
+	dir, err := os.MkdirTemp("", "sinkzone-man-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer func() {
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Printf("Warning: failed to remove %s: %v\n", dir, err)
 		}
+	}()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("failed to write man page: %w", err)
+	}
+
+	// A path containing a slash makes man read the file instead of searching its sections
+	// #nosec G204 -- path is the temporary file written above
+	cmd := exec.Command(manPath, path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
 
-		// Handle different troff commands
+// formatManPage converts the troff markup of a generated page to readable text
+func formatManPage(content string) string {
+	replacer := strings.NewReplacer(`\fB`, "", `\fI`, "", `\fR`, "", `\fP`, "", `\-`, "-", `\(bu`, "*", `\\`, `\`, `\&`, "")
+
+	var result []string
+	example := false
+	for _, line := range strings.Split(content, "\n") {
 		switch {
+		case strings.HasPrefix(line, ".EX"):
+			result = append(result, "")
+			example = true
+		case strings.HasPrefix(line, ".EE"):
+			example = false
 		case strings.HasPrefix(line, ".TH"):
-			// Title header - extract name and description
-			parts := strings.Fields(line)
-			if len(parts) >= 3 {
-				name := strings.Trim(parts[1], "\"")
-				desc := strings.Trim(parts[2], "\"")
-				result = append(result, "")
-				result = append(result, fmt.Sprintf("%s(1) - %s", name, desc))
-				result = append(result, "")
+			// Title header: .TH "SINKZONE" "1" "date" "source" "manual"
+			fields := strings.Fields(strings.ReplaceAll(line, `"`, ""))
+			if len(fields) >= 3 {
+				result = append(result, fmt.Sprintf("%s(%s)", strings.ToLower(fields[1]), fields[2]), "")
 			}
-
 		case strings.HasPrefix(line, ".SH"):
-			// Section header
-			section := strings.TrimSpace(strings.TrimPrefix(line, ".SH"))
-			result = append(result, "")
-			result = append(result, strings.ToUpper(section))
-			result = append(result, strings.Repeat("=", len(section)))
-			result = append(result, "")
-
-		case strings.HasPrefix(line, ".TP"):
-			// Tagged paragraph - skip, will handle in next line
-			continue
-
-		case strings.HasPrefix(line, ".B"):
-			// Bold text
-			bold := strings.TrimSpace(strings.TrimPrefix(line, ".B"))
-			bold = strings.Trim(bold, "\"")
-			result = append(result, fmt.Sprintf("  %s", bold))
-
-		case strings.HasPrefix(line, ".IP"):
-			// Indented paragraph
-			parts := strings.Fields(line)
-			if len(parts) >= 2 {
-				indent := parts[1]
-				text := strings.Join(parts[2:], " ")
-				result = append(result, fmt.Sprintf("  %s. %s", indent, text))
-			}
-
-		case strings.HasPrefix(line, ".br"):
-			// Line break
+			section := strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, ".SH")), `"`)
+			result = append(result, "", section)
+		case strings.HasPrefix(line, ".PP"), strings.HasPrefix(line, ".TP"), strings.HasPrefix(line, ".br"):
 			result = append(result, "")
-
-		case strings.HasPrefix(line, ".BR"):
-			// Bold and roman text
-			text := strings.TrimSpace(strings.TrimPrefix(line, ".BR"))
-			text = strings.Trim(text, "\"")
-			result = append(result, fmt.Sprintf("  %s", text))
-
-		case strings.HasPrefix(line, "\\fB"):
-			// Bold text in line
-			text := strings.TrimPrefix(line, "\\fB")
-			text = strings.TrimSuffix(text, "\\fR")
-			result = append(result, fmt.Sprintf("  %s", text))
-
-		case strings.HasPrefix(line, "\\fI"):
-			// Italic text in line
-			text := strings.TrimPrefix(line, "\\fI")
-			text = strings.TrimSuffix(text, "\\fR")
-			result = append(result, fmt.Sprintf("  %s", text))
-
 		case strings.HasPrefix(line, "."):
-			// Other troff commands - skip
+			// Other requests (.nh, .RS, .RE, ...) only affect layout
+			continue
+		case line == "":
+			// Paragraph breaks are marked with .PP instead
 			continue
-
 		default:
-			// Regular text
-			if line != "" {
-				result = append(result, line)
+			text := strings.ReplaceAll(replacer.Replace(line), "\t", "    ")
+			if example {
+				text = "  " + text
 			}
+			result = append(result, "    "+text)
 		}
 	}
-
-	return strings.Join(result, "\n")
+	return strings.TrimLeft(strings.Join(result, "\n"), "\n") + "\n"
 }
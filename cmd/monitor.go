@@ -1,14 +1,31 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/berbyte/sinkzone/internal/api"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
 )
 
-var apiURL string
+var (
+	apiURL        string
+	monitorFollow bool
+)
+
+// Colours of the query status in 'monitor --follow' (dropped when stdout is not a terminal)
+var (
+	allowStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	blockStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	warnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+)
 
 var monitorCmd = &cobra.Command{
 	Use:   "monitor",
@@ -17,6 +34,8 @@ var monitorCmd = &cobra.Command{
 
 Use this to observe which domains your system is accessing in real time. It's especially useful when configuring your allowlist — you'll see which domains need to be permitted for tools or websites you want to use during focus sessions.
 
+Without --follow it shows the last 20 queries, with repeats of a domain within recent_queries.aggregate (default 60s) counted on one line, e.g. github.com ×42. With --follow it keeps printing each query as it happens, like 'tail -f', until interrupted; with --output json it prints one JSON object per line.
+
 Make sure the resolver is running before using this command.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Create API client
@@ -24,36 +43,51 @@ Make sure the resolver is running before using this command.`,
 
 		// Try to connect to API
 		if err := client.HealthCheck(); err != nil {
-			return config.AdminError(err, "failed to connect to resolver API")
+			return resolverDownError(err)
+		}
+		if monitorFollow {
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			return followQueries(ctx, client)
 		}
-		fmt.Printf("Connected successfully!\n")
 
 		// Get recent queries
-		queries, err := client.GetQueries()
+		queries, err := client.GetQueries(0)
 		if err != nil {
 			return fmt.Errorf("failed to get queries: %w", err)
 		}
 
-		if len(queries) == 0 {
-			fmt.Println("No DNS queries recorded yet.")
-			fmt.Println("Try making some web requests to see DNS activity.")
-			return nil
-		}
-
 		// Show last 20 queries (or all if less than 20)
 		start := 0
 		if len(queries) > 20 {
 			start = len(queries) - 20
 		}
 
+		if jsonOutput() {
+			recent := queries[start:]
+			if recent == nil {
+				recent = []api.DNSQuery{}
+			}
+			return printJSON(recent)
+		}
+		fmt.Printf("Connected successfully!\n")
+
+		if len(queries) == 0 {
+			fmt.Println("No DNS queries recorded yet.")
+			fmt.Println("Try making some web requests to see DNS activity.")
+			return nil
+		}
+
 		fmt.Printf("Last %d DNS requests:\n\n", len(queries[start:]))
-		fmt.Printf("%-40s %-10s %-20s %s\n", "Domain", "Status", "Time", "Blocked")
+		fmt.Printf("%-40s %-10s %-20s %-8s %s\n", "Domain", "Status", "Time", "Blocked", "Latency")
 		fmt.Println(string(make([]byte, 80)))
 
 		for _, query := range queries[start:] {
 			status := "ALLOWED"
 			if query.Blocked {
 				status = "BLOCKED"
+			} else if query.WouldBlock {
+				status = "WARNED"
 			}
 
 			timeStr := query.Timestamp.Format("15:04:05")
@@ -62,20 +96,79 @@ Make sure the resolver is running before using this command.`,
 				blockedStr = "Yes"
 			}
 
-			// Truncate domain if too long
-			domain := query.Domain
-			if len(domain) > 38 {
-				domain = domain[:35] + "..."
+			// Truncate domain if too long, keeping the repeats counted in the entry
+			domain, count := query.Domain, ""
+			if query.Count > 1 {
+				count = fmt.Sprintf(" ×%d", query.Count)
+			}
+			if len(domain)+len(count) > 38 {
+				domain = domain[:max(35-len(count), 0)] + "..."
 			}
+			domain += count
 
-			fmt.Printf("%-40s %-10s %-20s %s\n", domain, status, timeStr, blockedStr)
+			fmt.Printf("%-40s %-10s %-20s %-8s %s\n", domain, status, timeStr, blockedStr, formatLatency(query.LatencyMS))
 		}
 
-		fmt.Printf("\nTotal queries: %d\n", len(queries))
+		total := 0
+		for _, query := range queries {
+			total += max(query.Count, 1)
+		}
+		fmt.Printf("\nTotal queries: %d\n", total)
 		return nil
 	},
 }
 
 func init() {
-	monitorCmd.Flags().StringVarP(&apiURL, "api-url", "u", "http://127.0.0.1:8080", "URL of the resolver API")
+	monitorCmd.Flags().StringVarP(&apiURL, "api-url", "u", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	monitorCmd.Flags().BoolVarP(&monitorFollow, "follow", "f", false, "Keep printing queries as they happen")
+}
+
+// followQueries prints each query from the stream until ctx is cancelled (by an interrupt)
+// or the stream breaks
+func followQueries(ctx context.Context, client *api.Client) error {
+	encoder := json.NewEncoder(os.Stdout)
+	if !jsonOutput() {
+		fmt.Printf("%-8s  %-6s  %-5s  %8s  %-40s  %s\n", "Time", "Status", "Type", "Latency", "Domain", "Client")
+	}
+
+	err := client.StreamQueries(ctx, func(query api.DNSQuery) {
+		if jsonOutput() {
+			if err := encoder.Encode(query); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to encode query: %v\n", err)
+			}
+			return
+		}
+		fmt.Println(formatStreamedQuery(query))
+	}, func(count int64) {
+		fmt.Fprintf(os.Stderr, "... %d queries skipped (monitor fell behind)\n", count)
+	})
+	if errors.Is(err, context.Canceled) {
+		return nil
+	}
+	return err
+}
+
+// formatStreamedQuery renders one line of 'monitor --follow', colouring the status
+func formatStreamedQuery(query api.DNSQuery) string {
+	// Padded before styling, since escape codes would throw off the width
+	status := allowStyle.Render("ALLOW ")
+	if query.Blocked {
+		status = blockStyle.Render("BLOCK ")
+	} else if query.WouldBlock {
+		status = warnStyle.Render("WARN  ")
+	}
+
+	line := fmt.Sprintf("%-8s  %s  %-5s  %8s  %-40s  %s", query.Timestamp.Format("15:04:05"), status, query.QueryType, formatLatency(query.LatencyMS), query.Domain, query.ClientLabel())
+	if query.Reason != "" {
+		line += "  (" + query.Reason + ")"
+	}
+	return line
+}
+
+// formatLatency renders the time taken to answer a query, or "-" when it wasn't recorded
+func formatLatency(ms float64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1fms", ms)
 }
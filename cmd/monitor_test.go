@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// streamServer serves GET /api/queries/stream with the given events, then keeps the
+// stream open when hold is set or closes it
+func streamServer(t *testing.T, status int, events []string, hold bool) *api.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status != http.StatusOK {
+			http.Error(w, "stream unavailable", status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+		w.(http.Flusher).Flush()
+		if hold {
+			<-r.Context().Done()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return api.NewClient(server.URL)
+}
+
+func queryEvent(domain string, blocked bool) string {
+	data, _ := json.Marshal(api.DNSQuery{Domain: domain, Blocked: blocked, QueryType: "A", Timestamp: time.Now()})
+	return fmt.Sprintf("event: query\ndata: %s\n\n", data)
+}
+
+func TestFollowQueries(t *testing.T) {
+	format := outputFormat
+	t.Cleanup(func() { outputFormat = format })
+
+	events := []string{
+		queryEvent("github.com", false),
+		": keep-alive\n\n",
+		"event: dropped\ndata: {\"count\": 3}\n\n",
+		queryEvent("example.com", true),
+	}
+	tests := []struct {
+		name   string
+		format string
+		status int
+		hold   bool
+		lines  [][]string // Words each printed line has, in order
+		fails  bool
+	}{
+		{"text until the stream closes", outputText, http.StatusOK, false, [][]string{{"Domain"}, {"ALLOW", "github.com"}, {"BLOCK", "example.com"}}, true},
+		{"JSON until interrupted", outputJSON, http.StatusOK, true, [][]string{{`"domain":"github.com"`}, {`"domain":"example.com"`, `"blocked":true`}}, false},
+		{"API error", outputText, http.StatusInternalServerError, false, [][]string{{"Domain"}}, true},
+	}
+	for _, test := range tests {
+		outputFormat = test.format
+		client := streamServer(t, test.status, events, test.hold)
+		ctx, cancel := context.WithCancel(context.Background())
+		interrupt := time.AfterFunc(300*time.Millisecond, cancel)
+		output, err := captureStdout(t, func() error { return followQueries(ctx, client) })
+		interrupt.Stop()
+		cancel()
+		if (err != nil) != test.fails {
+			t.Errorf("%s: expected failure %v, got %v", test.name, test.fails, err)
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		if len(lines) != len(test.lines) {
+			t.Errorf("%s: expected %d lines, got %q", test.name, len(test.lines), output)
+			continue
+		}
+		for i, words := range test.lines {
+			for _, word := range words {
+				if !strings.Contains(lines[i], word) {
+					t.Errorf("%s: expected line %d to contain %q, got %q", test.name, i+1, word, lines[i])
+				}
+			}
+		}
+		if test.format == outputJSON && bytes.Contains(output, []byte("dropped")) {
+			t.Errorf("%s: expected the dropped notice to stay off stdout, got %q", test.name, output)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Values accepted by --output
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+var (
+	outputFormat string
+	outputAsJSON bool
+)
+
+// validateOutputFormat checks --output, applying --json as a shorthand for '--output json'
+func validateOutputFormat() error {
+	if outputAsJSON {
+		outputFormat = outputJSON
+	}
+	switch outputFormat {
+	case outputText, outputJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown output format: %s. Use 'text' or 'json'", outputFormat)
+	}
+}
+
+// jsonOutput reports whether machine-readable output was requested
+func jsonOutput() bool {
+	return outputFormat == outputJSON
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+	"github.com/miekg/dns"
+)
+
+// captureStdout runs fn and returns what it wrote to stdout
+func captureStdout(t *testing.T, fn func() error) ([]byte, error) {
+	t.Helper()
+	return captureFile(t, &os.Stdout, fn)
+}
+
+// captureFile runs fn with *file replaced by a pipe and returns what it wrote to it
+func captureFile(t *testing.T, file **os.File, fn func() error) ([]byte, error) {
+	t.Helper()
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *file
+	*file = writer
+	done := make(chan []byte)
+	go func() {
+		data, _ := io.ReadAll(reader)
+		done <- data
+	}()
+
+	err = fn()
+	*file = original
+	_ = writer.Close()
+	return <-done, err
+}
+
+// decodeOutput decodes output that must hold exactly one JSON value into v
+func decodeOutput(t *testing.T, output []byte, v interface{}) {
+	t.Helper()
+
+	decoder := json.NewDecoder(bytes.NewReader(output))
+	if err := decoder.Decode(v); err != nil {
+		t.Fatalf("expected JSON output, got %q: %v", output, err)
+	}
+	if decoder.More() {
+		t.Fatalf("expected nothing after the JSON value, got %q", output)
+	}
+}
+
+// useJSONOutput selects --output json until the test ends
+func useJSONOutput(t *testing.T) {
+	t.Helper()
+
+	format, asJSON := outputFormat, outputAsJSON
+	t.Cleanup(func() { outputFormat, outputAsJSON = format, asJSON })
+	outputFormat, outputAsJSON = outputText, true
+	if err := validateOutputFormat(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateOutputFormat(t *testing.T) {
+	format, asJSON := outputFormat, outputAsJSON
+	t.Cleanup(func() { outputFormat, outputAsJSON = format, asJSON })
+
+	tests := []struct {
+		format string
+		asJSON bool
+		json   bool
+		fails  bool
+	}{
+		{outputText, false, false, false},
+		{outputJSON, false, true, false},
+		{outputText, true, true, false},
+		{"yaml", false, false, true},
+		{"yaml", true, true, false},
+	}
+	for _, test := range tests {
+		outputFormat, outputAsJSON = test.format, test.asJSON
+		err := validateOutputFormat()
+		if (err != nil) != test.fails || (err == nil && jsonOutput() != test.json) {
+			t.Errorf("%q with --json %v: expected JSON %v (failure %v), got %v (%v)", test.format, test.asJSON, test.json, test.fails, jsonOutput(), err)
+		}
+	}
+}
+
+func TestStatusJSON(t *testing.T) {
+	useJSONOutput(t)
+	r := sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com"}})
+	r.Focus(t, time.Hour)
+	r.Blocked(t, "example.com")
+	url := statusAPIURL
+	t.Cleanup(func() { statusAPIURL = url })
+
+	tests := []struct {
+		apiURL     string
+		statusType string
+		resolver   bool
+		blocked    int
+	}{
+		{r.APIURL, "", true, 1},
+		{r.APIURL, "focus", false, 1},
+		{"http://127.0.0.1:1", "focus", false, 0},
+	}
+	for _, test := range tests {
+		statusAPIURL = test.apiURL
+		output, err := captureStdout(t, func() error { return printStatusJSON(test.statusType) })
+		if err != nil {
+			t.Fatalf("%s %q: %v", test.apiURL, test.statusType, err)
+		}
+		var report statusReport
+		decodeOutput(t, output, &report)
+		if (report.Resolver != nil) != test.resolver {
+			t.Errorf("%s %q: expected the resolver reported: %v, got %+v", test.apiURL, test.statusType, test.resolver, report.Resolver)
+		}
+		if report.Focus == nil || !report.Focus.Enabled || report.Focus.Blocked != test.blocked {
+			t.Errorf("%s %q: expected active focus mode with %d blocked, got %+v", test.apiURL, test.statusType, test.blocked, report.Focus)
+		}
+	}
+
+	if _, err := captureStdout(t, func() error { return printStatusJSON("bogus") }); err == nil {
+		t.Error("expected an unknown status type to fail")
+	}
+}
+
+func TestMonitorJSON(t *testing.T) {
+	useJSONOutput(t)
+	r := sinktest.Start(t, sinktest.Options{})
+	url := apiURL
+	t.Cleanup(func() { apiURL = url })
+	apiURL = r.APIURL
+
+	output, err := captureStdout(t, func() error { return monitorCmd.RunE(monitorCmd, nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []api.DNSQuery
+	decodeOutput(t, output, &queries)
+	if queries == nil || len(queries) != 0 {
+		t.Errorf("expected an empty list before any query, got %q", output)
+	}
+
+	for i := range 25 {
+		r.Query(t, string(rune('a'+i))+".example.com", dns.TypeA)
+	}
+	output, err = captureStdout(t, func() error { return monitorCmd.RunE(monitorCmd, nil) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodeOutput(t, output, &queries)
+	if len(queries) != 20 || queries[19].Domain != "y.example.com" {
+		t.Errorf("expected the last 20 queries, got %d ending with %+v", len(queries), queries[len(queries)-1])
+	}
+}
+
+func TestAllowlistJSON(t *testing.T) {
+	useJSONOutput(t)
+	sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com", "*.golang.org"}})
+
+	output, err := captureStdout(t, listAllowlist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report allowlistReport
+	decodeOutput(t, output, &report)
+	if !slices.Equal(report.Domains, []string{"github.com", "*.golang.org"}) || report.Path == "" {
+		t.Errorf("expected the allowlist and its path, got %+v", report)
+	}
+
+	output, err = captureStdout(t, listBreakDomains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decodeOutput(t, output, &report)
+	if report.Domains == nil || len(report.Domains) != 0 {
+		t.Errorf("expected an empty list of break domains, got %q", output)
+	}
+}
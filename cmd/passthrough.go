@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/spf13/cobra"
+)
+
+var (
+	passthroughAPIURL string
+	passthroughFor    string
+	passthroughPIN    string
+)
+
+var passthroughCmd = &cobra.Command{
+	Use:   "passthrough [on/off/status]",
+	Short: "Let a hotel or café network's login page through for a few minutes",
+	Long: `Opens a passthrough window, so the login page (captive portal) of a hotel, café, or airport network can load without stopping sinkzone.
+
+  sinkzone passthrough                 Let everything through for captive_portal.passthrough (default 5m)
+  sinkzone passthrough --for 10m       Let everything through for 10 minutes (at most 30m)
+  sinkzone passthrough status          Show whether a portal is suspected and a window is open
+  sinkzone passthrough off             Block again right away
+
+Until the network's login is done, such networks usually answer DNS queries only through their own nameserver, or answer every name with the address of the login page, so the upstreams fail and a blocked connectivity check (e.g. captive.apple.com) keeps the login page from opening. During the window nothing is blocked and every query goes to the network's own nameserver, the default gateway; the cache is flushed when the window opens and closes, so the login page's answers aren't kept. Queries blocked only because of the window show up as would-be blocked.
+
+The resolver suspects a portal when a connectivity check fails or most queries within a minute fail, and says so in its log, sinkzone status, and the TUI. With captive_portal.mode: auto it opens a window by itself, at most once every 30 minutes and not during a PIN-locked focus session; with off it stops looking. Opening a window needs the focus PIN when one is set.`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"on", "off", "status"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := "on"
+		if len(args) > 0 {
+			command = args[0]
+		}
+		if command != "on" && command != "off" && command != "status" {
+			return fmt.Errorf("unknown command: %s. Use 'on', 'off', or 'status'", command)
+		}
+		if passthroughFor != "" {
+			if d, err := time.ParseDuration(passthroughFor); err != nil || d <= 0 {
+				return fmt.Errorf("invalid passthrough duration: %s", passthroughFor)
+			}
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(passthroughAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+		switch command {
+		case "off":
+			return endPassthrough(client)
+		case "status":
+			return showPortalState(client)
+		}
+		return startPassthrough(client)
+	},
+}
+
+func init() {
+	passthroughCmd.Flags().StringVar(&passthroughAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	passthroughCmd.Flags().StringVar(&passthroughFor, "for", "", "How long the window lasts (default: captive_portal.passthrough, 5m)")
+	passthroughCmd.Flags().StringVar(&passthroughPIN, "pin", "", "Focus PIN, required when one is set (or set SINKZONE_PIN)")
+}
+
+func startPassthrough(client *api.Client) error {
+	pin := passthroughPIN
+	if pin == "" {
+		pin = os.Getenv("SINKZONE_PIN")
+	}
+	state, err := client.StartPassthrough(api.PassthroughRequest{Duration: passthroughFor, PIN: pin})
+	if err != nil {
+		return fmt.Errorf("failed to open the passthrough window: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(state)
+	}
+	fmt.Printf("Nothing is blocked until %s, so the network's login page can load.\n", state.PassthroughUntil.Local().Format("15:04:05"))
+	if state.Nameserver != "" {
+		fmt.Printf("Queries go to the network's nameserver %s meanwhile.\n", state.Nameserver)
+	} else {
+		fmt.Println("No default gateway was found; queries go to the configured nameservers meanwhile.")
+	}
+	fmt.Println("Run 'sinkzone passthrough off' once you are logged in.")
+	return nil
+}
+
+func endPassthrough(client *api.Client) error {
+	state, err := client.EndPassthrough()
+	if err != nil {
+		return fmt.Errorf("failed to close the passthrough window: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(state)
+	}
+	fmt.Println("Passthrough window closed: blocking again.")
+	return nil
+}
+
+func showPortalState(client *api.Client) error {
+	health, err := client.GetHealth()
+	if err != nil {
+		return fmt.Errorf("failed to get the resolver's health: %w", err)
+	}
+	if jsonOutput() {
+		return printJSON(health.Portal)
+	}
+	if health.Portal == nil {
+		fmt.Println("This resolver doesn't detect captive portals.")
+		return nil
+	}
+	printPortalState(health.Portal)
+	return nil
+}
+
+// printPortalState describes a suspected captive portal and the passthrough window
+func printPortalState(portal *api.PortalState) {
+	switch {
+	case portal.Passthrough() && portal.Nameserver != "":
+		fmt.Println(i18n.T("Passthrough: nothing is blocked until %s, queries go to %s", portal.PassthroughUntil.Local().Format("15:04:05"), portal.Nameserver))
+	case portal.Passthrough():
+		fmt.Println(i18n.T("Passthrough: nothing is blocked until %s", portal.PassthroughUntil.Local().Format("15:04:05")))
+	case portal.Suspected:
+		fmt.Println(i18n.T("Warning: captive portal suspected (%s). Run 'sinkzone passthrough' to let the network's login page through.", portal.Reason))
+	case portal.Mode == config.CaptivePortalOff:
+		fmt.Println(i18n.T("Captive portal detection: off"))
+	default:
+		fmt.Println(i18n.T("Captive portal: none suspected"))
+	}
+}
@@ -0,0 +1,324 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	profileFrom                string
+	profileExtends             string
+	profileDuration            string
+	profileAllow               []string
+	profileUseDefaultAllowlist bool
+)
+
+// profileEntry is printed by 'profile list --output json' and 'profile show --output json'
+type profileEntry struct {
+	Name                string   `json:"name"`
+	Active              bool     `json:"active"`
+	Extends             string   `json:"extends,omitempty"`
+	Duration            string   `json:"duration,omitempty"`
+	Allowlist           []string `json:"allowlist"`
+	UseDefaultAllowlist bool     `json:"use_default_allowlist"`
+}
+
+var profileCmd = &cobra.Command{
+	Use:   "profile [list/create/use/delete/show] [name]",
+	Short: "Manage focus profiles",
+	Long: `List, create, choose, delete, or show focus profiles — named presets with their own allowlist and default session length, stored under profiles in sinkzone.yaml.
+
+  sinkzone profile list                              Show every profile
+  sinkzone profile create deep-work --duration 90m   Create a profile
+  sinkzone profile create writing --from deep-work   Copy an existing profile
+  sinkzone profile create review --extends deep-work Build on an existing profile
+  sinkzone profile use deep-work                     Use it when no --profile is given
+  sinkzone profile use default                       Go back to the default allowlist
+  sinkzone profile show deep-work                    Show a profile's settings
+  sinkzone profile delete writing                    Delete a profile
+
+Create a profile with --allow to give it allowlist entries, and --use-default-allowlist to allow the default allowlist's domains as well. Options given with --from override the copied ones; --allow adds to the copied allowlist.
+
+A profile created with --extends (extends: in sinkzone.yaml) builds on another profile: it allows the base profile's domains as well as its own, and uses the base's duration and use_default_allowlist unless it sets them. Changes to the base apply to every profile that extends it. 'profile show' lists the combined allowlist.
+
+The active profile is used by 'sinkzone focus' and the TUI whenever a session doesn't choose one. Restart the resolver so it knows about new or deleted profiles.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeProfileArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := args[0]
+
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		if command != "list" && name == "" {
+			return fmt.Errorf("profile name required for '%s' command", command)
+		}
+		cmd.SilenceUsage = true
+
+		switch command {
+		case "list":
+			return listProfiles()
+		case "create":
+			return createProfile(cmd, name)
+		case "use":
+			return useProfile(name)
+		case "delete":
+			return deleteProfile(name)
+		case "show":
+			return showProfile(name)
+		default:
+			cmd.SilenceUsage = false
+			return fmt.Errorf("unknown command: %s. Use 'list', 'create', 'use', 'delete', or 'show'", command)
+		}
+	},
+}
+
+func init() {
+	profileCmd.Flags().StringVar(&profileFrom, "from", "", "Copy an existing profile (used with 'create')")
+	profileCmd.Flags().StringVar(&profileDuration, "duration", "", "Default session length, e.g. 90m (used with 'create')")
+	profileCmd.Flags().StringSliceVar(&profileAllow, "allow", nil, "Domains or wildcards the profile allows (used with 'create')")
+	profileCmd.Flags().BoolVar(&profileUseDefaultAllowlist, "use-default-allowlist", false, "Also allow the default allowlist (used with 'create')")
+	profileCmd.Flags().StringVar(&profileExtends, "extends", "", "Build on an existing profile (used with 'create')")
+	for _, flag := range []string{"from", "extends"} {
+		_ = profileCmd.RegisterFlagCompletionFunc(flag, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			return profileNames(), cobra.ShellCompDirectiveNoFileComp
+		})
+	}
+}
+
+// completeProfileArgs completes the subcommand, then existing profile names
+func completeProfileArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return []string{"list", "create", "use", "delete", "show"}, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && args[0] == "use":
+		return append(profileNames(), "default"), cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && (args[0] == "delete" || args[0] == "show"):
+		return profileNames(), cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// profileNames returns the configured profiles for completion
+func profileNames() []string {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil
+	}
+	return cfg.ProfileNames()
+}
+
+// newProfileEntry converts a profile for printing
+func newProfileEntry(cfg *config.Config, name string, profile *config.Profile) profileEntry {
+	domains := profile.Allowlist
+	if domains == nil {
+		domains = []string{}
+	}
+	return profileEntry{
+		Name:                name,
+		Active:              cfg.ActiveProfile == name,
+		Extends:             profile.Extends,
+		Duration:            profile.Duration,
+		Allowlist:           domains,
+		UseDefaultAllowlist: profile.UseDefaultAllowlist,
+	}
+}
+
+func listProfiles() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	names := cfg.ProfileNames()
+	if jsonOutput() {
+		entries := make([]profileEntry, 0, len(names))
+		for _, name := range names {
+			profile, _ := cfg.GetProfile(name)
+			entries = append(entries, newProfileEntry(cfg, name, profile))
+		}
+		return printJSON(entries)
+	}
+
+	if len(names) == 0 {
+		fmt.Println("No profiles configured. Create one with 'sinkzone profile create <name>'.")
+		return nil
+	}
+
+	fmt.Printf("Profiles (%d):\n", len(names))
+	for _, name := range names {
+		profile, _ := cfg.GetProfile(name)
+		marker := " "
+		if cfg.ActiveProfile == name {
+			marker = "*"
+		}
+		duration := profile.Duration
+		if duration == "" {
+			duration = "no default length"
+		}
+		fmt.Printf("%s %s (", marker, name)
+		if profile.Extends != "" {
+			fmt.Printf("extends %s, ", profile.Extends)
+		}
+		fmt.Printf("%s, %d allowlist entries", duration, len(profile.Allowlist))
+		if profile.UseDefaultAllowlist {
+			fmt.Print(" plus the default allowlist")
+		}
+		fmt.Println(")")
+	}
+	if cfg.ActiveProfile == "" {
+		fmt.Println("\nNo active profile: sessions use the default allowlist unless --profile is given.")
+	}
+	return nil
+}
+
+func createProfile(cmd *cobra.Command, name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	profile, err := cfg.CreateProfile(name, profileFrom)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Flags().Changed("extends") {
+		if profileExtends != "" {
+			if _, err := cfg.GetProfile(profileExtends); err != nil {
+				return err
+			}
+		}
+		profile.Extends = profileExtends
+	}
+	if cmd.Flags().Changed("duration") {
+		if profileDuration != "" {
+			if duration, err := time.ParseDuration(profileDuration); err != nil || duration <= 0 {
+				return fmt.Errorf("invalid duration: %s", profileDuration)
+			}
+		}
+		profile.Duration = profileDuration
+	}
+	for _, domain := range profileAllow {
+		if err := allowlist.ValidatePattern(domain); err != nil {
+			return fmt.Errorf("invalid domain '%s': %w", domain, err)
+		}
+		profile.Allowlist = append(profile.Allowlist, domain)
+	}
+	if cmd.Flags().Changed("use-default-allowlist") {
+		profile.UseDefaultAllowlist = profileUseDefaultAllowlist
+	}
+	cfg.Profiles[name] = *profile
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	switch {
+	case profileFrom != "":
+		fmt.Printf("Profile '%s' created from '%s'.\n", name, profileFrom)
+	case profile.Extends != "":
+		fmt.Printf("Profile '%s' created, extending '%s'.\n", name, profile.Extends)
+	default:
+		fmt.Printf("Profile '%s' created.\n", name)
+	}
+	fmt.Println("Note: Restart the resolver to use the new profile.")
+	return nil
+}
+
+func useProfile(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if name == "default" || name == "none" {
+		name = ""
+	} else if _, err := cfg.GetProfile(name); err != nil {
+		return err
+	}
+	cfg.ActiveProfile = name
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	if name == "" {
+		fmt.Println("Focus sessions now use the default allowlist unless --profile is given.")
+	} else {
+		fmt.Printf("Focus sessions now use profile '%s' unless --profile is given.\n", name)
+	}
+	return nil
+}
+
+func deleteProfile(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	wasActive := cfg.ActiveProfile == name
+	if err := cfg.DeleteProfile(name); err != nil {
+		return err
+	}
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("Profile '%s' deleted.\n", name)
+	if wasActive {
+		fmt.Println("It was the active profile; focus sessions now use the default allowlist.")
+	}
+	return nil
+}
+
+func showProfile(name string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	profile, err := cfg.GetProfile(name)
+	if err != nil {
+		return err
+	}
+	entry := newProfileEntry(cfg, name, profile)
+	if jsonOutput() {
+		return printJSON(entry)
+	}
+
+	fmt.Printf("Profile: %s", name)
+	if entry.Active {
+		fmt.Print(" (active)")
+	}
+	fmt.Println()
+	if entry.Extends != "" {
+		fmt.Printf("Extends: %s\n", entry.Extends)
+	}
+	if entry.Duration != "" {
+		fmt.Printf("Duration: %s\n", entry.Duration)
+	} else {
+		fmt.Println("Duration: not set (sessions last 1h unless --duration is given)")
+	}
+	if entry.UseDefaultAllowlist {
+		fmt.Println("Default allowlist: included")
+	} else {
+		fmt.Println("Default allowlist: not included")
+	}
+	if len(entry.Allowlist) == 0 {
+		fmt.Println("Allowlist: empty")
+		return nil
+	}
+	fmt.Printf("Allowlist (%d domains):\n", len(entry.Allowlist))
+	for i, domain := range entry.Allowlist {
+		fmt.Printf("  %d. %s\n", i+1, domain)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	queriesAPIURL     string
+	queriesSince      time.Duration
+	queriesDomain     string
+	queriesClient     string
+	queriesLimit      int
+	queriesCSV        bool
+	queriesOlderThan  string
+	queriesMaxRecords int
+)
+
+var queriesCmd = &cobra.Command{
+	Use:     "queries [prune]",
+	Aliases: []string{"history"},
+	Short:   "Search or prune the query log",
+	Long: `Shows queries from the resolver's query log, oldest first. Unlike 'monitor', which shows only the latest query per domain, the log keeps every query, across restarts.
+
+Narrow the results with --since, --domain, and --client; --limit caps how many of the newest matches are shown. Use --output json or --csv to export them.
+
+The log is kept in queries.db in the sinkzone data directory (~/.sinkzone by default). The resolver deletes queries older than query_retention (default 7d) and the oldest ones beyond max_query_records every hour. 'sinkzone queries prune' does it now, or with other limits given by --older-than and --max-records; it works whether or not the resolver is running. Turn the log off with 'sinkzone config set query_log.enabled false'. With query_log.storage set to memory, the resolver keeps the log in memory instead, and it is lost when the resolver stops.
+
+Examples:
+  sinkzone queries --since 1h
+  sinkzone queries --domain github.com --limit 20
+  sinkzone queries --client 192.168.1.20 --since 24h --csv > queries.csv
+  sinkzone queries prune
+  sinkzone queries prune --older-than 1d`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"prune"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 1 {
+			if args[0] != "prune" {
+				return fmt.Errorf("unknown command: %s. Use 'prune', or no argument to search", args[0])
+			}
+			if queriesMaxRecords < 0 {
+				return fmt.Errorf("invalid --max-records: must not be negative")
+			}
+			cmd.SilenceUsage = true
+			return pruneQueries()
+		}
+		if queriesSince < 0 {
+			return fmt.Errorf("invalid --since: must be a positive duration")
+		}
+		if queriesLimit <= 0 {
+			return fmt.Errorf("invalid --limit: must be positive")
+		}
+		cmd.SilenceUsage = true
+		return showQueries()
+	},
+}
+
+func init() {
+	queriesCmd.Flags().StringVar(&queriesAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	queriesCmd.Flags().DurationVar(&queriesSince, "since", 0, "Only show queries from this long ago (e.g. 1h)")
+	queriesCmd.Flags().StringVar(&queriesDomain, "domain", "", "Only show queries for this domain")
+	queriesCmd.Flags().StringVar(&queriesClient, "client", "", "Only show queries from this client address")
+	queriesCmd.Flags().IntVarP(&queriesLimit, "limit", "n", 100, "Show at most this many of the newest matching queries")
+	queriesCmd.Flags().BoolVar(&queriesCSV, "csv", false, "Print CSV instead of a table")
+	queriesCmd.Flags().StringVar(&queriesOlderThan, "older-than", "", "Delete queries older than this, e.g. 7d or 12h (used with 'prune'; default query_retention)")
+	queriesCmd.Flags().IntVar(&queriesMaxRecords, "max-records", 0, "Keep at most this many of the newest queries (used with 'prune'; default max_query_records)")
+}
+
+func showQueries() error {
+	client := api.NewClient(queriesAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return resolverDownError(err)
+	}
+
+	filter := api.QueryFilter{Domain: queriesDomain, Client: queriesClient, Limit: queriesLimit}
+	if queriesSince > 0 {
+		filter.Since = time.Now().Add(-queriesSince)
+	}
+	queries, err := client.GetQueryHistory(filter)
+	if err != nil {
+		return fmt.Errorf("failed to get query history: %w", err)
+	}
+
+	switch {
+	case jsonOutput():
+		return printJSON(queries)
+	case queriesCSV:
+		return writeQueriesCSV(queries)
+	}
+
+	if len(queries) == 0 {
+		fmt.Println("No matching queries in the log.")
+		return nil
+	}
+	fmt.Printf("%-19s  %-6s  %-5s  %-40s  %s\n", "Time", "Status", "Type", "Domain", "Client")
+	for _, query := range queries {
+		fmt.Printf("%-19s  %-6s  %-5s  %-40s  %s\n", query.Timestamp.Local().Format("2006-01-02 15:04:05"), queryStatus(query), query.QueryType, query.Domain, query.ClientLabel())
+	}
+	return nil
+}
+
+// queryStatus names what happened to a query
+func queryStatus(query api.DNSQuery) string {
+	switch {
+	case query.Blocked:
+		return "BLOCK"
+	case query.WouldBlock:
+		return "WARN"
+	default:
+		return "ALLOW"
+	}
+}
+
+// writeQueriesCSV prints queries as CSV with a header row
+func writeQueriesCSV(queries []api.DNSQuery) error {
+	writer := csv.NewWriter(os.Stdout)
+	rows := [][]string{{"timestamp", "domain", "client", "client_name", "query_type", "rcode", "blocked", "would_block", "upstream", "latency_ms", "reason"}}
+	for _, query := range queries {
+		rows = append(rows, []string{
+			query.Timestamp.Format(time.RFC3339Nano),
+			query.Domain,
+			query.Client,
+			query.ClientName,
+			query.QueryType,
+			query.Rcode,
+			strconv.FormatBool(query.Blocked),
+			strconv.FormatBool(query.WouldBlock),
+			query.Upstream,
+			strconv.FormatFloat(query.LatencyMS, 'f', -1, 64),
+			query.Reason,
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// pruneQueries applies the retention policy, or the limits given by flags, through the
+// running resolver or directly to the query log when the resolver is stopped
+func pruneQueries() error {
+	var req api.PruneRequest
+	req.MaxRecords = queriesMaxRecords
+	if queriesOlderThan != "" {
+		maxAge, err := config.ParseDays(queriesOlderThan)
+		if err != nil || maxAge <= 0 {
+			return fmt.Errorf("invalid --older-than: use a duration like 7d or 12h")
+		}
+		req.OlderThan = maxAge.String()
+	}
+
+	var result *api.PruneResult
+	client := api.NewClient(queriesAPIURL)
+	if err := client.HealthCheck(); err == nil {
+		if result, err = client.PruneQueries(req); err != nil {
+			return err
+		}
+	} else if result, err = pruneQueryLogFile(req); err != nil {
+		return err
+	}
+
+	if jsonOutput() {
+		return printJSON(result)
+	}
+	fmt.Printf("Deleted %d queries, %d left in the query log.\n", result.Deleted, result.Remaining)
+	return nil
+}
+
+// pruneQueryLogFile prunes queries.db itself; the configured retention applies when the
+// request has no limits
+func pruneQueryLogFile(req api.PruneRequest) (*api.PruneResult, error) {
+	retention := api.Retention{MaxRecords: req.MaxRecords}
+	if req.OlderThan != "" {
+		retention.MaxAge, _ = time.ParseDuration(req.OlderThan)
+	}
+	if retention == (api.Retention{}) {
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load config: %w", err)
+		}
+		if retention.MaxAge, err = cfg.GetQueryRetention(); err != nil {
+			return nil, err
+		}
+		if retention.MaxRecords, err = cfg.GetMaxQueryRecords(); err != nil {
+			return nil, err
+		}
+	}
+
+	path := config.GetQueryLogPath()
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return &api.PruneResult{}, nil
+		}
+		return nil, fmt.Errorf("failed to check query log: %w", err)
+	}
+	queryLog, err := api.OpenQueryLog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := queryLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}()
+
+	deleted, err := queryLog.Prune(retention, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune query log: %w", err)
+	}
+	remaining, err := queryLog.Count()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count queries: %w", err)
+	}
+	return &api.PruneResult{Deleted: deleted, Remaining: remaining}, nil
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/report"
+	"github.com/spf13/cobra"
+)
+
+// Formats accepted by 'report --format'
+const (
+	reportMarkdown = "markdown"
+	reportHTML     = "html"
+)
+
+var (
+	reportAPIURL string
+	reportWeek   bool
+	reportFormat string
+	reportFile   string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Write a weekly report of focus time and distractions",
+	Long: `Writes a report of the last 7 days, today included, to skim at the end of the week:
+
+- Focus time per day, the week's total and how it compares with the week before, and the time per session label
+- Days the daily goal was met, and the current and longest streaks
+- The most blocked domains and the share of queries blocked, from the query log
+- How many domains the allowlist has, and how many it gained or lost during the week
+
+The report is Markdown, or a standalone HTML page with --format html (the default for a --file ending in .html). Use --output json for the numbers alone.
+
+Focus time comes from the state file. Blocked queries come from a running resolver, or else from queries.db directly, so they cover the query_retention (default 7d) the log keeps. The allowlist's size is recorded each day sinkzone changes it, so its growth shows up once a size was recorded before the week.
+
+Examples:
+  sinkzone report --week
+  sinkzone report --week --format html --file week.html
+  sinkzone report --week --output json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if !reportWeek && cmd.Flags().Changed("week") {
+			return fmt.Errorf("only weekly reports are supported: use --week")
+		}
+		if reportFormat == "" {
+			reportFormat = reportMarkdown
+			if strings.HasSuffix(strings.ToLower(reportFile), ".html") {
+				reportFormat = reportHTML
+			}
+		}
+		if reportFormat != reportMarkdown && reportFormat != reportHTML {
+			return fmt.Errorf("unknown format: %s. Use 'markdown' or 'html'", reportFormat)
+		}
+		cmd.SilenceUsage = true
+		return writeReport()
+	},
+}
+
+func init() {
+	reportCmd.Flags().StringVar(&reportAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	reportCmd.Flags().BoolVar(&reportWeek, "week", false, "Cover the last 7 days, today included (the default)")
+	reportCmd.Flags().StringVar(&reportFormat, "format", "", "Format of the report: markdown or html (default markdown, html for a --file ending in .html)")
+	reportCmd.Flags().StringVar(&reportFile, "file", "", "Write the report to a file instead of standard output")
+}
+
+func writeReport() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	goal, err := cfg.GetDailyGoal()
+	if err != nil {
+		return err
+	}
+	stateMgr, err := config.NewStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return err
+	}
+	allowed, err := manager.List()
+	if err != nil {
+		return fmt.Errorf("failed to list allowlist: %w", err)
+	}
+
+	now := time.Now()
+	queries, err := weekQueryStats(report.WeekStart(now), now)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: leaving blocked queries out of the report: %v\n", err)
+	}
+	weekly := report.Build(stateMgr.GetState(), goal, queries, len(allowed), now)
+
+	if jsonOutput() {
+		return printJSON(weekly)
+	}
+	var w io.Writer = os.Stdout
+	if reportFile != "" {
+		// #nosec G304 -- the file is chosen by the user running this command
+		file, err := os.Create(reportFile)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", reportFile, err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: failed to close %s: %v\n", reportFile, err)
+			}
+		}()
+		w = file
+	}
+	if reportFormat == reportHTML {
+		return weekly.HTML(w)
+	}
+	return weekly.Markdown(w)
+}
+
+// weekQueryStats sums up the queries since start: from a running resolver, or else from the
+// query log file. It returns nil without a query log.
+func weekQueryStats(start, now time.Time) (*api.QueryStats, error) {
+	client := api.NewClient(reportAPIURL)
+	if err := client.HealthCheck(); err == nil {
+		return client.GetQueryStatsSince(now.Sub(start))
+	}
+
+	path := config.GetQueryLogPath()
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check query log: %w", err)
+	}
+	queryLog, err := api.OpenQueryLog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := queryLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}()
+	stats, err := queryLog.Stats(start)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query log: %w", err)
+	}
+	return &stats, nil
+}
@@ -1,88 +1,1326 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"net/http/pprof" // #nosec G108 -- only served on the loopback address given with --debug-pprof
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/calendar"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/crash"
 	"github.com/berbyte/sinkzone/internal/dns"
+	"github.com/berbyte/sinkzone/internal/events"
+	"github.com/berbyte/sinkzone/internal/hooks"
+	"github.com/berbyte/sinkzone/internal/influx"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/berbyte/sinkzone/internal/macfocus"
+	"github.com/berbyte/sinkzone/internal/metrics"
+	"github.com/berbyte/sinkzone/internal/mqtt"
+	"github.com/berbyte/sinkzone/internal/notify"
+	"github.com/berbyte/sinkzone/internal/peersync"
+	"github.com/berbyte/sinkzone/internal/privileges"
+	"github.com/berbyte/sinkzone/internal/process"
+	"github.com/berbyte/sinkzone/internal/rules"
+	"github.com/berbyte/sinkzone/internal/schedule"
+	"github.com/berbyte/sinkzone/internal/service"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+	"github.com/berbyte/sinkzone/internal/tailnet"
+	"github.com/berbyte/sinkzone/internal/tracing"
+	"github.com/berbyte/sinkzone/internal/vpn"
 	"github.com/spf13/cobra"
 )
 
 var port string
 var apiPort string
+var resolverAPIAddr string
+var resolverDaemon bool
+var resolverPprof string   // --debug-pprof address, empty when off
+var resolverLAN bool       // --lan, passed on as SINKZONE_LAN
+var resolverTailscale bool // --tailscale, passed on as SINKZONE_TAILSCALE_ENABLED
+var resolverPIN string     // --pin, sent by stop and restart when they go through the API
+
+// Whether --port, --api-port, and --api-addr were given, overriding dns_listen and api_listen
+var portSet, apiPortSet, apiAddrSet bool
+
+// defaultPprofAddr is where --debug-pprof serves profiles when given without an address
+const defaultPprofAddr = "127.0.0.1:6060"
+
+// resolverStopTimeout is how long stop and restart wait for the running resolver to exit
+const resolverStopTimeout = 10 * time.Second
 
 var resolverCmd = &cobra.Command{
-	Use:   "resolver",
+	Use:   "resolver [stop|restart]",
 	Short: "Start the local DNS resolver with HTTP API (required first step)",
 	Long: `Starts Sinkzone's local DNS resolver with HTTP API, which intercepts DNS queries made by your system.
 
 This must be the first command you run. The resolver captures all outgoing DNS requests and enables Sinkzone to monitor and control domain access.
 
 The HTTP API provides endpoints for:
-- GET /api/queries - Get last 100 DNS queries
+- GET /api/queries - Get the newest query of the last 100 domains (?limit= for more)
+- GET /api/queries/stream - Stream DNS queries as server-sent events
+- GET /api/queries/history - Search the query log (?since=1h, ?domain=, ?client=, ?limit=)
+- POST /api/queries/prune - Delete old queries from the query log
 - GET /api/focus - Get focus mode state
 - POST /api/focus - Set focus mode
+- POST /api/focus/pause - Pause focus mode
+- POST /api/focus/resume - Resume focus mode
+- POST /api/focus/snooze - Temporarily allow a domain
+- GET /api/focus/sessions - List the focus sessions that ended, kept with the query log (?since=168h)
+- GET/POST /api/focus/schedule - List or queue focus sessions
+- DELETE /api/focus/schedule/{id} - Cancel a queued focus session
 - GET /api/state - Get complete resolver state
+- GET /api/stats/queries - Get query totals, top domains, blocked domains, clients, and latency per upstream (?since=1h for a window)
+- GET /api/stats - Get focus time, daily goal progress, and streaks
+- GET /api/health - Get DNS listener and upstream health, whether this machine's DNS queries reach the resolver, and a suspected captive portal
+- GET /api/devices - List the devices that sent queries, with their focus mode
+- GET /api/devices/{device} - Get the top and blocked domains and newest queries of one device
+- PUT /api/devices/{device}/focus - Keep a device in focus, out of it, or following the session
+- GET /api/clients - List the names given to clients in client_names
+- PUT /api/clients/{client} - Name a client by IP or MAC address
+- DELETE /api/clients/{client} - Remove a client's name, by address or name
+- POST /api/allowlist/reload - Reread the allowlist and blocklist files
+- PUT /api/config/upstreams - Replace the upstream nameservers, saved to sinkzone.yaml and used right away
+- GET /api/cache - Get the size and hit, miss, and eviction counts of the DNS cache
+- DELETE /api/cache - Drop every cached answer
+- GET /api/cooldowns - List clients refused for a while after too many rate-limited or malformed queries
+- DELETE /api/cooldowns[/{client}] - Lift the cooldown of every client, or of one
+- POST /api/portal/passthrough - Let everything through to the network's nameserver for a while, so a captive portal's login page loads
+- DELETE /api/portal/passthrough - Close the passthrough window early
+- GET /api/upstreams - Get success, failure, timeout, and latency statistics per upstream
+- GET /api/settings - Runtime settings: log level, dry run, rate limit, and poll intervals
+- PATCH /api/settings - Change runtime settings without saving them to sinkzone.yaml
+- POST /api/shutdown - Stop the resolver (local clients only; the focus PIN is needed during a PIN-protected session)
+- GET /api/extension/decision - Whether a domain is blocked right now, and why (?domain=)
+- POST /api/extension/allow - Let the domain of a browser tab through for a while (default 10m)
+- POST /api/extension/pair, GET /api/extension/pair/{id} - Ask for an extension token and collect it once approved
+- GET /api/extension/pairings, POST /api/extension/pairings/{code} - List or approve pairings
+- GET /metrics - Prometheus metrics, also served on metrics_listen when set
+- GET /livez, GET /readyz - Liveness and readiness probes for containers; /readyz answers 503 until the DNS port is bound and while every upstream is down
+
+When api_tokens are set in sinkzone.yaml, every route except /health, the probes, extension pairing, and triggers needs a bearer token whose scopes (read, focus, allowlist, extension, or admin) cover it. The CLI sends $SINKZONE_API_TOKEN, or else the first admin token of the config.
 
 Once running, other features like monitoring, allowlisting, and focus mode become active.
+
+The listen addresses, the order upstreams are tried in, the answer to blocked queries, the answer cache and its stale answers when every upstream fails, per-client rate limits, cooldowns of clients that keep going over them or send malformed queries, how many queries are handled at once, the recent queries kept in memory, the query log, how client addresses are recorded, the check of allowlist.txt and state.json for changes made outside sinkzone, and logging are set in sinkzone.yaml (dns_listen, api_listen, upstream_strategy, block_response, blocked_ttl, cache, rate_limit, cooldown, concurrency, recent_queries, query_log, client_privacy, file_integrity, query_retention, max_query_records, log_output, log_file, log_rotation, log_level, log_levels, log_format). --port overrides dns_listen and binds all interfaces.
+
+When another program holds the DNS port, such as systemd-resolved's stub listener or dnsmasq, the resolver names it and how to free the port, and does not start. With dns_fallback_port set in sinkzone.yaml (e.g. 5354) it listens on that port instead; if port 53 was taken and systemd-resolved manages DNS, it writes a drop-in to /etc/systemd/resolved.conf.d/ that forwards every query to it, so the system still uses sinkzone. Elsewhere it logs how to forward by hand. The drop-in is removed once the resolver gets port 53 again, or by 'sinkzone setup --undo'.
+
+Under systemd socket activation, the resolver answers on the sockets systemd passes (LISTEN_FDS) instead of binding dns_listen and api_listen: a UDP socket, or one named dns with FileDescriptorName=, for DNS, and a TCP socket, or one named api, for the HTTP API. It then needs no root to serve port 53.
+
+--lan (or lan: true in sinkzone.yaml) serves a whole network, such as a family's devices: DNS and the HTTP API bind all interfaces (keeping their ports, and an --api-addr as given), the API refuses to start without api_tokens, and each query records the MAC address of its device, so devices keep their identity when their address changes. 'sinkzone devices' and the TUI's Devices tab then list the devices and keep each in focus, out of it, or following the session.
+
+--tailscale (or tailscale.enabled: true in sinkzone.yaml) joins a tailnet with the resolver's own machine, without tailscaled or root, and answers DNS on its Tailscale address; the first start logs a URL to log it in unless tailscale.auth_key is set. Add that address as a nameserver with Override local DNS in the Tailscale admin console, and focus mode follows the tailnet's machines wherever they are. tailscale.api also serves the HTTP API on the tailnet and needs api_tokens.
+
+With a vpn section in sinkzone.yaml, the resolver watches for a VPN's interface (vpn.interfaces, such as wg0 or utun*, or an address in vpn.networks) and, while it is up, sends queries to the VPN's nameservers, so internal names keep resolving during focus sessions; it switches back once the VPN goes down. vpn.upstreams names those nameservers, otherwise the ones the system gained when the VPN came up are used. vpn.domains limits them to the VPN's own domains, e.g. corp.example.com.
+
+Names only the local network knows never go to the public upstreams: .local (mDNS), home.arpa, the reverse names of private, link-local, loopback, and CGNAT addresses, and the zones in private_zones.zones. With a local nameserver (private_zones.resolver, or an upstream on a private address such as the router) they go to it, otherwise the resolver answers NXDOMAIN itself; private_zones.mode picks one of nxdomain, forward, or upstream (forward them like any other name, as before). private_zones.hosts lists names the resolver answers itself, e.g. nas.lan: 192.168.1.20, along with their reverse names.
+
+On Linux, a users section in sinkzone.yaml tells the accounts of this machine apart: queries sent over loopback are attributed to the account owning their socket, and each account's entry keeps it out of focus sessions (focus: off), always in focus (on), or following them, and adds domains only it may reach (allowlist). 'sinkzone devices focus user:<name>' overrides an account's focus like a device's.
+
+Started with sudo, the resolver binds its ports as root and then switches to the user who ran sudo, keeping its files in that user's ~/.sinkzone/. Set run_as in sinkzone.yaml to switch to another user, or to root to stay root.
+
+The HTTP API listens on 127.0.0.1:8080 unless api_listen, --api-addr, or --api-port (which binds 127.0.0.1) says otherwise. Anyone who can reach the API controls focus mode, so an address other machines can reach, such as 0.0.0.0:8080, is refused unless api_allow_remote is set to true in sinkzone.yaml, or api_tls requires client certificates. With mutations_local_only set to true, requests that change anything are only accepted from this machine, so other machines can read the API but not control focus mode. With trigger_secret set, POST /api/hooks/trigger lets automations such as a Stream Deck or a script on another host start, stop, or toggle focus mode, or switch profiles, with that secret instead of a token, even with mutations_local_only. With api_tls the API serves HTTPS with api_tls.cert and api_tls.key, and with api_tls.client_ca only answers clients holding a certificate signed by that CA (mutual TLS); 'sinkzone cert generate' creates the certificates, and the CLI and TUI send the one of api_client_tls.
+
+The resolver watches sinkzone.yaml and applies changes to the upstreams, upstream_strategy, block_response, blocked_ttl, cache, rate_limit, cooldown, concurrency, recent_queries, query retention, query log sampling, client_privacy, api_tokens, allowlist_subscriptions, blocklist_subscriptions, mutations_local_only, trigger_secret, vpn, private_zones, log_level, log_levels, and log_format within seconds, logging what changed. Other changes, such as the listen addresses, are logged as waiting for a restart.
+
+Use 'sinkzone resolver stop' to stop a running resolver. It sends SIGTERM to the process in the PID file (or asks the API to shut down on Windows, or when the resolver runs as another user, which needs the focus PIN with --pin or SINKZONE_PIN during a PIN-protected session), waits for it to exit, and removes a stale PID file left by a crashed resolver. It leaves alone a process that is not a sinkzone resolver, in case the PID was reused after a crash. 'sinkzone resolver restart' stops the running resolver and starts a new one in this terminal with the given flags.
+
+Use 'sinkzone resolver --daemon' to run the resolver in the background instead of keeping a terminal open. It logs to log_file, or to resolver.log next to the PID file (~/.sinkzone/ on macOS and Linux) and is stopped with 'sinkzone resolver stop'. Combine it with restart ('sinkzone resolver restart --daemon') to restart in the background.
+
+Use --debug-pprof to serve Go's pprof profiles at http://127.0.0.1:6060/debug/pprof/ while diagnosing performance, e.g. 'go tool pprof http://127.0.0.1:6060/debug/pprof/profile?seconds=30' under load from 'sinkzone bench'. Give it an address with --debug-pprof=127.0.0.1:6061; only loopback addresses are accepted, since profiles expose the resolver's memory.
+
+With log_output: syslog in sinkzone.yaml the resolver logs to the system log instead, with a priority after each line's level: syslog, which journald reads too ('journalctl -t sinkzone'), or the Windows Event Log (the Application log, source sinkzone).
 `,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		// Check admin privileges for privileged ports
-		if err := config.CheckPortPrivileges(port); err != nil {
-			return err
+		portSet = cmd.Flags().Changed("port")
+		apiPortSet = cmd.Flags().Changed("api-port")
+		apiAddrSet = cmd.Flags().Changed("api-addr")
+		if len(args) > 0 {
+			switch args[0] {
+			case "stop":
+				return stopResolver()
+			case "restart":
+				if err := stopResolver(); err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown resolver command: %s. Use 'stop' or 'restart'", args[0])
+			}
 		}
-
-		// Load configuration
-		cfg, err := config.Load()
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+		if resolverLAN {
+			// Through the environment, so reloads and a daemonized resolver keep it
+			if err := os.Setenv(config.EnvName("lan"), "true"); err != nil {
+				return fmt.Errorf("failed to turn on LAN mode: %w", err)
+			}
 		}
+		if resolverTailscale {
+			if err := os.Setenv(config.EnvName("tailscale.enabled"), "true"); err != nil {
+				return fmt.Errorf("failed to turn on the tailnet: %w", err)
+			}
+		}
+		if resolverPprof != "" {
+			if err := checkPprofAddr(resolverPprof); err != nil {
+				return err
+			}
+		}
+		if service.IsWindowsService() {
+			return runResolverService()
+		}
+		if resolverDaemon && os.Getenv(daemonEnv) == "" {
+			return startResolverDaemon()
+		}
+		return runResolver(nil)
+	},
+}
+
+// runResolver runs the DNS resolver and API until they fail or the resolver is told to
+// stop: by a signal, POST /api/shutdown, or closing stop
+func runResolver(stop <-chan struct{}) error {
+	defer crash.Recover("resolver")
 
-		// Create API server
-		apiServer := api.NewServer(apiPort)
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := validateResolverConfig(cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	if err := applyResolverLogging(cfg); err != nil {
+		return err
+	}
 
-		// Create DNS server with API server reference
-		dnsServer := dns.NewServerWithPort(cfg, apiServer, port)
+	dnsAddr, apiAddr, err := resolverListen(cfg)
+	if err != nil {
+		return err
+	}
 
-		log.Printf("Starting sinkzone DNS resolver on :%s with API on :%s", port, apiPort)
+	// Sockets systemd bound for a socket unit replace the configured addresses
+	sockets, err := service.ActivationSockets()
+	if err != nil {
+		return fmt.Errorf("failed to use the sockets passed by systemd: %w", err)
+	}
+	if sockets != nil && sockets.DNS != nil {
+		dnsAddr = sockets.DNS.LocalAddr().String()
+		log.Printf("Using the DNS socket passed by systemd (%s)", dnsAddr)
+	}
+	if sockets != nil && sockets.API != nil {
+		if apiAddr, err = cfg.CheckAPIListen("API socket passed by systemd", sockets.API.Addr().String()); err != nil {
+			return err
+		}
+		log.Printf("Using the API socket passed by systemd (%s)", apiAddr)
+	}
+	if cfg.IsLAN() {
+		log.Printf("LAN mode: serving DNS on %s and the HTTP API on %s to the devices of the network", dnsAddr, apiAddr)
+	} else if !config.IsLoopbackListen(apiAddr) && !cfg.APITLS.RequiresClientCert() {
+		if cfg.AcceptsRemoteMutations() {
+			log.Printf("Warning: the HTTP API listens on %s, so other machines can control focus mode (api_allow_remote is set)", apiAddr)
+		} else {
+			log.Printf("The HTTP API listens on %s; other machines can read it, but changes are only accepted from this machine (mutations_local_only is set)", apiAddr)
+		}
+	}
+
+	// Check admin privileges for privileged ports, unless systemd bound the port
+	if sockets == nil || sockets.DNS == nil {
+		if err := config.CheckPortPrivileges(listenPort(dnsAddr)); err != nil {
+			return err
+		}
+	}
 
-		// Start both servers in goroutines
-		var wg sync.WaitGroup
-		var dnsErr, apiErr error
+	// Send traces of queries and API requests to an OpenTelemetry collector if configured
+	if cfg.Tracing != nil {
+		endpoint, _ := cfg.Tracing.GetEndpoint()
+		samplePercent, _ := cfg.Tracing.GetSamplePercent()
+		tracing.Configure(tracing.Options{Endpoint: endpoint, ServiceName: cfg.Tracing.GetServiceName(), SamplePercent: samplePercent})
+		defer tracing.Shutdown()
+		log.Printf("Sending traces to %s", endpoint)
+	}
 
-		wg.Add(2)
+	// Create API server
+	apiServer := api.NewServerWithAddr(apiAddr)
+	apiServer.SetTokens(apiTokens(cfg))
+	apiServer.SetTriggerSecret(cfg.TriggerSecret)
+	apiServer.SetMutationsLocalOnly(!cfg.AcceptsRemoteMutations())
+	// ValidateServer has loaded the certificates once already
+	apiTLS, _ := cfg.APITLS.ServerTLS()
+	if apiTLS != nil {
+		apiServer.SetTLS(apiTLS)
+		if cfg.APITLS.RequiresClientCert() {
+			log.Printf("The HTTP API serves HTTPS and requires client certificates")
+		} else {
+			log.Printf("The HTTP API serves HTTPS")
+		}
+	}
+	if len(cfg.APITokens) > 0 {
+		log.Printf("The HTTP API requires one of %d API tokens", len(cfg.APITokens))
+	}
 
-		// Start DNS server
+	// Serve /metrics on its own address too, e.g. for a scraper on another machine that
+	// mustn't reach the API
+	if metricsAddr, _ := cfg.GetMetricsListen(); metricsAddr != "" {
+		metricsServer := metrics.NewServer(metricsAddr)
+		listener, err := net.Listen("tcp", metricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", metricsAddr, err)
+		}
 		go func() {
-			defer wg.Done()
-			dnsErr = dnsServer.Start()
+			log.Printf("Serving metrics on %s", metricsAddr)
+			if err := metricsServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Warning: failed to serve metrics: %v", err)
+			}
+		}()
+		defer func() {
+			if err := metricsServer.Close(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
 		}()
+	}
 
-		// Start API server
+	// Serve profiles for diagnosing performance, only when asked to
+	if resolverPprof != "" {
+		pprofServer, err := newPprofServer(resolverPprof)
+		if err != nil {
+			return err
+		}
+		listener, err := net.Listen("tcp", resolverPprof)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", resolverPprof, err)
+		}
 		go func() {
-			defer wg.Done()
-			apiErr = apiServer.Start()
+			log.Printf("Serving pprof profiles on http://%s/debug/pprof/", resolverPprof)
+			if err := pprofServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Printf("Warning: failed to serve pprof profiles: %v", err)
+			}
 		}()
+		defer func() {
+			if err := pprofServer.Close(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+	}
+
+	historySize, historyMaxBytes, _ := cfg.RecentQueries.GetLimits()
+	apiServer.SetHistoryLimits(historySize, historyMaxBytes)
+	historyAggregate, _ := cfg.RecentQueries.GetAggregate()
+	apiServer.SetHistoryAggregate(historyAggregate)
+
+	// Keep query history on disk or in memory (optional - the resolver works without it)
+	var queryLog api.Storage
+	if cfg.QueryLog.IsEnabled() {
+		queryLog, err = openQueryLog(cfg)
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			log.Printf("Resolver will continue without a query log")
+		} else {
+			apiServer.SetQueryLog(queryLog)
+			maxAge, _ := cfg.GetQueryRetention()
+			maxRecords, _ := cfg.GetMaxQueryRecords()
+			queryLog.SetRetention(api.Retention{MaxAge: maxAge, MaxRecords: maxRecords})
+			sampleRate, _ := cfg.QueryLog.GetSampleRate()
+			queryLog.SetSampleRate(sampleRate)
+			if sampleRate > 1 {
+				log.Printf("The query log keeps 1 in %d allowed queries and every blocked one", sampleRate)
+			}
+			defer func() {
+				if err := queryLog.Close(); err != nil {
+					log.Printf("Warning: %v", err)
+				}
+			}()
+		}
+	}
+
+	// Create DNS server with API server reference
+	dnsServer := dns.NewServerWithAddr(cfg, apiServer, dnsAddr)
+
+	// Hand events to the hook scripts and plugins that want them
+	bus := events.NewBus()
+	if err := hooks.Subscribe(cfg.Hooks, bus); err != nil {
+		return fmt.Errorf("invalid hooks config: %w", err)
+	}
+	apiServer.SetEvents(bus)
+	dnsServer.SetEvents(bus)
 
-		// Wait for both servers to finish (or error)
-		wg.Wait()
+	// Drive focus mode from a calendar if configured
+	if cfg.Calendar != nil {
+		watcher, err := calendar.NewWatcher(cfg.Calendar, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid calendar config: %w", err)
+		}
+		go watcher.Run(make(chan struct{}))
+	}
 
-		// Return the first error that occurred
-		if dnsErr != nil {
-			return fmt.Errorf("DNS server error: %w", dnsErr)
+	// Drive focus mode from Google Calendar if configured
+	if cfg.GoogleCalendar != nil {
+		watcher, err := calendar.NewGoogleWatcher(cfg.GoogleCalendar, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid google_calendar config: %w", err)
 		}
-		if apiErr != nil {
-			return fmt.Errorf("API server error: %w", apiErr)
+		go watcher.Run(make(chan struct{}))
+	}
+
+	// Focus while configured programs are running
+	if len(cfg.ProcessTriggers) > 0 {
+		watcher, err := process.NewWatcher(cfg.ProcessTriggers, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid process_triggers config: %w", err)
 		}
+		go watcher.Run(make(chan struct{}))
+	}
+
+	// Focus during recurring weekly windows
+	if slices.ContainsFunc(cfg.Schedules, config.Schedule.StartsFocus) {
+		watcher, err := schedule.NewWatcher(cfg.Schedules, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid schedules config: %w", err)
+		}
+		go watcher.Run(make(chan struct{}))
+	}
+
+	// Mirror a macOS Focus and focus sessions
+	if cfg.MacOSFocus != nil {
+		syncer, err := macfocus.NewSyncer(cfg.MacOSFocus, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid macos_focus config: %w", err)
+		}
+		if runtime.GOOS == "darwin" {
+			go syncer.Run(make(chan struct{}))
+		} else {
+			log.Printf("Warning: macos_focus only works on macOS, ignoring it")
+		}
+	}
+
+	// Tunables operators can change without editing sinkzone.yaml
+	settings := &resolverSettings{dns: dnsServer}
+	settings.pollInterval.Store(int64(config.ConfigPollInterval))
+	apiServer.SetSettingsCallbacks(settings.get, settings.patch)
+
+	// Mirror focus sessions from resolvers on other machines
+	if cfg.Sync != nil {
+		syncer, err := peersync.NewSyncer(cfg.Sync, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid sync config: %w", err)
+		}
+		settings.syncer = syncer
+		go syncer.Run(make(chan struct{}))
+	}
+
+	// Warn before focus sessions end, and post and push them if configured
+	if cfg.Notifications != nil {
+		warnBefore, err := cfg.Notifications.GetWarnBefore()
+		if err != nil {
+			return err
+		}
+		pressureAlert, err := cfg.Notifications.GetPressureAlert()
+		if err != nil {
+			return err
+		}
+		if cfg.Notifications.IsDesktop() {
+			notifier := notify.NewNotifier(warnBefore, apiServer)
+			notifier.SetPressureAlert(pressureAlert, apiServer)
+			go notifier.Run(make(chan struct{}))
+		}
+		webhook, err := notify.NewWebhook(cfg.Notifications, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid notifications config: %w", err)
+		}
+		if webhook != nil {
+			go webhook.Run(make(chan struct{}))
+		}
+		push, err := notify.NewPush(cfg.Notifications, cfg.FocusPINHash != "", apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid notifications config: %w", err)
+		}
+		if push != nil {
+			go push.Run(make(chan struct{}))
+		}
+	}
+
+	// Run hook scripts and publish events when focus sessions start and end
+	if cfg.Hooks != nil || bus.Wants(events.FocusStarted) || bus.Wants(events.FocusEnded) {
+		runner := hooks.NewRunner(cfg.Hooks, apiServer)
+		runner.SetEvents(bus)
+		go runner.Run(make(chan struct{}))
+	}
+
+	// Publish the focus state to an MQTT broker, e.g. for Home Assistant
+	if cfg.MQTT != nil {
+		publisher, err := mqtt.NewPublisher(cfg.MQTT, apiServer)
+		if err != nil {
+			return fmt.Errorf("invalid mqtt config: %w", err)
+		}
+		go publisher.Run(make(chan struct{}))
+	}
+
+	// Write the metrics to InfluxDB for long-term dashboards
+	if cfg.InfluxDB != nil {
+		exporter, err := influx.NewExporter(cfg.InfluxDB)
+		if err != nil {
+			return fmt.Errorf("invalid influxdb config: %w", err)
+		}
+		go exporter.Run(make(chan struct{}))
+	}
+
+	// Keep subscribed allowlists and blocklists fresh
+	refresher, err := subscription.NewRefresher(cfg, dnsServer.ReloadLists)
+	if err != nil {
+		return err
+	}
+	if refresh, _ := cfg.GetSubscriptionRefresh(); refresh > 0 {
+		go refresher.Run(make(chan struct{}))
+	}
+
+	// Use the VPN's nameservers while it is up; the watcher idles until a vpn section is set
+	vpnWatcher, err := vpn.NewWatcher(cfg.VPN, dnsServer.SetVPNUpstreams)
+	if err != nil {
+		return fmt.Errorf("invalid vpn config: %w", err)
+	}
+	go vpnWatcher.Run(make(chan struct{}))
+
+	// Apply changes to sinkzone.yaml while running where possible, whether the file was
+	// edited or changed through the API
+	reloadStop := make(chan struct{})
+	defer close(reloadStop)
+	current := cfg
+	var reloadMutex sync.Mutex
+	go config.Watch(reloadStop, settings.configPollInterval, func(next *config.Config) {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		current = reloadResolverConfig(current, next, dnsServer, apiServer, queryLog, refresher, vpnWatcher)
+	})
+	apiServer.SetTokenCallback(func(name, secret string, scopes []string) (string, error) {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		next, name, err := saveAPIToken(name, secret, scopes)
+		if err != nil {
+			return "", err
+		}
+		current = reloadResolverConfig(current, next, dnsServer, apiServer, queryLog, refresher, vpnWatcher)
+		return name, nil
+	})
+	apiServer.SetUpstreamsCallback(func(upstreams []string) ([]string, error) {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		next, err := saveUpstreams(upstreams)
+		if err != nil {
+			return nil, err
+		}
+		current = reloadResolverConfig(current, next, dnsServer, apiServer, queryLog, refresher, vpnWatcher)
+		return current.UpstreamNameservers, nil
+	})
+	apiServer.SetClientNamesCallbacks(func() []api.ClientName {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		return clientNameList(current)
+	}, func(address, name string, byMAC bool) (api.ClientName, error) {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		next, saved, err := saveClientName(address, name, byMAC)
+		if err != nil {
+			return api.ClientName{}, err
+		}
+		current = reloadResolverConfig(current, next, dnsServer, apiServer, queryLog, refresher, vpnWatcher)
+		return saved, nil
+	}, func(client string) ([]api.ClientName, error) {
+		reloadMutex.Lock()
+		defer reloadMutex.Unlock()
+		next, removed, err := removeClientName(client)
+		if err != nil {
+			return nil, err
+		}
+		current = reloadResolverConfig(current, next, dnsServer, apiServer, queryLog, refresher, vpnWatcher)
+		return removed, nil
+	})
+
+	// Stop both servers on SIGINT/SIGTERM or POST /api/shutdown, so the PID file is removed
+	var stopOnce sync.Once
+	shutdown := func() {
+		stopOnce.Do(func() {
+			log.Printf("Shutting down sinkzone DNS resolver")
+			if err := dnsServer.Shutdown(); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := apiServer.Shutdown(ctx); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		})
+	}
+	apiServer.SetShutdownCallback(shutdown)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+		case <-stop:
+		}
+		shutdown()
+	}()
+
+	// Bind the ports while still root, then give up root (run_as)
+	if sockets != nil && sockets.DNS != nil {
+		dnsServer.SetPacketConn(sockets.DNS)
+	}
+	if sockets != nil && sockets.API != nil {
+		apiServer.SetListener(sockets.API)
+	}
+	if err := dnsServer.Listen(); err != nil {
+		if dnsAddr, err = listenFallback(cfg, dnsServer, dnsAddr, err); err != nil {
+			return err
+		}
+	} else if (sockets == nil || sockets.DNS == nil) && listenPort(dnsAddr) == "53" {
+		removeStaleForward()
+	}
+	if err := apiServer.Listen(); err != nil {
+		return err
+	}
+	if err := dropPrivileges(cfg); err != nil {
+		return err
+	}
+
+	log.Printf("Starting sinkzone DNS resolver on %s with API on %s", dnsAddr, apiAddr)
+
+	// Start both servers in goroutines
+	var wg sync.WaitGroup
+	var dnsErr, apiErr error
+
+	wg.Add(2)
+
+	// Start DNS server
+	go func() {
+		defer wg.Done()
+		dnsErr = dnsServer.Start()
+		shutdown() // Don't leave the API running without a resolver
+	}()
+
+	// Start API server
+	go func() {
+		defer wg.Done()
+		apiErr = apiServer.Start()
+		shutdown()
+	}()
+
+	// Serve DNS to the machines of a tailnet wherever they are, so focus mode follows them
+	if cfg.Tailscale.IsEnabled() {
+		node, err := tailnet.NewNode(cfg.Tailscale, listenPort(apiAddr))
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			log.Printf("Resolver will continue without the tailnet")
+		} else {
+			ctx, cancel := context.WithCancel(context.Background())
+			go func() {
+				if err := node.Run(ctx, dnsServer, apiServer); err != nil && ctx.Err() == nil {
+					log.Printf("Warning: %v", err)
+				}
+			}()
+			defer func() {
+				cancel()
+				if err := node.Close(); err != nil {
+					log.Printf("Warning: failed to leave the tailnet: %v", err)
+				}
+			}()
+		}
+	}
+
+	// Wait for both servers to finish (or error)
+	wg.Wait()
+
+	// Return the first error that occurred
+	if dnsErr != nil {
+		return fmt.Errorf("DNS server error: %w", dnsErr)
+	}
+	if apiErr != nil {
+		return fmt.Errorf("API server error: %w", apiErr)
+	}
 
+	return nil
+}
+
+// apiTokens returns the api_tokens of cfg keyed by secret, for api.Server.SetTokens
+func apiTokens(cfg *config.Config) map[string]api.Token {
+	tokens := make(map[string]api.Token, len(cfg.APITokens))
+	for _, token := range cfg.APITokens {
+		tokens[token.Token] = api.Token{Name: token.Name, Scopes: token.Scopes}
+	}
+	return tokens
+}
+
+// listenFallback handles the DNS address failing to bind: when another program holds the
+// port, it names that program and, with dns_fallback_port, binds that port instead and has
+// the system DNS forward to it where it can. It returns the address bound.
+func listenFallback(cfg *config.Config, dnsServer *dns.Server, dnsAddr string, listenErr error) (string, error) {
+	if !sysdns.IsAddrInUse(listenErr) {
+		return "", listenErr
+	}
+	host, portText, _ := net.SplitHostPort(dnsAddr)
+	port, _ := strconv.Atoi(portText)
+	owner, err := sysdns.FindPortOwner(port)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	holder := "another program"
+	if owner != nil {
+		holder = owner.String()
+	}
+
+	fallback, _ := cfg.GetDNSFallbackPort()
+	if fallback == 0 {
+		return "", fmt.Errorf("failed to listen on %s: port %d is used by %s; %s, or set dns_fallback_port to listen on another port", dnsAddr, port, holder, portOwnerFix(owner, portText))
+	}
+	fallbackAddr := net.JoinHostPort(host, strconv.Itoa(fallback))
+	dnsServer.SetAddr(fallbackAddr)
+	if err := dnsServer.Listen(); err != nil {
+		return "", fmt.Errorf("port %d is used by %s, and the fallback failed: %w", port, holder, err)
+	}
+	log.Printf("Warning: port %d is used by %s, so DNS is served on %s instead (dns_fallback_port)", port, holder, fallbackAddr)
+
+	if port != 53 {
+		return fallbackAddr, nil
+	}
+	forward := sysdns.ForwardAddr(fallbackAddr)
+	switch err := sysdns.Forward(forward); {
+	case err == nil:
+		log.Printf("systemd-resolved forwards the system's queries to %s", forward)
+	case errors.Is(err, sysdns.ErrForwardUnsupported):
+		log.Printf("Warning: the system DNS still uses port 53; %s", forwardFix(owner, forward))
+	default:
+		log.Printf("Warning: failed to have systemd-resolved forward to %s: %v", forward, err)
+	}
+	return fallbackAddr, nil
+}
+
+// removeStaleForward stops systemd-resolved forwarding to a fallback port once the resolver
+// listens on its own port again
+func removeStaleForward() {
+	if sysdns.ForwardPort() == "" {
+		return
+	}
+	if removed, err := sysdns.RemoveForward(); err != nil {
+		log.Printf("Warning: failed to stop systemd-resolved forwarding to dns_fallback_port: %v", err)
+	} else if removed {
+		log.Printf("systemd-resolved no longer forwards to dns_fallback_port")
+	}
+}
+
+// portOwnerFix suggests how to free a port from the program holding it
+func portOwnerFix(owner *sysdns.PortOwner, port string) string {
+	name := ""
+	if owner != nil {
+		name = owner.Name
+	}
+	switch {
+	case name == "systemd-resolved" || name == "systemd-resolve":
+		return "systemd-resolved's stub listener holds 127.0.0.53:53; set dns_listen to 127.0.0.1:53"
+	case name == "dnsmasq":
+		return "stop dnsmasq, or limit it to other addresses with bind-interfaces (NetworkManager starts it with dns=dnsmasq, libvirt for its networks)"
+	case owner != nil:
+		return fmt.Sprintf("stop %s", name)
+	default:
+		return portConflictFix(port)
+	}
+}
+
+// forwardFix tells how to have the system DNS use a resolver on another port by hand
+func forwardFix(owner *sysdns.PortOwner, addr string) string {
+	host, port, _ := net.SplitHostPort(addr)
+	if owner != nil && owner.Name == "dnsmasq" {
+		return fmt.Sprintf("have dnsmasq forward to sinkzone with 'server=%s#%s' and 'no-resolv' in its config", host, port)
+	}
+	return fmt.Sprintf("forward it to %s, e.g. from the program on port 53", addr)
+}
+
+// dropPrivileges switches to the run_as user once the ports are bound. The data directory
+// is pinned first, as it is found through sudo only while running as root.
+func dropPrivileges(cfg *config.Config) error {
+	u, err := cfg.GetRunAs()
+	if err != nil || u == nil {
+		return err
+	}
+	dataDir := config.GetDataDir()
+	if err := os.Setenv(config.ConfigDirEnv, dataDir); err != nil {
+		return fmt.Errorf("failed to pin the data directory: %w", err)
+	}
+	if err := privileges.Drop(u, dataDir); err != nil {
+		return fmt.Errorf("failed to switch to user %s: %w", u.Username, err)
+	}
+	log.Printf("Running as user %s", u.Username)
+	return nil
+}
+
+// liveSettings are the settings 'sinkzone config' doesn't manage that a running resolver
+// applies without a restart
+var liveSettings = []string{"client_names", "users", "rules", "categories", "budgets", "schedules.block", "log_levels", "api_tokens", "allowlist_subscriptions", "blocklist_subscriptions", "private_zones.hosts"}
+
+// validateResolverConfig checks the settings of the resolver, its rules and schedules included
+func validateResolverConfig(cfg *config.Config) error {
+	if err := cfg.ValidateServer(); err != nil {
+		return err
+	}
+	if _, err := rules.Compile(cfg); err != nil {
+		return err
+	}
+	if _, err := schedule.NewBlocks(cfg.Schedules); err != nil {
+		return fmt.Errorf("invalid schedules config: %w", err)
+	}
+	return nil
+}
+
+// openQueryLog opens the query log where query_log.storage keeps it
+func openQueryLog(cfg *config.Config) (api.Storage, error) {
+	storage, _ := cfg.QueryLog.GetStorage()
+	if storage == config.QueryLogMemory {
+		log.Printf("The query log is kept in memory and lost when the resolver stops")
+		return api.NewMemoryStorage(), nil
+	}
+	queryLog, err := api.OpenQueryLog(config.GetQueryLogPath())
+	if err != nil {
+		// Not a nil *QueryLog in a non-nil Storage
+		return nil, err
+	}
+	return queryLog, nil
+}
+
+// reloadResolverConfig applies the settings of a changed config file that a running resolver
+// can switch to, and logs which changes wait for a restart. It returns the config later
+// changes are compared with.
+func reloadResolverConfig(current, next *config.Config, dnsServer *dns.Server, apiServer *api.Server, queryLog api.Storage, refresher *subscription.Refresher, vpnWatcher *vpn.Watcher) *config.Config {
+	changed := config.ChangedKeys(current, next)
+	if len(changed) == 0 {
+		return current
+	}
+	if err := validateResolverConfig(next); err != nil {
+		log.Printf("Warning: not applying changes to %s: %v", config.GetConfigPath(), err)
+		return current
+	}
+
+	var applied, pending []string
+	for _, name := range changed {
+		if key, err := config.LookupKey(name); (err == nil && key.Live) || slices.Contains(liveSettings, name) {
+			applied = append(applied, name)
+		} else {
+			pending = append(pending, name)
+		}
+	}
+
+	dnsServer.ApplyConfig(next)
+	refresher.SetConfig(next)
+	vpnWatcher.SetConfig(next.VPN)
+	if slices.Contains(applied, "allowlist_subscriptions") || slices.Contains(applied, "blocklist_subscriptions") {
+		if err := dnsServer.ReloadLists(); err != nil {
+			log.Printf("Warning: failed to reload the lists after a subscription change: %v", err)
+		}
+	}
+	apiServer.SetTokens(apiTokens(next))
+	apiServer.SetTriggerSecret(next.TriggerSecret)
+	apiServer.SetMutationsLocalOnly(!next.AcceptsRemoteMutations())
+	historySize, historyMaxBytes, _ := next.RecentQueries.GetLimits()
+	apiServer.SetHistoryLimits(historySize, historyMaxBytes)
+	historyAggregate, _ := next.RecentQueries.GetAggregate()
+	apiServer.SetHistoryAggregate(historyAggregate)
+	if queryLog != nil {
+		maxAge, _ := next.GetQueryRetention()
+		maxRecords, _ := next.GetMaxQueryRecords()
+		queryLog.SetRetention(api.Retention{MaxAge: maxAge, MaxRecords: maxRecords})
+		sampleRate, _ := next.QueryLog.GetSampleRate()
+		queryLog.SetSampleRate(sampleRate)
+	}
+	if slices.Contains(applied, "log_level") || slices.Contains(applied, "log_levels") {
+		if verbose || quiet || logLevel != "" {
+			log.Printf("log_level changed, but the command line sets the log level")
+		} else {
+			level, _ := next.GetLogLevel()
+			levels, _ := next.GetLogLevels()
+			logs.SetLevel(level)
+			logs.SetComponentLevels(levels)
+		}
+	}
+	if slices.Contains(applied, "log_format") {
+		if logFormat != "" {
+			log.Printf("log_format changed, but the command line sets the log format")
+		} else {
+			format, _ := next.GetLogFormat()
+			logs.SetFormat(format)
+		}
+	}
+
+	if len(applied) > 0 {
+		log.Printf("Config reloaded, applied: %s", strings.Join(applied, ", "))
+	}
+	if len(pending) > 0 {
+		log.Printf("Warning: restart the resolver to apply: %s", strings.Join(pending, ", "))
+	}
+	return next
+}
+
+// maxConfigPollInterval bounds the config_poll_interval runtime setting, so a changed file
+// is never missed for long
+const maxConfigPollInterval = time.Minute
+
+// resolverSettings backs GET and PATCH /api/settings
+type resolverSettings struct {
+	dns          *dns.Server
+	syncer       *peersync.Syncer // nil without sync
+	pollInterval atomic.Int64     // How often sinkzone.yaml is checked for changes
+	mu           sync.Mutex       // Serializes changes
+}
+
+func (r *resolverSettings) configPollInterval() time.Duration {
+	return time.Duration(r.pollInterval.Load())
+}
+
+func (r *resolverSettings) get() api.RuntimeSettings {
+	rate, burst := r.dns.RateLimit()
+	settings := api.RuntimeSettings{
+		LogLevel:           logs.CurrentLevel().String(),
+		DryRun:             r.dns.DryRun(),
+		RateLimit:          api.RateLimitSettings{QueriesPerSecond: rate, Burst: burst},
+		ConfigPollInterval: r.configPollInterval().String(),
+	}
+	if r.syncer != nil {
+		settings.SyncInterval = r.syncer.Interval().String()
+	}
+	return settings
+}
+
+// patch checks every change before applying any, so a rejected patch changes nothing
+func (r *resolverSettings) patch(patch api.SettingsPatch) (api.RuntimeSettings, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var level logs.Level
+	if patch.LogLevel != nil {
+		var err error
+		if level, err = logs.ParseLevel(*patch.LogLevel); err != nil {
+			return api.RuntimeSettings{}, err
+		}
+	}
+	var rate, burst int
+	if patch.RateLimit != nil {
+		limit := config.RateLimitConfig{QueriesPerSecond: patch.RateLimit.QueriesPerSecond, Burst: patch.RateLimit.Burst}
+		var err error
+		if rate, burst, err = limit.GetLimit(); err != nil {
+			return api.RuntimeSettings{}, err
+		}
+	}
+	var pollInterval time.Duration
+	if patch.ConfigPollInterval != nil {
+		var err error
+		pollInterval, err = time.ParseDuration(*patch.ConfigPollInterval)
+		if err != nil || pollInterval < time.Second || pollInterval > maxConfigPollInterval {
+			return api.RuntimeSettings{}, fmt.Errorf("invalid config_poll_interval %q: must be a duration from 1s to %s", *patch.ConfigPollInterval, maxConfigPollInterval)
+		}
+	}
+	var syncInterval time.Duration
+	if patch.SyncInterval != nil {
+		if r.syncer == nil {
+			return api.RuntimeSettings{}, fmt.Errorf("sync_interval needs sync peers in sinkzone.yaml")
+		}
+		var err error
+		if syncInterval, err = (&config.SyncConfig{Interval: *patch.SyncInterval}).GetInterval(); err != nil {
+			return api.RuntimeSettings{}, err
+		}
+	}
+	// The only change that can still fail, on the PIN
+	if patch.DryRun != nil {
+		if err := r.dns.SetDryRun(*patch.DryRun, patch.PIN); err != nil {
+			return api.RuntimeSettings{}, err
+		}
+	}
+
+	if patch.LogLevel != nil {
+		logs.SetLevel(level)
+		log.Printf("Log level set to %s", level)
+	}
+	if patch.RateLimit != nil {
+		r.dns.SetRateLimit(rate, burst)
+		if rate == 0 {
+			log.Printf("Rate limit turned off")
+		} else {
+			log.Printf("Rate limit set to %d queries per second (burst %d)", rate, burst)
+		}
+	}
+	if patch.ConfigPollInterval != nil {
+		r.pollInterval.Store(int64(pollInterval))
+		log.Printf("Config poll interval set to %s", pollInterval)
+	}
+	if patch.SyncInterval != nil {
+		r.syncer.SetInterval(syncInterval)
+		log.Printf("Sync interval set to %s", syncInterval)
+	}
+	return r.get(), nil
+}
+
+// saveUpstreams replaces upstream_nameservers in sinkzone.yaml and returns the saved config
+func saveUpstreams(upstreams []string) (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if env := cfg.OverriddenBy("upstream_nameservers"); env != "" {
+		return nil, fmt.Errorf("upstream_nameservers is set by %s", env)
+	}
+	key, err := config.LookupKey("upstream_nameservers")
+	if err != nil {
+		return nil, err
+	}
+	if err := key.Set(cfg, strings.Join(upstreams, ",")); err != nil {
+		return nil, err
+	}
+	if err := cfg.ValidateServer(); err != nil {
+		return nil, err
+	}
+	if err := config.Save(cfg); err != nil {
+		return nil, fmt.Errorf("failed to save config: %w", err)
+	}
+	return cfg, nil
+}
+
+// saveAPIToken adds a token to api_tokens in sinkzone.yaml under name, or under name-2,
+// name-3, ... when name is taken, returning the config and the name
+func saveAPIToken(name, secret string, scopes []string) (*config.Config, string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	taken := map[string]bool{}
+	for _, token := range cfg.APITokens {
+		taken[token.Name] = true
+	}
+	unique := name
+	for i := 2; taken[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	cfg.APITokens = append(cfg.APITokens, config.APIToken{Name: unique, Token: secret, Scopes: scopes})
+	if err := cfg.ValidateServer(); err != nil {
+		return nil, "", err
+	}
+	if err := config.Save(cfg); err != nil {
+		return nil, "", fmt.Errorf("failed to save config: %w", err)
+	}
+	return cfg, unique, nil
+}
+
+// resolverListen returns the DNS and API listen addresses: from --port, --api-addr, and
+// --api-port when given, otherwise from dns_listen and api_listen in sinkzone.yaml. LAN
+// mode binds both on all interfaces, unless --api-addr says otherwise.
+func resolverListen(cfg *config.Config) (string, string, error) {
+	dnsAddr, err := cfg.GetDNSListen()
+	if err != nil {
+		return "", "", err
+	}
+	if portSet {
+		dnsAddr = ":" + port
+	}
+
+	var listen string
+	switch {
+	case apiAddrSet && apiPortSet:
+		return "", "", fmt.Errorf("use either --api-addr or --api-port, not both")
+	case apiAddrSet:
+		listen, err = cfg.CheckAPIListen("--api-addr", resolverAPIAddr)
+	case apiPortSet:
+		listen, err = cfg.CheckAPIListen("--api-port", net.JoinHostPort("127.0.0.1", apiPort))
+	default:
+		listen, err = cfg.GetAPIListen()
+	}
+	if err != nil {
+		return "", "", err
+	}
+	if cfg.IsLAN() {
+		// Every device of the network queries the resolver, and may open its dashboard
+		dnsAddr = allInterfaces(dnsAddr)
+		if !apiAddrSet {
+			listen = allInterfaces(listen)
+		}
+	}
+	return dnsAddr, listen, nil
+}
+
+// allInterfaces returns a listen address with the port of addr on every interface
+func allInterfaces(addr string) string {
+	return net.JoinHostPort("", listenPort(addr))
+}
+
+// checkPprofAddr accepts only loopback addresses for --debug-pprof
+func checkPprofAddr(addr string) error {
+	if !config.IsLoopbackListen(addr) {
+		return fmt.Errorf("invalid --debug-pprof %q: use a loopback address such as %s, since profiles expose the resolver's memory", addr, defaultPprofAddr)
+	}
+	return nil
+}
+
+// newPprofServer returns a server of the net/http/pprof handlers on addr, which must be a
+// loopback address, without the write timeout a CPU profile or trace would run into
+func newPprofServer(addr string) (*http.Server, error) {
+	if err := checkPprofAddr(addr); err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second, // Prevent Slowloris attacks
+	}, nil
+}
+
+// listenPort returns the port of a listen address such as 127.0.0.1:53
+func listenPort(addr string) string {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return port
+}
+
+// applyResolverLogging applies log_level, log_levels, and log_format unless a flag sets them,
+// and sends the log to the system log with log_output: syslog, or else to log_file, or
+// resolver.log when running in the background, rotating it after log_rotation
+func applyResolverLogging(cfg *config.Config) error {
+	if !verbose && !quiet && logLevel == "" {
+		level, err := cfg.GetLogLevel()
+		if err != nil {
+			return err
+		}
+		levels, err := cfg.GetLogLevels()
+		if err != nil {
+			return err
+		}
+		logs.SetLevel(level)
+		logs.SetComponentLevels(levels)
+	}
+	if logFormat == "" {
+		format, err := cfg.GetLogFormat()
+		if err != nil {
+			return err
+		}
+		logs.SetFormat(format)
+	}
+	if output, err := cfg.GetLogOutput(); err != nil {
+		return err
+	} else if output == logs.OutputSyslog {
+		sysLog, err := logs.OpenSyslog()
+		if err != nil {
+			return err
+		}
+		logs.SetOutput(sysLog)
 		return nil
-	},
+	}
+	path := cfg.LogFile
+	if path == "" && os.Getenv(daemonEnv) != "" {
+		// The daemon's output already goes to resolver.log, but only an open log rotates
+		var err error
+		if path, err = getResolverLogPath(); err != nil {
+			return err
+		}
+	}
+	if path == "" {
+		return nil
+	}
+	rotation, err := cfg.LogRotation.GetRotation()
+	if err != nil {
+		return err
+	}
+	logFile, err := logs.OpenFile(path, rotation)
+	if err != nil {
+		return err
+	}
+	logs.SetOutput(logFile)
+	return nil
+}
+
+// runResolverService runs the resolver under the Windows service manager, logging to
+// resolver.log next to the PID file since services have no console
+func runResolverService() error {
+	if logPath, err := getResolverLogPath(); err == nil {
+		rotation := logs.Rotation{MaxSize: config.DefaultLogMaxSize << 20, MaxFiles: config.DefaultLogMaxFiles}
+		if cfg, err := config.Load(); err == nil {
+			if configured, err := cfg.LogRotation.GetRotation(); err == nil {
+				rotation = configured
+			}
+		}
+		if logFile, err := logs.OpenFile(logPath, rotation); err == nil {
+			logs.SetOutput(logFile)
+		}
+	}
+	return service.RunWindowsService(runResolver)
+}
+
+// stopResolver stops the resolver recorded in the PID file and waits for it to exit
+func stopResolver() error {
+	pidFile, err := getPIDFilePath()
+	if err != nil {
+		return fmt.Errorf("failed to get PID file path: %w", err)
+	}
+
+	// #nosec G304 -- pidFile is a hardcoded path from user home directory
+	pidData, err := os.ReadFile(pidFile)
+	if os.IsNotExist(err) {
+		fmt.Println("Resolver is not running.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read PID file: %w", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidData)))
+	if err != nil || pid <= 0 || !processRunning(pid) {
+		if err := os.Remove(pidFile); err != nil {
+			return fmt.Errorf("failed to remove stale PID file: %w", err)
+		}
+		fmt.Printf("Resolver is not running. Removed stale PID file %s.\n", pidFile)
+		return nil
+	}
+	// The PID may have been reused by another program since the resolver crashed
+	if !isResolverProcess(pid) {
+		if err := os.Remove(pidFile); err != nil {
+			return fmt.Errorf("failed to remove stale PID file: %w", err)
+		}
+		fmt.Printf("Process %d is not a sinkzone resolver, so it was left running. Removed stale PID file %s.\n", pid, pidFile)
+		return nil
+	}
+
+	if err := signalResolver(pid); err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(resolverStopTimeout)
+	for processRunning(pid) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("resolver (PID %d) did not stop within %s", pid, resolverStopTimeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The resolver removes its PID file on shutdown; clean up in case it couldn't
+	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
+		log.Printf("Warning: failed to remove PID file: %v", err)
+	}
+
+	fmt.Printf("Resolver stopped (PID %d).\n", pid)
+	return nil
+}
+
+// signalResolver asks the resolver to shut down: with SIGTERM where signals are available,
+// otherwise (or when the resolver runs as another user) through the API
+func signalResolver(pid int) error {
+	if runtime.GOOS != "windows" {
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			return fmt.Errorf("failed to find resolver process: %w", err)
+		}
+		err = process.Signal(syscall.SIGTERM)
+		if err == nil {
+			return nil
+		}
+		if !errors.Is(err, os.ErrPermission) && !errors.Is(err, syscall.EPERM) {
+			return fmt.Errorf("failed to signal resolver: %w", err)
+		}
+	}
+
+	pin := resolverPIN
+	if pin == "" {
+		pin = os.Getenv("SINKZONE_PIN")
+	}
+	client, shutdownPort := localResolverClient()
+	if err := client.Shutdown(pin); err != nil {
+		return fmt.Errorf("failed to stop resolver (PID %d) through the API on port %s: %w", pid, shutdownPort, err)
+	}
+	return nil
+}
+
+// localResolverClient returns a client of the API of the resolver on this machine, at the
+// port the flags or sinkzone.yaml give, and that port
+func localResolverClient() (*api.Client, string) {
+	port := apiPort
+	scheme := "http"
+	if apiAddrSet {
+		port = listenPort(resolverAPIAddr)
+	} else if cfg, err := config.Load(); err == nil && !apiPortSet {
+		if listen, err := cfg.GetAPIListen(); err == nil {
+			port = listenPort(listen)
+		}
+	}
+	if cfg, err := config.Load(); err == nil && cfg.APITLS.IsEnabled() {
+		scheme = "https"
+	}
+	return api.NewClient(scheme + "://127.0.0.1:" + port), port
+}
+
+// isResolverProcess reports whether the process with the PID is a sinkzone resolver: the
+// resolver's API reports that PID, or, on Linux, the process runs a sinkzone binary
+func isResolverProcess(pid int) bool {
+	client, _ := localResolverClient()
+	if reported, err := client.GetPID(); err == nil {
+		return reported == pid
+	}
+	if runtime.GOOS != "linux" {
+		return false
+	}
+
+	// The command line is readable even when the resolver runs as another user
+	cmdline, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil {
+		return false
+	}
+	name, _, _ := strings.Cut(string(cmdline), "\x00")
+	name = filepath.Base(name)
+	if strings.Contains(name, "sinkzone") {
+		return true
+	}
+	self, err := os.Executable()
+	return err == nil && name == filepath.Base(self)
+}
+
+// processRunning reports whether a process with the PID exists
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process on Windows, which fails once it has exited
+		_ = process.Release()
+		return true
+	}
+
+	// Signal 0 checks for existence; EPERM means it exists but belongs to another user
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
 }
 
 func init() {
-	resolverCmd.Flags().StringVarP(&port, "port", "p", "53", "Port to bind the DNS server to")
-	resolverCmd.Flags().StringVarP(&apiPort, "api-port", "a", "8080", "Port to bind the HTTP API server to")
+	resolverCmd.Flags().StringVarP(&port, "port", "p", "53", "Port to bind the DNS server to on all interfaces (overrides dns_listen)")
+	resolverCmd.Flags().StringVarP(&apiPort, "api-port", "a", "8080", "Port to bind the HTTP API server to on 127.0.0.1 (overrides api_listen)")
+	resolverCmd.Flags().StringVar(&resolverAPIAddr, "api-addr", "", "Address to bind the HTTP API server to, e.g. 127.0.0.1:8081 (overrides api_listen; other interfaces need api_allow_remote)")
+	resolverCmd.Flags().BoolVar(&resolverLAN, "lan", false, "Serve the devices of this network: DNS and the API on all interfaces, API tokens required (same as lan: true)")
+	resolverCmd.Flags().BoolVar(&resolverTailscale, "tailscale", false, "Join a tailnet and serve DNS to its machines wherever they are (same as tailscale.enabled: true)")
+	resolverCmd.Flags().BoolVarP(&resolverDaemon, "daemon", "d", false, "Run in the background, logging to resolver.log next to the PID file")
+	resolverCmd.Flags().StringVar(&resolverPprof, "debug-pprof", "", "Serve Go's pprof profiles on a loopback address, "+defaultPprofAddr+" when given without one")
+	resolverCmd.Flags().StringVar(&resolverPIN, "pin", "", "Focus PIN, required by stop and restart to end a PIN-protected session through the API (or set SINKZONE_PIN)")
+	resolverCmd.Flags().Lookup("debug-pprof").NoOptDefVal = defaultPprofAddr
 }
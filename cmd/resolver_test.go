@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestSaveUpstreams(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	t.Setenv(config.SystemConfigEnv, "none")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.UpstreamNameservers = []string{"8.8.8.8"}
+	cfg.BlockResponse = "refused"
+	cfg.DailyGoal = "4h"
+	if err := config.Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		upstreams []string
+		saved     []string
+		fails     bool
+	}{
+		{[]string{"not an upstream"}, []string{"8.8.8.8"}, true},
+		{[]string{"9.9.9.9", "ftp://1.1.1.1"}, []string{"8.8.8.8"}, true},
+		{[]string{"9.9.9.9", "tls://1.1.1.1"}, []string{"9.9.9.9", "tls://1.1.1.1"}, false},
+	}
+	for _, test := range tests {
+		if _, err := saveUpstreams(test.upstreams); (err != nil) != test.fails {
+			t.Errorf("%v: expected failure %v, got %v", test.upstreams, test.fails, err)
+		}
+		saved, err := config.Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(saved.UpstreamNameservers, test.saved) {
+			t.Errorf("%v: expected the saved upstreams %v, got %v", test.upstreams, test.saved, saved.UpstreamNameservers)
+		}
+		if saved.BlockResponse != "refused" || saved.DailyGoal != "4h" {
+			t.Errorf("%v: expected the other settings to be kept, got %q and %q", test.upstreams, saved.BlockResponse, saved.DailyGoal)
+		}
+	}
+
+	t.Setenv("SINKZONE_UPSTREAM_NAMESERVERS", "1.1.1.1")
+	if _, err := saveUpstreams([]string{"9.9.9.9"}); err == nil {
+		t.Error("expected saving to fail while the environment overrides the upstreams")
+	}
+}
+
+func TestNewPprofServer(t *testing.T) {
+	flag := resolverCmd.Flags().Lookup("debug-pprof")
+	if flag.DefValue != "" || flag.NoOptDefVal != defaultPprofAddr {
+		t.Errorf("expected pprof to be off unless --debug-pprof is given, got default %q and %q without a value", flag.DefValue, flag.NoOptDefVal)
+	}
+
+	tests := []struct {
+		addr  string
+		fails bool
+	}{
+		{defaultPprofAddr, false},
+		{"[::1]:6060", false},
+		{"localhost:6060", false},
+		{"0.0.0.0:6060", true},
+		{":6060", true},
+		{"[::]:6060", true},
+		{"192.168.1.2:6060", true},
+		{"127.0.0.1", true},
+	}
+	for _, test := range tests {
+		server, err := newPprofServer(test.addr)
+		if (err != nil) != test.fails || (server == nil) != test.fails {
+			t.Errorf("%s: expected failure %v, got %v", test.addr, test.fails, err)
+		}
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := newPprofServer(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(func() { _ = server.Close() })
+
+	for path, status := range map[string]int{"/debug/pprof/cmdline": http.StatusOK, "/api/health": http.StatusNotFound} {
+		resp, err := http.Get("http://" + listener.Addr().String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != status {
+			t.Errorf("%s: expected %d, got %d", path, status, resp.StatusCode)
+		}
+		if status == http.StatusOK && !strings.Contains(string(body), "test") {
+			t.Errorf("%s: expected the test binary's command line, got %q", path, body)
+		}
+	}
+}
@@ -1,29 +1,216 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/crash"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/berbyte/sinkzone/internal/version"
 	"github.com/spf13/cobra"
 )
 
+// Logging flags; applyLogLevel turns them into the level and format of the log
+var (
+	verbose   bool
+	quiet     bool
+	logLevel  string
+	logFormat string
+)
+
+// configFile is the --config flag, made absolute so a background resolver finds the same file
+var configFile string
+
+// dataDir is the --data-dir flag, passed on to background resolvers through the environment
+var dataDir string
+
 var rootCmd = &cobra.Command{
 	Use:   "sinkzone",
 	Short: "DNS-based productivity tool",
 	Long: `Sinkzone is a DNS-based productivity tool that helps you stay focused by blocking distracting websites in real time.
 
 It works by intercepting DNS requests and enforcing a focus mode, where only allowed domains are accessible.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := applyConfigFlag(); err != nil {
+			return err
+		}
+		api.SetTokenSource(config.ClientAPIToken)
+		configureAPIClientTLS()
+		if err := applyLogLevel(); err != nil {
+			return err
+		}
+		selectLanguage()
+		configureCrashReports()
+		return validateOutputFormat()
+	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// If no subcommand is provided, show help
 		return cmd.Help()
 	},
 }
 
-func Execute() error {
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputText, "Output format for status, stats, report, cache, bench, monitor, queries, simulate, check, version, allowlist list, blocklist list, lists status, profile list/show, schedule list, and config list/get: text or json")
+	rootCmd.PersistentFlags().BoolVar(&outputAsJSON, "json", false, "Shorthand for --output json")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Log debug details, such as every API request and DNS query (same as --log-level debug)")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Log only errors (same as --log-level error)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "", "Lowest level logged: debug, info, warn, or error (default info)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "", "Format of the log: text or json (default text)")
+	rootCmd.PersistentFlags().StringVar(&dataDir, "data-dir", "", "Directory sinkzone keeps its files in (default: $"+config.ConfigDirEnv+", or ~/.sinkzone)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file to use (default: $"+config.ConfigFileEnv+", or sinkzone.yaml in $"+config.ConfigDirEnv+" or ~/.sinkzone)")
+
 	rootCmd.AddCommand(monitorCmd)
 	rootCmd.AddCommand(tuiCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(trayCmd)
 	rootCmd.AddCommand(focusCmd)
 	rootCmd.AddCommand(resolverCmd)
+	rootCmd.AddCommand(serviceCmd)
+	rootCmd.AddCommand(setupCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(statsCmd)
+	rootCmd.AddCommand(reportCmd)
+	rootCmd.AddCommand(cacheCmd)
+	rootCmd.AddCommand(passthroughCmd)
+	rootCmd.AddCommand(benchCmd)
+	rootCmd.AddCommand(queriesCmd)
+	rootCmd.AddCommand(logsCmd)
 	rootCmd.AddCommand(allowlistCmd)
+	rootCmd.AddCommand(suggestionsCmd)
+	rootCmd.AddCommand(simulateCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(blocklistCmd)
+	rootCmd.AddCommand(listsCmd)
+	rootCmd.AddCommand(extensionCmd)
+	rootCmd.AddCommand(devicesCmd)
+	rootCmd.AddCommand(clientsCmd)
+	rootCmd.AddCommand(profileCmd)
+	rootCmd.AddCommand(scheduleCmd)
+	rootCmd.AddCommand(calendarCmd)
 	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(backupCmd)
+	rootCmd.AddCommand(certCmd)
 	rootCmd.AddCommand(manCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(selfUpdateCmd)
+
+	rootCmd.Version = version.Get().String()
+	rootCmd.SetVersionTemplate("{{.Version}}\n")
+}
+
+// Root returns the root command, used to generate the man pages and markdown docs
+func Root() *cobra.Command {
+	return rootCmd
+}
+
+func Execute() error {
 	return rootCmd.Execute()
 }
+
+// applyConfigFlag points the config package at the directory chosen with --data-dir and the
+// file chosen with --config or SINKZONE_CONFIG
+func applyConfigFlag() error {
+	if dataDir != "" {
+		path, err := filepath.Abs(dataDir)
+		if err != nil {
+			return fmt.Errorf("failed to resolve data directory: %w", err)
+		}
+		if err := os.Setenv(config.ConfigDirEnv, path); err != nil {
+			return fmt.Errorf("failed to set data directory: %w", err)
+		}
+	}
+	if configFile == "" {
+		configFile = os.Getenv(config.ConfigFileEnv)
+	}
+	if configFile == "" {
+		return nil
+	}
+	path, err := filepath.Abs(configFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	configFile = path
+	config.SetConfigPath(path)
+	return nil
+}
+
+// applyLogLevel sets the level of the log from --verbose, --quiet, or --log-level, and its
+// format from --log-format
+func applyLogLevel() error {
+	set := 0
+	for _, given := range []bool{verbose, quiet, logLevel != ""} {
+		if given {
+			set++
+		}
+	}
+	if set > 1 {
+		return fmt.Errorf("use only one of --verbose, --quiet, and --log-level")
+	}
+
+	level := logs.LevelInfo
+	switch {
+	case verbose:
+		level = logs.LevelDebug
+	case quiet:
+		level = logs.LevelError
+	case logLevel != "":
+		parsed, err := logs.ParseLevel(logLevel)
+		if err != nil {
+			return err
+		}
+		level = parsed
+	}
+	format, err := logs.ParseFormat(logFormat)
+	if err != nil {
+		return err
+	}
+	logs.SetLevel(level)
+	logs.SetFormat(format)
+	logs.SetOutput(os.Stderr)
+	return nil
+}
+
+// selectLanguage applies the configured language, or the locale from the environment.
+// A missing config or an unsupported language only prints a warning.
+// configureCrashReports turns on crash reports when crash_reports.enabled is set
+func configureCrashReports() {
+	cfg, err := config.Load()
+	if err != nil || !cfg.CrashReports.IsEnabled() {
+		return
+	}
+	uploadURL, err := cfg.CrashReports.GetUploadURL()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	crash.Configure(crash.Options{Dir: config.GetCrashDir(), UploadURL: uploadURL})
+}
+
+// configureAPIClientTLS sets the certificates of api_client_tls for connecting to an https API
+func configureAPIClientTLS() {
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	tlsConfig, err := cfg.APIClientTLS.ClientTLS()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		return
+	}
+	api.SetClientTLS(tlsConfig)
+}
+
+func selectLanguage() {
+	language := ""
+	if cfg, err := config.Load(); err == nil {
+		language = cfg.Language
+	}
+	if err := i18n.SetLanguage(language); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		_ = i18n.SetLanguage("")
+	}
+}
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/schedule"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleProfile string
+	scheduleLabel   string
+	scheduleBlock   []string
+)
+
+// scheduleEntry is printed by 'schedule list --output json'
+type scheduleEntry struct {
+	Number  int        `json:"number"` // Position used by 'schedule remove'
+	When    string     `json:"when"`
+	Profile string     `json:"profile,omitempty"`
+	Label   string     `json:"label,omitempty"`
+	Block   []string   `json:"block,omitempty"` // Domains blocked instead of starting a session
+	Next    *time.Time `json:"next,omitempty"`  // Start of the next window
+	Error   string     `json:"error,omitempty"` // Why the schedule is invalid
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule [add/list/remove] [when|number]",
+	Short: "Manage recurring focus schedules",
+	Long: `Add, list, or remove recurring focus schedules: weekly windows during which the resolver starts focus mode by itself, or blocks some domains.
+
+  sinkzone schedule add "Mon-Fri 09:00-17:00" --profile work
+  sinkzone schedule add "Sat,Sun 10:00-12:00" --label reading
+  sinkzone schedule add "daily 22:00-06:00"      Overnight windows end the next day
+  sinkzone schedule add "weekdays 09:00-17:00" --block reddit.com --block "*.twitter.com"
+  sinkzone schedule list                         Show schedules and their next start
+  sinkzone schedule remove 2                     Remove a schedule by its number
+
+Days are day names or ranges (Mon-Fri, Fri-Mon, Mon,Wed,Fri), or weekdays, weekends, or daily. Times are local, in 24-hour HH:MM.
+
+Schedules are stored under schedules in sinkzone.yaml. Like calendar sessions, a scheduled session never overrides a running manual session, takes over when one ends during the window, and stays off for the rest of the window once disabled. Restart the resolver to apply changes.
+
+With --block, a schedule starts no session: it blocks the given domains or wildcard patterns during its windows, whether or not a focus session runs, and nothing else. Blocking schedules take no profile or label, and a running resolver applies changes to them within a few seconds.`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeScheduleArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := args[0]
+
+		switch command {
+		case "add":
+			if len(args) != 2 {
+				return fmt.Errorf("usage: sinkzone schedule add \"Mon-Fri 09:00-17:00\" [--profile name]")
+			}
+			cmd.SilenceUsage = true
+			return addSchedule(args[1])
+		case "list":
+			if len(args) > 1 {
+				return fmt.Errorf("'list' takes no arguments")
+			}
+			cmd.SilenceUsage = true
+			return listSchedules()
+		case "remove":
+			if len(args) != 2 {
+				return fmt.Errorf("usage: sinkzone schedule remove <number>")
+			}
+			cmd.SilenceUsage = true
+			return removeSchedule(args[1])
+		default:
+			return fmt.Errorf("unknown command: %s. Use 'add', 'list', or 'remove'", command)
+		}
+	},
+}
+
+func init() {
+	scheduleCmd.Flags().StringVar(&scheduleProfile, "profile", "", "Focus profile used for the scheduled sessions (used with 'add')")
+	scheduleCmd.Flags().StringVar(&scheduleLabel, "label", "", "Project label the scheduled sessions are attributed to (used with 'add')")
+	scheduleCmd.Flags().StringArrayVar(&scheduleBlock, "block", nil, "Domain or wildcard pattern to block during the windows instead of starting a session; repeat for more (used with 'add')")
+	_ = scheduleCmd.RegisterFlagCompletionFunc("profile", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return profileNames(), cobra.ShellCompDirectiveNoFileComp
+	})
+}
+
+// completeScheduleArgs completes the subcommand, then schedule numbers for remove
+func completeScheduleArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch {
+	case len(args) == 0:
+		return []string{"add", "list", "remove"}, cobra.ShellCompDirectiveNoFileComp
+	case len(args) == 1 && args[0] == "remove":
+		cfg, err := config.Load()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		numbers := make([]string, len(cfg.Schedules))
+		for i, entry := range cfg.Schedules {
+			numbers[i] = fmt.Sprintf("%d\t%s", i+1, entry.When)
+		}
+		return numbers, cobra.ShellCompDirectiveNoFileComp
+	default:
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func addSchedule(when string) error {
+	spec, err := schedule.Parse(when)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if scheduleProfile != "" {
+		if _, err := cfg.GetProfile(scheduleProfile); err != nil {
+			return err
+		}
+	}
+	if len(scheduleBlock) > 0 && (scheduleProfile != "" || scheduleLabel != "") {
+		return fmt.Errorf("a schedule that blocks domains starts no session, so it takes no --profile or --label")
+	}
+	for _, pattern := range scheduleBlock {
+		if err := allowlist.ValidatePattern(pattern); err != nil {
+			return fmt.Errorf("invalid domain %q: %w", pattern, err)
+		}
+	}
+	entry := config.Schedule{When: when, Profile: scheduleProfile, Label: scheduleLabel, Block: scheduleBlock}
+	for _, existing := range cfg.Schedules {
+		if existing.When == when && existing.Profile == scheduleProfile && slices.Equal(existing.Block, scheduleBlock) {
+			return fmt.Errorf("schedule %q already exists", when)
+		}
+	}
+
+	cfg.Schedules = append(cfg.Schedules, entry)
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("Schedule %d added: %s", len(cfg.Schedules), spec)
+	if scheduleProfile != "" {
+		fmt.Printf(" (profile %s)", scheduleProfile)
+	}
+	if !entry.StartsFocus() {
+		fmt.Printf(" (blocks %s)", strings.Join(entry.Block, ", "))
+	}
+	fmt.Println()
+	if entry.StartsFocus() {
+		fmt.Println("Next sessions:")
+	} else {
+		fmt.Println("Next windows:")
+	}
+	for _, start := range spec.Next(time.Now(), 3) {
+		fmt.Printf("  %s\n", start.Format("Mon Jan 2 15:04"))
+	}
+	printScheduleNote(entry)
+	return nil
+}
+
+// printScheduleNote tells when a running resolver applies a change to a schedule
+func printScheduleNote(entry config.Schedule) {
+	if entry.StartsFocus() {
+		fmt.Println("Note: Restart the resolver to apply schedule changes.")
+	} else {
+		fmt.Println("A running resolver applies the change within a few seconds.")
+	}
+}
+
+func listSchedules() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	now := time.Now()
+	entries := make([]scheduleEntry, 0, len(cfg.Schedules))
+	for i, entry := range cfg.Schedules {
+		result := scheduleEntry{Number: i + 1, When: entry.When, Profile: entry.Profile, Label: entry.Label, Block: entry.Block}
+		if spec, err := schedule.Parse(entry.When); err != nil {
+			result.Error = err.Error()
+		} else if next := spec.Next(now, 1); len(next) > 0 {
+			result.Next = &next[0]
+		}
+		entries = append(entries, result)
+	}
+
+	if jsonOutput() {
+		return printJSON(entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No schedules configured. Add one with 'sinkzone schedule add \"Mon-Fri 09:00-17:00\"'.")
+		return nil
+	}
+
+	fmt.Printf("Schedules (%d):\n", len(entries))
+	for _, entry := range entries {
+		fmt.Printf("  %d. %s", entry.Number, entry.When)
+		if entry.Profile != "" {
+			fmt.Printf("  profile: %s", entry.Profile)
+		}
+		if entry.Label != "" {
+			fmt.Printf("  label: %s", entry.Label)
+		}
+		if len(entry.Block) > 0 {
+			fmt.Printf("  blocks: %s", strings.Join(entry.Block, ", "))
+		}
+		switch {
+		case entry.Error != "":
+			fmt.Printf("  (%s)", entry.Error)
+		case entry.Next != nil:
+			fmt.Printf("  next: %s", entry.Next.Format("Mon Jan 2 15:04"))
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func removeSchedule(arg string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	number, err := strconv.Atoi(arg)
+	if err != nil || number < 1 || number > len(cfg.Schedules) {
+		return fmt.Errorf("no schedule %s; run 'sinkzone schedule list' to see the numbers", arg)
+	}
+	removed := cfg.Schedules[number-1]
+	cfg.Schedules = append(cfg.Schedules[:number-1], cfg.Schedules[number:]...)
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("Schedule %d removed: %s\n", number, removed.When)
+	printScheduleNote(removed)
+	return nil
+}
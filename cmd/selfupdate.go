@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/update"
+	"github.com/berbyte/sinkzone/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var (
+	selfUpdateCheckOnly bool
+	selfUpdateForce     bool
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update sinkzone to the latest release",
+	Long: `Checks the latest GitHub release and, if it is newer, downloads the binary for this platform, verifies it against the release's checksums.txt (SHA-256), and replaces the running binary. The old binary stays in place if anything fails.
+
+Installs from Homebrew or a Linux package should be updated with the package manager instead. Replacing a binary in a system directory needs sudo (Administrator on Windows).
+
+Restart a running resolver afterwards ('sinkzone resolver restart') to run the new version.
+
+Examples:
+  sinkzone self-update --check-only
+  sudo sinkzone self-update`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return selfUpdate()
+	},
+}
+
+func init() {
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheckOnly, "check-only", false, "Only report whether an update is available")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "Install the latest release even if this version is not older")
+}
+
+func selfUpdate() error {
+	current := version.Get().Version
+	release, err := update.Latest()
+	if err != nil {
+		return err
+	}
+
+	newer := update.IsNewer(current, release.TagName)
+	if selfUpdateCheckOnly {
+		if newer {
+			fmt.Printf("Update available: %s -> %s (%s)\n", current, release.TagName, release.URL)
+			fmt.Println("Install it with: sinkzone self-update")
+		} else {
+			fmt.Printf("sinkzone %s is up to date (latest release: %s).\n", current, release.TagName)
+		}
+		return nil
+	}
+	if !newer && !selfUpdateForce {
+		fmt.Printf("sinkzone %s is up to date (latest release: %s).\n", current, release.TagName)
+		return nil
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the sinkzone executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to resolve the sinkzone executable: %w", err)
+	}
+	if strings.Contains(executable, "/Cellar/") {
+		return fmt.Errorf("sinkzone was installed with Homebrew; update it with 'brew upgrade sinkzone'")
+	}
+
+	fmt.Printf("Downloading sinkzone %s...\n", release.TagName)
+	data, err := release.Download()
+	if err != nil {
+		return err
+	}
+	if err := update.Replace(executable, data); err != nil {
+		if errors.Is(err, os.ErrPermission) {
+			return fmt.Errorf("%w. Run 'sudo sinkzone self-update' (as Administrator on Windows)", err)
+		}
+		return err
+	}
+
+	fmt.Printf("Updated %s from %s to %s (checksum verified).\n", executable, current, release.TagName)
+	if pid := runningResolverPID(); pid != 0 {
+		fmt.Println("Restart the resolver to run the new version: sinkzone resolver restart")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/berbyte/sinkzone/internal/service"
+	"github.com/spf13/cobra"
+)
+
+var (
+	servicePort    string
+	serviceAPIPort string
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service [install|uninstall|status]",
+	Short: "Run the resolver as a system service that starts on boot",
+	Long: `Installs the resolver as a system service, so it starts on boot and keeps running without a terminal:
+
+- Linux: a systemd unit (/etc/systemd/system/sinkzone.service); logs go to the journal ('journalctl -u sinkzone')
+- macOS: a launchd daemon (/Library/LaunchDaemons/com.berbyte.sinkzone.plist) logging to ~/.sinkzone/resolver.log
+- Windows: an automatically starting service named sinkzone, logging to resolver.log next to the PID file
+
+Installing and uninstalling need root (sudo) or Administrator. The service uses the config, allowlist, and state of the user who installs it, also when installed with sudo.
+
+The service restarts the resolver after a crash, but 'sinkzone resolver stop' keeps it stopped until the next boot.
+
+Examples:
+  sudo sinkzone service install
+  sudo sinkzone service install --port 5353 --api-port 8081
+  sinkzone service status
+  sudo sinkzone service uninstall`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "install":
+			return installService()
+		case "uninstall":
+			return uninstallService()
+		case "status":
+			return showServiceStatus()
+		default:
+			return fmt.Errorf("unknown service command: %s. Use 'install', 'uninstall', or 'status'", args[0])
+		}
+	},
+}
+
+func init() {
+	serviceCmd.Flags().StringVarP(&servicePort, "port", "p", "", "Port the service binds the DNS server to (default: dns_listen, or 53)")
+	serviceCmd.Flags().StringVarP(&serviceAPIPort, "api-port", "a", "", "Port the service binds the HTTP API server to (default: api_listen, or 8080)")
+}
+
+func installService() error {
+	if err := config.RequireAdmin(); err != nil {
+		return err
+	}
+	if pid := runningResolverPID(); pid != 0 {
+		return fmt.Errorf("a resolver is already running (PID %d). Stop it with 'sinkzone resolver stop' first", pid)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the sinkzone executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to resolve the sinkzone executable: %w", err)
+	}
+
+	home, err := invokingUserHome()
+	if err != nil {
+		return err
+	}
+	dataDir := os.Getenv(config.ConfigDirEnv)
+	logDir := filepath.Join(home, ".sinkzone")
+	if dataDir != "" {
+		logDir = dataDir
+	}
+	opts := service.Options{
+		Executable: executable,
+		Home:       home,
+		Port:       servicePort,
+		APIPort:    serviceAPIPort,
+		LogPath:    filepath.Join(logDir, "resolver.log"),
+		ConfigPath: configFile,
+		DataDir:    dataDir,
+	}
+	if level := logs.CurrentLevel(); level != logs.LevelInfo {
+		opts.LogLevel = level.String()
+	}
+	opts.LogFormat = logFormat
+	if err := service.Install(opts); err != nil {
+		return err
+	}
+
+	fmt.Println("Sinkzone service installed and started. It starts automatically on boot.")
+	dnsListen, apiListen := "dns_listen", "api_listen"
+	if servicePort != "" {
+		dnsListen = "port " + servicePort
+	}
+	if serviceAPIPort != "" {
+		apiListen = "port " + serviceAPIPort
+	}
+	fmt.Printf("DNS on %s, API on %s, using the config in %s\n", dnsListen, apiListen, home)
+	fmt.Println("Check it with: sinkzone service status")
+	return nil
+}
+
+func uninstallService() error {
+	if err := config.RequireAdmin(); err != nil {
+		return err
+	}
+	if err := service.Uninstall(); err != nil {
+		return err
+	}
+	fmt.Println("Sinkzone service stopped and removed.")
+	return nil
+}
+
+func showServiceStatus() error {
+	status, err := service.Status()
+	if errors.Is(err, service.ErrNotInstalled) {
+		fmt.Println("The sinkzone service is not installed. Install it with 'sudo sinkzone service install'.")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println(status)
+	return nil
+}
+
+// invokingUserHome returns the home directory of the user running sinkzone, looking
+// through sudo so a service installed with sudo uses the user's own config
+func invokingUserHome() (string, error) {
+	if u, ok := config.InvokingUser(); ok {
+		return u.HomeDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return home, nil
+}
@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+	"github.com/spf13/cobra"
+)
+
+var (
+	setupUndo           bool
+	setupForce          bool
+	setupAPIURL         string
+	setupNetworkManager bool
+	setupWatch          bool
+	setupRepair         bool
+	setupDispatch       string
+)
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Point the system DNS at the local resolver (and restore it)",
+	Long: `Configures the system to use the local resolver (127.0.0.1) for DNS, saving the previous settings so 'sinkzone setup --undo' can put them back:
+
+- macOS: sets the DNS servers of every enabled network service with networksetup, and sets up services that appear later with --repair or --watch
+- Linux: configures systemd-resolved, NetworkManager, or /etc/resolv.conf, whichever manages DNS
+- Windows: sets the IPv4 DNS servers of every connected adapter with netsh, and clears their IPv6 servers, which Windows would otherwise prefer
+
+With systemd-resolved, setup sets the DNS server of every link to 127.0.0.1 with resolvectl and routes all domains (~.) to it, leaving /etc/resolv.conf pointed at the stub listener on 127.0.0.53. The stub keeps port 53 of 127.0.0.53, so the resolver must listen on 127.0.0.1:53 rather than all addresses (dns_listen: 127.0.0.1:53). Links the network manager reconfigures later, such as on a new Wi-Fi network, go back to their own servers; run setup --undo and setup again, or use --networkmanager. setup --undo also removes the forwarding to dns_fallback_port the resolver sets up when port 53 is taken (see 'sinkzone resolver --help').
+
+With --networkmanager (Linux), setup also installs a NetworkManager dispatcher hook that points every connection at the resolver as it comes up, so joining another Wi-Fi network doesn't quietly bypass focus mode. Their previous settings are saved too, and setup --undo removes the hook. If setup was already run, --networkmanager only installs the hook.
+
+On macOS, a DHCP renewal, a VPN, or a new adapter can replace the servers setup set. 'sinkzone setup --repair' points every service back at the resolver, saving the settings of new ones for --undo, and lists servers it can't change, such as those a VPN app pushes itself. With --watch, setup also installs a launchd job that runs --repair whenever the DNS settings change; setup --undo removes it. If setup was already run, --watch only installs the job. 'sinkzone doctor' reports servers that bypass the resolver either way.
+
+Setup needs root (sudo) or Administrator, and refuses to run unless the resolver is answering, so DNS keeps working. Start the resolver first, e.g. with 'sudo sinkzone service install'.
+
+Examples:
+  sudo sinkzone setup
+  sudo sinkzone setup --networkmanager
+  sudo sinkzone setup --watch
+  sudo sinkzone setup --repair
+  sudo sinkzone setup --undo`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := config.RequireAdmin(); err != nil {
+			return err
+		}
+		if setupDispatch != "" {
+			return dispatchSetup(setupDispatch)
+		}
+		if setupUndo {
+			return undoSetup()
+		}
+		if setupRepair {
+			return repairSetup()
+		}
+		return runSetup()
+	},
+}
+
+func init() {
+	setupCmd.Flags().BoolVar(&setupUndo, "undo", false, "Restore the DNS settings saved by setup")
+	setupCmd.Flags().BoolVar(&setupForce, "force", false, "Set up even if the resolver is not running")
+	setupCmd.Flags().StringVar(&setupAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	setupCmd.Flags().BoolVar(&setupNetworkManager, "networkmanager", false, "Also point connections at the resolver as NetworkManager brings them up (Linux)")
+	setupCmd.Flags().BoolVar(&setupWatch, "watch", false, "Also repair the DNS settings whenever they change (macOS)")
+	setupCmd.Flags().BoolVar(&setupRepair, "repair", false, "Point services whose DNS settings changed since setup back at the resolver (macOS)")
+	// Run by the NetworkManager dispatcher hook with the device that came up
+	setupCmd.Flags().StringVar(&setupDispatch, "dispatch", "", "")
+	_ = setupCmd.Flags().MarkHidden("dispatch")
+}
+
+func runSetup() error {
+	// Checked before anything changes
+	if setupNetworkManager && runtime.GOOS != "linux" {
+		return fmt.Errorf("--networkmanager is only supported on Linux")
+	}
+	if setupWatch && runtime.GOOS != "darwin" {
+		return fmt.Errorf("--watch is only supported on macOS")
+	}
+	if setupNetworkManager || setupWatch {
+		if _, err := os.Stat(config.GetDNSBackupPath()); err == nil {
+			return installHooks()
+		}
+	}
+	if !setupForce {
+		if err := api.NewClient(setupAPIURL).HealthCheck(); err != nil {
+			return fmt.Errorf("the resolver is not running, so DNS would stop working. Start it first, e.g. 'sudo sinkzone service install', or pass --force")
+		}
+	}
+
+	backup, err := sysdns.Apply(config.GetDNSBackupPath())
+	if err != nil {
+		return fmt.Errorf("failed to set up system DNS: %w", err)
+	}
+
+	fmt.Printf("System DNS now points at %s (using %s).\n", sysdns.LocalResolver, backup.Method)
+	if names := backup.Names(); len(names) > 0 {
+		fmt.Printf("Changed: %s\n", strings.Join(names, ", "))
+	}
+	if backup.Method == sysdns.MethodResolved {
+		warnStubConflict()
+	}
+	if err := installHooks(); err != nil {
+		return err
+	}
+	fmt.Println("Undo with: sudo sinkzone setup --undo")
+	return nil
+}
+
+// installHooks installs what --networkmanager and --watch ask for: a NetworkManager hook
+// that runs 'sinkzone setup --dispatch', or a launchd job that runs 'sinkzone setup --repair'
+func installHooks() error {
+	if !setupNetworkManager && !setupWatch {
+		return nil
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the sinkzone executable: %w", err)
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return fmt.Errorf("failed to resolve the sinkzone executable: %w", err)
+	}
+
+	if setupNetworkManager {
+		if err := sysdns.InstallDispatcher(executable, config.GetDataDir()); err != nil {
+			return fmt.Errorf("failed to install NetworkManager hook: %w", err)
+		}
+		fmt.Println("NetworkManager hook installed: connections that come up are pointed at the resolver.")
+	}
+	if setupWatch {
+		if err := sysdns.InstallWatcher(executable, config.GetDataDir()); err != nil {
+			return fmt.Errorf("failed to install DNS watcher: %w", err)
+		}
+		fmt.Println("DNS watcher installed: services whose DNS settings change are pointed back at the resolver.")
+	}
+	return nil
+}
+
+// repairSetup points services whose DNS settings changed since setup back at the resolver
+func repairSetup() error {
+	drift, err := sysdns.Repair(config.GetDNSBackupPath())
+	if errors.Is(err, sysdns.ErrNoBackup) {
+		// Also how the launchd job exits once setup was undone
+		fmt.Println("Nothing to repair: 'sinkzone setup' has not changed the system DNS.")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to repair system DNS: %w", err)
+	}
+
+	if len(drift.Changed) > 0 {
+		fmt.Printf("Pointed back at %s: %s\n", sysdns.LocalResolver, strings.Join(drift.Changed, ", "))
+	}
+	if len(drift.Added) > 0 {
+		fmt.Printf("Set up new services: %s\n", strings.Join(drift.Added, ", "))
+	}
+	if len(drift.Bypass) > 0 {
+		fmt.Printf("Still also using %s, which setup can't change; point the VPN's DNS at %s in its settings, or disconnect it.\n", strings.Join(drift.Bypass, ", "), sysdns.LocalResolver)
+	}
+	if drift.Empty() {
+		fmt.Printf("Nothing to repair: every service points at %s.\n", sysdns.LocalResolver)
+	}
+	return nil
+}
+
+// dispatchSetup points a device that NetworkManager brought up at the resolver. The hook
+// passes the connection name in CONNECTION_ID.
+func dispatchSetup(device string) error {
+	iface, err := sysdns.Repoint(config.GetDNSBackupPath(), device, os.Getenv("CONNECTION_ID"))
+	if errors.Is(err, sysdns.ErrNoBackup) {
+		return nil // Setup was undone, but the hook is still there
+	}
+	if err != nil {
+		return fmt.Errorf("failed to point %s at the resolver: %w", device, err)
+	}
+	if iface != nil {
+		fmt.Printf("Pointed %s at %s.\n", iface.Name, sysdns.LocalResolver)
+	}
+	return nil
+}
+
+// warnStubConflict points out a dns_listen that binds every address, which collides with
+// systemd-resolved's stub listener on 127.0.0.53:53
+func warnStubConflict() {
+	cfg, err := config.Load()
+	if err != nil {
+		return
+	}
+	addr, err := cfg.GetDNSListen()
+	if err != nil {
+		return
+	}
+	if host, port, err := net.SplitHostPort(addr); err == nil && port == "53" && (host == "" || host == "0.0.0.0" || host == "::") {
+		fmt.Println("Note: systemd-resolved's stub listener holds 127.0.0.53:53, so the resolver can't bind every address on port 53.")
+		fmt.Println("Run 'sinkzone config set dns_listen 127.0.0.1:53' and restart the resolver.")
+	}
+}
+
+func undoSetup() error {
+	// Removed first, so the hook doesn't point connections back at the resolver
+	removed, err := sysdns.RemoveDispatcher()
+	if err != nil {
+		return err
+	}
+	if removed {
+		fmt.Println("NetworkManager hook removed.")
+	}
+	unwatched, err := sysdns.RemoveWatcher()
+	if err != nil {
+		return err
+	}
+	if unwatched {
+		fmt.Println("DNS watcher removed.")
+	}
+	forwarded, err := sysdns.RemoveForward()
+	if err != nil {
+		return err
+	}
+	if forwarded {
+		fmt.Println("systemd-resolved no longer forwards to the resolver's dns_fallback_port.")
+	}
+	removed = removed || unwatched || forwarded
+
+	backup, err := sysdns.Restore(config.GetDNSBackupPath())
+	if errors.Is(err, sysdns.ErrNoBackup) {
+		if !removed {
+			fmt.Println("Nothing to undo: 'sinkzone setup' has not changed the system DNS.")
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore system DNS: %w", err)
+	}
+
+	fmt.Printf("System DNS restored to the settings saved on %s.\n", backup.SavedAt.Format("2006-01-02 15:04"))
+	return nil
+}
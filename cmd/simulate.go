@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/blocklist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/dns"
+	"github.com/spf13/cobra"
+)
+
+// simulateMaxQueries bounds the queries replayed, as GET /api/queries/history does
+const simulateMaxQueries = 100000
+
+var (
+	simulateAPIURL    string
+	simulateAllowlist string
+	simulateBlocklist string
+	simulateSince     time.Duration
+	simulateLimit     int
+)
+
+var simulateCmd = &cobra.Command{
+	Use:   "simulate [--allowlist <file>] [--blocklist <file>]",
+	Short: "Replay the query log against a candidate allowlist or blocklist",
+	Long: `Replays the queries in the query log against a proposed allowlist, blocklist, or both, and reports the domains that a focus session would have blocked or allowed differently than with the current lists. Try changes to the lists here before a real session depends on them.
+
+  sinkzone simulate --allowlist new.txt
+  sinkzone simulate --allowlist new.txt --since 72h --limit 50
+  sinkzone simulate --blocklist stricter.txt --output json
+
+The candidate files use the allowlist.txt format: one domain or wildcard pattern per line, with # comments. A candidate replaces allowlist.txt or blocklist.txt; the one not given stays as it is, and subscribed lists apply to both sides. Queries are decided as in a session at normal intensity without a profile, whether or not they were made during one, so the report shows what the lists decide rather than what happened.
+
+Queries come from the running resolver, or from queries.db when it is stopped; at most the newest 100000 since --since (default 24h) are replayed. Queries of ignore_domains are not in the log, and with query_log.sample_rate only some allowed queries are, so their counts are lower than the real traffic.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if simulateAllowlist == "" && simulateBlocklist == "" {
+			return fmt.Errorf("give a candidate list with --allowlist, --blocklist, or both")
+		}
+		if simulateSince <= 0 {
+			return fmt.Errorf("invalid --since: must be a positive duration")
+		}
+		if simulateLimit <= 0 {
+			return fmt.Errorf("invalid --limit: must be positive")
+		}
+		cmd.SilenceUsage = true
+		return runSimulation()
+	},
+}
+
+func init() {
+	simulateCmd.Flags().StringVar(&simulateAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	simulateCmd.Flags().StringVar(&simulateAllowlist, "allowlist", "", "Candidate allowlist file to use instead of allowlist.txt")
+	simulateCmd.Flags().StringVar(&simulateBlocklist, "blocklist", "", "Candidate blocklist file to use instead of blocklist.txt")
+	simulateCmd.Flags().DurationVar(&simulateSince, "since", 24*time.Hour, "Replay the queries from this long ago (e.g. 24h)")
+	simulateCmd.Flags().IntVarP(&simulateLimit, "limit", "n", 20, "Show at most this many domains that would be blocked, and as many that would be allowed")
+}
+
+func runSimulation() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return fmt.Errorf("failed to create allowlist manager: %w", err)
+	}
+	current, err := readSimulationLists(manager.GetPath(), blocklist.GetPath())
+	if err != nil {
+		return err
+	}
+	candidate := current
+	if simulateAllowlist != "" {
+		if candidate.Allowlist, err = readCandidateList(simulateAllowlist, "allowlist"); err != nil {
+			return err
+		}
+	}
+	if simulateBlocklist != "" {
+		if candidate.Blocklist, err = readCandidateList(simulateBlocklist, "blocklist"); err != nil {
+			return err
+		}
+	}
+
+	since := time.Now().Add(-simulateSince)
+	queries, err := replayedQueries(since)
+	if err != nil {
+		return err
+	}
+	report := dns.Simulate(queries, dns.NewSimulator(cfg, current), dns.NewSimulator(cfg, candidate))
+	report.Since = since
+
+	if jsonOutput() {
+		return printJSON(report)
+	}
+	printSimulation(report)
+	return nil
+}
+
+// readSimulationLists reads the current allowlist and blocklist
+func readSimulationLists(allowlistPath, blocklistPath string) (dns.SimulationLists, error) {
+	var lists dns.SimulationLists
+	var err error
+	if lists.Allowlist, err = allowlist.NewListManager(allowlistPath, "allowlist").List(); err != nil {
+		return lists, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+	if lists.Blocklist, err = allowlist.NewListManager(blocklistPath, "blocklist").List(); err != nil {
+		return lists, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	return lists, nil
+}
+
+// readCandidateList reads a candidate list file, refusing one with invalid entries
+func readCandidateList(path, name string) ([]string, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("failed to read candidate %s: %w", name, err)
+	}
+	manager := allowlist.NewListManager(path, "candidate "+name)
+	content, err := manager.Read()
+	if err != nil {
+		return nil, err
+	}
+	if lineErrors := allowlist.Validate(content); len(lineErrors) > 0 {
+		return nil, fmt.Errorf("candidate %s %s has %d invalid entries, the first: %s", name, path, len(lineErrors), lineErrors[0])
+	}
+	return manager.List()
+}
+
+// replayedQueries returns the logged queries since a time, oldest first, from the running
+// resolver or from the query log file when it is stopped
+func replayedQueries(since time.Time) ([]api.DNSQuery, error) {
+	filter := api.QueryFilter{Since: since, Limit: simulateMaxQueries}
+	client := api.NewClient(simulateAPIURL)
+	if err := client.HealthCheck(); err == nil {
+		queries, err := client.GetQueryHistory(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get query history: %w", err)
+		}
+		return queries, nil
+	}
+
+	path := config.GetQueryLogPath()
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check query log: %w", err)
+	}
+	queryLog, err := api.OpenQueryLog(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := queryLog.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}()
+	queries, err := queryLog.Queries(filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read query log: %w", err)
+	}
+	return queries, nil
+}
+
+func printSimulation(report dns.SimulationReport) {
+	if report.Queries == 0 {
+		fmt.Printf("No queries in the log since %s.\n", report.Since.Local().Format("2006-01-02 15:04"))
+		return
+	}
+	fmt.Printf("Replayed %d queries for %d domains since %s.\n", report.Queries, report.Domains, report.Since.Local().Format("2006-01-02 15:04"))
+	if report.Queries >= simulateMaxQueries {
+		fmt.Printf("Only the newest %d queries since then were replayed.\n", simulateMaxQueries)
+	}
+	fmt.Printf("In a focus session the current lists block %d of them, the candidate lists %d.\n", report.Blocked, report.CandidateBlocked)
+
+	if len(report.NewlyBlocked) == 0 && len(report.NewlyAllowed) == 0 {
+		fmt.Println("\nNo domain is decided differently.")
+		return
+	}
+	printSimulatedDomains("Would be blocked", report.NewlyBlocked)
+	printSimulatedDomains("Would be allowed", report.NewlyAllowed)
+}
+
+// printSimulatedDomains lists the most queried of the domains decided differently, with the
+// reason the side that blocks them does
+func printSimulatedDomains(title string, domains []dns.SimulatedDomain) {
+	if len(domains) == 0 {
+		return
+	}
+	queries := 0
+	for _, domain := range domains {
+		queries += domain.Queries
+	}
+	fmt.Printf("\n%s (%d domains, %d queries):\n", title, len(domains), queries)
+	for _, domain := range domains[:min(len(domains), simulateLimit)] {
+		fmt.Printf("  %6d  %-40s  %d clients, last %s  (%s)\n", domain.Queries, domain.Domain, domain.Clients, domain.LastSeen.Local().Format("01-02 15:04"), domain.Reason)
+	}
+	if len(domains) > simulateLimit {
+		fmt.Printf("  ... and %d more (see --limit)\n", len(domains)-simulateLimit)
+	}
+}
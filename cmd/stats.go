@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsAPIURL string
+	statsSince  time.Duration
+)
+
+// statsReport is printed by 'stats --output json'
+type statsReport struct {
+	Queries *api.QueryStats `json:"queries,omitempty"` // Omitted when the resolver is not running
+	Focus   *api.FocusStats `json:"focus"`
+}
+
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show query and focus time statistics",
+	Long: `Shows what the resolver has seen and how much you have focused:
+
+- Query totals: allowed and blocked, since the resolver started or over --since
+- The most blocked and most queried domains
+- Queries per client
+- Answer latency (average, median, 95th percentile) and queries per upstream nameserver
+- Focus time today and this week, and daily goal progress
+
+Query stats need a running resolver; focus time is read from the state file when it is not running. Use --output json for scripts.
+
+Examples:
+  sinkzone stats
+  sinkzone stats --since 1h
+  sinkzone stats --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if statsSince < 0 {
+			return fmt.Errorf("invalid --since: must be a positive duration")
+		}
+		return showStats()
+	},
+}
+
+func init() {
+	statsCmd.Flags().StringVar(&statsAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	statsCmd.Flags().DurationVar(&statsSince, "since", 0, "Only count queries from this long ago (e.g. 1h); default since the resolver started")
+}
+
+func showStats() error {
+	var report statsReport
+	client := api.NewClient(statsAPIURL)
+	if err := client.HealthCheck(); err == nil {
+		queries, err := client.GetQueryStatsSince(statsSince)
+		if err != nil {
+			return fmt.Errorf("failed to get query stats: %w", err)
+		}
+		report.Queries = queries
+		if report.Focus, err = client.GetStats(); err != nil {
+			return fmt.Errorf("failed to get focus stats: %w", err)
+		}
+	} else {
+		// Focus time is also recorded in the state file
+		stateMgr, err := config.NewStateManager()
+		if err != nil {
+			return fmt.Errorf("failed to initialize state manager: %w", err)
+		}
+		report.Focus = localFocusStats(stateMgr.GetState())
+	}
+
+	if jsonOutput() {
+		return printJSON(report)
+	}
+
+	fmt.Println("=== Sinkzone Stats ===")
+	if queries := report.Queries; queries != nil {
+		blockedShare := 0.0
+		if queries.Total > 0 {
+			blockedShare = float64(queries.Blocked) / float64(queries.Total) * 100
+		}
+		fmt.Printf("Queries since %s: %d total, %d allowed, %d blocked (%.1f%%)\n",
+			queries.Since.Format("Jan 2 15:04"), queries.Total, queries.Allowed, queries.Blocked, blockedShare)
+		printCounts("Top blocked domains", queries.TopBlocked, true)
+		printCounts("Top domains", queries.TopDomains, false)
+		printCounts("Clients", queries.TopClients, false)
+		printLatency(queries)
+	} else {
+		fmt.Println("Query stats: unavailable (resolver not running)")
+	}
+
+	fmt.Println()
+	fmt.Printf("Focus time: %s today, %s this week\n", report.Focus.Today, report.Focus.Week)
+	printGoalStats(report.Focus)
+	return nil
+}
+
+// printCounts lists domain or client counts under a heading, ranked by blocked queries
+// when byBlocked is set
+func printCounts(heading string, counts []api.Count, byBlocked bool) {
+	fmt.Printf("\n%s:\n", heading)
+	if len(counts) == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	for i, count := range counts {
+		if byBlocked {
+			fmt.Printf("  %2d. %-40s %6d blocked\n", i+1, count.Name, count.Blocked)
+		} else {
+			fmt.Printf("  %2d. %-40s %6d queries, %d blocked\n", i+1, count.Name, count.Count, count.Blocked)
+		}
+	}
+}
+
+// printLatency shows how quickly queries were answered, overall and per upstream
+func printLatency(queries *api.QueryStats) {
+	fmt.Println("\nLatency:")
+	latency := queries.Latency
+	if latency.Count == 0 {
+		fmt.Println("  (none)")
+		return
+	}
+	fmt.Printf("  average %.1f ms, median %.1f ms, p95 %.1f ms, max %.1f ms\n", latency.Average, latency.Median, latency.P95, latency.Max)
+	for _, upstream := range queries.Upstreams {
+		fmt.Printf("  %-40s %6d queries, %.1f ms average\n", upstream.Name, upstream.Count, upstream.Average)
+	}
+}
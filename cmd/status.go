@@ -4,48 +4,59 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"time"
 
 	"github.com/berbyte/sinkzone/internal/api"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/i18n"
 	"github.com/spf13/cobra"
 )
 
-// getPIDFilePath returns the platform-specific path for the PID file
+// getPIDFilePath returns the path of the PID file in the data directory
 func getPIDFilePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
+	return filepath.Join(config.GetDataDir(), "resolver.pid"), nil
+}
 
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			return filepath.Join(appData, "sinkzone", "resolver.pid"), nil
-		}
-		// Fallback to user home directory
-		return filepath.Join(homeDir, "sinkzone", "resolver.pid"), nil
-	}
+var statusAPIURL string
 
-	// Unix-like systems use ~/.sinkzone/
-	return filepath.Join(homeDir, ".sinkzone", "resolver.pid"), nil
+// statusReport is printed by 'status --output json'
+type statusReport struct {
+	Resolver *resolverReport     `json:"resolver,omitempty"`
+	Focus    *api.FocusModeState `json:"focus,omitempty"`
+	Stats    *api.FocusStats     `json:"stats,omitempty"` // Daily goal progress and budget use, when configured
 }
 
-var statusAPIURL string
+// resolverReport tells whether the resolver process is running and how its upstreams answer
+type resolverReport struct {
+	Running   bool                 `json:"running"`
+	PID       int                  `json:"pid,omitempty"`
+	Upstreams []api.UpstreamHealth `json:"upstreams,omitempty"` // Omitted when the API is not reachable
+	DNSCheck  *api.DNSCheck        `json:"dns_check,omitempty"` // Whether this machine's DNS queries reach the resolver
+	Portal    *api.PortalState     `json:"portal,omitempty"`    // A suspected captive portal and the passthrough window
+}
 
 var statusCmd = &cobra.Command{
 	Use:   "status [type]",
 	Short: "Show system status",
 	Long: `Displays the current state of the Sinkzone system, including:
 
-- Whether the resolver is running
+- Whether the resolver is running, and how each upstream nameserver answers: exchanges that succeeded, failed, and timed out, and the recent average and 95th percentile latency
 - If focus mode is active
+- Whether this machine's own DNS queries reach sinkzone: every 5 minutes the resolver looks up a unique name through the system resolver and watches for it, since a VPN or a DHCP change can replace the nameserver without notice (turn it off with bypass_check: false)
+- A suspected captive portal, the login page of a hotel or café network, and an open passthrough window (see sinkzone passthrough)
+- Progress toward the daily focus goal and the current streak
 
 Use this to get a quick overview of what Sinkzone is doing.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if jsonOutput() {
+			statusType := ""
+			if len(args) > 0 {
+				statusType = args[0]
+			}
+			return printStatusJSON(statusType)
+		}
+
 		if len(args) == 0 {
 			return showGeneralStatus()
 		}
@@ -62,11 +73,11 @@ Use this to get a quick overview of what Sinkzone is doing.`,
 }
 
 func init() {
-	statusCmd.Flags().StringVar(&statusAPIURL, "api-url", "http://127.0.0.1:8080", "URL of the resolver API")
+	statusCmd.Flags().StringVar(&statusAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
 }
 
 func showGeneralStatus() error {
-	fmt.Println("=== Sinkzone Status ===")
+	fmt.Println(i18n.T("=== Sinkzone Status ==="))
 
 	// Show focus status
 	if err := showFocusStatus(); err != nil {
@@ -90,21 +101,76 @@ func showResolverStatus() error {
 	}
 
 	if _, err := os.Stat(pidFile); os.IsNotExist(err) {
-		fmt.Println("Resolver: NOT RUNNING")
+		fmt.Println(i18n.T("Resolver: NOT RUNNING"))
 		return nil
 	}
 
 	// #nosec G304 -- pidFile is a hardcoded path from user home directory
 	pidData, err := os.ReadFile(pidFile)
 	if err != nil {
-		fmt.Println("Resolver: UNKNOWN (cannot read PID file)")
+		fmt.Println(i18n.T("Resolver: UNKNOWN (cannot read PID file)"))
 		return nil
 	}
 
-	fmt.Printf("Resolver: RUNNING (PID: %s)\n", string(pidData))
+	fmt.Println(i18n.T("Resolver: RUNNING (PID: %s)", string(pidData)))
+	printUpstreamStats()
+	printDNSCheck()
+	printPortal()
 	return nil
 }
 
+// printPortal tells about a suspected captive portal or an open passthrough window
+func printPortal() {
+	client := api.NewClient(statusAPIURL)
+	health, err := client.GetHealth()
+	if err != nil || health.Portal == nil || (!health.Portal.Suspected && !health.Portal.Passthrough()) {
+		return
+	}
+	printPortalState(health.Portal)
+}
+
+// printDNSCheck warns when the resolver found this machine's DNS queries going elsewhere
+func printDNSCheck() {
+	client := api.NewClient(statusAPIURL)
+	health, err := client.GetHealth()
+	if err != nil || health.DNSCheck == nil {
+		return
+	}
+	check := health.DNSCheck
+	switch {
+	case check.Bypassed() && check.LastOK != nil:
+		fmt.Println(i18n.T("Warning: this machine's DNS queries stopped reaching sinkzone after %s; a VPN or network change may have replaced its nameserver. Run 'sinkzone doctor'.", check.LastOK.Local().Format("15:04")))
+	case check.Bypassed():
+		fmt.Println(i18n.T("Warning: this machine's DNS queries don't reach sinkzone; a VPN or network change may have replaced its nameserver. Run 'sinkzone doctor'."))
+	case check.Status == api.HealthOK:
+		fmt.Println(i18n.T("DNS check: this machine's queries reach sinkzone (checked %s)", check.CheckedAt.Local().Format("15:04")))
+	}
+}
+
+// printUpstreamStats lists how each upstream answered, when the resolver API is reachable
+func printUpstreamStats() {
+	client := api.NewClient(statusAPIURL)
+	if err := client.HealthCheck(); err != nil {
+		return
+	}
+	upstreams, err := client.GetUpstreams()
+	if err != nil || len(upstreams) == 0 {
+		return
+	}
+
+	fmt.Println(i18n.T("Upstreams:"))
+	for _, upstream := range upstreams {
+		line := i18n.T("  %-24s %-9s %d answered, %d failed (%d timed out)", upstream.Address, upstream.Status, upstream.Successes, upstream.Failures, upstream.Timeouts)
+		if upstream.AverageMS > 0 {
+			line += i18n.T(", %.1f ms average, %.1f ms p95", upstream.AverageMS, upstream.P95MS)
+		}
+		if upstream.LastError != "" && upstream.Status != api.HealthOK {
+			line += i18n.T(", last error: %s", upstream.LastError)
+		}
+		fmt.Println(line)
+	}
+}
+
 func showFocusStatus() error {
 	// Try to get focus mode state from API first
 	client := api.NewClient(statusAPIURL)
@@ -114,25 +180,62 @@ func showFocusStatus() error {
 			return fmt.Errorf("failed to get focus mode state: %w", err)
 		}
 
-		if focusState.Enabled {
+		if focusState.Enabled && focusState.Paused {
+			if focusState.Break {
+				fmt.Println(i18n.T("Focus mode: ON BREAK (break domains allowed)"))
+			} else {
+				fmt.Println(i18n.T("Focus mode: PAUSED"))
+			}
+			if focusState.PausedUntil != nil {
+				fmt.Println(i18n.T("Resumes at: %s", focusState.PausedUntil.Format("15:04:05")))
+			}
+			if focusState.Remaining != "" {
+				fmt.Println(i18n.T("Remaining time after pause: %s", focusState.Remaining))
+			}
+		} else if focusState.Enabled {
 			if focusState.EndTime != nil {
 				remaining := time.Until(*focusState.EndTime)
 				if remaining > 0 {
-					fmt.Printf("Focus mode: ENABLED\n")
-					fmt.Printf("Remaining time: %s\n", remaining.Round(time.Minute))
-					fmt.Printf("Ends at: %s\n", focusState.EndTime.Format("15:04:05"))
+					fmt.Println(i18n.T("Focus mode: ENABLED"))
+					fmt.Println(i18n.T("Remaining time: %s", remaining.Round(time.Minute)))
+					fmt.Println(i18n.T("Ends at: %s", focusState.EndTime.Format("15:04:05")))
 				} else {
-					fmt.Printf("Focus mode: EXPIRED\n")
-					fmt.Printf("Ended at: %s\n", focusState.EndTime.Format("15:04:05"))
+					fmt.Println(i18n.T("Focus mode: EXPIRED"))
+					fmt.Println(i18n.T("Ended at: %s", focusState.EndTime.Format("15:04:05")))
 				}
 			} else {
-				fmt.Printf("Focus mode: ENABLED (no expiration)\n")
+				fmt.Println(i18n.T("Focus mode: ENABLED (no expiration)"))
 			}
 		} else {
-			fmt.Printf("Focus mode: DISABLED\n")
+			fmt.Println(i18n.T("Focus mode: DISABLED"))
+		}
+
+		if focusState.Enabled && focusState.Profile != "" {
+			fmt.Println(i18n.T("Profile: %s", focusState.Profile))
+		}
+		if focusState.Enabled && focusState.Label != "" {
+			fmt.Println(i18n.T("Label: %s", focusState.Label))
+		}
+		if focusState.Enabled && focusState.Intensity != "" && focusState.Intensity != config.IntensityNormal {
+			fmt.Println(i18n.T("Intensity: %s", focusState.Intensity))
+		}
+		if focusState.Enabled && focusState.DryRun {
+			fmt.Println(i18n.T("Dry run: %d queries would have been blocked", focusState.WouldBlock))
+		} else if focusState.Enabled && focusState.GraceUntil != nil {
+			fmt.Println(i18n.T("Grace period: blocking starts at %s", focusState.GraceUntil.Format("15:04:05")))
+		}
+		if focusState.Enabled && focusState.DisableAt != nil {
+			fmt.Println(i18n.T("Disable queued: focus mode ends at %s", focusState.DisableAt.Format("15:04:05")))
+		}
+		for _, snooze := range focusState.Snoozes {
+			fmt.Println(i18n.T("Snoozed: %s until %s", snooze.Domain, snooze.Until.Format("15:04:05")))
 		}
 
-		fmt.Printf("Last updated: %s\n", time.Now().Format("15:04:05"))
+		if stats, err := client.GetStats(); err == nil {
+			printGoalStats(stats)
+		}
+
+		fmt.Println(i18n.T("Last updated: %s", time.Now().Format("15:04:05")))
 		return nil
 	}
 
@@ -148,20 +251,116 @@ func showFocusStatus() error {
 		if state.FocusEndTime != nil {
 			remaining := time.Until(*state.FocusEndTime)
 			if remaining > 0 {
-				fmt.Printf("Focus mode: ENABLED\n")
-				fmt.Printf("Remaining time: %s\n", remaining.Round(time.Minute))
-				fmt.Printf("Ends at: %s\n", state.FocusEndTime.Format("15:04:05"))
+				fmt.Println(i18n.T("Focus mode: ENABLED"))
+				fmt.Println(i18n.T("Remaining time: %s", remaining.Round(time.Minute)))
+				fmt.Println(i18n.T("Ends at: %s", state.FocusEndTime.Format("15:04:05")))
 			} else {
-				fmt.Printf("Focus mode: EXPIRED\n")
-				fmt.Printf("Ended at: %s\n", state.FocusEndTime.Format("15:04:05"))
+				fmt.Println(i18n.T("Focus mode: EXPIRED"))
+				fmt.Println(i18n.T("Ended at: %s", state.FocusEndTime.Format("15:04:05")))
 			}
 		} else {
-			fmt.Printf("Focus mode: ENABLED (no expiration)\n")
+			fmt.Println(i18n.T("Focus mode: ENABLED (no expiration)"))
 		}
 	} else {
-		fmt.Printf("Focus mode: DISABLED\n")
+		fmt.Println(i18n.T("Focus mode: DISABLED"))
 	}
 
-	fmt.Printf("Last updated: %s\n", state.LastUpdated.Format("15:04:05"))
+	printGoalStats(localFocusStats(state))
+
+	fmt.Println(i18n.T("Last updated: %s", state.LastUpdated.Format("15:04:05")))
 	return nil
 }
+
+// localFocusStats computes focus time and daily goal progress from the saved state, for
+// when the API is unavailable
+func localFocusStats(state config.State) *api.FocusStats {
+	var goal time.Duration
+	if cfg, err := config.Load(); err == nil {
+		if dailyGoal, err := cfg.GetDailyGoal(); err == nil {
+			goal = dailyGoal
+		}
+	}
+	stats := state.GoalStats(goal, time.Now())
+	result := &api.FocusStats{
+		Today:         stats.Today.Round(time.Minute).String(),
+		Week:          stats.Week.Round(time.Minute).String(),
+		GoalMet:       stats.Met(),
+		Streak:        stats.Streak,
+		LongestStreak: stats.LongestStreak,
+	}
+	if goal > 0 {
+		result.Goal = goal.String()
+		result.Progress = float64(stats.Today) / float64(goal)
+	}
+	return result
+}
+
+// printStatusJSON prints the resolver and focus status ("resolver", "focus", or both for "") as JSON
+func printStatusJSON(statusType string) error {
+	if statusType != "" && statusType != "resolver" && statusType != "focus" {
+		return fmt.Errorf("unknown status type: %s. Use 'resolver' or 'focus'", statusType)
+	}
+
+	var report statusReport
+	if statusType != "focus" {
+		pid := runningResolverPID()
+		report.Resolver = &resolverReport{Running: pid != 0, PID: pid}
+	}
+
+	client := api.NewClient(statusAPIURL)
+	apiErr := client.HealthCheck()
+	if report.Resolver != nil && apiErr == nil {
+		if health, err := client.GetHealth(); err == nil {
+			report.Resolver.Upstreams = health.Upstreams
+			report.Resolver.DNSCheck = health.DNSCheck
+			report.Resolver.Portal = health.Portal
+		}
+	}
+	if statusType == "resolver" {
+		return printJSON(report)
+	}
+
+	if apiErr == nil {
+		focusState, err := client.GetFocusMode()
+		if err != nil {
+			return fmt.Errorf("failed to get focus mode state: %w", err)
+		}
+		report.Focus = focusState
+		if stats, err := client.GetStats(); err == nil && (stats.Goal != "" || len(stats.Budgets) > 0) {
+			report.Stats = stats
+		}
+		return printJSON(report)
+	}
+
+	// Fallback to state manager if API is not available
+	stateMgr, err := config.NewStateManager()
+	if err != nil {
+		return fmt.Errorf("failed to initialize state manager: %w", err)
+	}
+	state := stateMgr.GetState()
+	enabled := state.FocusMode && (state.FocusEndTime == nil || time.Now().Before(*state.FocusEndTime))
+	report.Focus = &api.FocusModeState{Enabled: enabled, EndTime: state.FocusEndTime}
+	if stats := localFocusStats(state); stats.Goal != "" {
+		report.Stats = stats
+	}
+	return printJSON(report)
+}
+
+// printGoalStats shows progress toward the daily focus goal and the use of the daily time
+// budgets, if they are configured
+func printGoalStats(stats *api.FocusStats) {
+	if stats.Goal != "" {
+		fmt.Println(i18n.T("Daily goal: %s / %s (%d%%)", stats.Today, stats.Goal, int(stats.Progress*100)))
+		fmt.Println(i18n.T("Streak: %d day(s) (longest: %d)", stats.Streak, stats.LongestStreak))
+		for _, label := range stats.Labels {
+			fmt.Println(i18n.T("  %s: %s today, %s total", label.Label, label.Today, label.Total))
+		}
+	}
+	for _, budget := range stats.Budgets {
+		if budget.Exhausted {
+			fmt.Println(i18n.T("Budget %s: %s used up, blocked until tomorrow", budget.Domain, budget.Limit))
+		} else {
+			fmt.Println(i18n.T("Budget %s: %s / %s used (%s left)", budget.Domain, budget.Used, budget.Limit, budget.Remaining))
+		}
+	}
+}
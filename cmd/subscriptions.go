@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/spf13/cobra"
+)
+
+// subscriptionReport is a subscribed list in the output of 'allowlist list --output json'
+// and 'blocklist list --output json'
+type subscriptionReport struct {
+	URL        string     `json:"url"`
+	Enabled    bool       `json:"enabled"`
+	Domains    int        `json:"domains"`              // Domains in the last download
+	Downloaded *time.Time `json:"downloaded,omitempty"` // Last download or check; unset if it hasn't been downloaded
+	Error      string     `json:"error,omitempty"`      // Why the cached list couldn't be read
+}
+
+// subscriptionCommands are the subcommands of allowlist and blocklist that manage subscriptions
+var subscriptionCommands = []string{"subscribe", "unsubscribe", "enable", "disable", "update"}
+
+// runSubscriptionCommand runs one of subscriptionCommands for a list; url is "" for update
+func runSubscriptionCommand(list subscription.List, command, url string) error {
+	switch command {
+	case "subscribe":
+		return subscribeList(list, url)
+	case "unsubscribe":
+		return unsubscribeList(list, url)
+	case "enable":
+		return setSubscriptionEnabled(list, url, true)
+	case "disable":
+		return setSubscriptionEnabled(list, url, false)
+	default:
+		return updateSubscriptions(list)
+	}
+}
+
+// completeSubscriptionURLs completes the subscribed URLs a subcommand applies to: disabled
+// ones for enable, enabled ones for disable, and all of them for unsubscribe
+func completeSubscriptionURLs(list subscription.List, command string) ([]string, cobra.ShellCompDirective) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var urls []string
+	for _, s := range *list.Subscriptions(cfg) {
+		if (command == "enable" && !s.Disabled) || (command == "disable" && s.Disabled) {
+			continue
+		}
+		urls = append(urls, s.URL)
+	}
+	return urls, cobra.ShellCompDirectiveNoFileComp
+}
+
+// findSubscription returns the index of a subscribed URL, or -1
+func findSubscription(subscriptions []config.Subscription, url string) int {
+	return slices.IndexFunc(subscriptions, func(s config.Subscription) bool { return s.URL == url })
+}
+
+func subscribeList(list subscription.List, url string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	subscriptions := list.Subscriptions(cfg)
+	if findSubscription(*subscriptions, url) >= 0 {
+		return fmt.Errorf("already subscribed to %s; run 'sinkzone %s update' to download it again", url, list)
+	}
+
+	// Download first so a mistyped URL isn't saved
+	result, err := list.Refresh(url)
+	if err != nil {
+		return err
+	}
+
+	*subscriptions = append(*subscriptions, config.Subscription{URL: url})
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("Subscribed to %s (%d domains).\n", url, result.Domains)
+	noteSubscriptionChange(list)
+	return nil
+}
+
+func unsubscribeList(list subscription.List, url string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	subscriptions := list.Subscriptions(cfg)
+	index := findSubscription(*subscriptions, url)
+	if index < 0 {
+		return fmt.Errorf("not subscribed to %s", url)
+	}
+	*subscriptions = slices.Delete(*subscriptions, index, index+1)
+
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	if err := list.RemoveCache(url); err != nil {
+		return err
+	}
+
+	fmt.Printf("Unsubscribed from %s.\n", url)
+	noteSubscriptionChange(list)
+	return nil
+}
+
+// setSubscriptionEnabled enables or disables a subscription. A disabled list keeps its
+// cache, so enabling it again works offline; a list never downloaded is downloaded first.
+func setSubscriptionEnabled(list subscription.List, url string, enabled bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	subscriptions := *list.Subscriptions(cfg)
+	index := findSubscription(subscriptions, url)
+	if index < 0 {
+		return fmt.Errorf("not subscribed to %s", url)
+	}
+	state := "disabled"
+	if enabled {
+		state = "enabled"
+	}
+	if subscriptions[index].Disabled == !enabled {
+		fmt.Printf("%s is already %s.\n", url, state)
+		return nil
+	}
+	if enabled {
+		if _, _, err := list.Cached(url); err != nil {
+			if _, err := list.Refresh(url); err != nil {
+				return err
+			}
+		}
+	}
+
+	subscriptions[index].Disabled = !enabled
+	if err := config.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+
+	fmt.Printf("Subscription to %s %s.\n", url, state)
+	noteSubscriptionChange(list)
+	return nil
+}
+
+// updateSubscriptions downloads every enabled subscription of a list again, keeping the
+// previous download of any list that fails
+func updateSubscriptions(list subscription.List) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	var urls []string
+	for _, s := range *list.Subscriptions(cfg) {
+		if !s.Disabled {
+			urls = append(urls, s.URL)
+		}
+	}
+	if len(urls) == 0 {
+		fmt.Printf("No enabled %s subscriptions. Add one with 'sinkzone %s subscribe <url>'.\n", list, list)
+		return nil
+	}
+
+	failed := 0
+	for _, url := range urls {
+		result, err := list.Refresh(url)
+		if err != nil {
+			fmt.Printf("  %s: %v\n", url, err)
+			failed++
+			continue
+		}
+		if result.Changed {
+			fmt.Printf("  %s: %d domains\n", url, result.Domains)
+		} else {
+			fmt.Printf("  %s: %d domains (unchanged)\n", url, result.Domains)
+		}
+	}
+
+	reloadList(string(list))
+	if failed > 0 {
+		return fmt.Errorf("%d of %d %ss could not be updated", failed, len(urls), list)
+	}
+	return nil
+}
+
+// subscriptionReports describes the subscriptions of a list with their cached downloads
+func subscriptionReports(list subscription.List, cfg *config.Config) []subscriptionReport {
+	subscriptions := *list.Subscriptions(cfg)
+	reports := make([]subscriptionReport, 0, len(subscriptions))
+	for _, s := range subscriptions {
+		report := subscriptionReport{URL: s.URL, Enabled: !s.Disabled}
+		if count, downloaded, err := list.Cached(s.URL); err != nil {
+			report.Error = err.Error()
+		} else {
+			report.Domains = count
+			report.Downloaded = &downloaded
+		}
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// printSubscriptions lists the subscriptions after the local entries of 'allowlist list'
+// and 'blocklist list'
+func printSubscriptions(list subscription.List, reports []subscriptionReport) {
+	if len(reports) == 0 {
+		return
+	}
+	fmt.Printf("\nSubscribed lists (%d):\n", len(reports))
+	for _, report := range reports {
+		state := ""
+		if !report.Enabled {
+			state = ", disabled"
+		}
+		if report.Error != "" {
+			fmt.Printf("  %s (not downloaded%s; run 'sinkzone %s update')\n", report.URL, state, list)
+			continue
+		}
+		fmt.Printf("  %s (%d domains, updated %s%s)\n", report.URL, report.Domains,
+			report.Downloaded.Format("2006-01-02 15:04"), state)
+	}
+}
+
+// noteSubscriptionChange tells how a changed subscription reaches a running resolver, which
+// applies it once it rereads sinkzone.yaml
+func noteSubscriptionChange(list subscription.List) {
+	// The list commands have no --api-url flag, so they use the default API URL
+	if err := api.NewClient(config.DefaultAPIURL()).HealthCheck(); err != nil {
+		fmt.Printf("Note: The resolver is not running; the %s applies when it starts.\n", list)
+		return
+	}
+	fmt.Printf("The resolver applies the change once it rereads %s, within a few seconds.\n", config.GetConfigPath())
+}
+
+// reloadList asks a running resolver to reread its lists, so changes apply right away; name
+// is the list changed, e.g. "blocklist"
+func reloadList(name string) {
+	// The list commands have no --api-url flag, so they use the default API URL
+	client := api.NewClient(config.DefaultAPIURL())
+	if err := client.HealthCheck(); err != nil {
+		fmt.Printf("Note: The resolver is not running; the %s applies when it starts.\n", name)
+		return
+	}
+	if err := client.ReloadAllowlist(); err != nil {
+		fmt.Printf("Warning: the resolver could not reload the %s: %v\n", name, err)
+		return
+	}
+	fmt.Printf("Resolver reloaded the %s.\n", name)
+}
@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	suggestionsAPIURL string
+	suggestionsAll    bool
+)
+
+var suggestionsCmd = &cobra.Command{
+	Use:   "suggestions [allow] [number or domain...]",
+	Short: "Suggest allowlist entries from the domains blocked in a focus session",
+	Long: `Lists domains that were blocked repeatedly in the running focus session, or in the last one, alongside allowed domains they likely belong with. Allowing them may fix a site that only half works during focus mode.
+
+  sinkzone suggestions              List the suggestions, most blocked first
+  sinkzone suggestions allow 1 3    Add suggestions 1 and 3 to the allowlist
+  sinkzone suggestions allow --all  Add every suggestion to the allowlist
+
+A domain is suggested once it was blocked at least twice and either shares its registered domain with an allowed domain queried in the session (cdn.example.com next to www.example.com), or an allowed domain's answer pointed to it with a CNAME record. Domains the allowlist already covers are left out. The resolver keeps the suggestions of the last session until the next one starts, and forgets them when it restarts.
+
+Allowed suggestions are added to allowlist.txt as exact domains, and a running resolver applies them right away. The TUI offers the same review when a session ends (S).`,
+	Args:      cobra.ArbitraryArgs,
+	ValidArgs: []string{"allow"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch {
+		case len(args) == 0:
+		case args[0] != "allow":
+			return fmt.Errorf("unknown command: %s. Use 'allow <number or domain...>' or 'allow --all'", args[0])
+		case len(args) == 1 && !suggestionsAll:
+			return fmt.Errorf("usage: sinkzone suggestions allow <number or domain...> or --all")
+		case len(args) > 1 && suggestionsAll:
+			return fmt.Errorf("use either suggestions or --all, not both")
+		}
+		cmd.SilenceUsage = true
+
+		client := api.NewClient(suggestionsAPIURL)
+		if err := client.HealthCheck(); err != nil {
+			return resolverDownError(err)
+		}
+		manager, err := allowlist.NewManager()
+		if err != nil {
+			return fmt.Errorf("failed to create allowlist manager: %w", err)
+		}
+		suggestions, err := openSuggestions(client, manager)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			return listSuggestions(suggestions)
+		}
+		return allowSuggestions(client, manager, suggestions, args[1:])
+	},
+}
+
+func init() {
+	suggestionsCmd.Flags().StringVar(&suggestionsAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	suggestionsCmd.Flags().BoolVar(&suggestionsAll, "all", false, "With allow, add every suggestion")
+}
+
+// openSuggestions returns the resolver's suggestions that the allowlist doesn't cover yet
+func openSuggestions(client *api.Client, manager *allowlist.Manager) (*api.Suggestions, error) {
+	suggestions, err := client.GetSuggestions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestions: %w", err)
+	}
+	entries, err := manager.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+	suggestions.Suggestions = slices.DeleteFunc(suggestions.Suggestions, func(suggestion api.Suggestion) bool {
+		return slices.ContainsFunc(entries, func(entry string) bool { return allowlist.MatchPattern(entry, suggestion.Domain) })
+	})
+	return suggestions, nil
+}
+
+func listSuggestions(suggestions *api.Suggestions) error {
+	if jsonOutput() {
+		return printJSON(suggestions)
+	}
+
+	switch {
+	case suggestions.Start == nil:
+		fmt.Println("No focus session since the resolver started")
+		return nil
+	case suggestions.Active:
+		fmt.Printf("Focus session running since %s\n", suggestions.Start.Local().Format("2006-01-02 15:04"))
+	case suggestions.End != nil:
+		fmt.Printf("Last focus session: %s to %s\n", suggestions.Start.Local().Format("2006-01-02 15:04"), suggestions.End.Local().Format("15:04"))
+	}
+	if len(suggestions.Suggestions) == 0 {
+		fmt.Println("No suggestions: no blocked domain looks like part of an allowed site")
+		return nil
+	}
+
+	fmt.Println()
+	for i, suggestion := range suggestions.Suggestions {
+		fmt.Printf("%3d. %-40s %4d blocked  %s\n", i+1, suggestion.Domain, suggestion.Blocked, suggestionReason(suggestion))
+	}
+	fmt.Println("\nAllow some with 'sinkzone suggestions allow <number...>', or all of them with --all.")
+	return nil
+}
+
+// suggestionReason explains why a domain is suggested
+func suggestionReason(suggestion api.Suggestion) string {
+	related := strings.Join(suggestion.Related, ", ")
+	if suggestion.Reason == api.SuggestionCNAME {
+		return "CNAME of " + related
+	}
+	return "alongside " + related
+}
+
+func allowSuggestions(client *api.Client, manager *allowlist.Manager, suggestions *api.Suggestions, picks []string) error {
+	var domains []string
+	if suggestionsAll {
+		for _, suggestion := range suggestions.Suggestions {
+			domains = append(domains, suggestion.Domain)
+		}
+	}
+	for _, pick := range picks {
+		if number, err := strconv.Atoi(pick); err == nil {
+			if number < 1 || number > len(suggestions.Suggestions) {
+				return fmt.Errorf("no suggestion %d; 'sinkzone suggestions' lists %d", number, len(suggestions.Suggestions))
+			}
+			pick = suggestions.Suggestions[number-1].Domain
+		} else if err := allowlist.ValidatePattern(pick); err != nil {
+			return fmt.Errorf("invalid domain %q: %w", pick, err)
+		}
+		domains = append(domains, pick)
+	}
+	if len(domains) == 0 {
+		fmt.Println("No suggestions to allow")
+		return nil
+	}
+
+	added, err := manager.AddAll(domains)
+	if err != nil {
+		return fmt.Errorf("failed to add to allowlist: %w", err)
+	}
+	if len(added) == 0 {
+		fmt.Println("The allowlist already has every domain")
+		return nil
+	}
+	fmt.Printf("Allowed %d domains: %s\n", len(added), strings.Join(added, ", "))
+	if err := client.ReloadAllowlist(); err != nil {
+		return fmt.Errorf("allowlist saved, but the resolver could not reload it: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/tray"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trayAPIURL   string
+	trayDuration time.Duration
+)
+
+var trayCmd = &cobra.Command{
+	Use:   "tray",
+	Short: "Show focus mode in the menu bar or system tray",
+	Long: `Shows an icon in the macOS menu bar, the Windows notification area, or a Linux system tray: a red dot while focusing, an amber one while paused, and a ring when focus mode is off. On macOS the time left is shown next to it.
+
+Its menu tells how long the session runs and how many queries were blocked in the last minute, and starts a session (for --duration, default 1h), starts one with a profile from sinkzone.yaml, or stops the running one. Quit closes the icon; the resolver keeps running.
+
+The icon follows the resolver with long polls of GET /api/summary, so sessions started from the CLI, the TUI, or a schedule show within a second, and it waits for a resolver that isn't running yet. Run it at login to keep it around. Linux needs a tray that supports StatusNotifierItem, such as KDE, or GNOME with the AppIndicator extension. On macOS it needs a build of sinkzone made with cgo; the release binaries are made without.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if trayDuration < 0 {
+			return fmt.Errorf("invalid duration: %s", trayDuration)
+		}
+		cmd.SilenceUsage = true
+
+		opts := tray.Options{Duration: trayDuration}
+		if cfg, err := config.Load(); err == nil {
+			for name := range cfg.Profiles {
+				opts.Profiles = append(opts.Profiles, name)
+			}
+			slices.Sort(opts.Profiles)
+		}
+		return tray.Run(api.NewClient(trayAPIURL), opts)
+	},
+}
+
+func init() {
+	trayCmd.Flags().StringVar(&trayAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+	trayCmd.Flags().DurationVar(&trayDuration, "duration", time.Hour, "Length of sessions started from the menu (0 = the profile's own, or no end)")
+}
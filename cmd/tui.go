@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"github.com/berbyte/sinkzone/internal/config"
 	"github.com/berbyte/sinkzone/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -17,5 +18,5 @@ var tuiCmd = &cobra.Command{
 }
 
 func init() {
-	tuiCmd.Flags().StringVarP(&tuiAPIURL, "api-url", "u", "http://127.0.0.1:8080", "URL of the resolver API")
+	tuiCmd.Flags().StringVarP(&tuiAPIURL, "api-url", "u", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
 }
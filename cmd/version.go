@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/version"
+	"github.com/spf13/cobra"
+)
+
+var versionAPIURL string
+
+// versionReport is printed by 'version --output json'
+type versionReport struct {
+	CLI      version.Info  `json:"cli"`
+	Resolver *version.Info `json:"resolver,omitempty"` // Omitted when the resolver is not reachable
+}
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Show the version of the CLI and the running resolver",
+	Long: `Prints the version, commit, build date, and Go version of this binary, and of the running resolver if its API is reachable, warning when they differ.
+
+'sinkzone --version' prints the CLI version only.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		report := versionReport{CLI: version.Get()}
+		resolver, resolverErr := api.NewClient(versionAPIURL).GetVersion()
+		report.Resolver = resolver
+
+		if jsonOutput() {
+			return printJSON(report)
+		}
+
+		fmt.Printf("CLI:      %s\n", report.CLI)
+		if resolverErr != nil {
+			fmt.Printf("Resolver: unknown (%v)\n", resolverErr)
+			return nil
+		}
+		fmt.Printf("Resolver: %s\n", resolver)
+		if resolver.Version != report.CLI.Version || resolver.Commit != report.CLI.Commit {
+			fmt.Println("Warning: the CLI and the resolver are different builds. Restart the resolver to run this version.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().StringVar(&versionAPIURL, "api-url", config.DefaultAPIURL(), "URL of the resolver API (or set SINKZONE_API_URL)")
+}
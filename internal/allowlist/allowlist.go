@@ -5,13 +5,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
 )
 
-// Manager handles allowlist operations
+// Manager handles allowlist operations, or those of another domain list file with the same
+// format (such as the blocklist)
 type Manager struct {
 	allowlistPath string
+	name          string // Used in messages, e.g. "allowlist"
+	checksum      bool   // Record the checksum the resolver verifies (see config.VerifyChecksum)
+	journalPath   string // Changes kept to undo (see Undo), empty to keep none
 }
 
 // NewManager creates a new allowlist manager
@@ -20,35 +26,29 @@ func NewManager() (*Manager, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Manager{allowlistPath: allowlistPath}, nil
+	journalPath := filepath.Join(config.GetDataDir(), "allowlist-journal.json")
+	return &Manager{allowlistPath: allowlistPath, name: "allowlist", checksum: true, journalPath: journalPath}, nil
 }
 
-// getAllowlistPath returns the platform-specific path for the allowlist file
-func getAllowlistPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			return filepath.Join(appData, "sinkzone", "allowlist.txt"), nil
-		}
-		// Fallback to user home directory
-		return filepath.Join(homeDir, "sinkzone", "allowlist.txt"), nil
-	}
+// NewListManager creates a manager for the domain list file at path, named name in messages
+func NewListManager(path, name string) *Manager {
+	return &Manager{allowlistPath: path, name: name}
+}
 
-	// Unix-like systems use ~/.sinkzone/
-	return filepath.Join(homeDir, ".sinkzone", "allowlist.txt"), nil
+// getAllowlistPath returns the path of the allowlist file in the data directory
+func getAllowlistPath() (string, error) {
+	return filepath.Join(config.GetDataDir(), "allowlist.txt"), nil
 }
 
 // Add adds a domain to the allowlist
 func (m *Manager) Add(domain string) error {
 	// Create directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(m.allowlistPath), 0750); err != nil {
-		return fmt.Errorf("failed to create allowlist directory: %w", err)
+		return fmt.Errorf("failed to create %s directory: %w", m.name, err)
+	}
+	previous, err := m.snapshot()
+	if err != nil {
+		return err
 	}
 
 	// Read existing allowlist
@@ -57,11 +57,11 @@ func (m *Manager) Add(domain string) error {
 		// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
 		file, err := os.Open(m.allowlistPath)
 		if err != nil {
-			return fmt.Errorf("failed to open allowlist file: %w", err)
+			return fmt.Errorf("failed to open %s file: %w", m.name, err)
 		}
 		defer func() {
 			if closeErr := file.Close(); closeErr != nil {
-				fmt.Printf("Warning: failed to close allowlist file: %v\n", closeErr)
+				fmt.Printf("Warning: failed to close %s file: %v\n", m.name, closeErr)
 			}
 		}()
 
@@ -74,50 +74,53 @@ func (m *Manager) Add(domain string) error {
 		}
 
 		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("failed to read allowlist file: %w", err)
+			return fmt.Errorf("failed to read %s file: %w", m.name, err)
 		}
 	}
 
 	// Check if domain is already in allowlist
 	if existingDomains[domain] {
-		return fmt.Errorf("domain '%s' is already in the allowlist", domain)
+		return fmt.Errorf("domain '%s' is already in the %s", domain, m.name)
 	}
 
 	// Add domain to allowlist
 	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
 	file, err := os.OpenFile(m.allowlistPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
-		return fmt.Errorf("failed to open allowlist file for writing: %w", err)
+		return fmt.Errorf("failed to open %s file for writing: %w", m.name, err)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			fmt.Printf("Warning: failed to close allowlist file: %v\n", closeErr)
+			fmt.Printf("Warning: failed to close %s file: %v\n", m.name, closeErr)
 		}
 	}()
 
 	if _, err := file.WriteString(domain + "\n"); err != nil {
-		return fmt.Errorf("failed to write to allowlist file: %w", err)
+		return fmt.Errorf("failed to write to %s file: %w", m.name, err)
 	}
 
-	return nil
+	if err := m.updateChecksum(); err != nil {
+		return err
+	}
+	return m.record("add "+domain, previous)
 }
 
 // Remove removes a domain from the allowlist
 func (m *Manager) Remove(domain string) error {
 	// Check if allowlist file exists
 	if _, err := os.Stat(m.allowlistPath); os.IsNotExist(err) {
-		return fmt.Errorf("domain '%s' is not in the allowlist", domain)
+		return fmt.Errorf("domain '%s' is not in the %s", domain, m.name)
 	}
 
 	// Read existing allowlist
 	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
 	file, err := os.Open(m.allowlistPath)
 	if err != nil {
-		return fmt.Errorf("failed to open allowlist file: %w", err)
+		return fmt.Errorf("failed to open %s file: %w", m.name, err)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			fmt.Printf("Warning: failed to close allowlist file: %v\n", closeErr)
+			fmt.Printf("Warning: failed to close %s file: %v\n", m.name, closeErr)
 		}
 	}()
 
@@ -138,19 +141,124 @@ func (m *Manager) Remove(domain string) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("failed to read allowlist file: %w", err)
+		return fmt.Errorf("failed to read %s file: %w", m.name, err)
 	}
 
 	if !found {
-		return fmt.Errorf("domain '%s' is not in the allowlist", domain)
+		return fmt.Errorf("domain '%s' is not in the %s", domain, m.name)
+	}
+	previous, err := m.snapshot()
+	if err != nil {
+		return err
 	}
 
 	// Write updated allowlist
 	if err := os.WriteFile(m.allowlistPath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
-		return fmt.Errorf("failed to write allowlist file: %w", err)
+		return fmt.Errorf("failed to write %s file: %w", m.name, err)
 	}
 
-	return nil
+	if err := m.updateChecksum(); err != nil {
+		return err
+	}
+	return m.record("remove "+domain, previous)
+}
+
+// AddAll adds several domains to the allowlist in one write, skipping ones already present.
+// It returns the domains that were added.
+func (m *Manager) AddAll(domains []string) ([]string, error) {
+	existing, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(existing))
+	for _, domain := range existing {
+		present[domain] = true
+	}
+
+	var added []string
+	for _, domain := range domains {
+		if domain == "" || present[domain] {
+			continue
+		}
+		present[domain] = true
+		added = append(added, domain)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	previous, err := m.snapshot()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(m.allowlistPath), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", m.name, err)
+	}
+	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
+	file, err := os.OpenFile(m.allowlistPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s file for writing: %w", m.name, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close %s file: %v\n", m.name, closeErr)
+		}
+	}()
+
+	if _, err := file.WriteString(strings.Join(added, "\n") + "\n"); err != nil {
+		return nil, fmt.Errorf("failed to write to %s file: %w", m.name, err)
+	}
+
+	if err := m.updateChecksum(); err != nil {
+		return nil, err
+	}
+	return added, m.record(describeChange("add", added), previous)
+}
+
+// RemoveAll removes several domains from the allowlist in one write.
+// It returns the domains that were found and removed.
+func (m *Manager) RemoveAll(domains []string) ([]string, error) {
+	if _, err := os.Stat(m.allowlistPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
+	content, err := os.ReadFile(m.allowlistPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %w", m.name, err)
+	}
+
+	// Domain -> whether a line with it was found
+	remove := make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		remove[domain] = false
+	}
+
+	var lines, removed []string
+	for _, line := range strings.Split(strings.TrimRight(string(content), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		found, ok := remove[trimmed]
+		if !ok {
+			lines = append(lines, line)
+			continue
+		}
+		if !found {
+			remove[trimmed] = true
+			removed = append(removed, trimmed)
+		}
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(m.allowlistPath, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s file: %w", m.name, err)
+	}
+
+	if err := m.updateChecksum(); err != nil {
+		return nil, err
+	}
+	return removed, m.record(describeChange("remove", removed), string(content))
 }
 
 // List returns all domains in the allowlist
@@ -164,11 +272,11 @@ func (m *Manager) List() ([]string, error) {
 	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
 	file, err := os.Open(m.allowlistPath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open allowlist file: %w", err)
+		return nil, fmt.Errorf("failed to open %s file: %w", m.name, err)
 	}
 	defer func() {
 		if closeErr := file.Close(); closeErr != nil {
-			fmt.Printf("Warning: failed to close allowlist file: %v\n", closeErr)
+			fmt.Printf("Warning: failed to close %s file: %v\n", m.name, closeErr)
 		}
 	}()
 
@@ -182,12 +290,36 @@ func (m *Manager) List() ([]string, error) {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("failed to read allowlist file: %w", err)
+		return nil, fmt.Errorf("failed to read %s file: %w", m.name, err)
 	}
 
 	return domains, nil
 }
 
+// updateChecksum records the content just written, so the resolver knows sinkzone wrote it,
+// and the allowlist's size for reports
+func (m *Manager) updateChecksum() error {
+	if !m.checksum {
+		return nil
+	}
+	m.recordSize()
+	return config.UpdateChecksum(m.allowlistPath)
+}
+
+// recordSize notes the allowlist's size in the state file. A failure, e.g. with the state
+// of a resolver running as another user, only leaves the change out of reports.
+func (m *Manager) recordSize() {
+	domains, err := m.List()
+	if err != nil {
+		return
+	}
+	stateMgr, err := config.NewStateManager()
+	if err != nil {
+		return
+	}
+	_ = stateMgr.RecordAllowlistSize(len(domains), time.Now())
+}
+
 // GetPath returns the allowlist file path
 func (m *Manager) GetPath() string {
 	return m.allowlistPath
@@ -0,0 +1,73 @@
+package allowlist
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestBatchChanges(t *testing.T) {
+	dir := t.TempDir()
+	manager := &Manager{allowlistPath: filepath.Join(dir, "allowlist.txt"), name: "allowlist", journalPath: filepath.Join(dir, "allowlist-journal.json")}
+	if err := os.WriteFile(manager.allowlistPath, []byte("github.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		add       bool
+		domains   []string
+		changed   []string
+		allowlist []string
+		journal   int // Changes in the journal afterwards
+	}{
+		{"add skips duplicates and listed domains", true, []string{"a.com", "github.com", "a.com", "", "b.com"}, []string{"a.com", "b.com"}, []string{"github.com", "a.com", "b.com"}, 1},
+		{"add only listed domains", true, []string{"a.com", "github.com"}, nil, []string{"github.com", "a.com", "b.com"}, 1},
+		{"remove skips missing entries", false, []string{"b.com", "missing.com", "b.com", "github.com"}, []string{"github.com", "b.com"}, []string{"a.com"}, 2},
+		{"remove only missing entries", false, []string{"missing.com"}, nil, []string{"a.com"}, 2},
+	}
+	for _, test := range tests {
+		var changed []string
+		var err error
+		if test.add {
+			changed, err = manager.AddAll(test.domains)
+		} else {
+			changed, err = manager.RemoveAll(test.domains)
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		if !slices.Equal(changed, test.changed) {
+			t.Errorf("%s: expected %v to change, got %v", test.name, test.changed, changed)
+		}
+		if allowlist, err := manager.List(); err != nil || !slices.Equal(allowlist, test.allowlist) {
+			t.Errorf("%s: expected the allowlist %v, got %v (%v)", test.name, test.allowlist, allowlist, err)
+		}
+		if journal, err := manager.Journal(); err != nil || len(journal) != test.journal {
+			t.Errorf("%s: expected %d journaled changes, got %v (%v)", test.name, test.journal, journal, err)
+		}
+	}
+
+	// Undoing the batch remove restores every domain it removed at once
+	change, err := manager.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if allowlist, _ := manager.List(); change.Operation != "remove github.com, b.com" || !slices.Equal(allowlist, []string{"github.com", "a.com", "b.com"}) {
+		t.Errorf("expected undoing %q to restore both domains, got %v", change.Operation, allowlist)
+	}
+}
+
+func TestRemoveAllWithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	manager := &Manager{allowlistPath: filepath.Join(dir, "allowlist.txt"), name: "allowlist", journalPath: filepath.Join(dir, "allowlist-journal.json")}
+
+	removed, err := manager.RemoveAll([]string{"github.com"})
+	if err != nil || removed != nil {
+		t.Errorf("expected nothing to be removed without an allowlist, got %v (%v)", removed, err)
+	}
+	if _, err := os.Stat(manager.allowlistPath); !os.IsNotExist(err) {
+		t.Errorf("expected no allowlist to be created, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package allowlist
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxJournalChanges bounds the changes the journal keeps to undo
+const maxJournalChanges = 20
+
+// ErrNothingToUndo is returned by Undo when the journal is empty
+var ErrNothingToUndo = errors.New("nothing to undo")
+
+// Change is a write to the list, kept in the journal to undo it
+type Change struct {
+	Time      time.Time `json:"time"`
+	Operation string    `json:"operation"` // e.g. "remove github.com" or "add 12 domains"
+	Previous  string    `json:"previous"`  // Content of the list file before the change
+}
+
+// describeChange names a change to several domains, listing them when there are few
+func describeChange(verb string, domains []string) string {
+	if len(domains) <= 3 {
+		return verb + " " + strings.Join(domains, ", ")
+	}
+	return fmt.Sprintf("%s %d domains", verb, len(domains))
+}
+
+// snapshot returns the list's content before a write, for record
+func (m *Manager) snapshot() (string, error) {
+	if m.journalPath == "" {
+		return "", nil
+	}
+	return m.Read()
+}
+
+// record adds a write to the journal, dropping the oldest changes beyond maxJournalChanges
+func (m *Manager) record(operation, previous string) error {
+	if m.journalPath == "" {
+		return nil
+	}
+	changes, err := m.Journal()
+	if err != nil {
+		return err
+	}
+	changes = append(changes, Change{Time: time.Now(), Operation: operation, Previous: previous})
+	if len(changes) > maxJournalChanges {
+		changes = changes[len(changes)-maxJournalChanges:]
+	}
+	if err := m.saveJournal(changes); err != nil {
+		return fmt.Errorf("%s saved, but the change could not be recorded for undo: %w", m.name, err)
+	}
+	return nil
+}
+
+// Journal returns the changes that can be undone, oldest first
+func (m *Manager) Journal() ([]Change, error) {
+	if m.journalPath == "" {
+		return nil, nil
+	}
+	// #nosec G304 -- m.journalPath is a hardcoded path in the data directory
+	data, err := os.ReadFile(m.journalPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s journal: %w", m.name, err)
+	}
+	var changes []Change
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s journal: %w", m.name, err)
+	}
+	return changes, nil
+}
+
+func (m *Manager) saveJournal(changes []Change) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s journal: %w", m.name, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.journalPath), 0750); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", m.name, err)
+	}
+	if err := os.WriteFile(m.journalPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write %s journal: %w", m.name, err)
+	}
+	return nil
+}
+
+// Undo restores the list as it was before the last recorded change and returns that change.
+// Undoing again goes back one more change; an undo can't itself be undone.
+func (m *Manager) Undo() (*Change, error) {
+	changes, err := m.Journal()
+	if err != nil {
+		return nil, err
+	}
+	if len(changes) == 0 {
+		return nil, ErrNothingToUndo
+	}
+
+	last := changes[len(changes)-1]
+	if err := m.replace(last.Previous); err != nil {
+		return nil, err
+	}
+	if err := m.saveJournal(changes[:len(changes)-1]); err != nil {
+		return nil, err
+	}
+	return &last, m.updateChecksum()
+}
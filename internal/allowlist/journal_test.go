@@ -0,0 +1,62 @@
+package allowlist
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestUndo(t *testing.T) {
+	dir := t.TempDir()
+	manager := &Manager{allowlistPath: filepath.Join(dir, "allowlist.txt"), name: "allowlist", journalPath: filepath.Join(dir, "allowlist-journal.json")}
+
+	read := func() string {
+		t.Helper()
+		content, err := manager.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return content
+	}
+
+	if err := manager.Add("github.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.AddAll([]string{"a.com", "b.com", "c.com", "d.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Remove("github.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Save(read()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving without changes records nothing, so undo starts with the remove
+	steps := []struct{ operation, content string }{
+		{"remove github.com", "github.com\na.com\nb.com\nc.com\nd.com\n"},
+		{"add 4 domains", "github.com\n"},
+		{"add github.com", ""},
+	}
+	for _, step := range steps {
+		change, err := manager.Undo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if change.Operation != step.operation || read() != step.content {
+			t.Fatalf("expected undoing %q to restore %q, got %q restoring %q", step.operation, step.content, change.Operation, read())
+		}
+	}
+	if _, err := manager.Undo(); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("expected nothing left to undo, got %v", err)
+	}
+
+	for i := 0; i < maxJournalChanges+5; i++ {
+		if _, err := manager.AddAll([]string{string(rune('a'+i)) + ".org"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if changes, err := manager.Journal(); err != nil || len(changes) != maxJournalChanges || changes[0].Operation != "add f.org" {
+		t.Errorf("expected the journal to keep the last %d changes, got %d (%v)", maxJournalChanges, len(changes), err)
+	}
+}
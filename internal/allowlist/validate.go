@@ -0,0 +1,157 @@
+package allowlist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Template is the comment header 'sinkzone allowlist edit' puts at the top of the file
+const Template = `# Sinkzone allowlist: domains that stay reachable during focus mode.
+# One domain or wildcard pattern per line; lines starting with # are ignored.
+#
+#   github.com        exactly github.com
+#   *.github.com      any subdomain of github.com
+#   *github*          any domain containing "github"
+#   api.*.com         api.<anything>.com
+#
+`
+
+// LineError reports an invalid entry in a list file
+type LineError struct {
+	Line  int
+	Entry string
+	Err   error
+}
+
+func (e LineError) Error() string {
+	return fmt.Sprintf("line %d: %q: %v", e.Line, e.Entry, e.Err)
+}
+
+// ValidatePattern checks that a list entry is a domain or a wildcard pattern
+func ValidatePattern(pattern string) error {
+	if len(pattern) > 253 {
+		return fmt.Errorf("longer than 253 characters")
+	}
+	if strings.Trim(pattern, "*.") == "" {
+		return fmt.Errorf("matches every domain")
+	}
+	for _, r := range pattern {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.', r == '*':
+		case r == ' ' || r == '\t':
+			return fmt.Errorf("contains spaces; put one domain per line")
+		default:
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	switch {
+	case strings.HasPrefix(pattern, "."):
+		return fmt.Errorf("starts with a dot; use *%s to match subdomains", pattern)
+	case strings.HasSuffix(pattern, "."):
+		return fmt.Errorf("ends with a dot; remove it")
+	case strings.Contains(pattern, ".."):
+		return fmt.Errorf("contains an empty label (..)")
+	}
+	return nil
+}
+
+// MatchPattern reports whether a list entry matches a domain: exactly, or with each *
+// matching any run of characters including dots, as the resolver does
+func MatchPattern(pattern, domain string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == domain
+	}
+	if !strings.HasPrefix(domain, parts[0]) {
+		return false
+	}
+	domain = domain[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(domain, part)
+		if i < 0 {
+			return false
+		}
+		domain = domain[i+len(part):]
+	}
+	return strings.HasSuffix(domain, last)
+}
+
+// Validate checks every entry of a list file's content, skipping blank lines and comments
+func Validate(content string) []LineError {
+	var errs []LineError
+	for i, line := range strings.Split(content, "\n") {
+		entry := strings.TrimSpace(line)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if err := ValidatePattern(entry); err != nil {
+			errs = append(errs, LineError{Line: i + 1, Entry: entry, Err: err})
+		}
+	}
+	return errs
+}
+
+// Read returns the raw content of the list file, or an empty string if it doesn't exist
+func (m *Manager) Read() (string, error) {
+	// #nosec G304 -- m.allowlistPath is a hardcoded path from user home directory
+	data, err := os.ReadFile(m.allowlistPath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s file: %w", m.name, err)
+	}
+	return string(data), nil
+}
+
+// Save replaces the list file with content, e.g. after editing it
+func (m *Manager) Save(content string) error {
+	previous, err := m.snapshot()
+	if err != nil {
+		return err
+	}
+	if err := m.replace(content); err != nil {
+		return err
+	}
+	if err := m.updateChecksum(); err != nil {
+		return err
+	}
+	if content == previous {
+		// Saved without changes, nothing to undo
+		return nil
+	}
+	return m.record("edit", previous)
+}
+
+// replace writes content to a temporary file and renames it over the list file, so the
+// resolver never reads a half-written list
+func (m *Manager) replace(content string) error {
+	dir := filepath.Dir(m.allowlistPath)
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", m.name, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+m.name+"-*.txt")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary %s file: %w", m.name, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // Already renamed away on success
+	}()
+
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s file: %w", m.name, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s file: %w", m.name, err)
+	}
+	if err := os.Rename(tmpPath, m.allowlistPath); err != nil {
+		return fmt.Errorf("failed to replace %s file: %w", m.name, err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package allowlist
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePattern(t *testing.T) {
+	for _, pattern := range []string{"github.com", "*.github.com", "*github*", "api.*.com", "my_host.local", "xn--bcher-kva.de"} {
+		if err := ValidatePattern(pattern); err != nil {
+			t.Errorf("expected %q to be valid, got %v", pattern, err)
+		}
+	}
+	for _, pattern := range []string{"*", "*.*", ".github.com", "github.com.", "git..hub.com", "github.com gitlab.com", "https://github.com", "git[hub].com"} {
+		if err := ValidatePattern(pattern); err == nil {
+			t.Errorf("expected %q to be rejected", pattern)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	cases := []struct {
+		pattern, domain string
+		want            bool
+	}{
+		{"github.com", "github.com", true},
+		{"github.com", "api.github.com", false},
+		{"*.pool.ntp.org", "2.pool.ntp.org", true},
+		{"*.pool.ntp.org", "pool.ntp.org", false},
+		{"*telemetry*", "v10.events.data.telemetry.example", true},
+		{"api.*.com", "api.a.b.com", true},
+		{"api.*.com", "www.a.com", false},
+	}
+	for _, c := range cases {
+		if got := MatchPattern(c.pattern, c.domain); got != c.want {
+			t.Errorf("MatchPattern(%q, %q) = %v, expected %v", c.pattern, c.domain, got, c.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	content := Template + "github.com\n\n*.example.com\n.bad.com\n  # indented comment\n*\n"
+	errs := Validate(content)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	base := strings.Count(Template, "\n")
+	if errs[0].Line != base+4 || errs[0].Entry != ".bad.com" {
+		t.Errorf("expected .bad.com on line %d, got %+v", base+4, errs[0])
+	}
+	if errs[1].Line != base+6 || errs[1].Entry != "*" {
+		t.Errorf("expected * on line %d, got %+v", base+6, errs[1])
+	}
+}
+
+func TestSave(t *testing.T) {
+	manager := &Manager{allowlistPath: filepath.Join(t.TempDir(), "sinkzone", "allowlist.txt")}
+	if err := manager.Save("github.com\n"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := manager.Read()
+	if err != nil || content != "github.com\n" {
+		t.Errorf("expected the saved content, got %q (%v)", content, err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(manager.allowlistPath))
+	if len(entries) != 1 {
+		t.Errorf("expected no leftover temporary files, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SetAllowlistReloadCallback registers the function that rereads the allowlist on POST /api/allowlist/reload
+func (s *Server) SetAllowlistReloadCallback(callback func() error) {
+	s.onReloadAllowlist = callback
+}
+
+func (s *Server) handleReloadAllowlist(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Reload allowlist request", "remote", r.RemoteAddr)
+
+	if s.onReloadAllowlist == nil {
+		http.Error(w, "Reloading the allowlist is not available", http.StatusServiceUnavailable)
+		return
+	}
+	if err := s.onReloadAllowlist(); err != nil {
+		logger.Error("Reloading allowlist failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to reload allowlist: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// CacheStats is returned by GET /api/cache. Counters start over when the cache is resized.
+type CacheStats struct {
+	Enabled     bool   `json:"enabled"` // False when cache.size is 0
+	Size        int    `json:"size"`    // Maximum number of answers
+	Entries     int    `json:"entries"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`   // Least recently used answers dropped to make room
+	Expirations uint64 `json:"expirations"` // Answers dropped when their TTL ran out
+	Stale       uint64 `json:"stale"`       // Expired answers served because every upstream failed
+}
+
+// HitRatio returns the share of lookups answered from the cache, 0 to 1
+func (c CacheStats) HitRatio() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// CacheFlushResponse is returned by DELETE /api/cache
+type CacheFlushResponse struct {
+	Flushed int `json:"flushed"` // Answers dropped
+}
+
+// SetCacheCallbacks registers the functions that report and empty the DNS cache
+func (s *Server) SetCacheCallbacks(stats func() CacheStats, flush func() int) {
+	s.onGetCacheStats = stats
+	s.onFlushCache = flush
+}
+
+func (s *Server) handleGetCache(w http.ResponseWriter, r *http.Request) {
+	if s.onGetCacheStats == nil {
+		http.Error(w, "Cache statistics are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.onGetCacheStats()); err != nil {
+		logger.Error("Encoding cache response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleFlushCache(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Flush cache request", "remote", r.RemoteAddr)
+
+	if s.onFlushCache == nil {
+		http.Error(w, "Flushing the cache is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(CacheFlushResponse{Flushed: s.onFlushCache()}); err != nil {
+		logger.Error("Encoding cache flush response failed", "error", err)
+	}
+}
+
+// GetCacheStats returns the size and hit, miss, and eviction counts of the DNS cache
+func (c *Client) GetCacheStats() (*CacheStats, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/cache")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cache stats: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var stats CacheStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode cache stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// FlushCache empties the DNS cache and returns how many answers were dropped
+func (c *Client) FlushCache() (int, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/cache", nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to flush cache: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError(resp)
+	}
+
+	var response CacheFlushResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return 0, fmt.Errorf("failed to decode cache flush response: %w", err)
+	}
+
+	return response.Flushed, nil
+}
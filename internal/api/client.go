@@ -5,9 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/berbyte/sinkzone/internal/version"
 )
 
 type Client struct {
@@ -15,17 +19,38 @@ type Client struct {
 	client  *http.Client
 }
 
+// NewClient returns a client of the API at baseURL, sending the token of SetTokenSource
 func NewClient(baseURL string) *Client {
+	token := ""
+	if tokenSource != nil {
+		token = tokenSource()
+	}
+	return NewClientWithToken(baseURL, token)
+}
+
+// NewClientWithToken returns a client of the API at baseURL that sends token, if not empty
+func NewClientWithToken(baseURL, token string) *Client {
+	client := &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: clientTransport(),
+	}
+	if token != "" {
+		client.Transport = &tokenTransport{token: token, base: clientTransport()}
+	}
 	return &Client{
 		baseURL: baseURL,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client:  client,
 	}
 }
 
-func (c *Client) GetQueries() ([]DNSQuery, error) {
-	resp, err := c.client.Get(c.baseURL + "/api/queries")
+// GetQueries returns the newest recent queries, oldest first: limit of them, or the resolver's
+// default of 100 when limit is 0
+func (c *Client) GetQueries(limit int) ([]DNSQuery, error) {
+	path := "/api/queries"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+	resp, err := c.client.Get(c.baseURL + path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get queries: %w", err)
 	}
@@ -48,6 +73,122 @@ func (c *Client) GetQueries() ([]DNSQuery, error) {
 	return queries, nil
 }
 
+// GetQueryHits returns how often domains were queried by each client since the resolver
+// started, most queried first, narrowed by the filter's Domain, Client, and Limit
+func (c *Client) GetQueryHits(filter QueryFilter) ([]Hit, error) {
+	params := url.Values{}
+	if filter.Domain != "" {
+		params.Set("domain", filter.Domain)
+	}
+	if filter.Client != "" {
+		params.Set("client", filter.Client)
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	endpoint := c.baseURL + "/api/queries/hits"
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	resp, err := c.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query hits: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var hits []Hit
+	if err := json.NewDecoder(resp.Body).Decode(&hits); err != nil {
+		return nil, fmt.Errorf("failed to decode query hits: %w", err)
+	}
+	return hits, nil
+}
+
+// GetQueryHistory returns queries from the resolver's query log, oldest first
+func (c *Client) GetQueryHistory(filter QueryFilter) ([]DNSQuery, error) {
+	params := url.Values{}
+	if !filter.Since.IsZero() {
+		params.Set("since", filter.Since.Format(time.RFC3339Nano))
+	}
+	if !filter.Until.IsZero() {
+		params.Set("until", filter.Until.Format(time.RFC3339Nano))
+	}
+	if filter.Domain != "" {
+		params.Set("domain", filter.Domain)
+	}
+	if filter.Client != "" {
+		params.Set("client", filter.Client)
+	}
+	if filter.Limit > 0 {
+		params.Set("limit", strconv.Itoa(filter.Limit))
+	}
+	endpoint := c.baseURL + "/api/queries/history"
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+
+	resp, err := c.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query history: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var queries []DNSQuery
+	if err := json.NewDecoder(resp.Body).Decode(&queries); err != nil {
+		return nil, fmt.Errorf("failed to decode query history: %w", err)
+	}
+
+	return queries, nil
+}
+
+// PruneQueries deletes old queries from the resolver's query log
+func (c *Client) PruneQueries(req PruneRequest) (*PruneResult, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/api/queries/prune", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune queries: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var result PruneResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode prune result: %w", err)
+	}
+
+	return &result, nil
+}
+
 func (c *Client) GetFocusMode() (*FocusModeState, error) {
 	resp, err := c.client.Get(c.baseURL + "/api/focus")
 	if err != nil {
@@ -73,14 +214,14 @@ func (c *Client) GetFocusMode() (*FocusModeState, error) {
 }
 
 func (c *Client) SetFocusMode(enabled bool, duration string) error {
-	req := struct {
-		Enabled  bool   `json:"enabled"`
-		Duration string `json:"duration,omitempty"`
-	}{
+	return c.SetFocusModeWithOptions(FocusRequest{
 		Enabled:  enabled,
 		Duration: duration,
-	}
+	})
+}
 
+// SetFocusModeWithOptions changes focus mode using the full set of session options
+func (c *Client) SetFocusModeWithOptions(req FocusRequest) error {
 	body, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -98,7 +239,105 @@ func (c *Client) SetFocusMode(enabled bool, duration string) error {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+func (c *Client) PauseFocusMode(duration, pin string) error {
+	return c.PauseFocusModeWithOptions(PauseRequest{Duration: duration, PIN: pin})
+}
+
+// PauseFocusModeWithOptions pauses focus mode, optionally as a break that only unblocks break domains
+func (c *Client) PauseFocusModeWithOptions(req PauseRequest) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/api/focus/pause", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return fmt.Errorf("failed to pause focus mode: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// SnoozeDomain lets a single blocked domain resolve for a limited time during the session
+func (c *Client) SnoozeDomain(req SnoozeRequest) (*Snooze, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/api/focus/snooze", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to snooze domain: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var snooze Snooze
+	if err := json.NewDecoder(resp.Body).Decode(&snooze); err != nil {
+		return nil, fmt.Errorf("failed to decode snooze: %w", err)
+	}
+
+	return &snooze, nil
+}
+
+func (c *Client) ResumeFocusMode() error {
+	resp, err := c.client.Post(c.baseURL+"/api/focus/resume", "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("failed to resume focus mode: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// ReloadAllowlist asks the resolver to reread the allowlist file
+func (c *Client) ReloadAllowlist() error {
+	resp, err := c.client.Post(c.baseURL+"/api/allowlist/reload", "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("failed to reload allowlist: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
 	}
 
 	return nil
@@ -128,30 +367,301 @@ func (c *Client) GetState() (*ResolverState, error) {
 	return &state, nil
 }
 
+// GetScheduledSessions returns the queued focus sessions
+func (c *Client) GetScheduledSessions() ([]ScheduledSession, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/focus/schedule")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled sessions: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var sessions []ScheduledSession
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// ScheduleFocusSession queues a focus session to start later
+func (c *Client) ScheduleFocusSession(session ScheduledSession) (*ScheduledSession, error) {
+	jsonData, err := json.Marshal(session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/api/focus/schedule", "application/json", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to schedule session: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, responseError(resp)
+	}
+
+	var scheduled ScheduledSession
+	if err := json.NewDecoder(resp.Body).Decode(&scheduled); err != nil {
+		return nil, fmt.Errorf("failed to decode scheduled session: %w", err)
+	}
+
+	return &scheduled, nil
+}
+
+// CancelScheduledSession removes a queued focus session
+func (c *Client) CancelScheduledSession(id string) error {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/focus/schedule/"+url.PathEscape(id), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to cancel session: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	return nil
+}
+
+// GetStats returns daily goal progress and streaks
+func (c *Client) GetStats() (*FocusStats, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/stats")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var stats FocusStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// GetQueryStats returns query totals, top domains and clients, and recent activity
+func (c *Client) GetQueryStats() (*QueryStats, error) {
+	return c.GetQueryStatsSince(0)
+}
+
+// GetQueryStatsSince returns query stats over the given window, or since startup for 0
+func (c *Client) GetQueryStatsSince(window time.Duration) (*QueryStats, error) {
+	endpoint := c.baseURL + "/api/stats/queries"
+	if window > 0 {
+		endpoint += "?since=" + url.QueryEscape(window.String())
+	}
+	resp, err := c.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get query stats: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var stats QueryStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, fmt.Errorf("failed to decode query stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
 func (c *Client) HealthCheck() error {
-	// log.Printf("API Client: Attempting health check to %s/health", c.baseURL)
+	logger.Debug("API client health check", "url", c.baseURL+"/health")
 
 	resp, err := c.client.Get(c.baseURL + "/health")
 	if err != nil {
-		log.Printf("API Client: Health check failed with error: %v", err)
+		logger.Debug("API client health check failed", "error", err)
 		return fmt.Errorf("health check failed: %w", err)
 	}
 	defer func() {
 		if closeErr := resp.Body.Close(); closeErr != nil {
 			// Log the error but don't return it since we're already returning
-			log.Printf("Warning: failed to close response body: %v", closeErr)
+			logger.Warn("Failed to close response body", "error", closeErr)
 		}
 	}()
 
-	// log.Printf("API Client: Health check response status: %d", resp.StatusCode)
+	logger.Debug("API client health check response", "status", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		log.Printf("API Client: Health check failed with status %d, body: %s", resp.StatusCode, string(body))
+		logger.Debug("API client health check failed", "status", resp.StatusCode, "body", string(body))
 		return fmt.Errorf("health check returned status: %d", resp.StatusCode)
 	}
 
-	// body, _ := io.ReadAll(resp.Body)
-	// log.Printf("API Client: Health check successful, response: %s", string(body))
+	logger.Debug("API client health check successful")
 	return nil
 }
+
+// GetVersion returns the build metadata of the resolver, reported by GET /health
+func (c *Client) GetVersion() (*version.Info, error) {
+	resp, err := c.client.Get(c.baseURL + "/health")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resolver version: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	// Resolvers from before version reporting answer with a plain "OK"
+	var health HealthInfo
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || health.Version == "" {
+		return nil, fmt.Errorf("the resolver does not report its version (it is older than this CLI)")
+	}
+	return &health.Info, nil
+}
+
+// GetPID returns the process ID of the resolver, reported by GET /health
+func (c *Client) GetPID() (int, error) {
+	resp, err := c.client.Get(c.baseURL + "/health")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get resolver PID: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError(resp)
+	}
+
+	var health HealthInfo
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil || health.PID == 0 {
+		return 0, fmt.Errorf("the resolver does not report its PID (it is older than this CLI)")
+	}
+	return health.PID, nil
+}
+
+// responseError builds an error from a non-OK response, including the server's message if any
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	if message := strings.TrimSpace(string(body)); message != "" {
+		return fmt.Errorf("unexpected status code: %d (%s)", resp.StatusCode, message)
+	}
+	return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+}
+
+// ListPairings returns the browser extensions waiting for approval
+func (c *Client) ListPairings() ([]Pairing, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/extension/pairings")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pairings: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var pairings []Pairing
+	if err := json.NewDecoder(resp.Body).Decode(&pairings); err != nil {
+		return nil, fmt.Errorf("failed to decode pairings: %w", err)
+	}
+
+	return pairings, nil
+}
+
+// ApprovePairing gives the browser extension showing code a token of the extension scope
+func (c *Client) ApprovePairing(code string) (*Pairing, error) {
+	resp, err := c.client.Post(c.baseURL+"/api/extension/pairings/"+url.PathEscape(code), "application/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve pairing: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var pairing Pairing
+	if err := json.NewDecoder(resp.Body).Decode(&pairing); err != nil {
+		return nil, fmt.Errorf("failed to decode pairing: %w", err)
+	}
+
+	return &pairing, nil
+}
+
+// GetDecision returns whether the resolver blocks domain right now, and why
+func (c *Client) GetDecision(domain string) (*Decision, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/extension/decision?domain=" + url.QueryEscape(domain))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decision: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var decision Decision
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("failed to decode decision: %w", err)
+	}
+
+	return &decision, nil
+}
@@ -1,8 +1,13 @@
 package api
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
+
+	"github.com/berbyte/sinkzone/internal/version"
 )
 
 func TestNewClient(t *testing.T) {
@@ -32,7 +37,7 @@ func TestHealthCheck(t *testing.T) {
 
 func TestGetQueries(t *testing.T) {
 	client := NewClient("http://127.0.0.1:8080")
-	queries, err := client.GetQueries()
+	queries, err := client.GetQueries(0)
 	if err != nil {
 		t.Skipf("Get queries failed (resolver not running): %v", err)
 	}
@@ -71,3 +76,29 @@ func TestGetState(t *testing.T) {
 	}
 }
 */
+
+func TestGetVersion(t *testing.T) {
+	server := NewServer("0")
+	ts := httptest.NewServer(http.HandlerFunc(server.handleHealth))
+	defer ts.Close()
+
+	info, err := NewClient(ts.URL).GetVersion()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *info != version.Get() {
+		t.Errorf("Expected %+v, got %+v", version.Get(), *info)
+	}
+	if pid, err := NewClient(ts.URL).GetPID(); err != nil || pid != os.Getpid() {
+		t.Errorf("Expected PID %d, got %d (%v)", os.Getpid(), pid, err)
+	}
+
+	// Older resolvers answer with a plain OK
+	old := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer old.Close()
+	if _, err := NewClient(old.URL).GetVersion(); err == nil {
+		t.Error("Expected an error for a resolver without version reporting")
+	}
+}
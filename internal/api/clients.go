@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrUnknownClient is returned when removing a client client_names doesn't name
+var ErrUnknownClient = errors.New("no such client in client_names")
+
+// ClientName is an entry of client_names
+type ClientName struct {
+	Address string `json:"address"` // IP address, or MAC address
+	Name    string `json:"name"`
+}
+
+// ClientNameRequest is the body accepted by PUT /api/clients/{client}
+type ClientNameRequest struct {
+	Name  string `json:"name"`
+	ByMAC bool   `json:"by_mac,omitempty"` // Name the MAC address the neighbor table has for the IP address
+}
+
+// SetClientNamesCallbacks registers the functions that list client_names, and that save a
+// name or remove one and apply the change
+func (s *Server) SetClientNamesCallbacks(list func() []ClientName, set func(address, name string, byMAC bool) (ClientName, error), remove func(client string) ([]ClientName, error)) {
+	s.onListClientNames = list
+	s.onSetClientName = set
+	s.onRemoveClientName = remove
+}
+
+func (s *Server) handleGetClientNames(w http.ResponseWriter, r *http.Request) {
+	names := []ClientName{}
+	if s.onListClientNames != nil {
+		names = append(names, s.onListClientNames()...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(names); err != nil {
+		logger.Error("Encoding client names response failed", "error", err)
+	}
+}
+
+func (s *Server) handleSetClientName(w http.ResponseWriter, r *http.Request) {
+	var req ClientNameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if s.onSetClientName == nil {
+		http.Error(w, "Naming clients is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	name, err := s.onSetClientName(mux.Vars(r)["client"], req.Name, req.ByMAC)
+	if err != nil {
+		logger.Error("Naming client failed", "client", mux.Vars(r)["client"], "error", err)
+		http.Error(w, fmt.Sprintf("Failed to name client: %v", err), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(name); err != nil {
+		logger.Error("Encoding client name response failed", "error", err)
+	}
+}
+
+// handleRemoveClientName removes a client from client_names, given by address or name
+func (s *Server) handleRemoveClientName(w http.ResponseWriter, r *http.Request) {
+	if s.onRemoveClientName == nil {
+		http.Error(w, "Naming clients is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	removed, err := s.onRemoveClientName(mux.Vars(r)["client"])
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrUnknownClient) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, fmt.Sprintf("Failed to remove client name: %v", err), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(removed); err != nil {
+		logger.Error("Encoding client names response failed", "error", err)
+	}
+}
+
+// GetClientNames returns the entries of client_names the resolver applies
+func (c *Client) GetClientNames() ([]ClientName, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/clients")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client names: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var names []ClientName
+	if err := json.NewDecoder(resp.Body).Decode(&names); err != nil {
+		return nil, fmt.Errorf("failed to decode client names: %w", err)
+	}
+
+	return names, nil
+}
+
+// SetClientName names a client, given by IP or MAC address, in client_names
+func (c *Client) SetClientName(client string, req ClientNameRequest) (*ClientName, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPut, c.baseURL+"/api/clients/"+url.PathEscape(client), bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to name client: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var name ClientName
+	if err := json.NewDecoder(resp.Body).Decode(&name); err != nil {
+		return nil, fmt.Errorf("failed to decode client name: %w", err)
+	}
+
+	return &name, nil
+}
+
+// RemoveClientName removes a client, given by address or name, from client_names and
+// returns the entries removed
+func (c *Client) RemoveClientName(client string) ([]ClientName, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/clients/"+url.PathEscape(client), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove client name: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var removed []ClientName
+	if err := json.NewDecoder(resp.Body).Decode(&removed); err != nil {
+		return nil, fmt.Errorf("failed to decode client names: %w", err)
+	}
+
+	return removed, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Cooldown is a client the resolver refuses for a while after too many rate-limited or
+// malformed queries, listed by GET /api/cooldowns
+type Cooldown struct {
+	Client  string    `json:"client"`
+	Name    string    `json:"name,omitempty"` // From client_names
+	Reason  string    `json:"reason"`         // rate_limit or malformed, the last strike
+	Until   time.Time `json:"until"`
+	Refused uint64    `json:"refused"` // Queries refused since the cooldown started
+}
+
+// CooldownLiftResponse is returned by DELETE /api/cooldowns and /api/cooldowns/{client}
+type CooldownLiftResponse struct {
+	Lifted int `json:"lifted"` // Clients no longer on cooldown
+}
+
+// SetCooldownCallbacks registers the functions that list clients on cooldown and lift
+// the cooldown of one client, or of every client when given ""
+func (s *Server) SetCooldownCallbacks(list func() []Cooldown, lift func(client string) int) {
+	s.onGetCooldowns = list
+	s.onLiftCooldown = lift
+}
+
+func (s *Server) handleGetCooldowns(w http.ResponseWriter, r *http.Request) {
+	if s.onGetCooldowns == nil {
+		http.Error(w, "Cooldowns are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.onGetCooldowns()); err != nil {
+		logger.Error("Encoding cooldowns response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleLiftCooldown(w http.ResponseWriter, r *http.Request) {
+	client := mux.Vars(r)["client"]
+	logger.Debug("Lift cooldown request", "remote", r.RemoteAddr, "client", client)
+
+	if s.onLiftCooldown == nil {
+		http.Error(w, "Lifting cooldowns is not available", http.StatusServiceUnavailable)
+		return
+	}
+	lifted := s.onLiftCooldown(client)
+	if client != "" && lifted == 0 {
+		http.Error(w, "Client is not on cooldown", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(CooldownLiftResponse{Lifted: lifted}); err != nil {
+		logger.Error("Encoding cooldown lift response failed", "error", err)
+	}
+}
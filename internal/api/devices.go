@@ -0,0 +1,369 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/gorilla/mux"
+)
+
+// Devices are the clients of the resolver, told apart by their MAC address where the
+// neighbor table has one (see lan) and by their address otherwise. Each follows the focus
+// session unless an override keeps it in focus or out of it.
+
+// Device focus modes, as in config.DeviceFocus*
+const (
+	DeviceFocusFollow = "follow"
+	DeviceFocusOn     = "on"
+	DeviceFocusOff    = "off"
+)
+
+// recentDeviceQueries is how many queries GET /api/devices/{device} returns
+const recentDeviceQueries = 20
+
+// Device is a client of the resolver, with its queries since the resolver started
+type Device struct {
+	ID         string     `json:"id"`                // MAC address, or client address
+	Address    string     `json:"address,omitempty"` // Latest client address
+	MAC        string     `json:"mac,omitempty"`
+	Name       string     `json:"name,omitempty"` // From client_names
+	FirstSeen  *time.Time `json:"first_seen,omitempty"`
+	LastSeen   *time.Time `json:"last_seen,omitempty"`
+	Queries    int        `json:"queries"`
+	Blocked    int        `json:"blocked"`
+	FocusMode  string     `json:"focus_mode"`            // follow, on, or off
+	FocusUntil *time.Time `json:"focus_until,omitempty"` // When an on or off override lapses
+	Focus      bool       `json:"focus"`                 // Its queries are blocked now
+}
+
+// Label names the device: its name from client_names, or its address
+func (d Device) Label() string {
+	switch {
+	case d.Name != "":
+		return d.Name
+	case d.Address != "":
+		return d.Address
+	}
+	return d.ID
+}
+
+// DeviceDetail is returned by GET /api/devices/{device}
+type DeviceDetail struct {
+	Device
+	TopDomains []Count    `json:"top_domains"`
+	TopBlocked []Count    `json:"top_blocked"`
+	Recent     []DNSQuery `json:"recent"` // Newest first
+}
+
+// DeviceFocus overrides the focus session for one device
+type DeviceFocus struct {
+	Mode  string     `json:"mode"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// DeviceFocusRequest is the body accepted by PUT /api/devices/{device}/focus
+type DeviceFocusRequest struct {
+	Mode     string `json:"mode"`               // follow, on, or off
+	Duration string `json:"duration,omitempty"` // How long on or off lasts; until changed when empty
+	PIN      string `json:"pin,omitempty"`      // Needed to take a device out of focus when a PIN is set
+}
+
+// DeviceID returns the device that sent the query: its MAC address, or its address
+func (q DNSQuery) DeviceID() string {
+	if q.ClientMAC != "" {
+		return q.ClientMAC
+	}
+	return q.Client
+}
+
+// deviceTracker counts the queries of each device
+type deviceTracker struct {
+	mu      sync.Mutex
+	devices map[string]*Device
+}
+
+func newDeviceTracker() *deviceTracker {
+	return &deviceTracker{devices: make(map[string]*Device)}
+}
+
+func (t *deviceTracker) add(query DNSQuery) {
+	id := query.DeviceID()
+	if id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	device, ok := t.devices[id]
+	if !ok {
+		if len(t.devices) >= maxCountedKeys {
+			return
+		}
+		seen := query.Timestamp
+		device = &Device{ID: id, FirstSeen: &seen}
+		t.devices[id] = device
+	}
+	seen := query.Timestamp
+	device.LastSeen = &seen
+	device.Address = query.Client
+	device.MAC = query.ClientMAC
+	device.Name = query.ClientName
+	device.Queries++
+	if query.Blocked {
+		device.Blocked++
+	}
+}
+
+// list returns copies of the devices seen
+func (t *deviceTracker) list() []Device {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	devices := make([]Device, 0, len(t.devices))
+	for _, device := range t.devices {
+		devices = append(devices, *device)
+	}
+	return devices
+}
+
+// SetDeviceFocusCallbacks registers the functions that list the device overrides, and
+// that set one and return the device it was saved for
+func (s *Server) SetDeviceFocusCallbacks(list func() map[string]DeviceFocus, set func(device string, focus DeviceFocus, pin string) (string, error)) {
+	s.onListDeviceFocus = list
+	s.onSetDeviceFocus = set
+}
+
+// Devices returns the devices seen since the resolver started and those with an override,
+// most recently seen first
+func (s *Server) Devices() []Device {
+	state := s.GetFocusState()
+	sessionBlocks := state.Enabled && (!state.Paused || state.Break)
+	var overrides map[string]DeviceFocus
+	if s.onListDeviceFocus != nil {
+		overrides = s.onListDeviceFocus()
+	}
+
+	// An override may name a device by its MAC address or its address
+	devices := s.devices.list()
+	applied := make(map[string]bool, len(overrides))
+	for i := range devices {
+		device := &devices[i]
+		device.FocusMode = DeviceFocusFollow
+		device.Focus = sessionBlocks
+		for _, id := range []string{device.ID, device.Address} {
+			if override, ok := overrides[id]; ok && !applied[id] {
+				applied[id] = true
+				device.FocusMode, device.FocusUntil = override.Mode, override.Until
+				device.Focus = override.Mode == DeviceFocusOn
+				break
+			}
+		}
+	}
+	for id, override := range overrides {
+		if !applied[id] {
+			devices = append(devices, Device{ID: id, FocusMode: override.Mode, FocusUntil: override.Until, Focus: override.Mode == DeviceFocusOn})
+		}
+	}
+
+	sort.Slice(devices, func(i, j int) bool {
+		a, b := devices[i].LastSeen, devices[j].LastSeen
+		switch {
+		case a != nil && b != nil && !a.Equal(*b):
+			return a.After(*b)
+		case (a == nil) != (b == nil):
+			return a != nil
+		}
+		return devices[i].ID < devices[j].ID
+	})
+	return devices
+}
+
+// findDevice looks a device up by ID, address, MAC address, or name
+func (s *Server) findDevice(key string) (Device, bool) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	devices := s.Devices()
+	for _, device := range devices {
+		if strings.ToLower(device.ID) == key {
+			return device, true
+		}
+	}
+	for _, device := range devices {
+		if strings.ToLower(device.Address) == key || strings.ToLower(device.MAC) == key || strings.ToLower(device.Name) == key {
+			return device, true
+		}
+	}
+	return Device{}, false
+}
+
+func (s *Server) handleGetDevices(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Devices()); err != nil {
+		logger.Error("Encoding devices response failed", "error", err)
+	}
+}
+
+// handleGetDevice returns the dashboard of one device: its most queried and most blocked
+// domains since the resolver started, and its newest queries
+func (s *Server) handleGetDevice(w http.ResponseWriter, r *http.Request) {
+	device, ok := s.findDevice(mux.Vars(r)["device"])
+	if !ok {
+		http.Error(w, "Device not found", http.StatusNotFound)
+		return
+	}
+
+	detail := DeviceDetail{Device: device, Recent: []DNSQuery{}}
+	detail.TopDomains, detail.TopBlocked = s.queryStats.deviceCounts(device.ID)
+	entries := s.history.latest(s.history.stats().Capacity)
+	for i := len(entries) - 1; i >= 0 && len(detail.Recent) < recentDeviceQueries; i-- {
+		if entries[i].DeviceID() == device.ID {
+			detail.Recent = append(detail.Recent, entries[i])
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(detail); err != nil {
+		logger.Error("Encoding device response failed", "error", err)
+	}
+}
+
+func (s *Server) handleSetDeviceFocus(w http.ResponseWriter, r *http.Request) {
+	var req DeviceFocusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	focus := DeviceFocus{Mode: req.Mode}
+	switch req.Mode {
+	case DeviceFocusFollow, DeviceFocusOn, DeviceFocusOff:
+	default:
+		http.Error(w, "Mode must be follow, on, or off", http.StatusBadRequest)
+		return
+	}
+	if req.Duration != "" && req.Mode != DeviceFocusFollow {
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, "Invalid duration format", http.StatusBadRequest)
+			return
+		}
+		until := clock.Now().Add(duration)
+		focus.Until = &until
+	}
+	if s.onSetDeviceFocus == nil {
+		http.Error(w, "Device focus is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	// Names and addresses of known devices stand for their ID; others are taken as given
+	id := mux.Vars(r)["device"]
+	if device, ok := s.findDevice(id); ok {
+		id = device.ID
+	}
+	saved, err := s.onSetDeviceFocus(id, focus, req.PIN)
+	if err != nil {
+		logger.Error("Setting device focus failed", "device", id, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to set device focus: %v", err), s.focusErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	device, ok := s.findDevice(saved)
+	if !ok {
+		device = Device{ID: saved, FocusMode: DeviceFocusFollow}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(device); err != nil {
+		logger.Error("Encoding device response failed", "error", err)
+	}
+}
+
+// GetDevices returns the devices the resolver has seen and those with an override
+func (c *Client) GetDevices() ([]Device, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/devices")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get devices: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var devices []Device
+	if err := json.NewDecoder(resp.Body).Decode(&devices); err != nil {
+		return nil, fmt.Errorf("failed to decode devices: %w", err)
+	}
+
+	return devices, nil
+}
+
+// GetDevice returns the dashboard of a device, given by ID, address, MAC address, or name
+func (c *Client) GetDevice(device string) (*DeviceDetail, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/devices/" + url.PathEscape(device))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var detail DeviceDetail
+	if err := json.NewDecoder(resp.Body).Decode(&detail); err != nil {
+		return nil, fmt.Errorf("failed to decode device: %w", err)
+	}
+
+	return &detail, nil
+}
+
+// SetDeviceFocus keeps a device in focus, out of it, or following the session
+func (c *Client) SetDeviceFocus(device string, req DeviceFocusRequest) (*Device, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPut, c.baseURL+"/api/devices/"+url.PathEscape(device)+"/focus", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set device focus: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var updated Device
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, fmt.Errorf("failed to decode device: %w", err)
+	}
+
+	return &updated, nil
+}
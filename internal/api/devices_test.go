@@ -0,0 +1,57 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestDevicesByMACWithFocusOverrides(t *testing.T) {
+	server := NewServer("0")
+	start := time.Now()
+	server.AddQuery(DNSQuery{Domain: "a.com", Client: "192.168.1.20", ClientMAC: "aa:bb:cc:dd:ee:01", ClientName: "kids-tablet", Timestamp: start})
+	// DHCP handed the tablet another address; it stays the same device
+	server.AddQuery(DNSQuery{Domain: "b.com", Client: "192.168.1.21", ClientMAC: "aa:bb:cc:dd:ee:01", ClientName: "kids-tablet", Timestamp: start.Add(time.Second), Blocked: true})
+	server.AddQuery(DNSQuery{Domain: "a.com", Client: "192.168.1.30", Timestamp: start.Add(2 * time.Second)})
+
+	overrides := map[string]DeviceFocus{}
+	server.SetDeviceFocusCallbacks(func() map[string]DeviceFocus { return overrides }, func(device string, focus DeviceFocus, pin string) (string, error) {
+		if focus.Mode == DeviceFocusFollow {
+			delete(overrides, device)
+		} else {
+			overrides[device] = focus
+		}
+		return device, nil
+	})
+
+	devices := server.Devices()
+	if len(devices) != 2 || devices[0].ID != "192.168.1.30" || devices[1].ID != "aa:bb:cc:dd:ee:01" {
+		t.Fatalf("Expected two devices, most recently seen first, got %+v", devices)
+	}
+	if tablet := devices[1]; tablet.Queries != 2 || tablet.Blocked != 1 || tablet.Address != "192.168.1.21" || tablet.FocusMode != DeviceFocusFollow {
+		t.Errorf("Expected the tablet's queries counted under its MAC address, got %+v", tablet)
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/api/devices/{device}/focus", server.handleSetDeviceFocus).Methods("PUT")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/devices/kids-tablet/focus", strings.NewReader(`{"mode": "on", "duration": "1h"}`)))
+	var device Device
+	if err := json.Unmarshal(rec.Body.Bytes(), &device); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected the override to be set, got status %d: %s", rec.Code, rec.Body.String())
+	}
+	if device.ID != "aa:bb:cc:dd:ee:01" || device.FocusMode != DeviceFocusOn || !device.Focus || device.FocusUntil == nil {
+		t.Errorf("Expected the name to stand for the MAC address and the device to be in focus, got %+v", device)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/devices/kids-tablet/focus", strings.NewReader(`{"mode": "sometimes"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown mode to be refused, got status %d", rec.Code)
+	}
+}
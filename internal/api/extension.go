@@ -0,0 +1,309 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// Routes for a browser extension: the decision for the domain of a tab, one-click
+// snoozes, and pairing, which gets the extension a token of the extension scope without
+// copying secrets around. Pairing starts in the extension, which shows a code; the user
+// approves it with 'sinkzone extension approve CODE' and the extension collects its token.
+
+const (
+	// DefaultExtensionAllow is how long POST /api/extension/allow lets a domain through
+	// when the request doesn't say
+	DefaultExtensionAllow = 10 * time.Minute
+	// pairingTTL is how long a pairing waits for approval and collection
+	pairingTTL = 5 * time.Minute
+	// maxPairings bounds the pairings waiting at once, as anyone may start one
+	maxPairings = 8
+)
+
+// Pairing states
+const (
+	PairingPending  = "pending"  // Waiting for approval
+	PairingApproved = "approved" // Approved; the token is returned once
+	PairingOpen     = "open"     // The API has no tokens, so none is needed
+)
+
+// Decision tells what the resolver would answer for a domain right now
+type Decision struct {
+	Domain       string     `json:"domain"`
+	Focus        bool       `json:"focus"`                   // Focus mode is blocking; nothing is blocked otherwise
+	Blocked      bool       `json:"blocked"`                 // Queries for the domain are blocked
+	WouldBlock   bool       `json:"would_block,omitempty"`   // Blocked, but a dry run or grace period lets it through
+	Reason       string     `json:"reason,omitempty"`        // Why the domain is blocked or let through
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"` // End of the domain's snooze
+}
+
+// ExtensionAllowRequest is the body accepted by POST /api/extension/allow
+type ExtensionAllowRequest struct {
+	URL      string `json:"url,omitempty"`      // Address of the tab, whose host is let through
+	Domain   string `json:"domain,omitempty"`   // Or the domain itself
+	Duration string `json:"duration,omitempty"` // Default 10m
+	PIN      string `json:"pin,omitempty"`
+}
+
+// PairRequest is the body accepted by POST /api/extension/pair
+type PairRequest struct {
+	Name string `json:"name"` // Shown when approving, e.g. "firefox"
+}
+
+// Pairing is a browser extension asking for a token
+type Pairing struct {
+	ID        string     `json:"id,omitempty"`   // Secret the extension polls GET /api/extension/pair/{id} with
+	Code      string     `json:"code,omitempty"` // Shown by the extension and approved by the user
+	Name      string     `json:"name,omitempty"`
+	Status    string     `json:"status"`
+	Token     string     `json:"token,omitempty"` // Returned once, when the extension collects an approved pairing
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// SetDecisionCallback registers the function that decides whether a domain is blocked
+func (s *Server) SetDecisionCallback(callback func(domain string) Decision) {
+	s.onDecide = callback
+}
+
+// SetTokenCallback registers the function that saves the token of an approved pairing
+// under a name and returns the name it was saved as
+func (s *Server) SetTokenCallback(callback func(name, secret string, scopes []string) (string, error)) {
+	s.onAddToken = callback
+}
+
+func (s *Server) handleGetDecision(w http.ResponseWriter, r *http.Request) {
+	domain := NormalizeDomain(r.URL.Query().Get("domain"))
+	if domain == "" {
+		http.Error(w, "Domain is required", http.StatusBadRequest)
+		return
+	}
+	if s.onDecide == nil {
+		http.Error(w, "Decisions are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.onDecide(domain)); err != nil {
+		logger.Error("Encoding decision response failed", "error", err)
+	}
+}
+
+func (s *Server) handleExtensionAllow(w http.ResponseWriter, r *http.Request) {
+	var req ExtensionAllowRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	domain := req.Domain
+	if req.URL != "" {
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Hostname() == "" {
+			http.Error(w, "Invalid URL", http.StatusBadRequest)
+			return
+		}
+		domain = u.Hostname()
+	}
+	duration := DefaultExtensionAllow
+	if req.Duration != "" {
+		var err error
+		duration, err = time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			http.Error(w, "Invalid duration format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.snooze(w, SnoozeRequest{Domain: domain, Duration: duration.String(), PIN: req.PIN})
+}
+
+func (s *Server) handleStartPairing(w http.ResponseWriter, r *http.Request) {
+	var req PairRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	name := pairingName(req.Name)
+
+	s.tokensMutex.RLock()
+	open := len(s.tokens) == 0
+	s.tokensMutex.RUnlock()
+	var pairing Pairing
+	if open {
+		pairing = Pairing{Name: name, Status: PairingOpen}
+	} else {
+		s.pairingsMutex.Lock()
+		s.expirePairings()
+		if len(s.pairings) >= maxPairings {
+			s.pairingsMutex.Unlock()
+			http.Error(w, "Too many pairings are waiting for approval", http.StatusTooManyRequests)
+			return
+		}
+		expiresAt := time.Now().Add(pairingTTL)
+		pairing = Pairing{ID: randomHex(16), Code: pairingCode(), Name: name, Status: PairingPending, ExpiresAt: &expiresAt}
+		if s.pairings == nil {
+			s.pairings = make(map[string]*Pairing)
+		}
+		s.pairings[pairing.ID] = &pairing
+		s.pairingsMutex.Unlock()
+		logger.Info("Browser extension pairing started", "name", name, "code", pairing.Code)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pairing); err != nil {
+		logger.Error("Encoding pairing response failed", "error", err)
+	}
+}
+
+// handleGetPairing reports the state of a pairing to the extension that started it, and
+// hands over the token once it is approved
+func (s *Server) handleGetPairing(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	s.pairingsMutex.Lock()
+	s.expirePairings()
+	pairing, ok := s.pairings[id]
+	var response Pairing
+	if ok {
+		response = *pairing
+		if pairing.Status == PairingApproved {
+			delete(s.pairings, id)
+		}
+	}
+	s.pairingsMutex.Unlock()
+	if !ok {
+		http.Error(w, "Pairing not found or expired", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Encoding pairing response failed", "error", err)
+	}
+}
+
+// handleListPairings lists the pairings waiting for approval, without their secrets
+func (s *Server) handleListPairings(w http.ResponseWriter, r *http.Request) {
+	s.pairingsMutex.Lock()
+	s.expirePairings()
+	pairings := []Pairing{}
+	for _, pairing := range s.pairings {
+		if pairing.Status == PairingPending {
+			pairings = append(pairings, Pairing{Code: pairing.Code, Name: pairing.Name, Status: pairing.Status, ExpiresAt: pairing.ExpiresAt})
+		}
+	}
+	s.pairingsMutex.Unlock()
+	sort.Slice(pairings, func(i, j int) bool {
+		return pairings[i].ExpiresAt.Before(*pairings[j].ExpiresAt)
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(pairings); err != nil {
+		logger.Error("Encoding pairings response failed", "error", err)
+	}
+}
+
+// handleApprovePairing creates the token of the pairing with the given code
+func (s *Server) handleApprovePairing(w http.ResponseWriter, r *http.Request) {
+	code := strings.ToUpper(strings.TrimSpace(mux.Vars(r)["code"]))
+	if s.onAddToken == nil {
+		http.Error(w, "Pairing is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	s.pairingsMutex.Lock()
+	defer s.pairingsMutex.Unlock()
+	s.expirePairings()
+	var pairing *Pairing
+	for _, candidate := range s.pairings {
+		if candidate.Code == code && candidate.Status == PairingPending {
+			pairing = candidate
+		}
+	}
+	if pairing == nil {
+		http.Error(w, "No pairing is waiting with that code", http.StatusNotFound)
+		return
+	}
+
+	secret := randomHex(32)
+	name, err := s.onAddToken("extension-"+pairing.Name, secret, []string{ScopeExtension})
+	if err != nil {
+		logger.Error("Saving extension token failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to save token: %v", err), http.StatusInternalServerError)
+		return
+	}
+	pairing.Status = PairingApproved
+	pairing.Token = secret
+	pairing.Name = name
+	logger.Info("Browser extension paired", "token", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Pairing{Code: pairing.Code, Name: name, Status: pairing.Status}); err != nil {
+		logger.Error("Encoding pairing response failed", "error", err)
+	}
+}
+
+// expirePairings drops the pairings that are past their time
+// This method assumes the caller holds the pairings lock
+func (s *Server) expirePairings() {
+	now := time.Now()
+	for id, pairing := range s.pairings {
+		if !now.Before(*pairing.ExpiresAt) {
+			delete(s.pairings, id)
+		}
+	}
+}
+
+// pairingName turns the name an extension sent into one fit for a token name: lowercase
+// letters, digits, and dashes
+func pairingName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		case r == '-' || r == ' ' || r == '_':
+			return '-'
+		}
+		return -1
+	}, strings.TrimSpace(name))
+	name = strings.Trim(name, "-")
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	if name == "" {
+		return "browser"
+	}
+	return name
+}
+
+// pairingCode returns a code that is easy to read out and type, e.g. K7QF-2MXA
+func pairingCode() string {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	code := make([]byte, 0, 9)
+	for i, c := range b {
+		if i == 4 {
+			code = append(code, '-')
+		}
+		code = append(code, alphabet[int(c)%len(alphabet)])
+	}
+	return string(code)
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
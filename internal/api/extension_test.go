@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestExtensionPairing(t *testing.T) {
+	server := NewServer("0")
+	server.SetTokens(map[string]Token{"admin-secret-00000001": {Name: "cli", Scopes: []string{ScopeAdmin}}})
+	var saved []string
+	server.SetTokenCallback(func(name, secret string, scopes []string) (string, error) {
+		saved = append(saved, name, strings.Join(scopes, ","))
+		return name, nil
+	})
+	router := mux.NewRouter()
+	router.HandleFunc("/api/extension/pair", server.handleStartPairing).Methods("POST")
+	router.HandleFunc("/api/extension/pair/{id}", server.handleGetPairing).Methods("GET")
+	router.HandleFunc("/api/extension/pairings/{code}", server.handleApprovePairing).Methods("POST")
+	request := func(method, path, body string) (int, Pairing) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		var pairing Pairing
+		_ = json.Unmarshal(rec.Body.Bytes(), &pairing)
+		return rec.Code, pairing
+	}
+
+	_, started := request(http.MethodPost, "/api/extension/pair", `{"name": "Firefox Dev"}`)
+	if started.Status != PairingPending || started.ID == "" || len(started.Code) != 9 {
+		t.Fatalf("Expected a pending pairing with an ID and code, got %+v", started)
+	}
+	if _, polled := request(http.MethodGet, "/api/extension/pair/"+started.ID, ""); polled.Status != PairingPending || polled.Token != "" {
+		t.Fatalf("Expected the pairing to wait for approval, got %+v", polled)
+	}
+
+	if code, _ := request(http.MethodPost, "/api/extension/pairings/"+strings.ToLower(started.Code), ""); code != http.StatusOK {
+		t.Fatalf("Expected the code to be approved, got status %d", code)
+	}
+	if len(saved) != 2 || saved[0] != "extension-firefox-dev" || saved[1] != ScopeExtension {
+		t.Errorf("Expected an extension token for extension-firefox-dev, got %v", saved)
+	}
+
+	if _, collected := request(http.MethodGet, "/api/extension/pair/"+started.ID, ""); collected.Status != PairingApproved || len(collected.Token) != 64 {
+		t.Fatalf("Expected the approved pairing to return the token, got %+v", collected)
+	}
+	if code, _ := request(http.MethodGet, "/api/extension/pair/"+started.ID, ""); code != http.StatusNotFound {
+		t.Errorf("Expected the token to be handed over only once, got status %d", code)
+	}
+}
+
+func TestExtensionPairingIsOpenWithoutTokens(t *testing.T) {
+	server := NewServer("0")
+	rec := httptest.NewRecorder()
+	server.handleStartPairing(rec, httptest.NewRequest(http.MethodPost, "/api/extension/pair", strings.NewReader(`{}`)))
+
+	var pairing Pairing
+	if err := json.Unmarshal(rec.Body.Bytes(), &pairing); err != nil {
+		t.Fatal(err)
+	}
+	if pairing.Status != PairingOpen || pairing.ID != "" {
+		t.Errorf("Expected pairing to be open without tokens, got %+v", pairing)
+	}
+}
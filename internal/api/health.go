@@ -0,0 +1,191 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Health states reported for the resolver and its upstreams
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+	HealthDown     = "down"
+	HealthUnknown  = "unknown" // Not queried yet
+
+	// DNSCheckBypassed means this machine's DNS queries don't reach the resolver
+	DNSCheckBypassed = "bypassed"
+)
+
+// UpstreamHealth reports how an upstream nameserver answered recently
+type UpstreamHealth struct {
+	Address             string     `json:"address"`
+	Status              string     `json:"status"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LatencyMS           int64      `json:"latency_ms,omitempty"` // Latency of the last successful exchange
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+
+	// Exchanges since the resolver started; timeouts are counted among the failures
+	Successes uint64  `json:"successes"`
+	Failures  uint64  `json:"failures"`
+	Timeouts  uint64  `json:"timeouts"`
+	AverageMS float64 `json:"average_ms,omitempty"` // Over the last 100 successful exchanges
+	P95MS     float64 `json:"p95_ms,omitempty"`
+}
+
+// UpstreamsResponse is returned by GET /api/upstreams
+type UpstreamsResponse struct {
+	Upstreams []UpstreamHealth `json:"upstreams"` // In the configured order
+}
+
+// DNSCheck reports whether this machine's DNS queries reach the resolver, as found by
+// resolving a unique name through the system resolver and watching for it
+type DNSCheck struct {
+	Status    string     `json:"status"` // ok, bypassed, or unknown before the first check
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+	LastOK    *time.Time `json:"last_ok,omitempty"` // When a check last saw the name arrive
+}
+
+// Bypassed reports whether the last check found this machine's DNS queries going elsewhere
+func (c *DNSCheck) Bypassed() bool {
+	return c != nil && c.Status == DNSCheckBypassed
+}
+
+// ResolverHealth is returned by GET /api/health
+type ResolverHealth struct {
+	DNSListening   bool             `json:"dns_listening"` // The DNS port is bound and serving
+	DNSPort        string           `json:"dns_port"`
+	UpstreamStatus string           `json:"upstream_status"` // Summary of all upstreams
+	Upstreams      []UpstreamHealth `json:"upstreams"`
+	DNSCheck       *DNSCheck        `json:"dns_check,omitempty"` // Omitted when bypass_check is off
+	Portal         *PortalState     `json:"portal,omitempty"`    // Captive portal detection and passthrough
+}
+
+// SetHealthCallback registers the function that reports DNS listener and upstream health
+func (s *Server) SetHealthCallback(callback func() ResolverHealth) {
+	s.onGetHealth = callback
+}
+
+func (s *Server) handleGetHealth(w http.ResponseWriter, r *http.Request) {
+	if s.onGetHealth == nil {
+		http.Error(w, "Health is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.onGetHealth()); err != nil {
+		logger.Error("Encoding health response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// handleGetUpstreams reports the per-upstream part of the health callback
+func (s *Server) handleGetUpstreams(w http.ResponseWriter, r *http.Request) {
+	if s.onGetHealth == nil {
+		http.Error(w, "Upstream statistics are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	response := UpstreamsResponse{Upstreams: s.onGetHealth().Upstreams}
+	if response.Upstreams == nil {
+		response.Upstreams = []UpstreamHealth{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Error("Encoding upstreams response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetHealth returns whether the DNS port is bound and how the upstreams are answering
+func (c *Client) GetHealth() (*ResolverHealth, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/health")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var health ResolverHealth
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("failed to decode health: %w", err)
+	}
+
+	return &health, nil
+}
+
+// GetUpstreams returns the success, failure, and latency statistics of each upstream
+func (c *Client) GetUpstreams() ([]UpstreamHealth, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/upstreams")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstreams: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var response UpstreamsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode upstreams: %w", err)
+	}
+
+	return response.Upstreams, nil
+}
+
+// Probe is returned by the container probes GET /livez and GET /readyz
+type Probe struct {
+	Status string `json:"status"`           // "ok", or "not ready"
+	Reason string `json:"reason,omitempty"` // Why the resolver isn't ready
+}
+
+// handleLivez answers as long as the API serves, for liveness probes
+func (s *Server) handleLivez(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, http.StatusOK, Probe{Status: HealthOK})
+}
+
+// handleReadyz answers 503 until the DNS port is bound and while every upstream is down,
+// for readiness probes. It tells no addresses, as it needs no token.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	reason := ""
+	if s.onGetHealth == nil {
+		reason = "resolver is starting"
+	} else if health := s.onGetHealth(); !health.DNSListening {
+		reason = "DNS port is not bound"
+	} else if health.UpstreamStatus == HealthDown {
+		reason = "no upstream nameserver is answering"
+	}
+
+	if reason != "" {
+		writeProbe(w, http.StatusServiceUnavailable, Probe{Status: "not ready", Reason: reason})
+		return
+	}
+	writeProbe(w, http.StatusOK, Probe{Status: HealthOK})
+}
+
+func writeProbe(w http.ResponseWriter, status int, probe Probe) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(probe); err != nil {
+		logger.Warn("Failed to write probe response", "error", err)
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistorySize is how many recent queries are kept in memory unless configured
+	DefaultHistorySize = 1000
+	// maxRecentDomains is how many entries GET /api/queries and /api/state return by default
+	maxRecentDomains = 100
+)
+
+// queryStructSize is the fixed size of a DNSQuery; its strings are counted on top
+var queryStructSize = int64(reflect.TypeOf(DNSQuery{}).Size())
+
+// HistoryStats is the footprint of the in-memory query history, reported by GET /health
+type HistoryStats struct {
+	Entries  int   `json:"entries"`
+	Capacity int   `json:"capacity"`
+	Bytes    int64 `json:"bytes"`               // Estimated memory held by the entries and the ring
+	MaxBytes int64 `json:"max_bytes,omitempty"` // Cap from recent_queries.max_size, 0 = none
+}
+
+// queryHistory keeps the most recent queries in a preallocated ring, dropping the oldest
+// when it is full or the entries outgrow maxBytes. Repeats of a query within the aggregation
+// window are counted in its entry instead of taking one of their own.
+type queryHistory struct {
+	mu       sync.RWMutex
+	ring     []DNSQuery
+	start    int // Index of the oldest entry
+	count    int
+	bytes    int64 // Bytes held by the strings of the entries
+	maxBytes int64
+
+	window time.Duration // 0 keeps every query as an entry of its own
+	first  int64         // Sequence number of the oldest entry
+	open   map[aggregateKey]aggregate
+}
+
+// aggregateKey tells which queries share an entry: those for the same domain from the same
+// client, answered the same way
+type aggregateKey struct {
+	domain     string
+	client     string
+	blocked    bool
+	wouldBlock bool
+}
+
+// aggregate is an entry still taking repeats: its sequence number and its first query's time
+type aggregate struct {
+	seq   int64
+	start time.Time
+}
+
+func newQueryHistory(size int, maxBytes int64) *queryHistory {
+	return &queryHistory{ring: make([]DNSQuery, max(size, 0)), maxBytes: maxBytes, open: make(map[aggregateKey]aggregate)}
+}
+
+func aggregateKeyOf(query DNSQuery) aggregateKey {
+	return aggregateKey{query.Domain, query.Client, query.Blocked, query.WouldBlock}
+}
+
+// querySize estimates the memory held by the strings of a query
+func querySize(q DNSQuery) int64 {
+	size := len(q.Domain) + len(q.Client) + len(q.ClientName) + len(q.QueryType) +
+		len(q.Rcode) + len(q.Upstream) + len(q.Reason)
+	for _, cname := range q.CNAMEs {
+		size += len(cname)
+	}
+	return int64(size)
+}
+
+// add records a query, counting it in the entry of an earlier one within the aggregation
+// window or else dropping the oldest entries to make room. It returns how many queries the
+// query's entry stands for, 0 when none is kept.
+func (h *queryHistory) add(query DNSQuery) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.ring) == 0 {
+		return 0
+	}
+	query.Count = max(query.Count, 1)
+
+	key := aggregateKeyOf(query)
+	if entry, ok := h.open[key]; ok && h.window > 0 && entry.seq >= h.first && query.Timestamp.Sub(entry.start) < h.window {
+		index := (h.start + int(entry.seq-h.first)) % len(h.ring)
+		query.Count += h.ring[index].Count
+		h.bytes += querySize(query) - querySize(h.ring[index])
+		h.ring[index] = query
+		return query.Count
+	}
+
+	if h.count == len(h.ring) {
+		h.dropOldest()
+	}
+	h.ring[(h.start+h.count)%len(h.ring)] = query
+	if h.window > 0 {
+		h.open[key] = aggregate{seq: h.first + int64(h.count), start: query.Timestamp}
+	}
+	h.count++
+	h.bytes += querySize(query)
+	for h.maxBytes > 0 && h.count > 1 && h.footprint() > h.maxBytes {
+		h.dropOldest()
+	}
+	return query.Count
+}
+
+// dropOldest removes the oldest entry; the caller holds the write lock
+func (h *queryHistory) dropOldest() {
+	oldest := h.ring[h.start]
+	if entry, ok := h.open[aggregateKeyOf(oldest)]; ok && entry.seq == h.first {
+		delete(h.open, aggregateKeyOf(oldest))
+	}
+	h.bytes -= querySize(oldest)
+	h.ring[h.start] = DNSQuery{}
+	h.start = (h.start + 1) % len(h.ring)
+	h.count--
+	h.first++
+}
+
+// setWindow changes the aggregation window; entries already kept take no more repeats
+func (h *queryHistory) setWindow(window time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if window != h.window {
+		h.window = window
+		clear(h.open)
+	}
+}
+
+// footprint is the estimated memory of the ring and the strings of its entries
+func (h *queryHistory) footprint() int64 {
+	return int64(len(h.ring))*queryStructSize + h.bytes
+}
+
+// resize changes the capacity and memory cap, keeping the newest entries that fit
+func (h *queryHistory) resize(size int, maxBytes int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	size = max(size, 0)
+	if size == len(h.ring) && maxBytes == h.maxBytes {
+		return
+	}
+	entries := h.entries()
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	h.ring = make([]DNSQuery, size)
+	h.start, h.count, h.bytes, h.maxBytes = 0, 0, 0, maxBytes
+	h.first = 0
+	clear(h.open)
+	copy(h.ring, entries)
+	h.count = len(entries)
+	for _, query := range entries {
+		h.bytes += querySize(query)
+	}
+	for h.maxBytes > 0 && h.count > 1 && h.footprint() > h.maxBytes {
+		h.dropOldest()
+	}
+}
+
+// entries returns the entries oldest first; the caller holds a lock
+func (h *queryHistory) entries() []DNSQuery {
+	entries := make([]DNSQuery, h.count)
+	for i := range entries {
+		entries[i] = h.ring[(h.start+i)%len(h.ring)]
+	}
+	return entries
+}
+
+// latest returns the last limit entries to take a query, oldest first
+func (h *queryHistory) latest(limit int) []DNSQuery {
+	h.mu.RLock()
+	entries := h.entries()
+	h.mu.RUnlock()
+
+	// Entries stay where their first query put them, so repeats make them newer than the
+	// entries after them
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+func (h *queryHistory) stats() HistoryStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return HistoryStats{Entries: h.count, Capacity: len(h.ring), Bytes: h.footprint(), MaxBytes: h.maxBytes}
+}
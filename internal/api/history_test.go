@@ -0,0 +1,100 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func historyDomains(queries []DNSQuery) string {
+	domains := make([]string, len(queries))
+	for i, query := range queries {
+		domains[i] = query.Domain
+	}
+	return strings.Join(domains, ",")
+}
+
+func TestQueryHistory(t *testing.T) {
+	history := newQueryHistory(3, 0)
+	for _, domain := range []string{"a.com", "b.com", "a.com", "c.com"} {
+		history.add(DNSQuery{Domain: domain})
+	}
+
+	// The ring is full, so b.com is the oldest entry left and the first a.com is gone
+	if got := historyDomains(history.latest(10)); got != "b.com,a.com,c.com" {
+		t.Errorf("expected b.com,a.com,c.com, got %s", got)
+	}
+	if got := historyDomains(history.latest(2)); got != "a.com,c.com" {
+		t.Errorf("expected the last 2 domains, got %s", got)
+	}
+	stats := history.stats()
+	if stats.Entries != 3 || stats.Capacity != 3 || stats.Bytes != 3*queryStructSize+15 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	// Shrinking keeps the newest entries
+	history.resize(2, 0)
+	if got := historyDomains(history.latest(10)); got != "a.com,c.com" {
+		t.Errorf("expected a.com,c.com after shrinking, got %s", got)
+	}
+
+	// The memory cap drops the oldest entries, but always keeps the newest
+	history.resize(10, 10*queryStructSize+5)
+	history.add(DNSQuery{Domain: strings.Repeat("x", 10)})
+	if got := history.stats().Entries; got != 1 {
+		t.Errorf("expected 1 entry under the memory cap, got %d", got)
+	}
+
+	// A size of 0 keeps nothing
+	history.resize(0, 0)
+	history.add(DNSQuery{Domain: "d.com"})
+	if got := history.stats().Entries; got != 0 {
+		t.Errorf("expected no entries, got %d", got)
+	}
+}
+
+func TestQueryHistoryAggregate(t *testing.T) {
+	history := newQueryHistory(10, 0)
+	history.setWindow(time.Minute)
+	now := time.Now()
+
+	add := func(domain string, blocked bool, after time.Duration) int {
+		return history.add(DNSQuery{Domain: domain, Client: "127.0.0.1", Blocked: blocked, Timestamp: now.Add(after)})
+	}
+	for i := range 3 {
+		if count := add("github.com", false, time.Duration(i)*time.Second); count != i+1 {
+			t.Fatalf("expected repeat %d to be counted as %d, got %d", i, i+1, count)
+		}
+	}
+	add("example.com", true, 10*time.Second)
+	add("github.com", true, 20*time.Second) // Answered differently, so an entry of its own
+	add("github.com", false, 30*time.Second)
+	add("github.com", false, time.Minute) // Past the window of the first entry
+
+	got := history.latest(10)
+	var summary []string
+	for _, query := range got {
+		summary = append(summary, query.Domain+"×"+strconv.Itoa(query.Count))
+	}
+	if want := "example.com×1,github.com×1,github.com×4,github.com×1"; strings.Join(summary, ",") != want {
+		t.Errorf("expected %s, got %s", want, strings.Join(summary, ","))
+	}
+	if stats := history.stats(); stats.Entries != 4 {
+		t.Errorf("expected 4 entries, got %+v", stats)
+	}
+
+	// Once its entry is dropped, a repeat starts a new one
+	history.resize(1, 0)
+	if count := add("github.com", false, 61*time.Second); count != 1 {
+		t.Errorf("expected a new entry after the ring shrank, got a count of %d", count)
+	}
+
+	// Without a window every query is an entry
+	history.resize(10, 0)
+	history.setWindow(0)
+	add("example.org", false, 0)
+	if count := add("example.org", false, time.Second); count != 1 {
+		t.Errorf("expected no aggregation without a window, got a count of %d", count)
+	}
+}
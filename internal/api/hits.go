@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultHitsLimit is how many entries GET /api/queries/hits returns unless asked for more
+const defaultHitsLimit = 100
+
+// Hit counts the queries for a domain from one client since the resolver started
+type Hit struct {
+	Domain     string    `json:"domain"`
+	Client     string    `json:"client"`
+	ClientName string    `json:"client_name,omitempty"` // Name given to the client in client_names
+	Count      int       `json:"count"`
+	Blocked    int       `json:"blocked"`
+	FirstSeen  time.Time `json:"first_seen"`
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+type hitKey struct {
+	domain string
+	client string
+}
+
+// hitCounter counts the queries per domain and client, and per domain across clients.
+// Once it holds maxCountedKeys of either, new ones are no longer counted.
+type hitCounter struct {
+	mu      sync.Mutex
+	pairs   map[hitKey]*Hit
+	domains map[string]*Hit // Without a client
+}
+
+func newHitCounter() *hitCounter {
+	return &hitCounter{pairs: make(map[hitKey]*Hit), domains: make(map[string]*Hit)}
+}
+
+// add counts a query
+func (c *hitCounter) add(query DNSQuery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := hitKey{query.Domain, query.Client}
+	pair, ok := c.pairs[key]
+	if !ok && len(c.pairs) < maxCountedKeys {
+		pair = &Hit{Domain: query.Domain, Client: query.Client, FirstSeen: query.Timestamp}
+		c.pairs[key] = pair
+	}
+	domain, ok := c.domains[query.Domain]
+	if !ok && len(c.domains) < maxCountedKeys {
+		domain = &Hit{Domain: query.Domain, FirstSeen: query.Timestamp}
+		c.domains[query.Domain] = domain
+	}
+	for _, hit := range []*Hit{pair, domain} {
+		if hit == nil {
+			continue
+		}
+		hit.Count++
+		if query.Blocked {
+			hit.Blocked++
+		}
+		hit.LastSeen = query.Timestamp
+	}
+	if pair != nil {
+		pair.ClientName = query.ClientName
+	}
+}
+
+// domain returns the queries for a domain from every client, false when it isn't counted
+func (c *hitCounter) domain(domain string) (Hit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hit, ok := c.domains[domain]
+	if !ok {
+		return Hit{}, false
+	}
+	return *hit, true
+}
+
+// annotate sets the hit counts of the domains of queries
+func (c *hitCounter) annotate(queries []DNSQuery) {
+	for i := range queries {
+		if hit, ok := c.domain(queries[i].Domain); ok {
+			first := hit.FirstSeen
+			queries[i].Hits, queries[i].FirstSeen = hit.Count, &first
+		}
+	}
+}
+
+// list returns the counts of the domain and client pairs matching the filter (its Domain
+// and Client; either empty matches all), most queried first
+func (c *hitCounter) list(filter QueryFilter) []Hit {
+	c.mu.Lock()
+	hits := make([]Hit, 0, len(c.pairs))
+	for key, hit := range c.pairs {
+		if (filter.Domain == "" || key.domain == filter.Domain) && (filter.Client == "" || key.client == filter.Client) {
+			hits = append(hits, *hit)
+		}
+	}
+	c.mu.Unlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Count != hits[j].Count {
+			return hits[i].Count > hits[j].Count
+		}
+		return hits[i].LastSeen.After(hits[j].LastSeen)
+	})
+	if filter.Limit > 0 && len(hits) > filter.Limit {
+		hits = hits[:filter.Limit]
+	}
+	return hits
+}
+
+// handleGetQueryHits returns how often each domain was queried by each client since the
+// resolver started, most queried first, narrowed by ?domain=, ?client=, and ?limit=
+func (s *Server) handleGetQueryHits(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get query hits request", "remote", r.RemoteAddr)
+
+	filter := QueryFilter{Domain: strings.TrimSuffix(r.URL.Query().Get("domain"), "."), Client: r.URL.Query().Get("client"), Limit: defaultHitsLimit}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.hits.list(filter)); err != nil {
+		logger.Error("Encoding query hits response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQueryHits(t *testing.T) {
+	server := NewServer("0")
+	start := time.Now()
+	server.AddQuery(DNSQuery{Domain: "a.com", Client: "192.168.1.20", ClientName: "laptop", Timestamp: start})
+	server.AddQuery(DNSQuery{Domain: "b.com", Client: "192.168.1.20", Timestamp: start.Add(time.Second), Blocked: true})
+	server.AddQuery(DNSQuery{Domain: "a.com", Client: "192.168.1.30", Timestamp: start.Add(2 * time.Second)})
+	server.AddQuery(DNSQuery{Domain: "a.com", Client: "192.168.1.20", ClientName: "laptop", Timestamp: start.Add(3 * time.Second), Blocked: true})
+
+	rec := httptest.NewRecorder()
+	server.handleGetQueryHits(rec, httptest.NewRequest(http.MethodGet, "/api/queries/hits?domain=a.com.", nil))
+	var hits []Hit
+	if err := json.Unmarshal(rec.Body.Bytes(), &hits); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("Expected hits, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(hits) != 2 {
+		t.Fatalf("Expected a.com from two clients, got %+v", hits)
+	}
+	if laptop := hits[0]; laptop.Client != "192.168.1.20" || laptop.ClientName != "laptop" || laptop.Count != 2 || laptop.Blocked != 1 ||
+		!laptop.FirstSeen.Equal(start) || !laptop.LastSeen.Equal(start.Add(3*time.Second)) {
+		t.Errorf("Expected the laptop's two queries first, got %+v", laptop)
+	}
+
+	rec = httptest.NewRecorder()
+	server.handleGetQueryHits(rec, httptest.NewRequest(http.MethodGet, "/api/queries/hits?client=192.168.1.20&limit=1", nil))
+	if err := json.Unmarshal(rec.Body.Bytes(), &hits); err != nil || len(hits) != 1 || hits[0].Domain != "a.com" {
+		t.Errorf("Expected only the laptop's most queried domain, got %+v (%v)", hits, err)
+	}
+
+	// GET /api/queries counts the domain's queries from every client
+	rec = httptest.NewRecorder()
+	server.handleGetQueries(rec, httptest.NewRequest(http.MethodGet, "/api/queries", nil))
+	var queries []DNSQuery
+	if err := json.Unmarshal(rec.Body.Bytes(), &queries); err != nil || len(queries) != 4 {
+		t.Fatalf("Expected four queries, got %s (%v)", rec.Body.String(), err)
+	}
+	if a := queries[3]; a.Domain != "a.com" || a.Count != 1 || a.Hits != 3 || a.FirstSeen == nil || !a.FirstSeen.Equal(start) {
+		t.Errorf("Expected 3 hits for a.com since the first query, got %+v", a)
+	}
+}
@@ -0,0 +1,27 @@
+package api
+
+import "net/http"
+
+// SetMutationsLocalOnly sets whether requests that change anything, every method but GET,
+// HEAD, and OPTIONS, are refused unless they come from this machine
+func (s *Server) SetMutationsLocalOnly(localOnly bool) {
+	s.mutationsLocalOnly.Store(localOnly)
+}
+
+// localOnlyMiddleware refuses changes from other machines while SetMutationsLocalOnly is
+// on. It goes by the connection's address, not by headers a proxy could have set. Triggers
+// are let through, as their secret is what authorizes them (see handleTrigger).
+func (s *Server) localOnlyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if s.mutationsLocalOnly.Load() && !isLoopback(r.RemoteAddr) && r.URL.Path != triggerPath {
+				logger.Warn("Refused API change from another machine", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+				http.Error(w, "Changes are only accepted from the resolver's machine (mutations_local_only)", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
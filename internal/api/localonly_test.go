@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMutationsLocalOnly(t *testing.T) {
+	server := NewServer("0")
+	handler := server.localOnlyMiddleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	status := func(method, remote string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(method, "/api/focus", nil)
+		req.RemoteAddr = remote
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := status(http.MethodPost, "192.168.1.23:50000"); code != http.StatusOK {
+		t.Fatalf("Expected changes from other machines by default, got status %d", code)
+	}
+
+	server.SetMutationsLocalOnly(true)
+	for _, tt := range []struct {
+		method, remote string
+		want           int
+	}{
+		{http.MethodGet, "192.168.1.23:50000", http.StatusOK},
+		{http.MethodPost, "192.168.1.23:50000", http.StatusForbidden},
+		{http.MethodDelete, "[2001:db8::1]:50000", http.StatusForbidden},
+		{http.MethodPost, "127.0.0.1:50000", http.StatusOK},
+		{http.MethodPatch, "[::1]:50000", http.StatusOK},
+		{http.MethodPut, "[::ffff:127.0.0.1]:50000", http.StatusOK},
+	} {
+		if code := status(tt.method, tt.remote); code != tt.want {
+			t.Errorf("Expected status %d for %s from %s, got %d", tt.want, tt.method, tt.remote, code)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package api
+
+import (
+	"strconv"
+
+	"github.com/berbyte/sinkzone/internal/metrics"
+)
+
+// Metrics of the HTTP API, served on GET /metrics. Requests are labeled by route template,
+// such as /api/focus/schedule/{id}.
+var (
+	requestsTotal   = metrics.NewCounterVec("sinkzone_api_requests_total", "HTTP API requests, by method, route, and status code", "method", "route", "code")
+	requestDuration = metrics.NewHistogramVec("sinkzone_api_request_duration_seconds", "Time to answer HTTP API requests, by route", metrics.DefaultBuckets, "route")
+)
+
+// observeRequest counts an answered request
+func observeRequest(method, route string, status int, seconds float64) {
+	requestsTotal.With(method, route, strconv.Itoa(status)).Inc()
+	requestDuration.With(route).Observe(seconds)
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrInvalidPassthrough is returned by the passthrough callback when the window asked for
+// is too long
+var ErrInvalidPassthrough = errors.New("invalid passthrough window")
+
+// PortalState reports a suspected captive portal, the login page of a hotel or café
+// network, and the passthrough window that lets it load
+type PortalState struct {
+	Mode             string     `json:"mode"`             // captive_portal.mode: notify, auto, or off
+	Suspected        bool       `json:"suspected"`        // The network seems to need a login
+	Reason           string     `json:"reason,omitempty"` // What gave the portal away, e.g. a failed connectivity check
+	SuspectedAt      *time.Time `json:"suspected_at,omitempty"`
+	PassthroughUntil *time.Time `json:"passthrough_until,omitempty"` // Nothing is blocked until then
+	Nameserver       string     `json:"nameserver,omitempty"`        // The network's nameserver queries go to during the window
+	Automatic        bool       `json:"automatic,omitempty"`         // The window was opened by captive_portal.mode auto
+}
+
+// Passthrough reports whether a passthrough window is open
+func (p *PortalState) Passthrough() bool {
+	return p != nil && p.PassthroughUntil != nil
+}
+
+// PassthroughRequest is the body accepted by POST /api/portal/passthrough
+type PassthroughRequest struct {
+	Duration string `json:"duration,omitempty"` // Default: captive_portal.passthrough
+	PIN      string `json:"pin,omitempty"`
+}
+
+// SetPortalCallbacks registers the functions that open a passthrough window, for the
+// configured length when given 0, and close it early
+func (s *Server) SetPortalCallbacks(start func(duration time.Duration, pin string) (PortalState, error), end func() PortalState) {
+	s.onStartPassthrough = start
+	s.onEndPassthrough = end
+}
+
+func (s *Server) handleStartPassthrough(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Passthrough request", "remote", r.RemoteAddr)
+
+	if s.onStartPassthrough == nil {
+		http.Error(w, "Passthrough is not available", http.StatusServiceUnavailable)
+		return
+	}
+	var req PassthroughRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Decoding passthrough request failed", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var duration time.Duration
+	if req.Duration != "" {
+		var err error
+		if duration, err = time.ParseDuration(req.Duration); err != nil || duration <= 0 {
+			http.Error(w, "Invalid duration format", http.StatusBadRequest)
+			return
+		}
+	}
+
+	state, err := s.onStartPassthrough(duration, req.PIN)
+	if err != nil {
+		logger.Error("Opening the passthrough window failed", "error", err)
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrInvalidPassthrough) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, fmt.Sprintf("Failed to open the passthrough window: %v", err), s.focusErrorStatus(err, status))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		logger.Error("Encoding passthrough response failed", "error", err)
+	}
+}
+
+func (s *Server) handleEndPassthrough(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("End passthrough request", "remote", r.RemoteAddr)
+
+	if s.onEndPassthrough == nil {
+		http.Error(w, "Passthrough is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.onEndPassthrough()); err != nil {
+		logger.Error("Encoding passthrough response failed", "error", err)
+	}
+}
+
+// StartPassthrough opens a passthrough window, so a captive portal's login page can load
+func (c *Client) StartPassthrough(req PassthroughRequest) (*PortalState, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	resp, err := c.client.Post(c.baseURL+"/api/portal/passthrough", "application/json", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the passthrough window: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var state PortalState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode portal state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// EndPassthrough closes the passthrough window before its time
+func (c *Client) EndPassthrough() (*PortalState, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/portal/passthrough", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to close the passthrough window: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var state PortalState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode portal state: %w", err)
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+)
+
+// PressureWindow is how far back distraction pressure looks
+const PressureWindow = 10 * time.Minute
+
+// pressureMinutes is how many one-minute buckets cover the window
+const pressureMinutes = int(PressureWindow / time.Minute)
+
+// Distraction pressure levels
+const (
+	PressureCalm     = "calm"
+	PressureElevated = "elevated" // At least one blocked attempt every two minutes
+	PressureHigh     = "high"     // At least two blocked attempts a minute
+)
+
+// DistractionPressure is how often blocked domains were tried lately during the focus
+// session: blocked attempts per minute over the last 10 minutes, or the session so far
+type DistractionPressure struct {
+	Active    bool    `json:"active"` // A focus session is running; the rest is zero without one
+	PerMinute float64 `json:"per_minute"`
+	Level     string  `json:"level"`    // calm, elevated, or high
+	Attempts  int     `json:"attempts"` // Blocked attempts within the window
+	Peak      float64 `json:"peak"`     // Highest per_minute of the session
+}
+
+// pressureLevel names a rate of blocked attempts per minute
+func pressureLevel(perMinute float64) string {
+	switch {
+	case perMinute >= 2:
+		return PressureHigh
+	case perMinute >= 0.5:
+		return PressureElevated
+	default:
+		return PressureCalm
+	}
+}
+
+// pressureTracker counts the blocked attempts of the focus session in one-minute buckets,
+// so it keeps the same memory however many there are
+type pressureTracker struct {
+	mu      sync.Mutex
+	start   time.Time // Start of the session, zero outside one
+	counts  [pressureMinutes]int
+	minutes [pressureMinutes]int64 // Minute (since the epoch) each count is of
+	peak    float64
+}
+
+// reset starts counting for a session starting at now, or stops counting when it ends
+func (p *pressureTracker) reset(now time.Time, enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.start, p.counts, p.minutes, p.peak = time.Time{}, [pressureMinutes]int{}, [pressureMinutes]int64{}, 0
+	if enabled {
+		p.start = now
+	}
+}
+
+// add counts a blocked attempt
+func (p *pressureTracker) add(now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		return
+	}
+	minute := now.Unix() / 60
+	i := int(minute % int64(pressureMinutes))
+	if p.minutes[i] != minute {
+		p.minutes[i], p.counts[i] = minute, 0
+	}
+	p.counts[i]++
+	p.peak = math.Max(p.peak, p.rate(now))
+}
+
+// get returns the pressure at now
+func (p *pressureTracker) get(now time.Time) DistractionPressure {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.start.IsZero() {
+		return DistractionPressure{Level: PressureCalm}
+	}
+	perMinute := p.rate(now)
+	return DistractionPressure{
+		Active:    true,
+		PerMinute: math.Round(perMinute*10) / 10,
+		Level:     pressureLevel(perMinute),
+		Attempts:  p.attempts(now),
+		Peak:      math.Round(math.Max(p.peak, perMinute)*10) / 10,
+	}
+}
+
+// attempts sums the counts within the window; the caller holds the lock
+func (p *pressureTracker) attempts(now time.Time) int {
+	minute := now.Unix() / 60
+	total := 0
+	for i, count := range p.counts {
+		if minute-p.minutes[i] < int64(pressureMinutes) {
+			total += count
+		}
+	}
+	return total
+}
+
+// rate returns the blocked attempts per minute within the window, over at least a minute
+// of a session that started less than a window ago; the caller holds the lock
+func (p *pressureTracker) rate(now time.Time) float64 {
+	span := min(now.Sub(p.start), PressureWindow)
+	span = max(span, time.Minute)
+	return float64(p.attempts(now)) / span.Minutes()
+}
+
+// DistractionPressure returns how often blocked domains were tried lately during the
+// running focus session
+func (s *Server) DistractionPressure() DistractionPressure {
+	state := s.GetFocusState()
+	now := clock.Now()
+	if !state.Enabled || (state.EndTime != nil && !now.Before(*state.EndTime)) {
+		return DistractionPressure{Level: PressureCalm}
+	}
+	return s.pressure.get(now)
+}
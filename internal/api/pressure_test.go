@@ -0,0 +1,52 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDistractionPressure(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var tracker pressureTracker
+
+	tracker.add(start)
+	if got := tracker.get(start); got.Active || got.Attempts != 0 {
+		t.Fatalf("Expected nothing counted outside a session, got %+v", got)
+	}
+
+	tracker.reset(start, true)
+	if got := tracker.get(start); !got.Active || got.Level != PressureCalm {
+		t.Fatalf("Expected a calm session, got %+v", got)
+	}
+
+	// Three attempts in the first minute count over a minute, not the seconds since the start
+	for i := 1; i <= 3; i++ {
+		tracker.add(start.Add(time.Duration(i) * 10 * time.Second))
+	}
+	got := tracker.get(start.Add(30 * time.Second))
+	if got.Attempts != 3 || got.PerMinute != 3 || got.Level != PressureHigh {
+		t.Fatalf("Expected 3 attempts a minute, got %+v", got)
+	}
+
+	// Later in the session the rate is over the whole window
+	got = tracker.get(start.Add(9*time.Minute + 59*time.Second))
+	if got.Attempts != 3 || got.PerMinute != 0.3 || got.Level != PressureCalm || got.Peak != 3 {
+		t.Fatalf("Expected 3 attempts over the window with the earlier peak, got %+v", got)
+	}
+	for i := 0; i < 3; i++ {
+		tracker.add(start.Add(9*time.Minute + 59*time.Second))
+	}
+	if got := tracker.get(start.Add(9*time.Minute + 59*time.Second)); got.Level != PressureElevated {
+		t.Fatalf("Expected 6 attempts in 10 minutes to be elevated, got %+v", got)
+	}
+
+	// Attempts older than the window drop out
+	if got := tracker.get(start.Add(15 * time.Minute)); got.Attempts != 3 {
+		t.Fatalf("Expected only the newer attempts, got %+v", got)
+	}
+
+	tracker.reset(start.Add(time.Hour), false)
+	if got := tracker.get(start.Add(time.Hour)); got.Active || got.Attempts != 0 || got.Peak != 0 {
+		t.Fatalf("Expected an ended session to reset, got %+v", got)
+	}
+}
@@ -0,0 +1,632 @@
+package api
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the query log: every query keyed by time, indexes from domain and client to
+// those keys, and the focus sessions keyed by the time they ended
+var (
+	queriesBucket  = []byte("queries")
+	byDomainBucket = []byte("by_domain")
+	byClientBucket = []byte("by_client")
+	sessionsBucket = []byte("sessions")
+)
+
+const (
+	// queryLogBuffer is how many queries may wait to be written before new ones are dropped
+	queryLogBuffer = 4096
+	// queryLogBatch is the most queries written in one transaction
+	queryLogBatch = 512
+	// queryLogFlushInterval is how long a query waits at most before it is written
+	queryLogFlushInterval = time.Second
+
+	// queryLogPruneInterval is how often the retention policy is applied
+	queryLogPruneInterval = time.Hour
+	// queryLogPruneBatch is the most queries deleted in one transaction
+	queryLogPruneBatch = 10000
+
+	// defaultHistoryLimit and maxHistoryLimit bound the queries returned by GET /api/queries/history
+	defaultHistoryLimit = 1000
+	maxHistoryLimit     = 100000
+)
+
+// QueryLog keeps every DNS query on disk, so history survives restarts. Queries are written
+// in batches in the background, so they show up in lookups within queryLogFlushInterval.
+type QueryLog struct {
+	db      *bolt.DB
+	pending chan DNSQuery
+	done    chan struct{}
+	stop    chan struct{} // Closed to stop the pruner
+	pruner  sync.Once     // Starts the pruner on the first SetRetention
+
+	// Applied by the pruner and by POST /api/queries/prune without limits (guarded by mutex)
+	retention Retention
+
+	// Whether Close was called (guarded by mutex)
+	closed bool
+	mutex  sync.RWMutex
+
+	sampler
+}
+
+// QueryFilter selects queries from the log. Zero values match everything.
+type QueryFilter struct {
+	Since  time.Time // Queries at or after this time
+	Until  time.Time // Queries before this time
+	Domain string
+	Client string
+	Limit  int // Only the newest queries, at most this many
+}
+
+// PruneRequest is the body accepted by POST /api/queries/prune. Without limits, the
+// configured retention is applied.
+type PruneRequest struct {
+	OlderThan  string `json:"older_than,omitempty"`  // Delete queries older than this duration
+	MaxRecords int    `json:"max_records,omitempty"` // Delete the oldest queries beyond this many
+}
+
+// PruneResult reports what POST /api/queries/prune deleted
+type PruneResult struct {
+	Deleted   int `json:"deleted"`
+	Remaining int `json:"remaining"`
+}
+
+// Retention bounds the query log. Zero values keep queries forever.
+type Retention struct {
+	MaxAge     time.Duration // Queries older than this are deleted
+	MaxRecords int           // The oldest queries beyond this many are deleted
+}
+
+// OpenQueryLog opens or creates the query log at path. Only one process can have it open.
+func OpenQueryLog(path string) (*QueryLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create query log directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open query log %s: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{queriesBucket, byDomainBucket, byClientBucket, sessionsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			logger.Warn("Failed to close query log", "error", closeErr)
+		}
+		return nil, fmt.Errorf("failed to initialize query log: %w", err)
+	}
+
+	l := &QueryLog{
+		db:      db,
+		pending: make(chan DNSQuery, queryLogBuffer),
+		done:    make(chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	go l.writeLoop()
+	return l, nil
+}
+
+// Append queues a query to be written. Queries are dropped when the disk can't keep up.
+func (l *QueryLog) Append(query DNSQuery) {
+	if !l.sample(&query) {
+		return
+	}
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	if l.closed {
+		return
+	}
+	select {
+	case l.pending <- query:
+	default:
+		logger.Warn("Query log is falling behind, dropping a query", "domain", query.Domain)
+	}
+}
+
+// Close writes the queued queries and closes the log
+func (l *QueryLog) Close() error {
+	l.mutex.Lock()
+	if l.closed {
+		l.mutex.Unlock()
+		return nil
+	}
+	l.closed = true
+	close(l.pending)
+	close(l.stop)
+	l.mutex.Unlock()
+
+	<-l.done
+	if err := l.db.Close(); err != nil {
+		return fmt.Errorf("failed to close query log: %w", err)
+	}
+	return nil
+}
+
+// writeLoop writes queued queries in batches until the log is closed
+func (l *QueryLog) writeLoop() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(queryLogFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]DNSQuery, 0, queryLogBatch)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := l.write(batch); err != nil {
+			logger.Warn("Failed to write queries to the query log", "count", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case query, ok := <-l.pending:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, query)
+			if len(batch) >= queryLogBatch {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func (l *QueryLog) write(queries []DNSQuery) error {
+	return l.db.Update(func(tx *bolt.Tx) error {
+		records := tx.Bucket(queriesBucket)
+		byDomain := tx.Bucket(byDomainBucket)
+		byClient := tx.Bucket(byClientBucket)
+		for _, query := range queries {
+			seq, err := records.NextSequence()
+			if err != nil {
+				return err
+			}
+			key := timeKey(query.Timestamp, seq)
+			value, err := json.Marshal(query)
+			if err != nil {
+				return fmt.Errorf("failed to encode query: %w", err)
+			}
+			if err := records.Put(key, value); err != nil {
+				return err
+			}
+			if err := byDomain.Put(indexKey(strings.ToLower(query.Domain), key), nil); err != nil {
+				return err
+			}
+			if query.Client != "" {
+				if err := byClient.Put(indexKey(query.Client, key), nil); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// AppendSession stores a focus session that ended
+func (l *QueryLog) AppendSession(session FocusSession) error {
+	value, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to encode focus session: %w", err)
+	}
+	err = l.db.Update(func(tx *bolt.Tx) error {
+		sessions := tx.Bucket(sessionsBucket)
+		seq, err := sessions.NextSequence()
+		if err != nil {
+			return err
+		}
+		return sessions.Put(timeKey(session.End, seq), value)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store focus session: %w", err)
+	}
+	return nil
+}
+
+// Sessions returns the focus sessions that ended at or after since, oldest first
+func (l *QueryLog) Sessions(since time.Time) ([]FocusSession, error) {
+	var sessions []FocusSession
+	err := l.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(sessionsBucket).Cursor()
+		for k, v := cursor.Seek(timeKey(since, 0)); k != nil; k, v = cursor.Next() {
+			var session FocusSession
+			if err := json.Unmarshal(v, &session); err != nil {
+				return fmt.Errorf("failed to decode focus session: %w", err)
+			}
+			sessions = append(sessions, session)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read focus sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// SetRetention sets the retention policy and applies it now and every hour in the
+// background until the log is closed. Calling it again replaces the policy.
+func (l *QueryLog) SetRetention(retention Retention) {
+	l.mutex.Lock()
+	l.retention = retention
+	l.mutex.Unlock()
+	if retention == (Retention{}) {
+		return
+	}
+
+	l.pruner.Do(func() {
+		go l.prune()
+	})
+}
+
+// prune applies the current retention policy until the log is closed
+func (l *QueryLog) prune() {
+	ticker := time.NewTicker(queryLogPruneInterval)
+	defer ticker.Stop()
+	for {
+		if retention := l.Retention(); retention != (Retention{}) {
+			if deleted, err := l.Prune(retention, time.Now()); err != nil {
+				logger.Warn("Failed to prune query log", "error", err)
+			} else if deleted > 0 {
+				logger.Debug("Pruned queries from the query log", "count", deleted)
+			}
+		}
+		select {
+		case <-ticker.C:
+		case <-l.stop:
+			return
+		}
+	}
+}
+
+// Retention returns the retention policy set with SetRetention
+func (l *QueryLog) Retention() Retention {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.retention
+}
+
+// Prune deletes queries older than MaxAge and the oldest ones beyond MaxRecords, returning
+// how many were deleted
+func (l *QueryLog) Prune(retention Retention, now time.Time) (int, error) {
+	deleted := 0
+	if retention.MaxAge > 0 {
+		cutoff := timeKey(now.Add(-retention.MaxAge), 0)
+		n, err := l.deleteOldest(func(key []byte, _ int) bool {
+			return bytes.Compare(key, cutoff) < 0
+		})
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	if retention.MaxRecords > 0 {
+		count, err := l.Count()
+		if err != nil {
+			return deleted, err
+		}
+		excess := count - retention.MaxRecords
+		n, err := l.deleteOldest(func(_ []byte, deleted int) bool {
+			return deleted < excess
+		})
+		deleted += n
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// Count returns the number of queries in the log
+func (l *QueryLog) Count() (int, error) {
+	count := 0
+	err := l.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(queriesBucket).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// deleteOldest deletes queries from the oldest on while more returns true for the next key
+// and the number deleted so far, in batches so writes aren't held up for long
+func (l *QueryLog) deleteOldest(more func(key []byte, deleted int) bool) (int, error) {
+	deleted := 0
+	for {
+		batch := 0
+		err := l.db.Update(func(tx *bolt.Tx) error {
+			records := tx.Bucket(queriesBucket)
+			byDomain := tx.Bucket(byDomainBucket)
+			byClient := tx.Bucket(byClientBucket)
+
+			var keys [][]byte
+			var queries []DNSQuery
+			cursor := records.Cursor()
+			for k, v := cursor.First(); k != nil && len(keys) < queryLogPruneBatch && more(k, deleted+len(keys)); k, v = cursor.Next() {
+				var query DNSQuery
+				if err := json.Unmarshal(v, &query); err != nil {
+					return fmt.Errorf("failed to decode query: %w", err)
+				}
+				keys = append(keys, bytes.Clone(k))
+				queries = append(queries, query)
+			}
+			for i, key := range keys {
+				if err := records.Delete(key); err != nil {
+					return err
+				}
+				if err := byDomain.Delete(indexKey(strings.ToLower(queries[i].Domain), key)); err != nil {
+					return err
+				}
+				if queries[i].Client != "" {
+					if err := byClient.Delete(indexKey(queries[i].Client, key)); err != nil {
+						return err
+					}
+				}
+			}
+			batch = len(keys)
+			return nil
+		})
+		deleted += batch
+		if err != nil || batch < queryLogPruneBatch {
+			return deleted, err
+		}
+	}
+}
+
+// Queries returns the queries matching the filter, oldest first
+func (l *QueryLog) Queries(filter QueryFilter) ([]DNSQuery, error) {
+	var queries []DNSQuery
+	err := l.scan(filter, func(query DNSQuery) {
+		queries = append(queries, query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(queries)-1; i < j; i, j = i+1, j-1 {
+		queries[i], queries[j] = queries[j], queries[i]
+	}
+	return queries, nil
+}
+
+// scan calls fn for each query matching the filter, newest first. A domain or client
+// filter walks its index, otherwise the queries are walked by time.
+func (l *QueryLog) scan(filter QueryFilter, fn func(DNSQuery)) error {
+	return l.db.View(func(tx *bolt.Tx) error {
+		records := tx.Bucket(queriesBucket)
+		var cursor *bolt.Cursor
+		var prefix []byte
+		switch {
+		case filter.Domain != "":
+			cursor = tx.Bucket(byDomainBucket).Cursor()
+			prefix = indexKey(strings.ToLower(filter.Domain), nil)
+		case filter.Client != "":
+			cursor = tx.Bucket(byClientBucket).Cursor()
+			prefix = indexKey(filter.Client, nil)
+		default:
+			cursor = records.Cursor()
+		}
+
+		// Start at the last key before Until
+		var k []byte
+		if filter.Until.IsZero() {
+			k, _ = cursor.Seek(append(bytes.Clone(prefix), 0xff))
+		} else {
+			k, _ = cursor.Seek(append(bytes.Clone(prefix), timeKey(filter.Until, 0)...))
+		}
+		if k == nil {
+			k, _ = cursor.Last()
+		} else {
+			k, _ = cursor.Prev()
+		}
+
+		count := 0
+		for ; k != nil && bytes.HasPrefix(k, prefix); k, _ = cursor.Prev() {
+			key := k[len(prefix):]
+			if len(key) != 16 {
+				continue
+			}
+			if !filter.Since.IsZero() && keyTime(key).Before(filter.Since) {
+				break
+			}
+			value := records.Get(key)
+			if value == nil {
+				continue
+			}
+			var query DNSQuery
+			if err := json.Unmarshal(value, &query); err != nil {
+				return fmt.Errorf("failed to decode query: %w", err)
+			}
+			if (filter.Domain != "" && !strings.EqualFold(query.Domain, filter.Domain)) || (filter.Client != "" && query.Client != filter.Client) {
+				continue
+			}
+			fn(query)
+			count++
+			if filter.Limit > 0 && count >= filter.Limit {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// timeKey orders queries by time; seq keeps queries in the same nanosecond apart
+func timeKey(t time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, uint64(max(t.UnixNano(), 0))) // #nosec G115 -- clamped to non-negative
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key))) // #nosec G115 -- written from a non-negative int64
+}
+
+// indexKey prefixes a query key with a domain or client; names never contain a NUL byte
+func indexKey(name string, key []byte) []byte {
+	return append(append([]byte(name), 0), key...)
+}
+
+// SetQueryLog stores every query in the log from now on, and restores the recent queries
+// from before the restart
+func (s *Server) SetQueryLog(queryLog Storage) {
+	s.queryLog = queryLog
+	capacity := s.history.stats().Capacity
+	if queryLog == nil || capacity == 0 {
+		return
+	}
+
+	recent, err := queryLog.Queries(QueryFilter{Limit: capacity})
+	if err != nil {
+		logger.Warn("Failed to restore recent queries", "error", err)
+		return
+	}
+	for _, query := range recent {
+		s.history.add(query)
+	}
+}
+
+// handleGetQueryHistory returns queries from the query log, filtered by time, domain, and client
+func (s *Server) handleGetQueryHistory(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get query history request", "remote", r.RemoteAddr)
+
+	if s.queryLog == nil {
+		http.Error(w, "The query log is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	filter, err := parseQueryFilter(r, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	queries, err := s.queryLog.Queries(filter)
+	if err != nil {
+		logger.Error("Reading query log failed", "error", err)
+		http.Error(w, "Failed to read query log", http.StatusInternalServerError)
+		return
+	}
+	if queries == nil {
+		queries = []DNSQuery{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(queries); err != nil {
+		logger.Error("Encoding query history response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// parseQueryFilter reads ?since= and ?until= (a duration ago or an RFC 3339 time), ?domain=,
+// ?client=, and ?limit=
+func parseQueryFilter(r *http.Request, now time.Time) (QueryFilter, error) {
+	params := r.URL.Query()
+	filter := QueryFilter{
+		Domain: strings.TrimSuffix(params.Get("domain"), "."),
+		Client: params.Get("client"),
+		Limit:  defaultHistoryLimit,
+	}
+	var err error
+	if filter.Since, err = parseQueryTime(params.Get("since"), now); err != nil {
+		return filter, fmt.Errorf("invalid since: %w", err)
+	}
+	if filter.Until, err = parseQueryTime(params.Get("until"), now); err != nil {
+		return filter, fmt.Errorf("invalid until: %w", err)
+	}
+	if value := params.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			return filter, fmt.Errorf("invalid limit: must be between 1 and %d", maxHistoryLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// parseQueryTime parses a duration before now (e.g. 1h) or an RFC 3339 time
+func parseQueryTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if window, err := time.ParseDuration(value); err == nil && window > 0 {
+		return now.Add(-window), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("use a positive duration (e.g. 1h) or an RFC 3339 time")
+	}
+	return t, nil
+}
+
+// handlePruneQueries deletes old queries from the query log
+func (s *Server) handlePruneQueries(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Prune queries request", "remote", r.RemoteAddr)
+
+	if s.queryLog == nil {
+		http.Error(w, "The query log is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	var req PruneRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	retention := Retention{MaxRecords: req.MaxRecords}
+	if req.OlderThan != "" {
+		maxAge, err := time.ParseDuration(req.OlderThan)
+		if err != nil || maxAge <= 0 {
+			http.Error(w, "Invalid older_than: must be a positive duration (e.g. 168h)", http.StatusBadRequest)
+			return
+		}
+		retention.MaxAge = maxAge
+	}
+	if retention.MaxRecords < 0 {
+		http.Error(w, "Invalid max_records: must not be negative", http.StatusBadRequest)
+		return
+	}
+	if retention == (Retention{}) {
+		retention = s.queryLog.Retention()
+	}
+
+	deleted, err := s.queryLog.Prune(retention, time.Now())
+	if err != nil {
+		logger.Error("Pruning query log failed", "error", err)
+		http.Error(w, "Failed to prune query log", http.StatusInternalServerError)
+		return
+	}
+	remaining, err := s.queryLog.Count()
+	if err != nil {
+		logger.Error("Counting queries failed", "error", err)
+	}
+	logger.Info("Pruned queries from the query log", "count", deleted)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(PruneResult{Deleted: deleted, Remaining: remaining}); err != nil {
+		logger.Error("Encoding prune response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestQueryLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.db")
+	queryLog, err := OpenQueryLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, domain := range []string{"github.com", "reddit.com", "GitHub.com", "news.ycombinator.com", "github.com"} {
+		client := "10.0.0.1"
+		if i%2 == 1 {
+			client = "10.0.0.2"
+		}
+		queryLog.Append(DNSQuery{Domain: domain, Client: client, Timestamp: start.Add(time.Duration(i) * time.Minute), Blocked: domain == "reddit.com"})
+	}
+	// Closing writes the queued queries; reopening checks they survive
+	if err := queryLog.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queryLog, err = OpenQueryLog(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer queryLog.Close()
+
+	tests := []struct {
+		name   string
+		filter QueryFilter
+		want   []string
+	}{
+		{"all", QueryFilter{}, []string{"github.com", "reddit.com", "GitHub.com", "news.ycombinator.com", "github.com"}},
+		{"domain", QueryFilter{Domain: "github.com"}, []string{"github.com", "GitHub.com", "github.com"}},
+		{"client", QueryFilter{Client: "10.0.0.2"}, []string{"reddit.com", "news.ycombinator.com"}},
+		{"window", QueryFilter{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}, []string{"reddit.com", "GitHub.com"}},
+		{"limit keeps the newest", QueryFilter{Domain: "github.com", Limit: 2}, []string{"GitHub.com", "github.com"}},
+		{"no match", QueryFilter{Domain: "git"}, nil},
+	}
+	for _, tt := range tests {
+		queries, err := queryLog.Queries(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var got []string
+		for _, query := range queries {
+			got = append(got, query.Domain)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+				break
+			}
+		}
+	}
+
+	// The recent queries come back after a restart
+	s := NewServer("0")
+	s.SetQueryLog(queryLog)
+	if got := s.history.latest(maxRecentDomains); len(got) != 5 {
+		t.Errorf("expected 5 restored queries, got %d", len(got))
+	}
+}
+
+func TestQueryLogSampleRate(t *testing.T) {
+	queryLog, err := OpenQueryLog(filepath.Join(t.TempDir(), "queries.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer queryLog.Close()
+	queryLog.SetSampleRate(4)
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i := range 8 {
+		queryLog.Append(DNSQuery{Domain: "github.com", Client: "10.0.0.1", Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+	queryLog.Append(DNSQuery{Domain: "reddit.com", Client: "10.0.0.1", Timestamp: start.Add(10 * time.Second), Blocked: true})
+
+	var queries []DNSQuery
+	for deadline := time.Now().Add(5 * time.Second); len(queries) < 3 && time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if queries, err = queryLog.Queries(QueryFilter{}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if len(queries) != 3 {
+		t.Fatalf("expected 2 of 8 allowed queries and the blocked one, got %d", len(queries))
+	}
+	if queries[0].SampleRate != 4 || queries[2].SampleRate != 0 {
+		t.Errorf("expected sample rates 4 and 0, got %d and %d", queries[0].SampleRate, queries[2].SampleRate)
+	}
+
+	// Stats over the log count each sampled query as the queries it stands for
+	s := NewServer("0")
+	s.queryLog = queryLog
+	stats, err := s.snapshotFromLog(start, start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Total != 9 || stats.Blocked != 1 {
+		t.Errorf("expected 9 queries with 1 blocked, got %d with %d blocked", stats.Total, stats.Blocked)
+	}
+}
+
+func TestQueryLogPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queries.db")
+	queryLog, err := OpenQueryLog(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		queryLog.Append(DNSQuery{Domain: "github.com", Client: "10.0.0.1", Timestamp: now.Add(-time.Duration(i) * 24 * time.Hour)})
+	}
+	// Closing writes the queued queries
+	if err := queryLog.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if queryLog, err = OpenQueryLog(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer queryLog.Close()
+
+	// Queries from 7 or more days ago go, then all but the newest 3
+	deleted, err := queryLog.Prune(Retention{MaxAge: 7*24*time.Hour - time.Minute}, now)
+	if err != nil || deleted != 3 {
+		t.Fatalf("expected 3 queries deleted by age, got %d (%v)", deleted, err)
+	}
+	deleted, err = queryLog.Prune(Retention{MaxRecords: 3}, now)
+	if err != nil || deleted != 4 {
+		t.Fatalf("expected 4 queries deleted by count, got %d (%v)", deleted, err)
+	}
+	queries, err := queryLog.Queries(QueryFilter{Domain: "github.com"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 3 || !queries[0].Timestamp.Equal(now.Add(-2*24*time.Hour)) {
+		t.Errorf("expected the newest 3 queries to be kept, got %v", queries)
+	}
+}
+
+func TestParseQueryFilter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	r := httptest.NewRequest("GET", "/api/queries/history?since=1h&until=2026-03-01T11:30:00Z&domain=github.com.&limit=5", nil)
+	filter, err := parseQueryFilter(r, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !filter.Since.Equal(now.Add(-time.Hour)) || !filter.Until.Equal(now.Add(-30*time.Minute)) || filter.Domain != "github.com" || filter.Limit != 5 {
+		t.Errorf("unexpected filter: %+v", filter)
+	}
+
+	for _, query := range []string{"since=-1h", "until=yesterday", "limit=0", "limit=many"} {
+		if _, err := parseQueryFilter(httptest.NewRequest("GET", "/api/queries/history?"+query, nil), now); err == nil {
+			t.Errorf("expected %s to be rejected", query)
+		}
+	}
+}
@@ -0,0 +1,466 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// maxCountedKeys bounds how many distinct domains and clients are counted
+	maxCountedKeys = 10000
+	// topCount is how many domains and clients are reported
+	topCount = 10
+	// activityMinutes is the length of the per-minute activity history
+	activityMinutes = 60
+	// maxRecentQueries bounds how many queries are kept for stats over a window (?since=)
+	maxRecentQueries = 50000
+)
+
+// QueryStats summarizes the DNS queries seen since the resolver started, or over a
+// recent window when requested with ?since=
+type QueryStats struct {
+	Since      time.Time `json:"since"` // Start of the period covered
+	Total      int       `json:"total"`
+	Blocked    int       `json:"blocked"`
+	Allowed    int       `json:"allowed"`
+	TopDomains []Count   `json:"top_domains"`
+	TopBlocked []Count   `json:"top_blocked"` // Most blocked domains
+	TopClients []Count   `json:"top_clients"`
+	PerMinute  []int     `json:"per_minute"` // Queries per minute over the last hour, oldest first
+
+	// Latency and Upstreams cover at most the last maxRecentQueries queries
+	Latency   LatencyStats    `json:"latency"`
+	Upstreams []UpstreamStats `json:"upstreams"` // Busiest first, "cache" for cached answers and "stale" for expired ones
+}
+
+// LatencyStats summarizes the time taken to answer queries, in milliseconds
+type LatencyStats struct {
+	Count   int     `json:"count"` // Queries with a recorded latency
+	Average float64 `json:"average_ms"`
+	Median  float64 `json:"median_ms"`
+	P95     float64 `json:"p95_ms"`
+	Max     float64 `json:"max_ms"`
+}
+
+// UpstreamStats is the number of queries a nameserver answered and how quickly
+type UpstreamStats struct {
+	Name    string  `json:"name"`
+	Count   int     `json:"count"`
+	Average float64 `json:"average_ms"`
+}
+
+// Count is the number of queries for a domain or client
+type Count struct {
+	Name    string `json:"name"`
+	Count   int    `json:"count"`
+	Blocked int    `json:"blocked"`
+}
+
+// queryCounter accumulates query totals for GET /api/stats/queries
+type queryCounter struct {
+	mu      sync.Mutex
+	since   time.Time
+	total   int
+	blocked int
+	domains map[string]*Count
+	clients map[string]*Count
+	minutes [activityMinutes]int
+	minute  int64 // Unix minute of the newest bucket
+
+	// Ring buffer of the most recent queries, oldest at next once full
+	recent []recentQuery
+	next   int
+}
+
+// recentQuery is what is kept of a query for stats over a window
+type recentQuery struct {
+	domain    string
+	client    string
+	device    string
+	timestamp time.Time
+	blocked   bool
+	upstream  string
+	latencyMS float64
+	weight    int // Queries it stands for: over 1 for allowed queries the query log sampled
+}
+
+func newRecentQuery(query DNSQuery) recentQuery {
+	return recentQuery{
+		domain:    query.Domain,
+		client:    query.ClientLabel(),
+		device:    query.DeviceID(),
+		timestamp: query.Timestamp,
+		blocked:   query.Blocked,
+		upstream:  query.Upstream,
+		latencyMS: query.LatencyMS,
+		weight:    max(query.SampleRate, 1),
+	}
+}
+
+func newQueryCounter() *queryCounter {
+	return &queryCounter{
+		since:   time.Now(),
+		domains: make(map[string]*Count),
+		clients: make(map[string]*Count),
+	}
+}
+
+// add counts a query
+func (c *queryCounter) add(query DNSQuery) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.total++
+	if query.Blocked {
+		c.blocked++
+	}
+	countKey(c.domains, query.Domain, query.Blocked, 1)
+	if query.Client != "" {
+		countKey(c.clients, query.ClientLabel(), query.Blocked, 1)
+	}
+
+	c.advance(query.Timestamp)
+	c.minutes[len(c.minutes)-1]++
+
+	recent := newRecentQuery(query)
+	if len(c.recent) < maxRecentQueries {
+		c.recent = append(c.recent, recent)
+	} else {
+		c.recent[c.next] = recent
+		c.next = (c.next + 1) % maxRecentQueries
+	}
+}
+
+// deviceCounts returns the most queried and most blocked domains of a device among the
+// recent queries
+func (c *queryCounter) deviceCounts(device string) ([]Count, []Count) {
+	c.mu.Lock()
+	domains := make(map[string]*Count)
+	for _, query := range c.recent {
+		if query.device == device {
+			countKey(domains, query.domain, query.blocked, query.weight)
+		}
+	}
+	c.mu.Unlock()
+	return topCounts(domains), topBlocked(domains)
+}
+
+// advance shifts the per-minute buckets so the last one covers the given time
+// This method assumes the caller holds the lock
+func (c *queryCounter) advance(now time.Time) {
+	minute := now.Unix() / 60
+	if c.minute == 0 {
+		c.minute = minute
+	}
+	shift := minute - c.minute
+	if shift <= 0 {
+		return
+	}
+	if shift >= activityMinutes {
+		c.minutes = [activityMinutes]int{}
+	} else {
+		copy(c.minutes[:], c.minutes[shift:])
+		for i := activityMinutes - int(shift); i < activityMinutes; i++ {
+			c.minutes[i] = 0
+		}
+	}
+	c.minute = minute
+}
+
+// snapshot returns the accumulated stats
+func (c *queryCounter) snapshot(now time.Time) QueryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	timing := newTimingStats()
+	for _, query := range c.recent {
+		timing.add(query)
+	}
+	return QueryStats{
+		Since:      c.since,
+		Total:      c.total,
+		Blocked:    c.blocked,
+		Allowed:    c.total - c.blocked,
+		TopDomains: topCounts(c.domains),
+		TopBlocked: topBlocked(c.domains),
+		TopClients: topCounts(c.clients),
+		PerMinute:  append([]int(nil), c.minutes[:]...),
+		Latency:    timing.latencyStats(),
+		Upstreams:  timing.upstreamStats(),
+	}
+}
+
+// blockedSince counts the blocked queries since the given time among the recent ones
+func (c *queryCounter) blockedSince(since time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	blocked := 0
+	for _, query := range c.recent {
+		if query.blocked && !query.timestamp.Before(since) {
+			blocked++
+		}
+	}
+	return blocked
+}
+
+// BlockedSince returns how many queries were blocked since the given time, which should be
+// recent: only the last queries are kept
+func (s *Server) BlockedSince(since time.Time) int {
+	return s.queryStats.blockedSince(since)
+}
+
+// snapshotSince returns stats over the queries since the given time. When older queries
+// have already been dropped from the buffer, Since reports where the window really starts.
+func (c *queryCounter) snapshotSince(since, now time.Time) QueryStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.advance(now)
+	if since.Before(c.since) {
+		since = c.since
+	}
+	if len(c.recent) == maxRecentQueries && c.recent[c.next].timestamp.After(since) {
+		since = c.recent[c.next].timestamp
+	}
+
+	window := newWindowStats(since, c.minutes[:])
+	for _, query := range c.recent {
+		if !query.timestamp.Before(since) {
+			window.add(query)
+		}
+	}
+	return window.result()
+}
+
+// windowStats counts the queries over a window
+type windowStats struct {
+	stats   QueryStats
+	domains map[string]*Count
+	clients map[string]*Count
+	timing  *timingStats
+}
+
+func newWindowStats(since time.Time, perMinute []int) *windowStats {
+	return &windowStats{
+		stats:   QueryStats{Since: since, PerMinute: append([]int(nil), perMinute...)},
+		domains: make(map[string]*Count),
+		clients: make(map[string]*Count),
+		timing:  newTimingStats(),
+	}
+}
+
+func (w *windowStats) add(query recentQuery) {
+	w.stats.Total += query.weight
+	if query.blocked {
+		w.stats.Blocked += query.weight
+	}
+	countKey(w.domains, query.domain, query.blocked, query.weight)
+	if query.client != "" {
+		countKey(w.clients, query.client, query.blocked, query.weight)
+	}
+	w.timing.add(query)
+}
+
+func (w *windowStats) result() QueryStats {
+	stats := w.stats
+	stats.Allowed = stats.Total - stats.Blocked
+	stats.TopDomains = topCounts(w.domains)
+	stats.TopBlocked = topBlocked(w.domains)
+	stats.TopClients = topCounts(w.clients)
+	stats.Latency = w.timing.latencyStats()
+	stats.Upstreams = w.timing.upstreamStats()
+	return stats
+}
+
+// snapshotFromLog returns stats over the queries in the log since the given time, so the
+// window may reach back before the resolver started
+func (s *Server) snapshotFromLog(since, now time.Time) (QueryStats, error) {
+	stats, err := s.queryLog.Stats(since)
+	if err != nil {
+		return QueryStats{}, err
+	}
+	stats.PerMinute = s.queryStats.snapshot(now).PerMinute
+	return stats, nil
+}
+
+// Stats summarizes the queries in the log since the given time, without PerMinute, which
+// only a running resolver counts
+func (l *QueryLog) Stats(since time.Time) (QueryStats, error) {
+	window := newWindowStats(since, nil)
+	err := l.scan(QueryFilter{Since: since}, func(query DNSQuery) {
+		window.add(newRecentQuery(query))
+	})
+	if err != nil {
+		return QueryStats{}, err
+	}
+	return window.result(), nil
+}
+
+// timingStats collects the latency of queries, overall and per upstream
+type timingStats struct {
+	latencies []float64
+	upstreams map[string]*upstreamTiming
+}
+
+type upstreamTiming struct {
+	count int
+	total float64 // Milliseconds
+}
+
+func newTimingStats() *timingStats {
+	return &timingStats{upstreams: make(map[string]*upstreamTiming)}
+}
+
+// add records a query's latency; queries logged before latency was recorded are skipped
+func (t *timingStats) add(query recentQuery) {
+	if query.latencyMS <= 0 {
+		return
+	}
+	t.latencies = append(t.latencies, query.latencyMS)
+	if query.upstream == "" {
+		return
+	}
+	entry, ok := t.upstreams[query.upstream]
+	if !ok {
+		if len(t.upstreams) >= maxCountedKeys {
+			return
+		}
+		entry = &upstreamTiming{}
+		t.upstreams[query.upstream] = entry
+	}
+	entry.count++
+	entry.total += query.latencyMS
+}
+
+func (t *timingStats) latencyStats() LatencyStats {
+	if len(t.latencies) == 0 {
+		return LatencyStats{}
+	}
+	sorted := slices.Clone(t.latencies)
+	slices.Sort(sorted)
+	sum := 0.0
+	for _, latency := range sorted {
+		sum += latency
+	}
+	return LatencyStats{
+		Count:   len(sorted),
+		Average: sum / float64(len(sorted)),
+		Median:  percentile(sorted, 50),
+		P95:     percentile(sorted, 95),
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+func (t *timingStats) upstreamStats() []UpstreamStats {
+	result := make([]UpstreamStats, 0, len(t.upstreams))
+	for name, entry := range t.upstreams {
+		result = append(result, UpstreamStats{Name: name, Count: entry.count, Average: entry.total / float64(entry.count)})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > topCount {
+		result = result[:topCount]
+	}
+	return result
+}
+
+// percentile returns the nearest-rank percentile of sorted, which must not be empty
+func percentile(sorted []float64, p int) float64 {
+	rank := (len(sorted)*p + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
+
+func countKey(counts map[string]*Count, name string, blocked bool, n int) {
+	entry, ok := counts[name]
+	if !ok {
+		if len(counts) >= maxCountedKeys {
+			return
+		}
+		entry = &Count{Name: name}
+		counts[name] = entry
+	}
+	entry.Count += n
+	if blocked {
+		entry.Blocked += n
+	}
+}
+
+// topBlocked returns the most blocked entries, most blocked first
+func topBlocked(counts map[string]*Count) []Count {
+	result := make([]Count, 0)
+	for _, entry := range counts {
+		if entry.Blocked > 0 {
+			result = append(result, *entry)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Blocked != result[j].Blocked {
+			return result[i].Blocked > result[j].Blocked
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > topCount {
+		result = result[:topCount]
+	}
+	return result
+}
+
+// topCounts returns the most queried entries, busiest first
+func topCounts(counts map[string]*Count) []Count {
+	result := make([]Count, 0, len(counts))
+	for _, entry := range counts {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	if len(result) > topCount {
+		result = result[:topCount]
+	}
+	return result
+}
+
+func (s *Server) handleGetQueryStats(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get query stats request", "remote", r.RemoteAddr)
+
+	now := time.Now()
+	var stats QueryStats
+	if value := r.URL.Query().Get("since"); value != "" {
+		window, err := time.ParseDuration(value)
+		if err != nil || window <= 0 {
+			http.Error(w, "Invalid since: must be a positive duration (e.g. 1h)", http.StatusBadRequest)
+			return
+		}
+		if s.queryLog != nil {
+			stats, err = s.snapshotFromLog(now.Add(-window), now)
+			if err != nil {
+				logger.Error("Reading query log failed", "error", err)
+				http.Error(w, "Failed to read query log", http.StatusInternalServerError)
+				return
+			}
+		} else {
+			stats = s.queryStats.snapshotSince(now.Add(-window), now)
+		}
+	} else {
+		stats = s.queryStats.snapshot(now)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.Error("Encoding query stats response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryCounter(t *testing.T) {
+	counter := newQueryCounter()
+	now := time.Date(2025, 3, 14, 12, 0, 30, 0, time.UTC)
+
+	counter.add(DNSQuery{Domain: "github.com", Client: "10.0.0.2", Timestamp: now.Add(-2 * time.Minute)})
+	counter.add(DNSQuery{Domain: "reddit.com", Client: "10.0.0.2", Timestamp: now, Blocked: true})
+	counter.add(DNSQuery{Domain: "reddit.com", Client: "10.0.0.3", Timestamp: now, Blocked: true})
+
+	stats := counter.snapshot(now.Add(time.Minute))
+	if stats.Total != 3 || stats.Blocked != 2 || stats.Allowed != 1 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	if len(stats.TopDomains) != 2 || stats.TopDomains[0].Name != "reddit.com" || stats.TopDomains[0].Blocked != 2 {
+		t.Errorf("Expected reddit.com to be the top domain, got %+v", stats.TopDomains)
+	}
+	if len(stats.TopClients) != 2 || stats.TopClients[0].Name != "10.0.0.2" {
+		t.Errorf("Expected 10.0.0.2 to be the top client, got %+v", stats.TopClients)
+	}
+
+	// The activity history ends with the current (empty) minute
+	last := len(stats.PerMinute) - 1
+	if stats.PerMinute[last] != 0 || stats.PerMinute[last-1] != 2 || stats.PerMinute[last-3] != 1 {
+		t.Errorf("Unexpected per-minute activity: %v", stats.PerMinute[last-3:])
+	}
+}
+
+func TestQueryCounterSince(t *testing.T) {
+	now := time.Now()
+	counter := newQueryCounter()
+	counter.since = now.Add(-3 * time.Hour)
+
+	counter.add(DNSQuery{Domain: "github.com", Client: "10.0.0.2", Timestamp: now.Add(-2 * time.Hour)})
+	counter.add(DNSQuery{Domain: "reddit.com", Client: "10.0.0.2", Timestamp: now.Add(-2 * time.Hour), Blocked: true})
+	counter.add(DNSQuery{Domain: "youtube.com", Client: "10.0.0.3", Timestamp: now.Add(-time.Minute), Blocked: true})
+	counter.add(DNSQuery{Domain: "github.com", Client: "10.0.0.3", Timestamp: now})
+
+	all := counter.snapshot(now)
+	if len(all.TopBlocked) != 2 || all.TopBlocked[0].Name != "reddit.com" {
+		t.Errorf("Expected reddit.com and youtube.com as the top blocked, got %+v", all.TopBlocked)
+	}
+
+	stats := counter.snapshotSince(now.Add(-time.Hour), now)
+	if stats.Total != 2 || stats.Blocked != 1 || stats.Allowed != 1 {
+		t.Errorf("Unexpected totals for the last hour: %+v", stats)
+	}
+	if len(stats.TopBlocked) != 1 || stats.TopBlocked[0].Name != "youtube.com" {
+		t.Errorf("Expected only youtube.com blocked in the last hour, got %+v", stats.TopBlocked)
+	}
+	if len(stats.TopClients) != 1 || stats.TopClients[0].Name != "10.0.0.3" || stats.TopClients[0].Count != 2 {
+		t.Errorf("Expected only 10.0.0.3 in the last hour, got %+v", stats.TopClients)
+	}
+}
+
+func TestQueryCounterLatency(t *testing.T) {
+	counter := newQueryCounter()
+	now := time.Now()
+
+	for i := 1; i <= 20; i++ {
+		counter.add(DNSQuery{Domain: "github.com", Timestamp: now, Upstream: "1.1.1.1:53", LatencyMS: float64(i)})
+	}
+	counter.add(DNSQuery{Domain: "github.com", Timestamp: now, Upstream: "cache", LatencyMS: 0.5})
+	counter.add(DNSQuery{Domain: "reddit.com", Timestamp: now, Blocked: true, LatencyMS: 0.5})
+	counter.add(DNSQuery{Domain: "old.example", Timestamp: now}) // No latency recorded
+
+	stats := counter.snapshot(now)
+	latency := stats.Latency
+	if latency.Count != 22 || latency.Max != 20 || latency.Median != 9 || latency.P95 != 19 {
+		t.Errorf("Unexpected latency: %+v", latency)
+	}
+	if len(stats.Upstreams) != 2 || stats.Upstreams[0].Name != "1.1.1.1:53" || stats.Upstreams[0].Count != 20 || stats.Upstreams[0].Average != 10.5 {
+		t.Errorf("Unexpected upstreams: %+v", stats.Upstreams)
+	}
+	if window := counter.snapshotSince(now.Add(-time.Minute), now); window.Latency != latency {
+		t.Errorf("Expected the window to report the same latency, got %+v", window.Latency)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ScheduledSession is a one-off focus session queued to start at a later time
+type ScheduledSession struct {
+	ID       string    `json:"id,omitempty"`
+	Start    time.Time `json:"start"`
+	Duration string    `json:"duration"`
+	Profile  string    `json:"profile,omitempty"`
+	Label    string    `json:"label,omitempty"`
+}
+
+// SessionScheduler stores queued focus sessions and starts them when they are due
+type SessionScheduler interface {
+	ScheduledSessions() ([]ScheduledSession, error)
+	ScheduleSession(session ScheduledSession) (*ScheduledSession, error)
+	CancelScheduledSession(id string) error
+}
+
+// SetSessionScheduler registers the scheduler behind the /api/focus/schedule endpoints
+func (s *Server) SetSessionScheduler(scheduler SessionScheduler) {
+	s.scheduler = scheduler
+}
+
+func (s *Server) handleGetScheduledSessions(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get scheduled sessions request", "remote", r.RemoteAddr)
+
+	if s.scheduler == nil {
+		http.Error(w, "Scheduling is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	sessions, err := s.scheduler.ScheduledSessions()
+	if err != nil {
+		logger.Error("Listing scheduled sessions failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to list scheduled sessions: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []ScheduledSession{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		logger.Error("Encoding scheduled sessions response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleScheduleSession(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Schedule session request", "remote", r.RemoteAddr)
+
+	if s.scheduler == nil {
+		http.Error(w, "Scheduling is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ScheduledSession
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Decoding schedule request failed", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := s.scheduler.ScheduleSession(req)
+	if err != nil {
+		logger.Error("Scheduling session failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to schedule session: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	logger.Info("Focus session scheduled", "id", session.ID, "start", session.Start, "duration", session.Duration)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(session); err != nil {
+		logger.Error("Encoding schedule response failed", "error", err)
+	}
+}
+
+func (s *Server) handleCancelScheduledSession(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	logger.Debug("Cancel scheduled session request", "id", id, "remote", r.RemoteAddr)
+
+	if s.scheduler == nil {
+		http.Error(w, "Scheduling is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := s.scheduler.CancelScheduledSession(id); err != nil {
+		logger.Error("Cancelling scheduled session failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to cancel session: %v", err), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,27 +1,176 @@
 package api
 
 import (
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"net/http"
-	"sort"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/berbyte/sinkzone/internal/events"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/berbyte/sinkzone/internal/metrics"
+	"github.com/berbyte/sinkzone/internal/tracing"
+	"github.com/berbyte/sinkzone/internal/version"
 	"github.com/gorilla/mux"
 )
 
+// logger writes the API's log, at the level set for logs.ComponentAPI
+var logger = logs.Component(logs.ComponentAPI)
+
+var (
+	// ErrPINRequired is returned by focus callbacks when a change needs the focus PIN
+	ErrPINRequired = errors.New("PIN required to disable, pause, or shorten focus mode")
+	// ErrInvalidPIN is returned by focus callbacks when the supplied PIN does not match
+	ErrInvalidPIN = errors.New("invalid PIN")
+)
+
 type DNSQuery struct {
-	Domain    string    `json:"domain"`
-	Timestamp time.Time `json:"timestamp"`
-	Blocked   bool      `json:"blocked"`
+	Domain     string    `json:"domain"`
+	Client     string    `json:"client,omitempty"`      // Address of the client that last queried the domain
+	ClientName string    `json:"client_name,omitempty"` // Name given to the client in client_names
+	ClientMAC  string    `json:"client_mac,omitempty"`  // MAC address of the client, where the neighbor table has it
+	User       string    `json:"user,omitempty"`        // Account of the resolver's machine that sent the query, while users is configured
+	Timestamp  time.Time `json:"timestamp"`
+	Blocked    bool      `json:"blocked"`
+	WouldBlock bool      `json:"would_block,omitempty"` // Resolved, but would have been blocked (e.g. during the grace period)
+	QueryType  string    `json:"query_type,omitempty"`  // Record type, e.g. A or AAAA
+	Rcode      string    `json:"rcode,omitempty"`       // Response code, e.g. NOERROR or NXDOMAIN
+	Upstream   string    `json:"upstream,omitempty"`    // Nameserver that answered, empty for blocked queries
+	LatencyMS  float64   `json:"latency_ms,omitempty"`  // Time taken to answer the client
+	Reason     string    `json:"reason,omitempty"`      // Why the query was (or would have been) blocked or let through
+	SampleRate int       `json:"sample_rate,omitempty"` // Allowed queries this logged one stands for, when the query log samples them
+	CNAMEs     []string  `json:"cnames,omitempty"`      // Names the answer pointed to with CNAME records, in order
+
+	// Set by GET /api/queries, /api/state, and the query stream, not in the query log
+	Count     int        `json:"count,omitempty"`      // Queries this entry stands for: repeats within recent_queries.aggregate
+	Hits      int        `json:"hits,omitempty"`       // Queries for the domain from every client since the resolver started
+	FirstSeen *time.Time `json:"first_seen,omitempty"` // First of them
+}
+
+// ClientLabel names the client that sent the query: its name from client_names, or its
+// address, after the account that sent it where known, e.g. sam@127.0.0.1
+func (q DNSQuery) ClientLabel() string {
+	label := q.Client
+	if q.ClientName != "" {
+		label = q.ClientName
+	}
+	if q.User != "" {
+		return q.User + "@" + label
+	}
+	return label
 }
 
 type FocusModeState struct {
-	Enabled  bool       `json:"enabled"`
-	EndTime  *time.Time `json:"end_time,omitempty"`
-	Duration string     `json:"duration,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	EndTime     *time.Time `json:"end_time,omitempty"`
+	Duration    string     `json:"duration,omitempty"`
+	Paused      bool       `json:"paused,omitempty"`
+	Break       bool       `json:"break,omitempty"` // The pause is a break: only break domains are unblocked
+	PausedUntil *time.Time `json:"paused_until,omitempty"`
+	Remaining   string     `json:"remaining,omitempty"`
+	Profile     string     `json:"profile,omitempty"`
+	Intensity   string     `json:"intensity,omitempty"`
+	Label       string     `json:"label,omitempty"` // Free-form project label used for reporting
+	GraceUntil  *time.Time `json:"grace_until,omitempty"`
+	DryRun      bool       `json:"dry_run,omitempty"`           // Blocked queries are only recorded, never enforced
+	DisableAt   *time.Time `json:"disable_at,omitempty"`        // Set when a disable is queued by the disable delay
+	Snoozes     []Snooze   `json:"snoozes,omitempty"`           // Domains temporarily let through during the session
+	Blocked     int        `json:"blocked_count"`               // Blocked queries in the current session
+	LastBlocked string     `json:"last_blocked,omitempty"`      // Most recently blocked domain in the current session
+	WouldBlock  int        `json:"would_block_count,omitempty"` // Queries a dry run or grace period let through
+	Violations  int        `json:"violations,omitempty"`        // Rejected attempts to end, pause, or shorten the PIN-locked session
+}
+
+// FocusRequest is the body accepted by POST /api/focus
+type FocusRequest struct {
+	Enabled   bool   `json:"enabled"`
+	Duration  string `json:"duration,omitempty"`
+	Until     string `json:"until,omitempty"` // Wall-clock end time instead of a duration (see ParseWallClock)
+	Profile   string `json:"profile,omitempty"`
+	Intensity string `json:"intensity,omitempty"` // "soft", "normal" (default), or "hard"
+	Label     string `json:"label,omitempty"`
+	DryRun    bool   `json:"dry_run,omitempty"` // Record what would be blocked, but resolve everything
+	PIN       string `json:"pin,omitempty"`
+}
+
+// PauseRequest is the body accepted by POST /api/focus/pause
+type PauseRequest struct {
+	Duration string `json:"duration"`
+	PIN      string `json:"pin,omitempty"`
+	Break    bool   `json:"break,omitempty"` // Only unblock the configured break domains
+}
+
+// PauseOptions carries a pause request to the DNS server
+type PauseOptions struct {
+	Duration time.Duration
+	PIN      string
+	Break    bool
+}
+
+// FocusOptions carries the per-session settings of a focus mode change to the DNS server.
+// The callback may fill in defaults, such as the duration configured for a profile.
+type FocusOptions struct {
+	Duration    time.Duration
+	Profile     string
+	Intensity   string
+	Label       string
+	GracePeriod time.Duration
+	DryRun      bool
+	PIN         string
+	DisableAt   *time.Time // Set by the callback when a disable is deferred instead of applied
+}
+
+// FocusStats reports progress toward the daily focus goal
+type FocusStats struct {
+	Goal          string  `json:"goal,omitempty"`
+	Today         string  `json:"today"`
+	Week          string  `json:"week"`     // Focus time since Monday
+	Progress      float64 `json:"progress"` // Fraction of today's goal reached (0 without a goal)
+	GoalMet       bool    `json:"goal_met"`
+	Streak        int     `json:"streak"`
+	LongestStreak int     `json:"longest_streak"`
+
+	Labels []LabelStats `json:"labels,omitempty"` // Focus time per session label
+
+	Pressure DistractionPressure `json:"pressure"` // Blocked attempts of the running session lately
+
+	Budgets []BudgetStatus `json:"budgets,omitempty"` // Daily time budgets and their use today
+}
+
+// BudgetStatus is a daily time budget and its use today. Each minute in which the budget's
+// domains were queried counts as a minute of use.
+type BudgetStatus struct {
+	Domain    string  `json:"domain"` // Domain or wildcard pattern
+	Limit     string  `json:"limit"`
+	Used      string  `json:"used"`
+	Remaining string  `json:"remaining"`
+	Progress  float64 `json:"progress"`  // Fraction of the budget used
+	Exhausted bool    `json:"exhausted"` // Used up: its domains are blocked for the rest of the day
+}
+
+// LabelStats reports the focus time recorded for one session label
+type LabelStats struct {
+	Label string `json:"label"`
+	Today string `json:"today"`
+	Total string `json:"total"`
+}
+
+// HealthInfo is returned by GET /health: the resolver is up, which build it is, and how
+// much memory the recent queries take
+type HealthInfo struct {
+	Status string `json:"status"`
+	version.Info
+	PID      int           `json:"pid,omitempty"` // Lets 'sinkzone resolver stop' confirm the PID file
+	History  *HistoryStats `json:"history,omitempty"`
+	DNSCheck *DNSCheck     `json:"dns_check,omitempty"` // Whether this machine's DNS queries reach the resolver
 }
 
 type ResolverState struct {
@@ -33,47 +182,173 @@ type Server struct {
 	port string
 	addr string
 
-	// State management - using map for unique hostnames with timestamps and blocked status
-	queryMap      map[string]DNSQuery // hostname -> DNSQuery (with timestamp and blocked status)
-	queryMapMutex sync.RWMutex
-
-	focusMode    bool
-	focusEndTime *time.Time
-	focusMutex   sync.RWMutex
+	// HTTP listener, listeners added with ServeListener before Start, and whether Shutdown
+	// was called (guarded by serverMutex)
+	httpServer  *http.Server
+	pending     []net.Listener
+	stopped     bool
+	serverMutex sync.Mutex
+	listener    net.Listener // Bound by Listen ahead of Start (optional)
+	tlsConfig   *tls.Config  // Serves HTTPS when set (see SetTLS)
+
+	// API tokens by secret; none leaves the API open (see SetTokens). The trigger secret is
+	// guarded by the same mutex (see SetTriggerSecret).
+	tokens        map[string]Token
+	triggerSecret string
+	tokensMutex   sync.RWMutex
+
+	mutationsLocalOnly atomic.Bool // See SetMutationsLocalOnly
+
+	history     *queryHistory     // Recent queries in memory, sized by SetHistoryLimits
+	queryStats  *queryCounter     // Totals since startup, for the stats dashboard
+	hits        *hitCounter       // Queries per domain and client since startup
+	pressure    pressureTracker   // Blocked attempts of the focus session, for its distraction pressure
+	suggestions suggestionTracker // Blocked and allowed domains of the focus session, for allowlist suggestions
+	queryLog    Storage           // Every query, on disk or elsewhere (optional)
+	devices     *deviceTracker
+	events      *events.Bus // Gets query_blocked events (optional)
+
+	// Clients following GET /api/queries/stream
+	subscribers      map[*querySubscriber]struct{}
+	subscribersMutex sync.Mutex
+
+	focusMode        bool
+	focusEndTime     *time.Time
+	focusPausedUntil *time.Time    // Set while a pause is in effect
+	focusRemaining   time.Duration // Focus time left when the pause started (0 = no expiration)
+	focusBreak       bool          // The pause only unblocks break domains
+	focusProfile     string
+	focusIntensity   string
+	focusLabel       string
+	focusGraceUntil  *time.Time
+	focusDryRun      bool
+	focusDisableAt   *time.Time
+	focusSnoozes     map[string]time.Time // Snoozed domain -> when the exception expires
+	focusBlocked     int
+	focusLastBlocked string
+	focusWouldBlock  int
+	focusViolations  atomic.Int64 // Atomic: PINs are rejected while the focus lock is held
+	focusMutex       sync.RWMutex
 
 	// Callbacks for DNS server communication
-	onFocusModeChange func(enabled bool, duration time.Duration) error
+	onFocusModeChange  func(enabled bool, opts *FocusOptions) error
+	onFocusPauseChange func(paused bool, opts PauseOptions) error
+	onGetStats         func() (*FocusStats, error)
+	onGetHealth        func() ResolverHealth
+	onGetCacheStats    func() CacheStats
+	onFlushCache       func() int
+	onGetCooldowns     func() []Cooldown
+	onLiftCooldown     func(client string) int
+	onShutdown         func()
+	onShutdownPIN      func(pin string) error
+	onSnooze           func(domain string, until time.Time, pin string) error
+	onReloadAllowlist  func() error
+	onSetUpstreams     func(upstreams []string) ([]string, error)
+	onGetSettings      func() RuntimeSettings
+	onPatchSettings    func(patch SettingsPatch) (RuntimeSettings, error)
+	onDecide           func(domain string) Decision
+	onAddToken         func(name, secret string, scopes []string) (string, error)
+	onListDeviceFocus  func() map[string]DeviceFocus
+	onSetDeviceFocus   func(device string, focus DeviceFocus, pin string) (string, error)
+	onListClientNames  func() []ClientName
+	onSetClientName    func(address, name string, byMAC bool) (ClientName, error)
+	onRemoveClientName func(client string) ([]ClientName, error)
+	onStartPassthrough func(duration time.Duration, pin string) (PortalState, error)
+	onEndPassthrough   func() PortalState
+
+	// Browser extensions waiting for a token, by the ID they poll with
+	pairings      map[string]*Pairing
+	pairingsMutex sync.Mutex
+
+	// Queued focus sessions (optional)
+	scheduler SessionScheduler
 }
 
 func NewServer(port string) *Server {
+	return NewServerWithAddr(":" + port)
+}
+
+// NewServerWithAddr creates a server listening on addr, e.g. 127.0.0.1:8080
+func NewServerWithAddr(addr string) *Server {
+	_, port, _ := net.SplitHostPort(addr)
 	return &Server{
-		port:     port,
-		addr:     ":" + port,
-		queryMap: make(map[string]DNSQuery),
+		port:       port,
+		addr:       addr,
+		history:    newQueryHistory(DefaultHistorySize, 0),
+		queryStats: newQueryCounter(),
+		hits:       newHitCounter(),
+		devices:    newDeviceTracker(),
 	}
 }
 
-func (s *Server) SetFocusModeCallback(callback func(enabled bool, duration time.Duration) error) {
+// SetHistoryLimits sets how many recent queries are kept in memory and, when maxBytes is
+// over 0, roughly how much memory they may take; the newest entries that fit are kept
+func (s *Server) SetHistoryLimits(size int, maxBytes int64) {
+	s.history.resize(size, maxBytes)
+}
+
+// SetHistoryAggregate counts repeats of a query within window in one recent query instead of
+// keeping each; 0 keeps every query
+func (s *Server) SetHistoryAggregate(window time.Duration) {
+	s.history.setWindow(window)
+}
+
+// SetEvents publishes a query_blocked event on bus for every blocked query
+func (s *Server) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+func (s *Server) SetFocusModeCallback(callback func(enabled bool, opts *FocusOptions) error) {
 	s.onFocusModeChange = callback
 }
 
-// loggingMiddleware logs all HTTP requests with method, path, and response status
+// SetFocusPauseCallback registers the function invoked when focus mode is paused or resumed
+func (s *Server) SetFocusPauseCallback(callback func(paused bool, opts PauseOptions) error) {
+	s.onFocusPauseChange = callback
+}
+
+// SetStatsCallback registers the function that provides daily goal and streak stats
+func (s *Server) SetStatsCallback(callback func() (*FocusStats, error)) {
+	s.onGetStats = callback
+}
+
+// loggingMiddleware logs all HTTP requests with method, path, and response status, counts
+// them in the metrics, and traces them, continuing the client's trace if it sent one
 func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
+		// Requests are labeled by route template, so IDs don't create new series
+		route := "other"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+		ctx := tracing.WithTraceParent(r.Context(), r.Header.Get("traceparent"))
+		ctx, span := tracing.Start(ctx, r.Method+" "+route, tracing.KindServer)
+		defer span.End()
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("http.route", route)
+		span.SetAttribute("client.address", r.RemoteAddr)
+
 		// Create a custom response writer to capture status code
 		responseWriter := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 
 		// Log the incoming request
-		log.Printf("API Request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		logger.Debug("API request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
 
 		// Call the next handler
-		next.ServeHTTP(responseWriter, r)
+		next.ServeHTTP(responseWriter, r.WithContext(ctx))
 
 		// Log the response
 		duration := time.Since(start)
-		log.Printf("API Response: %s %s - %d (%v)", r.Method, r.URL.Path, responseWriter.statusCode, duration)
+		logger.Debug("API response", "method", r.Method, "path", r.URL.Path, "status", responseWriter.statusCode, "took", duration)
+		observeRequest(r.Method, route, responseWriter.statusCode, duration.Seconds())
+		span.SetAttribute("http.response.status_code", responseWriter.statusCode)
+		if responseWriter.statusCode >= http.StatusInternalServerError {
+			span.SetError(errors.New(http.StatusText(responseWriter.statusCode)))
+		}
 	})
 }
 
@@ -88,224 +363,628 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Flush passes flushes through so streaming handlers work behind the middleware
+func (rw *responseWriter) Flush() {
+	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 func (s *Server) Start() error {
 	r := mux.NewRouter()
 
 	// Add logging middleware
 	r.Use(s.loggingMiddleware)
-
-	// API routes
-	r.HandleFunc("/api/queries", s.handleGetQueries).Methods("GET")
-	r.HandleFunc("/api/focus", s.handleGetFocusMode).Methods("GET")
-	r.HandleFunc("/api/focus", s.handleSetFocusMode).Methods("POST")
-	r.HandleFunc("/api/state", s.handleGetState).Methods("GET")
+	r.Use(s.localOnlyMiddleware)
+
+	// API routes, each needing a token with its scope when tokens are set
+	r.HandleFunc("/api/queries", s.requireScope(ScopeRead, s.handleGetQueries)).Methods("GET")
+	r.HandleFunc("/api/queries/stream", s.requireScope(ScopeRead, s.handleStreamQueries)).Methods("GET")
+	r.HandleFunc("/api/queries/history", s.requireScope(ScopeRead, s.handleGetQueryHistory)).Methods("GET")
+	r.HandleFunc("/api/queries/hits", s.requireScope(ScopeRead, s.handleGetQueryHits)).Methods("GET")
+	r.HandleFunc("/api/queries/prune", s.requireScope(ScopeAdmin, s.handlePruneQueries)).Methods("POST")
+	r.HandleFunc("/api/focus", s.requireScope(ScopeRead, s.handleGetFocusMode)).Methods("GET")
+	r.HandleFunc("/api/focus", s.requireScope(ScopeFocus, s.handleSetFocusMode)).Methods("POST")
+	r.HandleFunc("/api/focus/pause", s.requireScope(ScopeFocus, s.handlePauseFocusMode)).Methods("POST")
+	r.HandleFunc("/api/focus/resume", s.requireScope(ScopeFocus, s.handleResumeFocusMode)).Methods("POST")
+	r.HandleFunc("/api/focus/snooze", s.requireScope(ScopeFocus, s.handleSnoozeDomain)).Methods("POST")
+	r.HandleFunc("/api/portal/passthrough", s.requireScope(ScopeFocus, s.handleStartPassthrough)).Methods("POST")
+	r.HandleFunc("/api/portal/passthrough", s.requireScope(ScopeFocus, s.handleEndPassthrough)).Methods("DELETE")
+	r.HandleFunc("/api/focus/sessions", s.requireScope(ScopeRead, s.handleGetSessions)).Methods("GET")
+	r.HandleFunc("/api/focus/schedule", s.requireScope(ScopeRead, s.handleGetScheduledSessions)).Methods("GET")
+	r.HandleFunc("/api/focus/schedule", s.requireScope(ScopeFocus, s.handleScheduleSession)).Methods("POST")
+	r.HandleFunc("/api/focus/schedule/{id}", s.requireScope(ScopeFocus, s.handleCancelScheduledSession)).Methods("DELETE")
+	r.HandleFunc("/api/state", s.requireScope(ScopeRead, s.handleGetState)).Methods("GET")
+	r.HandleFunc("/api/summary", s.requireScope(ScopeRead, s.handleGetSummary)).Methods("GET")
+	r.HandleFunc("/api/stats", s.requireScope(ScopeRead, s.handleGetStats)).Methods("GET")
+	r.HandleFunc("/api/stats/queries", s.requireScope(ScopeRead, s.handleGetQueryStats)).Methods("GET")
+	r.HandleFunc("/api/allowlist/reload", s.requireScope(ScopeAllowlist, s.handleReloadAllowlist)).Methods("POST")
+	r.HandleFunc("/api/config/upstreams", s.requireScope(ScopeAdmin, s.handleSetUpstreams)).Methods("PUT")
+	r.HandleFunc("/api/upstreams", s.requireScope(ScopeRead, s.handleGetUpstreams)).Methods("GET")
+	r.HandleFunc("/api/cache", s.requireScope(ScopeRead, s.handleGetCache)).Methods("GET")
+	r.HandleFunc("/api/cache", s.requireScope(ScopeAdmin, s.handleFlushCache)).Methods("DELETE")
+	r.HandleFunc("/api/cooldowns", s.requireScope(ScopeRead, s.handleGetCooldowns)).Methods("GET")
+	r.HandleFunc("/api/cooldowns", s.requireScope(ScopeAdmin, s.handleLiftCooldown)).Methods("DELETE")
+	r.HandleFunc("/api/cooldowns/{client}", s.requireScope(ScopeAdmin, s.handleLiftCooldown)).Methods("DELETE")
+	r.HandleFunc("/api/settings", s.requireScope(ScopeRead, s.handleGetSettings)).Methods("GET")
+	r.HandleFunc("/api/settings", s.requireScope(ScopeAdmin, s.handlePatchSettings)).Methods("PATCH")
+	r.HandleFunc("/api/shutdown", s.requireScope(ScopeAdmin, s.handleShutdown)).Methods("POST")
+	r.HandleFunc("/api/suggestions", s.requireScope(ScopeRead, s.handleGetSuggestions)).Methods("GET")
+	r.HandleFunc("/api/devices", s.requireScope(ScopeRead, s.handleGetDevices)).Methods("GET")
+	r.HandleFunc("/api/devices/{device}", s.requireScope(ScopeRead, s.handleGetDevice)).Methods("GET")
+	r.HandleFunc("/api/devices/{device}/focus", s.requireScope(ScopeFocus, s.handleSetDeviceFocus)).Methods("PUT")
+	r.HandleFunc("/api/clients", s.requireScope(ScopeRead, s.handleGetClientNames)).Methods("GET")
+	r.HandleFunc("/api/clients/{client}", s.requireScope(ScopeAdmin, s.handleSetClientName)).Methods("PUT")
+	r.HandleFunc("/api/clients/{client}", s.requireScope(ScopeAdmin, s.handleRemoveClientName)).Methods("DELETE")
+	r.HandleFunc("/api/extension/decision", s.requireScope(ScopeExtension, s.handleGetDecision)).Methods("GET")
+	r.HandleFunc("/api/extension/allow", s.requireScope(ScopeExtension, s.handleExtensionAllow)).Methods("POST")
+	r.HandleFunc("/api/extension/pairings", s.requireScope(ScopeAdmin, s.handleListPairings)).Methods("GET")
+	r.HandleFunc("/api/extension/pairings/{code}", s.requireScope(ScopeAdmin, s.handleApprovePairing)).Methods("POST")
+
+	// Pairing needs no token: the extension has none yet, and the user approves it
+	r.HandleFunc("/api/extension/pair", s.handleStartPairing).Methods("POST")
+	r.HandleFunc("/api/extension/pair/{id}", s.handleGetPairing).Methods("GET")
+
+	// Triggers carry their own secret instead of a token
+	r.HandleFunc(triggerPath, s.handleTrigger).Methods("POST")
 
 	// Health check
 	r.HandleFunc("/health", s.handleHealth).Methods("GET")
 
+	// Container probes, which need no token either
+	r.HandleFunc("/livez", s.handleLivez).Methods("GET")
+	r.HandleFunc("/readyz", s.handleReadyz).Methods("GET")
+	r.HandleFunc("/api/health", s.requireScope(ScopeRead, s.handleGetHealth)).Methods("GET")
+
+	// Prometheus metrics
+	r.HandleFunc("/metrics", s.requireScope(ScopeRead, metrics.Handler().ServeHTTP)).Methods("GET")
+
 	server := &http.Server{
 		Addr:              s.addr,
 		Handler:           r,
 		ReadHeaderTimeout: 10 * time.Second, // Prevent Slowloris attacks
+		TLSConfig:         s.tlsConfig,
+	}
+
+	s.serverMutex.Lock()
+	if s.stopped {
+		s.serverMutex.Unlock()
+		if s.listener != nil {
+			_ = s.listener.Close()
+		}
+		for _, listener := range s.pending {
+			_ = listener.Close()
+		}
+		return nil
 	}
+	s.httpServer = server
+	for _, listener := range s.pending {
+		go s.serveExtra(server, listener)
+	}
+	s.pending = nil
+	s.serverMutex.Unlock()
+
+	logger.Info("API server starting", "addr", s.addr, "tls", s.tlsConfig != nil)
+	var err error
+	switch {
+	case s.listener != nil && s.tlsConfig != nil:
+		err = server.ServeTLS(s.listener, "", "")
+	case s.listener != nil:
+		err = server.Serve(s.listener)
+	case s.tlsConfig != nil:
+		err = server.ListenAndServeTLS("", "")
+	default:
+		err = server.ListenAndServe()
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// SetListener makes the server accept connections on a socket bound elsewhere, e.g. by
+// systemd, instead of binding its address in Listen
+func (s *Server) SetListener(listener net.Listener) {
+	s.listener = listener
+}
+
+// ServeListener also accepts API connections on listener, e.g. one on a tailnet, until
+// Shutdown. Requests through it are checked like those of other machines.
+func (s *Server) ServeListener(listener net.Listener) {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+	switch {
+	case s.stopped:
+		_ = listener.Close()
+	case s.httpServer == nil:
+		s.pending = append(s.pending, listener)
+	default:
+		go s.serveExtra(s.httpServer, listener)
+	}
+}
+
+func (s *Server) serveExtra(server *http.Server, listener net.Listener) {
+	logger.Info("API server accepting connections", "addr", listener.Addr().String())
+	var err error
+	if s.tlsConfig != nil {
+		err = server.ServeTLS(listener, "", "")
+	} else {
+		err = server.Serve(listener)
+	}
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		logger.Warn("API listener failed", "addr", listener.Addr().String(), "error", err)
+	}
+}
 
-	log.Printf("API server starting on %s", s.addr)
-	return server.ListenAndServe()
+// Listen binds the API address ahead of Start, while the resolver may still be root
+func (s *Server) Listen() error {
+	if s.listener != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+	}
+	s.listener = listener
+	return nil
 }
 
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Health check request from %s", r.RemoteAddr)
-	w.WriteHeader(http.StatusOK)
-	if _, err := w.Write([]byte("OK")); err != nil {
+	logger.Debug("Health check request", "remote", r.RemoteAddr)
+	w.Header().Set("Content-Type", "application/json")
+	history := s.history.stats()
+	info := HealthInfo{Status: "OK", Info: version.Get(), PID: os.Getpid(), History: &history}
+	if s.onGetHealth != nil {
+		info.DNSCheck = s.onGetHealth().DNSCheck
+	}
+	if err := json.NewEncoder(w).Encode(info); err != nil {
 		// Log error but don't return it since we can't change the response now
-		log.Printf("Warning: failed to write health response: %v", err)
+		logger.Warn("Failed to write health response", "error", err)
 	}
 }
 
 func (s *Server) handleGetQueries(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Get queries request from %s", r.RemoteAddr)
-
-	s.queryMapMutex.RLock()
-	defer s.queryMapMutex.RUnlock()
+	logger.Debug("Get queries request", "remote", r.RemoteAddr)
 
-	// Convert map to sorted slice of DNSQuery
-	queries := s.getSortedQueries()
-
-	// Return last 100 queries
-	if len(queries) > 100 {
-		queries = queries[len(queries)-100:]
+	limit := maxRecentDomains
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
 	}
 
-	log.Printf("Returning %d unique queries", len(queries))
+	// The newest recent queries, repeats counted in one entry, oldest first
+	queries := s.history.latest(limit)
+	s.hits.annotate(queries)
+
+	logger.Debug("Returning unique queries", "count", len(queries))
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(queries); err != nil {
-		log.Printf("Error encoding queries response: %v", err)
+		logger.Error("Encoding queries response failed", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (s *Server) handleGetFocusMode(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Get focus mode request from %s", r.RemoteAddr)
-
-	s.focusMutex.RLock()
-	defer s.focusMutex.RUnlock()
+	logger.Debug("Get focus mode request", "remote", r.RemoteAddr)
 
-	state := FocusModeState{
-		Enabled: s.focusMode,
-		EndTime: s.focusEndTime,
-	}
+	s.focusMutex.Lock()
+	s.checkFocusPauseExpiry()
+	state := s.focusModeState()
+	s.focusMutex.Unlock()
 
-	log.Printf("Focus mode state: enabled=%v, endTime=%v", s.focusMode, s.focusEndTime)
+	logger.Debug("Focus mode state", "enabled", state.Enabled, "end_time", state.EndTime, "paused", state.Paused)
 
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(state); err != nil {
-		log.Printf("Error encoding focus mode response: %v", err)
+		logger.Error("Encoding focus mode response failed", "error", err)
 		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
 		return
 	}
 }
 
 func (s *Server) handleSetFocusMode(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Set focus mode request from %s", r.RemoteAddr)
+	logger.Debug("Set focus mode request", "remote", r.RemoteAddr)
 
-	var req struct {
-		Enabled  bool   `json:"enabled"`
-		Duration string `json:"duration,omitempty"`
-	}
+	var req FocusRequest
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding focus mode request: %v", err)
+		logger.Error("Decoding focus mode request failed", "error", err)
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	log.Printf("Focus mode request: enabled=%v, duration=%s", req.Enabled, req.Duration)
+	logger.Debug("Focus mode request", "enabled", req.Enabled, "duration", req.Duration, "profile", req.Profile, "label", req.Label)
 
-	var duration time.Duration
-	var err error
-	if req.Enabled && req.Duration != "" {
-		duration, err = time.ParseDuration(req.Duration)
-		if err != nil {
-			log.Printf("Invalid duration format: %s", req.Duration)
-			http.Error(w, "Invalid duration format", http.StatusBadRequest)
-			return
+	if err := s.ApplyFocusMode(req); err != nil {
+		logger.Error("Updating focus mode failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to update focus mode: %v", err), s.focusErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	logger.Debug("Focus mode updated")
+}
+
+func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get state request", "remote", r.RemoteAddr)
+
+	s.focusMutex.Lock()
+	s.checkFocusPauseExpiry()
+	state := ResolverState{
+		FocusMode: s.focusModeState(),
+		Queries:   s.history.latest(maxRecentDomains),
+	}
+	s.focusMutex.Unlock()
+	s.hits.annotate(state.Queries)
+
+	logger.Debug("Returning state", "queries", len(state.Queries), "focus_mode", state.FocusMode.Enabled)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		logger.Error("Encoding state response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get stats request", "remote", r.RemoteAddr)
+
+	if s.onGetStats == nil {
+		http.Error(w, "Stats are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	stats, err := s.GetStats()
+	if err != nil {
+		logger.Error("Getting stats failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to get stats: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(stats); err != nil {
+		logger.Error("Encoding stats response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetStats returns the daily goal and streak stats
+func (s *Server) GetStats() (*FocusStats, error) {
+	if s.onGetStats == nil {
+		return nil, errors.New("stats are not available")
+	}
+	stats, err := s.onGetStats()
+	if err != nil {
+		return nil, err
+	}
+	stats.Pressure = s.DistractionPressure()
+	return stats, nil
+}
+
+// ApplyFocusMode validates and applies a focus mode change, notifying the DNS server
+func (s *Server) ApplyFocusMode(req FocusRequest) error {
+	opts := FocusOptions{PIN: req.PIN}
+	if req.Enabled {
+		opts.Profile = req.Profile
+		opts.Intensity = req.Intensity
+		opts.Label = req.Label
+		opts.DryRun = req.DryRun
+		if req.Duration != "" {
+			duration, err := time.ParseDuration(req.Duration)
+			if err != nil {
+				return fmt.Errorf("invalid duration format: %s", req.Duration)
+			}
+			opts.Duration = duration
+		}
+		if req.Until != "" {
+			if req.Duration != "" {
+				return fmt.Errorf("use either duration or until, not both")
+			}
+			now := time.Now()
+			until, err := ParseWallClock(req.Until, now)
+			if err != nil {
+				return err
+			}
+			if !until.After(now) {
+				return fmt.Errorf("until time %s has already passed", until.Format(time.RFC3339))
+			}
+			opts.Duration = until.Sub(now)
+		}
+	}
+
+	// Call DNS server callback first so invalid requests (e.g. unknown profiles) leave state untouched
+	if s.onFocusModeChange != nil {
+		if err := s.onFocusModeChange(req.Enabled, &opts); err != nil {
+			return err
 		}
 	}
 
+	if !req.Enabled && opts.DisableAt != nil {
+		s.focusMutex.Lock()
+		s.queueFocusDisable(*opts.DisableAt)
+		s.focusMutex.Unlock()
+		logger.Info("Focus mode disable queued", "at", *opts.DisableAt)
+		return nil
+	}
+
 	// Update focus mode
 	s.focusMutex.Lock()
 	s.focusMode = req.Enabled
-	if req.Enabled && duration > 0 {
-		endTime := time.Now().Add(duration)
+	s.focusDisableAt = nil
+	s.focusPausedUntil = nil
+	s.focusRemaining = 0
+	s.focusBreak = false
+	s.focusProfile = opts.Profile
+	s.focusIntensity = opts.Intensity
+	s.focusLabel = opts.Label
+	s.focusSnoozes = nil
+	s.focusGraceUntil = nil
+	s.focusDryRun = req.Enabled && opts.DryRun
+	s.focusBlocked = 0
+	s.focusLastBlocked = ""
+	s.pressure.reset(clock.Now(), req.Enabled)
+	s.suggestions.reset(clock.Now(), req.Enabled)
+	s.focusWouldBlock = 0
+	s.focusViolations.Store(0)
+	if req.Enabled && opts.GracePeriod > 0 {
+		graceUntil := clock.Now().Add(opts.GracePeriod)
+		s.focusGraceUntil = &graceUntil
+	}
+	if req.Enabled && opts.Duration > 0 {
+		endTime := clock.Now().Add(opts.Duration)
 		s.focusEndTime = &endTime
-		log.Printf("Focus mode enabled until %v", endTime)
+		logger.Info("Focus mode enabled", "until", endTime)
 	} else {
 		s.focusEndTime = nil
 		if req.Enabled {
-			log.Printf("Focus mode enabled indefinitely")
+			logger.Info("Focus mode enabled indefinitely")
 		} else {
-			log.Printf("Focus mode disabled")
+			logger.Info("Focus mode disabled")
 		}
 	}
 	s.focusMutex.Unlock()
 
-	// Call DNS server callback if set
-	if s.onFocusModeChange != nil {
-		if err := s.onFocusModeChange(req.Enabled, duration); err != nil {
-			log.Printf("Error updating focus mode in DNS server: %v", err)
-			http.Error(w, fmt.Sprintf("Failed to update focus mode: %v", err), http.StatusInternalServerError)
+	return nil
+}
+
+func (s *Server) handlePauseFocusMode(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Pause focus mode request", "remote", r.RemoteAddr)
+
+	var req PauseRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Decoding pause request failed", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		logger.Warn("Invalid pause duration", "duration", req.Duration)
+		http.Error(w, "Invalid duration format", http.StatusBadRequest)
+		return
+	}
+
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
+
+	s.checkFocusPauseExpiry()
+	if !s.focusMode {
+		http.Error(w, "Focus mode is not active", http.StatusConflict)
+		return
+	}
+	if s.focusPausedUntil != nil {
+		http.Error(w, "Focus mode is already paused", http.StatusConflict)
+		return
+	}
+
+	// Let the DNS server validate (e.g. the PIN) before any state changes
+	if s.onFocusPauseChange != nil {
+		if err := s.onFocusPauseChange(true, PauseOptions{Duration: duration, PIN: req.PIN, Break: req.Break}); err != nil {
+			logger.Error("Pausing focus mode in DNS server failed", "error", err)
+			http.Error(w, fmt.Sprintf("Failed to pause focus mode: %v", err), s.focusErrorStatus(err, http.StatusInternalServerError))
 			return
 		}
 	}
 
+	// Preserve the time left so it can be restored on resume
+	s.focusRemaining = 0
+	if s.focusEndTime != nil {
+		s.focusRemaining = time.Until(*s.focusEndTime)
+	}
+	pausedUntil := clock.Now().Add(duration)
+	s.focusPausedUntil = &pausedUntil
+	s.focusEndTime = nil
+	s.focusBreak = req.Break
+
+	if req.Break {
+		logger.Info("Focus mode break", "until", pausedUntil)
+	} else {
+		logger.Info("Focus mode paused", "until", pausedUntil)
+	}
 	w.WriteHeader(http.StatusOK)
-	log.Printf("Focus mode updated successfully")
 }
 
-func (s *Server) handleGetState(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Get state request from %s", r.RemoteAddr)
+func (s *Server) handleResumeFocusMode(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Resume focus mode request", "remote", r.RemoteAddr)
 
-	s.focusMutex.RLock()
-	s.queryMapMutex.RLock()
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
 
-	// Convert map to sorted slice of DNSQuery
-	queries := s.getSortedQueries()
+	s.checkFocusPauseExpiry()
+	if s.focusPausedUntil == nil {
+		http.Error(w, "Focus mode is not paused", http.StatusConflict)
+		return
+	}
 
-	state := ResolverState{
-		FocusMode: FocusModeState{
-			Enabled: s.focusMode,
-			EndTime: s.focusEndTime,
-		},
-		Queries: queries,
+	if s.onFocusPauseChange != nil {
+		if err := s.onFocusPauseChange(false, PauseOptions{}); err != nil {
+			logger.Error("Resuming focus mode in DNS server failed", "error", err)
+			http.Error(w, fmt.Sprintf("Failed to resume focus mode: %v", err), s.focusErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
 	}
 
-	// Limit to last 100 queries
-	if len(state.Queries) > 100 {
-		state.Queries = state.Queries[len(state.Queries)-100:]
+	s.resumeFocusMode(clock.Now())
+
+	logger.Info("Focus mode resumed")
+	w.WriteHeader(http.StatusOK)
+}
+
+// focusErrorStatus maps errors returned by focus callbacks to HTTP status codes, counting
+// rejected PINs as violations of the session
+func (s *Server) focusErrorStatus(err error, fallback int) int {
+	if errors.Is(err, ErrPINRequired) || errors.Is(err, ErrInvalidPIN) {
+		s.focusViolations.Add(1)
+		return http.StatusForbidden
 	}
+	return fallback
+}
 
-	s.focusMutex.RUnlock()
-	s.queryMapMutex.RUnlock()
+// checkFocusPauseExpiry resumes focus mode once the pause window has elapsed
+// This method assumes the caller holds the focus write lock
+func (s *Server) checkFocusPauseExpiry() {
+	if s.focusPausedUntil != nil && clock.Now().After(*s.focusPausedUntil) {
+		s.resumeFocusMode(*s.focusPausedUntil)
+	}
+}
 
-	log.Printf("Returning state with %d unique queries, focus mode: %v", len(state.Queries), s.focusMode)
+// resumeFocusMode restores the preserved focus time starting at the given instant
+// This method assumes the caller holds the focus write lock
+func (s *Server) resumeFocusMode(at time.Time) {
+	if s.focusRemaining > 0 {
+		endTime := at.Add(s.focusRemaining)
+		s.focusEndTime = &endTime
+	}
+	s.focusPausedUntil = nil
+	s.focusRemaining = 0
+	s.focusBreak = false
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(state); err != nil {
-		log.Printf("Error encoding state response: %v", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+// queueFocusDisable ends the session at the given time instead of disabling it immediately
+// This method assumes the caller holds the focus write lock
+func (s *Server) queueFocusDisable(at time.Time) {
+	s.focusDisableAt = &at
+	if s.focusPausedUntil != nil {
+		s.focusRemaining = at.Sub(*s.focusPausedUntil)
 		return
 	}
+	s.focusEndTime = &at
 }
 
-// getSortedQueries converts the query map to a sorted slice of DNSQuery
-// This method assumes the caller holds the appropriate read lock
-func (s *Server) getSortedQueries() []DNSQuery {
-	// Create a slice to hold the queries for sorting
-	var entries []DNSQuery
-	for _, query := range s.queryMap {
-		entries = append(entries, query)
-	}
+// MoveFocusDeadlines moves the session's end, pause, grace period, queued disable, and
+// snoozes by the given amount, after the system time was changed by as much
+func (s *Server) MoveFocusDeadlines(by time.Duration) {
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
 
-	// Sort by timestamp (oldest first)
-	sort.Slice(entries, func(i, j int) bool {
-		return entries[i].Timestamp.Before(entries[j].Timestamp)
-	})
+	for _, deadline := range []**time.Time{&s.focusEndTime, &s.focusPausedUntil, &s.focusGraceUntil, &s.focusDisableAt} {
+		if *deadline != nil {
+			moved := (*deadline).Add(by)
+			*deadline = &moved
+		}
+	}
+	for domain, until := range s.focusSnoozes {
+		s.focusSnoozes[domain] = until.Add(by)
+	}
+}
 
-	return entries
+// focusModeState builds the API representation of the focus mode state
+// This method assumes the caller holds the focus lock
+func (s *Server) focusModeState() FocusModeState {
+	state := FocusModeState{
+		Enabled:     s.focusMode,
+		EndTime:     s.focusEndTime,
+		Profile:     s.focusProfile,
+		Intensity:   s.focusIntensity,
+		Label:       s.focusLabel,
+		DryRun:      s.focusDryRun,
+		Blocked:     s.focusBlocked,
+		LastBlocked: s.focusLastBlocked,
+		WouldBlock:  s.focusWouldBlock,
+		Violations:  int(s.focusViolations.Load()),
+	}
+	if s.focusGraceUntil != nil && clock.Now().Before(*s.focusGraceUntil) {
+		state.GraceUntil = s.focusGraceUntil
+	}
+	if s.focusDisableAt != nil && clock.Now().Before(*s.focusDisableAt) {
+		state.DisableAt = s.focusDisableAt
+	}
+	if s.focusMode {
+		state.Snoozes = s.activeSnoozes()
+	}
+	if s.focusPausedUntil != nil {
+		state.Paused = true
+		state.Break = s.focusBreak
+		state.PausedUntil = s.focusPausedUntil
+		if s.focusRemaining > 0 {
+			state.Remaining = s.focusRemaining.Round(time.Second).String()
+		}
+	}
+	return state
 }
 
 // AddQuery adds a new DNS query to the server's query history
-// Now updates the timestamp for existing domains or adds new ones
 func (s *Server) AddQuery(query DNSQuery) {
-	s.queryMapMutex.Lock()
-	defer s.queryMapMutex.Unlock()
-
-	// Update or add the domain with the current timestamp and blocked status
-	s.queryMap[query.Domain] = query
-
-	// Keep only the last 100 unique domains
-	if len(s.queryMap) > 100 {
-		// Convert to slice for sorting
-		var entries []DNSQuery
-		for _, query := range s.queryMap {
-			entries = append(entries, query)
+	if query.Blocked || query.WouldBlock {
+		s.focusMutex.Lock()
+		if query.Blocked {
+			s.focusBlocked++
+			s.focusLastBlocked = query.Domain
+			s.pressure.add(clock.Now())
+		} else {
+			s.focusWouldBlock++
 		}
-
-		// Sort by timestamp (oldest first)
-		sort.Slice(entries, func(i, j int) bool {
-			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		s.focusMutex.Unlock()
+	}
+	if s.focusBlocking() {
+		s.suggestions.add(query)
+	}
+	s.queryStats.add(query)
+	s.devices.add(query)
+	if s.queryLog != nil {
+		s.queryLog.Append(query)
+	}
+	query.Count = s.history.add(query)
+
+	// Streamed queries carry the domain's hit counts, like those of GET /api/queries
+	s.hits.add(query)
+	counted := []DNSQuery{query}
+	s.hits.annotate(counted)
+	s.publishQuery(counted[0])
+	if query.Blocked && s.events.Wants(events.QueryBlocked) {
+		s.events.Publish(events.QueryBlocked, map[string]string{
+			"domain":     query.Domain,
+			"client":     query.ClientLabel(),
+			"query_type": query.QueryType,
+			"reason":     query.Reason,
 		})
-
-		// Remove oldest entries to keep only 100
-		entriesToRemove := len(entries) - 100
-		for i := 0; i < entriesToRemove; i++ {
-			delete(s.queryMap, entries[i].Domain)
-		}
 	}
 
-	log.Printf("DNS Query: %s (blocked: %v) - Updated timestamp", query.Domain, query.Blocked)
+	logger.Debug("DNS query recorded", "domain", query.Domain, "blocked", query.Blocked, "would_block", query.WouldBlock)
+}
+
+// focusBlocking reports whether a focus session is blocking, neither paused nor over
+func (s *Server) focusBlocking() bool {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	return s.focusMode && s.focusPausedUntil == nil && (s.focusEndTime == nil || clock.Now().Before(*s.focusEndTime))
+}
+
+// GetFocusState returns the full focus mode state, resuming an elapsed pause first
+func (s *Server) GetFocusState() FocusModeState {
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
+	s.checkFocusPauseExpiry()
+	return s.focusModeState()
 }
 
 // GetFocusMode returns the current focus mode state
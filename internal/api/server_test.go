@@ -0,0 +1,208 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPauseAndResumeFocusMode(t *testing.T) {
+	server := NewServer("0")
+
+	endTime := time.Now().Add(30 * time.Minute)
+	server.focusMode = true
+	server.focusEndTime = &endTime
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/focus/pause", strings.NewReader(`{"duration":"5m"}`))
+	server.handlePauseFocusMode(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected pause to succeed, got status %d", rec.Code)
+	}
+
+	state := server.focusModeState()
+	if !state.Enabled || !state.Paused {
+		t.Fatalf("Expected focus mode to be enabled and paused, got %+v", state)
+	}
+	if state.EndTime != nil {
+		t.Errorf("Expected no end time while paused, got %v", state.EndTime)
+	}
+	if server.focusRemaining < 29*time.Minute {
+		t.Errorf("Expected remaining time to be preserved, got %v", server.focusRemaining)
+	}
+
+	// A second pause must be rejected
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/focus/pause", strings.NewReader(`{"duration":"5m"}`))
+	server.handlePauseFocusMode(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected conflict when already paused, got status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/focus/resume", nil)
+	server.handleResumeFocusMode(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected resume to succeed, got status %d", rec.Code)
+	}
+
+	state = server.focusModeState()
+	if state.Paused || state.EndTime == nil {
+		t.Fatalf("Expected focus mode to be running again, got %+v", state)
+	}
+	if remaining := time.Until(*state.EndTime); remaining < 29*time.Minute {
+		t.Errorf("Expected resumed session to keep its remaining time, got %v", remaining)
+	}
+}
+
+func TestPauseExpiresAutomatically(t *testing.T) {
+	server := NewServer("0")
+
+	pausedUntil := time.Now().Add(-time.Minute)
+	server.focusMode = true
+	server.focusPausedUntil = &pausedUntil
+	server.focusRemaining = 10 * time.Minute
+
+	server.checkFocusPauseExpiry()
+
+	if server.focusPausedUntil != nil {
+		t.Fatal("Expected pause to expire")
+	}
+	if server.focusEndTime == nil {
+		t.Fatal("Expected end time to be restored after pause")
+	}
+	if want := pausedUntil.Add(10 * time.Minute); !server.focusEndTime.Equal(want) {
+		t.Errorf("Expected end time %v, got %v", want, *server.focusEndTime)
+	}
+}
+
+func TestPauseRequiresActiveFocusMode(t *testing.T) {
+	server := NewServer("0")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/focus/pause", strings.NewReader(`{"duration":"5m"}`))
+	server.handlePauseFocusMode(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("Expected conflict when focus mode is off, got status %d", rec.Code)
+	}
+}
+
+func TestQueuedDisableKeepsFocusActive(t *testing.T) {
+	server := NewServer("0")
+
+	endTime := time.Now().Add(time.Hour)
+	server.focusMode = true
+	server.focusEndTime = &endTime
+
+	disableAt := time.Now().Add(10 * time.Minute)
+	server.SetFocusModeCallback(func(enabled bool, opts *FocusOptions) error {
+		opts.DisableAt = &disableAt
+		return nil
+	})
+
+	if err := server.ApplyFocusMode(FocusRequest{Enabled: false}); err != nil {
+		t.Fatalf("Expected queued disable to succeed, got %v", err)
+	}
+
+	state := server.focusModeState()
+	if !state.Enabled {
+		t.Fatal("Expected focus mode to stay enabled until the queued disable")
+	}
+	if state.EndTime == nil || !state.EndTime.Equal(disableAt) {
+		t.Errorf("Expected session to end at %v, got %v", disableAt, state.EndTime)
+	}
+	if state.DisableAt == nil || !state.DisableAt.Equal(disableAt) {
+		t.Errorf("Expected queued disable at %v, got %v", disableAt, state.DisableAt)
+	}
+}
+
+func TestBreakIsReportedAndClearedOnResume(t *testing.T) {
+	server := NewServer("0")
+	server.focusMode = true
+
+	var got PauseOptions
+	server.SetFocusPauseCallback(func(paused bool, opts PauseOptions) error {
+		got = opts
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/focus/pause", strings.NewReader(`{"duration":"10m","break":true}`))
+	server.handlePauseFocusMode(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected break to start, got status %d", rec.Code)
+	}
+	if !got.Break || got.Duration != 10*time.Minute {
+		t.Errorf("Expected break options to reach the callback, got %+v", got)
+	}
+
+	if state := server.focusModeState(); !state.Paused || !state.Break {
+		t.Fatalf("Expected focus mode to be on a break, got %+v", state)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/focus/resume", nil)
+	server.handleResumeFocusMode(rec, req)
+	if state := server.focusModeState(); state.Paused || state.Break {
+		t.Errorf("Expected break to end on resume, got %+v", state)
+	}
+}
+
+func TestSnoozeIsReportedUntilSessionEnds(t *testing.T) {
+	server := NewServer("0")
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/focus/snooze", strings.NewReader(`{"domain":"Docs.Python.org.","duration":"10m"}`))
+	server.handleSnoozeDomain(rec, req)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("Expected conflict without an active session, got status %d", rec.Code)
+	}
+
+	server.focusMode = true
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/focus/snooze", strings.NewReader(`{"domain":"Docs.Python.org.","duration":"10m"}`))
+	server.handleSnoozeDomain(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected snooze to succeed, got status %d", rec.Code)
+	}
+
+	state := server.focusModeState()
+	if len(state.Snoozes) != 1 || state.Snoozes[0].Domain != "docs.python.org" {
+		t.Fatalf("Expected normalized snooze to be reported, got %+v", state.Snoozes)
+	}
+
+	if err := server.ApplyFocusMode(FocusRequest{Enabled: false}); err != nil {
+		t.Fatalf("Failed to disable focus mode: %v", err)
+	}
+	if server.focusSnoozes != nil {
+		t.Errorf("Expected snoozes to be revoked when the session ends, got %v", server.focusSnoozes)
+	}
+}
+
+func TestReadyzWaitsForDNSAndUpstreams(t *testing.T) {
+	server := NewServer("0")
+	status := func() int {
+		rec := httptest.NewRecorder()
+		server.handleReadyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 before the resolver reports health, got %d", code)
+	}
+	health := ResolverHealth{UpstreamStatus: HealthUnknown}
+	server.SetHealthCallback(func() ResolverHealth { return health })
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while the DNS port is not bound, got %d", code)
+	}
+	health.DNSListening = true
+	if code := status(); code != http.StatusOK {
+		t.Errorf("Expected 200 once the DNS port is bound, got %d", code)
+	}
+	health.UpstreamStatus = HealthDown
+	if code := status(); code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while every upstream is down, got %d", code)
+	}
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// FocusSession is a focus session that ended, as kept in the session history of the Storage
+type FocusSession struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Profile   string    `json:"profile,omitempty"`
+	Intensity string    `json:"intensity,omitempty"`
+	Label     string    `json:"label,omitempty"`
+	DryRun    bool      `json:"dry_run,omitempty"`
+}
+
+// RecordSession stores a focus session that ended in the query log's storage, if there is one
+func (s *Server) RecordSession(session FocusSession) {
+	if s.queryLog == nil {
+		return
+	}
+	if err := s.queryLog.AppendSession(session); err != nil {
+		logger.Warn("Failed to record focus session", "error", err)
+	}
+}
+
+// handleGetSessions returns the focus sessions that ended since ?since= (a duration ago or an
+// RFC 3339 time), or all of them
+func (s *Server) handleGetSessions(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get focus sessions request", "remote", r.RemoteAddr)
+
+	if s.queryLog == nil {
+		http.Error(w, "The query log is disabled", http.StatusServiceUnavailable)
+		return
+	}
+	since, err := parseQueryTime(r.URL.Query().Get("since"), time.Now())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid since: %v", err), http.StatusBadRequest)
+		return
+	}
+	sessions, err := s.queryLog.Sessions(since)
+	if err != nil {
+		logger.Error("Reading focus sessions failed", "error", err)
+		http.Error(w, "Failed to read focus sessions", http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []FocusSession{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(sessions); err != nil {
+		logger.Error("Encoding focus sessions response failed", "error", err)
+		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+}
+
+// GetSessions returns the focus sessions that ended since the given time (all of them when
+// zero), oldest first
+func (c *Client) GetSessions(since time.Time) ([]FocusSession, error) {
+	endpoint := c.baseURL + "/api/focus/sessions"
+	if !since.IsZero() {
+		endpoint += "?" + url.Values{"since": {since.Format(time.RFC3339Nano)}}.Encode()
+	}
+	resp, err := c.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get focus sessions: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var sessions []FocusSession
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, fmt.Errorf("failed to decode focus sessions: %w", err)
+	}
+	return sessions, nil
+}
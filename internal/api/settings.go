@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// RuntimeSettings are tunables of a running resolver, returned by GET /api/settings. They
+// start from sinkzone.yaml, but changes made through the API aren't saved to it and last
+// until the resolver restarts or the same setting changes in the file.
+type RuntimeSettings struct {
+	LogLevel           string            `json:"log_level"`               // Lowest level logged: debug, info, warn, or error
+	DryRun             bool              `json:"dry_run"`                 // Every focus session only warns instead of blocking
+	RateLimit          RateLimitSettings `json:"rate_limit"`              // Per-client query rate limit
+	ConfigPollInterval string            `json:"config_poll_interval"`    // How often sinkzone.yaml is checked for changes
+	SyncInterval       string            `json:"sync_interval,omitempty"` // How often sync peers are polled, empty without sync
+}
+
+// RateLimitSettings is a per-client query rate limit; a rate of 0 means unlimited
+type RateLimitSettings struct {
+	QueriesPerSecond int `json:"queries_per_second"`
+	Burst            int `json:"burst"` // 0 sets it to the per-second rate
+}
+
+// SettingsPatch is the body accepted by PATCH /api/settings; only the fields present change
+type SettingsPatch struct {
+	LogLevel           *string            `json:"log_level,omitempty"`
+	DryRun             *bool              `json:"dry_run,omitempty"` // Turning it on needs the focus PIN
+	RateLimit          *RateLimitSettings `json:"rate_limit,omitempty"`
+	ConfigPollInterval *string            `json:"config_poll_interval,omitempty"`
+	SyncInterval       *string            `json:"sync_interval,omitempty"`
+	PIN                string             `json:"pin,omitempty"`
+}
+
+// SetSettingsCallbacks registers the functions behind GET and PATCH /api/settings. The
+// patch callback applies every change or none, returning the settings now in effect.
+func (s *Server) SetSettingsCallbacks(get func() RuntimeSettings, patch func(SettingsPatch) (RuntimeSettings, error)) {
+	s.onGetSettings = get
+	s.onPatchSettings = patch
+}
+
+func (s *Server) handleGetSettings(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get settings request", "remote", r.RemoteAddr)
+
+	if s.onGetSettings == nil {
+		http.Error(w, "Runtime settings are not available", http.StatusServiceUnavailable)
+		return
+	}
+	writeSettings(w, s.onGetSettings())
+}
+
+func (s *Server) handlePatchSettings(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Patch settings request", "remote", r.RemoteAddr)
+
+	if s.onPatchSettings == nil {
+		http.Error(w, "Runtime settings are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var patch SettingsPatch
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	settings, err := s.onPatchSettings(patch)
+	if err != nil {
+		logger.Error("Changing settings failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to change settings: %v", err), s.focusErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+	writeSettings(w, settings)
+}
+
+func writeSettings(w http.ResponseWriter, settings RuntimeSettings) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(settings); err != nil {
+		logger.Error("Encoding settings response failed", "error", err)
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPatchSettings(t *testing.T) {
+	server := NewServer("0")
+	level := "info"
+	server.SetSettingsCallbacks(func() RuntimeSettings {
+		return RuntimeSettings{LogLevel: level}
+	}, func(patch SettingsPatch) (RuntimeSettings, error) {
+		if patch.DryRun != nil && *patch.DryRun && patch.PIN == "" {
+			return RuntimeSettings{}, ErrPINRequired
+		}
+		if patch.LogLevel != nil {
+			level = *patch.LogLevel
+		}
+		return RuntimeSettings{LogLevel: level}, nil
+	})
+
+	cases := []struct {
+		body   string
+		status int
+	}{
+		{`{"log_level": "debug"}`, http.StatusOK},
+		{`{"dry_run": true}`, http.StatusForbidden},
+		{`{"log_levle": "debug"}`, http.StatusBadRequest},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		server.handlePatchSettings(w, httptest.NewRequest(http.MethodPatch, "/api/settings", strings.NewReader(c.body)))
+		if w.Code != c.status {
+			t.Errorf("expected %s to return %d, got %d: %s", c.body, c.status, w.Code, w.Body)
+		}
+	}
+	if level != "debug" {
+		t.Errorf("expected the log level to be changed, got %s", level)
+	}
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ShutdownRequest is the optional body accepted by POST /api/shutdown
+type ShutdownRequest struct {
+	PIN string `json:"pin,omitempty"`
+}
+
+// SetShutdownCallback registers the function that stops the resolver on POST /api/shutdown
+func (s *Server) SetShutdownCallback(callback func()) {
+	s.onShutdown = callback
+}
+
+// SetShutdownPINCheck registers the function that checks the PIN of a shutdown request, since
+// stopping the resolver ends a PIN-protected focus session
+func (s *Server) SetShutdownPINCheck(check func(pin string) error) {
+	s.onShutdownPIN = check
+}
+
+// Shutdown stops the API listener, letting in-flight requests finish until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.serverMutex.Lock()
+	s.stopped = true
+	server := s.httpServer
+	s.serverMutex.Unlock()
+
+	if server == nil {
+		return nil
+	}
+	if err := server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to stop API server: %w", err)
+	}
+	return nil
+}
+
+// handleShutdown stops the resolver. Only local clients may do so, since the API
+// listens on all interfaces, and they need the focus PIN during a PIN-protected session.
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Shutdown request", "remote", r.RemoteAddr)
+
+	if !isLoopback(r.RemoteAddr) {
+		http.Error(w, "Shutdown is only allowed from this machine", http.StatusForbidden)
+		return
+	}
+	if s.onShutdown == nil {
+		http.Error(w, "Shutdown is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req ShutdownRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if s.onShutdownPIN != nil {
+		if err := s.onShutdownPIN(req.PIN); err != nil {
+			logger.Warn("Shutdown refused", "remote", r.RemoteAddr, "error", err)
+			http.Error(w, fmt.Sprintf("Failed to stop resolver: %v", err), s.focusErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	// Stop after the response is sent: shutting down waits for this request to finish
+	go s.onShutdown()
+}
+
+// isLoopback reports whether a request's remote address is on this machine
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Shutdown asks the resolver to stop; pin is needed during a PIN-protected focus session. It
+// returns once the request is accepted; the resolver exits shortly after.
+func (c *Client) Shutdown(pin string) error {
+	body, err := json.Marshal(ShutdownRequest{PIN: pin})
+	if err != nil {
+		return fmt.Errorf("failed to encode shutdown request: %w", err)
+	}
+	resp, err := c.client.Post(c.baseURL+"/api/shutdown", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to stop resolver: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusAccepted {
+		return responseError(resp)
+	}
+
+	return nil
+}
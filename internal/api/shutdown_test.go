@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownOnlyFromLoopback(t *testing.T) {
+	server := NewServer("0")
+	stopped := make(chan struct{})
+	server.SetShutdownCallback(func() { close(stopped) })
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shutdown", nil)
+	req.RemoteAddr = "192.168.1.20:51234"
+	server.handleShutdown(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("Expected remote shutdown to be forbidden, got status %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/api/shutdown", nil)
+	req.RemoteAddr = "[::1]:51234"
+	server.handleShutdown(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected local shutdown to be accepted, got status %d", rec.Code)
+	}
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the shutdown callback to run")
+	}
+}
+
+func TestShutdownNeedsPIN(t *testing.T) {
+	server := NewServer("0")
+	stopped := make(chan struct{})
+	server.SetShutdownCallback(func() { close(stopped) })
+	server.SetShutdownPINCheck(func(pin string) error {
+		switch pin {
+		case "":
+			return ErrPINRequired
+		case "1234":
+			return nil
+		default:
+			return ErrInvalidPIN
+		}
+	})
+
+	for _, body := range []string{"", `{"pin":"0000"}`} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/shutdown", strings.NewReader(body))
+		req.RemoteAddr = "127.0.0.1:51234"
+		server.handleShutdown(rec, req)
+		if rec.Code != http.StatusForbidden {
+			t.Fatalf("Expected shutdown with body %q to be forbidden, got status %d", body, rec.Code)
+		}
+	}
+	if violations := server.focusViolations.Load(); violations != 2 {
+		t.Errorf("Expected the rejected PINs to count as 2 violations, got %d", violations)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/shutdown", strings.NewReader(`{"pin":"1234"}`))
+	req.RemoteAddr = "127.0.0.1:51234"
+	server.handleShutdown(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("Expected shutdown with the PIN to be accepted, got status %d", rec.Code)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the shutdown callback to run")
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+)
+
+// SnoozeRequest is the body accepted by POST /api/focus/snooze
+type SnoozeRequest struct {
+	Domain   string `json:"domain"`
+	Duration string `json:"duration"`
+	PIN      string `json:"pin,omitempty"`
+}
+
+// Snooze is a time-boxed exception that lets a single blocked domain resolve
+type Snooze struct {
+	Domain string    `json:"domain"`
+	Until  time.Time `json:"until"`
+}
+
+// SetSnoozeCallback registers the function invoked when a domain is snoozed
+func (s *Server) SetSnoozeCallback(callback func(domain string, until time.Time, pin string) error) {
+	s.onSnooze = callback
+}
+
+func (s *Server) handleSnoozeDomain(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Snooze domain request", "remote", r.RemoteAddr)
+
+	var req SnoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Decoding snooze request failed", "error", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	s.snooze(w, req)
+}
+
+// snooze lets the domain of req through and writes the snooze, or the error, to w
+func (s *Server) snooze(w http.ResponseWriter, req SnoozeRequest) {
+	domain := NormalizeDomain(req.Domain)
+	if domain == "" {
+		http.Error(w, "Domain is required", http.StatusBadRequest)
+		return
+	}
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil || duration <= 0 {
+		logger.Warn("Invalid snooze duration", "duration", req.Duration)
+		http.Error(w, "Invalid duration format", http.StatusBadRequest)
+		return
+	}
+
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
+
+	s.checkFocusPauseExpiry()
+	if !s.focusMode {
+		http.Error(w, "Focus mode is not active", http.StatusConflict)
+		return
+	}
+
+	until := clock.Now().Add(duration)
+
+	// Let the DNS server validate (e.g. the PIN) before any state changes
+	if s.onSnooze != nil {
+		if err := s.onSnooze(domain, until, req.PIN); err != nil {
+			logger.Error("Snoozing in DNS server failed", "domain", domain, "error", err)
+			http.Error(w, fmt.Sprintf("Failed to snooze domain: %v", err), s.focusErrorStatus(err, http.StatusInternalServerError))
+			return
+		}
+	}
+
+	if s.focusSnoozes == nil {
+		s.focusSnoozes = make(map[string]time.Time)
+	}
+	s.focusSnoozes[domain] = until
+	logger.Info("Domain snoozed", "domain", domain, "until", until)
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(Snooze{Domain: domain, Until: until}); err != nil {
+		logger.Error("Encoding snooze response failed", "error", err)
+	}
+}
+
+// activeSnoozes lists the snoozes that haven't expired yet, soonest first
+// This method assumes the caller holds the focus lock
+func (s *Server) activeSnoozes() []Snooze {
+	var snoozes []Snooze
+	now := clock.Now()
+	for domain, until := range s.focusSnoozes {
+		if until.After(now) {
+			snoozes = append(snoozes, Snooze{Domain: domain, Until: until})
+		}
+	}
+	sort.Slice(snoozes, func(i, j int) bool {
+		return snoozes[i].Until.Before(snoozes[j].Until)
+	})
+	return snoozes
+}
+
+// NormalizeDomain lowercases a domain and strips the trailing dot of a fully qualified name
+func NormalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultMemoryQueries bounds a MemoryStorage whose retention sets no MaxRecords
+	defaultMemoryQueries = 100000
+	// maxMemorySessions bounds the focus sessions a MemoryStorage keeps
+	maxMemorySessions = 10000
+)
+
+// Storage keeps the query log, every query the resolver answered, and the focus sessions
+// that ended. QueryLog keeps them on disk and MemoryStorage in memory; another backend, such
+// as a database several resolvers share for long-term retention, plugs in by implementing
+// Storage and being passed to Server.SetQueryLog.
+type Storage interface {
+	// Append stores a query. It is called for every query answered, so it must not wait
+	// for slow writes.
+	Append(query DNSQuery)
+	// Queries returns the queries matching the filter, oldest first
+	Queries(filter QueryFilter) ([]DNSQuery, error)
+	// Stats summarizes the queries since the given time, without PerMinute
+	Stats(since time.Time) (QueryStats, error)
+	// Prune deletes queries older than MaxAge and the oldest ones beyond MaxRecords,
+	// returning how many were deleted
+	Prune(retention Retention, now time.Time) (int, error)
+	// Count returns the number of queries kept
+	Count() (int, error)
+	// SetRetention sets the policy the storage applies on its own from now on
+	SetRetention(retention Retention)
+	// Retention returns the policy set with SetRetention
+	Retention() Retention
+	// SetSampleRate keeps only 1 in rate allowed queries (see sampler)
+	SetSampleRate(rate int)
+	// AppendSession stores a focus session that ended. Sessions are few, so the retention
+	// doesn't apply to them.
+	AppendSession(session FocusSession) error
+	// Sessions returns the focus sessions that ended at or after since, oldest first
+	Sessions(since time.Time) ([]FocusSession, error)
+	// Close stores what is pending and releases the storage
+	Close() error
+}
+
+var (
+	_ Storage = (*QueryLog)(nil)
+	_ Storage = (*MemoryStorage)(nil)
+)
+
+// sampler picks the queries a Storage keeps when sampling, so a busy resolver doesn't
+// store every one. Blocked and would-be-blocked queries are always kept.
+type sampler struct {
+	sampleRate atomic.Int64  // Allowed queries per kept one (see SetSampleRate)
+	allowed    atomic.Uint64 // Allowed queries seen, to pick the ones kept
+}
+
+// SetSampleRate keeps only 1 in rate allowed queries; rate 1 keeps everything
+func (s *sampler) SetSampleRate(rate int) {
+	s.sampleRate.Store(int64(max(rate, 1)))
+}
+
+// sample reports whether a query is kept, recording the rate in the ones kept
+func (s *sampler) sample(query *DNSQuery) bool {
+	if rate := s.sampleRate.Load(); rate > 1 && !query.Blocked && !query.WouldBlock {
+		if s.allowed.Add(1)%uint64(rate) != 0 {
+			return false
+		}
+		query.SampleRate = int(rate)
+	}
+	return true
+}
+
+// matches reports whether a query passes the filter, except for its Limit
+func (f QueryFilter) matches(query DNSQuery) bool {
+	switch {
+	case !f.Since.IsZero() && query.Timestamp.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !query.Timestamp.Before(f.Until):
+		return false
+	case f.Domain != "" && !strings.EqualFold(query.Domain, f.Domain):
+		return false
+	case f.Client != "" && query.Client != f.Client:
+		return false
+	}
+	return true
+}
+
+// MemoryStorage keeps the query log in memory, e.g. for a resolver on a read-only disk.
+// The queries are lost when the resolver stops. It keeps at most the retention's
+// MaxRecords, or defaultMemoryQueries without them, and drops queries past MaxAge as new
+// ones arrive.
+type MemoryStorage struct {
+	sampler
+
+	mutex     sync.RWMutex
+	queries   []DNSQuery // Oldest first, from start on
+	start     int
+	retention Retention
+	sessions  []FocusSession // Oldest first
+}
+
+// NewMemoryStorage creates an empty in-memory query log
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+// Append stores a query, dropping the oldest ones the retention policy doesn't keep
+func (m *MemoryStorage) Append(query DNSQuery) {
+	if !m.sample(&query) {
+		return
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.queries = append(m.queries, query)
+	m.prune(m.retention, time.Now())
+}
+
+// Queries returns the queries matching the filter, oldest first
+func (m *MemoryStorage) Queries(filter QueryFilter) ([]DNSQuery, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var queries []DNSQuery
+	for i := len(m.queries) - 1; i >= m.start; i-- {
+		query := m.queries[i]
+		if !filter.Since.IsZero() && query.Timestamp.Before(filter.Since) {
+			break
+		}
+		if !filter.matches(query) {
+			continue
+		}
+		queries = append(queries, query)
+		if filter.Limit > 0 && len(queries) >= filter.Limit {
+			break
+		}
+	}
+	for i, j := 0, len(queries)-1; i < j; i, j = i+1, j-1 {
+		queries[i], queries[j] = queries[j], queries[i]
+	}
+	return queries, nil
+}
+
+// Stats summarizes the queries since the given time, without PerMinute
+func (m *MemoryStorage) Stats(since time.Time) (QueryStats, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	window := newWindowStats(since, nil)
+	for i := len(m.queries) - 1; i >= m.start && !m.queries[i].Timestamp.Before(since); i-- {
+		window.add(newRecentQuery(m.queries[i]))
+	}
+	return window.result(), nil
+}
+
+// Prune deletes queries older than MaxAge and the oldest ones beyond MaxRecords
+func (m *MemoryStorage) Prune(retention Retention, now time.Time) (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.prune(retention, now), nil
+}
+
+// prune drops the queries retention doesn't keep; the caller holds the write lock
+func (m *MemoryStorage) prune(retention Retention, now time.Time) int {
+	limit := retention.MaxRecords
+	if limit <= 0 {
+		limit = defaultMemoryQueries
+	}
+	deleted := 0
+	for m.start < len(m.queries) {
+		oldest := m.queries[m.start]
+		if len(m.queries)-m.start <= limit && (retention.MaxAge <= 0 || !oldest.Timestamp.Before(now.Add(-retention.MaxAge))) {
+			break
+		}
+		m.queries[m.start] = DNSQuery{}
+		m.start++
+		deleted++
+	}
+	// Move the queries kept to the front once half the slice is dropped ones
+	if m.start > 0 && m.start >= len(m.queries)/2 {
+		n := copy(m.queries, m.queries[m.start:])
+		clear(m.queries[n:])
+		m.queries = m.queries[:n]
+		m.start = 0
+	}
+	return deleted
+}
+
+// Count returns the number of queries kept
+func (m *MemoryStorage) Count() (int, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return len(m.queries) - m.start, nil
+}
+
+// SetRetention sets the retention policy and applies it now
+func (m *MemoryStorage) SetRetention(retention Retention) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.retention = retention
+	m.prune(retention, time.Now())
+}
+
+// Retention returns the retention policy set with SetRetention
+func (m *MemoryStorage) Retention() Retention {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.retention
+}
+
+// AppendSession stores a focus session that ended, dropping the oldest beyond
+// maxMemorySessions
+func (m *MemoryStorage) AppendSession(session FocusSession) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.sessions = append(m.sessions, session)
+	if len(m.sessions) > maxMemorySessions {
+		m.sessions = slices.Clone(m.sessions[len(m.sessions)-maxMemorySessions:])
+	}
+	return nil
+}
+
+// Sessions returns the focus sessions that ended at or after since, oldest first
+func (m *MemoryStorage) Sessions(since time.Time) ([]FocusSession, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	var sessions []FocusSession
+	for _, session := range m.sessions {
+		if !session.End.Before(since) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+// Close drops the queries and sessions
+func (m *MemoryStorage) Close() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.queries, m.start = nil, 0
+	m.sessions = nil
+	return nil
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for i, domain := range []string{"github.com", "reddit.com", "GitHub.com", "news.ycombinator.com", "github.com"} {
+		storage.Append(DNSQuery{Domain: domain, Client: "10.0.0.1", Timestamp: start.Add(time.Duration(i) * time.Minute), Blocked: domain == "reddit.com"})
+	}
+
+	queries, err := storage.Queries(QueryFilter{Domain: "github.com", Limit: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(queries) != 2 || queries[0].Domain != "GitHub.com" || !queries[1].Timestamp.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("expected the newest 2 github.com queries, oldest first, got %v", queries)
+	}
+	if queries, _ = storage.Queries(QueryFilter{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute)}); len(queries) != 2 {
+		t.Errorf("expected 2 queries in the window, got %d", len(queries))
+	}
+
+	stats, err := storage.Stats(start.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Total != 4 || stats.Blocked != 1 {
+		t.Errorf("expected 4 queries with 1 blocked, got %d with %d blocked", stats.Total, stats.Blocked)
+	}
+
+	// Setting a retention applies it at once, then to every query appended
+	storage.SetRetention(Retention{MaxRecords: 3})
+	storage.Append(DNSQuery{Domain: "example.com", Client: "10.0.0.1", Timestamp: start.Add(5 * time.Minute)})
+	if count, _ := storage.Count(); count != 3 {
+		t.Errorf("expected 3 queries kept, got %d", count)
+	}
+	deleted, err := storage.Prune(Retention{MaxAge: 3 * time.Minute}, start.Add(7*time.Minute))
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected 1 query deleted by age, got %d (%v)", deleted, err)
+	}
+	if queries, _ = storage.Queries(QueryFilter{}); len(queries) != 2 || queries[0].Domain != "github.com" || queries[1].Domain != "example.com" {
+		t.Errorf("expected the newest 2 queries to be kept, got %v", queries)
+	}
+
+	// Sampling keeps 1 in 4 allowed queries and every blocked one
+	storage = NewMemoryStorage()
+	storage.SetSampleRate(4)
+	for i := range 8 {
+		storage.Append(DNSQuery{Domain: "github.com", Timestamp: start.Add(time.Duration(i) * time.Second)})
+	}
+	storage.Append(DNSQuery{Domain: "reddit.com", Timestamp: start.Add(10 * time.Second), Blocked: true})
+	if queries, _ = storage.Queries(QueryFilter{}); len(queries) != 3 || queries[0].SampleRate != 4 || queries[2].SampleRate != 0 {
+		t.Errorf("expected 2 sampled queries and the blocked one, got %v", queries)
+	}
+}
+
+func TestStorageSessions(t *testing.T) {
+	queryLog, err := OpenQueryLog(filepath.Join(t.TempDir(), "queries.db"))
+	if err != nil {
+		t.Fatalf("failed to open query log: %v", err)
+	}
+	defer queryLog.Close()
+
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	for name, storage := range map[string]Storage{"memory": NewMemoryStorage(), "file": queryLog} {
+		for i, label := range []string{"writing", "", "review"} {
+			session := FocusSession{Start: start.Add(time.Duration(i) * time.Hour), End: start.Add(time.Duration(i)*time.Hour + 50*time.Minute), Label: label}
+			if err := storage.AppendSession(session); err != nil {
+				t.Fatalf("%s: unexpected error: %v", name, err)
+			}
+		}
+
+		sessions, err := storage.Sessions(start.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if len(sessions) != 2 || sessions[0].Label != "" || sessions[1].Label != "review" {
+			t.Errorf("%s: expected the 2 sessions that ended after 10:00, oldest first, got %v", name, sessions)
+		}
+		if sessions, _ = storage.Sessions(time.Time{}); len(sessions) != 3 || !sessions[0].End.Equal(start.Add(50*time.Minute)) {
+			t.Errorf("%s: expected every session, got %v", name, sessions)
+		}
+	}
+}
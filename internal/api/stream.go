@@ -0,0 +1,192 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// streamBuffer is how far a stream subscriber can fall behind before queries are dropped for it
+	streamBuffer = 256
+
+	// streamKeepAlive is how often an idle stream sends a comment so proxies keep it open
+	streamKeepAlive = 15 * time.Second
+)
+
+// StreamDropped is sent by GET /api/queries/stream before the next query when the
+// subscriber fell behind and queries were skipped
+type StreamDropped struct {
+	Count int64 `json:"count"`
+}
+
+// querySubscriber receives queries as they are recorded
+type querySubscriber struct {
+	queries chan DNSQuery
+	dropped atomic.Int64
+}
+
+func (s *Server) subscribeQueries() *querySubscriber {
+	sub := &querySubscriber{queries: make(chan DNSQuery, streamBuffer)}
+
+	s.subscribersMutex.Lock()
+	defer s.subscribersMutex.Unlock()
+	if s.subscribers == nil {
+		s.subscribers = make(map[*querySubscriber]struct{})
+	}
+	s.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (s *Server) unsubscribeQueries(sub *querySubscriber) {
+	s.subscribersMutex.Lock()
+	defer s.subscribersMutex.Unlock()
+	delete(s.subscribers, sub)
+}
+
+// publishQuery hands a query to every stream subscriber without blocking the resolver:
+// subscribers whose buffer is full skip the query and are told how many they missed
+func (s *Server) publishQuery(query DNSQuery) {
+	s.subscribersMutex.Lock()
+	defer s.subscribersMutex.Unlock()
+
+	for sub := range s.subscribers {
+		select {
+		case sub.queries <- query:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func (s *Server) handleStreamQueries(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Query stream request", "remote", r.RemoteAddr)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	sub := s.subscribeQueries()
+	defer s.unsubscribeQueries(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case query := <-sub.queries:
+			if dropped := sub.dropped.Swap(0); dropped > 0 {
+				err = writeEvent(w, "dropped", StreamDropped{Count: dropped})
+			}
+			if err == nil {
+				err = writeEvent(w, "query", query)
+			}
+		}
+		if err != nil {
+			logger.Info("Query stream closed", "remote", r.RemoteAddr, "error", err)
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes one server-sent event with a JSON payload
+func writeEvent(w http.ResponseWriter, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", event, err)
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+	return err
+}
+
+// StreamQueries calls onQuery for every query the resolver records until ctx is cancelled
+// or the stream breaks. onDropped is told how many queries the resolver skipped because
+// this client fell behind.
+func (c *Client) StreamQueries(ctx context.Context, onQuery func(DNSQuery), onDropped func(int64)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/queries/stream", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create stream request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	// The stream stays open indefinitely, so it can't use the client's request timeout
+	streamClient := &http.Client{Transport: c.client.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open query stream: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	var event, data string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		case line == "":
+			if err := dispatchEvent(event, data, onQuery, onDropped); err != nil {
+				return err
+			}
+			event, data = "", ""
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read query stream: %w", err)
+	}
+	return fmt.Errorf("query stream closed by the resolver")
+}
+
+func dispatchEvent(event, data string, onQuery func(DNSQuery), onDropped func(int64)) error {
+	switch event {
+	case "query":
+		var query DNSQuery
+		if err := json.Unmarshal([]byte(data), &query); err != nil {
+			return fmt.Errorf("failed to decode streamed query: %w", err)
+		}
+		onQuery(query)
+	case "dropped":
+		var dropped StreamDropped
+		if err := json.Unmarshal([]byte(data), &dropped); err != nil {
+			return fmt.Errorf("failed to decode dropped event: %w", err)
+		}
+		if onDropped != nil {
+			onDropped(dropped.Count)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStreamQueries(t *testing.T) {
+	s := NewServer("0")
+	ts := httptest.NewServer(s.loggingMiddleware(http.HandlerFunc(s.handleStreamQueries)))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan DNSQuery, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- NewClient(ts.URL).StreamQueries(ctx, func(query DNSQuery) { received <- query }, nil)
+	}()
+
+	// Wait for the client to subscribe before recording a query
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		s.subscribersMutex.Lock()
+		subscribed := len(s.subscribers) > 0
+		s.subscribersMutex.Unlock()
+		if subscribed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("client never subscribed to the query stream")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.AddQuery(DNSQuery{Domain: "example.com", Client: "127.0.0.1", Timestamp: time.Now(), Blocked: true})
+
+	select {
+	case query := <-received:
+		if query.Domain != "example.com" || !query.Blocked {
+			t.Errorf("unexpected streamed query: %+v", query)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("streamed query was not received")
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected the stream to end with context.Canceled, got %v", err)
+	}
+}
+
+func TestPublishQueryDropsForSlowSubscribers(t *testing.T) {
+	s := NewServer("0")
+	sub := s.subscribeQueries()
+	defer s.unsubscribeQueries(sub)
+
+	for i := 0; i < streamBuffer+5; i++ {
+		s.publishQuery(DNSQuery{Domain: "example.com"})
+	}
+	if dropped := sub.dropped.Load(); dropped != 5 {
+		t.Errorf("expected 5 dropped queries, got %d", dropped)
+	}
+}
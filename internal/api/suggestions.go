@@ -0,0 +1,218 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// minSuggestionBlocks is how often a domain must be blocked in a session to be suggested
+	minSuggestionBlocks = 2
+	// maxSuggestions bounds the suggestions GET /api/suggestions returns
+	maxSuggestions = 50
+	// maxSuggestionRelated bounds the allowed domains listed with a suggestion
+	maxSuggestionRelated = 5
+)
+
+// Reasons a blocked domain is suggested
+const (
+	SuggestionSameSite = "same_site" // Allowed domains of the same registered domain were queried
+	SuggestionCNAME    = "cname"     // An allowed domain's answer pointed to it with a CNAME record
+)
+
+// Suggestion is a domain blocked repeatedly in a focus session alongside allowed domains it
+// likely belongs with, so allowing it may fix a site that only half works
+type Suggestion struct {
+	Domain   string    `json:"domain"`
+	Blocked  int       `json:"blocked"` // Blocked queries in the session
+	Site     string    `json:"site"`    // Registered domain, e.g. example.com for cdn.example.com
+	Reason   string    `json:"reason"`  // same_site or cname
+	Related  []string  `json:"related"` // Allowed domains it was tried alongside
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// Suggestions are the allowlist suggestions of the running focus session, or of the last
+// one since the resolver started, most blocked first
+type Suggestions struct {
+	Active      bool         `json:"active"`          // The session is still running
+	Start       *time.Time   `json:"start,omitempty"` // Omitted without a session since the resolver started
+	End         *time.Time   `json:"end,omitempty"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// blockedAttempts counts the blocked queries for a domain
+type blockedAttempts struct {
+	count int
+	last  time.Time
+}
+
+// suggestionTracker collects the blocked and allowed domains of the focus session. The last
+// session's are kept once it ends, until the next one starts.
+type suggestionTracker struct {
+	mu      sync.Mutex
+	start   time.Time // Start of the session, zero before the first one
+	end     time.Time // Zero while it runs
+	blocked map[string]*blockedAttempts
+	allowed map[string]map[string]bool // Registered domain -> allowed domains
+	cnames  map[string]string          // CNAME target -> allowed domain whose answer had it
+	counted int                        // Allowed domains held
+}
+
+// registeredDomain returns the domain a name was registered under, or the name itself
+func registeredDomain(domain string) string {
+	if site, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil {
+		return site
+	}
+	return domain
+}
+
+// reset starts collecting for a session starting at now, or marks the session ended
+func (t *suggestionTracker) reset(now time.Time, enabled bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !enabled {
+		if !t.start.IsZero() && t.end.IsZero() {
+			t.end = now
+		}
+		return
+	}
+	t.start, t.end, t.counted = now, time.Time{}, 0
+	t.blocked = make(map[string]*blockedAttempts)
+	t.allowed = make(map[string]map[string]bool)
+	t.cnames = make(map[string]string)
+}
+
+// add records a query made while the session blocks
+func (t *suggestionTracker) add(query DNSQuery) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.start.IsZero() || !t.end.IsZero() {
+		return
+	}
+	domain := strings.ToLower(query.Domain)
+	if query.Blocked || query.WouldBlock {
+		attempts, ok := t.blocked[domain]
+		if !ok {
+			if len(t.blocked) >= maxCountedKeys {
+				return
+			}
+			attempts = &blockedAttempts{}
+			t.blocked[domain] = attempts
+		}
+		attempts.count++
+		attempts.last = query.Timestamp
+		return
+	}
+
+	site := registeredDomain(domain)
+	if !t.allowed[site][domain] && t.counted < maxCountedKeys {
+		if t.allowed[site] == nil {
+			t.allowed[site] = make(map[string]bool)
+		}
+		t.allowed[site][domain] = true
+		t.counted++
+	}
+	for _, target := range query.CNAMEs {
+		if target = strings.ToLower(target); t.cnames[target] == "" && len(t.cnames) < maxCountedKeys {
+			t.cnames[target] = domain
+		}
+	}
+}
+
+// get returns the suggestions; a session that ran out at expired without being ended
+// counts as ended then
+func (t *suggestionTracker) get(active bool, expired *time.Time) Suggestions {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	result := Suggestions{Active: active, Suggestions: []Suggestion{}}
+	if t.start.IsZero() {
+		return result
+	}
+	start := t.start
+	result.Start = &start
+	if end := t.end; !end.IsZero() {
+		result.End = &end
+	} else if !active && expired != nil {
+		end := *expired
+		result.End = &end
+	}
+
+	for domain, attempts := range t.blocked {
+		site := registeredDomain(domain)
+		if attempts.count < minSuggestionBlocks || t.allowed[site][domain] {
+			// Allowed during the session as well, e.g. added to the allowlist
+			continue
+		}
+		suggestion := Suggestion{Domain: domain, Blocked: attempts.count, Site: site, LastSeen: attempts.last}
+		if source, ok := t.cnames[domain]; ok {
+			suggestion.Reason, suggestion.Related = SuggestionCNAME, []string{source}
+		} else if len(t.allowed[site]) > 0 {
+			suggestion.Reason = SuggestionSameSite
+			for related := range t.allowed[site] {
+				suggestion.Related = append(suggestion.Related, related)
+			}
+			sort.Strings(suggestion.Related)
+			suggestion.Related = suggestion.Related[:min(len(suggestion.Related), maxSuggestionRelated)]
+		} else {
+			continue
+		}
+		result.Suggestions = append(result.Suggestions, suggestion)
+	}
+	sort.Slice(result.Suggestions, func(i, j int) bool {
+		a, b := result.Suggestions[i], result.Suggestions[j]
+		if a.Blocked != b.Blocked {
+			return a.Blocked > b.Blocked
+		}
+		return a.Domain < b.Domain
+	})
+	result.Suggestions = result.Suggestions[:min(len(result.Suggestions), maxSuggestions)]
+	return result
+}
+
+// Suggestions returns the allowlist suggestions of the running or the last focus session
+func (s *Server) Suggestions() Suggestions {
+	state := s.GetFocusState()
+	active := state.Enabled && (state.EndTime == nil || clock.Now().Before(*state.EndTime))
+	return s.suggestions.get(active, state.EndTime)
+}
+
+// handleGetSuggestions returns the allowlist suggestions of the running or the last focus
+// session
+func (s *Server) handleGetSuggestions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s.Suggestions()); err != nil {
+		logger.Error("Encoding suggestions response failed", "error", err)
+	}
+}
+
+// GetSuggestions returns the allowlist suggestions of the running or the last focus session
+func (c *Client) GetSuggestions() (*Suggestions, error) {
+	resp, err := c.client.Get(c.baseURL + "/api/suggestions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestions: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var suggestions Suggestions
+	if err := json.NewDecoder(resp.Body).Decode(&suggestions); err != nil {
+		return nil, fmt.Errorf("failed to decode suggestions: %w", err)
+	}
+	return &suggestions, nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSuggestions(t *testing.T) {
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var tracker suggestionTracker
+
+	blocked := func(domain string, times int) {
+		for i := 0; i < times; i++ {
+			tracker.add(DNSQuery{Domain: domain, Blocked: true, Timestamp: start.Add(time.Minute)})
+		}
+	}
+
+	blocked("cdn.example.com", 3)
+	if got := tracker.get(false, nil); got.Start != nil || len(got.Suggestions) != 0 {
+		t.Fatalf("Expected nothing collected outside a session, got %+v", got)
+	}
+
+	tracker.reset(start, true)
+	tracker.add(DNSQuery{Domain: "www.example.com"})
+	tracker.add(DNSQuery{Domain: "docs.vendor.io", CNAMEs: []string{"vendor.edgekey.net", "e1.akamaiedge.net"}})
+	blocked("cdn.example.com", 3)
+	blocked("e1.akamaiedge.net", 2)
+	blocked("api.example.com", 1)  // Blocked once
+	blocked("news.example.org", 5) // Nothing of the site allowed
+	tracker.add(DNSQuery{Domain: "Static.Example.com", WouldBlock: true})
+	tracker.add(DNSQuery{Domain: "static.example.com", Blocked: true})
+
+	got := tracker.get(true, nil)
+	var domains []string
+	for _, suggestion := range got.Suggestions {
+		domains = append(domains, suggestion.Domain)
+	}
+	if !got.Active || got.End != nil || !slices.Equal(domains, []string{"cdn.example.com", "e1.akamaiedge.net", "static.example.com"}) {
+		t.Fatalf("Expected the three related domains, most blocked first, got %+v", got)
+	}
+	if first := got.Suggestions[0]; first.Reason != SuggestionSameSite || first.Site != "example.com" || !slices.Equal(first.Related, []string{"www.example.com"}) {
+		t.Errorf("Expected cdn.example.com to be related to www.example.com, got %+v", first)
+	}
+	if second := got.Suggestions[1]; second.Reason != SuggestionCNAME || !slices.Equal(second.Related, []string{"docs.vendor.io"}) {
+		t.Errorf("Expected e1.akamaiedge.net to be related by CNAME, got %+v", second)
+	}
+
+	// A domain allowed later in the session is no longer suggested
+	tracker.add(DNSQuery{Domain: "cdn.example.com"})
+	if got := tracker.get(true, nil); len(got.Suggestions) != 2 {
+		t.Errorf("Expected the allowed domain to be dropped, got %+v", got.Suggestions)
+	}
+
+	// The suggestions outlast the session until the next one starts
+	end := start.Add(time.Hour)
+	tracker.reset(end, false)
+	blocked("late.example.com", 2)
+	if got := tracker.get(false, nil); got.End == nil || !got.End.Equal(end) || len(got.Suggestions) != 2 {
+		t.Errorf("Expected the ended session's suggestions, got %+v", got)
+	}
+	tracker.reset(end.Add(time.Hour), true)
+	if got := tracker.get(true, nil); len(got.Suggestions) != 0 {
+		t.Errorf("Expected a new session to start over, got %+v", got.Suggestions)
+	}
+}
@@ -0,0 +1,165 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+)
+
+const (
+	// summaryPollInterval is how often a long poll of GET /api/summary looks for a change
+	summaryPollInterval = 500 * time.Millisecond
+
+	// DefaultSummaryWait and maxSummaryWait bound how long a long poll waits for a change
+	DefaultSummaryWait = 30 * time.Second
+	maxSummaryWait     = 60 * time.Second
+)
+
+// Summary is the compact state GET /api/summary returns, everything a tray or menu-bar
+// helper shows
+type Summary struct {
+	Focus             bool       `json:"focus"`
+	Paused            bool       `json:"paused,omitempty"`
+	Break             bool       `json:"break,omitempty"`
+	EndTime           *time.Time `json:"end_time,omitempty"`
+	Remaining         int        `json:"remaining_seconds,omitempty"` // Focus time left, also while paused (0 = no end time)
+	PausedUntil       *time.Time `json:"paused_until,omitempty"`
+	Profile           string     `json:"profile,omitempty"`
+	Intensity         string     `json:"intensity,omitempty"`
+	Label             string     `json:"label,omitempty"`
+	Blocked           int        `json:"blocked"`             // Queries blocked in the session
+	BlockedLastMinute int        `json:"blocked_last_minute"` // Queries blocked in the last minute, in or out of a session
+	State             string     `json:"state"`               // Changes with everything but the remaining time; pass it as since to wait for a change
+}
+
+// summary returns the current Summary
+func (s *Server) summary() Summary {
+	s.focusMutex.Lock()
+	s.checkFocusPauseExpiry()
+	state := s.focusModeState()
+	remaining := s.focusRemaining
+	s.focusMutex.Unlock()
+
+	now := clock.Now()
+	summary := Summary{
+		Focus:             state.Enabled && (state.EndTime == nil || now.Before(*state.EndTime)),
+		BlockedLastMinute: s.BlockedSince(now.Add(-time.Minute)),
+	}
+	if summary.Focus {
+		summary.Paused = state.Paused
+		summary.Break = state.Break
+		summary.EndTime = state.EndTime
+		summary.PausedUntil = state.PausedUntil
+		summary.Profile = state.Profile
+		summary.Intensity = state.Intensity
+		summary.Label = state.Label
+		summary.Blocked = state.Blocked
+	}
+
+	// The state is hashed before the remaining time is filled in, so it only changes when
+	// something a helper shows does
+	data, _ := json.Marshal(summary)
+	hash := fnv.New64a()
+	_, _ = hash.Write(data)
+	summary.State = strconv.FormatUint(hash.Sum64(), 36)
+
+	switch {
+	case summary.Paused:
+		summary.Remaining = int(remaining.Seconds())
+	case summary.EndTime != nil:
+		summary.Remaining = int(summary.EndTime.Sub(now).Round(time.Second).Seconds())
+	}
+	return summary
+}
+
+// handleGetSummary returns the Summary. With since, it is a long poll: the answer waits
+// until the state differs from since, or for wait (default 30s, at most 60s).
+func (s *Server) handleGetSummary(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Get summary request", "remote", r.RemoteAddr)
+
+	summary := s.summary()
+	if since := r.URL.Query().Get("since"); since != "" && since == summary.State {
+		wait := DefaultSummaryWait
+		if value := r.URL.Query().Get("wait"); value != "" {
+			parsed, err := time.ParseDuration(value)
+			if err != nil || parsed < 0 {
+				http.Error(w, fmt.Sprintf("Invalid wait: %s", value), http.StatusBadRequest)
+				return
+			}
+			wait = min(parsed, maxSummaryWait)
+		}
+
+		timeout := time.NewTimer(wait)
+		defer timeout.Stop()
+		ticker := time.NewTicker(summaryPollInterval)
+		defer ticker.Stop()
+	wait:
+		for summary.State == since && !s.isStopped() {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-timeout.C:
+				break wait
+			case <-ticker.C:
+				summary = s.summary()
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		logger.Error("Encoding summary response failed", "error", err)
+	}
+}
+
+// isStopped reports whether Shutdown was called, so long polls end instead of holding it up
+func (s *Server) isStopped() bool {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+	return s.stopped
+}
+
+// GetSummary returns the compact focus state for a tray or menu-bar helper
+func (c *Client) GetSummary() (*Summary, error) {
+	return c.WaitSummary("", 0)
+}
+
+// WaitSummary returns the summary once its state differs from since, or after wait; an
+// empty since returns it right away
+func (c *Client) WaitSummary(since string, wait time.Duration) (*Summary, error) {
+	endpoint := c.baseURL + "/api/summary"
+	client := c.client
+	if since != "" {
+		endpoint += "?since=" + url.QueryEscape(since) + "&wait=" + url.QueryEscape(wait.String())
+		longPoll := *c.client
+		longPoll.Timeout += wait
+		client = &longPoll
+	}
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			// Log the error but don't return it since we're already returning
+			fmt.Printf("Warning: failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+
+	var summary Summary
+	if err := json.NewDecoder(resp.Body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("failed to decode summary: %w", err)
+	}
+	return &summary, nil
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSummaryLongPoll(t *testing.T) {
+	server := NewServer("0")
+	get := func(query string) Summary {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		server.handleGetSummary(rec, httptest.NewRequest(http.MethodGet, "/api/summary"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET /api/summary%s: status %d", query, rec.Code)
+		}
+		var summary Summary
+		if err := json.NewDecoder(rec.Body).Decode(&summary); err != nil {
+			t.Fatal(err)
+		}
+		return summary
+	}
+
+	idle := get("")
+	if idle.Focus || idle.State == "" {
+		t.Fatalf("Expected no focus and a state, got %+v", idle)
+	}
+
+	// Nothing changes, so the poll waits it out
+	start := time.Now()
+	if summary := get("?since=" + idle.State + "&wait=600ms"); summary.State != idle.State || time.Since(start) < 500*time.Millisecond {
+		t.Errorf("Expected an unchanged summary after the wait, got %+v after %s", summary, time.Since(start))
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = server.ApplyFocusMode(FocusRequest{Enabled: true, Duration: "25m"})
+	}()
+	start = time.Now()
+	summary := get("?since=" + idle.State + "&wait=10s")
+	if !summary.Focus || summary.State == idle.State || time.Since(start) > 5*time.Second {
+		t.Fatalf("Expected the poll to return the started session, got %+v after %s", summary, time.Since(start))
+	}
+	if summary.Remaining < 24*60 || summary.Remaining > 25*60 {
+		t.Errorf("Expected about 25 minutes remaining, got %ds", summary.Remaining)
+	}
+
+	server.AddQuery(DNSQuery{Domain: "news.example.com", Timestamp: time.Now(), Blocked: true})
+	if blocked := get(""); blocked.BlockedLastMinute != 1 || blocked.Blocked != 1 || blocked.State == summary.State {
+		t.Errorf("Expected the blocked query counted with a new state, got %+v", blocked)
+	}
+}
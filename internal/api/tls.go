@@ -0,0 +1,31 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// SetTLS serves the API over HTTPS with tlsConfig, which also decides whether clients
+// must present a certificate; call it before Start
+func (s *Server) SetTLS(tlsConfig *tls.Config) {
+	s.tlsConfig = tlsConfig
+}
+
+// clientTLS is the TLS configuration of every Client (see SetClientTLS)
+var clientTLS *tls.Config
+
+// SetClientTLS sets the CA and client certificate that clients made afterwards use for an
+// https API; nil keeps the system's CAs and sends no certificate
+func SetClientTLS(tlsConfig *tls.Config) {
+	clientTLS = tlsConfig
+}
+
+// clientTransport returns the transport of a new Client
+func clientTransport() http.RoundTripper {
+	if clientTLS == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = clientTLS
+	return transport
+}
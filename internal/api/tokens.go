@@ -0,0 +1,102 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Scopes of API tokens. Every route needs one of them; admin grants them all.
+const (
+	ScopeRead      = "read"      // GET routes: queries, focus state, stats, health, settings, and metrics
+	ScopeFocus     = "focus"     // Starting, ending, pausing, and scheduling focus sessions, and snoozes
+	ScopeAllowlist = "allowlist" // Reloading the allowlist and blocklist
+	ScopeExtension = "extension" // The browser extension routes: decisions for domains and one-click snoozes
+	ScopeAdmin     = "admin"     // Every route, including upstreams, the cache, cooldowns, settings, pruning, and shutdown
+)
+
+// Token is an API token, stored under its secret in SetTokens
+type Token struct {
+	Name   string
+	Scopes []string
+}
+
+// allows reports whether the token may use routes of scope
+func (t Token) allows(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+// SetTokens replaces the API tokens, keyed by secret. Without tokens the API is open to
+// everyone who reaches it.
+func (s *Server) SetTokens(tokens map[string]Token) {
+	s.tokensMutex.Lock()
+	defer s.tokensMutex.Unlock()
+	s.tokens = tokens
+}
+
+// requireScope lets a request through to next if its bearer token has scope, or when no
+// tokens are set
+func (s *Server) requireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.tokensMutex.RLock()
+		tokens := s.tokens
+		s.tokensMutex.RUnlock()
+		if len(tokens) == 0 {
+			next(w, r)
+			return
+		}
+
+		secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || secret == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sinkzone"`)
+			http.Error(w, "An API token is required", http.StatusUnauthorized)
+			return
+		}
+		token, ok := lookupToken(tokens, secret)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="sinkzone", error="invalid_token"`)
+			http.Error(w, "Invalid API token", http.StatusUnauthorized)
+			return
+		}
+		if !token.allows(scope) {
+			logger.Warn("API token lacks scope", "token", token.Name, "scope", scope, "path", r.URL.Path)
+			http.Error(w, fmt.Sprintf("The API token %s lacks the %s scope", token.Name, scope), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// lookupToken finds the token of a secret, comparing every secret in constant time
+func lookupToken(tokens map[string]Token, secret string) (Token, bool) {
+	var found Token
+	ok := false
+	for candidate, token := range tokens {
+		if subtle.ConstantTimeCompare([]byte(candidate), []byte(secret)) == 1 {
+			found, ok = token, true
+		}
+	}
+	return found, ok
+}
+
+// tokenTransport adds a bearer token to the requests of a Client
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
+
+// tokenSource returns the token NewClient sends (see SetTokenSource)
+var tokenSource func() string
+
+// SetTokenSource sets where NewClient gets its API token from; it is called once per client
+func SetTokenSource(source func() string) {
+	tokenSource = source
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireScope(t *testing.T) {
+	server := NewServer("0")
+	ok := func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) }
+	status := func(scope, secret string) int {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/focus", nil)
+		if secret != "" {
+			req.Header.Set("Authorization", "Bearer "+secret)
+		}
+		server.requireScope(scope, ok)(rec, req)
+		return rec.Code
+	}
+
+	if code := status(ScopeFocus, ""); code != http.StatusOK {
+		t.Fatalf("Expected the API to be open without tokens, got status %d", code)
+	}
+
+	server.SetTokens(map[string]Token{
+		"statusbar-secret-0001": {Name: "statusbar", Scopes: []string{ScopeRead}},
+		"admin-secret-00000001": {Name: "cli", Scopes: []string{ScopeAdmin}},
+	})
+	for _, tt := range []struct {
+		scope, secret string
+		want          int
+	}{
+		{ScopeRead, "", http.StatusUnauthorized},
+		{ScopeRead, "wrong-secret-00000001", http.StatusUnauthorized},
+		{ScopeRead, "statusbar-secret-0001", http.StatusOK},
+		{ScopeFocus, "statusbar-secret-0001", http.StatusForbidden},
+		{ScopeFocus, "admin-secret-00000001", http.StatusOK},
+		{ScopeAllowlist, "admin-secret-00000001", http.StatusOK},
+	} {
+		if code := status(tt.scope, tt.secret); code != tt.want {
+			t.Errorf("Expected status %d for scope %s with %q, got %d", tt.want, tt.scope, tt.secret, code)
+		}
+	}
+}
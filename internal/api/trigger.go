@@ -0,0 +1,144 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+)
+
+// Actions of POST /api/hooks/trigger
+const (
+	TriggerStart   = "start"   // Start a session, or restart the running one
+	TriggerStop    = "stop"    // End the running session
+	TriggerToggle  = "toggle"  // Stop a running session, or start one
+	TriggerProfile = "profile" // Switch the running session to a profile, keeping its end time, or start one with it
+)
+
+// triggerPath is the route of triggers
+const triggerPath = "/api/hooks/trigger"
+
+// TriggerSecretHeader carries the trigger secret when it isn't in the request body
+const TriggerSecretHeader = "X-Sinkzone-Secret"
+
+// TriggerRequest is the body accepted by POST /api/hooks/trigger, the endpoint for
+// automations that can't hold an API token, e.g. a Stream Deck, IFTTT, or a script on
+// another machine
+type TriggerRequest struct {
+	Secret   string `json:"secret,omitempty"` // Or the X-Sinkzone-Secret header
+	Action   string `json:"action"`
+	Duration string `json:"duration,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+	Label    string `json:"label,omitempty"`
+	PIN      string `json:"pin,omitempty"`
+}
+
+// TriggerResponse reports the focus state after a trigger
+type TriggerResponse struct {
+	Action    string         `json:"action"` // start or stop for a toggle
+	FocusMode FocusModeState `json:"focus_mode"`
+}
+
+// SetTriggerSecret sets the secret POST /api/hooks/trigger requires; "" turns the endpoint
+// off
+func (s *Server) SetTriggerSecret(secret string) {
+	s.tokensMutex.Lock()
+	defer s.tokensMutex.Unlock()
+	s.triggerSecret = secret
+}
+
+// handleTrigger starts, stops, or toggles focus mode, or switches profiles, for a request
+// with the trigger secret. The secret stands in for an API token, and for the local check
+// of mutations_local_only, as remote automations are what the endpoint is for.
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Trigger request", "remote", r.RemoteAddr)
+
+	s.tokensMutex.RLock()
+	secret := s.triggerSecret
+	s.tokensMutex.RUnlock()
+	if secret == "" {
+		http.Error(w, "The trigger endpoint is off; set trigger_secret to use it", http.StatusNotFound)
+		return
+	}
+
+	var req TriggerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	given := req.Secret
+	if header := r.Header.Get(TriggerSecretHeader); header != "" {
+		given = header
+	}
+	if subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+		logger.Warn("Refused trigger with a wrong secret", "remote", r.RemoteAddr)
+		http.Error(w, "Invalid trigger secret", http.StatusUnauthorized)
+		return
+	}
+
+	action, focus, err := s.triggerFocusRequest(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := s.ApplyFocusMode(focus); err != nil {
+		logger.Error("Trigger failed", "action", action, "error", err)
+		http.Error(w, fmt.Sprintf("Failed to update focus mode: %v", err), s.focusErrorStatus(err, http.StatusBadRequest))
+		return
+	}
+	logger.Info("Focus mode triggered", "action", action, "remote", r.RemoteAddr)
+
+	s.focusMutex.Lock()
+	state := s.focusModeState()
+	s.focusMutex.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(TriggerResponse{Action: action, FocusMode: state}); err != nil {
+		logger.Error("Encoding trigger response failed", "error", err)
+	}
+}
+
+// triggerFocusRequest turns a trigger into the focus change it makes, returning start or
+// stop for a toggle
+func (s *Server) triggerFocusRequest(req TriggerRequest) (string, FocusRequest, error) {
+	s.focusMutex.Lock()
+	s.checkFocusPauseExpiry()
+	state := s.focusModeState()
+	s.focusMutex.Unlock()
+	running := state.Enabled && (state.EndTime == nil || clock.Now().Before(*state.EndTime))
+
+	action := req.Action
+	if action == TriggerToggle {
+		action = TriggerStart
+		if running {
+			action = TriggerStop
+		}
+	}
+
+	start := FocusRequest{Enabled: true, Duration: req.Duration, Profile: req.Profile, Label: req.Label, PIN: req.PIN}
+	switch action {
+	case TriggerStart:
+		return action, start, nil
+	case TriggerStop:
+		return action, FocusRequest{PIN: req.PIN}, nil
+	case TriggerProfile:
+		if req.Profile == "" {
+			return "", FocusRequest{}, fmt.Errorf("the profile action needs a profile")
+		}
+		if running && req.Duration == "" {
+			start.Label = state.Label
+			start.Intensity = state.Intensity
+			if req.Label != "" {
+				start.Label = req.Label
+			}
+			if state.EndTime != nil {
+				start.Duration = state.EndTime.Sub(clock.Now()).Round(time.Second).String()
+			}
+		}
+		return action, start, nil
+	default:
+		return "", FocusRequest{}, fmt.Errorf("unknown action %q: use start, stop, toggle, or profile", req.Action)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrigger(t *testing.T) {
+	server := NewServer("0")
+	server.SetTokens(map[string]Token{"admin-token": {Name: "admin", Scopes: []string{ScopeAdmin}}})
+	server.SetMutationsLocalOnly(true)
+	trigger := func(secret, body string) (int, TriggerResponse) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, triggerPath, strings.NewReader(body))
+		req.RemoteAddr = "192.168.1.23:50000"
+		if secret != "" {
+			req.Header.Set(TriggerSecretHeader, secret)
+		}
+		server.localOnlyMiddleware(http.HandlerFunc(server.handleTrigger)).ServeHTTP(rec, req)
+		var resp TriggerResponse
+		_ = json.NewDecoder(rec.Body).Decode(&resp)
+		return rec.Code, resp
+	}
+
+	if code, _ := trigger("0123456789abcdef", `{"action": "start"}`); code != http.StatusNotFound {
+		t.Fatalf("Expected the endpoint off without a secret, got status %d", code)
+	}
+
+	server.SetTriggerSecret("0123456789abcdef")
+	if code, _ := trigger("wrong", `{"action": "start"}`); code != http.StatusUnauthorized {
+		t.Errorf("Expected a wrong secret refused, got status %d", code)
+	}
+	if code, _ := trigger("", `{"secret": "0123456789abcdef", "action": "dance"}`); code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown action refused, got status %d", code)
+	}
+
+	code, resp := trigger("", `{"secret": "0123456789abcdef", "action": "toggle", "duration": "1h", "label": "report"}`)
+	if code != http.StatusOK || resp.Action != TriggerStart || !resp.FocusMode.Enabled {
+		t.Fatalf("Expected a toggle to start focus mode from another machine, got %d %+v", code, resp)
+	}
+
+	code, resp = trigger("0123456789abcdef", `{"action": "profile", "profile": "deep"}`)
+	if code != http.StatusOK || resp.FocusMode.Profile != "deep" || resp.FocusMode.Label != "report" {
+		t.Fatalf("Expected the session switched to the profile, got %d %+v", code, resp)
+	}
+	if end := resp.FocusMode.EndTime; end == nil || time.Until(*end) < 59*time.Minute {
+		t.Errorf("Expected the profile switch to keep the end time, got %v", end)
+	}
+
+	code, resp = trigger("0123456789abcdef", `{"action": "toggle"}`)
+	if code != http.StatusOK || resp.Action != TriggerStop || resp.FocusMode.Enabled {
+		t.Errorf("Expected a toggle to stop focus mode, got %d %+v", code, resp)
+	}
+}
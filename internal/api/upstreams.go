@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// UpstreamsRequest is the body accepted by PUT /api/config/upstreams, and its response
+type UpstreamsRequest struct {
+	Upstreams []string `json:"upstreams"` // Nameservers in the order they are tried
+}
+
+// SetUpstreamsCallback registers the function that saves and applies the upstream
+// nameservers on PUT /api/config/upstreams, returning the list now in use
+func (s *Server) SetUpstreamsCallback(callback func(upstreams []string) ([]string, error)) {
+	s.onSetUpstreams = callback
+}
+
+func (s *Server) handleSetUpstreams(w http.ResponseWriter, r *http.Request) {
+	logger.Debug("Set upstreams request", "remote", r.RemoteAddr)
+
+	if s.onSetUpstreams == nil {
+		http.Error(w, "Changing the upstreams is not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req UpstreamsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Upstreams) == 0 {
+		http.Error(w, "At least one upstream nameserver is required", http.StatusBadRequest)
+		return
+	}
+
+	upstreams, err := s.onSetUpstreams(req.Upstreams)
+	if err != nil {
+		logger.Error("Setting upstreams failed", "error", err)
+		http.Error(w, fmt.Sprintf("Failed to set upstreams: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(UpstreamsRequest{Upstreams: upstreams}); err != nil {
+		logger.Error("Encoding upstreams response failed", "error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSetUpstreams(t *testing.T) {
+	server := NewServer("0")
+	w := httptest.NewRecorder()
+	server.handleSetUpstreams(w, httptest.NewRequest(http.MethodPut, "/api/config/upstreams", strings.NewReader(`{"upstreams": ["9.9.9.9"]}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 without a callback, got %d", w.Code)
+	}
+
+	current := []string{"8.8.8.8"}
+	server.SetUpstreamsCallback(func(upstreams []string) ([]string, error) {
+		for _, upstream := range upstreams {
+			if upstream == "bogus" {
+				return nil, fmt.Errorf("invalid upstream nameserver: %s", upstream)
+			}
+		}
+		current = upstreams
+		return current, nil
+	})
+
+	cases := []struct {
+		body   string
+		status int
+	}{
+		{`{"upstreams": []}`, http.StatusBadRequest},
+		{`{"upstreams": "9.9.9.9"}`, http.StatusBadRequest},
+		{`{"upstreams": ["9.9.9.9", "bogus"]}`, http.StatusBadRequest},
+		{`{"upstreams": ["9.9.9.9", "1.1.1.1"]}`, http.StatusOK},
+	}
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		server.handleSetUpstreams(w, httptest.NewRequest(http.MethodPut, "/api/config/upstreams", strings.NewReader(c.body)))
+		if w.Code != c.status {
+			t.Errorf("expected %s to return %d, got %d: %s", c.body, c.status, w.Code, w.Body)
+			continue
+		}
+		if w.Code != http.StatusOK {
+			continue
+		}
+		var resp UpstreamsRequest
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(resp.Upstreams, current) {
+			t.Errorf("expected the response to list %v, got %v", current, resp.Upstreams)
+		}
+	}
+	if !slices.Equal(current, []string{"9.9.9.9", "1.1.1.1"}) {
+		t.Errorf("expected only the valid list to be applied, got %v", current)
+	}
+}
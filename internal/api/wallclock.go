@@ -0,0 +1,28 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseWallClock parses a local wall-clock time: "15:04" (today, or tomorrow if already
+// past), "2006-01-02T15:04", or RFC 3339. Times are built with time.Date rather than by
+// adding hours, so they stay on the requested wall-clock time across DST changes.
+func ParseWallClock(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("15:04", value, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use HH:MM or YYYY-MM-DDTHH:MM", value)
+	}
+	at := time.Date(now.Year(), now.Month(), now.Day(), t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !at.After(now) {
+		at = time.Date(now.Year(), now.Month(), now.Day()+1, t.Hour(), t.Minute(), 0, 0, now.Location())
+	}
+	return at, nil
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWallClock(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone data not available: %v", err)
+	}
+
+	// The clocks go forward at 02:00 on 2025-03-30, so that day only has 23 hours
+	now := time.Date(2025, 3, 29, 18, 0, 0, 0, loc)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"20:30", 2*time.Hour + 30*time.Minute},  // Later today
+		{"17:30", 22*time.Hour + 30*time.Minute}, // Tomorrow, across the DST change
+		{"2025-03-30T09:00", 14 * time.Hour},
+	}
+
+	for _, test := range tests {
+		at, err := ParseWallClock(test.value, now)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", test.value, err)
+			continue
+		}
+		if got := at.Sub(now); got != test.expected {
+			t.Errorf("%q: expected %v until then, got %v", test.value, test.expected, got)
+		}
+	}
+
+	if _, err := ParseWallClock("tomorrow", now); err == nil {
+		t.Error("Expected an error for an invalid time")
+	}
+}
@@ -0,0 +1,232 @@
+// Package backup saves sinkzone's config, allowlist, blocklist, and focus history to a
+// .tar.gz archive and restores them.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/blocklist"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/version"
+)
+
+// FormatVersion is the archive layout this build writes. Archives with a newer layout are
+// refused; older ones are read by Restore as they were written.
+const FormatVersion = 1
+
+// manifestName is the archive entry describing the archive, written first
+const manifestName = "manifest.json"
+
+// maxEntrySize bounds each file read from an archive
+const maxEntrySize = 64 << 20
+
+// Files kept in an archive, by their name in it
+const (
+	configFile    = "sinkzone.yaml"
+	allowlistFile = "allowlist.txt"
+	blocklistFile = "blocklist.txt"
+	stateFile     = "state.json"
+)
+
+// Manifest describes an archive
+type Manifest struct {
+	Format        int       `json:"format"`           // Archive layout (see FormatVersion)
+	Version       string    `json:"sinkzone_version"` // sinkzone release that wrote the archive
+	ConfigVersion int       `json:"config_version"`   // Schema of the archived sinkzone.yaml
+	Created       time.Time `json:"created"`
+	Files         []string  `json:"files"`
+}
+
+// paths returns where each archived file lives on this machine
+func paths() map[string]string {
+	dataDir := config.GetDataDir()
+	return map[string]string{
+		configFile:    config.GetConfigPath(),
+		allowlistFile: filepath.Join(dataDir, allowlistFile),
+		blocklistFile: blocklist.GetPath(),
+		stateFile:     filepath.Join(dataDir, stateFile),
+	}
+}
+
+// Create writes an archive of the config file, the allowlist and blocklist, and the state
+// file with the focus history. Files that don't exist yet are left out.
+func Create(w io.Writer) (*Manifest, error) {
+	manifest := &Manifest{Format: FormatVersion, Version: version.Get().Version, Created: time.Now()}
+	contents := map[string][]byte{}
+	locations := paths()
+	for _, name := range []string{configFile, allowlistFile, blocklistFile, stateFile} {
+		// #nosec G304 -- the paths are sinkzone's own files
+		data, err := os.ReadFile(locations[name])
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		contents[name] = data
+		manifest.Files = append(manifest.Files, name)
+	}
+	if data, ok := contents[configFile]; ok {
+		configVersion, err := config.FileVersion(data)
+		if err != nil {
+			return nil, err
+		}
+		manifest.ConfigVersion = configVersion
+	}
+
+	encoded, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, manifestName, encoded, manifest.Created); err != nil {
+		return nil, err
+	}
+	for _, name := range manifest.Files {
+		if err := writeEntry(tw, name, contents[name], manifest.Created); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write backup: %w", err)
+	}
+	return manifest, nil
+}
+
+func writeEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+	return nil
+}
+
+// Read returns the manifest and files of an archive after checking that this sinkzone can
+// restore them
+func Read(r io.Reader) (*Manifest, map[string][]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("not a sinkzone backup: %w", err)
+	}
+	defer func() { _ = gz.Close() }()
+
+	contents := map[string][]byte{}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || header.Size > maxEntrySize {
+			return nil, nil, fmt.Errorf("invalid backup entry %s", header.Name)
+		}
+		data, err := io.ReadAll(io.LimitReader(tr, maxEntrySize))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+		}
+		contents[header.Name] = data
+	}
+
+	data, ok := contents[manifestName]
+	if !ok {
+		return nil, nil, fmt.Errorf("not a sinkzone backup: %s is missing", manifestName)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	if manifest.Format > FormatVersion {
+		return nil, nil, fmt.Errorf("backup format %d is newer than this sinkzone supports (%d): upgrade sinkzone", manifest.Format, FormatVersion)
+	}
+
+	files := map[string][]byte{}
+	for _, name := range manifest.Files {
+		data, ok := contents[name]
+		if _, known := paths()[name]; !ok || !known {
+			return nil, nil, fmt.Errorf("invalid backup: %s is listed but not restorable", name)
+		}
+		files[name] = data
+	}
+	if data, ok := files[configFile]; ok {
+		if _, err := config.FileVersion(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	if data, ok := files[stateFile]; ok {
+		if err := json.Unmarshal(data, &config.State{}); err != nil {
+			return nil, nil, fmt.Errorf("invalid %s in backup: %w", stateFile, err)
+		}
+	}
+	return &manifest, files, nil
+}
+
+// Restore replaces sinkzone's files with those in an archive. A config file from an older
+// sinkzone is upgraded to the current schema; from the state file only the focus history
+// and queued sessions are restored, so a running session isn't changed.
+func Restore(r io.Reader) (*Manifest, error) {
+	manifest, files, err := Read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := paths()
+	for _, name := range manifest.Files {
+		if name == stateFile {
+			continue
+		}
+		path := locations[name]
+		if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", name, err)
+		}
+		if err := os.WriteFile(path, files[name], 0600); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", name, err)
+		}
+		if name == allowlistFile {
+			if err := config.WriteChecksum(path, files[name]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if data, ok := files[stateFile]; ok {
+		var saved config.State
+		if err := json.Unmarshal(data, &saved); err != nil {
+			return nil, fmt.Errorf("invalid %s in backup: %w", stateFile, err)
+		}
+		stateManager, err := config.NewStateManager()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open state: %w", err)
+		}
+		if err := stateManager.RestoreHistory(saved); err != nil {
+			return nil, fmt.Errorf("failed to restore focus history: %w", err)
+		}
+	}
+
+	// Upgrade a config written by an older sinkzone, keeping the original as a .bak file
+	if _, ok := files[configFile]; ok {
+		if _, err := config.Load(); err != nil {
+			return manifest, fmt.Errorf("restored %s can't be loaded: %w", configFile, err)
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,99 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestCreateAndRestore(t *testing.T) {
+	t.Setenv(config.SystemConfigEnv, "none")
+	source := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, source)
+
+	// A config from before versioning, upgraded on restore
+	files := map[string]string{
+		"sinkzone.yaml": "upstream_nameservers:\n  - 9.9.9.9\nprofiles:\n  work:\n    duration: 90m\n",
+		"allowlist.txt": "github.com\n",
+		"state.json":    `{"focus_mode": false, "daily_focus_seconds": {"2026-03-02": 3600}}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(source, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var archive bytes.Buffer
+	manifest, err := Create(&archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(manifest.Files, ","); got != "sinkzone.yaml,allowlist.txt,state.json" {
+		t.Errorf("expected the existing files to be backed up, got %s", got)
+	}
+	if manifest.ConfigVersion != 0 || manifest.Format != FormatVersion {
+		t.Errorf("unexpected manifest %+v", manifest)
+	}
+
+	target := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, target)
+	if _, err := Restore(bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(target, "allowlist.txt")); string(data) != files["allowlist.txt"] {
+		t.Errorf("expected the allowlist to be restored, got %q", data)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Version != config.CurrentConfigVersion || cfg.UpstreamNameservers[0] != "9.9.9.9" {
+		t.Errorf("expected the restored config to be upgraded, got %+v", cfg)
+	}
+	if _, err := cfg.GetProfile("work"); err != nil {
+		t.Errorf("expected the profile to be restored: %v", err)
+	}
+	sm, err := config.NewStateManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seconds := sm.GetState().DailyFocus["2026-03-02"]; seconds != 3600 {
+		t.Errorf("expected the focus history to be restored, got %d", seconds)
+	}
+}
+
+func TestReadRejectsNewerFormats(t *testing.T) {
+	t.Setenv(config.SystemConfigEnv, "none")
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+
+	var archive bytes.Buffer
+	if _, err := Create(&archive); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Read(bytes.NewReader(archive.Bytes())); err != nil {
+		t.Fatalf("expected an empty backup to be readable: %v", err)
+	}
+	if _, _, err := Read(strings.NewReader("not an archive")); err == nil {
+		t.Error("expected a file that isn't a backup to be rejected")
+	}
+
+	var newer bytes.Buffer
+	gz := gzip.NewWriter(&newer)
+	tw := tar.NewWriter(gz)
+	if err := writeEntry(tw, manifestName, []byte(`{"format": 99}`), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	if _, _, err := Read(&newer); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a backup from a newer sinkzone to be refused, got %v", err)
+	}
+}
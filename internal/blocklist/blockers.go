@@ -0,0 +1,171 @@
+package blocklist
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// coldTurkeyBlock is a block of Cold Turkey Blocker: what its block export holds, and each
+// of the blocks of its settings export
+type coldTurkeyBlock struct {
+	Web  []string `json:"web"`
+	Data *struct {
+		Web []string `json:"web"`
+	} `json:"data"`
+}
+
+// isColdTurkey reports whether JSON data is a Cold Turkey export rather than a Pi-hole one
+func isColdTurkey(data []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return false
+	}
+	_, web := fields["web"]
+	_, blocks := fields["blocks"]
+	return web || blocks
+}
+
+// parseColdTurkey reads the websites of a Cold Turkey Blocker export: one block, or the
+// settings export with all of them. Exceptions, apps, and title or keyword rules aren't
+// imported.
+func parseColdTurkey(data []byte) ([]string, int, error) {
+	var export struct {
+		coldTurkeyBlock
+		Blocks map[string]coldTurkeyBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Cold Turkey export: %w", err)
+	}
+
+	var entries []string
+	blocks := append([]coldTurkeyBlock{export.coldTurkeyBlock}, mapValues(export.Blocks)...)
+	for _, block := range blocks {
+		entries = append(entries, block.Web...)
+		if block.Data != nil {
+			entries = append(entries, block.Data.Web...)
+		}
+	}
+	domains, skipped := siteEntries(entries)
+	return domains, skipped, nil
+}
+
+// mapValues returns the values of a map sorted by key, so imports keep a stable order
+func mapValues(m map[string]coldTurkeyBlock) []coldTurkeyBlock {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	values := make([]coldTurkeyBlock, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, m[key])
+	}
+	return values
+}
+
+// freedomColumns are the headers of the column holding the sites in a Freedom blocklist
+// export, and in similar CSV exports
+var freedomColumns = []string{"url", "website", "site", "domain"}
+
+// isFreedomCSV reports whether a list starts with a CSV header naming a site column
+func isFreedomCSV(reader *bufio.Reader) bool {
+	peek, _ := reader.Peek(512)
+	header, _, _ := strings.Cut(string(peek), "\n")
+	if !strings.Contains(header, ",") {
+		return false
+	}
+	return freedomColumn(strings.Split(header, ",")) >= 0
+}
+
+// freedomColumn returns the index of the site column of a CSV header, or -1
+func freedomColumn(header []string) int {
+	for i, name := range header {
+		name = strings.ToLower(strings.Trim(strings.TrimSpace(name), `"`))
+		if slices.Contains(freedomColumns, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseFreedomCSV reads the sites of a Freedom blocklist exported as CSV
+func parseFreedomCSV(r io.Reader) ([]string, int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Freedom export: %w", err)
+	}
+	column := freedomColumn(header)
+
+	var entries []string
+	skipped := 0
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to parse Freedom export: %w", err)
+		}
+		if column >= len(record) {
+			skipped++
+			continue
+		}
+		entries = append(entries, record[column])
+	}
+	domains, invalid := siteEntries(entries)
+	return domains, skipped + invalid, nil
+}
+
+// siteEntries turns the websites of a blocker, which block their subdomains too, into
+// blocklist entries: example.com becomes example.com and *.example.com. Rules for a path,
+// which DNS can't block alone, and anything else that isn't a site are skipped and counted.
+func siteEntries(entries []string) ([]string, int) {
+	var domains []string
+	skipped := 0
+	seen := make(map[string]bool)
+	add := func(domain string) {
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	for _, entry := range entries {
+		host, ok := siteHost(entry)
+		if !ok {
+			skipped++
+			continue
+		}
+		domain, ok := normalize(host)
+		if !ok {
+			skipped++
+			continue
+		}
+		add(domain)
+		if !strings.Contains(domain, "*") {
+			add("*." + domain)
+		}
+	}
+	return domains, skipped
+}
+
+// siteHost returns the host of a website entry such as example.com, *.example.com, or
+// https://www.example.com/, refusing entries limited to a path
+func siteHost(entry string) (string, bool) {
+	entry = strings.TrimSpace(entry)
+	if _, rest, ok := strings.Cut(entry, "://"); ok {
+		entry = rest
+	}
+	host, path, _ := strings.Cut(entry, "/")
+	if strings.Trim(path, "/*") != "" || strings.ContainsAny(host, "?#:") || host == "" {
+		return "", false
+	}
+	return host, true
+}
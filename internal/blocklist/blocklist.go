@@ -0,0 +1,148 @@
+// Package blocklist manages the domains blocked during every focus session, whatever the
+// intensity and even when they match the allowlist, and reads domain lists in the formats
+// of hosts files, Adblock, Pi-hole, Cold Turkey, and Freedom. Subscribed lists are kept by package subscription.
+package blocklist
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// GetPath returns the path of the blocklist file in the data directory
+func GetPath() string {
+	return filepath.Join(config.GetDataDir(), "blocklist.txt")
+}
+
+// NewManager creates a manager for the blocklist file, which has the allowlist's format
+func NewManager() *allowlist.Manager {
+	return allowlist.NewListManager(GetPath(), "blocklist")
+}
+
+// Parse reads a domain list in any of the common formats: one domain or URL per line, a
+// hosts file ("0.0.0.0 example.com"), Adblock Plus rules ("||example.com^"), a Pi-hole
+// domain export (JSON), or the export of a Cold Turkey block (JSON) or Freedom blocklist
+// (CSV). Comments, localhost entries, and entries that aren't a plain domain are skipped
+// and counted.
+func Parse(r io.Reader) (domains []string, skipped int, err error) {
+	reader := bufio.NewReader(r)
+	if isJSON(reader) {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read list: %w", err)
+		}
+		if isColdTurkey(data) {
+			return parseColdTurkey(data)
+		}
+		return parsePihole(bytes.NewReader(data))
+	}
+	if isFreedomCSV(reader) {
+		return parseFreedomCSV(reader)
+	}
+
+	seen := make(map[string]bool)
+	add := func(domain string) {
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		if line == "" || strings.HasPrefix(line, "!") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		names, ok := parseLine(line)
+		if !ok {
+			skipped++
+			continue
+		}
+		for _, name := range names {
+			if domain, ok := normalize(name); ok {
+				add(domain)
+			} else {
+				skipped++
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read list: %w", err)
+	}
+	return domains, skipped, nil
+}
+
+// parseLine extracts the names of one list entry, which a hosts file line may have several of
+func parseLine(line string) ([]string, bool) {
+	if strings.HasPrefix(line, "||") {
+		// Adblock rule: only plain domain blocks, not paths or rules with options
+		rule := strings.TrimPrefix(line, "||")
+		if !strings.HasSuffix(rule, "^") {
+			return nil, false
+		}
+		return []string{strings.TrimSuffix(rule, "^")}, true
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 1 {
+		if strings.Contains(line, "://") {
+			host, ok := siteHost(line)
+			return []string{host}, ok
+		}
+		return fields, true
+	}
+	// Hosts file: the address, such as 0.0.0.0, 127.0.0.1, or ::, then one or more names
+	if net.ParseIP(fields[0]) == nil {
+		return nil, false
+	}
+	return fields[1:], true
+}
+
+// normalize lowercases a listed name, rejecting localhost entries, addresses, and anything
+// that isn't a valid list entry
+func normalize(name string) (string, bool) {
+	domain := strings.TrimSuffix(strings.ToLower(name), ".")
+	switch domain {
+	case "localhost", "localhost.localdomain", "local", "broadcasthost", "ip6-localhost", "ip6-loopback":
+		return "", false
+	}
+	if net.ParseIP(domain) != nil || allowlist.ValidatePattern(domain) != nil {
+		return "", false
+	}
+	return domain, true
+}
+
+// Dedupe returns the domains that the existing entries, exact or wildcard, don't already
+// block
+func Dedupe(domains, existing []string) []string {
+	exact := make(map[string]bool, len(existing))
+	var wildcards []string
+	for _, entry := range existing {
+		if strings.Contains(entry, "*") {
+			wildcards = append(wildcards, entry)
+		} else {
+			exact[entry] = true
+		}
+	}
+
+	var fresh []string
+	for _, domain := range domains {
+		if exact[domain] || slices.ContainsFunc(wildcards, func(pattern string) bool { return allowlist.MatchPattern(pattern, domain) }) {
+			continue
+		}
+		fresh = append(fresh, domain)
+	}
+	return fresh
+}
@@ -0,0 +1,135 @@
+package blocklist
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	list := `# Hosts file
+127.0.0.1 localhost
+0.0.0.0 ads.example.com
+0.0.0.0 Tracker.Example.com. # trailing comment
+::1 ip6-localhost
+! Adblock
+||social.example.org^
+||example.net/path^
+||ads.example.com^
+plain.example.io
+not a domain at all
+0.0.0.0 0.0.0.0
+`
+	domains, skipped, err := Parse(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ads.example.com", "tracker.example.com", "social.example.org", "plain.example.io"}
+	if !slices.Equal(domains, want) {
+		t.Errorf("expected %v, got %v", want, domains)
+	}
+	if skipped != 5 {
+		t.Errorf("expected 5 skipped lines, got %d", skipped)
+	}
+}
+
+func TestParseHostsWithSeveralNames(t *testing.T) {
+	domains, skipped, err := Parse(strings.NewReader("0.0.0.0\tads.example.com  tracker.example.com localhost\n:: ipv6.example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ads.example.com", "tracker.example.com", "ipv6.example.com"}
+	if !slices.Equal(domains, want) || skipped != 1 {
+		t.Errorf("expected %v and 1 skipped, got %v and %d", want, domains, skipped)
+	}
+}
+
+func TestParsePihole(t *testing.T) {
+	// Pi-hole v5 teleporter: blacklist.exact.json and blacklist.regex.json
+	v5 := `[{"id":1,"type":1,"domain":"ads.example.com","enabled":1,"comment":"x"},
+		{"id":2,"type":1,"domain":"off.example.com","enabled":0},
+		{"id":3,"type":3,"domain":"(^|\\.)tracker\\.","enabled":1},
+		{"id":4,"type":0,"domain":"allowed.example.com","enabled":1}]`
+	domains, skipped, err := Parse(strings.NewReader(v5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ads.example.com"}; !slices.Equal(domains, want) || skipped != 3 {
+		t.Errorf("expected %v and 3 skipped, got %v and %d", want, domains, skipped)
+	}
+
+	// Pi-hole v6 API: GET /api/domains
+	v6 := `{"domains":[{"domain":"Ads.Example.org","type":"deny","kind":"exact","enabled":true},
+		{"domain":"allowed.example.org","type":"allow","kind":"exact","enabled":true},
+		{"domain":"ads\\.","type":"deny","kind":"regex","enabled":true}],"took":0.001}`
+	domains, skipped, err = Parse(strings.NewReader(v6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ads.example.org"}; !slices.Equal(domains, want) || skipped != 2 {
+		t.Errorf("expected %v and 2 skipped, got %v and %d", want, domains, skipped)
+	}
+
+	// An Adblock header isn't JSON
+	domains, _, err = Parse(strings.NewReader("[Adblock Plus 2.0]\n||ads.example.net^\n"))
+	if err != nil || !slices.Equal(domains, []string{"ads.example.net"}) {
+		t.Errorf("expected the Adblock list to parse, got %v (%v)", domains, err)
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	existing := []string{"ads.example.com", "*.tracker.net", "*metrics*"}
+	domains := []string{"ads.example.com", "a.tracker.net", "tracker.net", "app-metrics.io", "new.example.com"}
+	want := []string{"tracker.net", "new.example.com"}
+	if got := Dedupe(domains, existing); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestWriteHosts(t *testing.T) {
+	var b strings.Builder
+	written, wildcards, err := WriteHosts(&b, []string{"b.example.com", "*.tracker.net", "A.example.com", "b.example.com"}, "0.0.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written != 2 || wildcards != 1 {
+		t.Errorf("expected 2 domains and 1 wildcard, got %d and %d", written, wildcards)
+	}
+	if !strings.HasSuffix(b.String(), "\n0.0.0.0 a.example.com\n0.0.0.0 b.example.com\n") {
+		t.Errorf("unexpected hosts file:\n%s", b.String())
+	}
+
+	// The export reads back as the same domains
+	domains, _, err := Parse(strings.NewReader(b.String()))
+	if err != nil || !slices.Equal(domains, []string{"a.example.com", "b.example.com"}) {
+		t.Errorf("expected the export to parse, got %v (%v)", domains, err)
+	}
+}
+
+func TestParseColdTurkeyExport(t *testing.T) {
+	for name, export := range map[string]string{
+		"block":    `{"web": ["Reddit.com", "*.youtube.com", "news.ycombinator.com/item", "title:Netflix"], "exceptions": ["docs.google.com"], "apps": ["steam.exe"]}`,
+		"settings": `{"blocks": {"Distractions": {"enabled": "true", "data": {"web": ["reddit.com", "*.youtube.com", "https://www.reddit.com/r/all", "title:Netflix"]}}}}`,
+	} {
+		domains, skipped, err := Parse(strings.NewReader(export))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"reddit.com", "*.reddit.com", "*.youtube.com"}
+		if !slices.Equal(domains, want) || skipped != 2 {
+			t.Errorf("%s: expected %v with 2 skipped, got %v with %d", name, want, domains, skipped)
+		}
+	}
+}
+
+func TestParseFreedomCSV(t *testing.T) {
+	export := "Name,URL\nSocial,facebook.com\nSocial,https://twitter.com/\nNews,https://www.nytimes.com/section/world\nNews\n"
+	domains, skipped, err := Parse(strings.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"facebook.com", "*.facebook.com", "twitter.com", "*.twitter.com"}
+	if !slices.Equal(domains, want) || skipped != 2 {
+		t.Errorf("expected %v with 2 skipped, got %v with %d", want, domains, skipped)
+	}
+}
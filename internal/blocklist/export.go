@@ -0,0 +1,64 @@
+package blocklist
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+)
+
+// Entries returns every entry the resolver blocks with: the blocklist file and the cached
+// subscribed lists at paths, leaving out subscribed entries that the allowed entries (the
+// local allowlist) override
+func Entries(paths, allowed []string) ([]string, error) {
+	entries, err := NewManager().List()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		cached, err := allowlist.NewListManager(path, "blocklist cache").List()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, Dedupe(cached, allowed)...)
+	}
+	return entries, nil
+}
+
+// WriteHosts writes the entries as a hosts file that maps each domain to address, sorted
+// and without duplicates. Wildcard entries can't be expressed in a hosts file; they are
+// left out and counted.
+func WriteHosts(w io.Writer, entries []string, address string) (written, wildcards int, err error) {
+	seen := make(map[string]bool, len(entries))
+	domains := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if strings.Contains(entry, "*") {
+			wildcards++
+			continue
+		}
+		domain := strings.ToLower(entry)
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	slices.Sort(domains)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Blocked domains exported by sinkzone on %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(&b, "# %d domains", len(domains))
+	if wildcards > 0 {
+		fmt.Fprintf(&b, "; %d wildcard entries left out", wildcards)
+	}
+	b.WriteString("\n\n")
+	for _, domain := range domains {
+		b.WriteString(address + " " + domain + "\n")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return 0, 0, fmt.Errorf("failed to write hosts file: %w", err)
+	}
+	return len(domains), wildcards, nil
+}
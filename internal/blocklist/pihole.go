@@ -0,0 +1,106 @@
+package blocklist
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// piholeDomain is an entry of a Pi-hole domain list: the blacklist.exact.json and
+// blacklist.regex.json of a Pi-hole v5 teleporter backup, or the domains of GET
+// /api/domains in Pi-hole v6
+type piholeDomain struct {
+	Domain  string `json:"domain"`
+	Type    any    `json:"type"`    // v5: 1 exact deny, 3 regex deny (0 and 2 allow); v6: "deny" or "allow"
+	Kind    string `json:"kind"`    // v6: "exact" or "regex"
+	Enabled any    `json:"enabled"` // v5: 0 or 1; v6: true or false
+}
+
+// isJSON reports whether a list is JSON rather than lines of text; an Adblock header such
+// as "[Adblock Plus 2.0]" also starts with a bracket
+func isJSON(reader *bufio.Reader) bool {
+	for n := 1; n <= 64; n++ {
+		peek, err := reader.Peek(n)
+		if len(peek) < n {
+			return false
+		}
+		text := strings.TrimLeftFunc(string(peek), unicode.IsSpace)
+		switch {
+		case text == "":
+			if err != nil {
+				return false
+			}
+			continue
+		case text[0] == '{':
+			return true
+		case text[0] != '[':
+			return false
+		}
+		if rest := strings.TrimLeftFunc(text[1:], unicode.IsSpace); rest != "" {
+			return rest[0] == '{' || rest[0] == ']'
+		}
+	}
+	return false
+}
+
+// parsePihole reads a Pi-hole domain export, keeping enabled exact deny entries; allow
+// entries, regex entries, and disabled ones are skipped and counted
+func parsePihole(r io.Reader) ([]string, int, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read list: %w", err)
+	}
+	var entries []piholeDomain
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		var response struct {
+			Domains []piholeDomain `json:"domains"`
+		}
+		if err := json.Unmarshal(data, &response); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse Pi-hole export: %w", err)
+		}
+		entries = response.Domains
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse Pi-hole export: %w", err)
+	}
+
+	var domains []string
+	skipped := 0
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		domain, ok := normalize(strings.TrimSpace(entry.Domain))
+		if !ok || !entry.blocks() {
+			skipped++
+			continue
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains, skipped, nil
+}
+
+// blocks reports whether the entry is an enabled exact deny rule; entries without a type
+// or enabled field, such as a plain list of domains, count as one
+func (d piholeDomain) blocks() bool {
+	switch enabled := d.Enabled.(type) {
+	case bool:
+		if !enabled {
+			return false
+		}
+	case float64:
+		if enabled == 0 {
+			return false
+		}
+	}
+	switch kind := d.Type.(type) {
+	case float64:
+		return kind == 1
+	case string:
+		return kind == "deny" && d.Kind != "regex"
+	}
+	return d.Kind != "regex"
+}
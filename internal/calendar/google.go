@@ -0,0 +1,441 @@
+package calendar
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Google endpoints, variables so tests can point them at a local server
+var (
+	googleAuthURL  = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL = "https://oauth2.googleapis.com/token"
+	googleAPIURL   = "https://www.googleapis.com/calendar/v3"
+)
+
+// googleScope only lets sinkzone read calendars
+const googleScope = "https://www.googleapis.com/auth/calendar.readonly"
+
+// ErrNotLoggedIn is returned while there is no Google Calendar token
+var ErrNotLoggedIn = errors.New("not logged in to Google Calendar; run 'sinkzone calendar login'")
+
+// googleToken is the token kept in the data directory
+type googleToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// tokenResponse is Google's answer to a code exchange or a refresh
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	RefreshToken     string `json:"refresh_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// GoogleCalendar is a calendar of the logged-in Google account
+type GoogleCalendar struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// GoogleClient reads events through the Google Calendar API, refreshing its token as needed
+type GoogleClient struct {
+	cfg       *config.GoogleCalendarConfig
+	tokenPath string
+	client    *http.Client
+	token     *googleToken
+}
+
+// NewGoogleClient creates a client using the token of 'sinkzone calendar login'
+func NewGoogleClient(cfg *config.GoogleCalendarConfig) (*GoogleClient, error) {
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("google_calendar client_id is required")
+	}
+	return &GoogleClient{
+		cfg:       cfg,
+		tokenPath: config.GetGoogleTokenPath(),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Login asks for access to the calendars in the browser and keeps the token. The browser
+// is sent back to a listener on this machine, so it must run where the browser does.
+func (c *GoogleClient) Login(ctx context.Context, open func(authURL string)) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen for the login: %w", err)
+	}
+	redirect := "http://" + listener.Addr().String()
+
+	verifier, err := randomString()
+	if err != nil {
+		return err
+	}
+	state, err := randomString()
+	if err != nil {
+		return err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	authURL := googleAuthURL + "?" + url.Values{
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {redirect},
+		"response_type":         {"code"},
+		"scope":                 {googleScope},
+		"access_type":           {"offline"},
+		"prompt":                {"consent"}, // Google only hands out a refresh token with consent
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	codes := make(chan string, 1)
+	server := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			if query.Get("state") != state {
+				http.Error(w, "Unknown login", http.StatusBadRequest)
+				return
+			}
+			if reason := query.Get("error"); reason != "" {
+				http.Error(w, "Login failed: "+reason, http.StatusForbidden)
+				codes <- ""
+				return
+			}
+			_, _ = io.WriteString(w, "sinkzone can now read your calendars. You can close this window.")
+			codes <- query.Get("code")
+		}),
+	}
+	go func() { _ = server.Serve(listener) }()
+	defer func() { _ = server.Close() }()
+
+	open(authURL)
+
+	var code string
+	select {
+	case code = <-codes:
+	case <-ctx.Done():
+		return fmt.Errorf("login timed out: %w", ctx.Err())
+	}
+	if code == "" {
+		return fmt.Errorf("access to the calendars was denied")
+	}
+
+	token, err := c.requestToken(url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirect},
+		"code_verifier": {verifier},
+	}, "")
+	if err != nil {
+		return err
+	}
+	if token.RefreshToken == "" {
+		return fmt.Errorf("google did not grant offline access; remove sinkzone's access in your Google account and log in again")
+	}
+	return c.saveToken(token)
+}
+
+// Logout deletes the kept token
+func (c *GoogleClient) Logout() error {
+	if err := os.Remove(c.tokenPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete the Google Calendar token: %w", err)
+	}
+	return nil
+}
+
+// Calendars lists the calendars of the account
+func (c *GoogleClient) Calendars() ([]GoogleCalendar, error) {
+	var list struct {
+		Items []struct {
+			ID              string `json:"id"`
+			Summary         string `json:"summary"`
+			SummaryOverride string `json:"summaryOverride"`
+			Primary         bool   `json:"primary"`
+		} `json:"items"`
+	}
+	if err := c.get("/users/me/calendarList", url.Values{"maxResults": {"250"}}, &list); err != nil {
+		return nil, err
+	}
+
+	calendars := make([]GoogleCalendar, 0, len(list.Items))
+	for _, item := range list.Items {
+		name := item.Summary
+		if item.SummaryOverride != "" {
+			name = item.SummaryOverride
+		}
+		calendars = append(calendars, GoogleCalendar{ID: item.ID, Name: name, Primary: item.Primary})
+	}
+	return calendars, nil
+}
+
+// Events returns the events of the configured calendars running between from and to,
+// recurring events expanded into their occurrences
+func (c *GoogleClient) Events(from, to time.Time) ([]Event, error) {
+	ids, err := c.calendarIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	for _, id := range ids {
+		var page struct {
+			Items []googleEvent `json:"items"`
+		}
+		query := url.Values{
+			"timeMin":      {from.Format(time.RFC3339)},
+			"timeMax":      {to.Format(time.RFC3339)},
+			"singleEvents": {"true"},
+			"maxResults":   {"250"},
+		}
+		if err := c.get("/calendars/"+url.PathEscape(id)+"/events", query, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page.Items {
+			if event, ok := item.event(); ok {
+				events = append(events, event)
+			}
+		}
+	}
+	return events, nil
+}
+
+// calendarIDs resolves the configured calendar names
+func (c *GoogleClient) calendarIDs() ([]string, error) {
+	if len(c.cfg.Calendars) == 0 {
+		return []string{"primary"}, nil
+	}
+	calendars, err := c.Calendars()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(c.cfg.Calendars))
+	for _, name := range c.cfg.Calendars {
+		found := false
+		for _, calendar := range calendars {
+			if strings.EqualFold(calendar.Name, name) || calendar.ID == name {
+				ids = append(ids, calendar.ID)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no Google calendar named %q; run 'sinkzone calendar list' to see them", name)
+		}
+	}
+	return ids, nil
+}
+
+// googleEvent is the part of a Google Calendar event sinkzone uses
+type googleEvent struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	Summary      string `json:"summary"`
+	Description  string `json:"description"`
+	Transparency string `json:"transparency"`
+	EventType    string `json:"eventType"`
+	Start        struct {
+		DateTime time.Time `json:"dateTime"`
+		Date     string    `json:"date"`
+	} `json:"start"`
+	End struct {
+		DateTime time.Time `json:"dateTime"`
+		Date     string    `json:"date"`
+	} `json:"end"`
+	Attendees []struct {
+		Self           bool   `json:"self"`
+		ResponseStatus string `json:"responseStatus"`
+	} `json:"attendees"`
+}
+
+// event converts a Google Calendar event, leaving out cancelled and declined ones
+func (g googleEvent) event() (Event, bool) {
+	if g.Status == "cancelled" {
+		return Event{}, false
+	}
+	for _, attendee := range g.Attendees {
+		if attendee.Self && attendee.ResponseStatus == "declined" {
+			return Event{}, false
+		}
+	}
+
+	event := Event{
+		UID:         g.ID,
+		Summary:     g.Summary,
+		Description: g.Description,
+		Start:       g.Start.DateTime,
+		End:         g.End.DateTime,
+		// Working locations and out of office aren't time to focus
+		Transparent: g.Transparency == "transparent" || g.EventType == "workingLocation" || g.EventType == "outOfOffice",
+		FocusTime:   g.EventType == "focusTime",
+	}
+	if g.Start.Date != "" {
+		start, err := time.ParseInLocation("2006-01-02", g.Start.Date, time.Local)
+		if err != nil {
+			return Event{}, false
+		}
+		end, err := time.ParseInLocation("2006-01-02", g.End.Date, time.Local)
+		if err != nil {
+			end = start.AddDate(0, 0, 1)
+		}
+		event.Start, event.End, event.AllDay = start, end, true
+	}
+	return event, !event.Start.IsZero()
+}
+
+// get calls the Calendar API and decodes its answer into v
+func (c *GoogleClient) get(path string, query url.Values, v any) error {
+	accessToken, err := c.accessToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, googleAPIURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach Google Calendar: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close Google Calendar response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code from Google Calendar: %d %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 10*1024*1024)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse Google Calendar response: %w", err)
+	}
+	return nil
+}
+
+// accessToken returns a current access token, refreshing the kept one when it expires
+func (c *GoogleClient) accessToken() (string, error) {
+	if c.token == nil {
+		// #nosec G304 -- fixed file in the data directory
+		data, err := os.ReadFile(c.tokenPath)
+		if os.IsNotExist(err) {
+			return "", ErrNotLoggedIn
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read the Google Calendar token: %w", err)
+		}
+		var token googleToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			return "", fmt.Errorf("failed to parse the Google Calendar token: %w", err)
+		}
+		c.token = &token
+	}
+
+	if time.Until(c.token.Expiry) > time.Minute {
+		return c.token.AccessToken, nil
+	}
+	token, err := c.requestToken(url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {c.token.RefreshToken},
+	}, c.token.RefreshToken)
+	if errors.Is(err, ErrNotLoggedIn) {
+		// Read the file again next time, in case of a new login
+		c.token = nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if err := c.saveToken(token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
+
+// requestToken exchanges a code or refresh token for a token. Refreshes don't always
+// return a new refresh token, so the current one is kept.
+func (c *GoogleClient) requestToken(form url.Values, refreshToken string) (*googleToken, error) {
+	form.Set("client_id", c.cfg.ClientID)
+	if c.cfg.ClientSecret != "" {
+		form.Set("client_secret", c.cfg.ClientSecret)
+	}
+
+	resp, err := c.client.PostForm(googleTokenURL, form)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Google: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close Google token response: %v", err)
+		}
+	}()
+
+	var answer tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1024*1024)).Decode(&answer); err != nil {
+		return nil, fmt.Errorf("failed to parse Google token response: %w", err)
+	}
+	if answer.Error == "invalid_grant" {
+		// The refresh token was revoked or expired
+		return nil, ErrNotLoggedIn
+	}
+	if resp.StatusCode != http.StatusOK || answer.AccessToken == "" {
+		return nil, fmt.Errorf("google refused the token request: %s %s", answer.Error, answer.ErrorDescription)
+	}
+
+	token := &googleToken{
+		AccessToken:  answer.AccessToken,
+		RefreshToken: answer.RefreshToken,
+		Expiry:       time.Now().Add(time.Duration(answer.ExpiresIn) * time.Second),
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+	return token, nil
+}
+
+// saveToken keeps a token where only the user can read it
+func (c *GoogleClient) saveToken(token *googleToken) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the Google Calendar token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.tokenPath), 0o750); err != nil {
+		return fmt.Errorf("failed to create the data directory: %w", err)
+	}
+	if err := os.WriteFile(c.tokenPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to save the Google Calendar token: %w", err)
+	}
+	c.token = token
+	return nil
+}
+
+// randomString returns a random URL-safe string for the PKCE verifier and the login state
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,74 @@
+package calendar
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestGoogleWatcherRefreshesTokenAndMatchesEvents(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("grant_type") != "refresh_token" || r.FormValue("refresh_token") != "refresh" || r.FormValue("client_id") != "client" {
+			http.Error(w, `{"error": "invalid_request"}`, http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"access_token": "fresh", "expires_in": 3600}`))
+	})
+	mux.HandleFunc("GET /users/me/calendarList", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"items": [{"id": "primary@example.com", "summary": "Me", "primary": true}, {"id": "work@example.com", "summary": "Work"}]}`))
+	})
+	mux.HandleFunc("GET /calendars/{id}/events", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer fresh" || r.PathValue("id") != "work@example.com" || r.URL.Query().Get("singleEvents") != "true" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": [
+			{"id": "standup", "summary": "Standup", "start": {"dateTime": "2025-03-10T09:00:00Z"}, "end": {"dateTime": "2025-03-10T09:45:00Z"}},
+			{"id": "declined", "summary": "Deep work", "start": {"dateTime": "2025-03-10T09:00:00Z"}, "end": {"dateTime": "2025-03-10T12:00:00Z"},
+				"attendees": [{"self": true, "responseStatus": "declined"}]},
+			{"id": "writing", "summary": "Deep work: thesis", "start": {"dateTime": "2025-03-10T09:15:00Z"}, "end": {"dateTime": "2025-03-10T11:00:00Z"}}
+		]}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	googleTokenURL, googleAPIURL = server.URL+"/token", server.URL
+
+	cfg := &config.GoogleCalendarConfig{ClientID: "client", Calendars: []string{"work"}, Title: "(?i)deep work", Profile: "deep-work"}
+	focus := &fakeFocus{}
+	watcher, err := NewGoogleWatcher(cfg, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	// The fetch fails until a login kept a token
+	client := &GoogleClient{cfg: cfg, tokenPath: filepath.Join(t.TempDir(), "google-token.json"), client: server.Client()}
+	watcher.fetch = func(now time.Time) ([]Event, error) { return client.Events(now, now.Add(time.Hour)) }
+	watcher.check(now)
+	if len(focus.requests) != 0 {
+		t.Fatalf("Expected no session without a token, got %+v", focus.requests)
+	}
+
+	expired, _ := json.Marshal(googleToken{AccessToken: "stale", RefreshToken: "refresh", Expiry: time.Now().Add(-time.Hour)})
+	if err := os.WriteFile(client.tokenPath, expired, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	watcher.lastFetch = time.Time{}
+	watcher.check(now)
+	if len(focus.requests) != 1 || focus.requests[0].Profile != "deep-work" || focus.requests[0].Duration != "1h30m0s" {
+		t.Fatalf("Expected a session for the accepted deep work event, got %+v", focus.requests)
+	}
+
+	var saved googleToken
+	data, _ := os.ReadFile(client.tokenPath)
+	if err := json.Unmarshal(data, &saved); err != nil || saved.AccessToken != "fresh" || saved.RefreshToken != "refresh" {
+		t.Errorf("Expected the refreshed token to be kept with the refresh token, got %+v (%v)", saved, err)
+	}
+}
@@ -0,0 +1,365 @@
+package calendar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single calendar event (or one occurrence of a recurring event)
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Transparent bool // TRANSP:TRANSPARENT, i.e. shown as free
+	FocusTime   bool // A Focus time event of Google Calendar
+
+	rule    *recurrence
+	exDates []time.Time
+}
+
+// recurrence is the subset of RRULE supported by the parser
+type recurrence struct {
+	freq     string // DAILY or WEEKLY
+	interval int
+	count    int
+	until    *time.Time
+	byDay    []time.Weekday
+}
+
+// maxRecurrenceDays bounds how far a recurring event is expanded
+const maxRecurrenceDays = 3 * 366
+
+// Parse reads VEVENTs from an iCalendar stream. Recurring events are supported for
+// FREQ=DAILY and FREQ=WEEKLY with INTERVAL, COUNT, UNTIL, BYDAY and EXDATE.
+func Parse(r io.Reader) ([]Event, error) {
+	lines, err := unfoldLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []Event
+	var current *Event
+	var cancelled bool
+	var duration time.Duration
+
+	for i, line := range lines {
+		name, params, value := splitProperty(line)
+
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			current = &Event{}
+			cancelled = false
+			duration = 0
+		case name == "END" && value == "VEVENT":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: END:VEVENT without BEGIN:VEVENT", i+1)
+			}
+			if current.End.IsZero() {
+				switch {
+				case duration > 0:
+					current.End = current.Start.Add(duration)
+				case current.AllDay:
+					current.End = current.Start.AddDate(0, 0, 1)
+				default:
+					current.End = current.Start
+				}
+			}
+			if !cancelled && !current.Start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+		case current == nil:
+			continue
+		case name == "UID":
+			current.UID = value
+		case name == "SUMMARY":
+			current.Summary = unescapeText(value)
+		case name == "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case name == "STATUS":
+			cancelled = strings.EqualFold(value, "CANCELLED")
+		case name == "TRANSP":
+			current.Transparent = strings.EqualFold(value, "TRANSPARENT")
+		case name == "DTSTART":
+			start, allDay, err := parseDateTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTSTART: %w", i+1, err)
+			}
+			current.Start = start
+			current.AllDay = allDay
+		case name == "DTEND":
+			end, _, err := parseDateTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DTEND: %w", i+1, err)
+			}
+			current.End = end
+		case name == "DURATION":
+			d, err := parseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid DURATION: %w", i+1, err)
+			}
+			duration = d
+		case name == "RRULE":
+			rule, err := parseRecurrence(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			current.rule = rule
+		case name == "EXDATE":
+			for _, v := range strings.Split(value, ",") {
+				exDate, _, err := parseDateTime(v, params)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid EXDATE: %w", i+1, err)
+				}
+				current.exDates = append(current.exDates, exDate)
+			}
+		}
+	}
+
+	return events, nil
+}
+
+// OccurrenceAt returns the occurrence of the event in progress at the given time, if any
+func (e Event) OccurrenceAt(t time.Time) (Event, bool) {
+	if e.rule == nil {
+		return e, !t.Before(e.Start) && t.Before(e.End)
+	}
+
+	length := e.End.Sub(e.Start)
+	seen := 0
+	day := time.Date(e.Start.Year(), e.Start.Month(), e.Start.Day(), 0, 0, 0, 0, e.Start.Location())
+
+	for i := 0; i < maxRecurrenceDays; i++ {
+		candidate := day.AddDate(0, 0, i)
+		if !e.rule.matches(e.Start, candidate, i) {
+			continue
+		}
+
+		start := time.Date(candidate.Year(), candidate.Month(), candidate.Day(),
+			e.Start.Hour(), e.Start.Minute(), e.Start.Second(), 0, e.Start.Location())
+		if start.Before(e.Start) {
+			continue
+		}
+		if e.rule.until != nil && start.After(*e.rule.until) {
+			break
+		}
+		seen++
+		if e.rule.count > 0 && seen > e.rule.count {
+			break
+		}
+		if start.After(t) {
+			break
+		}
+		if e.isExcluded(start) {
+			continue
+		}
+
+		occurrence := e
+		occurrence.Start = start
+		occurrence.End = start.Add(length)
+		occurrence.rule = nil
+		if t.Before(occurrence.End) {
+			return occurrence, true
+		}
+	}
+
+	return Event{}, false
+}
+
+func (e Event) isExcluded(start time.Time) bool {
+	for _, exDate := range e.exDates {
+		if exDate.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether the day (dayIndex days after the first occurrence's date) is part of the rule
+func (r *recurrence) matches(first, day time.Time, dayIndex int) bool {
+	switch r.freq {
+	case "DAILY":
+		return dayIndex%r.interval == 0
+	case "WEEKLY":
+		// Weeks start on Monday (the RFC 5545 default WKST)
+		firstWeekday := (int(first.Weekday()) + 6) % 7
+		week := (dayIndex + firstWeekday) / 7
+		if week%r.interval != 0 {
+			return false
+		}
+		if len(r.byDay) == 0 {
+			return day.Weekday() == first.Weekday()
+		}
+		for _, weekday := range r.byDay {
+			if day.Weekday() == weekday {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseRecurrence(value string) (*recurrence, error) {
+	rule := &recurrence{interval: 1}
+
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval <= 0 {
+				return nil, fmt.Errorf("invalid RRULE INTERVAL: %s", val)
+			}
+			rule.interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count <= 0 {
+				return nil, fmt.Errorf("invalid RRULE COUNT: %s", val)
+			}
+			rule.count = count
+		case "UNTIL":
+			until, _, err := parseDateTime(val, nil)
+			if err != nil {
+				return nil, fmt.Errorf("invalid RRULE UNTIL: %w", err)
+			}
+			rule.until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(val, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("unsupported RRULE BYDAY: %s", day)
+				}
+				rule.byDay = append(rule.byDay, weekday)
+			}
+		}
+	}
+
+	if rule.freq != "DAILY" && rule.freq != "WEEKLY" {
+		return nil, fmt.Errorf("unsupported RRULE FREQ: %s", rule.freq)
+	}
+
+	return rule, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// parseDateTime parses DATE and DATE-TIME values, honoring the TZID parameter
+func parseDateTime(value string, params map[string]string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration parses the RFC 5545 DURATION format (e.g. PT1H30M, P1D)
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	var total time.Duration
+	inTime := false
+	number := ""
+
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			number += string(c)
+		case c == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			number = ""
+			switch {
+			case c == 'W':
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case c == 'D':
+				total += time.Duration(n) * 24 * time.Hour
+			case c == 'H' && inTime:
+				total += time.Duration(n) * time.Hour
+			case c == 'M' && inTime:
+				total += time.Duration(n) * time.Minute
+			case c == 'S' && inTime:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+		}
+	}
+
+	return total, nil
+}
+
+// unfoldLines joins continuation lines (starting with a space or tab) per RFC 5545
+func unfoldLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read calendar: %w", err)
+	}
+	return lines, nil
+}
+
+// splitProperty splits "NAME;PARAM=x:value" into its parts
+func splitProperty(line string) (string, map[string]string, string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+
+	params := make(map[string]string)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, "\"")
+	}
+
+	return strings.ToUpper(parts[0]), params, value
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`)
+	return replacer.Replace(value)
+}
@@ -0,0 +1,176 @@
+package calendar
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+const testCalendar = `BEGIN:VCALENDAR
+VERSION:2.0
+BEGIN:VEVENT
+UID:writing
+SUMMARY:Thesis writing #focus
+DTSTART:20250310T090000Z
+DTEND:20250310T110000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:standup
+SUMMARY:Standup
+DTSTART:20250310T080000Z
+DURATION:PT15M
+RRULE:FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=6
+EXDATE:20250312T080000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:cancelled
+SUMMARY:Cancelled #focus
+STATUS:CANCELLED
+DTSTART:20250310T120000Z
+DTEND:20250310T130000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:holiday
+SUMMARY:Public holiday
+DTSTART;VALUE=DATE:20250311
+TRANSP:TRANSPARENT
+DESCRIPTION:Long description that is
+  folded across lines
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestParse(t *testing.T) {
+	events, err := Parse(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events (cancelled skipped), got %d", len(events))
+	}
+
+	writing := events[0]
+	if writing.Summary != "Thesis writing #focus" {
+		t.Errorf("Unexpected summary: %s", writing.Summary)
+	}
+	if writing.End.Sub(writing.Start) != 2*time.Hour {
+		t.Errorf("Expected 2h event, got %v", writing.End.Sub(writing.Start))
+	}
+
+	standup := events[1]
+	if standup.End.Sub(standup.Start) != 15*time.Minute {
+		t.Errorf("Expected DURATION to set the end time, got %v", standup.End.Sub(standup.Start))
+	}
+
+	holiday := events[2]
+	if !holiday.AllDay || !holiday.Transparent {
+		t.Errorf("Expected all-day transparent event, got %+v", holiday)
+	}
+	if holiday.Description != "Long description that is folded across lines" {
+		t.Errorf("Expected folded description to be unfolded, got %q", holiday.Description)
+	}
+}
+
+func TestOccurrenceAt(t *testing.T) {
+	events, err := Parse(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+	standup := events[1]
+
+	tests := []struct {
+		at     string
+		active bool
+	}{
+		{"2025-03-10T08:05:00Z", true},  // Monday, first occurrence
+		{"2025-03-10T08:20:00Z", false}, // Monday, after it ended
+		{"2025-03-11T08:05:00Z", false}, // Tuesday is not in BYDAY
+		{"2025-03-12T08:05:00Z", false}, // Wednesday is excluded by EXDATE
+		{"2025-03-14T08:05:00Z", true},  // Friday
+		{"2025-03-19T08:05:00Z", true},  // Wednesday, 5th occurrence
+		{"2025-03-21T08:05:00Z", true},  // Friday, 6th occurrence (EXDATE still counts)
+		{"2025-03-24T08:05:00Z", false}, // Monday, past COUNT=6
+	}
+
+	for _, test := range tests {
+		at, _ := time.Parse(time.RFC3339, test.at)
+		_, active := standup.OccurrenceAt(at)
+		if active != test.active {
+			t.Errorf("At %s: expected active=%v, got %v", test.at, test.active, active)
+		}
+	}
+}
+
+type fakeFocus struct {
+	enabled  bool
+	endTime  *time.Time
+	requests []api.FocusRequest
+}
+
+func (f *fakeFocus) GetFocusMode() (bool, *time.Time) {
+	return f.enabled, f.endTime
+}
+
+func (f *fakeFocus) ApplyFocusMode(req api.FocusRequest) error {
+	f.requests = append(f.requests, req)
+	f.enabled = req.Enabled
+	return nil
+}
+
+func TestWatcherStartsFocusOncePerEvent(t *testing.T) {
+	events, err := Parse(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	focus := &fakeFocus{}
+	watcher, err := NewWatcher(&config.CalendarConfig{URL: "unused.ics", Profile: "deep-work"}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	watcher.events = events
+	watcher.lastFetch = time.Now().Add(time.Hour) // Skip fetching
+
+	now, _ := time.Parse(time.RFC3339, "2025-03-10T10:00:00Z")
+	watcher.check(now)
+
+	if len(focus.requests) != 1 {
+		t.Fatalf("Expected one focus request, got %d", len(focus.requests))
+	}
+	if focus.requests[0].Duration != "1h0m0s" || focus.requests[0].Profile != "deep-work" {
+		t.Errorf("Unexpected focus request: %+v", focus.requests[0])
+	}
+
+	// Disabling the calendar session dismisses the event
+	focus.enabled = false
+	watcher.check(now.Add(time.Minute))
+	if len(focus.requests) != 1 {
+		t.Errorf("Expected dismissed event not to restart focus mode, got %d requests", len(focus.requests))
+	}
+}
+
+func TestWatcherLeavesManualSessionAlone(t *testing.T) {
+	events, err := Parse(strings.NewReader(testCalendar))
+	if err != nil {
+		t.Fatalf("Failed to parse calendar: %v", err)
+	}
+
+	focus := &fakeFocus{enabled: true}
+	watcher, err := NewWatcher(&config.CalendarConfig{URL: "unused.ics"}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	watcher.events = events
+	watcher.lastFetch = time.Now().Add(time.Hour)
+
+	now, _ := time.Parse(time.RFC3339, "2025-03-10T10:00:00Z")
+	watcher.check(now)
+
+	if len(focus.requests) != 0 {
+		t.Errorf("Expected manual session to be left alone, got %d requests", len(focus.requests))
+	}
+}
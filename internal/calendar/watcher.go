@@ -0,0 +1,242 @@
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// FocusController is the part of the API server the watcher drives
+type FocusController interface {
+	GetFocusMode() (bool, *time.Time)
+	ApplyFocusMode(req api.FocusRequest) error
+}
+
+// Watcher periodically fetches a calendar, an ICS feed or Google Calendar, and enables focus
+// mode during matching events.
+//
+// Conflict rules with manual focus commands:
+//   - A calendar session only starts while focus mode is off; a manual session is never overridden.
+//   - If a manual session ends while a matching event is still running, the calendar takes over
+//     for the rest of the event.
+//   - Each event occurrence starts at most one session, so disabling a calendar session
+//     dismisses that event instead of it being re-enabled on the next check.
+type Watcher struct {
+	name    string // Logged, e.g. "Calendar"
+	fetch   func(now time.Time) ([]Event, error)
+	matches func(event Event) bool
+	profile string
+	refresh time.Duration
+	focus   FocusController
+	client  *http.Client
+
+	events    []Event
+	lastFetch time.Time
+	handled   map[string]bool // Event occurrences that already started a session
+}
+
+// checkInterval is how often the watcher looks for events starting or ending
+const checkInterval = 30 * time.Second
+
+// NewWatcher validates the calendar config and creates a watcher
+func NewWatcher(cfg *config.CalendarConfig, focus FocusController) (*Watcher, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("calendar url is required")
+	}
+	mode, err := cfg.GetMode()
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := cfg.GetRefresh()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		name:    "Calendar",
+		matches: func(event Event) bool { return matchesICS(mode, cfg.GetTag(), event) },
+		profile: cfg.Profile,
+		refresh: refresh,
+		focus:   focus,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		handled: make(map[string]bool),
+	}
+	w.fetch = func(time.Time) ([]Event, error) { return w.fetchICS(cfg.URL) }
+	return w, nil
+}
+
+// NewGoogleWatcher validates the google_calendar config and creates a watcher reading the
+// events of Google Calendar. Events matching title start focus mode, or without a title
+// every busy event that isn't all day; Focus time events always do.
+func NewGoogleWatcher(cfg *config.GoogleCalendarConfig, focus FocusController) (*Watcher, error) {
+	client, err := NewGoogleClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	title, err := cfg.GetTitle()
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := cfg.GetRefresh()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Watcher{
+		name: "Google Calendar",
+		fetch: func(now time.Time) ([]Event, error) {
+			// Cover the events running until the next refresh, with room for a late one
+			return client.Events(now, now.Add(2*refresh+time.Hour))
+		},
+		matches: func(event Event) bool {
+			switch {
+			case event.FocusTime:
+				return true
+			case title != nil:
+				return title.MatchString(event.Summary)
+			}
+			return !event.Transparent && !event.AllDay
+		},
+		profile: cfg.Profile,
+		refresh: refresh,
+		focus:   focus,
+		handled: make(map[string]bool),
+	}, nil
+}
+
+// Run checks the calendar until the stop channel is closed
+func (w *Watcher) Run(stop <-chan struct{}) {
+	log.Printf("%s watcher started (refresh every %s)", w.name, w.refresh)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check refreshes the calendar when due and starts a session for a matching event
+func (w *Watcher) check(now time.Time) {
+	if now.Sub(w.lastFetch) >= w.refresh {
+		events, err := w.fetch(now)
+		if err != nil {
+			// Keep using the previous events until the next refresh
+			log.Printf("Warning: failed to fetch %s: %v", strings.ToLower(w.name), err)
+		} else {
+			w.events = events
+			log.Printf("%s refreshed: %d events", w.name, len(events))
+		}
+		w.lastFetch = now
+	}
+
+	event, ok := w.activeEvent(now)
+	if !ok {
+		return
+	}
+
+	key := event.UID + "@" + event.Start.Format(time.RFC3339)
+	if w.handled[key] {
+		return
+	}
+
+	// Never override a running manual session
+	if enabled, endTime := w.focus.GetFocusMode(); enabled && (endTime == nil || endTime.After(now)) {
+		return
+	}
+
+	remaining := event.End.Sub(now).Round(time.Second)
+	log.Printf("%s event %q started, enabling focus mode for %s", w.name, event.Summary, remaining)
+
+	err := w.focus.ApplyFocusMode(api.FocusRequest{
+		Enabled:  true,
+		Duration: remaining.String(),
+		Profile:  w.profile,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to enable focus mode for calendar event: %v", err)
+		return
+	}
+	w.handled[key] = true
+}
+
+// activeEvent returns the matching event in progress, preferring the one ending last
+func (w *Watcher) activeEvent(now time.Time) (Event, bool) {
+	var active Event
+	found := false
+
+	for _, event := range w.events {
+		if !w.matches(event) {
+			continue
+		}
+		occurrence, ok := event.OccurrenceAt(now)
+		if !ok {
+			continue
+		}
+		if !found || occurrence.End.After(active.End) {
+			active = occurrence
+			found = true
+		}
+	}
+
+	return active, found
+}
+
+// matchesICS reports whether an event of an ICS calendar should trigger focus mode
+func matchesICS(mode, tag string, event Event) bool {
+	tag = strings.ToLower(tag)
+	tagged := strings.Contains(strings.ToLower(event.Summary), tag) ||
+		strings.Contains(strings.ToLower(event.Description), tag)
+
+	if mode == config.CalendarModeBusy {
+		// All-day entries (holidays, OOO) would block whole days, so only count them when tagged
+		return tagged || (!event.Transparent && !event.AllDay)
+	}
+	return tagged
+}
+
+// fetchICS downloads (or reads) and parses an ICS calendar
+func (w *Watcher) fetchICS(location string) ([]Event, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		// #nosec G304 -- the calendar path comes from the user's own config file
+		file, err := os.Open(strings.TrimPrefix(location, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open calendar: %w", err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("Warning: failed to close calendar file: %v", err)
+			}
+		}()
+		return Parse(file)
+	}
+
+	resp, err := w.client.Get(location)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download calendar: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close calendar response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	return Parse(io.LimitReader(resp.Body, 10*1024*1024))
+}
@@ -0,0 +1,223 @@
+// Package certs creates a private CA and the server and client certificates it signs,
+// for mutual TLS between the resolver's HTTP API and remote CLIs and TUIs
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Files written to the certificate directory; the client's are named after it
+const (
+	CAFile        = "ca.crt"
+	CAKeyFile     = "ca.key"
+	ServerFile    = "server.crt"
+	ServerKeyFile = "server.key"
+)
+
+// DefaultValidity is how long generated certificates are valid
+const DefaultValidity = 825 * 24 * time.Hour
+
+// localHosts are always in the server certificate, so the resolver's own CLI can connect
+var localHosts = []string{"localhost", "127.0.0.1", "::1"}
+
+// Options says what Generate creates
+type Options struct {
+	Dir      string        // Directory the files are written to
+	Hosts    []string      // Names and IP addresses clients reach the API at
+	Client   string        // Name of the client certificate, e.g. "laptop"
+	Validity time.Duration // How long the certificates are valid (default DefaultValidity)
+	Force    bool          // Replace existing files instead of keeping them
+}
+
+// Result lists the files Generate wrote and those it kept
+type Result struct {
+	Written []string
+	Kept    []string
+}
+
+// ClientFiles returns the certificate and key file names of a client
+func ClientFiles(client string) (string, string) {
+	return client + ".crt", client + ".key"
+}
+
+// Generate creates the CA, the server certificate, and the client certificate in
+// opts.Dir. Existing files are kept unless opts.Force is set, so another client can be
+// added with the same CA.
+func Generate(opts Options) (*Result, error) {
+	if opts.Client == "" || filepath.Base(opts.Client) != opts.Client || opts.Client == "ca" || opts.Client == "server" {
+		return nil, fmt.Errorf("invalid client name %q", opts.Client)
+	}
+	if opts.Validity <= 0 {
+		opts.Validity = DefaultValidity
+	}
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", opts.Dir, err)
+	}
+	result := &Result{}
+
+	ca, caKey, err := loadOrCreateCA(opts, result)
+	if err != nil {
+		return nil, err
+	}
+
+	server := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "sinkzone resolver"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range append(append([]string{}, opts.Hosts...), localHosts...) {
+		if ip := net.ParseIP(host); ip != nil {
+			server.IPAddresses = append(server.IPAddresses, ip)
+		} else if host != "" {
+			server.DNSNames = append(server.DNSNames, host)
+		}
+	}
+	if err := issue(opts, result, ServerFile, ServerKeyFile, server, ca, caKey); err != nil {
+		return nil, err
+	}
+
+	client := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: opts.Client},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certFile, keyFile := ClientFiles(opts.Client)
+	if err := issue(opts, result, certFile, keyFile, client, ca, caKey); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// loadOrCreateCA reads the CA of opts.Dir, or creates one when there is none
+func loadOrCreateCA(opts Options, result *Result) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPath := filepath.Join(opts.Dir, CAFile)
+	keyPath := filepath.Join(opts.Dir, CAKeyFile)
+	if !opts.Force && exists(certPath) {
+		ca, key, err := loadCA(certPath, keyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		result.Kept = append(result.Kept, CAFile, CAKeyFile)
+		return ca, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "sinkzone CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := sign(template, template, &key.PublicKey, key, opts.Validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	if err := writeFiles(opts.Dir, CAFile, CAKeyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	result.Written = append(result.Written, CAFile, CAKeyFile)
+	return ca, key, nil
+}
+
+func loadCA(certPath, keyPath string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	certPEM, err := os.ReadFile(certPath) // #nosec G304 -- the certificate directory is chosen by the user
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(keyPath) // #nosec G304 -- the certificate directory is chosen by the user
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CA key, which is needed to sign new certificates: %w", err)
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("failed to decode CA certificate or key in %s", filepath.Dir(certPath))
+	}
+	ca, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+	}
+	key, err := x509.ParseECPrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CA key: %w", err)
+	}
+	return ca, key, nil
+}
+
+// issue writes a certificate signed by the CA, unless it exists and opts.Force is unset
+func issue(opts Options, result *Result, certFile, keyFile string, template, ca *x509.Certificate, caKey *ecdsa.PrivateKey) error {
+	if !opts.Force && exists(filepath.Join(opts.Dir, certFile)) {
+		result.Kept = append(result.Kept, certFile, keyFile)
+		return nil
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key for %s: %w", certFile, err)
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := sign(template, ca, &key.PublicKey, caKey, opts.Validity)
+	if err != nil {
+		return err
+	}
+	if err := writeFiles(opts.Dir, certFile, keyFile, der, key); err != nil {
+		return err
+	}
+	result.Written = append(result.Written, certFile, keyFile)
+	return nil
+}
+
+// sign fills in the serial number and validity of template and signs it
+func sign(template, parent *x509.Certificate, pub *ecdsa.PublicKey, signer *ecdsa.PrivateKey, validity time.Duration) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %w", err)
+	}
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Hour) // Tolerate clocks running a little behind
+	template.NotAfter = time.Now().Add(validity)
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate %s: %w", template.Subject.CommonName, err)
+	}
+	return der, nil
+}
+
+// writeFiles writes a certificate and its key, the key readable only by its owner
+func writeFiles(dir, certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(filepath.Join(dir, keyFile), keyPEM, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", keyFile, err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, certFile), certPEM, 0o644); err != nil { // #nosec G306 -- certificates are public
+		return fmt.Errorf("failed to write %s: %w", certFile, err)
+	}
+	return nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
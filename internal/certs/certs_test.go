@@ -0,0 +1,38 @@
+package certs
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateKeepsExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := Generate(Options{Dir: dir, Hosts: []string{"nas.local"}, Client: "laptop"}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	ca, err := os.ReadFile(filepath.Join(dir, CAFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, CAKeyFile)); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the CA key to be private, got %v %v", info.Mode(), err)
+	}
+
+	// A second client is signed by the same CA
+	result, err := Generate(Options{Dir: dir, Client: "phone"})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if len(result.Written) != 2 || result.Written[0] != "phone.crt" {
+		t.Errorf("Expected only the phone's files to be written, got %v", result.Written)
+	}
+	if again, _ := os.ReadFile(filepath.Join(dir, CAFile)); !bytes.Equal(ca, again) {
+		t.Error("Expected the CA to be kept")
+	}
+
+	if _, err := Generate(Options{Dir: dir, Client: "../laptop"}); err == nil {
+		t.Error("Expected a client name with a path to be refused")
+	}
+}
@@ -0,0 +1,64 @@
+// Package clock keeps focus deadlines correct when the system time changes.
+//
+// Deadlines are plain wall-clock times, so they still pass while the computer sleeps (Go's
+// monotonic clock stops during sleep on Linux and macOS). A Detector compares the wall
+// clock with a clock that keeps running during sleep but ignores changes to the system
+// time, and reports how far the system time was moved, so callers can move their deadlines
+// by the same amount.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// MinJump is the smallest change of the system time a Detector reports; smaller
+// differences are clock adjustments such as NTP slewing
+const MinJump = time.Second
+
+// Now returns the current wall-clock time without Go's monotonic reading, so deadlines
+// built from it are compared by wall-clock time and can be moved with Add
+func Now() time.Time {
+	return time.Now().Round(0)
+}
+
+// Detector notices changes of the system time
+type Detector struct {
+	now    func() time.Time
+	uptime func() (time.Duration, bool)
+
+	mu     sync.Mutex
+	offset time.Duration // Wall-clock time minus uptime at the last check
+	ok     bool
+}
+
+// NewDetector returns a detector that reports changes made from now on
+func NewDetector() *Detector {
+	d := &Detector{now: Now, uptime: uptime}
+	d.Check()
+	return d
+}
+
+// Check returns how far the system time was moved since the last check (positive when
+// it was set forward), or 0 if it wasn't. It always returns 0 on systems without a clock
+// that keeps running during sleep.
+func (d *Detector) Check() time.Duration {
+	up, ok := d.uptime()
+	if !ok {
+		return 0
+	}
+	offset := d.now().Sub(time.Unix(0, 0)) - up
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.ok {
+		d.offset, d.ok = offset, true
+		return 0
+	}
+	jump := offset - d.offset
+	if jump > -MinJump && jump < MinJump {
+		return 0
+	}
+	d.offset = offset
+	return jump
+}
@@ -0,0 +1,40 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDetectorReportsClockChanges(t *testing.T) {
+	wall := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	up := time.Hour
+	d := &Detector{
+		now:    func() time.Time { return wall },
+		uptime: func() (time.Duration, bool) { return up, true },
+	}
+	d.Check()
+
+	// Time passing, including sleep, moves both clocks
+	wall, up = wall.Add(8*time.Hour), up+8*time.Hour
+	if jump := d.Check(); jump != 0 {
+		t.Errorf("expected no jump while time passes, got %s", jump)
+	}
+
+	wall = wall.Add(2 * time.Hour)
+	if jump := d.Check(); jump != 2*time.Hour {
+		t.Errorf("expected the clock to be set forward 2h, got %s", jump)
+	}
+	if jump := d.Check(); jump != 0 {
+		t.Errorf("expected a jump to be reported once, got %s", jump)
+	}
+
+	wall = wall.Add(-30 * time.Minute)
+	if jump := d.Check(); jump != -30*time.Minute {
+		t.Errorf("expected the clock to be set back 30m, got %s", jump)
+	}
+
+	wall = wall.Add(200 * time.Millisecond)
+	if jump := d.Check(); jump != 0 {
+		t.Errorf("expected small adjustments to be ignored, got %s", jump)
+	}
+}
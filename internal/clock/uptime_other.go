@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package clock
+
+import "time"
+
+// uptime isn't available here, so changes of the system time go unnoticed
+func uptime() (time.Duration, bool) {
+	return 0, false
+}
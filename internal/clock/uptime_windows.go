@@ -0,0 +1,18 @@
+package clock
+
+import (
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+var procGetTickCount64 = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetTickCount64")
+
+// uptime reads GetTickCount64, which counts time spent asleep
+func uptime() (time.Duration, bool) {
+	if procGetTickCount64.Find() != nil {
+		return 0, false
+	}
+	ms, _, _ := procGetTickCount64.Call()
+	return time.Duration(ms) * time.Millisecond, true
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// APITLSConfig serves the HTTP API over HTTPS, and with ClientCA only to clients holding
+// a certificate it signed (mutual TLS)
+type APITLSConfig struct {
+	Cert     string `yaml:"cert,omitempty"`      // Server certificate (PEM)
+	Key      string `yaml:"key,omitempty"`       // Its private key (PEM)
+	ClientCA string `yaml:"client_ca,omitempty"` // CA whose client certificates are required (default: none required)
+}
+
+// APIClientTLSConfig is how the CLI and TUI connect to an https API
+type APIClientTLSConfig struct {
+	CA   string `yaml:"ca,omitempty"`   // CA the API's certificate must chain to (default: the system's)
+	Cert string `yaml:"cert,omitempty"` // Client certificate sent to an API that requires one (PEM)
+	Key  string `yaml:"key,omitempty"`  // Its private key (PEM)
+}
+
+// GetCertsDir returns where 'sinkzone cert generate' writes certificates by default
+func GetCertsDir() string {
+	return filepath.Join(GetDataDir(), "certs")
+}
+
+// tlsPath resolves a certificate path; relative paths are in the sinkzone directory
+func tlsPath(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(GetDataDir(), path)
+}
+
+// IsEnabled reports whether the API is served over HTTPS
+func (c *APITLSConfig) IsEnabled() bool {
+	return c != nil && (c.Cert != "" || c.Key != "" || c.ClientCA != "")
+}
+
+// RequiresClientCert reports whether the API only answers clients with a certificate
+func (c *APITLSConfig) RequiresClientCert() bool {
+	return c != nil && c.ClientCA != ""
+}
+
+// ServerTLS loads the certificates of api_tls, or returns nil when the API is plain HTTP
+func (c *APITLSConfig) ServerTLS() (*tls.Config, error) {
+	if !c.IsEnabled() {
+		return nil, nil
+	}
+	if c.Cert == "" || c.Key == "" {
+		return nil, fmt.Errorf("invalid api_tls: cert and key are both required")
+	}
+	cert, err := tls.LoadX509KeyPair(tlsPath(c.Cert), tlsPath(c.Key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid api_tls: failed to load cert and key: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.ClientCA != "" {
+		pool, err := loadCertPool(c.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_tls client_ca: %w", err)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// ClientTLS loads the certificates of api_client_tls, or returns nil to use the defaults
+func (c *APIClientTLSConfig) ClientTLS() (*tls.Config, error) {
+	if c == nil || *c == (APIClientTLSConfig{}) {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CA != "" {
+		pool, err := loadCertPool(c.CA)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_client_tls ca: %w", err)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if c.Cert != "" || c.Key != "" {
+		if c.Cert == "" || c.Key == "" {
+			return nil, fmt.Errorf("invalid api_client_tls: cert and key are both required")
+		}
+		cert, err := tls.LoadX509KeyPair(tlsPath(c.Cert), tlsPath(c.Key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_client_tls: failed to load cert and key: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+func (c *APITLSConfig) checkFiles() error {
+	if c == nil {
+		return nil
+	}
+	return checkTLSFiles("api_tls", c.Cert, c.Key, c.ClientCA)
+}
+
+func (c *APIClientTLSConfig) checkFiles() error {
+	if c == nil {
+		return nil
+	}
+	return checkTLSFiles("api_client_tls", c.Cert, c.Key, c.CA)
+}
+
+// checkTLSFiles checks the files of api_tls or api_client_tls that are set, so 'sinkzone
+// config set' can set a cert before its key; using them needs both
+func checkTLSFiles(name, cert, key, ca string) error {
+	if cert != "" && key != "" {
+		if _, err := tls.LoadX509KeyPair(tlsPath(cert), tlsPath(key)); err != nil {
+			return fmt.Errorf("invalid %s: failed to load cert and key: %w", name, err)
+		}
+	}
+	for _, path := range []string{cert, key} {
+		if _, err := os.Stat(tlsPath(path)); path != "" && err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	if ca != "" {
+		if _, err := loadCertPool(ca); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// loadCertPool reads the PEM certificates of a CA file
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(tlsPath(path)) // #nosec G304 -- path comes from the user's config
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("%s holds no PEM certificates", path)
+	}
+	return pool, nil
+}
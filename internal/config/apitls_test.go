@@ -0,0 +1,53 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/certs"
+)
+
+func TestAPITLSRequiresClientCert(t *testing.T) {
+	t.Setenv(ConfigDirEnv, t.TempDir())
+	if _, err := certs.Generate(certs.Options{Dir: GetCertsDir(), Client: "laptop"}); err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	// Relative paths are in the sinkzone directory
+	serverTLS, err := (&APITLSConfig{Cert: "certs/server.crt", Key: "certs/server.key", ClientCA: "certs/ca.crt"}).ServerTLS()
+	if err != nil {
+		t.Fatalf("ServerTLS failed: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	server.TLS = serverTLS
+	server.StartTLS()
+	defer server.Close()
+
+	get := func(c *APIClientTLSConfig) error {
+		clientTLS, err := c.ClientTLS()
+		if err != nil {
+			t.Fatalf("ClientTLS failed: %v", err)
+		}
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS}}
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(&APIClientTLSConfig{CA: "certs/ca.crt", Cert: "certs/laptop.crt", Key: "certs/laptop.key"}); err != nil {
+		t.Errorf("Expected a client with a certificate to connect, got %v", err)
+	}
+	if err := get(&APIClientTLSConfig{CA: "certs/ca.crt"}); err == nil {
+		t.Error("Expected a client without a certificate to be refused")
+	}
+
+	cfg := &Config{APITLS: &APITLSConfig{Cert: "certs/server.crt", Key: "certs/server.key", ClientCA: "certs/ca.crt"}}
+	if _, err := cfg.CheckAPIListen("api_listen", "0.0.0.0:8080"); err != nil {
+		t.Errorf("Expected a remote api_listen to be allowed with client certificates, got %v", err)
+	}
+	if _, err := (&APITLSConfig{Cert: "certs/server.crt"}).ServerTLS(); err == nil {
+		t.Error("Expected a cert without a key to be refused")
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// GetBudgets returns the daily time budgets by domain or wildcard pattern
+func (c *Config) GetBudgets() (map[string]time.Duration, error) {
+	budgets := make(map[string]time.Duration, len(c.Budgets))
+	for pattern, limit := range c.Budgets {
+		if strings.TrimSpace(pattern) == "" || strings.ContainsAny(pattern, " \t") {
+			return nil, fmt.Errorf("invalid budgets entry %q: use a domain or wildcard pattern", pattern)
+		}
+		budget, err := time.ParseDuration(strings.TrimSuffix(limit, "/day"))
+		if err != nil || budget < time.Minute || budget > 24*time.Hour {
+			return nil, fmt.Errorf("invalid budget %q of %s: use a duration from 1m to 24h, e.g. 30m", limit, pattern)
+		}
+		budgets[strings.ToLower(pattern)] = budget.Round(time.Minute)
+	}
+	return budgets, nil
+}
+
+// BudgetUsage returns the minutes each budget was used on the given day, as last saved
+func (sm *StateManager) BudgetUsage(day time.Time) map[string]int {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	if sm.state.BudgetUsage == nil || sm.state.BudgetUsage.Day != dayKey(day) {
+		return nil
+	}
+	return maps.Clone(sm.state.BudgetUsage.Minutes)
+}
+
+// SetBudgetUsage saves the minutes each budget was used on the given day
+func (sm *StateManager) SetBudgetUsage(day time.Time, minutes map[string]int) error {
+	return sm.update(func(state *State) error {
+		state.BudgetUsage = &BudgetUsage{Day: dayKey(day), Minutes: minutes}
+		return nil
+	})
+}
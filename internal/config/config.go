@@ -1,29 +1,588 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"os/user"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/berbyte/sinkzone/internal/events"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	UpstreamNameservers []string `yaml:"upstream_nameservers"`
+	Version                int                   `yaml:"version,omitempty"` // Schema of the file, upgraded by Load (see CurrentConfigVersion)
+	UpstreamNameservers    []string              `yaml:"upstream_nameservers"`
+	UpstreamStrategy       string                `yaml:"upstream_strategy,omitempty"`        // Order upstreams are tried in (default sequential)
+	DNSListen              string                `yaml:"dns_listen,omitempty"`               // Address the DNS server binds (default :53)
+	DNSFallbackPort        string                `yaml:"dns_fallback_port,omitempty"`        // Port the DNS server binds when dns_listen's is taken (default off)
+	APIListen              string                `yaml:"api_listen,omitempty"`               // Address the HTTP API binds (default 127.0.0.1:8080)
+	APIAllowRemote         *bool                 `yaml:"api_allow_remote,omitempty"`         // Allow an api_listen other machines can reach (default false)
+	MutationsLocalOnly     *bool                 `yaml:"mutations_local_only,omitempty"`     // Only accept API changes from this machine (default false)
+	LAN                    *bool                 `yaml:"lan,omitempty"`                      // Serve the devices of a network (default false)
+	MetricsListen          string                `yaml:"metrics_listen,omitempty"`           // Extra address serving only /metrics (default: the API only)
+	RunAs                  string                `yaml:"run_as,omitempty"`                   // User the resolver switches to after binding its ports (default: the sudo user)
+	APITokens              []APIToken            `yaml:"api_tokens,omitempty"`               // Bearer tokens the API requires once any is set
+	TriggerSecret          string                `yaml:"trigger_secret,omitempty"`           // Secret of POST /api/hooks/trigger, off when empty
+	APITLS                 *APITLSConfig         `yaml:"api_tls,omitempty"`                  // Serve the API over HTTPS, optionally requiring client certificates
+	APIClientTLS           *APIClientTLSConfig   `yaml:"api_client_tls,omitempty"`           // Certificates the CLI and TUI use for an https API
+	BlockResponse          string                `yaml:"block_response,omitempty"`           // nxdomain (default), null, or refused
+	BlockedTTL             string                `yaml:"blocked_ttl,omitempty"`              // TTL of blocked answers (default 10s)
+	Cache                  *CacheConfig          `yaml:"cache,omitempty"`                    // Cache of upstream answers
+	RateLimit              *RateLimitConfig      `yaml:"rate_limit,omitempty"`               // Per-client query rate limit
+	Cooldown               *CooldownConfig       `yaml:"cooldown,omitempty"`                 // Clients refused for a while after repeated abuse
+	Concurrency            *ConcurrencyConfig    `yaml:"concurrency,omitempty"`              // Queries handled at once
+	CaptivePortal          *CaptivePortalConfig  `yaml:"captive_portal,omitempty"`           // Passthrough windows for the login pages of hotel and café networks
+	PrivateZones           *PrivateZonesConfig   `yaml:"private_zones,omitempty"`            // Names only the local network knows, kept from the upstreams
+	RecentQueries          *RecentQueriesConfig  `yaml:"recent_queries,omitempty"`           // Query history kept in memory
+	QueryLog               *QueryLogConfig       `yaml:"query_log,omitempty"`                // Query history kept on disk
+	QueryRetention         string                `yaml:"query_retention,omitempty"`          // Queries older than this are deleted from the log (default 7d, 0 keeps them)
+	MaxQueryRecords        int                   `yaml:"max_query_records,omitempty"`        // The oldest queries beyond this many are deleted (0 = no limit)
+	ResolveClientHostnames *bool                 `yaml:"resolve_client_hostnames,omitempty"` // Reverse DNS lookups of clients (default true)
+	BypassCheck            *bool                 `yaml:"bypass_check,omitempty"`             // Check that this machine's DNS queries reach the resolver (default true)
+	ClientNames            map[string]string     `yaml:"client_names,omitempty"`             // Names shown for client IP or MAC addresses
+	Users                  map[string]UserPolicy `yaml:"users,omitempty"`                    // Policies of the user accounts of this machine, by user name
+	ClientPrivacy          string                `yaml:"client_privacy,omitempty"`           // How client addresses are recorded: off (default), truncate, or hash
+	LogOutput              string                `yaml:"log_output,omitempty"`               // Where the resolver logs: file (default) or syslog
+	LogFile                string                `yaml:"log_file,omitempty"`                 // Resolver log file (default: standard error)
+	LogRotation            *LogRotationConfig    `yaml:"log_rotation,omitempty"`             // When log_file is rotated
+	LogLevel               string                `yaml:"log_level,omitempty"`                // Lowest level logged: debug, info (default), warn, or error
+	LogLevels              map[string]string     `yaml:"log_levels,omitempty"`               // Levels of single components (dns, api), overriding log_level
+	LogFormat              string                `yaml:"log_format,omitempty"`               // Format of the log: text (default) or json
+	Profiles               map[string]Profile    `yaml:"profiles,omitempty"`
+	ActiveProfile          string                `yaml:"active_profile,omitempty"` // Profile used when a session doesn't choose one
+	FocusGracePeriod       string                `yaml:"focus_grace_period,omitempty"`
+	FocusPINHash           string                `yaml:"focus_pin_hash,omitempty"`
+	FileIntegrity          string                `yaml:"file_integrity,omitempty"` // What the resolver does about allowlist.txt and state.json changed outside sinkzone: warn (default) or enforce
+	FocusOnStart           string                `yaml:"focus_on_start,omitempty"`
+	FocusDisableDelay      string                `yaml:"focus_disable_delay,omitempty"`
+	FocusIntensity         string                `yaml:"focus_intensity,omitempty"` // Default intensity for new sessions
+	DailyGoal              string                `yaml:"daily_goal,omitempty"`
+	Budgets                map[string]string     `yaml:"budgets,omitempty"`                 // Daily time budgets by domain pattern, e.g. reddit.com: 30m
+	BreakDomains           []string              `yaml:"break_domains,omitempty"`           // Allowed only during focus breaks
+	IgnoreDomains          []string              `yaml:"ignore_domains,omitempty"`          // Resolved and blocked as usual, but left out of the monitor and stats
+	AllowlistSubscriptions []Subscription        `yaml:"allowlist_subscriptions,omitempty"` // Hosted lists added to the allowlist
+	BlocklistSubscriptions []Subscription        `yaml:"blocklist_subscriptions,omitempty"` // Hosted lists blocked in every focus session
+	SubscriptionRefresh    string                `yaml:"subscription_refresh,omitempty"`    // How often subscribed lists are downloaded again (default 24h)
+	Calendar               *CalendarConfig       `yaml:"calendar,omitempty"`
+	GoogleCalendar         *GoogleCalendarConfig `yaml:"google_calendar,omitempty"`
+	ProcessTriggers        []ProcessTrigger      `yaml:"process_triggers,omitempty"`
+	Schedules              []Schedule            `yaml:"schedules,omitempty"`
+	Rules                  []Rule                `yaml:"rules,omitempty"`      // Allow or block queries by conditions, ahead of the lists
+	Categories             []Category            `yaml:"categories,omitempty"` // Named groups of domains rules refer to as category
+	MacOSFocus             *MacOSFocusConfig     `yaml:"macos_focus,omitempty"`
+	Sync                   *SyncConfig           `yaml:"sync,omitempty"`
+	Notifications          *NotifyConfig         `yaml:"notifications,omitempty"`
+	Hooks                  *HooksConfig          `yaml:"hooks,omitempty"`
+	Tracing                *TracingConfig        `yaml:"tracing,omitempty"`
+	MQTT                   *MQTTConfig           `yaml:"mqtt,omitempty"`
+	InfluxDB               *InfluxDBConfig       `yaml:"influxdb,omitempty"`
+	Tailscale              *TailscaleConfig      `yaml:"tailscale,omitempty"`     // Serve DNS on a tailnet, off unless enabled
+	VPN                    *VPNConfig            `yaml:"vpn,omitempty"`           // Use the VPN's nameservers while it is up
+	CrashReports           *CrashReportsConfig   `yaml:"crash_reports,omitempty"` // Reports of crashes, off unless enabled
+	Theme                  *ThemeConfig          `yaml:"theme,omitempty"`
+	Keymap                 Keymap                `yaml:"keymap,omitempty"`
+	TUI                    *TUIConfig            `yaml:"tui,omitempty"`
+	Language               string                `yaml:"language,omitempty"` // TUI and CLI language (e.g. "de"); empty follows LANG
+
+	// Keys overridden by SINKZONE_* environment variables, restored from the file on Save
+	overrides []envOverride
+
+	// The system-wide and user files as parsed, to tell inherited values from the user's own
+	system     map[string]any
+	user       map[string]any
+	systemPath string
+}
+
+// Keymap rebinds TUI actions (e.g. "quit", "focus", "up") to lists of keys, overriding the defaults
+type Keymap map[string][]string
+
+// ThemeConfig selects the TUI colour palette. Colour fields are hex values ("#RRGGBB")
+// that override the named theme.
+type ThemeConfig struct {
+	Name       string `yaml:"name,omitempty"` // dark (default), light, or solarized
+	Background string `yaml:"background,omitempty"`
+	Text       string `yaml:"text,omitempty"`
+	Accent     string `yaml:"accent,omitempty"` // Banner and footer
+	Border     string `yaml:"border,omitempty"`
+	Muted      string `yaml:"muted,omitempty"` // Inactive tabs
+	Selected   string `yaml:"selected,omitempty"`
+	Alert      string `yaml:"alert,omitempty"` // Focus mode indicator
+	Warning    string `yaml:"warning,omitempty"`
+	Success    string `yaml:"success,omitempty"`
+}
+
+// TUIConfig sets how often the TUI polls the resolver
+type TUIConfig struct {
+	Refresh         string `yaml:"refresh,omitempty"`          // Query, focus, and health poll (default 3s)
+	AllowlistReload string `yaml:"allowlist_reload,omitempty"` // Allowlist file reload (default 5s)
+}
+
+// SyncConfig mirrors focus sessions from sinkzone resolvers on other machines
+type SyncConfig struct {
+	Peers    []string `yaml:"peers"`              // API URLs of the other resolvers, e.g. http://desktop.local:8080
+	Interval string   `yaml:"interval,omitempty"` // How often peers are polled (default 10s)
+	Token    string   `yaml:"token,omitempty"`    // API token sent to the peers (read scope)
+}
+
+// ProcessTrigger starts focus mode while a program is running and ends it when the program exits
+type ProcessTrigger struct {
+	Process string `yaml:"process"`           // Executable name, e.g. "code" or "obsidian"
+	Profile string `yaml:"profile,omitempty"` // Focus profile used for the session
+	Label   string `yaml:"label,omitempty"`   // Session label used for reporting
+}
+
+// Schedule starts focus mode during a recurring weekly window, or with Block, blocks
+// domains during it whether or not a focus session runs
+type Schedule struct {
+	When    string   `yaml:"when"`              // Days and local times, e.g. "Mon-Fri 09:00-17:00"
+	Profile string   `yaml:"profile,omitempty"` // Focus profile used for the session
+	Label   string   `yaml:"label,omitempty"`   // Session label used for reporting
+	Block   []string `yaml:"block,omitempty"`   // Domains or wildcard patterns blocked instead of starting a session
+}
+
+// StartsFocus reports whether the schedule starts focus sessions rather than blocking domains
+func (s Schedule) StartsFocus() bool {
+	return len(s.Block) == 0
+}
+
+// MacOSFocusConfig mirrors a macOS Focus, such as Work, and sinkzone's focus sessions
+type MacOSFocusConfig struct {
+	Focus       string `yaml:"focus,omitempty"`        // Name of the macOS Focus (default Work)
+	Sync        string `yaml:"sync,omitempty"`         // "both" (default), "from_mac", or "to_mac"
+	OnShortcut  string `yaml:"on_shortcut,omitempty"`  // Shortcut turning the Focus on (default "Sinkzone Focus On")
+	OffShortcut string `yaml:"off_shortcut,omitempty"` // Shortcut turning the Focus off (default "Sinkzone Focus Off")
+	Profile     string `yaml:"profile,omitempty"`      // Focus profile used for sessions started by the Focus
+	Label       string `yaml:"label,omitempty"`        // Session label used for reporting
+}
+
+// Directions macos_focus mirrors in
+const (
+	MacOSFocusSyncBoth    = "both"     // Either side turns the other on and off
+	MacOSFocusSyncFromMac = "from_mac" // The macOS Focus starts and ends sessions
+	MacOSFocusSyncToMac   = "to_mac"   // Sessions turn the macOS Focus on and off
+)
+
+// GetFocus returns the name of the mirrored macOS Focus
+func (c *MacOSFocusConfig) GetFocus() string {
+	if c.Focus == "" {
+		return "Work"
+	}
+	return c.Focus
+}
+
+// GetSync returns the directions mirrored, defaulting to both
+func (c *MacOSFocusConfig) GetSync() (string, error) {
+	switch c.Sync {
+	case "", MacOSFocusSyncBoth:
+		return MacOSFocusSyncBoth, nil
+	case MacOSFocusSyncFromMac, MacOSFocusSyncToMac:
+		return c.Sync, nil
+	default:
+		return "", fmt.Errorf("invalid macos_focus sync %q: use %q, %q, or %q", c.Sync, MacOSFocusSyncBoth, MacOSFocusSyncFromMac, MacOSFocusSyncToMac)
+	}
+}
+
+// GetShortcuts returns the names of the shortcuts turning the Focus on and off
+func (c *MacOSFocusConfig) GetShortcuts() (string, string) {
+	on, off := c.OnShortcut, c.OffShortcut
+	if on == "" {
+		on = "Sinkzone Focus On"
+	}
+	if off == "" {
+		off = "Sinkzone Focus Off"
+	}
+	return on, off
+}
+
+// Rule actions
+const (
+	RuleAllow = "allow"
+	RuleBlock = "block"
+)
+
+// Rule allows or blocks the queries its condition holds for, whether or not a focus session
+// is running, ahead of the allowlist and blocklist
+type Rule struct {
+	Name   string `yaml:"name,omitempty"`
+	If     string `yaml:"if"`     // Condition, e.g. domain matches "*.youtube.com" and time >= "18:00"
+	Action string `yaml:"action"` // allow or block
+}
+
+// Category is a named group of domains, e.g. video sites
+type Category struct {
+	Name    string   `yaml:"name"`
+	Domains []string `yaml:"domains"` // Domains and wildcard patterns, as in allowlist.txt
+}
+
+// HooksConfig lists executables the resolver runs when focus sessions start and end or
+// other events happen, and Go plugins it hands the events to
+type HooksConfig struct {
+	OnFocusStart string            `yaml:"on_focus_start,omitempty"`
+	OnFocusEnd   string            `yaml:"on_focus_end,omitempty"`
+	Events       map[string]string `yaml:"events,omitempty"`  // Executable run for each event of a type, e.g. query_blocked
+	Plugins      []string          `yaml:"plugins,omitempty"` // Go plugins (.so files) handed the events
+}
+
+// GetEvents returns the executables run for events by event type, refusing unknown types
+func (h *HooksConfig) GetEvents() (map[string]string, error) {
+	if h == nil {
+		return nil, nil
+	}
+	for event, path := range h.Events {
+		if !slices.Contains(events.Types, event) {
+			return nil, fmt.Errorf("invalid hooks event %q: use %s", event, strings.Join(events.Types, ", "))
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid hooks event %s: give the executable to run", event)
+		}
+	}
+	return h.Events, nil
+}
+
+// TracingConfig sends traces of DNS queries and API requests to an OpenTelemetry collector
+type TracingConfig struct {
+	Endpoint      string `yaml:"endpoint"`                 // OTLP/HTTP endpoint, e.g. http://localhost:4318
+	ServiceName   string `yaml:"service_name,omitempty"`   // service.name of the spans (default sinkzone)
+	SamplePercent *int   `yaml:"sample_percent,omitempty"` // Share of DNS queries and API requests traced (default 100)
+}
+
+// GetEndpoint returns the URL spans are posted to; an endpoint without a path gets the
+// standard /v1/traces
+func (c *TracingConfig) GetEndpoint() (string, error) {
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid tracing endpoint %q: use an http or https URL, e.g. http://localhost:4318", c.Endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	return u.String(), nil
+}
+
+// GetServiceName returns the service.name of the spans
+func (c *TracingConfig) GetServiceName() string {
+	if c.ServiceName == "" {
+		return "sinkzone"
+	}
+	return c.ServiceName
+}
+
+// GetSamplePercent returns the share of DNS queries and API requests traced
+func (c *TracingConfig) GetSamplePercent() (int, error) {
+	if c.SamplePercent == nil {
+		return 100, nil
+	}
+	if *c.SamplePercent < 0 || *c.SamplePercent > 100 {
+		return 0, fmt.Errorf("invalid tracing sample_percent %d: must be between 0 and 100", *c.SamplePercent)
+	}
+	return *c.SamplePercent, nil
+}
+
+// InfluxDBConfig writes the resolver's metrics to InfluxDB, or another endpoint taking the
+// InfluxDB line protocol, for long-term dashboards without Prometheus
+type InfluxDBConfig struct {
+	URL      string `yaml:"url"`                // Write endpoint, e.g. http://localhost:8086/api/v2/write?org=home&bucket=sinkzone
+	Token    string `yaml:"token,omitempty"`    // Sent as "Authorization: Token <token>" (InfluxDB 2)
+	Username string `yaml:"username,omitempty"` // Sent with password as basic auth (InfluxDB 1, Grafana Cloud)
+	Password string `yaml:"password,omitempty"`
+	Interval string `yaml:"interval,omitempty"` // How often the metrics are written (default 1m)
+}
+
+// GetURL returns the write endpoint
+func (c *InfluxDBConfig) GetURL() (string, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid influxdb url %q: use an http:// or https:// URL, e.g. http://localhost:8086/api/v2/write?org=home&bucket=sinkzone", c.URL)
+	}
+	return c.URL, nil
+}
+
+// GetInterval returns how often the metrics are written
+func (c *InfluxDBConfig) GetInterval() (time.Duration, error) {
+	if c.Interval == "" {
+		return time.Minute, nil
+	}
+	interval, err := time.ParseDuration(c.Interval)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid influxdb interval %q: must be a duration of at least 1s", c.Interval)
+	}
+	return interval, nil
+}
+
+// MQTTConfig publishes the focus state to an MQTT broker, announced to Home Assistant
+type MQTTConfig struct {
+	Broker          string `yaml:"broker"`                     // e.g. tcp://homeassistant.local:1883, or tls:// for TLS
+	Username        string `yaml:"username,omitempty"`         // Sent to brokers that require a login
+	Password        string `yaml:"password,omitempty"`         // Sent with username
+	Topic           string `yaml:"topic,omitempty"`            // Prefix of the published topics (default sinkzone)
+	DiscoveryPrefix string `yaml:"discovery_prefix,omitempty"` // Home Assistant discovery prefix (default homeassistant, "none" for no discovery)
+}
+
+// GetBroker returns the host:port of the broker and whether it is reached over TLS
+func (c *MQTTConfig) GetBroker() (string, bool, error) {
+	u, err := url.Parse(c.Broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("invalid mqtt broker %q: use a URL, e.g. tcp://homeassistant.local:1883", c.Broker)
+	}
+	var useTLS bool
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "tls", "ssl", "mqtts":
+		useTLS, port = true, "8883"
+	default:
+		return "", false, fmt.Errorf("invalid mqtt broker %q: use tcp:// or tls://", c.Broker)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+// GetTopic returns the prefix of the published topics
+func (c *MQTTConfig) GetTopic() (string, error) {
+	topic := strings.Trim(c.Topic, "/")
+	if c.Topic == "" {
+		topic = "sinkzone"
+	}
+	if topic == "" || strings.ContainsAny(topic, "+#") {
+		return "", fmt.Errorf("invalid mqtt topic %q: must not be empty or contain + or #", c.Topic)
+	}
+	return topic, nil
+}
+
+// GetDiscoveryPrefix returns the Home Assistant discovery prefix, or "" for no discovery
+func (c *MQTTConfig) GetDiscoveryPrefix() string {
+	switch c.DiscoveryPrefix {
+	case "":
+		return "homeassistant"
+	case "none":
+		return ""
+	}
+	return strings.Trim(c.DiscoveryPrefix, "/")
+}
+
+// TailscaleConfig joins the resolver to a tailnet as a machine of its own (tsnet), so the
+// devices of the tailnet can use its DNS wherever they are
+type TailscaleConfig struct {
+	Enabled  *bool  `yaml:"enabled,omitempty"`   // Join the tailnet (default false)
+	Hostname string `yaml:"hostname,omitempty"`  // Machine name on the tailnet (default sinkzone)
+	AuthKey  string `yaml:"auth_key,omitempty"`  // Joins without a login in the browser (default $TS_AUTHKEY)
+	StateDir string `yaml:"state_dir,omitempty"` // Keys and state of the machine (default tailscale/ in the sinkzone directory)
+	API      *bool  `yaml:"api,omitempty"`       // Also serve the HTTP API on the tailnet (default false; needs api_tokens)
+}
+
+// IsEnabled reports whether the resolver joins a tailnet
+func (c *TailscaleConfig) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+// ServesAPI reports whether the HTTP API is served on the tailnet too
+func (c *TailscaleConfig) ServesAPI() bool {
+	return c.IsEnabled() && c.API != nil && *c.API
+}
+
+// GetHostname returns the machine name on the tailnet
+func (c *TailscaleConfig) GetHostname() (string, error) {
+	if c == nil || c.Hostname == "" {
+		return "sinkzone", nil
+	}
+	if !isHostnameLabel(c.Hostname) {
+		return "", fmt.Errorf("invalid tailscale hostname %q: use letters, digits, and hyphens, e.g. sinkzone", c.Hostname)
+	}
+	return c.Hostname, nil
+}
+
+// GetStateDir returns the directory the tailnet machine keeps its keys in
+func (c *TailscaleConfig) GetStateDir() string {
+	switch {
+	case c == nil || c.StateDir == "":
+		return filepath.Join(GetDataDir(), "tailscale")
+	case filepath.IsAbs(c.StateDir):
+		return c.StateDir
+	}
+	return filepath.Join(GetDataDir(), c.StateDir)
+}
+
+// isHostnameLabel reports whether name is a single DNS label: letters, digits, and inner hyphens
+func isHostnameLabel(name string) bool {
+	if name == "" || len(name) > 63 || strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// CrashReportsConfig turns on reports of crashes of the resolver and the TUI
+type CrashReportsConfig struct {
+	Enabled   *bool  `yaml:"enabled,omitempty"`    // Write a report to the crashes directory (default false)
+	UploadURL string `yaml:"upload_url,omitempty"` // Also post each report here as JSON (default: keep them local)
+}
+
+// IsEnabled reports whether crash reports are written
+func (c *CrashReportsConfig) IsEnabled() bool {
+	return c != nil && c.Enabled != nil && *c.Enabled
+}
+
+// GetUploadURL returns where crash reports are posted, or "" to keep them local
+func (c *CrashReportsConfig) GetUploadURL() (string, error) {
+	if c == nil || c.UploadURL == "" {
+		return "", nil
+	}
+	u, err := url.Parse(c.UploadURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid crash_reports upload_url %q: use an http or https URL", c.UploadURL)
+	}
+	return c.UploadURL, nil
+}
+
+// NotifyConfig enables notifications about focus sessions: on the desktop, in Slack or
+// Discord channels, and pushed to phones
+type NotifyConfig struct {
+	WarnBefore     string       `yaml:"warn_before,omitempty"`     // How long before the end to warn (default 5m, 0 to disable)
+	Desktop        *bool        `yaml:"desktop,omitempty"`         // Show desktop notifications (default true)
+	SlackWebhook   string       `yaml:"slack_webhook,omitempty"`   // Slack incoming webhook URL session events are posted to
+	DiscordWebhook string       `yaml:"discord_webhook,omitempty"` // Discord webhook URL session events are posted to
+	DailySummary   string       `yaml:"daily_summary,omitempty"`   // Time of day the day's focus time is posted, e.g. 18:00 (default none)
+	PressureAlert  int          `yaml:"pressure_alert,omitempty"`  // Distraction pressure (blocked attempts per minute) that prompts a break (default 0, off)
+	Push           []PushConfig `yaml:"push,omitempty"`            // Push notifiers, each with its own events
+}
+
+// PushConfig is a push notifier: an ntfy topic or a Pushover user
+type PushConfig struct {
+	Service        string `yaml:"service"`                   // "ntfy" or "pushover"
+	URL            string `yaml:"url,omitempty"`             // ntfy topic URL, e.g. https://ntfy.sh/my-focus
+	Token          string `yaml:"token,omitempty"`           // ntfy access token, or the Pushover application token
+	User           string `yaml:"user,omitempty"`            // Pushover user or group key
+	Sessions       string `yaml:"sessions,omitempty"`        // Sessions whose start and end are pushed: strict (default), all, or none
+	SpikeThreshold int    `yaml:"spike_threshold,omitempty"` // Blocked queries within spike_window that send a push (default 0, off)
+	SpikeWindow    string `yaml:"spike_window,omitempty"`    // Window blocked queries are counted in (default 5m)
+}
+
+// CalendarConfig drives focus mode from an iCalendar (ICS) feed
+type CalendarConfig struct {
+	URL     string `yaml:"url"`               // http(s) URL or local file path
+	Mode    string `yaml:"mode,omitempty"`    // "tagged" (default) or "busy"
+	Tag     string `yaml:"tag,omitempty"`     // Tag marking focus events in tagged mode (default "#focus")
+	Refresh string `yaml:"refresh,omitempty"` // How often to re-fetch the calendar (default 15m)
+	Profile string `yaml:"profile,omitempty"` // Focus profile used for calendar sessions
+}
+
+// GoogleCalendarConfig drives focus mode from Google Calendar, read through its API with
+// the token 'sinkzone calendar login' keeps in the data directory
+type GoogleCalendarConfig struct {
+	ClientID     string   `yaml:"client_id"`               // OAuth client of a Google Cloud project, of the Desktop app type
+	ClientSecret string   `yaml:"client_secret,omitempty"` // Secret of the OAuth client
+	Calendars    []string `yaml:"calendars,omitempty"`     // Names of the calendars read (default: the primary calendar)
+	Title        string   `yaml:"title,omitempty"`         // Regular expression of the titles that start focus mode (default: every busy event)
+	Refresh      string   `yaml:"refresh,omitempty"`       // How often the events are fetched (default 5m)
+	Profile      string   `yaml:"profile,omitempty"`       // Focus profile used for the sessions
+}
+
+// GetTitle returns the expression of the titles that start focus mode, or nil for every
+// busy event
+func (c *GoogleCalendarConfig) GetTitle() (*regexp.Regexp, error) {
+	if c.Title == "" {
+		return nil, nil
+	}
+	title, err := regexp.Compile(c.Title)
+	if err != nil {
+		return nil, fmt.Errorf("invalid google_calendar title %q: %w", c.Title, err)
+	}
+	return title, nil
+}
+
+// GetRefresh returns how often the events are fetched
+func (c *GoogleCalendarConfig) GetRefresh() (time.Duration, error) {
+	if c.Refresh == "" {
+		return 5 * time.Minute, nil
+	}
+	refresh, err := time.ParseDuration(c.Refresh)
+	if err != nil || refresh < time.Minute {
+		return 0, fmt.Errorf("invalid google_calendar refresh %q: must be a duration of at least 1m", c.Refresh)
+	}
+	return refresh, nil
+}
+
+// Calendar modes
+const (
+	CalendarModeTagged = "tagged"
+	CalendarModeBusy   = "busy"
+)
+
+// Push notification services
+const (
+	PushNtfy     = "ntfy"
+	PushPushover = "pushover"
+)
+
+// Sessions a push notifier reports on; strict sessions run at the hard intensity or are
+// locked with the focus PIN
+const (
+	PushSessionsStrict = "strict"
+	PushSessionsAll    = "all"
+	PushSessionsNone   = "none"
+)
+
+// Focus intensities
+const (
+	IntensitySoft   = "soft"   // Only deny-listed domains are blocked
+	IntensityNormal = "normal" // Everything not allowlisted is blocked
+	IntensityHard   = "hard"   // Like normal, but wildcard matches only allow domains seen before the session
+)
+
+// Values accepted by focus_on_start besides a duration
+const (
+	FocusOnStartResume     = "resume"     // Restore the session that was active when the resolver stopped
+	FocusOnStartIndefinite = "indefinite" // Start focus mode with no expiration
+)
+
+// Profile is a named focus preset with its own allowlist and default duration. A profile
+// that extends another adds to its allowlist and inherits the settings it leaves unset.
+type Profile struct {
+	Extends             string   `yaml:"extends,omitempty"` // Profile this one builds on
+	Duration            string   `yaml:"duration,omitempty"`
+	Allowlist           []string `yaml:"allowlist,omitempty"`
+	UseDefaultAllowlist bool     `yaml:"use_default_allowlist,omitempty"`
 }
 
 func Load() (*Config, error) {
-	configPath := getConfigPath()
+	configPath := GetConfigPath()
+
+	if !dataDirKnown() {
+		return nil, ErrNoDataDir
+	}
 
 	// Create config directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(configPath), 0750); err != nil {
+		if os.Getenv(ConfigDirEnv) == "" {
+			return nil, fmt.Errorf("failed to create config directory: %w (set %s or --data-dir to keep sinkzone's files elsewhere)", err, ConfigDirEnv)
+		}
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Load existing config or create default
+	// Start from the system-wide config, if there is one
 	cfg := &Config{}
+	hasSystem, err := cfg.loadSystemConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load existing config or create default
 	if _, err := os.Stat(configPath); err == nil {
 		// #nosec G304 -- configPath is a hardcoded path from user home directory
 		data, err := os.ReadFile(configPath)
@@ -31,12 +590,24 @@ func Load() (*Config, error) {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 
-		if err := yaml.Unmarshal(data, cfg); err != nil {
+		// Upgrade files written by older versions of sinkzone
+		migrated, version, err := migrate(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(migrated, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		if err := yaml.Unmarshal(migrated, &cfg.user); err != nil {
 			return nil, fmt.Errorf("failed to parse config file: %w", err)
 		}
-	} else {
+		if version < CurrentConfigVersion {
+			upgradeConfig(configPath, data, version, cfg)
+		}
+	} else if !hasSystem {
 		// Create default config
 		cfg = &Config{
+			Version:             CurrentConfigVersion,
 			UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1"},
 		}
 
@@ -46,24 +617,33 @@ func Load() (*Config, error) {
 		}
 	}
 
-	// Check for environment variable override
-	if envNameservers := os.Getenv("SINKZONE_UPSTREAM_NAMESERVERS"); envNameservers != "" {
-		// Split by comma if multiple nameservers are provided
-		nameservers := strings.Split(envNameservers, ",")
-		// Trim whitespace from each nameserver
-		for i, ns := range nameservers {
-			nameservers[i] = strings.TrimSpace(ns)
-		}
-		cfg.UpstreamNameservers = nameservers
+	// Apply SINKZONE_* environment variable overrides
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validateProfiles(); err != nil {
+		return nil, err
 	}
 
 	return cfg, nil
 }
 
+// Save writes the config file. Values overridden by environment variables are written as
+// they were in the file, and values inherited from the system-wide file are left out.
 func Save(cfg *Config) error {
-	configPath := getConfigPath()
+	configPath := GetConfigPath()
 
-	data, err := yaml.Marshal(cfg)
+	if cfg.Version == 0 {
+		cfg.Version = CurrentConfigVersion
+	}
+
+	var data []byte
+	err := cfg.withFileValues(func() error {
+		var err error
+		data, err = cfg.marshalUserLayer()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %w", err)
 	}
@@ -75,9 +655,70 @@ func Save(cfg *Config) error {
 	return nil
 }
 
-func getConfigPath() string {
-	homeDir, err := os.UserHomeDir()
+// ConfigDirEnv names the environment variable that moves every sinkzone file (config,
+// allowlist, state, PID file, and logs) to another directory
+const ConfigDirEnv = "SINKZONE_CONFIG_DIR"
+
+// configPath replaces the default config file when set with SetConfigPath
+var configPath string
+
+// SetConfigPath makes Load and Save use the config file at path ("" restores the default)
+func SetConfigPath(path string) {
+	configPath = path
+}
+
+// GetExportsDir returns the directory exports from the TUI are written to
+func GetExportsDir() string {
+	return filepath.Join(GetDataDir(), "exports")
+}
+
+// GetDNSBackupPath returns where 'sinkzone setup' saves the system DNS settings it replaced
+func GetDNSBackupPath() string {
+	return filepath.Join(GetDataDir(), "dns-backup.json")
+}
+
+// GetCrashDir returns where crash reports are written
+func GetCrashDir() string {
+	return filepath.Join(GetDataDir(), "crashes")
+}
+
+// GetGoogleTokenPath returns where 'sinkzone calendar login' keeps the Google Calendar token
+func GetGoogleTokenPath() string {
+	return filepath.Join(GetDataDir(), "google-token.json")
+}
+
+// GetQueryLogPath returns the database the resolver keeps its query history in
+func GetQueryLogPath() string {
+	return filepath.Join(GetDataDir(), "queries.db")
+}
+
+// InvokingUser returns the user who started sinkzone with sudo, while it runs as root
+func InvokingUser() (*user.User, bool) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		return nil, false
+	}
+	name := os.Getenv("SUDO_USER")
+	if name == "" || name == "root" {
+		return nil, false
+	}
+	u, err := user.Lookup(name)
 	if err != nil {
+		return nil, false
+	}
+	return u, true
+}
+
+// GetDataDir returns the directory sinkzone keeps its files in: $SINKZONE_CONFIG_DIR if
+// set, otherwise ~/.sinkzone (%APPDATA%\sinkzone on Windows). Under sudo, ~ is the home
+// of the user who ran sudo, not root's. Without a home directory Load fails with
+// ErrNoDataDir rather than scattering files in the working directory.
+func GetDataDir() string {
+	if dir := os.Getenv(ConfigDirEnv); dir != "" {
+		return dir
+	}
+
+	homeDir, ok := homeDir()
+	if !ok {
 		homeDir = "."
 	}
 
@@ -86,26 +727,436 @@ func getConfigPath() string {
 		// On Windows, use AppData for better compatibility
 		appData := os.Getenv("APPDATA")
 		if appData != "" {
-			return filepath.Join(appData, "sinkzone", "sinkzone.yaml")
+			return filepath.Join(appData, "sinkzone")
 		}
 		// Fallback to user home directory
-		return filepath.Join(homeDir, "sinkzone", "sinkzone.yaml")
+		return filepath.Join(homeDir, "sinkzone")
 	}
 
 	// Unix-like systems use ~/.sinkzone/
-	return filepath.Join(homeDir, ".sinkzone", "sinkzone.yaml")
+	return filepath.Join(homeDir, ".sinkzone")
+}
+
+// ErrNoDataDir is returned by Load when there is no home directory to keep sinkzone's files
+// in, e.g. in a container running as a user without one, and no other directory was given
+var ErrNoDataDir = errors.New("no home directory to keep sinkzone's files in: set " + ConfigDirEnv + " or --data-dir")
+
+// homeDir returns the home directory of the user, or of the user who ran sudo, and whether
+// there is one: $HOME may be unset or point at a directory that doesn't exist
+func homeDir() (string, bool) {
+	home, err := os.UserHomeDir()
+	if u, ok := InvokingUser(); ok {
+		home, err = u.HomeDir, nil
+	}
+	if err != nil || home == "" {
+		return "", false
+	}
+	if info, err := os.Stat(home); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return home, true
+}
+
+// dataDirKnown reports whether GetDataDir has a directory to go by rather than falling back
+// to the working directory
+func dataDirKnown() bool {
+	if os.Getenv(ConfigDirEnv) != "" || (runtime.GOOS == "windows" && os.Getenv("APPDATA") != "") {
+		return true
+	}
+	_, ok := homeDir()
+	return ok
+}
+
+// GetConfigPath returns the path of the config file: the one chosen with --config, or
+// sinkzone.yaml in the data directory
+func GetConfigPath() string {
+	if configPath != "" {
+		return configPath
+	}
+	return filepath.Join(GetDataDir(), "sinkzone.yaml")
+}
+
+// GetFocusGracePeriod returns how long after enabling focus mode blocked queries are only warned about
+func (c *Config) GetFocusGracePeriod() (time.Duration, error) {
+	if c.FocusGracePeriod == "" {
+		return 0, nil
+	}
+	grace, err := time.ParseDuration(c.FocusGracePeriod)
+	if err != nil {
+		return 0, fmt.Errorf("invalid focus_grace_period %q: %w", c.FocusGracePeriod, err)
+	}
+	if grace < 0 {
+		return 0, fmt.Errorf("invalid focus_grace_period %q: must not be negative", c.FocusGracePeriod)
+	}
+	return grace, nil
+}
+
+// GetFocusDisableDelay returns how long a disable request waits before focus mode ends
+func (c *Config) GetFocusDisableDelay() (time.Duration, error) {
+	if c.FocusDisableDelay == "" {
+		return 0, nil
+	}
+	delay, err := time.ParseDuration(c.FocusDisableDelay)
+	if err != nil {
+		return 0, fmt.Errorf("invalid focus_disable_delay %q: %w", c.FocusDisableDelay, err)
+	}
+	if delay < 0 {
+		return 0, fmt.Errorf("invalid focus_disable_delay %q: must not be negative", c.FocusDisableDelay)
+	}
+	return delay, nil
 }
 
-// GetUpstreamAddresses returns the upstream nameservers with port 53 appended
-func (c *Config) GetUpstreamAddresses() []string {
-	addresses := make([]string, len(c.UpstreamNameservers))
-	for i, addr := range c.UpstreamNameservers {
-		// If the address doesn't already have a port, append :53
-		if !strings.Contains(addr, ":") {
-			addresses[i] = addr + ":53"
-		} else {
-			addresses[i] = addr
+// ParseIntensity validates a focus intensity, mapping "" to normal
+func ParseIntensity(value string) (string, error) {
+	switch value {
+	case "", IntensityNormal:
+		return IntensityNormal, nil
+	case IntensitySoft, IntensityHard:
+		return value, nil
+	default:
+		return "", fmt.Errorf("invalid focus intensity %q: use %q, %q, or %q", value, IntensitySoft, IntensityNormal, IntensityHard)
+	}
+}
+
+// GetFocusIntensity returns the intensity used when a session doesn't choose one
+func (c *Config) GetFocusIntensity() (string, error) {
+	return ParseIntensity(c.FocusIntensity)
+}
+
+// GetFocusOnStart parses focus_on_start, returning the mode ("" when disabled, "resume",
+// "indefinite", or "duration") and the duration for the "duration" mode
+func (c *Config) GetFocusOnStart() (string, time.Duration, error) {
+	switch c.FocusOnStart {
+	case "", "off", "false":
+		return "", 0, nil
+	case FocusOnStartResume, FocusOnStartIndefinite:
+		return c.FocusOnStart, 0, nil
+	}
+
+	duration, err := time.ParseDuration(c.FocusOnStart)
+	if err != nil || duration <= 0 {
+		return "", 0, fmt.Errorf("invalid focus_on_start %q: use a duration, %q, or %q", c.FocusOnStart, FocusOnStartResume, FocusOnStartIndefinite)
+	}
+	return "duration", duration, nil
+}
+
+// GetProfile returns the named focus profile with the settings it inherits filled in
+func (c *Config) GetProfile(name string) (*Profile, error) {
+	profile, err := c.resolveProfile(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// resolveProfile merges a profile over the profiles it extends: its allowlist follows the
+// base's, and an unset duration or use_default_allowlist comes from the base. chain holds
+// the profiles that led here, to detect cycles.
+func (c *Config) resolveProfile(name string, chain []string) (Profile, error) {
+	if i := slices.Index(chain, name); i >= 0 {
+		return Profile{}, fmt.Errorf("profiles extend each other in a cycle: %s", strings.Join(append(chain[i:], name), " -> "))
+	}
+	profile, ok := c.Profiles[name]
+	if !ok {
+		if len(chain) > 0 {
+			return Profile{}, fmt.Errorf("profile %s extends unknown profile: %s", chain[len(chain)-1], name)
+		}
+		return Profile{}, fmt.Errorf("unknown profile: %s", name)
+	}
+	profile.Allowlist = slices.Clone(profile.Allowlist)
+	if profile.Extends == "" {
+		return profile, nil
+	}
+
+	base, err := c.resolveProfile(profile.Extends, append(chain, name))
+	if err != nil {
+		return Profile{}, err
+	}
+	if profile.Duration == "" {
+		profile.Duration = base.Duration
+	}
+	profile.Allowlist = append(base.Allowlist, profile.Allowlist...)
+	profile.UseDefaultAllowlist = profile.UseDefaultAllowlist || base.UseDefaultAllowlist
+	return profile, nil
+}
+
+// validateProfiles resolves every profile, so a missing base or a cycle fails at load time
+func (c *Config) validateProfiles() error {
+	for _, name := range c.ProfileNames() {
+		if _, err := c.GetProfile(name); err != nil {
+			return fmt.Errorf("invalid profiles: %w", err)
+		}
+	}
+	return nil
+}
+
+// ProfileNames returns the configured profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateProfileName checks the name of a new profile. "default" is reserved for the
+// default allowlist.
+func ValidateProfileName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("profile name is required")
+	case name == "default" || name == "none":
+		return fmt.Errorf("%q is reserved for the default allowlist", name)
+	case strings.ContainsAny(name, " \t/\\"):
+		return fmt.Errorf("invalid profile name %q: must not contain spaces or slashes", name)
+	}
+	return nil
+}
+
+// CreateProfile adds a profile, copied from an existing one when from is set
+func (c *Config) CreateProfile(name, from string) (*Profile, error) {
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+	if _, ok := c.Profiles[name]; ok {
+		return nil, fmt.Errorf("profile %s already exists", name)
+	}
+
+	profile := Profile{}
+	if from != "" {
+		if _, err := c.GetProfile(from); err != nil {
+			return nil, err
 		}
+		// Copy the profile as written, so the copy extends the same base
+		profile = c.Profiles[from]
+		profile.Allowlist = slices.Clone(profile.Allowlist)
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]Profile)
+	}
+	c.Profiles[name] = profile
+	return &profile, nil
+}
+
+// DeleteProfile removes a profile. It refuses while another profile extends it or a
+// calendar, a process trigger, a schedule, or macos_focus still uses it, and stops using it
+// as the active profile.
+func (c *Config) DeleteProfile(name string) error {
+	if _, ok := c.Profiles[name]; !ok {
+		return fmt.Errorf("unknown profile: %s", name)
+	}
+	for _, other := range c.ProfileNames() {
+		if c.Profiles[other].Extends == name {
+			return fmt.Errorf("profile %s is extended by profile %s; change that first", name, other)
+		}
+	}
+	if c.Calendar != nil && c.Calendar.Profile == name {
+		return fmt.Errorf("profile %s is used by calendar.profile; change that first", name)
+	}
+	if c.GoogleCalendar != nil && c.GoogleCalendar.Profile == name {
+		return fmt.Errorf("profile %s is used by google_calendar.profile; change that first", name)
+	}
+	for _, trigger := range c.ProcessTriggers {
+		if trigger.Profile == name {
+			return fmt.Errorf("profile %s is used by the process trigger for %s; change that first", name, trigger.Process)
+		}
+	}
+	for _, schedule := range c.Schedules {
+		if schedule.Profile == name {
+			return fmt.Errorf("profile %s is used by the schedule %q; remove that first", name, schedule.When)
+		}
+	}
+	if c.MacOSFocus != nil && c.MacOSFocus.Profile == name {
+		return fmt.Errorf("profile %s is used by macos_focus.profile; change that first", name)
+	}
+
+	delete(c.Profiles, name)
+	if c.ActiveProfile == name {
+		c.ActiveProfile = ""
+	}
+	return nil
+}
+
+// GetDuration returns the profile's default session length (0 if unset)
+func (p *Profile) GetDuration() (time.Duration, error) {
+	if p.Duration == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(p.Duration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid profile duration %q: %w", p.Duration, err)
+	}
+	return duration, nil
+}
+
+// GetWarnBefore returns how long before a session ends to send a warning
+func (n *NotifyConfig) GetWarnBefore() (time.Duration, error) {
+	if n.WarnBefore == "" {
+		return 5 * time.Minute, nil
+	}
+	warnBefore, err := time.ParseDuration(n.WarnBefore)
+	if err != nil || warnBefore < 0 {
+		return 0, fmt.Errorf("invalid notifications warn_before %q: must be a non-negative duration", n.WarnBefore)
+	}
+	return warnBefore, nil
+}
+
+// IsDesktop reports whether desktop notifications are shown
+func (n *NotifyConfig) IsDesktop() bool {
+	return n.Desktop == nil || *n.Desktop
+}
+
+// GetWebhooks returns the Slack and Discord webhook URLs, "" for those not set
+func (n *NotifyConfig) GetWebhooks() (slack, discord string, err error) {
+	for _, webhook := range []struct{ name, url string }{{"slack_webhook", n.SlackWebhook}, {"discord_webhook", n.DiscordWebhook}} {
+		if webhook.url == "" {
+			continue
+		}
+		u, err := url.Parse(webhook.url)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return "", "", fmt.Errorf("invalid notifications %s %q: use an https URL", webhook.name, webhook.url)
+		}
+	}
+	return n.SlackWebhook, n.DiscordWebhook, nil
+}
+
+// Validate checks the service of a push notifier and the settings it needs
+func (p *PushConfig) Validate() error {
+	switch p.Service {
+	case PushNtfy:
+		u, err := url.Parse(p.URL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" || strings.Trim(u.Path, "/") == "" {
+			return fmt.Errorf("invalid notifications push url %q: use the http(s) URL of an ntfy topic, e.g. https://ntfy.sh/my-focus", p.URL)
+		}
+	case PushPushover:
+		if p.Token == "" || p.User == "" {
+			return fmt.Errorf("notifications push for pushover needs the token of an application and a user key")
+		}
+	default:
+		return fmt.Errorf("invalid notifications push service %q: use %q or %q", p.Service, PushNtfy, PushPushover)
+	}
+	if _, err := p.GetSessions(); err != nil {
+		return err
+	}
+	_, _, err := p.GetSpike()
+	return err
+}
+
+// GetSessions returns which sessions a push notifier reports starting and ending
+func (p *PushConfig) GetSessions() (string, error) {
+	switch p.Sessions {
+	case "":
+		return PushSessionsStrict, nil
+	case PushSessionsStrict, PushSessionsAll, PushSessionsNone:
+		return p.Sessions, nil
+	}
+	return "", fmt.Errorf("invalid notifications push sessions %q: use %q, %q, or %q", p.Sessions, PushSessionsStrict, PushSessionsAll, PushSessionsNone)
+}
+
+// GetSpike returns how many blocked queries within the window send a push, 0 when spikes
+// aren't pushed
+func (p *PushConfig) GetSpike() (int, time.Duration, error) {
+	if p.SpikeThreshold < 0 {
+		return 0, 0, fmt.Errorf("invalid notifications push spike_threshold %d: must not be negative", p.SpikeThreshold)
+	}
+	if p.SpikeWindow == "" {
+		return p.SpikeThreshold, 5 * time.Minute, nil
+	}
+	window, err := time.ParseDuration(p.SpikeWindow)
+	if err != nil || window < time.Minute {
+		return 0, 0, fmt.Errorf("invalid notifications push spike_window %q: must be a duration of at least 1m", p.SpikeWindow)
+	}
+	return p.SpikeThreshold, window, nil
+}
+
+// GetDailySummary returns the time after midnight the daily summary is posted at, or -1
+// for no summary
+func (n *NotifyConfig) GetDailySummary() (time.Duration, error) {
+	if n.DailySummary == "" {
+		return -1, nil
+	}
+	at, err := time.Parse("15:04", n.DailySummary)
+	if err != nil {
+		return 0, fmt.Errorf("invalid notifications daily_summary %q: use a time of day, e.g. 18:00", n.DailySummary)
+	}
+	return time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute, nil
+}
+
+// GetPressureAlert returns the distraction pressure, in blocked attempts per minute, that
+// shows a notification to take a break or recommit, 0 when none is shown
+func (n *NotifyConfig) GetPressureAlert() (int, error) {
+	if n.PressureAlert < 0 {
+		return 0, fmt.Errorf("invalid notifications pressure_alert %d: must not be negative", n.PressureAlert)
+	}
+	return n.PressureAlert, nil
+}
+
+// GetInterval returns how often sync peers are polled
+func (c *SyncConfig) GetInterval() (time.Duration, error) {
+	if c.Interval == "" {
+		return 10 * time.Second, nil
+	}
+	interval, err := time.ParseDuration(c.Interval)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid sync interval %q: must be a duration of at least 1s", c.Interval)
+	}
+	return interval, nil
+}
+
+// GetRefresh returns how often the TUI polls the resolver
+func (c *TUIConfig) GetRefresh() (time.Duration, error) {
+	if c == nil || c.Refresh == "" {
+		return 3 * time.Second, nil
+	}
+	return parseTUIInterval("refresh", c.Refresh)
+}
+
+// GetAllowlistReload returns how often the TUI reloads the allowlist file
+func (c *TUIConfig) GetAllowlistReload() (time.Duration, error) {
+	if c == nil || c.AllowlistReload == "" {
+		return 5 * time.Second, nil
+	}
+	return parseTUIInterval("allowlist_reload", c.AllowlistReload)
+}
+
+func parseTUIInterval(name, value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid tui %s %q: must be a duration of at least 1s", name, value)
+	}
+	return interval, nil
+}
+
+// GetRefresh returns the calendar refresh interval
+func (c *CalendarConfig) GetRefresh() (time.Duration, error) {
+	if c.Refresh == "" {
+		return 15 * time.Minute, nil
+	}
+	refresh, err := time.ParseDuration(c.Refresh)
+	if err != nil || refresh < time.Minute {
+		return 0, fmt.Errorf("invalid calendar refresh %q: must be a duration of at least 1m", c.Refresh)
+	}
+	return refresh, nil
+}
+
+// GetMode returns the calendar mode, defaulting to tagged
+func (c *CalendarConfig) GetMode() (string, error) {
+	switch c.Mode {
+	case "", CalendarModeTagged:
+		return CalendarModeTagged, nil
+	case CalendarModeBusy:
+		return CalendarModeBusy, nil
+	default:
+		return "", fmt.Errorf("invalid calendar mode %q: use %q or %q", c.Mode, CalendarModeTagged, CalendarModeBusy)
+	}
+}
+
+// GetTag returns the tag marking focus events, defaulting to #focus
+func (c *CalendarConfig) GetTag() string {
+	if c.Tag == "" {
+		return "#focus"
 	}
-	return addresses
+	return c.Tag
 }
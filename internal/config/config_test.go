@@ -0,0 +1,170 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCreateAndDeleteProfile(t *testing.T) {
+	cfg := &Config{Profiles: map[string]Profile{
+		"deep-work": {Duration: "90m", Allowlist: []string{"github.com"}},
+	}}
+
+	for _, name := range []string{"", "default", "deep work", "deep-work"} {
+		if _, err := cfg.CreateProfile(name, ""); err == nil {
+			t.Errorf("expected profile name %q to be rejected", name)
+		}
+	}
+	if _, err := cfg.CreateProfile("writing", "missing"); err == nil {
+		t.Error("expected copying an unknown profile to fail")
+	}
+
+	copied, err := cfg.CreateProfile("writing", "deep-work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Duration != "90m" || len(copied.Allowlist) != 1 {
+		t.Errorf("expected a copy of deep-work, got %+v", copied)
+	}
+	copied.Allowlist[0] = "docs.google.com"
+	if cfg.Profiles["deep-work"].Allowlist[0] != "github.com" {
+		t.Error("expected the copy not to share the source allowlist")
+	}
+
+	cfg.ActiveProfile = "writing"
+	cfg.Calendar = &CalendarConfig{URL: "cal.ics", Profile: "deep-work"}
+	if err := cfg.DeleteProfile("deep-work"); err == nil {
+		t.Error("expected deleting a profile used by the calendar to fail")
+	}
+	if err := cfg.DeleteProfile("writing"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cfg.Profiles["writing"]; ok || cfg.ActiveProfile != "" {
+		t.Errorf("expected writing to be deleted and no longer active, got %+v", cfg)
+	}
+}
+
+func TestProfileExtends(t *testing.T) {
+	cfg := &Config{Profiles: map[string]Profile{
+		"base":   {Duration: "90m", Allowlist: []string{"github.com"}, UseDefaultAllowlist: true},
+		"review": {Extends: "base", Allowlist: []string{"gitlab.com"}},
+		"short":  {Extends: "review", Duration: "25m"},
+	}}
+	if err := cfg.validateProfiles(); err != nil {
+		t.Fatal(err)
+	}
+
+	short, err := cfg.GetProfile("short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if short.Duration != "25m" || !short.UseDefaultAllowlist {
+		t.Errorf("expected short to keep its duration and inherit the default allowlist, got %+v", short)
+	}
+	if got := strings.Join(short.Allowlist, ","); got != "github.com,gitlab.com" {
+		t.Errorf("expected the inherited allowlists in order, got %s", got)
+	}
+	if review, _ := cfg.GetProfile("review"); review.Duration != "90m" {
+		t.Errorf("expected review to inherit the base duration, got %q", review.Duration)
+	}
+	if len(cfg.Profiles["review"].Allowlist) != 1 {
+		t.Error("expected resolving not to change the stored profile")
+	}
+
+	if err := cfg.DeleteProfile("base"); err == nil {
+		t.Error("expected deleting an extended profile to fail")
+	}
+	copied, err := cfg.CreateProfile("copy", "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if copied.Extends != "base" || len(copied.Allowlist) != 1 {
+		t.Errorf("expected the copy to extend base like review, got %+v", copied)
+	}
+
+	cfg.Profiles["base"] = Profile{Extends: "short"}
+	if err := cfg.validateProfiles(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle error, got %v", err)
+	}
+	cfg.Profiles["base"] = Profile{Extends: "missing"}
+	if err := cfg.validateProfiles(); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected an unknown base error, got %v", err)
+	}
+}
+
+func TestGetFocusGracePeriod(t *testing.T) {
+	tests := []struct {
+		value string
+		grace time.Duration
+		fails bool
+	}{
+		{"", 0, false},
+		{"0s", 0, false},
+		{"2m", 2 * time.Minute, false},
+		{"-1m", 0, true},
+		{"soon", 0, true},
+	}
+
+	for _, test := range tests {
+		cfg := &Config{FocusGracePeriod: test.value}
+		grace, err := cfg.GetFocusGracePeriod()
+		if (err != nil) != test.fails || grace != test.grace {
+			t.Errorf("%q: expected %v (failure %v), got %v (%v)", test.value, test.grace, test.fails, grace, err)
+		}
+	}
+}
+
+func TestGetFocusOnStart(t *testing.T) {
+	tests := []struct {
+		value    string
+		mode     string
+		duration time.Duration
+		fails    bool
+	}{
+		{"", "", 0, false},
+		{"off", "", 0, false},
+		{FocusOnStartResume, FocusOnStartResume, 0, false},
+		{FocusOnStartIndefinite, FocusOnStartIndefinite, 0, false},
+		{"45m", "duration", 45 * time.Minute, false},
+		{"0s", "", 0, true},
+		{"always", "", 0, true},
+	}
+
+	for _, test := range tests {
+		cfg := &Config{FocusOnStart: test.value}
+		mode, duration, err := cfg.GetFocusOnStart()
+		if (err != nil) != test.fails || mode != test.mode || duration != test.duration {
+			t.Errorf("%q: expected %q %v (failure %v), got %q %v (%v)", test.value, test.mode, test.duration, test.fails, mode, duration, err)
+		}
+	}
+}
+
+func TestTUIIntervals(t *testing.T) {
+	tests := []struct {
+		tui     *TUIConfig
+		refresh time.Duration
+		reload  time.Duration
+		fails   bool
+	}{
+		{nil, 3 * time.Second, 5 * time.Second, false},
+		{&TUIConfig{}, 3 * time.Second, 5 * time.Second, false},
+		{&TUIConfig{Refresh: "10s", AllowlistReload: "1m"}, 10 * time.Second, time.Minute, false},
+		{&TUIConfig{Refresh: "1s", AllowlistReload: "1s"}, time.Second, time.Second, false},
+		{&TUIConfig{Refresh: "0s", AllowlistReload: "0s"}, 0, 0, true},
+		{&TUIConfig{Refresh: "-5s", AllowlistReload: "-5s"}, 0, 0, true},
+		{&TUIConfig{Refresh: "500ms", AllowlistReload: "500ms"}, 0, 0, true},
+		{&TUIConfig{Refresh: "often", AllowlistReload: "often"}, 0, 0, true},
+	}
+
+	for _, test := range tests {
+		refresh, err := test.tui.GetRefresh()
+		if (err != nil) != test.fails || refresh != test.refresh {
+			t.Errorf("refresh %+v: expected %v (failure %v), got %v (%v)", test.tui, test.refresh, test.fails, refresh, err)
+		}
+		reload, err := test.tui.GetAllowlistReload()
+		if (err != nil) != test.fails || reload != test.reload {
+			t.Errorf("allowlist_reload %+v: expected %v (failure %v), got %v (%v)", test.tui, test.reload, test.fails, reload, err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+)
+
+// Focus modes of a single device
+const (
+	DeviceFocusFollow = "follow" // Blocked while a focus session runs (default)
+	DeviceFocusOn     = "on"     // Blocked as if a focus session ran, whether or not one does
+	DeviceFocusOff    = "off"    // Never blocked
+)
+
+// DeviceFocus overrides the focus session for one device, until the given time if set
+type DeviceFocus struct {
+	Mode  string     `json:"mode"`
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// Active reports whether the override still applies at the given time
+func (f DeviceFocus) Active(now time.Time) bool {
+	return f.Mode != DeviceFocusFollow && (f.Until == nil || now.Before(*f.Until))
+}
+
+// ParseDeviceFocusMode checks a device focus mode
+func ParseDeviceFocusMode(mode string) (string, error) {
+	switch mode {
+	case DeviceFocusFollow, DeviceFocusOn, DeviceFocusOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid device focus mode %q: use follow, on, or off", mode)
+}
+
+// UserIDPrefix starts the device ID of a user account, e.g. user:sam, under which the
+// account's focus can be overridden like a device's
+const UserIDPrefix = "user:"
+
+// UserPolicy applies to the queries of one user account of this machine. Users are told
+// apart on Linux, for queries sent over loopback.
+type UserPolicy struct {
+	Focus     string   `yaml:"focus,omitempty"`     // follow (default), on, or off, as for a device
+	Allowlist []string `yaml:"allowlist,omitempty"` // Allowed for the user on top of the allowlist, wildcards included
+}
+
+// GetUsers returns the user policies, with their focus modes checked
+func (c *Config) GetUsers() (map[string]UserPolicy, error) {
+	users := make(map[string]UserPolicy, len(c.Users))
+	for name, policy := range c.Users {
+		if strings.TrimSpace(name) == "" || strings.ContainsAny(name, ": ") {
+			return nil, fmt.Errorf("invalid users entry %q: use the user name of an account", name)
+		}
+		if policy.Focus == "" {
+			policy.Focus = DeviceFocusFollow
+		}
+		if _, err := ParseDeviceFocusMode(policy.Focus); err != nil {
+			return nil, fmt.Errorf("invalid focus of user %s: use follow, on, or off", name)
+		}
+		users[name] = policy
+	}
+	return users, nil
+}
+
+// IsLAN reports whether the resolver serves a whole network (lan, or --lan)
+func (c *Config) IsLAN() bool {
+	return c.LAN != nil && *c.LAN
+}
+
+// DeviceFocusOverrides returns the saved device overrides, keyed by device
+func (sm *StateManager) DeviceFocusOverrides() map[string]DeviceFocus {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return maps.Clone(sm.state.DeviceFocus)
+}
+
+// SetDeviceFocus saves the override of a device; following the session removes it
+func (sm *StateManager) SetDeviceFocus(device string, focus DeviceFocus) error {
+	return sm.update(func(state *State) error {
+		if focus.Mode == DeviceFocusFollow {
+			delete(state.DeviceFocus, device)
+			return nil
+		}
+		if state.DeviceFocus == nil {
+			state.DeviceFocus = make(map[string]DeviceFocus)
+		}
+		state.DeviceFocus[device] = focus
+		return nil
+	})
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Environment variables override config keys for one process without changing the file,
+// e.g. in containers. The variable is SINKZONE_ followed by the key in upper case with dots
+// replaced by underscores: SINKZONE_BLOCK_RESPONSE, SINKZONE_CACHE_SIZE, or
+// SINKZONE_UPSTREAM_NAMESERVERS (list keys take comma-separated values).
+const envPrefix = "SINKZONE_"
+
+// Environment variables for settings outside the config file
+const (
+	ConfigFileEnv = "SINKZONE_CONFIG"  // Config file, like --config
+	APIURLEnv     = "SINKZONE_API_URL" // Resolver API the CLI and TUI talk to
+)
+
+// defaultAPIURL is the API of a resolver on this machine with the default api_listen
+const defaultAPIURL = "http://127.0.0.1:8080"
+
+// DefaultAPIURL returns the resolver API used when --api-url isn't given: $SINKZONE_API_URL,
+// or the local resolver
+func DefaultAPIURL() string {
+	if url := os.Getenv(APIURLEnv); url != "" {
+		return url
+	}
+	return defaultAPIURL
+}
+
+// EnvName returns the environment variable that overrides a key
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// envOverride records a key replaced from the environment, with the value from the file
+type envOverride struct {
+	key   *Key
+	env   string
+	file  []string
+	value []string
+}
+
+// applyEnv overrides keys from the environment. Empty variables are ignored. A value
+// checked against another key, such as api_listen against api_allow_remote, is tried again
+// once the other overrides are applied.
+func (c *Config) applyEnv() error {
+	var pending []*Key
+	for i := range keys {
+		if os.Getenv(EnvName(keys[i].Name)) != "" {
+			pending = append(pending, &keys[i])
+		}
+	}
+	for len(pending) > 0 {
+		var failed []*Key
+		var firstErr error
+		for _, key := range pending {
+			name := EnvName(key.Name)
+			file := slices.Clone(key.get(c))
+			if err := key.Set(c, os.Getenv(name)); err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("invalid %s: %w", name, err)
+				}
+				failed = append(failed, key)
+				continue
+			}
+			c.overrides = append(c.overrides, envOverride{key: key, env: name, file: file, value: slices.Clone(key.get(c))})
+		}
+		if len(failed) == len(pending) {
+			return firstErr
+		}
+		pending = failed
+	}
+	return nil
+}
+
+// OverriddenBy returns the environment variable overriding a key, or "" if none does
+func (c *Config) OverriddenBy(name string) string {
+	for _, override := range c.overrides {
+		if override.key.Name == name {
+			return override.env
+		}
+	}
+	return ""
+}
+
+// withFileValues runs fn with the values from the file restored for keys overridden by the
+// environment, so saving doesn't persist the overrides. Keys changed since Load keep their
+// new value.
+func (c *Config) withFileValues(fn func() error) error {
+	var restored []envOverride
+	for i := len(c.overrides) - 1; i >= 0; i-- {
+		override := c.overrides[i]
+		if slices.Equal(override.key.get(c), override.value) {
+			override.key.set(c, override.file)
+			restored = append(restored, override)
+		}
+	}
+	c.pruneSections()
+
+	err := fn()
+
+	for i := len(restored) - 1; i >= 0; i-- {
+		restored[i].key.set(c, restored[i].value)
+	}
+	c.pruneSections()
+	return err
+}
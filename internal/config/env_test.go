@@ -0,0 +1,90 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverrides(t *testing.T) {
+	t.Setenv(ConfigDirEnv, t.TempDir())
+	if err := Save(&Config{UpstreamNameservers: []string{"8.8.8.8"}, BlockedTTL: "1m"}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("SINKZONE_UPSTREAM_NAMESERVERS", "9.9.9.9, 1.1.1.1")
+	t.Setenv("SINKZONE_BLOCK_RESPONSE", "refused")
+	t.Setenv("SINKZONE_CACHE_SIZE", "0")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9", "1.1.1.1"}) || cfg.BlockResponse != "refused" {
+		t.Errorf("expected the environment to override the file, got %+v", cfg)
+	}
+	if size, _ := cfg.Cache.GetSize(); size != 0 {
+		t.Errorf("expected SINKZONE_CACHE_SIZE to disable the cache, got %d", size)
+	}
+	if got := cfg.OverriddenBy("block_response"); got != "SINKZONE_BLOCK_RESPONSE" {
+		t.Errorf("expected block_response to be overridden by SINKZONE_BLOCK_RESPONSE, got %q", got)
+	}
+
+	// Overrides stay out of the file, while other changes are saved
+	cfg.BlockedTTL = "10s"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(GetConfigPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text := string(data); strings.Contains(text, "9.9.9.9") || strings.Contains(text, "refused") || strings.Contains(text, "cache") || !strings.Contains(text, "10s") {
+		t.Errorf("expected only the blocked_ttl change to be saved, got:\n%s", text)
+	}
+	if cfg.BlockResponse != "refused" {
+		t.Error("expected the override to stay in effect after saving")
+	}
+
+	t.Setenv("SINKZONE_BLOCK_RESPONSE", "blackhole")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SINKZONE_BLOCK_RESPONSE") {
+		t.Errorf("expected an invalid override to be reported, got %v", err)
+	}
+}
+
+func TestEnvOnlyContainerConfig(t *testing.T) {
+	t.Setenv(ConfigDirEnv, t.TempDir())
+	t.Setenv(SystemConfigEnv, "none")
+
+	// api_listen is checked against api_allow_remote, which comes later in the key order
+	t.Setenv("SINKZONE_API_LISTEN", "0.0.0.0:8080")
+	t.Setenv("SINKZONE_API_ALLOW_REMOTE", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if listen, err := cfg.GetAPIListen(); err != nil || listen != "0.0.0.0:8080" {
+		t.Errorf("expected the API to listen on all interfaces, got %q (%v)", listen, err)
+	}
+}
+
+func TestLoadWithoutHomeDir(t *testing.T) {
+	t.Setenv(ConfigDirEnv, "")
+	t.Setenv("HOME", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("SUDO_USER", "")
+	if runtime.GOOS == "windows" {
+		t.Skip("APPDATA always gives a data directory")
+	}
+	if _, err := Load(); !errors.Is(err, ErrNoDataDir) {
+		t.Errorf("expected ErrNoDataDir, got %v", err)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("rate_limit.queries_per_second"); got != "SINKZONE_RATE_LIMIT_QUERIES_PER_SECOND" {
+		t.Errorf("unexpected name %s", got)
+	}
+}
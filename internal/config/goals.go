@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxFocusHistoryDays bounds how many days of focus time are kept in the state file
+const maxFocusHistoryDays = 400
+
+// GoalStats summarizes progress toward the daily focus goal
+type GoalStats struct {
+	Goal          time.Duration
+	Today         time.Duration
+	Week          time.Duration // Focus time since Monday, including today
+	Streak        int           // Consecutive days the goal was met, including today once it is met
+	LongestStreak int
+}
+
+// Met reports whether today's goal has been reached
+func (g GoalStats) Met() bool {
+	return g.Goal > 0 && g.Today >= g.Goal
+}
+
+// GetDailyGoal returns the daily focus time goal (0 if unset)
+func (c *Config) GetDailyGoal() (time.Duration, error) {
+	if c.DailyGoal == "" {
+		return 0, nil
+	}
+	goal, err := time.ParseDuration(c.DailyGoal)
+	if err != nil || goal < 0 {
+		return 0, fmt.Errorf("invalid daily_goal %q: must be a non-negative duration", c.DailyGoal)
+	}
+	return goal, nil
+}
+
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// FocusTimeOn returns the focus time recorded on the given day
+func (s State) FocusTimeOn(day time.Time) time.Duration {
+	return time.Duration(s.DailyFocus[dayKey(day)]) * time.Second
+}
+
+// FocusTimeThisWeek returns the focus time recorded since Monday, including today
+func (s State) FocusTimeThisWeek(now time.Time) time.Duration {
+	// time.Weekday counts from Sunday; weeks here start on Monday
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	var total time.Duration
+	for i := 0; i <= daysSinceMonday; i++ {
+		total += s.FocusTimeOn(now.AddDate(0, 0, -i))
+	}
+	return total
+}
+
+// GoalStats computes today's progress and the goal streaks
+func (s State) GoalStats(goal time.Duration, now time.Time) GoalStats {
+	stats := GoalStats{
+		Goal:  goal,
+		Today: s.FocusTimeOn(now),
+		Week:  s.FocusTimeThisWeek(now),
+	}
+	if goal <= 0 {
+		return stats
+	}
+
+	// The current streak counts back from today, or from yesterday while today's goal is pending
+	day := now
+	if !stats.Met() {
+		day = now.AddDate(0, 0, -1)
+	}
+	for s.FocusTimeOn(day) >= goal {
+		stats.Streak++
+		day = day.AddDate(0, 0, -1)
+	}
+
+	// The longest streak scans the whole history
+	run := 0
+	day = now.AddDate(0, 0, -maxFocusHistoryDays)
+	for !day.After(now) {
+		if s.FocusTimeOn(day) >= goal {
+			run++
+			if run > stats.LongestStreak {
+				stats.LongestStreak = run
+			}
+		} else {
+			run = 0
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return stats
+}
+
+// GoalStats computes goal progress from the current state (thread-safe)
+func (sm *StateManager) GoalStats(goal time.Duration, now time.Time) GoalStats {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state.GoalStats(goal, now)
+}
+
+// LabelTime is the focus time recorded for one session label
+type LabelTime struct {
+	Label string
+	Today time.Duration
+	Total time.Duration
+}
+
+// LabelTimes breaks the recorded focus time down by session label, most focused first
+func (s State) LabelTimes(now time.Time) []LabelTime {
+	totals := make(map[string]*LabelTime)
+	today := dayKey(now)
+	for day, labels := range s.LabelFocus {
+		for label, seconds := range labels {
+			entry, ok := totals[label]
+			if !ok {
+				entry = &LabelTime{Label: label}
+				totals[label] = entry
+			}
+			entry.Total += time.Duration(seconds) * time.Second
+			if day == today {
+				entry.Today += time.Duration(seconds) * time.Second
+			}
+		}
+	}
+
+	result := make([]LabelTime, 0, len(totals))
+	for _, entry := range totals {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Label < result[j].Label
+	})
+	return result
+}
+
+// LabelTimes breaks the recorded focus time down by session label (thread-safe)
+func (sm *StateManager) LabelTimes(now time.Time) []LabelTime {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.state.LabelTimes(now)
+}
+
+// AddFocusTime records focus time between start and end, splitting it across days.
+// A non-empty label also attributes the time to that label.
+func (sm *StateManager) AddFocusTime(start, end time.Time, label string) error {
+	if !end.After(start) {
+		return nil
+	}
+
+	return sm.update(func(state *State) error {
+		if state.DailyFocus == nil {
+			state.DailyFocus = make(map[string]int64)
+		}
+
+		for start.Before(end) {
+			midnight := time.Date(start.Year(), start.Month(), start.Day()+1, 0, 0, 0, 0, start.Location())
+			chunkEnd := end
+			if midnight.Before(end) {
+				chunkEnd = midnight
+			}
+			seconds := int64(chunkEnd.Sub(start).Round(time.Second) / time.Second)
+			state.DailyFocus[dayKey(start)] += seconds
+			if label != "" {
+				if state.LabelFocus == nil {
+					state.LabelFocus = make(map[string]map[string]int64)
+				}
+				if state.LabelFocus[dayKey(start)] == nil {
+					state.LabelFocus[dayKey(start)] = make(map[string]int64)
+				}
+				state.LabelFocus[dayKey(start)][label] += seconds
+			}
+			start = chunkEnd
+		}
+
+		// Drop days that are too old to matter
+		cutoff := dayKey(end.AddDate(0, 0, -maxFocusHistoryDays))
+		for day := range state.DailyFocus {
+			if day < cutoff {
+				delete(state.DailyFocus, day)
+			}
+		}
+		for day := range state.LabelFocus {
+			if day < cutoff {
+				delete(state.LabelFocus, day)
+			}
+		}
+		return nil
+	})
+}
+
+// RecordAllowlistSize notes how many domains the allowlist has now, for the growth shown in
+// reports
+func (sm *StateManager) RecordAllowlistSize(domains int, now time.Time) error {
+	return sm.update(func(state *State) error {
+		if size, ok := state.AllowlistSize[dayKey(now)]; ok && size == domains {
+			return errStateUnchanged
+		}
+		if state.AllowlistSize == nil {
+			state.AllowlistSize = make(map[string]int)
+		}
+		state.AllowlistSize[dayKey(now)] = domains
+
+		cutoff := dayKey(now.AddDate(0, 0, -maxFocusHistoryDays))
+		for day := range state.AllowlistSize {
+			if day < cutoff {
+				delete(state.AllowlistSize, day)
+			}
+		}
+		return nil
+	})
+}
+
+// AllowlistSizeOn returns how many domains the allowlist had at the end of the given day,
+// false when no size was recorded on or before it
+func (s State) AllowlistSizeOn(day time.Time) (int, bool) {
+	key, latest := dayKey(day), ""
+	for recorded := range s.AllowlistSize {
+		if recorded <= key && recorded > latest {
+			latest = recorded
+		}
+	}
+	if latest == "" {
+		return 0, false
+	}
+	return s.AllowlistSize[latest], true
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGoalStats(t *testing.T) {
+	now := time.Date(2025, 3, 14, 15, 0, 0, 0, time.Local)
+	state := State{DailyFocus: map[string]int64{
+		"2025-03-07": 7200, // Earlier streak of 3 days
+		"2025-03-08": 7200,
+		"2025-03-09": 7200,
+		"2025-03-11": 3600, // Below goal
+		"2025-03-12": 7200,
+		"2025-03-13": 9000,
+		"2025-03-14": 1800,
+	}}
+
+	stats := state.GoalStats(2*time.Hour, now)
+	if stats.Today != 30*time.Minute {
+		t.Errorf("Expected 30m today, got %v", stats.Today)
+	}
+	if stats.Week != 6*time.Hour { // Monday 2025-03-10 through Friday
+		t.Errorf("Expected 6h this week, got %v", stats.Week)
+	}
+	if stats.Met() {
+		t.Error("Expected today's goal not to be met yet")
+	}
+	if stats.Streak != 2 {
+		t.Errorf("Expected streak of 2 while today is pending, got %d", stats.Streak)
+	}
+	if stats.LongestStreak != 3 {
+		t.Errorf("Expected longest streak of 3, got %d", stats.LongestStreak)
+	}
+
+	state.DailyFocus["2025-03-14"] = 7200
+	stats = state.GoalStats(2*time.Hour, now)
+	if !stats.Met() || stats.Streak != 3 {
+		t.Errorf("Expected met goal and streak of 3, got %+v", stats)
+	}
+}
+
+func TestAddFocusTimeSplitsAtMidnight(t *testing.T) {
+	sm := &StateManager{statePath: t.TempDir() + "/state.json"}
+
+	start := time.Date(2025, 3, 13, 23, 30, 0, 0, time.Local)
+	if err := sm.AddFocusTime(start, start.Add(time.Hour), ""); err != nil {
+		t.Fatalf("Failed to add focus time: %v", err)
+	}
+
+	state := sm.GetState()
+	if got := state.FocusTimeOn(start); got != 30*time.Minute {
+		t.Errorf("Expected 30m on the first day, got %v", got)
+	}
+	if got := state.FocusTimeOn(start.Add(time.Hour)); got != 30*time.Minute {
+		t.Errorf("Expected 30m on the second day, got %v", got)
+	}
+}
+
+func TestLabelTimes(t *testing.T) {
+	sm := &StateManager{statePath: t.TempDir() + "/state.json"}
+
+	now := time.Date(2025, 3, 14, 12, 0, 0, 0, time.Local)
+	yesterday := now.AddDate(0, 0, -1)
+	if err := sm.AddFocusTime(yesterday, yesterday.Add(2*time.Hour), "thesis writing"); err != nil {
+		t.Fatalf("Failed to add focus time: %v", err)
+	}
+	if err := sm.AddFocusTime(now.Add(-time.Hour), now, "thesis writing"); err != nil {
+		t.Fatalf("Failed to add focus time: %v", err)
+	}
+	if err := sm.AddFocusTime(now.Add(-3*time.Hour), now.Add(-2*time.Hour), ""); err != nil {
+		t.Fatalf("Failed to add focus time: %v", err)
+	}
+
+	labels := sm.LabelTimes(now)
+	if len(labels) != 1 {
+		t.Fatalf("Expected one label, got %+v", labels)
+	}
+	if labels[0].Today != time.Hour || labels[0].Total != 3*time.Hour {
+		t.Errorf("Unexpected label time: %+v", labels[0])
+	}
+	if got := sm.GetState().FocusTimeOn(now); got != 2*time.Hour {
+		t.Errorf("Expected unlabeled time to count toward the day, got %v", got)
+	}
+}
+
+func TestAllowlistSizeOn(t *testing.T) {
+	state := State{AllowlistSize: map[string]int{"2025-03-01": 10, "2025-03-09": 14}}
+	for day, want := range map[int]int{1: 10, 8: 10, 9: 14, 20: 14} {
+		if got, ok := state.AllowlistSizeOn(time.Date(2025, 3, day, 12, 0, 0, 0, time.Local)); !ok || got != want {
+			t.Errorf("expected %d domains on March %d, got %d (%v)", want, day, got, ok)
+		}
+	}
+	if _, ok := state.AllowlistSizeOn(time.Date(2025, 2, 28, 12, 0, 0, 0, time.Local)); ok {
+		t.Error("expected no size before the first one recorded")
+	}
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sinkzone records the SHA-256 of allowlist.txt and state.json next to them whenever it
+// writes them, in the format of sha256sum, so the resolver can tell when either was edited
+// by something else. It is a checksum anyone can recompute, not a signature: it catches
+// edits made outside sinkzone, while the state key protects the focus session itself.
+
+// File integrity modes (file_integrity)
+const (
+	IntegrityWarn    = "warn"    // Log files changed outside sinkzone (default)
+	IntegrityEnforce = "enforce" // Also ignore a changed allowlist during strict sessions
+)
+
+var (
+	// ErrNoChecksum is returned by VerifyChecksum when a file has no recorded checksum
+	ErrNoChecksum = errors.New("no checksum was recorded")
+	// ErrChecksumMismatch is returned by VerifyChecksum when a file doesn't match its checksum
+	ErrChecksumMismatch = errors.New("the file doesn't match the checksum sinkzone recorded")
+)
+
+// ChecksumPath returns the file holding the checksum of path
+func ChecksumPath(path string) string {
+	return path + ".sha256"
+}
+
+// WriteChecksum records data as the content sinkzone wrote to path
+func WriteChecksum(path string, data []byte) error {
+	sum := sha256.Sum256(data)
+	line := hex.EncodeToString(sum[:]) + "  " + filepath.Base(path) + "\n"
+	if err := os.WriteFile(ChecksumPath(path), []byte(line), 0600); err != nil {
+		return fmt.Errorf("failed to write checksum of %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// UpdateChecksum records the current content of path, or removes its checksum when the
+// file doesn't exist
+func UpdateChecksum(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is one of sinkzone's own files
+	if errors.Is(err, fs.ErrNotExist) {
+		if err := os.Remove(ChecksumPath(path)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove checksum of %s: %w", filepath.Base(path), err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	return WriteChecksum(path, data)
+}
+
+// VerifyChecksum checks that path still holds what sinkzone last wrote to it. A missing
+// file passes; a file without a checksum returns ErrNoChecksum, and an edited one
+// ErrChecksumMismatch.
+func VerifyChecksum(path string) error {
+	data, err := os.ReadFile(path) // #nosec G304 -- path is one of sinkzone's own files
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	recorded, err := os.ReadFile(ChecksumPath(path)) // #nosec G304 -- path is one of sinkzone's own files
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNoChecksum
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read checksum of %s: %w", filepath.Base(path), err)
+	}
+	sum := sha256.Sum256(data)
+	fields := strings.Fields(string(recorded))
+	if len(fields) == 0 || !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+// GetFileIntegrity returns what the resolver does about files changed outside sinkzone
+func (c *Config) GetFileIntegrity() (string, error) {
+	switch c.FileIntegrity {
+	case "", IntegrityWarn:
+		return IntegrityWarn, nil
+	case IntegrityEnforce:
+		return IntegrityEnforce, nil
+	default:
+		return "", fmt.Errorf("invalid file_integrity %q: use warn or enforce", c.FileIntegrity)
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	if err := VerifyChecksum(path); err != nil {
+		t.Fatalf("VerifyChecksum of a missing file = %v, want nil", err)
+	}
+
+	if err := os.WriteFile(path, []byte("example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(path); !errors.Is(err, ErrNoChecksum) {
+		t.Fatalf("VerifyChecksum without a checksum = %v, want ErrNoChecksum", err)
+	}
+
+	if err := UpdateChecksum(path); err != nil {
+		t.Fatalf("UpdateChecksum failed: %v", err)
+	}
+	if err := VerifyChecksum(path); err != nil {
+		t.Fatalf("VerifyChecksum after UpdateChecksum = %v, want nil", err)
+	}
+
+	// An edit made by something else
+	if err := os.WriteFile(path, []byte("example.com\nreddit.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksum(path); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("VerifyChecksum after an edit = %v, want ErrChecksumMismatch", err)
+	}
+
+	// Removing the file removes its checksum
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateChecksum(path); err != nil {
+		t.Fatalf("UpdateChecksum of a missing file failed: %v", err)
+	}
+	if _, err := os.Stat(ChecksumPath(path)); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("checksum of a missing file wasn't removed: %v", err)
+	}
+}
@@ -0,0 +1,1140 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Key is a config setting that can be read and changed with 'sinkzone config'. Profiles and
+// schedules have their own commands; process triggers and the keymap are edited in the file.
+type Key struct {
+	Name        string
+	Description string
+	List        bool // Changed with add/remove; set replaces the whole comma-separated list
+	Live        bool // A running resolver applies changes without a restart
+
+	get func(c *Config) []string
+	set func(c *Config, values []string)
+	// parse checks that a single value has the right type before it is set; nil accepts any text
+	parse func(value string) error
+	// validate checks the config after a change; nil accepts any value
+	validate func(c *Config) error
+}
+
+// keyAliases maps shorthand key names to their config keys
+var keyAliases = map[string]string{
+	"upstream":  "upstream_nameservers",
+	"upstreams": "upstream_nameservers",
+}
+
+// keys lists every setting in the order of the config file
+var keys = []Key{
+	{
+		Name:        "upstream_nameservers",
+		Description: "Upstream nameservers queries are forwarded to (IP, IP:port, or a udp://, tcp://, tls://, or https:// URL)",
+		List:        true,
+		Live:        true,
+		get:         func(c *Config) []string { return c.UpstreamNameservers },
+		set:         func(c *Config, values []string) { c.UpstreamNameservers = values },
+		validate: func(c *Config) error {
+			if len(c.UpstreamNameservers) == 0 {
+				return fmt.Errorf("at least one upstream nameserver is required")
+			}
+			for _, server := range c.UpstreamNameservers {
+				if err := ValidateNameserver(server); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Name:        "resolver",
+		Description: "Primary upstream nameserver (the first of upstream_nameservers)",
+		Live:        true,
+		get: func(c *Config) []string {
+			if len(c.UpstreamNameservers) == 0 {
+				return nil
+			}
+			return c.UpstreamNameservers[:1]
+		},
+		set: func(c *Config, values []string) {
+			if len(c.UpstreamNameservers) == 0 {
+				c.UpstreamNameservers = values
+			} else {
+				c.UpstreamNameservers = append(values, c.UpstreamNameservers[1:]...)
+			}
+		},
+		validate: func(c *Config) error {
+			if len(c.UpstreamNameservers) == 0 {
+				return fmt.Errorf("at least one upstream nameserver is required")
+			}
+			return ValidateNameserver(c.UpstreamNameservers[0])
+		},
+	},
+	live(stringKey("upstream_strategy", "Order upstreams are tried in: sequential, round_robin, random, or fastest",
+		func(c *Config, _ bool) *string { return &c.UpstreamStrategy },
+		func(c *Config) error { _, err := c.GetUpstreamStrategy(); return err })),
+	stringKey("dns_listen", "Address the DNS server binds, e.g. 127.0.0.1:53 (default :53)",
+		func(c *Config, _ bool) *string { return &c.DNSListen },
+		func(c *Config) error { _, err := c.GetDNSListen(); return err }),
+	stringKey("dns_fallback_port", "Port the DNS server binds instead when the port of dns_listen is taken, e.g. by systemd-resolved or dnsmasq, with systemd-resolved forwarding to it (default: off)",
+		func(c *Config, _ bool) *string { return &c.DNSFallbackPort },
+		func(c *Config) error { _, err := c.GetDNSFallbackPort(); return err }),
+	stringKey("api_listen", "Address the HTTP API binds, e.g. 0.0.0.0:8080 (default 127.0.0.1:8080)",
+		func(c *Config, _ bool) *string { return &c.APIListen },
+		func(c *Config) error { _, err := c.GetAPIListen(); return err }),
+	boolKey("api_allow_remote", "Allow an api_listen other machines can reach, letting them control focus mode: true or false (default)",
+		func(c *Config, _ bool) **bool { return &c.APIAllowRemote }),
+	boolKey("lan", "Serve the devices of a network: DNS and the API on all interfaces, API tokens required, devices told apart by MAC address: true or false (default)",
+		func(c *Config, _ bool) **bool { return &c.LAN }),
+	live(boolKey("mutations_local_only", "Refuse API requests that change anything unless they come from this machine: true or false (default)",
+		func(c *Config, _ bool) **bool { return &c.MutationsLocalOnly })),
+	live(stringKey("trigger_secret", "Secret of at least 16 characters that lets automations start and stop focus mode with POST /api/hooks/trigger, from other machines too (default: off)",
+		func(c *Config, _ bool) *string { return &c.TriggerSecret },
+		func(c *Config) error { _, err := c.GetTriggerSecret(); return err })),
+	sectionKey("api_tls.cert", "Certificate the HTTP API serves HTTPS with (PEM; relative paths are in the sinkzone directory)",
+		func(c *Config) **APITLSConfig { return &c.APITLS },
+		func(s *APITLSConfig) *string { return &s.Cert },
+		func(c *Config) error { return c.APITLS.checkFiles() }),
+	sectionKey("api_tls.key", "Private key of api_tls.cert (PEM)",
+		func(c *Config) **APITLSConfig { return &c.APITLS },
+		func(s *APITLSConfig) *string { return &s.Key },
+		func(c *Config) error { return c.APITLS.checkFiles() }),
+	sectionKey("api_tls.client_ca", "CA whose client certificates the HTTP API requires (default: none required)",
+		func(c *Config) **APITLSConfig { return &c.APITLS },
+		func(s *APITLSConfig) *string { return &s.ClientCA },
+		func(c *Config) error { return c.APITLS.checkFiles() }),
+	sectionKey("api_client_tls.ca", "CA the certificate of an https API must chain to (default: the system's)",
+		func(c *Config) **APIClientTLSConfig { return &c.APIClientTLS },
+		func(s *APIClientTLSConfig) *string { return &s.CA },
+		func(c *Config) error { return c.APIClientTLS.checkFiles() }),
+	sectionKey("api_client_tls.cert", "Client certificate the CLI and TUI send to an API that requires one (PEM)",
+		func(c *Config) **APIClientTLSConfig { return &c.APIClientTLS },
+		func(s *APIClientTLSConfig) *string { return &s.Cert },
+		func(c *Config) error { return c.APIClientTLS.checkFiles() }),
+	sectionKey("api_client_tls.key", "Private key of api_client_tls.cert (PEM)",
+		func(c *Config) **APIClientTLSConfig { return &c.APIClientTLS },
+		func(s *APIClientTLSConfig) *string { return &s.Key },
+		func(c *Config) error { return c.APIClientTLS.checkFiles() }),
+	stringKey("metrics_listen", "Extra address serving only Prometheus metrics, e.g. 0.0.0.0:9153 (default: /metrics on the API)",
+		func(c *Config, _ bool) *string { return &c.MetricsListen },
+		func(c *Config) error { _, err := c.GetMetricsListen(); return err }),
+	stringKey("run_as", "User the resolver switches to after binding its ports, or root to stay root (default: the user who ran sudo)",
+		func(c *Config, _ bool) *string { return &c.RunAs },
+		func(c *Config) error { _, err := c.GetRunAs(); return err }),
+	live(stringKey("block_response", "How blocked queries are answered: nxdomain, null, or refused",
+		func(c *Config, _ bool) *string { return &c.BlockResponse },
+		func(c *Config) error { _, err := c.GetBlockResponse(); return err })),
+	live(stringKey("blocked_ttl", "How long clients may cache a blocked answer (default 10s)",
+		func(c *Config, _ bool) *string { return &c.BlockedTTL },
+		func(c *Config) error { _, err := c.GetBlockedTTL(); return err })),
+	live(intKey("cache.size", "Maximum number of cached upstream answers (default 1000, 0 disables the cache)",
+		func(c *Config) *int {
+			if c.Cache == nil {
+				return nil
+			}
+			return c.Cache.Size
+		},
+		func(c *Config, value *int) {
+			if c.Cache == nil {
+				c.Cache = &CacheConfig{}
+			}
+			c.Cache.Size = value
+		},
+		func(c *Config) error { _, err := c.Cache.GetSize(); return err })),
+	live(sectionKey("cache.min_ttl", "Shortest time an upstream answer is cached (default 0)",
+		func(c *Config) **CacheConfig { return &c.Cache },
+		func(s *CacheConfig) *string { return &s.MinTTL },
+		func(c *Config) error { _, _, err := c.Cache.GetTTLBounds(); return err })),
+	live(sectionKey("cache.max_ttl", "Longest time an upstream answer is cached (default 1h)",
+		func(c *Config) **CacheConfig { return &c.Cache },
+		func(s *CacheConfig) *string { return &s.MaxTTL },
+		func(c *Config) error { _, _, err := c.Cache.GetTTLBounds(); return err })),
+	live(sectionKey("cache.serve_stale", "How long expired answers are still served when every upstream fails, e.g. 1d (default 0 = never)",
+		func(c *Config) **CacheConfig { return &c.Cache },
+		func(s *CacheConfig) *string { return &s.ServeStale },
+		func(c *Config) error { _, err := c.Cache.GetServeStale(); return err })),
+	live(intKey("rate_limit.queries_per_second", "Queries each client may send per second (0 or unset: unlimited)",
+		func(c *Config) *int {
+			if c.RateLimit == nil || c.RateLimit.QueriesPerSecond == 0 {
+				return nil
+			}
+			return &c.RateLimit.QueriesPerSecond
+		},
+		func(c *Config, value *int) {
+			if c.RateLimit == nil {
+				c.RateLimit = &RateLimitConfig{}
+			}
+			c.RateLimit.QueriesPerSecond = 0
+			if value != nil {
+				c.RateLimit.QueriesPerSecond = *value
+			}
+		},
+		func(c *Config) error { _, _, err := c.RateLimit.GetLimit(); return err })),
+	live(intKey("rate_limit.burst", "Queries a client may send at once (default: the per-second rate)",
+		func(c *Config) *int {
+			if c.RateLimit == nil || c.RateLimit.Burst == 0 {
+				return nil
+			}
+			return &c.RateLimit.Burst
+		},
+		func(c *Config, value *int) {
+			if c.RateLimit == nil {
+				c.RateLimit = &RateLimitConfig{}
+			}
+			c.RateLimit.Burst = 0
+			if value != nil {
+				c.RateLimit.Burst = *value
+			}
+		},
+		func(c *Config) error { _, _, err := c.RateLimit.GetLimit(); return err })),
+	live(intKey("cooldown.strikes", "Rate-limited or malformed queries from a client within a minute that refuse it for cooldown.duration (0 or unset: never)",
+		func(c *Config) *int {
+			if c.Cooldown == nil || c.Cooldown.Strikes == 0 {
+				return nil
+			}
+			return &c.Cooldown.Strikes
+		},
+		func(c *Config, value *int) {
+			if c.Cooldown == nil {
+				c.Cooldown = &CooldownConfig{}
+			}
+			c.Cooldown.Strikes = 0
+			if value != nil {
+				c.Cooldown.Strikes = *value
+			}
+		},
+		func(c *Config) error { _, _, err := c.Cooldown.GetCooldown(); return err })),
+	live(sectionKey("cooldown.duration", "How long a client on cooldown is refused (default 10m)",
+		func(c *Config) **CooldownConfig { return &c.Cooldown },
+		func(s *CooldownConfig) *string { return &s.Duration },
+		func(c *Config) error { _, _, err := c.Cooldown.GetCooldown(); return err })),
+	live(sectionKey("captive_portal.mode", "What the resolver does when a network's login page seems blocked: notify (default), auto (open a passthrough window), or off",
+		func(c *Config) **CaptivePortalConfig { return &c.CaptivePortal },
+		func(s *CaptivePortalConfig) *string { return &s.Mode },
+		func(c *Config) error { _, err := c.CaptivePortal.GetMode(); return err })),
+	live(sectionKey("captive_portal.passthrough", "How long a passthrough window lasts (default 5m, at most 30m)",
+		func(c *Config) **CaptivePortalConfig { return &c.CaptivePortal },
+		func(s *CaptivePortalConfig) *string { return &s.Passthrough },
+		func(c *Config) error { _, err := c.CaptivePortal.GetPassthrough(); return err })),
+	live(intKey("concurrency.max_queries", "DNS queries handled at once (default 256, 0 = unlimited)",
+		func(c *Config) *int {
+			if c.Concurrency == nil {
+				return nil
+			}
+			return c.Concurrency.MaxQueries
+		},
+		func(c *Config, value *int) {
+			if c.Concurrency == nil {
+				c.Concurrency = &ConcurrencyConfig{}
+			}
+			c.Concurrency.MaxQueries = value
+		},
+		func(c *Config) error { _, _, err := c.Concurrency.GetLimits(); return err })),
+	live(intKey("concurrency.max_queued", "DNS queries waiting while max_queries are handled; more are refused (default 1024)",
+		func(c *Config) *int {
+			if c.Concurrency == nil {
+				return nil
+			}
+			return c.Concurrency.MaxQueued
+		},
+		func(c *Config, value *int) {
+			if c.Concurrency == nil {
+				c.Concurrency = &ConcurrencyConfig{}
+			}
+			c.Concurrency.MaxQueued = value
+		},
+		func(c *Config) error { _, _, err := c.Concurrency.GetLimits(); return err })),
+	live(sectionKey("private_zones.mode", "How names only the local network knows (.local, reverse names of private addresses) are answered: nxdomain, forward, or upstream (default: forward with a local nameserver, else nxdomain)",
+		func(c *Config) **PrivateZonesConfig { return &c.PrivateZones },
+		func(s *PrivateZonesConfig) *string { return &s.Mode },
+		func(c *Config) error { _, err := c.GetPrivateMode(); return err })),
+	live(sectionKey("private_zones.resolver", "Local nameserver private names are forwarded to, e.g. the router at 192.168.1.1 (default: upstreams on private addresses)",
+		func(c *Config) **PrivateZonesConfig { return &c.PrivateZones },
+		func(s *PrivateZonesConfig) *string { return &s.Resolver },
+		func(c *Config) error { _, err := c.GetPrivateMode(); return err })),
+	live(Key{
+		Name:        "private_zones.zones",
+		Description: "Private zones besides .local, home.arpa, and the reverse zones of private addresses, e.g. lan or corp.internal",
+		List:        true,
+		get: func(c *Config) []string {
+			if c.PrivateZones == nil {
+				return nil
+			}
+			return c.PrivateZones.Zones
+		},
+		set: func(c *Config, values []string) {
+			if c.PrivateZones == nil {
+				c.PrivateZones = &PrivateZonesConfig{}
+			}
+			c.PrivateZones.Zones = values
+		},
+		validate: func(c *Config) error { _, err := c.PrivateZones.GetZones(); return err },
+	}),
+	live(intKey("recent_queries.size", "Recent queries kept in memory for the API and TUI (default 1000)",
+		func(c *Config) *int {
+			if c.RecentQueries == nil {
+				return nil
+			}
+			return c.RecentQueries.Size
+		},
+		func(c *Config, value *int) {
+			if c.RecentQueries == nil {
+				c.RecentQueries = &RecentQueriesConfig{}
+			}
+			c.RecentQueries.Size = value
+		},
+		func(c *Config) error { _, _, err := c.RecentQueries.GetLimits(); return err })),
+	live(intKey("recent_queries.max_size", "Megabytes the recent queries may take in memory (default 0 = no limit)",
+		func(c *Config) *int {
+			if c.RecentQueries == nil {
+				return nil
+			}
+			return c.RecentQueries.MaxSize
+		},
+		func(c *Config, value *int) {
+			if c.RecentQueries == nil {
+				c.RecentQueries = &RecentQueriesConfig{}
+			}
+			c.RecentQueries.MaxSize = value
+		},
+		func(c *Config) error { _, _, err := c.RecentQueries.GetLimits(); return err })),
+	live(sectionKey("recent_queries.aggregate", "Repeats of a domain within this window share one recent query with a count (default 60s, 0 = one per query)",
+		func(c *Config) **RecentQueriesConfig { return &c.RecentQueries },
+		func(s *RecentQueriesConfig) *string { return &s.Aggregate },
+		func(c *Config) error { _, err := c.RecentQueries.GetAggregate(); return err })),
+	boolKey("query_log.enabled", "Keep every query in queries.db so history survives restarts: true (default) or false",
+		func(c *Config, create bool) **bool {
+			if c.QueryLog == nil && create {
+				c.QueryLog = &QueryLogConfig{}
+			}
+			if c.QueryLog == nil {
+				return nil
+			}
+			return &c.QueryLog.Enabled
+		}),
+	live(intKey("query_log.sample_rate", "Keep 1 in this many allowed queries in the query log; blocked ones are always kept (default 1 = every query)",
+		func(c *Config) *int {
+			if c.QueryLog == nil {
+				return nil
+			}
+			return c.QueryLog.SampleRate
+		},
+		func(c *Config, value *int) {
+			if c.QueryLog == nil {
+				c.QueryLog = &QueryLogConfig{}
+			}
+			c.QueryLog.SampleRate = value
+		},
+		func(c *Config) error { _, err := c.QueryLog.GetSampleRate(); return err })),
+	sectionKey("query_log.storage", "Where the query log is kept: file (queries.db, default) or memory (lost when the resolver stops)",
+		func(c *Config) **QueryLogConfig { return &c.QueryLog },
+		func(s *QueryLogConfig) *string { return &s.Storage },
+		func(c *Config) error { _, err := c.QueryLog.GetStorage(); return err }),
+	live(stringKey("query_retention", "Queries older than this are deleted from the query log, e.g. 7d (default) or 12h; 0 keeps them",
+		func(c *Config, _ bool) *string { return &c.QueryRetention },
+		func(c *Config) error { _, err := c.GetQueryRetention(); return err })),
+	live(intKey("max_query_records", "The oldest queries beyond this many are deleted from the query log (0 = no limit)",
+		func(c *Config) *int {
+			if c.MaxQueryRecords == 0 {
+				return nil
+			}
+			return &c.MaxQueryRecords
+		},
+		func(c *Config, value *int) {
+			c.MaxQueryRecords = 0
+			if value != nil {
+				c.MaxQueryRecords = *value
+			}
+		},
+		func(c *Config) error { _, err := c.GetMaxQueryRecords(); return err })),
+	boolKey("resolve_client_hostnames", "Look up client names with reverse DNS: true (default) or false",
+		func(c *Config, _ bool) **bool { return &c.ResolveClientHostnames }),
+	live(boolKey("bypass_check", "Check every few minutes that this machine's DNS queries reach the resolver: true (default) or false",
+		func(c *Config, _ bool) **bool { return &c.BypassCheck })),
+	stringKey("file_integrity", "What the resolver does about allowlist.txt and state.json changed outside sinkzone: warn (default) or enforce (also keep the last checked allowlist during hard or PIN-locked sessions)",
+		func(c *Config, _ bool) *string { return &c.FileIntegrity },
+		func(c *Config) error { _, err := c.GetFileIntegrity(); return err }),
+	live(stringKey("client_privacy", "How client addresses are recorded: off (default), truncate (network only), or hash",
+		func(c *Config, _ bool) *string { return &c.ClientPrivacy },
+		func(c *Config) error { _, err := c.GetClientPrivacy(); return err })),
+	stringKey("log_output", "Where the resolver logs: file (log_file, default) or syslog (syslog or journald, or the Windows Event Log)",
+		func(c *Config, _ bool) *string { return &c.LogOutput },
+		func(c *Config) error { _, err := c.GetLogOutput(); return err }),
+	stringKey("log_file", "File the resolver logs to (default: standard error)",
+		func(c *Config, _ bool) *string { return &c.LogFile }, nil),
+	intKey("log_rotation.max_size", "Megabytes log_file may reach before it's rotated (default 10, 0 = no limit)",
+		func(c *Config) *int {
+			if c.LogRotation == nil {
+				return nil
+			}
+			return c.LogRotation.MaxSize
+		},
+		func(c *Config, value *int) {
+			if c.LogRotation == nil {
+				c.LogRotation = &LogRotationConfig{}
+			}
+			c.LogRotation.MaxSize = value
+		},
+		func(c *Config) error { _, err := c.LogRotation.GetRotation(); return err }),
+	sectionKey("log_rotation.max_age", "Rotate log_file once it's been written to this long, e.g. 1d (default: no limit)",
+		func(c *Config) **LogRotationConfig { return &c.LogRotation },
+		func(s *LogRotationConfig) *string { return &s.MaxAge },
+		func(c *Config) error { _, err := c.LogRotation.GetRotation(); return err }),
+	intKey("log_rotation.max_files", "Rotated copies of log_file kept (default 5)",
+		func(c *Config) *int {
+			if c.LogRotation == nil {
+				return nil
+			}
+			return c.LogRotation.MaxFiles
+		},
+		func(c *Config, value *int) {
+			if c.LogRotation == nil {
+				c.LogRotation = &LogRotationConfig{}
+			}
+			c.LogRotation.MaxFiles = value
+		},
+		func(c *Config) error { _, err := c.LogRotation.GetRotation(); return err }),
+	live(stringKey("log_level", "Lowest level the resolver logs: debug, info, warn, or error",
+		func(c *Config, _ bool) *string { return &c.LogLevel },
+		func(c *Config) error { _, err := c.GetLogLevel(); return err })),
+	live(stringKey("log_format", "Format of the resolver log: text or json",
+		func(c *Config, _ bool) *string { return &c.LogFormat },
+		func(c *Config) error { _, err := c.GetLogFormat(); return err })),
+	stringKey("focus_grace_period", "How long after focus starts blocked queries are only warned about",
+		func(c *Config, _ bool) *string { return &c.FocusGracePeriod },
+		func(c *Config) error { _, err := c.GetFocusGracePeriod(); return err }),
+	stringKey("focus_on_start", "Start focus mode with the resolver: a duration, resume, or indefinite",
+		func(c *Config, _ bool) *string { return &c.FocusOnStart },
+		func(c *Config) error { _, _, err := c.GetFocusOnStart(); return err }),
+	stringKey("focus_disable_delay", "How long a disable request waits before focus mode ends",
+		func(c *Config, _ bool) *string { return &c.FocusDisableDelay },
+		func(c *Config) error { _, err := c.GetFocusDisableDelay(); return err }),
+	stringKey("focus_intensity", "Default focus intensity: soft, normal, or hard",
+		func(c *Config, _ bool) *string { return &c.FocusIntensity },
+		func(c *Config) error { _, err := c.GetFocusIntensity(); return err }),
+	stringKey("active_profile", "Focus profile used when a session doesn't choose one",
+		func(c *Config, _ bool) *string { return &c.ActiveProfile },
+		func(c *Config) error { return c.validateProfile(c.ActiveProfile) }),
+	stringKey("daily_goal", "Daily focus time goal, e.g. 4h",
+		func(c *Config, _ bool) *string { return &c.DailyGoal },
+		func(c *Config) error { _, err := c.GetDailyGoal(); return err }),
+	{
+		Name:        "break_domains",
+		Description: "Domains allowed only during focus breaks",
+		List:        true,
+		get:         func(c *Config) []string { return c.BreakDomains },
+		set:         func(c *Config, values []string) { c.BreakDomains = values },
+	},
+	{
+		Name:        "ignore_domains",
+		Description: "Domains resolved and blocked as usual but left out of the monitor and stats, e.g. *.pool.ntp.org",
+		List:        true,
+		Live:        true,
+		get:         func(c *Config) []string { return c.IgnoreDomains },
+		set:         func(c *Config, values []string) { c.IgnoreDomains = values },
+	},
+	stringKey("subscription_refresh", "How often the resolver downloads subscribed lists again (default 24h, 0 to only update by hand)",
+		func(c *Config, _ bool) *string { return &c.SubscriptionRefresh },
+		func(c *Config) error { _, err := c.GetSubscriptionRefresh(); return err }),
+	sectionKey("calendar.url", "iCalendar feed (http(s) URL or file path) that drives focus mode",
+		func(c *Config) **CalendarConfig { return &c.Calendar },
+		func(s *CalendarConfig) *string { return &s.URL }, nil),
+	sectionKey("calendar.mode", "Which calendar events start focus mode: tagged or busy",
+		func(c *Config) **CalendarConfig { return &c.Calendar },
+		func(s *CalendarConfig) *string { return &s.Mode },
+		func(c *Config) error { _, err := c.Calendar.GetMode(); return err }),
+	sectionKey("calendar.tag", "Tag marking focus events in tagged mode (default #focus)",
+		func(c *Config) **CalendarConfig { return &c.Calendar },
+		func(s *CalendarConfig) *string { return &s.Tag }, nil),
+	sectionKey("calendar.refresh", "How often the calendar is re-fetched (default 15m)",
+		func(c *Config) **CalendarConfig { return &c.Calendar },
+		func(s *CalendarConfig) *string { return &s.Refresh },
+		func(c *Config) error { _, err := c.Calendar.GetRefresh(); return err }),
+	sectionKey("calendar.profile", "Focus profile used for calendar sessions",
+		func(c *Config) **CalendarConfig { return &c.Calendar },
+		func(s *CalendarConfig) *string { return &s.Profile },
+		func(c *Config) error { return c.validateProfile(c.Calendar.Profile) }),
+	sectionKey("macos_focus.focus", "macOS Focus mirrored with focus sessions (default Work)",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.Focus }, nil),
+	sectionKey("macos_focus.sync", "Directions the macOS Focus is mirrored in: both, from_mac, or to_mac",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.Sync },
+		func(c *Config) error {
+			if c.MacOSFocus == nil {
+				return nil
+			}
+			_, err := c.MacOSFocus.GetSync()
+			return err
+		}),
+	sectionKey("macos_focus.on_shortcut", "Shortcut that turns the macOS Focus on (default Sinkzone Focus On)",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.OnShortcut }, nil),
+	sectionKey("macos_focus.off_shortcut", "Shortcut that turns the macOS Focus off (default Sinkzone Focus Off)",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.OffShortcut }, nil),
+	sectionKey("macos_focus.profile", "Focus profile used for sessions started by the macOS Focus",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.Profile },
+		func(c *Config) error {
+			if c.MacOSFocus == nil {
+				return nil
+			}
+			return c.validateProfile(c.MacOSFocus.Profile)
+		}),
+	sectionKey("macos_focus.label", "Session label of sessions started by the macOS Focus",
+		func(c *Config) **MacOSFocusConfig { return &c.MacOSFocus },
+		func(s *MacOSFocusConfig) *string { return &s.Label }, nil),
+	sectionKey("google_calendar.client_id", "OAuth client ID of a Google Cloud project (Desktop app) for Google Calendar",
+		func(c *Config) **GoogleCalendarConfig { return &c.GoogleCalendar },
+		func(s *GoogleCalendarConfig) *string { return &s.ClientID }, nil),
+	sectionKey("google_calendar.client_secret", "Secret of the Google Calendar OAuth client",
+		func(c *Config) **GoogleCalendarConfig { return &c.GoogleCalendar },
+		func(s *GoogleCalendarConfig) *string { return &s.ClientSecret }, nil),
+	{
+		Name:        "google_calendar.calendars",
+		Description: "Names of the Google calendars read (default: the primary calendar)",
+		List:        true,
+		get: func(c *Config) []string {
+			if c.GoogleCalendar == nil {
+				return nil
+			}
+			return c.GoogleCalendar.Calendars
+		},
+		set: func(c *Config, values []string) {
+			if c.GoogleCalendar == nil {
+				c.GoogleCalendar = &GoogleCalendarConfig{}
+			}
+			c.GoogleCalendar.Calendars = values
+		},
+	},
+	sectionKey("google_calendar.title", "Regular expression of the Google Calendar event titles that start focus mode (default: every busy event)",
+		func(c *Config) **GoogleCalendarConfig { return &c.GoogleCalendar },
+		func(s *GoogleCalendarConfig) *string { return &s.Title },
+		func(c *Config) error {
+			if c.GoogleCalendar == nil {
+				return nil
+			}
+			_, err := c.GoogleCalendar.GetTitle()
+			return err
+		}),
+	sectionKey("google_calendar.refresh", "How often Google Calendar events are fetched (default 5m)",
+		func(c *Config) **GoogleCalendarConfig { return &c.GoogleCalendar },
+		func(s *GoogleCalendarConfig) *string { return &s.Refresh },
+		func(c *Config) error {
+			if c.GoogleCalendar == nil {
+				return nil
+			}
+			_, err := c.GoogleCalendar.GetRefresh()
+			return err
+		}),
+	sectionKey("google_calendar.profile", "Focus profile used for Google Calendar sessions",
+		func(c *Config) **GoogleCalendarConfig { return &c.GoogleCalendar },
+		func(s *GoogleCalendarConfig) *string { return &s.Profile },
+		func(c *Config) error {
+			if c.GoogleCalendar == nil {
+				return nil
+			}
+			return c.validateProfile(c.GoogleCalendar.Profile)
+		}),
+	{
+		Name:        "sync.peers",
+		Description: "API URLs of resolvers on other machines to mirror focus sessions from",
+		List:        true,
+		get: func(c *Config) []string {
+			if c.Sync == nil {
+				return nil
+			}
+			return c.Sync.Peers
+		},
+		set: func(c *Config, values []string) {
+			if c.Sync == nil {
+				c.Sync = &SyncConfig{}
+			}
+			c.Sync.Peers = values
+		},
+		validate: func(c *Config) error {
+			for _, peer := range c.Sync.Peers {
+				if u, err := url.Parse(peer); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+					return fmt.Errorf("invalid sync peer %q: use an API URL such as http://desktop.local:8080", peer)
+				}
+			}
+			return nil
+		},
+	},
+	sectionKey("sync.interval", "How often sync peers are polled (default 10s)",
+		func(c *Config) **SyncConfig { return &c.Sync },
+		func(s *SyncConfig) *string { return &s.Interval },
+		func(c *Config) error { _, err := c.Sync.GetInterval(); return err }),
+	sectionKey("sync.token", "API token sent to sync peers that set api_tokens (read scope)",
+		func(c *Config) **SyncConfig { return &c.Sync },
+		func(s *SyncConfig) *string { return &s.Token }, nil),
+	sectionKey("notifications.warn_before", "How long before a session ends to warn (default 5m, 0 to disable)",
+		func(c *Config) **NotifyConfig { return &c.Notifications },
+		func(s *NotifyConfig) *string { return &s.WarnBefore },
+		func(c *Config) error { _, err := c.Notifications.GetWarnBefore(); return err }),
+	boolKey("notifications.desktop", "Show desktop notifications about focus sessions: true (default) or false",
+		func(c *Config, create bool) **bool {
+			if c.Notifications == nil && create {
+				c.Notifications = &NotifyConfig{}
+			}
+			if c.Notifications == nil {
+				return nil
+			}
+			return &c.Notifications.Desktop
+		}),
+	sectionKey("notifications.slack_webhook", "Slack incoming webhook URL focus sessions are posted to",
+		func(c *Config) **NotifyConfig { return &c.Notifications },
+		func(s *NotifyConfig) *string { return &s.SlackWebhook },
+		func(c *Config) error { _, _, err := c.Notifications.GetWebhooks(); return err }),
+	sectionKey("notifications.discord_webhook", "Discord webhook URL focus sessions are posted to",
+		func(c *Config) **NotifyConfig { return &c.Notifications },
+		func(s *NotifyConfig) *string { return &s.DiscordWebhook },
+		func(c *Config) error { _, _, err := c.Notifications.GetWebhooks(); return err }),
+	sectionKey("notifications.daily_summary", "Time of day the day's focus time is posted to the webhooks, e.g. 18:00",
+		func(c *Config) **NotifyConfig { return &c.Notifications },
+		func(s *NotifyConfig) *string { return &s.DailySummary },
+		func(c *Config) error { _, err := c.Notifications.GetDailySummary(); return err }),
+	intKey("notifications.pressure_alert", "Distraction pressure, in blocked attempts per minute over 10 minutes of a focus session, that shows a notification to take a break or recommit (0 or unset: never)",
+		func(c *Config) *int {
+			if c.Notifications == nil || c.Notifications.PressureAlert == 0 {
+				return nil
+			}
+			return &c.Notifications.PressureAlert
+		},
+		func(c *Config, value *int) {
+			if c.Notifications == nil {
+				c.Notifications = &NotifyConfig{}
+			}
+			c.Notifications.PressureAlert = 0
+			if value != nil {
+				c.Notifications.PressureAlert = *value
+			}
+		},
+		func(c *Config) error { _, err := c.Notifications.GetPressureAlert(); return err }),
+	sectionKey("hooks.on_focus_start", "Executable run when a focus session starts",
+		func(c *Config) **HooksConfig { return &c.Hooks },
+		func(s *HooksConfig) *string { return &s.OnFocusStart }, nil),
+	sectionKey("hooks.on_focus_end", "Executable run when a focus session ends",
+		func(c *Config) **HooksConfig { return &c.Hooks },
+		func(s *HooksConfig) *string { return &s.OnFocusEnd }, nil),
+	sectionKey("mqtt.broker", "MQTT broker the focus state is published to, e.g. tcp://homeassistant.local:1883 (tls:// for TLS)",
+		func(c *Config) **MQTTConfig { return &c.MQTT },
+		func(s *MQTTConfig) *string { return &s.Broker },
+		func(c *Config) error {
+			if c.MQTT == nil {
+				return nil
+			}
+			_, _, err := c.MQTT.GetBroker()
+			return err
+		}),
+	sectionKey("mqtt.username", "Username sent to the MQTT broker",
+		func(c *Config) **MQTTConfig { return &c.MQTT },
+		func(s *MQTTConfig) *string { return &s.Username }, nil),
+	sectionKey("mqtt.password", "Password sent to the MQTT broker",
+		func(c *Config) **MQTTConfig { return &c.MQTT },
+		func(s *MQTTConfig) *string { return &s.Password }, nil),
+	sectionKey("mqtt.topic", "Prefix of the MQTT topics published (default sinkzone)",
+		func(c *Config) **MQTTConfig { return &c.MQTT },
+		func(s *MQTTConfig) *string { return &s.Topic },
+		func(c *Config) error {
+			if c.MQTT == nil {
+				return nil
+			}
+			_, err := c.MQTT.GetTopic()
+			return err
+		}),
+	sectionKey("mqtt.discovery_prefix", "Home Assistant MQTT discovery prefix (default homeassistant, none to turn discovery off)",
+		func(c *Config) **MQTTConfig { return &c.MQTT },
+		func(s *MQTTConfig) *string { return &s.DiscoveryPrefix }, nil),
+	sectionKey("influxdb.url", "InfluxDB write endpoint the metrics are written to, e.g. http://localhost:8086/api/v2/write?org=home&bucket=sinkzone",
+		func(c *Config) **InfluxDBConfig { return &c.InfluxDB },
+		func(s *InfluxDBConfig) *string { return &s.URL },
+		func(c *Config) error {
+			if c.InfluxDB == nil {
+				return nil
+			}
+			_, err := c.InfluxDB.GetURL()
+			return err
+		}),
+	sectionKey("influxdb.token", "Token sent to InfluxDB 2",
+		func(c *Config) **InfluxDBConfig { return &c.InfluxDB },
+		func(s *InfluxDBConfig) *string { return &s.Token }, nil),
+	sectionKey("influxdb.username", "Username sent to InfluxDB 1 or Grafana Cloud",
+		func(c *Config) **InfluxDBConfig { return &c.InfluxDB },
+		func(s *InfluxDBConfig) *string { return &s.Username }, nil),
+	sectionKey("influxdb.password", "Password sent with influxdb.username",
+		func(c *Config) **InfluxDBConfig { return &c.InfluxDB },
+		func(s *InfluxDBConfig) *string { return &s.Password }, nil),
+	sectionKey("influxdb.interval", "How often the metrics are written to InfluxDB (default 1m)",
+		func(c *Config) **InfluxDBConfig { return &c.InfluxDB },
+		func(s *InfluxDBConfig) *string { return &s.Interval },
+		func(c *Config) error {
+			if c.InfluxDB == nil {
+				return nil
+			}
+			_, err := c.InfluxDB.GetInterval()
+			return err
+		}),
+	boolKey("tailscale.enabled", "Join a tailnet, serving DNS to its machines wherever they are: true or false (default)",
+		func(c *Config, create bool) **bool {
+			if c.Tailscale == nil && create {
+				c.Tailscale = &TailscaleConfig{}
+			}
+			if c.Tailscale == nil {
+				return nil
+			}
+			return &c.Tailscale.Enabled
+		}),
+	sectionKey("tailscale.hostname", "Machine name of the resolver on the tailnet (default sinkzone)",
+		func(c *Config) **TailscaleConfig { return &c.Tailscale },
+		func(s *TailscaleConfig) *string { return &s.Hostname },
+		func(c *Config) error { _, err := c.Tailscale.GetHostname(); return err }),
+	sectionKey("tailscale.auth_key", "Auth key that joins the tailnet without a login in the browser (default $TS_AUTHKEY)",
+		func(c *Config) **TailscaleConfig { return &c.Tailscale },
+		func(s *TailscaleConfig) *string { return &s.AuthKey }, nil),
+	sectionKey("tailscale.state_dir", "Directory the tailnet machine keeps its keys in (default tailscale/ in the sinkzone directory)",
+		func(c *Config) **TailscaleConfig { return &c.Tailscale },
+		func(s *TailscaleConfig) *string { return &s.StateDir }, nil),
+	boolKey("tailscale.api", "Also serve the HTTP API on the tailnet, API tokens required: true or false (default)",
+		func(c *Config, create bool) **bool {
+			if c.Tailscale == nil && create {
+				c.Tailscale = &TailscaleConfig{}
+			}
+			if c.Tailscale == nil {
+				return nil
+			}
+			return &c.Tailscale.API
+		}),
+	live(vpnListKey("vpn.interfaces", "Names of the VPN's network interfaces, with * wildcards, e.g. wg0,tun*,utun*",
+		func(v *VPNConfig) *[]string { return &v.Interfaces },
+		(*VPNConfig).validateInterfaces)),
+	live(vpnListKey("vpn.networks", "The VPN is also up while an interface has an address in one of these networks, e.g. 10.8.0.0/16",
+		func(v *VPNConfig) *[]string { return &v.Networks },
+		func(v *VPNConfig) error { _, err := v.GetNetworks(); return err })),
+	live(vpnListKey("vpn.upstreams", "Nameservers used while the VPN is up (default: those the system gained when it came up)",
+		func(v *VPNConfig) *[]string { return &v.Upstreams },
+		func(v *VPNConfig) error { _, err := v.GetUpstreams(); return err })),
+	live(vpnListKey("vpn.domains", "Only names in these domains go to the VPN's nameservers, e.g. corp.example.com (default: every name)",
+		func(v *VPNConfig) *[]string { return &v.Domains },
+		func(v *VPNConfig) error { _, err := v.GetDomains(); return err })),
+	live(sectionKey("vpn.check_interval", "How often the network interfaces are checked for the VPN (default 5s)",
+		func(c *Config) **VPNConfig { return &c.VPN },
+		func(s *VPNConfig) *string { return &s.CheckInterval },
+		func(c *Config) error { _, err := c.VPN.GetCheckInterval(); return err })),
+	sectionKey("tracing.endpoint", "OpenTelemetry collector traces are sent to over OTLP/HTTP, e.g. http://localhost:4318",
+		func(c *Config) **TracingConfig { return &c.Tracing },
+		func(s *TracingConfig) *string { return &s.Endpoint },
+		func(c *Config) error {
+			if c.Tracing == nil {
+				return nil
+			}
+			_, err := c.Tracing.GetEndpoint()
+			return err
+		}),
+	sectionKey("tracing.service_name", "service.name of the traces (default sinkzone)",
+		func(c *Config) **TracingConfig { return &c.Tracing },
+		func(s *TracingConfig) *string { return &s.ServiceName }, nil),
+	intKey("tracing.sample_percent", "Share of DNS queries and API requests traced, 0 to 100 (default 100)",
+		func(c *Config) *int {
+			if c.Tracing == nil {
+				return nil
+			}
+			return c.Tracing.SamplePercent
+		},
+		func(c *Config, value *int) {
+			if c.Tracing == nil {
+				c.Tracing = &TracingConfig{}
+			}
+			c.Tracing.SamplePercent = value
+		},
+		func(c *Config) error {
+			if c.Tracing == nil {
+				return nil
+			}
+			_, err := c.Tracing.GetSamplePercent()
+			return err
+		}),
+	boolKey("crash_reports.enabled", "Write a report when the resolver or TUI crashes: true or false (default)",
+		func(c *Config, create bool) **bool {
+			if c.CrashReports == nil && create {
+				c.CrashReports = &CrashReportsConfig{}
+			}
+			if c.CrashReports == nil {
+				return nil
+			}
+			return &c.CrashReports.Enabled
+		}),
+	sectionKey("crash_reports.upload_url", "Also post each crash report here as JSON (default: keep them local)",
+		func(c *Config) **CrashReportsConfig { return &c.CrashReports },
+		func(s *CrashReportsConfig) *string { return &s.UploadURL },
+		func(c *Config) error { _, err := c.CrashReports.GetUploadURL(); return err }),
+	sectionKey("theme.name", "TUI colour theme: dark, light, or solarized",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Name }, nil),
+	sectionKey("theme.background", "TUI background colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Background }, nil),
+	sectionKey("theme.text", "TUI text colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Text }, nil),
+	sectionKey("theme.accent", "TUI banner and footer colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Accent }, nil),
+	sectionKey("theme.border", "TUI border colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Border }, nil),
+	sectionKey("theme.muted", "TUI inactive tab colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Muted }, nil),
+	sectionKey("theme.selected", "TUI selected row colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Selected }, nil),
+	sectionKey("theme.alert", "TUI focus mode indicator colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Alert }, nil),
+	sectionKey("theme.warning", "TUI warning colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Warning }, nil),
+	sectionKey("theme.success", "TUI success colour (#RRGGBB)",
+		func(c *Config) **ThemeConfig { return &c.Theme },
+		func(s *ThemeConfig) *string { return &s.Success }, nil),
+	sectionKey("tui.refresh", "How often the TUI polls the resolver (default 3s)",
+		func(c *Config) **TUIConfig { return &c.TUI },
+		func(s *TUIConfig) *string { return &s.Refresh },
+		func(c *Config) error { _, err := c.TUI.GetRefresh(); return err }),
+	sectionKey("tui.allowlist_reload", "How often the TUI reloads the allowlist file (default 5s)",
+		func(c *Config) **TUIConfig { return &c.TUI },
+		func(s *TUIConfig) *string { return &s.AllowlistReload },
+		func(c *Config) error { _, err := c.TUI.GetAllowlistReload(); return err }),
+	stringKey("language", "TUI and CLI language, e.g. de (empty follows LANG)",
+		func(c *Config, _ bool) *string { return &c.Language }, nil),
+}
+
+// live marks a key a running resolver applies without a restart
+func live(k Key) Key {
+	k.Live = true
+	return k
+}
+
+// stringKey builds a single-value key. field returns the value to change, creating its
+// section when create is set, or nil when the section is missing.
+func stringKey(name, description string, field func(c *Config, create bool) *string, validate func(c *Config) error) Key {
+	return Key{
+		Name:        name,
+		Description: description,
+		get: func(c *Config) []string {
+			if value := field(c, false); value != nil && *value != "" {
+				return []string{*value}
+			}
+			return nil
+		},
+		set: func(c *Config, values []string) {
+			value := ""
+			if len(values) > 0 {
+				value = values[0]
+			}
+			*field(c, true) = value
+		},
+		validate: validate,
+	}
+}
+
+// sectionKey builds a single-value key for a field of an optional config section, which
+// is created when the key is set
+func sectionKey[S any](name, description string, section func(c *Config) **S, field func(s *S) *string, validate func(c *Config) error) Key {
+	return stringKey(name, description, func(c *Config, create bool) *string {
+		ptr := section(c)
+		if *ptr == nil && create {
+			*ptr = new(S)
+		}
+		if *ptr == nil {
+			return nil
+		}
+		return field(*ptr)
+	}, validate)
+}
+
+// vpnListKey builds a list key for a field of the vpn section, which is created when the
+// key is set. validate checks only that field, so the section may be filled in any order.
+func vpnListKey(name, description string, field func(v *VPNConfig) *[]string, validate func(v *VPNConfig) error) Key {
+	return Key{
+		Name:        name,
+		Description: description,
+		List:        true,
+		get: func(c *Config) []string {
+			if c.VPN == nil {
+				return nil
+			}
+			return *field(c.VPN)
+		},
+		set: func(c *Config, values []string) {
+			if c.VPN == nil {
+				c.VPN = &VPNConfig{}
+			}
+			*field(c.VPN) = values
+		},
+		validate: func(c *Config) error {
+			if c.VPN == nil {
+				return nil
+			}
+			return validate(c.VPN)
+		},
+	}
+}
+
+// boolKey builds a single-value key for an optional true/false setting. field returns nil
+// when the setting's section is missing and create is false.
+func boolKey(name, description string, field func(c *Config, create bool) **bool) Key {
+	return Key{
+		Name:        name,
+		Description: description,
+		get: func(c *Config) []string {
+			if value := field(c, false); value != nil && *value != nil {
+				return []string{strconv.FormatBool(**value)}
+			}
+			return nil
+		},
+		set: func(c *Config, values []string) {
+			if len(values) == 0 {
+				if value := field(c, false); value != nil {
+					*value = nil
+				}
+				return
+			}
+			// parse has checked the value
+			enabled, _ := strconv.ParseBool(values[0])
+			*field(c, true) = &enabled
+		},
+		parse: func(value string) error {
+			if _, err := strconv.ParseBool(value); err != nil {
+				return fmt.Errorf("%q is not true or false", value)
+			}
+			return nil
+		},
+	}
+}
+
+// intKey builds a single-value key for a number. get returns nil when the setting is
+// unset, and set receives nil to unset it.
+func intKey(name, description string, get func(c *Config) *int, set func(c *Config, value *int), validate func(c *Config) error) Key {
+	return Key{
+		Name:        name,
+		Description: description,
+		get: func(c *Config) []string {
+			if value := get(c); value != nil {
+				return []string{strconv.Itoa(*value)}
+			}
+			return nil
+		},
+		set: func(c *Config, values []string) {
+			if len(values) == 0 {
+				set(c, nil)
+				return
+			}
+			// parse has checked the value
+			value, _ := strconv.Atoi(values[0])
+			set(c, &value)
+		},
+		parse: func(value string) error {
+			if _, err := strconv.Atoi(value); err != nil {
+				return fmt.Errorf("%q is not a number", value)
+			}
+			return nil
+		},
+		validate: validate,
+	}
+}
+
+// Keys returns every setting 'sinkzone config' can change
+func Keys() []Key {
+	return keys
+}
+
+// KeyNames returns the names of every setting, including aliases
+func KeyNames() []string {
+	names := make([]string, 0, len(keys)+len(keyAliases))
+	for _, key := range keys {
+		names = append(names, key.Name)
+	}
+	for alias := range keyAliases {
+		names = append(names, alias)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// LookupKey finds a setting by name or alias
+func LookupKey(name string) (*Key, error) {
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+	for i := range keys {
+		if keys[i].Name == name {
+			return &keys[i], nil
+		}
+	}
+	switch name {
+	case "profiles":
+		return nil, fmt.Errorf("profiles are managed with 'sinkzone profile'")
+	case "schedules":
+		return nil, fmt.Errorf("schedules are managed with 'sinkzone schedule'")
+	case "allowlist_subscriptions", "blocklist_subscriptions":
+		return nil, fmt.Errorf("%s are managed with 'sinkzone %s subscribe'", name, strings.TrimSuffix(name, "_subscriptions"))
+	case "process_triggers", "keymap", "notifications.push", "users":
+		return nil, fmt.Errorf("%s is structured; edit it in %s", name, GetConfigPath())
+	}
+	return nil, fmt.Errorf("unknown config key: %s. Run 'sinkzone config list' to see all keys", name)
+}
+
+// Get returns the values of a setting: nil when unset, one value for single-value keys
+func (k *Key) Get(c *Config) []string {
+	return k.get(c)
+}
+
+// Set changes a setting. An empty value unsets single-value keys; list keys take a
+// comma-separated list that replaces the current one.
+func (k *Key) Set(c *Config, value string) error {
+	var values []string
+	if k.List {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+	} else if value = strings.TrimSpace(value); value != "" {
+		values = []string{value}
+	}
+	if k.parse != nil {
+		for _, value := range values {
+			if err := k.parse(value); err != nil {
+				return fmt.Errorf("invalid %s: %w", k.Name, err)
+			}
+		}
+	}
+	return k.change(c, values)
+}
+
+// Add appends a value to a list setting
+func (k *Key) Add(c *Config, value string) error {
+	if !k.List {
+		return fmt.Errorf("%s is not a list; use 'set' instead", k.Name)
+	}
+	value = strings.TrimSpace(value)
+	current := k.get(c)
+	if slices.Contains(current, value) {
+		return fmt.Errorf("%s already contains %s", k.Name, value)
+	}
+	return k.change(c, append(slices.Clone(current), value))
+}
+
+// Remove deletes a value from a list setting
+func (k *Key) Remove(c *Config, value string) error {
+	if !k.List {
+		return fmt.Errorf("%s is not a list; use 'set' with an empty value instead", k.Name)
+	}
+	value = strings.TrimSpace(value)
+	current := k.get(c)
+	index := slices.Index(current, value)
+	if index < 0 {
+		return fmt.Errorf("%s does not contain %s", k.Name, value)
+	}
+	return k.change(c, slices.Delete(slices.Clone(current), index, index+1))
+}
+
+// change applies values and validates the result, restoring the old values if they are rejected
+func (k *Key) change(c *Config, values []string) error {
+	old := slices.Clone(k.get(c))
+	k.set(c, values)
+	if k.validate != nil {
+		if err := k.validate(c); err != nil {
+			k.set(c, old)
+			c.pruneSections()
+			return err
+		}
+	}
+	c.pruneSections()
+	return nil
+}
+
+// pruneSections drops optional sections left without any settings, so unsetting the last
+// field of a section doesn't leave an empty section behind
+func (c *Config) pruneSections() {
+	if c.Calendar != nil && *c.Calendar == (CalendarConfig{}) {
+		c.Calendar = nil
+	}
+	if c.InfluxDB != nil && *c.InfluxDB == (InfluxDBConfig{}) {
+		c.InfluxDB = nil
+	}
+	if c.MacOSFocus != nil && *c.MacOSFocus == (MacOSFocusConfig{}) {
+		c.MacOSFocus = nil
+	}
+	if g := c.GoogleCalendar; g != nil && len(g.Calendars) == 0 && g.ClientID == "" && g.ClientSecret == "" && g.Title == "" && g.Refresh == "" && g.Profile == "" {
+		c.GoogleCalendar = nil
+	}
+	if c.Sync != nil && len(c.Sync.Peers) == 0 && c.Sync.Interval == "" && c.Sync.Token == "" {
+		c.Sync = nil
+	}
+	if v := c.VPN; v != nil && len(v.Interfaces) == 0 && len(v.Networks) == 0 && len(v.Upstreams) == 0 && len(v.Domains) == 0 && v.CheckInterval == "" {
+		c.VPN = nil
+	}
+	if n := c.Notifications; n != nil && len(n.Push) == 0 && n.WarnBefore == "" && n.Desktop == nil && n.SlackWebhook == "" && n.DiscordWebhook == "" && n.DailySummary == "" && n.PressureAlert == 0 {
+		c.Notifications = nil
+	}
+	if h := c.Hooks; h != nil && h.OnFocusStart == "" && h.OnFocusEnd == "" && len(h.Events) == 0 && len(h.Plugins) == 0 {
+		c.Hooks = nil
+	}
+	if c.Theme != nil && *c.Theme == (ThemeConfig{}) {
+		c.Theme = nil
+	}
+	if c.TUI != nil && *c.TUI == (TUIConfig{}) {
+		c.TUI = nil
+	}
+	if p := c.PrivateZones; p != nil && p.Mode == "" && p.Resolver == "" && len(p.Zones) == 0 && len(p.Hosts) == 0 {
+		c.PrivateZones = nil
+	}
+	if c.Cache != nil && *c.Cache == (CacheConfig{}) {
+		c.Cache = nil
+	}
+	if c.RateLimit != nil && *c.RateLimit == (RateLimitConfig{}) {
+		c.RateLimit = nil
+	}
+	if c.Cooldown != nil && *c.Cooldown == (CooldownConfig{}) {
+		c.Cooldown = nil
+	}
+	if c.CaptivePortal != nil && *c.CaptivePortal == (CaptivePortalConfig{}) {
+		c.CaptivePortal = nil
+	}
+	if c.Concurrency != nil && *c.Concurrency == (ConcurrencyConfig{}) {
+		c.Concurrency = nil
+	}
+	if c.RecentQueries != nil && *c.RecentQueries == (RecentQueriesConfig{}) {
+		c.RecentQueries = nil
+	}
+	if c.CrashReports != nil && *c.CrashReports == (CrashReportsConfig{}) {
+		c.CrashReports = nil
+	}
+	if c.APITLS != nil && *c.APITLS == (APITLSConfig{}) {
+		c.APITLS = nil
+	}
+	if c.APIClientTLS != nil && *c.APIClientTLS == (APIClientTLSConfig{}) {
+		c.APIClientTLS = nil
+	}
+	if c.QueryLog != nil && *c.QueryLog == (QueryLogConfig{}) {
+		c.QueryLog = nil
+	}
+}
+
+// validateProfile checks that a profile referenced by another setting exists
+func (c *Config) validateProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := c.GetProfile(name)
+	return err
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestKeySetValidates(t *testing.T) {
+	cfg := &Config{UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1"}}
+
+	key, err := LookupKey("resolver")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := key.Set(cfg, "9.9.9.9"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9", "1.1.1.1"}) {
+		t.Errorf("expected the primary resolver to be replaced, got %v", cfg.UpstreamNameservers)
+	}
+	if err := key.Set(cfg, "999.1.1.1"); err == nil {
+		t.Error("expected an invalid IP address to be rejected")
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9", "1.1.1.1"}) {
+		t.Errorf("expected a rejected value to be rolled back, got %v", cfg.UpstreamNameservers)
+	}
+
+	grace, _ := LookupKey("focus_grace_period")
+	if err := grace.Set(cfg, "soon"); err == nil || cfg.FocusGracePeriod != "" {
+		t.Errorf("expected an invalid duration to be rejected, got %q (%v)", cfg.FocusGracePeriod, err)
+	}
+}
+
+func TestKeyListOperations(t *testing.T) {
+	cfg := &Config{UpstreamNameservers: []string{"8.8.8.8"}}
+	upstream, err := LookupKey("upstream")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := upstream.Add(cfg, "2620:fe::fe"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := upstream.Add(cfg, "8.8.8.8"); err == nil {
+		t.Error("expected a duplicate to be rejected")
+	}
+	if err := upstream.Remove(cfg, "8.8.8.8"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := upstream.Remove(cfg, "2620:fe::fe"); err == nil {
+		t.Error("expected removing the last upstream nameserver to be rejected")
+	}
+	if got := upstream.Get(cfg); !slices.Equal(got, []string{"2620:fe::fe"}) {
+		t.Errorf("expected [2620:fe::fe], got %v", got)
+	}
+	if got := cfg.GetUpstreamAddresses(); !slices.Equal(got, []string{"[2620:fe::fe]:53"}) {
+		t.Errorf("expected the IPv6 address with port 53, got %v", got)
+	}
+}
+
+func TestSectionKeys(t *testing.T) {
+	cfg := &Config{}
+	key, _ := LookupKey("tui.refresh")
+	if got := key.Get(cfg); got != nil {
+		t.Errorf("expected no value for a missing section, got %v", got)
+	}
+	if err := key.Set(cfg, "10s"); err != nil || cfg.TUI == nil || cfg.TUI.Refresh != "10s" {
+		t.Fatalf("expected the tui section to be created, got %+v (%v)", cfg.TUI, err)
+	}
+	if err := key.Set(cfg, ""); err != nil || cfg.TUI != nil {
+		t.Errorf("expected the empty tui section to be dropped, got %+v (%v)", cfg.TUI, err)
+	}
+
+	if _, err := LookupKey("profiles"); err == nil {
+		t.Error("expected structured keys to be rejected")
+	}
+}
+
+func TestValidateNameserver(t *testing.T) {
+	for _, server := range []string{"1.1.1.1", "1.1.1.1:5353", "2620:fe::fe", "[2620:fe::fe]:53"} {
+		if err := ValidateNameserver(server); err != nil {
+			t.Errorf("expected %s to be valid, got %v", server, err)
+		}
+	}
+	for _, server := range []string{"1.1.1", "dns.google", "1.1.1.1:0", "1.1.1.1:dns"} {
+		if err := ValidateNameserver(server); err == nil {
+			t.Errorf("expected %s to be rejected", server)
+		}
+	}
+}
+
+func TestConfigLocation(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	defer SetConfigPath("")
+
+	if got := GetConfigPath(); got != filepath.Join(dir, "sinkzone.yaml") {
+		t.Errorf("expected the config in %s, got %s", dir, got)
+	}
+	if got, _ := getStatePath(); got != filepath.Join(dir, "state.json") {
+		t.Errorf("expected the state in %s, got %s", dir, got)
+	}
+
+	custom := filepath.Join(t.TempDir(), "work.yaml")
+	SetConfigPath(custom)
+	if err := Save(&Config{UpstreamNameservers: []string{"9.9.9.9"}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9"}) {
+		t.Errorf("expected the config from %s, got %v", custom, cfg.UpstreamNameservers)
+	}
+	if got := GetDataDir(); got != dir {
+		t.Errorf("expected --config to leave the data directory alone, got %s", got)
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	old := &Config{UpstreamNameservers: []string{"8.8.8.8"}, DNSListen: ":53"}
+	updated := &Config{UpstreamNameservers: []string{"9.9.9.9"}, DNSListen: ":5353", BlockResponse: BlockResponseRefused}
+
+	changed := ChangedKeys(old, updated)
+	if !slices.Equal(changed, []string{"upstream_nameservers", "resolver", "dns_listen", "block_response"}) {
+		t.Errorf("unexpected changed keys: %v", changed)
+	}
+	for _, name := range changed {
+		key, err := LookupKey(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key.Live == (name == "dns_listen") {
+			t.Errorf("unexpected Live %v for %s", key.Live, name)
+		}
+	}
+}
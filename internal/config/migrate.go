@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentConfigVersion is the config file schema this build writes. Files with an older
+// version are upgraded by Load; files with a newer one are refused.
+const CurrentConfigVersion = 2
+
+// migration upgrades a parsed config file from one version to the next. It works on the
+// raw YAML document, so it can rename or restructure keys the Config struct no longer has.
+type migration struct {
+	description string
+	apply       func(doc map[string]any) error
+}
+
+// migrations[i] upgrades a file from version i to i+1. Append a migration and bump
+// CurrentConfigVersion whenever the schema changes incompatibly.
+var migrations = []migration{
+	{
+		// Files written before versioning share today's schema
+		description: "add the version field",
+		apply:       func(map[string]any) error { return nil },
+	},
+	{
+		description: "turn blocklist_subscriptions into entries that can be disabled",
+		apply:       migrateSubscriptions,
+	},
+}
+
+// migrate upgrades a config file to CurrentConfigVersion. It returns the file unchanged
+// and the version it had when no upgrade is needed.
+func migrate(data []byte) ([]byte, int, error) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc == nil {
+		doc = map[string]any{}
+	}
+
+	version, err := docVersion(doc)
+	if err != nil {
+		return nil, version, err
+	}
+	if version == CurrentConfigVersion {
+		return data, version, nil
+	}
+
+	for v := version; v < CurrentConfigVersion; v++ {
+		if err := migrations[v].apply(doc); err != nil {
+			return nil, version, fmt.Errorf("failed to migrate config from version %d (%s): %w", v, migrations[v].description, err)
+		}
+	}
+	doc["version"] = CurrentConfigVersion
+
+	migrated, err := yaml.Marshal(doc)
+	if err != nil {
+		return nil, version, fmt.Errorf("failed to marshal migrated config: %w", err)
+	}
+	return migrated, version, nil
+}
+
+// FileVersion returns the schema version of a config file, 0 for files written before
+// versioning. It fails for files newer than CurrentConfigVersion.
+func FileVersion(data []byte) (int, error) {
+	doc := map[string]any{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	return docVersion(doc)
+}
+
+// docVersion reads the version field of a parsed config file
+func docVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["version"]
+	if !ok {
+		return 0, nil
+	}
+	version, ok := raw.(int)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("invalid config version %v: must be a non-negative integer", raw)
+	}
+	if version > CurrentConfigVersion {
+		return version, fmt.Errorf("config file version %d is newer than this sinkzone supports (%d): upgrade sinkzone", version, CurrentConfigVersion)
+	}
+	return version, nil
+}
+
+// backupConfig copies the config file before a migration rewrites it, e.g. to
+// sinkzone.yaml.v0.bak, and returns the backup path
+func backupConfig(configPath string, data []byte, version int) (string, error) {
+	backupPath := fmt.Sprintf("%s.v%d.bak", configPath, version)
+	if err := os.WriteFile(backupPath, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to back up config file: %w", err)
+	}
+	return backupPath, nil
+}
+
+// upgradeConfig rewrites a migrated config file in the current schema, keeping a backup of
+// the original. A file that can't be rewritten is still used in its migrated form.
+func upgradeConfig(configPath string, original []byte, version int, cfg *Config) {
+	backupPath, err := backupConfig(configPath, original, version)
+	if err != nil {
+		log.Printf("Warning: not rewriting config file %s: %v", configPath, err)
+		return
+	}
+	if err := Save(cfg); err != nil {
+		log.Printf("Warning: failed to rewrite migrated config file %s: %v", configPath, err)
+		return
+	}
+	log.Printf("Migrated config file %s from version %d to %d (original saved as %s)", configPath, version, CurrentConfigVersion, backupPath)
+}
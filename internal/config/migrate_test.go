@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadMigratesOldConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	path := filepath.Join(dir, "sinkzone.yaml")
+	original := []byte("upstream_nameservers:\n  - 9.9.9.9\nblock_response: refused\n")
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Version != CurrentConfigVersion || cfg.BlockResponse != "refused" || !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9"}) {
+		t.Errorf("unexpected migrated config: %+v", cfg)
+	}
+
+	backup, err := os.ReadFile(path + ".v0.bak")
+	if err != nil {
+		t.Fatalf("expected a backup of the original: %v", err)
+	}
+	if string(backup) != string(original) {
+		t.Errorf("expected the backup to match the original, got %q", backup)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), fmt.Sprintf("version: %d", CurrentConfigVersion)) {
+		t.Errorf("expected the rewritten file to record its version, got %q", data)
+	}
+}
+
+func TestLoadRefusesNewerConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	if err := os.WriteFile(filepath.Join(dir, "sinkzone.yaml"), []byte("version: 99\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "newer") {
+		t.Errorf("expected a newer config to be refused, got %v", err)
+	}
+}
+
+func TestLoadMigratesBlocklistSubscriptions(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	original := []byte("version: 1\nblocklist_subscriptions:\n  - https://example.com/hosts\n")
+	if err := os.WriteFile(filepath.Join(dir, "sinkzone.yaml"), original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Subscription{{URL: "https://example.com/hosts"}}
+	if !slices.Equal(cfg.BlocklistSubscriptions, want) {
+		t.Errorf("expected %v, got %v", want, cfg.BlocklistSubscriptions)
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	pinHashScheme     = "pbkdf2-sha256"
+	pinHashIterations = 600000
+	pinHashKeyLength  = 32
+	pinSaltLength     = 16
+)
+
+// HashPIN derives a salted hash of the PIN suitable for storing in the config file.
+// The result has the form "pbkdf2-sha256$<iterations>$<salt>$<key>".
+func HashPIN(pin string) (string, error) {
+	if pin == "" {
+		return "", fmt.Errorf("PIN must not be empty")
+	}
+
+	salt := make([]byte, pinSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, pin, salt, pinHashIterations, pinHashKeyLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash PIN: %w", err)
+	}
+
+	return fmt.Sprintf("%s$%d$%s$%s",
+		pinHashScheme,
+		pinHashIterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// VerifyPIN reports whether the PIN matches a hash produced by HashPIN
+func VerifyPIN(hash, pin string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != pinHashScheme {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, pin, salt, iterations, len(expected))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
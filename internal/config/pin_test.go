@@ -0,0 +1,36 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashAndVerifyPIN(t *testing.T) {
+	hash, err := HashPIN("1234")
+	if err != nil {
+		t.Fatalf("Failed to hash PIN: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, pinHashScheme+"$") {
+		t.Errorf("Expected hash to start with scheme, got '%s'", hash)
+	}
+	if strings.Contains(hash, "1234") {
+		t.Error("Expected hash not to contain the PIN")
+	}
+
+	if !VerifyPIN(hash, "1234") {
+		t.Error("Expected correct PIN to verify")
+	}
+	if VerifyPIN(hash, "4321") {
+		t.Error("Expected wrong PIN to be rejected")
+	}
+	if VerifyPIN("garbage", "1234") {
+		t.Error("Expected malformed hash to be rejected")
+	}
+}
+
+func TestHashPINRejectsEmpty(t *testing.T) {
+	if _, err := HashPIN(""); err == nil {
+		t.Error("Expected empty PIN to be rejected")
+	}
+}
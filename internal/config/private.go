@@ -0,0 +1,173 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// How names of private zones are answered
+const (
+	PrivateNXDomain = "nxdomain" // Answered NXDOMAIN by the resolver itself (default without a local nameserver)
+	PrivateForward  = "forward"  // Sent to a local nameserver (default with one, see GetPrivateResolvers)
+	PrivateUpstream = "upstream" // Sent to the upstreams like any other name, as before private zones
+)
+
+// BuiltinPrivateZones are the zones that only mean something on the local network: mDNS
+// names (RFC 6762), home.arpa (RFC 8375), and the reverse zones of private, loopback,
+// link-local, and shared (CGNAT) addresses (RFC 6303, RFC 7793)
+var BuiltinPrivateZones = builtinPrivateZones()
+
+func builtinPrivateZones() []string {
+	zones := []string{
+		"local",
+		"home.arpa",
+		"10.in-addr.arpa",
+		"168.192.in-addr.arpa",
+		"254.169.in-addr.arpa",
+		"127.in-addr.arpa",
+		"d.f.ip6.arpa",
+		"8.e.f.ip6.arpa", "9.e.f.ip6.arpa", "a.e.f.ip6.arpa", "b.e.f.ip6.arpa",
+		"1" + strings.Repeat(".0", 31) + ".ip6.arpa", // ::1
+	}
+	for i := 16; i <= 31; i++ {
+		zones = append(zones, fmt.Sprintf("%d.172.in-addr.arpa", i))
+	}
+	for i := 64; i <= 127; i++ {
+		zones = append(zones, fmt.Sprintf("%d.100.in-addr.arpa", i))
+	}
+	return zones
+}
+
+// PrivateZonesConfig keeps names that only the local network knows, such as printer.local
+// or the reverse names of 192.168.x.x addresses, from leaking to the public upstreams
+type PrivateZonesConfig struct {
+	Mode     string            `yaml:"mode,omitempty"`     // nxdomain, forward, or upstream (default: forward with a local nameserver, else nxdomain)
+	Resolver string            `yaml:"resolver,omitempty"` // Local nameserver private names go to, e.g. the router at 192.168.1.1
+	Zones    []string          `yaml:"zones,omitempty"`    // Private zones besides the built-in ones, e.g. lan or corp.internal
+	Hosts    map[string]string `yaml:"hosts,omitempty"`    // Names the resolver answers itself, with an IPv4 or IPv6 address
+}
+
+// GetResolver returns the local nameserver private names go to, false without one
+func (p *PrivateZonesConfig) GetResolver() (Upstream, bool, error) {
+	if p == nil || p.Resolver == "" {
+		return Upstream{}, false, nil
+	}
+	upstream, err := ParseUpstream(p.Resolver)
+	if err != nil {
+		return Upstream{}, false, fmt.Errorf("invalid private_zones.resolver: %w", err)
+	}
+	return upstream, true, nil
+}
+
+// GetPrivateResolvers returns where names of private zones go in forward mode:
+// private_zones.resolver, else the upstreams on private network addresses, such as the
+// router, which are part of the local network already
+func (c *Config) GetPrivateResolvers() ([]Upstream, error) {
+	if resolver, ok, err := c.PrivateZones.GetResolver(); err != nil || ok {
+		return []Upstream{resolver}, err
+	}
+	upstreams, err := c.GetUpstreams()
+	if err != nil {
+		return nil, err
+	}
+	var resolvers []Upstream
+	for _, upstream := range upstreams {
+		host, _, err := net.SplitHostPort(upstream.Address)
+		// Not loopback ones, which are mostly forwarders to public nameservers
+		if ip := net.ParseIP(host); err == nil && ip != nil && ip.IsPrivate() {
+			resolvers = append(resolvers, upstream)
+		}
+	}
+	return resolvers, nil
+}
+
+// GetPrivateMode returns how names of private zones are answered: private_zones.mode, else
+// forward when GetPrivateResolvers finds a local nameserver and nxdomain otherwise
+func (c *Config) GetPrivateMode() (string, error) {
+	resolvers, err := c.GetPrivateResolvers()
+	if err != nil {
+		return "", err
+	}
+	mode := ""
+	if c.PrivateZones != nil {
+		mode = c.PrivateZones.Mode
+	}
+	switch mode {
+	case "":
+		if len(resolvers) > 0 {
+			return PrivateForward, nil
+		}
+		return PrivateNXDomain, nil
+	case PrivateNXDomain, PrivateUpstream:
+		return mode, nil
+	case PrivateForward:
+		if len(resolvers) == 0 {
+			return "", fmt.Errorf("private_zones.mode forward needs private_zones.resolver, or an upstream on a private address")
+		}
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid private_zones.mode %q: use nxdomain, forward, or upstream", mode)
+	}
+}
+
+// GetZones returns the built-in private zones and the configured ones, lowercase and
+// without a trailing dot
+func (p *PrivateZonesConfig) GetZones() ([]string, error) {
+	zones := append([]string(nil), BuiltinPrivateZones...)
+	if p == nil {
+		return zones, nil
+	}
+	for _, zone := range p.Zones {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(zone)), ".")
+		if name == "" || strings.ContainsAny(name, " */:") {
+			return nil, fmt.Errorf("invalid private zone %q: use a domain name, e.g. lan or corp.internal", zone)
+		}
+		zones = append(zones, name)
+	}
+	return zones, nil
+}
+
+// GetHosts returns the names the resolver answers itself, lowercase and without a trailing
+// dot, with their addresses
+func (p *PrivateZonesConfig) GetHosts() (map[string]netip.Addr, error) {
+	hosts := make(map[string]netip.Addr)
+	if p == nil {
+		return hosts, nil
+	}
+	for name, address := range p.Hosts {
+		host := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(name)), ".")
+		addr, err := netip.ParseAddr(strings.TrimSpace(address))
+		if host == "" || err != nil {
+			return nil, fmt.Errorf("invalid private_zones.hosts entry %q: %q: use a name and an IP address, e.g. nas.lan: 192.168.1.20", name, address)
+		}
+		hosts[host] = addr.Unmap()
+	}
+	return hosts, nil
+}
+
+// ValidatePrivateZones checks the private zone settings
+func (c *Config) ValidatePrivateZones() error {
+	if _, err := c.GetPrivateMode(); err != nil {
+		return err
+	}
+	if _, err := c.PrivateZones.GetZones(); err != nil {
+		return err
+	}
+	_, err := c.PrivateZones.GetHosts()
+	return err
+}
+
+// IsPrivateAddress reports whether an address belongs to a private, loopback, link-local,
+// or shared (CGNAT) network, whose reverse names only the local network knows
+func IsPrivateAddress(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+	ip4 := ip.To4()
+	return ip4 != nil && ip4[0] == 100 && ip4[1]&0xc0 == 64
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/logs"
+)
+
+// SchemaURI is the JSON Schema dialect of Schema
+const SchemaURI = "http://json-schema.org/draft-07/schema#"
+
+// schemaEnums lists the values accepted by settings that take one of a few words
+var schemaEnums = map[string][]string{
+	"upstream_strategy":             {UpstreamSequential, UpstreamRoundRobin, UpstreamRandom, UpstreamFastest},
+	"block_response":                {BlockResponseNXDomain, BlockResponseNullIP, BlockResponseRefused},
+	"focus_intensity":               {IntensitySoft, IntensityNormal, IntensityHard},
+	"calendar.mode":                 {CalendarModeTagged, CalendarModeBusy},
+	"private_zones.mode":            {PrivateNXDomain, PrivateForward, PrivateUpstream},
+	"macos_focus.sync":              {MacOSFocusSyncBoth, MacOSFocusSyncFromMac, MacOSFocusSyncToMac},
+	"notifications.push[].service":  {PushNtfy, PushPushover},
+	"notifications.push[].sessions": {PushSessionsStrict, PushSessionsAll, PushSessionsNone},
+	"log_format":                    {logs.FormatText, logs.FormatJSON},
+	"log_output":                    {logs.OutputFile, logs.OutputSyslog},
+	"file_integrity":                {IntegrityWarn, IntegrityEnforce},
+	"log_levels.*":                  {"debug", "info", "warn", "error"},
+	"users.*.focus":                 {DeviceFocusFollow, DeviceFocusOn, DeviceFocusOff},
+	"api_tokens[].scopes[]":         apiTokenScopes,
+}
+
+// schemaDescriptions describes the settings 'sinkzone config' doesn't manage; the others
+// take the description of their key
+var schemaDescriptions = map[string]string{
+	"version":                 "Schema of the file, upgraded automatically by sinkzone",
+	"focus_pin_hash":          "Salted hash of the focus PIN; set it with 'sinkzone config set pin'",
+	"profiles":                "Focus profiles by name; manage them with 'sinkzone profile'",
+	"process_triggers":        "Processes that start focus mode while they run",
+	"schedules":               "Recurring focus sessions",
+	"allowlist_subscriptions": "Hosted lists added to the allowlist; manage them with 'sinkzone allowlist subscribe'",
+	"blocklist_subscriptions": "Hosted lists blocked in every focus session; manage them with 'sinkzone blocklist subscribe'",
+	"api_tokens":              "Bearer tokens the HTTP API requires once any is set, each with scopes: read, focus, allowlist, extension, or admin",
+	"client_names":            "Names shown for client IP or MAC addresses",
+	"users":                   "Policies of the user accounts of this machine, by user name: their focus mode and extra allowed domains",
+	"private_zones.hosts":     "Names of private zones the resolver answers itself, each with an IPv4 or IPv6 address",
+	"log_levels":              "Lowest levels logged by single components (dns, api), overriding log_level",
+	"keymap":                  "TUI actions rebound to lists of keys",
+	"notifications.push":      "Push notifiers (ntfy or Pushover) told about strict sessions and spikes of blocked queries",
+}
+
+// Schema returns a JSON Schema describing sinkzone.yaml, for editors that complete and
+// check the file. It is derived from the yaml tags of Config, so it follows the struct.
+func Schema() map[string]any {
+	schema := schemaFor(reflect.TypeOf(Config{}), "")
+	schema["$schema"] = SchemaURI
+	schema["title"] = "sinkzone.yaml"
+	return schema
+}
+
+// schemaFor describes a Go type; path is the dotted key of the value, "" at the top, with
+// [] standing for list items and * for map values
+func schemaFor(t reflect.Type, path string) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	schema := map[string]any{}
+	if path != "" {
+		if key, err := LookupKey(path); err == nil && key.Name == path {
+			schema["description"] = key.Description
+		} else if description, ok := schemaDescriptions[path]; ok {
+			schema["description"] = description
+		}
+	}
+	if values, ok := schemaEnums[path]; ok {
+		schema["enum"] = values
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = schemaFor(t.Elem(), path+"[]")
+	case reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaFor(t.Elem(), path+".*")
+	case reflect.Struct:
+		properties := map[string]any{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, options, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if !field.IsExported() || name == "" || name == "-" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			properties[name] = schemaFor(field.Type, fieldPath)
+			// Top-level settings all have defaults
+			if !strings.Contains(options, "omitempty") && path != "" {
+				required = append(required, name)
+			}
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	}
+	return schema
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchemaCoversKeys(t *testing.T) {
+	schema := Schema()
+	for _, key := range Keys() {
+		if key.Name == "resolver" {
+			continue // The first of upstream_nameservers, not a field of its own
+		}
+		node := schema
+		for _, part := range strings.Split(key.Name, ".") {
+			properties, _ := node["properties"].(map[string]any)
+			child, ok := properties[part].(map[string]any)
+			if !ok {
+				t.Fatalf("expected %s in the schema", key.Name)
+			}
+			node = child
+		}
+		if node["description"] != key.Description {
+			t.Errorf("expected %s to be described as %q, got %q", key.Name, key.Description, node["description"])
+		}
+		if node["type"] == nil {
+			t.Errorf("expected %s to have a type", key.Name)
+		}
+	}
+
+	profile := schema["properties"].(map[string]any)["profiles"].(map[string]any)["additionalProperties"].(map[string]any)
+	if profile["properties"].(map[string]any)["allowlist"].(map[string]any)["type"] != "array" {
+		t.Errorf("expected profile allowlists to be arrays, got %v", profile)
+	}
+}
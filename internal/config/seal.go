@@ -0,0 +1,185 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// The resolver signs the focus session it saves in state.json with a key only it can read
+// (when it runs as root or a service), so a user editing the file can't end a session early:
+// the resolver keeps the session it signed, not the edited one.
+
+// ErrStateTampered is returned by SetSealKey when the focus session in state.json doesn't
+// match the resolver's signature
+var ErrStateTampered = errors.New("the focus session in state.json was changed outside the resolver")
+
+// stateKeySize is the length of the HMAC-SHA256 key in bytes
+const stateKeySize = 32
+
+// sealedFocus is the part of the state the resolver signs
+type sealedFocus struct {
+	FocusMode      bool       `json:"focus_mode"`
+	FocusEndTime   *time.Time `json:"focus_end_time,omitempty"`
+	FocusProfile   string     `json:"focus_profile,omitempty"`
+	FocusIntensity string     `json:"focus_intensity,omitempty"`
+	FocusLabel     string     `json:"focus_label,omitempty"`
+	FocusDryRun    bool       `json:"focus_dry_run,omitempty"`
+}
+
+// StateKeyEnv names the environment variable that moves the state signing key, e.g. onto
+// a container's volume
+const StateKeyEnv = "SINKZONE_STATE_KEY"
+
+// GetStateKeyPath returns the key the resolver signs focus sessions with: $SINKZONE_STATE_KEY
+// when set, otherwise outside the user's reach when the resolver runs as root or as a Windows
+// service, and in the data directory otherwise
+func GetStateKeyPath() string {
+	if path := os.Getenv(StateKeyEnv); path != "" {
+		return path
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(programDataDir(), "state.key")
+	}
+	if os.Geteuid() == 0 {
+		return "/var/lib/sinkzone/state.key"
+	}
+	return filepath.Join(GetDataDir(), "state.key")
+}
+
+// LoadStateKey reads the signing key, creating it on first use. It reports whether the key
+// was created, in which case an unsigned state is expected rather than tampered with.
+func LoadStateKey() ([]byte, bool, error) {
+	path := GetStateKeyPath()
+
+	// #nosec G304 -- path is the fixed key location
+	data, err := os.ReadFile(path)
+	if err == nil {
+		key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+		if err != nil || len(key) != stateKeySize {
+			return nil, false, fmt.Errorf("invalid state key in %s", path)
+		}
+		return key, false, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, false, fmt.Errorf("failed to read state key: %w", err)
+	}
+
+	key := make([]byte, stateKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, false, fmt.Errorf("failed to generate state key: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, false, fmt.Errorf("failed to create state key directory: %w", err)
+	}
+	// #nosec G304 -- path is the fixed key location
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create state key: %w", err)
+	}
+	if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+		_ = f.Close()
+		return nil, false, fmt.Errorf("failed to write state key: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, false, fmt.Errorf("failed to write state key: %w", err)
+	}
+	return key, true, nil
+}
+
+// SetSealKey makes the state manager sign the focus session on every write from now on,
+// and keep the session it signed even if the file is edited in between. It returns
+// ErrStateTampered when the saved session doesn't match its signature; the edited session
+// is kept then, and signed on the next write.
+func (sm *StateManager) SetSealKey(key []byte, created bool) error {
+	sm.mu.Lock()
+	sealed, err := sm.state.unseal(key)
+	if err != nil && sm.state.FocusSeal == "" && created {
+		// Saved before the key existed
+		sealed, err = sm.state.sealedFocus(), nil
+	}
+	if err != nil {
+		sealed = sm.state.sealedFocus()
+	}
+	sm.sealKey = key
+	sm.sealed = sealed
+	sm.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Restore the signed session over any edits
+	return sm.update(func(*State) error { return nil })
+}
+
+// sealedFocus returns the focus session of the state
+func (s State) sealedFocus() sealedFocus {
+	return sealedFocus{
+		FocusMode:      s.FocusMode,
+		FocusEndTime:   s.FocusEndTime,
+		FocusProfile:   s.FocusProfile,
+		FocusIntensity: s.FocusIntensity,
+		FocusLabel:     s.FocusLabel,
+		FocusDryRun:    s.FocusDryRun,
+	}
+}
+
+// applySealed replaces the focus session of the state
+func (s *State) applySealed(sealed sealedFocus) {
+	s.FocusMode = sealed.FocusMode
+	s.FocusEndTime = sealed.FocusEndTime
+	s.FocusProfile = sealed.FocusProfile
+	s.FocusIntensity = sealed.FocusIntensity
+	s.FocusLabel = sealed.FocusLabel
+	s.FocusDryRun = sealed.FocusDryRun
+}
+
+// seal signs the focus session of the state with key
+func (s *State) seal(key []byte) error {
+	payload, err := json.Marshal(s.sealedFocus())
+	if err != nil {
+		return fmt.Errorf("failed to marshal focus session: %w", err)
+	}
+	s.FocusSeal = base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(sealMAC(key, payload))
+	return nil
+}
+
+// unseal returns the signed focus session, or ErrStateTampered if the signature is missing
+// or doesn't match
+func (s State) unseal(key []byte) (sealedFocus, error) {
+	encoded, signature, ok := strings.Cut(s.FocusSeal, ".")
+	if !ok {
+		return sealedFocus{}, ErrStateTampered
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return sealedFocus{}, ErrStateTampered
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sealMAC(key, payload)) {
+		return sealedFocus{}, ErrStateTampered
+	}
+
+	var sealed sealedFocus
+	if err := json.Unmarshal(payload, &sealed); err != nil {
+		return sealedFocus{}, ErrStateTampered
+	}
+	return sealed, nil
+}
+
+func sealMAC(key, payload []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
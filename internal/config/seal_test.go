@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSealedFocusSurvivesEdits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	key := make([]byte, stateKeySize)
+
+	resolver := &StateManager{statePath: path}
+	if err := resolver.SetSealKey(key, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := resolver.SetFocusMode(true, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	end := *resolver.GetState().FocusEndTime
+
+	// The user ends the session by editing the file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := strings.Replace(string(data), `"focus_mode": true`, `"focus_mode": false`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A running resolver restores its session on the next write
+	if err := resolver.AddFocusTime(end.Add(-time.Hour), end.Add(-time.Minute), ""); err != nil {
+		t.Fatal(err)
+	}
+	if state := resolver.GetState(); !state.FocusMode || !state.FocusEndTime.Equal(end) {
+		t.Errorf("expected the signed session to survive, got %+v", state)
+	}
+
+	// So does a restarted one, as long as the signature is intact
+	if err := os.WriteFile(path, []byte(edited), 0600); err != nil {
+		t.Fatal(err)
+	}
+	restarted := &StateManager{statePath: path}
+	if err := restarted.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if err := restarted.SetSealKey(key, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !restarted.GetState().FocusMode {
+		t.Error("expected the restarted resolver to restore the signed session")
+	}
+}
+
+func TestSealDetectsTampering(t *testing.T) {
+	key := make([]byte, stateKeySize)
+	state := State{FocusMode: true}
+	if err := state.seal(key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := state.unseal(key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	other := make([]byte, stateKeySize)
+	other[0] = 1
+	if _, err := state.unseal(other); !errors.Is(err, ErrStateTampered) {
+		t.Errorf("expected a signature from another key to fail, got %v", err)
+	}
+
+	sm := &StateManager{statePath: filepath.Join(t.TempDir(), "state.json"), state: State{FocusMode: false}}
+	if err := sm.SetSealKey(key, false); !errors.Is(err, ErrStateTampered) {
+		t.Errorf("expected an unsigned state to count as tampered once the key exists, got %v", err)
+	}
+}
@@ -0,0 +1,847 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os/user"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/logs"
+)
+
+// Responses to blocked queries, chosen with block_response
+const (
+	BlockResponseNXDomain = "nxdomain" // The domain doesn't exist (default)
+	BlockResponseNullIP   = "null"     // 0.0.0.0 and :: for address queries, NODATA otherwise
+	BlockResponseRefused  = "refused"  // The server refuses to answer
+)
+
+// Orders upstream nameservers are tried in, chosen with upstream_strategy. Later upstreams
+// are only tried when earlier ones fail.
+const (
+	UpstreamSequential = "sequential"  // In the configured order (default)
+	UpstreamRoundRobin = "round_robin" // Starting with the next upstream for each query
+	UpstreamRandom     = "random"      // In a random order for each query
+	UpstreamFastest    = "fastest"     // By measured latency, kept across restarts
+)
+
+// Server defaults used when the settings are unset
+const (
+	DefaultDNSListen  = ":53"
+	DefaultAPIListen  = "127.0.0.1:8080"
+	DefaultBlockedTTL = 10 * time.Second
+	DefaultCacheSize  = 1000
+	DefaultCacheMax   = time.Hour
+
+	DefaultMaxQueries = 256
+	DefaultMaxQueued  = 1024
+
+	DefaultCooldown = 10 * time.Minute
+
+	DefaultPassthrough = 5 * time.Minute
+	MaxPassthrough     = 30 * time.Minute
+
+	DefaultRecentQueries  = 1000
+	DefaultAggregate      = time.Minute
+	DefaultQueryRetention = 7 * 24 * time.Hour
+
+	DefaultLogMaxSize  = 10 // Megabytes
+	DefaultLogMaxFiles = 5
+)
+
+// CacheConfig bounds the cache of upstream answers
+type CacheConfig struct {
+	Size       *int   `yaml:"size,omitempty"`        // Maximum number of cached answers (default 1000, 0 disables the cache)
+	MinTTL     string `yaml:"min_ttl,omitempty"`     // Answers are cached at least this long (default 0)
+	MaxTTL     string `yaml:"max_ttl,omitempty"`     // and at most this long (default 1h)
+	ServeStale string `yaml:"serve_stale,omitempty"` // Expired answers are kept this long for when every upstream fails (default 0 = never)
+}
+
+// RateLimitConfig limits how many queries each client may send
+type RateLimitConfig struct {
+	QueriesPerSecond int `yaml:"queries_per_second,omitempty"` // Sustained rate per client (0 = unlimited)
+	Burst            int `yaml:"burst,omitempty"`              // Queries allowed at once (default: the per-second rate)
+}
+
+// CooldownConfig refuses clients for a while once they keep going over rate_limit or
+// sending malformed queries
+type CooldownConfig struct {
+	Strikes  int    `yaml:"strikes,omitempty"`  // Rate-limited or malformed queries within a minute that start a cooldown (0 = never)
+	Duration string `yaml:"duration,omitempty"` // How long a client on cooldown is refused (default 10m)
+}
+
+// CaptivePortalConfig sets what the resolver does when a network's login page (captive
+// portal) seems to be kept from loading
+type CaptivePortalConfig struct {
+	Mode        string `yaml:"mode,omitempty"`        // notify (default), auto, or off
+	Passthrough string `yaml:"passthrough,omitempty"` // How long a passthrough window lasts (default 5m, at most 30m)
+}
+
+// ConcurrencyConfig bounds the queries handled at once, so a query storm can't exhaust memory
+type ConcurrencyConfig struct {
+	MaxQueries *int `yaml:"max_queries,omitempty"` // Queries handled at once (default 256, 0 = unlimited)
+	MaxQueued  *int `yaml:"max_queued,omitempty"`  // Queries waiting for one of them; more are refused (default 1024)
+}
+
+// RecentQueriesConfig bounds the recent queries the resolver keeps in memory for the API
+type RecentQueriesConfig struct {
+	Size    *int `yaml:"size,omitempty"`     // Queries kept (default 1000)
+	MaxSize *int `yaml:"max_size,omitempty"` // Megabytes they may take; the oldest are dropped beyond it (default 0 = no limit)
+
+	// Repeats of a domain within this long of its first query are counted in one entry
+	// (default 60s, 0 = every query is an entry)
+	Aggregate string `yaml:"aggregate,omitempty"`
+}
+
+// QueryLogConfig controls the query history kept on disk
+type QueryLogConfig struct {
+	Enabled    *bool  `yaml:"enabled,omitempty"`     // Keep every query in queries.db (default true)
+	SampleRate *int   `yaml:"sample_rate,omitempty"` // Keep 1 in this many allowed queries; blocked ones are always kept (default 1)
+	Storage    string `yaml:"storage,omitempty"`     // Where the queries are kept: file (queries.db, default) or memory
+}
+
+// Where the query log is kept
+const (
+	QueryLogFile   = "file"
+	QueryLogMemory = "memory"
+)
+
+// LogRotationConfig says when the resolver's log file is rotated
+type LogRotationConfig struct {
+	MaxSize  *int   `yaml:"max_size,omitempty"`  // Megabytes the log may reach before it's rotated (default 10, 0 = no limit)
+	MaxAge   string `yaml:"max_age,omitempty"`   // Rotate the log once it's been written to this long, e.g. 1d (default: no limit)
+	MaxFiles *int   `yaml:"max_files,omitempty"` // Rotated logs kept (default 5)
+}
+
+// GetRotation returns when the log file is rotated
+func (c *LogRotationConfig) GetRotation() (logs.Rotation, error) {
+	rotation := logs.Rotation{MaxSize: DefaultLogMaxSize << 20, MaxFiles: DefaultLogMaxFiles}
+	if c == nil {
+		return rotation, nil
+	}
+	if c.MaxSize != nil {
+		if *c.MaxSize < 0 {
+			return rotation, fmt.Errorf("invalid log_rotation.max_size %d: must not be negative", *c.MaxSize)
+		}
+		rotation.MaxSize = int64(*c.MaxSize) << 20
+	}
+	if c.MaxAge != "" {
+		maxAge, err := ParseDays(c.MaxAge)
+		if err != nil || maxAge < 0 {
+			return rotation, fmt.Errorf("invalid log_rotation.max_age %q: use a duration like 1d or 12h, or 0 for no limit", c.MaxAge)
+		}
+		rotation.MaxAge = maxAge
+	}
+	if c.MaxFiles != nil {
+		if *c.MaxFiles < 0 {
+			return rotation, fmt.Errorf("invalid log_rotation.max_files %d: must not be negative", *c.MaxFiles)
+		}
+		rotation.MaxFiles = *c.MaxFiles
+	}
+	return rotation, nil
+}
+
+// IsEnabled reports whether queries are kept on disk
+func (c *QueryLogConfig) IsEnabled() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// GetSampleRate returns how many allowed queries one logged query stands for (1 = every
+// query is logged)
+func (c *QueryLogConfig) GetSampleRate() (int, error) {
+	if c == nil || c.SampleRate == nil {
+		return 1, nil
+	}
+	if *c.SampleRate < 1 {
+		return 0, fmt.Errorf("invalid query_log sample_rate %d: must be at least 1", *c.SampleRate)
+	}
+	return *c.SampleRate, nil
+}
+
+// GetStorage returns where the query log is kept
+func (c *QueryLogConfig) GetStorage() (string, error) {
+	if c == nil {
+		return QueryLogFile, nil
+	}
+	switch c.Storage {
+	case "", QueryLogFile:
+		return QueryLogFile, nil
+	case QueryLogMemory:
+		return QueryLogMemory, nil
+	default:
+		return "", fmt.Errorf("invalid query_log storage %q: use file or memory", c.Storage)
+	}
+}
+
+// GetQueryRetention returns how long queries stay in the log (0 keeps them forever)
+func (c *Config) GetQueryRetention() (time.Duration, error) {
+	if c.QueryRetention == "" {
+		return DefaultQueryRetention, nil
+	}
+	retention, err := ParseDays(c.QueryRetention)
+	if err != nil || retention < 0 {
+		return 0, fmt.Errorf("invalid query_retention %q: use a duration like 7d or 12h, or 0 to keep queries", c.QueryRetention)
+	}
+	return retention, nil
+}
+
+// GetMaxQueryRecords returns how many queries the log keeps at most (0 = no limit)
+func (c *Config) GetMaxQueryRecords() (int, error) {
+	if c.MaxQueryRecords < 0 {
+		return 0, fmt.Errorf("invalid max_query_records %d: must not be negative", c.MaxQueryRecords)
+	}
+	return c.MaxQueryRecords, nil
+}
+
+// ParseDays parses a duration that may also be given in whole days, e.g. 7d or 36h
+func ParseDays(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// GetDNSListen returns the address the DNS server binds
+func (c *Config) GetDNSListen() (string, error) {
+	return parseListen("dns_listen", c.DNSListen, DefaultDNSListen)
+}
+
+// GetDNSFallbackPort returns the port the DNS server binds when the port of dns_listen is
+// taken, or 0 when it then fails to start
+func (c *Config) GetDNSFallbackPort() (int, error) {
+	if c.DNSFallbackPort == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(c.DNSFallbackPort)
+	if err != nil || port < 1 || port > 65535 || port == 53 {
+		return 0, fmt.Errorf("invalid dns_fallback_port %q: use a port other than 53, e.g. 5354", c.DNSFallbackPort)
+	}
+	return port, nil
+}
+
+// GetAPIListen returns the address the HTTP API binds
+func (c *Config) GetAPIListen() (string, error) {
+	return c.CheckAPIListen("api_listen", c.APIListen)
+}
+
+// GetMetricsListen returns the address that serves only /metrics, or "" when metrics are
+// only served by the HTTP API
+func (c *Config) GetMetricsListen() (string, error) {
+	if c.MetricsListen == "" {
+		return "", nil
+	}
+	return parseListen("metrics_listen", c.MetricsListen, "")
+}
+
+// RunAsRoot, as run_as, keeps the resolver running as root
+const RunAsRoot = "root"
+
+// GetRunAs returns the user the resolver switches to once its ports are bound, or nil to
+// keep the current user. Unset, it is the user who started the resolver with sudo.
+func (c *Config) GetRunAs() (*user.User, error) {
+	switch c.RunAs {
+	case "":
+		if u, ok := InvokingUser(); ok {
+			return u, nil
+		}
+		return nil, nil
+	case RunAsRoot:
+		return nil, nil
+	}
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("invalid run_as %q: not supported on Windows", c.RunAs)
+	}
+	u, err := user.Lookup(c.RunAs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run_as %q: %w", c.RunAs, err)
+	}
+	return u, nil
+}
+
+// CheckAPIListen checks an address for the HTTP API, such as api_listen or --api-addr.
+// Anyone who reaches the API controls focus mode, so addresses other machines can reach
+// are refused unless api_allow_remote is set, api_tls requires client certificates, or lan
+// requires API tokens.
+func (c *Config) CheckAPIListen(name, value string) (string, error) {
+	addr, err := parseListen(name, value, DefaultAPIListen)
+	if err != nil {
+		return "", err
+	}
+	if !IsLoopbackListen(addr) && (c.APIAllowRemote == nil || !*c.APIAllowRemote) && !c.APITLS.RequiresClientCert() && !c.IsLAN() {
+		return "", fmt.Errorf("invalid %s %q: it lets other machines control focus mode; set api_allow_remote to true or api_tls.client_ca to allow that, or bind 127.0.0.1", name, value)
+	}
+	return addr, nil
+}
+
+// IsLoopbackListen reports whether a listen address only accepts connections from this machine
+func IsLoopbackListen(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// parseListen checks a host:port listen address; the host may be empty for all interfaces
+func parseListen(name, value, fallback string) (string, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s %q: use host:port, e.g. 127.0.0.1:53 or :53", name, value)
+	}
+	if host != "" && host != "localhost" && net.ParseIP(host) == nil {
+		return "", fmt.Errorf("invalid %s %q: the host must be an IP address", name, value)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("invalid %s %q: invalid port", name, value)
+	}
+	return value, nil
+}
+
+// GetUpstreamStrategy returns the order upstream nameservers are tried in
+func (c *Config) GetUpstreamStrategy() (string, error) {
+	switch c.UpstreamStrategy {
+	case "":
+		return UpstreamSequential, nil
+	case UpstreamSequential, UpstreamRoundRobin, UpstreamRandom, UpstreamFastest:
+		return c.UpstreamStrategy, nil
+	default:
+		return "", fmt.Errorf("invalid upstream_strategy %q: use %q, %q, %q, or %q", c.UpstreamStrategy, UpstreamSequential, UpstreamRoundRobin, UpstreamRandom, UpstreamFastest)
+	}
+}
+
+// GetBlockResponse returns how blocked queries are answered
+func (c *Config) GetBlockResponse() (string, error) {
+	switch c.BlockResponse {
+	case "":
+		return BlockResponseNXDomain, nil
+	case BlockResponseNXDomain, BlockResponseNullIP, BlockResponseRefused:
+		return c.BlockResponse, nil
+	default:
+		return "", fmt.Errorf("invalid block_response %q: use %q, %q, or %q", c.BlockResponse, BlockResponseNXDomain, BlockResponseNullIP, BlockResponseRefused)
+	}
+}
+
+// GetBlockedTTL returns how long clients may cache the answer to a blocked query
+func (c *Config) GetBlockedTTL() (time.Duration, error) {
+	if c.BlockedTTL == "" {
+		return DefaultBlockedTTL, nil
+	}
+	ttl, err := time.ParseDuration(c.BlockedTTL)
+	if err != nil || ttl < 0 || ttl%time.Second != 0 {
+		return 0, fmt.Errorf("invalid blocked_ttl %q: must be a non-negative duration in whole seconds", c.BlockedTTL)
+	}
+	return ttl, nil
+}
+
+// GetLogLevel returns the lowest level the resolver logs when no flag overrides it
+func (c *Config) GetLogLevel() (logs.Level, error) {
+	level, err := logs.ParseLevel(c.LogLevel)
+	if err != nil {
+		return logs.LevelInfo, fmt.Errorf("invalid log_level: %w", err)
+	}
+	return level, nil
+}
+
+// GetLogLevels returns the levels given to single components in log_levels
+func (c *Config) GetLogLevels() (map[string]logs.Level, error) {
+	levels := make(map[string]logs.Level, len(c.LogLevels))
+	for component, value := range c.LogLevels {
+		if !slices.Contains(logs.Components, component) {
+			return nil, fmt.Errorf("invalid log_levels: unknown component %q (use %s)", component, strings.Join(logs.Components, " or "))
+		}
+		level, err := logs.ParseLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid log_levels.%s: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// GetLogOutput returns where the resolver logs: log_file (or standard error), or the system log
+func (c *Config) GetLogOutput() (string, error) {
+	output, err := logs.ParseOutput(c.LogOutput)
+	if err != nil {
+		return "", fmt.Errorf("invalid log_output: %w", err)
+	}
+	return output, nil
+}
+
+// GetLogFormat returns the format the resolver logs in when no flag overrides it
+func (c *Config) GetLogFormat() (string, error) {
+	format, err := logs.ParseFormat(c.LogFormat)
+	if err != nil {
+		return "", fmt.Errorf("invalid log_format: %w", err)
+	}
+	return format, nil
+}
+
+// AcceptsRemoteMutations reports whether API requests from other machines may change
+// anything, or only read
+func (c *Config) AcceptsRemoteMutations() bool {
+	return c.MutationsLocalOnly == nil || !*c.MutationsLocalOnly
+}
+
+// minTriggerSecret is the shortest trigger_secret accepted, as it guards an endpoint other
+// machines may reach
+const minTriggerSecret = 16
+
+// GetTriggerSecret returns the secret of the trigger endpoint, "" when it is off
+func (c *Config) GetTriggerSecret() (string, error) {
+	if c.TriggerSecret != "" && len(c.TriggerSecret) < minTriggerSecret {
+		return "", fmt.Errorf("trigger_secret must be at least %d characters long", minTriggerSecret)
+	}
+	return c.TriggerSecret, nil
+}
+
+// ResolvesClientHostnames reports whether client addresses are looked up with reverse DNS
+func (c *Config) ResolvesClientHostnames() bool {
+	return c.ResolveClientHostnames == nil || *c.ResolveClientHostnames
+}
+
+// ChecksBypass reports whether the resolver checks that this machine's DNS queries reach it
+func (c *Config) ChecksBypass() bool {
+	return c.BypassCheck == nil || *c.BypassCheck
+}
+
+// What the resolver does about a suspected captive portal, chosen with captive_portal.mode
+const (
+	CaptivePortalNotify = "notify" // Warn and suggest 'sinkzone passthrough' (default)
+	CaptivePortalAuto   = "auto"   // Start a passthrough window by itself
+	CaptivePortalOff    = "off"    // Don't look for captive portals
+)
+
+// GetMode returns what the resolver does about a suspected captive portal
+func (c *CaptivePortalConfig) GetMode() (string, error) {
+	if c == nil || c.Mode == "" {
+		return CaptivePortalNotify, nil
+	}
+	switch c.Mode {
+	case CaptivePortalNotify, CaptivePortalAuto, CaptivePortalOff:
+		return c.Mode, nil
+	default:
+		return "", fmt.Errorf("invalid captive_portal mode %q: use notify, auto, or off", c.Mode)
+	}
+}
+
+// GetPassthrough returns how long a passthrough window lasts when no length is asked for
+func (c *CaptivePortalConfig) GetPassthrough() (time.Duration, error) {
+	if c == nil || c.Passthrough == "" {
+		return DefaultPassthrough, nil
+	}
+	d, err := time.ParseDuration(c.Passthrough)
+	if err != nil || d <= 0 || d > MaxPassthrough {
+		return 0, fmt.Errorf("invalid captive_portal passthrough %q: must be a duration of at most %d minutes, e.g. 5m", c.Passthrough, int(MaxPassthrough.Minutes()))
+	}
+	return d, nil
+}
+
+// Ways of recording client addresses, chosen with client_privacy
+const (
+	ClientPrivacyOff      = "off"      // The full address (default)
+	ClientPrivacyTruncate = "truncate" // Only the network: /24 for IPv4, /48 for IPv6
+	ClientPrivacyHash     = "hash"     // A label derived from the address with a key that changes at every start
+)
+
+// GetClientPrivacy returns how client addresses are recorded in the query log, API, and exports
+func (c *Config) GetClientPrivacy() (string, error) {
+	switch c.ClientPrivacy {
+	case "":
+		return ClientPrivacyOff, nil
+	case ClientPrivacyOff, ClientPrivacyTruncate, ClientPrivacyHash:
+		return c.ClientPrivacy, nil
+	default:
+		return "", fmt.Errorf("invalid client_privacy %q: use off, truncate, or hash", c.ClientPrivacy)
+	}
+}
+
+// GetClientNames returns the names given to clients, keyed by normalized IP or MAC address
+// (see ClientAddressKey)
+func (c *Config) GetClientNames() (map[string]string, error) {
+	names := make(map[string]string, len(c.ClientNames))
+	for addr, name := range c.ClientNames {
+		key, ok := ClientAddressKey(addr)
+		if !ok {
+			return nil, fmt.Errorf("invalid client_names address %q: use an IP address or a MAC address such as aa:bb:cc:dd:ee:ff", addr)
+		}
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid client_names entry for %s: the name is empty", addr)
+		}
+		if _, ok := names[key]; ok {
+			return nil, fmt.Errorf("invalid client_names: %s is named twice", addr)
+		}
+		names[key] = name
+	}
+	return names, nil
+}
+
+// ClientAddressKey normalizes an IP or MAC address for looking up client names: IP
+// addresses in their canonical form, MAC addresses in lower case separated by colons
+func ClientAddressKey(addr string) (string, bool) {
+	addr = strings.TrimSpace(addr)
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String(), true
+	}
+	if mac, err := net.ParseMAC(addr); err == nil {
+		return mac.String(), true
+	}
+	return "", false
+}
+
+// SetClientName names a client in client_names, replacing its name under any spelling of
+// the address, and returns the address as saved
+func (c *Config) SetClientName(addr, name string) (string, error) {
+	key, ok := ClientAddressKey(addr)
+	if !ok {
+		return "", fmt.Errorf("invalid address %q: use an IP address or a MAC address such as aa:bb:cc:dd:ee:ff", addr)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("the name for %s is empty", key)
+	}
+	for existing := range c.ClientNames {
+		if other, _ := ClientAddressKey(existing); other == key {
+			delete(c.ClientNames, existing)
+		}
+	}
+	if c.ClientNames == nil {
+		c.ClientNames = make(map[string]string)
+	}
+	c.ClientNames[key] = name
+	return key, nil
+}
+
+// RemoveClientName removes a client from client_names, given by address or by name (every
+// address with that name), and returns the addresses removed
+func (c *Config) RemoveClientName(client string) []string {
+	key, isAddr := ClientAddressKey(client)
+	var removed []string
+	for addr, name := range c.ClientNames {
+		other, _ := ClientAddressKey(addr)
+		if (isAddr && other == key) || (!isAddr && strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(client))) {
+			delete(c.ClientNames, addr)
+			removed = append(removed, addr)
+		}
+	}
+	slices.Sort(removed)
+	return removed
+}
+
+// GetSize returns the maximum number of cached answers (0 when caching is off)
+func (c *CacheConfig) GetSize() (int, error) {
+	if c == nil || c.Size == nil {
+		return DefaultCacheSize, nil
+	}
+	if *c.Size < 0 {
+		return 0, fmt.Errorf("invalid cache size %d: must not be negative", *c.Size)
+	}
+	return *c.Size, nil
+}
+
+// GetTTLBounds returns the range the TTL of cached answers is clamped to
+func (c *CacheConfig) GetTTLBounds() (time.Duration, time.Duration, error) {
+	if c == nil {
+		return 0, DefaultCacheMax, nil
+	}
+	minTTL, maxTTL := time.Duration(0), DefaultCacheMax
+	var err error
+	if c.MinTTL != "" {
+		if minTTL, err = time.ParseDuration(c.MinTTL); err != nil || minTTL < 0 {
+			return 0, 0, fmt.Errorf("invalid cache min_ttl %q: must be a non-negative duration", c.MinTTL)
+		}
+	}
+	if c.MaxTTL != "" {
+		if maxTTL, err = time.ParseDuration(c.MaxTTL); err != nil || maxTTL <= 0 {
+			return 0, 0, fmt.Errorf("invalid cache max_ttl %q: must be a positive duration", c.MaxTTL)
+		}
+	}
+	if minTTL > maxTTL {
+		return 0, 0, fmt.Errorf("invalid cache TTLs: min_ttl %s is longer than max_ttl %s", minTTL, maxTTL)
+	}
+	return minTTL, maxTTL, nil
+}
+
+// GetServeStale returns how long after their TTL runs out answers may still be served
+// when every upstream fails (0 = never)
+func (c *CacheConfig) GetServeStale() (time.Duration, error) {
+	if c == nil || c.ServeStale == "" {
+		return 0, nil
+	}
+	stale, err := ParseDays(c.ServeStale)
+	if err != nil || stale < 0 {
+		return 0, fmt.Errorf("invalid cache serve_stale %q: must be a non-negative duration, e.g. 1d", c.ServeStale)
+	}
+	return stale, nil
+}
+
+// GetLimit returns the per-client query rate and burst (a rate of 0 means unlimited)
+func (c *RateLimitConfig) GetLimit() (int, int, error) {
+	if c == nil || c.QueriesPerSecond == 0 {
+		return 0, 0, nil
+	}
+	if c.QueriesPerSecond < 0 {
+		return 0, 0, fmt.Errorf("invalid rate_limit queries_per_second %d: must not be negative", c.QueriesPerSecond)
+	}
+	if c.Burst < 0 {
+		return 0, 0, fmt.Errorf("invalid rate_limit burst %d: must not be negative", c.Burst)
+	}
+	burst := c.Burst
+	if burst == 0 {
+		burst = c.QueriesPerSecond
+	}
+	return c.QueriesPerSecond, burst, nil
+}
+
+// GetCooldown returns how many strikes within a minute start a cooldown (0 = never) and
+// how long it lasts
+func (c *CooldownConfig) GetCooldown() (int, time.Duration, error) {
+	if c == nil {
+		return 0, DefaultCooldown, nil
+	}
+	if c.Strikes < 0 {
+		return 0, 0, fmt.Errorf("invalid cooldown strikes %d: must not be negative", c.Strikes)
+	}
+	duration := DefaultCooldown
+	if c.Duration != "" {
+		d, err := time.ParseDuration(c.Duration)
+		if err != nil || d <= 0 {
+			return 0, 0, fmt.Errorf("invalid cooldown duration %q: must be a positive duration, e.g. 10m", c.Duration)
+		}
+		duration = d
+	}
+	return c.Strikes, duration, nil
+}
+
+// GetLimits returns how many queries are handled at once (0 = unlimited) and how many may
+// wait for a turn
+func (c *ConcurrencyConfig) GetLimits() (int, int, error) {
+	maxQueries, maxQueued := DefaultMaxQueries, DefaultMaxQueued
+	if c != nil && c.MaxQueries != nil {
+		maxQueries = *c.MaxQueries
+	}
+	if c != nil && c.MaxQueued != nil {
+		maxQueued = *c.MaxQueued
+	}
+	if maxQueries < 0 {
+		return 0, 0, fmt.Errorf("invalid concurrency max_queries %d: must not be negative", maxQueries)
+	}
+	if maxQueued < 0 {
+		return 0, 0, fmt.Errorf("invalid concurrency max_queued %d: must not be negative", maxQueued)
+	}
+	return maxQueries, maxQueued, nil
+}
+
+// GetLimits returns how many recent queries are kept in memory and how many bytes they may
+// take (0 = no limit)
+func (c *RecentQueriesConfig) GetLimits() (int, int64, error) {
+	size, maxSize := DefaultRecentQueries, 0
+	if c != nil && c.Size != nil {
+		size = *c.Size
+	}
+	if c != nil && c.MaxSize != nil {
+		maxSize = *c.MaxSize
+	}
+	if size < 0 {
+		return 0, 0, fmt.Errorf("invalid recent_queries size %d: must not be negative", size)
+	}
+	if maxSize < 0 {
+		return 0, 0, fmt.Errorf("invalid recent_queries max_size %d: must not be negative", maxSize)
+	}
+	return size, int64(maxSize) << 20, nil
+}
+
+// GetAggregate returns how long repeats of a domain are counted in one recent query, 0 when
+// every query is kept as one
+func (c *RecentQueriesConfig) GetAggregate() (time.Duration, error) {
+	if c == nil || c.Aggregate == "" {
+		return DefaultAggregate, nil
+	}
+	d, err := time.ParseDuration(c.Aggregate)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid recent_queries aggregate %q: must be a duration such as 60s, or 0 to keep every query", c.Aggregate)
+	}
+	return d, nil
+}
+
+// ValidateServer checks every server setting, so the resolver fails at startup instead of
+// when a setting is first used
+func (c *Config) ValidateServer() error {
+	if _, err := c.GetDNSListen(); err != nil {
+		return err
+	}
+	if _, err := c.GetDNSFallbackPort(); err != nil {
+		return err
+	}
+	if _, err := c.GetAPIListen(); err != nil {
+		return err
+	}
+	if _, err := c.GetMetricsListen(); err != nil {
+		return err
+	}
+	if _, err := c.GetRunAs(); err != nil {
+		return err
+	}
+	if err := c.ValidateAPITokens(); err != nil {
+		return err
+	}
+	if _, err := c.GetTriggerSecret(); err != nil {
+		return err
+	}
+	if c.IsLAN() && len(c.APITokens) == 0 {
+		return fmt.Errorf("lan needs api_tokens, as every device of the network reaches the API")
+	}
+	if _, err := c.APITLS.ServerTLS(); err != nil {
+		return err
+	}
+	if _, err := c.CrashReports.GetUploadURL(); err != nil {
+		return err
+	}
+	if _, err := c.Hooks.GetEvents(); err != nil {
+		return err
+	}
+	if _, err := c.GetBudgets(); err != nil {
+		return err
+	}
+	if c.Tracing != nil {
+		if _, err := c.Tracing.GetEndpoint(); err != nil {
+			return err
+		}
+		if _, err := c.Tracing.GetSamplePercent(); err != nil {
+			return err
+		}
+	}
+	if c.Notifications != nil {
+		if _, _, err := c.Notifications.GetWebhooks(); err != nil {
+			return err
+		}
+		if _, err := c.Notifications.GetDailySummary(); err != nil {
+			return err
+		}
+		if _, err := c.Notifications.GetPressureAlert(); err != nil {
+			return err
+		}
+		for i := range c.Notifications.Push {
+			if err := c.Notifications.Push[i].Validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := c.Tailscale.GetHostname(); err != nil {
+		return err
+	}
+	if c.Tailscale.ServesAPI() && len(c.APITokens) == 0 {
+		return fmt.Errorf("tailscale.api needs api_tokens, as every machine of the tailnet reaches the API")
+	}
+	if err := c.VPN.Validate(); err != nil {
+		return err
+	}
+	if c.MQTT != nil {
+		if _, _, err := c.MQTT.GetBroker(); err != nil {
+			return err
+		}
+		if _, err := c.MQTT.GetTopic(); err != nil {
+			return err
+		}
+	}
+	if _, err := c.GetUpstreams(); err != nil {
+		return err
+	}
+	if _, err := c.GetUpstreamStrategy(); err != nil {
+		return err
+	}
+	if _, err := c.GetBlockResponse(); err != nil {
+		return err
+	}
+	if _, err := c.GetBlockedTTL(); err != nil {
+		return err
+	}
+	if _, err := c.GetLogLevel(); err != nil {
+		return err
+	}
+	if _, err := c.GetLogLevels(); err != nil {
+		return err
+	}
+	if _, err := c.GetLogFormat(); err != nil {
+		return err
+	}
+	if _, err := c.GetLogOutput(); err != nil {
+		return err
+	}
+	if _, err := c.LogRotation.GetRotation(); err != nil {
+		return err
+	}
+	if _, err := c.GetFileIntegrity(); err != nil {
+		return err
+	}
+	if _, err := c.GetClientPrivacy(); err != nil {
+		return err
+	}
+	if _, err := c.GetClientNames(); err != nil {
+		return err
+	}
+	if _, err := c.GetUsers(); err != nil {
+		return err
+	}
+	if _, err := c.Cache.GetSize(); err != nil {
+		return err
+	}
+	if _, _, err := c.Cache.GetTTLBounds(); err != nil {
+		return err
+	}
+	if _, err := c.Cache.GetServeStale(); err != nil {
+		return err
+	}
+	if _, _, err := c.RateLimit.GetLimit(); err != nil {
+		return err
+	}
+	if _, _, err := c.Cooldown.GetCooldown(); err != nil {
+		return err
+	}
+	if _, err := c.CaptivePortal.GetMode(); err != nil {
+		return err
+	}
+	if _, err := c.CaptivePortal.GetPassthrough(); err != nil {
+		return err
+	}
+	if err := c.ValidatePrivateZones(); err != nil {
+		return err
+	}
+	if _, _, err := c.Concurrency.GetLimits(); err != nil {
+		return err
+	}
+	if _, err := c.QueryLog.GetSampleRate(); err != nil {
+		return err
+	}
+	if _, err := c.QueryLog.GetStorage(); err != nil {
+		return err
+	}
+	if _, _, err := c.RecentQueries.GetLimits(); err != nil {
+		return err
+	}
+	if _, err := c.RecentQueries.GetAggregate(); err != nil {
+		return err
+	}
+	if _, err := c.GetQueryRetention(); err != nil {
+		return err
+	}
+	if _, err := c.GetMaxQueryRecords(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"net"
+	"os/user"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestValidateServer(t *testing.T) {
+	cfg := &Config{}
+	if err := cfg.ValidateServer(); err != nil {
+		t.Fatalf("expected the defaults to be valid: %v", err)
+	}
+	if addr, _ := cfg.GetDNSListen(); addr != DefaultDNSListen {
+		t.Errorf("expected %s, got %s", DefaultDNSListen, addr)
+	}
+	if ttl, _ := cfg.GetBlockedTTL(); ttl != DefaultBlockedTTL {
+		t.Errorf("expected %s, got %s", DefaultBlockedTTL, ttl)
+	}
+	if retention, _ := (&Config{QueryRetention: "30d"}).GetQueryRetention(); retention != 30*24*time.Hour {
+		t.Errorf("expected 30 days, got %s", retention)
+	}
+	if !cfg.ResolvesClientHostnames() {
+		t.Error("expected client hostnames to be resolved by default")
+	}
+
+	negative := -1
+	lan := true
+	invalid := []*Config{
+		{LAN: &lan},
+		{Tailscale: &TailscaleConfig{Enabled: &lan, API: &lan}},
+		{Tailscale: &TailscaleConfig{Hostname: "sink zone"}},
+		{DNSListen: "53"},
+		{APIListen: "example.com:8080"},
+		{APIListen: ":8080"},
+		{APIListen: "0.0.0.0:8080"},
+		{BlockResponse: "blackhole"},
+		{BlockedTTL: "1.5s"},
+		{LogLevel: "chatty"},
+		{LogOutput: "journal"},
+		{CrashReports: &CrashReportsConfig{UploadURL: "ftp://example.com"}},
+		{Cache: &CacheConfig{MinTTL: "2h"}},
+		{RateLimit: &RateLimitConfig{QueriesPerSecond: -1}},
+		{Concurrency: &ConcurrencyConfig{MaxQueued: &negative}},
+		{CaptivePortal: &CaptivePortalConfig{Mode: "always"}},
+		{CaptivePortal: &CaptivePortalConfig{Passthrough: "2h"}},
+		{RecentQueries: &RecentQueriesConfig{Size: &negative}},
+		{RecentQueries: &RecentQueriesConfig{Aggregate: "-1m"}},
+		{QueryRetention: "a week"},
+		{MaxQueryRecords: -1},
+		{RunAs: "no-such-sinkzone-user"},
+		{APITokens: []APIToken{{Name: "statusbar", Token: "short", Scopes: []string{"read"}}}},
+		{APITokens: []APIToken{{Name: "statusbar", Token: "0123456789abcdef", Scopes: []string{"write"}}}},
+		{APITokens: []APIToken{{Name: "a", Token: "0123456789abcdef", Scopes: []string{"read"}}, {Name: "b", Token: "0123456789abcdef", Scopes: []string{"admin"}}}},
+		{ClientNames: map[string]string{"laptop": "work-laptop"}},
+		{ClientNames: map[string]string{"10.0.0.2": " "}},
+		{ClientNames: map[string]string{"AA-BB-CC-DD-EE-FF": "a", "aa:bb:cc:dd:ee:ff": "b"}},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.ValidateServer(); err == nil {
+			t.Errorf("expected %+v to be rejected", cfg)
+		}
+	}
+}
+
+func TestClientNames(t *testing.T) {
+	cfg := &Config{ClientNames: map[string]string{
+		"192.168.1.23":      "kids-ipad",
+		"2001:DB8::1":       "kids-ipad",
+		"AA-BB-CC-DD-EE-FF": "work-laptop",
+	}}
+	names, err := cfg.GetClientNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for addr, want := range map[string]string{
+		"192.168.1.23":      "kids-ipad",
+		"2001:db8::1":       "kids-ipad",
+		"aa:bb:cc:dd:ee:ff": "work-laptop",
+	} {
+		if names[addr] != want {
+			t.Errorf("expected %s to be named %q, got %q", addr, want, names[addr])
+		}
+	}
+
+	// Renaming replaces the entry under another spelling of the address
+	if key, err := cfg.SetClientName("aa:bb:cc:dd:ee:ff", "desk"); err != nil || key != "aa:bb:cc:dd:ee:ff" || len(cfg.ClientNames) != 3 {
+		t.Errorf("expected the MAC address to be renamed, got %q, %v, %v", key, err, cfg.ClientNames)
+	}
+	if _, err := cfg.SetClientName("laptop", "desk"); err == nil {
+		t.Error("expected a name for an invalid address to be rejected")
+	}
+	if removed := cfg.RemoveClientName("KIDS-IPAD"); len(removed) != 2 || len(cfg.ClientNames) != 1 {
+		t.Errorf("expected both addresses of kids-ipad to be removed, got %v", removed)
+	}
+	if removed := cfg.RemoveClientName("AA-BB-CC-DD-EE-FF"); len(removed) != 1 || len(cfg.ClientNames) != 0 {
+		t.Errorf("expected the MAC address to be removed, got %v", removed)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	cfg := &Config{Users: map[string]UserPolicy{"sam": {Focus: DeviceFocusOff}, "alex": {Allowlist: []string{"docs.example"}}}}
+	users, err := cfg.GetUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if users["sam"].Focus != DeviceFocusOff || users["alex"].Focus != DeviceFocusFollow {
+		t.Errorf("expected sam off and alex following the session, got %+v", users)
+	}
+
+	for _, invalid := range []map[string]UserPolicy{
+		{"sam": {Focus: "sometimes"}},
+		{"user:sam": {}},
+		{"": {}},
+	} {
+		cfg.Users = invalid
+		if err := cfg.ValidateServer(); err == nil {
+			t.Errorf("expected %v to be rejected", invalid)
+		}
+	}
+}
+
+func TestAPIListenNeedsOptIn(t *testing.T) {
+	for _, addr := range []string{"", "127.0.0.1:8081", "localhost:8080", "[::1]:8080"} {
+		if _, err := (&Config{APIListen: addr}).GetAPIListen(); err != nil {
+			t.Errorf("expected %q to be allowed: %v", addr, err)
+		}
+	}
+
+	allow := true
+	cfg := &Config{APIListen: ":8080", APIAllowRemote: &allow}
+	if addr, err := cfg.GetAPIListen(); err != nil || addr != ":8080" {
+		t.Errorf("expected api_allow_remote to allow all interfaces, got %q (%v)", addr, err)
+	}
+	if _, err := (&Config{}).CheckAPIListen("--api-addr", "192.168.1.10:8080"); err == nil {
+		t.Error("expected a LAN address to need api_allow_remote")
+	}
+
+	lanCfg := &Config{LAN: &allow, APIListen: "0.0.0.0:8080", APITokens: []APIToken{{Name: "cli", Token: "0123456789abcdef", Scopes: []string{"admin"}}}}
+	if err := lanCfg.ValidateServer(); err != nil {
+		t.Errorf("expected lan with api_tokens to allow all interfaces: %v", err)
+	}
+}
+
+func TestRunAs(t *testing.T) {
+	t.Setenv("SUDO_USER", "")
+	for _, runAs := range []string{"", RunAsRoot} {
+		if u, err := (&Config{RunAs: runAs}).GetRunAs(); err != nil || u != nil {
+			t.Errorf("expected run_as %q to keep the current user, got %v (%v)", runAs, u, err)
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("run_as is not supported on Windows")
+	}
+	nobody, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip(err)
+	}
+	u, err := (&Config{RunAs: "nobody"}).GetRunAs()
+	if err != nil || u == nil || u.Uid != nobody.Uid {
+		t.Errorf("expected run_as to look up nobody, got %v (%v)", u, err)
+	}
+}
+
+func TestServerKeys(t *testing.T) {
+	cfg := &Config{}
+
+	size, _ := LookupKey("cache.size")
+	if err := size.Set(cfg, "lots"); err == nil {
+		t.Error("expected a non-number to be rejected")
+	}
+	if err := size.Set(cfg, "0"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := cfg.Cache.GetSize(); n != 0 {
+		t.Errorf("expected the cache to be disabled, got size %d", n)
+	}
+	if err := size.Set(cfg, ""); err != nil || cfg.Cache != nil {
+		t.Errorf("expected unsetting the size to drop the cache section, got %+v (%v)", cfg.Cache, err)
+	}
+
+	rate, _ := LookupKey("rate_limit.queries_per_second")
+	if err := rate.Set(cfg, "50"); err != nil {
+		t.Fatal(err)
+	}
+	if qps, burst, _ := cfg.RateLimit.GetLimit(); qps != 50 || burst != 50 {
+		t.Errorf("expected 50 queries per second with a burst of 50, got %d/%d", qps, burst)
+	}
+
+	resolve, _ := LookupKey("resolve_client_hostnames")
+	if err := resolve.Set(cfg, "maybe"); err == nil {
+		t.Error("expected a non-boolean to be rejected")
+	}
+	if err := resolve.Set(cfg, "false"); err != nil || cfg.ResolvesClientHostnames() {
+		t.Errorf("expected reverse lookups to be disabled (%v)", err)
+	}
+
+	ttl, _ := LookupKey("blocked_ttl")
+	if err := ttl.Set(cfg, "30s"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := cfg.GetBlockedTTL(); got != 30*time.Second {
+		t.Errorf("expected 30s, got %s", got)
+	}
+}
+
+func TestPrivateZones(t *testing.T) {
+	cfg := &Config{UpstreamNameservers: []string{"1.1.1.1", "127.0.0.1:5353"}}
+	if mode, err := cfg.GetPrivateMode(); err != nil || mode != PrivateNXDomain {
+		t.Errorf("expected nxdomain without a local nameserver, got %q (%v)", mode, err)
+	}
+	cfg.PrivateZones = &PrivateZonesConfig{Mode: PrivateForward}
+	if err := cfg.ValidateServer(); err == nil {
+		t.Error("expected the forward mode to need a local nameserver")
+	}
+
+	cfg.UpstreamNameservers = append(cfg.UpstreamNameservers, "192.168.1.1")
+	if mode, err := cfg.GetPrivateMode(); err != nil || mode != PrivateForward {
+		t.Errorf("expected forward to the router, got %q (%v)", mode, err)
+	}
+	cfg.PrivateZones.Resolver = "10.0.0.53:5353"
+	if resolvers, _ := cfg.GetPrivateResolvers(); len(resolvers) != 1 || resolvers[0].Address != "10.0.0.53:5353" {
+		t.Errorf("expected the configured resolver, got %v", resolvers)
+	}
+
+	cfg.PrivateZones.Hosts = map[string]string{"nas.lan": "nas"}
+	if err := cfg.ValidatePrivateZones(); err == nil {
+		t.Error("expected a host without an address to be rejected")
+	}
+
+	for ip, want := range map[string]bool{"192.168.1.5": true, "100.64.0.1": true, "fe80::1": true, "100.128.0.1": false, "8.8.8.8": false} {
+		if got := IsPrivateAddress(net.ParseIP(ip)); got != want {
+			t.Errorf("expected IsPrivateAddress(%s) to be %v", ip, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ScheduledSession is a one-off focus session queued to start at a later time
+type ScheduledSession struct {
+	ID       string        `json:"id"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	Profile  string        `json:"profile,omitempty"`
+	Label    string        `json:"label,omitempty"`
+}
+
+// End returns when the session is over
+func (s ScheduledSession) End() time.Time {
+	return s.Start.Add(s.Duration)
+}
+
+// ScheduledSessions returns the queued sessions ordered by start time
+func (sm *StateManager) ScheduledSessions() []ScheduledSession {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	sessions := make([]ScheduledSession, len(sm.state.ScheduledSessions))
+	copy(sessions, sm.state.ScheduledSessions)
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Start.Before(sessions[j].Start)
+	})
+	return sessions
+}
+
+// AddScheduledSession queues a session, assigning it an ID. Sessions must not overlap.
+func (sm *StateManager) AddScheduledSession(session ScheduledSession) (ScheduledSession, error) {
+	if session.Duration <= 0 {
+		return ScheduledSession{}, fmt.Errorf("scheduled sessions need a positive duration")
+	}
+	if !session.End().After(time.Now()) {
+		return ScheduledSession{}, fmt.Errorf("session would already be over at %s", session.End().Format("15:04"))
+	}
+
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return ScheduledSession{}, fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	session.ID = hex.EncodeToString(id)
+
+	err := sm.update(func(state *State) error {
+		for _, other := range state.ScheduledSessions {
+			if session.Start.Before(other.End()) && other.Start.Before(session.End()) {
+				return fmt.Errorf("overlaps scheduled session %s (%s-%s)",
+					other.ID, other.Start.Format("Jan 2 15:04"), other.End().Format("15:04"))
+			}
+		}
+		state.ScheduledSessions = append(state.ScheduledSessions, session)
+		return nil
+	})
+	if err != nil {
+		return ScheduledSession{}, err
+	}
+	return session, nil
+}
+
+// RemoveScheduledSession removes a queued session by ID
+func (sm *StateManager) RemoveScheduledSession(id string) error {
+	return sm.update(func(state *State) error {
+		for i, session := range state.ScheduledSessions {
+			if session.ID == id {
+				state.ScheduledSessions = append(state.ScheduledSessions[:i], state.ScheduledSessions[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("no scheduled session with ID %s", id)
+	})
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduledSessionsRejectOverlap(t *testing.T) {
+	sm := &StateManager{statePath: t.TempDir() + "/state.json"}
+	start := time.Now().Add(time.Hour).Truncate(time.Minute)
+
+	first, err := sm.AddScheduledSession(ScheduledSession{Start: start, Duration: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to schedule session: %v", err)
+	}
+	if first.ID == "" {
+		t.Error("Expected scheduled session to get an ID")
+	}
+
+	if _, err := sm.AddScheduledSession(ScheduledSession{Start: start.Add(time.Hour), Duration: time.Hour}); err == nil {
+		t.Error("Expected overlapping session to be rejected")
+	}
+
+	// Back-to-back sessions are fine
+	if _, err := sm.AddScheduledSession(ScheduledSession{Start: start.Add(2 * time.Hour), Duration: time.Hour}); err != nil {
+		t.Errorf("Expected adjacent session to be accepted, got %v", err)
+	}
+
+	if err := sm.RemoveScheduledSession(first.ID); err != nil {
+		t.Fatalf("Failed to cancel session: %v", err)
+	}
+	if sessions := sm.ScheduledSessions(); len(sessions) != 1 {
+		t.Errorf("Expected one session left, got %d", len(sessions))
+	}
+	if err := sm.RemoveScheduledSession(first.ID); err == nil {
+		t.Error("Expected cancelling an unknown session to fail")
+	}
+}
@@ -2,27 +2,81 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
+	"maps"
 	"os"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"sync"
 	"time"
 )
 
 // State represents the real-time state that can be shared between processes
 type State struct {
-	FocusMode    bool       `json:"focus_mode"`
-	FocusEndTime *time.Time `json:"focus_end_time,omitempty"`
-	LastUpdated  time.Time  `json:"last_updated"`
+	FocusMode      bool       `json:"focus_mode"`
+	FocusEndTime   *time.Time `json:"focus_end_time,omitempty"`
+	FocusProfile   string     `json:"focus_profile,omitempty"`
+	FocusIntensity string     `json:"focus_intensity,omitempty"`
+	FocusLabel     string     `json:"focus_label,omitempty"`
+	FocusDryRun    bool       `json:"focus_dry_run,omitempty"`
+	LastUpdated    time.Time  `json:"last_updated"`
+
+	// Focus time per day (YYYY-MM-DD -> seconds), used for daily goals and streaks
+	DailyFocus map[string]int64 `json:"daily_focus_seconds,omitempty"`
+
+	// Focus time per day and session label (YYYY-MM-DD -> label -> seconds), used for reports
+	LabelFocus map[string]map[string]int64 `json:"label_focus_seconds,omitempty"`
+
+	// Allowlist entries at the end of each day it changed (YYYY-MM-DD -> domains), used for reports
+	AllowlistSize map[string]int `json:"allowlist_size,omitempty"`
+
+	// Minutes each daily time budget was used today
+	BudgetUsage *BudgetUsage `json:"budget_usage,omitempty"`
+
+	// One-off focus sessions queued to start later
+	ScheduledSessions []ScheduledSession `json:"scheduled_sessions,omitempty"`
+
+	// Average latency per upstream address in microseconds, used by the fastest strategy
+	UpstreamLatency map[string]int64 `json:"upstream_latency_us,omitempty"`
+
+	// Focus overrides of single devices, keyed by MAC or client address
+	DeviceFocus map[string]DeviceFocus `json:"device_focus,omitempty"`
+
+	// Incremented on every write, for CompareAndSet
+	Revision uint64 `json:"revision"`
+
+	// The focus session signed by the resolver (see SetSealKey)
+	FocusSeal string `json:"focus_seal,omitempty"`
+}
+
+// BudgetUsage is the use of the daily time budgets on one day
+type BudgetUsage struct {
+	Day     string         `json:"day"`     // YYYY-MM-DD
+	Minutes map[string]int `json:"minutes"` // Minutes with queries, by budget pattern
 }
 
-// StateManager handles real-time state updates
+// ErrStateChanged is returned by CompareAndSet when the state was written since the caller
+// read it
+var ErrStateChanged = errors.New("state changed since it was read")
+
+// errStateUnchanged lets an update leave the state file alone
+var errStateUnchanged = errors.New("state unchanged")
+
+// StateManager handles real-time state updates. Writers in every process hold a lock on
+// state.json.lock while they read, change, and replace the file, so concurrent updates
+// from the resolver and the CLI don't overwrite each other.
 type StateManager struct {
 	statePath string
 	mu        sync.RWMutex
 	state     State
 	listeners []chan State
+
+	// Set by the resolver to sign the focus session and keep it over edits to the file
+	sealKey []byte
+	sealed  sealedFocus
 }
 
 // NewStateManager creates a new state manager
@@ -40,11 +94,11 @@ func NewStateManager() (*StateManager, error) {
 	// Load initial state
 	if err := sm.loadState(); err != nil {
 		// Create default state if file doesn't exist
-		sm.state = State{
-			FocusMode:   false,
-			LastUpdated: time.Now(),
-		}
-		if err := sm.saveState(); err != nil {
+		err := sm.update(func(state *State) error {
+			state.LastUpdated = time.Now()
+			return nil
+		})
+		if err != nil {
 			return nil, fmt.Errorf("failed to create default state: %w", err)
 		}
 	}
@@ -59,54 +113,194 @@ func (sm *StateManager) GetState() State {
 	return sm.state
 }
 
+// FocusSessionOptions are the settings of a focus session saved by SetFocusSession
+type FocusSessionOptions struct {
+	Duration  time.Duration // Time left in the session, 0 when it runs until stopped
+	Profile   string
+	Intensity string
+	Label     string
+	DryRun    bool
+}
+
 // SetFocusMode updates the focus mode state
 func (sm *StateManager) SetFocusMode(enabled bool, duration time.Duration) error {
+	return sm.SetFocusSession(enabled, FocusSessionOptions{Duration: duration})
+}
+
+// SetFocusSession updates the focus mode state including the settings of the session; they
+// are cleared when focus mode is disabled
+func (sm *StateManager) SetFocusSession(enabled bool, opts FocusSessionOptions) error {
+	return sm.update(func(state *State) error {
+		state.FocusMode = enabled
+		state.LastUpdated = time.Now()
+		state.FocusProfile = ""
+		state.FocusIntensity = ""
+		state.FocusLabel = ""
+		state.FocusDryRun = false
+		if enabled {
+			state.FocusProfile = opts.Profile
+			state.FocusIntensity = opts.Intensity
+			state.FocusLabel = opts.Label
+			state.FocusDryRun = opts.DryRun
+		}
+
+		if enabled && opts.Duration > 0 {
+			endTime := time.Now().Add(opts.Duration)
+			state.FocusEndTime = &endTime
+		} else {
+			state.FocusEndTime = nil
+		}
+		return nil
+	})
+}
+
+// CheckFocusMode checks if focus mode is active and handles expiration
+func (sm *StateManager) CheckFocusMode() bool {
+	if state := sm.GetState(); !state.focusExpired() {
+		return state.FocusMode
+	}
+
+	err := sm.update(func(state *State) error {
+		// Another process may have extended the session in the meantime
+		if !state.focusExpired() {
+			return errStateUnchanged
+		}
+		state.FocusMode = false
+		state.FocusEndTime = nil
+		state.FocusProfile = ""
+		state.FocusIntensity = ""
+		state.FocusLabel = ""
+		state.FocusDryRun = false
+		state.LastUpdated = time.Now()
+		return nil
+	})
+	if err != nil {
+		// Log error but don't fail
+		fmt.Printf("Warning: failed to save expired focus state: %v\n", err)
+	}
+
+	return sm.GetState().FocusMode
+}
+
+// focusExpired reports whether a timed focus session has run out
+func (s State) focusExpired() bool {
+	return s.FocusMode && s.FocusEndTime != nil && time.Now().After(*s.FocusEndTime)
+}
+
+// CompareAndSet applies fn to the state only if it is still at revision (State.Revision
+// as returned by GetState), so a change decided on an older state isn't written over a
+// newer one. It returns ErrStateChanged otherwise.
+func (sm *StateManager) CompareAndSet(revision uint64, fn func(state *State) error) error {
+	return sm.change(&revision, fn)
+}
+
+// update applies fn to the latest state and saves the result
+func (sm *StateManager) update(fn func(state *State) error) error {
+	return sm.change(nil, fn)
+}
+
+// change reloads the state under the file lock, applies fn to a copy, and atomically
+// replaces the file with the result. An error from fn leaves the state unchanged.
+func (sm *StateManager) change(revision *uint64, fn func(state *State) error) error {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
-	sm.state.FocusMode = enabled
-	sm.state.LastUpdated = time.Now()
+	unlock, err := sm.lockState()
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
-	if enabled && duration > 0 {
-		endTime := time.Now().Add(duration)
-		sm.state.FocusEndTime = &endTime
-	} else {
-		sm.state.FocusEndTime = nil
+	// Start from what other processes wrote since the state was last read
+	if err := sm.loadState(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("Warning: replacing unreadable state file: %v", err)
+	}
+	if revision != nil && sm.state.Revision != *revision {
+		return ErrStateChanged
 	}
 
-	// Save to file
-	if err := sm.saveState(); err != nil {
+	next := sm.state.clone()
+	if sm.sealKey != nil {
+		next.applySealed(sm.sealed)
+	}
+	if err := fn(&next); err != nil {
+		if errors.Is(err, errStateUnchanged) {
+			return nil
+		}
+		return err
+	}
+	next.Revision++
+	if sm.sealKey != nil {
+		if err := next.seal(sm.sealKey); err != nil {
+			return err
+		}
+	}
+	if err := sm.saveState(next); err != nil {
 		return fmt.Errorf("failed to save state: %w", err)
 	}
+	sm.state = next
+	if sm.sealKey != nil {
+		sm.sealed = next.sealedFocus()
+	}
 
 	// Notify listeners
 	sm.notifyListeners()
-
 	return nil
 }
 
-// CheckFocusMode checks if focus mode is active and handles expiration
-func (sm *StateManager) CheckFocusMode() bool {
-	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
-	// Check if focus mode has expired
-	if sm.state.FocusMode && sm.state.FocusEndTime != nil && time.Now().After(*sm.state.FocusEndTime) {
-		sm.state.FocusMode = false
-		sm.state.FocusEndTime = nil
-		sm.state.LastUpdated = time.Now()
-
-		// Save updated state
-		if err := sm.saveState(); err != nil {
-			// Log error but don't fail
-			fmt.Printf("Warning: failed to save expired focus state: %v\n", err)
+// clone copies the state so changes to it don't reach the original's maps and slices
+func (s State) clone() State {
+	s.DailyFocus = maps.Clone(s.DailyFocus)
+	if s.LabelFocus != nil {
+		labels := make(map[string]map[string]int64, len(s.LabelFocus))
+		for day, times := range s.LabelFocus {
+			labels[day] = maps.Clone(times)
 		}
+		s.LabelFocus = labels
+	}
+	s.ScheduledSessions = slices.Clone(s.ScheduledSessions)
+	s.AllowlistSize = maps.Clone(s.AllowlistSize)
+	s.UpstreamLatency = maps.Clone(s.UpstreamLatency)
+	s.DeviceFocus = maps.Clone(s.DeviceFocus)
+	if s.BudgetUsage != nil {
+		s.BudgetUsage = &BudgetUsage{Day: s.BudgetUsage.Day, Minutes: maps.Clone(s.BudgetUsage.Minutes)}
+	}
+	return s
+}
+
+// UpstreamLatencies returns the upstream latencies saved by the resolver
+func (sm *StateManager) UpstreamLatencies() map[string]time.Duration {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
 
-		// Notify listeners
-		sm.notifyListeners()
+	latencies := make(map[string]time.Duration, len(sm.state.UpstreamLatency))
+	for upstream, us := range sm.state.UpstreamLatency {
+		latencies[upstream] = time.Duration(us) * time.Microsecond
 	}
+	return latencies
+}
+
+// SetUpstreamLatencies saves the measured upstream latencies, replacing the previous ones
+func (sm *StateManager) SetUpstreamLatencies(latencies map[string]time.Duration) error {
+	return sm.update(func(state *State) error {
+		state.UpstreamLatency = make(map[string]int64, len(latencies))
+		for upstream, latency := range latencies {
+			state.UpstreamLatency[upstream] = latency.Microseconds()
+		}
+		return nil
+	})
+}
 
-	return sm.state.FocusMode
+// RestoreHistory replaces the focus time history and queued sessions with those of a saved
+// state, such as a backup. The current focus session is kept.
+func (sm *StateManager) RestoreHistory(saved State) error {
+	return sm.update(func(state *State) error {
+		state.DailyFocus = saved.DailyFocus
+		state.LabelFocus = saved.LabelFocus
+		state.AllowlistSize = saved.AllowlistSize
+		state.ScheduledSessions = saved.ScheduledSessions
+		return nil
+	})
 }
 
 // AddListener adds a channel to receive state updates
@@ -140,54 +334,89 @@ func (sm *StateManager) notifyListeners() {
 	}
 }
 
-// loadState loads state from file
+// loadState loads state from file. Callers hold the mutex.
 func (sm *StateManager) loadState() error {
 	data, err := os.ReadFile(sm.statePath)
 	if err != nil {
 		return fmt.Errorf("failed to read state file: %w", err)
 	}
 
-	if err := json.Unmarshal(data, &sm.state); err != nil {
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
 		return fmt.Errorf("failed to parse state file: %w", err)
 	}
+	sm.state = state
 
 	return nil
 }
 
-// saveState saves state to file
-func (sm *StateManager) saveState() error {
-	// Ensure directory exists with proper permissions
-	dir := filepath.Dir(sm.statePath)
-	if err := os.MkdirAll(dir, 0750); err != nil {
-		return fmt.Errorf("failed to create state directory: %w", err)
+// lockState takes the lock writers hold across read-modify-write. It lives in its own file
+// because state.json is replaced on every write.
+func (sm *StateManager) lockState() (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(sm.statePath), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
 	}
 
-	// Try to create the file with user permissions first
-	data, err := json.MarshalIndent(sm.state, "", "  ")
+	lockPath := sm.statePath + ".lock"
+	// #nosec G304 -- lockPath is next to the state file in the data directory
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		if closeErr := f.Close(); closeErr != nil {
+			log.Printf("Warning: failed to close state lock: %v", closeErr)
+		}
+		return nil, fmt.Errorf("failed to lock state: %w", err)
+	}
+
+	return func() {
+		if err := unlockFile(f); err != nil {
+			log.Printf("Warning: failed to unlock state: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Printf("Warning: failed to close state lock: %v", err)
+		}
+	}, nil
+}
+
+// saveState writes state to a temporary file and renames it over the state file, so
+// readers never see a partly written file
+func (sm *StateManager) saveState(state State) error {
+	dir := filepath.Dir(sm.statePath)
+	data, err := json.MarshalIndent(state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	// Try to write the file
-	if err := os.WriteFile(sm.statePath, data, 0600); err != nil {
-		// If we can't write to the file, try to create it in a user-writable location
-		if os.IsPermission(err) {
-			// Try to create the file in a temporary location first
-			tempFile := sm.statePath + ".tmp"
-			if writeErr := os.WriteFile(tempFile, data, 0600); writeErr == nil {
-				// Try to move it to the final location
-				if moveErr := os.Rename(tempFile, sm.statePath); moveErr == nil {
-					return nil
-				}
-				// Clean up temp file
-				if removeErr := os.Remove(tempFile); removeErr != nil {
-					// Log but don't fail - this is cleanup
-					fmt.Printf("Warning: failed to remove temp file %s: %v\n", tempFile, removeErr)
-				}
-			}
+	tmp, err := os.CreateTemp(dir, filepath.Base(sm.statePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		if removeErr := os.Remove(tmpPath); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+			log.Printf("Warning: failed to remove temp file %s: %v", tmpPath, removeErr)
 		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+	if err := os.Rename(tmpPath, sm.statePath); err != nil {
+		return fmt.Errorf("failed to replace state file: %w", err)
+	}
+	if err := WriteChecksum(sm.statePath, data); err != nil {
+		log.Printf("Warning: %v", err)
+	}
 
 	return nil
 }
@@ -199,29 +428,30 @@ func (sm *StateManager) WatchState(updateChan chan State) {
 
 	// Start file watcher
 	go func() {
-		lastMod := time.Time{}
+		var last os.FileInfo
+		revision := sm.GetState().Revision
 
 		for {
-			// Check file modification time
-			if info, err := os.Stat(sm.statePath); err == nil {
-				if info.ModTime().After(lastMod) {
-					// File was modified, reload state
-					if err := sm.loadState(); err == nil {
-						sm.mu.RLock()
-						state := sm.state
-						sm.mu.RUnlock()
-
-						// Check for expiration
-						sm.CheckFocusMode()
-
-						// Send updated state
-						select {
-						case updateChan <- state:
-						default:
-							// Channel is full, skip
-						}
-
-						lastMod = info.ModTime()
+			// Every write replaces the file, so a new file or modification time means a change
+			if info, err := os.Stat(sm.statePath); err == nil && (last == nil || !os.SameFile(info, last) || !info.ModTime().Equal(last.ModTime())) {
+				last = info
+
+				sm.mu.Lock()
+				err := sm.loadState()
+				state := sm.state
+				sm.mu.Unlock()
+
+				if err == nil && state.Revision != revision {
+					revision = state.Revision
+
+					// Check for expiration
+					sm.CheckFocusMode()
+
+					// Send updated state
+					select {
+					case updateChan <- state:
+					default:
+						// Channel is full, skip
 					}
 				}
 			}
@@ -232,23 +462,12 @@ func (sm *StateManager) WatchState(updateChan chan State) {
 	}()
 }
 
-// getStatePath returns the platform-specific path for the state file
-func getStatePath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", fmt.Errorf("failed to get home directory: %w", err)
-	}
-
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			return filepath.Join(appData, "sinkzone", "state.json"), nil
-		}
-		// Fallback to user home directory
-		return filepath.Join(homeDir, "sinkzone", "state.json"), nil
-	}
+// GetStatePath returns the file focus sessions and focus history are saved in
+func GetStatePath() string {
+	return filepath.Join(GetDataDir(), "state.json")
+}
 
-	// Unix-like systems use ~/.sinkzone/
-	return filepath.Join(homeDir, ".sinkzone", "state.json"), nil
+// getStatePath returns the path of the state file in the data directory
+func getStatePath() (string, error) {
+	return GetStatePath(), nil
 }
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStateConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	first := &StateManager{statePath: path}
+	second := &StateManager{statePath: path}
+
+	// Both managers stand in for separate processes sharing one state file
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	var wg sync.WaitGroup
+	for _, sm := range []*StateManager{first, second} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if err := sm.AddFocusTime(start, start.Add(time.Minute), ""); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	reader := &StateManager{statePath: path}
+	if err := reader.loadState(); err != nil {
+		t.Fatal(err)
+	}
+	if got := reader.GetState().FocusTimeOn(start); got != 40*time.Minute {
+		t.Errorf("expected every write to survive, got %s of focus time", got)
+	}
+	if got := reader.GetState().Revision; got != 40 {
+		t.Errorf("expected revision 40, got %d", got)
+	}
+}
+
+func TestStateCompareAndSet(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	sm := &StateManager{statePath: path}
+	if err := sm.SetFocusMode(true, 0); err != nil {
+		t.Fatal(err)
+	}
+	revision := sm.GetState().Revision
+
+	other := &StateManager{statePath: path}
+	if err := other.SetFocusMode(false, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	err := sm.CompareAndSet(revision, func(state *State) error {
+		state.FocusLabel = "stale"
+		return nil
+	})
+	if !errors.Is(err, ErrStateChanged) {
+		t.Fatalf("expected ErrStateChanged, got %v", err)
+	}
+	if state := sm.GetState(); state.FocusMode || state.FocusLabel != "" {
+		t.Errorf("expected the newer state from the file, got %+v", state)
+	}
+
+	err = sm.CompareAndSet(sm.GetState().Revision, func(state *State) error {
+		state.FocusLabel = "current"
+		return nil
+	})
+	if err != nil || sm.GetState().FocusLabel != "current" {
+		t.Errorf("expected the change at the current revision to apply, got %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultSubscriptionRefresh is how often a running resolver downloads subscribed lists again
+const DefaultSubscriptionRefresh = 24 * time.Hour
+
+// Subscription is a hosted domain list followed with 'sinkzone allowlist subscribe' or
+// 'sinkzone blocklist subscribe'
+type Subscription struct {
+	URL      string `yaml:"url"`                // http(s) URL or file path of the list
+	Disabled bool   `yaml:"disabled,omitempty"` // Kept, but neither used nor refreshed
+}
+
+// GetSubscriptionRefresh returns how often subscribed lists are downloaded again, 0 when
+// only 'update' downloads them
+func (c *Config) GetSubscriptionRefresh() (time.Duration, error) {
+	if c.SubscriptionRefresh == "" {
+		return DefaultSubscriptionRefresh, nil
+	}
+	refresh, err := time.ParseDuration(c.SubscriptionRefresh)
+	if err != nil || (refresh != 0 && refresh < time.Hour) {
+		return 0, fmt.Errorf("invalid subscription_refresh %q: must be 0 or a duration of at least 1h", c.SubscriptionRefresh)
+	}
+	return refresh, nil
+}
+
+// migrateSubscriptions turns the URLs once listed under blocklist_subscriptions into
+// subscription entries
+func migrateSubscriptions(doc map[string]any) error {
+	raw, ok := doc["blocklist_subscriptions"]
+	if !ok {
+		return nil
+	}
+	urls, ok := raw.([]any)
+	if !ok {
+		return fmt.Errorf("blocklist_subscriptions must be a list")
+	}
+	subscriptions := make([]any, 0, len(urls))
+	for _, url := range urls {
+		if _, ok := url.(string); !ok {
+			return fmt.Errorf("blocklist_subscriptions entries must be URLs, got %v", url)
+		}
+		subscriptions = append(subscriptions, map[string]any{"url": url})
+	}
+	doc["blocklist_subscriptions"] = subscriptions
+	return nil
+}
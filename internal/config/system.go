@@ -0,0 +1,188 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are layered, each layer replacing the values of the one before it:
+//
+//  1. built-in defaults
+//  2. the system-wide config file (GetSystemConfigPath), shared by services and every user
+//  3. the user's config file (GetConfigPath, or --config)
+//  4. SINKZONE_* environment variables
+//
+// A key set in a later layer replaces the whole value, including lists; sections such as
+// cache or profiles are merged key by key. Only the user's file is ever written, so values
+// inherited from the system file stay out of it until they are changed.
+
+// SystemConfigEnv names the environment variable that moves the system-wide config file,
+// or turns it off when set to "none"
+const SystemConfigEnv = "SINKZONE_SYSTEM_CONFIG"
+
+// GetSystemConfigPath returns the system-wide config file layered under the user's:
+// /etc/sinkzone/sinkzone.yaml, or sinkzone\sinkzone.yaml in ProgramData on Windows.
+// It returns "" when the system config is turned off.
+func GetSystemConfigPath() string {
+	switch path := os.Getenv(SystemConfigEnv); path {
+	case "none":
+		return ""
+	case "":
+	default:
+		return path
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(programDataDir(), "sinkzone.yaml")
+	}
+	return "/etc/sinkzone/sinkzone.yaml"
+}
+
+// programDataDir returns sinkzone's machine-wide directory on Windows
+func programDataDir() string {
+	programData := os.Getenv("ProgramData")
+	if programData == "" {
+		programData = `C:\ProgramData`
+	}
+	return filepath.Join(programData, "sinkzone")
+}
+
+// loadSystemConfig reads the system-wide config file into cfg. It reports whether the file
+// exists; a file the user may not read is skipped with a warning.
+func (c *Config) loadSystemConfig(userPath string) (bool, error) {
+	path := GetSystemConfigPath()
+	if path == "" || sameFile(path, userPath) {
+		return false, nil
+	}
+
+	// #nosec G304 -- path is the fixed system config location or set by the administrator
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		log.Printf("Warning: skipping system config file %s: %v", path, err)
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read system config file: %w", err)
+	}
+
+	// Old system files are upgraded in memory only; the administrator rewrites them
+	migrated, _, err := migrate(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid system config file %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(migrated, c); err != nil {
+		return false, fmt.Errorf("failed to parse system config file %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(migrated, &c.system); err != nil {
+		return false, fmt.Errorf("failed to parse system config file %s: %w", path, err)
+	}
+	if c.system == nil {
+		c.system = map[string]any{}
+	}
+	c.systemPath = path
+	return true, nil
+}
+
+// sameFile reports whether two paths name the same file, so a system config chosen with
+// --config isn't layered under itself
+func sameFile(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// SystemConfigPath returns the system-wide config file the config was layered on, or ""
+// if there was none
+func (c *Config) SystemConfigPath() string {
+	return c.systemPath
+}
+
+// FromSystem reports whether a key's value is inherited from the system-wide config file
+func (c *Config) FromSystem(name string) bool {
+	if c.system == nil {
+		return false
+	}
+	if name == "resolver" {
+		name = "upstream_nameservers" // resolver is the first upstream nameserver
+	}
+	if _, ok := lookupPath(c.user, name); ok {
+		return false
+	}
+	_, ok := lookupPath(c.system, name)
+	return ok
+}
+
+// lookupPath finds a dotted key such as cache.size in a parsed config file
+func lookupPath(doc map[string]any, name string) (any, bool) {
+	parts := strings.Split(name, ".")
+	var value any = doc
+	for _, part := range parts {
+		section, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if value, ok = section[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// marshalUserLayer encodes the config for the user's file, leaving out values inherited
+// unchanged from the system-wide file
+func (c *Config) marshalUserLayer() ([]byte, error) {
+	if c.system == nil {
+		return yaml.Marshal(c)
+	}
+	var node yaml.Node
+	if err := node.Encode(c); err != nil {
+		return nil, err
+	}
+	stripInherited(&node, c.system, c.user, true)
+	return yaml.Marshal(&node)
+}
+
+// stripInherited removes the entries of a mapping that the user's file doesn't set and
+// that equal the system file's value
+func stripInherited(mapping *yaml.Node, system, user map[string]any, top bool) {
+	if mapping.Kind != yaml.MappingNode {
+		return
+	}
+	kept := make([]*yaml.Node, 0, len(mapping.Content))
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		systemValue, inSystem := system[key.Value]
+		userValue, inUser := user[key.Value]
+		if inSystem && !(top && key.Value == "version") {
+			if section, ok := systemValue.(map[string]any); ok && value.Kind == yaml.MappingNode {
+				userSection, _ := userValue.(map[string]any)
+				stripInherited(value, section, userSection, false)
+				if len(value.Content) == 0 && !inUser {
+					continue
+				}
+			} else if !inUser {
+				var decoded any
+				if err := value.Decode(&decoded); err == nil && reflect.DeepEqual(decoded, systemValue) {
+					continue
+				}
+			}
+		}
+		kept = append(kept, key, value)
+	}
+	mapping.Content = kept
+}
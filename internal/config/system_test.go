@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSystemConfigLayering(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	systemPath := filepath.Join(t.TempDir(), "sinkzone.yaml")
+	t.Setenv(SystemConfigEnv, systemPath)
+
+	system := "version: 2\nupstream_nameservers: [9.9.9.9]\nblock_response: refused\ncache:\n  size: 50\n  max_ttl: 10m\n"
+	if err := os.WriteFile(systemPath, []byte(system), 0600); err != nil {
+		t.Fatal(err)
+	}
+	user := "version: 2\nupstream_nameservers: [1.1.1.1]\ncache:\n  size: 10\n"
+	if err := os.WriteFile(filepath.Join(dir, "sinkzone.yaml"), []byte(user), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"1.1.1.1"}) {
+		t.Errorf("expected the user's upstreams to replace the system's, got %v", cfg.UpstreamNameservers)
+	}
+	if cfg.BlockResponse != "refused" || !cfg.FromSystem("block_response") {
+		t.Errorf("expected block_response inherited from the system file, got %q", cfg.BlockResponse)
+	}
+	if size, _ := cfg.Cache.GetSize(); size != 10 || cfg.Cache.MaxTTL != "10m" {
+		t.Errorf("expected the cache sections merged, got %+v", cfg.Cache)
+	}
+	if cfg.FromSystem("cache.size") || !cfg.FromSystem("cache.max_ttl") {
+		t.Error("expected cache.size from the user and cache.max_ttl from the system")
+	}
+
+	cfg.DailyGoal = "4h"
+	if err := Save(cfg); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "sinkzone.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inherited := range []string{"block_response", "max_ttl", "9.9.9.9"} {
+		if strings.Contains(string(data), inherited) {
+			t.Errorf("expected %s to stay out of the user's file, got %q", inherited, data)
+		}
+	}
+	for _, own := range []string{"daily_goal: 4h", "1.1.1.1", "size: 10", "version: 2"} {
+		if !strings.Contains(string(data), own) {
+			t.Errorf("expected %q in the user's file, got %q", own, data)
+		}
+	}
+}
+
+func TestSystemConfigWithoutUserFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(ConfigDirEnv, dir)
+	systemPath := filepath.Join(t.TempDir(), "sinkzone.yaml")
+	t.Setenv(SystemConfigEnv, systemPath)
+	if err := os.WriteFile(systemPath, []byte("upstream_nameservers: [9.9.9.9]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(cfg.UpstreamNameservers, []string{"9.9.9.9"}) {
+		t.Errorf("expected the system's upstreams instead of the defaults, got %v", cfg.UpstreamNameservers)
+	}
+	if _, err := os.Stat(systemPath + ".v0.bak"); err == nil {
+		t.Error("expected the system file to be migrated in memory only")
+	}
+}
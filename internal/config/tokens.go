@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// APITokenEnv names the environment variable holding the API token the CLI and TUI send,
+// e.g. a read-only token for a status bar script
+const APITokenEnv = "SINKZONE_API_TOKEN"
+
+// Scopes of API tokens, matching those of internal/api
+var apiTokenScopes = []string{"read", "focus", "allowlist", "extension", "admin"}
+
+// minAPITokenLength keeps tokens too long to guess
+const minAPITokenLength = 16
+
+// APIToken lets whoever sends Token as a bearer token use the API routes of its scopes
+type APIToken struct {
+	Name   string   `yaml:"name"`   // Shown in logs, e.g. "statusbar"
+	Token  string   `yaml:"token"`  // The secret, at least 16 characters
+	Scopes []string `yaml:"scopes"` // read, focus, allowlist, extension, or admin (everything)
+}
+
+// ValidateAPITokens checks api_tokens: unique names and secrets, and known scopes
+func (c *Config) ValidateAPITokens() error {
+	names := map[string]bool{}
+	secrets := map[string]bool{}
+	for _, token := range c.APITokens {
+		if token.Name == "" {
+			return fmt.Errorf("invalid api_tokens: every token needs a name")
+		}
+		if names[token.Name] {
+			return fmt.Errorf("invalid api_tokens: %s is listed twice", token.Name)
+		}
+		names[token.Name] = true
+		if len(token.Token) < minAPITokenLength {
+			return fmt.Errorf("invalid api token %s: the token must be at least %d characters", token.Name, minAPITokenLength)
+		}
+		if secrets[token.Token] {
+			return fmt.Errorf("invalid api token %s: another token has the same secret", token.Name)
+		}
+		secrets[token.Token] = true
+		if len(token.Scopes) == 0 {
+			return fmt.Errorf("invalid api token %s: no scopes, use read, focus, allowlist, extension, or admin", token.Name)
+		}
+		for _, scope := range token.Scopes {
+			if !slices.Contains(apiTokenScopes, scope) {
+				return fmt.Errorf("invalid api token %s: unknown scope %q, use read, focus, allowlist, extension, or admin", token.Name, scope)
+			}
+		}
+	}
+	return nil
+}
+
+// ClientAPIToken returns the token the CLI and TUI send: $SINKZONE_API_TOKEN, or else the
+// first admin token of the config, which whoever reads the config may use anyway
+func ClientAPIToken() string {
+	if token := os.Getenv(APITokenEnv); token != "" {
+		return token
+	}
+	cfg, err := Load()
+	if err != nil {
+		return ""
+	}
+	for _, token := range cfg.APITokens {
+		if slices.Contains(token.Scopes, "admin") {
+			return token.Token
+		}
+	}
+	return ""
+}
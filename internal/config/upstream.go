@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Protocols an upstream nameserver is queried over, chosen by the scheme of its entry
+const (
+	UpstreamUDP   = "udp"   // Plain DNS over UDP (default for bare IP addresses)
+	UpstreamTCP   = "tcp"   // Plain DNS over TCP
+	UpstreamTLS   = "tls"   // DNS over TLS
+	UpstreamHTTPS = "https" // DNS over HTTPS
+)
+
+// defaultUpstreamPorts are used when an entry has no port
+var defaultUpstreamPorts = map[string]string{
+	UpstreamUDP:   "53",
+	UpstreamTCP:   "53",
+	UpstreamTLS:   "853",
+	UpstreamHTTPS: "443",
+}
+
+// Upstream is a parsed upstream_nameservers entry
+type Upstream struct {
+	Protocol   string // One of the Upstream* protocols
+	Address    string // host:port to connect to
+	ServerName string // Name the TLS certificate is checked against (tls and https)
+	URL        string // Endpoint queries are posted to (https)
+}
+
+// ParseUpstream parses an upstream entry: an IP address with an optional port for plain
+// DNS over UDP, or a URL such as tcp://9.9.9.9:5353, tls://1.1.1.1, or
+// https://dns.quad9.net/dns-query. Plain UDP and TCP upstreams need an IP address, as
+// resolving their name would go through the resolver itself.
+func ParseUpstream(entry string) (Upstream, error) {
+	if !strings.Contains(entry, "://") {
+		host, port, err := net.SplitHostPort(entry)
+		if err != nil {
+			host, port = entry, defaultUpstreamPorts[UpstreamUDP]
+		}
+		if net.ParseIP(host) == nil {
+			return Upstream{}, fmt.Errorf("invalid IP address: %s", entry)
+		}
+		if !validPort(port) {
+			return Upstream{}, fmt.Errorf("invalid port in %s", entry)
+		}
+		return Upstream{Protocol: UpstreamUDP, Address: net.JoinHostPort(host, port)}, nil
+	}
+
+	u, err := url.Parse(entry)
+	if err != nil {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: %w", entry, err)
+	}
+	defaultPort, ok := defaultUpstreamPorts[u.Scheme]
+	if !ok {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: use udp://, tcp://, tls://, or https://", entry)
+	}
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: missing host", entry)
+	}
+	if port == "" {
+		port = defaultPort
+	}
+	if !validPort(port) {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: invalid port", entry)
+	}
+	if u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: credentials, queries, and fragments aren't supported", entry)
+	}
+
+	upstream := Upstream{Protocol: u.Scheme, Address: net.JoinHostPort(host, port)}
+	switch u.Scheme {
+	case UpstreamUDP, UpstreamTCP:
+		if net.ParseIP(host) == nil {
+			return Upstream{}, fmt.Errorf("invalid upstream %q: %s upstreams need an IP address", entry, u.Scheme)
+		}
+	case UpstreamTLS:
+		upstream.ServerName = host
+	case UpstreamHTTPS:
+		upstream.ServerName = host
+		if u.Path == "" {
+			u.Path = "/dns-query"
+		}
+		upstream.URL = u.String()
+	}
+	if u.Scheme != UpstreamHTTPS && u.Path != "" && u.Path != "/" {
+		return Upstream{}, fmt.Errorf("invalid upstream %q: only https upstreams have a path", entry)
+	}
+	return upstream, nil
+}
+
+// String returns how the upstream is shown in health reports and logs: host:port for plain
+// UDP, the URL otherwise
+func (u Upstream) String() string {
+	switch u.Protocol {
+	case UpstreamUDP:
+		return u.Address
+	case UpstreamHTTPS:
+		return u.URL
+	default:
+		return u.Protocol + "://" + u.Address
+	}
+}
+
+// GetUpstreams parses the upstream nameservers
+func (c *Config) GetUpstreams() ([]Upstream, error) {
+	upstreams := make([]Upstream, 0, len(c.UpstreamNameservers))
+	for _, entry := range c.UpstreamNameservers {
+		upstream, err := ParseUpstream(entry)
+		if err != nil {
+			return nil, err
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return upstreams, nil
+}
+
+// GetUpstreamAddresses returns the upstream nameservers as shown by Upstream.String,
+// skipping invalid entries
+func (c *Config) GetUpstreamAddresses() []string {
+	addresses := make([]string, 0, len(c.UpstreamNameservers))
+	for _, entry := range c.UpstreamNameservers {
+		if upstream, err := ParseUpstream(entry); err == nil {
+			addresses = append(addresses, upstream.String())
+		}
+	}
+	return addresses
+}
+
+// ValidateNameserver checks an upstream nameserver entry (see ParseUpstream)
+func ValidateNameserver(server string) error {
+	_, err := ParseUpstream(server)
+	return err
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 1 && n <= 65535
+}
@@ -0,0 +1,49 @@
+package config
+
+import "testing"
+
+func TestParseUpstream(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  Upstream
+	}{
+		{"8.8.8.8", Upstream{Protocol: UpstreamUDP, Address: "8.8.8.8:53"}},
+		{"2620:fe::fe", Upstream{Protocol: UpstreamUDP, Address: "[2620:fe::fe]:53"}},
+		{"udp://8.8.8.8", Upstream{Protocol: UpstreamUDP, Address: "8.8.8.8:53"}},
+		{"tcp://9.9.9.9:5353", Upstream{Protocol: UpstreamTCP, Address: "9.9.9.9:5353"}},
+		{"tls://1.1.1.1", Upstream{Protocol: UpstreamTLS, Address: "1.1.1.1:853", ServerName: "1.1.1.1"}},
+		{"tls://dns.quad9.net", Upstream{Protocol: UpstreamTLS, Address: "dns.quad9.net:853", ServerName: "dns.quad9.net"}},
+		{"https://dns.quad9.net/dns-query", Upstream{Protocol: UpstreamHTTPS, Address: "dns.quad9.net:443", ServerName: "dns.quad9.net", URL: "https://dns.quad9.net/dns-query"}},
+		{"https://1.1.1.1", Upstream{Protocol: UpstreamHTTPS, Address: "1.1.1.1:443", ServerName: "1.1.1.1", URL: "https://1.1.1.1/dns-query"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseUpstream(tt.entry)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.entry, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %+v, got %+v", tt.entry, tt.want, got)
+		}
+	}
+
+	for _, entry := range []string{"dns.google", "udp://dns.google", "tcp://9.9.9.9:0", "quic://1.1.1.1", "tls://1.1.1.1/path", "https://", "https://dns.quad9.net/dns-query?x=1"} {
+		if _, err := ParseUpstream(entry); err == nil {
+			t.Errorf("expected %q to be rejected", entry)
+		}
+	}
+}
+
+func TestUpstreamString(t *testing.T) {
+	cfg := &Config{UpstreamNameservers: []string{"8.8.8.8", "tcp://9.9.9.9", "https://dns.quad9.net/dns-query"}}
+	upstreams, err := cfg.GetUpstreams()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"8.8.8.8:53", "tcp://9.9.9.9:53", "https://dns.quad9.net/dns-query"}
+	for i, upstream := range upstreams {
+		if upstream.String() != want[i] {
+			t.Errorf("expected %s, got %s", want[i], upstream)
+		}
+	}
+}
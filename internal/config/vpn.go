@@ -0,0 +1,109 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"path"
+	"strings"
+	"time"
+)
+
+// DefaultVPNCheckInterval is how often the network interfaces are checked for the VPN
+const DefaultVPNCheckInterval = 5 * time.Second
+
+// VPNConfig switches to the VPN's nameservers while a VPN is connected, so names only it
+// resolves, such as those of a corporate network, keep working
+type VPNConfig struct {
+	Interfaces    []string `yaml:"interfaces,omitempty"`     // Names of the VPN's interfaces, with * wildcards, e.g. wg0 or tun*
+	Networks      []string `yaml:"networks,omitempty"`       // The VPN is also up while an interface has an address in one of these, e.g. 10.8.0.0/16
+	Upstreams     []string `yaml:"upstreams,omitempty"`      // Nameservers used while it is up (default: those the system gained when it came up)
+	Domains       []string `yaml:"domains,omitempty"`        // Only names in these domains go to the VPN's nameservers (default: every name)
+	CheckInterval string   `yaml:"check_interval,omitempty"` // How often the interfaces are checked (default 5s)
+}
+
+// Validate checks the VPN settings
+func (v *VPNConfig) Validate() error {
+	if v == nil {
+		return nil
+	}
+	if len(v.Interfaces) == 0 && len(v.Networks) == 0 {
+		return fmt.Errorf("vpn needs interfaces or networks to tell when the VPN is up")
+	}
+	if err := v.validateInterfaces(); err != nil {
+		return err
+	}
+	if _, err := v.GetNetworks(); err != nil {
+		return err
+	}
+	if _, err := v.GetUpstreams(); err != nil {
+		return err
+	}
+	if _, err := v.GetDomains(); err != nil {
+		return err
+	}
+	_, err := v.GetCheckInterval()
+	return err
+}
+
+// validateInterfaces checks the patterns of the interface names
+func (v *VPNConfig) validateInterfaces() error {
+	for _, pattern := range v.Interfaces {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid vpn interface %q: use a name, with * wildcards, e.g. wg0 or tun*", pattern)
+		}
+	}
+	return nil
+}
+
+// GetNetworks returns the networks an address of the VPN is in
+func (v *VPNConfig) GetNetworks() ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(v.Networks))
+	for _, network := range v.Networks {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vpn network %q: use CIDR notation, e.g. 10.8.0.0/16", network)
+		}
+		networks = append(networks, prefix.Masked())
+	}
+	return networks, nil
+}
+
+// GetUpstreams returns the nameservers used while the VPN is up, none to use the ones it
+// brings
+func (v *VPNConfig) GetUpstreams() ([]Upstream, error) {
+	upstreams := make([]Upstream, 0, len(v.Upstreams))
+	for _, entry := range v.Upstreams {
+		upstream, err := ParseUpstream(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid vpn upstream: %w", err)
+		}
+		upstreams = append(upstreams, upstream)
+	}
+	return upstreams, nil
+}
+
+// GetDomains returns the domains sent to the VPN's nameservers, lowercase and without a
+// trailing dot; none sends every name
+func (v *VPNConfig) GetDomains() ([]string, error) {
+	domains := make([]string, 0, len(v.Domains))
+	for _, domain := range v.Domains {
+		name := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if name == "" || strings.ContainsAny(name, " */:") {
+			return nil, fmt.Errorf("invalid vpn domain %q: use a domain name, e.g. corp.example.com", domain)
+		}
+		domains = append(domains, name)
+	}
+	return domains, nil
+}
+
+// GetCheckInterval returns how often the interfaces are checked for the VPN
+func (v *VPNConfig) GetCheckInterval() (time.Duration, error) {
+	if v == nil || v.CheckInterval == "" {
+		return DefaultVPNCheckInterval, nil
+	}
+	interval, err := time.ParseDuration(v.CheckInterval)
+	if err != nil || interval < time.Second {
+		return 0, fmt.Errorf("invalid vpn check_interval %q: must be a duration of at least 1s", v.CheckInterval)
+	}
+	return interval, nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"log"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+)
+
+// ConfigPollInterval is how often a running resolver checks the config files for changes
+const ConfigPollInterval = 2 * time.Second
+
+// fileStamp identifies a version of a file; the zero value means the file doesn't exist
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+func stampOf(path string) fileStamp {
+	if path == "" {
+		return fileStamp{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{modTime: info.ModTime(), size: info.Size()}
+}
+
+// Watch reloads the config whenever the user's or the system-wide config file changes and
+// passes it to apply, checking every interval() until stop is closed; a changed interval
+// takes effect after the next check. A file that fails to load is reported and skipped until
+// it changes again; so is a deleted config file.
+func Watch(stop <-chan struct{}, interval func() time.Duration, apply func(*Config)) {
+	stamps := func() [2]fileStamp {
+		return [2]fileStamp{stampOf(GetConfigPath()), stampOf(GetSystemConfigPath())}
+	}
+	last := stamps()
+
+	period := interval()
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+		if next := interval(); next != period {
+			period = next
+			ticker.Reset(period)
+		}
+
+		current := stamps()
+		if current == last {
+			continue
+		}
+		last = current
+		if current[0] == (fileStamp{}) && current[1] == (fileStamp{}) {
+			// Load would write a default config over the deleted one
+			log.Printf("Warning: config file %s is gone; keeping the current settings", GetConfigPath())
+			continue
+		}
+
+		cfg, err := Load()
+		if err != nil {
+			log.Printf("Warning: not applying changes to %s: %v", GetConfigPath(), err)
+			continue
+		}
+		apply(cfg)
+	}
+}
+
+// ChangedKeys returns the keys whose values differ between two configs, in config file
+// order, followed by the settings 'sinkzone config' doesn't manage: pin, profiles,
+// process_triggers, schedules, allowlist_subscriptions, blocklist_subscriptions, api_tokens,
+// client_names, users, log_levels, keymap, and notifications.push.
+func ChangedKeys(old, updated *Config) []string {
+	var changed []string
+	for i := range keys {
+		key := &keys[i]
+		if !slices.Equal(key.get(old), key.get(updated)) {
+			changed = append(changed, key.Name)
+		}
+	}
+	others := []struct {
+		name    string
+		changed bool
+	}{
+		{"pin", old.FocusPINHash != updated.FocusPINHash},
+		{"profiles", !reflect.DeepEqual(old.Profiles, updated.Profiles)},
+		{"process_triggers", !slices.Equal(old.ProcessTriggers, updated.ProcessTriggers)},
+		{"schedules", !reflect.DeepEqual(schedulesOf(old, true), schedulesOf(updated, true))},
+		{"schedules.block", !reflect.DeepEqual(schedulesOf(old, false), schedulesOf(updated, false))},
+		{"allowlist_subscriptions", !slices.Equal(old.AllowlistSubscriptions, updated.AllowlistSubscriptions)},
+		{"blocklist_subscriptions", !slices.Equal(old.BlocklistSubscriptions, updated.BlocklistSubscriptions)},
+		{"api_tokens", !reflect.DeepEqual(old.APITokens, updated.APITokens)},
+		{"client_names", !maps.Equal(old.ClientNames, updated.ClientNames)},
+		{"users", !reflect.DeepEqual(old.Users, updated.Users)},
+		{"rules", !slices.Equal(old.Rules, updated.Rules)},
+		{"categories", !reflect.DeepEqual(old.Categories, updated.Categories)},
+		{"budgets", !maps.Equal(old.Budgets, updated.Budgets)},
+		{"private_zones.hosts", !maps.Equal(privateHostsOf(old), privateHostsOf(updated))},
+		{"log_levels", !maps.Equal(old.LogLevels, updated.LogLevels)},
+		{"keymap", !reflect.DeepEqual(old.Keymap, updated.Keymap)},
+		{"notifications.push", !slices.Equal(pushOf(old), pushOf(updated))},
+		{"hooks.events", !maps.Equal(hookEventsOf(old), hookEventsOf(updated))},
+		{"hooks.plugins", !slices.Equal(hookPluginsOf(old), hookPluginsOf(updated))},
+	}
+	for _, other := range others {
+		if other.changed {
+			changed = append(changed, other.name)
+		}
+	}
+	return changed
+}
+
+// privateHostsOf returns the names answered locally of a config, nil without private zones
+func privateHostsOf(c *Config) map[string]string {
+	if c.PrivateZones == nil {
+		return nil
+	}
+	return c.PrivateZones.Hosts
+}
+
+// pushOf returns the push notifiers of a config, nil without notifications
+func pushOf(c *Config) []PushConfig {
+	if c.Notifications == nil {
+		return nil
+	}
+	return c.Notifications.Push
+}
+
+// schedulesOf returns the schedules of a config that start focus sessions, or those that
+// block domains
+func schedulesOf(c *Config, focus bool) []Schedule {
+	var schedules []Schedule
+	for _, schedule := range c.Schedules {
+		if schedule.StartsFocus() == focus {
+			schedules = append(schedules, schedule)
+		}
+	}
+	return schedules
+}
+
+// hookEventsOf returns the event hooks of a config, nil without hooks
+func hookEventsOf(c *Config) map[string]string {
+	if c.Hooks == nil {
+		return nil
+	}
+	return c.Hooks.Events
+}
+
+// hookPluginsOf returns the plugins of a config, nil without hooks
+func hookPluginsOf(c *Config) []string {
+	if c.Hooks == nil {
+		return nil
+	}
+	return c.Hooks.Plugins
+}
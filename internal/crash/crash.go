@@ -0,0 +1,137 @@
+// Package crash writes a report of a panic to a local file and, when an upload URL is
+// configured, posts it there. Nothing is captured until Configure is called, which sinkzone
+// only does when crash_reports.enabled is set.
+package crash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/version"
+)
+
+const (
+	// maxStack bounds the goroutine dump in a report
+	maxStack = 1 << 20
+	// uploadTimeout bounds the upload, since the process is about to exit
+	uploadTimeout = 10 * time.Second
+)
+
+// Options configure crash reports
+type Options struct {
+	Dir       string // Where reports are written
+	UploadURL string // Reports are posted here as JSON when set
+}
+
+// Report is what a crash report contains: the panic, where it happened, and the build.
+// It holds no settings or queries, though a panic message may name a domain.
+type Report struct {
+	Time      time.Time `json:"time"`
+	Component string    `json:"component"` // The part of sinkzone that crashed, e.g. resolver or tui
+	Panic     string    `json:"panic"`
+	Stack     string    `json:"stack"` // Every goroutine, the crashing one first
+	version.Info
+}
+
+var (
+	options atomic.Pointer[Options]
+
+	lastMu   sync.Mutex
+	lastPath string
+)
+
+// Configure turns crash reports on
+func Configure(opts Options) {
+	options.Store(&opts)
+}
+
+// Enabled reports whether panics are captured
+func Enabled() bool {
+	return options.Load() != nil
+}
+
+// LastReport returns the path of the last report written by this process, or ""
+func LastReport() string {
+	lastMu.Lock()
+	defer lastMu.Unlock()
+	return lastPath
+}
+
+// Recover captures a panic of the calling goroutine and panics again, so the program fails
+// as it would have without it. Defer it directly: defer crash.Recover("resolver").
+func Recover(component string) {
+	if r := recover(); r != nil {
+		path, err := Capture(component, r)
+		if path != "" {
+			fmt.Fprintf(os.Stderr, "Crash report written to %s\n", path)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		panic(r)
+	}
+}
+
+// Capture writes a report of a recovered panic value, uploading it when configured, and
+// returns its path; it does nothing while crash reports are off
+func Capture(component string, value any) (string, error) {
+	opts := options.Load()
+	if opts == nil {
+		return "", nil
+	}
+	stack := make([]byte, maxStack)
+	stack = stack[:runtime.Stack(stack, true)]
+	report := Report{
+		Time:      time.Now().UTC(),
+		Component: component,
+		Panic:     fmt.Sprint(value),
+		Stack:     string(stack),
+		Info:      version.Get(),
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode crash report: %w", err)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create crash report directory: %w", err)
+	}
+	path := filepath.Join(opts.Dir, fmt.Sprintf("crash-%s-%s.json", component, report.Time.Format("20060102-150405")))
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write crash report: %w", err)
+	}
+	lastMu.Lock()
+	lastPath = path
+	lastMu.Unlock()
+
+	if opts.UploadURL != "" {
+		if err := upload(opts.UploadURL, data); err != nil {
+			return path, err
+		}
+	}
+	return path, nil
+}
+
+// upload posts a report to url
+func upload(url string, data []byte) error {
+	client := &http.Client{Timeout: uploadTimeout}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to upload crash report: %w", err)
+	}
+	if err := resp.Body.Close(); err != nil {
+		return fmt.Errorf("failed to close response body: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to upload crash report: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package crash
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCapture(t *testing.T) {
+	defer options.Store(nil)
+
+	if path, err := Capture("tui", "boom"); path != "" || err != nil {
+		t.Fatalf("expected nothing while crash reports are off, got %q, %v", path, err)
+	}
+
+	var uploaded Report
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &uploaded); err != nil {
+			t.Errorf("failed to parse the upload: %v", err)
+		}
+	}))
+	defer collector.Close()
+
+	dir := t.TempDir()
+	Configure(Options{Dir: dir, UploadURL: collector.URL})
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("expected the panic to continue, got %v", r)
+			}
+		}()
+		defer Recover("resolver")
+		panic("boom")
+	}()
+
+	path := LastReport()
+	if filepath.Dir(path) != dir {
+		t.Fatalf("expected a report in %s, got %q", dir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if report.Component != "resolver" || report.Panic != "boom" || !strings.Contains(report.Stack, "TestCapture") || report.GoVersion == "" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if uploaded.Panic != "boom" {
+		t.Errorf("expected the report to be uploaded, got %+v", uploaded)
+	}
+}
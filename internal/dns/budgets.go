@@ -0,0 +1,199 @@
+package dns
+
+import (
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// budgetSaveInterval is how often changed budget use is saved for the next start
+const budgetSaveInterval = time.Minute
+
+// budget is a daily time budget of the domains a pattern matches
+type budget struct {
+	pattern string
+	regex   *regexp.Regexp // nil for an exact domain
+	limit   time.Duration
+}
+
+func (b budget) matches(domain string) bool {
+	if b.regex == nil {
+		return b.pattern == domain
+	}
+	return b.regex.MatchString(domain)
+}
+
+// budgetTracker counts the minutes in which each budget's domains were queried today, as
+// a proxy for the time spent on them, and tells once a budget is used up
+type budgetTracker struct {
+	mutex   sync.Mutex
+	budgets []budget         // Sorted by pattern
+	day     string           // YYYY-MM-DD the use was counted on
+	minutes map[string]int   // Minutes used today, by pattern
+	last    map[string]int64 // Minute (since the epoch) last counted, by pattern
+	changed bool             // Use changed since it was last saved
+}
+
+func newBudgetTracker() *budgetTracker {
+	return &budgetTracker{minutes: make(map[string]int), last: make(map[string]int64)}
+}
+
+// setBudgets replaces the budgets, keeping today's use of the patterns kept
+func (t *budgetTracker) setBudgets(limits map[string]time.Duration) {
+	budgets := make([]budget, 0, len(limits))
+	for pattern, limit := range limits {
+		b := budget{pattern: pattern, limit: limit}
+		if isWildcardPattern(pattern) {
+			regex, err := wildcardToRegex(pattern)
+			if err != nil {
+				logger.Warn("Skipping invalid budget", "pattern", pattern, "error", err)
+				continue
+			}
+			b.regex = regex
+		}
+		budgets = append(budgets, b)
+	}
+	sort.Slice(budgets, func(i, j int) bool { return budgets[i].pattern < budgets[j].pattern })
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.budgets = budgets
+}
+
+// restore sets the use of a day saved before a restart
+func (t *budgetTracker) restore(day time.Time, minutes map[string]int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.day = day.Format("2006-01-02")
+	t.minutes = maps.Clone(minutes)
+	if t.minutes == nil {
+		t.minutes = make(map[string]int)
+	}
+}
+
+// rollover starts counting afresh on a new day; callers hold mutex
+func (t *budgetTracker) rollover(now time.Time) {
+	if day := now.Format("2006-01-02"); day != t.day {
+		t.day = day
+		t.minutes = make(map[string]int)
+		t.last = make(map[string]int64)
+		t.changed = true
+	}
+}
+
+// exhausted returns the first budget of a domain that is used up today
+func (t *budgetTracker) exhausted(domain string, now time.Time) (budget, bool) {
+	if t == nil {
+		return budget{}, false
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.budgets) == 0 {
+		return budget{}, false
+	}
+	t.rollover(now)
+	domain = strings.ToLower(domain)
+	for _, b := range t.budgets {
+		if b.matches(domain) && time.Duration(t.minutes[b.pattern])*time.Minute >= b.limit {
+			return b, true
+		}
+	}
+	return budget{}, false
+}
+
+// use counts the current minute for every budget of a domain, once per minute
+func (t *budgetTracker) use(domain string, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.budgets) == 0 {
+		return
+	}
+	t.rollover(now)
+	domain = strings.ToLower(domain)
+	minute := now.Unix() / 60
+	for _, b := range t.budgets {
+		if b.matches(domain) && t.last[b.pattern] != minute {
+			t.last[b.pattern] = minute
+			t.minutes[b.pattern]++
+			t.changed = true
+		}
+	}
+}
+
+// status returns the budgets with their use today
+func (t *budgetTracker) status(now time.Time) []api.BudgetStatus {
+	if t == nil {
+		return nil
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.rollover(now)
+	statuses := make([]api.BudgetStatus, 0, len(t.budgets))
+	for _, b := range t.budgets {
+		used := min(time.Duration(t.minutes[b.pattern])*time.Minute, b.limit)
+		statuses = append(statuses, api.BudgetStatus{
+			Domain:    b.pattern,
+			Limit:     b.limit.String(),
+			Used:      used.String(),
+			Remaining: (b.limit - used).String(),
+			Progress:  float64(used) / float64(b.limit),
+			Exhausted: used >= b.limit,
+		})
+	}
+	return statuses
+}
+
+// unsaved returns the day and use to save if they changed since the last call
+func (t *budgetTracker) unsaved() (time.Time, map[string]int, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if !t.changed {
+		return time.Time{}, nil, false
+	}
+	t.changed = false
+	day, err := time.ParseInLocation("2006-01-02", t.day, time.Local)
+	if err != nil {
+		return time.Time{}, nil, false
+	}
+	return day, maps.Clone(t.minutes), true
+}
+
+// budgetReason is the reason recorded with a query blocked by a used up budget
+func budgetReason(b budget) string {
+	return "daily budget of " + b.limit.String() + " for " + b.pattern + " used up"
+}
+
+// saveBudgetUsage periodically saves the budgets' use when it changed
+func (s *Server) saveBudgetUsage() {
+	ticker := time.NewTicker(budgetSaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.persistBudgetUsage()
+	}
+}
+
+// persistBudgetUsage saves today's use of the budgets if it changed
+func (s *Server) persistBudgetUsage() {
+	day, minutes, ok := s.budgets.unsaved()
+	if !ok {
+		return
+	}
+	if err := s.stateManager.SetBudgetUsage(day, minutes); err != nil {
+		logger.Warn("Failed to save budget use", "error", err)
+	}
+}
+
+// restoreBudgetUsage restores today's use of the budgets from before the last restart
+func (s *Server) restoreBudgetUsage() {
+	now := time.Now()
+	s.budgets.restore(now, s.stateManager.BudgetUsage(now))
+}
@@ -0,0 +1,50 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetTracker(t *testing.T) {
+	tracker := newBudgetTracker()
+	tracker.setBudgets(map[string]time.Duration{"reddit.com": 2 * time.Minute, "*.youtube.com": time.Hour})
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.Local)
+
+	// Queries in the same minute count once, and an exact domain doesn't cover its subdomains
+	tracker.use("reddit.com", now)
+	tracker.use("Reddit.com", now.Add(10*time.Second))
+	tracker.use("old.reddit.com", now.Add(time.Minute))
+	if _, exhausted := tracker.exhausted("reddit.com", now.Add(time.Minute)); exhausted {
+		t.Fatal("expected one minute of the budget to be left")
+	}
+	tracker.use("reddit.com", now.Add(5*time.Minute))
+	budget, exhausted := tracker.exhausted("reddit.com", now.Add(6*time.Minute))
+	if !exhausted || budget.pattern != "reddit.com" {
+		t.Fatal("expected the budget to be used up after queries in two minutes")
+	}
+	if _, exhausted := tracker.exhausted("www.youtube.com", now); exhausted {
+		t.Error("expected other budgets to be unaffected")
+	}
+
+	statuses := tracker.status(now.Add(6 * time.Minute))
+	if len(statuses) != 2 || statuses[1].Domain != "reddit.com" || !statuses[1].Exhausted || statuses[1].Remaining != "0s" {
+		t.Errorf("unexpected budget status: %+v", statuses)
+	}
+
+	day, minutes, ok := tracker.unsaved()
+	if !ok || day.Day() != 10 || minutes["reddit.com"] != 2 {
+		t.Errorf("expected the use to be saved, got %v %v %v", day, minutes, ok)
+	}
+	if _, _, ok := tracker.unsaved(); ok {
+		t.Error("expected nothing to save without changes")
+	}
+
+	// A new day starts afresh, and use restored from another day is dropped
+	if _, exhausted := tracker.exhausted("reddit.com", now.Add(24*time.Hour)); exhausted {
+		t.Error("expected the budget to be reset the next day")
+	}
+	tracker.restore(now, minutes)
+	if _, exhausted := tracker.exhausted("reddit.com", now); !exhausted {
+		t.Error("expected the restored use to count")
+	}
+}
@@ -0,0 +1,130 @@
+package dns
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+const (
+	// canaryZone holds the unique names the bypass check resolves; the resolver answers them
+	// itself and leaves them out of the query log
+	canaryZone = "canary.sinkzone.internal"
+
+	// bypassCheckDelay is how long after startup the first check runs, so the system
+	// resolver has been pointed at sinkzone by then
+	bypassCheckDelay = 30 * time.Second
+
+	// bypassCheckInterval is how often this machine's DNS queries are checked
+	bypassCheckInterval = 5 * time.Minute
+
+	// bypassCheckTimeout bounds the lookup of one check
+	bypassCheckTimeout = 5 * time.Second
+)
+
+// bypassCheck finds out whether this machine's DNS queries reach the resolver, e.g. after
+// a VPN or a DHCP lease replaced its nameserver, by resolving a unique name through the
+// system resolver and watching for it to arrive
+type bypassCheck struct {
+	enabled atomic.Bool
+
+	// lookup resolves a name through the system resolver, replaced in tests
+	lookup func(ctx context.Context, name string)
+
+	mu      sync.Mutex
+	pending string // Canary name of the running check, "" between checks
+	seen    bool   // The pending name arrived
+	status  api.DNSCheck
+}
+
+func newBypassCheck() *bypassCheck {
+	return &bypassCheck{
+		lookup: func(ctx context.Context, name string) {
+			_, _ = net.DefaultResolver.LookupHost(ctx, name)
+		},
+		status: api.DNSCheck{Status: api.HealthUnknown},
+	}
+}
+
+// canary reports whether a queried domain is a name of the bypass check, noting it when
+// it is the one the running check waits for
+func (b *bypassCheck) canary(domain string) bool {
+	domain = strings.ToLower(domain)
+	if b == nil || !strings.HasSuffix(domain, "."+canaryZone) {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if domain == b.pending {
+		b.seen = true
+	}
+	return true
+}
+
+// run resolves a new canary name and records whether it arrived, returning the previous
+// and the new status
+func (b *bypassCheck) run(now time.Time) (previous, current string) {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return "", ""
+	}
+	name := hex.EncodeToString(token) + "." + canaryZone
+
+	b.mu.Lock()
+	b.pending, b.seen = name, false
+	b.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), bypassCheckTimeout)
+	defer cancel()
+	b.lookup(ctx, name+".") // Rooted, so search domains aren't appended
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous = b.status.Status
+	b.status.CheckedAt = &now
+	b.status.Status = api.DNSCheckBypassed
+	if b.seen {
+		b.status.Status = api.HealthOK
+		b.status.LastOK = &now
+	}
+	b.pending, b.seen = "", false
+	return previous, b.status.Status
+}
+
+// report returns the result of the last check, or nil while the check is off
+func (b *bypassCheck) report() *api.DNSCheck {
+	if b == nil || !b.enabled.Load() {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := b.status
+	return &status
+}
+
+// checkBypass checks every bypassCheckInterval that this machine's DNS queries reach the
+// resolver, logging when they stop and start doing so
+func (s *Server) checkBypass() {
+	time.Sleep(bypassCheckDelay)
+	ticker := time.NewTicker(bypassCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.bypass.enabled.Load() && s.listening.Load() {
+			switch previous, current := s.bypass.run(time.Now()); {
+			case current == api.DNSCheckBypassed && previous != current:
+				logger.Warn("This machine's DNS queries don't reach sinkzone, so nothing is blocked for it; a VPN or network change may have replaced its nameserver (see sinkzone doctor)")
+			case current == api.HealthOK && previous == api.DNSCheckBypassed:
+				logger.Info("This machine's DNS queries reach sinkzone again")
+			}
+		}
+		<-ticker.C
+	}
+}
@@ -0,0 +1,42 @@
+package dns
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+func TestBypassCheck(t *testing.T) {
+	check := newBypassCheck()
+	check.enabled.Store(true)
+	reaches := true
+	check.lookup = func(ctx context.Context, name string) {
+		if reaches {
+			check.canary(strings.ToUpper(strings.TrimSuffix(name, ".")))
+		}
+	}
+
+	now := time.Now()
+	if previous, current := check.run(now); previous != api.HealthUnknown || current != api.HealthOK {
+		t.Errorf("expected the first check to pass, got %s after %s", current, previous)
+	}
+	reaches = false
+	if _, current := check.run(now.Add(time.Minute)); current != api.DNSCheckBypassed {
+		t.Errorf("expected the check to find the queries bypassing the resolver, got %s", current)
+	}
+	if report := check.report(); !report.Bypassed() || !report.LastOK.Equal(now) {
+		t.Errorf("expected a bypass reported with the last passing check, got %+v", report)
+	}
+
+	// Late or stray canary names are still kept out of the log, but don't pass a check
+	if !check.canary("0123456789abcdef."+canaryZone) || check.canary("example.com") {
+		t.Error("expected only names of the canary zone to be taken as canaries")
+	}
+	check.enabled.Store(false)
+	if check.report() != nil {
+		t.Error("expected no report while the check is off")
+	}
+}
@@ -0,0 +1,305 @@
+package dns
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/miekg/dns"
+)
+
+// responseCache keeps upstream answers until their TTL expires, so repeated queries don't
+// wait for the upstream. Only allowed queries reach it, so focus mode changes apply at once.
+// When full, the least recently used answer makes room.
+//
+// With serve-stale (RFC 8767), expired answers are kept for a while longer and given to
+// clients when every upstream fails, so a short outage of the upstreams goes unnoticed.
+type responseCache struct {
+	size   int
+	minTTL time.Duration
+	maxTTL time.Duration
+
+	entries  map[cacheKey]*list.Element // Values are *cacheEntry
+	order    *list.List                 // Most recently used first
+	staleFor time.Duration              // How long expired answers are kept for getStale (guarded by mutex)
+	mutex    sync.Mutex
+
+	// Counted since the cache was created
+	hits, misses, evictions, expirations, stale uint64
+}
+
+// staleTTL is the TTL of stale answers, as RFC 8767 recommends, so clients ask again soon
+const staleTTL = 30
+
+type cacheKey struct {
+	name   string
+	qtype  uint16
+	qclass uint16
+}
+
+type cacheEntry struct {
+	key      cacheKey
+	response *dns.Msg
+	stored   time.Time
+	expires  time.Time
+}
+
+// newResponseCache creates a cache of up to size answers, or returns nil when size is 0
+func newResponseCache(size int, minTTL, maxTTL time.Duration) *responseCache {
+	if size <= 0 {
+		return nil
+	}
+	return &responseCache{
+		size:    size,
+		minTTL:  minTTL,
+		maxTTL:  maxTTL,
+		entries: make(map[cacheKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// hasLimits reports whether the cache was created with these limits (a nil cache has size 0)
+func (c *responseCache) hasLimits(size int, minTTL, maxTTL time.Duration) bool {
+	if c == nil {
+		return size <= 0
+	}
+	return c.size == size && c.minTTL == minTTL && c.maxTTL == maxTTL
+}
+
+// setServeStale sets how long expired answers are kept for getStale (0 = not at all)
+func (c *responseCache) setServeStale(staleFor time.Duration) {
+	if c == nil {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.staleFor = staleFor
+}
+
+func keyOf(r *dns.Msg) (cacheKey, bool) {
+	if len(r.Question) != 1 {
+		return cacheKey{}, false
+	}
+	q := r.Question[0]
+	return cacheKey{name: strings.ToLower(q.Name), qtype: q.Qtype, qclass: q.Qclass}, true
+}
+
+// get returns a cached answer to r with its TTLs reduced by the time it was cached, or nil
+func (c *responseCache) get(r *dns.Msg, now time.Time) *dns.Msg {
+	if c == nil {
+		return nil
+	}
+	key, ok := keyOf(r)
+	if !ok {
+		return nil
+	}
+
+	c.mutex.Lock()
+	var entry *cacheEntry
+	if element, ok := c.entries[key]; ok {
+		entry = element.Value.(*cacheEntry)
+		switch {
+		case now.Before(entry.expires):
+			c.order.MoveToFront(element)
+		case now.Before(entry.expires.Add(c.staleFor)):
+			// Kept for getStale
+			entry = nil
+		default:
+			c.remove(element)
+			c.expirations++
+			entry = nil
+		}
+	}
+	if entry == nil {
+		c.misses++
+		c.mutex.Unlock()
+		cacheMissesTotal.Inc()
+		return nil
+	}
+	c.hits++
+	c.mutex.Unlock()
+	cacheHitsTotal.Inc()
+
+	elapsed := uint32(now.Sub(entry.stored) / time.Second)
+	return entry.reply(r, func(ttl uint32) uint32 {
+		if ttl > elapsed {
+			return ttl - elapsed
+		}
+		return 0
+	})
+}
+
+// getStale returns an expired answer to r that is still within serve_stale, with a TTL of
+// at most staleTTL and an Extended DNS Error saying it is stale, or nil
+func (c *responseCache) getStale(r *dns.Msg, now time.Time) *dns.Msg {
+	if c == nil {
+		return nil
+	}
+	key, ok := keyOf(r)
+	if !ok {
+		return nil
+	}
+
+	c.mutex.Lock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.mutex.Unlock()
+		return nil
+	}
+	entry := element.Value.(*cacheEntry)
+	if now.Before(entry.expires) || !now.Before(entry.expires.Add(c.staleFor)) {
+		c.mutex.Unlock()
+		return nil
+	}
+	c.stale++
+	c.mutex.Unlock()
+	cacheStaleTotal.Inc()
+
+	response := entry.reply(r, func(ttl uint32) uint32 { return min(ttl, staleTTL) })
+	if opt := response.IsEdns0(); opt != nil {
+		opt.Option = append(opt.Option, &dns.EDNS0_EDE{InfoCode: dns.ExtendedErrorCodeStaleAnswer})
+	}
+	return response
+}
+
+// reply copies the cached answer for r, changing the TTL of every record with ttl
+func (e *cacheEntry) reply(r *dns.Msg, ttl func(uint32) uint32) *dns.Msg {
+	response := e.response.Copy()
+	response.Id = r.Id
+	response.Question = r.Question
+	for _, section := range [][]dns.RR{response.Answer, response.Ns, response.Extra} {
+		for _, rr := range section {
+			header := rr.Header()
+			if header.Rrtype == dns.TypeOPT {
+				continue
+			}
+			header.Ttl = ttl(header.Ttl)
+		}
+	}
+	return response
+}
+
+// put caches a successful or NXDOMAIN answer for its lowest TTL, within the configured bounds
+func (c *responseCache) put(r, response *dns.Msg, now time.Time) {
+	if c == nil || response.Truncated || (response.Rcode != dns.RcodeSuccess && response.Rcode != dns.RcodeNameError) {
+		return
+	}
+	key, ok := keyOf(r)
+	if !ok {
+		return
+	}
+	ttl, ok := lowestTTL(response)
+	if !ok {
+		return
+	}
+	ttl = max(ttl, c.minTTL)
+	ttl = min(ttl, c.maxTTL)
+	if ttl <= 0 {
+		return
+	}
+
+	entry := &cacheEntry{key: key, response: response.Copy(), stored: now, expires: now.Add(ttl)}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	for len(c.entries) >= c.size {
+		c.remove(c.order.Back())
+		c.evictions++
+		cacheEvictionsTotal.Inc()
+	}
+	c.entries[key] = c.order.PushFront(entry)
+}
+
+// remove drops an entry. Callers hold the mutex.
+func (c *responseCache) remove(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
+
+// flush drops every cached answer and returns how many there were
+func (c *responseCache) flush() int {
+	if c == nil {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	flushed := len(c.entries)
+	clear(c.entries)
+	c.order.Init()
+	return flushed
+}
+
+// len returns the number of cached answers, including expired ones not yet dropped
+func (c *responseCache) len() int {
+	if c == nil {
+		return 0
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// stats reports the size and counters of the cache
+func (c *responseCache) stats() api.CacheStats {
+	if c == nil {
+		return api.CacheStats{}
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return api.CacheStats{
+		Enabled:     true,
+		Size:        c.size,
+		Entries:     len(c.entries),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+		Stale:       c.stale,
+	}
+}
+
+// lowestTTL returns the smallest TTL of the answer and authority records. Negative answers
+// use the SOA's minimum TTL when it is lower.
+func lowestTTL(response *dns.Msg) (time.Duration, bool) {
+	found := false
+	var lowest uint32
+	consider := func(ttl uint32) {
+		if !found || ttl < lowest {
+			lowest = ttl
+			found = true
+		}
+	}
+	for _, section := range [][]dns.RR{response.Answer, response.Ns} {
+		for _, rr := range section {
+			consider(rr.Header().Ttl)
+			if soa, ok := rr.(*dns.SOA); ok && len(response.Answer) == 0 {
+				consider(soa.Minttl)
+			}
+		}
+	}
+	return time.Duration(lowest) * time.Second, found
+}
+
+// cacheStats reports the cache for GET /api/cache
+func (s *Server) cacheStats() api.CacheStats {
+	s.settingsMutex.RLock()
+	cache := s.cache
+	s.settingsMutex.RUnlock()
+	return cache.stats()
+}
+
+// flushCache empties the cache for DELETE /api/cache, e.g. after a network change
+func (s *Server) flushCache() int {
+	s.settingsMutex.RLock()
+	cache := s.cache
+	s.settingsMutex.RUnlock()
+	flushed := cache.flush()
+	logger.Info("Cache flushed", "entries", flushed)
+	return flushed
+}
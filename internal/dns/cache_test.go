@@ -0,0 +1,160 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func answer(name string, ttl uint32) (*dns.Msg, *dns.Msg) {
+	query := new(dns.Msg)
+	query.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	response := new(dns.Msg)
+	response.SetReply(query)
+	response.Answer = append(response.Answer, &dns.A{
+		Hdr: dns.RR_Header{Name: dns.Fqdn(name), Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+		A:   net.ParseIP("192.0.2.1"),
+	})
+	return query, response
+}
+
+func TestResponseCache(t *testing.T) {
+	if newResponseCache(0, 0, time.Hour) != nil {
+		t.Error("expected a size of 0 to disable the cache")
+	}
+
+	cache := newResponseCache(2, 0, time.Minute)
+	now := time.Now()
+	query, response := answer("example.com", 300)
+	cache.put(query, response, now)
+
+	query.Id = 42
+	cached := cache.get(query, now.Add(10*time.Second))
+	if cached == nil {
+		t.Fatal("expected a cached answer")
+	}
+	if cached.Id != 42 || cached.Answer[0].Header().Ttl != 290 {
+		t.Errorf("expected the query ID and a TTL reduced to 290, got %d and %d", cached.Id, cached.Answer[0].Header().Ttl)
+	}
+	// max_ttl caps how long the answer is kept
+	if cache.get(query, now.Add(time.Minute)) != nil {
+		t.Error("expected the answer to expire after max_ttl")
+	}
+
+	for _, name := range []string{"a.example", "b.example", "c.example"} {
+		query, response := answer(name, 300)
+		cache.put(query, response, now)
+	}
+	if len(cache.entries) != 2 {
+		t.Errorf("expected the cache to stay at 2 entries, got %d", len(cache.entries))
+	}
+}
+
+func TestResponseCacheServeStale(t *testing.T) {
+	cache := newResponseCache(10, 0, time.Hour)
+	now := time.Now()
+	query, response := answer("example.com", 300)
+	response.SetEdns0(dns.DefaultMsgSize, false)
+	cache.put(query, response, now)
+
+	if cache.getStale(query, now.Add(10*time.Minute)) != nil {
+		t.Error("expected no stale answers without serve_stale")
+	}
+
+	cache.setServeStale(time.Hour)
+	if cache.getStale(query, now.Add(time.Minute)) != nil {
+		t.Error("expected no stale answer before the TTL runs out")
+	}
+	expired := now.Add(10 * time.Minute)
+	if cache.get(query, expired) != nil {
+		t.Fatal("expected get to miss once the TTL ran out")
+	}
+	stale := cache.getStale(query, expired)
+	if stale == nil {
+		t.Fatal("expected a stale answer within serve_stale")
+	}
+	if ttl := stale.Answer[0].Header().Ttl; ttl != staleTTL {
+		t.Errorf("expected a TTL of %d, got %d", staleTTL, ttl)
+	}
+	if opt := stale.IsEdns0(); opt == nil || len(opt.Option) != 1 || opt.Option[0].(*dns.EDNS0_EDE).InfoCode != dns.ExtendedErrorCodeStaleAnswer {
+		t.Errorf("expected a Stale Answer extended error, got %v", stale.Extra)
+	}
+	if again := cache.getStale(query, expired); len(again.IsEdns0().Option) != 1 {
+		t.Error("expected the cached answer to stay unchanged")
+	}
+	if stats := cache.stats(); stats.Stale != 2 {
+		t.Errorf("expected 2 stale answers, got %d", stats.Stale)
+	}
+
+	// Dropped once serve_stale has passed too
+	if cache.getStale(query, now.Add(2*time.Hour)) != nil || cache.get(query, now.Add(2*time.Hour)) != nil || cache.len() != 0 {
+		t.Error("expected the answer to be dropped after serve_stale")
+	}
+}
+
+func TestResponseCacheLRU(t *testing.T) {
+	cache := newResponseCache(2, 0, time.Hour)
+	now := time.Now()
+	queries := map[string]*dns.Msg{}
+	for _, name := range []string{"a.example", "b.example"} {
+		query, response := answer(name, 300)
+		cache.put(query, response, now)
+		queries[name] = query
+	}
+
+	// Using a.example makes b.example the least recently used
+	if cache.get(queries["a.example"], now) == nil {
+		t.Fatal("expected a.example to be cached")
+	}
+	query, response := answer("c.example", 300)
+	cache.put(query, response, now)
+	if cache.get(queries["b.example"], now) != nil {
+		t.Error("expected b.example to be evicted")
+	}
+	if cache.get(queries["a.example"], now) == nil || cache.get(query, now) == nil {
+		t.Error("expected a.example and c.example to stay cached")
+	}
+	cache.get(query, now.Add(2*time.Hour))
+
+	stats := cache.stats()
+	if stats.Hits != 3 || stats.Misses != 2 || stats.Evictions != 1 || stats.Expirations != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if flushed := cache.flush(); flushed != 1 || cache.get(queries["a.example"], now) != nil {
+		t.Errorf("expected the flush to drop the one remaining answer, dropped %d", flushed)
+	}
+}
+
+func BenchmarkResponseCache(b *testing.B) {
+	now := time.Now()
+	b.Run("hit", func(b *testing.B) {
+		cache := newResponseCache(1000, 0, time.Hour)
+		queries := make([]*dns.Msg, 1000)
+		for i := range queries {
+			query, response := answer(fmt.Sprintf("site%d.example", i), 300)
+			cache.put(query, response, now)
+			queries[i] = query
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if cache.get(queries[i%len(queries)], now) == nil {
+				b.Fatal("expected a cached answer")
+			}
+		}
+	})
+	b.Run("put with eviction", func(b *testing.B) {
+		cache := newResponseCache(1000, 0, time.Hour)
+		queries := make([]*dns.Msg, 2000)
+		responses := make([]*dns.Msg, len(queries))
+		for i := range queries {
+			queries[i], responses[i] = answer(fmt.Sprintf("site%d.example", i), 300)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			cache.put(queries[i%len(queries)], responses[i%len(queries)], now)
+		}
+	})
+}
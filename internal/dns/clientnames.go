@@ -0,0 +1,101 @@
+package dns
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// neighborRefresh is how often the table of neighbors' MAC addresses is reread while
+// client_names names a MAC address or the resolver serves a network (lan)
+const neighborRefresh = 30 * time.Second
+
+// clientNamer names clients after client_names, by IP address or, where the system's
+// neighbor table has one, by MAC address
+type clientNamer struct {
+	names map[string]string // Keyed by config.ClientAddressKey
+	byMAC bool              // Some names are for MAC addresses, or devices are told apart by them
+
+	mu        sync.Mutex
+	neighbors map[string]string // IP address to MAC address
+	readAt    time.Time
+}
+
+// newClientNamer names clients after names; with lan it also looks up the MAC address of
+// every client
+func newClientNamer(names map[string]string, lan bool) *clientNamer {
+	namer := &clientNamer{names: names, byMAC: lan}
+	for addr := range names {
+		if _, err := net.ParseMAC(addr); err == nil {
+			namer.byMAC = true
+		}
+	}
+	return namer
+}
+
+// name returns the name given to a client address, or "" if it has none
+func (n *clientNamer) name(ip string) string {
+	if n == nil || len(n.names) == 0 || ip == "" {
+		return ""
+	}
+	key, ok := config.ClientAddressKey(ip)
+	if !ok {
+		return ""
+	}
+	if name, ok := n.names[key]; ok {
+		return name
+	}
+	if mac := n.mac(ip); mac != "" {
+		return n.names[mac]
+	}
+	return ""
+}
+
+// mac returns the MAC address of a client on the local network, or "" if it isn't known or
+// MAC addresses aren't looked up
+func (n *clientNamer) mac(ip string) string {
+	if n == nil || !n.byMAC || ip == "" {
+		return ""
+	}
+	key, ok := config.ClientAddressKey(ip)
+	if !ok {
+		return ""
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	mac, ok := n.neighbors[key]
+	if !ok && time.Since(n.readAt) >= neighborRefresh {
+		// Reread on a miss, so new devices are named within neighborRefresh
+		n.neighbors = readNeighbors()
+		n.readAt = time.Now()
+		mac = n.neighbors[key]
+	}
+	return mac
+}
+
+// clientName returns the name given to a client address in client_names, or "" (always
+// while client_privacy hides addresses, as a name would tell who the client is)
+func (s *Server) clientName(ip string) string {
+	s.settingsMutex.RLock()
+	namer, anonymizer := s.clientNames, s.clientAnonymizer
+	s.settingsMutex.RUnlock()
+	if anonymizer.enabled() {
+		return ""
+	}
+	return namer.name(ip)
+}
+
+// clientMAC returns the MAC address of a client, or "" (always while client_privacy hides
+// addresses)
+func (s *Server) clientMAC(ip string) string {
+	s.settingsMutex.RLock()
+	namer, anonymizer := s.clientNames, s.clientAnonymizer
+	s.settingsMutex.RUnlock()
+	if anonymizer.enabled() {
+		return ""
+	}
+	return namer.mac(ip)
+}
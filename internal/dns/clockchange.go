@@ -0,0 +1,77 @@
+package dns
+
+import (
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// clockCheckInterval is how often the system time is checked for changes between queries
+const clockCheckInterval = 5 * time.Second
+
+// watchClock keeps the focus session's deadlines in step with changes of the system time
+func (s *Server) watchClock() {
+	ticker := time.NewTicker(clockCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.reconcileClock()
+	}
+}
+
+// reconcileClock moves the session's end, pause, grace period, and snoozes by as much as
+// the system time was changed, so the session keeps the time it had left instead of
+// ending early or running late, and saves the new end time
+func (s *Server) reconcileClock() {
+	if s.clockJumps == nil {
+		return
+	}
+	jump := s.clockJumps.Check()
+	if jump == 0 {
+		return
+	}
+
+	s.focusMutex.Lock()
+	move := func(t *time.Time) *time.Time {
+		if t == nil {
+			return nil
+		}
+		moved := t.Add(jump)
+		return &moved
+	}
+	s.focusEndTime = move(s.focusEndTime)
+	s.focusPausedUntil = move(s.focusPausedUntil)
+	s.focusGraceUntil = move(s.focusGraceUntil)
+	for domain, until := range s.snoozes {
+		s.snoozes[domain] = until.Add(jump)
+	}
+	focusMode := s.focusMode
+	endTime := s.focusEndTime
+	session := config.FocusSessionOptions{
+		Profile:   s.focusProfile,
+		Intensity: s.focusIntensity,
+		Label:     s.focusLabel,
+		DryRun:    s.focusDryRun,
+	}
+	s.focusMutex.Unlock()
+
+	logger.Info("System time changed", "by", jump)
+	if !focusMode {
+		return
+	}
+	if s.apiServer != nil {
+		s.apiServer.MoveFocusDeadlines(jump)
+	}
+	if endTime == nil {
+		return
+	}
+	logger.Info("Focus mode keeps its remaining time", "ends", *endTime)
+
+	session.Duration = endTime.Sub(clock.Now())
+	if s.stateManager != nil && session.Duration > 0 {
+		if err := s.stateManager.SetFocusSession(true, session); err != nil {
+			logger.Warn("Failed to persist focus state", "error", err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package dns
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/crash"
+	"github.com/miekg/dns"
+)
+
+// queueTimeout is how long a query waits for a turn before it is refused
+const queueTimeout = 2 * time.Second
+
+// queryLimiter bounds the queries handled at once. Queries over the limit wait in a queue
+// of bounded length; once that is full too, they are refused straight away (load shedding).
+type queryLimiter struct {
+	maxQueries int
+	maxQueued  int
+	slots      chan struct{}
+	queued     atomic.Int64
+}
+
+// newQueryLimiter creates a limiter, or returns nil when maxQueries is 0 (unlimited)
+func newQueryLimiter(maxQueries, maxQueued int) *queryLimiter {
+	if maxQueries <= 0 {
+		return nil
+	}
+	return &queryLimiter{
+		maxQueries: maxQueries,
+		maxQueued:  maxQueued,
+		slots:      make(chan struct{}, maxQueries),
+	}
+}
+
+// hasLimits reports whether the limiter was created with these limits (a nil limiter has
+// maxQueries 0)
+func (l *queryLimiter) hasLimits(maxQueries, maxQueued int) bool {
+	if l == nil {
+		return maxQueries <= 0
+	}
+	return l.maxQueries == maxQueries && l.maxQueued == maxQueued
+}
+
+// acquire waits for a turn to handle a query, reporting false when the query should be
+// refused instead. Every successful acquire must be followed by release.
+func (l *queryLimiter) acquire() bool {
+	if l == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	if l.queued.Add(1) > int64(l.maxQueued) {
+		l.queued.Add(-1)
+		return false
+	}
+	defer l.queued.Add(-1)
+	timer := time.NewTimer(queueTimeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// release ends a turn taken with acquire
+func (l *queryLimiter) release() {
+	if l != nil {
+		<-l.slots
+	}
+}
+
+// inFlight returns the number of queries being handled
+func (l *queryLimiter) inFlight() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots)
+}
+
+// queueDepth returns the number of queries waiting for a turn
+func (l *queryLimiter) queueDepth() int {
+	if l == nil {
+		return 0
+	}
+	return int(l.queued.Load())
+}
+
+// serveDNS handles a query once the concurrency limit allows, refusing it when the resolver
+// is overloaded
+func (s *Server) serveDNS(w dns.ResponseWriter, r *dns.Msg) {
+	defer crash.Recover("resolver")
+	s.settingsMutex.RLock()
+	limiter := s.queryLimiter
+	s.settingsMutex.RUnlock()
+
+	if !limiter.acquire() {
+		shedTotal.Inc()
+		logger.Debug("DNS response: REFUSED (overloaded)", "client", clientIP(w.RemoteAddr()))
+		msg := new(dns.Msg)
+		msg.SetRcode(r, dns.RcodeRefused)
+		if err := w.WriteMsg(msg); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		}
+		return
+	}
+	defer limiter.release()
+	s.handleRequest(w, r)
+}
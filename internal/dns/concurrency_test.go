@@ -0,0 +1,41 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryLimiter(t *testing.T) {
+	if newQueryLimiter(0, 10) != nil {
+		t.Error("expected max_queries 0 to leave queries unlimited")
+	}
+
+	limiter := newQueryLimiter(1, 1)
+	if !limiter.acquire() {
+		t.Fatal("expected the first query to be handled")
+	}
+
+	// The second query waits in the queue for the first to finish
+	queued := make(chan bool)
+	go func() { queued <- limiter.acquire() }()
+	deadline := time.Now().Add(time.Second)
+	for limiter.queueDepth() != 1 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if limiter.queueDepth() != 1 {
+		t.Fatalf("expected one queued query, got %d", limiter.queueDepth())
+	}
+
+	// With the queue full, a third is refused straight away
+	if limiter.acquire() {
+		t.Error("expected a query to be refused while the queue is full")
+	}
+
+	limiter.release()
+	if !<-queued {
+		t.Error("expected the queued query to be handled once the first finished")
+	}
+	if limiter.inFlight() != 1 || limiter.queueDepth() != 0 {
+		t.Errorf("expected 1 query in flight and none queued, got %d and %d", limiter.inFlight(), limiter.queueDepth())
+	}
+}
@@ -0,0 +1,203 @@
+package dns
+
+import (
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/miekg/dns"
+)
+
+// cooldownWindow is how long a strike counts towards cooldown.strikes
+const cooldownWindow = time.Minute
+
+// Reasons clients get a strike, shown by GET /api/cooldowns
+const (
+	strikeRateLimit = "rate_limit" // A query refused by the rate limit
+	strikeMalformed = "malformed"  // A query the DNS library rejected with FORMERR or NOTIMP
+)
+
+// cooldownList refuses clients for a while once they collect cooldown.strikes strikes
+// within a minute
+type cooldownList struct {
+	strikes  int // 0 = never
+	duration time.Duration
+	counts   map[string]*strikeCount
+	clients  map[string]*cooldown
+	mutex    sync.Mutex
+}
+
+type strikeCount struct {
+	count int
+	since time.Time // Start of the window the strikes are counted in
+}
+
+type cooldown struct {
+	reason  string
+	until   time.Time
+	refused uint64 // Queries refused since the cooldown started
+}
+
+func newCooldownList() *cooldownList {
+	return &cooldownList{
+		counts:  make(map[string]*strikeCount),
+		clients: make(map[string]*cooldown),
+	}
+}
+
+// setLimits changes the strikes that start a cooldown and its length; cooldowns in
+// effect keep their end
+func (l *cooldownList) setLimits(strikes int, duration time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if strikes != l.strikes {
+		clear(l.counts)
+	}
+	l.strikes, l.duration = strikes, duration
+}
+
+// refused reports whether the client is on cooldown, counting the query if it is
+func (l *cooldownList) refused(client string, now time.Time) bool {
+	if l == nil {
+		return false
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	c, ok := l.clients[client]
+	if !ok {
+		return false
+	}
+	if !now.Before(c.until) {
+		delete(l.clients, client)
+		return false
+	}
+	c.refused++
+	return true
+}
+
+// strike counts a strike against the client and reports whether it started a cooldown
+func (l *cooldownList) strike(client, reason string, now time.Time) (time.Time, bool) {
+	if l == nil {
+		return time.Time{}, false
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.strikes <= 0 || l.clients[client] != nil {
+		return time.Time{}, false
+	}
+
+	count, ok := l.counts[client]
+	if !ok || now.Sub(count.since) >= cooldownWindow {
+		if !ok && len(l.counts) >= maxRateLimitClients {
+			l.dropStale(now)
+		}
+		count = &strikeCount{since: now}
+		l.counts[client] = count
+	}
+	count.count++
+	if count.count < l.strikes {
+		return time.Time{}, false
+	}
+
+	delete(l.counts, client)
+	until := now.Add(l.duration)
+	l.clients[client] = &cooldown{reason: reason, until: until}
+	return until, true
+}
+
+// dropStale forgets strikes outside the window and cooldowns that have ended. Callers
+// hold the mutex.
+func (l *cooldownList) dropStale(now time.Time) {
+	for client, count := range l.counts {
+		if now.Sub(count.since) >= cooldownWindow {
+			delete(l.counts, client)
+		}
+	}
+	for client, c := range l.clients {
+		if !now.Before(c.until) {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// list returns the clients on cooldown, the soonest to end first
+func (l *cooldownList) list(now time.Time) []api.Cooldown {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.dropStale(now)
+	cooldowns := make([]api.Cooldown, 0, len(l.clients))
+	for client, c := range l.clients {
+		cooldowns = append(cooldowns, api.Cooldown{Client: client, Reason: c.reason, Until: c.until, Refused: c.refused})
+	}
+	sort.Slice(cooldowns, func(i, j int) bool { return cooldowns[i].Until.Before(cooldowns[j].Until) })
+	return cooldowns
+}
+
+// lift ends the cooldown of a client, or of every client when client is "", and returns
+// how many ended
+func (l *cooldownList) lift(client string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if client == "" {
+		lifted := len(l.clients)
+		clear(l.clients)
+		clear(l.counts)
+		return lifted
+	}
+	delete(l.counts, client)
+	if _, ok := l.clients[client]; !ok {
+		return 0
+	}
+	delete(l.clients, client)
+	return 1
+}
+
+// strike counts a strike against a client, logging when it starts a cooldown
+func (s *Server) strike(ip, reason string, now time.Time) {
+	if until, started := s.cooldowns.strike(ip, reason, now); started {
+		logger.Warn("Client put on cooldown", "client", s.recordedClient(ip), "reason", reason, "until", until.Format(time.RFC3339))
+		cooldownsTotal.Inc()
+	}
+}
+
+// cooldownList returns the clients on cooldown for GET /api/cooldowns
+func (s *Server) cooldownList() []api.Cooldown {
+	cooldowns := s.cooldowns.list(time.Now())
+	s.settingsMutex.RLock()
+	names := s.clientNames
+	s.settingsMutex.RUnlock()
+	for i := range cooldowns {
+		cooldowns[i].Name = names.name(cooldowns[i].Client)
+	}
+	return cooldowns
+}
+
+// liftCooldown ends the cooldown of a client, or of every client when client is ""
+func (s *Server) liftCooldown(client string) int {
+	lifted := s.cooldowns.lift(client)
+	if lifted > 0 {
+		logger.Info("Cooldown lifted", "clients", lifted)
+	}
+	return lifted
+}
+
+// strikeWriter gives a strike to clients whose query the DNS library rejected before it
+// reached the handler, which it answers with FORMERR or NOTIMP
+type strikeWriter struct {
+	dns.Writer
+	server *Server
+}
+
+func (w *strikeWriter) Write(b []byte) (int, error) {
+	if len(b) >= 4 {
+		switch int(b[3] & 0x0f) {
+		case dns.RcodeFormatError, dns.RcodeNotImplemented:
+			if remote, ok := w.Writer.(interface{ RemoteAddr() net.Addr }); ok {
+				w.server.strike(clientIP(remote.RemoteAddr()), strikeMalformed, time.Now())
+			}
+		}
+	}
+	return w.Writer.Write(b)
+}
@@ -0,0 +1,45 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCooldownList(t *testing.T) {
+	list := newCooldownList()
+	now := time.Now()
+	if _, started := list.strike("192.0.2.1", strikeRateLimit, now); started {
+		t.Fatal("expected no cooldowns while cooldown.strikes is 0")
+	}
+
+	list.setLimits(3, 10*time.Minute)
+	list.strike("192.0.2.1", strikeRateLimit, now)
+	list.strike("192.0.2.1", strikeRateLimit, now)
+	// Strikes older than a minute start a new count
+	if _, started := list.strike("192.0.2.1", strikeMalformed, now.Add(time.Minute)); started {
+		t.Fatal("expected the strikes of the last minute to be counted only")
+	}
+	list.strike("192.0.2.1", strikeMalformed, now.Add(time.Minute))
+	until, started := list.strike("192.0.2.1", strikeMalformed, now.Add(time.Minute))
+	if !started || !until.Equal(now.Add(11*time.Minute)) {
+		t.Fatalf("expected a 10 minute cooldown after 3 strikes, got %v %v", until, started)
+	}
+
+	if !list.refused("192.0.2.1", now.Add(2*time.Minute)) || list.refused("192.0.2.2", now) {
+		t.Error("expected only the client on cooldown to be refused")
+	}
+	cooldowns := list.list(now.Add(2 * time.Minute))
+	if len(cooldowns) != 1 || cooldowns[0].Reason != strikeMalformed || cooldowns[0].Refused != 1 {
+		t.Errorf("unexpected cooldowns: %+v", cooldowns)
+	}
+	if list.refused("192.0.2.1", until) {
+		t.Error("expected the cooldown to end")
+	}
+
+	list.strike("192.0.2.3", strikeRateLimit, now)
+	list.strike("192.0.2.3", strikeRateLimit, now)
+	list.strike("192.0.2.3", strikeRateLimit, now)
+	if list.lift("192.0.2.3") != 1 || list.refused("192.0.2.3", now) || list.lift("192.0.2.3") != 0 {
+		t.Error("expected lifting to end the cooldown once")
+	}
+}
@@ -0,0 +1,70 @@
+package dns
+
+import (
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/rules"
+)
+
+// decide tells what the resolver would answer for a domain right now, as handleRequest
+// does, without recording a query or marking the domain as seen
+func (s *Server) decide(domain string) api.Decision {
+	domain = api.NormalizeDomain(domain)
+	now := clock.Now()
+
+	s.focusMutex.RLock()
+	focusMode := s.focusMode
+	if s.focusEndTime != nil && !now.Before(*s.focusEndTime) {
+		focusMode = false
+	}
+	if s.focusPausedUntil != nil && now.Before(*s.focusPausedUntil) && !s.focusBreak {
+		focusMode = false
+	}
+	inGracePeriod := focusMode && s.focusGraceUntil != nil && now.Before(*s.focusGraceUntil)
+	dryRun := s.focusDryRun || s.dryRun.Load()
+	intensity := s.focusIntensity
+	profile := s.focusProfile
+	startedAt := s.focusStartedAt
+	snoozedUntil, snoozed := s.snoozes[strings.ToLower(domain)]
+	s.focusMutex.RUnlock()
+
+	decision := api.Decision{Domain: domain, Focus: focusMode}
+	// Rules that look at the client see none, as no query was sent
+	if action, rule := s.ruleAction(rules.Input{Domain: domain, Time: now, Focus: focusMode, Profile: profile, Intensity: intensity}); action != "" {
+		decision.Reason = ruleReason(action, rule)
+		decision.Blocked = action == config.RuleBlock
+	} else if scheduled, ok := s.scheduledBlock(domain, now); ok {
+		decision.Reason = scheduleReason(scheduled)
+		decision.Blocked = true
+	} else if budget, exhausted := s.budgets.exhausted(domain, time.Now()); exhausted {
+		decision.Reason = budgetReason(budget)
+		decision.Blocked = true
+	} else if focusMode {
+		s.seenMutex.Lock()
+		firstSeen, seen := s.seenDomains[domain]
+		s.seenMutex.Unlock()
+
+		decision.Reason = s.blockReason(domain, intensity, seen && firstSeen.Before(startedAt))
+		decision.Blocked = decision.Reason != ""
+	}
+	switch {
+	case decision.Blocked && snoozed && now.Before(snoozedUntil):
+		decision.Blocked = false
+		decision.Reason = "snoozed during the focus session"
+		decision.SnoozedUntil = &snoozedUntil
+	case decision.Blocked && dryRun:
+		decision.Blocked, decision.WouldBlock = false, true
+		decision.Reason += " (dry run, not enforced)"
+	case decision.Blocked && inGracePeriod:
+		decision.Blocked, decision.WouldBlock = false, true
+		decision.Reason += " (grace period, not enforced yet)"
+	case decision.Blocked && s.portal.passthrough(now):
+		decision.Blocked, decision.WouldBlock = false, true
+		decision.Reason += " (captive portal passthrough, not enforced)"
+	}
+	return decision
+}
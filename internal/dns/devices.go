@@ -0,0 +1,123 @@
+package dns
+
+import (
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// deviceOverride returns the override of a device, known by its MAC address or its address,
+// if one applies at the given time
+func (s *Server) deviceOverride(now time.Time, ids ...string) (config.DeviceFocus, bool) {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	for _, id := range ids {
+		if focus, ok := s.deviceFocus[id]; ok && id != "" && focus.Active(now) {
+			return focus, true
+		}
+	}
+	return config.DeviceFocus{}, false
+}
+
+// focusOverride returns the override of the session that applies to a query: the one set
+// for the account of this machine that sent it (user:<name>), else the focus of its users
+// entry, else the override of the device
+func (s *Server) focusOverride(now time.Time, user string, policy *userPolicy, ids ...string) (config.DeviceFocus, bool) {
+	if user != "" {
+		if override, ok := s.deviceOverride(now, config.UserIDPrefix+user); ok {
+			return override, true
+		}
+	}
+	if policy != nil && policy.focus != config.DeviceFocusFollow {
+		return config.DeviceFocus{Mode: policy.focus}, true
+	}
+	return s.deviceOverride(now, ids...)
+}
+
+// loadDeviceFocus restores the device overrides saved in the state
+func (s *Server) loadDeviceFocus() {
+	overrides := s.stateManager.DeviceFocusOverrides()
+	s.focusMutex.Lock()
+	s.deviceFocus = overrides
+	s.focusMutex.Unlock()
+	if len(overrides) > 0 {
+		logger.Info("Device focus overrides restored", "devices", len(overrides))
+	}
+}
+
+// listDeviceFocus returns the device overrides that still apply
+func (s *Server) listDeviceFocus() map[string]api.DeviceFocus {
+	now := clock.Now()
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	overrides := make(map[string]api.DeviceFocus, len(s.deviceFocus))
+	for device, focus := range s.deviceFocus {
+		if focus.Active(now) {
+			overrides[device] = api.DeviceFocus{Mode: focus.Mode, Until: focus.Until}
+		}
+	}
+	return overrides
+}
+
+// setDeviceFocus keeps a device in focus, out of it, or following the session, and returns
+// the device it was saved for. Taking a device out of focus needs the PIN when one is set.
+func (s *Server) setDeviceFocus(device string, focus api.DeviceFocus, pin string) (string, error) {
+	mode, err := config.ParseDeviceFocusMode(focus.Mode)
+	if err != nil {
+		return "", err
+	}
+	device = s.deviceKey(device)
+
+	current, _ := s.deviceOverride(clock.Now(), device)
+	if mode == config.DeviceFocusOff || (mode == config.DeviceFocusFollow && current.Mode == config.DeviceFocusOn) {
+		if err := s.verifyPIN(pin); err != nil {
+			return "", err
+		}
+	}
+
+	override := config.DeviceFocus{Mode: mode, Until: focus.Until}
+	s.focusMutex.Lock()
+	overrides := maps.Clone(s.deviceFocus)
+	if overrides == nil {
+		overrides = make(map[string]config.DeviceFocus)
+	}
+	if mode == config.DeviceFocusFollow {
+		delete(overrides, device)
+	} else {
+		overrides[device] = override
+	}
+	s.deviceFocus = overrides
+	s.focusMutex.Unlock()
+
+	if s.stateManager != nil {
+		if err := s.stateManager.SetDeviceFocus(device, override); err != nil {
+			logger.Warn("Failed to save device focus", "device", device, "error", err)
+		}
+	}
+	logger.Info("Device focus changed", "device", device, "mode", mode)
+	return device, nil
+}
+
+// deviceKey turns a device given as an address, or by its name in client_names, into the
+// ID its queries carry
+func (s *Server) deviceKey(device string) string {
+	device = strings.TrimSpace(device)
+	if key, ok := config.ClientAddressKey(device); ok {
+		return key
+	}
+	s.settingsMutex.RLock()
+	namer := s.clientNames
+	s.settingsMutex.RUnlock()
+	if namer != nil {
+		for addr, name := range namer.names {
+			if strings.EqualFold(name, device) {
+				return addr
+			}
+		}
+	}
+	return device
+}
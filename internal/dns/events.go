@@ -0,0 +1,68 @@
+package dns
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/events"
+)
+
+// maxChangedEntries bounds the entries an allowlist_changed event names
+const maxChangedEntries = 20
+
+// SetEvents publishes an allowlist_changed event on bus whenever the resolver loads an
+// allowlist with other entries than before, e.g. after an edit, a profile switch, or a break
+func (s *Server) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// publishAllowlistChange compares the allowlist entries just loaded with the previous ones
+// and publishes what changed. The first load at startup is only recorded.
+func (s *Server) publishAllowlistChange(patterns []string) {
+	if !s.events.Wants(events.AllowlistChanged) {
+		return
+	}
+	entries := make(map[string]bool, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = strings.TrimSpace(pattern); pattern != "" && !strings.HasPrefix(pattern, "#") {
+			entries[pattern] = true
+		}
+	}
+
+	s.eventsMutex.Lock()
+	previous := s.allowEntries
+	s.allowEntries = entries
+	s.eventsMutex.Unlock()
+	if previous == nil {
+		return
+	}
+
+	var added, removed []string
+	for entry := range entries {
+		if !previous[entry] {
+			added = append(added, entry)
+		}
+	}
+	for entry := range previous {
+		if !entries[entry] {
+			removed = append(removed, entry)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	s.events.Publish(events.AllowlistChanged, map[string]string{
+		"added":           strconv.Itoa(len(added)),
+		"removed":         strconv.Itoa(len(removed)),
+		"entries":         strconv.Itoa(len(entries)),
+		"added_domains":   joinEntries(added),
+		"removed_domains": joinEntries(removed),
+	})
+}
+
+// joinEntries lists the first maxChangedEntries entries in order, separated by commas
+func joinEntries(entries []string) string {
+	slices.Sort(entries)
+	return strings.Join(entries[:min(len(entries), maxChangedEntries)], ",")
+}
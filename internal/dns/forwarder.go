@@ -0,0 +1,256 @@
+package dns
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/miekg/dns"
+)
+
+const (
+	// dohMediaType is the content type of DNS messages sent over HTTPS (RFC 8484)
+	dohMediaType = "application/dns-message"
+
+	// maxIdleConns is how many idle TCP or TLS connections are kept per upstream
+	maxIdleConns = 4
+	// idleConnTimeout is how long an idle connection is kept; servers close them soon after
+	// (RFC 7766 suggests some seconds), so older ones likely fail
+	idleConnTimeout = 10 * time.Second
+	// keepAlive is the TCP keepalive period of upstream connections
+	keepAlive = 15 * time.Second
+)
+
+// Forwarder sends queries to upstream nameservers over their protocol. Clients are created
+// once per upstream; TCP and TLS connections are kept open between queries, and DNS over
+// HTTPS reuses connections through its HTTP transport. Plain UDP queries each use a new
+// socket, so every query gets a random source port.
+type Forwarder struct {
+	timeout   time.Duration
+	dialer    *net.Dialer
+	http      *http.Client
+	transport *http.Transport
+
+	mu    sync.Mutex
+	pools map[config.Upstream]*connPool
+}
+
+// NewForwarder creates a forwarder for the given upstreams. Host names in tls:// and https://
+// upstreams are looked up through the plain upstreams when there are any, so the lookup
+// doesn't loop back into this resolver.
+func NewForwarder(upstreams []config.Upstream, timeout time.Duration) *Forwarder {
+	dialer := &net.Dialer{Timeout: timeout, KeepAlive: keepAlive, Resolver: PlainResolver(upstreams)}
+	transport := &http.Transport{
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     &tls.Config{MinVersion: tls.VersionTLS12},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConnsPerHost: maxIdleConns,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &Forwarder{
+		timeout:   timeout,
+		dialer:    dialer,
+		http:      &http.Client{Timeout: timeout, Transport: transport},
+		transport: transport,
+		pools:     make(map[config.Upstream]*connPool),
+	}
+}
+
+// PlainResolver returns a resolver that sends queries straight to the first plain UDP or
+// TCP upstream, bypassing the system resolver (which may be sinkzone itself), or nil if
+// there is no plain upstream
+func PlainResolver(upstreams []config.Upstream) *net.Resolver {
+	for _, upstream := range upstreams {
+		if upstream.Protocol != config.UpstreamUDP && upstream.Protocol != config.UpstreamTCP {
+			continue
+		}
+		address, network := upstream.Address, upstream.Protocol
+		return &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return nil
+}
+
+// Exchange sends r to the upstream and returns its answer and the round-trip time
+func (f *Forwarder) Exchange(r *dns.Msg, upstream config.Upstream) (*dns.Msg, time.Duration, error) {
+	if upstream.Protocol == config.UpstreamHTTPS {
+		return f.exchangeHTTPS(r, upstream)
+	}
+	return f.pool(upstream).exchange(r)
+}
+
+// Close closes the idle connections; exchanges still running close theirs when done
+func (f *Forwarder) Close() {
+	f.mu.Lock()
+	pools := f.pools
+	f.pools = make(map[config.Upstream]*connPool)
+	f.mu.Unlock()
+	for _, pool := range pools {
+		pool.close()
+	}
+	f.transport.CloseIdleConnections()
+}
+
+// pool returns the client and idle connections of an upstream, creating them on first use
+func (f *Forwarder) pool(upstream config.Upstream) *connPool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pool, ok := f.pools[upstream]; ok {
+		return pool
+	}
+
+	client := &dns.Client{Net: upstream.Protocol, Timeout: f.timeout, Dialer: f.dialer}
+	if upstream.Protocol == config.UpstreamTLS {
+		client.Net = "tcp-tls"
+		client.TLSConfig = &tls.Config{
+			ServerName:         upstream.ServerName,
+			MinVersion:         tls.VersionTLS12,
+			ClientSessionCache: tls.NewLRUClientSessionCache(0), // Resumes sessions on new connections
+		}
+	}
+	pool := &connPool{client: client, address: upstream.Address, reuse: upstream.Protocol != config.UpstreamUDP}
+	f.pools[upstream] = pool
+	return pool
+}
+
+// connPool keeps the client of one upstream and, for TCP and TLS, its idle connections
+type connPool struct {
+	client  *dns.Client
+	address string
+	reuse   bool // Whether connections are kept between queries
+
+	mu     sync.Mutex
+	idle   []idleConn // Most recently used last
+	closed bool
+}
+
+type idleConn struct {
+	conn  *dns.Conn
+	since time.Time
+}
+
+// exchange sends r over an idle connection, or a new one. The upstream may have closed an
+// idle connection without us noticing, so a failure on one is retried on a new connection.
+func (p *connPool) exchange(r *dns.Msg) (*dns.Msg, time.Duration, error) {
+	if !p.reuse {
+		return p.client.Exchange(r, p.address)
+	}
+	if conn := p.get(); conn != nil {
+		if response, rtt, err := p.client.ExchangeWithConn(r, conn); err == nil {
+			p.put(conn)
+			return response, rtt, nil
+		}
+		closeConn(conn)
+	}
+
+	conn, err := p.client.Dial(p.address)
+	if err != nil {
+		return nil, 0, err
+	}
+	response, rtt, err := p.client.ExchangeWithConn(r, conn)
+	if err != nil {
+		closeConn(conn)
+		return nil, 0, err
+	}
+	p.put(conn)
+	return response, rtt, nil
+}
+
+// get takes the most recently used idle connection, dropping those idle too long
+func (p *connPool) get() *dns.Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for len(p.idle) > 0 {
+		last := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if time.Since(last.since) < idleConnTimeout {
+			return last.conn
+		}
+		closeConn(last.conn)
+	}
+	return nil
+}
+
+// put keeps a connection for the next query, closing it when enough are kept already
+func (p *connPool) put(conn *dns.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || len(p.idle) >= maxIdleConns {
+		closeConn(conn)
+		return
+	}
+	p.idle = append(p.idle, idleConn{conn: conn, since: time.Now()})
+}
+
+// close closes the idle connections and those returned later
+func (p *connPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, idle := range p.idle {
+		closeConn(idle.conn)
+	}
+	p.idle = nil
+}
+
+func closeConn(conn *dns.Conn) {
+	if err := conn.Close(); err != nil {
+		logger.Debug("Failed to close upstream connection", "error", err)
+	}
+}
+
+// exchangeHTTPS posts the query to a DNS over HTTPS endpoint
+func (f *Forwarder) exchangeHTTPS(r *dns.Msg, upstream config.Upstream) (*dns.Msg, time.Duration, error) {
+	// The ID is 0 on the wire, as the HTTP exchange already matches answers to queries
+	query := r.Copy()
+	query.Id = 0
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to pack query: %w", err)
+	}
+
+	start := time.Now()
+	req, err := http.NewRequest(http.MethodPost, upstream.URL, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+
+	resp, err := f.http.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			logger.Warn("Failed to close response body", "error", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("upstream returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read answer: %w", err)
+	}
+	rtt := time.Since(start)
+
+	response := new(dns.Msg)
+	if err := response.Unpack(body); err != nil {
+		return nil, 0, fmt.Errorf("failed to unpack answer: %w", err)
+	}
+	response.Id = r.Id
+	return response, rtt, nil
+}
@@ -0,0 +1,126 @@
+package dns
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/miekg/dns"
+)
+
+func TestExchangeHTTPS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != dohMediaType {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(req.Body)
+		query := new(dns.Msg)
+		if err := query.Unpack(body); err != nil || query.Id != 0 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		answer := new(dns.Msg)
+		answer.SetReply(query)
+		answer.Answer = append(answer.Answer, &dns.A{
+			Hdr: dns.RR_Header{Name: query.Question[0].Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+			A:   []byte{192, 0, 2, 1},
+		})
+		packed, _ := answer.Pack()
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write(packed)
+	}))
+	defer ts.Close()
+
+	upstream, err := config.ParseUpstream(ts.URL + "/dns-query")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := NewForwarder([]config.Upstream{upstream}, time.Second)
+	f.http = ts.Client()
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.com.", dns.TypeA)
+	response, _, err := f.Exchange(r, upstream)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.Id != r.Id || len(response.Answer) != 1 {
+		t.Errorf("unexpected answer: %v", response)
+	}
+}
+
+// countingListener counts accepted connections and can close them all
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+	mu       sync.Mutex
+	conns    []net.Conn
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+		l.mu.Lock()
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+	}
+	return conn, err
+}
+
+func (l *countingListener) closeConns() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.conns {
+		_ = conn.Close()
+	}
+}
+
+func TestExchangeReusesTCPConnections(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &countingListener{Listener: inner}
+	server := &dns.Server{Listener: listener, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		answer := new(dns.Msg)
+		answer.SetReply(r)
+		_ = w.WriteMsg(answer)
+	})}
+	go func() { _ = server.ActivateAndServe() }()
+	defer func() { _ = server.Shutdown() }()
+
+	upstream, err := config.ParseUpstream("tcp://" + inner.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := NewForwarder([]config.Upstream{upstream}, time.Second)
+	defer f.Close()
+
+	r := new(dns.Msg)
+	r.SetQuestion("example.com.", dns.TypeA)
+	for i := 0; i < 3; i++ {
+		if _, _, err := f.Exchange(r, upstream); err != nil {
+			t.Fatalf("query %d failed: %v", i+1, err)
+		}
+	}
+	if accepted := listener.accepted.Load(); accepted != 1 {
+		t.Errorf("expected the queries to share one connection, got %d", accepted)
+	}
+
+	// A connection the upstream closed while idle is replaced without failing the query
+	listener.closeConns()
+	if _, _, err := f.Exchange(r, upstream); err != nil {
+		t.Fatalf("expected a query after the upstream closed the connection to succeed: %v", err)
+	}
+	if accepted := listener.accepted.Load(); accepted != 2 {
+		t.Errorf("expected a second connection, got %d", accepted)
+	}
+}
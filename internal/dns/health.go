@@ -0,0 +1,197 @@
+package dns
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+const (
+	// slowUpstreamLatency marks an answering upstream as degraded
+	slowUpstreamLatency = time.Second
+
+	// downUpstreamFailures is how many failures in a row mark an upstream as down
+	downUpstreamFailures = 3
+
+	// latencySamples is how many successful exchanges the rolling latency covers
+	latencySamples = 100
+)
+
+// upstreamState tracks the exchanges with one upstream nameserver
+type upstreamState struct {
+	failures    int // In a row
+	latency     time.Duration
+	lastSuccess time.Time
+	lastFailure time.Time
+	lastError   string
+
+	// Totals since the resolver started
+	successes     uint64
+	totalFailures uint64
+	timeouts      uint64
+
+	// Ring buffer of the latest latencies, oldest at next once full
+	samples []time.Duration
+	next    int
+}
+
+// addSample adds a latency to the rolling window
+func (state *upstreamState) addSample(latency time.Duration) {
+	if len(state.samples) < latencySamples {
+		state.samples = append(state.samples, latency)
+		return
+	}
+	state.samples[state.next] = latency
+	state.next = (state.next + 1) % latencySamples
+}
+
+// rollingLatency returns the average and 95th percentile of the latest latencies
+func (state *upstreamState) rollingLatency() (average, p95 time.Duration) {
+	if len(state.samples) == 0 {
+		return 0, 0
+	}
+	sorted := slices.Clone(state.samples)
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, sample := range sorted {
+		sum += sample
+	}
+	rank := (len(sorted)*95 + 99) / 100
+	return sum / time.Duration(len(sorted)), sorted[rank-1]
+}
+
+// recordUpstream remembers the outcome of an exchange with an upstream
+func (s *Server) recordUpstream(upstream string, latency time.Duration, err error) {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+
+	if s.upstreams == nil {
+		s.upstreams = make(map[string]*upstreamState)
+	}
+	state, ok := s.upstreams[upstream]
+	if !ok {
+		state = &upstreamState{}
+		s.upstreams[upstream] = state
+	}
+
+	now := time.Now()
+	if err != nil {
+		upstreamErrorsTotal.With(upstream).Inc()
+		state.failures++
+		state.totalFailures++
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			state.timeouts++
+		}
+		state.lastFailure = now
+		state.lastError = err.Error()
+		return
+	}
+	upstreamDuration.With(upstream).ObserveDuration(latency)
+	state.failures = 0
+	state.successes++
+	state.latency = latency
+	state.addSample(latency)
+	state.lastSuccess = now
+	s.settingsMutex.RLock()
+	fastest := s.upstreamStrategy == config.UpstreamFastest
+	s.settingsMutex.RUnlock()
+	if fastest {
+		s.recordLatency(upstream, latency)
+	}
+}
+
+// resolverHealth reports whether the DNS port is bound and how the configured upstreams answer
+func (s *Server) resolverHealth() api.ResolverHealth {
+	health := api.ResolverHealth{
+		DNSListening: s.listening.Load(),
+		DNSPort:      s.port,
+		DNSCheck:     s.bypass.report(),
+		Portal:       s.portal.report(),
+	}
+
+	configured := s.upstreamAddresses()
+
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+
+	for _, upstream := range configured {
+		entry := api.UpstreamHealth{Address: upstream, Status: api.HealthUnknown}
+		if state, ok := s.upstreams[upstream]; ok {
+			entry = upstreamHealth(upstream, state)
+		}
+		health.Upstreams = append(health.Upstreams, entry)
+	}
+	health.UpstreamStatus = summarizeUpstreams(health.Upstreams)
+	return health
+}
+
+func upstreamHealth(upstream string, state *upstreamState) api.UpstreamHealth {
+	average, p95 := state.rollingLatency()
+	entry := api.UpstreamHealth{
+		Address:             upstream,
+		ConsecutiveFailures: state.failures,
+		LatencyMS:           state.latency.Milliseconds(),
+		LastError:           state.lastError,
+		Successes:           state.successes,
+		Failures:            state.totalFailures,
+		Timeouts:            state.timeouts,
+		AverageMS:           durationMS(average),
+		P95MS:               durationMS(p95),
+	}
+	if !state.lastSuccess.IsZero() {
+		lastSuccess := state.lastSuccess
+		entry.LastSuccess = &lastSuccess
+	}
+	if !state.lastFailure.IsZero() {
+		lastFailure := state.lastFailure
+		entry.LastFailure = &lastFailure
+	}
+
+	switch {
+	case state.failures >= downUpstreamFailures:
+		entry.Status = api.HealthDown
+	case state.failures > 0, state.latency > slowUpstreamLatency:
+		entry.Status = api.HealthDegraded
+	default:
+		entry.Status = api.HealthOK
+	}
+	return entry
+}
+
+// durationMS converts a duration to fractional milliseconds
+func durationMS(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// summarizeUpstreams rates the upstreams as a whole: ok when every queried upstream is ok,
+// down when none of them answers, and degraded in between
+func summarizeUpstreams(upstreams []api.UpstreamHealth) string {
+	queried, ok, down := 0, 0, 0
+	for _, upstream := range upstreams {
+		switch upstream.Status {
+		case api.HealthUnknown:
+			continue
+		case api.HealthOK:
+			ok++
+		case api.HealthDown:
+			down++
+		}
+		queried++
+	}
+
+	switch {
+	case queried == 0:
+		return api.HealthUnknown
+	case ok == queried:
+		return api.HealthOK
+	case down == queried:
+		return api.HealthDown
+	default:
+		return api.HealthDegraded
+	}
+}
@@ -0,0 +1,74 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestResolverHealth(t *testing.T) {
+	s := &Server{port: "53"}
+	s.ApplyConfig(&config.Config{UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1", "9.9.9.9"}})
+
+	if status := s.resolverHealth().UpstreamStatus; status != api.HealthUnknown {
+		t.Errorf("expected %s before any query, got %s", api.HealthUnknown, status)
+	}
+
+	s.recordUpstream("8.8.8.8:53", 20*time.Millisecond, nil)
+	health := s.resolverHealth()
+	if health.UpstreamStatus != api.HealthOK {
+		t.Errorf("expected %s with one answering upstream, got %s", api.HealthOK, health.UpstreamStatus)
+	}
+	if health.DNSListening {
+		t.Error("expected the DNS port to be reported as not bound")
+	}
+
+	for i := 0; i < downUpstreamFailures; i++ {
+		s.recordUpstream("8.8.8.8:53", 0, errors.New("timeout"))
+	}
+	s.recordUpstream("1.1.1.1:53", 30*time.Millisecond, nil)
+	health = s.resolverHealth()
+	if health.UpstreamStatus != api.HealthDegraded {
+		t.Errorf("expected %s with one upstream down, got %s", api.HealthDegraded, health.UpstreamStatus)
+	}
+	if health.Upstreams[0].Status != api.HealthDown || health.Upstreams[0].LastError != "timeout" {
+		t.Errorf("unexpected health for the failing upstream: %+v", health.Upstreams[0])
+	}
+	if health.Upstreams[2].Status != api.HealthUnknown {
+		t.Errorf("expected the unqueried upstream to be %s, got %s", api.HealthUnknown, health.Upstreams[2].Status)
+	}
+
+	for i := 0; i < downUpstreamFailures; i++ {
+		s.recordUpstream("1.1.1.1:53", 0, errors.New("timeout"))
+	}
+	if status := s.resolverHealth().UpstreamStatus; status != api.HealthDown {
+		t.Errorf("expected %s when no upstream answers, got %s", api.HealthDown, status)
+	}
+}
+
+func TestUpstreamStats(t *testing.T) {
+	s := &Server{port: "53"}
+	s.ApplyConfig(&config.Config{UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1"}})
+
+	for i := 1; i <= latencySamples+20; i++ {
+		s.recordUpstream("1.1.1.1:53", time.Duration(i)*time.Millisecond, nil)
+	}
+	s.recordUpstream("8.8.8.8:53", 10*time.Millisecond, nil)
+	s.recordUpstream("8.8.8.8:53", 0, fmt.Errorf("read udp: %w", os.ErrDeadlineExceeded))
+	s.recordUpstream("8.8.8.8:53", 0, errors.New("connection refused"))
+
+	upstreams := s.resolverHealth().Upstreams
+	google, cloudflare := upstreams[0], upstreams[1]
+	if google.Successes != 1 || google.Failures != 2 || google.Timeouts != 1 {
+		t.Errorf("unexpected counts for the timing out upstream: %+v", google)
+	}
+	// The rolling window only holds the latest 100 latencies: 21ms to 120ms
+	if cloudflare.Successes != latencySamples+20 || cloudflare.AverageMS != 70.5 || cloudflare.P95MS != 115 {
+		t.Errorf("unexpected stats for the answering upstream: %+v", cloudflare)
+	}
+}
@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"errors"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// checkIntegrity reports whether a file still holds what sinkzone last wrote to it,
+// warning when it was changed by something else
+func checkIntegrity(path, name string) bool {
+	err := config.VerifyChecksum(path)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, config.ErrNoChecksum), errors.Is(err, config.ErrChecksumMismatch):
+		logger.Warn(name+" was changed outside sinkzone", "path", path, "reason", err)
+		integrityFailuresTotal.Inc()
+		return false
+	default:
+		logger.Warn("Failed to check "+name, "error", err)
+		return true
+	}
+}
+
+// strictSession reports whether the running focus session is hard to end: hard intensity,
+// or locked with the focus PIN
+func (s *Server) strictSession() bool {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	return s.focusMode && (s.focusIntensity == config.IntensityHard || s.config.FocusPINHash != "")
+}
+
+// trustedAllowlist returns the entries of allowlist.txt to use. A file changed outside
+// sinkzone is used anyway, unless file_integrity is enforce and a strict session is
+// running: then the entries it had when it last passed the check are kept.
+func (s *Server) trustedAllowlist(patterns []string) []string {
+	intact := checkIntegrity(s.allowlistPath, "allowlist.txt")
+	mode, _ := s.config.GetFileIntegrity()
+	strict := s.strictSession()
+
+	s.allowlistMutex.Lock()
+	defer s.allowlistMutex.Unlock()
+	if intact {
+		s.verifiedAllowlist = patterns
+		return patterns
+	}
+	if mode != config.IntegrityEnforce || !strict {
+		return patterns
+	}
+	logger.Error("Ignoring changes to allowlist.txt made outside sinkzone during a strict focus session", "kept", len(s.verifiedAllowlist))
+	return s.verifiedAllowlist
+}
@@ -0,0 +1,45 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestShouldBlock(t *testing.T) {
+	s := &Server{}
+	s.allowlist, s.wildcardPatterns = compilePatterns([]string{"github.com", "*.google.com"})
+	s.denylist, s.denyPatterns = compilePatterns([]string{"*.reddit.com"})
+	s.subscribedDenylist, s.subscribedDenyPatterns = compilePatterns([]string{"github.com", "ads.example.com"})
+	s.localAllowlist, s.localAllowPatterns = s.allowlist, s.wildcardPatterns
+
+	tests := []struct {
+		domain     string
+		intensity  string
+		seenBefore bool
+		blocked    bool
+	}{
+		{"example.com", config.IntensitySoft, false, false},
+		{"www.reddit.com", config.IntensitySoft, true, true},
+		{"example.com", config.IntensityNormal, true, true},
+		{"github.com", config.IntensityNormal, false, false},
+		{"mail.google.com", config.IntensityNormal, false, false},
+		{"mail.google.com", config.IntensityHard, true, false},
+		{"new.google.com", config.IntensityHard, false, true},
+		{"github.com", config.IntensityHard, false, false},
+		// The local allowlist overrides subscribed blocklists
+		{"github.com", config.IntensitySoft, false, false},
+		{"ads.example.com", config.IntensitySoft, false, true},
+		// The blocklist ignores case and a trailing dot
+		{"WWW.Reddit.com", config.IntensitySoft, false, true},
+		{"ADS.example.com.", config.IntensitySoft, false, true},
+	}
+
+	for _, test := range tests {
+		blocked := s.shouldBlock(test.domain, test.intensity, test.seenBefore)
+		if blocked != test.blocked {
+			t.Errorf("%s at %s intensity (seen before: %v): expected blocked=%v, got %v",
+				test.domain, test.intensity, test.seenBefore, test.blocked, blocked)
+		}
+	}
+}
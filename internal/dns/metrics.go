@@ -0,0 +1,79 @@
+package dns
+
+import (
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/clock"
+	"github.com/berbyte/sinkzone/internal/metrics"
+	"github.com/miekg/dns"
+)
+
+// Metrics of the DNS server, served on GET /metrics
+var (
+	queriesTotal           = metrics.NewCounter("sinkzone_dns_queries_total", "DNS queries received")
+	blockedTotal           = metrics.NewCounter("sinkzone_dns_queries_blocked_total", "Queries answered with the block response during focus mode")
+	wouldBlockTotal        = metrics.NewCounter("sinkzone_dns_queries_would_block_total", "Queries focus mode would have blocked during a grace period or dry run")
+	rateLimitedTotal       = metrics.NewCounter("sinkzone_dns_queries_rate_limited_total", "Queries refused because the client was over rate_limit")
+	cooldownRefusedTotal   = metrics.NewCounter("sinkzone_dns_queries_cooldown_total", "Queries refused because the client was on cooldown")
+	cooldownsTotal         = metrics.NewCounter("sinkzone_dns_cooldowns_total", "Clients put on cooldown after cooldown.strikes strikes")
+	integrityFailuresTotal = metrics.NewCounter("sinkzone_integrity_failures_total", "Loads of allowlist.txt or state.json that had been changed outside sinkzone")
+	shedTotal              = metrics.NewCounter("sinkzone_dns_queries_shed_total", "Queries refused because concurrency.max_queries were being handled and the queue was full")
+	ignoredTotal           = metrics.NewCounter("sinkzone_dns_queries_ignored_total", "Queries for ignore_domains, answered but not recorded")
+	forwardedTotal         = metrics.NewCounter("sinkzone_dns_queries_forwarded_total", "Queries sent to upstream nameservers")
+	cacheHitsTotal         = metrics.NewCounter("sinkzone_dns_cache_hits_total", "Queries answered from the cache")
+	cacheMissesTotal       = metrics.NewCounter("sinkzone_dns_cache_misses_total", "Queries the cache had no answer to, while the cache is on")
+	cacheEvictionsTotal    = metrics.NewCounter("sinkzone_dns_cache_evictions_total", "Cached answers dropped to make room before they expired")
+	cacheStaleTotal        = metrics.NewCounter("sinkzone_dns_cache_stale_answers_total", "Expired answers served from the cache because every upstream failed")
+	responsesTotal         = metrics.NewCounterVec("sinkzone_dns_responses_total", "Responses sent, by response code", "rcode")
+	requestDuration        = metrics.NewHistogram("sinkzone_dns_request_duration_seconds", "Time from receiving a query to answering it", metrics.DefaultBuckets)
+
+	upstreamErrorsTotal = metrics.NewCounterVec("sinkzone_dns_upstream_errors_total", "Failed exchanges with an upstream nameserver", "upstream")
+	upstreamDuration    = metrics.NewHistogramVec("sinkzone_dns_upstream_duration_seconds", "Round trip time of successful exchanges with an upstream nameserver", metrics.DefaultBuckets, "upstream")
+)
+
+// observeResponse counts a response sent to a query received at start
+func observeResponse(rcode int, start time.Time) {
+	responsesTotal.With(dns.RcodeToString[rcode]).Inc()
+	requestDuration.ObserveDuration(time.Since(start))
+}
+
+// registerCacheMetrics exposes the number of answers in the cache of s
+func (s *Server) registerCacheMetrics() {
+	metrics.NewGaugeFunc("sinkzone_dns_cache_entries", "Answers in the cache", func() float64 {
+		s.settingsMutex.RLock()
+		cache := s.cache
+		s.settingsMutex.RUnlock()
+		return float64(cache.len())
+	})
+}
+
+// registerConcurrencyMetrics exposes the queries s is handling and those waiting for a turn
+func (s *Server) registerConcurrencyMetrics() {
+	limiter := func() *queryLimiter {
+		s.settingsMutex.RLock()
+		defer s.settingsMutex.RUnlock()
+		return s.queryLimiter
+	}
+	metrics.NewGaugeFunc("sinkzone_dns_queries_in_flight", "Queries being handled", func() float64 {
+		return float64(limiter().inFlight())
+	})
+	metrics.NewGaugeFunc("sinkzone_dns_queue_depth", "Queries waiting for a turn while concurrency.max_queries are handled", func() float64 {
+		return float64(limiter().queueDepth())
+	})
+}
+
+// registerFocusMetrics exposes the focus state of s, replacing that of an earlier server
+func (s *Server) registerFocusMetrics() {
+	metrics.NewGaugeFunc("sinkzone_focus_mode_active", "1 while focus mode blocks queries, 0 otherwise (including pauses)", func() float64 {
+		s.focusMutex.RLock()
+		defer s.focusMutex.RUnlock()
+		active := s.focusMode && (s.focusPausedUntil == nil || !clock.Now().Before(*s.focusPausedUntil))
+		if active && s.focusEndTime != nil && clock.Now().After(*s.focusEndTime) {
+			active = false
+		}
+		if active {
+			return 1
+		}
+		return 0
+	})
+}
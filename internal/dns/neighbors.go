@@ -0,0 +1,61 @@
+package dns
+
+import (
+	"net"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// NeighborMAC returns the MAC address of a device on the local network, looked up by its IP
+// address in the system's neighbor table, or "" if the table has none for it
+func NeighborMAC(ip string) string {
+	key, ok := config.ClientAddressKey(ip)
+	if !ok {
+		return ""
+	}
+	return readNeighbors()[key]
+}
+
+// parseARP maps the IP addresses in the output of arp -a to their MAC addresses, whether
+// written as on macOS and the BSDs ("? (192.168.1.23) at 0:1c:42:a:b:c on en0") or as on
+// Windows ("192.168.1.23  00-1c-42-0a-0b-0c  dynamic")
+func parseARP(output string) map[string]string {
+	neighbors := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		var ip, mac string
+		for _, field := range strings.Fields(line) {
+			if ip == "" {
+				if parsed := net.ParseIP(strings.Trim(field, "()")); parsed != nil {
+					ip = parsed.String()
+				}
+			} else if hw, ok := parseARPMAC(field); ok {
+				mac = hw
+				break
+			}
+		}
+		if ip != "" && mac != "" {
+			neighbors[ip] = mac
+		}
+	}
+	return neighbors
+}
+
+// parseARPMAC parses a unicast MAC address as arp prints it, where macOS drops leading
+// zeros ("0:1c:42:a:b:c")
+func parseARPMAC(field string) (string, bool) {
+	parts := strings.FieldsFunc(field, func(r rune) bool { return r == ':' || r == '-' })
+	if len(parts) != 6 {
+		return "", false
+	}
+	for i, part := range parts {
+		if len(part) == 1 {
+			parts[i] = "0" + part
+		}
+	}
+	mac, err := net.ParseMAC(strings.Join(parts, ":"))
+	if err != nil || mac[0]&1 == 1 {
+		return "", false // Not a MAC address, or a broadcast or multicast one
+	}
+	return mac.String(), true
+}
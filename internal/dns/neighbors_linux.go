@@ -0,0 +1,35 @@
+package dns
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// readNeighbors maps the IPv4 addresses in the kernel's ARP table to their MAC addresses
+func readNeighbors() map[string]string {
+	neighbors := make(map[string]string)
+	f, err := os.Open("/proc/net/arp")
+	if err != nil {
+		return neighbors
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// IP address, HW type, Flags, HW address, Mask, Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] == "0x0" {
+			continue // Incomplete entry
+		}
+		ip, ok := config.ClientAddressKey(fields[0])
+		mac, macOK := config.ClientAddressKey(fields[3])
+		if ok && macOK {
+			neighbors[ip] = mac
+		}
+	}
+	return neighbors
+}
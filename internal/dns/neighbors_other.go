@@ -0,0 +1,26 @@
+//go:build !linux
+
+package dns
+
+import (
+	"context"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// readNeighbors maps neighbors' IP addresses to their MAC addresses, as listed by arp -a
+func readNeighbors() map[string]string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	args := []string{"-an"}
+	if runtime.GOOS == "windows" {
+		args = []string{"-a"}
+	}
+	// #nosec G204 -- fixed command and arguments
+	output, err := exec.CommandContext(ctx, "arp", args...).Output()
+	if err != nil {
+		return nil
+	}
+	return parseARP(string(output))
+}
@@ -0,0 +1,28 @@
+package dns
+
+import "testing"
+
+func TestParseARP(t *testing.T) {
+	output := `? (192.168.1.1) at 0:1c:42:a:b:c on en0 ifscope [ethernet]
+? (192.168.1.23) at (incomplete) on en0 ifscope [ethernet]
+? (192.168.1.255) at ff:ff:ff:ff:ff:ff on en0 ifscope [ethernet]
+
+Interface: 192.168.1.5 --- 0x4
+  Internet Address      Physical Address      Type
+  192.168.1.40          a4-83-e7-12-34-56     dynamic
+  224.0.0.22            01-00-5e-00-00-16     static
+`
+	neighbors := parseARP(output)
+	want := map[string]string{
+		"192.168.1.1":  "00:1c:42:0a:0b:0c",
+		"192.168.1.40": "a4:83:e7:12:34:56",
+	}
+	if len(neighbors) != len(want) {
+		t.Errorf("expected %v, got %v", want, neighbors)
+	}
+	for ip, mac := range want {
+		if neighbors[ip] != mac {
+			t.Errorf("expected %s at %s, got %q", ip, mac, neighbors[ip])
+		}
+	}
+}
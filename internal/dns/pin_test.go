@@ -0,0 +1,76 @@
+package dns
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestLoosensFocusMode(t *testing.T) {
+	end := time.Now().Add(time.Hour)
+	s := &Server{focusMode: true, focusEndTime: &end, focusProfile: "writing", focusIntensity: config.IntensityNormal}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		opts    api.FocusOptions
+		loosens bool
+	}{
+		{"disable", false, api.FocusOptions{}, true},
+		{"shorten", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensityNormal, Duration: 10 * time.Minute}, true},
+		{"lengthen", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensityNormal, Duration: 2 * time.Hour}, false},
+		{"until stopped", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensityNormal}, false},
+		{"other profile", true, api.FocusOptions{Profile: "coding", Intensity: config.IntensityNormal, Duration: 2 * time.Hour}, true},
+		{"softer", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensitySoft, Duration: 2 * time.Hour}, true},
+		{"harder", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensityHard, Duration: 2 * time.Hour}, false},
+		{"dry run", true, api.FocusOptions{Profile: "writing", Intensity: config.IntensityNormal, Duration: 2 * time.Hour, DryRun: true}, true},
+	}
+
+	for _, test := range tests {
+		if got := s.loosensFocusMode(test.enabled, &test.opts); got != test.loosens {
+			t.Errorf("%s: expected loosens=%v, got %v", test.name, test.loosens, got)
+		}
+	}
+
+	// Nothing is loosened once the session has ended
+	s.focusEndTime = &time.Time{}
+	if s.loosensFocusMode(false, &api.FocusOptions{}) {
+		t.Error("expected ending an expired session not to need the PIN")
+	}
+}
+
+func TestVerifyPINLockout(t *testing.T) {
+	hash, err := config.HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{config: &config.Config{FocusPINHash: hash}}
+
+	if err := s.verifyPIN(""); !errors.Is(err, api.ErrPINRequired) {
+		t.Errorf("expected a missing PIN to be required, got %v", err)
+	}
+	for range maxPINFailures {
+		if err := s.verifyPIN("9999"); !errors.Is(err, api.ErrInvalidPIN) {
+			t.Fatalf("expected a wrong PIN to be rejected, got %v", err)
+		}
+	}
+	if err := s.verifyPIN("1234"); !errors.Is(err, api.ErrInvalidPIN) {
+		t.Errorf("expected the correct PIN to be refused during the lockout, got %v", err)
+	}
+
+	s.pinLockedUntil = time.Now().Add(-time.Second)
+	if err := s.verifyPIN("1234"); err != nil {
+		t.Errorf("expected the correct PIN once the lockout expired, got %v", err)
+	}
+	// A success resets the failures, so one more wrong PIN doesn't lock again
+	if err := s.verifyPIN("9999"); err == nil || s.pinLockedUntil.After(time.Now()) {
+		t.Errorf("expected a single wrong PIN to be rejected without a lockout, got %v", err)
+	}
+
+	if err := (&Server{config: &config.Config{}}).verifyPIN(""); err != nil {
+		t.Errorf("expected no PIN to be needed without one configured, got %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+)
+
+const (
+	// portalWindow is how long answers are counted to spot a storm of failures, as on a
+	// network that fails every query until its login page is filled in
+	portalWindow = time.Minute
+
+	// A window with at least portalMinAnswers answers, portalFailureShare of them failed,
+	// suggests a captive portal
+	portalMinAnswers   = 20
+	portalFailureShare = 0.8
+
+	// portalAutoCooldown is how long after an automatic passthrough window another one may
+	// open by itself, so a network that never lets queries through isn't passed through for good
+	portalAutoCooldown = 30 * time.Minute
+
+	// portalCheckInterval is how often the end of the passthrough window is checked
+	portalCheckInterval = 5 * time.Second
+)
+
+// connectivityChecks are the names operating systems and browsers resolve to find out
+// whether a network needs a login
+var connectivityChecks = map[string]bool{
+	"captive.apple.com":             true,
+	"connectivitycheck.gstatic.com": true,
+	"connectivitycheck.android.com": true,
+	"clients3.google.com":           true,
+	"detectportal.firefox.com":      true,
+	"www.msftconnecttest.com":       true,
+	"www.msftncsi.com":              true,
+	"dns.msftncsi.com":              true,
+	"nmcheck.gnome.org":             true,
+	"connectivity-check.ubuntu.com": true,
+	"network-test.debian.org":       true,
+}
+
+// portalDetector watches forwarded answers for signs of a captive portal, the login page
+// of a hotel or café network, and holds the passthrough window that lets it load
+type portalDetector struct {
+	mu     sync.Mutex
+	mode   string        // captive_portal.mode
+	length time.Duration // captive_portal.passthrough
+
+	// Answers and failed answers since windowStart
+	windowStart time.Time
+	answers     int
+	failures    int
+
+	suspectedAt *time.Time
+	reason      string
+
+	until      time.Time // End of the passthrough window, zero while none is open
+	nameserver string
+	automatic  bool
+	autoEnded  time.Time // When the last automatic window ended
+}
+
+func newPortalDetector() *portalDetector {
+	return &portalDetector{mode: config.CaptivePortalNotify, length: config.DefaultPassthrough}
+}
+
+// configure changes what is done about a suspected portal and how long a window lasts,
+// forgetting the suspicion when detection is turned off
+func (p *portalDetector) configure(mode string, length time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.mode, p.length = mode, length
+	if mode == config.CaptivePortalOff {
+		p.suspectedAt, p.reason = nil, ""
+	}
+}
+
+// observe records a forwarded answer, failed when the upstreams didn't answer or the name
+// doesn't exist, and reports whether that made a portal suspected or cleared the suspicion
+func (p *portalDetector) observe(domain string, failed bool, now time.Time) (suspected, changed bool) {
+	if p == nil {
+		return false, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Nothing is learned while the window sends queries to the network's nameserver
+	if p.mode == config.CaptivePortalOff || !p.until.IsZero() {
+		return p.suspectedAt != nil, false
+	}
+	if now.Sub(p.windowStart) >= portalWindow {
+		p.windowStart, p.answers, p.failures = now, 0, 0
+	}
+	p.answers++
+	if failed {
+		p.failures++
+	}
+
+	wasSuspected := p.suspectedAt != nil
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	switch {
+	case connectivityChecks[domain] && !failed:
+		p.suspectedAt, p.reason = nil, ""
+	case wasSuspected:
+	case connectivityChecks[domain]:
+		p.suspectedAt, p.reason = &now, fmt.Sprintf("the connectivity check %s failed", domain)
+	case p.answers >= portalMinAnswers && float64(p.failures) >= portalFailureShare*float64(p.answers):
+		p.suspectedAt, p.reason = &now, fmt.Sprintf("%d of the last %d queries failed", p.failures, p.answers)
+	}
+	return p.suspectedAt != nil, wasSuspected != (p.suspectedAt != nil)
+}
+
+// wantsAuto reports whether a suspected portal should get a passthrough window by itself
+func (p *portalDetector) wantsAuto(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.mode == config.CaptivePortalAuto && p.suspectedAt != nil && p.until.IsZero() &&
+		(p.autoEnded.IsZero() || now.Sub(p.autoEnded) >= portalAutoCooldown)
+}
+
+// defaultLength returns how long a window lasts when no length is asked for
+func (p *portalDetector) defaultLength() time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.length
+}
+
+// open starts a passthrough window
+func (p *portalDetector) open(until time.Time, nameserver string, automatic bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.until, p.nameserver, p.automatic = until, nameserver, automatic
+}
+
+// close ends the passthrough window, reporting whether one was open, and starts watching
+// for a portal afresh
+func (p *portalDetector) close(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.until.IsZero() {
+		return false
+	}
+	if p.automatic {
+		p.autoEnded = now
+	}
+	p.until, p.nameserver, p.automatic = time.Time{}, "", false
+	p.suspectedAt, p.reason = nil, ""
+	p.windowStart, p.answers, p.failures = time.Time{}, 0, 0
+	return true
+}
+
+// passthrough reports whether a passthrough window is open at now
+func (p *portalDetector) passthrough(now time.Time) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.until.IsZero() && now.Before(p.until)
+}
+
+// expired reports whether the passthrough window has ended by now
+func (p *portalDetector) expired(now time.Time) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.until.IsZero() && !now.Before(p.until)
+}
+
+// report returns the detector's state as shown in /api/health, nil without a detector
+func (p *portalDetector) report() *api.PortalState {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	state := &api.PortalState{
+		Mode:        p.mode,
+		Suspected:   p.suspectedAt != nil,
+		Reason:      p.reason,
+		SuspectedAt: p.suspectedAt,
+		Nameserver:  p.nameserver,
+		Automatic:   p.automatic,
+	}
+	if !p.until.IsZero() {
+		until := p.until
+		state.PassthroughUntil = &until
+	}
+	return state
+}
+
+// observePortal feeds a forwarded answer to the portal detector, logging a new suspicion
+// and opening a passthrough window in the auto mode
+func (s *Server) observePortal(domain string, failed bool, now time.Time) {
+	suspected, changed := s.portal.observe(domain, failed, now)
+	switch {
+	case !changed:
+		return
+	case !suspected:
+		logger.Info("The network no longer seems to need a login")
+		return
+	}
+
+	reason := s.portal.report().Reason
+	if s.portal.wantsAuto(now) && !s.pinLocked() {
+		logger.Warn("This network seems to need a login (captive portal); letting its login page through", "reason", reason)
+		go func() {
+			if _, err := s.openPassthrough(0, true); err != nil {
+				logger.Warn("Failed to open the passthrough window", "error", err)
+			}
+		}()
+		return
+	}
+	logger.Warn("This network seems to need a login (captive portal), which sinkzone may keep from loading; run 'sinkzone passthrough' to let it through", "reason", reason)
+}
+
+// pinLocked reports whether a focus session is on and protected by the focus PIN, when
+// passthrough windows don't open by themselves
+func (s *Server) pinLocked() bool {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	return s.focusMode && s.config.FocusPINHash != ""
+}
+
+// startPassthrough opens a passthrough window on request, which needs the focus PIN when
+// one is set
+func (s *Server) startPassthrough(duration time.Duration, pin string) (api.PortalState, error) {
+	if err := s.verifyPIN(pin); err != nil {
+		return api.PortalState{}, err
+	}
+	return s.openPassthrough(duration, false)
+}
+
+// openPassthrough stops blocking for duration (captive_portal.passthrough when 0) and sends
+// every query to the network's own nameserver, the default gateway, so a captive portal's
+// login page can load. Without a gateway the configured upstreams are used. The cache is
+// flushed, as the portal may answer names with its own address until the login.
+func (s *Server) openPassthrough(duration time.Duration, automatic bool) (api.PortalState, error) {
+	if duration == 0 {
+		duration = s.portal.defaultLength()
+	}
+	if duration > config.MaxPassthrough {
+		return api.PortalState{}, fmt.Errorf("%w: %s is longer than %d minutes", api.ErrInvalidPassthrough, duration, int(config.MaxPassthrough.Minutes()))
+	}
+
+	var upstreams []config.Upstream
+	gateway, err := sysdns.DefaultGateway()
+	if err != nil {
+		logger.Warn("Failed to find the default gateway, using the configured nameservers", "error", err)
+	} else if gateway != "" && !sysdns.IsLocal(gateway) {
+		if upstream, err := config.ParseUpstream(gateway); err == nil {
+			upstreams = []config.Upstream{upstream}
+		}
+	}
+
+	s.settingsMutex.Lock()
+	if s.portalForwarder != nil {
+		s.portalForwarder.Close()
+		s.portalForwarder = nil
+	}
+	if len(upstreams) > 0 {
+		s.portalForwarder = NewForwarder(upstreams, upstreamTimeout)
+	}
+	s.portalUpstreams = upstreams
+	cache := s.cache
+	s.settingsMutex.Unlock()
+
+	until := time.Now().Add(duration)
+	s.portal.open(until, gateway, automatic)
+	if cache != nil {
+		cache.flush()
+	}
+	logger.Info("Passthrough window opened: nothing is blocked", "until", until.Format("15:04:05"), "nameserver", gateway, "automatic", automatic)
+	return *s.portal.report(), nil
+}
+
+// endPassthrough closes the passthrough window, if one is open, and returns the new state
+func (s *Server) endPassthrough() api.PortalState {
+	if s.portal.close(time.Now()) {
+		s.settingsMutex.Lock()
+		if s.portalForwarder != nil {
+			s.portalForwarder.Close()
+			s.portalForwarder = nil
+		}
+		s.portalUpstreams = nil
+		cache := s.cache
+		s.settingsMutex.Unlock()
+
+		if cache != nil {
+			cache.flush()
+		}
+		logger.Info("Passthrough window closed: blocking again")
+	}
+	return *s.portal.report()
+}
+
+// watchPortal closes the passthrough window once it has ended
+func (s *Server) watchPortal() {
+	ticker := time.NewTicker(portalCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if s.portal.expired(time.Now()) {
+			s.endPassthrough()
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package dns
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestPortalDetector(t *testing.T) {
+	portal := newPortalDetector()
+	now := time.Now()
+
+	if suspected, changed := portal.observe("Captive.Apple.com.", true, now); !suspected || !changed {
+		t.Fatal("expected a failed connectivity check to suggest a portal")
+	}
+	if portal.wantsAuto(now) {
+		t.Error("expected the notify mode not to open a window by itself")
+	}
+	if suspected, changed := portal.observe("captive.apple.com", false, now); suspected || !changed {
+		t.Error("expected a passing connectivity check to clear the suspicion")
+	}
+
+	// A storm of failures within a minute, but not a few failures among answers
+	later := now.Add(2 * portalWindow)
+	for i := 0; i < portalMinAnswers-1; i++ {
+		if suspected, _ := portal.observe(fmt.Sprintf("host%d.example.com", i), true, later); suspected {
+			t.Fatalf("expected %d failures not to be enough", i+1)
+		}
+	}
+	if suspected, _ := portal.observe("example.org", true, later); !suspected {
+		t.Fatal("expected a storm of failed queries to suggest a portal")
+	}
+
+	portal.configure(config.CaptivePortalAuto, time.Minute)
+	if !portal.wantsAuto(later) {
+		t.Error("expected the auto mode to open a window")
+	}
+	portal.open(later.Add(time.Minute), "192.168.1.1", true)
+	if !portal.passthrough(later) || portal.expired(later) || !portal.report().Passthrough() {
+		t.Error("expected the window to be open")
+	}
+	if !portal.expired(later.Add(time.Minute)) || !portal.close(later.Add(time.Minute)) {
+		t.Fatal("expected the window to end")
+	}
+	if report := portal.report(); report.Passthrough() || report.Suspected {
+		t.Errorf("expected a fresh start after the window, got %+v", report)
+	}
+
+	// Another automatic window waits for the cooldown
+	portal.observe("connectivitycheck.gstatic.com", true, later.Add(2*time.Minute))
+	if portal.wantsAuto(later.Add(2 * time.Minute)) {
+		t.Error("expected no automatic window right after the last one")
+	}
+	if !portal.wantsAuto(later.Add(time.Minute + portalAutoCooldown)) {
+		t.Error("expected an automatic window once the cooldown has passed")
+	}
+
+	portal.configure(config.CaptivePortalOff, time.Minute)
+	if suspected, _ := portal.observe("captive.apple.com", true, later); suspected {
+		t.Error("expected nothing to be suspected while detection is off")
+	}
+}
@@ -0,0 +1,62 @@
+package dns
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// clientAnonymizer hides client addresses before queries are recorded, after client_privacy
+type clientAnonymizer struct {
+	mode string
+	key  []byte // HMAC key of the hash mode, new at every start so labels can't be reversed later
+}
+
+func newClientAnonymizer(mode string) *clientAnonymizer {
+	a := &clientAnonymizer{mode: mode}
+	if mode == config.ClientPrivacyHash {
+		a.key = make([]byte, 32)
+		_, _ = rand.Read(a.key)
+	}
+	return a
+}
+
+// enabled reports whether addresses are hidden
+func (a *clientAnonymizer) enabled() bool {
+	return a != nil && a.mode != config.ClientPrivacyOff
+}
+
+// anonymize returns what is recorded of a client address: the network it is in, a label
+// like client-1a2b3c4d, or the address itself when privacy is off
+func (a *clientAnonymizer) anonymize(ip string) string {
+	if !a.enabled() || ip == "" {
+		return ip
+	}
+	switch a.mode {
+	case config.ClientPrivacyTruncate:
+		parsed := net.ParseIP(ip)
+		if parsed == nil {
+			return ""
+		}
+		if v4 := parsed.To4(); v4 != nil {
+			return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+		}
+		return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+	default:
+		mac := hmac.New(sha256.New, a.key)
+		mac.Write([]byte(ip))
+		return "client-" + hex.EncodeToString(mac.Sum(nil)[:4])
+	}
+}
+
+// recordedClient returns what is recorded of a client address under client_privacy
+func (s *Server) recordedClient(ip string) string {
+	s.settingsMutex.RLock()
+	anonymizer := s.clientAnonymizer
+	s.settingsMutex.RUnlock()
+	return anonymizer.anonymize(ip)
+}
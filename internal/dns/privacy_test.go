@@ -0,0 +1,40 @@
+package dns
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestClientAnonymizer(t *testing.T) {
+	off := newClientAnonymizer(config.ClientPrivacyOff)
+	if got := off.anonymize("192.168.1.23"); got != "192.168.1.23" {
+		t.Errorf("expected the address to be kept, got %s", got)
+	}
+
+	truncate := newClientAnonymizer(config.ClientPrivacyTruncate)
+	for ip, want := range map[string]string{
+		"192.168.1.23":            "192.168.1.0/24",
+		"2001:db8:abcd:12::1":     "2001:db8:abcd::/48",
+		"::ffff:10.0.0.7":         "10.0.0.0/24",
+		"not an address":          "",
+		"2001:db8:abcd:ffff::1:2": "2001:db8:abcd::/48",
+	} {
+		if got := truncate.anonymize(ip); got != want {
+			t.Errorf("expected %s to become %q, got %q", ip, want, got)
+		}
+	}
+
+	hash := newClientAnonymizer(config.ClientPrivacyHash)
+	first, second := hash.anonymize("192.168.1.23"), hash.anonymize("192.168.1.23")
+	if first != second || !strings.HasPrefix(first, "client-") || strings.Contains(first, "192.168") {
+		t.Errorf("expected a stable label without the address, got %s and %s", first, second)
+	}
+	if hash.anonymize("192.168.1.24") == first {
+		t.Error("expected different clients to get different labels")
+	}
+	if newClientAnonymizer(config.ClientPrivacyHash).anonymize("192.168.1.23") == first {
+		t.Error("expected a new key to give new labels")
+	}
+}
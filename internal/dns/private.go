@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"net/netip"
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// privateTTL is the TTL of the answers the resolver gives for private names itself
+const privateTTL = 60
+
+// privateZones keeps names only the local network knows, such as printer.local or the
+// reverse names of 192.168.x.x addresses, from the public upstreams (see
+// config.PrivateZonesConfig)
+type privateZones struct {
+	mode      string
+	zones     []string              // Lowercase, without a trailing dot
+	hosts     map[string]netip.Addr // Names answered locally
+	ptrs      map[string]string     // Reverse names of the hosts' addresses, with the host (FQDN)
+	resolvers []config.Upstream     // Local nameservers of the forward mode
+	forwarder *Forwarder            // Queries the resolvers; nil unless the mode is forward
+}
+
+// newPrivateZones reads the private zone settings of cfg, reusing the forwarder of previous
+// while the resolvers stay the same. Invalid settings fall back to the defaults.
+func newPrivateZones(cfg *config.Config, previous *privateZones) *privateZones {
+	mode, err := cfg.GetPrivateMode()
+	if err != nil {
+		mode = config.PrivateNXDomain
+	}
+	zones, err := cfg.PrivateZones.GetZones()
+	if err != nil {
+		zones = config.BuiltinPrivateZones
+	}
+	hosts, _ := cfg.PrivateZones.GetHosts()
+	p := &privateZones{mode: mode, zones: zones, hosts: hosts, ptrs: make(map[string]string, len(hosts))}
+	for host, addr := range hosts {
+		if reverse, err := dns.ReverseAddr(addr.String()); err == nil {
+			p.ptrs[strings.TrimSuffix(reverse, ".")] = dns.Fqdn(host)
+		}
+	}
+	if mode == config.PrivateForward {
+		p.resolvers, _ = cfg.GetPrivateResolvers()
+	}
+
+	if previous != nil && previous.forwarder != nil {
+		if slices.Equal(previous.resolvers, p.resolvers) {
+			p.forwarder = previous.forwarder
+			return p
+		}
+		previous.forwarder.Close()
+	}
+	if len(p.resolvers) > 0 {
+		p.forwarder = NewForwarder(p.resolvers, upstreamTimeout)
+	}
+	return p
+}
+
+// contains reports whether a query name is private: one of the hosts or their reverse
+// names, or in a private zone. With the upstream mode only the hosts are.
+func (p *privateZones) contains(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if _, ok := p.hosts[name]; ok {
+		return true
+	}
+	if _, ok := p.ptrs[name]; ok {
+		return true
+	}
+	if p.mode == config.PrivateUpstream {
+		return false
+	}
+	for _, zone := range p.zones {
+		if name == zone || strings.HasSuffix(name, "."+zone) {
+			return true
+		}
+	}
+	return false
+}
+
+// privateAnswer answers a query for a private name itself: from private_zones.hosts (also
+// their reverse names), or with NXDOMAIN in the nxdomain mode. It returns nil for names
+// that are forwarded, which upstreamsFor sends to the local nameservers in the forward
+// mode. Names the VPN's nameservers resolve are left to them.
+func (s *Server) privateAnswer(r *dns.Msg) *dns.Msg {
+	if len(r.Question) == 0 {
+		return nil
+	}
+	question := r.Question[0]
+	s.settingsMutex.RLock()
+	private := s.private
+	vpn := s.vpnForwarder != nil && inVPNDomains(question.Name, s.vpnDomains)
+	s.settingsMutex.RUnlock()
+	if private == nil || !private.contains(question.Name) {
+		return nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetReply(r)
+	name := strings.TrimSuffix(strings.ToLower(question.Name), ".")
+	if host, ok := private.ptrs[name]; ok {
+		if question.Qtype == dns.TypePTR {
+			header := dns.RR_Header{Name: question.Name, Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: privateTTL}
+			msg.Answer = append(msg.Answer, &dns.PTR{Hdr: header, Ptr: host})
+		} else {
+			msg.Ns = append(msg.Ns, negativeSOA(question.Name, privateTTL))
+		}
+		return msg
+	}
+	if addr, ok := private.hosts[name]; ok {
+		header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: privateTTL}
+		switch {
+		case question.Qtype == dns.TypeA && addr.Is4():
+			msg.Answer = append(msg.Answer, &dns.A{Hdr: header, A: addr.AsSlice()})
+		case question.Qtype == dns.TypeAAAA && addr.Is6():
+			msg.Answer = append(msg.Answer, &dns.AAAA{Hdr: header, AAAA: addr.AsSlice()})
+		default:
+			// The name exists, without records of this type
+			msg.Ns = append(msg.Ns, negativeSOA(question.Name, privateTTL))
+		}
+		return msg
+	}
+	if vpn || private.mode != config.PrivateNXDomain {
+		return nil
+	}
+	msg.SetRcode(r, dns.RcodeNameError)
+	msg.Ns = append(msg.Ns, negativeSOA(question.Name, privateTTL))
+	return msg
+}
@@ -0,0 +1,77 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestPrivateAnswer(t *testing.T) {
+	cfg := &config.Config{
+		UpstreamNameservers: []string{"1.1.1.1"},
+		PrivateZones: &config.PrivateZonesConfig{
+			Zones: []string{"LAN."},
+			Hosts: map[string]string{"nas.lan": "192.168.1.20"},
+		},
+	}
+	s := NewServerWithAddr(cfg, nil, "127.0.0.1:0")
+	defer s.forwarder.Close()
+
+	ask := func(name string, qtype uint16) *dns.Msg {
+		query := new(dns.Msg)
+		query.SetQuestion(name, qtype)
+		return s.privateAnswer(query)
+	}
+
+	for _, name := range []string{"printer.local.", "21.1.168.192.in-addr.arpa.", "tv.lan.", "5.20.172.in-addr.arpa."} {
+		if answer := ask(name, dns.TypeA); answer == nil || answer.Rcode != dns.RcodeNameError {
+			t.Errorf("expected NXDOMAIN for %s, got %v", name, answer)
+		}
+	}
+	for _, name := range []string{"example.com.", "8.8.8.8.in-addr.arpa.", "5.32.172.in-addr.arpa."} {
+		if answer := ask(name, dns.TypeA); answer != nil {
+			t.Errorf("expected %s to be forwarded, got %v", name, answer)
+		}
+	}
+
+	answer := ask("NAS.lan.", dns.TypeA)
+	if answer == nil || len(answer.Answer) != 1 || answer.Answer[0].(*dns.A).A.String() != "192.168.1.20" {
+		t.Fatalf("expected the host's address, got %v", answer)
+	}
+	if answer := ask("nas.lan.", dns.TypeAAAA); answer == nil || answer.Rcode != dns.RcodeSuccess || len(answer.Answer) != 0 || len(answer.Ns) != 1 {
+		t.Errorf("expected NODATA for AAAA, got %v", answer)
+	}
+	answer = ask("20.1.168.192.in-addr.arpa.", dns.TypePTR)
+	if answer == nil || len(answer.Answer) != 1 || answer.Answer[0].(*dns.PTR).Ptr != "nas.lan." {
+		t.Errorf("expected the host's name, got %v", answer)
+	}
+
+	// The upstream mode forwards private zones again, but still answers the hosts
+	cfg.PrivateZones.Mode = config.PrivateUpstream
+	s.ApplyConfig(cfg)
+	if answer := ask("printer.local.", dns.TypeA); answer != nil {
+		t.Errorf("expected printer.local to be forwarded, got %v", answer)
+	}
+	if answer := ask("nas.lan.", dns.TypeA); answer == nil || len(answer.Answer) != 1 {
+		t.Errorf("expected the host's address, got %v", answer)
+	}
+
+	// With a local nameserver, private names go to it instead
+	cfg.PrivateZones.Mode = ""
+	cfg.UpstreamNameservers = []string{"192.168.1.1", "1.1.1.1"}
+	s.ApplyConfig(cfg)
+	if answer := ask("printer.local.", dns.TypeA); answer != nil {
+		t.Errorf("expected printer.local to be forwarded, got %v", answer)
+	}
+	query := new(dns.Msg)
+	query.SetQuestion("printer.local.", dns.TypeA)
+	if upstreams, _ := s.upstreamsFor(query); len(upstreams) != 1 || upstreams[0].Address != "192.168.1.1:53" {
+		t.Errorf("expected the router, got %v", upstreams)
+	}
+	query.SetQuestion("example.com.", dns.TypeA)
+	if upstreams, _ := s.upstreamsFor(query); len(upstreams) != 2 {
+		t.Errorf("expected the configured upstreams, got %v", upstreams)
+	}
+}
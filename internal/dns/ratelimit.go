@@ -0,0 +1,82 @@
+package dns
+
+import (
+	"sync"
+	"time"
+)
+
+// maxRateLimitClients bounds the memory used to track clients; idle clients are dropped
+// first once it is reached
+const maxRateLimitClients = 10000
+
+// rateLimiter allows each client a sustained number of queries per second, with bursts up
+// to a limit (a token bucket per client address)
+type rateLimiter struct {
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+	mutex   sync.Mutex
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter, or returns nil when rate is 0 (unlimited)
+func newRateLimiter(rate, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		rate:    float64(rate),
+		burst:   float64(burst),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// hasLimits reports whether the limiter was created with this rate and burst (a nil
+// limiter has rate 0)
+func (l *rateLimiter) hasLimits(rate, burst int) bool {
+	if l == nil {
+		return rate <= 0
+	}
+	return l.rate == float64(rate) && l.burst == float64(burst)
+}
+
+// allow reports whether the client may send another query now
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		if len(l.buckets) >= maxRateLimitClients {
+			l.dropIdle(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// dropIdle forgets clients whose bucket has refilled, as they start out full anyway.
+// Callers hold the mutex.
+func (l *rateLimiter) dropIdle(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= refill {
+			delete(l.buckets, client)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package dns
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	if !newRateLimiter(0, 0).allow("192.0.2.1", time.Now()) {
+		t.Error("expected no limit when the rate is 0")
+	}
+
+	limiter := newRateLimiter(2, 3)
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !limiter.allow("192.0.2.1", now) {
+			t.Fatalf("expected query %d of the burst to be allowed", i+1)
+		}
+	}
+	if limiter.allow("192.0.2.1", now) {
+		t.Error("expected a query beyond the burst to be refused")
+	}
+	if !limiter.allow("192.0.2.2", now) {
+		t.Error("expected other clients to have their own limit")
+	}
+	if !limiter.allow("192.0.2.1", now.Add(500*time.Millisecond)) {
+		t.Error("expected the bucket to refill at 2 queries per second")
+	}
+}
@@ -0,0 +1,38 @@
+package dns
+
+import (
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/rules"
+)
+
+// ruleAction returns the action and name of the first rule that holds for a query, or ""
+// when none does and the lists decide
+func (s *Server) ruleAction(in rules.Input) (action, name string) {
+	s.settingsMutex.RLock()
+	engine := s.rules
+	s.settingsMutex.RUnlock()
+	return engine.Decide(in)
+}
+
+// ruleReason is the reason recorded with a query a rule decided
+func ruleReason(action, name string) string {
+	if action == config.RuleBlock {
+		return "blocked by rule " + name
+	}
+	return "allowed by rule " + name
+}
+
+// scheduledBlock returns the schedule blocking a domain at now, if any
+func (s *Server) scheduledBlock(domain string, now time.Time) (string, bool) {
+	s.settingsMutex.RLock()
+	blocks := s.blockSchedules
+	s.settingsMutex.RUnlock()
+	return blocks.Blocked(domain, now)
+}
+
+// scheduleReason is the reason recorded with a query a schedule blocked
+func scheduleReason(schedule string) string {
+	return "blocked by schedule " + schedule
+}
@@ -0,0 +1,138 @@
+package dns
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// scheduleCheckInterval is how often queued sessions are checked
+const scheduleCheckInterval = 15 * time.Second
+
+// ScheduledSessions lists the queued focus sessions
+func (s *Server) ScheduledSessions() ([]api.ScheduledSession, error) {
+	if s.stateManager == nil {
+		return nil, fmt.Errorf("session storage is not available")
+	}
+
+	var sessions []api.ScheduledSession
+	for _, session := range s.stateManager.ScheduledSessions() {
+		sessions = append(sessions, toAPISession(session))
+	}
+	return sessions, nil
+}
+
+// ScheduleSession queues a focus session after validating its profile and duration
+func (s *Server) ScheduleSession(req api.ScheduledSession) (*api.ScheduledSession, error) {
+	if s.stateManager == nil {
+		return nil, fmt.Errorf("session storage is not available")
+	}
+
+	duration, err := time.ParseDuration(req.Duration)
+	if err != nil {
+		return nil, fmt.Errorf("invalid duration format: %s", req.Duration)
+	}
+	if req.Profile != "" {
+		if _, err := s.config.GetProfile(req.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	session, err := s.stateManager.AddScheduledSession(config.ScheduledSession{
+		Start:    req.Start,
+		Duration: duration,
+		Profile:  req.Profile,
+		Label:    req.Label,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := toAPISession(session)
+	return &result, nil
+}
+
+// CancelScheduledSession removes a queued focus session
+func (s *Server) CancelScheduledSession(id string) error {
+	if s.stateManager == nil {
+		return fmt.Errorf("session storage is not available")
+	}
+	if err := s.stateManager.RemoveScheduledSession(id); err != nil {
+		return err
+	}
+	logger.Info("Scheduled focus session cancelled", "id", id)
+	return nil
+}
+
+// runScheduledSessions starts queued sessions when they are due. A session that comes due
+// while focus mode is already active waits for the running session to end and then covers
+// the rest of its window; sessions whose window has passed are dropped.
+func (s *Server) runScheduledSessions() {
+	ticker := time.NewTicker(scheduleCheckInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		s.startDueSession(now)
+	}
+}
+
+func (s *Server) startDueSession(now time.Time) {
+	for _, session := range s.stateManager.ScheduledSessions() {
+		if now.Before(session.Start) {
+			// Sessions are sorted by start time
+			return
+		}
+
+		if !now.Before(session.End()) {
+			logger.Info("Scheduled focus session missed its window, dropping it", "id", session.ID)
+			s.removeScheduledSession(session.ID)
+			continue
+		}
+
+		if s.focusActive(now) {
+			continue
+		}
+
+		remaining := session.End().Sub(now).Round(time.Second)
+		logger.Info("Starting scheduled focus session", "id", session.ID, "duration", remaining)
+
+		req := api.FocusRequest{Enabled: true, Duration: remaining.String(), Profile: session.Profile, Label: session.Label}
+		var err error
+		if s.apiServer != nil {
+			err = s.apiServer.ApplyFocusMode(req)
+		} else {
+			err = s.setFocusMode(true, &api.FocusOptions{Duration: remaining, Profile: session.Profile, Label: session.Label})
+		}
+		if err != nil {
+			logger.Warn("Failed to start scheduled focus session", "error", err)
+			continue
+		}
+		s.removeScheduledSession(session.ID)
+		return
+	}
+}
+
+// focusActive reports whether a focus session (possibly paused) is running
+func (s *Server) focusActive(now time.Time) bool {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+	return s.focusMode && (s.focusEndTime == nil || now.Before(*s.focusEndTime))
+}
+
+func (s *Server) removeScheduledSession(id string) {
+	if err := s.stateManager.RemoveScheduledSession(id); err != nil {
+		logger.Warn("Failed to remove scheduled session", "error", err)
+	}
+}
+
+func toAPISession(session config.ScheduledSession) api.ScheduledSession {
+	return api.ScheduledSession{
+		ID:       session.ID,
+		Start:    session.Start,
+		Duration: session.Duration.String(),
+		Profile:  session.Profile,
+		Label:    session.Label,
+	}
+}
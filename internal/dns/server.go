@@ -2,72 +2,380 @@ package dns
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
-	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/clock"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/events"
+	"github.com/berbyte/sinkzone/internal/logs"
+	"github.com/berbyte/sinkzone/internal/rules"
+	"github.com/berbyte/sinkzone/internal/schedule"
+	"github.com/berbyte/sinkzone/internal/subscription"
+	"github.com/berbyte/sinkzone/internal/tracing"
 	"github.com/miekg/dns"
 )
 
+// logger writes the DNS server's log, at the level set for logs.ComponentDNS
+var logger = logs.Component(logs.ComponentDNS)
+
 type Server struct {
 	config *config.Config
-	server *dns.Server
+	addr   string
 	port   string
 
+	// Answers to blocked queries (see config.BlockResponse*), and their TTL in seconds
+	// (guarded by settingsMutex, like every setting ApplyConfig changes)
+	blockResponse string
+	blockedTTL    uint32
+
+	// Upstream answers (nil when caching is off) and per-client rate limits (nil when unlimited)
+	cache   *responseCache
+	limiter *rateLimiter
+
+	// Queries handled at once (nil when unlimited)
+	queryLimiter *queryLimiter
+
+	// Clients refused for a while after too many strikes (see cooldown.strikes)
+	cooldowns *cooldownList
+
+	// Names given to clients in client_names
+	clientNames *clientNamer
+	// Hides client addresses before queries are recorded (client_privacy)
+	clientAnonymizer *clientAnonymizer
+	// Policies of the accounts of this machine (users), nil when queries aren't told apart by user
+	users map[string]*userPolicy
+	// Rules that allow or block queries ahead of the lists, nil without any
+	rules *rules.Engine
+	// Daily time budgets of domains and their use today (see budgets)
+	budgets *budgetTracker
+	// Schedules that block domains during their windows, nil without any
+	blockSchedules *schedule.Blocks
+	// Domains left out of the recorded queries (ignore_domains)
+	ignoredExact     map[string]bool
+	ignoredWildcards []*regexp.Regexp
+
+	// The rate and burst last taken from the config; limits set at runtime are kept until
+	// these change
+	configRate [2]int
+
+	// Blocking only warns in every session, switched at runtime (see SetDryRun)
+	dryRun atomic.Bool
+
+	settingsMutex sync.RWMutex
+
+	// DNS listener, those added with ServePacketConn, and whether Shutdown was called
+	// (guarded by serverMutex)
+	server      *dns.Server
+	extra       []*dns.Server
+	stopped     bool
+	serverMutex sync.Mutex
+
+	// Socket and signing key taken by Listen, before the resolver drops root privileges
+	conn     net.PacketConn
+	stateKey *stateKey
+
 	// API server reference
 	apiServer *api.Server
 
+	// Persisted focus session state (optional)
+	stateManager  *config.StateManager
+	stateTampered bool // The saved focus session failed its signature check at startup
+
 	// Allowlist management
 	allowlistPath    string
 	allowlist        map[string]bool  // Exact domain matches
 	wildcardPatterns []*regexp.Regexp // Compiled wildcard patterns
-	allowlistMutex   sync.RWMutex
+	// Entries of allowlist.txt when it last passed its integrity check
+	verifiedAllowlist []string
+	allowlistMutex    sync.RWMutex
+
+	// Deny-list, blocked at every intensity (guarded by allowlistMutex)
+	blocklistPath string
+	denylist      map[string]bool
+	denyPatterns  []*regexp.Regexp
+	// Subscribed blocklists, which local allowlist entries override, and those entries: the
+	// profile's, allowlist.txt, and break domains (guarded by allowlistMutex)
+	subscribedDenylist     map[string]bool
+	subscribedDenyPatterns []*regexp.Regexp
+	localAllowlist         map[string]bool
+	localAllowPatterns     []*regexp.Regexp
+
+	// Gets allowlist_changed events (optional), with the entries they are compared with
+	events       *events.Bus
+	allowEntries map[string]bool
+	eventsMutex  sync.Mutex
+
+	// Subscriptions of the allowlist and blocklist (guarded by settingsMutex)
+	allowlistSubscriptions []config.Subscription
+	blocklistSubscriptions []config.Subscription
+
+	// Domains queried since the resolver started, used by the hard intensity
+	seenDomains map[string]time.Time
+	seenMutex   sync.Mutex
 
 	// Focus mode state (in-memory)
-	focusMode    bool
-	focusEndTime *time.Time
-	focusMutex   sync.RWMutex
+	focusMode        bool
+	focusEndTime     *time.Time
+	focusPausedUntil *time.Time    // Set while a pause is in effect
+	focusRemaining   time.Duration // Focus time left when the pause started (0 = no expiration)
+	focusBreak       bool          // The pause is a break: break domains are allowed, the rest stays blocked
+	focusProfile     string        // Name of the profile whose allowlist is active
+	focusIntensity   string        // How strictly the session blocks (see config.Intensity*)
+	focusLabel       string        // Project label the session's focus time is attributed to
+	focusStartedAt   time.Time     // When the session started, used by the hard intensity
+	focusGraceUntil  *time.Time    // Blocking only warns until this time after enabling
+	focusDryRun      bool          // Blocking only warns for the whole session
+	focusMutex       sync.RWMutex
+
+	// Domains let through until the given time during the session (guarded by focusMutex)
+	snoozes map[string]time.Time
+
+	// Devices kept in focus or out of it, by device ID (guarded by focusMutex; replaced, not
+	// changed in place)
+	deviceFocus map[string]config.DeviceFocus
+
+	// Whether the DNS port is bound, and recent exchanges per upstream (guarded by healthMutex)
+	listening   atomic.Bool
+	upstreams   map[string]*upstreamState
+	healthMutex sync.Mutex
+
+	// Whether this machine's own DNS queries reach the resolver
+	bypass *bypassCheck
+
+	// Signs of a captive portal and the passthrough window that lets its login page load
+	portal *portalDetector
+
+	// Parsed upstream nameservers and the forwarder that queries them (guarded by settingsMutex)
+	upstreamList []config.Upstream
+	forwarder    *Forwarder
+
+	// Nameservers of a connected VPN, the domains sent to them (none: every name), and the
+	// forwarder that queries them, nil while no VPN is up (guarded by settingsMutex)
+	vpnUpstreams []config.Upstream
+	vpnDomains   []string
+	vpnForwarder *Forwarder
+
+	// The network's own nameserver and the forwarder that queries it while a passthrough
+	// window is open, nil otherwise (guarded by settingsMutex)
+	portalUpstreams []config.Upstream
+	portalForwarder *Forwarder
+
+	// Names only the local network knows and how they are answered (guarded by settingsMutex)
+	private *privateZones
+
+	// Order upstreams are tried in (see config.Upstream*, guarded by settingsMutex), the next
+	// round-robin start, and the average latencies used by the fastest strategy (guarded by
+	// healthMutex)
+	upstreamStrategy string
+	nextUpstream     atomic.Uint64
+	latencies        map[string]time.Duration
+	latenciesChanged bool
+
+	// Notices changes of the system time, so focus deadlines can follow them
+	clockJumps *clock.Detector
+
+	// Failed PIN attempts, used to slow down guessing
+	pinFailures    int
+	pinLockedUntil time.Time
+	pinMutex       sync.Mutex
 }
 
+const (
+	// upstreamTimeout bounds each exchange with an upstream nameserver
+	upstreamTimeout = 5 * time.Second
+
+	maxPINFailures  = 5
+	pinLockDuration = time.Minute
+
+	// maxSeenDomains bounds the memory used to remember queried domains
+	maxSeenDomains = 50000
+)
+
 func NewServer(cfg *config.Config, apiServer *api.Server) *Server {
 	return NewServerWithPort(cfg, apiServer, "53")
 }
 
 func NewServerWithPort(cfg *config.Config, apiServer *api.Server, port string) *Server {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
+	return NewServerWithAddr(cfg, apiServer, ":"+port)
+}
 
-	var allowlistPath string
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			allowlistPath = filepath.Join(appData, "sinkzone", "allowlist.txt")
-		} else {
-			allowlistPath = filepath.Join(homeDir, "sinkzone", "allowlist.txt")
-		}
-	} else {
-		// Unix-like systems use ~/.sinkzone/
-		allowlistPath = filepath.Join(homeDir, ".sinkzone", "allowlist.txt")
-	}
+// NewServerWithAddr creates a server listening on addr, e.g. 127.0.0.1:53. Invalid server
+// settings fall back to their defaults; the resolver checks them with ValidateServer first.
+func NewServerWithAddr(cfg *config.Config, apiServer *api.Server, addr string) *Server {
+	allowlistPath := filepath.Join(config.GetDataDir(), "allowlist.txt")
+	_, port, _ := net.SplitHostPort(addr)
 
-	return &Server{
+	s := &Server{
 		config:        cfg,
 		apiServer:     apiServer,
 		allowlistPath: allowlistPath,
 		allowlist:     make(map[string]bool),
+		blocklistPath: filepath.Join(filepath.Dir(allowlistPath), "blocklist.txt"),
+		denylist:      make(map[string]bool),
+		seenDomains:   make(map[string]time.Time),
+		clockJumps:    clock.NewDetector(),
+		bypass:        newBypassCheck(),
+		portal:        newPortalDetector(),
+		addr:          addr,
 		port:          port,
 	}
+
+	s.ApplyConfig(cfg)
+	s.registerFocusMetrics()
+	s.registerCacheMetrics()
+	s.registerConcurrencyMetrics()
+
+	// Set up API server callbacks for focus mode changes. This happens here rather than
+	// in Start so focus changes made while the servers are starting still reach us.
+	if apiServer != nil {
+		apiServer.SetFocusModeCallback(s.setFocusMode)
+		apiServer.SetFocusPauseCallback(s.pauseFocusMode)
+		apiServer.SetStatsCallback(s.focusStats)
+		apiServer.SetSnoozeCallback(s.snoozeDomain)
+		apiServer.SetDecisionCallback(s.decide)
+		apiServer.SetDeviceFocusCallbacks(s.listDeviceFocus, s.setDeviceFocus)
+		apiServer.SetAllowlistReloadCallback(s.loadAllowlist)
+		apiServer.SetHealthCallback(s.resolverHealth)
+		apiServer.SetCacheCallbacks(s.cacheStats, s.flushCache)
+		apiServer.SetCooldownCallbacks(s.cooldownList, s.liftCooldown)
+		apiServer.SetPortalCallbacks(s.startPassthrough, s.endPassthrough)
+		apiServer.SetShutdownPINCheck(s.verifyShutdownPIN)
+		apiServer.SetSessionScheduler(s)
+	}
+
+	return s
+}
+
+// ApplyConfig takes the upstreams, upstream strategy, block response, cache limits, rate
+// limits, cooldowns, budgets, private zones, and client names from cfg; a running server switches to them right
+// away, other settings need a restart. Invalid settings fall back to their defaults. The cache and rate
+// limiter are only replaced, and their contents lost, when their limits change.
+func (s *Server) ApplyConfig(cfg *config.Config) {
+	upstreams, _ := cfg.GetUpstreams()
+	strategy, _ := cfg.GetUpstreamStrategy()
+	blockResponse, _ := cfg.GetBlockResponse()
+	if blockResponse == "" {
+		blockResponse = config.BlockResponseNXDomain
+	}
+	blockedTTL, err := cfg.GetBlockedTTL()
+	if err != nil {
+		blockedTTL = config.DefaultBlockedTTL
+	}
+	clientNames, _ := cfg.GetClientNames()
+	clientPrivacy, err := cfg.GetClientPrivacy()
+	if err != nil {
+		clientPrivacy = config.ClientPrivacyOff
+	}
+
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+
+	s.allowlistSubscriptions = cfg.AllowlistSubscriptions
+	s.blocklistSubscriptions = cfg.BlocklistSubscriptions
+
+	// The forwarder keeps connections to the upstreams, so it is only replaced when they change
+	if s.forwarder == nil || !slices.Equal(s.upstreamList, upstreams) {
+		if s.forwarder != nil {
+			s.forwarder.Close()
+		}
+		s.forwarder = NewForwarder(upstreams, upstreamTimeout)
+	}
+	s.upstreamList = upstreams
+	s.private = newPrivateZones(cfg, s.private)
+	s.upstreamStrategy = strategy
+	s.blockResponse = blockResponse
+	s.blockedTTL = uint32(blockedTTL / time.Second)
+	s.clientNames = newClientNamer(clientNames, cfg.IsLAN())
+	if s.bypass != nil {
+		s.bypass.enabled.Store(cfg.ChecksBypass())
+	}
+	if s.portal != nil {
+		mode, err := cfg.CaptivePortal.GetMode()
+		if err != nil {
+			mode = config.CaptivePortalNotify
+		}
+		length, err := cfg.CaptivePortal.GetPassthrough()
+		if err != nil {
+			length = config.DefaultPassthrough
+		}
+		s.portal.configure(mode, length)
+	}
+	s.users = newUserPolicies(cfg)
+	if engine, err := rules.Compile(cfg); err == nil {
+		s.rules = engine
+	} else {
+		logger.Warn("Keeping the previous rules", "error", err)
+	}
+	if blocks, err := schedule.NewBlocks(cfg.Schedules); err == nil {
+		s.blockSchedules = blocks
+	} else {
+		logger.Warn("Keeping the previous blocking schedules", "error", err)
+	}
+	s.ignoredExact, s.ignoredWildcards = compilePatterns(cfg.IgnoreDomains)
+	// Kept while the mode stays, so hashed labels don't change
+	if s.clientAnonymizer == nil || s.clientAnonymizer.mode != clientPrivacy {
+		s.clientAnonymizer = newClientAnonymizer(clientPrivacy)
+	}
+	if size, err := cfg.Cache.GetSize(); err == nil {
+		minTTL, maxTTL, err := cfg.Cache.GetTTLBounds()
+		if err != nil {
+			minTTL, maxTTL = 0, config.DefaultCacheMax
+		}
+		if !s.cache.hasLimits(size, minTTL, maxTTL) {
+			s.cache = newResponseCache(size, minTTL, maxTTL)
+		}
+	}
+	if staleFor, err := cfg.Cache.GetServeStale(); err == nil {
+		s.cache.setServeStale(staleFor)
+	}
+	if maxQueries, maxQueued, err := cfg.Concurrency.GetLimits(); err == nil && !s.queryLimiter.hasLimits(maxQueries, maxQueued) {
+		s.queryLimiter = newQueryLimiter(maxQueries, maxQueued)
+	}
+	if s.cooldowns == nil {
+		// Created once, so cooldowns in effect survive config changes
+		s.cooldowns = newCooldownList()
+	}
+	if strikes, duration, err := cfg.Cooldown.GetCooldown(); err == nil {
+		s.cooldowns.setLimits(strikes, duration)
+	}
+	if s.budgets == nil {
+		// Created once, so today's use survives config changes
+		s.budgets = newBudgetTracker()
+	}
+	if budgets, err := cfg.GetBudgets(); err == nil {
+		s.budgets.setBudgets(budgets)
+	}
+	if rate, burst, err := cfg.RateLimit.GetLimit(); err == nil && s.configRate != [2]int{rate, burst} {
+		s.configRate = [2]int{rate, burst}
+		if !s.limiter.hasLimits(rate, burst) {
+			s.limiter = newRateLimiter(rate, burst)
+		}
+	}
+}
+
+// upstreamAddresses returns the configured upstreams as shown to users
+func (s *Server) upstreamAddresses() []string {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+
+	addresses := make([]string, 0, len(s.upstreamList))
+	for _, upstream := range s.upstreamList {
+		addresses = append(addresses, upstream.String())
+	}
+	return addresses
 }
 
 // wildcardToRegex converts a wildcard pattern to a regex pattern
@@ -77,6 +385,11 @@ func NewServerWithPort(cfg *config.Config, apiServer *api.Server, port string) *
 //	"*.example.com" -> ".*\\.example\\.com"
 //	"api.*.com" -> "api\\..*\\.com"
 func wildcardToRegex(pattern string) (*regexp.Regexp, error) {
+	// Query names are never invalid UTF-8; the DNS library escapes such bytes as \DDD
+	if !utf8.ValidString(pattern) {
+		return nil, fmt.Errorf("pattern %q is not valid UTF-8", pattern)
+	}
+
 	// Escape regex special characters except *
 	escaped := regexp.QuoteMeta(pattern)
 
@@ -101,146 +414,804 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to load allowlist: %w", err)
 	}
 
-	// Set up API server callback for focus mode changes
-	if s.apiServer != nil {
-		s.apiServer.SetFocusModeCallback(s.setFocusMode)
+	// Persist focus sessions so they can be restored after a restart (optional)
+	if stateManager, err := config.NewStateManager(); err != nil {
+		logger.Warn("Failed to initialize state manager", "error", err)
+	} else {
+		s.stateManager = stateManager
+		// Before sealState saves the state, recording a new checksum
+		checkIntegrity(config.GetStatePath(), "state.json")
+		s.sealState()
+		s.loadDeviceFocus()
+		go s.trackFocusTime()
+		go s.runScheduledSessions()
+		go s.watchClock()
+		// Kept for every strategy, as a reload may switch to fastest
+		s.loadLatencies()
+		go s.saveLatencies()
+		defer s.persistLatencies()
+		s.restoreBudgetUsage()
+		go s.saveBudgetUsage()
+		defer s.persistBudgetUsage()
+	}
+
+	go s.checkBypass()
+	go s.watchPortal()
+
+	// Enter focus mode right away if configured
+	if err := s.autoStartFocusMode(); err != nil {
+		logger.Warn("Failed to auto-start focus mode", "error", err)
 	}
 
 	// Create PID file (optional - don't fail if we can't create it)
 	if err := s.createPIDFile(); err != nil {
-		log.Printf("Warning: failed to create PID file: %v", err)
-		log.Printf("Resolver will continue without PID file")
+		logger.Warn("Failed to create PID file, continuing without it", "error", err)
 	} else {
 		defer s.cleanupPIDFile()
 	}
 
-	dns.HandleFunc(".", s.handleRequest)
+	server := s.newDNSServer(s.conn)
+	server.Addr = s.addr
+	server.NotifyStartedFunc = func() {
+		s.listening.Store(true)
+	}
+	s.serverMutex.Lock()
+	if s.stopped {
+		s.serverMutex.Unlock()
+		if s.conn != nil {
+			_ = s.conn.Close()
+		}
+		return nil
+	}
+	s.server = server
+	s.serverMutex.Unlock()
+	defer s.listening.Store(false)
+
+	logger.Info("Starting DNS server", "addr", s.addr)
+	if s.conn != nil {
+		return server.ActivateAndServe()
+	}
+	return server.ListenAndServe()
+}
+
+// SetAddr changes the address Listen binds, e.g. to dns_fallback_port when the configured
+// port is taken
+func (s *Server) SetAddr(addr string) {
+	s.addr = addr
+	_, s.port, _ = net.SplitHostPort(addr)
+}
+
+// SetPacketConn makes the server answer on a socket bound elsewhere, e.g. by systemd,
+// instead of binding its address in Listen
+func (s *Server) SetPacketConn(conn net.PacketConn) {
+	s.conn = conn
+}
+
+// Listen binds the DNS address and loads the focus session signing key ahead of Start,
+// while the resolver may still need root for both
+func (s *Server) Listen() error {
+	if s.conn == nil {
+		conn, err := net.ListenPacket("udp", s.addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", s.addr, err)
+		}
+		s.conn = conn
+	}
+	key, created, err := config.LoadStateKey()
+	s.stateKey = &stateKey{key: key, created: created, err: err}
+	return nil
+}
+
+// newDNSServer returns a server answering queries on conn
+func (s *Server) newDNSServer(conn net.PacketConn) *dns.Server {
+	return &dns.Server{
+		Net:        "udp",
+		PacketConn: conn,
+		Handler:    dns.HandlerFunc(s.serveDNS),
+		DecorateWriter: func(w dns.Writer) dns.Writer {
+			return &strikeWriter{Writer: w, server: s}
+		},
+	}
+}
 
-	s.server = &dns.Server{
-		Addr: ":" + s.port,
-		Net:  "udp",
+// ServePacketConn also answers queries on conn, e.g. one on a tailnet, until Shutdown
+func (s *Server) ServePacketConn(conn net.PacketConn) error {
+	server := s.newDNSServer(conn)
+	s.serverMutex.Lock()
+	if s.stopped {
+		s.serverMutex.Unlock()
+		return conn.Close()
 	}
+	s.extra = append(s.extra, server)
+	s.serverMutex.Unlock()
 
-	log.Printf("Starting DNS server on :%s", s.port)
-	return s.server.ListenAndServe()
+	logger.Info("Answering DNS queries", "addr", conn.LocalAddr().String())
+	return server.ActivateAndServe()
 }
 
+// Shutdown stops the DNS listeners, so Start returns and removes the PID file
+func (s *Server) Shutdown() error {
+	s.serverMutex.Lock()
+	defer s.serverMutex.Unlock()
+
+	s.stopped = true
+	for _, server := range s.extra {
+		if err := server.Shutdown(); err != nil {
+			logger.Warn("Failed to stop DNS listener", "addr", server.PacketConn.LocalAddr().String(), "error", err)
+		}
+	}
+	if s.server == nil {
+		return nil
+	}
+	if err := s.server.Shutdown(); err != nil {
+		return fmt.Errorf("failed to stop DNS server: %w", err)
+	}
+	return nil
+}
+
+// loadAllowlist rereads the allowlist and blocklist with their enabled subscriptions. Their
+// merge order: the local blocklist blocks in every session, the local allowlist overrides
+// subscribed blocklists, which block in every session, and subscribed allowlists only allow
+// what no blocklist blocks.
 func (s *Server) loadAllowlist() error {
-	log.Printf("Loading allowlist from: %s", s.allowlistPath)
+	s.focusMutex.RLock()
+	profileName := s.focusProfile
+	onBreak := s.focusBreak
+	s.focusMutex.RUnlock()
 
-	// Create directory if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(s.allowlistPath), 0750); err != nil {
-		return fmt.Errorf("failed to create allowlist directory: %w", err)
+	s.settingsMutex.RLock()
+	allowSubscriptions := s.allowlistSubscriptions
+	blockSubscriptions := s.blocklistSubscriptions
+	s.settingsMutex.RUnlock()
+
+	var patterns, subscribedPatterns []string
+	useAllowlistFile := true
+
+	if profileName != "" {
+		profile, err := s.config.GetProfile(profileName)
+		if err != nil {
+			return err
+		}
+		logger.Info("Loading allowlist for profile", "profile", profileName)
+		patterns = append(patterns, profile.Allowlist...)
+		useAllowlistFile = profile.UseDefaultAllowlist
 	}
 
-	// Load allowlist from file
-	if _, err := os.Stat(s.allowlistPath); err == nil {
-		// #nosec G304 -- s.allowlistPath is a hardcoded path from user home directory
-		file, err := os.Open(s.allowlistPath)
+	if useAllowlistFile {
+		filePatterns, err := readListFile(s.allowlistPath, "allowlist")
 		if err != nil {
-			return fmt.Errorf("failed to open allowlist file: %w", err)
+			return err
 		}
-		defer func() {
-			if err := file.Close(); err != nil {
-				log.Printf("Warning: failed to close allowlist file: %v", err)
-			}
-		}()
-
-		scanner := bufio.NewScanner(file)
-		s.allowlistMutex.Lock()
-		s.allowlist = make(map[string]bool)
-		s.wildcardPatterns = nil // Reset wildcard patterns
-
-		exactMatches := 0
-		wildcardMatches := 0
-
-		for scanner.Scan() {
-			pattern := strings.TrimSpace(scanner.Text())
-			if pattern != "" && !strings.HasPrefix(pattern, "#") {
-				if isWildcardPattern(pattern) {
-					// Compile wildcard pattern
-					if regex, err := wildcardToRegex(pattern); err == nil {
-						s.wildcardPatterns = append(s.wildcardPatterns, regex)
-						wildcardMatches++
-						log.Printf("Loaded wildcard pattern: %s", pattern)
-					} else {
-						log.Printf("Warning: invalid wildcard pattern '%s': %v", pattern, err)
-					}
-				} else {
-					// Exact domain match
-					s.allowlist[pattern] = true
-					exactMatches++
-					log.Printf("Loaded exact domain: %s", pattern)
-				}
+		patterns = append(patterns, s.trustedAllowlist(filePatterns)...)
+		// Subscribed allowlists extend allowlist.txt, so profiles without it leave them out too
+		subscribedPatterns = readSubscribedLists(subscription.Allowlist, allowSubscriptions)
+	}
+
+	if onBreak {
+		logger.Info("Adding break domains to the allowlist", "count", len(s.config.BreakDomains))
+		patterns = append(patterns, s.config.BreakDomains...)
+	}
+
+	denyPatterns, err := readListFile(s.blocklistPath, "blocklist")
+	if err != nil {
+		return err
+	}
+	subscribedDenyPatterns := readSubscribedLists(subscription.Blocklist, blockSubscriptions)
+
+	allowlist, wildcards := compilePatterns(slices.Concat(patterns, subscribedPatterns))
+	localAllowlist, localWildcards := compilePatterns(patterns)
+	denylist, denyWildcards := compilePatterns(denyPatterns)
+	subscribedDenylist, subscribedDenyWildcards := compilePatterns(subscribedDenyPatterns)
+
+	s.allowlistMutex.Lock()
+	s.allowlist = allowlist
+	s.wildcardPatterns = wildcards
+	s.localAllowlist = localAllowlist
+	s.localAllowPatterns = localWildcards
+	s.denylist = denylist
+	s.denyPatterns = denyWildcards
+	s.subscribedDenylist = subscribedDenylist
+	s.subscribedDenyPatterns = subscribedDenyWildcards
+	s.allowlistMutex.Unlock()
+	s.publishAllowlistChange(slices.Concat(patterns, subscribedPatterns))
+
+	logger.Info("Allowlist loaded", "domains", len(allowlist), "wildcards", len(wildcards))
+	logger.Info("Blocklist loaded", "domains", len(denylist)+len(subscribedDenylist), "wildcards", len(denyWildcards)+len(subscribedDenyWildcards))
+	return nil
+}
+
+// ReloadLists rereads the allowlist and blocklist, e.g. after their subscriptions changed
+func (s *Server) ReloadLists() error {
+	return s.loadAllowlist()
+}
+
+// readSubscribedLists returns the entries of a list's enabled subscriptions, as last
+// downloaded by 'sinkzone allowlist/blocklist update' or the subscription refresher
+func readSubscribedLists(list subscription.List, subscriptions []config.Subscription) []string {
+	var patterns []string
+	for _, path := range list.CachedLists(subscriptions) {
+		listPatterns, err := readListFile(path, "subscribed "+string(list))
+		if err != nil {
+			logger.Warn("Skipping subscribed "+string(list), "error", err)
+			continue
+		}
+		patterns = append(patterns, listPatterns...)
+	}
+	return patterns
+}
+
+// compilePatterns splits list entries into exact domains and compiled wildcard patterns
+func compilePatterns(patterns []string) (map[string]bool, []*regexp.Regexp) {
+	exact := make(map[string]bool)
+	var wildcards []*regexp.Regexp
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" || strings.HasPrefix(pattern, "#") {
+			continue
+		}
+		if isWildcardPattern(pattern) {
+			// Compile wildcard pattern
+			if regex, err := wildcardToRegex(pattern); err == nil {
+				wildcards = append(wildcards, regex)
+				logger.Debug("Loaded wildcard pattern", "pattern", pattern)
+			} else {
+				logger.Warn("Invalid wildcard pattern", "pattern", pattern, "error", err)
 			}
+		} else {
+			// Exact domain match
+			exact[pattern] = true
+			logger.Debug("Loaded exact domain", "domain", pattern)
 		}
-		s.allowlistMutex.Unlock()
+	}
 
-		log.Printf("Allowlist loaded: %d exact domains, %d wildcard patterns", exactMatches, wildcardMatches)
+	return exact, wildcards
+}
 
-		if err := scanner.Err(); err != nil {
-			return fmt.Errorf("failed to read allowlist file: %w", err)
+// readListFile returns the raw lines of a domain list file (allowlist or blocklist)
+func readListFile(path, name string) ([]string, error) {
+	logger.Debug("Loading "+name, "path", path)
+
+	// Create directory if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create %s directory: %w", name, err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		logger.Info("No "+name+" file, starting with an empty "+name, "path", path)
+		return nil, nil
+	}
+
+	// #nosec G304 -- path is a hardcoded path from user home directory
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s file: %w", name, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Warn("Failed to close "+name+" file", "error", err)
 		}
-	} else {
-		log.Printf("Allowlist file not found, starting with empty allowlist")
+	}()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
 	}
 
-	return nil
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s file: %w", name, err)
+	}
+
+	return lines, nil
 }
 
-func (s *Server) setFocusMode(enabled bool, duration time.Duration) error {
-	log.Printf("Setting focus mode: enabled=%v, duration=%v", enabled, duration)
+func (s *Server) setFocusMode(enabled bool, opts *api.FocusOptions) error {
+	logger.Info("Setting focus mode", "enabled", enabled, "duration", opts.Duration, "profile", opts.Profile)
+
+	// Apply profile defaults before touching any state
+	if enabled && opts.Profile != "" {
+		profile, err := s.config.GetProfile(opts.Profile)
+		if err != nil {
+			return err
+		}
+		if opts.Duration == 0 {
+			duration, err := profile.GetDuration()
+			if err != nil {
+				return err
+			}
+			opts.Duration = duration
+		}
+	}
+	if enabled {
+		intensity := opts.Intensity
+		if intensity == "" {
+			intensity = s.config.FocusIntensity
+		}
+		intensity, err := config.ParseIntensity(intensity)
+		if err != nil {
+			return err
+		}
+		opts.Intensity = intensity
+	}
+	if s.loosensFocusMode(enabled, opts) {
+		if err := s.verifyPIN(opts.PIN); err != nil {
+			return err
+		}
+	}
+	if enabled {
+		grace, err := s.config.GetFocusGracePeriod()
+		if err != nil {
+			return err
+		}
+		opts.GracePeriod = grace
+	} else {
+		disableAt, err := s.queueFocusDisable()
+		if err != nil {
+			return err
+		}
+		if disableAt != nil {
+			opts.DisableAt = disableAt
+			return nil
+		}
+	}
+	duration := opts.Duration
 
 	// Set focus mode in memory
 	s.focusMutex.Lock()
+	var ended *api.FocusSession
+	if !enabled || s.focusExpired(clock.Now()) {
+		ended = s.endedSession(clock.Now())
+	}
 	s.focusMode = enabled
+	s.focusPausedUntil = nil
+	s.focusRemaining = 0
+	s.focusBreak = false
+	s.focusProfile = ""
+	s.focusIntensity = ""
+	s.focusLabel = ""
+	s.snoozes = nil
+	s.focusGraceUntil = nil
+	s.focusDryRun = false
+	if enabled {
+		s.focusProfile = opts.Profile
+		s.focusIntensity = opts.Intensity
+		s.focusLabel = opts.Label
+		s.focusDryRun = opts.DryRun
+		s.focusStartedAt = time.Now()
+		if opts.DryRun {
+			logger.Info("Focus mode dry run: blocked queries are only recorded")
+		}
+		if opts.GracePeriod > 0 {
+			graceUntil := clock.Now().Add(opts.GracePeriod)
+			s.focusGraceUntil = &graceUntil
+			logger.Info("Focus mode grace period", "blocking_starts", graceUntil)
+		}
+	}
 	if enabled && duration > 0 {
-		endTime := time.Now().Add(duration)
+		endTime := clock.Now().Add(duration)
 		s.focusEndTime = &endTime
-		log.Printf("Focus mode enabled until %v", endTime)
+		logger.Info("Focus mode enabled", "until", endTime)
 	} else {
 		s.focusEndTime = nil
 		if enabled {
-			log.Printf("Focus mode enabled indefinitely")
+			logger.Info("Focus mode enabled indefinitely")
 		} else {
-			log.Printf("Focus mode disabled")
+			logger.Info("Focus mode disabled")
 		}
 	}
 	s.focusMutex.Unlock()
-
-	// Reload allowlist when enabling focus mode to pick up any changes
-	if enabled {
-		log.Printf("Reloading allowlist for focus session")
-		if err := s.loadAllowlist(); err != nil {
-			log.Printf("Warning: failed to reload allowlist: %v", err)
-		} else {
-			log.Printf("Allowlist reloaded successfully for focus session")
+	s.recordSession(ended)
+
+	// Persist the session so it survives resolver restarts
+	if s.stateManager != nil {
+		if err := s.stateManager.SetFocusSession(enabled, config.FocusSessionOptions{
+			Duration:  duration,
+			Profile:   opts.Profile,
+			Intensity: opts.Intensity,
+			Label:     opts.Label,
+			DryRun:    opts.DryRun,
+		}); err != nil {
+			logger.Warn("Failed to persist focus state", "error", err)
 		}
 	}
 
+	// Reload allowlist to pick up any changes and switch between profile and default allowlists
+	logger.Debug("Reloading allowlist for focus session")
+	if err := s.loadAllowlist(); err != nil {
+		logger.Warn("Failed to reload allowlist", "error", err)
+	} else {
+		logger.Debug("Allowlist reloaded for focus session")
+	}
+
 	return nil
 }
 
-func (s *Server) createPIDFile() error {
-	homeDir, err := os.UserHomeDir()
+// focusExpired reports whether an active session ran past its end time without being disabled
+// yet. This method assumes the caller holds the focus lock.
+func (s *Server) focusExpired(now time.Time) bool {
+	return s.focusMode && s.focusEndTime != nil && now.After(*s.focusEndTime)
+}
+
+// endedSession describes the active session as it ends now, or at its end time when that
+// passed already; nil when no session is active. This method assumes the caller holds the
+// focus lock.
+func (s *Server) endedSession(now time.Time) *api.FocusSession {
+	if !s.focusMode {
+		return nil
+	}
+	end := now
+	if s.focusEndTime != nil && s.focusEndTime.Before(end) {
+		end = *s.focusEndTime
+	}
+	return &api.FocusSession{
+		Start:     s.focusStartedAt,
+		End:       end,
+		Profile:   s.focusProfile,
+		Intensity: s.focusIntensity,
+		Label:     s.focusLabel,
+		DryRun:    s.focusDryRun,
+	}
+}
+
+// recordSession adds a session that ended to the session history, kept by the API server's
+// Storage
+func (s *Server) recordSession(session *api.FocusSession) {
+	if session != nil && s.apiServer != nil {
+		s.apiServer.RecordSession(*session)
+	}
+}
+
+// queueFocusDisable defers disabling an active session by focus_disable_delay, returning when
+// focus mode will end. It returns nil when the disable should take effect immediately.
+func (s *Server) queueFocusDisable() (*time.Time, error) {
+	delay, err := s.config.GetFocusDisableDelay()
+	if err != nil || delay == 0 {
+		return nil, err
+	}
+
+	s.focusMutex.Lock()
+	now := clock.Now()
+	if !s.focusMode || (s.focusEndTime != nil && now.After(*s.focusEndTime)) {
+		s.focusMutex.Unlock()
+		return nil, nil
+	}
+
+	disableAt := now.Add(delay)
+	var remaining time.Duration
+	if s.focusPausedUntil != nil {
+		if !disableAt.After(*s.focusPausedUntil) {
+			// Blocking stays lifted until the queued disable anyway
+			s.focusMutex.Unlock()
+			return nil, nil
+		}
+		if s.focusRemaining == 0 || s.focusPausedUntil.Add(s.focusRemaining).After(disableAt) {
+			s.focusRemaining = disableAt.Sub(*s.focusPausedUntil)
+		}
+		disableAt = s.focusPausedUntil.Add(s.focusRemaining)
+		remaining = disableAt.Sub(now)
+	} else {
+		// Never extend a session that already ends before the delay
+		if s.focusEndTime == nil || s.focusEndTime.After(disableAt) {
+			s.focusEndTime = &disableAt
+		}
+		disableAt = *s.focusEndTime
+		remaining = disableAt.Sub(now)
+	}
+	session := config.FocusSessionOptions{
+		Duration:  remaining,
+		Profile:   s.focusProfile,
+		Intensity: s.focusIntensity,
+		Label:     s.focusLabel,
+		DryRun:    s.focusDryRun,
+	}
+	s.focusMutex.Unlock()
+
+	logger.Info("Focus mode disable queued", "ends", disableAt)
+
+	if s.stateManager != nil {
+		if err := s.stateManager.SetFocusSession(true, session); err != nil {
+			logger.Warn("Failed to persist focus state", "error", err)
+		}
+	}
+
+	return &disableAt, nil
+}
+
+// sealState signs the saved focus session from now on, so editing state.json can't end a
+// session early, and checks the signature of the session saved last
+func (s *Server) sealState() {
+	key, created, err := s.loadStateKey()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		logger.Warn("Focus sessions in state.json won't be signed", "error", err)
+		return
+	}
+	if err := s.stateManager.SetSealKey(key, created); errors.Is(err, config.ErrStateTampered) {
+		logger.Warn(err.Error())
+		s.stateTampered = true
+	} else if err != nil {
+		logger.Warn("Failed to sign the focus session", "error", err)
+	}
+}
+
+// stateKey is the result of config.LoadStateKey
+type stateKey struct {
+	key     []byte
+	created bool
+	err     error
+}
+
+// loadStateKey returns the key loaded by Listen, or loads it now
+func (s *Server) loadStateKey() ([]byte, bool, error) {
+	if s.stateKey != nil {
+		return s.stateKey.key, s.stateKey.created, s.stateKey.err
+	}
+	return config.LoadStateKey()
+}
+
+// autoStartFocusMode enables focus mode at startup according to focus_on_start
+func (s *Server) autoStartFocusMode() error {
+	mode, duration, err := s.config.GetFocusOnStart()
+	if err != nil || mode == "" {
+		return err
+	}
+
+	req := api.FocusRequest{Enabled: true}
+	switch mode {
+	case config.FocusOnStartResume:
+		if s.stateManager == nil {
+			return fmt.Errorf("no persisted state available to resume")
+		}
+		state := s.stateManager.GetState()
+		if s.stateTampered && s.config.FocusPINHash != "" {
+			// The signed end time is unknown, so the PIN is the only way out
+			logger.Warn("Resuming focus mode without an end time because state.json was edited; disable it with the PIN")
+			if _, err := s.config.GetProfile(state.FocusProfile); err == nil {
+				req.Profile = state.FocusProfile
+			}
+			req.Intensity = state.FocusIntensity
+			req.Label = state.FocusLabel
+			break
+		}
+		if !state.FocusMode {
+			logger.Info("No focus session to resume")
+			return nil
+		}
+		if state.FocusEndTime != nil {
+			remaining := time.Until(*state.FocusEndTime)
+			if remaining <= 0 {
+				logger.Info("Previous focus session has already ended")
+				return nil
+			}
+			req.Duration = remaining.String()
+		}
+		req.Profile = state.FocusProfile
+		req.Intensity = state.FocusIntensity
+		req.Label = state.FocusLabel
+		req.DryRun = state.FocusDryRun
+	case "duration":
+		req.Duration = duration.String()
+	}
+
+	logger.Info("Auto-starting focus mode", "focus_on_start", s.config.FocusOnStart)
+
+	// Go through the API server when available so both servers agree on the session
+	if s.apiServer != nil {
+		return s.apiServer.ApplyFocusMode(req)
+	}
+	opts := &api.FocusOptions{Profile: req.Profile, Intensity: req.Intensity, Label: req.Label, DryRun: req.DryRun}
+	if req.Duration != "" {
+		if opts.Duration, err = time.ParseDuration(req.Duration); err != nil {
+			return err
+		}
+	}
+	return s.setFocusMode(true, opts)
+}
+
+// loosensFocusMode reports whether the requested change disables, shortens, lowers the
+// intensity of, or switches the profile of an active focus session
+func (s *Server) loosensFocusMode(enabled bool, opts *api.FocusOptions) bool {
+	s.focusMutex.RLock()
+	defer s.focusMutex.RUnlock()
+
+	now := clock.Now()
+	if !s.focusMode || (s.focusEndTime != nil && now.After(*s.focusEndTime)) {
+		return false
+	}
+	if !enabled || opts.Profile != s.focusProfile || (opts.DryRun && !s.focusDryRun) {
+		return true
+	}
+	if intensityRank(opts.Intensity) < intensityRank(s.focusIntensity) {
+		return true
+	}
+
+	// Work out when the current session ends (nil = no expiration)
+	var currentEnd *time.Time
+	switch {
+	case s.focusPausedUntil != nil && s.focusRemaining > 0:
+		end := s.focusPausedUntil.Add(s.focusRemaining)
+		currentEnd = &end
+	case s.focusPausedUntil == nil:
+		currentEnd = s.focusEndTime
+	}
+
+	if currentEnd == nil {
+		return opts.Duration > 0
+	}
+	return opts.Duration > 0 && now.Add(opts.Duration).Before(*currentEnd)
+}
+
+// verifyPIN checks the PIN against the configured hash, locking out repeated failures
+func (s *Server) verifyPIN(pin string) error {
+	if s.config.FocusPINHash == "" {
+		return nil
+	}
+
+	s.pinMutex.Lock()
+	defer s.pinMutex.Unlock()
+
+	if time.Now().Before(s.pinLockedUntil) {
+		return fmt.Errorf("%w: too many failed attempts, try again after %s", api.ErrInvalidPIN, s.pinLockedUntil.Format("15:04:05"))
+	}
+	if pin == "" {
+		return api.ErrPINRequired
+	}
+	if !config.VerifyPIN(s.config.FocusPINHash, pin) {
+		s.pinFailures++
+		logger.Warn("Invalid focus PIN attempt", "failures", s.pinFailures, "max", maxPINFailures)
+		if s.pinFailures >= maxPINFailures {
+			s.pinLockedUntil = time.Now().Add(pinLockDuration)
+			s.pinFailures = 0
+		}
+		return api.ErrInvalidPIN
+	}
+
+	s.pinFailures = 0
+	return nil
+}
+
+// verifyShutdownPIN checks the PIN of a request to stop the resolver, which would end a
+// PIN-protected focus session
+func (s *Server) verifyShutdownPIN(pin string) error {
+	if !s.pinLocked() {
+		return nil
 	}
+	return s.verifyPIN(pin)
+}
 
-	var pidDir string
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			pidDir = filepath.Join(appData, "sinkzone")
+// pauseFocusMode suspends blocking for the given duration, preserving the remaining focus time
+func (s *Server) pauseFocusMode(paused bool, opts api.PauseOptions) error {
+	if paused {
+		if err := s.verifyPIN(opts.PIN); err != nil {
+			return err
+		}
+	}
+
+	s.focusMutex.Lock()
+	wasBreak := s.focusBreak
+
+	if paused {
+		if !s.focusMode {
+			s.focusMutex.Unlock()
+			return fmt.Errorf("focus mode is not active")
+		}
+		s.focusRemaining = 0
+		if s.focusEndTime != nil {
+			s.focusRemaining = time.Until(*s.focusEndTime)
+		}
+		pausedUntil := clock.Now().Add(opts.Duration)
+		s.focusPausedUntil = &pausedUntil
+		s.focusEndTime = nil
+		s.focusBreak = opts.Break
+		if opts.Break {
+			logger.Info("Focus mode break", "until", pausedUntil, "remaining", s.focusRemaining)
 		} else {
-			pidDir = filepath.Join(homeDir, "sinkzone")
+			logger.Info("Focus mode paused", "until", pausedUntil, "remaining", s.focusRemaining)
 		}
 	} else {
-		// Unix-like systems use ~/.sinkzone/
-		pidDir = filepath.Join(homeDir, ".sinkzone")
+		if s.focusPausedUntil == nil {
+			s.focusMutex.Unlock()
+			return fmt.Errorf("focus mode is not paused")
+		}
+		s.resumeFocusMode(clock.Now())
+	}
+	s.focusMutex.Unlock()
+
+	// Break domains are only part of the allowlist during a break
+	if opts.Break || wasBreak {
+		if err := s.loadAllowlist(); err != nil {
+			logger.Warn("Failed to reload allowlist", "error", err)
+		}
+	}
+	return nil
+}
+
+// resumeFocusMode restores the preserved focus time starting at the given instant
+// This method assumes the caller holds the focus write lock
+func (s *Server) resumeFocusMode(at time.Time) {
+	if s.focusRemaining > 0 {
+		endTime := at.Add(s.focusRemaining)
+		s.focusEndTime = &endTime
+		logger.Info("Focus mode resumed", "until", endTime)
+	} else {
+		logger.Info("Focus mode resumed indefinitely")
+	}
+	s.focusPausedUntil = nil
+	s.focusRemaining = 0
+}
+
+// focusTrackInterval is how often active focus time is added to the daily totals
+const focusTrackInterval = time.Minute
+
+// trackFocusTime records time spent in active (unpaused) focus mode for daily goals
+func (s *Server) trackFocusTime() {
+	ticker := time.NewTicker(focusTrackInterval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	for now := range ticker.C {
+		s.focusMutex.RLock()
+		// A dry run blocks nothing, so it doesn't count as focus time
+		active := s.focusMode && !s.focusDryRun && !s.dryRun.Load() && s.focusPausedUntil == nil
+		label := s.focusLabel
+		// Measured on the monotonic clock, so changing the system time adds no focus time
+		start := now.Add(-now.Sub(last))
+		end := now
+		if active && s.focusEndTime != nil && s.focusEndTime.Before(end) {
+			end = *s.focusEndTime
+		}
+		s.focusMutex.RUnlock()
+		last = now
+
+		if !active {
+			continue
+		}
+		if err := s.stateManager.AddFocusTime(start, end, label); err != nil {
+			logger.Warn("Failed to record focus time", "error", err)
+		}
+	}
+}
+
+// focusStats reports daily goal progress for the API
+func (s *Server) focusStats() (*api.FocusStats, error) {
+	if s.stateManager == nil {
+		return nil, fmt.Errorf("focus history is not available")
+	}
+	goal, err := s.config.GetDailyGoal()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	stats := toFocusStats(s.stateManager.GoalStats(goal, now))
+	stats.Budgets = s.budgets.status(now)
+	for _, label := range s.stateManager.LabelTimes(now) {
+		stats.Labels = append(stats.Labels, api.LabelStats{
+			Label: label.Label,
+			Today: label.Today.Round(time.Minute).String(),
+			Total: label.Total.Round(time.Minute).String(),
+		})
+	}
+	return stats, nil
+}
+
+// toFocusStats converts goal stats to their API representation
+func toFocusStats(stats config.GoalStats) *api.FocusStats {
+	result := &api.FocusStats{
+		Today:         stats.Today.Round(time.Minute).String(),
+		Week:          stats.Week.Round(time.Minute).String(),
+		GoalMet:       stats.Met(),
+		Streak:        stats.Streak,
+		LongestStreak: stats.LongestStreak,
 	}
+	if stats.Goal > 0 {
+		result.Goal = stats.Goal.String()
+		result.Progress = float64(stats.Today) / float64(stats.Goal)
+	}
+	return result
+}
 
+func (s *Server) createPIDFile() error {
+	pidDir := config.GetDataDir()
 	if err := os.MkdirAll(pidDir, 0750); err != nil {
 		return fmt.Errorf("failed to create config directory: %w", err)
 	}
@@ -256,34 +1227,16 @@ func (s *Server) createPIDFile() error {
 }
 
 func (s *Server) cleanupPIDFile() {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		log.Printf("Warning: failed to get home directory for PID cleanup: %v", err)
-		return
-	}
-
-	var pidFile string
-	if runtime.GOOS == "windows" {
-		// On Windows, use AppData for better compatibility
-		appData := os.Getenv("APPDATA")
-		if appData != "" {
-			pidFile = filepath.Join(appData, "sinkzone", "resolver.pid")
-		} else {
-			pidFile = filepath.Join(homeDir, "sinkzone", "resolver.pid")
-		}
-	} else {
-		// Unix-like systems use ~/.sinkzone/
-		pidFile = filepath.Join(homeDir, ".sinkzone", "resolver.pid")
-	}
+	pidFile := filepath.Join(config.GetDataDir(), "resolver.pid")
 
 	if err := os.Remove(pidFile); err != nil {
 		if os.IsNotExist(err) {
 			// PID file doesn't exist, which is fine
 			return
 		}
-		log.Printf("Warning: failed to remove PID file: %v", err)
+		logger.Warn("Failed to remove PID file", "error", err)
 	} else {
-		log.Printf("PID file cleaned up successfully")
+		logger.Info("PID file cleaned up")
 	}
 }
 
@@ -299,133 +1252,441 @@ func (s *Server) handleRequest(w dns.ResponseWriter, r *dns.Msg) {
 		domain = strings.TrimSuffix(r.Question[0].Name, ".")
 	}
 
+	ctx, span := tracing.Start(context.Background(), "dns.query", tracing.KindServer)
+	defer span.End()
+	span.SetAttribute("dns.question.name", domain)
+	ip := clientIP(w.RemoteAddr())
+	client := s.recordedClient(ip)
+	span.SetAttribute("client.address", client)
+	if len(r.Question) > 0 {
+		span.SetAttribute("dns.question.type", dns.TypeToString[r.Question[0].Qtype])
+	}
+
 	// Log the incoming DNS request
-	log.Printf("DNS Request: %s from %s", domain, w.RemoteAddr())
+	logger.Debug("DNS request", "domain", domain, "client", client)
+	queriesTotal.Inc()
+
+	s.settingsMutex.RLock()
+	limiter, cache := s.limiter, s.cache
+	s.settingsMutex.RUnlock()
+
+	// Refuse clients on cooldown or over the rate limit without recording their queries
+	if s.cooldowns.refused(ip, start) {
+		logger.Debug("DNS response: REFUSED (cooldown)", "domain", domain, "client", client)
+		msg.SetRcode(r, dns.RcodeRefused)
+		cooldownRefusedTotal.Inc()
+		observeResponse(msg.Rcode, start)
+		span.SetAttribute("sinkzone.cooldown", true)
+		if err := writeMsg(ctx, w, &msg); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		}
+		return
+	}
+	if !limiter.allow(ip, start) {
+		logger.Debug("DNS response: REFUSED (rate limit)", "domain", domain, "client", client)
+		msg.SetRcode(r, dns.RcodeRefused)
+		rateLimitedTotal.Inc()
+		observeResponse(msg.Rcode, start)
+		span.SetAttribute("sinkzone.rate_limited", true)
+		if err := writeMsg(ctx, w, &msg); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		}
+		s.strike(ip, strikeRateLimit, start)
+		return
+	}
+
+	// Answer the bypass check's names without forwarding or recording them
+	if s.bypass.canary(domain) {
+		msg.SetRcode(r, dns.RcodeNameError)
+		observeResponse(msg.Rcode, start)
+		if err := writeMsg(ctx, w, &msg); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		}
+		return
+	}
+
+	// Move the session's deadlines first if the system time was changed
+	s.reconcileClock()
 
 	// Check if we're in focus mode
 	s.focusMutex.RLock()
 	focusMode := s.focusMode
 	focusEndTime := s.focusEndTime
+	focusPausedUntil := s.focusPausedUntil
+	focusBreak := s.focusBreak
+	focusGraceUntil := s.focusGraceUntil
+	focusDryRun := s.focusDryRun || s.dryRun.Load()
+	focusIntensity := s.focusIntensity
+	focusProfile := s.focusProfile
+	focusStartedAt := s.focusStartedAt
 	s.focusMutex.RUnlock()
 
+	// Resume focus mode once the pause has elapsed
+	if focusPausedUntil != nil {
+		if clock.Now().After(*focusPausedUntil) {
+			s.focusMutex.Lock()
+			if s.focusPausedUntil != nil {
+				s.resumeFocusMode(*s.focusPausedUntil)
+			}
+			focusEndTime = s.focusEndTime
+			s.focusMutex.Unlock()
+			if focusBreak {
+				// Drop the break domains from the allowlist again
+				if err := s.loadAllowlist(); err != nil {
+					logger.Warn("Failed to reload allowlist", "error", err)
+				}
+			}
+		} else if !focusBreak {
+			// Blocking is suspended while paused; during a break only the break domains are let through
+			focusMode = false
+		}
+	}
+
 	// Check for expiration
-	if focusMode && focusEndTime != nil && time.Now().After(*focusEndTime) {
+	if focusMode && focusEndTime != nil && clock.Now().After(*focusEndTime) {
 		// Focus mode has expired, disable it
 		s.focusMutex.Lock()
+		ended := s.endedSession(clock.Now())
 		s.focusMode = false
 		s.focusEndTime = nil
+		s.focusProfile = ""
+		s.focusIntensity = ""
+		s.focusLabel = ""
+		s.focusDryRun = false
+		s.snoozes = nil
 		s.focusMutex.Unlock()
+		s.recordSession(ended)
 		focusMode = false
-		log.Printf("Focus mode expired and disabled")
+		logger.Info("Focus mode expired and disabled")
+	}
+
+	// During the grace period blocked queries are only warned about
+	inGracePeriod := focusMode && focusGraceUntil != nil && clock.Now().Before(*focusGraceUntil)
+
+	// So is every query while a captive portal's login page is let through
+	passthrough := s.portal.passthrough(start)
+
+	// A device, or an account of this machine, may be kept in focus, or out of it, whatever
+	// the session
+	mac := s.clientMAC(ip)
+	user, policy := s.queryUser(w.RemoteAddr())
+	if override, ok := s.focusOverride(clock.Now(), user, policy, mac, client); ok {
+		focusMode = override.Mode == config.DeviceFocusOn
+		inGracePeriod = inGracePeriod && focusMode
 	}
 
 	// Log the request and record query
+	blocked := false
+	wouldBlock := false
+	var query *api.DNSQuery
 	if domain != "" {
-		blocked := focusMode && !s.isAllowed(domain)
-
-		// Add to API server if available
-		if s.apiServer != nil {
-			query := api.DNSQuery{
-				Domain:    domain,
-				Timestamp: time.Now(),
-				Blocked:   blocked,
+		_, checkSpan := tracing.Start(ctx, "dns.allowlist_check", tracing.KindInternal)
+		firstSeen, seen := s.markSeen(domain, start)
+		seenBeforeSession := seen && firstSeen.Before(focusStartedAt)
+		reason := ""
+		clientName := s.clientName(ip)
+		action, rule := s.ruleAction(rules.Input{
+			Domain:     domain,
+			Client:     client,
+			ClientName: clientName,
+			User:       user,
+			Time:       clock.Now(),
+			Focus:      focusMode,
+			Profile:    focusProfile,
+			Intensity:  focusIntensity,
+		})
+		scheduled, inSchedule := s.scheduledBlock(domain, clock.Now())
+		budget, exhausted := s.budgets.exhausted(domain, start)
+		switch {
+		case action != "":
+			reason = ruleReason(action, rule)
+			blocked = action == config.RuleBlock
+		case inSchedule:
+			reason = scheduleReason(scheduled)
+			blocked = true
+		case exhausted:
+			reason = budgetReason(budget)
+			blocked = true
+		case focusMode:
+			reason = s.blockReason(domain, focusIntensity, seenBeforeSession)
+			if reason != "" && policy.allows(domain) && s.denyReason(domain) == "" {
+				reason = ""
+				logger.Debug("Allowed for the user", "domain", domain, "user", user)
+			}
+			blocked = reason != ""
+		}
+		if blocked && s.isSnoozed(strings.ToLower(domain), start) {
+			logger.Debug("Snoozed domain allowed", "domain", domain)
+			blocked = false
+			reason = "snoozed during the focus session"
+		}
+		switch {
+		case blocked && focusDryRun:
+			blocked = false
+			wouldBlock = true
+			reason += " (dry run, not enforced)"
+		case blocked && inGracePeriod:
+			blocked = false
+			wouldBlock = true
+			reason += " (grace period, not enforced yet)"
+		case blocked && passthrough:
+			blocked = false
+			wouldBlock = true
+			reason += " (captive portal passthrough, not enforced)"
+		}
+		if wouldBlock {
+			wouldBlockTotal.Inc()
+		}
+		if !blocked {
+			s.budgets.use(domain, start)
+		}
+		checkSpan.SetAttribute("sinkzone.focus_mode", focusMode)
+		checkSpan.SetAttribute("sinkzone.blocked", blocked)
+		checkSpan.SetAttribute("sinkzone.would_block", wouldBlock)
+		if reason != "" {
+			checkSpan.SetAttribute("sinkzone.reason", reason)
+		}
+		checkSpan.End()
+
+		// Recorded in the API server once the response has been sent, unless ignored
+		if s.isIgnored(domain) {
+			ignoredTotal.Inc()
+		} else if s.apiServer != nil {
+			query = &api.DNSQuery{
+				Domain:     domain,
+				Client:     client,
+				ClientName: clientName,
+				ClientMAC:  mac,
+				User:       s.recordedUser(user),
+				Timestamp:  time.Now(),
+				Blocked:    blocked,
+				WouldBlock: wouldBlock,
+				QueryType:  dns.TypeToString[r.Question[0].Qtype],
+				Reason:     reason,
 			}
-			s.apiServer.AddQuery(query)
-			log.Printf("DNS Query recorded in API: %s (blocked: %v)", domain, blocked)
+			defer func() {
+				query.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+				s.apiServer.AddQuery(*query)
+				logger.Debug("DNS query recorded in API", "domain", domain, "blocked", blocked)
+			}()
 		}
 
 		// Check if domain is in allowlist for logging purposes
 		isAllowed := s.isAllowed(domain)
 
 		if focusMode {
-			if blocked {
-				log.Printf("BLOCKED: %s (focus mode active)", domain)
+			if wouldBlock && focusDryRun {
+				logger.Debug("Would be blocked (dry run)", "domain", domain)
+			} else if wouldBlock && passthrough {
+				logger.Debug("Would be blocked (captive portal passthrough)", "domain", domain)
+			} else if wouldBlock {
+				logger.Debug("Would be blocked (grace period)", "domain", domain, "grace_until", focusGraceUntil.Format("15:04:05"))
+			} else if blocked {
+				logger.Debug("Blocked", "domain", domain, "intensity", focusIntensity)
 			} else {
-				log.Printf("ALLOWED: %s (in allowlist)", domain)
+				logger.Debug("Allowed (in allowlist)", "domain", domain)
 			}
 		} else {
 			// In normal mode, show what would happen if focus mode were active
 			if isAllowed {
-				log.Printf("DNS request: %s (normal mode) - would be ALLOWED in focus mode", domain)
+				logger.Debug("Normal mode: would be allowed in focus mode", "domain", domain)
 			} else {
-				log.Printf("DNS request: %s (normal mode) - would be BLOCKED in focus mode", domain)
+				logger.Debug("Normal mode: would be blocked in focus mode", "domain", domain)
 			}
 		}
 	}
 
-	// If in focus mode, check allowlist
-	if focusMode {
-		if !s.isAllowed(domain) {
-			// Return NXDOMAIN for blocked domains
-			msg.SetRcode(r, dns.RcodeNameError)
-
-			// Add SOA record for negative response with 5-minute TTL
-			soa := &dns.SOA{
-				Hdr: dns.RR_Header{
-					Name:   r.Question[0].Name,
-					Rrtype: dns.TypeSOA,
-					Class:  dns.ClassINET,
-					Ttl:    300, // 5 minutes
-				},
-				Ns:      "sinkzone.local.",
-				Mbox:    "admin.sinkzone.local.",
-				Serial:  getDNSSerial(),
-				Refresh: 300,
-				Retry:   300,
-				Expire:  300,
-				Minttl:  300,
-			}
-			msg.Ns = append(msg.Ns, soa)
+	// If in focus mode, block domains that didn't pass the intensity check
+	if blocked {
+		s.writeBlocked(r, &msg)
+		if query != nil {
+			query.Rcode = dns.RcodeToString[msg.Rcode]
+		}
+		blockedTotal.Inc()
+		observeResponse(msg.Rcode, start)
+		span.SetAttribute("sinkzone.blocked", true)
+		span.SetAttribute("dns.response.code", dns.RcodeToString[msg.Rcode])
 
-			if err := w.WriteMsg(&msg); err != nil {
-				log.Printf("Warning: failed to write DNS response: %v", err)
-			} else {
-				log.Printf("DNS Response: %s - NXDOMAIN (blocked) (%v)", domain, time.Since(start))
-			}
-			return
+		if err := writeMsg(ctx, w, &msg); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		} else {
+			logger.Debug("DNS response (blocked)", "domain", domain, "rcode", dns.RcodeToString[msg.Rcode], "took", time.Since(start))
+		}
+		return
+	}
+
+	// Names only the local network knows never reach the public upstreams
+	if local := s.privateAnswer(r); local != nil {
+		if query != nil {
+			query.Rcode = dns.RcodeToString[local.Rcode]
+			query.Upstream = "local"
+		}
+		observeResponse(local.Rcode, start)
+		span.SetAttribute("sinkzone.upstream", "local")
+		span.SetAttribute("dns.response.code", dns.RcodeToString[local.Rcode])
+		if err := writeMsg(ctx, w, local); err != nil {
+			logger.Warn("Failed to write DNS response", "error", err)
+		} else {
+			logger.Debug("DNS response (private name)", "domain", domain, "rcode", dns.RcodeToString[local.Rcode], "took", time.Since(start))
 		}
+		return
 	}
 
-	// Forward to upstream nameservers
-	response, err := s.forward(r)
+	// Answer from the cache, or forward to upstream nameservers
+	var response *dns.Msg
+	upstream := "cache"
+	var err error
+	forwardCtx, forwardSpan := tracing.Start(ctx, "dns.forward", tracing.KindInternal)
+	if response = cache.get(r, start); response == nil {
+		forwardedTotal.Inc()
+		response, upstream, err = s.forward(forwardCtx, r)
+		if err == nil {
+			cache.put(r, response, time.Now())
+		} else if stale := cache.getStale(r, time.Now()); stale != nil {
+			logger.Warn("Forward failed, answering from the expired cache", "domain", domain, "error", err)
+			response, upstream, err = stale, "stale", nil
+		}
+	}
+	forwardSpan.SetAttribute("sinkzone.cache_hit", upstream == "cache")
+	forwardSpan.SetError(err)
+	forwardSpan.End()
 	if err != nil {
-		log.Printf("Forward error: %v", err)
+		logger.Warn("Forward failed", "domain", domain, "error", err)
+		s.observePortal(domain, true, start)
 		msg.SetRcode(r, dns.RcodeServerFailure)
-		if err := w.WriteMsg(&msg); err != nil {
-			log.Printf("Warning: failed to write DNS error response: %v", err)
+		if query != nil {
+			query.Rcode = dns.RcodeToString[msg.Rcode]
+		}
+		observeResponse(msg.Rcode, start)
+		span.SetError(err)
+		span.SetAttribute("dns.response.code", dns.RcodeToString[msg.Rcode])
+		if err := writeMsg(ctx, w, &msg); err != nil {
+			logger.Warn("Failed to write DNS error response", "error", err)
 		} else {
-			log.Printf("DNS Response: %s - SERVFAIL (forward error) (%v)", domain, time.Since(start))
+			logger.Debug("DNS response: SERVFAIL (forward error)", "domain", domain, "took", time.Since(start))
 		}
 		return
 	}
 
-	if err := w.WriteMsg(response); err != nil {
-		log.Printf("Warning: failed to write DNS response: %v", err)
+	if upstream != "cache" && upstream != "stale" {
+		s.observePortal(domain, response.Rcode == dns.RcodeNameError || response.Rcode == dns.RcodeServerFailure, start)
+	}
+	if query != nil {
+		query.Rcode = dns.RcodeToString[response.Rcode]
+		query.Upstream = upstream
+		query.CNAMEs = cnameTargets(response)
+	}
+	observeResponse(response.Rcode, start)
+	span.SetAttribute("dns.response.code", dns.RcodeToString[response.Rcode])
+	span.SetAttribute("sinkzone.upstream", upstream)
+
+	if err := writeMsg(ctx, w, response); err != nil {
+		logger.Warn("Failed to write DNS response", "error", err)
 	} else {
-		log.Printf("DNS Response: %s - %s (%v)", domain, dns.RcodeToString[response.Rcode], time.Since(start))
+		logger.Debug("DNS response", "domain", domain, "rcode", dns.RcodeToString[response.Rcode], "took", time.Since(start))
+	}
+}
+
+// cnameTargets returns the names an answer points to with CNAME records, in order
+func cnameTargets(response *dns.Msg) []string {
+	var targets []string
+	for _, record := range response.Answer {
+		if cname, ok := record.(*dns.CNAME); ok {
+			targets = append(targets, strings.TrimSuffix(cname.Target, "."))
+		}
 	}
+	return targets
 }
 
-func (s *Server) forward(r *dns.Msg) (*dns.Msg, error) {
-	client := &dns.Client{
-		Timeout: 5 * time.Second,
+// writeBlocked fills msg with the configured answer to a blocked query
+func (s *Server) writeBlocked(r *dns.Msg, msg *dns.Msg) {
+	s.settingsMutex.RLock()
+	blockResponse, ttl := s.blockResponse, s.blockedTTL
+	s.settingsMutex.RUnlock()
+
+	if blockResponse == config.BlockResponseRefused {
+		msg.SetRcode(r, dns.RcodeRefused)
+		return
 	}
 
-	upstreams := s.config.GetUpstreamAddresses()
-	log.Printf("Forwarding DNS request to %d upstream servers: %v", len(upstreams), upstreams)
+	question := r.Question[0]
+	header := dns.RR_Header{Name: question.Name, Rrtype: question.Qtype, Class: dns.ClassINET, Ttl: ttl}
+	if blockResponse == config.BlockResponseNullIP {
+		msg.SetRcode(r, dns.RcodeSuccess)
+		switch question.Qtype {
+		case dns.TypeA:
+			msg.Answer = append(msg.Answer, &dns.A{Hdr: header, A: net.IPv4zero})
+			return
+		case dns.TypeAAAA:
+			msg.Answer = append(msg.Answer, &dns.AAAA{Hdr: header, AAAA: net.IPv6zero})
+			return
+		}
+		// Other record types get an empty answer (NODATA) with the SOA below
+	} else {
+		msg.SetRcode(r, dns.RcodeNameError)
+	}
 
-	for i, upstream := range upstreams {
-		log.Printf("Trying upstream %d/%d: %s", i+1, len(upstreams), upstream)
-		response, _, err := client.Exchange(r, upstream)
+	msg.Ns = append(msg.Ns, negativeSOA(question.Name, ttl))
+}
+
+// negativeSOA returns the SOA sent with NXDOMAIN and empty answers, which tells clients how
+// long to cache them
+func negativeSOA(name string, ttl uint32) *dns.SOA {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:      "sinkzone.local.",
+		Mbox:    "admin.sinkzone.local.",
+		Serial:  getDNSSerial(),
+		Refresh: ttl,
+		Retry:   ttl,
+		Expire:  ttl,
+		Minttl:  ttl,
+	}
+}
+
+// writeMsg sends a response, traced as a child of the query's span
+func writeMsg(ctx context.Context, w dns.ResponseWriter, msg *dns.Msg) error {
+	_, span := tracing.Start(ctx, "dns.write", tracing.KindInternal)
+	defer span.End()
+	err := w.WriteMsg(msg)
+	span.SetError(err)
+	return err
+}
+
+// forward sends a query to the upstream nameservers in order, returning the response and the upstream that answered
+func (s *Server) forward(ctx context.Context, r *dns.Msg) (*dns.Msg, string, error) {
+	upstreams, forwarder := s.upstreamsFor(r)
+	logger.Debug("Forwarding DNS request", "upstreams", len(upstreams))
+
+	for i, upstream := range s.upstreamOrder(upstreams) {
+		logger.Debug("Trying upstream", "upstream", upstream.String(), "attempt", i+1)
+		_, span := tracing.Start(ctx, "dns.upstream", tracing.KindClient)
+		span.SetAttribute("server.address", upstream.String())
+		response, rtt, err := forwarder.Exchange(r, upstream)
+		span.SetError(err)
+		span.End()
+		s.recordUpstream(upstream.String(), rtt, err)
 		if err == nil {
-			log.Printf("DNS forward successful via %s", upstream)
-			return response, nil
+			logger.Debug("DNS forward successful", "upstream", upstream.String())
+			return response, upstream.String(), nil
 		}
-		log.Printf("Upstream %s failed: %v", upstream, err)
+		logger.Warn("Upstream failed", "upstream", upstream.String(), "error", err)
 	}
 
-	log.Printf("All %d upstream nameservers failed", len(upstreams))
-	return nil, fmt.Errorf("all upstream nameservers failed")
+	logger.Debug("All upstream nameservers failed", "upstreams", len(upstreams))
+	return nil, "", fmt.Errorf("all upstream nameservers failed")
+}
+
+// clientIP returns the IP address of a DNS client without its port
+func clientIP(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
 }
 
 // getDNSSerial returns a safe DNS serial number
@@ -460,3 +1721,106 @@ func (s *Server) isAllowed(domain string) bool {
 
 	return false
 }
+
+// denyReason explains why the blocklist blocks the domain, or returns "" if it doesn't. The
+// local blocklist always blocks; a subscribed blocklist gives way to the local allowlist.
+// Names are matched case-insensitively, as DNS compares them.
+func (s *Server) denyReason(domain string) string {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+
+	s.allowlistMutex.RLock()
+	defer s.allowlistMutex.RUnlock()
+
+	if matchesPatterns(s.denylist, s.denyPatterns, domain) {
+		return "on the blocklist"
+	}
+	if matchesPatterns(s.subscribedDenylist, s.subscribedDenyPatterns, domain) &&
+		!matchesPatterns(s.localAllowlist, s.localAllowPatterns, domain) {
+		return "on a subscribed blocklist"
+	}
+	return ""
+}
+
+// matchesPatterns reports whether a domain is one of the exact domains or matches a wildcard
+func matchesPatterns(exact map[string]bool, wildcards []*regexp.Regexp, domain string) bool {
+	if exact[domain] {
+		return true
+	}
+	for _, pattern := range wildcards {
+		if pattern.MatchString(domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIgnored reports whether queries for the domain are left unrecorded (ignore_domains)
+func (s *Server) isIgnored(domain string) bool {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+	return matchesPatterns(s.ignoredExact, s.ignoredWildcards, strings.ToLower(domain))
+}
+
+// isExactlyAllowed reports whether the domain is listed in the allowlist without a wildcard
+func (s *Server) isExactlyAllowed(domain string) bool {
+	s.allowlistMutex.RLock()
+	defer s.allowlistMutex.RUnlock()
+	return s.allowlist[domain]
+}
+
+// shouldBlock decides whether a query is blocked at the given focus intensity
+func (s *Server) shouldBlock(domain, intensity string, seenBeforeSession bool) bool {
+	return s.blockReason(domain, intensity, seenBeforeSession) != ""
+}
+
+// blockReason explains why a domain is blocked at the given intensity, or returns "" if it is allowed
+func (s *Server) blockReason(domain, intensity string, seenBeforeSession bool) string {
+	if reason := s.denyReason(domain); reason != "" {
+		return reason
+	}
+
+	switch intensity {
+	case config.IntensitySoft:
+		return ""
+	case config.IntensityHard:
+		if s.isExactlyAllowed(domain) || (seenBeforeSession && s.isAllowed(domain)) {
+			return ""
+		}
+		// Wildcards don't let through domains that first appeared during the session
+		if s.isAllowed(domain) {
+			return "first queried during the session (hard intensity ignores wildcards)"
+		}
+		return "not on the allowlist"
+	default:
+		if s.isAllowed(domain) {
+			return ""
+		}
+		return "not on the allowlist"
+	}
+}
+
+// markSeen records a queried domain, returning when it was first seen and whether it had been seen before
+func (s *Server) markSeen(domain string, at time.Time) (time.Time, bool) {
+	s.seenMutex.Lock()
+	defer s.seenMutex.Unlock()
+
+	if firstSeen, ok := s.seenDomains[domain]; ok {
+		return firstSeen, true
+	}
+	if len(s.seenDomains) < maxSeenDomains {
+		s.seenDomains[domain] = at
+	}
+	return time.Time{}, false
+}
+
+// intensityRank orders intensities from least to most strict
+func intensityRank(intensity string) int {
+	switch intensity {
+	case config.IntensitySoft:
+		return 0
+	case config.IntensityHard:
+		return 2
+	default:
+		return 1
+	}
+}
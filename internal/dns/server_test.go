@@ -0,0 +1,245 @@
+package dns
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/miekg/dns"
+)
+
+func TestWriteBlocked(t *testing.T) {
+	query := new(dns.Msg)
+	query.SetQuestion("blocked.example.", dns.TypeA)
+
+	cases := map[string]int{
+		config.BlockResponseNXDomain: dns.RcodeNameError,
+		config.BlockResponseNullIP:   dns.RcodeSuccess,
+		config.BlockResponseRefused:  dns.RcodeRefused,
+	}
+	for response, rcode := range cases {
+		s := &Server{blockResponse: response, blockedTTL: 10}
+		msg := new(dns.Msg)
+		s.writeBlocked(query, msg)
+		if msg.Rcode != rcode {
+			t.Errorf("%s: expected %s, got %s", response, dns.RcodeToString[rcode], dns.RcodeToString[msg.Rcode])
+		}
+		if response == config.BlockResponseNullIP {
+			a, ok := msg.Answer[0].(*dns.A)
+			if !ok || !a.A.Equal(net.IPv4zero) || a.Hdr.Ttl != 10 {
+				t.Errorf("expected 0.0.0.0 with a TTL of 10, got %v", msg.Answer)
+			}
+		}
+	}
+}
+
+// benchWriter is a dns.ResponseWriter that drops the answer
+type benchWriter struct{}
+
+func (benchWriter) LocalAddr() net.Addr { return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53} }
+func (benchWriter) RemoteAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}
+}
+func (benchWriter) WriteMsg(*dns.Msg) error     { return nil }
+func (benchWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (benchWriter) Close() error                { return nil }
+func (benchWriter) TsigStatus() error           { return nil }
+func (benchWriter) TsigTimersOnly(bool)         {}
+func (benchWriter) Hijack()                     {}
+
+// fuzzWriter is a dns.ResponseWriter that keeps the answer
+type fuzzWriter struct {
+	benchWriter
+	msg *dns.Msg
+}
+
+func (w *fuzzWriter) WriteMsg(msg *dns.Msg) error {
+	w.msg = msg
+	return nil
+}
+
+// FuzzServeDNS feeds the resolver messages a client could send, with focus mode on and off,
+// and checks that each is answered with a message that packs
+func FuzzServeDNS(f *testing.F) {
+	for _, seed := range []func(*dns.Msg){
+		func(m *dns.Msg) { m.SetQuestion("example.com.", dns.TypeA) },
+		func(m *dns.Msg) { m.SetQuestion("cdn.allowed.example.", dns.TypeAAAA) },
+		func(m *dns.Msg) { m.SetQuestion("example.com.", dns.TypeHTTPS); m.SetEdns0(4096, true) },
+		func(m *dns.Msg) { m.SetQuestion(".", dns.TypeNS) },
+		func(m *dns.Msg) { m.SetQuestion("1.0.0.127.in-addr.arpa.", dns.TypePTR) },
+		func(m *dns.Msg) { m.SetQuestion("version.bind.", dns.TypeTXT); m.Question[0].Qclass = dns.ClassCHAOS },
+		func(m *dns.Msg) { m.SetNotify("example.com.") },
+	} {
+		msg := new(dns.Msg)
+		seed(msg)
+		packed, err := msg.Pack()
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(packed)
+	}
+
+	f.Setenv(config.ConfigDirEnv, f.TempDir())
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		f.Fatal(err)
+	}
+	upstream := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		response := new(dns.Msg)
+		response.SetReply(r)
+		_ = w.WriteMsg(response)
+	})}
+	go func() { _ = upstream.ActivateAndServe() }()
+	defer func() { _ = upstream.Shutdown() }()
+
+	s := NewServerWithAddr(&config.Config{UpstreamNameservers: []string{conn.LocalAddr().String()}}, nil, "127.0.0.1:0")
+	defer s.forwarder.Close()
+	regex, err := wildcardToRegex("*.allowed.example")
+	if err != nil {
+		f.Fatal(err)
+	}
+	s.wildcardPatterns = append(s.wildcardPatterns, regex)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Like the DNS library, answer messages it rejects before the handler is called
+		query := new(dns.Msg)
+		if err := query.Unpack(data); err != nil {
+			return
+		}
+		header := dns.Header{
+			Id:      query.Id,
+			Bits:    binary.BigEndian.Uint16(data[2:]),
+			Qdcount: binary.BigEndian.Uint16(data[4:]),
+			Ancount: binary.BigEndian.Uint16(data[6:]),
+			Nscount: binary.BigEndian.Uint16(data[8:]),
+			Arcount: binary.BigEndian.Uint16(data[10:]),
+		}
+		if dns.DefaultMsgAcceptFunc(header) != dns.MsgAccept {
+			return
+		}
+		for _, focus := range []bool{false, true} {
+			s.focusMutex.Lock()
+			s.focusMode = focus
+			s.focusMutex.Unlock()
+
+			w := &fuzzWriter{}
+			s.serveDNS(w, query)
+			if w.msg == nil {
+				t.Fatalf("no answer with focus mode %v to %v", focus, query)
+			}
+			if w.msg.Id != query.Id {
+				t.Errorf("expected the answer to have ID %d, got %d", query.Id, w.msg.Id)
+			}
+			if _, err := w.msg.Pack(); err != nil {
+				t.Errorf("failed to pack the answer with focus mode %v to %v: %v", focus, query, err)
+			}
+		}
+	})
+}
+
+// BenchmarkServeDNS measures a query from receiving it to writing the answer, with focus
+// mode off, answered from the cache or forwarded to an upstream on this machine
+func BenchmarkServeDNS(b *testing.B) {
+	b.Setenv(config.ConfigDirEnv, b.TempDir())
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	upstream := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		_, response := answer(r.Question[0].Name, 300)
+		response.Id = r.Id
+		_ = w.WriteMsg(response)
+	})}
+	go func() { _ = upstream.ActivateAndServe() }()
+	defer func() { _ = upstream.Shutdown() }()
+
+	for name, size := range map[string]int{"cached": 1000, "forwarded": 0} {
+		b.Run(name, func(b *testing.B) {
+			cfg := &config.Config{
+				UpstreamNameservers: []string{conn.LocalAddr().String()},
+				Cache:               &config.CacheConfig{Size: &size},
+			}
+			s := NewServerWithAddr(cfg, nil, "127.0.0.1:0")
+			defer s.forwarder.Close()
+			query := new(dns.Msg)
+			query.SetQuestion("example.com.", dns.TypeA)
+			s.serveDNS(benchWriter{}, query)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.serveDNS(benchWriter{}, query)
+			}
+		})
+	}
+}
+
+func TestServePacketConn(t *testing.T) {
+	upstreamConn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstream := &dns.Server{PacketConn: upstreamConn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		response := new(dns.Msg)
+		response.SetReply(r)
+		_ = w.WriteMsg(response)
+	})}
+	go func() { _ = upstream.ActivateAndServe() }()
+	defer func() { _ = upstream.Shutdown() }()
+
+	s := NewServerWithAddr(&config.Config{UpstreamNameservers: []string{upstreamConn.LocalAddr().String()}}, nil, "127.0.0.1:0")
+	defer s.forwarder.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ServePacketConn(conn) }()
+
+	query := new(dns.Msg)
+	query.SetQuestion("example.com.", dns.TypeA)
+	client := &dns.Client{Timeout: time.Second}
+	var response *dns.Msg
+	for range 20 {
+		// Until the listener is served
+		if response, _, err = client.Exchange(query, conn.LocalAddr().String()); err == nil {
+			break
+		}
+	}
+	if err != nil || response.Rcode != dns.RcodeSuccess {
+		t.Fatalf("expected the extra listener to answer, got %v (%v)", response, err)
+	}
+
+	if err := s.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Error("expected Shutdown to stop the extra listener")
+	}
+}
+
+func TestIsIgnored(t *testing.T) {
+	cfg := &config.Config{UpstreamNameservers: []string{"1.1.1.1"}, IgnoreDomains: []string{"*.pool.ntp.org", "telemetry.example"}}
+	s := NewServerWithAddr(cfg, nil, "127.0.0.1:0")
+	defer s.forwarder.Close()
+
+	for _, domain := range []string{"2.pool.ntp.org", "Telemetry.Example"} {
+		if !s.isIgnored(domain) {
+			t.Errorf("expected %s to be ignored", domain)
+		}
+	}
+	for _, domain := range []string{"pool.ntp.org", "example.com", "v1.telemetry.example"} {
+		if s.isIgnored(domain) {
+			t.Errorf("expected %s to be recorded", domain)
+		}
+	}
+
+	cfg.IgnoreDomains = nil
+	s.ApplyConfig(cfg)
+	if s.isIgnored("2.pool.ntp.org") {
+		t.Error("expected an emptied ignore_domains to record every domain again")
+	}
+}
@@ -0,0 +1,45 @@
+package dns
+
+// SetDryRun switches every session, current and future, to only warn about queries it
+// would block. Turning it on loosens focus mode, so it needs the focus PIN; it lasts until
+// turned off or the resolver restarts.
+func (s *Server) SetDryRun(enabled bool, pin string) error {
+	if enabled && !s.dryRun.Load() {
+		if err := s.verifyPIN(pin); err != nil {
+			return err
+		}
+	}
+	switch {
+	case s.dryRun.Swap(enabled) == enabled:
+	case enabled:
+		logger.Info("Dry run turned on: focus sessions only warn about queries they would block")
+	default:
+		logger.Info("Dry run turned off")
+	}
+	return nil
+}
+
+// DryRun reports whether every session only warns (see SetDryRun)
+func (s *Server) DryRun() bool {
+	return s.dryRun.Load()
+}
+
+// SetRateLimit changes the per-client rate limit (a rate of 0 means unlimited) until the
+// resolver restarts or rate_limit changes in the config
+func (s *Server) SetRateLimit(rate, burst int) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+	if !s.limiter.hasLimits(rate, burst) {
+		s.limiter = newRateLimiter(rate, burst)
+	}
+}
+
+// RateLimit returns the per-client query rate and burst in effect (a rate of 0 means unlimited)
+func (s *Server) RateLimit() (int, int) {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+	if s.limiter == nil {
+		return 0, 0
+	}
+	return int(s.limiter.rate), int(s.limiter.burst)
+}
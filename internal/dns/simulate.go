@@ -0,0 +1,124 @@
+package dns
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/subscription"
+)
+
+// SimulationLists are the allowlist and blocklist entries a simulated focus session
+// decides by, e.g. those of allowlist.txt and blocklist.txt or of candidates for them
+type SimulationLists struct {
+	Allowlist []string
+	Blocklist []string
+}
+
+// Simulator decides domains as the resolver does in a focus session at normal intensity
+// without a profile, using the given lists and the lists subscribed in the config
+type Simulator struct {
+	server *Server
+}
+
+// NewSimulator creates a simulator for lists
+func NewSimulator(cfg *config.Config, lists SimulationLists) *Simulator {
+	s := &Server{}
+	subscribedAllow := readSubscribedLists(subscription.Allowlist, cfg.AllowlistSubscriptions)
+	subscribedDeny := readSubscribedLists(subscription.Blocklist, cfg.BlocklistSubscriptions)
+	s.allowlist, s.wildcardPatterns = compilePatterns(slices.Concat(lists.Allowlist, subscribedAllow))
+	s.localAllowlist, s.localAllowPatterns = compilePatterns(lists.Allowlist)
+	s.denylist, s.denyPatterns = compilePatterns(lists.Blocklist)
+	s.subscribedDenylist, s.subscribedDenyPatterns = compilePatterns(subscribedDeny)
+	return &Simulator{server: s}
+}
+
+// BlockReason explains why the simulated session blocks a domain, or returns "" if it
+// allows it
+func (sim *Simulator) BlockReason(domain string) string {
+	return sim.server.blockReason(domain, config.IntensityNormal, true)
+}
+
+// SimulatedDomain is a domain the candidate lists decide differently from the current ones
+type SimulatedDomain struct {
+	Domain   string    `json:"domain"`
+	Queries  int       `json:"queries"` // Queries replayed for it
+	Clients  int       `json:"clients"` // Clients that queried it
+	LastSeen time.Time `json:"last_seen"`
+	Reason   string    `json:"reason"` // Why the lists that block it do
+}
+
+// SimulationReport compares how the current and the candidate lists decide the replayed
+// queries
+type SimulationReport struct {
+	Since            time.Time         `json:"since"`
+	Queries          int               `json:"queries"`           // Queries replayed
+	Domains          int               `json:"domains"`           // Distinct domains among them
+	Blocked          int               `json:"blocked"`           // Queries the current lists block
+	CandidateBlocked int               `json:"candidate_blocked"` // Queries the candidate lists block
+	NewlyBlocked     []SimulatedDomain `json:"newly_blocked"`     // Allowed now, blocked by the candidate lists
+	NewlyAllowed     []SimulatedDomain `json:"newly_allowed"`     // Blocked now, allowed by the candidate lists
+}
+
+// Simulate replays queries against the current and the candidate lists. The changed domains
+// are sorted by how often they were queried.
+func Simulate(queries []api.DNSQuery, current, candidate *Simulator) SimulationReport {
+	type decisions struct {
+		current, candidate string
+		changed            *SimulatedDomain
+		clients            map[string]bool
+	}
+	domains := make(map[string]*decisions)
+	report := SimulationReport{NewlyBlocked: []SimulatedDomain{}, NewlyAllowed: []SimulatedDomain{}}
+
+	for _, query := range queries {
+		decided, ok := domains[query.Domain]
+		if !ok {
+			decided = &decisions{current: current.BlockReason(query.Domain), candidate: candidate.BlockReason(query.Domain)}
+			if (decided.current == "") != (decided.candidate == "") {
+				// Only one of them blocks the domain
+				decided.changed = &SimulatedDomain{Domain: query.Domain, Reason: decided.current + decided.candidate}
+				decided.clients = make(map[string]bool)
+			}
+			domains[query.Domain] = decided
+		}
+
+		report.Queries++
+		if decided.current != "" {
+			report.Blocked++
+		}
+		if decided.candidate != "" {
+			report.CandidateBlocked++
+		}
+		if changed := decided.changed; changed != nil {
+			changed.Queries++
+			decided.clients[query.ClientLabel()] = true
+			changed.Clients = len(decided.clients)
+			if query.Timestamp.After(changed.LastSeen) {
+				changed.LastSeen = query.Timestamp
+			}
+		}
+	}
+
+	report.Domains = len(domains)
+	for _, decided := range domains {
+		switch {
+		case decided.changed == nil:
+		case decided.candidate != "":
+			report.NewlyBlocked = append(report.NewlyBlocked, *decided.changed)
+		default:
+			report.NewlyAllowed = append(report.NewlyAllowed, *decided.changed)
+		}
+	}
+	for _, changed := range [][]SimulatedDomain{report.NewlyBlocked, report.NewlyAllowed} {
+		sort.Slice(changed, func(i, j int) bool {
+			if changed[i].Queries != changed[j].Queries {
+				return changed[i].Queries > changed[j].Queries
+			}
+			return changed[i].Domain < changed[j].Domain
+		})
+	}
+	return report
+}
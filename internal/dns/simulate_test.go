@@ -0,0 +1,44 @@
+package dns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestSimulate(t *testing.T) {
+	cfg := &config.Config{}
+	current := NewSimulator(cfg, SimulationLists{Allowlist: []string{"github.com", "*.slack.com"}, Blocklist: []string{"*.reddit.com"}})
+	candidate := NewSimulator(cfg, SimulationLists{Allowlist: []string{"github.com", "docs.python.org"}, Blocklist: []string{"*.reddit.com"}})
+
+	at := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var queries []api.DNSQuery
+	query := func(domain, client string, times int) {
+		for i := 0; i < times; i++ {
+			queries = append(queries, api.DNSQuery{Domain: domain, Client: client, Timestamp: at.Add(time.Duration(len(queries)) * time.Minute)})
+		}
+	}
+	query("github.com", "10.0.0.2", 4)
+	query("app.slack.com", "10.0.0.2", 3)
+	query("edge.slack.com", "10.0.0.3", 1)
+	query("app.slack.com", "10.0.0.3", 1)
+	query("docs.python.org", "10.0.0.2", 2)
+	query("www.reddit.com", "10.0.0.2", 5)
+
+	report := Simulate(queries, current, candidate)
+	if report.Queries != 16 || report.Domains != 5 || report.Blocked != 7 || report.CandidateBlocked != 10 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if len(report.NewlyBlocked) != 2 || len(report.NewlyAllowed) != 1 {
+		t.Fatalf("expected 2 newly blocked and 1 newly allowed domains, got %+v", report)
+	}
+	first := report.NewlyBlocked[0]
+	if first.Domain != "app.slack.com" || first.Queries != 4 || first.Clients != 2 || !first.LastSeen.Equal(queries[8].Timestamp) || first.Reason != "not on the allowlist" {
+		t.Errorf("expected app.slack.com first, queried 4 times by 2 clients, got %+v", first)
+	}
+	if allowed := report.NewlyAllowed[0]; allowed.Domain != "docs.python.org" || allowed.Queries != 2 || allowed.Reason != "not on the allowlist" {
+		t.Errorf("expected docs.python.org to be allowed, got %+v", allowed)
+	}
+}
@@ -0,0 +1,47 @@
+package dns
+
+import (
+	"fmt"
+	"time"
+)
+
+// snoozeDomain grants a blocked domain a time-boxed exception for the current session
+func (s *Server) snoozeDomain(domain string, until time.Time, pin string) error {
+	if err := s.verifyPIN(pin); err != nil {
+		return err
+	}
+
+	s.focusMutex.Lock()
+	defer s.focusMutex.Unlock()
+
+	if !s.focusMode {
+		return fmt.Errorf("focus mode is not active")
+	}
+	if s.snoozes == nil {
+		s.snoozes = make(map[string]time.Time)
+	}
+	s.snoozes[domain] = until
+	logger.Info("Domain snoozed", "domain", domain, "until", until)
+	return nil
+}
+
+// isSnoozed reports whether the domain has an unexpired snooze, revoking it once it has expired
+func (s *Server) isSnoozed(domain string, now time.Time) bool {
+	s.focusMutex.RLock()
+	until, ok := s.snoozes[domain]
+	s.focusMutex.RUnlock()
+	if !ok {
+		return false
+	}
+	if now.Before(until) {
+		return true
+	}
+
+	s.focusMutex.Lock()
+	if current, ok := s.snoozes[domain]; ok && !now.Before(current) {
+		delete(s.snoozes, domain)
+		logger.Info("Snooze expired", "domain", domain)
+	}
+	s.focusMutex.Unlock()
+	return false
+}
@@ -0,0 +1,112 @@
+package dns
+
+import (
+	"cmp"
+	"maps"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+const (
+	// latencyWeight is how much a new measurement moves an upstream's average latency
+	latencyWeight = 0.2
+
+	// latencySaveInterval is how often changed latencies are saved for the next start
+	latencySaveInterval = time.Minute
+)
+
+// upstreamOrder returns the upstreams in the order the configured strategy tries them
+func (s *Server) upstreamOrder(upstreams []config.Upstream) []config.Upstream {
+	if len(upstreams) < 2 {
+		return upstreams
+	}
+	s.settingsMutex.RLock()
+	strategy := s.upstreamStrategy
+	s.settingsMutex.RUnlock()
+
+	ordered := slices.Clone(upstreams)
+	switch strategy {
+	case config.UpstreamRoundRobin:
+		start := int(s.nextUpstream.Add(1)-1) % len(ordered)
+		ordered = append(ordered[start:], ordered[:start]...)
+	case config.UpstreamRandom:
+		rand.Shuffle(len(ordered), func(i, j int) { // #nosec G404 -- load spreading, not security
+			ordered[i], ordered[j] = ordered[j], ordered[i]
+		})
+	case config.UpstreamFastest:
+		s.healthMutex.Lock()
+		defer s.healthMutex.Unlock()
+		slices.SortStableFunc(ordered, func(a, b config.Upstream) int {
+			return cmp.Compare(s.upstreamRank(a.String()), s.upstreamRank(b.String()))
+		})
+	}
+	return ordered
+}
+
+// upstreamRank sorts for the fastest strategy: upstreams never measured come first so they
+// get measured, down upstreams last. Callers hold healthMutex.
+func (s *Server) upstreamRank(upstream string) time.Duration {
+	if state, ok := s.upstreams[upstream]; ok && state.failures >= downUpstreamFailures {
+		return math.MaxInt64
+	}
+	latency, ok := s.latencies[upstream]
+	if !ok {
+		return -1
+	}
+	return latency
+}
+
+// recordLatency folds a measurement into the upstream's average latency. Callers hold
+// healthMutex.
+func (s *Server) recordLatency(upstream string, latency time.Duration) {
+	if s.latencies == nil {
+		s.latencies = make(map[string]time.Duration)
+	}
+	if average, ok := s.latencies[upstream]; ok {
+		latency = average + time.Duration(latencyWeight*float64(latency-average))
+	}
+	s.latencies[upstream] = latency
+	s.latenciesChanged = true
+}
+
+// loadLatencies restores the latencies measured before the last restart
+func (s *Server) loadLatencies() {
+	s.healthMutex.Lock()
+	defer s.healthMutex.Unlock()
+	s.latencies = s.stateManager.UpstreamLatencies()
+}
+
+// saveLatencies periodically saves the measured latencies when they changed
+func (s *Server) saveLatencies() {
+	ticker := time.NewTicker(latencySaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.persistLatencies()
+	}
+}
+
+// persistLatencies saves the latencies of the configured upstreams if they changed
+func (s *Server) persistLatencies() {
+	s.healthMutex.Lock()
+	if !s.latenciesChanged {
+		s.healthMutex.Unlock()
+		return
+	}
+	latencies := maps.Clone(s.latencies)
+	s.latenciesChanged = false
+	s.healthMutex.Unlock()
+
+	// Drop upstreams that are no longer configured
+	configured := s.upstreamAddresses()
+	maps.DeleteFunc(latencies, func(upstream string, _ time.Duration) bool {
+		return !slices.Contains(configured, upstream)
+	})
+	if err := s.stateManager.SetUpstreamLatencies(latencies); err != nil {
+		logger.Warn("Failed to save upstream latencies", "error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func testUpstreams(addresses ...string) []config.Upstream {
+	upstreams := make([]config.Upstream, len(addresses))
+	for i, address := range addresses {
+		upstreams[i] = config.Upstream{Protocol: config.UpstreamUDP, Address: address}
+	}
+	return upstreams
+}
+
+func TestUpstreamOrder(t *testing.T) {
+	upstreams := testUpstreams("a:53", "b:53", "c:53")
+
+	s := &Server{upstreamStrategy: config.UpstreamSequential}
+	if got := s.upstreamOrder(upstreams); !slices.Equal(got, upstreams) {
+		t.Errorf("sequential: expected %v, got %v", upstreams, got)
+	}
+
+	s = &Server{upstreamStrategy: config.UpstreamRoundRobin}
+	for _, first := range []string{"a:53", "b:53", "c:53", "a:53"} {
+		if got := s.upstreamOrder(upstreams); got[0].Address != first || len(got) != 3 {
+			t.Errorf("round robin: expected %s first, got %v", first, got)
+		}
+	}
+
+	s = &Server{upstreamStrategy: config.UpstreamRandom}
+	got := s.upstreamOrder(upstreams)
+	slices.SortFunc(got, func(a, b config.Upstream) int { return strings.Compare(a.Address, b.Address) })
+	if !slices.Equal(got, upstreams) {
+		t.Errorf("random: expected a permutation of %v, got %v", upstreams, got)
+	}
+}
+
+func TestUpstreamOrderFastest(t *testing.T) {
+	upstreams := testUpstreams("a:53", "b:53", "c:53")
+	s := &Server{
+		upstreamStrategy: config.UpstreamFastest,
+		latencies:        map[string]time.Duration{"a:53": 80 * time.Millisecond, "b:53": 10 * time.Millisecond},
+	}
+
+	// Unmeasured upstreams are tried first so they get measured
+	if got := s.upstreamOrder(upstreams); !slices.Equal(got, testUpstreams("c:53", "b:53", "a:53")) {
+		t.Errorf("unexpected order %v", got)
+	}
+
+	s.recordUpstream("c:53", 50*time.Millisecond, nil)
+	for i := 0; i < downUpstreamFailures; i++ {
+		s.recordUpstream("b:53", 0, errors.New("timeout"))
+	}
+	if got := s.upstreamOrder(upstreams); !slices.Equal(got, testUpstreams("c:53", "a:53", "b:53")) {
+		t.Errorf("expected the down upstream last, got %v", got)
+	}
+
+	// The average moves towards new measurements
+	s.recordUpstream("c:53", 150*time.Millisecond, nil)
+	if latency := s.latencies["c:53"]; latency != 70*time.Millisecond {
+		t.Errorf("expected an average of 70ms, got %s", latency)
+	}
+}
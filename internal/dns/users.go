@@ -0,0 +1,111 @@
+package dns
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"net"
+	"net/netip"
+	"os/user"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// userPolicy is the users entry of one account of this machine
+type userPolicy struct {
+	focus     string
+	allowlist map[string]bool
+	wildcards []*regexp.Regexp
+}
+
+// newUserPolicies compiles the users section; invalid entries are left out
+func newUserPolicies(cfg *config.Config) map[string]*userPolicy {
+	users, err := cfg.GetUsers()
+	if err != nil || len(users) == 0 {
+		return nil
+	}
+	policies := make(map[string]*userPolicy, len(users))
+	for name, policy := range users {
+		allowlist, wildcards := compilePatterns(policy.Allowlist)
+		policies[name] = &userPolicy{focus: policy.Focus, allowlist: allowlist, wildcards: wildcards}
+	}
+	return policies
+}
+
+// allows reports whether the user's own allowlist has the domain
+func (p *userPolicy) allows(domain string) bool {
+	return p != nil && matchesPatterns(p.allowlist, p.wildcards, domain)
+}
+
+// queryUser returns the account of this machine that sent a query over loopback, and its
+// policy (nil without one). Accounts are only looked up while users is configured.
+func (s *Server) queryUser(addr net.Addr) (string, *userPolicy) {
+	s.settingsMutex.RLock()
+	users := s.users
+	s.settingsMutex.RUnlock()
+	if len(users) == 0 || addr == nil {
+		return "", nil
+	}
+	source, err := netip.ParseAddrPort(addr.String())
+	if err != nil || !source.Addr().IsLoopback() {
+		return "", nil
+	}
+	uid, ok := socketUID(addr.Network(), source)
+	if !ok {
+		return "", nil
+	}
+	name := userName(uid)
+	return name, users[name]
+}
+
+// recordedUser returns the account recorded with a query: none while client_privacy hides
+// clients, as it tells who sent the query
+func (s *Server) recordedUser(name string) string {
+	s.settingsMutex.RLock()
+	anonymizer := s.clientAnonymizer
+	s.settingsMutex.RUnlock()
+	if anonymizer.enabled() {
+		return ""
+	}
+	return name
+}
+
+// userNames caches the names of the uids seen
+var userNames sync.Map
+
+// userName returns the name of the account with a uid, or the uid if it has none
+func userName(uid uint32) string {
+	if name, ok := userNames.Load(uid); ok {
+		return name.(string)
+	}
+	id := strconv.FormatUint(uint64(uid), 10)
+	name := id
+	if account, err := user.LookupId(id); err == nil {
+		name = account.Username
+	}
+	userNames.Store(uid, name)
+	return name
+}
+
+// parseProcAddr parses an address of the kernel's socket tables (/proc/net/udp and the
+// like), e.g. 0100007F:D431: the IP address in hex, in 32-bit words of the host's byte
+// order, and the port
+func parseProcAddr(s string) (netip.AddrPort, bool) {
+	host, port, ok := strings.Cut(s, ":")
+	raw, err := hex.DecodeString(host)
+	if !ok || err != nil || (len(raw) != 4 && len(raw) != 16) {
+		return netip.AddrPort{}, false
+	}
+	number, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, false
+	}
+	for i := 0; i < len(raw); i += 4 {
+		binary.NativeEndian.PutUint32(raw[i:], binary.BigEndian.Uint32(raw[i:]))
+	}
+	addr, _ := netip.AddrFromSlice(raw)
+	return netip.AddrPortFrom(addr.Unmap(), uint16(number)), true
+}
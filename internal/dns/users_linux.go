@@ -0,0 +1,53 @@
+package dns
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// socketUID returns the uid of the local socket a query came from, found in the kernel's
+// socket tables by its address
+func socketUID(network string, source netip.AddrPort) (uint32, bool) {
+	tables := []string{"/proc/net/udp", "/proc/net/udp6"}
+	if strings.HasPrefix(network, "tcp") {
+		tables = []string{"/proc/net/tcp", "/proc/net/tcp6"}
+	}
+	source = netip.AddrPortFrom(source.Addr().Unmap(), source.Port())
+	for _, table := range tables {
+		if uid, ok := findSocketUID(table, source); ok {
+			return uid, true
+		}
+	}
+	return 0, false
+}
+
+// findSocketUID looks a socket bound to source, or to its port on every address, up in a
+// socket table
+func findSocketUID(table string, source netip.AddrPort) (uint32, bool) {
+	f, err := os.Open(table)
+	if err != nil {
+		return 0, false
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Header
+	for scanner.Scan() {
+		// sl, local_address, rem_address, st, tx_queue:rx_queue, tr:tm->when, retrnsmt, uid, ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		local, ok := parseProcAddr(fields[1])
+		if !ok || local.Port() != source.Port() || (local.Addr() != source.Addr() && !local.Addr().IsUnspecified()) {
+			continue
+		}
+		if uid, err := strconv.ParseUint(fields[7], 10, 32); err == nil {
+			return uint32(uid), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,98 @@
+package dns
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// procAddr formats an address as the kernel's socket tables do
+func procAddr(addr netip.AddrPort) string {
+	raw := addr.Addr().AsSlice()
+	var b strings.Builder
+	for i := 0; i < len(raw); i += 4 {
+		fmt.Fprintf(&b, "%08X", binary.NativeEndian.Uint32(raw[i:]))
+	}
+	return fmt.Sprintf("%s:%04X", b.String(), addr.Port())
+}
+
+func TestParseProcAddr(t *testing.T) {
+	for _, want := range []string{"127.0.0.1:54321", "[::1]:53", "0.0.0.0:5353", "[fe80::1:2]:443"} {
+		addr := netip.MustParseAddrPort(want)
+		got, ok := parseProcAddr(procAddr(addr))
+		if !ok || got != addr {
+			t.Errorf("expected %s, got %s (%v)", addr, got, ok)
+		}
+	}
+	mapped := netip.AddrPortFrom(netip.MustParseAddr("::ffff:127.0.0.1"), 80)
+	if got, ok := parseProcAddr(procAddr(mapped)); !ok || got.String() != "127.0.0.1:80" {
+		t.Errorf("expected IPv4-mapped addresses to be unmapped, got %s", got)
+	}
+	for _, invalid := range []string{"", "0100007F", "XYZ:0035", "0100007F:XYZ", "01007F:0035"} {
+		if _, ok := parseProcAddr(invalid); ok {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestSocketUID(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("sockets are only looked up on Linux")
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	source := netip.MustParseAddrPort(conn.LocalAddr().String())
+	uid, ok := socketUID("udp", source)
+	if !ok || int(uid) != os.Getuid() {
+		t.Fatalf("expected the socket to be ours (uid %d), got %d (%v)", os.Getuid(), uid, ok)
+	}
+}
+
+func TestFocusOverride(t *testing.T) {
+	now := time.Now()
+	s := &Server{deviceFocus: map[string]config.DeviceFocus{
+		"127.0.0.1": {Mode: config.DeviceFocusOn},
+		"user:alex": {Mode: config.DeviceFocusOn},
+	}}
+	users := newUserPolicies(&config.Config{Users: map[string]config.UserPolicy{
+		"sam":  {Focus: config.DeviceFocusOff, Allowlist: []string{"*.school.example"}},
+		"alex": {Focus: config.DeviceFocusOff},
+		"kim":  {},
+	}})
+
+	cases := []struct {
+		user string
+		want string
+	}{
+		{"sam", config.DeviceFocusOff},  // The users entry beats the device
+		{"alex", config.DeviceFocusOn},  // An override set for the user beats the entry
+		{"kim", config.DeviceFocusOn},   // Following the session leaves it to the device
+		{"", config.DeviceFocusOn},      // Queries without a user
+		{"robin", config.DeviceFocusOn}, // Users without an entry
+	}
+	for _, c := range cases {
+		override, ok := s.focusOverride(now, c.user, users[c.user], "", "127.0.0.1")
+		if !ok || override.Mode != c.want {
+			t.Errorf("%q: expected %s, got %+v (%v)", c.user, c.want, override, ok)
+		}
+	}
+
+	if !users["sam"].allows("www.school.example") || users["sam"].allows("games.example") || users["kim"].allows("www.school.example") {
+		t.Error("expected only sam's allowlist to allow www.school.example")
+	}
+	if users["robin"].allows("www.school.example") {
+		t.Error("expected users without an entry to have no allowlist of their own")
+	}
+}
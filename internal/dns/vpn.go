@@ -0,0 +1,81 @@
+package dns
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// SetVPNUpstreams sends queries to the nameservers of a VPN while it is up, only names in
+// domains when any are given, with no fallback to the configured upstreams, which don't know
+// the VPN's names. Nil upstreams switch back. The cache is flushed on each switch, as names
+// may resolve differently inside the VPN.
+func (s *Server) SetVPNUpstreams(upstreams []config.Upstream, domains []string) {
+	s.settingsMutex.Lock()
+	if slices.Equal(s.vpnUpstreams, upstreams) && slices.Equal(s.vpnDomains, domains) {
+		s.settingsMutex.Unlock()
+		return
+	}
+	if s.vpnForwarder != nil {
+		s.vpnForwarder.Close()
+		s.vpnForwarder = nil
+	}
+	if len(upstreams) > 0 {
+		s.vpnForwarder = NewForwarder(upstreams, upstreamTimeout)
+	}
+	s.vpnUpstreams = upstreams
+	s.vpnDomains = domains
+	cache := s.cache
+	s.settingsMutex.Unlock()
+
+	if cache != nil {
+		cache.flush()
+	}
+	if len(upstreams) > 0 {
+		logger.Info("Using the VPN's nameservers", "upstreams", len(upstreams), "domains", len(domains))
+	} else {
+		logger.Info("Using the configured nameservers again")
+	}
+}
+
+// upstreamsFor returns the upstreams a query goes to and the forwarder that queries them:
+// the network's own nameserver during a passthrough window, the VPN's while it is up and
+// the name is one of its domains, the local nameservers for private names in the forward
+// mode, the configured ones otherwise
+func (s *Server) upstreamsFor(r *dns.Msg) ([]config.Upstream, *Forwarder) {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+
+	if len(r.Question) == 0 {
+		return s.upstreamList, s.forwarder
+	}
+	if s.portalForwarder != nil {
+		return s.portalUpstreams, s.portalForwarder
+	}
+	name := r.Question[0].Name
+	if s.vpnForwarder != nil && inVPNDomains(name, s.vpnDomains) {
+		return s.vpnUpstreams, s.vpnForwarder
+	}
+	if s.private != nil && s.private.forwarder != nil && s.private.contains(name) {
+		return s.private.resolvers, s.private.forwarder
+	}
+	return s.upstreamList, s.forwarder
+}
+
+// inVPNDomains reports whether a name is one of the domains or below one; every name is
+// when there are none
+func inVPNDomains(name string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, domain := range domains {
+		if name == domain || strings.HasSuffix(name, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
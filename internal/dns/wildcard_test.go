@@ -1,7 +1,10 @@
 package dns
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestWildcardToRegex(t *testing.T) {
@@ -64,3 +67,62 @@ func TestIsWildcardPattern(t *testing.T) {
 		}
 	}
 }
+
+// FuzzWildcardToRegex checks that every allowlist line compiles, and that a pattern matches
+// the domains made by filling in its wildcards
+func FuzzWildcardToRegex(f *testing.F) {
+	for _, seed := range []string{"*github*", "*.example.com", "api.*.com", "exact.com", "", "*", "**", "a+b(c)[d]{2}\\|^$?.*"} {
+		f.Add(seed, "sub")
+	}
+	f.Fuzz(func(t *testing.T, pattern, fill string) {
+		regex, err := wildcardToRegex(pattern)
+		if !utf8.ValidString(pattern) {
+			if err == nil {
+				t.Errorf("expected an error for %q, which isn't valid UTF-8", pattern)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("failed to compile %q: %v", pattern, err)
+		}
+		if !regex.MatchString(pattern) {
+			t.Errorf("%q doesn't match itself", pattern)
+		}
+		// .* in the regular expression matches neither a newline nor invalid UTF-8
+		if strings.Contains(fill, "\n") || !utf8.ValidString(fill) {
+			return
+		}
+		if domain := strings.ReplaceAll(pattern, "*", fill); !regex.MatchString(domain) {
+			t.Errorf("%q doesn't match %q", pattern, domain)
+		}
+		if !isWildcardPattern(pattern) && regex.MatchString(pattern+"x") {
+			t.Errorf("%q without wildcards matches %q", pattern, pattern+"x")
+		}
+	})
+}
+
+func BenchmarkIsAllowed(b *testing.B) {
+	s := &Server{allowlist: make(map[string]bool)}
+	for i := 0; i < 1000; i++ {
+		s.allowlist[fmt.Sprintf("site%d.example.com", i)] = true
+	}
+	for i := 0; i < 50; i++ {
+		regex, err := wildcardToRegex(fmt.Sprintf("*.cdn%d.example.net", i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		s.wildcardPatterns = append(s.wildcardPatterns, regex)
+	}
+
+	for name, domain := range map[string]string{
+		"exact":    "site500.example.com",
+		"wildcard": "assets.cdn49.example.net",
+		"miss":     "unlisted.example.org",
+	} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s.isAllowed(domain)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package events
+
+import (
+	"log"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Event types
+const (
+	QueryBlocked     = "query_blocked"     // A query was blocked
+	FocusStarted     = "focus_started"     // A focus session started
+	FocusEnded       = "focus_ended"       // A focus session ended or expired
+	AllowlistChanged = "allowlist_changed" // The resolver loaded a changed allowlist
+)
+
+// Types lists every event type
+var Types = []string{QueryBlocked, FocusStarted, FocusEnded, AllowlistChanged}
+
+// subscriberBuffer is how far a handler can fall behind before events are dropped for it
+const subscriberBuffer = 256
+
+// Event is something that happened in the resolver. Its fields are plain strings, so exec
+// hooks get them as environment variables and plugins need no sinkzone types.
+type Event struct {
+	Type   string
+	Time   time.Time
+	Fields map[string]string // e.g. "domain" and "reason" of query_blocked
+}
+
+// Handler handles events, e.g. a hook script or a plugin
+type Handler func(Event)
+
+// subscriber hands events to one handler in its own goroutine
+type subscriber struct {
+	name    string
+	types   []string // Event types it gets, all without any
+	events  chan Event
+	dropped atomic.Int64
+}
+
+// Bus hands the resolver's events to the handlers subscribed to them. A nil Bus drops
+// every event.
+type Bus struct {
+	mutex       sync.RWMutex
+	subscribers []*subscriber
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls handler for each event of the given types, or of every type without any.
+// The handler runs in its own goroutine, one event at a time; once it is subscriberBuffer
+// events behind, newer ones are dropped for it, so a slow handler never holds up the
+// resolver.
+func (b *Bus) Subscribe(name string, handler Handler, types ...string) {
+	sub := &subscriber{name: name, types: types, events: make(chan Event, subscriberBuffer)}
+	go sub.run(handler)
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.subscribers = append(b.subscribers, sub)
+}
+
+// Wants reports whether a handler is subscribed to an event type, so publishers can skip
+// describing events nobody gets
+func (b *Bus) Wants(eventType string) bool {
+	if b == nil {
+		return false
+	}
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, sub := range b.subscribers {
+		if sub.wants(eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Publish hands an event to its subscribers without waiting for them
+func (b *Bus) Publish(eventType string, fields map[string]string) {
+	if b == nil {
+		return
+	}
+	event := Event{Type: eventType, Time: time.Now(), Fields: fields}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	for _, sub := range b.subscribers {
+		if !sub.wants(eventType) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func (s *subscriber) wants(eventType string) bool {
+	return len(s.types) == 0 || slices.Contains(s.types, eventType)
+}
+
+// run hands the subscriber's events to handler until the resolver stops
+func (s *subscriber) run(handler Handler) {
+	for event := range s.events {
+		if dropped := s.dropped.Swap(0); dropped > 0 {
+			log.Printf("Warning: %s fell behind and missed %d events", s.name, dropped)
+		}
+		s.handle(handler, event)
+	}
+}
+
+// handle calls handler, so a panicking plugin doesn't take the resolver down
+func (s *subscriber) handle(handler Handler, event Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Warning: %s panicked on %s: %v", s.name, event.Type, r)
+		}
+	}()
+	handler(event)
+}
@@ -0,0 +1,57 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(QueryBlocked, nil) // A nil bus drops events
+	if bus.Wants(QueryBlocked) {
+		t.Fatal("expected a nil bus to want nothing")
+	}
+
+	bus = NewBus()
+	blocked := make(chan Event, 10)
+	all := make(chan Event, 10)
+	bus.Subscribe("blocked", func(event Event) { blocked <- event }, QueryBlocked)
+	bus.Subscribe("all", func(event Event) {
+		all <- event
+		if event.Type == FocusStarted {
+			panic("handler failed")
+		}
+	})
+	if !bus.Wants(QueryBlocked) || !bus.Wants(AllowlistChanged) {
+		t.Fatal("expected both subscribed types to be wanted")
+	}
+
+	bus.Publish(FocusStarted, map[string]string{"focus_profile": "deep-work"})
+	bus.Publish(QueryBlocked, map[string]string{"domain": "reddit.com"})
+
+	receive := func(events chan Event) Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for an event")
+			return Event{}
+		}
+	}
+	if event := receive(blocked); event.Type != QueryBlocked || event.Fields["domain"] != "reddit.com" {
+		t.Errorf("expected the blocked query, got %+v", event)
+	}
+	// The handler keeps getting events after panicking on the first
+	if event := receive(all); event.Type != FocusStarted {
+		t.Errorf("expected focus_started first, got %+v", event)
+	}
+	if event := receive(all); event.Type != QueryBlocked {
+		t.Errorf("expected query_blocked second, got %+v", event)
+	}
+	select {
+	case event := <-blocked:
+		t.Errorf("expected only query_blocked events, got %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,40 @@
+//go:build cgo && (linux || darwin || freebsd)
+
+package events
+
+import (
+	"fmt"
+	"plugin"
+	"time"
+)
+
+// LoadPlugin opens a Go plugin, built with 'go build -buildmode=plugin' by the same Go
+// version as sinkzone, and returns its handler. The plugin exports
+//
+//	func HandleEvent(event string, at time.Time, fields map[string]string)
+//
+// and may export Events, a []string of the event types it gets (all without it).
+func LoadPlugin(path string) (Handler, []string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open plugin %s: %w", path, err)
+	}
+	symbol, err := p.Lookup("HandleEvent")
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid plugin %s: %w", path, err)
+	}
+	handle, ok := symbol.(func(string, time.Time, map[string]string))
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid plugin %s: HandleEvent must be a func(string, time.Time, map[string]string), not %T", path, symbol)
+	}
+
+	var types []string
+	if symbol, err := p.Lookup("Events"); err == nil {
+		events, ok := symbol.(*[]string)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid plugin %s: Events must be a []string, not %T", path, symbol)
+		}
+		types = *events
+	}
+	return func(event Event) { handle(event.Type, event.Time, event.Fields) }, types, nil
+}
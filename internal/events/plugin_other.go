@@ -0,0 +1,10 @@
+//go:build !cgo || !(linux || darwin || freebsd)
+
+package events
+
+import "fmt"
+
+// LoadPlugin fails: Go plugins need a build with cgo on Linux, macOS, or FreeBSD
+func LoadPlugin(path string) (Handler, []string, error) {
+	return nil, nil, fmt.Errorf("failed to open plugin %s: this sinkzone build doesn't support Go plugins (build it from source with cgo on Linux, macOS, or FreeBSD)", path)
+}
@@ -0,0 +1,18 @@
+// Package focus holds what the CLI and the TUI share about showing focus sessions
+package focus
+
+import (
+	"fmt"
+	"time"
+)
+
+// Countdown formats the time left as mm:ss, or h:mm:ss from an hour up. Negative durations
+// show as 00:00.
+func Countdown(d time.Duration) string {
+	d = max(d, 0).Truncate(time.Second)
+	hours, minutes, seconds := int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60
+	if hours > 0 {
+		return fmt.Sprintf("%d:%02d:%02d", hours, minutes, seconds)
+	}
+	return fmt.Sprintf("%02d:%02d", minutes, seconds)
+}
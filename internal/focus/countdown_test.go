@@ -0,0 +1,30 @@
+package focus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountdown(t *testing.T) {
+	tests := []struct {
+		d        time.Duration
+		expected string
+	}{
+		{-time.Minute, "00:00"},
+		{0, "00:00"},
+		{59*time.Second + 900*time.Millisecond, "00:59"},
+		{25 * time.Minute, "25:00"},
+		{time.Hour - time.Second, "59:59"},
+		// Truncated rather than rounded, so it never shows more time than is left
+		{time.Hour - 400*time.Millisecond, "59:59"},
+		{time.Hour - 600*time.Millisecond, "59:59"},
+		{time.Hour, "1:00:00"},
+		{26*time.Hour + 3*time.Minute + 7*time.Second, "26:03:07"},
+	}
+
+	for _, test := range tests {
+		if got := Countdown(test.d); got != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.d, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package hooks
+
+import (
+	"log"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/events"
+)
+
+// Subscribe runs the executables of hooks.events for the events they are configured for,
+// and hands events to the Go plugins of hooks.plugins. A plugin that fails to load is
+// skipped with a warning.
+func Subscribe(cfg *config.HooksConfig, bus *events.Bus) error {
+	scripts, err := cfg.GetEvents()
+	if err != nil {
+		return err
+	}
+	for eventType, path := range scripts {
+		bus.Subscribe(eventType+" hook", scriptHandler(path, runScript), eventType)
+	}
+
+	if cfg == nil {
+		return nil
+	}
+	for _, path := range cfg.Plugins {
+		handler, types, err := events.LoadPlugin(expandHome(path))
+		if err != nil {
+			log.Printf("Warning: %v", err)
+			continue
+		}
+		log.Printf("Loaded plugin %s", path)
+		bus.Subscribe("plugin "+path, handler, types...)
+	}
+	return nil
+}
+
+// scriptHandler runs the executable at path for each event, with SINKZONE_EVENT,
+// SINKZONE_TIME, and the event's fields in its environment
+func scriptHandler(path string, exec func(path string, env []string) error) events.Handler {
+	return func(event events.Event) {
+		env := append([]string{"SINKZONE_EVENT=" + event.Type, "SINKZONE_TIME=" + event.Time.Format(time.RFC3339)}, environ(event.Fields)...)
+		if err := exec(path, env); err != nil {
+			log.Printf("Warning: %s hook failed: %v", event.Type, err)
+		}
+	}
+}
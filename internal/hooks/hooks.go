@@ -0,0 +1,178 @@
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/events"
+	"github.com/berbyte/sinkzone/internal/logs"
+)
+
+// Hook events, passed to scripts as SINKZONE_EVENT
+const (
+	EventFocusStart = "focus_start"
+	EventFocusEnd   = "focus_end"
+)
+
+// FocusState is the part of the API server the hook runner watches
+type FocusState interface {
+	GetFocusState() api.FocusModeState
+}
+
+// Runner runs the configured hook scripts when a focus session starts or ends, and
+// publishes focus_started and focus_ended events
+type Runner struct {
+	cfg    *config.HooksConfig
+	focus  FocusState
+	events *events.Bus
+	exec   func(path string, env []string) error
+
+	active  bool
+	session api.FocusModeState // Latest state of the running session, reported again when it ends
+	started time.Time
+}
+
+// logger writes the hook runner's log; hooks follow focus sessions, so at the level set for
+// logs.ComponentDNS
+var logger = logs.Component(logs.ComponentDNS)
+
+const (
+	// checkInterval is how often the runner polls the focus state
+	checkInterval = 2 * time.Second
+	// hookTimeout bounds how long a hook script may run
+	hookTimeout = 30 * time.Second
+)
+
+// NewRunner creates a hook runner for the configured scripts; cfg may be nil
+func NewRunner(cfg *config.HooksConfig, focus FocusState) *Runner {
+	if cfg == nil {
+		cfg = &config.HooksConfig{}
+	}
+	return &Runner{
+		cfg:   cfg,
+		focus: focus,
+		exec:  runScript,
+	}
+}
+
+// SetEvents makes the runner publish focus sessions starting and ending on bus
+func (r *Runner) SetEvents(bus *events.Bus) {
+	r.events = bus
+}
+
+// Run watches the focus state until the stop channel is closed
+func (r *Runner) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		r.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check fires the start or end hook when the session state flips
+func (r *Runner) check(now time.Time) {
+	state := r.focus.GetFocusState()
+	active := state.Enabled && (state.EndTime == nil || now.Before(*state.EndTime))
+
+	switch {
+	case active && !r.active:
+		r.active = true
+		r.session = state
+		r.started = now
+		r.fire(r.cfg.OnFocusStart, EventFocusStart, events.FocusStarted, now)
+	case active:
+		// Track changes such as extensions so the end hook sees the final session
+		r.session = state
+	case r.active:
+		r.active = false
+		r.fire(r.cfg.OnFocusEnd, EventFocusEnd, events.FocusEnded, now)
+	}
+}
+
+// fire publishes a session event and runs its hook script in the background, with the
+// session described in the script's environment
+func (r *Runner) fire(path, event, eventType string, now time.Time) {
+	fields := map[string]string{
+		"focus_profile": r.session.Profile,
+		"focus_label":   r.session.Label,
+		"focus_start":   r.started.Format(time.RFC3339),
+	}
+	if r.session.EndTime != nil {
+		fields["focus_end"] = r.session.EndTime.Format(time.RFC3339)
+		fields["focus_duration"] = r.session.EndTime.Sub(r.started).Round(time.Second).String()
+	}
+	if event == EventFocusEnd {
+		fields["focus_elapsed"] = now.Sub(r.started).Round(time.Second).String()
+	}
+	r.events.Publish(eventType, fields)
+	if path == "" {
+		return
+	}
+
+	env := append([]string{"SINKZONE_EVENT=" + event}, environ(fields)...)
+	logger.Info("Running hook", "event", event, "path", path)
+	go func() {
+		if err := r.exec(path, env); err != nil {
+			logger.Warn("Hook failed", "event", event, "error", err)
+		}
+	}()
+}
+
+// environ turns event fields into environment variables, e.g. focus_label into
+// SINKZONE_FOCUS_LABEL
+func environ(fields map[string]string) []string {
+	env := make([]string, 0, len(fields))
+	for name, value := range fields {
+		env = append(env, "SINKZONE_"+strings.ToUpper(name)+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// runScript executes a hook with the given extra environment variables
+func runScript(path string, env []string) error {
+	path = expandHome(path)
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	// #nosec G204 -- the hook path comes from the user's own config file
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Env = append(os.Environ(), env...)
+
+	output, err := cmd.CombinedOutput()
+	if len(output) > 0 {
+		logger.Info("Hook output", "path", path, "output", strings.TrimSpace(string(output)))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to run %s: %w", path, err)
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
@@ -0,0 +1,76 @@
+package hooks
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/events"
+)
+
+type fakeFocus struct {
+	state api.FocusModeState
+}
+
+func (f *fakeFocus) GetFocusState() api.FocusModeState {
+	return f.state
+}
+
+func TestRunnerFiresOnStartAndEnd(t *testing.T) {
+	focus := &fakeFocus{}
+	runner := NewRunner(&config.HooksConfig{OnFocusStart: "start.sh", OnFocusEnd: "end.sh"}, focus)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	calls := map[string][]string{}
+	runner.exec = func(path string, env []string) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		calls[path] = env
+		return nil
+	}
+
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := now.Add(time.Hour)
+
+	runner.check(now)
+	if len(calls) != 0 {
+		t.Fatalf("Expected no hooks while focus mode is off, got %v", calls)
+	}
+
+	focus.state = api.FocusModeState{Enabled: true, EndTime: &end, Profile: "deep-work"}
+	wg.Add(1)
+	runner.check(now)
+	runner.check(now.Add(time.Minute))
+
+	// The session expires without being disabled
+	wg.Add(1)
+	runner.check(end.Add(time.Second))
+	wg.Wait()
+
+	start := calls["start.sh"]
+	if !slices.Contains(start, "SINKZONE_EVENT=focus_start") || !slices.Contains(start, "SINKZONE_FOCUS_PROFILE=deep-work") || !slices.Contains(start, "SINKZONE_FOCUS_DURATION=1h0m0s") {
+		t.Errorf("Unexpected start hook environment: %v", start)
+	}
+	if !slices.Contains(calls["end.sh"], "SINKZONE_EVENT=focus_end") {
+		t.Errorf("Unexpected end hook environment: %v", calls["end.sh"])
+	}
+}
+
+func TestScriptHandler(t *testing.T) {
+	var env []string
+	handler := scriptHandler("blocked.sh", func(path string, scriptEnv []string) error {
+		env = scriptEnv
+		return nil
+	})
+	handler(events.Event{Type: events.QueryBlocked, Time: time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC), Fields: map[string]string{"domain": "reddit.com", "query_type": "A"}})
+
+	want := []string{"SINKZONE_EVENT=query_blocked", "SINKZONE_TIME=2025-03-10T09:00:00Z", "SINKZONE_DOMAIN=reddit.com", "SINKZONE_QUERY_TYPE=A"}
+	if !slices.Equal(env, want) {
+		t.Errorf("expected %v, got %v", want, env)
+	}
+}
@@ -0,0 +1,303 @@
+package i18n
+
+// german translates the TUI and CLI into German
+var german = map[string]string{
+	// sinkzone allowlist
+	"Domain '%s' added to allowlist.":                                          "Domain '%s' zur Allowlist hinzugefügt.",
+	"Note: Allowlist changes take effect when you start a new focus session.":  "Hinweis: Änderungen an der Allowlist gelten ab der nächsten Fokus-Sitzung.",
+	"Domain '%s' removed from allowlist.":                                      "Domain '%s' aus der Allowlist entfernt.",
+	"Allowlist is empty.":                                                      "Die Allowlist ist leer.",
+	"Allowlist (%d domains):":                                                  "Allowlist (%d Domains):",
+	"Domain '%s' added to break domains.":                                      "Domain '%s' zu den Pausen-Domains hinzugefügt.",
+	"Note: Restart the resolver to apply break domain changes.":                "Hinweis: Starte den Resolver neu, damit Änderungen an den Pausen-Domains gelten.",
+	"Domain '%s' removed from break domains.":                                  "Domain '%s' aus den Pausen-Domains entfernt.",
+	"No break domains configured.":                                             "Keine Pausen-Domains konfiguriert.",
+	"Break domains (%d domains):":                                              "Pausen-Domains (%d Domains):",
+	"The allowlist has %d invalid entries:":                                    "Die Allowlist enthält %d ungültige Einträge:",
+	"Edit again? [Y/n] ":                                                       "Erneut bearbeiten? [J/n] ",
+	"Allowlist saved to %s.":                                                   "Allowlist in %s gespeichert.",
+	"Note: The resolver is not running; the allowlist applies when it starts.": "Hinweis: Der Resolver läuft nicht; die Allowlist gilt, sobald er startet.",
+	"Resolver reloaded the allowlist.":                                         "Der Resolver hat die Allowlist neu geladen.",
+	"Added %d domains to the allowlist (%d already listed).":                   "%d Domains zur Allowlist hinzugefügt (%d waren schon eingetragen).",
+	"Removed %d domains from the allowlist (%d not listed).":                   "%d Domains aus der Allowlist entfernt (%d waren nicht eingetragen).",
+	"Nothing to undo.":                                                         "Nichts rückgängig zu machen.",
+	"Undid '%s' from %s.":                                                      "'%s' vom %s rückgängig gemacht.",
+
+	// sinkzone status
+	"=== Sinkzone Status ===":                            "=== Sinkzone-Status ===",
+	"Resolver: NOT RUNNING":                              "Resolver: LÄUFT NICHT",
+	"Resolver: UNKNOWN (cannot read PID file)":           "Resolver: UNBEKANNT (PID-Datei nicht lesbar)",
+	"Resolver: RUNNING (PID: %s)":                        "Resolver: LÄUFT (PID: %s)",
+	"Upstreams:":                                         "Upstreams:",
+	"  %-24s %-9s %d answered, %d failed (%d timed out)": "  %-24s %-9s %d beantwortet, %d fehlgeschlagen (%d Zeitüberschreitungen)",
+	", %.1f ms average, %.1f ms p95":                     ", %.1f ms im Schnitt, %.1f ms p95",
+	", last error: %s":                                   ", letzter Fehler: %s",
+	"Focus mode: ON BREAK (break domains allowed)":       "Fokusmodus: PAUSE (Pausen-Domains erlaubt)",
+	"Focus mode: PAUSED":                                 "Fokusmodus: PAUSIERT",
+	"Resumes at: %s":                                     "Wird fortgesetzt um: %s",
+	"Remaining time after pause: %s":                     "Restzeit nach der Pause: %s",
+	"Focus mode: ENABLED":                                "Fokusmodus: AKTIV",
+	"Remaining time: %s":                                 "Restzeit: %s",
+	"Ends at: %s":                                        "Endet um: %s",
+	"Focus mode: EXPIRED":                                "Fokusmodus: ABGELAUFEN",
+	"Ended at: %s":                                       "Beendet um: %s",
+	"Focus mode: ENABLED (no expiration)":                "Fokusmodus: AKTIV (ohne Ablauf)",
+	"Focus mode: DISABLED":                               "Fokusmodus: INAKTIV",
+	"Profile: %s":                                        "Profil: %s",
+	"Label: %s":                                          "Label: %s",
+	"Intensity: %s":                                      "Intensität: %s",
+	"Grace period: blocking starts at %s":                "Schonfrist: Blockieren beginnt um %s",
+	"Dry run: %d queries would have been blocked":        "Testlauf: %d Anfragen wären blockiert worden",
+	"Disable queued: focus mode ends at %s":              "Beenden geplant: Fokusmodus endet um %s",
+	"Snoozed: %s until %s":                               "Zurückgestellt: %s bis %s",
+	"Last updated: %s":                                   "Zuletzt aktualisiert: %s",
+	"System config: %s":                                  "Systemkonfiguration: %s",
+	" (from %s)":                                         " (aus %s)",
+	"Daily goal: %s / %s (%d%%)":                         "Tagesziel: %s / %s (%d%%)",
+	"Streak: %d day(s) (longest: %d)":                    "Serie: %d Tag(e) (längste: %d)",
+	"  %s: %s today, %s total":                           "  %s: %s heute, %s insgesamt",
+	"Budget %s: %s / %s used (%s left)":                  "Budget %s: %s / %s genutzt (%s übrig)",
+	"Budget %s: %s used up, blocked until tomorrow":      "Budget %s: %s aufgebraucht, bis morgen blockiert",
+
+	// sinkzone status: the check that this machine's DNS queries reach sinkzone
+	"Warning: this machine's DNS queries stopped reaching sinkzone after %s; a VPN or network change may have replaced its nameserver. Run 'sinkzone doctor'.": "Warnung: Die DNS-Anfragen dieses Rechners erreichen sinkzone seit %s nicht mehr; ein VPN oder Netzwechsel hat vielleicht den Nameserver ersetzt. Führe 'sinkzone doctor' aus.",
+	"Warning: this machine's DNS queries don't reach sinkzone; a VPN or network change may have replaced its nameserver. Run 'sinkzone doctor'.":               "Warnung: Die DNS-Anfragen dieses Rechners erreichen sinkzone nicht; ein VPN oder Netzwechsel hat vielleicht den Nameserver ersetzt. Führe 'sinkzone doctor' aus.",
+	"DNS check: this machine's queries reach sinkzone (checked %s)":                                                                                            "DNS-Prüfung: Die Anfragen dieses Rechners erreichen sinkzone (geprüft %s)",
+
+	// sinkzone status and passthrough: captive portals
+	"Passthrough: nothing is blocked until %s, queries go to %s":                                                  "Durchlass: Bis %s wird nichts blockiert, Anfragen gehen an %s",
+	"Passthrough: nothing is blocked until %s":                                                                    "Durchlass: Bis %s wird nichts blockiert",
+	"Warning: captive portal suspected (%s). Run 'sinkzone passthrough' to let the network's login page through.": "Warnung: Vermutlich ein Captive Portal (%s). Führe 'sinkzone passthrough' aus, um die Anmeldeseite des Netzes durchzulassen.",
+	"Captive portal detection: off":                                                                               "Captive-Portal-Erkennung: aus",
+	"Captive portal: none suspected":                                                                              "Captive Portal: keines vermutet",
+
+	// TUI: tabs, header, and footer
+	"Monitoring":           "Überwachung",
+	"Allowlist":            "Allowlist",
+	"Stats":                "Statistik",
+	"Focus":                "Fokus",
+	"Devices":              "Geräte",
+	"Goodbye!":             "Auf Wiedersehen!",
+	"No content available": "Kein Inhalt verfügbar",
+	"%s Switch tabs | %s Focus mode | %s Help | %s Quit": "%s Tabs wechseln | %s Fokusmodus | %s Hilfe | %s Beenden",
+	"🔒 FOCUS MODE ACTIVE":                                "🔒 FOKUSMODUS AKTIV",
+	"Upstream":                                           "Upstream",
+	"no data received yet":                               "noch keine Daten empfangen",
+	"showing data from %s":                               "Daten von %s",
+	"⚠ Resolver API error: %s | %s":                      "⚠ Fehler der Resolver-API: %s | %s",
+	"⚠ This machine's DNS queries bypass sinkzone, so nothing is blocked for it. Run 'sinkzone doctor'.": "⚠ Die DNS-Anfragen dieses Rechners umgehen sinkzone, daher wird nichts blockiert. Führe 'sinkzone doctor' aus.",
+	"⚠ Passthrough: nothing is blocked until %s so the network's login page can load":                    "⚠ Durchlass: Bis %s wird nichts blockiert, damit die Anmeldeseite des Netzes laden kann",
+	"⚠ Captive portal suspected: run 'sinkzone passthrough' to let the network's login page through":     "⚠ Vermutlich ein Captive Portal: Führe 'sinkzone passthrough' aus, um die Anmeldeseite des Netzes durchzulassen",
+
+	// TUI: Monitoring tab
+	"\n🔒 FOCUS MODE ACTIVE\n\nMonitoring is disabled during focus mode.\n\nDNS monitoring is temporarily disabled to help you stay focused.\n\nYou can still manage your allowlist.\n\nPress ←/→ to switch to other tabs.": "\n🔒 FOKUSMODUS AKTIV\n\nDie Überwachung ist im Fokusmodus deaktiviert.\n\nDie DNS-Überwachung ist vorübergehend aus, damit du konzentriert bleibst.\n\nDeine Allowlist kannst du weiterhin verwalten.\n\nMit ←/→ wechselst du zu anderen Tabs.",
+	"Search: %s█  (Enter to apply, Esc to clear)": "Suche: %s█  (Enter anwenden, Esc löschen)",
+	"Filter: %s  (/ to edit, Esc to clear)":       "Filter: %s  (/ bearbeiten, Esc löschen)",
+	"\nNo queries match the filter.\n\nSearch by domain, or use client:<address>, user:<name>, is:blocked, or is:allowed.":                              "\nKeine Anfragen passen zum Filter.\n\nSuche nach Domain oder nutze client:<adresse>, user:<name>, is:blocked oder is:allowed.",
+	"\nNo DNS queries recorded yet.\n\nTry making some web requests to see DNS activity.\n\nMake sure the resolver is running with 'sinkzone resolver'": "\nNoch keine DNS-Anfragen aufgezeichnet.\n\nRufe ein paar Webseiten auf, um DNS-Aktivität zu sehen.\n\nStelle sicher, dass der Resolver mit 'sinkzone resolver' läuft",
+	"Domain":                           "Domain",
+	"Time":                             "Zeit",
+	"Status":                           "Status",
+	"BLOCK":                            "SPERRE",
+	"ALLOW":                            "FREI",
+	"● Following newest":               "● Folgt den neuesten",
+	"At %d/%d (Home to follow newest)": "Bei %d/%d (Pos1 folgt den neuesten)",
+	"%s | Rows %d-%d of %d | Last updated: %s": "%s | Zeilen %d-%d von %d | Aktualisiert: %s",
+	"Rows %d-%d of %d":                         "Zeilen %d-%d von %d",
+	"allow (or remove)":                        "erlauben (oder entfernen)",
+	"%s %s to stop | %s":                       "%s %s zum Beenden | %s",
+	"⏸ PAUSED":                                 "⏸ PAUSIERT",
+	"%s %d new, %s to resume | %s":             "%s %d neu, %s zum Fortsetzen | %s",
+	"● LIVE (%d skipped)":                      "● LIVE (%d übersprungen)",
+	"● LIVE":                                   "● LIVE",
+	"%d marked: %s to %s, Esc to clear":        "%d markiert: %s zum %s, Esc hebt auf",
+
+	// TUI: allowlist chooser and query details
+	"Allow %s":                             "%s erlauben",
+	"Exact domain":                         "Exakte Domain",
+	"Sibling hosts":                        "Geschwister-Hosts",
+	"Registered domain and all subdomains": "Registrierte Domain und alle Subdomains",
+	"%s/%s Select | %s or 1-%d Allow | Esc Cancel":                        "%s/%s Auswählen | %s oder 1-%d Erlauben | Esc Abbrechen",
+	"%s/%s Select | %s Allow | %s Mark | %s Allow marked | Esc Close":     "%s/%s Auswählen | %s Erlauben | %s Markieren | %s Markierte erlauben | Esc Schließen",
+	"Allowlist suggestions: blocked repeatedly alongside allowed domains": "Vorschläge für die Allowlist: wiederholt blockiert neben erlaubten Domains",
+	"alongside %s":                "neben %s",
+	"CNAME of %s":                 "CNAME von %s",
+	"%d blocked":                  "%d blockiert",
+	"Query details":               "Details der Anfrage",
+	"Client":                      "Client",
+	"Client host":                 "Client-Host",
+	"Query type":                  "Anfragetyp",
+	"Response":                    "Antwort",
+	"Latency":                     "Latenz",
+	"User":                        "Benutzer",
+	"Reason":                      "Grund",
+	"Count":                       "Anzahl",
+	"Allowed":                     "Erlaubt",
+	"Blocked":                     "Blockiert",
+	"Would be blocked":            "Würde blockiert",
+	"looking up...":               "wird nachgeschlagen...",
+	"unknown":                     "unbekannt",
+	"none (answered locally)":     "keiner (lokal beantwortet)",
+	"listed":                      "eingetragen",
+	"Press Esc or %s to go back.": "Esc oder %s führt zurück.",
+
+	"%d within recent_queries.aggregate": "%d innerhalb von recent_queries.aggregate",
+
+	// TUI: Allowlist tab
+	"\nAllowlist is empty.\n\nAdd domains to your allowlist to permit them during focus mode.\n\nUse the Monitoring tab to see which domains are being accessed.": "\nDie Allowlist ist leer.\n\nFüge Domains hinzu, um sie im Fokusmodus zu erlauben.\n\nIm Tab Überwachung siehst du, welche Domains aufgerufen werden.",
+	"Type":     "Typ",
+	"EXACT":    "EXAKT",
+	"WILDCARD": "WILDCARD",
+	"Allowlist (%d domains) | %s to remove domains | %s to mark": "Allowlist (%d Domains) | %s entfernt Domains | %s markiert",
+	"remove them":            "Entfernen",
+	"Allowlist (%d domains)": "Allowlist (%d Domains)",
+
+	// TUI: Stats tab
+	"\nStats are not available.\n\nMake sure the resolver is running with 'sinkzone resolver'":                              "\nKeine Statistik verfügbar.\n\nStelle sicher, dass der Resolver mit 'sinkzone resolver' läuft",
+	"\nFocus time today: %s\n\nNo daily goal configured.\n\nSet one in sinkzone.yaml to track streaks:\n\n  daily_goal: 2h": "\nFokuszeit heute: %s\n\nKein Tagesziel konfiguriert.\n\nLege eines in sinkzone.yaml fest, um Serien zu verfolgen:\n\n  daily_goal: 2h",
+	"In progress": "Läuft",
+	"Goal met!":   "Ziel erreicht!",
+	"\nDaily goal:      %s\nFocus today:     %s\nProgress:        %s %d%%\nStatus:          %s\n\nCurrent streak:  %d day(s)\nLongest streak:  %d day(s)": "\nTagesziel:       %s\nFokus heute:     %s\nFortschritt:     %s %d%%\nStatus:          %s\n\nAktuelle Serie:  %d Tag(e)\nLängste Serie:   %d Tag(e)",
+	"Queries since %s: %d (%d allowed, %d blocked)":        "Anfragen seit %s: %d (%d erlaubt, %d blockiert)",
+	"Blocked:         %s %d%%":                             "Blockiert:       %s %d%%",
+	"Last hour:       %s":                                  "Letzte Stunde:   %s",
+	"Latency:         %.1f ms average, %.1f ms p95":        "Latenz:          %.1f ms im Schnitt, %.1f ms p95",
+	"Distraction:     %s %.1f blocked/min (%s), peak %.1f": "Ablenkung:       %s %.1f blockiert/min (%s), Spitze %.1f",
+	"calm":                           "ruhig",
+	"elevated":                       "erhöht",
+	"high, take a break or recommit": "hoch, Pause machen oder neu fokussieren",
+	"Top domains":                    "Häufigste Domains",
+	"Top clients":                    "Häufigste Clients",
+	"(none yet)":                     "(noch keine)",
+	"By label:":                      "Nach Label:",
+	"Label":                          "Label",
+	"Today":                          "Heute",
+	"Total":                          "Gesamt",
+	"Daily budgets:":                 "Tagesbudgets:",
+	"used up":                        "aufgebraucht",
+
+	// TUI: Focus tab
+	"profile default":                        "Profilvorgabe",
+	"until stopped":                          "bis zum Beenden",
+	"default allowlist":                      "Standard-Allowlist",
+	"configured default":                     "konfigurierte Vorgabe",
+	"Duration:   ◀ %s ▶":                     "Dauer:      ◀ %s ▶",
+	"Profile:    ◀ %s ▶":                     "Profil:     ◀ %s ▶",
+	"Intensity:  ◀ %s ▶":                     "Intensität: ◀ %s ▶",
+	"Focus mode is off. Choose a session:":   "Der Fokusmodus ist aus. Wähle eine Sitzung:",
+	"%s/%s Select | %s/%s Change | %s Start": "%s/%s Auswählen | %s/%s Ändern | %s Starten",
+	"🔒 Focus mode is active.":                "🔒 Der Fokusmodus ist aktiv.",
+	"Session details are unavailable while the resolver is unreachable.": "Sitzungsdetails sind nicht verfügbar, solange der Resolver nicht erreichbar ist.",
+	"🔒 Focus mode is active": "🔒 Der Fokusmodus ist aktiv",
+	"Paused":                 "Pausiert",
+	"On a break":             "In der Pause",
+	"Status:     %s until %s (%s left in the session)":     "Status:     %s bis %s (noch %s in der Sitzung)",
+	"Remaining:  %s (ends %s)":                             "Restzeit:   %s (endet %s)",
+	"Remaining:  until stopped":                            "Restzeit:   bis zum Beenden",
+	"Profile:    %s":                                       "Profil:     %s",
+	"Intensity:  %s":                                       "Intensität: %s",
+	"Label:      %s":                                       "Label:      %s",
+	"Stopping:   at %s (disable delay)":                    "Endet:      um %s (Abschaltverzögerung)",
+	"Blocked:    %d queries this session":                  "Blockiert:  %d Anfragen in dieser Sitzung",
+	"Dry run:    %d queries would have been blocked":       "Testlauf:   %d Anfragen wären blockiert worden",
+	"%s Extend %s | %s Pause %s | %s Stop":                 "%s Verlängern %s | %s Pausieren %s | %s Beenden",
+	"%s Resume | %s Stop":                                  "%s Fortsetzen | %s Beenden",
+	"A focus PIN is set: pausing and stopping ask for it.": "Eine Fokus-PIN ist gesetzt: Pausieren und Beenden fragen nach ihr.",
+	// TUI: Devices tab
+	"\nNo devices yet.\n\nDevices appear here once they send queries. Start the resolver with --lan\nto serve the devices of your network and tell them apart by MAC address.": "\nNoch keine Geräte.\n\nGeräte erscheinen hier, sobald sie Anfragen senden. Starte den Resolver mit --lan,\num die Geräte deines Netzwerks zu bedienen und sie an ihrer MAC-Adresse zu unterscheiden.",
+	"Device":              "Gerät",
+	"MAC":                 "MAC",
+	"Queries":             "Anfragen",
+	"always in focus":     "immer im Fokus",
+	"never in focus":      "nie im Fokus",
+	"follows the session": "folgt der Sitzung",
+	"until %s":            "bis %s",
+	"Devices (%d) | %s to switch focus: follow the session, always, never": "Geräte (%d) | %s wechselt den Fokus: Sitzung folgen, immer, nie",
+	"A focus PIN is set: taking a device out of focus asks for it.":        "Eine Fokus-PIN ist gesetzt: Ein Gerät aus dem Fokus zu nehmen fragt nach ihr.",
+	// TUI: focus PIN prompt
+	"%s: focus PIN %s█ (Enter to confirm, Esc to cancel)": "%s: Fokus-PIN %s█ (Enter bestätigt, Esc bricht ab)",
+	"Pause focus mode":             "Fokusmodus pausieren",
+	"Take the device out of focus": "Gerät aus dem Fokus nehmen",
+
+	"paused, %s left": "pausiert, noch %s",
+	"paused":          "pausiert",
+	"%s left":         "noch %s",
+
+	// TUI: overlays
+	"Keyboard shortcuts":        "Tastenkürzel",
+	"Press %s or Esc to close.": "%s oder Esc schließt.",
+	"Messages":                  "Meldungen",
+	"No messages yet.":          "Noch keine Meldungen.",
+	"Settings":                  "Einstellungen",
+	"Refresh:           ◀ %s ▶  (queries, focus, and health)":                                     "Aktualisierung:    ◀ %s ▶  (Anfragen, Fokus und Zustand)",
+	"Allowlist reload:  ◀ %s ▶":                                                                   "Allowlist laden:   ◀ %s ▶",
+	"Changes apply right away. %s/%s Select | %s/%s Change | %s Save to config | %s or Esc Close": "Änderungen gelten sofort. %s/%s Auswählen | %s/%s Ändern | %s In Konfiguration speichern | %s oder Esc Schließen",
+
+	// TUI: help sections
+	"Global":         "Allgemein",
+	"Stats and help": "Statistik und Hilfe",
+	"Commands":       "Befehle",
+	"Previous tab":   "Vorheriger Tab",
+	"Next tab":       "Nächster Tab",
+	"Monitoring tab": "Tab Überwachung",
+	"Allowlist tab":  "Tab Allowlist",
+	"Stats tab":      "Tab Statistik",
+	"Focus tab":      "Tab Fokus",
+	"Devices tab":    "Tab Geräte",
+	"Start focus mode with the session chosen in the Focus tab":         "Fokusmodus mit der im Tab Fokus gewählten Sitzung starten",
+	"Cycle the focus profile":                                           "Fokusprofil wechseln",
+	"Show or hide this help":                                            "Diese Hilfe ein- oder ausblenden",
+	"Show or hide recent messages and errors":                           "Letzte Meldungen und Fehler ein- oder ausblenden",
+	"Adjust the refresh and allowlist reload intervals":                 "Intervalle für Aktualisierung und Neuladen der Allowlist anpassen",
+	"Open the command prompt (commands below; ↑/↓ recall earlier ones)": "Befehlszeile öffnen (Befehle unten; ↑/↓ holt frühere zurück)",
+	"Review allowlist suggestions: domains blocked repeatedly in the last focus session alongside allowed ones": "Vorschläge für die Allowlist prüfen: Domains, die in der letzten Fokus-Sitzung neben erlaubten wiederholt blockiert wurden",
+	"Quit (first clears an active search; Ctrl+C always quits)":                                                 "Beenden (löscht zuerst eine aktive Suche; Strg+C beendet immer)",
+	"Select the previous query":                    "Vorherige Anfrage auswählen",
+	"Select the next query":                        "Nächste Anfrage auswählen",
+	"Scroll a page up":                             "Eine Seite nach oben blättern",
+	"Scroll a page down":                           "Eine Seite nach unten blättern",
+	"Jump to the newest query and follow new ones": "Zur neuesten Anfrage springen und neuen folgen",
+	"Jump to the oldest query":                     "Zur ältesten Anfrage springen",
+	"Allow the selected domain (choose exact, sibling hosts, or the whole registered domain) or remove it": "Ausgewählte Domain erlauben (exakt, Geschwister-Hosts oder die ganze registrierte Domain) oder entfernen",
+	"Mark or unmark the selected domain for a batch action":                                                "Ausgewählte Domain für eine Sammelaktion markieren oder Markierung aufheben",
+	"Allow the marked domains, or remove them when all are allowlisted (Esc clears marks)":                 "Markierte Domains erlauben oder entfernen, wenn alle schon erlaubt sind (Esc hebt Markierungen auf)",
+	"Show details of the selected query (Esc closes)":                                                      "Details der ausgewählten Anfrage zeigen (Esc schließt)",
+	"Pause or resume auto-refresh of the table":                                                            "Automatische Aktualisierung der Tabelle pausieren oder fortsetzen",
+	"Export the filtered table as CSV":                                                                     "Gefilterte Tabelle als CSV exportieren",
+	"Export the filtered table as JSON":                                                                    "Gefilterte Tabelle als JSON exportieren",
+	"Resume auto-refresh":                                                                                  "Automatische Aktualisierung fortsetzen",
+	"Live tail: stream new queries as they happen instead of polling":                                      "Live-Ansicht: neue Anfragen sofort streamen statt abzufragen",
+	"Search: domain text, client:<address>, user:<name>, is:blocked, is:allowed":                           "Suche: Domaintext, client:<adresse>, user:<name>, is:blocked, is:allowed",
+	"Undo the last allowlist change":                                                                       "Letzte Änderung an der Allowlist rückgängig machen",
+	"Ignore the selected domain: hide its queries here and from stats (ignore_domains)":                    "Ausgewählte Domain ignorieren: ihre Anfragen hier und in der Statistik ausblenden (ignore_domains)",
+	"While searching: apply / clear the filter":                                                            "Beim Suchen: Filter anwenden / löschen",
+	"Select the previous domain":                                                                           "Vorherige Domain auswählen",
+	"Select the next domain":                                                                               "Nächste Domain auswählen",
+	"Remove the selected domain":                                                                           "Ausgewählte Domain entfernen",
+	"Mark or unmark the selected domain":                                                                   "Ausgewählte Domain markieren oder Markierung aufheben",
+	"Remove the marked domains (Esc clears marks)":                                                         "Markierte Domains entfernen (Esc hebt Markierungen auf)",
+	"Export the allowlist as CSV":                                                                          "Allowlist als CSV exportieren",
+	"Export the allowlist as JSON":                                                                         "Allowlist als JSON exportieren",
+	"Scroll up":                                                                                            "Nach oben scrollen",
+	"Scroll down":                                                                                          "Nach unten scrollen",
+	"Select the previous setting":                                                                          "Vorherige Einstellung auswählen",
+	"Select the next setting":                                                                              "Nächste Einstellung auswählen",
+	"Next value of the selected setting":                                                                   "Nächster Wert der ausgewählten Einstellung",
+	"Previous value of the selected setting":                                                               "Vorheriger Wert der ausgewählten Einstellung",
+	"Start the session":                                                                                    "Sitzung starten",
+	"Extend the running session by %s":                                                                     "Laufende Sitzung um %s verlängern",
+	"Pause for %s":                                                                                         "Für %s pausieren",
+	"Resume a paused session":                                                                              "Pausierte Sitzung fortsetzen",
+	"Select the previous device":                                                                           "Vorheriges Gerät auswählen",
+	"Select the next device":                                                                               "Nächstes Gerät auswählen",
+	"Switch the device between following the session, always in focus, and never in focus": "Gerät zwischen Sitzung folgen, immer im Fokus und nie im Fokus wechseln",
+	"Stop the session":                                                                            "Sitzung beenden",
+	"Add a domain or wildcard to the allowlist":                                                   "Domain oder Wildcard zur Allowlist hinzufügen",
+	"Remove an allowlist entry (also :rm)":                                                        "Eintrag aus der Allowlist entfernen (auch :rm)",
+	"Start focus mode with the chosen profile and intensity (e.g. :focus 45m)":                    "Fokusmodus mit gewähltem Profil und gewählter Intensität starten (z. B. :focus 45m)",
+	"Stop focus mode":                                                                             "Fokusmodus beenden",
+	"Stop focus mode (asks for the focus PIN when one is set)":                                    "Fokusmodus beenden (fragt nach der Fokus-PIN, wenn eine gesetzt ist)",
+	"Choose the focus profile (:profile default for the default allowlist)":                       "Fokusprofil wählen (:profile default für die Standard-Allowlist)",
+	"Filter the Monitoring tab; blocked and allowed are shorthands for is:blocked and is:allowed": "Tab Überwachung filtern; blocked und allowed stehen für is:blocked und is:allowed",
+	"Quit (also :q)": "Beenden (auch :q)",
+}
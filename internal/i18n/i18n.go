@@ -0,0 +1,108 @@
+// Package i18n translates the TUI and CLI. English strings are the message keys, so an
+// untranslated string falls back to English.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// translations maps each supported language other than English to its catalog
+var translations = map[language.Tag]map[string]string{
+	language.German: german,
+}
+
+// supported lists the selectable languages; the first is the fallback
+var supported = []language.Tag{language.English, language.German}
+
+var (
+	messages = newCatalog()
+	matcher  = language.NewMatcher(supported)
+	printer  = message.NewPrinter(language.English, message.Catalog(messages))
+	current  = language.English
+)
+
+func newCatalog() catalog.Catalog {
+	builder := catalog.NewBuilder(catalog.Fallback(language.English))
+	for tag, entries := range translations {
+		for key, translation := range entries {
+			if err := builder.SetString(tag, key, translation); err != nil {
+				panic(fmt.Sprintf("invalid translation of %q: %v", key, err))
+			}
+		}
+	}
+	return builder
+}
+
+// SetLanguage selects the output language. configured is the language setting from the
+// config file (e.g. "de"); when empty, LC_ALL, LC_MESSAGES, and LANG are consulted in turn.
+// Unsupported locales fall back to English; an unsupported configured language is an error.
+func SetLanguage(configured string) error {
+	name := configured
+	if name == "" {
+		name = localeFromEnv()
+	}
+
+	tag := language.English
+	if name != "" {
+		parsed, err := language.Parse(normalizeLocale(name))
+		if err == nil {
+			_, index, confidence := matcher.Match(parsed)
+			if confidence != language.No {
+				tag = supported[index]
+			} else {
+				err = fmt.Errorf("no translation available")
+			}
+		}
+		if err != nil && configured != "" {
+			return fmt.Errorf("unsupported language %q (use %s): %w", configured, strings.Join(Supported(), " or "), err)
+		}
+	}
+
+	current = tag
+	printer = message.NewPrinter(tag, message.Catalog(messages))
+	return nil
+}
+
+// Language returns the selected language, e.g. "en" or "de"
+func Language() string {
+	return current.String()
+}
+
+// Supported lists the codes of the selectable languages
+func Supported() []string {
+	codes := make([]string, len(supported))
+	for i, tag := range supported {
+		codes[i] = tag.String()
+	}
+	return codes
+}
+
+// T translates key and formats it with args, like fmt.Sprintf
+func T(key string, args ...interface{}) string {
+	return printer.Sprintf(key, args...)
+}
+
+// localeFromEnv returns the locale from the environment, ignoring the C and POSIX locales
+func localeFromEnv() string {
+	for _, variable := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(variable)
+		if value != "" && value != "C" && value != "POSIX" {
+			return value
+		}
+	}
+	return ""
+}
+
+// normalizeLocale turns a POSIX locale such as "de_DE.UTF-8@euro" into a BCP 47 tag ("de-DE")
+func normalizeLocale(locale string) string {
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return strings.ReplaceAll(locale, "_", "-")
+}
@@ -0,0 +1,60 @@
+package i18n
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSetLanguage(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_DE.UTF-8")
+	defer func() { _ = SetLanguage("en") }()
+
+	if err := SetLanguage(""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if Language() != "de" {
+		t.Errorf("expected LANG to select de, got %s", Language())
+	}
+	if got := T("Rows %d-%d of %d", 1, 20, 42); got != "Zeilen 1-20 von 42" {
+		t.Errorf("unexpected translation: %q", got)
+	}
+	if got := T("not in the catalog"); got != "not in the catalog" {
+		t.Errorf("expected untranslated keys to fall back to English, got %q", got)
+	}
+
+	if err := SetLanguage("en"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := T("Rows %d-%d of %d", 1, 20, 42); got != "Rows 1-20 of 42" {
+		t.Errorf("the configured language should override LANG, got %q", got)
+	}
+
+	if err := SetLanguage("fr"); err == nil {
+		t.Error("expected an error for an unsupported configured language")
+	}
+
+	t.Setenv("LANG", "fr_FR.UTF-8")
+	if err := SetLanguage(""); err != nil || Language() != "en" {
+		t.Errorf("expected an unsupported LANG to fall back to en, got %s (%v)", Language(), err)
+	}
+}
+
+// TestGermanVerbs keeps translations from dropping or reordering format verbs
+func TestGermanVerbs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+	for key, translation := range german {
+		want, got := verbs.FindAllString(key, -1), verbs.FindAllString(translation, -1)
+		if len(want) != len(got) {
+			t.Errorf("%q: expected verbs %v, got %v", key, want, got)
+			continue
+		}
+		for i := range want {
+			if want[i] != got[i] {
+				t.Errorf("%q: expected verbs %v, got %v", key, want, got)
+				break
+			}
+		}
+	}
+}
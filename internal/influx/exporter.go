@@ -0,0 +1,116 @@
+// Package influx writes the resolver's metrics to InfluxDB, or any endpoint taking the
+// InfluxDB line protocol, such as VictoriaMetrics or Grafana Cloud
+package influx
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/metrics"
+)
+
+// Exporter periodically writes the metrics of GET /metrics to a line protocol endpoint
+type Exporter struct {
+	cfg      *config.InfluxDBConfig
+	url      string
+	interval time.Duration
+	tags     map[string]string
+	client   *http.Client
+
+	failing bool // The last write failed; logged once until a write succeeds
+}
+
+// NewExporter validates the influxdb config and creates an exporter
+func NewExporter(cfg *config.InfluxDBConfig) (*Exporter, error) {
+	url, err := cfg.GetURL()
+	if err != nil {
+		return nil, err
+	}
+	interval, err := cfg.GetInterval()
+	if err != nil {
+		return nil, err
+	}
+	// Tell the machines apart when several resolvers write to the same bucket
+	host, _ := os.Hostname()
+
+	return &Exporter{
+		cfg:      cfg,
+		url:      url,
+		interval: interval,
+		tags:     map[string]string{"host": host},
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// Run writes the metrics every interval until the stop channel is closed
+func (e *Exporter) Run(stop <-chan struct{}) {
+	log.Printf("Writing metrics to InfluxDB every %s", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			e.export(time.Now())
+		}
+	}
+}
+
+// export writes the current metrics, logging when writes start or stop failing
+func (e *Exporter) export(now time.Time) {
+	err := e.write(now)
+	switch {
+	case err != nil && !e.failing:
+		log.Printf("Warning: failed to write metrics to InfluxDB (retrying every %s): %v", e.interval, err)
+		e.failing = true
+	case err == nil && e.failing:
+		log.Printf("Writing metrics to InfluxDB again")
+		e.failing = false
+	}
+}
+
+func (e *Exporter) write(now time.Time) error {
+	var body bytes.Buffer
+	if err := metrics.WriteLineProtocol(&body, e.tags, now); err != nil {
+		return fmt.Errorf("failed to format metrics: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, e.url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	switch {
+	case e.cfg.Token != "":
+		req.Header.Set("Authorization", "Token "+e.cfg.Token)
+	case e.cfg.Username != "":
+		req.SetBasicAuth(e.cfg.Username, e.cfg.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close InfluxDB response: %v", err)
+		}
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		// InfluxDB explains rejected writes in the body
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status code: %d %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package influx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/metrics"
+)
+
+func TestExporterWritesLineProtocol(t *testing.T) {
+	metrics.NewCounter("test_influx_writes_total", "Writes to InfluxDB").Inc()
+
+	var body, auth string
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body, auth = string(data), r.Header.Get("Authorization")
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	exporter, err := NewExporter(&config.InfluxDBConfig{URL: server.URL + "/api/v2/write?org=home&bucket=sinkzone", Token: "secret"})
+	if err != nil {
+		t.Fatalf("Failed to create exporter: %v", err)
+	}
+	exporter.tags = map[string]string{"host": "laptop"}
+
+	if err := exporter.write(time.Unix(1, 0)); err != nil {
+		t.Fatalf("Expected the write to succeed, got %v", err)
+	}
+	if auth != "Token secret" || !strings.Contains(body, "test_influx_writes_total,host=laptop value=1i 1000000000\n") {
+		t.Errorf("Expected the counter written with the token, got %q:\n%s", auth, body)
+	}
+
+	status = http.StatusBadRequest
+	if err := exporter.write(time.Now()); err == nil {
+		t.Error("Expected a rejected write to fail")
+	}
+
+	if _, err := NewExporter(&config.InfluxDBConfig{URL: "localhost:8086"}); err == nil {
+		t.Error("Expected a URL without a scheme to be refused")
+	}
+}
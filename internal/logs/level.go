@@ -0,0 +1,96 @@
+package logs
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// debugPrefix starts the messages written by Debugf, so their level can be told apart
+const debugPrefix = "debug: "
+
+// minLevel is the lowest level the standard logger writes, set with SetLevel
+var minLevel atomic.Int32
+
+func init() {
+	minLevel.Store(int32(LevelInfo))
+}
+
+// SetLevel sets the lowest level written by the standard logger once SetOutput installed
+// the filter, and by Debugf
+func SetLevel(level Level) {
+	minLevel.Store(int32(level))
+}
+
+// CurrentLevel returns the level set with SetLevel (info by default)
+func CurrentLevel() Level {
+	return Level(minLevel.Load())
+}
+
+// SetOutput sends the standard logger and the component loggers to w, dropping lines below
+// the level set with SetLevel. The level of each standard logger line is inferred from its
+// wording, like when reading the log.
+func SetOutput(w io.Writer) {
+	outputMu.Lock()
+	output = w
+	outputMu.Unlock()
+	log.SetOutput(&levelWriter{out: w})
+}
+
+// Debugf logs a message that is only written at the debug level
+func Debugf(format string, args ...interface{}) {
+	if CurrentLevel() > LevelDebug {
+		return
+	}
+	// Calldepth 2 reports the caller of Debugf when log.Lshortfile is set
+	_ = log.Output(2, "Debug: "+fmt.Sprintf(format, args...))
+}
+
+// levelWriter drops log lines below the current level
+type levelWriter struct {
+	out io.Writer
+}
+
+// Write receives one complete line per call from the standard logger
+func (w *levelWriter) Write(p []byte) (int, error) {
+	message := strings.TrimSpace(string(p))
+	// Skip the timestamp written with log.LstdFlags
+	if len(message) >= len(timeLayout) {
+		if _, err := time.Parse(timeLayout, message[:len(timeLayout)]); err == nil {
+			message = strings.TrimSpace(message[len(timeLayout):])
+		}
+	}
+	level := levelOf(message)
+	if level < CurrentLevel() {
+		return len(p), nil
+	}
+	if jsonFormat.Load() {
+		if err := write(time.Now(), level, trimLevelPrefix(message), nil); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if leveled, ok := w.out.(leveledOutput); ok {
+		if err := leveled.WriteLevel(level, trimLevelPrefix(message)); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	return w.out.Write(p)
+}
+
+// trimLevelPrefix drops the "Debug: " or "Warning: " a message starts with, which JSON
+// entries carry in their level instead
+func trimLevelPrefix(message string) string {
+	for _, prefix := range []string{"Debug: ", "Warning: ", "WARNING: "} {
+		if trimmed, ok := strings.CutPrefix(message, prefix); ok {
+			return trimmed
+		}
+	}
+	return message
+}
@@ -0,0 +1,222 @@
+// Package logs writes and reads the resolver log. Components log through slog (see
+// Component) and everything else through the standard log package, both as text lines
+// ("2006/01/02 15:04:05 message") or, with SetFormat, as JSON objects. Text lines have no
+// explicit levels, so the level of a line is inferred from how the message starts.
+package logs
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Level is the severity of a log line
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// timeLayout is the prefix written by the standard log package (log.LstdFlags)
+const timeLayout = "2006/01/02 15:04:05"
+
+// pollInterval is how often Follow checks the file for new lines
+const pollInterval = 500 * time.Millisecond
+
+// String returns the name accepted by ParseLevel
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
+// ParseLevel parses "debug", "info", "warn" (or "warning"), or "error"
+func ParseLevel(value string) (Level, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return LevelDebug, nil
+	case "info", "":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarn, nil
+	case "error":
+		return LevelError, nil
+	default:
+		return LevelInfo, fmt.Errorf("invalid log level %q (use debug, info, warn, or error)", value)
+	}
+}
+
+// Entry is one line of the log with its parsed time and inferred level
+type Entry struct {
+	Time  time.Time // Zero when neither the line nor an earlier one had a timestamp
+	Level Level
+	Line  string
+}
+
+// ParseLine parses a log line, in text or JSON format. Lines without a timestamp (e.g. a
+// panic trace) continue the previous entry and take its time and level.
+func ParseLine(line string, prev Entry) Entry {
+	if strings.HasPrefix(line, "{") {
+		var entry struct {
+			Time  time.Time `json:"time"`
+			Level string    `json:"level"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err == nil && !entry.Time.IsZero() {
+			level, _ := ParseLevel(entry.Level)
+			return Entry{Time: entry.Time, Level: level, Line: line}
+		}
+	}
+	if len(line) < len(timeLayout) {
+		return Entry{Time: prev.Time, Level: prev.Level, Line: line}
+	}
+	t, err := time.ParseInLocation(timeLayout, line[:len(timeLayout)], time.Local)
+	if err != nil {
+		return Entry{Time: prev.Time, Level: prev.Level, Line: line}
+	}
+	return Entry{Time: t, Level: levelOf(strings.TrimSpace(line[len(timeLayout):])), Line: line}
+}
+
+// levelOf infers the level of a log message from its wording
+func levelOf(message string) Level {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.HasPrefix(lower, debugPrefix):
+		return LevelDebug
+	case strings.HasPrefix(lower, "error"), strings.HasPrefix(lower, "fatal"), strings.HasPrefix(lower, "panic"):
+		return LevelError
+	case strings.HasPrefix(lower, "warning"), strings.Contains(lower, " failed"):
+		return LevelWarn
+	default:
+		return LevelInfo
+	}
+}
+
+// Filter selects log entries by level and age
+type Filter struct {
+	MinLevel Level
+	Since    time.Time // Zero keeps entries of any age
+}
+
+// Match reports whether an entry passes the filter
+func (f Filter) Match(entry Entry) bool {
+	if entry.Level < f.MinLevel {
+		return false
+	}
+	return f.Since.IsZero() || !entry.Time.Before(f.Since)
+}
+
+// Read returns the entries in the file that pass the filter, keeping only the last
+// limit of them (all when limit <= 0), and the file size so Follow can continue from there
+func Read(path string, filter Filter, limit int) ([]Entry, int64, error) {
+	// #nosec G304 -- path is the resolver log file chosen by the user
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close log file: %v\n", closeErr)
+		}
+	}()
+
+	var entries []Entry
+	var prev Entry
+	var offset int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			// A partial last line is picked up by Follow once it is complete
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to read log file: %w", err)
+		}
+		offset += int64(len(line))
+		prev = ParseLine(strings.TrimRight(line, "\r\n"), prev)
+		if filter.Match(prev) {
+			entries = append(entries, prev)
+			if limit > 0 && len(entries) > limit {
+				entries = entries[1:]
+			}
+		}
+	}
+	return entries, offset, nil
+}
+
+// Follow calls emit for each entry appended to the file after offset that passes the
+// filter, until stop is closed. When the file is truncated (e.g. rotated), it starts over
+// from the beginning.
+func Follow(path string, offset int64, filter Filter, stop <-chan struct{}, emit func(Entry)) error {
+	var prev Entry
+	var partial string
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		info, err := os.Stat(path)
+		switch {
+		case os.IsNotExist(err):
+			offset, partial = 0, ""
+		case err != nil:
+			return fmt.Errorf("failed to stat log file: %w", err)
+		case info.Size() < offset:
+			offset, partial = 0, ""
+		case info.Size() > offset:
+			data, err := readFrom(path, offset, info.Size())
+			if err != nil {
+				return err
+			}
+			offset += int64(len(data))
+			lines := strings.Split(partial+string(data), "\n")
+			partial = lines[len(lines)-1]
+			for _, line := range lines[:len(lines)-1] {
+				prev = ParseLine(strings.TrimRight(line, "\r"), prev)
+				if filter.Match(prev) {
+					emit(prev)
+				}
+			}
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// readFrom reads the bytes of the file between start and end
+func readFrom(path string, start, end int64) ([]byte, error) {
+	// #nosec G304 -- path is the resolver log file chosen by the user
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close log file: %v\n", closeErr)
+		}
+	}()
+
+	data := make([]byte, end-start)
+	n, err := file.ReadAt(data, start)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return data[:n], nil
+}
@@ -0,0 +1,224 @@
+package logs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseLine(t *testing.T) {
+	entry := ParseLine("2026/10/17 09:30:00 Warning: failed to save state: disk full", Entry{})
+	want := time.Date(2026, 10, 17, 9, 30, 0, 0, time.Local)
+	if !entry.Time.Equal(want) || entry.Level != LevelWarn {
+		t.Errorf("expected a warning at %v, got %v at %v", want, entry.Level, entry.Time)
+	}
+
+	for line, level := range map[string]Level{
+		"2026/10/17 09:30:00 Error starting DNS server: permission denied": LevelError,
+		"2026/10/17 09:30:00 Upstream 8.8.8.8:53 failed: i/o timeout":      LevelWarn,
+		"2026/10/17 09:30:00 DNS query for example.com":                    LevelInfo,
+		"2026/10/17 09:30:00 Debug: API Client: Health check failed":       LevelDebug,
+	} {
+		if got := ParseLine(line, Entry{}).Level; got != level {
+			t.Errorf("expected %v for %q, got %v", level, line, got)
+		}
+	}
+
+	continued := ParseLine("goroutine 1 [running]:", entry)
+	if !continued.Time.Equal(entry.Time) || continued.Level != entry.Level {
+		t.Errorf("expected a continuation line to inherit %v/%v, got %v/%v", entry.Time, entry.Level, continued.Time, continued.Level)
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer func() {
+		SetLevel(LevelInfo)
+		log.SetOutput(os.Stderr)
+	}()
+
+	SetLevel(LevelWarn)
+	log.Printf("Starting DNS server")
+	Debugf("API Request: GET /health")
+	log.Printf("Warning: upstream slow")
+	if got := buf.String(); !bytes.Contains(buf.Bytes(), []byte("Warning: upstream slow")) || bytes.Contains(buf.Bytes(), []byte("Starting")) {
+		t.Errorf("expected only the warning at the warn level, got %q", got)
+	}
+
+	buf.Reset()
+	SetLevel(LevelDebug)
+	Debugf("API Request: %s", "GET /health")
+	if !bytes.Contains(buf.Bytes(), []byte("Debug: API Request: GET /health")) {
+		t.Errorf("expected the debug line at the debug level, got %q", buf.String())
+	}
+}
+
+func TestComponent(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer func() {
+		SetLevel(LevelInfo)
+		SetComponentLevels(nil)
+		SetFormat(FormatText)
+		SetOutput(os.Stderr)
+	}()
+
+	SetComponentLevels(map[string]Level{ComponentDNS: LevelDebug})
+	Component(ComponentDNS).Debug("Blocked", "domain", "reddit.com")
+	Component(ComponentAPI).Debug("API request", "path", "/health")
+	if got := buf.String(); !strings.Contains(got, "Debug: Blocked domain=reddit.com") || strings.Contains(got, "API request") {
+		t.Errorf("expected only the dns debug line, got %q", got)
+	}
+	if entry := ParseLine(strings.TrimSpace(buf.String()), Entry{}); entry.Level != LevelDebug {
+		t.Errorf("expected the text line to read back as debug, got %v", entry.Level)
+	}
+
+	buf.Reset()
+	SetFormat(FormatJSON)
+	Component(ComponentAPI).Warn("Failed to close query log", "error", errors.New("disk full"))
+	log.Printf("Starting DNS server")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 JSON lines, got %q", buf.String())
+	}
+	var warning map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &warning); err != nil {
+		t.Fatalf("failed to parse %q: %v", lines[0], err)
+	}
+	if warning["level"] != "warn" || warning["component"] != ComponentAPI || warning["error"] != "disk full" {
+		t.Errorf("unexpected JSON line %v", warning)
+	}
+	if entry := ParseLine(lines[1], Entry{}); entry.Level != LevelInfo || entry.Time.IsZero() || !strings.Contains(entry.Line, `"msg":"Starting DNS server"`) {
+		t.Errorf("expected the standard logger line as JSON, got %+v", entry)
+	}
+}
+
+func TestFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "resolver.log")
+	f, err := OpenFile(path, Rotation{MaxSize: 20, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, line := range []string{"first line 123\n", "second line 12\n", "third line 123\n", "fourth line 12\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for file, want := range map[string]string{path: "fourth line 12\n", path + ".1": "third line 123\n", path + ".2": "second line 12\n"} {
+		if data, _ := os.ReadFile(file); string(data) != want {
+			t.Errorf("expected %q in %s, got %q", want, file, data)
+		}
+	}
+	if rotated := RotatedFiles(path); len(rotated) != 2 || rotated[0] != path+".1" {
+		t.Errorf("expected 2 rotated files, newest first, got %v", rotated)
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolver.log")
+	content := "2026/10/17 09:00:00 Starting DNS server\n" +
+		"2026/10/17 09:10:00 Warning: upstream slow\n" +
+		"2026/10/17 09:20:00 Error handling request\n" +
+		"  detail line\n" +
+		"2026/10/17 09:30:00 Warning: partial"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+
+	entries, offset, err := Read(path, Filter{MinLevel: LevelWarn}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 3 || entries[2].Line != "  detail line" {
+		t.Errorf("expected 3 entries ending with the detail line, got %+v", entries)
+	}
+	if want := int64(len(content) - len("2026/10/17 09:30:00 Warning: partial")); offset != want {
+		t.Errorf("expected offset %d before the partial line, got %d", want, offset)
+	}
+
+	since := time.Date(2026, 10, 17, 9, 15, 0, 0, time.Local)
+	entries, _, err = Read(path, Filter{Since: since}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Line != "  detail line" {
+		t.Errorf("expected only the last matching entry, got %+v", entries)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolver.log")
+	if err := os.WriteFile(path, []byte("2026/10/17 09:00:00 Starting\n"), 0600); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	_, offset, err := Read(path, Filter{}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stop := make(chan struct{})
+	lines := make(chan string, 10)
+	done := make(chan error)
+	go func() {
+		done <- Follow(path, offset, Filter{}, stop, func(entry Entry) { lines <- entry.Line })
+	}()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	if _, err := file.WriteString("2026/10/17 09:01:00 Appended\n"); err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+	_ = file.Close()
+
+	select {
+	case line := <-lines:
+		if line != "2026/10/17 09:01:00 Appended" {
+			t.Errorf("expected the appended line, got %q", line)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("timed out waiting for the appended line")
+	}
+	close(stop)
+	if err := <-done; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// leveledBuffer records entries like the system log, with their level
+type leveledBuffer struct {
+	entries []string
+}
+
+func (b *leveledBuffer) WriteLevel(level Level, message string) error {
+	b.entries = append(b.entries, level.String()+" "+message)
+	return nil
+}
+
+func (b *leveledBuffer) Write(p []byte) (int, error) {
+	return len(p), b.WriteLevel(LevelInfo, strings.TrimSpace(string(p)))
+}
+
+func TestLeveledOutput(t *testing.T) {
+	var buf leveledBuffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+
+	log.Printf("Warning: upstream slow")
+	log.Printf("Starting DNS server")
+	Component(ComponentDNS).Error("Failed to bind", "addr", ":53")
+	want := []string{"warn upstream slow", "info Starting DNS server", "error Failed to bind addr=:53"}
+	if strings.Join(buf.entries, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected %q, got %q", want, buf.entries)
+	}
+}
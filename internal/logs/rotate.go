@@ -0,0 +1,147 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation says when a log file is rotated and how many rotated files are kept
+type Rotation struct {
+	MaxSize  int64         // Bytes the file may reach before it's rotated (0 = no limit)
+	MaxAge   time.Duration // How long the file is written to before it's rotated (0 = no limit)
+	MaxFiles int           // Rotated files kept, as path.1 (newest) to path.N (0 = keep none)
+}
+
+// File is a log file that rotates itself: once it would grow beyond Rotation.MaxSize or has
+// been written to for Rotation.MaxAge, it's renamed to path.1, older files move up by one,
+// and a new file is started
+type File struct {
+	path     string
+	rotation Rotation
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+}
+
+// OpenFile opens a log file for appending, creating its directory when needed
+func OpenFile(path string, rotation Rotation) (*File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &File{path: path, rotation: rotation}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *File) open() error {
+	// #nosec G304 -- the log file is chosen by the user in sinkzone.yaml
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
+	f.file, f.size, f.opened = file, info.Size(), time.Now()
+	return nil
+}
+
+// Write appends p, rotating the file first when p would take it past its limits. A
+// failed rotation keeps writing to the current file rather than losing the line.
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.size > 0 && f.due(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to rotate log file: %v\n", err)
+			f.opened = time.Now() // Retry at the next limit, not at every line
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// due reports whether writing n more bytes calls for a rotation
+func (f *File) due(n int64) bool {
+	r := f.rotation
+	return (r.MaxSize > 0 && f.size+n > r.MaxSize) || (r.MaxAge > 0 && time.Since(f.opened) >= r.MaxAge)
+}
+
+// rotate renames the current file to path.1, shifting and pruning older ones
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	// Closed first, as Windows can't rename an open file
+	if f.rotation.MaxFiles > 0 {
+		for i := f.rotation.MaxFiles - 1; i >= 1; i-- {
+			_ = os.Rename(rotatedPath(f.path, i), rotatedPath(f.path, i+1))
+		}
+		if err := os.Rename(f.path, rotatedPath(f.path, 1)); err != nil {
+			return f.reopen(err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return f.reopen(err)
+	}
+	f.prune()
+	return f.open()
+}
+
+// reopen continues with the current file after a failed rotation
+func (f *File) reopen(cause error) error {
+	if err := f.open(); err != nil {
+		return err
+	}
+	return cause
+}
+
+// prune removes rotated files beyond MaxFiles, e.g. left behind by a lower setting
+func (f *File) prune() {
+	for _, path := range RotatedFiles(f.path) {
+		n, _ := strconv.Atoi(path[strings.LastIndexByte(path, '.')+1:])
+		if n > f.rotation.MaxFiles {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// Close closes the file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.file.Close()
+}
+
+// RotatedFiles returns the rotated files of a log, newest first
+func RotatedFiles(path string) []string {
+	matches, _ := filepath.Glob(path + ".*")
+	var rotated []string
+	numbers := map[string]int{}
+	for _, match := range matches {
+		n, err := strconv.Atoi(strings.TrimPrefix(match, path+"."))
+		if err == nil && n > 0 {
+			rotated = append(rotated, match)
+			numbers[match] = n
+		}
+	}
+	sort.Slice(rotated, func(i, j int) bool { return numbers[rotated[i]] < numbers[rotated[j]] })
+	return rotated
+}
+
+func rotatedPath(path string, n int) string {
+	return path + "." + strconv.Itoa(n)
+}
@@ -0,0 +1,224 @@
+package logs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Components whose loggers (see Component) can be given their own level
+const (
+	ComponentDNS = "dns" // The DNS server: queries, focus sessions, upstreams
+	ComponentAPI = "api" // The HTTP API and its client
+)
+
+// Components lists the components in the order they are documented
+var Components = []string{ComponentDNS, ComponentAPI}
+
+// Formats of the log, chosen with --log-format or log_format
+const (
+	FormatText = "text" // Lines like the standard log package writes (default)
+	FormatJSON = "json" // One JSON object per line
+)
+
+// ParseFormat checks a log format name; empty means text
+func ParseFormat(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid log format %q (use text or json)", value)
+	}
+}
+
+var (
+	// output is where every log line goes, set with SetOutput
+	output   io.Writer = os.Stderr
+	outputMu sync.Mutex
+
+	// jsonFormat is set while the log is written as JSON (see SetFormat)
+	jsonFormat atomic.Bool
+
+	// componentLevels holds the levels set with SetComponentLevels
+	componentLevels atomic.Pointer[map[string]Level]
+)
+
+// SetFormat switches every log line, including those of the standard logger, to format
+func SetFormat(format string) {
+	jsonFormat.Store(format == FormatJSON)
+}
+
+// SetComponentLevels gives components their own lowest level, replacing earlier ones;
+// other components log at the level set with SetLevel
+func SetComponentLevels(levels map[string]Level) {
+	copied := make(map[string]Level, len(levels))
+	for component, level := range levels {
+		copied[component] = level
+	}
+	componentLevels.Store(&copied)
+}
+
+// ComponentLevel returns the lowest level a component logs
+func ComponentLevel(component string) Level {
+	if levels := componentLevels.Load(); levels != nil {
+		if level, ok := (*levels)[component]; ok {
+			return level
+		}
+	}
+	return CurrentLevel()
+}
+
+// Component returns the structured logger of a component. Its records carry a component
+// attribute and are filtered by the component's level.
+func Component(name string) *slog.Logger {
+	return slog.New(&handler{component: name})
+}
+
+// handler writes slog records in the current format, filtered by the component's level
+type handler struct {
+	component string
+	attrs     []slog.Attr
+	group     string // Prefix of the keys of later attributes, e.g. "request."
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= ComponentLevel(h.component).slogLevel()
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := *h
+	next.attrs = append(append([]slog.Attr(nil), h.attrs...), h.prefixed(attrs)...)
+	return &next
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	next := *h
+	next.group = h.group + name + "."
+	return &next
+}
+
+// prefixed qualifies the keys of attributes with the current group
+func (h *handler) prefixed(attrs []slog.Attr) []slog.Attr {
+	if h.group == "" {
+		return attrs
+	}
+	qualified := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		qualified[i] = slog.Attr{Key: h.group + attr.Key, Value: attr.Value}
+	}
+	return qualified
+}
+
+func (h *handler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make([]slog.Attr, 0, len(h.attrs)+record.NumAttrs()+1)
+	attrs = append(attrs, slog.String("component", h.component))
+	attrs = append(attrs, h.attrs...)
+	var own []slog.Attr
+	record.Attrs(func(attr slog.Attr) bool {
+		own = append(own, attr)
+		return true
+	})
+	attrs = append(attrs, h.prefixed(own)...)
+	return write(record.Time, levelFromSlog(record.Level), record.Message, attrs)
+}
+
+// write formats one log entry and writes it to the output
+func write(t time.Time, level Level, message string, attrs []slog.Attr) error {
+	var line []byte
+	asJSON := jsonFormat.Load()
+	if asJSON {
+		entry := map[string]any{"time": t.Format(time.RFC3339Nano), "level": level.String(), "msg": message}
+		for _, attr := range attrs {
+			entry[attr.Key] = attr.Value.Resolve().Any()
+			if err, ok := entry[attr.Key].(error); ok {
+				entry[attr.Key] = err.Error()
+			}
+		}
+		var err error
+		if line, err = json.Marshal(entry); err != nil {
+			return err
+		}
+	} else {
+		var b strings.Builder
+		b.WriteString(message)
+		for _, attr := range attrs {
+			if attr.Key == "component" {
+				continue // Text lines read like the rest of the log
+			}
+			b.WriteByte(' ')
+			b.WriteString(attr.Key)
+			b.WriteByte('=')
+			b.WriteString(textValue(attr.Value.Resolve().String()))
+		}
+		line = []byte(b.String())
+	}
+
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	if leveled, ok := output.(leveledOutput); ok {
+		return leveled.WriteLevel(level, string(line))
+	}
+	if !asJSON {
+		line = append([]byte(t.Format(timeLayout)+" "+level.prefix()), line...)
+	}
+	_, err := output.Write(append(line, '\n'))
+	return err
+}
+
+// textValue quotes a value that would otherwise be hard to tell apart in a text line
+func textValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\n") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// prefix starts text messages of the level, so the level can be inferred when reading them
+func (l Level) prefix() string {
+	switch l {
+	case LevelDebug:
+		return "Debug: "
+	case LevelWarn:
+		return "Warning: "
+	case LevelError:
+		return "Error: "
+	default:
+		return ""
+	}
+}
+
+func (l Level) slogLevel() slog.Level {
+	switch l {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func levelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	default:
+		return LevelError
+	}
+}
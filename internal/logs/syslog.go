@@ -0,0 +1,33 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Outputs of the resolver log, chosen with log_output
+const (
+	OutputFile   = "file"   // log_file, or standard error (default)
+	OutputSyslog = "syslog" // The system log: syslog (read by journald too) or the Windows Event Log
+)
+
+// SyslogTag names the resolver in the system log
+const SyslogTag = "sinkzone"
+
+// ParseOutput checks a log output name; empty means file
+func ParseOutput(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "", OutputFile:
+		return OutputFile, nil
+	case OutputSyslog:
+		return OutputSyslog, nil
+	default:
+		return "", fmt.Errorf("invalid log output %q (use file or syslog)", value)
+	}
+}
+
+// leveledOutput is an output that records the level of each entry itself, like the system
+// log; it gets entries without the timestamp and level prefix of text lines
+type leveledOutput interface {
+	WriteLevel(level Level, message string) error
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package logs
+
+import (
+	"fmt"
+	"log/syslog"
+	"strings"
+)
+
+// Syslog is the system log, sent to the local syslog daemon (or journald) with the
+// daemon facility and a priority after the level of each entry
+type Syslog struct {
+	w *syslog.Writer
+}
+
+// OpenSyslog connects to the local syslog daemon
+func OpenSyslog() (*Syslog, error) {
+	w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, SyslogTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &Syslog{w: w}, nil
+}
+
+// WriteLevel sends a message with the priority of level
+func (s *Syslog) WriteLevel(level Level, message string) error {
+	switch level {
+	case LevelDebug:
+		return s.w.Debug(message)
+	case LevelWarn:
+		return s.w.Warning(message)
+	case LevelError:
+		return s.w.Err(message)
+	default:
+		return s.w.Info(message)
+	}
+}
+
+// Write sends a line at the info priority
+func (s *Syslog) Write(p []byte) (int, error) {
+	if err := s.w.Info(strings.TrimSpace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the connection to the syslog daemon
+func (s *Syslog) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,53 @@
+//go:build windows
+
+package logs
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventID is the ID of every event the resolver logs
+const eventID = 1
+
+// Syslog is the system log on Windows: the Application log of the Event Log, under the
+// source registered when the service is installed. Debug entries are logged as information.
+type Syslog struct {
+	log *eventlog.Log
+}
+
+// OpenSyslog opens the Event Log
+func OpenSyslog() (*Syslog, error) {
+	log, err := eventlog.Open(SyslogTag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the event log: %w", err)
+	}
+	return &Syslog{log: log}, nil
+}
+
+// WriteLevel logs a message with the event type of level
+func (s *Syslog) WriteLevel(level Level, message string) error {
+	switch level {
+	case LevelWarn:
+		return s.log.Warning(eventID, message)
+	case LevelError:
+		return s.log.Error(eventID, message)
+	default:
+		return s.log.Info(eventID, message)
+	}
+}
+
+// Write logs a line as information
+func (s *Syslog) Write(p []byte) (int, error) {
+	if err := s.log.Info(eventID, strings.TrimSpace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close closes the Event Log
+func (s *Syslog) Close() error {
+	return s.log.Close()
+}
@@ -0,0 +1,112 @@
+// Package macfocus mirrors a macOS Focus, such as Work, and sinkzone's focus sessions
+package macfocus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Focus is a macOS Focus mode
+type Focus struct {
+	ID   string // e.g. com.apple.focus.work
+	Name string // e.g. Work, as shown in Control Center
+}
+
+// assertions is the part of ~/Library/DoNotDisturb/DB/Assertions.json naming the Focus
+// turned on, whether from Control Center, a shortcut, or another device of the Apple ID
+type assertions struct {
+	Data []struct {
+		StoreAssertionRecords []struct {
+			AssertionStartDateTimestamp float64 `json:"assertionStartDateTimestamp"`
+			AssertionDetails            struct {
+				ModeIdentifier string `json:"assertionDetailsModeIdentifier"`
+			} `json:"assertionDetails"`
+		} `json:"storeAssertionRecords"`
+	} `json:"data"`
+}
+
+// modeConfigurations is the part of ~/Library/DoNotDisturb/DB/ModeConfigurations.json
+// naming each Focus
+type modeConfigurations struct {
+	Data []struct {
+		ModeConfigurations map[string]struct {
+			Mode struct {
+				Name           string `json:"name"`
+				ModeIdentifier string `json:"modeIdentifier"`
+			} `json:"mode"`
+		} `json:"modeConfigurations"`
+	} `json:"data"`
+}
+
+// Active reads the Focus turned on for the user the resolver runs for, or nil when none is.
+// Reading the files needs Full Disk Access for the resolver's executable.
+func Active() (*Focus, error) {
+	dir, err := databaseDir()
+	if err != nil {
+		return nil, err
+	}
+	// #nosec G304 -- fixed files in the user's Library
+	assertionsJSON, err := os.ReadFile(filepath.Join(dir, "Assertions.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Focus state (grant sinkzone Full Disk Access): %w", err)
+	}
+	// #nosec G304 -- fixed files in the user's Library
+	modesJSON, err := os.ReadFile(filepath.Join(dir, "ModeConfigurations.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Focus modes (grant sinkzone Full Disk Access): %w", err)
+	}
+	return parseActive(assertionsJSON, modesJSON)
+}
+
+// parseActive picks the most recently turned on Focus from the assertions and names it
+func parseActive(assertionsJSON, modesJSON []byte) (*Focus, error) {
+	var state assertions
+	if err := json.Unmarshal(assertionsJSON, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse the Focus state: %w", err)
+	}
+
+	var focus *Focus
+	var started float64
+	for _, data := range state.Data {
+		for _, record := range data.StoreAssertionRecords {
+			id := record.AssertionDetails.ModeIdentifier
+			if id != "" && (focus == nil || record.AssertionStartDateTimestamp > started) {
+				focus = &Focus{ID: id, Name: id}
+				started = record.AssertionStartDateTimestamp
+			}
+		}
+	}
+	if focus == nil {
+		return nil, nil
+	}
+
+	var modes modeConfigurations
+	if err := json.Unmarshal(modesJSON, &modes); err != nil {
+		return nil, fmt.Errorf("failed to parse the Focus modes: %w", err)
+	}
+	for _, data := range modes.Data {
+		if mode, ok := data.ModeConfigurations[focus.ID]; ok && mode.Mode.Name != "" {
+			focus.Name = mode.Mode.Name
+		}
+	}
+	return focus, nil
+}
+
+// databaseDir returns the Focus database of the user who ran sudo, or the user the resolver
+// runs as
+func databaseDir() (string, error) {
+	u, ok := config.InvokingUser()
+	if !ok {
+		current, err := user.Current()
+		if err != nil {
+			return "", fmt.Errorf("failed to look up the current user: %w", err)
+		}
+		u = current
+	}
+	return filepath.Join(u.HomeDir, "Library", "DoNotDisturb", "DB"), nil
+}
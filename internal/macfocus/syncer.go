@@ -0,0 +1,196 @@
+package macfocus
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// FocusController is the part of the API server the syncer drives
+type FocusController interface {
+	GetFocusMode() (bool, *time.Time)
+	ApplyFocusMode(req api.FocusRequest) error
+}
+
+// Syncer mirrors a macOS Focus and sinkzone's focus sessions. macOS has no API for turning
+// a Focus on, so the syncer runs shortcuts made with the "Set Focus" action of Shortcuts.
+//
+// Conflict rules with manual focus commands, as for process triggers:
+//   - The Focus only starts a session while focus mode is off; a manual session is never overridden.
+//   - Only sessions started by the Focus are ended when it turns off, and only the Focus the
+//     syncer turned on is turned off when the session ends.
+//   - Disabling a session started by the Focus dismisses it until the Focus turns off.
+type Syncer struct {
+	cfg      *config.MacOSFocusConfig
+	focus    FocusController
+	fromMac  bool
+	toMac    bool
+	active   func() (*Focus, error)
+	shortcut func(name string) error
+
+	owned     bool   // The running session was started by the Focus
+	turnedOn  bool   // The Focus was turned on for the running session
+	failedOn  bool   // Turning the Focus on failed for the running session; not retried
+	dismissed bool   // Don't start a session until the Focus turns off
+	lastError string // Logged once until the Focus can be read again
+}
+
+const (
+	// checkInterval is how often the Focus and the session are compared
+	checkInterval = 5 * time.Second
+	// shortcutTimeout bounds how long a shortcut may run
+	shortcutTimeout = 30 * time.Second
+)
+
+// NewSyncer validates the macos_focus config and creates a syncer
+func NewSyncer(cfg *config.MacOSFocusConfig, focus FocusController) (*Syncer, error) {
+	sync, err := cfg.GetSync()
+	if err != nil {
+		return nil, err
+	}
+	return &Syncer{
+		cfg:      cfg,
+		focus:    focus,
+		fromMac:  sync != config.MacOSFocusSyncToMac,
+		toMac:    sync != config.MacOSFocusSyncFromMac,
+		active:   Active,
+		shortcut: runShortcut,
+	}, nil
+}
+
+// Run mirrors the Focus until the stop channel is closed
+func (s *Syncer) Run(stop <-chan struct{}) {
+	sync, _ := s.cfg.GetSync()
+	log.Printf("macOS Focus sync started (%s Focus, %s)", s.cfg.GetFocus(), sync)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		s.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check starts or ends a session when the Focus turns on or off, and turns the Focus on or
+// off when a session starts or ends
+func (s *Syncer) check(now time.Time) {
+	current, err := s.active()
+	if err != nil {
+		if err.Error() != s.lastError {
+			log.Printf("Warning: failed to read the macOS Focus: %v", err)
+			s.lastError = err.Error()
+		}
+		return
+	}
+	s.lastError = ""
+	focusOn := current != nil && s.matches(*current)
+
+	enabled, endTime := s.focus.GetFocusMode()
+	sessionOn := enabled && (endTime == nil || endTime.After(now))
+
+	if s.owned {
+		switch {
+		case !sessionOn:
+			// Ended elsewhere (disabled or expired): don't restart while the Focus stays on
+			s.dismissed = focusOn
+			s.owned = false
+		case !focusOn:
+			log.Printf("macOS %s Focus turned off, disabling focus mode", s.cfg.GetFocus())
+			if err := s.focus.ApplyFocusMode(api.FocusRequest{Enabled: false}); err != nil {
+				log.Printf("Warning: failed to disable focus mode after the macOS Focus turned off (disable it manually): %v", err)
+			}
+			s.owned = false
+		}
+		return
+	}
+
+	if s.turnedOn {
+		if sessionOn {
+			// Turning the Focus off by hand leaves the session running
+			return
+		}
+		s.turnedOn = false
+		if focusOn {
+			s.run(s.offShortcut(), "off")
+			// Don't take the Focus for a new session if the shortcut failed to turn it off
+			s.dismissed = true
+		}
+		return
+	}
+
+	if !focusOn {
+		s.dismissed = false
+	}
+	if !sessionOn {
+		s.failedOn = false
+	}
+
+	switch {
+	case s.toMac && sessionOn && !focusOn && !s.failedOn:
+		s.turnedOn = s.run(s.onShortcut(), "on")
+		s.failedOn = !s.turnedOn
+	case s.fromMac && focusOn && !sessionOn && !s.dismissed:
+		log.Printf("macOS %s Focus turned on, enabling focus mode", s.cfg.GetFocus())
+		err := s.focus.ApplyFocusMode(api.FocusRequest{
+			Enabled: true,
+			Profile: s.cfg.Profile,
+			Label:   s.cfg.Label,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to enable focus mode for the macOS Focus: %v", err)
+			return
+		}
+		s.owned = true
+	}
+}
+
+// matches reports whether a Focus is the configured one, by name or identifier
+func (s *Syncer) matches(focus Focus) bool {
+	name := s.cfg.GetFocus()
+	return strings.EqualFold(focus.Name, name) || strings.EqualFold(focus.ID, name)
+}
+
+func (s *Syncer) onShortcut() string {
+	on, _ := s.cfg.GetShortcuts()
+	return on
+}
+
+func (s *Syncer) offShortcut() string {
+	_, off := s.cfg.GetShortcuts()
+	return off
+}
+
+// run runs a shortcut turning the Focus on or off, reporting whether it succeeded
+func (s *Syncer) run(shortcut, state string) bool {
+	log.Printf("Turning the macOS %s Focus %s with the shortcut %q", s.cfg.GetFocus(), state, shortcut)
+	if err := s.shortcut(shortcut); err != nil {
+		log.Printf("Warning: failed to turn the macOS Focus %s: %v", state, err)
+		return false
+	}
+	return true
+}
+
+// runShortcut runs a shortcut of the Shortcuts app by name
+func runShortcut(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), shortcutTimeout)
+	defer cancel()
+
+	// #nosec G204 -- the shortcut name comes from the user's own config file
+	output, err := exec.CommandContext(ctx, "shortcuts", "run", name).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to run shortcut %q: %w (%s)", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
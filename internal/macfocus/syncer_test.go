@@ -0,0 +1,131 @@
+package macfocus
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeFocus struct {
+	enabled  bool
+	requests []api.FocusRequest
+}
+
+func (f *fakeFocus) GetFocusMode() (bool, *time.Time) {
+	return f.enabled, nil
+}
+
+func (f *fakeFocus) ApplyFocusMode(req api.FocusRequest) error {
+	f.requests = append(f.requests, req)
+	f.enabled = req.Enabled
+	return nil
+}
+
+func TestParseActive(t *testing.T) {
+	assertionsJSON := []byte(`{"data": [{"storeAssertionRecords": [
+		{"assertionStartDateTimestamp": 100, "assertionDetails": {"assertionDetailsModeIdentifier": "com.apple.donotdisturb.mode.default"}},
+		{"assertionStartDateTimestamp": 200, "assertionDetails": {"assertionDetailsModeIdentifier": "com.apple.focus.work"}}
+	]}]}`)
+	modesJSON := []byte(`{"data": [{"modeConfigurations": {"com.apple.focus.work": {"mode": {"name": "Work", "modeIdentifier": "com.apple.focus.work"}}}}]}`)
+
+	focus, err := parseActive(assertionsJSON, modesJSON)
+	if err != nil || focus == nil || focus.ID != "com.apple.focus.work" || focus.Name != "Work" {
+		t.Fatalf("Expected the latest Focus to be Work, got %+v (%v)", focus, err)
+	}
+
+	focus, err = parseActive([]byte(`{"data": [{"storeAssertionRecords": []}]}`), modesJSON)
+	if err != nil || focus != nil {
+		t.Errorf("Expected no Focus without assertions, got %+v (%v)", focus, err)
+	}
+}
+
+func TestSyncerMirrorsBothWays(t *testing.T) {
+	focus := &fakeFocus{}
+	syncer, err := NewSyncer(&config.MacOSFocusConfig{Profile: "deep-work"}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	var mac *Focus
+	var shortcuts []string
+	syncer.active = func() (*Focus, error) { return mac, nil }
+	syncer.shortcut = func(name string) error {
+		shortcuts = append(shortcuts, name)
+		if name == "Sinkzone Focus On" {
+			mac = &Focus{ID: "com.apple.focus.work", Name: "Work"}
+		} else {
+			mac = nil
+		}
+		return nil
+	}
+
+	// The Focus starts and ends a session
+	mac = &Focus{ID: "com.apple.focus.work", Name: "Work"}
+	syncer.check(time.Now())
+	if !focus.enabled || focus.requests[0].Profile != "deep-work" {
+		t.Fatalf("Expected a deep-work session while the Focus is on, got %+v", focus.requests)
+	}
+	mac = nil
+	syncer.check(time.Now())
+	if focus.enabled || len(shortcuts) != 0 {
+		t.Fatalf("Expected the session to end with the Focus and no shortcut run, got %v", shortcuts)
+	}
+
+	// Another Focus is left alone
+	mac = &Focus{ID: "com.apple.focus.personal-time", Name: "Personal"}
+	syncer.check(time.Now())
+	if focus.enabled {
+		t.Fatal("Expected no session for another Focus")
+	}
+
+	// A session turns the Focus on and off
+	mac = nil
+	focus.enabled = true
+	syncer.check(time.Now())
+	syncer.check(time.Now())
+	focus.enabled = false
+	syncer.check(time.Now())
+	syncer.check(time.Now())
+	if !slices.Equal(shortcuts, []string{"Sinkzone Focus On", "Sinkzone Focus Off"}) || mac != nil {
+		t.Errorf("Expected the Focus turned on and off once with the session, got %v", shortcuts)
+	}
+	if len(focus.requests) != 2 {
+		t.Errorf("Expected the Focus turned on for a session not to start another, got %+v", focus.requests)
+	}
+}
+
+func TestSyncerRespectsDismissal(t *testing.T) {
+	focus := &fakeFocus{}
+	syncer, err := NewSyncer(&config.MacOSFocusConfig{Sync: config.MacOSFocusSyncFromMac}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	mac := &Focus{ID: "com.apple.focus.work", Name: "Work"}
+	syncer.active = func() (*Focus, error) { return mac, nil }
+	syncer.shortcut = func(name string) error {
+		t.Errorf("Expected no shortcut with sync from_mac, got %s", name)
+		return nil
+	}
+
+	syncer.check(time.Now())
+	focus.enabled = false // Disabled by hand while the Focus stays on
+	syncer.check(time.Now())
+	syncer.check(time.Now())
+	if len(focus.requests) != 1 {
+		t.Fatalf("Expected a dismissed session not to restart, got %+v", focus.requests)
+	}
+
+	mac = nil
+	syncer.check(time.Now())
+	mac = &Focus{ID: "com.apple.focus.work", Name: "Work"}
+	syncer.check(time.Now())
+	if !focus.enabled || len(focus.requests) != 2 {
+		t.Errorf("Expected a new session once the Focus turns on again, got %+v", focus.requests)
+	}
+
+	if _, err := NewSyncer(&config.MacOSFocusConfig{Sync: "sideways"}, focus); err == nil {
+		t.Error("Expected an unknown sync direction to be refused")
+	}
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WriteLineProtocol writes every registered metric in the InfluxDB line protocol, stamped
+// with now. Each metric is a measurement of its name, its labels and tags are tags, and
+// counters and gauges have a value field. Histograms have count and sum fields, and their
+// cumulative buckets are a <name>_bucket measurement tagged with le.
+func WriteLineProtocol(w io.Writer, tags map[string]string, now time.Time) error {
+	registryMu.Lock()
+	families := append([]*family(nil), registry...)
+	registryMu.Unlock()
+
+	var b strings.Builder
+	timestamp := now.UnixNano()
+	for _, f := range families {
+		f.writeLines(&b, tags, timestamp)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *family) writeLines(b *strings.Builder, tags map[string]string, timestamp int64) {
+	f.mu.Lock()
+	if value := f.value; value != nil {
+		f.mu.Unlock()
+		fmt.Fprintf(b, "%s%s value=%s %d\n", escapeMeasurement(f.name), tagSet(tags), formatFloat(value()), timestamp)
+		return
+	}
+	keys := slices.Sorted(maps.Keys(f.children))
+	children := make([]any, len(keys))
+	values := make([][]string, len(keys))
+	for i, key := range keys {
+		children[i], values[i] = f.children[key], f.values[key]
+	}
+	f.mu.Unlock()
+
+	for i, child := range children {
+		childTags := maps.Clone(tags)
+		if childTags == nil {
+			childTags = map[string]string{}
+		}
+		for j, label := range f.labels {
+			childTags[label] = values[i][j]
+		}
+		switch c := child.(type) {
+		case *Counter:
+			fmt.Fprintf(b, "%s%s value=%di %d\n", escapeMeasurement(f.name), tagSet(childTags), c.Value(), timestamp)
+		case *Histogram:
+			c.mu.Lock()
+			cumulative := uint64(0)
+			for j, bound := range c.buckets {
+				cumulative += c.counts[j]
+				childTags["le"] = formatFloat(bound)
+				fmt.Fprintf(b, "%s_bucket%s value=%di %d\n", escapeMeasurement(f.name), tagSet(childTags), cumulative, timestamp)
+			}
+			childTags["le"] = "+Inf"
+			fmt.Fprintf(b, "%s_bucket%s value=%di %d\n", escapeMeasurement(f.name), tagSet(childTags), c.count, timestamp)
+			delete(childTags, "le")
+			fmt.Fprintf(b, "%s%s count=%di,sum=%s %d\n", escapeMeasurement(f.name), tagSet(childTags), c.count, formatFloat(c.sum), timestamp)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// tagSet formats tags sorted by key, as InfluxDB prefers them, leaving out empty values
+func tagSet(tags map[string]string) string {
+	var b strings.Builder
+	for _, key := range slices.Sorted(maps.Keys(tags)) {
+		if tags[key] == "" {
+			continue
+		}
+		b.WriteString("," + tagEscaper.Replace(key) + "=" + tagEscaper.Replace(tags[key]))
+	}
+	return b.String()
+}
+
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+)
+
+func escapeMeasurement(name string) string {
+	return measurementEscaper.Replace(name)
+}
@@ -0,0 +1,273 @@
+// Package metrics keeps the resolver's counters and histograms and writes them in the
+// Prometheus text format, for GET /metrics, or the InfluxDB line protocol. Metrics register
+// themselves in a default registry when they are created, usually as package variables.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds, in seconds, of latency histograms: from a cached
+// answer to a slow upstream
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+var (
+	registryMu sync.Mutex
+	registry   []*family
+)
+
+// family is a metric with all its label values
+type family struct {
+	name, help, kind string
+	labels           []string
+	buckets          []float64 // Histograms only
+
+	mu       sync.Mutex
+	value    func() float64 // Gauge functions only
+	children map[string]any // *Counter or *Histogram, keyed by joined label values
+	values   map[string][]string
+}
+
+// register adds a metric to the registry, or returns the one registered under its name
+func register(name, help, kind string, labels []string) *family {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.name == name {
+			if existing.kind != kind || !slices.Equal(existing.labels, labels) {
+				panic("metrics: " + name + " registered twice with different kinds or labels")
+			}
+			return existing
+		}
+	}
+	f := &family{name: name, help: help, kind: kind, labels: labels, children: map[string]any{}, values: map[string][]string{}}
+	registry = append(registry, f)
+	return f
+}
+
+// child returns the metric of a set of label values, creating it with create
+func (f *family) child(values []string, create func() any) any {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.children[key]; ok {
+		return c
+	}
+	c := create()
+	f.children[key] = c
+	f.values[key] = append([]string(nil), values...)
+	return c
+}
+
+// Counter counts events; it only goes up
+type Counter struct {
+	n atomic.Uint64
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.n.Add(1)
+}
+
+// Value returns the count
+func (c *Counter) Value() uint64 {
+	return c.n.Load()
+}
+
+// NewCounter registers a counter without labels
+func NewCounter(name, help string) *Counter {
+	return NewCounterVec(name, help).With()
+}
+
+// CounterVec is a counter split by labels
+type CounterVec struct {
+	f *family
+}
+
+// NewCounterVec registers a counter with labels
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{f: register(name, help, "counter", labels)}
+}
+
+// With returns the counter of a set of label values, in the order of the labels
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.f.child(values, func() any { return &Counter{} }).(*Counter)
+}
+
+// Histogram counts observations in buckets, e.g. of request latency
+type Histogram struct {
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64 // Per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// Observe records a value
+func (h *Histogram) Observe(value float64) {
+	i := sort.SearchFloat64s(h.buckets, value)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.counts) {
+		h.counts[i]++
+	}
+	h.sum += value
+	h.count++
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+// NewHistogram registers a histogram without labels
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return NewHistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec is a histogram split by labels
+type HistogramVec struct {
+	f *family
+}
+
+// NewHistogramVec registers a histogram with labels; buckets are ascending upper bounds
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	f := register(name, help, "histogram", labels)
+	f.buckets = buckets
+	return &HistogramVec{f: f}
+}
+
+// With returns the histogram of a set of label values, in the order of the labels
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.f.child(values, func() any {
+		return &Histogram{buckets: v.f.buckets, counts: make([]uint64, len(v.f.buckets))}
+	}).(*Histogram)
+}
+
+// NewGaugeFunc registers a gauge whose value is read from value when metrics are written,
+// replacing the function of an earlier gauge of the same name
+func NewGaugeFunc(name, help string, value func() float64) {
+	f := register(name, help, "gauge", nil)
+	f.mu.Lock()
+	f.value = value
+	f.mu.Unlock()
+}
+
+// Write writes every registered metric in the Prometheus text format
+func Write(w io.Writer) error {
+	registryMu.Lock()
+	families := append([]*family(nil), registry...)
+	registryMu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the registered metrics
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = Write(w)
+	})
+}
+
+// NewServer returns a server that only serves /metrics on addr
+func NewServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second, // Prevent Slowloris attacks
+	}
+}
+
+func (f *family) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+	f.mu.Lock()
+	if value := f.value; value != nil {
+		f.mu.Unlock()
+		fmt.Fprintf(b, "%s %s\n", f.name, formatFloat(value()))
+		return
+	}
+	keys := make([]string, 0, len(f.children))
+	for key := range f.children {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	children := make([]any, len(keys))
+	values := make([][]string, len(keys))
+	for i, key := range keys {
+		children[i], values[i] = f.children[key], f.values[key]
+	}
+	f.mu.Unlock()
+
+	for i, child := range children {
+		labels := labelPairs(f.labels, values[i])
+		switch c := child.(type) {
+		case *Counter:
+			fmt.Fprintf(b, "%s%s %d\n", f.name, braced(labels), c.Value())
+		case *Histogram:
+			c.mu.Lock()
+			cumulative := uint64(0)
+			for j, bound := range c.buckets {
+				cumulative += c.counts[j]
+				fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, braced(append(labels, `le="`+formatFloat(bound)+`"`)), cumulative)
+			}
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, braced(append(labels, `le="+Inf"`)), c.count)
+			fmt.Fprintf(b, "%s_sum%s %s\n", f.name, braced(labels), formatFloat(c.sum))
+			fmt.Fprintf(b, "%s_count%s %d\n", f.name, braced(labels), c.count)
+			c.mu.Unlock()
+		}
+	}
+}
+
+// labelPairs formats label names and values as name="value"
+func labelPairs(names, values []string) []string {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return pairs
+}
+
+func braced(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
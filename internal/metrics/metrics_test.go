@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	queries := NewCounterVec("test_queries_total", "Queries answered", "rcode")
+	queries.With("NOERROR").Inc()
+	queries.With("NOERROR").Inc()
+	queries.With(`odd"value`).Inc()
+	latency := NewHistogram("test_latency_seconds", "Time to answer", []float64{0.01, 0.1})
+	latency.Observe(0.005)
+	latency.Observe(0.05)
+	latency.Observe(3)
+	NewGaugeFunc("test_focus_active", "Whether focus mode is on", func() float64 { return 1 })
+
+	var b strings.Builder
+	if err := Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE test_queries_total counter\n",
+		`test_queries_total{rcode="NOERROR"} 2` + "\n",
+		`test_queries_total{rcode="odd\"value"} 1` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{le="0.01"} 1` + "\n",
+		`test_latency_seconds_bucket{le="0.1"} 2` + "\n",
+		`test_latency_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_latency_seconds_sum 3.055\n",
+		"test_latency_seconds_count 3\n",
+		"test_focus_active 1\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestWriteLineProtocol(t *testing.T) {
+	NewCounterVec("test_lines_total", "Lines written", "upstream").With("tls://1.1.1.1, 8.8.8.8").Inc()
+	latency := NewHistogram("test_lines_seconds", "Time to write", []float64{0.1})
+	latency.Observe(0.05)
+	latency.Observe(2)
+	NewGaugeFunc("test_lines_active", "Whether lines are written", func() float64 { return 1 })
+
+	var b strings.Builder
+	if err := WriteLineProtocol(&b, map[string]string{"host": "my laptop", "empty": ""}, time.Unix(1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`test_lines_total,host=my\ laptop,upstream=tls://1.1.1.1\,\ 8.8.8.8 value=1i 1000000000` + "\n",
+		`test_lines_seconds_bucket,host=my\ laptop,le=0.1 value=1i 1000000000` + "\n",
+		`test_lines_seconds_bucket,host=my\ laptop,le=+Inf value=2i 1000000000` + "\n",
+		`test_lines_seconds,host=my\ laptop count=2i,sum=2.05 1000000000` + "\n",
+		`test_lines_active,host=my\ laptop value=1 1000000000` + "\n",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package mqtt publishes the focus state to an MQTT broker, with Home Assistant discovery
+// payloads so the broker's Home Assistant finds the sensors by itself. It speaks just
+// enough MQTT 3.1.1 to publish: QoS 0 messages, retained or not, and a last will.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MQTT 3.1.1 control packet types, shifted into the first byte
+const (
+	packetConnect    = 1 << 4
+	packetConnack    = 2 << 4
+	packetPublish    = 3 << 4
+	packetDisconnect = 14 << 4
+)
+
+const (
+	// keepAlive is the keep alive interval sent to the broker; the publisher publishes more
+	// often than that, so it never needs to ping
+	keepAlive = 60 * time.Second
+	// dialTimeout bounds connecting and waiting for the broker to accept
+	dialTimeout = 10 * time.Second
+	// writeTimeout bounds sending one packet
+	writeTimeout = 10 * time.Second
+)
+
+// connackErrors are the reasons a broker refuses a connection
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad username or password",
+	5: "not authorized",
+}
+
+// Message is a message to publish
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+// ClientOptions says how to connect to a broker
+type ClientOptions struct {
+	Address  string // host:port
+	TLS      bool
+	Username string
+	Password string
+	Will     *Message // Published by the broker when the connection drops
+}
+
+// client is a connection to a broker
+type client struct {
+	conn   net.Conn
+	mutex  sync.Mutex // Serializes writes
+	closed chan struct{}
+}
+
+// dial connects to a broker and waits for it to accept the connection
+func dial(opts ClientOptions) (*client, error) {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	var conn net.Conn
+	var err error
+	if opts.TLS {
+		host, _, _ := net.SplitHostPort(opts.Address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", opts.Address, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	} else {
+		conn, err = dialer.Dial("tcp", opts.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.Address, err)
+	}
+
+	if err := conn.SetDeadline(time.Now().Add(dialTimeout)); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.Address, err)
+	}
+	if _, err := conn.Write(connectPacket(opts)); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.Address, err)
+	}
+	reader := bufio.NewReader(conn)
+	kind, body, err := readPacket(reader)
+	if err == nil && (kind != packetConnack || len(body) != 2) {
+		err = fmt.Errorf("expected CONNACK, got packet type %d", kind>>4)
+	}
+	if err == nil && body[1] != 0 {
+		reason, ok := connackErrors[body[1]]
+		if !ok {
+			reason = fmt.Sprintf("return code %d", body[1])
+		}
+		err = errors.New(reason)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("MQTT broker %s refused the connection: %w", opts.Address, err)
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to connect to MQTT broker %s: %w", opts.Address, err)
+	}
+
+	c := &client{conn: conn, closed: make(chan struct{})}
+	go c.drain(reader)
+	return c, nil
+}
+
+// drain reads what the broker sends, such as ping responses, until the connection closes
+func (c *client) drain(reader *bufio.Reader) {
+	defer close(c.closed)
+	for {
+		if _, _, err := readPacket(reader); err != nil {
+			return
+		}
+	}
+}
+
+// publish sends a QoS 0 message
+func (c *client) publish(message Message) error {
+	return c.write(publishPacket(message))
+}
+
+// close disconnects cleanly, so the broker doesn't publish the will
+func (c *client) close() {
+	_ = c.write([]byte{packetDisconnect, 0})
+	_ = c.conn.Close()
+}
+
+func (c *client) write(packet []byte) error {
+	select {
+	case <-c.closed:
+		return errors.New("the MQTT broker closed the connection")
+	default:
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err := c.conn.SetWriteDeadline(time.Now().Add(writeTimeout)); err != nil {
+		return err
+	}
+	if _, err := c.conn.Write(packet); err != nil {
+		return fmt.Errorf("failed to write to MQTT broker: %w", err)
+	}
+	return nil
+}
+
+// connectPacket builds the CONNECT packet: a clean session with a random client
+// identifier, the will, and the login
+func connectPacket(opts ClientOptions) []byte {
+	id := make([]byte, 6)
+	_, _ = rand.Read(id)
+
+	var flags byte = 0x02 // Clean session
+	body := appendString(nil, "MQTT")
+	body = append(body, 4) // Protocol level 3.1.1
+	flagsAt := len(body)
+	body = append(body, 0)
+	body = binary.BigEndian.AppendUint16(body, uint16(keepAlive/time.Second))
+
+	body = appendString(body, "sinkzone-"+hex.EncodeToString(id))
+	if opts.Will != nil {
+		flags |= 0x04
+		if opts.Will.Retain {
+			flags |= 0x20
+		}
+		body = appendString(body, opts.Will.Topic)
+		body = appendBytes(body, opts.Will.Payload)
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		body = appendString(body, opts.Username)
+		if opts.Password != "" {
+			flags |= 0x40
+			body = appendString(body, opts.Password)
+		}
+	}
+	body[flagsAt] = flags
+	return packet(packetConnect, body)
+}
+
+// publishPacket builds a QoS 0 PUBLISH packet
+func publishPacket(message Message) []byte {
+	var kind byte = packetPublish
+	if message.Retain {
+		kind |= 0x01
+	}
+	body := appendString(nil, message.Topic)
+	return packet(kind, append(body, message.Payload...))
+}
+
+// packet prefixes a packet body with its fixed header
+func packet(kind byte, body []byte) []byte {
+	out := []byte{kind}
+	// Remaining length: 7 bits per byte, least significant first
+	length := len(body)
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		out = append(out, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+// readPacket reads one packet, returning its type and flags byte and its body
+func readPacket(reader *bufio.Reader) (byte, []byte, error) {
+	kind, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		digit, err := reader.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(digit&0x7f) * multiplier
+		if digit&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed MQTT packet length")
+		}
+		multiplier *= 128
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return 0, nil, err
+	}
+	return kind & 0xf0, body, nil
+}
+
+func appendString(b []byte, s string) []byte {
+	return appendBytes(b, []byte(s))
+}
+
+func appendBytes(b, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data))) // #nosec G115 -- topics and payloads here are far below 64 KB
+	return append(b, data...)
+}
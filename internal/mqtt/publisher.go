@@ -0,0 +1,239 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/version"
+)
+
+// Source is the part of the API server the publisher reports on
+type Source interface {
+	GetFocusState() api.FocusModeState
+	BlockedSince(since time.Time) int
+}
+
+const (
+	// checkInterval is how often the publisher polls the focus state
+	checkInterval = 2 * time.Second
+	// publishInterval is how often the state is published when nothing flips, to keep the
+	// countdown and the counters current
+	publishInterval = 10 * time.Second
+	// minRetry and maxRetry bound the wait before connecting again to a broker that is down
+	minRetry = 5 * time.Second
+	maxRetry = time.Minute
+)
+
+// State is the JSON published, retained, on <topic>/state
+type State struct {
+	Focus            bool       `json:"focus"`
+	Paused           bool       `json:"paused"`
+	Profile          string     `json:"profile,omitempty"`
+	Label            string     `json:"label,omitempty"`
+	RemainingMinutes int        `json:"remaining_minutes"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	BlockedPerMinute int        `json:"blocked_per_minute"`
+}
+
+// Publisher publishes the focus state to an MQTT broker
+type Publisher struct {
+	opts      ClientOptions
+	topic     string
+	discovery string // Home Assistant discovery prefix, "" for none
+	source    Source
+	dial      func(ClientOptions) (*client, error)
+
+	client    *client
+	retry     time.Duration
+	nextDial  time.Time
+	focus     bool
+	published time.Time
+}
+
+// NewPublisher creates a publisher for the configured broker
+func NewPublisher(cfg *config.MQTTConfig, source Source) (*Publisher, error) {
+	address, useTLS, err := cfg.GetBroker()
+	if err != nil {
+		return nil, err
+	}
+	topic, err := cfg.GetTopic()
+	if err != nil {
+		return nil, err
+	}
+	return &Publisher{
+		opts: ClientOptions{
+			Address:  address,
+			TLS:      useTLS,
+			Username: cfg.Username,
+			Password: cfg.Password,
+			Will:     &Message{Topic: topic + "/availability", Payload: []byte("offline"), Retain: true},
+		},
+		topic:     topic,
+		discovery: cfg.GetDiscoveryPrefix(),
+		source:    source,
+		dial:      dial,
+		retry:     minRetry,
+	}, nil
+}
+
+// Run publishes the focus state until the stop channel is closed
+func (p *Publisher) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		p.check(time.Now())
+
+		select {
+		case <-stop:
+			if p.client != nil {
+				_ = p.client.publish(Message{Topic: p.topic + "/availability", Payload: []byte("offline"), Retain: true})
+				p.client.close()
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check connects when needed and publishes the state when focus flips or it is due
+func (p *Publisher) check(now time.Time) {
+	if p.client == nil {
+		if now.Before(p.nextDial) {
+			return
+		}
+		if err := p.connect(); err != nil {
+			log.Printf("Warning: %v; retrying in %s", err, p.retry)
+			p.nextDial = now.Add(p.retry)
+			p.retry = min(p.retry*2, maxRetry)
+			return
+		}
+		p.retry = minRetry
+		p.published = time.Time{}
+	}
+
+	state := p.state(now)
+	if state.Focus == p.focus && now.Sub(p.published) < publishInterval {
+		return
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("Warning: failed to encode MQTT state: %v", err)
+		return
+	}
+	if err := p.client.publish(Message{Topic: p.topic + "/state", Payload: payload, Retain: true}); err != nil {
+		log.Printf("Warning: %v", err)
+		p.client.close()
+		p.client = nil
+		return
+	}
+	p.focus = state.Focus
+	p.published = now
+}
+
+// connect dials the broker and announces sinkzone as online, with its discovery configs
+func (p *Publisher) connect() error {
+	c, err := p.dial(p.opts)
+	if err != nil {
+		return err
+	}
+	messages := append(p.discoveryMessages(), Message{Topic: p.topic + "/availability", Payload: []byte("online"), Retain: true})
+	for _, message := range messages {
+		if err := c.publish(message); err != nil {
+			c.close()
+			return err
+		}
+	}
+	log.Printf("Publishing focus state to MQTT broker %s on %s/state", p.opts.Address, p.topic)
+	p.client = c
+	return nil
+}
+
+// state reads the focus state and the blocked queries of the last minute
+func (p *Publisher) state(now time.Time) State {
+	focus := p.source.GetFocusState()
+	state := State{
+		Focus:            focus.Enabled && (focus.EndTime == nil || now.Before(*focus.EndTime)),
+		BlockedPerMinute: p.source.BlockedSince(now.Add(-time.Minute)),
+	}
+	if !state.Focus {
+		return state
+	}
+	state.Paused = focus.Paused
+	state.Profile = focus.Profile
+	state.Label = focus.Label
+	if focus.EndTime != nil {
+		state.EndsAt = focus.EndTime
+		state.RemainingMinutes = int(math.Ceil(focus.EndTime.Sub(now).Minutes()))
+	}
+	return state
+}
+
+// discoveryMessages returns the retained configs that announce the sensors to Home Assistant
+func (p *Publisher) discoveryMessages() []Message {
+	if p.discovery == "" {
+		return nil
+	}
+	id := strings.NewReplacer("/", "_", " ", "_").Replace(p.topic)
+	device := map[string]any{
+		"identifiers":  []string{id},
+		"name":         "sinkzone",
+		"manufacturer": "berbyte",
+		"model":        "sinkzone",
+		"sw_version":   version.Get().Version,
+	}
+	common := func(name, key string) map[string]any {
+		return map[string]any{
+			"name":               name,
+			"unique_id":          id + "_" + key,
+			"object_id":          id + "_" + key,
+			"state_topic":        p.topic + "/state",
+			"availability_topic": p.topic + "/availability",
+			"device":             device,
+		}
+	}
+
+	focus := common("Focus", "focus")
+	focus["value_template"] = "{{ 'ON' if value_json.focus else 'OFF' }}"
+	focus["json_attributes_topic"] = p.topic + "/state"
+	focus["icon"] = "mdi:target"
+
+	remaining := common("Focus time remaining", "remaining")
+	remaining["value_template"] = "{{ value_json.remaining_minutes }}"
+	remaining["unit_of_measurement"] = "min"
+	remaining["device_class"] = "duration"
+	remaining["icon"] = "mdi:timer-sand"
+
+	blocked := common("Blocked per minute", "blocked_per_minute")
+	blocked["value_template"] = "{{ value_json.blocked_per_minute }}"
+	blocked["unit_of_measurement"] = "queries/min"
+	blocked["state_class"] = "measurement"
+	blocked["icon"] = "mdi:shield-off"
+
+	configs := []struct {
+		component, key string
+		config         map[string]any
+	}{
+		{"binary_sensor", "focus", focus},
+		{"sensor", "remaining", remaining},
+		{"sensor", "blocked_per_minute", blocked},
+	}
+	var messages []Message
+	for _, c := range configs {
+		payload, err := json.Marshal(c.config)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, Message{
+			Topic:   p.discovery + "/" + c.component + "/" + id + "/" + c.key + "/config",
+			Payload: payload,
+			Retain:  true,
+		})
+	}
+	return messages
+}
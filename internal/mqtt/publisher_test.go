@@ -0,0 +1,109 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeSource struct {
+	state   api.FocusModeState
+	blocked int
+}
+
+func (f *fakeSource) GetFocusState() api.FocusModeState {
+	return f.state
+}
+
+func (f *fakeSource) BlockedSince(time.Time) int {
+	return f.blocked
+}
+
+// publishedMessage decodes the topic and payload of a PUBLISH packet body
+func publishedMessage(t *testing.T, body []byte) (string, string) {
+	t.Helper()
+	if len(body) < 2 {
+		t.Fatalf("short PUBLISH packet: %v", body)
+	}
+	length := int(body[0])<<8 | int(body[1])
+	return string(body[2 : 2+length]), string(body[2+length:])
+}
+
+func TestPublisherAnnouncesAndPublishesState(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = listener.Close() }()
+
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := now.Add(25*time.Minute + 10*time.Second)
+	source := &fakeSource{state: api.FocusModeState{Enabled: true, EndTime: &end, Profile: "deep"}, blocked: 7}
+	publisher, err := NewPublisher(&config.MQTTConfig{Broker: "tcp://" + listener.Addr().String(), Username: "ha", Password: "secret"}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan map[string]string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		kind, body, err := readPacket(reader)
+		if err != nil || kind != packetConnect || !strings.Contains(string(body), "secret") {
+			return
+		}
+		_, _ = conn.Write([]byte{packetConnack, 2, 0, 0})
+		messages := map[string]string{}
+		for len(messages) < 5 {
+			kind, body, err := readPacket(reader)
+			if err != nil || kind != packetPublish {
+				break
+			}
+			topic, payload := publishedMessage(t, body)
+			messages[topic] = payload
+		}
+		received <- messages
+	}()
+
+	publisher.check(now)
+	var messages map[string]string
+	select {
+	case messages = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the broker received nothing")
+	}
+
+	if messages["sinkzone/availability"] != "online" {
+		t.Errorf("availability = %q, want online", messages["sinkzone/availability"])
+	}
+	if _, ok := messages["homeassistant/binary_sensor/sinkzone/focus/config"]; !ok {
+		t.Errorf("missing the focus discovery config, got %v", messages)
+	}
+	var state State
+	if err := json.Unmarshal([]byte(messages["sinkzone/state"]), &state); err != nil {
+		t.Fatalf("failed to decode state: %v", err)
+	}
+	if !state.Focus || state.Profile != "deep" || state.RemainingMinutes != 26 || state.BlockedPerMinute != 7 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestDiscoveryCanBeTurnedOff(t *testing.T) {
+	publisher, err := NewPublisher(&config.MQTTConfig{Broker: "tcp://localhost", DiscoveryPrefix: "none"}, &fakeSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if messages := publisher.discoveryMessages(); len(messages) != 0 {
+		t.Errorf("discoveryMessages() = %v, want none", messages)
+	}
+}
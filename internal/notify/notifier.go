@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// FocusState is the part of the API server the notifier watches
+type FocusState interface {
+	GetFocusMode() (bool, *time.Time)
+}
+
+// PressureSource is the part of the API server the notifier reads distraction pressure from
+type PressureSource interface {
+	DistractionPressure() api.DistractionPressure
+}
+
+// Notifier sends a warning shortly before a focus session ends and a notification when it
+// expires, and, with a pressure alert, when blocked domains are tried too often
+type Notifier struct {
+	warnBefore time.Duration
+	focus      FocusState
+	send       func(title, message string) error
+
+	endTime *time.Time // End of the session being tracked
+	warned  bool
+	expired bool
+
+	pressureAlert int // Blocked attempts per minute that prompt a break, 0 for none
+	pressure      PressureSource
+	alerted       time.Time // Last pressure alert, so one is shown per window
+}
+
+// checkInterval is how often the notifier polls the focus state
+const checkInterval = 5 * time.Second
+
+// NewNotifier creates a notifier; a zero warnBefore only notifies on expiry
+func NewNotifier(warnBefore time.Duration, focus FocusState) *Notifier {
+	return &Notifier{
+		warnBefore: warnBefore,
+		focus:      focus,
+		send:       Send,
+	}
+}
+
+// SetPressureAlert shows a notification to take a break or recommit when the distraction
+// pressure of a session reaches perMinute blocked attempts a minute, at most once per
+// api.PressureWindow
+func (n *Notifier) SetPressureAlert(perMinute int, source PressureSource) {
+	n.pressureAlert = perMinute
+	n.pressure = source
+}
+
+// Run watches the focus state until the stop channel is closed
+func (n *Notifier) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		n.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check compares the current session with the tracked one and sends due notifications
+func (n *Notifier) check(now time.Time) {
+	enabled, endTime := n.focus.GetFocusMode()
+
+	if n.endTime != nil && (!enabled || endTime == nil || !endTime.Equal(*n.endTime)) {
+		// The tracked session was replaced; announce it only if it ran out rather than being disabled early
+		if !n.expired && !now.Before(*n.endTime) && now.Sub(*n.endTime) < time.Minute {
+			n.notifyExpired()
+		}
+		n.endTime = nil
+	}
+
+	if n.pressureAlert > 0 && n.pressure != nil {
+		n.checkPressure(now)
+	}
+
+	if !enabled || endTime == nil {
+		return
+	}
+
+	if n.endTime == nil {
+		end := *endTime
+		n.endTime = &end
+		n.expired = false
+		// Don't warn about a session that starts inside the warning window
+		n.warned = end.Sub(now) <= n.warnBefore
+	}
+
+	if !now.Before(*n.endTime) {
+		if !n.expired {
+			n.notifyExpired()
+		}
+		return
+	}
+
+	if !n.warned && n.warnBefore > 0 && n.endTime.Sub(now) <= n.warnBefore {
+		n.warned = true
+		remaining := n.endTime.Sub(now).Round(time.Minute)
+		if remaining < time.Minute {
+			remaining = time.Minute
+		}
+		n.notify("Focus session ending soon", fmt.Sprintf("Focus mode ends in %s (at %s).", formatMinutes(remaining), n.endTime.Format("15:04")))
+	}
+}
+
+// checkPressure alerts when the distraction pressure reached the alert
+func (n *Notifier) checkPressure(now time.Time) {
+	pressure := n.pressure.DistractionPressure()
+	if !pressure.Active {
+		n.alerted = time.Time{}
+		return
+	}
+	if pressure.PerMinute < float64(n.pressureAlert) || (!n.alerted.IsZero() && now.Sub(n.alerted) < api.PressureWindow) {
+		return
+	}
+	n.alerted = now
+	n.notify("Take a break or recommit", fmt.Sprintf("%d blocked attempts in the last %d minutes. Step away for a few minutes, or get back to the task at hand.",
+		pressure.Attempts, int(api.PressureWindow.Minutes())))
+}
+
+func (n *Notifier) notifyExpired() {
+	n.expired = true
+	n.notify("Focus session ended", "Focus mode has expired. Blocked domains are reachable again.")
+}
+
+func (n *Notifier) notify(title, message string) {
+	if err := n.send(title, message); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
+
+func formatMinutes(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
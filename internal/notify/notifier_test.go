@@ -0,0 +1,111 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+type fakeFocus struct {
+	enabled bool
+	endTime *time.Time
+}
+
+func (f *fakeFocus) GetFocusMode() (bool, *time.Time) {
+	return f.enabled, f.endTime
+}
+
+func TestNotifierWarnsAndAnnouncesExpiry(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	focus := &fakeFocus{enabled: true, endTime: &end}
+
+	var titles []string
+	notifier := NewNotifier(5*time.Minute, focus)
+	notifier.send = func(title, message string) error {
+		titles = append(titles, title)
+		return nil
+	}
+
+	notifier.check(start)
+	notifier.check(end.Add(-10 * time.Minute))
+	if len(titles) != 0 {
+		t.Fatalf("Expected no notifications yet, got %v", titles)
+	}
+
+	notifier.check(end.Add(-5 * time.Minute))
+	notifier.check(end.Add(-4 * time.Minute))
+	if len(titles) != 1 || titles[0] != "Focus session ending soon" {
+		t.Fatalf("Expected one warning, got %v", titles)
+	}
+
+	notifier.check(end.Add(time.Second))
+	notifier.check(end.Add(10 * time.Second))
+	if len(titles) != 2 || titles[1] != "Focus session ended" {
+		t.Fatalf("Expected a single expiry notification, got %v", titles)
+	}
+}
+
+func TestNotifierIgnoresManualDisable(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	focus := &fakeFocus{enabled: true, endTime: &end}
+
+	sent := 0
+	notifier := NewNotifier(5*time.Minute, focus)
+	notifier.send = func(title, message string) error {
+		sent++
+		return nil
+	}
+
+	notifier.check(start)
+	focus.enabled = false
+	focus.endTime = nil
+	notifier.check(start.Add(time.Minute))
+	notifier.check(end.Add(time.Second))
+
+	if sent != 0 {
+		t.Errorf("Expected no notifications after disabling focus mode, got %d", sent)
+	}
+}
+
+type fakePressure struct {
+	pressure api.DistractionPressure
+}
+
+func (f *fakePressure) DistractionPressure() api.DistractionPressure {
+	return f.pressure
+}
+
+func TestNotifierPressureAlert(t *testing.T) {
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	focus := &fakeFocus{enabled: true, endTime: &end}
+	pressure := &fakePressure{api.DistractionPressure{Active: true, PerMinute: 1, Attempts: 10}}
+
+	var titles []string
+	notifier := NewNotifier(0, focus)
+	notifier.SetPressureAlert(2, pressure)
+	notifier.send = func(title, message string) error {
+		titles = append(titles, title)
+		return nil
+	}
+
+	notifier.check(start)
+	if len(titles) != 0 {
+		t.Fatalf("Expected no alert below the threshold, got %v", titles)
+	}
+
+	pressure.pressure.PerMinute = 2.5
+	notifier.check(start.Add(time.Minute))
+	notifier.check(start.Add(5 * time.Minute))
+	if len(titles) != 1 || titles[0] != "Take a break or recommit" {
+		t.Fatalf("Expected one alert per window, got %v", titles)
+	}
+
+	notifier.check(start.Add(11 * time.Minute))
+	if len(titles) != 2 {
+		t.Fatalf("Expected another alert after the window, got %v", titles)
+	}
+}
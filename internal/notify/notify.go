@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Send shows a desktop notification using the platform's notification tool:
+// notify-send on Linux, osascript on macOS and a PowerShell toast on Windows.
+func Send(title, message string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// #nosec G204 -- arguments are passed directly, not through a shell
+		cmd = exec.Command("notify-send", "--app-name=sinkzone", title, message)
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", appleScriptString(message), appleScriptString(title))
+		// #nosec G204 -- title and message are quoted as AppleScript strings
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		// #nosec G204 -- title and message are quoted as PowerShell strings
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsToastScript(title, message))
+	default:
+		return fmt.Errorf("desktop notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send notification: %w (%s)", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// appleScriptString quotes a string for use in AppleScript
+func appleScriptString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellString quotes a string as a single-quoted PowerShell literal
+func powerShellString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func windowsToastScript(title, message string) string {
+	return `[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $template.GetElementsByTagName('text')
+$text.Item(0).AppendChild($template.CreateTextNode(` + powerShellString(title) + `)) | Out-Null
+$text.Item(1).AppendChild($template.CreateTextNode(` + powerShellString(message) + `)) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('sinkzone').Show($toast)`
+}
@@ -0,0 +1,201 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// pushoverURL is Pushover's message API, a variable so tests can replace it
+var pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// pusher is one configured push notifier and its spike state
+type pusher struct {
+	config.PushConfig
+	sessions       string
+	spikeThreshold int // 0 when spikes aren't pushed
+	spikeWindow    time.Duration
+	spiked         time.Time // Last spike pushed, so a spike is pushed once per window
+}
+
+// blockedSample is the blocked count of the running session at a check
+type blockedSample struct {
+	at      time.Time
+	blocked int
+}
+
+// Push sends push notifications to phones through ntfy or Pushover: strict sessions (or
+// every session) starting and ending, and spikes of blocked queries during a session, a
+// sign of getting distracted. Each notifier chooses its own events.
+type Push struct {
+	pushers   []*pusher
+	pinLocked bool // A focus PIN is set, which locks, and so makes strict, every session
+	source    Source
+	post      func(p *pusher, title, message string, urgent bool) error
+
+	active    bool
+	session   api.FocusModeState // Latest state of the running session
+	started   time.Time
+	samples   []blockedSample // Blocked counts within the longest spike window, oldest first
+	maxWindow time.Duration
+}
+
+// NewPush creates a push notifier for the configured services, or returns nil when none is
+// configured; pinLocked tells whether a focus PIN is set
+func NewPush(cfg *config.NotifyConfig, pinLocked bool, source Source) (*Push, error) {
+	if len(cfg.Push) == 0 {
+		return nil, nil
+	}
+	p := &Push{pinLocked: pinLocked, source: source, post: postPush}
+	for _, push := range cfg.Push {
+		if err := push.Validate(); err != nil {
+			return nil, err
+		}
+		sessions, _ := push.GetSessions()
+		threshold, window, _ := push.GetSpike()
+		p.pushers = append(p.pushers, &pusher{PushConfig: push, sessions: sessions, spikeThreshold: threshold, spikeWindow: window})
+		if threshold > 0 && window > p.maxWindow {
+			p.maxWindow = window
+		}
+	}
+	return p, nil
+}
+
+// Run watches the focus state until the stop channel is closed
+func (p *Push) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		p.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pushes the session events since the last check, and spikes of blocked queries
+func (p *Push) check(now time.Time) {
+	state := p.source.GetFocusState()
+	active := state.Enabled && (state.EndTime == nil || now.Before(*state.EndTime))
+
+	switch {
+	case active && !p.active:
+		p.active = true
+		p.session = state
+		p.started = now
+		p.samples = nil
+		p.sendSession(state, "Focus session started", describeStart(state))
+	case active:
+		p.session = state
+	case p.active:
+		p.active = false
+		p.sendSession(p.session, "Focus session ended", describeEnd(p.session, p.started, now))
+	}
+
+	if active && p.maxWindow > 0 {
+		p.checkSpikes(now, state)
+	}
+}
+
+// sendSession pushes a session starting or ending to the notifiers that report on it
+func (p *Push) sendSession(session api.FocusModeState, title, message string) {
+	strict := session.Intensity == config.IntensityHard || p.pinLocked
+	for _, pusher := range p.pushers {
+		if pusher.sessions == config.PushSessionsAll || (pusher.sessions == config.PushSessionsStrict && strict) {
+			p.send(pusher, title, message, false)
+		}
+	}
+}
+
+// checkSpikes records the session's blocked count and pushes to every notifier whose
+// threshold was reached within its window
+func (p *Push) checkSpikes(now time.Time, state api.FocusModeState) {
+	p.samples = append(p.samples, blockedSample{at: now, blocked: state.Blocked})
+	for len(p.samples) > 1 && now.Sub(p.samples[0].at) > p.maxWindow {
+		p.samples = p.samples[1:]
+	}
+
+	for _, pusher := range p.pushers {
+		if pusher.spikeThreshold == 0 || (!pusher.spiked.IsZero() && now.Sub(pusher.spiked) < pusher.spikeWindow) {
+			continue
+		}
+		// The oldest count within the window is the baseline
+		baseline := state.Blocked
+		for _, sample := range p.samples {
+			if now.Sub(sample.at) <= pusher.spikeWindow {
+				baseline = sample.blocked
+				break
+			}
+		}
+		if blocked := state.Blocked - baseline; blocked >= pusher.spikeThreshold {
+			pusher.spiked = now
+			message := fmt.Sprintf("%d queries blocked in the last %s", blocked, pusher.spikeWindow)
+			if state.LastBlocked != "" {
+				message += ", most recently " + state.LastBlocked
+			}
+			p.send(pusher, "Getting distracted?", message+".", true)
+		}
+	}
+}
+
+// send pushes a message in the background
+func (p *Push) send(pusher *pusher, title, message string, urgent bool) {
+	go func() {
+		if err := p.post(pusher, title, message, urgent); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}()
+}
+
+// postPush sends a message through the notifier's service; urgent messages get a higher
+// priority
+func postPush(p *pusher, title, message string, urgent bool) error {
+	var req *http.Request
+	var err error
+	switch p.Service {
+	case config.PushNtfy:
+		req, err = http.NewRequest(http.MethodPost, p.URL, strings.NewReader(message))
+		if err != nil {
+			return fmt.Errorf("failed to create ntfy request: %w", err)
+		}
+		req.Header.Set("Title", title)
+		req.Header.Set("Tags", "sinkzone")
+		if urgent {
+			req.Header.Set("Priority", "high")
+		}
+		if p.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+p.Token)
+		}
+	default:
+		form := url.Values{"token": {p.Token}, "user": {p.User}, "title": {title}, "message": {message}}
+		if urgent {
+			form.Set("priority", "1")
+		}
+		req, err = http.NewRequest(http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return fmt.Errorf("failed to create pushover request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", p.Service, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to send %s notification: %s", p.Service, resp.Status)
+	}
+	return nil
+}
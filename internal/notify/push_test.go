@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestPushReportsStrictSessionsAndSpikes(t *testing.T) {
+	source := &fakeSource{}
+	push, err := NewPush(&config.NotifyConfig{Push: []config.PushConfig{
+		{Service: config.PushNtfy, URL: "https://ntfy.sh/focus"},
+		{Service: config.PushPushover, Token: "app", User: "me", Sessions: config.PushSessionsNone, SpikeThreshold: 10},
+	}}, false, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var sent []string
+	push.post = func(p *pusher, title, message string, urgent bool) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, p.Service+": "+title)
+		return nil
+	}
+	expect := func(now time.Time, want ...string) {
+		t.Helper()
+		sent = nil
+		wg.Add(len(want))
+		push.check(now)
+		wg.Wait()
+		slices.Sort(sent)
+		if !slices.Equal(sent, want) {
+			t.Fatalf("check(%s) sent %v, want %v", now.Format("15:04:05"), sent, want)
+		}
+	}
+
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	end := now.Add(time.Hour)
+
+	// A normal session isn't strict
+	source.state = api.FocusModeState{Enabled: true, EndTime: &end, Intensity: config.IntensityNormal}
+	expect(now)
+	source.state = api.FocusModeState{}
+	expect(now.Add(time.Minute))
+
+	source.state = api.FocusModeState{Enabled: true, EndTime: &end, Intensity: config.IntensityHard}
+	expect(now.Add(2*time.Minute), "ntfy: Focus session started")
+
+	// 9 blocked queries within 5 minutes aren't a spike yet, the 10th is, and it is pushed once
+	source.state.Blocked = 9
+	expect(now.Add(3 * time.Minute))
+	source.state.Blocked = 10
+	expect(now.Add(4*time.Minute), "pushover: Getting distracted?")
+	source.state.Blocked = 30
+	expect(now.Add(5 * time.Minute))
+
+	// Spread out over more than the window, blocked queries aren't a spike
+	source.state.Blocked = 35
+	expect(now.Add(12 * time.Minute))
+
+	expect(end.Add(time.Second), "ntfy: Focus session ended")
+}
+
+func TestPostPush(t *testing.T) {
+	var headers http.Header
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		headers, body = r.Header, string(data)
+	}))
+	defer server.Close()
+
+	ntfy := &pusher{PushConfig: config.PushConfig{Service: config.PushNtfy, URL: server.URL + "/focus", Token: "tk"}}
+	if err := postPush(ntfy, "Getting distracted?", "12 queries blocked", true); err != nil {
+		t.Fatal(err)
+	}
+	if headers.Get("Title") != "Getting distracted?" || headers.Get("Priority") != "high" || headers.Get("Authorization") != "Bearer tk" || body != "12 queries blocked" {
+		t.Errorf("unexpected ntfy request: %v %q", headers, body)
+	}
+
+	pushoverURL = server.URL
+	defer func() { pushoverURL = "https://api.pushover.net/1/messages.json" }()
+	pushover := &pusher{PushConfig: config.PushConfig{Service: config.PushPushover, Token: "app", User: "me"}}
+	if err := postPush(pushover, "Focus session started", "Focus mode is on.", false); err != nil {
+		t.Fatal(err)
+	}
+	if body != "message=Focus+mode+is+on.&title=Focus+session+started&token=app&user=me" {
+		t.Errorf("unexpected pushover form: %q", body)
+	}
+}
+
+func TestNewPushValidatesNotifiers(t *testing.T) {
+	for _, push := range []config.PushConfig{
+		{Service: "email"},
+		{Service: config.PushNtfy, URL: "https://ntfy.sh/"},
+		{Service: config.PushPushover, Token: "app"},
+		{Service: config.PushNtfy, URL: "https://ntfy.sh/focus", Sessions: "some"},
+		{Service: config.PushNtfy, URL: "https://ntfy.sh/focus", SpikeThreshold: 5, SpikeWindow: "10s"},
+	} {
+		if _, err := NewPush(&config.NotifyConfig{Push: []config.PushConfig{push}}, false, &fakeSource{}); err == nil {
+			t.Errorf("expected %+v to be refused", push)
+		}
+	}
+	if push, err := NewPush(&config.NotifyConfig{}, false, &fakeSource{}); err != nil || push != nil {
+		t.Errorf("NewPush() = %v, %v; want nil without notifiers", push, err)
+	}
+}
@@ -0,0 +1,225 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Source is the part of the API server the webhook poster and push notifier watch
+type Source interface {
+	GetFocusState() api.FocusModeState
+	GetStats() (*api.FocusStats, error)
+}
+
+// webhookTimeout bounds posting one message
+const webhookTimeout = 10 * time.Second
+
+// webhook is a channel messages are posted to
+type webhook struct {
+	service string // "slack" or "discord"
+	url     string
+}
+
+// Webhook posts focus sessions starting and ending, attempts to break a PIN-locked session,
+// and a daily summary to Slack and Discord channels, e.g. for an accountability buddy
+type Webhook struct {
+	webhooks  []webhook
+	summaryAt time.Duration // Time after midnight of the daily summary, -1 for none
+	source    Source
+	post      func(hook webhook, title, message string) error
+
+	active     bool
+	session    api.FocusModeState // Latest state of the running session
+	started    time.Time
+	violations int
+	summarized time.Time // Day of the last summary
+}
+
+// NewWebhook creates a webhook poster for the configured channels, or returns nil when
+// none is configured
+func NewWebhook(cfg *config.NotifyConfig, source Source) (*Webhook, error) {
+	slack, discord, err := cfg.GetWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	summaryAt, err := cfg.GetDailySummary()
+	if err != nil {
+		return nil, err
+	}
+	var webhooks []webhook
+	if slack != "" {
+		webhooks = append(webhooks, webhook{service: "slack", url: slack})
+	}
+	if discord != "" {
+		webhooks = append(webhooks, webhook{service: "discord", url: discord})
+	}
+	if len(webhooks) == 0 {
+		return nil, nil
+	}
+	return &Webhook{
+		webhooks:  webhooks,
+		summaryAt: summaryAt,
+		source:    source,
+		post:      postWebhook,
+	}, nil
+}
+
+// Run watches the focus state until the stop channel is closed
+func (w *Webhook) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	// A summary that was due before the resolver started is not posted again
+	w.summarized = w.summaryDay(time.Now())
+	for {
+		w.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check posts the session events since the last check, and the summary when it is due
+func (w *Webhook) check(now time.Time) {
+	state := w.source.GetFocusState()
+	active := state.Enabled && (state.EndTime == nil || now.Before(*state.EndTime))
+
+	switch {
+	case active && !w.active:
+		w.active = true
+		w.session = state
+		w.started = now
+		w.violations = state.Violations
+		w.send("Focus session started", describeStart(state))
+	case active:
+		if state.Violations > w.violations {
+			attempts := "an attempt"
+			if n := state.Violations - w.violations; n > 1 {
+				attempts = fmt.Sprintf("%d attempts", n)
+			}
+			w.send("Focus session lock held", fmt.Sprintf("Rejected %s to end, pause, or shorten the PIN-locked session.", attempts))
+		}
+		w.violations = state.Violations
+		w.session = state
+	case w.active:
+		w.active = false
+		w.send("Focus session ended", describeEnd(w.session, w.started, now))
+	}
+
+	if day := w.summaryDay(now); !day.IsZero() && day.After(w.summarized) {
+		w.summarized = day
+		stats, err := w.source.GetStats()
+		if err != nil {
+			log.Printf("Warning: failed to get focus stats for the daily summary: %v", err)
+			return
+		}
+		w.send("Daily focus summary", describeStats(stats))
+	}
+}
+
+// summaryDay returns the midnight of the latest day whose summary is due by now, or the
+// zero time without a daily summary
+func (w *Webhook) summaryDay(now time.Time) time.Time {
+	if w.summaryAt < 0 {
+		return time.Time{}
+	}
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if now.Before(day.Add(w.summaryAt)) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// send posts a message to every webhook in the background
+func (w *Webhook) send(title, message string) {
+	for _, hook := range w.webhooks {
+		go func() {
+			if err := w.post(hook, title, message); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+	}
+}
+
+func describeStart(state api.FocusModeState) string {
+	var b strings.Builder
+	if state.EndTime != nil {
+		fmt.Fprintf(&b, "Focus mode is on until %s", state.EndTime.Format("15:04"))
+	} else {
+		b.WriteString("Focus mode is on with no end time")
+	}
+	if state.Profile != "" {
+		fmt.Fprintf(&b, ", profile %s", state.Profile)
+	}
+	if state.Label != "" {
+		fmt.Fprintf(&b, ", working on %s", state.Label)
+	}
+	b.WriteString(".")
+	return b.String()
+}
+
+// describeEnd describes a session seen ending at now, with its latest state
+func describeEnd(session api.FocusModeState, started, now time.Time) string {
+	how := "was ended early"
+	if session.EndTime != nil && !now.Before(*session.EndTime) {
+		how = "ran out"
+		now = *session.EndTime
+	}
+	message := fmt.Sprintf("The session %s after %s", how, now.Sub(started).Round(time.Minute))
+	if session.Label != "" {
+		message += " on " + session.Label
+	}
+	return fmt.Sprintf("%s, with %d blocked queries.", message, session.Blocked)
+}
+
+func describeStats(stats *api.FocusStats) string {
+	message := "Focused for " + stats.Today + " today"
+	if stats.Goal != "" {
+		message += fmt.Sprintf(" (%.0f%% of the %s goal)", stats.Progress*100, stats.Goal)
+	}
+	message += ", " + stats.Week + " this week."
+	switch {
+	case stats.Streak == 1:
+		message += " Streak: 1 day."
+	case stats.Streak > 1:
+		message += fmt.Sprintf(" Streak: %d days.", stats.Streak)
+	}
+	return message
+}
+
+// postWebhook posts a message in the format of the webhook's service
+func postWebhook(hook webhook, title, message string) error {
+	var payload map[string]string
+	switch hook.service {
+	case "slack":
+		payload = map[string]string{"text": "*" + title + "*\n" + message}
+	default:
+		payload = map[string]string{"username": "sinkzone", "content": "**" + title + "**\n" + message}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message: %w", hook.service, err)
+	}
+
+	client := &http.Client{Timeout: webhookTimeout}
+	resp, err := client.Post(hook.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post to %s webhook: %w", hook.service, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post to %s webhook: %s", hook.service, resp.Status)
+	}
+	return nil
+}
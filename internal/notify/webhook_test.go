@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeSource struct {
+	state api.FocusModeState
+}
+
+func (f *fakeSource) GetFocusState() api.FocusModeState {
+	return f.state
+}
+
+func (f *fakeSource) GetStats() (*api.FocusStats, error) {
+	return &api.FocusStats{Today: "2h0m0s", Week: "9h0m0s", Goal: "4h0m0s", Progress: 0.5, Streak: 3}, nil
+}
+
+func TestWebhookPostsSessionEvents(t *testing.T) {
+	source := &fakeSource{}
+	poster, err := NewWebhook(&config.NotifyConfig{SlackWebhook: "https://hooks.slack.com/services/x", DailySummary: "18:00"}, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var titles []string
+	poster.post = func(hook webhook, title, message string) error {
+		defer wg.Done()
+		mu.Lock()
+		defer mu.Unlock()
+		titles = append(titles, title)
+		return nil
+	}
+	expect := func(now time.Time, want ...string) {
+		t.Helper()
+		titles = nil
+		wg.Add(len(want))
+		poster.check(now)
+		wg.Wait()
+		if len(titles) != len(want) {
+			t.Fatalf("check(%s) posted %v, want %v", now.Format("15:04"), titles, want)
+		}
+		for i := range want {
+			if titles[i] != want[i] {
+				t.Fatalf("check(%s) posted %v, want %v", now.Format("15:04"), titles, want)
+			}
+		}
+	}
+
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	poster.summarized = poster.summaryDay(now)
+	end := now.Add(time.Hour)
+	source.state = api.FocusModeState{Enabled: true, EndTime: &end}
+	expect(now, "Focus session started")
+
+	source.state.Violations = 2
+	expect(now.Add(time.Minute), "Focus session lock held")
+	expect(now.Add(2 * time.Minute))
+
+	expect(end.Add(time.Second), "Focus session ended")
+	expect(time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC), "Daily focus summary")
+	expect(time.Date(2025, 3, 10, 18, 30, 0, 0, time.UTC))
+}
+
+func TestNewWebhookRequiresHTTPS(t *testing.T) {
+	if _, err := NewWebhook(&config.NotifyConfig{DiscordWebhook: "http://discord.com/api/webhooks/1"}, &fakeSource{}); err == nil {
+		t.Error("expected an error for a plain http webhook")
+	}
+	if webhook, err := NewWebhook(&config.NotifyConfig{}, &fakeSource{}); err != nil || webhook != nil {
+		t.Errorf("NewWebhook() = %v, %v; want nil without webhooks", webhook, err)
+	}
+}
@@ -0,0 +1,190 @@
+package peersync
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// FocusController is the part of the local API server the syncer drives
+type FocusController interface {
+	GetFocusState() api.FocusModeState
+	ApplyFocusMode(req api.FocusRequest) error
+}
+
+// Peer is the focus state of another sinkzone resolver
+type Peer interface {
+	GetFocusMode() (*api.FocusModeState, error)
+}
+
+// Syncer polls other sinkzone resolvers and mirrors the focus sessions they start.
+//
+// Conflict rules:
+//   - A peer's session is only mirrored while focus mode is off locally; a local session is never overridden.
+//   - A mirrored session ends when the peer's session ends. Local sessions are never ended by a peer.
+//   - Disabling a mirrored session locally dismisses it until the peer starts a new session.
+type Syncer struct {
+	peers    []string
+	clients  map[string]Peer
+	focus    FocusController
+	interval atomic.Int64 // Time between polls; changed at runtime with SetInterval
+
+	active      map[string]bool // Whether each peer had a session at the last successful poll
+	unreachable map[string]bool // Peers whose last poll failed, so failures are logged once
+	mirroring   string          // Peer whose session is mirrored locally ("" = none)
+}
+
+// NewSyncer validates the sync config and creates a syncer
+func NewSyncer(cfg *config.SyncConfig, focus FocusController) (*Syncer, error) {
+	if len(cfg.Peers) == 0 {
+		return nil, fmt.Errorf("at least one sync peer is required")
+	}
+	interval, err := cfg.GetInterval()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Syncer{
+		clients:     make(map[string]Peer),
+		focus:       focus,
+		active:      make(map[string]bool),
+		unreachable: make(map[string]bool),
+	}
+	s.interval.Store(int64(interval))
+	for _, peer := range cfg.Peers {
+		peer = strings.TrimSuffix(peer, "/")
+		if !strings.HasPrefix(peer, "http://") && !strings.HasPrefix(peer, "https://") {
+			return nil, fmt.Errorf("invalid sync peer %q: must be an http(s) URL", peer)
+		}
+		s.peers = append(s.peers, peer)
+		s.clients[peer] = api.NewClientWithToken(peer, cfg.Token)
+	}
+	return s, nil
+}
+
+// Run polls the peers until the stop channel is closed
+func (s *Syncer) Run(stop <-chan struct{}) {
+	interval := s.Interval()
+	log.Printf("Focus sync started (%d peers, every %s)", len(s.peers), interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if next := s.Interval(); next != interval {
+			interval = next
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// Interval returns the time between polls
+func (s *Syncer) Interval() time.Duration {
+	return time.Duration(s.interval.Load())
+}
+
+// SetInterval changes the time between polls, starting after the next poll
+func (s *Syncer) SetInterval(interval time.Duration) {
+	s.interval.Store(int64(interval))
+}
+
+// check polls every peer once and starts or ends the mirrored session
+func (s *Syncer) check(now time.Time) {
+	local := s.focus.GetFocusState()
+	localActive := isActive(&local, now)
+	if s.mirroring != "" && !localActive {
+		// Disabled locally or expired: wait for the peer's next session
+		s.mirroring = ""
+	}
+
+	for _, peer := range s.peers {
+		state, err := s.clients[peer].GetFocusMode()
+		if err != nil {
+			if !s.unreachable[peer] {
+				log.Printf("Warning: failed to reach sync peer %s: %v", peer, err)
+				s.unreachable[peer] = true
+			}
+			continue
+		}
+		if s.unreachable[peer] {
+			log.Printf("Sync peer %s is reachable again", peer)
+			delete(s.unreachable, peer)
+		}
+
+		peerActive := isActive(state, now)
+		wasActive, known := s.active[peer]
+		s.active[peer] = peerActive
+
+		if s.mirroring == peer {
+			if !peerActive {
+				log.Printf("Focus session on %s ended, disabling the mirrored session", peer)
+				if err := s.focus.ApplyFocusMode(api.FocusRequest{Enabled: false}); err != nil {
+					log.Printf("Warning: failed to disable mirrored focus session: %v", err)
+				}
+				s.mirroring = ""
+				localActive = false
+			}
+			continue
+		}
+
+		// Mirror sessions the peer starts, or is already running when we first see it
+		if !peerActive || (known && wasActive) || localActive || s.mirroring != "" {
+			continue
+		}
+		if err := s.mirror(peer, state, now); err != nil {
+			log.Printf("Warning: failed to mirror focus session from %s: %v", peer, err)
+			continue
+		}
+		s.mirroring = peer
+		localActive = true
+	}
+}
+
+// mirror starts a local session matching the peer's
+func (s *Syncer) mirror(peer string, state *api.FocusModeState, now time.Time) error {
+	req := api.FocusRequest{
+		Enabled:   true,
+		Profile:   state.Profile,
+		Intensity: state.Intensity,
+		Label:     state.Label,
+		DryRun:    state.DryRun,
+	}
+	end := state.EndTime
+	if state.Paused && state.Remaining != "" {
+		// A paused peer's session ends after the pause plus the remaining time
+		if remaining, err := time.ParseDuration(state.Remaining); err == nil && state.PausedUntil != nil {
+			pausedEnd := state.PausedUntil.Add(remaining)
+			end = &pausedEnd
+		}
+	}
+	if end != nil {
+		req.Duration = end.Sub(now).Round(time.Second).String()
+	}
+
+	log.Printf("Focus session started on %s, mirroring it (duration: %s, profile: %s)", peer, req.Duration, req.Profile)
+	err := s.focus.ApplyFocusMode(req)
+	if err != nil && req.Profile != "" {
+		// The profile may only exist on the peer; fall back to the default allowlist
+		log.Printf("Warning: failed to mirror with profile %s, using the default allowlist: %v", req.Profile, err)
+		req.Profile = ""
+		err = s.focus.ApplyFocusMode(req)
+	}
+	return err
+}
+
+// isActive reports whether a focus state describes a running (possibly paused) session
+func isActive(state *api.FocusModeState, now time.Time) bool {
+	return state.Enabled && (state.EndTime == nil || state.EndTime.After(now))
+}
@@ -0,0 +1,93 @@
+package peersync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeFocus struct {
+	state    api.FocusModeState
+	requests []api.FocusRequest
+}
+
+func (f *fakeFocus) GetFocusState() api.FocusModeState {
+	return f.state
+}
+
+func (f *fakeFocus) ApplyFocusMode(req api.FocusRequest) error {
+	f.requests = append(f.requests, req)
+	f.state = api.FocusModeState{Enabled: req.Enabled, Label: req.Label}
+	return nil
+}
+
+type fakePeer struct {
+	state *api.FocusModeState
+	err   error
+}
+
+func (p *fakePeer) GetFocusMode() (*api.FocusModeState, error) {
+	return p.state, p.err
+}
+
+func newTestSyncer(t *testing.T, focus FocusController, peer Peer) *Syncer {
+	syncer, err := NewSyncer(&config.SyncConfig{Peers: []string{"http://desktop:8080/"}}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create syncer: %v", err)
+	}
+	syncer.clients["http://desktop:8080"] = peer
+	return syncer
+}
+
+func TestSyncerMirrorsPeerSession(t *testing.T) {
+	now := time.Now()
+	focus := &fakeFocus{}
+	peer := &fakePeer{state: &api.FocusModeState{}}
+	syncer := newTestSyncer(t, focus, peer)
+
+	syncer.check(now)
+	if len(focus.requests) != 0 {
+		t.Fatalf("Expected no requests while the peer is idle, got %+v", focus.requests)
+	}
+
+	end := now.Add(time.Hour)
+	peer.state = &api.FocusModeState{Enabled: true, EndTime: &end, Label: "thesis"}
+	syncer.check(now)
+	if len(focus.requests) != 1 || focus.requests[0].Duration != "1h0m0s" || focus.requests[0].Label != "thesis" {
+		t.Fatalf("Expected the peer session to be mirrored, got %+v", focus.requests)
+	}
+
+	peer.state = &api.FocusModeState{}
+	syncer.check(now)
+	if focus.state.Enabled {
+		t.Errorf("Expected the mirrored session to end with the peer's session")
+	}
+}
+
+func TestSyncerLeavesLocalSessionAlone(t *testing.T) {
+	now := time.Now()
+	focus := &fakeFocus{state: api.FocusModeState{Enabled: true}}
+	peer := &fakePeer{state: &api.FocusModeState{Enabled: true}}
+	syncer := newTestSyncer(t, focus, peer)
+
+	syncer.check(now)
+	peer.state = &api.FocusModeState{}
+	syncer.check(now)
+
+	if len(focus.requests) != 0 || !focus.state.Enabled {
+		t.Errorf("Expected the local session to be left alone, got %+v", focus.requests)
+	}
+}
+
+func TestSyncerToleratesUnreachablePeer(t *testing.T) {
+	focus := &fakeFocus{}
+	syncer := newTestSyncer(t, focus, &fakePeer{err: fmt.Errorf("connection refused")})
+
+	syncer.check(time.Now())
+	if len(focus.requests) != 0 || !syncer.unreachable["http://desktop:8080"] {
+		t.Errorf("Expected the unreachable peer to be skipped and remembered")
+	}
+}
@@ -0,0 +1,80 @@
+//go:build !windows
+
+// Package privileges lets the resolver give up root once it has bound its ports
+package privileges
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// Drop switches the process to u, first handing it the files root created in dataDir, such
+// as a log opened before the switch. It does nothing when the process already runs as u.
+func Drop(u *user.User, dataDir string) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q of %s: %w", u.Uid, u.Username, err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q of %s: %w", u.Gid, u.Username, err)
+	}
+	if os.Geteuid() == uid {
+		return nil
+	}
+	if os.Geteuid() != 0 {
+		return fmt.Errorf("switching to %s requires starting as root", u.Username)
+	}
+
+	groups := []int{gid}
+	if ids, err := u.GroupIds(); err == nil {
+		for _, id := range ids {
+			if n, err := strconv.Atoi(id); err == nil && n != gid {
+				groups = append(groups, n)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(dataDir, 0o750); err != nil {
+		return fmt.Errorf("failed to create %s: %w", dataDir, err)
+	}
+	if err := chownRootFiles(dataDir, uid, gid); err != nil {
+		return err
+	}
+
+	if err := syscall.Setgroups(groups); err != nil {
+		return fmt.Errorf("failed to set groups: %w", err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("failed to set gid %d: %w", gid, err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("failed to set uid %d: %w", uid, err)
+	}
+	return nil
+}
+
+// chownRootFiles gives uid every file under dir that root owns
+func chownRootFiles(dir string, uid, gid int) error {
+	return filepath.WalkDir(dir, func(path string, _ fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := os.Lstat(path)
+		if err != nil {
+			return err
+		}
+		if stat, ok := info.Sys().(*syscall.Stat_t); !ok || stat.Uid != 0 {
+			return nil
+		}
+		if err := os.Lchown(path, uid, gid); err != nil {
+			return fmt.Errorf("failed to hand %s to uid %d: %w", path, uid, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,11 @@
+package privileges
+
+import (
+	"fmt"
+	"os/user"
+)
+
+// Drop isn't supported on Windows, where the resolver runs as a service account instead
+func Drop(u *user.User, _ string) error {
+	return fmt.Errorf("switching to %s is not supported on Windows", u.Username)
+}
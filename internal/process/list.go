@@ -0,0 +1,95 @@
+package process
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// List returns the lowercased executable names of the running processes:
+// /proc on Linux, ps on macOS and the BSDs, and tasklist on Windows.
+func List() ([]string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return listProc()
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		return listPS()
+	case "windows":
+		return listTasklist()
+	default:
+		return nil, fmt.Errorf("process watching is not supported on %s", runtime.GOOS)
+	}
+}
+
+// listProc reads the command name of every process from /proc
+func listProc() ([]string, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.Trim(entry.Name(), "0123456789") != "" {
+			continue
+		}
+		// Processes may exit while we scan, so unreadable entries are skipped
+		comm, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		names = append(names, normalizeName(string(comm)))
+	}
+	return names, nil
+}
+
+// listPS lists process names with ps
+func listPS() ([]string, error) {
+	output, err := exec.Command("ps", "-axco", "comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := normalizeName(line); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// listTasklist lists process image names with tasklist
+func listTasklist() ([]string, error) {
+	output, err := exec.Command("tasklist", "/fo", "csv", "/nh").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	records, err := csv.NewReader(strings.NewReader(string(output))).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse tasklist output: %w", err)
+	}
+
+	var names []string
+	for _, record := range records {
+		if len(record) > 0 {
+			names = append(names, normalizeName(record[0]))
+		}
+	}
+	return names, nil
+}
+
+// normalizeName lowercases a process name and drops its path and Windows .exe suffix
+func normalizeName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return ""
+	}
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	return strings.TrimSuffix(name, ".exe")
+}
@@ -0,0 +1,145 @@
+package process
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// FocusController is the part of the API server the watcher drives
+type FocusController interface {
+	GetFocusMode() (bool, *time.Time)
+	ApplyFocusMode(req api.FocusRequest) error
+}
+
+// Watcher enables focus mode while a configured process is running and disables it when
+// the process exits.
+//
+// Conflict rules with manual focus commands:
+//   - A process session only starts while focus mode is off; a manual session is never overridden.
+//   - Only sessions started by the watcher are ended by it.
+//   - Disabling a process session dismisses it until the process exits and starts again.
+type Watcher struct {
+	triggers []config.ProcessTrigger
+	focus    FocusController
+	list     func() ([]string, error)
+
+	owned     *config.ProcessTrigger // Trigger whose session is running
+	dismissed map[string]bool        // Triggers whose session was ended while the process kept running
+}
+
+// checkInterval is how often the running processes are listed
+const checkInterval = 5 * time.Second
+
+// NewWatcher validates the process triggers and creates a watcher
+func NewWatcher(triggers []config.ProcessTrigger, focus FocusController) (*Watcher, error) {
+	for i, trigger := range triggers {
+		if strings.TrimSpace(trigger.Process) == "" {
+			return nil, fmt.Errorf("process trigger %d: process name is required", i+1)
+		}
+	}
+
+	return &Watcher{
+		triggers:  triggers,
+		focus:     focus,
+		list:      List,
+		dismissed: make(map[string]bool),
+	}, nil
+}
+
+// Run checks the running processes until the stop channel is closed
+func (w *Watcher) Run(stop <-chan struct{}) {
+	log.Printf("Process watcher started (%d triggers)", len(w.triggers))
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check starts or ends a session when a trigger process starts or exits
+func (w *Watcher) check(now time.Time) {
+	processes, err := w.list()
+	if err != nil {
+		log.Printf("Warning: failed to list processes: %v", err)
+		return
+	}
+
+	enabled, endTime := w.focus.GetFocusMode()
+	active := enabled && (endTime == nil || endTime.After(now))
+
+	if w.owned != nil {
+		trigger := *w.owned
+		switch {
+		case !active:
+			// Ended elsewhere (disabled or expired): don't restart while the process keeps running
+			if isRunning(processes, trigger.Process) {
+				w.dismissed[trigger.Process] = true
+			}
+			w.owned = nil
+		case !isRunning(processes, trigger.Process):
+			log.Printf("Process %q exited, disabling focus mode", trigger.Process)
+			if err := w.focus.ApplyFocusMode(api.FocusRequest{Enabled: false}); err != nil {
+				log.Printf("Warning: failed to disable focus mode after %q exited (disable it manually): %v", trigger.Process, err)
+			}
+			w.owned = nil
+		}
+		return
+	}
+
+	for name := range w.dismissed {
+		if !isRunning(processes, name) {
+			delete(w.dismissed, name)
+		}
+	}
+
+	// Never override a running manual session
+	if active {
+		return
+	}
+
+	for i, trigger := range w.triggers {
+		if w.dismissed[trigger.Process] || !isRunning(processes, trigger.Process) {
+			continue
+		}
+
+		log.Printf("Process %q is running, enabling focus mode", trigger.Process)
+		err := w.focus.ApplyFocusMode(api.FocusRequest{
+			Enabled: true,
+			Profile: trigger.Profile,
+			Label:   trigger.Label,
+		})
+		if err != nil {
+			log.Printf("Warning: failed to enable focus mode for %q: %v", trigger.Process, err)
+			return
+		}
+		w.owned = &w.triggers[i]
+		return
+	}
+}
+
+// linuxCommLength is the length Linux truncates process names to in /proc/<pid>/comm
+const linuxCommLength = 15
+
+// isRunning reports whether a process with the given executable name is in the list
+func isRunning(processes []string, name string) bool {
+	name = normalizeName(name)
+	for _, process := range processes {
+		if process == name || (len(process) == linuxCommLength && strings.HasPrefix(name, process)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeFocus struct {
+	enabled  bool
+	requests []api.FocusRequest
+}
+
+func (f *fakeFocus) GetFocusMode() (bool, *time.Time) {
+	return f.enabled, nil
+}
+
+func (f *fakeFocus) ApplyFocusMode(req api.FocusRequest) error {
+	f.requests = append(f.requests, req)
+	f.enabled = req.Enabled
+	return nil
+}
+
+func TestWatcherFollowsProcess(t *testing.T) {
+	focus := &fakeFocus{}
+	watcher, err := NewWatcher([]config.ProcessTrigger{{Process: "Code.exe", Profile: "deep-work"}}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	processes := []string{"bash"}
+	watcher.list = func() ([]string, error) { return processes, nil }
+
+	watcher.check(time.Now())
+	if len(focus.requests) != 0 {
+		t.Fatalf("Expected no session before the process starts, got %+v", focus.requests)
+	}
+
+	processes = []string{"bash", "code"}
+	watcher.check(time.Now())
+	if !focus.enabled || focus.requests[0].Profile != "deep-work" {
+		t.Fatalf("Expected a deep-work session while the process runs, got %+v", focus.requests)
+	}
+
+	processes = []string{"bash"}
+	watcher.check(time.Now())
+	if focus.enabled {
+		t.Errorf("Expected focus mode to end when the process exits")
+	}
+}
+
+func TestWatcherRespectsDismissal(t *testing.T) {
+	focus := &fakeFocus{}
+	watcher, err := NewWatcher([]config.ProcessTrigger{{Process: "obsidian"}}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	watcher.list = func() ([]string, error) { return []string{"obsidian"}, nil }
+
+	watcher.check(time.Now())
+	focus.enabled = false // Disabled manually while the process keeps running
+	watcher.check(time.Now())
+	watcher.check(time.Now())
+
+	if len(focus.requests) != 1 {
+		t.Errorf("Expected a dismissed session not to restart, got %d requests", len(focus.requests))
+	}
+}
+
+func TestIsRunningHandlesTruncatedNames(t *testing.T) {
+	if !isRunning([]string{"gnome-text-edit"}, "gnome-text-editor") {
+		t.Error("Expected a truncated /proc name to match")
+	}
+	if isRunning([]string{"code"}, "codex") {
+		t.Error("Expected different names not to match")
+	}
+}
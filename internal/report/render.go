@@ -0,0 +1,228 @@
+package report
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// barWidth is how many blocks the longest day's bar has in Markdown
+const barWidth = 20
+
+// view is a report with its numbers formatted, for the templates
+type view struct {
+	Title    string
+	Period   string
+	Focus    string
+	LastWeek string
+	Change   string // e.g. +1h30m, empty without focus time last week
+	Goal     string
+	Days     []dayView
+	Labels   []labelView
+
+	DaysGoalMet   int
+	Streak        int
+	LongestStreak int
+
+	Queries *Queries
+	Share   string // Blocked share of the queries, e.g. 6.2%
+
+	AllowlistDomains int
+	AllowlistGrowth  string // e.g. +3, empty when unknown
+}
+
+type dayView struct {
+	Name    string // e.g. Fri Oct 17
+	Focus   string
+	Bar     string // Blocks for Markdown
+	Percent int    // Of the longest day, for HTML
+	GoalMet bool
+}
+
+type labelView struct {
+	Label string
+	Focus string
+}
+
+func newView(r Report) view {
+	v := view{
+		Title:            "Sinkzone weekly report",
+		Period:           r.Start.Format("Mon Jan 2") + " – " + r.End.Format("Mon Jan 2, 2006"),
+		Focus:            hours(r.FocusSeconds),
+		DaysGoalMet:      r.DaysGoalMet,
+		Streak:           r.Streak,
+		LongestStreak:    r.LongestStreak,
+		Queries:          r.Queries,
+		AllowlistDomains: r.AllowlistDomains,
+	}
+	if r.LastWeek > 0 {
+		v.LastWeek = hours(r.LastWeek)
+		change := r.FocusSeconds - r.LastWeek
+		if change < 0 {
+			v.Change = "-" + hours(-change)
+		} else {
+			v.Change = "+" + hours(change)
+		}
+	}
+	if r.GoalSeconds > 0 {
+		v.Goal = hours(r.GoalSeconds)
+	}
+
+	var longest int64
+	for _, day := range r.Days {
+		longest = max(longest, day.FocusSeconds)
+	}
+	for _, day := range r.Days {
+		date, _ := time.Parse("2006-01-02", day.Date)
+		entry := dayView{Name: date.Format("Mon Jan 2"), Focus: hours(day.FocusSeconds), GoalMet: day.GoalMet}
+		if longest > 0 {
+			entry.Percent = int(day.FocusSeconds * 100 / longest)
+			entry.Bar = strings.Repeat("█", int(day.FocusSeconds*barWidth/longest))
+		}
+		v.Days = append(v.Days, entry)
+	}
+	for _, label := range r.Labels {
+		v.Labels = append(v.Labels, labelView{Label: label.Label, Focus: hours(label.FocusSeconds)})
+	}
+
+	if r.Queries != nil && r.Queries.Total > 0 {
+		v.Share = fmt.Sprintf("%.1f%%", float64(r.Queries.Blocked)/float64(r.Queries.Total)*100)
+	}
+	if r.AllowlistGrowth != nil {
+		v.AllowlistGrowth = fmt.Sprintf("%+d", *r.AllowlistGrowth)
+	}
+	return v
+}
+
+// hours formats focus time, e.g. 45m or 2h05m
+func hours(seconds int64) string {
+	minutes := seconds / 60
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
+
+// templateFuncs numbers the lists of the templates from 1
+var templateFuncs = map[string]any{"inc": func(i int) int { return i + 1 }}
+
+var markdownTemplate = template.Must(template.New("markdown").Funcs(templateFuncs).Parse(`# {{.Title}}
+
+{{.Period}}
+
+## Focus
+
+**{{.Focus}}** of focus time{{if .LastWeek}} ({{.LastWeek}} the week before, {{.Change}}){{end}}.
+{{- if .Goal}}
+Daily goal of {{.Goal}} met on {{.DaysGoalMet}} of {{len .Days}} days; streak {{.Streak}} days, longest {{.LongestStreak}}.
+{{- end}}
+
+| Day | Focus | |
+|-----|------:|---|
+{{- range .Days}}
+| {{.Name}} | {{.Focus}} | {{.Bar}}{{if .GoalMet}} ✓{{end}} |
+{{- end}}
+{{- if .Labels}}
+
+By label:
+{{range .Labels}}
+- {{.Label}}: {{.Focus}}
+{{- end}}
+{{- end}}
+
+## Distractions
+{{if not .Queries}}
+No query log to read yet; a running resolver keeps one unless query_log.enabled is false.
+{{- else if not .Queries.Distractions}}
+Nothing blocked this week.
+{{- else}}
+{{.Queries.Blocked}} of {{.Queries.Total}} queries blocked ({{.Share}}).
+
+| # | Domain | Blocked |
+|--:|--------|--------:|
+{{- range $i, $count := .Queries.Distractions}}
+| {{inc $i}} | {{$count.Name}} | {{$count.Blocked}} |
+{{- end}}
+{{- end}}
+
+## Allowlist
+
+{{.AllowlistDomains}} domains{{if .AllowlistGrowth}} ({{.AllowlistGrowth}} this week){{end}}.
+`))
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("html").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; max-width: 42em; margin: 2em auto; padding: 0 1em; color: #222; }
+h1 { margin-bottom: 0; }
+.period { color: #666; margin-top: .25em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { padding: .3em .5em; text-align: left; border-bottom: 1px solid #eee; }
+td.number, th.number { text-align: right; white-space: nowrap; }
+.bar { background: #4a7; height: .8em; border-radius: 2px; }
+.met { color: #4a7; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="period">{{.Period}}</p>
+
+<h2>Focus</h2>
+<p><strong>{{.Focus}}</strong> of focus time{{if .LastWeek}} ({{.LastWeek}} the week before, {{.Change}}){{end}}.
+{{- if .Goal}}<br>Daily goal of {{.Goal}} met on {{.DaysGoalMet}} of {{len .Days}} days; streak {{.Streak}} days, longest {{.LongestStreak}}.{{end}}</p>
+<table>
+{{- range .Days}}
+<tr><td>{{.Name}}</td><td class="number">{{.Focus}}</td><td style="width: 60%"><div class="bar" style="width: {{.Percent}}%"></div></td><td class="met">{{if .GoalMet}}✓{{end}}</td></tr>
+{{- end}}
+</table>
+{{- if .Labels}}
+<p>By label:</p>
+<ul>
+{{- range .Labels}}
+<li>{{.Label}}: {{.Focus}}</li>
+{{- end}}
+</ul>
+{{- end}}
+
+<h2>Distractions</h2>
+{{- if not .Queries}}
+<p>No query log to read yet; a running resolver keeps one unless <code>query_log.enabled</code> is false.</p>
+{{- else if not .Queries.Distractions}}
+<p>Nothing blocked this week.</p>
+{{- else}}
+<p>{{.Queries.Blocked}} of {{.Queries.Total}} queries blocked ({{.Share}}).</p>
+<table>
+<tr><th class="number">#</th><th>Domain</th><th class="number">Blocked</th></tr>
+{{- range $i, $count := .Queries.Distractions}}
+<tr><td class="number">{{inc $i}}</td><td>{{$count.Name}}</td><td class="number">{{$count.Blocked}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Allowlist</h2>
+<p>{{.AllowlistDomains}} domains{{if .AllowlistGrowth}} ({{.AllowlistGrowth}} this week){{end}}.</p>
+</body>
+</html>
+`))
+
+// Markdown writes the report as Markdown
+func (r Report) Markdown(w io.Writer) error {
+	if err := markdownTemplate.Execute(w, newView(r)); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// HTML writes the report as a standalone HTML page
+func (r Report) HTML(w io.Writer) error {
+	if err := htmlTemplate.Execute(w, newView(r)); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// Package report sums up a week of focus sessions and blocked queries for 'sinkzone
+// report', as Markdown or a standalone HTML page
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Days is how many days a weekly report covers, ending today
+const Days = 7
+
+// Report is a week of focus time, streaks, blocked queries, and allowlist changes
+type Report struct {
+	Start time.Time `json:"start"` // Midnight of the first day
+	End   time.Time `json:"end"`   // When the report was made
+
+	Days         []Day   `json:"days"`
+	FocusSeconds int64   `json:"focus_seconds"`
+	LastWeek     int64   `json:"last_week_focus_seconds"` // Focus time of the 7 days before, for comparison
+	Labels       []Label `json:"labels,omitempty"`        // Focus time per session label, most focused first
+
+	GoalSeconds   int64 `json:"goal_seconds,omitempty"` // Daily goal; streaks are only counted with one
+	DaysGoalMet   int   `json:"days_goal_met"`
+	Streak        int   `json:"streak"`
+	LongestStreak int   `json:"longest_streak"`
+
+	Queries *Queries `json:"queries,omitempty"` // Omitted without a query log to read
+
+	AllowlistDomains int  `json:"allowlist_domains"`
+	AllowlistGrowth  *int `json:"allowlist_growth,omitempty"` // Omitted when no size was recorded before the week
+}
+
+// Day is the focus time of one day
+type Day struct {
+	Date         string `json:"date"` // YYYY-MM-DD
+	FocusSeconds int64  `json:"focus_seconds"`
+	GoalMet      bool   `json:"goal_met"`
+}
+
+// Label is the focus time of sessions with one label
+type Label struct {
+	Label        string `json:"label"`
+	FocusSeconds int64  `json:"focus_seconds"`
+}
+
+// Queries sums up the queries of the week
+type Queries struct {
+	Total        int         `json:"total"`
+	Blocked      int         `json:"blocked"`
+	Distractions []api.Count `json:"distractions"` // The 10 most blocked domains
+}
+
+// WeekStart returns midnight of the first day of the report made at now
+func WeekStart(now time.Time) time.Time {
+	return time.Date(now.Year(), now.Month(), now.Day()-(Days-1), 0, 0, 0, 0, now.Location())
+}
+
+// Build puts a report together from the state file's focus history, the goal, the week's
+// query stats (nil without them), and the allowlist's current size
+func Build(state config.State, goal time.Duration, queries *api.QueryStats, allowlistDomains int, now time.Time) Report {
+	start := WeekStart(now)
+	report := Report{Start: start, End: now, AllowlistDomains: allowlistDomains}
+
+	labels := make(map[string]int64)
+	for i := 0; i < Days; i++ {
+		day := start.AddDate(0, 0, i)
+		focus := state.FocusTimeOn(day)
+		entry := Day{Date: day.Format("2006-01-02"), FocusSeconds: int64(focus / time.Second), GoalMet: goal > 0 && focus >= goal}
+		if entry.GoalMet {
+			report.DaysGoalMet++
+		}
+		report.Days = append(report.Days, entry)
+		report.FocusSeconds += entry.FocusSeconds
+		report.LastWeek += int64(state.FocusTimeOn(day.AddDate(0, 0, -Days)) / time.Second)
+		for label, seconds := range state.LabelFocus[entry.Date] {
+			labels[label] += seconds
+		}
+	}
+	for label, seconds := range labels {
+		report.Labels = append(report.Labels, Label{Label: label, FocusSeconds: seconds})
+	}
+	sort.Slice(report.Labels, func(i, j int) bool {
+		if report.Labels[i].FocusSeconds != report.Labels[j].FocusSeconds {
+			return report.Labels[i].FocusSeconds > report.Labels[j].FocusSeconds
+		}
+		return report.Labels[i].Label < report.Labels[j].Label
+	})
+
+	if goal > 0 {
+		stats := state.GoalStats(goal, now)
+		report.GoalSeconds = int64(goal / time.Second)
+		report.Streak, report.LongestStreak = stats.Streak, stats.LongestStreak
+	}
+
+	if queries != nil {
+		report.Queries = &Queries{Total: queries.Total, Blocked: queries.Blocked, Distractions: queries.TopBlocked}
+	}
+
+	if before, ok := state.AllowlistSizeOn(start.AddDate(0, 0, -1)); ok {
+		growth := allowlistDomains - before
+		report.AllowlistGrowth = &growth
+	}
+	return report
+}
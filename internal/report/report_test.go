@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2025, 3, 14, 17, 0, 0, 0, time.Local) // A Friday
+	state := config.State{
+		DailyFocus: map[string]int64{
+			"2025-03-01": 3600, // The week before
+			"2025-03-07": 3600,
+			"2025-03-08": 7200,
+			"2025-03-12": 7200,
+			"2025-03-13": 9000,
+			"2025-03-14": 1800,
+		},
+		LabelFocus:    map[string]map[string]int64{"2025-03-13": {"writing": 5400}, "2025-03-07": {"old": 3600}},
+		AllowlistSize: map[string]int{"2025-03-01": 10, "2025-03-12": 13},
+	}
+	queries := &api.QueryStats{Total: 200, Blocked: 50, TopBlocked: []api.Count{{Name: "news.example", Count: 40, Blocked: 40}}}
+
+	r := Build(state, 2*time.Hour, queries, 13, now)
+	if !r.Start.Equal(time.Date(2025, 3, 8, 0, 0, 0, 0, time.Local)) || len(r.Days) != Days {
+		t.Fatalf("expected the 7 days from March 8, got %s and %d days", r.Start, len(r.Days))
+	}
+	if r.FocusSeconds != 7200+7200+9000+1800 || r.LastWeek != 3600+3600 {
+		t.Errorf("expected 7h this week and 2h the week before, got %d and %d", r.FocusSeconds, r.LastWeek)
+	}
+	if r.DaysGoalMet != 3 || r.Streak != 2 {
+		t.Errorf("expected the goal met on 3 days and a streak of 2, got %d and %d", r.DaysGoalMet, r.Streak)
+	}
+	if len(r.Labels) != 1 || r.Labels[0].Label != "writing" {
+		t.Errorf("expected only this week's label, got %+v", r.Labels)
+	}
+	if r.AllowlistGrowth == nil || *r.AllowlistGrowth != 3 {
+		t.Errorf("expected the allowlist to have grown by 3, got %v", r.AllowlistGrowth)
+	}
+
+	var markdown bytes.Buffer
+	if err := r.Markdown(&markdown); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"**7h00m**", "(2h00m the week before, +5h00m)", "| Fri Mar 14 | 30m |", "| 1 | news.example | 40 |", "50 of 200 queries blocked (25.0%)", "13 domains (+3 this week)"} {
+		if !strings.Contains(markdown.String(), want) {
+			t.Errorf("expected %q in the Markdown report:\n%s", want, markdown.String())
+		}
+	}
+
+	r.Queries.Distractions[0].Name = "<script>"
+	var html bytes.Buffer
+	if err := r.HTML(&html); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(html.String(), "<script>") || !strings.Contains(html.String(), "&lt;script&gt;") {
+		t.Error("expected domains to be escaped in the HTML report")
+	}
+
+	if r := Build(config.State{}, 0, nil, 0, now); r.Queries != nil || r.AllowlistGrowth != nil || r.GoalSeconds != 0 {
+		t.Errorf("expected an empty report, got %+v", r)
+	}
+}
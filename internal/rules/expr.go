@@ -0,0 +1,362 @@
+package rules
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+)
+
+// Conditions are expressions such as
+//
+//	domain matches "*.youtube.com" and not (time >= "18:00" and time < "20:00")
+//
+// built from the variables of Input, string literals in double quotes, lists of them in
+// brackets, true and false, the comparisons == != < <= > >=, in (a list), matches (a
+// pattern or a list of them), and not, and, or, and parentheses. Strings compare as
+// text, which orders the HH:MM times.
+
+// kind is the type of a value
+type kind int
+
+const (
+	kindString kind = iota
+	kindBool
+	kindList
+)
+
+func (k kind) String() string {
+	return [...]string{"string", "bool", "list"}[k]
+}
+
+// variables are the names a condition can use, with their types
+var variables = map[string]kind{
+	"domain":      kindString,
+	"client":      kindString,
+	"client_name": kindString,
+	"user":        kindString,
+	"time":        kindString,
+	"weekday":     kindString,
+	"category":    kindString,
+	"focus":       kindBool,
+	"profile":     kindString,
+	"intensity":   kindString,
+}
+
+// node is a parsed expression
+type node interface {
+	kind() kind
+	eval(in *Input) value
+}
+
+// value is the result of evaluating a node; only the field of its kind is set
+type value struct {
+	str  string
+	b    bool
+	list []string
+}
+
+type literal struct {
+	k kind
+	v value
+}
+
+func (l literal) kind() kind        { return l.k }
+func (l literal) eval(*Input) value { return l.v }
+
+type variable struct{ name string }
+
+func (v variable) kind() kind { return variables[v.name] }
+func (v variable) eval(in *Input) value {
+	if v.name == "focus" {
+		return value{b: in.Focus}
+	}
+	return value{str: in.get(v.name)}
+}
+
+type unary struct{ operand node } // not
+
+func (u unary) kind() kind           { return kindBool }
+func (u unary) eval(in *Input) value { return value{b: !u.operand.eval(in).b} }
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (b binary) kind() kind { return kindBool }
+
+func (b binary) eval(in *Input) value {
+	switch b.op {
+	case "and":
+		return value{b: b.left.eval(in).b && b.right.eval(in).b}
+	case "or":
+		return value{b: b.left.eval(in).b || b.right.eval(in).b}
+	}
+
+	left, right := b.left.eval(in), b.right.eval(in)
+	switch b.op {
+	case "in":
+		return value{b: slices.Contains(right.list, left.str)}
+	case "matches":
+		patterns := right.list
+		if b.right.kind() == kindString {
+			patterns = []string{right.str}
+		}
+		return value{b: slices.ContainsFunc(patterns, func(pattern string) bool { return allowlist.MatchPattern(pattern, left.str) })}
+	}
+	if b.left.kind() == kindBool {
+		equal := left.b == right.b
+		return value{b: equal == (b.op == "==")}
+	}
+	c := strings.Compare(left.str, right.str)
+	switch b.op {
+	case "==":
+		return value{b: c == 0}
+	case "!=":
+		return value{b: c != 0}
+	case "<":
+		return value{b: c < 0}
+	case "<=":
+		return value{b: c <= 0}
+	case ">":
+		return value{b: c > 0}
+	default: // >=
+		return value{b: c >= 0}
+	}
+}
+
+// token is a word of a condition
+type token struct {
+	text string
+	str  bool // A string literal, with text unquoted
+	pos  int  // Column, from 1
+}
+
+// tokenize splits a condition into tokens
+func tokenize(condition string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(condition); {
+		c := rune(condition[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"':
+			end := strings.IndexByte(condition[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at column %d", i+1)
+			}
+			tokens = append(tokens, token{text: condition[i+1 : i+1+end], str: true, pos: i + 1})
+			i += end + 2
+		case strings.ContainsRune("()[],", c):
+			tokens = append(tokens, token{text: string(c), pos: i + 1})
+			i++
+		case strings.ContainsRune("=!<>", c):
+			op := string(c)
+			if i+1 < len(condition) && condition[i+1] == '=' {
+				op += "="
+			}
+			if op == "=" || op == "!" {
+				return nil, fmt.Errorf("unknown operator %q at column %d; use == or !=", op, i+1)
+			}
+			tokens = append(tokens, token{text: op, pos: i + 1})
+			i += len(op)
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(condition) && (condition[i] == '_' || unicode.IsLetter(rune(condition[i])) || unicode.IsDigit(rune(condition[i]))) {
+				i++
+			}
+			tokens = append(tokens, token{text: condition[start:i], pos: start + 1})
+		default:
+			return nil, fmt.Errorf("unexpected %q at column %d", c, i+1)
+		}
+	}
+	return tokens, nil
+}
+
+// parser reads a condition by recursive descent
+type parser struct {
+	tokens []token
+	next   int
+}
+
+// parse parses a condition, which must be a bool expression
+func parse(condition string) (node, error) {
+	tokens, err := tokenize(condition)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.next < len(p.tokens) {
+		return nil, p.unexpected()
+	}
+	if n.kind() != kindBool {
+		return nil, fmt.Errorf("the condition is a %s, not true or false", n.kind())
+	}
+	return n, nil
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.next >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.next], true
+}
+
+// accept consumes the next token if it is the given keyword or symbol
+func (p *parser) accept(text string) bool {
+	if t, ok := p.peek(); ok && !t.str && t.text == text {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) unexpected() error {
+	t, ok := p.peek()
+	if !ok {
+		return fmt.Errorf("unexpected end of the condition")
+	}
+	return fmt.Errorf("unexpected %q at column %d", t.text, t.pos)
+}
+
+func (p *parser) or() (node, error) {
+	return p.logical("or", p.and)
+}
+
+func (p *parser) and() (node, error) {
+	return p.logical("and", p.not)
+}
+
+// logical parses operands joined by and or or, which must all be bool
+func (p *parser) logical(op string, operand func() (node, error)) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(op) {
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.kind() != kindBool || right.kind() != kindBool {
+			return nil, fmt.Errorf("%s joins true or false conditions, not a %s and a %s", op, left.kind(), right.kind())
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (node, error) {
+	if !p.accept("not") {
+		return p.comparison()
+	}
+	operand, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	if operand.kind() != kindBool {
+		return nil, fmt.Errorf("not needs a true or false condition, not a %s", operand.kind())
+	}
+	return unary{operand: operand}, nil
+}
+
+func (p *parser) comparison() (node, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := p.peek()
+	if !ok || t.str || !slices.Contains([]string{"==", "!=", "<", "<=", ">", ">=", "in", "matches"}, t.text) {
+		return left, nil
+	}
+	p.next++
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case t.text == "in" && (left.kind() != kindString || right.kind() != kindList):
+		return nil, fmt.Errorf("in at column %d needs a string and a list", t.pos)
+	case t.text == "matches" && (left.kind() != kindString || right.kind() == kindBool):
+		return nil, fmt.Errorf("matches at column %d needs a string and a pattern or a list of them", t.pos)
+	case t.text == "in" || t.text == "matches":
+	case left.kind() != right.kind() || left.kind() == kindList:
+		return nil, fmt.Errorf("%s at column %d compares a %s with a %s", t.text, t.pos, left.kind(), right.kind())
+	case left.kind() == kindBool && t.text != "==" && t.text != "!=":
+		return nil, fmt.Errorf("%s at column %d can't order true and false", t.text, t.pos)
+	}
+	return binary{op: t.text, left: left, right: right}, nil
+}
+
+func (p *parser) operand() (node, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, p.unexpected()
+	}
+	p.next++
+	switch {
+	case t.str:
+		return literal{k: kindString, v: value{str: t.text}}, nil
+	case t.text == "true" || t.text == "false":
+		return literal{k: kindBool, v: value{b: t.text == "true"}}, nil
+	case t.text == "(":
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+		return n, nil
+	case t.text == "[":
+		var list []string
+		for !p.accept("]") {
+			if len(list) > 0 && !p.accept(",") {
+				return nil, p.unexpected()
+			}
+			item, ok := p.peek()
+			if !ok || !item.str {
+				return nil, p.unexpected()
+			}
+			p.next++
+			list = append(list, item.text)
+		}
+		return literal{k: kindList, v: value{list: list}}, nil
+	}
+	if _, ok := variables[t.text]; ok {
+		return variable{name: t.text}, nil
+	}
+	p.next--
+	if isWord(t.text) && !slices.Contains(keywords, t.text) {
+		return nil, fmt.Errorf("unknown name %q at column %d; use %s", t.text, t.pos, strings.Join(variableNames(), ", "))
+	}
+	return nil, p.unexpected()
+}
+
+// keywords are the words of the language that aren't values
+var keywords = []string{"and", "or", "not", "in", "matches"}
+
+func isWord(text string) bool {
+	return text != "" && (text[0] == '_' || unicode.IsLetter(rune(text[0])))
+}
+
+// variableNames lists the variables in alphabetical order
+func variableNames() []string {
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
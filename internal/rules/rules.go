@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Input describes a query to the rules
+type Input struct {
+	Domain     string
+	Client     string // Address of the client as recorded
+	ClientName string // Its name from client_names
+	User       string // Account of this machine that sent the query, where known
+	Time       time.Time
+	Focus      bool // A focus session blocks the client's queries
+	Profile    string
+	Intensity  string
+
+	category string // Set by Engine.Decide
+}
+
+// get returns the value of a string variable
+func (in *Input) get(name string) string {
+	switch name {
+	case "domain":
+		return in.Domain
+	case "client":
+		return in.Client
+	case "client_name":
+		return in.ClientName
+	case "user":
+		return in.User
+	case "time":
+		return in.Time.Format("15:04")
+	case "weekday":
+		return strings.ToLower(in.Time.Weekday().String()[:3])
+	case "category":
+		return in.category
+	case "profile":
+		return in.Profile
+	case "intensity":
+		return in.Intensity
+	}
+	return ""
+}
+
+// rule is a compiled config.Rule
+type rule struct {
+	name   string
+	action string
+	when   node
+}
+
+// category is a compiled config.Category
+type category struct {
+	name     string
+	patterns []string
+}
+
+// Engine decides queries by the rules of the config, before the allowlist and blocklist do
+type Engine struct {
+	rules      []rule
+	categories []category
+}
+
+// Compile checks and compiles the rules and categories of the config; it returns nil
+// without rules
+func Compile(cfg *config.Config) (*Engine, error) {
+	if len(cfg.Rules) == 0 {
+		return nil, nil
+	}
+	e := &Engine{}
+	names := make(map[string]bool)
+	for _, c := range cfg.Categories {
+		if c.Name == "" || names[c.Name] {
+			return nil, fmt.Errorf("invalid categories: each needs a name of its own (%q)", c.Name)
+		}
+		names[c.Name] = true
+		compiled := category{name: c.Name}
+		for _, pattern := range c.Domains {
+			pattern = strings.ToLower(strings.TrimSpace(pattern))
+			if err := allowlist.ValidatePattern(pattern); err != nil {
+				return nil, fmt.Errorf("invalid domain %q of category %s: %v", pattern, c.Name, err)
+			}
+			compiled.patterns = append(compiled.patterns, pattern)
+		}
+		e.categories = append(e.categories, compiled)
+	}
+
+	for i, r := range cfg.Rules {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("%d", i+1)
+		}
+		if r.Action != config.RuleAllow && r.Action != config.RuleBlock {
+			return nil, fmt.Errorf("invalid action %q of rule %s: use allow or block", r.Action, name)
+		}
+		when, err := parse(r.If)
+		if err != nil {
+			return nil, fmt.Errorf("invalid condition of rule %s: %w", name, err)
+		}
+		e.rules = append(e.rules, rule{name: name, action: r.Action, when: when})
+	}
+	return e, nil
+}
+
+// Decide returns the action and name of the first rule whose condition holds for the
+// query, or "" when none does. A nil Engine decides nothing.
+func (e *Engine) Decide(in Input) (action, name string) {
+	if e == nil {
+		return "", ""
+	}
+	in.Domain = strings.ToLower(in.Domain)
+	in.category = e.categoryOf(in.Domain)
+	for _, r := range e.rules {
+		if r.when.eval(&in).b {
+			return r.action, r.name
+		}
+	}
+	return "", ""
+}
+
+// categoryOf returns the first category listing a domain
+func (e *Engine) categoryOf(domain string) string {
+	for _, c := range e.categories {
+		for _, pattern := range c.patterns {
+			if allowlist.MatchPattern(pattern, domain) {
+				return c.name
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestDecide(t *testing.T) {
+	cfg := &config.Config{
+		Categories: []config.Category{{Name: "video", Domains: []string{"youtube.com", "*.youtube.com", "twitch.tv"}}},
+		Rules: []config.Rule{
+			{Name: "evening-video", If: `category == "video" and time >= "18:00" and time < "20:00"`, Action: config.RuleAllow},
+			{Name: "no-video", If: `category == "video"`, Action: config.RuleBlock},
+			{Name: "kids", If: `client_name in ["tablet", "console"] and (weekday == "sat" or weekday == "sun") and not focus`, Action: config.RuleBlock},
+			{If: `focus and profile == "deep-work" and domain matches ["*.slack.com", "zoom.us"]`, Action: config.RuleAllow},
+		},
+	}
+	engine, err := Compile(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	evening := time.Date(2026, 3, 6, 19, 30, 0, 0, time.Local) // A Friday
+	morning := time.Date(2026, 3, 7, 9, 0, 0, 0, time.Local)   // A Saturday
+	tests := []struct {
+		name       string
+		in         Input
+		wantAction string
+		wantRule   string
+	}{
+		{"in the evening window", Input{Domain: "www.YouTube.com", Time: evening}, config.RuleAllow, "evening-video"},
+		{"outside the window", Input{Domain: "twitch.tv", Time: morning}, config.RuleBlock, "no-video"},
+		{"weekend device", Input{Domain: "example.com", ClientName: "tablet", Time: morning}, config.RuleBlock, "kids"},
+		{"weekday device", Input{Domain: "example.com", ClientName: "tablet", Time: evening}, "", ""},
+		{"unnamed rule", Input{Domain: "app.slack.com", Focus: true, Profile: "deep-work", Time: morning}, config.RuleAllow, "4"},
+		{"no rule", Input{Domain: "app.slack.com", Time: morning}, "", ""},
+	}
+	for _, tt := range tests {
+		action, rule := engine.Decide(tt.in)
+		if action != tt.wantAction || rule != tt.wantRule {
+			t.Errorf("%s: expected %q by rule %q, got %q by rule %q", tt.name, tt.wantAction, tt.wantRule, action, rule)
+		}
+	}
+
+	if action, _ := (*Engine)(nil).Decide(Input{Domain: "youtube.com"}); action != "" {
+		t.Errorf("expected no rules to decide nothing, got %q", action)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{``, "empty condition"},
+		{`domain`, "is a string"},
+		{`domain = "a.com"`, "use == or !="},
+		{`domian == "a.com"`, `unknown name "domian"`},
+		{`domain == "a.com`, "unterminated string"},
+		{`domain == "a.com" and`, "unexpected end"},
+		{`(domain == "a.com"`, "unexpected end"},
+		{`focus < true`, "can't order"},
+		{`domain == true`, "compares a string with a bool"},
+		{`domain in "a.com"`, "needs a string and a list"},
+		{`domain == "a.com" domain`, `unexpected "domain" at column 19`},
+	}
+	for _, tt := range tests {
+		_, err := Compile(&config.Config{Rules: []config.Rule{{Name: "r", If: tt.condition, Action: config.RuleBlock}}})
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: expected an error containing %q, got %v", tt.condition, tt.want, err)
+		}
+	}
+
+	if _, err := Compile(&config.Config{Rules: []config.Rule{{If: "focus", Action: "deny"}}}); err == nil {
+		t.Error("expected an unknown action to be refused")
+	}
+	if _, err := Compile(&config.Config{Rules: []config.Rule{{If: "focus", Action: config.RuleBlock}}, Categories: []config.Category{{Name: "video", Domains: []string{"*"}}}}); err == nil {
+		t.Error("expected a category matching every domain to be refused")
+	}
+}
@@ -0,0 +1,70 @@
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// Blocks are the schedules that block domains during their windows, whether or not a
+// focus session runs
+type Blocks struct {
+	schedules []blockSchedule
+}
+
+type blockSchedule struct {
+	spec     *Spec
+	patterns []string
+}
+
+// NewBlocks validates the schedules that block domains, skipping those that start focus
+// sessions, and returns nil when there are none
+func NewBlocks(schedules []config.Schedule) (*Blocks, error) {
+	var blocks []blockSchedule
+	for i, schedule := range schedules {
+		if schedule.StartsFocus() {
+			continue
+		}
+		spec, err := Parse(schedule.When)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		if schedule.Profile != "" || schedule.Label != "" {
+			return nil, fmt.Errorf("schedule %d: a schedule that blocks domains starts no session, so it takes no profile or label", i+1)
+		}
+		patterns := make([]string, len(schedule.Block))
+		for j, pattern := range schedule.Block {
+			if err := allowlist.ValidatePattern(pattern); err != nil {
+				return nil, fmt.Errorf("schedule %d: invalid domain %q: %w", i+1, pattern, err)
+			}
+			patterns[j] = strings.ToLower(pattern)
+		}
+		blocks = append(blocks, blockSchedule{spec: spec, patterns: patterns})
+	}
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return &Blocks{schedules: blocks}, nil
+}
+
+// Blocked returns the schedule blocking a domain at now, formatted as Spec.String does
+func (b *Blocks) Blocked(domain string, now time.Time) (string, bool) {
+	if b == nil {
+		return "", false
+	}
+	domain = strings.ToLower(domain)
+	for _, schedule := range b.schedules {
+		if _, _, ok := schedule.spec.ActiveAt(now); !ok {
+			continue
+		}
+		for _, pattern := range schedule.patterns {
+			if allowlist.MatchPattern(pattern, domain) {
+				return schedule.spec.String(), true
+			}
+		}
+	}
+	return "", false
+}
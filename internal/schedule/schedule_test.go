@@ -0,0 +1,154 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+type fakeFocus struct {
+	enabled  bool
+	requests []api.FocusRequest
+}
+
+func (f *fakeFocus) GetFocusMode() (bool, *time.Time) {
+	return f.enabled, nil
+}
+
+func (f *fakeFocus) ApplyFocusMode(req api.FocusRequest) error {
+	f.requests = append(f.requests, req)
+	f.enabled = req.Enabled
+	return nil
+}
+
+func TestParse(t *testing.T) {
+	cases := map[string]string{
+		"Mon-Fri 09:00-17:00":     "Mon,Tue,Wed,Thu,Fri 09:00-17:00",
+		"weekends 10:00-12:30":    "Sat,Sun 10:00-12:30",
+		"fri-mon 22:00-06:00":     "Mon,Fri,Sat,Sun 22:00-06:00",
+		"Monday,Wed 08:00-24:00":  "Mon,Wed 08:00-24:00",
+		"daily 06:00-07:00":       "daily 06:00-07:00",
+		"Tue,Thu-Sat 13:00-14:00": "Tue,Thu,Fri,Sat 13:00-14:00",
+	}
+	for text, want := range cases {
+		spec, err := Parse(text)
+		if err != nil {
+			t.Errorf("Failed to parse %q: %v", text, err)
+			continue
+		}
+		if got := spec.String(); got != want {
+			t.Errorf("Parse(%q) = %q, want %q", text, got, want)
+		}
+	}
+
+	for _, text := range []string{"", "Mon-Fri", "Mon-Fri 9-17", "Mon-Fri 09:00-09:00", "Funday 09:00-10:00", "Monxyz 09:00-10:00", "Mon 25:00-26:00", "Mon 09:00 17:00"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Expected %q to be rejected", text)
+		}
+	}
+}
+
+func TestActiveAtAndNext(t *testing.T) {
+	spec, err := Parse("Fri 22:00-06:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Saturday 2024-06-08 03:00 is inside Friday's overnight window
+	saturday := time.Date(2024, 6, 8, 3, 0, 0, 0, time.UTC)
+	start, end, ok := spec.ActiveAt(saturday)
+	if !ok || start.Day() != 7 || end != time.Date(2024, 6, 8, 6, 0, 0, 0, time.UTC) {
+		t.Errorf("Expected Friday's window to be active, got %v-%v (%v)", start, end, ok)
+	}
+	if _, _, ok := spec.ActiveAt(saturday.Add(4 * time.Hour)); ok {
+		t.Error("Expected no window after 06:00")
+	}
+
+	next := spec.Next(saturday, 2)
+	if len(next) != 2 || next[0] != time.Date(2024, 6, 14, 22, 0, 0, 0, time.UTC) || next[1] != time.Date(2024, 6, 21, 22, 0, 0, 0, time.UTC) {
+		t.Errorf("Unexpected next windows: %v", next)
+	}
+}
+
+func TestWatcherStartsFocusOncePerWindow(t *testing.T) {
+	focus := &fakeFocus{}
+	watcher, err := NewWatcher([]config.Schedule{{When: "Mon-Fri 09:00-17:00", Profile: "work"}}, focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	// Monday 2024-06-10
+	watcher.check(time.Date(2024, 6, 10, 8, 59, 0, 0, time.UTC))
+	if len(focus.requests) != 0 {
+		t.Fatalf("Expected no session before the window, got %+v", focus.requests)
+	}
+
+	watcher.check(time.Date(2024, 6, 10, 9, 0, 30, 0, time.UTC))
+	if len(focus.requests) != 1 || focus.requests[0].Profile != "work" || focus.requests[0].Duration != "7h59m30s" {
+		t.Fatalf("Expected a work session until 17:00, got %+v", focus.requests)
+	}
+
+	focus.enabled = false // Disabled manually during the window
+	watcher.check(time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC))
+	if len(focus.requests) != 1 {
+		t.Errorf("Expected a dismissed window not to restart, got %d requests", len(focus.requests))
+	}
+
+	watcher.check(time.Date(2024, 6, 11, 9, 0, 0, 0, time.UTC))
+	if len(focus.requests) != 2 {
+		t.Errorf("Expected the next day's window to start a session, got %d requests", len(focus.requests))
+	}
+}
+
+func TestNewWatcherRejectsInvalidSchedule(t *testing.T) {
+	if _, err := NewWatcher([]config.Schedule{{When: "someday 09:00-17:00"}}, &fakeFocus{}); err == nil {
+		t.Error("Expected an invalid schedule to be rejected")
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	schedules := []config.Schedule{
+		{When: "Mon-Fri 09:00-17:00", Profile: "work"},
+		{When: "weekdays 09:00-17:00", Block: []string{"reddit.com", "*.Twitter.com"}},
+	}
+	blocks, err := NewBlocks(schedules)
+	if err != nil {
+		t.Fatalf("Failed to create blocks: %v", err)
+	}
+
+	// Monday 2024-06-10
+	monday := time.Date(2024, 6, 10, 10, 0, 0, 0, time.UTC)
+	if schedule, ok := blocks.Blocked("mobile.twitter.com", monday); !ok || schedule != "Mon,Tue,Wed,Thu,Fri 09:00-17:00" {
+		t.Errorf("Expected the schedule to block a matching domain, got %q (%v)", schedule, ok)
+	}
+	if _, ok := blocks.Blocked("github.com", monday); ok {
+		t.Error("Expected other domains to be allowed")
+	}
+	if _, ok := blocks.Blocked("reddit.com", monday.Add(8*time.Hour)); ok {
+		t.Error("Expected no block after the window")
+	}
+
+	// The watcher leaves blocking schedules alone
+	focus := &fakeFocus{}
+	watcher, err := NewWatcher(schedules[1:], focus)
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+	if watcher.check(monday); len(focus.requests) != 0 {
+		t.Errorf("Expected a blocking schedule to start no session, got %+v", focus.requests)
+	}
+
+	if blocks, err := NewBlocks(schedules[:1]); blocks != nil || err != nil {
+		t.Errorf("Expected no blocks without blocking schedules, got %v (%v)", blocks, err)
+	}
+	for _, schedule := range []config.Schedule{
+		{When: "Mon 09:00-17:00", Block: []string{"reddit.com"}, Profile: "work"},
+		{When: "Mon 09:00-17:00", Block: []string{"*"}},
+	} {
+		if _, err := NewBlocks([]config.Schedule{schedule}); err == nil {
+			t.Errorf("Expected %+v to be rejected", schedule)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+// Package schedule starts focus mode, or blocks domains, during recurring weekly windows
+// such as "Mon-Fri 09:00-17:00", configured under schedules in sinkzone.yaml.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Spec is a parsed schedule: the weekdays a window starts on and its local start and end
+// times. A window whose end is before its start runs overnight into the next day.
+type Spec struct {
+	Days  [7]bool // Indexed by time.Weekday
+	Start time.Duration
+	End   time.Duration
+}
+
+var dayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse reads a schedule: days ("Mon-Fri", "Mon,Wed,Fri", "Sat-Sun", "weekdays",
+// "weekends", or "daily"), then a time range ("09:00-17:00", or "22:00-06:00" overnight)
+func Parse(text string) (*Spec, error) {
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("invalid schedule %q: use days and a time range, e.g. \"Mon-Fri 09:00-17:00\"", text)
+	}
+
+	spec := &Spec{}
+	if err := spec.parseDays(fields[0]); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", text, err)
+	}
+
+	start, end, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid schedule %q: time range must look like 09:00-17:00", text)
+	}
+	var err error
+	if spec.Start, err = parseClock(start); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", text, err)
+	}
+	if spec.End, err = parseClock(end); err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", text, err)
+	}
+	if spec.Start == spec.End || spec.Start == 24*time.Hour {
+		return nil, fmt.Errorf("invalid schedule %q: the window must not be empty", text)
+	}
+	return spec, nil
+}
+
+// parseDays reads a comma-separated list of days and day ranges
+func (s *Spec) parseDays(text string) error {
+	switch strings.ToLower(text) {
+	case "daily", "everyday", "*":
+		text = "sun-sat"
+	case "weekdays":
+		text = "mon-fri"
+	case "weekends":
+		text = "sat,sun"
+	}
+
+	for _, part := range strings.Split(text, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := parseDay(from)
+		if err != nil {
+			return err
+		}
+		last := first
+		if isRange {
+			if last, err = parseDay(to); err != nil {
+				return err
+			}
+		}
+		// Ranges may wrap around the weekend, e.g. Fri-Mon
+		for day := first; ; day = (day + 1) % 7 {
+			s.Days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func parseDay(text string) (time.Weekday, error) {
+	name := strings.ToLower(text)
+	if len(name) > 3 {
+		name = name[:3]
+	}
+	day, ok := dayNames[name]
+	if !ok || (len(text) > 3 && !strings.HasPrefix(strings.ToLower(day.String()), strings.ToLower(text))) {
+		return 0, fmt.Errorf("unknown day %q", text)
+	}
+	return day, nil
+}
+
+// parseClock reads a local time of day (HH:MM, 24:00 meaning midnight at the end of the day)
+func parseClock(text string) (time.Duration, error) {
+	if text == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	clock, err := time.Parse("15:04", text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q: use HH:MM", text)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// String formats the schedule in the form Parse reads
+func (s *Spec) String() string {
+	if s.Days == [7]bool{true, true, true, true, true, true, true} {
+		return fmt.Sprintf("daily %s-%s", formatClock(s.Start), formatClock(s.End))
+	}
+	var days []string
+	for i := 1; i <= 7; i++ {
+		day := time.Weekday(i % 7)
+		if s.Days[day] {
+			days = append(days, day.String()[:3])
+		}
+	}
+	return fmt.Sprintf("%s %s-%s", strings.Join(days, ","), formatClock(s.Start), formatClock(s.End))
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+// window returns the window starting on the day of t, in t's location. Times are built
+// from the calendar date so windows keep their wall-clock times across DST changes.
+func (s *Spec) window(t time.Time) (time.Time, time.Time) {
+	year, month, day := t.Date()
+	at := func(day int, d time.Duration) time.Time {
+		return time.Date(year, month, day, 0, int(d.Minutes()), 0, 0, t.Location())
+	}
+	start := at(day, s.Start)
+	end := at(day, s.End)
+	if s.End < s.Start {
+		end = at(day+1, s.End)
+	}
+	return start, end
+}
+
+// ActiveAt returns the window in progress at now, if any
+func (s *Spec) ActiveAt(now time.Time) (time.Time, time.Time, bool) {
+	// A window that started yesterday may still be running overnight
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if !s.Days[day.Weekday()] {
+			continue
+		}
+		start, end := s.window(day)
+		if !now.Before(start) && now.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// Next returns the starts of the next n windows after now
+func (s *Spec) Next(now time.Time, n int) []time.Time {
+	var starts []time.Time
+	for offset := 0; len(starts) < n && offset <= 7*n; offset++ {
+		day := now.AddDate(0, 0, offset)
+		if !s.Days[day.Weekday()] {
+			continue
+		}
+		if start, _ := s.window(day); start.After(now) {
+			starts = append(starts, start)
+		}
+	}
+	return starts
+}
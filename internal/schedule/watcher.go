@@ -0,0 +1,118 @@
+package schedule
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// FocusController is the part of the API server the watcher drives
+type FocusController interface {
+	GetFocusMode() (bool, *time.Time)
+	ApplyFocusMode(req api.FocusRequest) error
+}
+
+// Watcher enables focus mode during the configured schedule windows.
+//
+// Conflict rules with manual focus commands follow the calendar watcher:
+//   - A scheduled session only starts while focus mode is off; a manual session is never overridden.
+//   - If a manual session ends while a window is still open, the schedule takes over for the
+//     rest of the window.
+//   - Each window starts at most one session, so disabling a scheduled session dismisses that
+//     window instead of it being re-enabled on the next check.
+type Watcher struct {
+	schedules []config.Schedule
+	specs     []*Spec
+	focus     FocusController
+
+	handled map[string]bool // Windows that already started a session
+}
+
+// checkInterval is how often the watcher looks for windows opening
+const checkInterval = 30 * time.Second
+
+// NewWatcher validates the schedules and creates a watcher for those that start focus
+// sessions; the others are left to Blocks
+func NewWatcher(schedules []config.Schedule, focus FocusController) (*Watcher, error) {
+	var sessions []config.Schedule
+	var specs []*Spec
+	for i, schedule := range schedules {
+		spec, err := Parse(schedule.When)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %d: %w", i+1, err)
+		}
+		if schedule.StartsFocus() {
+			sessions = append(sessions, schedule)
+			specs = append(specs, spec)
+		}
+	}
+
+	return &Watcher{
+		schedules: sessions,
+		specs:     specs,
+		focus:     focus,
+		handled:   make(map[string]bool),
+	}, nil
+}
+
+// Run checks the schedules until the stop channel is closed
+func (w *Watcher) Run(stop <-chan struct{}) {
+	log.Printf("Schedule watcher started (%d schedules)", len(w.schedules))
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		w.check(time.Now())
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check starts a session for the open window ending last, if any
+func (w *Watcher) check(now time.Time) {
+	active := -1
+	var start, end time.Time
+	for i, spec := range w.specs {
+		windowStart, windowEnd, ok := spec.ActiveAt(now)
+		if ok && (active < 0 || windowEnd.After(end)) {
+			active, start, end = i, windowStart, windowEnd
+		}
+	}
+	if active < 0 {
+		return
+	}
+
+	key := fmt.Sprintf("%d@%s", active, start.Format(time.RFC3339))
+	if w.handled[key] {
+		return
+	}
+
+	// Never override a running manual session
+	if enabled, endTime := w.focus.GetFocusMode(); enabled && (endTime == nil || endTime.After(now)) {
+		return
+	}
+
+	schedule := w.schedules[active]
+	remaining := end.Sub(now).Round(time.Second)
+	log.Printf("Schedule %q started, enabling focus mode for %s", schedule.When, remaining)
+
+	err := w.focus.ApplyFocusMode(api.FocusRequest{
+		Enabled:  true,
+		Duration: remaining.String(),
+		Profile:  schedule.Profile,
+		Label:    schedule.Label,
+	})
+	if err != nil {
+		log.Printf("Warning: failed to enable focus mode for schedule: %v", err)
+		return
+	}
+	w.handled[key] = true
+}
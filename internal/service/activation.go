@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+)
+
+// Names given to sockets with FileDescriptorName= in a socket unit, telling which server
+// each is for. Others, such as the default name of the unit, go by type: datagram for DNS,
+// stream for the API.
+const (
+	SocketDNS = "dns"
+	SocketAPI = "api"
+)
+
+// Sockets are the listening sockets systemd passed to a socket-activated resolver
+type Sockets struct {
+	DNS net.PacketConn // UDP socket of the DNS server (nil when not passed)
+	API net.Listener   // TCP socket of the HTTP API (nil when not passed)
+}
+
+// add takes over a socket passed by systemd. The file is left for the caller to close.
+func (s *Sockets) add(file *os.File, name string) error {
+	if conn, err := net.FilePacketConn(file); err == nil {
+		switch {
+		case name == SocketAPI:
+			err = fmt.Errorf("systemd socket %q is a datagram socket, which only the DNS server takes", name)
+		case s.DNS != nil:
+			err = errors.New("systemd passed more than one datagram socket")
+		default:
+			s.DNS = conn
+			return nil
+		}
+		_ = conn.Close()
+		return err
+	}
+	if listener, err := net.FileListener(file); err == nil {
+		switch {
+		case name == SocketDNS:
+			err = fmt.Errorf("systemd socket %q is a stream socket, which only the HTTP API takes (DNS is served over UDP)", name)
+		case s.API != nil:
+			err = errors.New("systemd passed more than one stream socket")
+		default:
+			s.API = listener
+			return nil
+		}
+		_ = listener.Close()
+		return err
+	}
+	return fmt.Errorf("systemd socket %q is neither a UDP nor a listening TCP socket", name)
+}
+
+// Close closes the sockets, for when the resolver can't use them
+func (s *Sockets) Close() {
+	if s.DNS != nil {
+		_ = s.DNS.Close()
+	}
+	if s.API != nil {
+		_ = s.API.Close()
+	}
+}
@@ -0,0 +1,71 @@
+//go:build !windows
+
+package service
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestSocketsAdd(t *testing.T) {
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcp.Close()
+	udpFile, err := udp.(*net.UDPConn).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udpFile.Close()
+	tcpFile, err := tcp.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tcpFile.Close()
+
+	// Unnamed sockets, or those with the unit's default name, go by type
+	sockets := &Sockets{}
+	if err := sockets.add(udpFile, ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sockets.add(tcpFile, "sinkzone.socket"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sockets.Close()
+	if sockets.DNS == nil || sockets.DNS.LocalAddr().String() != udp.LocalAddr().String() {
+		t.Errorf("expected the UDP socket for DNS, got %v", sockets.DNS)
+	}
+	if sockets.API == nil || sockets.API.Addr().String() != tcp.Addr().String() {
+		t.Errorf("expected the TCP socket for the API, got %v", sockets.API)
+	}
+
+	if err := (&Sockets{}).add(tcpFile, SocketDNS); err == nil {
+		t.Error("expected a stream socket named dns to be refused")
+	}
+	if err := (&Sockets{}).add(udpFile, SocketAPI); err == nil {
+		t.Error("expected a datagram socket named api to be refused")
+	}
+	if err := sockets.add(udpFile, ""); err == nil {
+		t.Error("expected a second datagram socket to be refused")
+	}
+}
+
+func TestActivationSocketsOtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_FDS", "1")
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	sockets, err := ActivationSockets()
+	if sockets != nil || err != nil {
+		t.Errorf("expected sockets meant for another process to be ignored, got %v, %v", sockets, err)
+	}
+	if _, ok := os.LookupEnv("LISTEN_FDS"); ok {
+		t.Error("expected LISTEN_FDS to be removed")
+	}
+}
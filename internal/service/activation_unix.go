@@ -0,0 +1,56 @@
+//go:build !windows
+
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// listenFDsStart is the first file descriptor systemd passes sockets in
+const listenFDsStart = 3
+
+// ActivationSockets returns the sockets systemd passed with socket activation (LISTEN_FDS),
+// or nil when the resolver wasn't socket-activated. The variables are removed, so programs
+// the resolver starts don't take the sockets for theirs.
+func ActivationSockets() (*Sockets, error) {
+	count := os.Getenv("LISTEN_FDS")
+	if count == "" {
+		return nil, nil
+	}
+	pid, names := os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDNAMES")
+	for _, name := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		_ = os.Unsetenv(name)
+	}
+	if pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		// Meant for another process, e.g. a parent that started the resolver
+		return nil, nil
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS %q", count)
+	}
+
+	fdNames := strings.Split(names, ":")
+	sockets := &Sockets{}
+	for i := range n {
+		fd := listenFDsStart + i
+		syscall.CloseOnExec(fd)
+		name := ""
+		if i < len(fdNames) {
+			name = fdNames[i]
+		}
+		file := os.NewFile(uintptr(fd), "systemd socket "+name)
+		err := sockets.add(file, name)
+		// The net package holds a duplicate of the descriptor
+		_ = file.Close()
+		if err != nil {
+			sockets.Close()
+			return nil, err
+		}
+	}
+	return sockets, nil
+}
@@ -0,0 +1,178 @@
+// Package service installs the resolver as a system service that starts on boot: a
+// systemd unit on Linux, a launchd daemon on macOS, and a Windows service.
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+const (
+	// Name is the systemd unit and Windows service name
+	Name = "sinkzone"
+	// Label is the launchd job label
+	Label = "com.berbyte.sinkzone"
+
+	systemdUnitPath  = "/etc/systemd/system/" + Name + ".service"
+	launchdPlistPath = "/Library/LaunchDaemons/" + Label + ".plist"
+
+	displayName = "Sinkzone DNS resolver"
+	description = "Block-by-default DNS resolver for deep focus"
+)
+
+// Options describes how the service runs the resolver
+type Options struct {
+	Executable string // Absolute path of the sinkzone binary
+	Home       string // Home directory whose ~/.sinkzone config, allowlist, and state the service uses
+	Port       string // DNS port ("" for dns_listen in the config)
+	APIPort    string // HTTP API port ("" for api_listen in the config)
+	LogPath    string // Where launchd writes the resolver's output (systemd uses the journal)
+	ConfigPath string // Config file passed with --config ("" for the default)
+	DataDir    string // SINKZONE_CONFIG_DIR for the service ("" for the default)
+	LogLevel   string // Passed with --log-level ("" for the default)
+	LogFormat  string // Passed with --log-format ("" for the default)
+}
+
+// args returns the command line the service starts the resolver with
+func (o Options) args() []string {
+	args := []string{"resolver"}
+	if o.Port != "" {
+		args = append(args, "--port", o.Port)
+	}
+	if o.APIPort != "" {
+		args = append(args, "--api-port", o.APIPort)
+	}
+	if o.ConfigPath != "" {
+		args = append(args, "--config", o.ConfigPath)
+	}
+	if o.LogLevel != "" {
+		args = append(args, "--log-level", o.LogLevel)
+	}
+	if o.LogFormat != "" {
+		args = append(args, "--log-format", o.LogFormat)
+	}
+	return args
+}
+
+var systemdTemplate = template.Must(template.New("unit").Parse(`[Unit]
+Description={{.Description}}
+Documentation=https://github.com/berbyte/sinkzone
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+ExecStart={{.ExecStart}}
+Environment="HOME={{.Home}}"
+{{- if .DataDir}}
+Environment="SINKZONE_CONFIG_DIR={{.DataDir}}"
+{{- end}}
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// SystemdUnit renders the systemd unit for the resolver. It restarts only after a
+// crash, so 'sinkzone resolver stop' keeps it stopped until the next boot.
+func SystemdUnit(opts Options) (string, error) {
+	execStart := []string{systemdQuote(opts.Executable)}
+	for _, arg := range opts.args() {
+		execStart = append(execStart, systemdQuote(arg))
+	}
+
+	var b bytes.Buffer
+	err := systemdTemplate.Execute(&b, map[string]string{
+		"Description": displayName,
+		"ExecStart":   strings.Join(execStart, " "),
+		"Home":        strings.ReplaceAll(opts.Home, `"`, `\"`),
+		"DataDir":     strings.ReplaceAll(opts.DataDir, `"`, `\"`),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render systemd unit: %w", err)
+	}
+	return b.String(), nil
+}
+
+// systemdQuote quotes an ExecStart argument when it contains spaces or quotes
+func systemdQuote(arg string) string {
+	if !strings.ContainsAny(arg, " \t\"'\\") {
+		return arg
+	}
+	arg = strings.ReplaceAll(arg, `\`, `\\`)
+	return `"` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+}
+
+// launchdTemplate escapes every value, since paths may contain XML special characters
+var launchdTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"xml": xmlEscape,
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Arguments}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>HOME</key>
+		<string>{{xml .Home}}</string>
+{{- if .DataDir}}
+		<key>SINKZONE_CONFIG_DIR</key>
+		<string>{{xml .DataDir}}</string>
+{{- end}}
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>{{xml .LogPath}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .LogPath}}</string>
+</dict>
+</plist>
+`))
+
+// LaunchdPlist renders the launchd daemon definition for the resolver. Like the systemd
+// unit, it restarts only after a crash.
+func LaunchdPlist(opts Options) (string, error) {
+	var b bytes.Buffer
+	err := launchdTemplate.Execute(&b, map[string]interface{}{
+		"Label":     Label,
+		"Arguments": append([]string{opts.Executable}, opts.args()...),
+		"Home":      opts.Home,
+		"DataDir":   opts.DataDir,
+		"LogPath":   opts.LogPath,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to render launchd plist: %w", err)
+	}
+	return b.String(), nil
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;").Replace(s)
+}
+
+// run executes a service manager command, including its output in the error
+func run(name string, args ...string) error {
+	// #nosec G204 -- runs fixed service manager commands with arguments passed directly
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSystemdUnit(t *testing.T) {
+	unit, err := SystemdUnit(Options{
+		Executable: "/opt/my tools/sinkzone",
+		Home:       "/home/ada",
+		Port:       "53",
+		APIPort:    "8080",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`ExecStart="/opt/my tools/sinkzone" resolver --port 53 --api-port 8080`,
+		`Environment="HOME=/home/ada"`,
+		"Restart=on-failure",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("expected the unit to contain %q, got:\n%s", want, unit)
+		}
+	}
+}
+
+func TestSystemdUnitWithConfig(t *testing.T) {
+	unit, err := SystemdUnit(Options{
+		Executable: "/usr/local/bin/sinkzone",
+		Home:       "/home/ada",
+		Port:       "53",
+		APIPort:    "8080",
+		ConfigPath: "/etc/sinkzone/work.yaml",
+		DataDir:    "/var/lib/sinkzone",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, want := range []string{
+		`ExecStart=/usr/local/bin/sinkzone resolver --port 53 --api-port 8080 --config /etc/sinkzone/work.yaml`,
+		`Environment="SINKZONE_CONFIG_DIR=/var/lib/sinkzone"`,
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("expected the unit to contain %q, got:\n%s", want, unit)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	plist, err := LaunchdPlist(Options{
+		Executable: "/usr/local/bin/sinkzone",
+		Home:       "/Users/R&D",
+		Port:       "53",
+		APIPort:    "8080",
+		LogPath:    "/Users/R&D/.sinkzone/resolver.log",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The plist must stay well-formed when paths contain XML special characters
+	decoder := xml.NewDecoder(strings.NewReader(plist))
+	decoder.Strict = true
+	for {
+		if _, err := decoder.Token(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("expected well-formed XML, got %v:\n%s", err, plist)
+			}
+			break
+		}
+	}
+
+	for _, want := range []string{
+		"<string>" + Label + "</string>",
+		"<string>/usr/local/bin/sinkzone</string>\n\t\t<string>resolver</string>",
+		"<string>/Users/R&amp;D</string>",
+		"<key>SuccessfulExit</key>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("expected the plist to contain %q, got:\n%s", want, plist)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+//go:build !windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNotInstalled is returned when the service definition does not exist
+var ErrNotInstalled = errors.New("the sinkzone service is not installed")
+
+// Install writes the service definition, enables it at boot, and starts it
+func Install(opts Options) error {
+	path, definition, err := render(opts)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("the sinkzone service is already installed (%s). Run 'sinkzone service uninstall' first", path)
+	}
+
+	// #nosec G306 -- service definitions are world-readable, like the others in their directory
+	if err := os.WriteFile(path, []byte(definition), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		if err := run("systemctl", "daemon-reload"); err != nil {
+			return err
+		}
+		return run("systemctl", "enable", "--now", Name+".service")
+	default:
+		return run("launchctl", "load", "-w", path)
+	}
+}
+
+// Uninstall stops the service, disables it at boot, and removes its definition
+func Uninstall() error {
+	path, err := definitionPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return ErrNotInstalled
+	}
+
+	switch runtime.GOOS {
+	case "linux":
+		if err := run("systemctl", "disable", "--now", Name+".service"); err != nil {
+			return err
+		}
+	default:
+		if err := run("launchctl", "unload", "-w", path); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	if runtime.GOOS == "linux" {
+		return run("systemctl", "daemon-reload")
+	}
+	return nil
+}
+
+// Status describes the installed service as reported by the service manager
+func Status() (string, error) {
+	path, err := definitionPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", ErrNotInstalled
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "linux":
+		cmd = exec.Command("systemctl", "status", "--no-pager", Name+".service")
+	default:
+		cmd = exec.Command("launchctl", "list", Label)
+	}
+	// systemctl status exits non-zero for a stopped service, so only missing output is an error
+	output, err := cmd.CombinedOutput()
+	if len(output) == 0 && err != nil {
+		return "", fmt.Errorf("failed to query the service: %w", err)
+	}
+	return fmt.Sprintf("Installed: %s\n\n%s", path, strings.TrimSpace(string(output))), nil
+}
+
+// render returns the path and contents of the service definition for this platform
+func render(opts Options) (string, string, error) {
+	path, err := definitionPath()
+	if err != nil {
+		return "", "", err
+	}
+	var definition string
+	if runtime.GOOS == "linux" {
+		definition, err = SystemdUnit(opts)
+	} else {
+		definition, err = LaunchdPlist(opts)
+	}
+	return path, definition, err
+}
+
+// definitionPath returns where the service definition lives on this platform
+func definitionPath() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		if _, err := exec.LookPath("systemctl"); err != nil {
+			return "", fmt.Errorf("systemd is required to install the service on Linux")
+		}
+		return systemdUnitPath, nil
+	case "darwin":
+		return launchdPlistPath, nil
+	default:
+		return "", fmt.Errorf("installing a service is not supported on %s", runtime.GOOS)
+	}
+}
+
+// IsWindowsService reports whether the process was started by the Windows service manager
+func IsWindowsService() bool {
+	return false
+}
+
+// RunWindowsService runs the resolver under the Windows service manager. Elsewhere it
+// just runs the resolver.
+func RunWindowsService(runResolver func(stop <-chan struct{}) error) error {
+	return runResolver(nil)
+}
@@ -0,0 +1,233 @@
+//go:build windows
+
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// ErrNotInstalled is returned when the service does not exist
+var ErrNotInstalled = errors.New("the sinkzone service is not installed")
+
+// stopTimeout is how long Uninstall waits for the service to stop before deleting it
+const stopTimeout = 10 * time.Second
+
+// restartDelay is how long the service manager waits before restarting a failed resolver,
+// like RestartSec in the systemd unit
+const restartDelay = 5 * time.Second
+
+// Install creates an automatically starting service and starts it
+func Install(opts Options) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	if s, err := m.OpenService(Name); err == nil {
+		_ = s.Close()
+		return fmt.Errorf("the sinkzone service is already installed. Run 'sinkzone service uninstall' first")
+	}
+
+	s, err := m.CreateService(Name, opts.Executable, mgr.Config{
+		DisplayName: displayName,
+		Description: description,
+		StartType:   mgr.StartAutomatic,
+	}, opts.args()...)
+	if err != nil {
+		return fmt.Errorf("failed to create the service: %w", err)
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := setEnvironment(opts.Home, opts.DataDir); err != nil {
+		return err
+	}
+	if err := setRecovery(s); err != nil {
+		return err
+	}
+	// The event source lets log_output: syslog write to the Application log; an earlier
+	// installation may have left it registered
+	_ = eventlog.InstallAsEventCreate(Name, eventlog.Error|eventlog.Warning|eventlog.Info)
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("failed to start the service: %w", err)
+	}
+	return nil
+}
+
+// setEnvironment points the service, which runs as LocalSystem, at the installing
+// user's profile so it uses the same config, allowlist, and state
+func setEnvironment(home, dataDir string) error {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+Name, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the service registry key: %w", err)
+	}
+	defer func() { _ = key.Close() }()
+
+	environment := []string{"USERPROFILE=" + home}
+	if appData := os.Getenv("APPDATA"); appData != "" {
+		environment = append(environment, "APPDATA="+appData)
+	}
+	if dataDir != "" {
+		environment = append(environment, "SINKZONE_CONFIG_DIR="+dataDir)
+	}
+	if err := key.SetStringsValue("Environment", environment); err != nil {
+		return fmt.Errorf("failed to set the service environment: %w", err)
+	}
+	return nil
+}
+
+// setRecovery restarts the resolver after a crash or an error exit, like Restart=on-failure
+// in the systemd unit; 'sinkzone resolver stop' exits cleanly, so it stays stopped
+func setRecovery(s *mgr.Service) error {
+	actions := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+		{Type: mgr.ServiceRestart, Delay: restartDelay},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}
+	// The failure count resets after a day without failures
+	if err := s.SetRecoveryActions(actions, uint32((24 * time.Hour).Seconds())); err != nil {
+		return fmt.Errorf("failed to set the service recovery actions: %w", err)
+	}
+	if err := s.SetRecoveryActionsOnNonCrashFailures(true); err != nil {
+		return fmt.Errorf("failed to set the service recovery actions: %w", err)
+	}
+	return nil
+}
+
+// Uninstall stops and deletes the service
+func Uninstall() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(Name)
+	if err != nil {
+		return ErrNotInstalled
+	}
+	defer func() { _ = s.Close() }()
+
+	if status, err := s.Control(svc.Stop); err == nil {
+		deadline := time.Now().Add(stopTimeout)
+		for status.State != svc.Stopped && time.Now().Before(deadline) {
+			time.Sleep(300 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				break
+			}
+		}
+	}
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete the service: %w", err)
+	}
+	_ = eventlog.Remove(Name)
+	return nil
+}
+
+// Status describes the installed service as reported by the service manager
+func Status() (string, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to the service manager: %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(Name)
+	if err != nil {
+		return "", ErrNotInstalled
+	}
+	defer func() { _ = s.Close() }()
+
+	status, err := s.Query()
+	if err != nil {
+		return "", fmt.Errorf("failed to query the service: %w", err)
+	}
+	startType := "manual"
+	if config, err := s.Config(); err == nil && config.StartType == mgr.StartAutomatic {
+		startType = "automatic"
+	}
+	return fmt.Sprintf("Installed: Windows service %q\nState: %s (PID %d)\nStart: %s", Name, stateName(status.State), status.ProcessId, startType), nil
+}
+
+func stateName(state svc.State) string {
+	switch state {
+	case svc.Running:
+		return "running"
+	case svc.Stopped:
+		return "stopped"
+	case svc.StartPending:
+		return "starting"
+	case svc.StopPending:
+		return "stopping"
+	case svc.Paused:
+		return "paused"
+	}
+	return "unknown"
+}
+
+// IsWindowsService reports whether the process was started by the Windows service manager
+func IsWindowsService() bool {
+	isService, err := svc.IsWindowsService()
+	return err == nil && isService
+}
+
+// RunWindowsService runs the resolver under the Windows service manager, closing stop
+// when the service is stopped or the machine shuts down
+func RunWindowsService(runResolver func(stop <-chan struct{}) error) error {
+	h := &handler{run: runResolver}
+	if err := svc.Run(Name, h); err != nil {
+		return fmt.Errorf("failed to run as a service: %w", err)
+	}
+	return h.err
+}
+
+// handler reports the resolver's state to the service manager
+type handler struct {
+	run func(stop <-chan struct{}) error
+	err error
+}
+
+func (h *handler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- h.run(stop)
+	}()
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case h.err = <-done:
+			if h.err != nil {
+				return true, 1
+			}
+			return false, 0
+		case request := <-requests:
+			switch request.Cmd {
+			case svc.Interrogate:
+				changes <- request.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				changes <- svc.Status{State: svc.StopPending}
+				close(stop)
+				h.err = <-done
+				return false, 0
+			}
+		}
+	}
+}
+
+// ActivationSockets returns nil: socket activation is a systemd feature
+func ActivationSockets() (*Sockets, error) {
+	return nil, nil
+}
@@ -0,0 +1,242 @@
+// Package sinktest runs a resolver in-process for end-to-end tests: the DNS server and the
+// HTTP API on ephemeral loopback ports, sinkzone.yaml, the allowlist, and the state in a
+// temporary directory, and a fake upstream nameserver answering every name. Tests need
+// neither root nor ~/.sinkzone.
+package sinktest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	sinkdns "github.com/berbyte/sinkzone/internal/dns"
+)
+
+// UpstreamAddress is the address the fake upstream answers A queries with
+const UpstreamAddress = "192.0.2.10"
+
+// startTimeout bounds how long Start waits for the DNS server to serve
+const startTimeout = 10 * time.Second
+
+// Options configure a test resolver
+type Options struct {
+	// Config is saved as sinkzone.yaml and used by the resolver; its upstreams are replaced
+	// by the fake upstream unless KeepUpstreams is set. Nil means the defaults.
+	Config        *config.Config
+	KeepUpstreams bool
+
+	Allowlist []string // Lines of allowlist.txt, e.g. "github.com" or "*.example.com"
+	Blocklist []string // Lines of blocklist.txt
+}
+
+// Resolver is a resolver running in the test's process
+type Resolver struct {
+	Dir      string // Data directory ($SINKZONE_CONFIG_DIR)
+	DNSAddr  string // host:port the DNS server answers on (UDP)
+	APIURL   string // Base URL of the HTTP API
+	Client   *api.Client
+	DNS      *sinkdns.Server
+	API      *api.Server
+	Upstream *Upstream
+}
+
+// Start runs a resolver until the test ends. It sets $SINKZONE_CONFIG_DIR for the test, so
+// tests using it can't run in parallel.
+func Start(t testing.TB, opts Options) *Resolver {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.SystemConfigEnv, "none")
+
+	upstream := StartUpstream(t)
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if !opts.KeepUpstreams {
+		cfg.UpstreamNameservers = []string{upstream.Addr}
+	}
+	if err := config.Save(cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	writeLines(t, filepath.Join(dir, "allowlist.txt"), opts.Allowlist)
+	writeLines(t, filepath.Join(dir, "blocklist.txt"), opts.Blocklist)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for DNS: %v", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		_ = conn.Close()
+		t.Fatalf("failed to listen for the API: %v", err)
+	}
+
+	r := &Resolver{
+		Dir:      dir,
+		DNSAddr:  conn.LocalAddr().String(),
+		APIURL:   "http://" + listener.Addr().String(),
+		Upstream: upstream,
+	}
+	r.API = api.NewServerWithAddr(listener.Addr().String())
+	r.API.SetListener(listener)
+	historySize, historyMaxBytes, _ := cfg.RecentQueries.GetLimits()
+	r.API.SetHistoryLimits(historySize, historyMaxBytes)
+	historyAggregate, _ := cfg.RecentQueries.GetAggregate()
+	r.API.SetHistoryAggregate(historyAggregate)
+	r.DNS = sinkdns.NewServerWithAddr(cfg, r.API, r.DNSAddr)
+	r.DNS.SetPacketConn(conn)
+	r.Client = api.NewClient(r.APIURL)
+
+	if err := r.DNS.Listen(); err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := r.DNS.Start(); err != nil {
+			t.Errorf("DNS server failed: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if err := r.API.Start(); err != nil {
+			t.Errorf("API server failed: %v", err)
+		}
+	}()
+	t.Cleanup(func() {
+		if err := r.DNS.Shutdown(); err != nil {
+			t.Errorf("failed to stop the DNS server: %v", err)
+		}
+		// A spare connection the client dialed but never used counts as new,
+		// not idle, and would hold up Shutdown until it times out
+		if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+			transport.CloseIdleConnections()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.API.Shutdown(ctx); err != nil {
+			t.Errorf("failed to stop the API: %v", err)
+		}
+		wg.Wait()
+	})
+
+	deadline := time.Now().Add(startTimeout)
+	for {
+		if health, err := r.Client.GetHealth(); err == nil && health.DNSListening {
+			return r
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the resolver didn't start serving in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Query sends a query for name to the resolver and returns its answer
+func (r *Resolver) Query(t testing.TB, name string, qtype uint16) *dns.Msg {
+	t.Helper()
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	client := &dns.Client{Timeout: 5 * time.Second}
+	answer, _, err := client.Exchange(msg, r.DNSAddr)
+	if err != nil {
+		t.Fatalf("query for %s failed: %v", name, err)
+	}
+	return answer
+}
+
+// Blocked reports whether the resolver blocks name, answering its A query with NXDOMAIN
+// (the default block_response) instead of the fake upstream's answer
+func (r *Resolver) Blocked(t testing.TB, name string) bool {
+	t.Helper()
+
+	answer := r.Query(t, name, dns.TypeA)
+	switch answer.Rcode {
+	case dns.RcodeNameError:
+		return true
+	case dns.RcodeSuccess:
+		return false
+	default:
+		t.Fatalf("unexpected answer for %s: %s", name, dns.RcodeToString[answer.Rcode])
+		return false
+	}
+}
+
+// Focus starts a focus session of duration through the API
+func (r *Resolver) Focus(t testing.TB, duration time.Duration) {
+	t.Helper()
+
+	if err := r.Client.SetFocusMode(true, duration.String()); err != nil {
+		t.Fatalf("failed to start focus mode: %v", err)
+	}
+}
+
+// Upstream is a fake upstream nameserver that answers every A query with UpstreamAddress
+// and counts the queries it gets
+type Upstream struct {
+	Addr    string // host:port it answers on (UDP)
+	queries atomic.Int64
+}
+
+// StartUpstream runs a fake upstream nameserver until the test ends
+func StartUpstream(t testing.TB) *Upstream {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for the upstream: %v", err)
+	}
+	u := &Upstream{Addr: conn.LocalAddr().String()}
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        conn,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+			u.queries.Add(1)
+			answer := new(dns.Msg)
+			answer.SetReply(r)
+			if q := r.Question[0]; q.Qtype == dns.TypeA {
+				answer.Answer = append(answer.Answer, &dns.A{
+					Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: 60},
+					A:   net.ParseIP(UpstreamAddress),
+				})
+			}
+			_ = w.WriteMsg(answer)
+		}),
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return u
+}
+
+// Queries returns how many queries the upstream got
+func (u *Upstream) Queries() int {
+	return int(u.queries.Load())
+}
+
+func writeLines(t testing.TB, path string, lines []string) {
+	t.Helper()
+
+	if len(lines) == 0 {
+		return
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write %s: %v", filepath.Base(path), err)
+	}
+}
@@ -0,0 +1,321 @@
+package sinktest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestFocusBlocking(t *testing.T) {
+	r := Start(t, Options{
+		Allowlist: []string{"github.com", "*.golang.org"},
+		Blocklist: []string{"news.example.com"},
+	})
+
+	answer := r.Query(t, "example.com", dns.TypeA)
+	if answer.Rcode != dns.RcodeSuccess || len(answer.Answer) != 1 || answer.Answer[0].(*dns.A).A.String() != UpstreamAddress {
+		t.Fatalf("expected the upstream's answer outside focus mode, got %v", answer)
+	}
+
+	r.Focus(t, time.Hour)
+	for name, blocked := range map[string]bool{
+		"github.com":       false,
+		"pkg.golang.org":   false,
+		"example.com":      true,
+		"news.example.com": true,
+	} {
+		if got := r.Blocked(t, name); got != blocked {
+			t.Errorf("expected %s to be blocked: %v, got %v", name, blocked, got)
+		}
+	}
+
+	queries, err := r.Client.GetQueries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last api.DNSQuery
+	for _, query := range queries {
+		if query.Domain == "example.com" {
+			last = query
+		}
+	}
+	if !last.Blocked {
+		t.Errorf("expected the last query for example.com to be recorded as blocked, got %+v", last)
+	}
+
+	if err := r.Client.SetFocusMode(false, ""); err != nil {
+		t.Fatal(err)
+	}
+	if r.Blocked(t, "example.com") {
+		t.Error("expected example.com to resolve once focus mode ended")
+	}
+}
+
+func TestQueriesLimit(t *testing.T) {
+	r := Start(t, Options{})
+	for i := range 120 {
+		r.Query(t, fmt.Sprintf("host%d.example.com", i), dns.TypeA)
+	}
+
+	for limit, expected := range map[int]int{0: 100, 110: 110, 500: 120} {
+		queries, err := r.Client.GetQueries(limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(queries) != expected {
+			t.Errorf("expected %d queries with limit %d, got %d", expected, limit, len(queries))
+		}
+	}
+}
+
+func TestSessionHistory(t *testing.T) {
+	r := Start(t, Options{})
+	r.API.SetQueryLog(api.NewMemoryStorage())
+
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Duration: "1h", Label: "writing"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Client.SetFocusMode(false, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	sessions, err := r.Client.GetSessions(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 || sessions[0].Label != "writing" || sessions[0].End.Before(sessions[0].Start) {
+		t.Errorf("expected the ended session in the history, got %+v", sessions)
+	}
+}
+
+func TestFocusProfiles(t *testing.T) {
+	r := Start(t, Options{
+		Allowlist: []string{"github.com"},
+		Config: &config.Config{Profiles: map[string]config.Profile{
+			"writing": {Duration: "25m", Allowlist: []string{"docs.example.com"}},
+			"coding":  {Allowlist: []string{"pkg.go.dev"}, UseDefaultAllowlist: true},
+		}},
+	})
+
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Profile: "missing"}); err == nil {
+		t.Error("expected an unknown profile to be rejected")
+	}
+	if state, err := r.Client.GetFocusMode(); err != nil || state.Enabled {
+		t.Fatalf("expected focus mode to stay off after an unknown profile, got %+v (%v)", state, err)
+	}
+
+	tests := []struct {
+		profile string
+		blocked map[string]bool
+	}{
+		{"writing", map[string]bool{"docs.example.com": false, "github.com": true, "pkg.go.dev": true}},
+		{"coding", map[string]bool{"docs.example.com": true, "github.com": false, "pkg.go.dev": false}},
+	}
+	for _, test := range tests {
+		if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Profile: test.profile}); err != nil {
+			t.Fatal(err)
+		}
+		for name, blocked := range test.blocked {
+			if got := r.Blocked(t, name); got != blocked {
+				t.Errorf("%s: expected %s to be blocked: %v, got %v", test.profile, name, blocked, got)
+			}
+		}
+	}
+
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Profile: "writing"}); err != nil {
+		t.Fatal(err)
+	}
+	state, err := r.Client.GetFocusMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Profile != "writing" || state.EndTime == nil || time.Until(*state.EndTime).Round(time.Minute) != 25*time.Minute {
+		t.Errorf("expected a writing session of the profile's 25m, got %+v", state)
+	}
+}
+
+func TestFocusGracePeriod(t *testing.T) {
+	r := Start(t, Options{
+		Allowlist: []string{"github.com"},
+		Config:    &config.Config{FocusGracePeriod: "300ms"},
+	})
+	r.Focus(t, time.Hour)
+
+	if r.Blocked(t, "example.com") {
+		t.Error("expected example.com to resolve during the grace period")
+	}
+	state, err := r.Client.GetFocusMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.GraceUntil == nil || state.WouldBlock != 1 || state.Blocked != 0 {
+		t.Fatalf("expected one would-block query during the grace period, got %+v", state)
+	}
+
+	time.Sleep(time.Until(*state.GraceUntil) + 50*time.Millisecond)
+	if !r.Blocked(t, "example.com") {
+		t.Error("expected example.com to be blocked once the grace period ended")
+	}
+}
+
+func TestFocusOnStart(t *testing.T) {
+	tests := []struct {
+		value   string
+		enabled bool
+		ends    time.Duration
+	}{
+		{"45m", true, 45 * time.Minute},
+		{config.FocusOnStartIndefinite, true, 0},
+		{config.FocusOnStartResume, false, 0},
+	}
+
+	for _, test := range tests {
+		r := Start(t, Options{Config: &config.Config{FocusOnStart: test.value}})
+		state, err := r.Client.GetFocusMode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if state.Enabled != test.enabled {
+			t.Errorf("%s: expected focus mode %v at start, got %+v", test.value, test.enabled, state)
+		}
+		if test.ends == 0 && state.EndTime != nil {
+			t.Errorf("%s: expected no end time, got %v", test.value, state.EndTime)
+		}
+		if test.ends > 0 && (state.EndTime == nil || time.Until(*state.EndTime).Round(time.Minute) != test.ends) {
+			t.Errorf("%s: expected the session to end in %v, got %v", test.value, test.ends, state.EndTime)
+		}
+	}
+}
+
+func TestQueryDetails(t *testing.T) {
+	r := Start(t, Options{
+		Allowlist: []string{"github.com"},
+		Blocklist: []string{"ads.example.com"},
+	})
+	r.Focus(t, time.Hour)
+
+	r.Query(t, "github.com", dns.TypeAAAA)
+	r.Query(t, "example.com", dns.TypeA)
+	r.Query(t, "ads.example.com", dns.TypeA)
+
+	queries, err := r.Client.GetQueries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	details := make(map[string]api.DNSQuery)
+	for _, query := range queries {
+		details[query.Domain] = query
+	}
+
+	tests := []struct {
+		domain    string
+		queryType string
+		rcode     string
+		upstream  bool
+		reason    string
+	}{
+		{"github.com", "AAAA", "NOERROR", true, ""},
+		{"example.com", "A", "NXDOMAIN", false, "not on the allowlist"},
+		{"ads.example.com", "A", "NXDOMAIN", false, "on the blocklist"},
+	}
+	for _, test := range tests {
+		query := details[test.domain]
+		if query.QueryType != test.queryType || query.Rcode != test.rcode || query.Reason != test.reason {
+			t.Errorf("%s: expected %s %s (%q), got %s %s (%q)", test.domain, test.queryType, test.rcode, test.reason, query.QueryType, query.Rcode, query.Reason)
+		}
+		if (query.Upstream == r.Upstream.Addr) != test.upstream {
+			t.Errorf("%s: expected the answer from the upstream: %v, got %q", test.domain, test.upstream, query.Upstream)
+		}
+		if query.LatencyMS <= 0 {
+			t.Errorf("%s: expected the latency to be recorded, got %v", test.domain, query.LatencyMS)
+		}
+	}
+}
+
+func TestFocusPIN(t *testing.T) {
+	hash, err := config.HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := Start(t, Options{Config: &config.Config{FocusPINHash: hash}})
+	r.Focus(t, time.Hour)
+
+	forbidden := func(name string, err error) {
+		t.Helper()
+		if err == nil || !strings.Contains(err.Error(), "403") {
+			t.Errorf("%s: expected 403, got %v", name, err)
+		}
+	}
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Duration: "2h"}); err != nil {
+		t.Errorf("expected lengthening the session not to need the PIN, got %v", err)
+	}
+	forbidden("shorten", r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Duration: "10m"}))
+	forbidden("pause", r.Client.PauseFocusMode("5m", ""))
+	forbidden("wrong PIN", r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: false, PIN: "9999"}))
+
+	state, err := r.Client.GetFocusMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.Enabled || state.Paused || state.Violations != 3 {
+		t.Errorf("expected the session to go on with 3 violations, got %+v", state)
+	}
+
+	if err := r.Client.PauseFocusMode("5m", "1234"); err != nil {
+		t.Errorf("expected the correct PIN to pause, got %v", err)
+	}
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: false, PIN: "1234"}); err != nil {
+		t.Fatalf("expected the correct PIN to stop the session, got %v", err)
+	}
+	if state, err := r.Client.GetFocusMode(); err != nil || state.Enabled {
+		t.Errorf("expected focus mode to be off, got %+v (%v)", state, err)
+	}
+}
+
+func TestFocusDryRun(t *testing.T) {
+	r := Start(t, Options{Allowlist: []string{"github.com"}})
+	if err := r.Client.SetFocusModeWithOptions(api.FocusRequest{Enabled: true, Duration: "1h", DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.Blocked(t, "example.com") {
+		t.Error("expected example.com to resolve during a dry run")
+	}
+	queries, err := r.Client.GetQueries(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last api.DNSQuery
+	for _, query := range queries {
+		if query.Domain == "example.com" {
+			last = query
+		}
+	}
+	if last.Blocked || !last.WouldBlock || !strings.Contains(last.Reason, "dry run") {
+		t.Errorf("expected example.com to be recorded as would-block, got %+v", last)
+	}
+	state, err := r.Client.GetFocusMode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !state.DryRun || state.WouldBlock != 1 || state.Blocked != 0 {
+		t.Errorf("expected one would-block query in the dry run, got %+v", state)
+	}
+
+	if err := r.Client.SetFocusMode(false, ""); err != nil {
+		t.Fatal(err)
+	}
+	r.Focus(t, time.Hour)
+	if !r.Blocked(t, "example.com") {
+		t.Error("expected example.com to be blocked in a normal session after a dry run")
+	}
+	if state, err := r.Client.GetFocusMode(); err != nil || state.DryRun {
+		t.Errorf("expected the normal session not to be a dry run, got %+v (%v)", state, err)
+	}
+}
@@ -0,0 +1,126 @@
+package subscription
+
+import (
+	"log"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+const (
+	// checkInterval is how often the refresher looks for lists due for a download
+	checkInterval = 15 * time.Minute
+	// maxJitter bounds the random delay added to each list's refresh
+	maxJitter = 2 * time.Hour
+)
+
+// Refresher downloads the enabled subscriptions of a running resolver again once their cache
+// is older than subscription_refresh, and reloads the resolver's lists when any changed.
+// Each list waits a random extra tenth of the interval (at most maxJitter), drawn again after
+// every attempt, so lists and machines don't all ask the servers at the same time. A list that
+// fails to download is retried with a growing delay (see Status.RetryAt).
+type Refresher struct {
+	refresh time.Duration
+	reload  func() error
+
+	mu     sync.Mutex
+	cfg    *config.Config
+	jitter map[string]time.Duration // Extra wait of a list, by cache path
+}
+
+// NewRefresher creates a refresher for the subscriptions of cfg; reload rereads the lists
+func NewRefresher(cfg *config.Config, reload func() error) (*Refresher, error) {
+	refresh, err := cfg.GetSubscriptionRefresh()
+	if err != nil {
+		return nil, err
+	}
+	return &Refresher{
+		refresh: refresh,
+		reload:  reload,
+		cfg:     cfg,
+		jitter:  make(map[string]time.Duration),
+	}, nil
+}
+
+// SetConfig switches to the subscriptions of a changed config
+func (r *Refresher) SetConfig(cfg *config.Config) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cfg = cfg
+}
+
+// Run refreshes the subscriptions until the stop channel is closed. The first check waits
+// checkInterval, so downloads don't race the resolver that may answer their own lookups.
+func (r *Refresher) Run(stop <-chan struct{}) {
+	log.Printf("Subscription refresher started (refresh every %s)", r.refresh)
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.check(time.Now())
+		}
+	}
+}
+
+// check downloads the lists that are due and reloads the resolver's lists if any changed
+func (r *Refresher) check(now time.Time) {
+	r.mu.Lock()
+	cfg := r.cfg
+	r.mu.Unlock()
+
+	changed := false
+	for _, list := range Lists {
+		for _, subscription := range *list.Subscriptions(cfg) {
+			if subscription.Disabled || !r.due(list, subscription.URL, now) {
+				continue
+			}
+			result, err := list.Refresh(subscription.URL)
+			delete(r.jitter, list.CachePath(subscription.URL))
+			if err != nil {
+				// The previous download stays in use
+				status, _ := list.Status(subscription.URL)
+				log.Printf("Warning: failed to refresh subscribed %s %s (retrying after %s): %v", list, subscription.URL,
+					status.RetryAt().Format("15:04"), err)
+				continue
+			}
+			if result.Changed {
+				log.Printf("Subscribed %s %s refreshed: %d domains (%d added, %d removed)", list, subscription.URL,
+					result.Domains, result.Added, result.Removed)
+				changed = true
+			}
+		}
+	}
+
+	if changed {
+		if err := r.reload(); err != nil {
+			log.Printf("Warning: failed to reload refreshed lists: %v", err)
+		}
+	}
+}
+
+// due reports whether a list's cache is older than the refresh interval and its jitter, or,
+// after a failed download, whether its retry is due
+func (r *Refresher) due(list List, url string, now time.Time) bool {
+	if status, err := list.Status(url); err == nil && status.Failures > 0 {
+		return !now.Before(status.RetryAt())
+	}
+	path := list.CachePath(url)
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	jitter, ok := r.jitter[path]
+	if !ok {
+		jitter = time.Duration(rand.Int64N(int64(min(r.refresh/10, maxJitter)) + 1)) // #nosec G404 -- load spreading, not security
+		r.jitter[path] = jitter
+	}
+	return now.Sub(info.ModTime()) >= r.refresh+jitter
+}
@@ -0,0 +1,122 @@
+package subscription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// minRetryInterval is how long a list waits after its first failed download, doubling
+	// with each failure in a row up to maxRetryInterval
+	minRetryInterval = 15 * time.Minute
+	maxRetryInterval = 24 * time.Hour
+)
+
+// Status records the updates of a subscribed list, for 'sinkzone lists status'. It is
+// kept next to the cached list, so the resolver and the CLI never overwrite each other's.
+type Status struct {
+	Checked   time.Time `json:"checked"`              // Last download, or check that found the list unchanged
+	Updated   time.Time `json:"updated"`              // Last download that changed the domains
+	Domains   int       `json:"domains"`              // Domains in the last download
+	Added     int       `json:"added"`                // Domains the last change added
+	Removed   int       `json:"removed"`              // Domains the last change removed
+	Attempted time.Time `json:"attempted"`            // Last attempt, successful or not
+	Failures  int       `json:"failures,omitempty"`   // Failed attempts in a row
+	LastError string    `json:"last_error,omitempty"` // Why the last attempt failed
+}
+
+// RetryAt returns when a list that failed to download is tried again: minRetryInterval
+// after the first failure, twice as long after each further one, at most maxRetryInterval.
+// It is the zero time when the last attempt succeeded.
+func (s Status) RetryAt() time.Time {
+	if s.Failures == 0 {
+		return time.Time{}
+	}
+	wait := minRetryInterval
+	for i := 1; i < s.Failures && wait < maxRetryInterval; i++ {
+		wait *= 2
+	}
+	return s.Attempted.Add(min(wait, maxRetryInterval))
+}
+
+// statusPath returns where the status of a subscribed list is kept
+func (l List) statusPath(url string) string {
+	return strings.TrimSuffix(l.CachePath(url), ".txt") + ".json"
+}
+
+// Status returns the recorded updates of a subscribed list; a list never tried has none
+func (l List) Status(url string) (Status, error) {
+	var status Status
+	// #nosec G304 -- the path is in the cache directory
+	data, err := os.ReadFile(l.statusPath(url))
+	if os.IsNotExist(err) {
+		return status, nil
+	}
+	if err != nil {
+		return status, fmt.Errorf("failed to read list status: %w", err)
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		return status, fmt.Errorf("failed to parse list status: %w", err)
+	}
+	return status, nil
+}
+
+// saveStatus records the updates of a subscribed list
+func (l List) saveStatus(url string, status Status) error {
+	data, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal list status: %w", err)
+	}
+	path := l.statusPath(url)
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create list cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write list status: %w", err)
+	}
+	return nil
+}
+
+// record notes the outcome of an attempt to refresh a list in its status
+func (l List) record(url string, result Result, refreshErr error, now time.Time) error {
+	status, err := l.Status(url)
+	if err != nil {
+		// Start over rather than keep a broken file
+		status = Status{}
+	}
+	status.Attempted = now
+	if refreshErr != nil {
+		status.Failures++
+		status.LastError = refreshErr.Error()
+		return l.saveStatus(url, status)
+	}
+	status.Failures, status.LastError = 0, ""
+	status.Checked = now
+	status.Domains = result.Domains
+	if result.Changed {
+		status.Updated = now
+		status.Added, status.Removed = result.Added, result.Removed
+	}
+	return l.saveStatus(url, status)
+}
+
+// diff counts the domains of next that aren't in previous, and those of previous that
+// aren't in next
+func diff(previous, next []string) (added, removed int) {
+	seen := make(map[string]bool, len(previous))
+	for _, domain := range previous {
+		seen[domain] = true
+	}
+	for _, domain := range next {
+		if seen[domain] {
+			delete(seen, domain)
+		} else {
+			added++
+		}
+	}
+	return added, len(seen)
+}
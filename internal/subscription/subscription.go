@@ -0,0 +1,244 @@
+// Package subscription follows hosted domain lists, such as community allowlists and
+// blocklists: it downloads them into a cache the resolver reads, asks the server for a list
+// only when it changed since the last download, and keeps them fresh in a running resolver.
+package subscription
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/blocklist"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// List is the list a subscription adds its domains to
+type List string
+
+const (
+	Allowlist List = "allowlist"
+	Blocklist List = "blocklist"
+)
+
+// Lists are the lists that take subscriptions
+var Lists = []List{Allowlist, Blocklist}
+
+// maxListSize bounds a downloaded list; the largest public lists are a few MB
+const maxListSize = 50 * 1024 * 1024
+
+var httpClient = &http.Client{Timeout: time.Minute}
+
+// errNotModified is returned by fetch when the list hasn't changed since the cached download
+var errNotModified = errors.New("list not modified")
+
+// Result describes a refreshed subscription
+type Result struct {
+	Domains int
+	Changed bool // False when the server reported the list unchanged since the last download
+	Added   int  // Domains not in the previous download
+	Removed int  // Domains of the previous download that are gone
+}
+
+// validators identify a downloaded version of a list, so it is only downloaded again once
+// it changed
+type validators struct {
+	etag         string
+	lastModified string
+}
+
+// Subscriptions returns the subscriptions of the list in cfg, for changing them in place
+func (l List) Subscriptions(cfg *config.Config) *[]config.Subscription {
+	if l == Allowlist {
+		return &cfg.AllowlistSubscriptions
+	}
+	return &cfg.BlocklistSubscriptions
+}
+
+// CacheDir returns the directory downloaded subscriptions of the list are kept in, e.g.
+// ~/.sinkzone/blocklists
+func (l List) CacheDir() string {
+	return filepath.Join(config.GetDataDir(), string(l)+"s")
+}
+
+// CachePath returns where the domains of a subscribed list are cached
+func (l List) CachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(l.CacheDir(), hex.EncodeToString(sum[:8])+".txt")
+}
+
+// CachedLists returns the cache files of the enabled subscriptions, which the resolver loads
+// along with the local list
+func (l List) CachedLists(subscriptions []config.Subscription) []string {
+	var paths []string
+	for _, subscription := range subscriptions {
+		if !subscription.Disabled {
+			paths = append(paths, l.CachePath(subscription.URL))
+		}
+	}
+	return paths
+}
+
+// Refresh downloads a subscribed list and replaces its cache. A list the server reports
+// unchanged keeps its cache. A list without any domains is rejected, since it is most likely
+// not a domain list. The outcome is recorded in the list's Status.
+func (l List) Refresh(url string) (Result, error) {
+	result, err := l.refresh(url)
+	if recordErr := l.record(url, result, err, time.Now()); recordErr != nil {
+		log.Printf("Warning: %v", recordErr)
+	}
+	return result, err
+}
+
+// refresh downloads a list for Refresh, counting the domains it added and removed
+func (l List) refresh(url string) (Result, error) {
+	path := l.CachePath(url)
+	domains, next, err := fetch(url, readValidators(path))
+	if errors.Is(err, errNotModified) {
+		now := time.Now()
+		if err := os.Chtimes(path, now, now); err != nil {
+			return Result{}, fmt.Errorf("failed to mark cached list as checked: %w", err)
+		}
+		count, _, err := l.Cached(url)
+		return Result{Domains: count}, err
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	if len(domains) == 0 {
+		return Result{}, fmt.Errorf("no domains found in %s", url)
+	}
+
+	header := fmt.Sprintf("# %s\n# Downloaded %s\n", url, time.Now().Format(time.RFC3339))
+	if next.etag != "" {
+		header += "# ETag: " + next.etag + "\n"
+	}
+	if next.lastModified != "" {
+		header += "# Last-Modified: " + next.lastModified + "\n"
+	}
+	manager := allowlist.NewListManager(path, string(l)+" cache")
+	previous, _ := manager.List() // Every domain is added when there is no previous download
+	if err := manager.Save(header + strings.Join(domains, "\n") + "\n"); err != nil {
+		return Result{}, err
+	}
+	added, removed := diff(previous, domains)
+	return Result{Domains: len(domains), Changed: true, Added: added, Removed: removed}, nil
+}
+
+// Cached returns the number of domains cached for a subscribed list and when it was last
+// downloaded or found unchanged, or an error if it hasn't been downloaded
+func (l List) Cached(url string) (int, time.Time, error) {
+	path := l.CachePath(url)
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("list not downloaded: %w", err)
+	}
+	domains, err := allowlist.NewListManager(path, string(l)+" cache").List()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return len(domains), info.ModTime(), nil
+}
+
+// RemoveCache deletes the cached domains and status of a list that is no longer subscribed
+func (l List) RemoveCache(url string) error {
+	if err := os.Remove(l.CachePath(url)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cached list: %w", err)
+	}
+	if err := os.Remove(l.statusPath(url)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove list status: %w", err)
+	}
+	return nil
+}
+
+// fetch downloads a list from an http(s) URL, or reads it from a local path. A download
+// with the validators of the cached copy fails with errNotModified if the list is unchanged.
+func fetch(url string, cached validators) ([]string, validators, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		// #nosec G304 -- the list path is chosen by the user subscribing to it
+		file, err := os.Open(strings.TrimPrefix(url, "file://"))
+		if err != nil {
+			return nil, validators{}, fmt.Errorf("failed to open list: %w", err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Printf("Warning: failed to close list file: %v", err)
+			}
+		}()
+		domains, _, err := blocklist.Parse(io.LimitReader(file, maxListSize))
+		return domains, validators{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, validators{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+	if cached.lastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.lastModified)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, validators{}, fmt.Errorf("failed to download list: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			log.Printf("Warning: failed to close list response: %v", err)
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, validators{}, errNotModified
+	default:
+		return nil, validators{}, fmt.Errorf("failed to download list: unexpected status code: %d", resp.StatusCode)
+	}
+
+	domains, _, err := blocklist.Parse(io.LimitReader(resp.Body, maxListSize))
+	if err != nil {
+		return nil, validators{}, err
+	}
+	return domains, validators{etag: resp.Header.Get("ETag"), lastModified: resp.Header.Get("Last-Modified")}, nil
+}
+
+// readValidators reads the validators Refresh recorded in the header of a cached list
+func readValidators(path string) validators {
+	// #nosec G304 -- the path is in the cache directory
+	file, err := os.Open(path)
+	if err != nil {
+		return validators{}
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Printf("Warning: failed to close cached list: %v", err)
+		}
+	}()
+
+	var cached validators
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		if etag, ok := strings.CutPrefix(line, "# ETag: "); ok {
+			cached.etag = etag
+		} else if lastModified, ok := strings.CutPrefix(line, "# Last-Modified: "); ok {
+			cached.lastModified = lastModified
+		}
+	}
+	return cached
+}
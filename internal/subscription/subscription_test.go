@@ -0,0 +1,163 @@
+package subscription
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestRefreshLocalFile(t *testing.T) {
+	t.Setenv("SINKZONE_CONFIG_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "hosts")
+	if err := os.WriteFile(path, []byte("0.0.0.0 ads.example.com\n0.0.0.0 tracker.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Blocklist.Refresh(path)
+	if err != nil || result.Domains != 2 || !result.Changed {
+		t.Fatalf("expected 2 domains, got %+v (%v)", result, err)
+	}
+	if cached, _, err := Blocklist.Cached(path); err != nil || cached != 2 {
+		t.Errorf("expected 2 cached domains, got %d (%v)", cached, err)
+	}
+	if _, _, err := Allowlist.Cached(path); err == nil {
+		t.Error("expected the allowlist to keep its own cache")
+	}
+	subscriptions := []config.Subscription{{URL: path}, {URL: "https://example.com/off.txt", Disabled: true}}
+	if lists := Blocklist.CachedLists(subscriptions); len(lists) != 1 || lists[0] != Blocklist.CachePath(path) {
+		t.Errorf("expected only the enabled list, got %v", lists)
+	}
+
+	if err := Blocklist.RemoveCache(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Blocklist.Cached(path); err == nil {
+		t.Error("expected the cache to be removed")
+	}
+}
+
+func TestRefreshSendsValidators(t *testing.T) {
+	t.Setenv("SINKZONE_CONFIG_DIR", t.TempDir())
+
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("docs.example.com\nexample.org\n"))
+	}))
+	defer server.Close()
+
+	result, err := Allowlist.Refresh(server.URL)
+	if err != nil || result.Domains != 2 || !result.Changed {
+		t.Fatalf("expected the list downloaded, got %+v (%v)", result, err)
+	}
+
+	// Backdate the cache to see the unchanged list marked as checked
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(Allowlist.CachePath(server.URL), old, old); err != nil {
+		t.Fatal(err)
+	}
+	result, err = Allowlist.Refresh(server.URL)
+	if err != nil || result.Domains != 2 || result.Changed || downloads != 1 {
+		t.Fatalf("expected the unchanged list kept, got %+v (%v) after %d downloads", result, err, downloads)
+	}
+	if _, checked, _ := Allowlist.Cached(server.URL); checked.Before(time.Now().Add(-time.Minute)) {
+		t.Errorf("expected the cache marked as checked, got %s", checked)
+	}
+}
+
+func TestRefresherReloadsChangedLists(t *testing.T) {
+	t.Setenv("SINKZONE_CONFIG_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(path, []byte("ads.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{
+		BlocklistSubscriptions: []config.Subscription{{URL: path}, {URL: filepath.Join(t.TempDir(), "missing.txt")}},
+		AllowlistSubscriptions: []config.Subscription{{URL: path, Disabled: true}},
+	}
+
+	reloads := 0
+	refresher, err := NewRefresher(cfg, func() error { reloads++; return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	refresher.check(now)
+	if reloads != 1 {
+		t.Fatalf("expected one reload after the first download, got %d", reloads)
+	}
+	if _, _, err := Allowlist.Cached(path); err == nil {
+		t.Error("expected the disabled subscription left alone")
+	}
+	missing := cfg.BlocklistSubscriptions[1].URL
+	if status, _ := Blocklist.Status(missing); status.Failures != 1 || refresher.due(Blocklist, missing, now) {
+		t.Errorf("expected the missing list to wait for a retry, got %+v", status)
+	}
+
+	// Nothing is due until the refresh interval passes
+	refresher.check(now.Add(time.Hour))
+	if reloads != 1 {
+		t.Errorf("expected no reload before the refresh interval, got %d", reloads)
+	}
+}
+
+func TestRefreshRecordsChanges(t *testing.T) {
+	t.Setenv("SINKZONE_CONFIG_DIR", t.TempDir())
+
+	path := filepath.Join(t.TempDir(), "list.txt")
+	write := func(content string) {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.example.com\nb.example.com\n")
+	if _, err := Blocklist.Refresh(path); err != nil {
+		t.Fatal(err)
+	}
+	write("b.example.com\nc.example.com\nd.example.com\n")
+	result, err := Blocklist.Refresh(path)
+	if err != nil || result.Added != 2 || result.Removed != 1 {
+		t.Fatalf("expected 2 domains added and 1 removed, got %+v (%v)", result, err)
+	}
+	status, err := Blocklist.Status(path)
+	if err != nil || status.Domains != 3 || status.Added != 2 || status.Removed != 1 || status.Updated.IsZero() {
+		t.Fatalf("expected the change recorded, got %+v (%v)", status, err)
+	}
+
+	// A failed download keeps the recorded change and counts the failures in a row
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	for range 3 {
+		if _, err := Blocklist.Refresh(path); err == nil {
+			t.Fatal("expected the missing list to fail")
+		}
+	}
+	status, _ = Blocklist.Status(path)
+	if status.Failures != 3 || status.LastError == "" || status.Added != 2 {
+		t.Errorf("expected 3 failures after the change, got %+v", status)
+	}
+	if wait := status.RetryAt().Sub(status.Attempted); wait != 4*minRetryInterval {
+		t.Errorf("expected the retry to wait %s after 3 failures, got %s", 4*minRetryInterval, wait)
+	}
+
+	if err := Blocklist.RemoveCache(path); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := Blocklist.Status(path); status.Failures != 0 {
+		t.Errorf("expected the status removed with the cache, got %+v", status)
+	}
+}
@@ -0,0 +1,140 @@
+package sysdns
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// dispatcherPath is the NetworkManager dispatcher hook installed by InstallDispatcher
+const dispatcherPath = "/etc/NetworkManager/dispatcher.d/90-sinkzone"
+
+// dispatcherScript renders the hook, which runs 'sinkzone setup --dispatch' for the device
+// whenever a connection comes up or gets new DHCP settings
+func dispatcherScript(executable, dataDir string) string {
+	return "#!/bin/sh\n" + header +
+		"# Points connections that come up at the local resolver, so a new network doesn't bypass it\n" +
+		"case \"$2\" in\nup|dhcp4-change|dhcp6-change) ;;\n*) exit 0 ;;\nesac\n" +
+		"SINKZONE_CONFIG_DIR=" + shellQuote(dataDir) + " exec " + shellQuote(executable) + " setup --dispatch \"$1\"\n"
+}
+
+// InstallDispatcher installs a NetworkManager dispatcher hook that runs executable to point
+// new connections at the local resolver, using the backup in dataDir
+func InstallDispatcher(executable, dataDir string) error {
+	dir := filepath.Dir(dispatcherPath)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("NetworkManager's dispatcher directory %s was not found; is NetworkManager installed?", dir)
+	}
+	// NetworkManager only runs hooks that are owned by root and not writable by others
+	// #nosec G306 -- the hook must be executable
+	if err := os.WriteFile(dispatcherPath, []byte(dispatcherScript(executable, dataDir)), 0755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dispatcherPath, err)
+	}
+	return nil
+}
+
+// RemoveDispatcher removes the hook installed by InstallDispatcher and reports whether
+// there was one
+func RemoveDispatcher() (bool, error) {
+	err := os.Remove(dispatcherPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to remove %s: %w", dispatcherPath, err)
+	}
+	return true, nil
+}
+
+// Repoint points a device or connection that came up after Apply at the local resolver,
+// adding its previous settings to the backup so Restore puts them back. It returns the
+// interface it changed, or nil when there was nothing to do.
+func Repoint(backupPath, device, connection string) (*Interface, error) {
+	backup, err := loadBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch backup.Method {
+	case MethodResolved:
+		// The network manager sets a link's servers again whenever it comes up
+		if device == "" || device == "lo" || backup.ResolvConfLink != "" {
+			return nil, nil
+		}
+		if backup.find(device) == nil {
+			iface, err := captureResolvedLink(device)
+			if err != nil {
+				return nil, err
+			}
+			backup.Interfaces = append(backup.Interfaces, iface)
+			if err := saveBackup(backupPath, backup); err != nil {
+				return nil, err
+			}
+		}
+		if err := run("resolvectl", "dns", device, LocalResolver); err != nil {
+			return nil, err
+		}
+		if err := run("resolvectl", "domain", device, "~."); err != nil {
+			return nil, err
+		}
+		return backup.find(device), nil
+	case MethodNetworkManager:
+		// Connections changed by Apply keep ignoring the servers from DHCP
+		if connection == "" || backup.find(connection) != nil {
+			return nil, nil
+		}
+		settings, err := output("nmcli", "-g", "ipv4.dns,ipv4.ignore-auto-dns", "connection", "show", connection)
+		if err != nil {
+			return nil, err
+		}
+		iface := parseNMSettings(connection, settings)
+		backup.Interfaces = append(backup.Interfaces, iface)
+		if err := saveBackup(backupPath, backup); err != nil {
+			return nil, err
+		}
+		if err := run("nmcli", "connection", "modify", connection, "ipv4.dns", LocalResolver, "ipv4.ignore-auto-dns", "yes"); err != nil {
+			return nil, err
+		}
+		// Reapplying doesn't bring the connection up again, so the hook isn't run twice
+		if device != "" {
+			if err := run("nmcli", "device", "reapply", device); err != nil {
+				return nil, err
+			}
+		}
+		return &iface, nil
+	}
+	return nil, nil
+}
+
+// find returns the saved interface with the given name, or nil
+func (b *Backup) find(name string) *Interface {
+	for i := range b.Interfaces {
+		if b.Interfaces[i].Name == name {
+			return &b.Interfaces[i]
+		}
+	}
+	return nil
+}
+
+// captureResolvedLink reads the servers and domains systemd-resolved has for one link
+func captureResolvedLink(name string) (Interface, error) {
+	iface := Interface{Name: name}
+	dns, err := output("resolvectl", "dns", name)
+	if err != nil {
+		return iface, err
+	}
+	domains, err := output("resolvectl", "domain", name)
+	if err != nil {
+		return iface, err
+	}
+	_, servers := parseResolvectl(dns)
+	_, linkDomains := parseResolvectl(domains)
+	iface.Servers, iface.Domains = servers[name], linkDomains[name]
+	return iface, nil
+}
+
+// shellQuote quotes a string for sh
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
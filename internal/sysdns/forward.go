@@ -0,0 +1,103 @@
+package sysdns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// forwardDropInPath makes systemd-resolved forward every query to the resolver on its
+// fallback port
+const forwardDropInPath = "/etc/systemd/resolved.conf.d/sinkzone-forward.conf"
+
+// ErrForwardUnsupported is returned by Forward when the system can't be made to use a
+// resolver on a port other than 53
+var ErrForwardUnsupported = errors.New("the system DNS can't forward to another port here")
+
+// forwardDropIn returns the systemd-resolved drop-in forwarding to addr
+func forwardDropIn(addr string) string {
+	return "# Written by the sinkzone resolver, which listens on dns_fallback_port while port 53 is taken;\n" +
+		"# removed when the resolver gets port 53 again or by 'sinkzone setup --undo'\n" +
+		"[Resolve]\nDNS=" + addr + "\nDomains=~.\n"
+}
+
+// Forward makes the system DNS use the resolver at addr, e.g. 127.0.0.1:5354, when it can't
+// listen on port 53: systemd-resolved is told to forward every query to it. It returns
+// ErrForwardUnsupported without systemd-resolved, and does nothing if already forwarding.
+func Forward(addr string) error {
+	if !resolvedManagesDNS() {
+		return ErrForwardUnsupported
+	}
+	content := forwardDropIn(addr)
+	// #nosec G304 -- fixed path of the drop-in
+	if current, err := os.ReadFile(forwardDropInPath); err == nil && string(current) == content {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(forwardDropInPath), 0755); err != nil { // #nosec G301 -- system configuration directory
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(forwardDropInPath), err)
+	}
+	// #nosec G306 -- systemd-resolved drop-ins are world-readable, like the others in their directory
+	if err := os.WriteFile(forwardDropInPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", forwardDropInPath, err)
+	}
+	if err := run("systemctl", "restart", "systemd-resolved"); err != nil {
+		return err
+	}
+	flushCache()
+	return nil
+}
+
+// RemoveForward undoes Forward and reports whether it had been done
+func RemoveForward() (bool, error) {
+	if _, err := os.Stat(forwardDropInPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := os.Remove(forwardDropInPath); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %w", forwardDropInPath, err)
+	}
+	if err := run("systemctl", "restart", "systemd-resolved"); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ForwardPort returns the port the system DNS forwards to since Forward, or "" when it
+// doesn't
+func ForwardPort() string {
+	// #nosec G304 -- fixed path of the drop-in
+	data, err := os.ReadFile(forwardDropInPath)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if addr, ok := strings.CutPrefix(line, "DNS="); ok {
+			if _, port, err := net.SplitHostPort(addr); err == nil {
+				return port
+			}
+		}
+	}
+	return ""
+}
+
+// ForwardAddr returns the address the system DNS should forward to for a resolver
+// listening on addr: its own host, or 127.0.0.1 when it listens on every address
+func ForwardAddr(addr string) string {
+	host, port, _ := net.SplitHostPort(addr)
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = LocalResolver
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// resolvedManagesDNS reports whether systemd-resolved answers the system's queries
+func resolvedManagesDNS() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	target, err := os.Readlink(resolvConfPath)
+	return err == nil && strings.Contains(target, "systemd/resolve")
+}
@@ -0,0 +1,84 @@
+package sysdns
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// DefaultGateway returns the IPv4 address of the default gateway, the router that usually
+// runs the network's nameserver, or "" when there is no default route
+func DefaultGateway() (string, error) {
+	switch runtime.GOOS {
+	case "linux":
+		data, err := os.ReadFile("/proc/net/route")
+		if err != nil {
+			return "", fmt.Errorf("failed to read the routing table: %w", err)
+		}
+		return parseProcRoute(string(data)), nil
+	case "windows":
+		out, err := output("route", "print", "-4", "0.0.0.0")
+		if err != nil {
+			return "", err
+		}
+		return parseWindowsRoute(out), nil
+	default:
+		out, err := output("route", "-n", "get", "default")
+		if err != nil {
+			return "", err
+		}
+		return parseRouteGet(out), nil
+	}
+}
+
+// parseProcRoute returns the gateway of the default route in /proc/net/route, whose
+// addresses are little-endian hex, e.g. "wlan0 00000000 0101A8C0 0003 ..."
+func parseProcRoute(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" || fields[2] == "00000000" {
+			continue
+		}
+		raw, err := hex.DecodeString(fields[2])
+		if err != nil || len(raw) != 4 {
+			continue
+		}
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		return ip.String()
+	}
+	return ""
+}
+
+// parseRouteGet returns the gateway listed by route -n get default on macOS and the BSDs,
+// e.g. "    gateway: 192.168.1.1"
+func parseRouteGet(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if ok && key == "gateway" {
+			if ip := net.ParseIP(strings.TrimSpace(value)); ip != nil && ip.To4() != nil {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
+// parseWindowsRoute returns the gateway of the default route listed by route print, e.g.
+// "          0.0.0.0          0.0.0.0      192.168.1.1    192.168.1.23     25"
+func parseWindowsRoute(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "0.0.0.0" || fields[1] != "0.0.0.0" {
+			continue
+		}
+		if ip := net.ParseIP(fields[2]); ip != nil && ip.To4() != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
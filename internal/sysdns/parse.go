@@ -0,0 +1,146 @@
+package sysdns
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// windowsAdaptersScript lists connected adapters with their static IPv4 and IPv6 DNS
+// servers from the registry (empty when the servers come from DHCP or router
+// advertisements), which unlike netsh output is not localized
+const windowsAdaptersScript = `ConvertTo-Json -Compress -InputObject @(Get-NetAdapter | Where-Object Status -eq 'Up' | ForEach-Object {
+  $p = Get-ItemProperty ('HKLM:\SYSTEM\CurrentControlSet\Services\Tcpip\Parameters\Interfaces\' + $_.InterfaceGuid)
+  $p6 = Get-ItemProperty ('HKLM:\SYSTEM\CurrentControlSet\Services\Tcpip6\Parameters\Interfaces\' + $_.InterfaceGuid) -ErrorAction SilentlyContinue
+  [pscustomobject]@{ Name = $_.Name; NameServer = [string]$p.NameServer; NameServer6 = [string]$p6.NameServer }
+})`
+
+// parseNetworkServices returns the enabled services listed by networksetup
+// -listallnetworkservices, skipping its explanatory first line and disabled services (marked *)
+func parseNetworkServices(output string) []string {
+	var services []string
+	for i, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 || line == "" || strings.HasPrefix(line, "*") {
+			continue
+		}
+		services = append(services, line)
+	}
+	return services
+}
+
+// parseDNSServers returns the servers printed by networksetup -getdnsservers, or nil when
+// it reports that none are set (the servers come from DHCP)
+func parseDNSServers(output string) []string {
+	var servers []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if net.ParseIP(line) != nil {
+			servers = append(servers, line)
+		}
+	}
+	return servers
+}
+
+// parseNMConnections returns the names of active connections from nmcli -t -f NAME,TYPE,
+// skipping the loopback connection. Terse output escapes colons in names as \:.
+func parseNMConnections(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		fields := splitTerse(line)
+		if len(fields) < 2 || fields[1] == "loopback" {
+			continue
+		}
+		names = append(names, fields[0])
+	}
+	return names
+}
+
+// splitTerse splits a line of nmcli terse output on unescaped colons
+func splitTerse(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			field.WriteByte(line[i])
+		case line[i] == ':':
+			fields = append(fields, field.String())
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, field.String())
+}
+
+// parseNMSettings reads the output of nmcli -g ipv4.dns,ipv4.ignore-auto-dns: one line
+// per field, servers separated by commas
+func parseNMSettings(name, output string) Interface {
+	iface := Interface{Name: name}
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) > 0 {
+		for _, server := range strings.Split(lines[0], ",") {
+			if server = strings.TrimSpace(server); server != "" {
+				iface.Servers = append(iface.Servers, server)
+			}
+		}
+	}
+	if len(lines) > 1 {
+		iface.IgnoreAutoDNS = strings.TrimSpace(lines[1]) == "yes"
+	}
+	return iface
+}
+
+// parseResolvectl reads the output of resolvectl dns or resolvectl domain, one line per
+// link such as "Link 2 (eth0): 192.168.1.1 fe80::1%eth0". It returns the link names in
+// order, without the loopback link, and the values of each.
+func parseResolvectl(output string) ([]string, map[string][]string) {
+	var names []string
+	values := make(map[string][]string)
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "Link ") {
+			continue // Global settings, which setup leaves alone
+		}
+		open, end := strings.Index(line, "("), strings.Index(line, "):")
+		if open < 0 || end < open {
+			continue
+		}
+		name := line[open+1 : end]
+		if name == "lo" {
+			continue
+		}
+		names = append(names, name)
+		values[name] = strings.Fields(line[end+2:])
+	}
+	return names, values
+}
+
+// parseWindowsAdapters reads the JSON printed by windowsAdaptersScript
+func parseWindowsAdapters(output string) ([]Interface, error) {
+	var adapters []struct {
+		Name        string
+		NameServer  string
+		NameServer6 string
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &adapters); err != nil {
+		return nil, fmt.Errorf("failed to parse network adapters: %w", err)
+	}
+
+	interfaces := make([]Interface, 0, len(adapters))
+	for _, adapter := range adapters {
+		// The registry separates static servers with commas or spaces
+		split := func(r rune) bool { return r == ',' || r == ' ' }
+		interfaces = append(interfaces, Interface{
+			Name:        adapter.Name,
+			Servers:     strings.FieldsFunc(adapter.NameServer, split),
+			IPv6Servers: strings.FieldsFunc(adapter.NameServer6, split),
+		})
+	}
+	return interfaces, nil
+}
@@ -0,0 +1,169 @@
+package sysdns
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// wsaeAddrInUse is Windows' error for a port another socket holds
+const wsaeAddrInUse = syscall.Errno(10048)
+
+// PortOwner is the program holding a port
+type PortOwner struct {
+	PID  int    `json:"pid"`
+	Name string `json:"name,omitempty"` // Executable name, e.g. systemd-resolved or dnsmasq
+}
+
+func (o *PortOwner) String() string {
+	if o.Name == "" {
+		return fmt.Sprintf("PID %d", o.PID)
+	}
+	return fmt.Sprintf("%s (PID %d)", o.Name, o.PID)
+}
+
+// IsAddrInUse reports whether binding failed because another socket holds the port
+func IsAddrInUse(err error) bool {
+	return errors.Is(err, syscall.EADDRINUSE) || errors.Is(err, wsaeAddrInUse)
+}
+
+// FindPortOwner returns the program holding a UDP port, or nil when none is found. Finding
+// the programs of other users needs root.
+func FindPortOwner(port int) (*PortOwner, error) {
+	switch runtime.GOOS {
+	case "linux":
+		return findPortOwnerProc(port)
+	case "windows":
+		return findPortOwnerNetstat(port)
+	default:
+		return findPortOwnerLsof(port)
+	}
+}
+
+// findPortOwnerProc finds the socket bound to the port in /proc/net, then the process with
+// that socket open
+func findPortOwnerProc(port int) (*PortOwner, error) {
+	inodes := make(map[string]bool)
+	for _, path := range []string{"/proc/net/udp", "/proc/net/udp6"} {
+		// #nosec G304 -- fixed paths of the kernel's socket tables
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, inode := range parseProcNet(string(data), port) {
+			inodes[inode] = true
+		}
+	}
+	if len(inodes) == 0 {
+		return nil, nil
+	}
+
+	fds, err := filepath.Glob("/proc/[0-9]*/fd/*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open files: %w", err)
+	}
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		if !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid, err := strconv.Atoi(filepath.Base(pidDir))
+		if err != nil {
+			continue
+		}
+		// #nosec G304 -- the name of a process found under /proc
+		comm, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+		return &PortOwner{PID: pid, Name: strings.TrimSpace(string(comm))}, nil
+	}
+	return nil, fmt.Errorf("port %d is in use, but not by a process this user can see; run as root to find it", port)
+}
+
+// parseProcNet returns the inodes of the sockets bound to a local port in a /proc/net/udp
+// table
+func parseProcNet(content string, port int) []string {
+	var inodes []string
+	for _, line := range strings.Split(content, "\n")[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		_, hexPort, ok := strings.Cut(fields[1], ":")
+		if n, err := strconv.ParseUint(hexPort, 16, 16); ok && err == nil && int(n) == port && fields[9] != "0" {
+			inodes = append(inodes, fields[9])
+		}
+	}
+	return inodes
+}
+
+// findPortOwnerLsof asks lsof for the process holding the port
+func findPortOwnerLsof(port int) (*PortOwner, error) {
+	out, err := output("lsof", "-nP", fmt.Sprintf("-iUDP:%d", port), "-Fpc")
+	if err != nil {
+		// lsof exits with 1 when nothing matches
+		return nil, nil
+	}
+	return parseLsof(out), nil
+}
+
+// parseLsof reads the first process of lsof -Fpc output: a p<pid> line, then c<command>
+func parseLsof(out string) *PortOwner {
+	var owner *PortOwner
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && owner == nil:
+			pid, err := strconv.Atoi(line[1:])
+			if err != nil {
+				return nil
+			}
+			owner = &PortOwner{PID: pid}
+		case strings.HasPrefix(line, "c") && owner != nil:
+			owner.Name = line[1:]
+			return owner
+		}
+	}
+	return owner
+}
+
+// findPortOwnerNetstat finds the PID holding the port with netstat, then its name with
+// tasklist
+func findPortOwnerNetstat(port int) (*PortOwner, error) {
+	out, err := output("netstat", "-ano", "-p", "UDP")
+	if err != nil {
+		return nil, err
+	}
+	pid := parseNetstat(out, port)
+	if pid == 0 {
+		return nil, nil
+	}
+	owner := &PortOwner{PID: pid}
+	if tasks, err := output("tasklist", "/FI", fmt.Sprintf("PID eq %d", pid), "/FO", "CSV", "/NH"); err == nil {
+		if name, _, ok := strings.Cut(strings.TrimSpace(tasks), ","); ok {
+			owner.Name = strings.Trim(name, `"`)
+		}
+	}
+	return owner, nil
+}
+
+// parseNetstat returns the PID of the UDP socket bound to a local port in netstat -ano output
+func parseNetstat(out string, port int) int {
+	suffix := ":" + strconv.Itoa(port)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "UDP" || !strings.HasSuffix(fields[1], suffix) {
+			continue
+		}
+		if pid, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			return pid
+		}
+	}
+	return 0
+}
@@ -0,0 +1,116 @@
+package sysdns
+
+import "fmt"
+
+// Drift is how the system DNS settings moved away from the local resolver after Apply
+type Drift struct {
+	Changed []string // Services set up by Apply whose servers were replaced, e.g. by a DHCP renewal
+	Added   []string // Services that appeared after Apply, e.g. a VPN or a new adapter
+	Bypass  []string // Servers the system still sends queries to, e.g. ones a VPN app pushes
+}
+
+// Empty reports whether nothing drifted
+func (d *Drift) Empty() bool {
+	return len(d.Changed) == 0 && len(d.Added) == 0 && len(d.Bypass) == 0
+}
+
+// Check compares the system DNS settings with the ones Apply made. Changed and Added are
+// only found with networksetup (macOS); Bypass on every system.
+func Check(backupPath string) (*Drift, error) {
+	backup, err := loadBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	drift := &Drift{}
+	if backup.Method == MethodNetworksetup {
+		if _, err := checkNetworksetup(backup, drift); err != nil {
+			return nil, err
+		}
+	}
+	drift.Bypass, err = bypassServers()
+	if err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// Repair points the services that drifted back at the local resolver, adding the settings
+// of new ones to the backup so Restore puts them back. Servers that no service setting
+// explains, such as those a VPN app pushes with scutil, are left in Bypass.
+func Repair(backupPath string) (*Drift, error) {
+	backup, err := loadBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	if backup.Method != MethodNetworksetup {
+		return nil, fmt.Errorf("repairing system DNS is only supported with networksetup (macOS), not %s", backup.Method)
+	}
+
+	drift := &Drift{}
+	added, err := checkNetworksetup(backup, drift)
+	if err != nil {
+		return nil, err
+	}
+	if len(added) > 0 {
+		backup.Interfaces = append(backup.Interfaces, added...)
+		if err := saveBackup(backupPath, backup); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range append(append([]string{}, drift.Changed...), drift.Added...) {
+		if err := run("networksetup", "-setdnsservers", name, LocalResolver); err != nil {
+			return nil, err
+		}
+	}
+	if len(drift.Changed) > 0 || len(drift.Added) > 0 {
+		flushCache()
+	}
+
+	drift.Bypass, err = bypassServers()
+	if err != nil {
+		return nil, err
+	}
+	return drift, nil
+}
+
+// checkNetworksetup fills in the services that no longer point at the local resolver, and
+// returns the previous settings of those that appeared after Apply
+func checkNetworksetup(backup *Backup, drift *Drift) ([]Interface, error) {
+	list, err := output("networksetup", "-listallnetworkservices")
+	if err != nil {
+		return nil, err
+	}
+	var added []Interface
+	for _, name := range parseNetworkServices(list) {
+		out, err := output("networksetup", "-getdnsservers", name)
+		if err != nil {
+			return nil, err
+		}
+		servers := parseDNSServers(out)
+		if len(servers) == 1 && servers[0] == LocalResolver {
+			continue
+		}
+		if backup.find(name) == nil {
+			drift.Added = append(drift.Added, name)
+			added = append(added, Interface{Name: name, Servers: servers})
+		} else {
+			drift.Changed = append(drift.Changed, name)
+		}
+	}
+	return added, nil
+}
+
+// bypassServers returns the servers the system uses besides the local resolver
+func bypassServers() ([]string, error) {
+	servers, err := SystemResolvers()
+	if err != nil {
+		return nil, err
+	}
+	var bypass []string
+	for _, server := range servers {
+		if !IsLocal(server) {
+			bypass = append(bypass, server)
+		}
+	}
+	return bypass, nil
+}
@@ -0,0 +1,98 @@
+package sysdns
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// resolvedStub is the address of systemd-resolved's stub listener
+const resolvedStub = "127.0.0.53"
+
+// SystemResolvers returns the DNS servers the system currently sends queries to
+func SystemResolvers() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := output("scutil", "--dns")
+		if err != nil {
+			return nil, err
+		}
+		return parseScutilDNS(out), nil
+	case "windows":
+		out, err := output("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			"Get-NetAdapter | Where-Object Status -eq 'Up' | Get-DnsClientServerAddress -AddressFamily IPv4 | Select-Object -ExpandProperty ServerAddresses")
+		if err != nil {
+			return nil, err
+		}
+		return unique(parseDNSServers(out)), nil
+	default:
+		servers, err := readResolvConf(resolvConfPath)
+		if err != nil {
+			return nil, err
+		}
+		// The stub forwards to the servers systemd-resolved is configured with
+		if len(servers) == 1 && servers[0] == resolvedStub {
+			if upstream, err := readResolvConf(resolvedConfPath); err == nil && len(upstream) > 0 {
+				return upstream, nil
+			}
+		}
+		return servers, nil
+	}
+}
+
+// IsLocal reports whether a DNS server address is on this machine
+func IsLocal(server string) bool {
+	ip := net.ParseIP(server)
+	return ip != nil && ip.IsLoopback()
+}
+
+func readResolvConf(path string) ([]string, error) {
+	// #nosec G304 -- path is a fixed system file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return parseResolvConf(string(data)), nil
+}
+
+// parseResolvConf returns the nameserver entries of a resolv.conf file
+func parseResolvConf(content string) []string {
+	var servers []string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
+
+// parseScutilDNS returns the nameservers of every resolver listed by scutil --dns,
+// e.g. "  nameserver[0] : 192.168.1.1"
+func parseScutilDNS(output string) []string {
+	var servers []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "nameserver[") {
+			continue
+		}
+		if parts := strings.SplitN(line, " : ", 2); len(parts) == 2 {
+			servers = append(servers, strings.TrimSpace(parts[1]))
+		}
+	}
+	return unique(servers)
+}
+
+func unique(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,432 @@
+// Package sysdns points the system's DNS settings at the local resolver and restores the
+// previous settings: networksetup on macOS; systemd-resolved, NetworkManager, or
+// /etc/resolv.conf on Linux; and netsh on Windows.
+package sysdns
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// LocalResolver is the address the system is pointed at
+const LocalResolver = "127.0.0.1"
+
+// How the system's DNS settings are changed
+const (
+	MethodNetworksetup   = "networksetup"
+	MethodResolved       = "systemd-resolved"
+	MethodNetworkManager = "networkmanager"
+	MethodResolvConf     = "resolv.conf"
+	MethodNetsh          = "netsh"
+)
+
+const (
+	resolvConfPath     = "/etc/resolv.conf"
+	resolvedDropInPath = "/etc/systemd/resolved.conf.d/sinkzone.conf"
+	resolvedConfPath   = "/run/systemd/resolve/resolv.conf" // Lists the real DNS servers, unlike the stub file
+
+	header = "# Written by 'sinkzone setup'; restore with 'sinkzone setup --undo'\n"
+)
+
+// ErrNoBackup is returned by Restore when there are no saved settings
+var ErrNoBackup = errors.New("no saved DNS settings to restore")
+
+// Backup records the settings replaced by Apply, so Restore can put them back
+type Backup struct {
+	Method         string      `json:"method"`
+	Interfaces     []Interface `json:"interfaces,omitempty"`
+	ResolvConf     string      `json:"resolv_conf,omitempty"`      // Previous /etc/resolv.conf contents
+	ResolvConfLink string      `json:"resolv_conf_link,omitempty"` // Previous /etc/resolv.conf symlink target (also set by older systemd-resolved setups)
+	SavedAt        time.Time   `json:"saved_at"`
+}
+
+// Interface is the DNS configuration of one network service, connection, or adapter
+type Interface struct {
+	Name          string   `json:"name"`
+	Servers       []string `json:"servers,omitempty"`         // Empty means the servers came from DHCP
+	IgnoreAutoDNS bool     `json:"ignore_auto_dns,omitempty"` // NetworkManager: DHCP servers were ignored
+	Domains       []string `json:"domains,omitempty"`         // systemd-resolved: search and routing domains of the link
+	IPv6Servers   []string `json:"ipv6_servers,omitempty"`    // netsh: static IPv6 servers; empty means automatic
+}
+
+// Names lists the interfaces that were changed
+func (b *Backup) Names() []string {
+	names := make([]string, len(b.Interfaces))
+	for i, iface := range b.Interfaces {
+		names[i] = iface.Name
+	}
+	return names
+}
+
+// Apply saves the current DNS settings to backupPath and points the system at the local
+// resolver. It refuses to run twice, so the saved settings are never the resolver's own.
+func Apply(backupPath string) (*Backup, error) {
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil, fmt.Errorf("system DNS is already set up (settings saved in %s). Run 'sinkzone setup --undo' first", backupPath)
+	}
+
+	method, err := detectMethod()
+	if err != nil {
+		return nil, err
+	}
+	backup, err := capture(method)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveBackup(backupPath, backup); err != nil {
+		return nil, err
+	}
+
+	if err := configure(backup); err != nil {
+		// Put back whatever was changed before the failure
+		if restoreErr := restore(backup); restoreErr == nil {
+			_ = os.Remove(backupPath)
+		}
+		return nil, err
+	}
+	flushCache()
+	return backup, nil
+}
+
+// Restore puts back the settings saved by Apply and removes the backup
+func Restore(backupPath string) (*Backup, error) {
+	backup, err := loadBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := restore(backup); err != nil {
+		return nil, err
+	}
+	flushCache()
+	if err := os.Remove(backupPath); err != nil {
+		return nil, fmt.Errorf("failed to remove DNS backup: %w", err)
+	}
+	return backup, nil
+}
+
+func loadBackup(path string) (*Backup, error) {
+	// #nosec G304 -- path is a hardcoded path in the config directory
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNoBackup
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DNS backup: %w", err)
+	}
+	var backup Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse DNS backup: %w", err)
+	}
+	return &backup, nil
+}
+
+func saveBackup(path string, backup *Backup) error {
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal DNS backup: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write DNS backup: %w", err)
+	}
+	return nil
+}
+
+// detectMethod picks how DNS is configured on this system
+func detectMethod() (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return MethodNetworksetup, nil
+	case "windows":
+		return MethodNetsh, nil
+	case "linux":
+		if target, err := os.Readlink(resolvConfPath); err == nil && strings.Contains(target, "systemd/resolve") {
+			if _, err := exec.LookPath("resolvectl"); err != nil {
+				return "", fmt.Errorf("systemd-resolved manages DNS, but resolvectl was not found")
+			}
+			return MethodResolved, nil
+		}
+		if state, err := output("nmcli", "-t", "-f", "RUNNING", "general"); err == nil && strings.TrimSpace(state) == "running" {
+			return MethodNetworkManager, nil
+		}
+		return MethodResolvConf, nil
+	default:
+		return "", fmt.Errorf("configuring system DNS is not supported on %s", runtime.GOOS)
+	}
+}
+
+// capture reads the settings the method is about to change
+func capture(method string) (*Backup, error) {
+	backup := &Backup{Method: method, SavedAt: time.Now()}
+
+	switch method {
+	case MethodNetworksetup:
+		list, err := output("networksetup", "-listallnetworkservices")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range parseNetworkServices(list) {
+			servers, err := output("networksetup", "-getdnsservers", name)
+			if err != nil {
+				return nil, err
+			}
+			backup.Interfaces = append(backup.Interfaces, Interface{Name: name, Servers: parseDNSServers(servers)})
+		}
+	case MethodResolved:
+		dns, err := output("resolvectl", "dns")
+		if err != nil {
+			return nil, err
+		}
+		domains, err := output("resolvectl", "domain")
+		if err != nil {
+			return nil, err
+		}
+		links, servers := parseResolvectl(dns)
+		_, linkDomains := parseResolvectl(domains)
+		for _, name := range links {
+			backup.Interfaces = append(backup.Interfaces, Interface{Name: name, Servers: servers[name], Domains: linkDomains[name]})
+		}
+	case MethodNetworkManager:
+		active, err := output("nmcli", "-t", "-f", "NAME,TYPE", "connection", "show", "--active")
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range parseNMConnections(active) {
+			settings, err := output("nmcli", "-g", "ipv4.dns,ipv4.ignore-auto-dns", "connection", "show", name)
+			if err != nil {
+				return nil, err
+			}
+			backup.Interfaces = append(backup.Interfaces, parseNMSettings(name, settings))
+		}
+	case MethodResolvConf:
+		if target, err := os.Readlink(resolvConfPath); err == nil {
+			backup.ResolvConfLink = target
+		}
+		data, err := os.ReadFile(resolvConfPath)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read %s: %w", resolvConfPath, err)
+		}
+		backup.ResolvConf = string(data)
+	case MethodNetsh:
+		adapters, err := output("powershell", "-NoProfile", "-NonInteractive", "-Command", windowsAdaptersScript)
+		if err != nil {
+			return nil, err
+		}
+		interfaces, err := parseWindowsAdapters(adapters)
+		if err != nil {
+			return nil, err
+		}
+		backup.Interfaces = interfaces
+	}
+
+	if len(backup.Interfaces) == 0 && (method == MethodNetworksetup || method == MethodResolved || method == MethodNetworkManager || method == MethodNetsh) {
+		return nil, fmt.Errorf("no active network interfaces found")
+	}
+	return backup, nil
+}
+
+// configure points every captured interface at the local resolver
+func configure(backup *Backup) error {
+	switch backup.Method {
+	case MethodNetworksetup:
+		for _, iface := range backup.Interfaces {
+			if err := run("networksetup", "-setdnsservers", iface.Name, LocalResolver); err != nil {
+				return err
+			}
+		}
+	case MethodResolved:
+		// /etc/resolv.conf keeps pointing at the stub, which now forwards every domain (~.)
+		// of every link to the local resolver
+		for _, iface := range backup.Interfaces {
+			if err := run("resolvectl", "dns", iface.Name, LocalResolver); err != nil {
+				return err
+			}
+			if err := run("resolvectl", "domain", iface.Name, "~."); err != nil {
+				return err
+			}
+		}
+	case MethodNetworkManager:
+		for _, iface := range backup.Interfaces {
+			if err := run("nmcli", "connection", "modify", iface.Name, "ipv4.dns", LocalResolver, "ipv4.ignore-auto-dns", "yes"); err != nil {
+				return err
+			}
+			if err := run("nmcli", "connection", "up", iface.Name); err != nil {
+				return err
+			}
+		}
+	case MethodResolvConf:
+		if err := os.Remove(resolvConfPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to replace %s: %w", resolvConfPath, err)
+		}
+		// #nosec G306 -- resolv.conf must be world-readable
+		if err := os.WriteFile(resolvConfPath, []byte(header+"nameserver "+LocalResolver+"\n"), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", resolvConfPath, err)
+		}
+	case MethodNetsh:
+		for _, iface := range backup.Interfaces {
+			if err := run("netsh", "interface", "ipv4", "set", "dnsservers", "name="+iface.Name, "source=static",
+				"address="+LocalResolver, "register=primary", "validate=no"); err != nil {
+				return err
+			}
+			// Windows prefers IPv6 servers, such as those from router advertisements, which
+			// would bypass the resolver
+			if err := run("netsh", "interface", "ipv6", "set", "dnsservers", "name="+iface.Name, "source=static",
+				"address=none", "validate=no"); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown DNS setup method: %s", backup.Method)
+	}
+	return nil
+}
+
+// restore puts back the captured settings
+func restore(backup *Backup) error {
+	switch backup.Method {
+	case MethodNetworksetup:
+		for _, iface := range backup.Interfaces {
+			servers := iface.Servers
+			if len(servers) == 0 {
+				servers = []string{"Empty"} // Back to the servers from DHCP
+			}
+			if err := run("networksetup", append([]string{"-setdnsservers", iface.Name}, servers...)...); err != nil {
+				return err
+			}
+		}
+	case MethodResolved:
+		if backup.ResolvConfLink != "" {
+			return restoreResolvedDropIn(backup.ResolvConfLink)
+		}
+		for _, iface := range backup.Interfaces {
+			// Reverting hands the link back to the network manager; the saved servers and
+			// domains are set again in case it doesn't push them
+			if err := run("resolvectl", "revert", iface.Name); err != nil {
+				return err
+			}
+			if len(iface.Servers) > 0 {
+				if err := run("resolvectl", append([]string{"dns", iface.Name}, iface.Servers...)...); err != nil {
+					return err
+				}
+			}
+			if len(iface.Domains) > 0 {
+				if err := run("resolvectl", append([]string{"domain", iface.Name}, iface.Domains...)...); err != nil {
+					return err
+				}
+			}
+		}
+	case MethodNetworkManager:
+		for _, iface := range backup.Interfaces {
+			ignore := "no"
+			if iface.IgnoreAutoDNS {
+				ignore = "yes"
+			}
+			if err := run("nmcli", "connection", "modify", iface.Name, "ipv4.dns", strings.Join(iface.Servers, ","), "ipv4.ignore-auto-dns", ignore); err != nil {
+				return err
+			}
+			if err := run("nmcli", "connection", "up", iface.Name); err != nil {
+				return err
+			}
+		}
+	case MethodResolvConf:
+		if backup.ResolvConfLink != "" {
+			return replaceSymlink(resolvConfPath, backup.ResolvConfLink)
+		}
+		// #nosec G306 -- resolv.conf must be world-readable
+		if err := os.WriteFile(resolvConfPath, []byte(backup.ResolvConf), 0644); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", resolvConfPath, err)
+		}
+	case MethodNetsh:
+		for _, iface := range backup.Interfaces {
+			if err := restoreNetsh("ipv4", iface.Name, iface.Servers); err != nil {
+				return err
+			}
+			if err := restoreNetsh("ipv6", iface.Name, iface.IPv6Servers); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown DNS setup method: %s", backup.Method)
+	}
+	return nil
+}
+
+// restoreNetsh sets the servers of one address family of an adapter, or hands them back
+// to DHCP when there are none
+func restoreNetsh(family, name string, servers []string) error {
+	if len(servers) == 0 {
+		return run("netsh", "interface", family, "set", "dnsservers", "name="+name, "source=dhcp")
+	}
+	if err := run("netsh", "interface", family, "set", "dnsservers", "name="+name, "source=static",
+		"address="+servers[0], "register=primary", "validate=no"); err != nil {
+		return err
+	}
+	for i, server := range servers[1:] {
+		if err := run("netsh", "interface", family, "add", "dnsservers", "name="+name,
+			"address="+server, fmt.Sprintf("index=%d", i+2), "validate=no"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restoreResolvedDropIn undoes a setup made by older versions, which turned off the stub
+// listener with a drop-in and linked /etc/resolv.conf to the list of real servers
+func restoreResolvedDropIn(resolvConfLink string) error {
+	if err := os.Remove(resolvedDropInPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", resolvedDropInPath, err)
+	}
+	if err := replaceSymlink(resolvConfPath, resolvConfLink); err != nil {
+		return err
+	}
+	return run("systemctl", "restart", "systemd-resolved")
+}
+
+// replaceSymlink points path at target, replacing whatever is there
+func replaceSymlink(path, target string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	if err := os.Symlink(target, path); err != nil {
+		return fmt.Errorf("failed to link %s to %s: %w", path, target, err)
+	}
+	return nil
+}
+
+// flushCache drops cached answers so the change takes effect right away (best effort)
+func flushCache() {
+	switch runtime.GOOS {
+	case "darwin":
+		_ = run("dscacheutil", "-flushcache")
+		_ = run("killall", "-HUP", "mDNSResponder")
+	case "windows":
+		_ = run("ipconfig", "/flushdns")
+	case "linux":
+		_ = run("resolvectl", "flush-caches")
+	}
+}
+
+func run(name string, args ...string) error {
+	_, err := output(name, args...)
+	return err
+}
+
+// output runs a system command and returns its output, including it in the error
+func output(name string, args ...string) (string, error) {
+	// #nosec G204 -- runs fixed system tools with arguments passed directly, not through a shell
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s failed: %w (%s)", name, strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
@@ -0,0 +1,200 @@
+package sysdns
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseNetworkServices(t *testing.T) {
+	output := "An asterisk (*) denotes that a network service is disabled.\nWi-Fi\n*Thunderbolt Bridge\nUSB 10/100/1000 LAN\n\n"
+	got := parseNetworkServices(output)
+	want := []string{"Wi-Fi", "USB 10/100/1000 LAN"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseDNSServers(t *testing.T) {
+	if got := parseDNSServers("There aren't any DNS Servers set on Wi-Fi.\n"); got != nil {
+		t.Errorf("expected no servers, got %v", got)
+	}
+	got := parseDNSServers("1.1.1.1\n2606:4700:4700::1111\n")
+	want := []string{"1.1.1.1", "2606:4700:4700::1111"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseNMConnections(t *testing.T) {
+	output := "Home\\: 5GHz:802-11-wireless\nlo:loopback\nWired connection 1:802-3-ethernet\n"
+	got := parseNMConnections(output)
+	want := []string{"Home: 5GHz", "Wired connection 1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseNMSettings(t *testing.T) {
+	got := parseNMSettings("Home", "9.9.9.9,1.1.1.1\nyes\n")
+	want := Interface{Name: "Home", Servers: []string{"9.9.9.9", "1.1.1.1"}, IgnoreAutoDNS: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	got = parseNMSettings("Home", "\nno\n")
+	want = Interface{Name: "Home"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestParseWindowsAdapters(t *testing.T) {
+	got, err := parseWindowsAdapters(`[{"Name":"Ethernet","NameServer":"8.8.8.8,8.8.4.4","NameServer6":"2001:4860:4860::8888"},{"Name":"Wi-Fi","NameServer":"","NameServer6":""}]` + "\r\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Interface{
+		{Name: "Ethernet", Servers: []string{"8.8.8.8", "8.8.4.4"}, IPv6Servers: []string{"2001:4860:4860::8888"}},
+		{Name: "Wi-Fi", Servers: []string{}, IPv6Servers: []string{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+
+	if _, err := parseWindowsAdapters("not json"); err == nil {
+		t.Error("expected an error for invalid output")
+	}
+}
+
+func TestParseResolvectl(t *testing.T) {
+	output := "Global:\nLink 1 (lo):\nLink 2 (eth0): 192.168.1.1 fe80::1%eth0\nLink 3 (wlan0):\n"
+	names, values := parseResolvectl(output)
+	if want := []string{"eth0", "wlan0"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("expected links %v, got %v", want, names)
+	}
+	if want := []string{"192.168.1.1", "fe80::1%eth0"}; !reflect.DeepEqual(values["eth0"], want) {
+		t.Errorf("expected eth0 servers %v, got %v", want, values["eth0"])
+	}
+	if len(values["wlan0"]) != 0 {
+		t.Errorf("expected no wlan0 servers, got %v", values["wlan0"])
+	}
+}
+
+func TestParseResolvConf(t *testing.T) {
+	content := "# Generated\nnameserver 127.0.0.1\nsearch lan\nnameserver ::1 # local\n"
+	got := parseResolvConf(content)
+	want := []string{"127.0.0.1", "::1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestParseScutilDNS(t *testing.T) {
+	output := `DNS configuration
+
+resolver #1
+  nameserver[0] : 127.0.0.1
+  nameserver[1] : fe80::1%en0
+  flags    : Request A records
+
+resolver #2
+  domain   : local
+  options  : mdns
+
+DNS configuration (for scoped queries)
+
+resolver #1
+  nameserver[0] : 127.0.0.1
+`
+	got := parseScutilDNS(output)
+	want := []string{"127.0.0.1", "fe80::1%en0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestDispatcherScript(t *testing.T) {
+	script := dispatcherScript("/opt/sink zone/sinkzone", "/home/o'neil/.sinkzone")
+	want := `SINKZONE_CONFIG_DIR='/home/o'\''neil/.sinkzone' exec '/opt/sink zone/sinkzone' setup --dispatch "$1"`
+	if !strings.Contains(script, want) {
+		t.Errorf("expected the script to run %s, got:\n%s", want, script)
+	}
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Errorf("expected a shell script, got:\n%s", script)
+	}
+}
+
+func TestRepointWithoutBackup(t *testing.T) {
+	if _, err := Repoint(filepath.Join(t.TempDir(), "dns-backup.json"), "wlan0", "Cafe"); !errors.Is(err, ErrNoBackup) {
+		t.Errorf("expected ErrNoBackup, got %v", err)
+	}
+}
+
+func TestWatcherPlist(t *testing.T) {
+	plist := watcherPlist("/Applications/S&Z/sinkzone", "/Users/me/.sinkzone")
+	for _, want := range []string{
+		"<string>/Applications/S&amp;Z/sinkzone</string>",
+		"<string>--repair</string>",
+		"<string>/var/run/resolv.conf</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("expected the plist to contain %s, got:\n%s", want, plist)
+		}
+	}
+}
+
+func TestParsePortOwner(t *testing.T) {
+	procNet := `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  512: 3500007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000   991        0 20871 2 0000000000000000 0
+  641: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000   104        0 19312 2 0000000000000000 0
+`
+	if got := parseProcNet(procNet, 53); !reflect.DeepEqual(got, []string{"20871"}) {
+		t.Errorf("expected the socket on port 53, got %v", got)
+	}
+	if got := parseProcNet(procNet, 54); got != nil {
+		t.Errorf("expected no socket on port 54, got %v", got)
+	}
+
+	if got := parseLsof("p812\ncdnsmasq\nf5\n"); got == nil || *got != (PortOwner{PID: 812, Name: "dnsmasq"}) {
+		t.Errorf("unexpected lsof owner: %+v", got)
+	}
+
+	netstat := "\r\nActive Connections\r\n\r\n  Proto  Local Address          Foreign Address        State           PID\r\n  UDP    0.0.0.0:5353           *:*                                    2240\r\n  UDP    0.0.0.0:53             *:*                                    1804\r\n"
+	if got := parseNetstat(netstat, 53); got != 1804 {
+		t.Errorf("expected PID 1804, got %d", got)
+	}
+
+	for addr, want := range map[string]string{":5354": "127.0.0.1:5354", "0.0.0.0:5354": "127.0.0.1:5354", "192.0.2.1:5354": "192.0.2.1:5354"} {
+		if got := ForwardAddr(addr); got != want {
+			t.Errorf("ForwardAddr(%q) = %q, want %q", addr, got, want)
+		}
+	}
+	if !strings.Contains(forwardDropIn("127.0.0.1:5354"), "\nDNS=127.0.0.1:5354\nDomains=~.\n") {
+		t.Error("expected the drop-in to forward every domain")
+	}
+}
+
+func TestParseDefaultGateway(t *testing.T) {
+	procRoute := "Iface\tDestination\tGateway \tFlags\tRefCnt\tUse\tMetric\tMask\t\tMTU\tWindow\tIRTT\n" +
+		"wlan0\t0000A8C0\t00000000\t0001\t0\t0\t600\t00FFFFFF\t0\t0\t0\n" +
+		"wlan0\t00000000\t0101A8C0\t0003\t0\t0\t600\t00000000\t0\t0\t0\n"
+	if got := parseProcRoute(procRoute); got != "192.168.1.1" {
+		t.Errorf("expected 192.168.1.1, got %q", got)
+	}
+
+	routeGet := "   route to: default\ndestination: default\n       mask: default\n    gateway: 10.0.0.1\n  interface: en0\n"
+	if got := parseRouteGet(routeGet); got != "10.0.0.1" {
+		t.Errorf("expected 10.0.0.1, got %q", got)
+	}
+
+	windows := "IPv4 Route Table\r\n===\r\nActive Routes:\r\nNetwork Destination        Netmask          Gateway       Interface  Metric\r\n          0.0.0.0          0.0.0.0      172.16.0.1    172.16.0.23     25\r\n"
+	if got := parseWindowsRoute(windows); got != "172.16.0.1" {
+		t.Errorf("expected 172.16.0.1, got %q", got)
+	}
+	if got := parseWindowsRoute("Active Routes:\r\n  None\r\n"); got != "" {
+		t.Errorf("expected no gateway, got %q", got)
+	}
+}
@@ -0,0 +1,94 @@
+package sysdns
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+const (
+	// watcherLabel is the launchd job installed by InstallWatcher
+	watcherLabel = "com.berbyte.sinkzone.dns-watch"
+	watcherPath  = "/Library/LaunchDaemons/" + watcherLabel + ".plist"
+)
+
+// watchedPaths change whenever the DNS settings of macOS do: resolv.conf is rewritten by
+// configd, including for VPNs and DHCP renewals, and preferences.plist holds the
+// networksetup settings
+var watchedPaths = []string{"/var/run/resolv.conf", "/Library/Preferences/SystemConfiguration/preferences.plist"}
+
+// watcherPlist renders the launchd job, which runs 'sinkzone setup --repair' whenever a
+// watched path changes
+func watcherPlist(executable, dataDir string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + watcherLabel + `</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>`)
+	// Writing to a strings.Builder never fails
+	_ = xml.EscapeText(&b, []byte(executable))
+	b.WriteString(`</string>
+		<string>setup</string>
+		<string>--repair</string>
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>SINKZONE_CONFIG_DIR</key>
+		<string>`)
+	_ = xml.EscapeText(&b, []byte(dataDir))
+	b.WriteString(`</string>
+	</dict>
+	<key>WatchPaths</key>
+	<array>
+`)
+	for _, path := range watchedPaths {
+		b.WriteString("\t\t<string>" + path + "</string>\n")
+	}
+	b.WriteString(`	</array>
+	<key>ThrottleInterval</key>
+	<integer>10</integer>
+</dict>
+</plist>
+`)
+	return b.String()
+}
+
+// InstallWatcher installs a launchd job that runs executable to repair the system DNS
+// settings whenever they change, using the backup in dataDir
+func InstallWatcher(executable, dataDir string) error {
+	if runtime.GOOS != "darwin" {
+		return fmt.Errorf("watching system DNS is only supported on macOS")
+	}
+	if _, err := os.Stat(watcherPath); err == nil {
+		if err := run("launchctl", "unload", "-w", watcherPath); err != nil {
+			return err
+		}
+	}
+	// #nosec G306 -- launchd definitions are world-readable, like the others in their directory
+	if err := os.WriteFile(watcherPath, []byte(watcherPlist(executable, dataDir)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", watcherPath, err)
+	}
+	return run("launchctl", "load", "-w", watcherPath)
+}
+
+// RemoveWatcher removes the job installed by InstallWatcher and reports whether there
+// was one
+func RemoveWatcher() (bool, error) {
+	if _, err := os.Stat(watcherPath); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := run("launchctl", "unload", "-w", watcherPath); err != nil {
+		return false, err
+	}
+	if err := os.Remove(watcherPath); err != nil {
+		return false, fmt.Errorf("failed to remove %s: %w", watcherPath, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package tailnet
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"os"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"tailscale.com/logtail"
+	"tailscale.com/tsnet"
+)
+
+// DNSServer answers queries arriving on the tailnet
+type DNSServer interface {
+	ServePacketConn(conn net.PacketConn) error
+}
+
+// APIServer accepts API connections arriving on the tailnet
+type APIServer interface {
+	ServeListener(listener net.Listener)
+}
+
+// Node is the resolver's own machine on a tailnet. It runs in the resolver's process, so
+// neither tailscaled nor root is needed, and serves DNS on port 53 of its Tailscale address.
+type Node struct {
+	server  *tsnet.Server
+	apiPort string // "" when the API isn't served on the tailnet
+}
+
+// NewNode starts the machine of the tailscale config section; apiPort is the port the API
+// is served on when tailscale.api is set
+func NewNode(cfg *config.TailscaleConfig, apiPort string) (*Node, error) {
+	hostname, err := cfg.GetHostname()
+	if err != nil {
+		return nil, err
+	}
+	dir := cfg.GetStateDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create tailscale state directory: %w", err)
+	}
+
+	// Tailscale's own logs stay in the state directory instead of going to log.tailscale.com
+	logtail.Disable()
+
+	node := &Node{server: &tsnet.Server{
+		Dir:      dir,
+		Hostname: hostname,
+		AuthKey:  cfg.AuthKey,
+		// Shows the login URL when the machine has no auth key yet
+		UserLogf: func(format string, args ...any) {
+			log.Printf("Tailscale: "+format, args...)
+		},
+	}}
+	if cfg.ServesAPI() {
+		node.apiPort = apiPort
+	}
+	if err := node.server.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the tailscale machine: %w", err)
+	}
+	return node, nil
+}
+
+// Run joins the tailnet, waiting for a login if needed, and then serves DNS (and the API)
+// on it until Close
+func (n *Node) Run(ctx context.Context, dns DNSServer, api APIServer) error {
+	status, err := n.server.Up(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to join the tailnet: %w", err)
+	}
+	ip4, _ := n.server.TailscaleIPs()
+	if !ip4.IsValid() {
+		return fmt.Errorf("failed to join the tailnet: the machine has no IPv4 address")
+	}
+	name := n.server.Hostname
+	if status.Self != nil && status.Self.DNSName != "" {
+		name = status.Self.DNSName
+	}
+
+	if n.apiPort != "" {
+		listener, err := n.server.Listen("tcp", ":"+n.apiPort)
+		if err != nil {
+			return fmt.Errorf("failed to serve the API on the tailnet: %w", err)
+		}
+		api.ServeListener(listener)
+		log.Printf("Serving the HTTP API on the tailnet at http://%s", net.JoinHostPort(ip4.String(), n.apiPort))
+	}
+
+	conn, err := n.server.ListenPacket("udp", net.JoinHostPort(ip4.String(), "53"))
+	if err != nil {
+		return fmt.Errorf("failed to serve DNS on the tailnet: %w", err)
+	}
+	log.Printf("Serving DNS on the tailnet as %s (%s). Add %s as a nameserver with Override local DNS in the DNS settings of the Tailscale admin console, so the machines of the tailnet use sinkzone wherever they are", name, ip4, ip4)
+	return dns.ServePacketConn(conn)
+}
+
+// Close leaves the tailnet until the next start; the machine keeps its address
+func (n *Node) Close() error {
+	return n.server.Close()
+}
@@ -0,0 +1,213 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// exportInterval is how often finished spans are sent
+	exportInterval = 5 * time.Second
+	// maxBatch is the most spans sent in one request
+	maxBatch = 512
+	// maxQueued is how many finished spans wait to be sent before new ones are dropped
+	maxQueued = 4096
+)
+
+// exporter batches finished spans and posts them to the collector
+type exporter struct {
+	endpoint      string
+	serviceName   string
+	samplePercent int
+	client        *http.Client
+
+	spans chan *Span
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newExporter(opts Options) *exporter {
+	e := &exporter{
+		endpoint:      opts.Endpoint,
+		serviceName:   opts.ServiceName,
+		samplePercent: opts.SamplePercent,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		spans:         make(chan *Span, maxQueued),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// queue hands a finished span to the run loop, dropping it when the queue is full
+func (e *exporter) queue(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+	}
+}
+
+func (e *exporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	var batch []*Span
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) < maxBatch {
+				continue
+			}
+		case <-ticker.C:
+		case <-e.stop:
+			for len(e.spans) > 0 {
+				batch = append(batch, <-e.spans)
+			}
+			for len(batch) > 0 {
+				n := min(len(batch), maxBatch)
+				e.send(batch[:n])
+				batch = batch[n:]
+			}
+			return
+		}
+		if len(batch) > 0 {
+			e.send(batch)
+			batch = nil
+		}
+	}
+}
+
+// shutdown sends the queued spans and stops the run loop
+func (e *exporter) shutdown() {
+	e.once.Do(func() { close(e.stop) })
+	<-e.done
+}
+
+// send posts a batch of spans; failures are logged and the spans dropped
+func (e *exporter) send(batch []*Span) {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		log.Printf("Warning: failed to encode %d spans: %v", len(batch), err)
+		return
+	}
+	resp, err := e.client.Post(e.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Warning: failed to send %d spans to %s: %v", len(batch), e.endpoint, err)
+		return
+	}
+	if err := resp.Body.Close(); err != nil {
+		log.Printf("Warning: failed to close response body: %v", err)
+	}
+	if resp.StatusCode >= 300 {
+		log.Printf("Warning: failed to send %d spans to %s: %s", len(batch), e.endpoint, resp.Status)
+	}
+}
+
+// OTLP/HTTP JSON request body (ExportTraceServiceRequest)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		ParentSpanID      string          `json:"parentSpanId,omitempty"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes,omitempty"`
+		Status            *otlpStatus     `json:"status,omitempty"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code"` // 2 = error
+		Message string `json:"message,omitempty"`
+	}
+	otlpAttribute struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue *string  `json:"stringValue,omitempty"`
+		BoolValue   *bool    `json:"boolValue,omitempty"`
+		IntValue    *string  `json:"intValue,omitempty"` // 64-bit integers are strings in OTLP JSON
+		DoubleValue *float64 `json:"doubleValue,omitempty"`
+	}
+)
+
+func (e *exporter) request(batch []*Span) otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, span := range batch {
+		span.mu.Lock()
+		s := otlpSpan{
+			TraceID:           hex.EncodeToString(span.traceID[:]),
+			SpanID:            hex.EncodeToString(span.spanID[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+		}
+		if span.parentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		for _, attr := range span.attributes {
+			s.Attributes = append(s.Attributes, otlpAttribute{Key: attr.key, Value: valueOf(attr.value)})
+		}
+		if span.err != "" {
+			s.Status = &otlpStatus{Code: 2, Message: span.err}
+		}
+		span.mu.Unlock()
+		spans = append(spans, s)
+	}
+
+	service := e.serviceName
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: &service}}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "github.com/berbyte/sinkzone"}, Spans: spans}},
+	}}}
+}
+
+func valueOf(value any) otlpValue {
+	switch v := value.(type) {
+	case string:
+		return otlpValue{StringValue: &v}
+	case bool:
+		return otlpValue{BoolValue: &v}
+	case int:
+		s := strconv.Itoa(v)
+		return otlpValue{IntValue: &s}
+	case int64:
+		s := strconv.FormatInt(v, 10)
+		return otlpValue{IntValue: &s}
+	case float64:
+		return otlpValue{DoubleValue: &v}
+	default:
+		s := fmt.Sprint(v)
+		return otlpValue{StringValue: &s}
+	}
+}
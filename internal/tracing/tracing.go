@@ -0,0 +1,184 @@
+// Package tracing records spans of DNS queries and API requests and sends them to an
+// OpenTelemetry collector over OTLP/HTTP, encoded as JSON. Tracing is off until Configure
+// is called; until then Start returns nil spans, whose methods do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	mathrand "math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of spans, as numbered by OTLP
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// Options configure tracing
+type Options struct {
+	Endpoint      string // Where spans are posted, e.g. http://localhost:4318/v1/traces
+	ServiceName   string // service.name of the spans
+	SamplePercent int    // Share of traces recorded, 0 to 100
+}
+
+// tracer is set by Configure; nil while tracing is off
+var tracer atomic.Pointer[exporter]
+
+// Configure starts sending spans to opts.Endpoint, replacing an earlier configuration
+func Configure(opts Options) {
+	if previous := tracer.Swap(newExporter(opts)); previous != nil {
+		previous.shutdown()
+	}
+}
+
+// Shutdown stops tracing, sending the spans that haven't been sent yet
+func Shutdown() {
+	if previous := tracer.Swap(nil); previous != nil {
+		previous.shutdown()
+	}
+}
+
+// Enabled reports whether spans are recorded
+func Enabled() bool {
+	return tracer.Load() != nil
+}
+
+// Span is an operation within a trace. A nil Span is valid and records nothing.
+type Span struct {
+	exporter *exporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte // Zero for the root span
+	name     string
+	kind     int
+	start    time.Time
+
+	mu         sync.Mutex
+	end        time.Time
+	attributes []attribute
+	err        string
+}
+
+type attribute struct {
+	key   string
+	value any
+}
+
+type spanKey struct{}
+
+// Start begins a span as a child of the span in ctx, or as the root of a new trace, which is
+// sampled at the configured rate. It returns a context carrying the span for its children.
+func Start(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	exp := tracer.Load()
+	if exp == nil {
+		return ctx, nil
+	}
+	span := &Span{exporter: exp, name: name, kind: kind, start: time.Now()}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	} else if remote, ok := ctx.Value(remoteKey{}).(remoteParent); ok {
+		span.traceID, span.parentID = remote.traceID, remote.spanID
+	} else {
+		if mathrand.IntN(100) >= exp.samplePercent {
+			return ctx, nil
+		}
+		span.traceID = newID16()
+	}
+	span.spanID = newID8()
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span carried by ctx, or nil
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SetAttribute records a string, bool, int, or float64 detail of the operation
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.attributes = append(s.attributes, attribute{key: key, value: value})
+	s.mu.Unlock()
+}
+
+// SetError marks the operation as failed
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.err = err.Error()
+	s.mu.Unlock()
+}
+
+// End finishes the span and queues it to be sent; later calls do nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.exporter.queue(s)
+}
+
+// TraceParent returns the W3C traceparent header that continues the span's trace elsewhere
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	return fmt.Sprintf("00-%s-%s-01", hex.EncodeToString(s.traceID[:]), hex.EncodeToString(s.spanID[:]))
+}
+
+type remoteKey struct{}
+
+type remoteParent struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+// WithTraceParent returns a context whose next span continues the trace of a W3C
+// traceparent header, such as one sent by a traced client of the API. Invalid or unsampled
+// headers are ignored.
+func WithTraceParent(ctx context.Context, header string) context.Context {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 || parts[3] != "01" {
+		return ctx
+	}
+	var remote remoteParent
+	if _, err := hex.Decode(remote.traceID[:], []byte(parts[1])); err != nil {
+		return ctx
+	}
+	if _, err := hex.Decode(remote.spanID[:], []byte(parts[2])); err != nil {
+		return ctx
+	}
+	if remote.traceID == [16]byte{} || remote.spanID == [8]byte{} {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, remote)
+}
+
+func newID16() (id [16]byte) {
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newID8() (id [8]byte) {
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSpansAreExported(t *testing.T) {
+	received := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode spans: %v", err)
+		}
+		received <- req
+	}))
+	defer collector.Close()
+
+	if _, span := Start(context.Background(), "off", KindInternal); span != nil {
+		t.Fatal("expected no span before Configure")
+	}
+
+	Configure(Options{Endpoint: collector.URL, ServiceName: "sinkzone-test", SamplePercent: 100})
+	ctx := WithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, root := Start(ctx, "dns.query", KindServer)
+	root.SetAttribute("dns.question.name", "example.com")
+	_, child := Start(ctx, "dns.forward", KindInternal)
+	child.SetError(errors.New("all upstream nameservers failed"))
+	child.End()
+	root.End()
+	Shutdown()
+
+	req := <-received
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	forward, query := spans[0], spans[1]
+	if query.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || query.ParentSpanID != "00f067aa0ba902b7" {
+		t.Errorf("expected the query to continue the remote trace, got %+v", query)
+	}
+	if forward.TraceID != query.TraceID || forward.ParentSpanID != query.SpanID {
+		t.Errorf("expected the forward to be a child of the query, got %+v", forward)
+	}
+	if forward.Status == nil || forward.Status.Code != 2 {
+		t.Errorf("expected the forward to have failed, got %+v", forward.Status)
+	}
+	if len(query.Attributes) != 1 || *query.Attributes[0].Value.StringValue != "example.com" {
+		t.Errorf("unexpected attributes %+v", query.Attributes)
+	}
+	if name := req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue; *name != "sinkzone-test" {
+		t.Errorf("expected service name sinkzone-test, got %s", *name)
+	}
+}
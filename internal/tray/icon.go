@@ -0,0 +1,98 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"runtime"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// iconSize is the width and height of the tray icons, scaled down by the platform
+const iconSize = 32
+
+var (
+	focusColor       = color.NRGBA{R: 0xd9, G: 0x48, B: 0x3b, A: 0xff} // Focusing
+	pausedColor      = color.NRGBA{R: 0xe8, G: 0xa3, B: 0x17, A: 0xff} // Paused or on a break
+	offColor         = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff} // Focus mode is off
+	unreachableColor = color.NRGBA{R: 0x80, G: 0x80, B: 0x80, A: 0x80} // Resolver not reachable
+)
+
+// iconState names the icon of a summary, so it is only replaced when it changes
+func iconState(summary *api.Summary) string {
+	switch {
+	case summary == nil:
+		return "unreachable"
+	case summary.Focus && summary.Paused:
+		return "paused"
+	case summary.Focus:
+		return "focus"
+	default:
+		return "off"
+	}
+}
+
+// iconFor returns the icon of a summary, which is nil when the resolver can't be reached: a
+// filled dot while focusing or paused, a ring otherwise
+func iconFor(summary *api.Summary) []byte {
+	switch iconState(summary) {
+	case "unreachable":
+		return drawIcon(unreachableColor, false)
+	case "paused":
+		return drawIcon(pausedColor, true)
+	case "focus":
+		return drawIcon(focusColor, true)
+	default:
+		return drawIcon(offColor, false)
+	}
+}
+
+// drawIcon draws a dot, or a ring, in the image format the platform's tray takes
+func drawIcon(c color.NRGBA, filled bool) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, iconSize, iconSize))
+	center := float64(iconSize) / 2
+	outer := center - 2
+	inner := outer - 4
+	for y := 0; y < iconSize; y++ {
+		for x := 0; x < iconSize; x++ {
+			d := math.Hypot(float64(x)+0.5-center, float64(y)+0.5-center)
+			// Coverage of the pixel, smoothing the edges over one pixel
+			alpha := math.Min(1, math.Max(0, outer-d+0.5))
+			if !filled {
+				alpha = math.Min(alpha, math.Min(1, math.Max(0, d-inner+0.5)))
+			}
+			if alpha > 0 {
+				pixel := c
+				pixel.A = uint8(float64(c.A) * alpha)
+				img.SetNRGBA(x, y, pixel)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img) // Encoding to memory doesn't fail
+	if runtime.GOOS == "windows" {
+		return pngToICO(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// pngToICO wraps a PNG in an ICO file, which Windows tray icons need and which may hold
+// PNG images since Windows Vista
+func pngToICO(data []byte) []byte {
+	var buf bytes.Buffer
+	// ICONDIR: reserved, type 1 (icon), one image
+	_ = binary.Write(&buf, binary.LittleEndian, [3]uint16{0, 1, 1})
+	// ICONDIRENTRY: width, height, colors, reserved, planes, bits per pixel, size, offset
+	_ = binary.Write(&buf, binary.LittleEndian, struct {
+		Width, Height, Colors, Reserved uint8
+		Planes, BitCount                uint16
+		Size, Offset                    uint32
+	}{iconSize, iconSize, 0, 0, 1, 32, uint32(len(data)), 6 + 16}) // #nosec G115 -- an icon is a few kilobytes
+	buf.Write(data)
+	return buf.Bytes()
+}
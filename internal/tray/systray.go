@@ -0,0 +1,143 @@
+//go:build !darwin || cgo
+
+package tray
+
+import (
+	"log"
+	"time"
+
+	"fyne.io/systray"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// tickInterval is how often the countdown next to the icon is redrawn
+const tickInterval = 15 * time.Second
+
+// Run shows the tray icon until Quit is chosen from its menu. It follows the resolver with
+// long polls of GET /api/summary, so changes made elsewhere show within a second.
+func Run(controller Controller, opts Options) error {
+	systray.Run(func() { onReady(controller, opts) }, nil)
+	return nil
+}
+
+// onReady builds the menu and keeps it current
+func onReady(controller Controller, opts Options) {
+	systray.SetTitle("")
+	systray.SetTooltip("sinkzone")
+	systray.SetIcon(iconFor(nil))
+
+	statusItem := systray.AddMenuItem("Connecting…", "")
+	statusItem.Disable()
+	systray.AddSeparator()
+	startTitle := "Start focus"
+	if opts.Duration > 0 {
+		startTitle += " (" + shortDuration(opts.Duration) + ")"
+	}
+	startItem := systray.AddMenuItem(startTitle, "Start a focus session with the default profile")
+	starts := make(chan api.FocusRequest)
+	forward := func(item *systray.MenuItem, req api.FocusRequest) {
+		go func() {
+			for range item.ClickedCh {
+				starts <- req
+			}
+		}()
+	}
+	forward(startItem, opts.startRequest(""))
+	var profilesItem *systray.MenuItem
+	if len(opts.Profiles) > 0 {
+		profilesItem = systray.AddMenuItem("Start with profile", "")
+		for _, profile := range opts.Profiles {
+			forward(profilesItem.AddSubMenuItem(profile, ""), opts.startRequest(profile))
+		}
+	}
+	stopItem := systray.AddMenuItem("Stop focus", "End the running session")
+	stopItem.Disable()
+	systray.AddSeparator()
+	quitItem := systray.AddMenuItem("Quit", "Close the tray icon; the resolver keeps running")
+
+	updates := make(chan *api.Summary)
+	go poll(controller, updates)
+
+	go func() {
+		ticker := time.NewTicker(tickInterval)
+		defer ticker.Stop()
+
+		var summary *api.Summary
+		failure := ""
+		render := func() {
+			title, line := status(summary, time.Now())
+			if failure != "" {
+				line = failure
+			}
+			systray.SetTitle(title)
+			systray.SetTooltip("sinkzone: " + line)
+			statusItem.SetTitle(line)
+		}
+		act := func(req api.FocusRequest) {
+			failure = ""
+			if err := controller.SetFocusModeWithOptions(req); err != nil {
+				log.Printf("Warning: %v", err)
+				failure = err.Error()
+				render()
+			}
+		}
+		for {
+			select {
+			case next := <-updates:
+				if iconState(next) != iconState(summary) {
+					systray.SetIcon(iconFor(next))
+				}
+				summary = next
+				failure = ""
+				focused := summary != nil && summary.Focus
+				setEnabled(startItem, summary != nil && !focused)
+				setEnabled(profilesItem, summary != nil && !focused)
+				setEnabled(stopItem, focused)
+				render()
+			case <-ticker.C:
+				render()
+			case req := <-starts:
+				act(req)
+			case <-stopItem.ClickedCh:
+				act(api.FocusRequest{Enabled: false})
+			case <-quitItem.ClickedCh:
+				systray.Quit()
+				return
+			}
+		}
+	}()
+}
+
+// setEnabled enables or disables a menu item, which may be nil
+func setEnabled(item *systray.MenuItem, enabled bool) {
+	switch {
+	case item == nil:
+	case enabled:
+		item.Enable()
+	default:
+		item.Disable()
+	}
+}
+
+// poll sends each new summary, and nil once the resolver can't be reached
+func poll(controller Controller, updates chan<- *api.Summary) {
+	since, reachable := "", true
+	for {
+		summary, err := controller.WaitSummary(since, api.DefaultSummaryWait)
+		if err != nil {
+			if reachable {
+				updates <- nil
+				reachable = false
+			}
+			since = ""
+			time.Sleep(retryInterval)
+			continue
+		}
+		reachable = true
+		if summary.State != since {
+			updates <- summary
+			since = summary.State
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build darwin && !cgo
+
+package tray
+
+import "errors"
+
+// Run fails: the macOS menu bar is only reachable through cgo, which this build of sinkzone
+// was made without
+func Run(controller Controller, opts Options) error {
+	return errors.New("this build of sinkzone has no menu bar support; build it with cgo (CGO_ENABLED=1 go install github.com/berbyte/sinkzone@latest) to use 'sinkzone tray' on macOS")
+}
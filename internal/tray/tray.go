@@ -0,0 +1,79 @@
+// Package tray shows focus mode in the menu bar or system tray, with actions that start
+// and stop sessions, for 'sinkzone tray'
+package tray
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+// retryInterval is how long the tray waits before asking an unreachable resolver again
+const retryInterval = 5 * time.Second
+
+// Controller is the part of the API client the tray uses
+type Controller interface {
+	WaitSummary(since string, wait time.Duration) (*api.Summary, error)
+	SetFocusModeWithOptions(req api.FocusRequest) error
+}
+
+// Options are the sessions the tray's menu starts
+type Options struct {
+	Duration time.Duration // Length of sessions started from the menu (0 = the profile's own or none)
+	Profiles []string      // Offered under Start with profile
+}
+
+// startRequest is the session the menu starts, with a profile or the default one for ""
+func (o Options) startRequest(profile string) api.FocusRequest {
+	req := api.FocusRequest{Enabled: true, Profile: profile}
+	if o.Duration > 0 {
+		req.Duration = o.Duration.String()
+	}
+	return req
+}
+
+// status describes a summary, nil when the resolver can't be reached: the short title shown
+// next to the icon where the platform has room for one, and the first line of the menu
+func status(summary *api.Summary, now time.Time) (title, line string) {
+	switch {
+	case summary == nil:
+		return "", "Resolver not reachable"
+	case !summary.Focus:
+		return "", "Focus mode is off"
+	case summary.Paused:
+		line = "Focus paused"
+		if summary.Break {
+			line = "On a break"
+		}
+		if summary.PausedUntil != nil {
+			line += " until " + summary.PausedUntil.Local().Format("15:04")
+		}
+		return "paused", line
+	}
+
+	line = "Focusing"
+	if summary.EndTime != nil {
+		title = shortDuration(summary.EndTime.Sub(now))
+		line += " until " + summary.EndTime.Local().Format("15:04")
+	}
+	if summary.Profile != "" {
+		line += ", profile " + summary.Profile
+	}
+	if summary.BlockedLastMinute > 0 {
+		line += fmt.Sprintf(" (%d blocked in the last minute)", summary.BlockedLastMinute)
+	}
+	return title, line
+}
+
+// shortDuration formats the time left for the menu bar, e.g. 42m or 1h05m
+func shortDuration(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute) // Round up, so 0m only shows at the end
+	if minutes < 0 {
+		minutes = 0
+	}
+	if minutes < 60 {
+		return fmt.Sprintf("%dm", minutes)
+	}
+	return fmt.Sprintf("%dh%02dm", minutes/60, minutes%60)
+}
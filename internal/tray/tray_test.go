@@ -0,0 +1,40 @@
+package tray
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+)
+
+func TestStatus(t *testing.T) {
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.Local)
+	end := now.Add(85*time.Minute + 10*time.Second)
+	for _, tt := range []struct {
+		summary     *api.Summary
+		title, line string
+	}{
+		{nil, "", "Resolver not reachable"},
+		{&api.Summary{}, "", "Focus mode is off"},
+		{&api.Summary{Focus: true, EndTime: &end, Profile: "deep", BlockedLastMinute: 3}, "1h26m", "Focusing until 10:25, profile deep (3 blocked in the last minute)"},
+		{&api.Summary{Focus: true}, "", "Focusing"},
+		{&api.Summary{Focus: true, EndTime: &end, Paused: true, Break: true, PausedUntil: &end}, "paused", "On a break until 10:25"},
+	} {
+		title, line := status(tt.summary, now)
+		if title != tt.title || line != tt.line {
+			t.Errorf("status(%+v) = %q, %q; want %q, %q", tt.summary, title, line, tt.title, tt.line)
+		}
+	}
+}
+
+func TestPNGToICO(t *testing.T) {
+	icon := pngToICO(drawIcon(focusColor, true))
+	if !bytes.Equal(icon[:6], []byte{0, 0, 1, 0, 1, 0}) {
+		t.Fatalf("unexpected ICO header % x", icon[:6])
+	}
+	if _, err := png.Decode(bytes.NewReader(icon[22:])); err != nil {
+		t.Errorf("expected the PNG after the directory entry: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+	"golang.org/x/net/publicsuffix"
+)
+
+// allowOption is one way of allowlisting a domain offered by the chooser
+type allowOption struct {
+	label    string
+	patterns []string
+}
+
+// allowChooser asks how broadly to allow a domain picked in the monitoring table
+type allowChooser struct {
+	domain  string
+	options []allowOption
+	cursor  int
+}
+
+// allowOptions lists the exact domain, its sibling hosts, and its registered domain with all
+// subdomains. Sibling hosts are left out when their parent is a public suffix, such as co.uk
+// or github.io, which would allow every site under it.
+func allowOptions(domain string) []allowOption {
+	options := []allowOption{{label: "Exact domain", patterns: []string{domain}}}
+
+	if _, parent, ok := strings.Cut(domain, "."); ok {
+		if _, err := publicsuffix.EffectiveTLDPlusOne(parent); err == nil {
+			options = append(options, allowOption{label: "Sibling hosts", patterns: []string{"*." + parent}})
+		}
+	}
+
+	if registered, err := publicsuffix.EffectiveTLDPlusOne(domain); err == nil && registered != domain {
+		options = append(options, allowOption{label: "Registered domain and all subdomains", patterns: []string{registered, "*." + registered}})
+	}
+
+	return options
+}
+
+// openAllowChooser starts the chooser, or allows the domain directly when there is only one choice
+func (m *Model) openAllowChooser(domain string) {
+	options := allowOptions(domain)
+	if len(options) == 1 {
+		m.allowPatterns(domain, options[0].patterns)
+		return
+	}
+	m.monitoring.chooser = &allowChooser{domain: domain, options: options}
+}
+
+func (m *Model) updateChooser(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+	chooser := m.monitoring.chooser
+
+	key := msg.String()
+	switch m.keys.action(key, scopeMonitoring) {
+	case actionUp:
+		chooser.cursor = max(chooser.cursor-1, 0)
+		return *m, nil
+	case actionDown:
+		chooser.cursor = min(chooser.cursor+1, len(chooser.options)-1)
+		return *m, nil
+	case actionToggle:
+		m.monitoring.chooser = nil
+		m.allowPatterns(chooser.domain, chooser.options[chooser.cursor].patterns)
+		return *m, nil
+	}
+
+	switch {
+	case key == "ctrl+c":
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case key == "esc":
+		m.monitoring.chooser = nil
+	case len(key) == 1 && key[0] >= '1' && int(key[0]-'1') < len(chooser.options):
+		// Number keys pick an option directly
+		m.monitoring.chooser = nil
+		m.allowPatterns(chooser.domain, chooser.options[key[0]-'1'].patterns)
+	}
+	return *m, nil
+}
+
+// allowPatterns adds allowlist entries for a domain, skipping ones already present
+func (m *Model) allowPatterns(domain string, patterns []string) {
+	var added []string
+	for _, pattern := range patterns {
+		if m.isInAllowlist(pattern) {
+			continue
+		}
+		if err := m.addToAllowlist(pattern); err != nil {
+			m.notifyError(fmt.Sprintf("Could not allow %s", pattern), err)
+			return
+		}
+		added = append(added, pattern)
+	}
+
+	m.loadAllowlistData()
+	m.lastChangedDomain = domain
+	m.lastChangeTime = time.Now()
+	if len(added) > 0 {
+		m.notify(severitySuccess, fmt.Sprintf("Allowed %s", strings.Join(added, ", ")))
+	}
+}
+
+// renderAllowChooser shows the allowlist choices for the selected domain
+func (m Model) renderAllowChooser() string {
+	chooser := m.monitoring.chooser
+
+	var b strings.Builder
+	b.WriteString(i18n.T("Allow %s", chooser.domain) + "\n\n")
+	for i, option := range chooser.options {
+		cursor := "  "
+		if i == chooser.cursor {
+			cursor = "> "
+		}
+		b.WriteString(fmt.Sprintf("%s%d. %-38s %s\n", cursor, i+1, i18n.T(option.label), strings.Join(option.patterns, ", ")))
+	}
+	b.WriteString("\n" + i18n.T("%s/%s Select | %s or 1-%d Allow | Esc Cancel",
+		m.keys.describe(actionUp), m.keys.describe(actionDown), m.keys.describe(actionToggle), len(chooser.options)))
+	return b.String()
+}
+
+// allowlistMatch returns the allowlist entry that covers a domain, exact entries first
+func (m Model) allowlistMatch(domain string) string {
+	if m.isInAllowlist(domain) {
+		return domain
+	}
+	for _, pattern := range m.allowedDomains.domains {
+		if !strings.Contains(pattern, "*") {
+			continue
+		}
+		expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$"
+		if matched, err := regexp.MatchString(expr, domain); err == nil && matched {
+			return pattern
+		}
+	}
+	return ""
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAllowOptions(t *testing.T) {
+	tests := []struct {
+		domain   string
+		patterns [][]string
+	}{
+		{"example.com", [][]string{{"example.com"}}},
+		{"www.example.com", [][]string{
+			{"www.example.com"},
+			{"*.example.com"},
+			{"example.com", "*.example.com"},
+		}},
+		// The parent is a public suffix, so no sibling hosts
+		{"bbc.co.uk", [][]string{{"bbc.co.uk"}}},
+		{"myblog.github.io", [][]string{{"myblog.github.io"}}},
+		{"news.bbc.co.uk", [][]string{
+			{"news.bbc.co.uk"},
+			{"*.bbc.co.uk"},
+			{"bbc.co.uk", "*.bbc.co.uk"},
+		}},
+	}
+
+	for _, test := range tests {
+		var patterns [][]string
+		for _, option := range allowOptions(test.domain) {
+			patterns = append(patterns, option.patterns)
+		}
+		if !reflect.DeepEqual(patterns, test.patterns) {
+			t.Errorf("%s: expected %v, got %v", test.domain, test.patterns, patterns)
+		}
+	}
+}
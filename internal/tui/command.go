@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxCommandHistory bounds the commands recalled with up and down at the prompt
+const maxCommandHistory = 50
+
+// commandHelp lists the commands accepted at the : prompt, in the order shown by the help overlay
+var commandHelp = []keyHelp{
+	{keys: ":add <domain>", description: "Add a domain or wildcard to the allowlist"},
+	{keys: ":remove <domain>", description: "Remove an allowlist entry (also :rm)"},
+	{keys: ":focus [duration]", description: "Start focus mode with the chosen profile and intensity (e.g. :focus 45m)"},
+	{keys: ":focus off", description: "Stop focus mode (asks for the focus PIN when one is set)"},
+	{keys: ":profile <name>", description: "Choose the focus profile (:profile default for the default allowlist)"},
+	{keys: ":filter <terms>", description: "Filter the Monitoring tab; blocked and allowed are shorthands for is:blocked and is:allowed"},
+	{keys: ":quit", description: "Quit (also :q)"},
+}
+
+// updateCommand edits the command prompt, running the command on Enter
+func (m *Model) updateCommand(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case tea.KeyEsc:
+		m.commandMode = false
+		m.command = ""
+	case tea.KeyEnter:
+		command := strings.TrimSpace(m.command)
+		m.commandMode = false
+		m.command = ""
+		if command == "" {
+			break
+		}
+		m.commandHistory = append(m.commandHistory, command)
+		if len(m.commandHistory) > maxCommandHistory {
+			m.commandHistory = m.commandHistory[len(m.commandHistory)-maxCommandHistory:]
+		}
+		return m.runCommand(command)
+	case tea.KeyUp:
+		if m.historyIndex > 0 {
+			m.historyIndex--
+			m.command = m.commandHistory[m.historyIndex]
+		}
+	case tea.KeyDown:
+		if m.historyIndex < len(m.commandHistory)-1 {
+			m.historyIndex++
+			m.command = m.commandHistory[m.historyIndex]
+		} else {
+			m.historyIndex = len(m.commandHistory)
+			m.command = ""
+		}
+	case tea.KeyBackspace:
+		if runes := []rune(m.command); len(runes) > 0 {
+			m.command = string(runes[:len(runes)-1])
+		} else {
+			m.commandMode = false
+		}
+	case tea.KeySpace:
+		m.command += " "
+	case tea.KeyRunes:
+		m.command += string(msg.Runes)
+	}
+	return *m, nil
+}
+
+// openCommand shows the command prompt
+func (m *Model) openCommand() {
+	m.commandMode = true
+	m.command = ""
+	m.historyIndex = len(m.commandHistory)
+}
+
+// runCommand executes a command typed at the prompt
+func (m *Model) runCommand(command string) (Model, tea.Cmd) {
+	fields := strings.Fields(command)
+	name, args := strings.ToLower(fields[0]), fields[1:]
+
+	switch name {
+	case "add":
+		m.commandAdd(args)
+	case "remove", "rm":
+		m.commandRemove(args)
+	case "focus":
+		m.commandFocus(args)
+	case "profile":
+		m.commandProfile(args)
+	case "filter":
+		m.commandFilter(args)
+	case "quit", "q":
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	default:
+		m.notify(severityError, fmt.Sprintf("Unknown command %q (see %s for the list)", name, m.keys.describe(actionHelp)))
+	}
+	return *m, nil
+}
+
+func (m *Model) commandAdd(args []string) {
+	if len(args) != 1 {
+		m.notify(severityWarning, "Usage: :add <domain>")
+		return
+	}
+	domain := api.NormalizeDomain(args[0])
+	if err := m.addToAllowlist(domain); err != nil {
+		m.notifyError(fmt.Sprintf("Could not allow %s", domain), err)
+		return
+	}
+	m.loadAllowlistData()
+	m.lastChangedDomain = domain
+	m.lastChangeTime = time.Now()
+	m.notify(severitySuccess, fmt.Sprintf("Allowed %s", domain))
+}
+
+func (m *Model) commandRemove(args []string) {
+	if len(args) != 1 {
+		m.notify(severityWarning, "Usage: :remove <domain>")
+		return
+	}
+	domain := api.NormalizeDomain(args[0])
+	if err := m.removeFromAllowlist(domain); err != nil {
+		m.notifyError(fmt.Sprintf("Could not remove %s", domain), err)
+		return
+	}
+	m.loadAllowlistData()
+	m.notify(severitySuccess, fmt.Sprintf("Removed %s from the allowlist", domain))
+}
+
+func (m *Model) commandFocus(args []string) {
+	if len(args) > 1 {
+		m.notify(severityWarning, "Usage: :focus [duration|off]")
+		return
+	}
+
+	if len(args) == 1 && (args[0] == "off" || args[0] == "stop") {
+		m.withPIN("Stop focus mode", (*Model).stopFocusMode)
+		return
+	}
+
+	req := m.focusRequest()
+	if len(args) == 1 {
+		duration, err := time.ParseDuration(args[0])
+		if err != nil || duration <= 0 {
+			m.notify(severityError, fmt.Sprintf("Invalid duration %q (e.g. 45m or 1h30m)", args[0]))
+			return
+		}
+		req.Duration = args[0]
+	}
+	if err := m.apiClient.SetFocusModeWithOptions(req); err != nil {
+		m.notifyError("Could not start focus mode", err)
+		return
+	}
+	m.updateFocusModeStatus()
+
+	length := req.Duration
+	if length == "" {
+		length = m.durationLabel()
+	}
+	m.notify(severitySuccess, fmt.Sprintf("🔒 Focus mode activated (%s)", length))
+}
+
+func (m *Model) commandProfile(args []string) {
+	if len(args) != 1 {
+		m.notify(severityWarning, "Usage: :profile <name>")
+		return
+	}
+	if args[0] == "default" || args[0] == "none" {
+		m.selectedProfile = ""
+		m.notify(severityInfo, "Focus profile: default")
+		return
+	}
+	if _, err := m.config.GetProfile(args[0]); err != nil {
+		m.notifyError("Could not choose the profile", err)
+		return
+	}
+	m.selectedProfile = args[0]
+	m.notify(severityInfo, fmt.Sprintf("Focus profile: %s", args[0]))
+}
+
+func (m *Model) commandFilter(args []string) {
+	terms := make([]string, len(args))
+	for i, term := range args {
+		switch strings.ToLower(term) {
+		case "blocked":
+			terms[i] = "is:blocked"
+		case "allowed":
+			terms[i] = "is:allowed"
+		default:
+			terms[i] = term
+		}
+	}
+
+	m.activeTab = 0
+	m.monitoring.filter = strings.Join(terms, " ")
+	m.applyQueryFilter()
+}
+
+// renderCommandPrompt renders the command being typed in place of the footer
+func (m Model) renderCommandPrompt() string {
+	return footerStyle.Width(m.width).MaxHeight(1).Render(":" + m.command + "█")
+}
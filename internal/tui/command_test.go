@@ -0,0 +1,74 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRunCommand(t *testing.T) {
+	tests := []struct {
+		command  string
+		severity severity // Of the message left by the command, -1 for none
+		check    func(m Model) bool
+	}{
+		{"nonsense now", severityError, nil},
+		{"add", severityWarning, nil},
+		{"add a.com b.com", severityWarning, nil},
+		{"rm", severityWarning, nil},
+		{"profile", severityWarning, nil},
+		{"focus 1h off", severityWarning, nil},
+		{"focus soon", severityError, nil},
+		{"focus -5m", severityError, nil},
+		{"FOCUS off", -1, func(m Model) bool { return m.pinPrompt != nil && m.pinPrompt.title == "Stop focus mode" }},
+		{"profile default", severityInfo, func(m Model) bool { return m.selectedProfile == "" }},
+		{"filter  blocked   reddit", -1, func(m Model) bool { return m.monitoring.filter == "is:blocked reddit" && m.activeTab == 0 }},
+		{"q", -1, func(m Model) bool { return m.quitting }},
+	}
+
+	for _, test := range tests {
+		keys, _ := newKeyMap(nil)
+		m := Model{keys: keys, activeTab: 2, selectedProfile: "writing", config: &config.Config{FocusPINHash: "set"}}
+		m, _ = m.runCommand(test.command)
+
+		message := m.activeMessage()
+		switch {
+		case test.severity < 0 && message != nil:
+			t.Errorf("%q: expected no message, got %q", test.command, message.text)
+		case test.severity >= 0 && (message == nil || message.severity != test.severity):
+			t.Errorf("%q: expected a message of severity %d, got %+v", test.command, test.severity, message)
+		}
+		if test.check != nil && !test.check(m) {
+			t.Errorf("%q: unexpected state after the command", test.command)
+		}
+	}
+}
+
+func TestUpdateCommand(t *testing.T) {
+	keys, _ := newKeyMap(nil)
+	m := Model{keys: keys}
+	m.openCommand()
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("filterx")},
+		{Type: tea.KeyBackspace},
+		{Type: tea.KeySpace},
+		{Type: tea.KeyRunes, Runes: []rune("github")},
+		{Type: tea.KeyEnter},
+	} {
+		m, _ = m.updateCommand(key)
+	}
+	if m.commandMode || m.monitoring.filter != "github" || len(m.commandHistory) != 1 {
+		t.Fatalf("expected :filter github to run and be kept in the history, got filter %q and history %v", m.monitoring.filter, m.commandHistory)
+	}
+
+	// A blank command runs nothing, and up recalls the last one
+	m.openCommand()
+	m, _ = m.updateCommand(tea.KeyMsg{Type: tea.KeySpace})
+	m, _ = m.updateCommand(tea.KeyMsg{Type: tea.KeyEnter})
+	m.openCommand()
+	m, _ = m.updateCommand(tea.KeyMsg{Type: tea.KeyUp})
+	if len(m.commandHistory) != 1 || m.command != "filter github" {
+		t.Errorf("expected up to recall the last command, got %q (history %v)", m.command, m.commandHistory)
+	}
+}
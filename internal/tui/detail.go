@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hostnameMsg carries the result of a reverse DNS lookup for the detail panel
+type hostnameMsg struct {
+	ip   string
+	host string
+}
+
+// hitsMsg carries the queries per client of the detail panel's domain
+type hitsMsg struct {
+	domain string
+	hits   []api.Hit
+}
+
+// maxDetailHits is how many clients the detail panel lists the queries of
+const maxDetailHits = 5
+
+// loadHits fetches how often each client queried a domain in the background; the panel
+// leaves them out when the resolver can't tell
+func (m Model) loadHits(domain string) tea.Cmd {
+	client := m.apiClient
+	return func() tea.Msg {
+		hits, err := client.GetQueryHits(api.QueryFilter{Domain: domain, Limit: maxDetailHits})
+		if err != nil {
+			return nil
+		}
+		return hitsMsg{domain: domain, hits: hits}
+	}
+}
+
+func (m *Model) updateDetail(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch {
+	case msg.String() == "ctrl+c":
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case msg.String() == "esc", m.keys.action(msg.String(), scopeMonitoring) == actionDetail, m.keys.action(msg.String(), scopeMonitoring) == actionToggle:
+		m.monitoring.detail = nil
+	}
+	return *m, nil
+}
+
+// renderQueryDetail shows everything recorded about the selected query
+func (m Model) renderQueryDetail() string {
+	query := m.monitoring.detail
+
+	status := i18n.T("Allowed")
+	switch {
+	case query.Blocked:
+		status = i18n.T("Blocked")
+	case query.WouldBlock:
+		status = i18n.T("Would be blocked")
+	}
+
+	host := m.monitoring.detailHost
+	if host == "" {
+		host = i18n.T("looking up...")
+	}
+
+	rows := [][2]string{
+		{"Domain", query.Domain},
+		{"Time", query.Timestamp.Format("2006-01-02 15:04:05")},
+		{"Client", fallback(query.Client, i18n.T("unknown"))},
+		{"Client host", host},
+		{"Query type", fallback(query.QueryType, i18n.T("unknown"))},
+		{"Response", fallback(query.Rcode, i18n.T("unknown"))},
+		{"Upstream", fallback(query.Upstream, i18n.T("none (answered locally)"))},
+		{"Latency", latencyText(query.LatencyMS)},
+		{"Status", status},
+	}
+	if query.User != "" {
+		rows = append(rows, [2]string{"User", query.User})
+	}
+	if len(query.CNAMEs) > 0 {
+		rows = append(rows, [2]string{"CNAME", strings.Join(query.CNAMEs, " → ")})
+	}
+	if query.Reason != "" {
+		rows = append(rows, [2]string{"Reason", query.Reason})
+	}
+	if query.Count > 1 {
+		rows = append(rows, [2]string{"Count", i18n.T("%d within recent_queries.aggregate", query.Count)})
+	}
+	if query.Hits > 0 && query.FirstSeen != nil {
+		rows = append(rows, [2]string{"Hits", i18n.T("%d since %s", query.Hits, query.FirstSeen.Format("2006-01-02 15:04"))})
+	}
+	if m.isInAllowlist(query.Domain) {
+		rows = append(rows, [2]string{"Allowlist", i18n.T("listed")})
+	}
+
+	var b strings.Builder
+	b.WriteString(i18n.T("Query details") + "\n\n")
+	for _, row := range rows {
+		b.WriteString(fmt.Sprintf("  %-12s %s\n", i18n.T(row[0])+":", row[1]))
+	}
+	if len(m.monitoring.detailHits) > 0 {
+		b.WriteString("\n" + i18n.T("Hits by client:") + "\n")
+		for _, hit := range m.monitoring.detailHits {
+			client := fallback(hit.ClientName, fallback(hit.Client, i18n.T("unknown")))
+			b.WriteString(fmt.Sprintf("  %-24s %s\n", client, i18n.T("%d (%d blocked), last %s", hit.Count, hit.Blocked, hit.LastSeen.Format("15:04:05"))))
+		}
+	}
+	b.WriteString("\n" + i18n.T("Press Esc or %s to go back.", m.keys.describe(actionDetail)))
+	return b.String()
+}
+
+// fallback returns value, or placeholder when value is empty
+func fallback(value, placeholder string) string {
+	if value == "" {
+		return placeholder
+	}
+	return value
+}
+
+// latencyText renders the time taken to answer a query, which older log entries lack
+func latencyText(ms float64) string {
+	if ms <= 0 {
+		return i18n.T("unknown")
+	}
+	return fmt.Sprintf("%.1f ms", ms)
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// nextDeviceFocus is the focus mode Enter moves a device to on the Devices tab
+var nextDeviceFocus = map[string]string{
+	api.DeviceFocusFollow: api.DeviceFocusOn,
+	api.DeviceFocusOn:     api.DeviceFocusOff,
+	api.DeviceFocusOff:    api.DeviceFocusFollow,
+}
+
+type DevicesState struct {
+	devices []api.Device // Most recently seen first
+	cursor  int
+}
+
+// loadDevices fetches the devices of the resolver, keeping the selection on the same device
+func (m *Model) loadDevices() {
+	devices, err := m.apiClient.GetDevices()
+	if err != nil {
+		m.recordAPIError(err)
+		return
+	}
+
+	selected := ""
+	if m.devices.cursor < len(m.devices.devices) {
+		selected = m.devices.devices[m.devices.cursor].ID
+	}
+	m.devices.devices = devices
+	m.devices.cursor = min(m.devices.cursor, max(len(devices)-1, 0))
+	for i, device := range devices {
+		if device.ID == selected {
+			m.devices.cursor = i
+		}
+	}
+}
+
+func (m *Model) updateDevices(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch m.keys.action(msg.String(), scopeDevices) {
+	case actionUp:
+		if m.devices.cursor > 0 {
+			m.devices.cursor--
+		}
+	case actionDown:
+		if m.devices.cursor < len(m.devices.devices)-1 {
+			m.devices.cursor++
+		}
+	case actionToggle:
+		if m.devices.cursor >= len(m.devices.devices) {
+			break
+		}
+		device := m.devices.devices[m.devices.cursor]
+		mode := nextDeviceFocus[device.FocusMode]
+		if mode == "" {
+			mode = api.DeviceFocusFollow
+		}
+		setFocus := func(m *Model, pin string) { m.setDeviceFocus(device, mode, pin) }
+		// Taking a device out of focus needs the focus PIN, as the resolver checks
+		if mode == api.DeviceFocusOff || (mode == api.DeviceFocusFollow && device.FocusMode == api.DeviceFocusOn) {
+			m.withPIN("Take the device out of focus", setFocus)
+		} else {
+			setFocus(m, "")
+		}
+	}
+	return *m, nil
+}
+
+// setDeviceFocus switches the focus mode of a device, with the focus PIN when one is needed
+func (m *Model) setDeviceFocus(device api.Device, mode, pin string) {
+	if _, err := m.apiClient.SetDeviceFocus(device.ID, api.DeviceFocusRequest{Mode: mode, PIN: pin}); err != nil {
+		m.notifyError(fmt.Sprintf("Could not change focus of %s", device.Label()), err)
+		return
+	}
+	m.loadDevices()
+	m.notify(severitySuccess, fmt.Sprintf("%s: %s", device.Label(), deviceFocusLabel(mode)))
+}
+
+// deviceFocusLabel describes a device focus mode
+func deviceFocusLabel(mode string) string {
+	switch mode {
+	case api.DeviceFocusOn:
+		return i18n.T("always in focus")
+	case api.DeviceFocusOff:
+		return i18n.T("never in focus")
+	}
+	return i18n.T("follows the session")
+}
+
+func (m Model) renderDevices() string {
+	devices := m.devices.devices
+	if len(devices) == 0 {
+		return i18n.T(`
+No devices yet.
+
+Devices appear here once they send queries. Start the resolver with --lan
+to serve the devices of your network and tell them apart by MAC address.`)
+	}
+
+	columns := fitColumns(m.layout().innerWidth, []table.Column{
+		{Title: i18n.T("Device")},
+		{Title: i18n.T("MAC"), Width: 17},
+		{Title: i18n.T("Queries"), Width: 8},
+		{Title: i18n.T("Blocked"), Width: 8},
+		{Title: i18n.T("Focus"), Width: 20},
+	})
+
+	// Keep the selected device inside the rows that fit
+	visible := max(m.layout().innerHeight-5, 3) // Account for the table header and rule, and the footer
+	top := max(m.devices.cursor-visible+1, 0)
+	bottom := min(top+visible, len(devices))
+
+	var rows []table.Row
+	for _, device := range devices[top:bottom] {
+		focus := deviceFocusLabel(device.FocusMode)
+		if device.FocusUntil != nil {
+			focus += " " + i18n.T("until %s", device.FocusUntil.Format("15:04"))
+		}
+		if device.Focus {
+			focus = "🔒 " + focus
+		}
+		rows = append(rows, table.Row{device.Label(), device.MAC, strconv.Itoa(device.Queries), strconv.Itoa(device.Blocked), focus})
+	}
+
+	footer := "\n" + i18n.T("Devices (%d) | %s to switch focus: follow the session, always, never", len(devices), m.keys.describe(actionToggle))
+	if m.pinConfigured() {
+		footer += "\n" + i18n.T("A focus PIN is set: taking a device out of focus asks for it.")
+	}
+	return renderTable(columns, rows, m.devices.cursor-top, currentTheme.Selected) + footer
+}
@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+// exportView writes the filtered query table or the allowlist to a timestamped file
+// in the exports directory, returning its path
+func (m Model) exportView(format string) (string, error) {
+	var (
+		name   string
+		header []string
+		rows   [][]string
+		data   any
+	)
+
+	switch m.activeTab {
+	case 0:
+		name = "queries"
+		header = []string{"domain", "timestamp", "client", "client_name", "query_type", "rcode", "blocked", "would_block", "allowlisted", "reason", "count", "hits", "first_seen"}
+		for _, query := range m.monitoring.dnsQueries {
+			firstSeen := ""
+			if query.FirstSeen != nil {
+				firstSeen = query.FirstSeen.Format(time.RFC3339)
+			}
+			rows = append(rows, []string{
+				query.Domain,
+				query.Timestamp.Format(time.RFC3339),
+				query.Client,
+				query.ClientName,
+				query.QueryType,
+				query.Rcode,
+				strconv.FormatBool(query.Blocked),
+				strconv.FormatBool(query.WouldBlock),
+				strconv.FormatBool(m.isInAllowlist(query.Domain)),
+				query.Reason,
+				strconv.Itoa(query.Count),
+				strconv.Itoa(query.Hits),
+				firstSeen,
+			})
+		}
+		data = m.monitoring.dnsQueries
+		if m.monitoring.dnsQueries == nil {
+			data = []any{} // "[]" rather than "null" when the filter matches nothing
+		}
+	case 1:
+		name = "allowlist"
+		header = []string{"domain", "type"}
+		for _, domain := range m.allowedDomains.domains {
+			domainType := "exact"
+			if strings.Contains(domain, "*") {
+				domainType = "wildcard"
+			}
+			rows = append(rows, []string{domain, domainType})
+		}
+		data = m.allowedDomains.domains
+	default:
+		return "", fmt.Errorf("nothing to export on this tab")
+	}
+
+	dir := config.GetExportsDir()
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create exports directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102-150405"), format))
+
+	// #nosec G304 -- path is built from the exports directory and a generated file name
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create export file: %w", err)
+	}
+
+	if format == "json" {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(data)
+	} else {
+		writer := csv.NewWriter(file)
+		if err = writer.Write(header); err == nil {
+			err = writer.WriteAll(rows)
+		}
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return path, nil
+}
+
+// exportAndReport exports the current view and shows the result in the message bar
+func (m *Model) exportAndReport(format string) {
+	path, err := m.exportView(format)
+	if err != nil {
+		m.notifyError("Export failed", err)
+		return
+	}
+	m.notify(severitySuccess, fmt.Sprintf("Exported to %s", path))
+}
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestExportView(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(config.ConfigDirEnv, dir)
+	t.Setenv(config.SystemConfigEnv, "none")
+
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	m := Model{
+		monitoring: MonitoringState{allQueries: []api.DNSQuery{
+			{Domain: "github.com", Timestamp: now, QueryType: "A"},
+			{Domain: "www.reddit.com", Timestamp: now.Add(time.Second), QueryType: "A", Blocked: true, Reason: "not on the allowlist"},
+			{Domain: "old.reddit.com", Timestamp: now.Add(2 * time.Second), QueryType: "AAAA", Count: 3},
+		}},
+		allowedDomains: AllowedDomainsState{domains: []string{"github.com", "*.golang.org"}},
+	}
+	m.monitoring.filter = "reddit"
+	m.applyQueryFilter()
+
+	read := func(path string) []byte {
+		t.Helper()
+		if filepath.Dir(path) != config.GetExportsDir() {
+			t.Errorf("expected the export in %s, got %s", config.GetExportsDir(), path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	path, err := m.exportView("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(bytes.NewReader(read(path))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "domain" || records[1][0] != "old.reddit.com" || records[1][10] != "3" || records[2][0] != "www.reddit.com" || records[2][6] != "true" {
+		t.Errorf("expected the header and the filtered rows, got %v", records)
+	}
+
+	path, err = m.exportView("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries []api.DNSQuery
+	if err := json.Unmarshal(read(path), &queries); err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0].Domain != "old.reddit.com" || queries[1].Reason != "not on the allowlist" {
+		t.Errorf("expected the filtered queries, got %+v", queries)
+	}
+
+	m.monitoring.filter = "nothing matches"
+	m.applyQueryFilter()
+	path, err = m.exportView("json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := read(path); string(data) != "[]\n" {
+		t.Errorf("expected an empty list when the filter matches nothing, got %q", data)
+	}
+
+	m.activeTab = 1
+	path, err = m.exportView("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err = csv.NewReader(bytes.NewReader(read(path))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"domain", "type"}, {"github.com", "exact"}, {"*.golang.org", "wildcard"}}
+	if !slices.EqualFunc(records, want, slices.Equal[[]string]) {
+		t.Errorf("expected %v, got %v", want, records)
+	}
+
+	m.activeTab = 2
+	if _, err := m.exportView("csv"); err == nil {
+		t.Error("expected the stats tab to have nothing to export")
+	}
+}
@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/focus"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Session lengths offered by the Focus tab ("" = the profile's duration, or until stopped)
+var focusDurations = []string{"", "15m", "25m", "45m", "1h", "90m", "2h", "4h"}
+
+// Intensities offered by the Focus tab ("" = the configured default)
+var focusIntensities = []string{"", config.IntensitySoft, config.IntensityNormal, config.IntensityHard}
+
+const (
+	focusExtendStep = 15 * time.Minute // Added to the session by the extend action
+	focusPauseStep  = 5 * time.Minute  // Length of a pause started by the pause action
+)
+
+// Rows of the Focus tab session picker
+const (
+	focusFieldDuration = iota
+	focusFieldProfile
+	focusFieldIntensity
+	focusFieldCount
+)
+
+type FocusState struct {
+	field     int // Selected picker row
+	duration  int // Index into focusDurations
+	intensity int // Index into focusIntensities
+}
+
+// newFocusState selects a one hour session at the configured intensity
+func newFocusState() FocusState {
+	return FocusState{duration: 4}
+}
+
+// durationLabel describes the selected session length
+func (m Model) durationLabel() string {
+	duration := focusDurations[m.focus.duration]
+	switch {
+	case duration != "":
+		return duration
+	case m.selectedProfile != "":
+		return i18n.T("profile default")
+	default:
+		return i18n.T("until stopped")
+	}
+}
+
+// focusRequest builds the session selected in the Focus tab picker
+func (m Model) focusRequest() api.FocusRequest {
+	return api.FocusRequest{
+		Enabled:   true,
+		Duration:  focusDurations[m.focus.duration],
+		Profile:   m.selectedProfile,
+		Intensity: focusIntensities[m.focus.intensity],
+	}
+}
+
+func (m *Model) updateFocus(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch m.keys.action(msg.String(), scopeFocus) {
+	case actionUp:
+		if m.focus.field > 0 {
+			m.focus.field--
+		}
+	case actionDown:
+		if m.focus.field < focusFieldCount-1 {
+			m.focus.field++
+		}
+	case actionIncrease:
+		m.changeFocusField(1)
+	case actionDecrease:
+		m.changeFocusField(-1)
+	case actionToggle:
+		if m.focusModeActive {
+			m.notify(severityInfo, "Focus mode is already active")
+			break
+		}
+		if err := m.enableFocusMode(); err != nil {
+			m.notifyError("Could not start focus mode", err)
+			break
+		}
+		m.notify(severitySuccess, fmt.Sprintf("🔒 Focus mode activated (%s)", m.durationLabel()))
+	case actionExtend:
+		m.extendFocusMode()
+	case actionPause:
+		if !m.focusModeActive {
+			break
+		}
+		m.withPIN("Pause focus mode", (*Model).pauseFocusMode)
+	case actionResume:
+		if m.focusDetails == nil || !m.focusDetails.Paused {
+			break
+		}
+		if err := m.apiClient.ResumeFocusMode(); err != nil {
+			m.notifyError("Could not resume", err)
+			break
+		}
+		m.updateFocusModeStatus()
+		m.notify(severitySuccess, "Focus mode resumed")
+	case actionStop:
+		if !m.focusModeActive {
+			break
+		}
+		m.withPIN("Stop focus mode", (*Model).stopFocusMode)
+	}
+
+	return *m, nil
+}
+
+// pauseFocusMode pauses the session for focusPauseStep, with the focus PIN when one is set
+func (m *Model) pauseFocusMode(pin string) {
+	if err := m.apiClient.PauseFocusMode(focusPauseStep.String(), pin); err != nil {
+		m.notifyError("Could not pause", err)
+		return
+	}
+	m.updateFocusModeStatus()
+	m.notify(severitySuccess, fmt.Sprintf("Focus mode paused for %s", focusPauseStep))
+}
+
+// stopFocusMode ends the session, with the focus PIN when one is set
+func (m *Model) stopFocusMode(pin string) {
+	if err := m.apiClient.SetFocusModeWithOptions(api.FocusRequest{Enabled: false, PIN: pin}); err != nil {
+		m.notifyError("Could not stop", err)
+		return
+	}
+	m.updateFocusModeStatus()
+	if m.focusModeActive {
+		// The resolver queued the disable because of the configured disable delay
+		m.notify(severityInfo, "Focus mode will end after the disable delay")
+	} else {
+		m.notify(severitySuccess, "Focus mode stopped")
+	}
+}
+
+// changeFocusField steps the selected picker row through its options
+func (m *Model) changeFocusField(step int) {
+	switch m.focus.field {
+	case focusFieldDuration:
+		m.focus.duration = wrapIndex(m.focus.duration+step, len(focusDurations))
+	case focusFieldProfile:
+		m.cycleProfile()
+	case focusFieldIntensity:
+		m.focus.intensity = wrapIndex(m.focus.intensity+step, len(focusIntensities))
+	}
+}
+
+// extendFocusMode restarts the running session with the same settings and a later end time
+func (m *Model) extendFocusMode() {
+	state := m.focusDetails
+	if state == nil || !state.Enabled {
+		return
+	}
+	if state.Paused {
+		m.notify(severityWarning, "Resume the session before extending it")
+		return
+	}
+	if state.EndTime == nil {
+		m.notify(severityWarning, "The session has no end time to extend")
+		return
+	}
+
+	remaining := time.Until(*state.EndTime).Round(time.Second)
+	req := api.FocusRequest{
+		Enabled:   true,
+		Duration:  (remaining + focusExtendStep).String(),
+		Profile:   state.Profile,
+		Intensity: state.Intensity,
+		Label:     state.Label,
+	}
+	if err := m.apiClient.SetFocusModeWithOptions(req); err != nil {
+		m.notifyError("Could not extend", err)
+		return
+	}
+	m.updateFocusModeStatus()
+	m.notify(severitySuccess, fmt.Sprintf("Focus session extended by %s", focusExtendStep))
+}
+
+func (m Model) renderFocus() string {
+	if m.focusModeActive {
+		return m.renderActiveFocus()
+	}
+
+	profile := m.selectedProfile
+	if profile == "" {
+		profile = i18n.T("default allowlist")
+	}
+	intensity := focusIntensities[m.focus.intensity]
+	if intensity == "" {
+		intensity = i18n.T("configured default")
+	}
+
+	rows := []string{
+		i18n.T("Duration:   ◀ %s ▶", m.durationLabel()),
+		i18n.T("Profile:    ◀ %s ▶", profile),
+		i18n.T("Intensity:  ◀ %s ▶", intensity),
+	}
+	for i := range rows {
+		if i == m.focus.field {
+			rows[i] = "> " + rows[i]
+		} else {
+			rows[i] = "  " + rows[i]
+		}
+	}
+
+	return "\n" + i18n.T("Focus mode is off. Choose a session:") + "\n\n" + strings.Join(rows, "\n") + "\n\n" +
+		i18n.T("%s/%s Select | %s/%s Change | %s Start", m.keys.describe(actionUp), m.keys.describe(actionDown),
+			m.keys.describe(actionIncrease), m.keys.describe(actionDecrease), m.keys.describe(actionToggle))
+}
+
+func (m Model) renderActiveFocus() string {
+	state := m.focusDetails
+	if state == nil {
+		return "\n" + i18n.T("🔒 Focus mode is active.") + "\n\n" + i18n.T("Session details are unavailable while the resolver is unreachable.")
+	}
+
+	lines := []string{i18n.T("🔒 Focus mode is active"), ""}
+	switch {
+	case state.Paused && state.PausedUntil != nil:
+		kind := i18n.T("Paused")
+		if state.Break {
+			kind = i18n.T("On a break")
+		}
+		lines = append(lines, i18n.T("Status:     %s until %s (%s left in the session)", kind, state.PausedUntil.Format("15:04"), state.Remaining))
+	case state.EndTime != nil:
+		lines = append(lines, i18n.T("Remaining:  %s (ends %s)", focus.Countdown(time.Until(*state.EndTime)), state.EndTime.Format("15:04")))
+	default:
+		lines = append(lines, i18n.T("Remaining:  until stopped"))
+	}
+	if state.Profile != "" {
+		lines = append(lines, i18n.T("Profile:    %s", state.Profile))
+	}
+	if state.Intensity != "" {
+		lines = append(lines, i18n.T("Intensity:  %s", state.Intensity))
+	}
+	if state.Label != "" {
+		lines = append(lines, i18n.T("Label:      %s", state.Label))
+	}
+	if state.DisableAt != nil {
+		lines = append(lines, i18n.T("Stopping:   at %s (disable delay)", state.DisableAt.Format("15:04:05")))
+	}
+	if state.DryRun {
+		lines = append(lines, i18n.T("Dry run:    %d queries would have been blocked", state.WouldBlock))
+	} else {
+		lines = append(lines, i18n.T("Blocked:    %d queries this session", state.Blocked))
+	}
+
+	actions := i18n.T("%s Extend %s | %s Pause %s | %s Stop", m.keys.describe(actionExtend), focusExtendStep,
+		m.keys.describe(actionPause), focusPauseStep, m.keys.describe(actionStop))
+	if state.Paused {
+		actions = i18n.T("%s Resume | %s Stop", m.keys.describe(actionResume), m.keys.describe(actionStop))
+	}
+	lines = append(lines, "", actions)
+	if m.pinConfigured() {
+		lines = append(lines, i18n.T("A focus PIN is set: pausing and stopping ask for it."))
+	}
+
+	return "\n" + strings.Join(lines, "\n")
+}
+
+// clockMsg redraws the focus countdown every second
+type clockMsg time.Time
+
+func clockTick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return clockMsg(t)
+	})
+}
+
+// focusCountdown describes the time left in the session, or "" when it runs until stopped
+func (m Model) focusCountdown() string {
+	if state := m.focusDetails; state != nil && state.Paused {
+		if remaining, err := time.ParseDuration(state.Remaining); err == nil && remaining > 0 {
+			return i18n.T("paused, %s left", focus.Countdown(remaining))
+		}
+		return i18n.T("paused")
+	}
+	if m.focusEndTime == nil {
+		return ""
+	}
+	return i18n.T("%s left", focus.Countdown(time.Until(*m.focusEndTime)))
+}
+
+// wrapIndex keeps an index within [0, length), wrapping at both ends
+func wrapIndex(index, length int) int {
+	return (index%length + length) % length
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+)
+
+func TestWrapIndex(t *testing.T) {
+	tests := []struct {
+		index, length, expected int
+	}{
+		{0, 4, 0},
+		{3, 4, 3},
+		{4, 4, 0},
+		{-1, 4, 3},
+		{-5, 4, 3},
+	}
+
+	for _, test := range tests {
+		if got := wrapIndex(test.index, test.length); got != test.expected {
+			t.Errorf("wrapIndex(%d, %d): expected %d, got %d", test.index, test.length, test.expected, got)
+		}
+	}
+}
+
+func TestFocusPicker(t *testing.T) {
+	m := Model{focus: newFocusState()}
+	if req := m.focusRequest(); !req.Enabled || req.Duration != "1h" || req.Intensity != "" {
+		t.Errorf("expected a one hour session at the default intensity, got %+v", req)
+	}
+
+	// Past the last length wraps to running until stopped
+	m.changeFocusField(len(focusDurations) - 4)
+	if req := m.focusRequest(); req.Duration != "" || m.durationLabel() != "until stopped" {
+		t.Errorf("expected a session until stopped, got %q (%s)", req.Duration, m.durationLabel())
+	}
+	m.selectedProfile = "writing"
+	if req := m.focusRequest(); req.Profile != "writing" || m.durationLabel() != "profile default" {
+		t.Errorf("expected the writing profile's duration, got %+v (%s)", req, m.durationLabel())
+	}
+
+	m.focus.field = focusFieldIntensity
+	m.changeFocusField(-1)
+	if req := m.focusRequest(); req.Intensity != config.IntensityHard {
+		t.Errorf("expected stepping back from the default to wrap to hard, got %q", req.Intensity)
+	}
+}
+
+func TestExtendFocusMode(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{})
+	m := Model{apiClient: r.Client}
+	r.Focus(t, time.Hour)
+	m.updateFocusModeStatus()
+
+	m.extendFocusMode()
+	if m.focusEndTime == nil || time.Until(*m.focusEndTime).Round(time.Minute) != time.Hour+focusExtendStep {
+		t.Fatalf("expected the session to end in %v, got %v", time.Hour+focusExtendStep, m.focusEndTime)
+	}
+
+	if err := r.Client.PauseFocusMode("5m", ""); err != nil {
+		t.Fatal(err)
+	}
+	m.updateFocusModeStatus()
+	m.extendFocusMode()
+	if message := m.activeMessage(); message == nil || message.severity != severityWarning {
+		t.Errorf("expected a paused session not to be extended, got %+v", message)
+	}
+}
+
+func TestFocusIndicatorText(t *testing.T) {
+	end := time.Now().Add(25*time.Minute + 30*time.Second + 500*time.Millisecond)
+	longEnd := time.Now().Add(2*time.Hour + 500*time.Millisecond)
+	tests := []struct {
+		name    string
+		profile string
+		endTime *time.Time
+		details *api.FocusModeState
+		text    string
+	}{
+		{"until stopped", "", nil, nil, "🔒 FOCUS MODE ACTIVE"},
+		{"minutes left", "", &end, nil, "🔒 FOCUS MODE ACTIVE · 25:30 left"},
+		{"hours left with a profile", "deep", &longEnd, nil, "🔒 FOCUS MODE ACTIVE (deep) · 2:00:00 left"},
+		{"paused", "", &end, &api.FocusModeState{Paused: true, Remaining: "4m59s"}, "🔒 FOCUS MODE ACTIVE · paused, 04:59 left"},
+		{"paused without a remaining time", "", nil, &api.FocusModeState{Paused: true}, "🔒 FOCUS MODE ACTIVE · paused"},
+	}
+
+	for _, test := range tests {
+		m := Model{focusModeActive: true, focusProfile: test.profile, focusEndTime: test.endTime, focusDetails: test.details, width: 200}
+		if text := m.focusIndicatorText(); text != test.text {
+			t.Errorf("%s: expected %q, got %q", test.name, test.text, text)
+		}
+		if header := m.renderCompactHeader(); !strings.Contains(header, test.text) {
+			t.Errorf("%s: expected the compact header to show %q, got %q", test.name, test.text, header)
+		}
+	}
+
+	m := Model{}
+	if _, cmd := m.Update(clockMsg(time.Now())); cmd == nil {
+		t.Error("expected the countdown clock to keep ticking")
+	}
+}
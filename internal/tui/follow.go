@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// followBuffer is how many streamed queries wait for the TUI before new ones are skipped
+const followBuffer = 256
+
+// streamMsg carries one event from the query stream to Update
+type streamMsg struct {
+	stream  chan streamMsg // Stream the event came from, to ignore events of a stopped stream
+	query   *api.DNSQuery
+	dropped int   // Queries skipped before this one because the TUI or the resolver fell behind
+	done    bool  // The stream ended
+	err     error // Why the stream ended
+}
+
+// startFollow opens the query stream so new queries show up as soon as they are recorded
+func (m *Model) startFollow() tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := make(chan streamMsg, followBuffer)
+	m.monitoring.following = true
+	m.monitoring.stream = stream
+	m.monitoring.streamCtx = ctx
+	m.monitoring.stopStream = cancel
+	m.monitoring.dropped = 0
+
+	client := m.apiClient
+	go func() {
+		skipped := 0
+		onQuery := func(query api.DNSQuery) {
+			// Never block the stream reader: skip queries while the buffer is full
+			select {
+			case stream <- streamMsg{stream: stream, query: &query, dropped: skipped}:
+				skipped = 0
+			default:
+				skipped++
+			}
+		}
+		onDropped := func(count int64) {
+			skipped += int(count)
+		}
+
+		err := client.StreamQueries(ctx, onQuery, onDropped)
+		select {
+		case stream <- streamMsg{stream: stream, done: true, err: err}:
+		case <-ctx.Done():
+		}
+	}()
+
+	return waitForStream(ctx, stream)
+}
+
+// stopFollow closes the query stream and goes back to polling
+func (m *Model) stopFollow() {
+	if m.monitoring.stopStream != nil {
+		m.monitoring.stopStream()
+	}
+	m.monitoring.following = false
+	m.monitoring.stream = nil
+	m.monitoring.streamCtx = nil
+	m.monitoring.stopStream = nil
+}
+
+// waitForStream delivers the next stream event, or nothing once the stream is stopped
+func waitForStream(ctx context.Context, stream chan streamMsg) tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case msg := <-stream:
+			return msg
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// updateStream applies a streamed query and waits for the next one
+func (m *Model) updateStream(msg streamMsg) (Model, tea.Cmd) {
+	if msg.stream == nil || msg.stream != m.monitoring.stream {
+		return *m, nil
+	}
+
+	if msg.done {
+		m.stopFollow()
+		if msg.err != nil && !errors.Is(msg.err, context.Canceled) {
+			m.recordAPIError(msg.err)
+			m.notify(severityWarning, fmt.Sprintf("Live tail stopped, polling every %s", formatInterval(m.refreshInterval)))
+		}
+		return *m, nil
+	}
+
+	m.monitoring.dropped += msg.dropped
+	if msg.query != nil {
+		m.addStreamedQuery(*msg.query)
+	}
+	return *m, waitForStream(m.monitoring.streamCtx, m.monitoring.stream)
+}
+
+// addStreamedQuery merges a streamed query into the table. While the table is paused the
+// query is merged into the pending snapshot instead, which holds at most one entry per
+// domain, so a long pause can't grow it without bound.
+func (m *Model) addStreamedQuery(query api.DNSQuery) {
+	if m.monitoring.frozen {
+		if m.monitoring.pending == nil {
+			m.monitoring.pending = append([]api.DNSQuery(nil), m.monitoring.allQueries...)
+		}
+		m.monitoring.pending = mergeQuery(m.monitoring.pending, query, m.historySize)
+		return
+	}
+
+	m.monitoring.allQueries = mergeQuery(m.monitoring.allQueries, query, m.historySize)
+	m.applyQueryFilter()
+	m.monitoring.lastUpdate = time.Now()
+}
+
+// mergeQuery adds a streamed query to queries (oldest first), dropping the oldest rows
+// beyond limit. A query the resolver counted in an earlier entry replaces that
+// entry's row, as does any query of the domain from resolvers before counts.
+func mergeQuery(queries []api.DNSQuery, query api.DNSQuery, limit int) []api.DNSQuery {
+	replace := -1
+	for i := len(queries) - 1; i >= 0; i-- {
+		if sameEntry(queries[i], query) {
+			replace = i
+			break
+		}
+	}
+	merged := make([]api.DNSQuery, 0, len(queries)+1)
+	for i, existing := range queries {
+		if i != replace {
+			merged = append(merged, existing)
+		}
+	}
+	merged = append(merged, query)
+	if len(merged) > limit {
+		merged = merged[len(merged)-limit:]
+	}
+	return merged
+}
+
+// sameEntry reports whether query was counted in the entry shown by row
+func sameEntry(row, query api.DNSQuery) bool {
+	if query.Count == 0 {
+		return row.Domain == query.Domain
+	}
+	return query.Count > 1 && row.Domain == query.Domain && row.Client == query.Client &&
+		row.Blocked == query.Blocked && row.WouldBlock == query.WouldBlock
+}
+
+// followStatus describes the live tail for the monitoring table header
+func (m Model) followStatus() string {
+	if !m.monitoring.following {
+		return ""
+	}
+	if m.monitoring.dropped > 0 {
+		return i18n.T("● LIVE (%d skipped)", m.monitoring.dropped)
+	}
+	return i18n.T("● LIVE")
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// updateHealth polls the resolver health, recording an API error when it can't be reached
+func (m *Model) updateHealth() {
+	health, err := m.apiClient.GetHealth()
+	if err != nil {
+		m.health = nil
+		m.recordAPIError(err)
+		return
+	}
+	m.health = health
+	m.apiError = ""
+}
+
+// recordAPIError keeps the error for the status bar until a later request succeeds
+func (m *Model) recordAPIError(err error) {
+	m.apiError = err.Error()
+}
+
+// healthIndicator renders the API, DNS port, and upstream lights shown in the header
+func (m Model) healthIndicator() string {
+	apiStatus, dnsStatus, upstreamStatus := api.HealthDown, api.HealthUnknown, api.HealthUnknown
+	if m.health != nil {
+		apiStatus, dnsStatus, upstreamStatus = api.HealthOK, api.HealthDown, m.health.UpstreamStatus
+		if m.health.DNSListening {
+			dnsStatus = api.HealthOK
+		}
+	}
+
+	lights := []string{healthLight("API", apiStatus), healthLight("DNS", dnsStatus), healthLight(i18n.T("Upstream"), upstreamStatus)}
+	return strings.Join(lights, "  ")
+}
+
+// healthLight renders a label with a green, yellow, or red dot for the status
+func healthLight(label, status string) string {
+	color := currentTheme.Muted
+	switch status {
+	case api.HealthOK:
+		color = currentTheme.Success
+	case api.HealthDegraded:
+		color = currentTheme.Warning
+	case api.HealthDown:
+		color = currentTheme.Alert
+	}
+	return label + " " + lipgloss.NewStyle().Foreground(color).Render("●")
+}
+
+// renderStatusBar describes the last API error and how old the shown data is,
+// or returns "" while the API answers
+func (m Model) renderStatusBar() string {
+	if m.apiError == "" {
+		return ""
+	}
+
+	stale := i18n.T("no data received yet")
+	if !m.monitoring.lastUpdate.IsZero() && len(m.monitoring.allQueries) > 0 {
+		stale = i18n.T("showing data from %s", m.monitoring.lastUpdate.Format("15:04:05"))
+	}
+	return lipgloss.NewStyle().
+		Background(currentTheme.Alert).
+		Foreground(currentTheme.OnColor).
+		Padding(0, 1).
+		Width(m.width).
+		MaxHeight(1).
+		Render(i18n.T("⚠ Resolver API error: %s | %s", m.apiError, stale))
+}
+
+// renderBypassBanner warns that this machine's DNS queries don't reach the resolver, as
+// its periodic check found, or returns "" while they do
+func (m Model) renderBypassBanner() string {
+	if m.health == nil || !m.health.DNSCheck.Bypassed() {
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Background(currentTheme.Warning).
+		Foreground(currentTheme.OnColor).
+		Padding(0, 1).
+		Width(m.width).
+		MaxHeight(1).
+		Render(i18n.T("⚠ This machine's DNS queries bypass sinkzone, so nothing is blocked for it. Run 'sinkzone doctor'."))
+}
+
+// renderPortalBanner tells about a suspected captive portal or an open passthrough window,
+// or returns "" when there is neither
+func (m Model) renderPortalBanner() string {
+	if m.health == nil || m.health.Portal == nil {
+		return ""
+	}
+	portal := m.health.Portal
+	text := ""
+	switch {
+	case portal.Passthrough():
+		text = i18n.T("⚠ Passthrough: nothing is blocked until %s so the network's login page can load", portal.PassthroughUntil.Local().Format("15:04:05"))
+	case portal.Suspected:
+		text = i18n.T("⚠ Captive portal suspected: run 'sinkzone passthrough' to let the network's login page through")
+	default:
+		return ""
+	}
+	return lipgloss.NewStyle().
+		Background(currentTheme.Warning).
+		Foreground(currentTheme.OnColor).
+		Padding(0, 1).
+		Width(m.width).
+		MaxHeight(1).
+		Render(text)
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/i18n"
+)
+
+// keyHelp is one key binding shown in the help overlay: an action from the keymap,
+// or fixed keys when the binding isn't configurable
+type keyHelp struct {
+	action      string
+	keys        string
+	description string        // Translated when rendered
+	args        []interface{} // Formatted into the translated description
+}
+
+// helpSections lists the key bindings per tab, in the order shown by the help overlay
+var helpSections = []struct {
+	title string
+	keys  []keyHelp
+}{
+	{"Global", []keyHelp{
+		{action: actionPrevTab, description: "Previous tab"},
+		{action: actionNextTab, description: "Next tab"},
+		{action: actionTabMonitoring, description: "Monitoring tab"},
+		{action: actionTabAllowlist, description: "Allowlist tab"},
+		{action: actionTabStats, description: "Stats tab"},
+		{action: actionTabFocus, description: "Focus tab"},
+		{action: actionTabDevices, description: "Devices tab"},
+		{action: actionFocus, description: "Start focus mode with the session chosen in the Focus tab"},
+		{action: actionProfile, description: "Cycle the focus profile"},
+		{action: actionHelp, description: "Show or hide this help"},
+		{action: actionMessages, description: "Show or hide recent messages and errors"},
+		{action: actionSettings, description: "Adjust the refresh and allowlist reload intervals"},
+		{action: actionCommand, description: "Open the command prompt (commands below; ↑/↓ recall earlier ones)"},
+		{action: actionSuggestions, description: "Review allowlist suggestions: domains blocked repeatedly in the last focus session alongside allowed ones"},
+		{action: actionQuit, description: "Quit (first clears an active search; Ctrl+C always quits)"},
+	}},
+	{"Monitoring", []keyHelp{
+		{action: actionUp, description: "Select the previous query"},
+		{action: actionDown, description: "Select the next query"},
+		{action: actionPageUp, description: "Scroll a page up"},
+		{action: actionPageDown, description: "Scroll a page down"},
+		{action: actionTop, description: "Jump to the newest query and follow new ones"},
+		{action: actionBottom, description: "Jump to the oldest query"},
+		{action: actionToggle, description: "Allow the selected domain (choose exact, sibling hosts, or the whole registered domain) or remove it"},
+		{action: actionMark, description: "Mark or unmark the selected domain for a batch action"},
+		{action: actionBatch, description: "Allow the marked domains, or remove them when all are allowlisted (Esc clears marks)"},
+		{action: actionUndo, description: "Undo the last allowlist change"},
+		{action: actionDetail, description: "Show details of the selected query (Esc closes)"},
+		{action: actionPause, description: "Pause or resume auto-refresh of the table"},
+		{action: actionExportCSV, description: "Export the filtered table as CSV"},
+		{action: actionExportJSON, description: "Export the filtered table as JSON"},
+		{action: actionResume, description: "Resume auto-refresh"},
+		{action: actionFollow, description: "Live tail: stream new queries as they happen instead of polling"},
+		{action: actionSort, description: "Sort by hit count, most queried first, or by time again"},
+		{action: actionIgnore, description: "Ignore the selected domain: hide its queries here and from stats (ignore_domains)"},
+		{action: actionSearch, description: "Search: domain text, client:<address>, user:<name>, is:blocked, is:allowed"},
+		{keys: "Enter / Esc", description: "While searching: apply / clear the filter"},
+	}},
+	{"Allowlist", []keyHelp{
+		{action: actionUp, description: "Select the previous domain"},
+		{action: actionDown, description: "Select the next domain"},
+		{action: actionToggle, description: "Remove the selected domain"},
+		{action: actionMark, description: "Mark or unmark the selected domain"},
+		{action: actionBatch, description: "Remove the marked domains (Esc clears marks)"},
+		{action: actionUndo, description: "Undo the last allowlist change"},
+		{action: actionExportCSV, description: "Export the allowlist as CSV"},
+		{action: actionExportJSON, description: "Export the allowlist as JSON"},
+	}},
+	{"Stats and help", []keyHelp{
+		{action: actionUp, description: "Scroll up"},
+		{action: actionDown, description: "Scroll down"},
+		{action: actionPageUp, description: "Scroll a page up"},
+		{action: actionPageDown, description: "Scroll a page down"},
+	}},
+	{"Focus", []keyHelp{
+		{action: actionUp, description: "Select the previous setting"},
+		{action: actionDown, description: "Select the next setting"},
+		{action: actionIncrease, description: "Next value of the selected setting"},
+		{action: actionDecrease, description: "Previous value of the selected setting"},
+		{action: actionToggle, description: "Start the session"},
+		{action: actionExtend, description: "Extend the running session by %s", args: []interface{}{focusExtendStep}},
+		{action: actionPause, description: "Pause for %s", args: []interface{}{focusPauseStep}},
+		{action: actionResume, description: "Resume a paused session"},
+		{action: actionStop, description: "Stop the session"},
+	}},
+	{"Devices", []keyHelp{
+		{action: actionUp, description: "Select the previous device"},
+		{action: actionDown, description: "Select the next device"},
+		{action: actionToggle, description: "Switch the device between following the session, always in focus, and never in focus"},
+	}},
+	{"Commands", commandHelp},
+}
+
+// renderHelp renders the key binding overlay using the active keymap
+func (m Model) renderHelp() string {
+	var b strings.Builder
+	b.WriteString(i18n.T("Keyboard shortcuts") + "\n")
+	for _, section := range helpSections {
+		b.WriteString("\n" + i18n.T(section.title) + "\n")
+		for _, key := range section.keys {
+			keys := key.keys
+			if key.action != "" {
+				keys = m.keys.describe(key.action)
+			}
+			b.WriteString(fmt.Sprintf("  %-18s %s\n", keys, i18n.T(key.description, key.args...)))
+		}
+	}
+	b.WriteString("\n" + i18n.T("Press %s or Esc to close.", m.keys.describe(actionHelp)))
+	return b.String()
+}
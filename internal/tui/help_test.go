@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHelpSections(t *testing.T) {
+	km, err := newKeyMap(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range helpSections {
+		for _, key := range section.keys {
+			if key.action == "" {
+				continue
+			}
+			if _, ok := actionScopes[key.action]; !ok {
+				t.Errorf("%s: unknown action %q", section.title, key.action)
+			} else if km.describe(key.action) == "(unbound)" {
+				t.Errorf("%s: %q has no default key", section.title, key.action)
+			}
+		}
+	}
+}
+
+func TestRenderHelp(t *testing.T) {
+	km, err := newKeyMap(map[string][]string{actionHelp: {"f1"}, actionSearch: {"ctrl+f", "/"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	help := Model{keys: km}.renderHelp()
+
+	// describe joins the keys with slashes, so ctrl+f and / read "ctrl+f//"
+	for _, line := range []string{"ctrl+f//", "Press f1 or Esc to close."} {
+		if !strings.Contains(help, line) {
+			t.Errorf("expected the help to show the configured keys %q, got:\n%s", line, help)
+		}
+	}
+	if !strings.Contains(help, "Extend the running session by 15m0s") {
+		t.Errorf("expected the extend step in the Focus section, got:\n%s", help)
+	}
+}
@@ -0,0 +1,134 @@
+package tui
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/dns"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// hostnameLookupTimeout bounds the reverse DNS lookup of a client address
+	hostnameLookupTimeout = 2 * time.Second
+
+	// How long reverse DNS names, and lookups that found none, are reused
+	hostnameCacheTTL    = 10 * time.Minute
+	hostnameNegativeTTL = time.Minute
+
+	// maxCachedHostnames bounds the cache; it is emptied when full
+	maxCachedHostnames = 1000
+)
+
+// hostnames looks up the reverse DNS names of client addresses and caches them. Lookups go
+// to a plain upstream nameserver directly, so they never pass through sinkzone itself and
+// land in its query log or get blocked during focus mode.
+type hostnames struct {
+	cfg *config.Config
+
+	resolverOnce    sync.Once
+	resolver        *net.Resolver // nil when every way to look names up leads back to sinkzone
+	privateResolver *net.Resolver // For private addresses; nil when their names stay unknown
+
+	mu      sync.Mutex
+	entries map[string]hostnameEntry
+}
+
+type hostnameEntry struct {
+	host    string
+	expires time.Time
+}
+
+func newHostnames(cfg *config.Config) *hostnames {
+	return &hostnames{cfg: cfg, entries: make(map[string]hostnameEntry)}
+}
+
+// lookup resolves a client address to a host name in the background, answering from the
+// cache when it can
+func (h *hostnames) lookup(ip string) tea.Cmd {
+	if ip == "" {
+		return nil
+	}
+	if parsed := net.ParseIP(ip); parsed != nil && parsed.IsLoopback() {
+		return func() tea.Msg { return hostnameMsg{ip: ip, host: "(this machine)"} }
+	}
+	h.mu.Lock()
+	entry, ok := h.entries[ip]
+	h.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return func() tea.Msg { return hostnameMsg{ip: ip, host: entry.host} }
+	}
+
+	return func() tea.Msg {
+		h.resolverOnce.Do(func() {
+			h.resolver = reverseResolver(h.cfg)
+			h.privateResolver = privateReverseResolver(h.cfg, h.resolver)
+		})
+		resolver := h.resolver
+		if config.IsPrivateAddress(net.ParseIP(ip)) {
+			if h.privateResolver == nil {
+				return hostnameMsg{ip: ip, host: "(not looked up: private address)"}
+			}
+			resolver = h.privateResolver
+		}
+		if resolver == nil {
+			return hostnameMsg{ip: ip, host: "(not looked up: the system resolver is sinkzone)"}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), hostnameLookupTimeout)
+		defer cancel()
+		host, ttl := "(no reverse DNS name)", hostnameNegativeTTL
+		names, err := resolver.LookupAddr(ctx, ip)
+		if err == nil && len(names) > 0 {
+			host, ttl = strings.TrimSuffix(names[0], "."), hostnameCacheTTL
+		}
+
+		h.mu.Lock()
+		if len(h.entries) >= maxCachedHostnames {
+			clear(h.entries)
+		}
+		h.entries[ip] = hostnameEntry{host: host, expires: time.Now().Add(ttl)}
+		h.mu.Unlock()
+		return hostnameMsg{ip: ip, host: host}
+	}
+}
+
+// reverseResolver picks where reverse lookups go: the first plain UDP or TCP upstream, else
+// the system resolver unless it is on this machine, where it is likely sinkzone
+func reverseResolver(cfg *config.Config) *net.Resolver {
+	upstreams, _ := cfg.GetUpstreams()
+	if resolver := dns.PlainResolver(upstreams); resolver != nil {
+		return resolver
+	}
+
+	servers, err := sysdns.SystemResolvers()
+	if err != nil || len(servers) == 0 {
+		return nil
+	}
+	for _, server := range servers {
+		if sysdns.IsLocal(server) {
+			return nil
+		}
+	}
+	return net.DefaultResolver
+}
+
+// privateReverseResolver picks where reverse lookups of private addresses go, which only the
+// local network can answer: its nameserver (see private_zones), or the regular resolver
+// when private_zones.mode is upstream
+func privateReverseResolver(cfg *config.Config, resolver *net.Resolver) *net.Resolver {
+	mode, err := cfg.GetPrivateMode()
+	if err != nil {
+		return nil
+	}
+	if mode == config.PrivateUpstream {
+		return resolver
+	}
+	resolvers, _ := cfg.GetPrivateResolvers()
+	return dns.PlainResolver(resolvers)
+}
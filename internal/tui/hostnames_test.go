@@ -0,0 +1,138 @@
+package tui
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/dns"
+	miekg "github.com/miekg/dns"
+)
+
+// startPTRServer runs a nameserver that answers reverse lookups of 203.0.113.7 and counts
+// the queries it gets
+func startPTRServer(t *testing.T) (string, *atomic.Int64) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var queries atomic.Int64
+	started := make(chan struct{})
+	server := &miekg.Server{
+		PacketConn:        conn,
+		NotifyStartedFunc: func() { close(started) },
+		Handler: miekg.HandlerFunc(func(w miekg.ResponseWriter, r *miekg.Msg) {
+			queries.Add(1)
+			answer := new(miekg.Msg)
+			answer.SetReply(r)
+			if q := r.Question[0]; q.Name == "7.113.0.203.in-addr.arpa." {
+				answer.Answer = append(answer.Answer, &miekg.PTR{
+					Hdr: miekg.RR_Header{Name: q.Name, Rrtype: miekg.TypePTR, Class: miekg.ClassINET, Ttl: 60},
+					Ptr: "host.example.net.",
+				})
+			} else {
+				answer.Rcode = miekg.RcodeNameError
+			}
+			_ = w.WriteMsg(answer)
+		}),
+	}
+	go func() { _ = server.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = server.Shutdown() })
+	return conn.LocalAddr().String(), &queries
+}
+
+// newTestHostnames returns a cache that looks names up at addr
+func newTestHostnames(addr string) *hostnames {
+	h := newHostnames(nil)
+	h.resolverOnce.Do(func() {
+		h.resolver = dns.PlainResolver([]config.Upstream{{Protocol: config.UpstreamUDP, Address: addr}})
+	})
+	return h
+}
+
+func lookupHostname(h *hostnames, ip string) string {
+	return h.lookup(ip)().(hostnameMsg).host
+}
+
+func TestHostnames(t *testing.T) {
+	addr, queries := startPTRServer(t)
+	h := newTestHostnames(addr)
+
+	tests := []struct {
+		ip      string
+		host    string
+		queries int64 // Total queries sent to the nameserver after the lookup
+	}{
+		{"203.0.113.7", "host.example.net", 1},
+		{"203.0.113.7", "host.example.net", 1},
+		{"203.0.113.8", "(no reverse DNS name)", 2},
+		{"203.0.113.8", "(no reverse DNS name)", 2},
+		{"127.0.0.1", "(this machine)", 2},
+		{"192.168.1.10", "(not looked up: private address)", 2},
+	}
+	for _, test := range tests {
+		if host := lookupHostname(h, test.ip); host != test.host {
+			t.Errorf("%s: expected %q, got %q", test.ip, test.host, host)
+		}
+		if got := queries.Load(); got != test.queries {
+			t.Errorf("%s: expected %d queries in total, got %d", test.ip, test.queries, got)
+		}
+	}
+
+	if ttl := time.Until(h.entries["203.0.113.7"].expires); ttl <= hostnameNegativeTTL || ttl > hostnameCacheTTL {
+		t.Errorf("expected a name to be cached for %v, got %v", hostnameCacheTTL, ttl)
+	}
+	if ttl := time.Until(h.entries["203.0.113.8"].expires); ttl > hostnameNegativeTTL {
+		t.Errorf("expected a miss to be cached for %v, got %v", hostnameNegativeTTL, ttl)
+	}
+
+	h.entries["203.0.113.7"] = hostnameEntry{host: "stale.example.net", expires: time.Now().Add(-time.Second)}
+	if host := lookupHostname(h, "203.0.113.7"); host != "host.example.net" || queries.Load() != 3 {
+		t.Errorf("expected an expired name to be looked up again, got %q after %d queries", host, queries.Load())
+	}
+}
+
+func TestHostnamesFull(t *testing.T) {
+	addr, _ := startPTRServer(t)
+	h := newTestHostnames(addr)
+	for i := range maxCachedHostnames {
+		h.entries[net.IPv4(198, 51, byte(i/256), byte(i%256)).String()] = hostnameEntry{host: "cached", expires: time.Now().Add(time.Hour)}
+	}
+
+	lookupHostname(h, "203.0.113.7")
+	if len(h.entries) != 1 {
+		t.Errorf("expected a full cache to be emptied, got %d entries", len(h.entries))
+	}
+}
+
+func TestHostnamesConcurrent(t *testing.T) {
+	addr, _ := startPTRServer(t)
+	h := newTestHostnames(addr)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		ip := "203.0.113.7"
+		if i%2 == 1 {
+			ip = "203.0.113.8"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			want := map[string]string{"203.0.113.7": "host.example.net", "203.0.113.8": "(no reverse DNS name)"}[ip]
+			if host := lookupHostname(h, ip); host != want {
+				t.Errorf("%s: expected %q, got %q", ip, want, host)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(h.entries) != 2 {
+		t.Errorf("expected both addresses to be cached, got %v", h.entries)
+	}
+}
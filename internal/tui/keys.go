@@ -0,0 +1,325 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Actions that can be bound to keys in the keymap config section
+const (
+	actionQuit          = "quit"
+	actionHelp          = "help"
+	actionMessages      = "messages"
+	actionSettings      = "settings"
+	actionCommand       = "command"
+	actionSuggestions   = "suggestions"
+	actionPrevTab       = "prev_tab"
+	actionNextTab       = "next_tab"
+	actionTabMonitoring = "tab_monitoring"
+	actionTabAllowlist  = "tab_allowlist"
+	actionTabStats      = "tab_stats"
+	actionTabFocus      = "tab_focus"
+	actionTabDevices    = "tab_devices"
+	actionUp            = "up"
+	actionDown          = "down"
+	actionPageUp        = "page_up"
+	actionPageDown      = "page_down"
+	actionTop           = "top"
+	actionBottom        = "bottom"
+	actionToggle        = "toggle"
+	actionMark          = "mark"
+	actionBatch         = "batch"
+	actionSearch        = "search"
+	actionDetail        = "detail"
+	actionFocus         = "focus"
+	actionProfile       = "profile"
+	actionIncrease      = "increase"
+	actionDecrease      = "decrease"
+	actionExtend        = "extend"
+	actionPause         = "pause"
+	actionResume        = "resume"
+	actionFollow        = "follow"
+	actionSort          = "sort"
+	actionIgnore        = "ignore"
+	actionUndo          = "undo"
+	actionStop          = "stop"
+	actionExportCSV     = "export_csv"
+	actionExportJSON    = "export_json"
+)
+
+// Scopes decide where an action's keys apply. Global actions work everywhere, shared ones
+// in every tab, and the rest only in their tab, so tabs can reuse the same keys.
+const (
+	scopeGlobal     = "global"
+	scopeShared     = "shared"
+	scopeMonitoring = "monitoring"
+	scopeAllowlist  = "allowlist"
+	scopeFocus      = "focus"
+	scopeDevices    = "devices"
+	scopeTables     = "tables" // Monitoring and Allowlist
+)
+
+// actionScopes assigns every action to a scope
+var actionScopes = map[string]string{
+	actionQuit:          scopeGlobal,
+	actionHelp:          scopeGlobal,
+	actionMessages:      scopeGlobal,
+	actionSettings:      scopeGlobal,
+	actionCommand:       scopeGlobal,
+	actionSuggestions:   scopeGlobal,
+	actionPrevTab:       scopeGlobal,
+	actionNextTab:       scopeGlobal,
+	actionTabMonitoring: scopeGlobal,
+	actionTabAllowlist:  scopeGlobal,
+	actionTabStats:      scopeGlobal,
+	actionTabFocus:      scopeGlobal,
+	actionTabDevices:    scopeGlobal,
+	actionFocus:         scopeGlobal,
+	actionProfile:       scopeGlobal,
+	actionUp:            scopeShared,
+	actionDown:          scopeShared,
+	actionPageUp:        scopeShared,
+	actionPageDown:      scopeShared,
+	actionTop:           scopeShared,
+	actionBottom:        scopeShared,
+	actionToggle:        scopeShared,
+	actionPause:         scopeShared,
+	actionResume:        scopeShared,
+	actionSearch:        scopeMonitoring,
+	actionDetail:        scopeMonitoring,
+	actionFollow:        scopeMonitoring,
+	actionSort:          scopeMonitoring,
+	actionIgnore:        scopeMonitoring,
+	actionMark:          scopeTables,
+	actionBatch:         scopeTables,
+	actionUndo:          scopeTables,
+	actionExportCSV:     scopeTables,
+	actionExportJSON:    scopeTables,
+	actionIncrease:      scopeFocus,
+	actionDecrease:      scopeFocus,
+	actionExtend:        scopeFocus,
+	actionStop:          scopeFocus,
+}
+
+// defaultKeys are the bindings used for actions the config doesn't rebind
+var defaultKeys = map[string][]string{
+	actionQuit:          {"esc"},
+	actionHelp:          {"?"},
+	actionMessages:      {"m"},
+	actionSettings:      {","},
+	actionCommand:       {":"},
+	actionSuggestions:   {"S"},
+	actionPrevTab:       {"left", "h"},
+	actionNextTab:       {"right", "l"},
+	actionTabMonitoring: {"1"},
+	actionTabAllowlist:  {"2"},
+	actionTabStats:      {"3"},
+	actionTabFocus:      {"4"},
+	actionTabDevices:    {"5"},
+	actionUp:            {"up", "k"},
+	actionDown:          {"down", "j"},
+	actionPageUp:        {"pgup"},
+	actionPageDown:      {"pgdown"},
+	actionTop:           {"home", "g"},
+	actionBottom:        {"end", "G"},
+	actionToggle:        {"enter"},
+	actionMark:          {" "},
+	actionBatch:         {"b"},
+	actionUndo:          {"u"},
+	actionSearch:        {"/"},
+	actionDetail:        {"d"},
+	actionFollow:        {"t"},
+	actionSort:          {"o"},
+	actionIgnore:        {"i"},
+	actionFocus:         {"f"},
+	actionProfile:       {"P"},
+	actionIncrease:      {"+", "="},
+	actionDecrease:      {"-"},
+	actionExtend:        {"e"},
+	actionPause:         {"p"},
+	actionResume:        {"r"},
+	actionStop:          {"s"},
+	actionExportCSV:     {"e"},
+	actionExportJSON:    {"E"},
+}
+
+// keyMap resolves pressed keys to actions
+type keyMap struct {
+	actions map[string][]string // Key -> actions, at most one per scope
+	keys    map[string][]string // Action -> keys
+}
+
+// newKeyMap applies keymap overrides on top of the defaults. A key bound by an override
+// is taken away from any action it would clash with. When two overrides bind the same key
+// where both apply, the later action in alphabetical order keeps it and the conflict is
+// reported. Ctrl+C always quits.
+func newKeyMap(overrides map[string][]string) (keyMap, error) {
+	km := keyMap{actions: make(map[string][]string), keys: make(map[string][]string)}
+	for action, keys := range defaultKeys {
+		km.bind(action, keys)
+	}
+
+	var unknown, conflicts []string
+	overridden := make(map[string][]string) // Key -> actions the overrides bound it to
+	for _, action := range sortedActions(overrides) {
+		if _, ok := actionScopes[action]; !ok {
+			unknown = append(unknown, action)
+			continue
+		}
+		for _, key := range km.keys[action] {
+			km.actions[key] = removeKey(km.actions[key], action)
+		}
+		km.keys[action] = nil
+
+		var keys []string
+		for _, key := range overrides[action] {
+			key = normalizeKey(key)
+			for _, other := range overridden[key] {
+				if other != action && scopesOverlap(actionScopes[other], actionScopes[action]) {
+					conflicts = append(conflicts, fmt.Sprintf("%s is bound to both %s and %s (%s keeps it)", displayKey(key), other, action, action))
+				}
+			}
+			overridden[key] = append(overridden[key], action)
+			keys = append(keys, key)
+		}
+		km.bind(action, keys)
+	}
+
+	var problems []string
+	if len(unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown keymap actions: %s", strings.Join(unknown, ", ")))
+	}
+	if len(conflicts) > 0 {
+		problems = append(problems, fmt.Sprintf("conflicting keymap bindings: %s", strings.Join(conflicts, "; ")))
+	}
+	if len(problems) > 0 {
+		return km, errors.New(strings.Join(problems, "; "))
+	}
+	return km, nil
+}
+
+// bind assigns keys to an action, removing them from actions they would clash with
+func (km keyMap) bind(action string, keys []string) {
+	for _, key := range keys {
+		for _, previous := range km.actions[key] {
+			if previous != action && scopesOverlap(actionScopes[previous], actionScopes[action]) {
+				km.keys[previous] = removeKey(km.keys[previous], key)
+				km.actions[key] = removeKey(km.actions[key], previous)
+			}
+		}
+		km.actions[key] = append(removeKey(km.actions[key], action), action)
+	}
+	km.keys[action] = append(km.keys[action], keys...)
+}
+
+// action returns the action a key triggers from a scope, or "" if the key is unbound there.
+// Global actions are found from every scope, and shared ones from every tab.
+func (km keyMap) action(key, scope string) string {
+	if key == "ctrl+c" {
+		return actionQuit
+	}
+	for _, action := range km.actions[key] {
+		if reachable(actionScopes[action], scope) {
+			return action
+		}
+	}
+	return ""
+}
+
+// reachable reports whether an action in actionScope can be triggered from scope
+func reachable(actionScope, scope string) bool {
+	switch {
+	case actionScope == scope, actionScope == scopeGlobal:
+		return true
+	case scope == scopeGlobal:
+		return false
+	case actionScope == scopeShared:
+		return true
+	}
+	return actionScope == scopeTables && (scope == scopeMonitoring || scope == scopeAllowlist)
+}
+
+// scopesOverlap reports whether actions in the two scopes can be triggered from the same place
+func scopesOverlap(a, b string) bool {
+	if a == b || a == scopeGlobal || b == scopeGlobal || a == scopeShared || b == scopeShared {
+		return true
+	}
+	tables := func(scope string) bool {
+		return scope == scopeTables || scope == scopeMonitoring || scope == scopeAllowlist
+	}
+	return (a == scopeTables && tables(b)) || (b == scopeTables && tables(a))
+}
+
+// describe formats the keys bound to an action for the footer and help overlay
+func (km keyMap) describe(action string) string {
+	keys := km.keys[action]
+	if len(keys) == 0 {
+		return "(unbound)"
+	}
+
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = displayKey(key)
+	}
+	return strings.Join(names, "/")
+}
+
+// normalizeKey maps the names accepted in the config to the key strings bubbletea reports
+func normalizeKey(key string) string {
+	switch strings.ToLower(key) {
+	case "space":
+		return " "
+	case "escape":
+		return "esc"
+	case "return":
+		return "enter"
+	case "pageup":
+		return "pgup"
+	case "pagedown":
+		return "pgdown"
+	}
+	return key
+}
+
+func displayKey(key string) string {
+	switch key {
+	case " ":
+		return "Space"
+	case "up":
+		return "↑"
+	case "down":
+		return "↓"
+	case "left":
+		return "←"
+	case "right":
+		return "→"
+	case "enter", "esc", "home", "end":
+		return strings.ToUpper(key[:1]) + key[1:]
+	case "pgup":
+		return "PgUp"
+	case "pgdown":
+		return "PgDn"
+	}
+	return key
+}
+
+func removeKey(keys []string, key string) []string {
+	var result []string
+	for _, k := range keys {
+		if k != key {
+			result = append(result, k)
+		}
+	}
+	return result
+}
+
+func sortedActions(overrides map[string][]string) []string {
+	actions := make([]string, 0, len(overrides))
+	for action := range overrides {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	return actions
+}
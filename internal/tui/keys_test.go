@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewKeyMap(t *testing.T) {
+	km, err := newKeyMap(map[string][]string{
+		actionQuit:   {"q"},
+		actionMark:   {"Space", "x"},
+		actionPageUp: {"PageUp"},
+		actionSearch: {"j"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		key, scope, action string
+	}{
+		{"q", scopeMonitoring, actionQuit},
+		{"esc", scopeMonitoring, ""}, // Replaced by the override
+		{"ctrl+c", scopeFocus, actionQuit},
+		{" ", scopeAllowlist, actionMark},
+		{"x", scopeMonitoring, actionMark},
+		{"pgup", scopeFocus, actionPageUp},
+		// j is taken from down where search applies, and kept where it doesn't
+		{"j", scopeMonitoring, actionSearch},
+		{"j", scopeFocus, ""},
+		{"k", scopeFocus, actionUp},
+		// Tabs reuse keys: e extends in Focus and exports elsewhere
+		{"e", scopeFocus, actionExtend},
+		{"e", scopeAllowlist, actionExportCSV},
+	}
+	for _, test := range tests {
+		if action := km.action(test.key, test.scope); action != test.action {
+			t.Errorf("%q in %s: expected %q, got %q", test.key, test.scope, test.action, action)
+		}
+	}
+	if keys := km.describe(actionMark); keys != "Space/x" {
+		t.Errorf("expected the normalized keys to be described, got %s", keys)
+	}
+}
+
+func TestNewKeyMapProblems(t *testing.T) {
+	km, err := newKeyMap(map[string][]string{
+		"warp":       {"w"},
+		actionDetail: {"z"},
+		actionSort:   {"z"},
+		actionExtend: {"y"},
+		actionUndo:   {"y"}, // Focus and the tables don't overlap
+	})
+	if err == nil {
+		t.Fatal("expected the unknown action and the conflict to be reported")
+	}
+	for _, part := range []string{"unknown keymap actions: warp", "z is bound to both detail and sort (sort keeps it)"} {
+		if !strings.Contains(err.Error(), part) {
+			t.Errorf("expected %q in %q", part, err)
+		}
+	}
+	if strings.Contains(err.Error(), "extend") {
+		t.Errorf("expected keys shared by separate tabs not to conflict, got %q", err)
+	}
+	if action := km.action("z", scopeMonitoring); action != actionSort {
+		t.Errorf("expected sort to keep z, got %q", action)
+	}
+}
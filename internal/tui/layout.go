@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	compactBannerMinHeight = 30 // Terminals shorter than this show a one-line title instead of the banner
+	minInnerWidth          = 20 // Narrowest content area the tables are laid out for
+	narrowTableWidth       = 50 // Below this the monitoring table drops its time column
+)
+
+// bannerWidth is the width of the ASCII art banner
+var bannerWidth = lipgloss.Width(strings.Trim(sinkzoneBanner, "\n"))
+
+// layout holds the sizes of the screen areas for the current terminal size
+type layout struct {
+	compactBanner bool
+	contentHeight int // Height passed to contentStyle, including its padding
+	innerWidth    int // Text width inside the content box
+	innerHeight   int // Text lines inside the content box
+}
+
+func (m Model) layout() layout {
+	l := layout{compactBanner: m.height < compactBannerMinHeight || m.width < bannerWidth+4}
+
+	// Header, tabs, content box with its border, message, bypass, portal, and status bars, footer
+	headerHeight := lipgloss.Height(m.renderHeader(l.compactBanner))
+	tabHeight := 1
+	footerHeight := 1
+	if m.apiError != "" {
+		footerHeight++
+	}
+	if m.renderBypassBanner() != "" {
+		footerHeight++
+	}
+	if m.renderPortalBanner() != "" {
+		footerHeight++
+	}
+	if m.activeMessage() != nil {
+		footerHeight++
+	}
+	l.contentHeight = max(m.height-headerHeight-tabHeight-footerHeight-2, 5)
+
+	// contentStyle has a one-cell border and 1x2 padding
+	l.innerWidth = max(m.width-8, minInnerWidth)
+	l.innerHeight = l.contentHeight - 2
+	return l
+}
+
+// renderCompactHeader renders the one-line title and health indicator used on small terminals
+func (m Model) renderCompactHeader() string {
+	title := "SINKZONE"
+	style := headerStyle.Margin(0).Padding(0).Width(m.width).MaxHeight(1)
+	if m.focusModeActive {
+		title += "  " + m.focusIndicatorText()
+		style = style.Background(currentTheme.AlertBackground).Foreground(currentTheme.Alert)
+	}
+	return style.Render(title + "  " + m.healthIndicator())
+}
+
+// renderTable lays out rows with a bubbles table, highlighting the row at cursor
+func renderTable(columns []table.Column, rows []table.Row, cursor int, highlight lipgloss.Color) string {
+	styles := table.DefaultStyles()
+	styles.Header = styles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(currentTheme.Border).
+		BorderBottom(true).
+		Bold(true)
+	styles.Selected = lipgloss.NewStyle().
+		Background(highlight).
+		Foreground(currentTheme.OnColor)
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithStyles(styles),
+		table.WithRows(rows),
+	)
+	t.SetHeight(len(rows) + lipgloss.Height(styles.Header.Render("")))
+	t.SetCursor(cursor)
+	return t.View()
+}
+
+// fitColumns sizes the first column to take the width the other columns leave over
+func fitColumns(width int, columns []table.Column) []table.Column {
+	used := 0
+	for _, column := range columns[1:] {
+		used += column.Width + 2 // Cells are padded by one space on each side
+	}
+	columns[0].Width = max(width-used-2, 10)
+	return columns
+}
+
+// renderPane renders scrollable content sized to the content box
+func (m Model) renderPane(content string) string {
+	l := m.layout()
+	pane := m.pane
+	pane.Width = l.innerWidth
+	pane.Height = l.innerHeight
+	pane.SetContent(content)
+	return pane.View()
+}
+
+// updatePane scrolls the content rendered by renderPane
+func (m *Model) updatePane(msg tea.KeyMsg, content string) (Model, tea.Cmd) {
+	l := m.layout()
+	m.pane.Width = l.innerWidth
+	m.pane.Height = l.innerHeight
+	m.pane.SetContent(content)
+
+	switch m.keys.action(msg.String(), scopeShared) {
+	case actionUp:
+		m.pane.ScrollUp(1)
+	case actionDown:
+		m.pane.ScrollDown(1)
+	case actionPageUp:
+		m.pane.PageUp()
+	case actionPageDown:
+		m.pane.PageDown()
+	case actionTop:
+		m.pane.GotoTop()
+	case actionBottom:
+		m.pane.GotoBottom()
+	}
+	return *m, nil
+}
+
+// newPane creates the viewport used for the Stats, Focus, and help content
+func newPane() viewport.Model {
+	return viewport.New(minInnerWidth, 5)
+}
@@ -0,0 +1,52 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+func TestFitColumns(t *testing.T) {
+	tests := []struct {
+		width    int
+		expected int
+	}{
+		{80, 80 - (20 + 2) - (10 + 2) - 2},
+		{40, 10}, // The first column never gets narrower than 10
+	}
+
+	for _, test := range tests {
+		columns := fitColumns(test.width, []table.Column{{Title: "Domain"}, {Title: "Time", Width: 20}, {Title: "Status", Width: 10}})
+		if columns[0].Width != test.expected {
+			t.Errorf("width %d: expected the first column to be %d wide, got %d", test.width, test.expected, columns[0].Width)
+		}
+	}
+}
+
+func TestLayout(t *testing.T) {
+	tests := []struct {
+		width, height int
+		compact       bool
+		innerWidth    int
+	}{
+		{120, 50, false, 112},
+		{120, 20, true, 112},
+		{bannerWidth, 50, true, bannerWidth - 8},
+		{10, 4, true, minInnerWidth},
+	}
+
+	for _, test := range tests {
+		m := Model{width: test.width, height: test.height}
+		l := m.layout()
+		if l.compactBanner != test.compact || l.innerWidth != test.innerWidth {
+			t.Errorf("%dx%d: expected compact %v and width %d, got %v and %d", test.width, test.height, test.compact, test.innerWidth, l.compactBanner, l.innerWidth)
+		}
+		// Tiny terminals still get the minimum content height of 5
+		if l.contentHeight < 5 || l.innerHeight != l.contentHeight-2 || (test.height > 5 && l.contentHeight >= test.height) {
+			t.Errorf("%dx%d: content height %d and inner height %d don't fit the screen", test.width, test.height, l.contentHeight, l.innerHeight)
+		}
+		if rows := m.maxVisibleQueries(); rows < 3 {
+			t.Errorf("%dx%d: expected at least 3 table rows, got %d", test.width, test.height, rows)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+)
+
+// toggleMark marks or unmarks a domain for a batch action
+func toggleMark(marks map[string]bool, domain string) map[string]bool {
+	if marks == nil {
+		marks = make(map[string]bool)
+	}
+	if marks[domain] {
+		delete(marks, domain)
+	} else {
+		marks[domain] = true
+	}
+	return marks
+}
+
+// markedDomains lists the marked domains that are still present, in table order
+func markedDomains(marks map[string]bool, domains []string) []string {
+	var marked []string
+	for _, domain := range domains {
+		if marks[domain] {
+			marked = append(marked, domain)
+		}
+	}
+	return marked
+}
+
+// markCell prefixes a table cell with the mark indicator
+func markCell(marked bool, text string) string {
+	if marked {
+		return "◆ " + text
+	}
+	return "  " + text
+}
+
+// countedDomain renders a query's domain with the repeats counted in its entry, e.g.
+// "github.com ×42"
+func countedDomain(query api.DNSQuery) string {
+	if query.Count > 1 {
+		return fmt.Sprintf("%s ×%d", query.Domain, query.Count)
+	}
+	return query.Domain
+}
+
+// queryDomains lists the domains of the recorded queries, newest first, each once
+func (m Model) queryDomains() []string {
+	domains := make([]string, 0, len(m.monitoring.allQueries))
+	seen := make(map[string]bool, len(m.monitoring.allQueries))
+	for i := len(m.monitoring.allQueries) - 1; i >= 0; i-- {
+		domain := m.monitoring.allQueries[i].Domain
+		if !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// applyMarkedQueries allows every marked query domain in one allowlist write, or removes
+// them when all of them are already allowlisted
+func (m *Model) applyMarkedQueries() {
+	marked := markedDomains(m.monitoring.marked, m.queryDomains())
+	if len(marked) == 0 {
+		m.notify(severityInfo, fmt.Sprintf("Mark rows with %s first", m.keys.describe(actionMark)))
+		return
+	}
+
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		m.notifyError("Could not open the allowlist", err)
+		return
+	}
+
+	allAllowed := true
+	for _, domain := range marked {
+		if !m.isInAllowlist(domain) {
+			allAllowed = false
+			break
+		}
+	}
+
+	if allAllowed {
+		removed, err := manager.RemoveAll(marked)
+		if err != nil {
+			m.notifyError("Could not remove the marked domains", err)
+			return
+		}
+		m.notify(severitySuccess, fmt.Sprintf("Removed %d domains from the allowlist", len(removed)))
+	} else {
+		added, err := manager.AddAll(marked)
+		if err != nil {
+			m.notifyError("Could not allow the marked domains", err)
+			return
+		}
+		m.notify(severitySuccess, fmt.Sprintf("Allowed %d domains: %s", len(added), strings.Join(added, ", ")))
+	}
+
+	m.monitoring.marked = nil
+	m.loadAllowlistData()
+	m.lastChangedDomain = marked[0]
+	m.lastChangeTime = time.Now()
+}
+
+// removeMarkedDomains removes every marked allowlist entry in one write
+func (m *Model) removeMarkedDomains() {
+	marked := markedDomains(m.allowedDomains.marked, m.allowedDomains.domains)
+	if len(marked) == 0 {
+		m.notify(severityInfo, fmt.Sprintf("Mark rows with %s first", m.keys.describe(actionMark)))
+		return
+	}
+
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		m.notifyError("Could not open the allowlist", err)
+		return
+	}
+	removed, err := manager.RemoveAll(marked)
+	if err != nil {
+		m.notifyError("Could not remove the marked domains", err)
+		return
+	}
+
+	m.allowedDomains.marked = nil
+	m.loadAllowlistData()
+	m.notify(severitySuccess, fmt.Sprintf("Removed %d domains from the allowlist", len(removed)))
+}
+
+// markSummary describes the marked rows for a table footer, or returns "" when none are marked
+func (m Model) markSummary(count int, verb string) string {
+	if count == 0 {
+		return ""
+	}
+	return i18n.T("%d marked: %s to %s, Esc to clear", count, m.keys.describe(actionBatch), verb) + " | "
+}
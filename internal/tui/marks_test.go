@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMarkedDomains(t *testing.T) {
+	var marks map[string]bool
+	for _, domain := range []string{"a.com", "b.com", "c.com", "b.com", "gone.com"} {
+		marks = toggleMark(marks, domain)
+	}
+
+	if got := markedDomains(marks, []string{"c.com", "b.com", "a.com"}); !slices.Equal(got, []string{"c.com", "a.com"}) {
+		t.Errorf("expected the marked domains still listed, in table order, got %v", got)
+	}
+	if got := markCell(true, "a.com"); got != "◆ a.com" {
+		t.Errorf("expected a marked cell, got %q", got)
+	}
+	if got := markCell(false, "a.com"); got != "  a.com" {
+		t.Errorf("expected an unmarked cell to keep its width, got %q", got)
+	}
+}
+
+// testAllowlist points the allowlist at a temporary directory holding domains
+func testAllowlist(t *testing.T, domains ...string) *allowlist.Manager {
+	t.Helper()
+
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	t.Setenv(config.SystemConfigEnv, "none")
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.AddAll(domains); err != nil {
+		t.Fatal(err)
+	}
+	return manager
+}
+
+func TestMarkAllowedDomains(t *testing.T) {
+	manager := testAllowlist(t, "a.com", "b.com", "c.com")
+	keys, _ := newKeyMap(nil)
+	m := Model{keys: keys, activeTab: 1}
+	m.loadAllowlistData()
+
+	mark := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")}
+	batch := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")}
+	m, _ = m.updateAllowedDomains(batch)
+	if msg := m.activeMessage(); msg.severity != severityInfo {
+		t.Errorf("expected a hint to mark rows first, got %+v", msg)
+	}
+
+	m, _ = m.updateAllowedDomains(mark)
+	m, _ = m.updateAllowedDomains(mark)
+	if got := markedDomains(m.allowedDomains.marked, m.allowedDomains.domains); !slices.Equal(got, []string{"a.com", "b.com"}) || m.allowedDomains.cursor != 2 {
+		t.Fatalf("expected marking to mark a.com and b.com and move down, got %v at %d", got, m.allowedDomains.cursor)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = updated.(Model)
+	if m.quitting || len(m.allowedDomains.marked) != 0 {
+		t.Fatalf("expected Esc to clear the marks, got %v (quitting %v)", m.allowedDomains.marked, m.quitting)
+	}
+
+	m.allowedDomains.cursor = 0
+	m, _ = m.updateAllowedDomains(mark)
+	m, _ = m.updateAllowedDomains(mark)
+	m, _ = m.updateAllowedDomains(batch)
+	if domains, _ := manager.List(); !slices.Equal(domains, []string{"c.com"}) || !slices.Equal(m.allowedDomains.domains, domains) {
+		t.Errorf("expected the marked domains to be removed, got %v shown as %v", domains, m.allowedDomains.domains)
+	}
+	if m.allowedDomains.marked != nil || m.activeMessage().severity != severitySuccess {
+		t.Errorf("expected the marks to be cleared after the batch, got %v and %+v", m.allowedDomains.marked, m.activeMessage())
+	}
+	if journal, _ := manager.Journal(); len(journal) != 2 {
+		t.Errorf("expected the batch to be one journaled change, got %v", journal)
+	}
+}
+
+func TestMarkQueries(t *testing.T) {
+	manager := testAllowlist(t, "a.com")
+	keys, _ := newKeyMap(nil)
+	m := Model{keys: keys, monitoring: MonitoringState{allQueries: []api.DNSQuery{
+		{Domain: "a.com"},
+		{Domain: "b.com"},
+	}}}
+	m.loadAllowlistData()
+	m.applyQueryFilter()
+
+	mark := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")}
+	batch := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")}
+	m, _ = m.updateMonitoring(mark)
+	m, _ = m.updateMonitoring(mark)
+	m, _ = m.updateMonitoring(batch)
+	if domains, _ := manager.List(); !slices.Equal(domains, []string{"a.com", "b.com"}) || m.monitoring.marked != nil {
+		t.Fatalf("expected the unlisted marked domain to be allowed, got %v (%+v)", domains, m.activeMessage())
+	}
+
+	// Once every marked domain is allowlisted, the batch removes them instead
+	m.monitoring.tableCursor = 0
+	m, _ = m.updateMonitoring(mark)
+	m, _ = m.updateMonitoring(mark)
+	m, _ = m.updateMonitoring(batch)
+	if domains, _ := manager.List(); len(domains) != 0 {
+		t.Errorf("expected the allowlisted marked domains to be removed, got %v", domains)
+	}
+}
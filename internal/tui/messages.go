@@ -0,0 +1,119 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// severity orders message bar entries from least to most important
+type severity int
+
+const (
+	severityInfo severity = iota
+	severitySuccess
+	severityWarning
+	severityError
+)
+
+const (
+	messageTimeout      = 4 * time.Second  // How long info and success messages stay in the bar
+	errorMessageTimeout = 10 * time.Second // How long warnings and errors stay in the bar
+	maxMessageHistory   = 50               // Messages kept for the history overlay
+)
+
+// statusMessage is one message shown in the message bar and kept in the history
+type statusMessage struct {
+	text     string
+	severity severity
+	at       time.Time
+}
+
+// notify shows a message in the message bar and records it in the history
+func (m *Model) notify(level severity, text string) {
+	m.messages = append(m.messages, statusMessage{text: text, severity: level, at: time.Now()})
+	if len(m.messages) > maxMessageHistory {
+		m.messages = m.messages[len(m.messages)-maxMessageHistory:]
+	}
+}
+
+// notifyError reports a failed action in the message bar
+func (m *Model) notifyError(action string, err error) {
+	m.notify(severityError, fmt.Sprintf("%s: %v", action, err))
+}
+
+// activeMessage returns the latest message while it is still shown, or nil
+func (m Model) activeMessage() *statusMessage {
+	if len(m.messages) == 0 {
+		return nil
+	}
+	latest := m.messages[len(m.messages)-1]
+	timeout := messageTimeout
+	if latest.severity >= severityWarning {
+		timeout = errorMessageTimeout
+	}
+	if time.Since(latest.at) > timeout {
+		return nil
+	}
+	return &latest
+}
+
+// renderMessageBar renders the latest message in its severity colour, or "" when there is none
+func (m Model) renderMessageBar() string {
+	message := m.activeMessage()
+	if message == nil {
+		return ""
+	}
+
+	return lipgloss.NewStyle().
+		Background(severityColor(message.severity)).
+		Foreground(currentTheme.OnColor).
+		Bold(true).
+		Padding(0, 1).
+		Width(m.width).
+		MaxHeight(1).
+		Render(severityIcon(message.severity) + " " + message.text)
+}
+
+// renderMessageHistory lists recent messages, newest first, for the history overlay
+func (m Model) renderMessageHistory() string {
+	var b strings.Builder
+	b.WriteString(i18n.T("Messages") + "\n\n")
+	if len(m.messages) == 0 {
+		b.WriteString(i18n.T("No messages yet.") + "\n")
+	}
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		message := m.messages[i]
+		icon := lipgloss.NewStyle().Foreground(severityColor(message.severity)).Render(severityIcon(message.severity))
+		b.WriteString(fmt.Sprintf("%s %s  %s\n", message.at.Format("15:04:05"), icon, message.text))
+	}
+	b.WriteString("\n" + i18n.T("Press %s or Esc to close.", m.keys.describe(actionMessages)))
+	return b.String()
+}
+
+func severityColor(level severity) lipgloss.Color {
+	switch level {
+	case severitySuccess:
+		return currentTheme.Success
+	case severityWarning:
+		return currentTheme.Warning
+	case severityError:
+		return currentTheme.Alert
+	}
+	return currentTheme.Border
+}
+
+func severityIcon(level severity) string {
+	switch level {
+	case severitySuccess:
+		return "✓"
+	case severityWarning:
+		return "⚠"
+	case severityError:
+		return "✗"
+	}
+	return "ℹ"
+}
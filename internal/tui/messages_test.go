@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestActiveMessage(t *testing.T) {
+	tests := []struct {
+		severity severity
+		age      time.Duration
+		shown    bool
+	}{
+		{severityInfo, time.Second, true},
+		{severityInfo, messageTimeout + time.Second, false},
+		{severitySuccess, messageTimeout + time.Second, false},
+		{severityWarning, messageTimeout + time.Second, true},
+		{severityError, messageTimeout + time.Second, true},
+		{severityError, errorMessageTimeout + time.Second, false},
+	}
+
+	for _, test := range tests {
+		m := Model{messages: []statusMessage{{text: "saved", severity: test.severity, at: time.Now().Add(-test.age)}}}
+		if shown := m.activeMessage() != nil; shown != test.shown {
+			t.Errorf("severity %d after %v: expected shown %v, got %v", test.severity, test.age, test.shown, shown)
+		}
+		if bar := m.renderMessageBar(); (bar != "") != test.shown {
+			t.Errorf("severity %d after %v: expected a message bar %v, got %q", test.severity, test.age, test.shown, bar)
+		}
+	}
+
+	if (Model{}).activeMessage() != nil {
+		t.Error("expected no message without any")
+	}
+}
+
+func TestMessageHistory(t *testing.T) {
+	keys, _ := newKeyMap(nil)
+	m := Model{keys: keys}
+	for i := 1; i <= maxMessageHistory+10; i++ {
+		m.notify(severityInfo, fmt.Sprintf("message %d", i))
+	}
+
+	if len(m.messages) != maxMessageHistory || m.messages[0].text != "message 11" {
+		t.Fatalf("expected the last %d messages, got %d starting with %q", maxMessageHistory, len(m.messages), m.messages[0].text)
+	}
+	if message := m.activeMessage(); message == nil || message.text != fmt.Sprintf("message %d", maxMessageHistory+10) {
+		t.Errorf("expected the newest message in the bar, got %+v", message)
+	}
+
+	history := m.renderMessageHistory()
+	newest := strings.Index(history, fmt.Sprintf("message %d", maxMessageHistory+10))
+	oldest := strings.Index(history, "message 11\n")
+	if newest < 0 || oldest < 0 || newest > oldest || strings.Contains(history, "message 10\n") {
+		t.Errorf("expected the kept messages newest first, got %q", history)
+	}
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// pinPrompt asks for the focus PIN before running an action that loosens focus
+type pinPrompt struct {
+	title  string // What the PIN is for, e.g. "Stop focus mode"
+	pin    string
+	action func(m *Model, pin string)
+}
+
+// pinConfigured reports whether a focus PIN is set in sinkzone.yaml
+func (m Model) pinConfigured() bool {
+	return m.config != nil && m.config.FocusPINHash != ""
+}
+
+// withPIN runs an action that loosens focus, asking for the focus PIN first when one is set
+func (m *Model) withPIN(title string, action func(m *Model, pin string)) {
+	if !m.pinConfigured() {
+		action(m, "")
+		return
+	}
+	m.pinPrompt = &pinPrompt{title: title, action: action}
+}
+
+// updatePIN edits the PIN prompt, running its action on Enter
+func (m *Model) updatePIN(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case tea.KeyEsc:
+		m.pinPrompt = nil
+		m.notify(severityInfo, "Cancelled")
+	case tea.KeyEnter:
+		prompt := m.pinPrompt
+		m.pinPrompt = nil
+		prompt.action(m, prompt.pin)
+	case tea.KeyBackspace:
+		if runes := []rune(m.pinPrompt.pin); len(runes) > 0 {
+			m.pinPrompt.pin = string(runes[:len(runes)-1])
+		}
+	case tea.KeyRunes:
+		m.pinPrompt.pin += string(msg.Runes)
+	}
+	return *m, nil
+}
+
+// renderPINPrompt renders the masked PIN being typed in place of the footer
+func (m Model) renderPINPrompt() string {
+	masked := strings.Repeat("•", len([]rune(m.pinPrompt.pin)))
+	return footerStyle.Width(m.width).MaxHeight(1).Render(i18n.T("%s: focus PIN %s█ (Enter to confirm, Esc to cancel)", i18n.T(m.pinPrompt.title), masked))
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPINPrompt(t *testing.T) {
+	hash, err := config.HashPIN("1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{FocusPINHash: hash}
+	r := sinktest.Start(t, sinktest.Options{Config: cfg})
+	keys, _ := newKeyMap(nil)
+	m := Model{apiClient: r.Client, config: cfg, keys: keys}
+	r.Focus(t, time.Hour)
+	m.updateFocusModeStatus()
+
+	stop := func(pin string) {
+		m, _ = m.updateFocus(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+		if m.pinPrompt == nil {
+			t.Fatal("expected stopping a PIN-locked session to ask for the PIN")
+		}
+		m, _ = m.updatePIN(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(pin + "x")})
+		m, _ = m.updatePIN(tea.KeyMsg{Type: tea.KeyBackspace})
+		if prompt := m.renderPINPrompt(); strings.Contains(prompt, pin) || !strings.Contains(prompt, strings.Repeat("•", len(pin))) {
+			t.Errorf("expected the PIN to be masked, got %q", prompt)
+		}
+		m, _ = m.updatePIN(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	stop("9999")
+	if !m.focusModeActive || m.activeMessage().severity != severityError {
+		t.Fatalf("expected a wrong PIN to keep the session, got %+v", m.activeMessage())
+	}
+
+	m, _ = m.updateFocus(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m, _ = m.updatePIN(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.pinPrompt != nil || !m.focusModeActive {
+		t.Fatal("expected Esc to cancel the prompt")
+	}
+
+	stop("1234")
+	if m.focusModeActive {
+		t.Errorf("expected the correct PIN to stop the session, got %+v", m.activeMessage())
+	}
+}
+
+func TestDevicePINPrompt(t *testing.T) {
+	keys, _ := newKeyMap(nil)
+	m := Model{config: &config.Config{FocusPINHash: "set"}, keys: keys}
+	tests := []struct {
+		current, next string
+		asks          bool
+	}{
+		{api.DeviceFocusFollow, api.DeviceFocusOn, false},
+		{api.DeviceFocusOn, api.DeviceFocusOff, true},
+		{api.DeviceFocusOff, api.DeviceFocusFollow, false},
+	}
+
+	for _, test := range tests {
+		m.pinPrompt = nil
+		m.devices = DevicesState{devices: []api.Device{{ID: "laptop", FocusMode: test.current}}}
+		if !test.asks {
+			// Without a resolver the change fails, but only after it was sent without a PIN
+			m.apiClient = api.NewClient("http://127.0.0.1:1")
+		}
+		m, _ = m.updateDevices(tea.KeyMsg{Type: tea.KeyEnter})
+		if (m.pinPrompt != nil) != test.asks {
+			t.Errorf("%s -> %s: expected a PIN prompt: %v, got %v", test.current, test.next, test.asks, m.pinPrompt != nil)
+		}
+	}
+}
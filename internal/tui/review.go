@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/allowlist"
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// suggestionReview lists the allowlist suggestions of the last focus session, to allow one
+// at a time or the marked ones together
+type suggestionReview struct {
+	suggestions []api.Suggestion
+	cursor      int
+	marked      map[string]bool
+}
+
+// loadSuggestions returns the resolver's suggestions that the allowlist doesn't cover yet
+func (m Model) loadSuggestions() ([]api.Suggestion, error) {
+	result, err := m.apiClient.GetSuggestions()
+	if err != nil {
+		return nil, err
+	}
+	var suggestions []api.Suggestion
+	for _, suggestion := range result.Suggestions {
+		if m.allowlistMatch(suggestion.Domain) == "" {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions, nil
+}
+
+// openReview shows the suggestions, or says there are none
+func (m *Model) openReview() {
+	suggestions, err := m.loadSuggestions()
+	if err != nil {
+		m.notifyError("Could not load suggestions", err)
+		return
+	}
+	if len(suggestions) == 0 {
+		m.notify(severityInfo, "No allowlist suggestions from the last focus session")
+		return
+	}
+	m.review = &suggestionReview{suggestions: suggestions}
+	m.pane.SetYOffset(0)
+}
+
+// offerReview points to the suggestions once a focus session ends
+func (m *Model) offerReview() {
+	suggestions, err := m.loadSuggestions()
+	if err != nil || len(suggestions) == 0 {
+		return
+	}
+	m.notify(severityInfo, fmt.Sprintf("%d allowlist suggestions from the session: press %s to review", len(suggestions), m.keys.describe(actionSuggestions)))
+}
+
+func (m *Model) updateReview(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+	review := m.review
+
+	key := msg.String()
+	switch {
+	case key == "ctrl+c":
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case key == "esc" && len(review.marked) > 0:
+		review.marked = nil
+		return *m, nil
+	case key == "esc", m.keys.action(key, scopeGlobal) == actionSuggestions:
+		m.review = nil
+		return *m, nil
+	}
+
+	switch m.keys.action(key, scopeAllowlist) {
+	case actionUp:
+		review.cursor = max(review.cursor-1, 0)
+	case actionDown:
+		review.cursor = min(review.cursor+1, len(review.suggestions)-1)
+	case actionMark:
+		review.marked = toggleMark(review.marked, review.suggestions[review.cursor].Domain)
+		review.cursor = min(review.cursor+1, len(review.suggestions)-1)
+	case actionToggle:
+		m.allowSuggestions([]string{review.suggestions[review.cursor].Domain})
+	case actionBatch:
+		var domains []string
+		for _, suggestion := range review.suggestions {
+			domains = append(domains, suggestion.Domain)
+		}
+		marked := markedDomains(review.marked, domains)
+		if len(marked) == 0 {
+			m.notify(severityInfo, fmt.Sprintf("Mark rows with %s first", m.keys.describe(actionMark)))
+			break
+		}
+		m.allowSuggestions(marked)
+	}
+	return *m, nil
+}
+
+// allowSuggestions adds suggested domains to the allowlist and drops them from the review,
+// closing it once none are left
+func (m *Model) allowSuggestions(domains []string) {
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		m.notifyError("Could not open the allowlist", err)
+		return
+	}
+	added, err := manager.AddAll(domains)
+	if err != nil {
+		m.notifyError("Could not allow the suggestions", err)
+		return
+	}
+	m.loadAllowlistData()
+	m.notify(severitySuccess, fmt.Sprintf("Allowed %d domains: %s", len(added), strings.Join(added, ", ")))
+
+	review := m.review
+	var left []api.Suggestion
+	for _, suggestion := range review.suggestions {
+		if !slices.Contains(domains, suggestion.Domain) {
+			left = append(left, suggestion)
+		}
+	}
+	if len(left) == 0 {
+		m.review = nil
+		return
+	}
+	review.suggestions = left
+	review.marked = nil
+	review.cursor = min(review.cursor, len(left)-1)
+}
+
+// renderReview shows the suggestions with the reason each was made
+func (m Model) renderReview() string {
+	review := m.review
+
+	var b strings.Builder
+	b.WriteString(i18n.T("Allowlist suggestions: blocked repeatedly alongside allowed domains") + "\n\n")
+	// Account for the title, the blank lines, and the key hints
+	rows := max(m.layout().innerHeight-4, 3)
+	first := max(review.cursor-rows+1, 0)
+	for i := first; i < min(first+rows, len(review.suggestions)); i++ {
+		suggestion := review.suggestions[i]
+		cursor := "  "
+		if i == review.cursor {
+			cursor = "> "
+		}
+		reason := i18n.T("alongside %s", strings.Join(suggestion.Related, ", "))
+		if suggestion.Reason == api.SuggestionCNAME {
+			reason = i18n.T("CNAME of %s", strings.Join(suggestion.Related, ", "))
+		}
+		b.WriteString(cursor + markCell(review.marked[suggestion.Domain], fmt.Sprintf("%-40s %s  %s", suggestion.Domain, i18n.T("%d blocked", suggestion.Blocked), reason)) + "\n")
+	}
+	b.WriteString("\n" + i18n.T("%s/%s Select | %s Allow | %s Mark | %s Allow marked | Esc Close",
+		m.keys.describe(actionUp), m.keys.describe(actionDown), m.keys.describe(actionToggle), m.keys.describe(actionMark), m.keys.describe(actionBatch)))
+	return b.String()
+}
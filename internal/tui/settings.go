@@ -0,0 +1,149 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Intervals offered by the settings panel
+var (
+	refreshIntervals = []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second}
+	reloadIntervals  = []time.Duration{time.Second, 2 * time.Second, 5 * time.Second, 10 * time.Second, 30 * time.Second, time.Minute}
+)
+
+// Intervals used when the config sets none, or an invalid one
+const (
+	defaultRefreshInterval = 3 * time.Second
+	defaultReloadInterval  = 5 * time.Second
+)
+
+// Rows of the settings panel
+const (
+	settingRefresh = iota
+	settingAllowlistReload
+	settingCount
+)
+
+// updateSettings adjusts the polling intervals. The panel borrows the Focus tab's picker keys.
+func (m *Model) updateSettings(msg tea.KeyMsg) (Model, tea.Cmd) {
+	key := msg.String()
+	switch {
+	case key == "ctrl+c":
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case key == "esc", m.keys.action(key, scopeGlobal) == actionSettings:
+		m.showSettings = false
+		return *m, nil
+	}
+
+	switch m.keys.action(key, scopeFocus) {
+	case actionUp:
+		m.settingsField = max(m.settingsField-1, 0)
+	case actionDown:
+		m.settingsField = min(m.settingsField+1, settingCount-1)
+	case actionIncrease:
+		m.changeSetting(1)
+	case actionDecrease:
+		m.changeSetting(-1)
+	case actionToggle:
+		if err := m.saveSettings(); err != nil {
+			m.notifyError("Could not save settings", err)
+			break
+		}
+		m.notify(severitySuccess, "Settings saved to the config file")
+	}
+	return *m, nil
+}
+
+// changeSetting steps the selected interval through its options
+func (m *Model) changeSetting(step int) {
+	switch m.settingsField {
+	case settingRefresh:
+		m.refreshInterval = stepInterval(refreshIntervals, m.refreshInterval, step)
+	case settingAllowlistReload:
+		m.reloadInterval = stepInterval(reloadIntervals, m.reloadInterval, step)
+	}
+}
+
+// stepInterval moves from current to the next larger or smaller option, clamping at the ends.
+// A configured value between options steps to its nearest neighbour; stepping down from below
+// the smallest option gives the smallest, so the result is never under it.
+func stepInterval(options []time.Duration, current time.Duration, step int) time.Duration {
+	if step > 0 {
+		for _, option := range options {
+			if option > current {
+				return option
+			}
+		}
+		return max(current, options[len(options)-1])
+	}
+	for i := len(options) - 1; i >= 0; i-- {
+		if options[i] < current {
+			return options[i]
+		}
+	}
+	return options[0]
+}
+
+// tickInterval returns how long to wait for the next refresh, never less than the shortest
+// option, so a model without a configured interval doesn't poll in a tight loop
+func (m Model) tickInterval() time.Duration {
+	if m.refreshInterval < refreshIntervals[0] {
+		return defaultRefreshInterval
+	}
+	return m.refreshInterval
+}
+
+// saveSettings writes the current intervals to the tui section of the config file
+func (m *Model) saveSettings() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cfg.TUI = &config.TUIConfig{
+		Refresh:         formatInterval(m.refreshInterval),
+		AllowlistReload: formatInterval(m.reloadInterval),
+	}
+	if err := config.Save(cfg); err != nil {
+		return err
+	}
+	m.config.TUI = cfg.TUI
+	return nil
+}
+
+func (m Model) renderSettings() string {
+	rows := []string{
+		i18n.T("Refresh:           ◀ %s ▶  (queries, focus, and health)", formatInterval(m.refreshInterval)),
+		i18n.T("Allowlist reload:  ◀ %s ▶", formatInterval(m.reloadInterval)),
+	}
+	for i := range rows {
+		if i == m.settingsField {
+			rows[i] = "> " + rows[i]
+		} else {
+			rows[i] = "  " + rows[i]
+		}
+	}
+
+	return i18n.T("Settings") + "\n\n" + strings.Join(rows, "\n") + "\n\n" +
+		i18n.T("Changes apply right away. %s/%s Select | %s/%s Change | %s Save to config | %s or Esc Close",
+			m.keys.describe(actionUp), m.keys.describe(actionDown), m.keys.describe(actionIncrease),
+			m.keys.describe(actionDecrease), m.keys.describe(actionToggle), m.keys.describe(actionSettings))
+}
+
+// formatInterval formats an interval the way it is written in the config, e.g. "3s" or "1m"
+func formatInterval(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStepInterval(t *testing.T) {
+	tests := []struct {
+		current time.Duration
+		step    int
+		next    time.Duration
+	}{
+		{3 * time.Second, 1, 5 * time.Second},
+		{3 * time.Second, -1, 2 * time.Second},
+		{4 * time.Second, 1, 5 * time.Second},
+		{4 * time.Second, -1, 3 * time.Second},
+		{time.Second, -1, time.Second},
+		{30 * time.Second, 1, 30 * time.Second},
+		{5 * time.Minute, 1, 5 * time.Minute},
+		{5 * time.Minute, -1, 30 * time.Second},
+		{0, -1, time.Second},
+		{-time.Second, 1, time.Second},
+	}
+
+	for _, test := range tests {
+		if next := stepInterval(refreshIntervals, test.current, test.step); next != test.next {
+			t.Errorf("%v by %d: expected %v, got %v", test.current, test.step, test.next, next)
+		}
+	}
+}
+
+func TestTickInterval(t *testing.T) {
+	tests := []struct {
+		refresh time.Duration
+		tick    time.Duration
+	}{
+		{0, defaultRefreshInterval},
+		{-time.Second, defaultRefreshInterval},
+		{time.Millisecond, defaultRefreshInterval},
+		{time.Second, time.Second},
+		{10 * time.Second, 10 * time.Second},
+	}
+
+	for _, test := range tests {
+		m := Model{refreshInterval: test.refresh}
+		if tick := m.tickInterval(); tick != test.tick {
+			t.Errorf("%v: expected ticks every %v, got %v", test.refresh, test.tick, tick)
+		}
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	t.Setenv(config.ConfigDirEnv, t.TempDir())
+	t.Setenv(config.SystemConfigEnv, "none")
+	keys, _ := newKeyMap(nil)
+	m := Model{keys: keys, config: &config.Config{}, showSettings: true, refreshInterval: defaultRefreshInterval, reloadInterval: defaultReloadInterval}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("+")},
+		{Type: tea.KeyRunes, Runes: []rune("+")},
+		{Type: tea.KeyDown},
+		{Type: tea.KeyDown},
+		{Type: tea.KeyRunes, Runes: []rune("-")},
+		{Type: tea.KeyEnter},
+	} {
+		m, _ = m.updateSettings(key)
+	}
+	if m.refreshInterval != 10*time.Second || m.reloadInterval != 2*time.Second || m.settingsField != settingAllowlistReload {
+		t.Fatalf("expected 10s and 2s with the last row selected, got %v and %v at row %d", m.refreshInterval, m.reloadInterval, m.settingsField)
+	}
+	if !strings.Contains(m.renderSettings(), "> Allowlist reload:  ◀ 2s ▶") {
+		t.Errorf("expected the selected row to be marked, got %q", m.renderSettings())
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TUI == nil || cfg.TUI.Refresh != "10s" || cfg.TUI.AllowlistReload != "2s" || m.activeMessage().severity != severitySuccess {
+		t.Errorf("expected the intervals to be saved, got %+v (%+v)", cfg.TUI, m.activeMessage())
+	}
+
+	m, _ = m.updateSettings(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.showSettings {
+		t.Error("expected Esc to close the settings panel")
+	}
+}
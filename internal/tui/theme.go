@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Theme is the colour palette used by every TUI style
+type Theme struct {
+	Background      lipgloss.Color
+	Text            lipgloss.Color
+	Accent          lipgloss.Color // Banner and footer
+	Border          lipgloss.Color
+	Muted           lipgloss.Color // Inactive tabs
+	OnColor         lipgloss.Color // Text on coloured backgrounds
+	Selected        lipgloss.Color // Selected table row
+	Changed         lipgloss.Color // Recently changed table row
+	SelectedChanged lipgloss.Color // Selected and recently changed table row
+	Alert           lipgloss.Color // Focus mode indicator
+	AlertBackground lipgloss.Color // Header while focus mode is active
+	Warning         lipgloss.Color // Degraded resolver health
+	Success         lipgloss.Color // Temporary messages and healthy resolver
+}
+
+// themes lists the built-in palettes by name
+var themes = map[string]Theme{
+	"dark": {
+		Background:      "#000000",
+		Text:            "#FFFFFF",
+		Accent:          "#FF69B4", // Pink
+		Border:          "#87CEEB", // Sky Blue
+		Muted:           "#808080", // Grey
+		OnColor:         "#FFFFFF",
+		Selected:        "#3B82F6",
+		Changed:         "#8B5CF6",
+		SelectedChanged: "#059669",
+		Alert:           "#FF6B6B",
+		AlertBackground: "#2D1B1B",
+		Warning:         "#FACC15",
+		Success:         "#4ADE80",
+	},
+	"light": {
+		Background:      "#FFFFFF",
+		Text:            "#1F2937",
+		Accent:          "#C2185B",
+		Border:          "#1E88E5",
+		Muted:           "#6B7280",
+		OnColor:         "#FFFFFF",
+		Selected:        "#2563EB",
+		Changed:         "#7C3AED",
+		SelectedChanged: "#047857",
+		Alert:           "#DC2626",
+		AlertBackground: "#FEE2E2",
+		Warning:         "#B45309",
+		Success:         "#15803D",
+	},
+	"solarized": {
+		Background:      "#002B36",
+		Text:            "#839496",
+		Accent:          "#D33682",
+		Border:          "#268BD2",
+		Muted:           "#586E75",
+		OnColor:         "#FDF6E3",
+		Selected:        "#268BD2",
+		Changed:         "#6C71C4",
+		SelectedChanged: "#2AA198",
+		Alert:           "#DC322F",
+		AlertBackground: "#073642",
+		Warning:         "#B58900",
+		Success:         "#859900",
+	},
+}
+
+var hexColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// resolveTheme builds the palette for the configured theme name and colour overrides
+func resolveTheme(cfg *config.ThemeConfig) (Theme, error) {
+	if cfg == nil {
+		return themes["dark"], nil
+	}
+
+	name := strings.ToLower(cfg.Name)
+	if name == "" || name == "custom" {
+		name = "dark"
+	}
+	theme, ok := themes[name]
+	if !ok {
+		return themes["dark"], fmt.Errorf("unknown theme %q: use one of %s", cfg.Name, strings.Join(themeNames(), ", "))
+	}
+
+	overrides := []struct {
+		key   string
+		value string
+		color *lipgloss.Color
+	}{
+		{"background", cfg.Background, &theme.Background},
+		{"text", cfg.Text, &theme.Text},
+		{"accent", cfg.Accent, &theme.Accent},
+		{"border", cfg.Border, &theme.Border},
+		{"muted", cfg.Muted, &theme.Muted},
+		{"selected", cfg.Selected, &theme.Selected},
+		{"alert", cfg.Alert, &theme.Alert},
+		{"warning", cfg.Warning, &theme.Warning},
+		{"success", cfg.Success, &theme.Success},
+	}
+	for _, override := range overrides {
+		if override.value == "" {
+			continue
+		}
+		if !hexColorPattern.MatchString(override.value) {
+			return themes["dark"], fmt.Errorf("invalid theme color %s: %q (use #RRGGBB)", override.key, override.value)
+		}
+		*override.color = lipgloss.Color(override.value)
+	}
+
+	return theme, nil
+}
+
+// ValidateTheme checks the theme name and colour overrides, as the TUI would on startup
+func ValidateTheme(cfg *config.ThemeConfig) error {
+	_, err := resolveTheme(cfg)
+	return err
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyTheme rebuilds the shared styles from a palette
+func applyTheme(theme Theme) {
+	currentTheme = theme
+
+	headerStyle = lipgloss.NewStyle().
+		Foreground(theme.Accent).
+		Background(theme.Background).
+		Bold(true).
+		Align(lipgloss.Center).
+		Margin(1, 0).
+		Width(0) // Full width
+
+	tabStyle = lipgloss.NewStyle().
+		Foreground(theme.Muted).
+		Padding(0, 2).
+		Background(theme.Background)
+
+	activeTabStyle = lipgloss.NewStyle().
+		Foreground(theme.Text).
+		Bold(true).
+		Padding(0, 2).
+		Background(theme.Background)
+
+	contentStyle = lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(theme.Border).
+		Padding(1, 2).
+		Background(theme.Background).
+		Foreground(theme.Text)
+
+	footerStyle = lipgloss.NewStyle().
+		Foreground(theme.OnColor).
+		Background(theme.Accent).
+		Padding(0, 1).
+		Width(0) // Full width
+
+	docStyle = lipgloss.NewStyle().
+		Background(theme.Background).
+		Foreground(theme.Text).
+		Width(0).
+		Height(0)
+}
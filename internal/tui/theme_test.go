@@ -0,0 +1,35 @@
+package tui
+
+import (
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestResolveTheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    *config.ThemeConfig
+		accent lipgloss.Color
+		text   lipgloss.Color
+		fails  bool
+	}{
+		{"default", nil, themes["dark"].Accent, themes["dark"].Text, false},
+		{"named", &config.ThemeConfig{Name: "Light"}, themes["light"].Accent, themes["light"].Text, false},
+		{"override", &config.ThemeConfig{Name: "solarized", Accent: "#123456"}, "#123456", themes["solarized"].Text, false},
+		{"custom", &config.ThemeConfig{Name: "custom", Text: "#abc"}, themes["dark"].Accent, "#abc", false},
+		{"unknown", &config.ThemeConfig{Name: "neon"}, themes["dark"].Accent, themes["dark"].Text, true},
+		{"invalid color", &config.ThemeConfig{Name: "light", Accent: "pink"}, themes["dark"].Accent, themes["dark"].Text, true},
+	}
+
+	for _, test := range tests {
+		theme, err := resolveTheme(test.cfg)
+		if (err != nil) != test.fails {
+			t.Errorf("%s: expected failure %v, got %v", test.name, test.fails, err)
+		}
+		if theme.Accent != test.accent || theme.Text != test.text {
+			t.Errorf("%s: expected accent %s and text %s, got %s and %s", test.name, test.accent, test.text, theme.Accent, theme.Text)
+		}
+	}
+}
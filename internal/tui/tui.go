@@ -1,13 +1,23 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/berbyte/sinkzone/internal/allowlist"
 	"github.com/berbyte/sinkzone/internal/api"
 	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/crash"
+	"github.com/berbyte/sinkzone/internal/i18n"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -28,15 +38,47 @@ const sinkzoneBanner = `
 
 // Tab-specific state structures
 type MonitoringState struct {
-	dnsQueries  []api.DNSQuery
+	dnsQueries  []api.DNSQuery // Queries matching the filter, newest first
+	allQueries  []api.DNSQuery // Every query from the resolver, before the search filter
 	lastUpdate  time.Time
 	lastRefresh time.Time
-	tableCursor int
+	tableCursor int // Selected row in dnsQueries
+	scrollTop   int // First row shown in the table viewport
+
+	// Auto-refresh is paused; queries fetched meanwhile wait in pending until it resumes
+	frozen  bool
+	pending []api.DNSQuery
+
+	// Live tail: queries arrive from the API stream instead of the 3 second poll
+	following  bool
+	stream     chan streamMsg
+	streamCtx  context.Context
+	stopStream context.CancelFunc
+	dropped    int // Streamed queries skipped because the TUI or the resolver fell behind
+
+	// Sorted by hit count, most queried first, instead of newest first
+	byHits bool
+
+	// Search filter: domain substrings, client:<address or name>, is:blocked, or is:allowed
+	filter    string
+	searching bool // The filter is being typed
+
+	// Domains marked for a batch allow or remove
+	marked map[string]bool
+
+	// Allowlist choices for the selected domain, nil when the table is shown
+	chooser *allowChooser
+
+	// Query shown in the detail panel, nil when the table is shown
+	detail     *api.DNSQuery
+	detailHost string    // Reverse DNS name of the detail query's client
+	detailHits []api.Hit // Queries for the detail query's domain per client
 }
 
 type AllowedDomainsState struct {
 	cursor  int // Which domain is currently selected
 	domains []string
+	marked  map[string]bool // Domains marked for a batch remove
 }
 
 type Model struct {
@@ -44,8 +86,31 @@ type Model struct {
 	height    int
 	activeTab int
 	quitting  bool
+	showHelp  bool // The key binding overlay replaces the tab content
 	tabs      []string
 
+	// Message bar entries, oldest first; the history overlay lists them
+	messages     []statusMessage
+	showMessages bool
+
+	// Polling intervals from the tui config section, adjustable in the settings panel
+	refreshInterval time.Duration
+	reloadInterval  time.Duration
+	showSettings    bool
+	settingsField   int // Selected settings panel row
+
+	// Allowlist suggestions under review, opened with S or offered when a session ends
+	review *suggestionReview
+
+	// Command prompt opened with :
+	commandMode    bool
+	command        string
+	commandHistory []string // Commands run, oldest first
+	historyIndex   int      // Position in commandHistory while recalling with up and down
+
+	// Focus PIN prompt, open while an action that loosens focus waits for the PIN
+	pinPrompt *pinPrompt
+
 	// Animation state
 	bannerLines   []string
 	currentLine   int
@@ -54,16 +119,33 @@ type Model struct {
 	// API client and config
 	apiClient *api.Client
 	config    *config.Config
+	keys      keyMap     // Key bindings, defaults plus the keymap config section
+	hostnames *hostnames // Reverse DNS names of clients, shown in the query detail
+
+	// Recent queries fetched and kept for scrollback, the resolver's recent_queries.size
+	historySize int
 
 	// Focus mode state
-	focusModeActive  bool
-	focusEndTime     *time.Time
-	focusMessage     string // Temporary message when focus mode is activated
-	focusMessageTime time.Time
+	focusModeActive bool
+	focusEndTime    *time.Time
+	focusProfile    string // Profile of the active focus session
+	focusDetails    *api.FocusModeState
+	selectedProfile string // Profile used when enabling focus mode ("" = default allowlist)
+
+	// Daily goal progress and query totals, nil when the resolver is unreachable
+	stats      *api.FocusStats
+	queryStats *api.QueryStats
+
+	// Resolver health, nil when the API is unreachable
+	health   *api.ResolverHealth
+	apiError string // Last API error, shown in the status bar until a request succeeds
 
 	// Tab-specific states
 	monitoring     MonitoringState
 	allowedDomains AllowedDomainsState
+	focus          FocusState
+	devices        DevicesState
+	pane           viewport.Model // Scrolls the Stats, Focus, and help content
 
 	// Update tracking
 	lastChangedDomain   string    // Track the last domain that was changed
@@ -79,20 +161,20 @@ type Model struct {
 
 // Cleanup function to restore terminal
 func (m Model) cleanup() {
+	// Close the live tail stream
+	if m.monitoring.stopStream != nil {
+		m.monitoring.stopStream()
+	}
+
 	// Restore terminal state
 	fmt.Print("\033[?25h") // Show cursor
 	fmt.Print("\033[2J")   // Clear screen
 	fmt.Print("\033[H")    // Move cursor to top
 }
 
-// Style definitions
+// Style definitions, built from the configured theme by applyTheme
 var (
-	// Colors
-	background = lipgloss.Color("#000000")
-	textColor  = lipgloss.Color("#FFFFFF")
-	accent2    = lipgloss.Color("#FF69B4") // Pink
-	accent4    = lipgloss.Color("#87CEEB") // Sky Blue
-	muted      = lipgloss.Color("#808080") // Grey
+	currentTheme Theme
 
 	// Rainbow colors for easter egg
 	rainbowColors = []lipgloss.Color{
@@ -105,71 +187,36 @@ var (
 		lipgloss.Color("#9400D3"), // Violet
 	}
 
-	// Header style - blue bar like in screenshot
-	headerStyle = lipgloss.NewStyle().
-			Foreground(accent2). // Pink color for banner
-			Background(background).
-			Bold(true).
-			Align(lipgloss.Center).
-			Margin(1, 0).
-			Width(0) // Full width
-
-	// Simple tab style - just text, no borders
-	tabStyle = lipgloss.NewStyle().
-			Foreground(muted).
-			Padding(0, 2).
-			Background(background)
-
-	activeTabStyle = lipgloss.NewStyle().
-			Foreground(textColor).
-			Bold(true).
-			Padding(0, 2).
-			Background(background)
-
-	// Content area style
-	contentStyle = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(accent4).
-			Padding(1, 2).
-			Background(background).
-			Foreground(textColor)
-
-	// Footer style - pink bar like in screenshot
-	footerStyle = lipgloss.NewStyle().
-			Foreground(textColor).
-			Background(accent2). // Pink background
-			Padding(0, 1).
-			Width(0) // Full width
-
-	// Document style
-	docStyle = lipgloss.NewStyle().
-			Background(background).
-			Foreground(textColor).
-			Width(0).
-			Height(0)
+	headerStyle    lipgloss.Style // Banner
+	tabStyle       lipgloss.Style // Simple tab style - just text, no borders
+	activeTabStyle lipgloss.Style
+	contentStyle   lipgloss.Style // Content area
+	footerStyle    lipgloss.Style // Key hints bar
+	docStyle       lipgloss.Style // Document
 )
 
 // Tick message for animation
 type tickMsg time.Time
 
 func Start() error {
-	return StartWithAPIURL("http://127.0.0.1:8080")
+	return StartWithAPIURL(config.DefaultAPIURL())
 }
 
 func StartWithAPIURL(apiURL string) error {
 	// Restore terminal state before starting
 	checkAndRestoreTerminal()
 
-	// Ensure terminal is restored on exit
+	// Ensure terminal is restored on exit, keeping the screen after a crash so its stack
+	// trace stays readable
+	panicked := true
 	defer func() {
-		if r := recover(); r != nil {
-			fmt.Printf("Recovered from panic: %v\n", r)
-		}
-		// Force terminal restoration
 		fmt.Print("\033[?25h") // Show cursor
-		fmt.Print("\033[2J")   // Clear screen
-		fmt.Print("\033[H")    // Move cursor to top
+		if !panicked {
+			fmt.Print("\033[2J") // Clear screen
+			fmt.Print("\033[H")  // Move cursor to top
+		}
 	}()
+	defer crash.Recover("tui")
 
 	// Split banner into lines for animation
 	bannerLines := strings.Split(strings.TrimSpace(sinkzoneBanner), "\n")
@@ -177,22 +224,54 @@ func StartWithAPIURL(apiURL string) error {
 	// Initialize API client
 	apiClient := api.NewClient(apiURL)
 
-	// Load config
+	// Load config. Warnings go to the message bar, since the alt screen hides anything printed.
+	var warnings []string
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("Warning: failed to load config: %v\n", err)
+		warnings = append(warnings, fmt.Sprintf("Failed to load config: %v", err))
 		cfg = &config.Config{
 			UpstreamNameservers: []string{"8.8.8.8", "1.1.1.1"},
 		}
 	}
 
+	theme, err := resolveTheme(cfg.Theme)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("%v, using the dark theme", err))
+	}
+	applyTheme(theme)
+
+	keys, err := newKeyMap(cfg.Keymap)
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	refreshInterval, err := cfg.TUI.GetRefresh()
+	if err != nil {
+		warnings = append(warnings, err.Error())
+		refreshInterval = defaultRefreshInterval
+	}
+	reloadInterval, err := cfg.TUI.GetAllowlistReload()
+	if err != nil {
+		warnings = append(warnings, err.Error())
+		reloadInterval = defaultReloadInterval
+	}
+	historySize, _, err := cfg.RecentQueries.GetLimits()
+	if err != nil || historySize == 0 {
+		historySize = config.DefaultRecentQueries
+	}
+
 	m := Model{
-		tabs:          []string{"Monitoring", "Allowlist"},
+		tabs:          []string{"Monitoring", "Allowlist", "Stats", "Focus", "Devices"},
 		bannerLines:   bannerLines,
 		currentLine:   0,
 		animationDone: false,
 		apiClient:     apiClient,
 		config:        cfg,
+		keys:          keys,
+		historySize:   historySize,
+		hostnames:     newHostnames(cfg),
+		// Sessions started from the TUI use the active profile until another is chosen
+		selectedProfile: cfg.ActiveProfile,
 		monitoring: MonitoringState{
 			dnsQueries:  []api.DNSQuery{},
 			lastUpdate:  time.Now(),
@@ -203,6 +282,10 @@ func StartWithAPIURL(apiURL string) error {
 			cursor:  0,
 			domains: []string{},
 		},
+		focus:               newFocusState(),
+		refreshInterval:     refreshInterval,
+		reloadInterval:      reloadInterval,
+		pane:                newPane(),
 		lastAllowlistReload: time.Now(),
 		lastUserActivity:    time.Now(),
 		rainbowMode:         false,
@@ -210,8 +293,14 @@ func StartWithAPIURL(apiURL string) error {
 		keyBuffer:           "",
 	}
 
+	for _, warning := range warnings {
+		m.notify(severityWarning, warning)
+	}
+
 	// Initialize focus mode status
 	m.updateFocusModeStatus()
+	m.updateStats()
+	m.updateHealth()
 
 	// Load initial data
 	m.loadInitialData()
@@ -223,19 +312,25 @@ func StartWithAPIURL(apiURL string) error {
 		tea.WithMouseCellMotion(),
 	)
 
-	// Run the program with error handling
-	if _, err := p.Run(); err != nil {
-		// Ensure terminal is restored even on error
-		fmt.Print("\033[?25h\033[2J\033[H")
+	// Run the program with error handling. Bubble Tea has restored the terminal and
+	// printed the stack trace of a panic in the model.
+	_, err = p.Run()
+	if errors.Is(err, tea.ErrProgramPanic) {
+		if path := crash.LastReport(); path != "" {
+			return fmt.Errorf("the TUI crashed; a report was written to %s", path)
+		}
+		return fmt.Errorf("the TUI crashed; set crash_reports.enabled to true in sinkzone.yaml to keep a report next time")
+	}
+	panicked = false
+	if err != nil {
 		return fmt.Errorf("failed to run TUI: %w", err)
 	}
-
 	return nil
 }
 
 func (m Model) loadInitialData() {
 	// Load initial DNS queries
-	if queries, err := m.apiClient.GetQueries(); err == nil {
+	if queries, err := m.apiClient.GetQueries(m.historySize); err == nil {
 		m.monitoring.dnsQueries = queries
 		m.monitoring.lastUpdate = time.Now()
 	}
@@ -279,8 +374,8 @@ func (m *Model) loadAllowlistData() {
 }
 
 func (m *Model) enableFocusMode() error {
-	// Enable focus mode for 1 hour via API
-	if err := m.apiClient.SetFocusMode(true, "1h"); err != nil {
+	// Enable focus mode via API with the session chosen in the Focus tab
+	if err := m.apiClient.SetFocusModeWithOptions(m.focusRequest()); err != nil {
 		return fmt.Errorf("failed to enable focus mode: %w", err)
 	}
 
@@ -297,6 +392,8 @@ func (m *Model) updateFocusModeStatus() {
 		m.focusModeActive = focusState.Enabled
 		//nolint:staticcheck // SA4005: These assignments are necessary for state synchronization
 		m.focusEndTime = focusState.EndTime
+		m.focusProfile = focusState.Profile
+		m.focusDetails = focusState
 		return
 	}
 
@@ -306,6 +403,7 @@ func (m *Model) updateFocusModeStatus() {
 		return
 	}
 
+	m.focusDetails = nil
 	state := stateMgr.GetState()
 	// Update focus mode state from state manager
 	//nolint:staticcheck // SA4005: These assignments are necessary for state synchronization
@@ -314,23 +412,78 @@ func (m *Model) updateFocusModeStatus() {
 	m.focusEndTime = state.FocusEndTime
 }
 
+func (m *Model) updateStats() {
+	stats, err := m.apiClient.GetStats()
+	if err != nil {
+		m.stats = nil
+		m.queryStats = nil
+		return
+	}
+	m.stats = stats
+
+	queryStats, err := m.apiClient.GetQueryStats()
+	if err != nil {
+		m.queryStats = nil
+		return
+	}
+	m.queryStats = queryStats
+}
+
+// cycleProfile selects the next configured focus profile, wrapping back to the default allowlist
+func (m *Model) cycleProfile() {
+	names := m.config.ProfileNames()
+	if len(names) == 0 {
+		m.selectedProfile = ""
+		return
+	}
+
+	next := 0
+	for i, name := range names {
+		if name == m.selectedProfile {
+			next = i + 1
+			break
+		}
+	}
+	if m.selectedProfile != "" && next >= len(names) {
+		m.selectedProfile = ""
+		return
+	}
+	m.selectedProfile = names[next]
+}
+
 func checkAndRestoreTerminal() {
 	// Check if terminal is in raw mode and restore if needed
 	fmt.Print("\033[?25h") // Show cursor
 }
 
 func (m Model) Init() tea.Cmd {
-	// Start animation tick
-	return tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
+	// Start animation tick, and the clock that redraws the focus countdown
+	return tea.Batch(
+		tea.Tick(time.Millisecond*100, func(t time.Time) tea.Msg {
+			return tickMsg(t)
+		}),
+		clockTick(),
+	)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	defer crash.Recover("tui")
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+	case streamMsg:
+		return m.updateStream(msg)
+	case clockMsg:
+		return m, clockTick()
+	case hostnameMsg:
+		if m.monitoring.detail != nil && m.monitoring.detail.Client == msg.ip {
+			m.monitoring.detailHost = msg.host
+		}
+	case hitsMsg:
+		if m.monitoring.detail != nil && m.monitoring.detail.Domain == msg.domain {
+			m.monitoring.detailHits = msg.hits
+		}
 	case tickMsg:
 		if !m.animationDone {
 			m.currentLine++
@@ -349,60 +502,39 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return tickMsg(t)
 			})
 		} else {
-			// Update DNS data every 3 seconds, but pause if user is actively navigating
-			if time.Since(m.lastUserActivity) > 2*time.Second {
-				if queries, err := m.apiClient.GetQueries(); err == nil {
-					if len(queries) > 0 {
-						// Calculate how many entries we can display
-						headerHeight := lipgloss.Height(headerStyle.Render(sinkzoneBanner)) + 2
-						tabHeight := 1
-						footerHeight := 1
-						contentHeight := m.height - headerHeight - tabHeight - footerHeight - 2
-						maxVisibleEntries := contentHeight - 4 // Account for header, footer, and padding
-						if maxVisibleEntries < 5 {
-							maxVisibleEntries = 5 // Minimum entries
-						}
-
-						// Truncate to only keep the most recent entries that fit
-						if len(queries) > maxVisibleEntries {
-							queries = queries[len(queries)-maxVisibleEntries:]
-						}
-
-						// Store the currently selected domain before updating data
-						var selectedDomain string
-						if len(m.monitoring.dnsQueries) > 0 && m.monitoring.tableCursor < len(m.monitoring.dnsQueries) {
-							selectedDomain = m.monitoring.dnsQueries[m.monitoring.tableCursor].Domain
-						}
-
-						// Update the data
-						m.monitoring.dnsQueries = queries
-						m.monitoring.lastUpdate = time.Now()
-
-						// Try to restore cursor position to the same domain
-						if selectedDomain != "" {
-							for i, query := range queries {
-								if query.Domain == selectedDomain {
-									m.monitoring.tableCursor = i
-									break
-								}
-							}
-						} else if len(queries) > 0 {
-							// If no domain was selected, default to the newest entry (first in array since we display newest first)
-							m.monitoring.tableCursor = 0
-						}
-					}
+			// Check whether the API, DNS port, and upstreams are healthy
+			m.updateHealth()
+
+			// Update DNS data every refresh interval, but pause if user is actively navigating
+			if !m.monitoring.following && time.Since(m.lastUserActivity) > 2*time.Second {
+				queries, err := m.apiClient.GetQueries(m.historySize)
+				if err != nil {
+					m.recordAPIError(err)
+				} else if len(queries) > 0 && m.monitoring.frozen {
+					m.monitoring.pending = queries
+				} else if len(queries) > 0 {
+					m.monitoring.allQueries = queries
+					m.applyQueryFilter()
+					m.monitoring.lastUpdate = time.Now()
 				}
 			}
 
 			// Update last refresh time
 			m.monitoring.lastRefresh = time.Now()
 
-			// Check focus mode status
+			// Check focus mode status, offering the session's allowlist suggestions once it ends
+			wasFocused := m.focusModeActive
 			m.updateFocusModeStatus()
+			if wasFocused && !m.focusModeActive {
+				m.offerReview()
+			}
 
-			// Clear focus message after 3 seconds
-			if m.focusMessage != "" && time.Since(m.focusMessageTime) > 3*time.Second {
-				m.focusMessage = ""
+			// Refresh daily goal stats
+			m.updateStats()
+
+			// Refresh the device list while it's shown
+			if m.activeTab == 4 {
+				m.loadDevices()
 			}
 
 			// Clear last changed domain after 2 seconds
@@ -410,8 +542,8 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.lastChangedDomain = ""
 			}
 
-			// Reload allowlist data periodically (every 5 seconds)
-			if time.Since(m.lastAllowlistReload) >= 5*time.Second {
+			// Reload allowlist data periodically
+			if time.Since(m.lastAllowlistReload) >= m.reloadInterval {
 				m.loadAllowlistData()
 				m.lastAllowlistReload = time.Now()
 			}
@@ -421,11 +553,78 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.rainbowOffset = (m.rainbowOffset + 1) % len(rainbowColors)
 			}
 
-			return m, tea.Tick(3*time.Second, func(t time.Time) tea.Msg {
+			return m, tea.Tick(m.tickInterval(), func(t time.Time) tea.Msg {
 				return tickMsg(t)
 			})
 		}
 	case tea.KeyMsg:
+		// While typing a command, keys edit the command instead of triggering shortcuts
+		if m.commandMode {
+			return m.updateCommand(msg)
+		}
+
+		// While typing the focus PIN, keys edit the PIN instead of triggering shortcuts
+		if m.pinPrompt != nil {
+			return m.updatePIN(msg)
+		}
+
+		// While typing a search filter, keys edit the filter instead of triggering shortcuts
+		if m.activeTab == 0 && m.monitoring.searching {
+			return m.updateSearch(msg)
+		}
+
+		// The allowlist chooser swallows keys until a choice is made
+		if m.activeTab == 0 && m.monitoring.chooser != nil && !m.focusModeActive {
+			return m.updateChooser(msg)
+		}
+
+		// The detail panel swallows keys until it is closed
+		if m.activeTab == 0 && m.monitoring.detail != nil && !m.focusModeActive {
+			return m.updateDetail(msg)
+		}
+
+		// The help overlay swallows keys until it is closed
+		if m.showHelp {
+			switch {
+			case msg.String() == "ctrl+c":
+				m.quitting = true
+				m.cleanup()
+				return m, tea.Quit
+			case msg.String() == "esc", m.keys.action(msg.String(), scopeGlobal) == actionHelp:
+				m.showHelp = false
+				m.pane.SetYOffset(0)
+			default:
+				return m.updatePane(msg, m.renderHelp())
+			}
+			return m, nil
+		}
+
+		// The settings panel swallows keys until it is closed
+		if m.showSettings {
+			return m.updateSettings(msg)
+		}
+
+		// The suggestion review swallows keys until it is closed
+		if m.review != nil {
+			return m.updateReview(msg)
+		}
+
+		// The message history overlay swallows keys until it is closed
+		if m.showMessages {
+			switch {
+			case msg.String() == "ctrl+c":
+				m.quitting = true
+				m.cleanup()
+				return m, tea.Quit
+			case msg.String() == "esc", m.keys.action(msg.String(), scopeGlobal) == actionMessages:
+				m.showMessages = false
+				m.pane.SetYOffset(0)
+			default:
+				return m.updatePane(msg, m.renderMessageHistory())
+			}
+			return m, nil
+		}
+
 		// Handle easter egg key sequence detection
 		if !m.rainbowMode {
 			// Only add to buffer if it's a single character (not special keys like arrows, etc.)
@@ -447,27 +646,66 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
-		switch msg.String() {
-		case "esc", "ctrl+c":
+		prevTab := m.activeTab
+		switch m.keys.action(msg.String(), scopeGlobal) {
+		case actionQuit:
+			if msg.String() != "ctrl+c" && m.activeTab == 0 && len(m.monitoring.marked) > 0 {
+				// Clear the marks before quitting
+				m.monitoring.marked = nil
+				return m, nil
+			}
+			if msg.String() != "ctrl+c" && m.activeTab == 1 && len(m.allowedDomains.marked) > 0 {
+				m.allowedDomains.marked = nil
+				return m, nil
+			}
+			if msg.String() != "ctrl+c" && m.activeTab == 0 && m.monitoring.filter != "" {
+				// Clear the search filter before quitting
+				m.monitoring.filter = ""
+				m.applyQueryFilter()
+				return m, nil
+			}
 			m.quitting = true
 			// Cleanup terminal before quitting
 			m.cleanup()
 			return m, tea.Quit
-		case "f":
-			// Enable focus mode for 1 hour
+		case actionHelp:
+			m.showHelp = true
+			m.pane.SetYOffset(0)
+		case actionMessages:
+			m.showMessages = true
+			m.pane.SetYOffset(0)
+		case actionSettings:
+			m.showSettings = true
+			m.settingsField = 0
+		case actionCommand:
+			m.openCommand()
+		case actionSuggestions:
+			m.openReview()
+		case actionFocus:
+			// Enable focus mode with the session chosen in the Focus tab
 			if err := m.enableFocusMode(); err != nil {
-				// Could add error handling here, but for now just continue
-				fmt.Printf("Warning: failed to enable focus mode: %v\n", err)
+				m.notifyError("Could not start focus mode", err)
 			} else {
 				// If we're on monitoring tab, switch to allowlist tab
 				if m.activeTab == 0 {
 					m.activeTab = 1
 				}
 				// Show temporary success message
-				m.focusMessage = "🔒 Focus mode activated for 1 hour!"
-				m.focusMessageTime = time.Now()
+				if m.selectedProfile != "" {
+					m.notify(severitySuccess, fmt.Sprintf("🔒 Focus mode activated with profile %s (%s)!", m.selectedProfile, m.durationLabel()))
+				} else {
+					m.notify(severitySuccess, fmt.Sprintf("🔒 Focus mode activated (%s)!", m.durationLabel()))
+				}
 			}
-		case "left", "h":
+		case actionProfile:
+			// Cycle the profile used for the next focus session
+			m.cycleProfile()
+			profileName := m.selectedProfile
+			if profileName == "" {
+				profileName = "default"
+			}
+			m.notify(severityInfo, fmt.Sprintf("Focus profile: %s", profileName))
+		case actionPrevTab:
 			// Navigate to previous tab
 			if m.activeTab > 0 {
 				m.activeTab--
@@ -477,8 +715,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Reload allowlist data when switching to allowlist tab
 			if m.activeTab == 1 {
 				m.loadAllowlistData()
+			} else if m.activeTab == 4 {
+				m.loadDevices()
 			}
-		case "right", "l":
+		case actionNextTab:
 			// Navigate to next tab
 			if m.activeTab < len(m.tabs)-1 {
 				m.activeTab++
@@ -488,17 +728,24 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Reload allowlist data when switching to allowlist tab
 			if m.activeTab == 1 {
 				m.loadAllowlistData()
+			} else if m.activeTab == 4 {
+				m.loadDevices()
 			}
-		case "1":
+		case actionTabMonitoring:
 			m.activeTab = 0
-			// Reload allowlist data when switching to allowlist tab
-			if m.activeTab == 1 {
-				m.loadAllowlistData()
-			}
-		case "2":
+		case actionTabAllowlist:
 			m.activeTab = 1
 			// Reload allowlist data when switching to allowlist tab
 			m.loadAllowlistData()
+		case actionTabStats:
+			m.activeTab = 2
+			m.updateStats()
+		case actionTabFocus:
+			m.activeTab = 3
+			m.updateFocusModeStatus()
+		case actionTabDevices:
+			m.activeTab = 4
+			m.loadDevices()
 		default:
 			// Handle tab-specific key events
 			switch m.activeTab {
@@ -506,8 +753,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m.updateMonitoring(msg)
 			case 1:
 				return m.updateAllowedDomains(msg)
+			case 2:
+				return m.updatePane(msg, m.renderStats())
+			case 3:
+				return m.updateFocus(msg)
+			case 4:
+				return m.updateDevices(msg)
 			}
 		}
+		if m.activeTab != prevTab {
+			// Start each tab's scrollable content at the top
+			m.pane.SetYOffset(0)
+		}
 	}
 	return m, nil
 }
@@ -516,67 +773,330 @@ func (m *Model) updateMonitoring(msg tea.KeyMsg) (Model, tea.Cmd) {
 	// Track user activity
 	m.lastUserActivity = time.Now()
 
-	// Since we're now keeping only the visible entries, we can simplify this
 	visibleCount := len(m.monitoring.dnsQueries)
-
-	switch msg.String() {
-	case "up", "k":
+	pageSize := m.maxVisibleQueries()
+
+	switch m.keys.action(msg.String(), scopeMonitoring) {
+	case actionExportCSV:
+		m.exportAndReport("csv")
+	case actionExportJSON:
+		m.exportAndReport("json")
+	case actionPause:
+		if m.monitoring.frozen {
+			m.resumeRefresh()
+		} else {
+			m.monitoring.frozen = true
+		}
+	case actionResume:
+		m.resumeRefresh()
+	case actionMark:
+		if !m.focusModeActive && m.monitoring.tableCursor < len(m.monitoring.dnsQueries) {
+			domain := m.monitoring.dnsQueries[m.monitoring.tableCursor].Domain
+			m.monitoring.marked = toggleMark(m.monitoring.marked, domain)
+			if m.monitoring.tableCursor < len(m.monitoring.dnsQueries)-1 {
+				m.monitoring.tableCursor++
+			}
+		}
+	case actionBatch:
+		if !m.focusModeActive {
+			m.applyMarkedQueries()
+		}
+	case actionUndo:
+		m.undoAllowlist()
+	case actionFollow:
+		if m.monitoring.following {
+			m.stopFollow()
+			m.notify(severityInfo, fmt.Sprintf("Live tail off, polling every %s", formatInterval(m.refreshInterval)))
+			break
+		}
+		m.notify(severityInfo, "Live tail on")
+		return *m, m.startFollow()
+	case actionSort:
+		m.monitoring.byHits = !m.monitoring.byHits
+		m.monitoring.tableCursor = 0
+		m.applyQueryFilter()
+	case actionIgnore:
+		if !m.focusModeActive && m.monitoring.tableCursor < len(m.monitoring.dnsQueries) {
+			m.ignoreDomain(m.monitoring.dnsQueries[m.monitoring.tableCursor].Domain)
+		}
+	case actionSearch:
+		// The table is hidden during focus mode
+		m.monitoring.searching = !m.focusModeActive
+	case actionUp:
 		if m.monitoring.tableCursor > 0 {
 			m.monitoring.tableCursor--
 		}
-	case "down", "j":
+	case actionDown:
 		if m.monitoring.tableCursor < visibleCount-1 {
 			m.monitoring.tableCursor++
 		}
-	case " ", "enter":
+	case actionPageUp:
+		m.monitoring.tableCursor = max(m.monitoring.tableCursor-pageSize, 0)
+	case actionPageDown:
+		m.monitoring.tableCursor = max(min(m.monitoring.tableCursor+pageSize, visibleCount-1), 0)
+	case actionTop:
+		// Back to the newest entry, following new queries again
+		m.monitoring.tableCursor = 0
+	case actionBottom:
+		m.monitoring.tableCursor = max(visibleCount-1, 0)
+	case actionDetail:
+		if m.focusModeActive || m.monitoring.tableCursor >= len(m.monitoring.dnsQueries) {
+			break
+		}
+		query := m.monitoring.dnsQueries[m.monitoring.tableCursor]
+		m.monitoring.detail = &query
+		m.monitoring.detailHost = ""
+		m.monitoring.detailHits = nil
+		hits := m.loadHits(query.Domain)
+		if query.ClientName != "" {
+			// Named in client_names, so the reverse DNS name adds nothing
+			m.monitoring.detailHost = query.ClientName
+			return *m, hits
+		}
+		if !m.config.ResolvesClientHostnames() {
+			m.monitoring.detailHost = "(reverse DNS disabled)"
+			return *m, hits
+		}
+		if query.Client != "" && net.ParseIP(query.Client) == nil {
+			// client_privacy recorded a network or a label instead of the address
+			m.monitoring.detailHost = "(address hidden by client_privacy)"
+			return *m, hits
+		}
+		return *m, tea.Batch(hits, m.hostnames.lookup(query.Client))
+	case actionToggle:
 		if len(m.monitoring.dnsQueries) > 0 && m.monitoring.tableCursor < len(m.monitoring.dnsQueries) {
-			// Map cursor position to the original data order (since we reversed for display)
-			originalIndex := len(m.monitoring.dnsQueries) - 1 - m.monitoring.tableCursor
-			selectedQuery := m.monitoring.dnsQueries[originalIndex]
-			selectedDomain := selectedQuery.Domain
+			selectedDomain := m.monitoring.dnsQueries[m.monitoring.tableCursor].Domain
 
 			// Check if domain is already in allowlist
 			isInAllowlist := m.isInAllowlist(selectedDomain)
 
 			if isInAllowlist {
 				// Remove from allowlist if already present
-				if err := m.removeFromAllowlist(selectedDomain); err == nil {
+				if err := m.removeFromAllowlist(selectedDomain); err != nil {
+					m.notifyError(fmt.Sprintf("Could not remove %s", selectedDomain), err)
+				} else {
 					m.loadAllowlistData()
 					m.lastChangedDomain = selectedDomain
 					m.lastChangeTime = time.Now()
 				}
+			} else if pattern := m.allowlistMatch(selectedDomain); pattern != "" {
+				m.notify(severityInfo, fmt.Sprintf("%s is already allowed by %s", selectedDomain, pattern))
 			} else {
-				// Add to allowlist if not present
-				if err := m.addToAllowlist(selectedDomain); err == nil {
-					m.loadAllowlistData()
-					m.lastChangedDomain = selectedDomain
-					m.lastChangeTime = time.Now()
-				}
+				// Ask how broadly to allow the domain
+				m.openAllowChooser(selectedDomain)
 			}
 		}
 	}
+	m.scrollToCursor()
+	return *m, nil
+}
+
+// resumeRefresh unfreezes the monitoring table and applies the queries fetched while it was paused
+func (m *Model) resumeRefresh() {
+	m.monitoring.frozen = false
+	if m.monitoring.pending != nil {
+		m.monitoring.allQueries = m.monitoring.pending
+		m.monitoring.pending = nil
+		m.applyQueryFilter()
+		m.monitoring.lastUpdate = time.Now()
+	}
+}
+
+// newPendingQueries counts the queries fetched while paused that arrived after the last table update
+func (m Model) newPendingQueries() int {
+	count := 0
+	for _, query := range m.monitoring.pending {
+		if query.Timestamp.After(m.monitoring.lastUpdate) {
+			count++
+		}
+	}
+	return count
+}
+
+// updateSearch edits the monitoring search filter, applying it as the user types
+func (m *Model) updateSearch(msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.lastUserActivity = time.Now()
+
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		m.quitting = true
+		m.cleanup()
+		return *m, tea.Quit
+	case tea.KeyEsc:
+		m.monitoring.searching = false
+		m.monitoring.filter = ""
+	case tea.KeyEnter:
+		m.monitoring.searching = false
+	case tea.KeyBackspace:
+		if runes := []rune(m.monitoring.filter); len(runes) > 0 {
+			m.monitoring.filter = string(runes[:len(runes)-1])
+		}
+	case tea.KeySpace:
+		m.monitoring.filter += " "
+	case tea.KeyRunes:
+		m.monitoring.filter += string(msg.Runes)
+	}
+
+	m.applyQueryFilter()
 	return *m, nil
 }
 
+// applyQueryFilter rebuilds the monitoring table (newest first, or most queried first) from
+// all queries. While following the top entry the cursor stays there; otherwise it stays on
+// the selected domain as new queries arrive.
+func (m *Model) applyQueryFilter() {
+	following := m.monitoring.tableCursor == 0
+	var selectedDomain string
+	if m.monitoring.tableCursor < len(m.monitoring.dnsQueries) {
+		selectedDomain = m.monitoring.dnsQueries[m.monitoring.tableCursor].Domain
+	}
+
+	var queries []api.DNSQuery
+	for i := len(m.monitoring.allQueries) - 1; i >= 0; i-- {
+		if query := m.monitoring.allQueries[i]; !m.isIgnored(query.Domain) && m.matchesFilter(query) {
+			queries = append(queries, query)
+		}
+	}
+	if m.monitoring.byHits {
+		sort.SliceStable(queries, func(i, j int) bool { return queries[i].Hits > queries[j].Hits })
+	}
+	m.monitoring.dnsQueries = queries
+
+	m.monitoring.tableCursor = 0
+	if !following {
+		for i, query := range queries {
+			if query.Domain == selectedDomain {
+				m.monitoring.tableCursor = i
+				break
+			}
+		}
+	}
+	m.scrollToCursor()
+}
+
+// scrollToCursor moves the table viewport so the selected row is visible
+func (m *Model) scrollToCursor() {
+	pageSize := m.maxVisibleQueries()
+	if m.monitoring.tableCursor < m.monitoring.scrollTop {
+		m.monitoring.scrollTop = m.monitoring.tableCursor
+	}
+	if m.monitoring.tableCursor >= m.monitoring.scrollTop+pageSize {
+		m.monitoring.scrollTop = m.monitoring.tableCursor - pageSize + 1
+	}
+	m.monitoring.scrollTop = max(min(m.monitoring.scrollTop, len(m.monitoring.dnsQueries)-pageSize), 0)
+}
+
+// isIgnored reports whether a domain is in ignore_domains; the resolver stops recording its
+// queries, and those recorded before are hidden
+func (m Model) isIgnored(domain string) bool {
+	if m.config == nil {
+		return false
+	}
+	domain = strings.ToLower(domain)
+	return slices.ContainsFunc(m.config.IgnoreDomains, func(pattern string) bool { return allowlist.MatchPattern(pattern, domain) })
+}
+
+// ignoreDomain adds a domain to ignore_domains and hides its queries
+func (m *Model) ignoreDomain(domain string) {
+	domain = strings.ToLower(domain)
+	if err := allowlist.ValidatePattern(domain); err != nil {
+		m.notifyError("Could not ignore "+domain, err)
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		m.notifyError("Could not load the config", err)
+		return
+	}
+	key, err := config.LookupKey("ignore_domains")
+	if err == nil {
+		err = key.Add(cfg, domain)
+	}
+	if err == nil {
+		err = config.Save(cfg)
+	}
+	if err != nil {
+		m.notifyError("Could not ignore "+domain, err)
+		return
+	}
+	m.config.IgnoreDomains = cfg.IgnoreDomains
+	m.applyQueryFilter()
+	m.notify(severitySuccess, fmt.Sprintf("Ignoring %s; 'sinkzone config remove ignore_domains %s' shows it again", domain, domain))
+}
+
+// matchesFilter reports whether a query matches every term of the search filter
+func (m Model) matchesFilter(query api.DNSQuery) bool {
+	for _, term := range strings.Fields(strings.ToLower(m.monitoring.filter)) {
+		switch {
+		case term == "is:blocked":
+			if m.isInAllowlist(query.Domain) {
+				return false
+			}
+		case term == "is:allowed":
+			if !m.isInAllowlist(query.Domain) {
+				return false
+			}
+		case strings.HasPrefix(term, "user:"):
+			if strings.ToLower(query.User) != strings.TrimPrefix(term, "user:") {
+				return false
+			}
+		case strings.HasPrefix(term, "client:"):
+			client := strings.TrimPrefix(term, "client:")
+			if !strings.Contains(strings.ToLower(query.Client), client) && !strings.Contains(strings.ToLower(query.ClientName), client) {
+				return false
+			}
+		default:
+			if !strings.Contains(strings.ToLower(query.Domain), term) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// maxVisibleQueries returns how many query rows fit in the monitoring table
+func (m Model) maxVisibleQueries() int {
+	// Account for the search bar, table header and rule, and the footer
+	return max(m.layout().innerHeight-5, 3)
+}
+
 func (m *Model) updateAllowedDomains(msg tea.KeyMsg) (Model, tea.Cmd) {
 	// Track user activity
 	m.lastUserActivity = time.Now()
 
-	switch msg.String() {
-	case "up", "k":
+	switch m.keys.action(msg.String(), scopeAllowlist) {
+	case actionExportCSV:
+		m.exportAndReport("csv")
+	case actionExportJSON:
+		m.exportAndReport("json")
+	case actionUp:
 		if m.allowedDomains.cursor > 0 {
 			m.allowedDomains.cursor--
 		}
-	case "down", "j":
+	case actionDown:
 		if m.allowedDomains.cursor < len(m.allowedDomains.domains)-1 {
 			m.allowedDomains.cursor++
 		}
-	case " ", "enter":
+	case actionMark:
+		if m.allowedDomains.cursor < len(m.allowedDomains.domains) {
+			domain := m.allowedDomains.domains[m.allowedDomains.cursor]
+			m.allowedDomains.marked = toggleMark(m.allowedDomains.marked, domain)
+			if m.allowedDomains.cursor < len(m.allowedDomains.domains)-1 {
+				m.allowedDomains.cursor++
+			}
+		}
+	case actionBatch:
+		m.removeMarkedDomains()
+	case actionUndo:
+		m.undoAllowlist()
+	case actionToggle:
 		if len(m.allowedDomains.domains) > 0 && m.allowedDomains.cursor < len(m.allowedDomains.domains) {
 			selectedDomain := m.allowedDomains.domains[m.allowedDomains.cursor]
 
 			// Remove from allowlist
-			if err := m.removeFromAllowlist(selectedDomain); err == nil {
+			if err := m.removeFromAllowlist(selectedDomain); err != nil {
+				m.notifyError(fmt.Sprintf("Could not remove %s", selectedDomain), err)
+			} else {
 				m.loadAllowlistData()
 				m.lastChangedDomain = selectedDomain
 				m.lastChangeTime = time.Now()
@@ -587,15 +1107,40 @@ func (m *Model) updateAllowedDomains(msg tea.KeyMsg) (Model, tea.Cmd) {
 }
 
 func (m Model) renderTabs() string {
-	var renderedTabs []string
+	names := make([]string, len(m.tabs))
 	for i, tab := range m.tabs {
+		names[i] = i18n.T(tab)
+	}
+
+	tabs := lipgloss.JoinHorizontal(lipgloss.Left, m.tabLabels(names)...)
+	// Fall back to numbered abbreviations, then bare numbers, on narrow terminals
+	for _, abbreviation := range []int{3, 0} {
+		if m.width == 0 || lipgloss.Width(tabs) <= m.width {
+			break
+		}
+		short := make([]string, len(names))
+		for i, name := range names {
+			short[i] = fmt.Sprintf("%d", i+1)
+			if abbreviation > 0 {
+				runes := []rune(name)
+				short[i] += ":" + string(runes[:min(len(runes), abbreviation)])
+			}
+		}
+		tabs = lipgloss.JoinHorizontal(lipgloss.Left, m.tabLabels(short)...)
+	}
+	return tabs
+}
+
+func (m Model) tabLabels(names []string) []string {
+	var renderedTabs []string
+	for i, tab := range names {
 		if i == m.activeTab {
 			renderedTabs = append(renderedTabs, activeTabStyle.Render(tab))
 		} else {
 			renderedTabs = append(renderedTabs, tabStyle.Render(tab))
 		}
 	}
-	return lipgloss.JoinHorizontal(lipgloss.Left, renderedTabs...)
+	return renderedTabs
 }
 
 func (m Model) renderBanner() string {
@@ -617,7 +1162,7 @@ func (m Model) renderBanner() string {
 			// Create rainbow style for this line
 			rainbowStyle := lipgloss.NewStyle().
 				Foreground(color).
-				Background(background).
+				Background(currentTheme.Background).
 				Bold(true)
 
 			rainbowBanner.WriteString(rainbowStyle.Render(line) + "\n")
@@ -631,8 +1176,9 @@ func (m Model) renderBanner() string {
 }
 
 func (m Model) View() string {
+	defer crash.Recover("tui")
 	if m.quitting {
-		return "Goodbye!\n"
+		return i18n.T("Goodbye!") + "\n"
 	}
 
 	// Safety check to ensure activeTab is within bounds
@@ -640,68 +1186,21 @@ func (m Model) View() string {
 		m.activeTab = 0
 	}
 
-	// Render header with banner animation
-	bannerText := ""
-	if m.animationDone {
-		bannerText = "\n" + m.renderBanner() // Add newline to start from 2nd line
-	} else {
-		// Show animated banner starting from 2nd line
-		bannerText = "\n" // Start from 2nd line
-		for i := 0; i <= m.currentLine && i < len(m.bannerLines); i++ {
-			bannerText += m.bannerLines[i] + "\n"
-		}
-		// Add empty lines to maintain height during animation
-		for i := len(m.bannerLines) - m.currentLine - 1; i > 0; i-- {
-			bannerText += "\n"
-		}
-	}
-
 	// Calculate consistent heights to prevent jiggling
-	headerHeight := lipgloss.Height(headerStyle.Render(m.renderBanner())) + 2 // Add padding for banner
-	tabHeight := 1
-	footerHeight := 1
-
-	// Calculate content height to fill remaining space
-	contentHeight := m.height - headerHeight - tabHeight - footerHeight - 2 // Minimal padding
-
-	// Ensure minimum content height
-	if contentHeight < 5 {
-		contentHeight = 5
-	}
-
-	// Add focus mode indicator to header if active
-	var header string
-	if m.focusModeActive {
-		focusIndicator := lipgloss.NewStyle().
-			Background(lipgloss.Color("#FF6B6B")). // Red background
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Bold(true).
-			Padding(0, 1).
-			Render("🔒 FOCUS MODE ACTIVE")
-
-		// Combine banner with focus indicator
-		headerContent := bannerText + "\n" + focusIndicator
-
-		// Use red-tinted header style for focus mode
-		focusHeaderStyle := headerStyle.
-			Background(lipgloss.Color("#2D1B1B")). // Dark red background
-			Foreground(lipgloss.Color("#FF6B6B"))  // Red text
-		header = focusHeaderStyle.Width(m.width).Height(headerHeight).Align(lipgloss.Center).Padding(1, 0).Render(headerContent)
-	} else {
-		// Always render header with full height to prevent jiggling
-		header = headerStyle.Width(m.width).Height(headerHeight).Align(lipgloss.Center).Padding(1, 0).Render(bannerText)
-	}
+	l := m.layout()
+	contentHeight := l.contentHeight
+	header := m.renderHeader(l.compactBanner)
 
 	// Render tabs
 	tabs := m.renderTabs()
 
 	// Content area with safety check
-	contentText := "No content available"
+	contentText := i18n.T("No content available")
 	if m.activeTab < len(m.tabs) {
 		switch m.activeTab {
 		case 0: // Monitoring tab
 			if m.focusModeActive {
-				contentText = `
+				contentText = i18n.T(`
 🔒 FOCUS MODE ACTIVE
 
 Monitoring is disabled during focus mode.
@@ -710,198 +1209,449 @@ DNS monitoring is temporarily disabled to help you stay focused.
 
 You can still manage your allowlist.
 
-Press ←/→ to switch to other tabs.`
+Press ←/→ to switch to other tabs.`)
+			} else if m.monitoring.chooser != nil {
+				contentText = m.renderAllowChooser()
+			} else if m.monitoring.detail != nil {
+				contentText = m.renderQueryDetail()
 			} else {
 				contentText = m.renderDNSMonitoring()
 			}
 		case 1: // Allowlist tab
 			contentText = m.renderAllowedDomains()
+		case 2: // Stats tab
+			contentText = m.renderPane(m.renderStats())
+		case 3: // Focus tab
+			contentText = m.renderPane(m.renderFocus())
+		case 4: // Devices tab
+			contentText = m.renderDevices()
 		}
 	}
 
-	// Show temporary focus message if present
-	if m.focusMessage != "" {
-		messageStyle := lipgloss.NewStyle().
-			Background(lipgloss.Color("#4ADE80")). // Green background
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Bold(true).
-			Padding(1, 2).
-			Align(lipgloss.Center)
-
-		contentText = messageStyle.Render(m.focusMessage) + "\n\n" + contentText
+	if m.showHelp {
+		contentText = m.renderPane(m.renderHelp())
+	}
+	if m.showMessages {
+		contentText = m.renderPane(m.renderMessageHistory())
+	}
+	if m.showSettings {
+		contentText = m.renderPane(m.renderSettings())
+	}
+	if m.review != nil {
+		contentText = m.renderReview()
 	}
 
 	// Apply content style with conditional height
 	content := contentStyle.Width(m.width - 4).Height(contentHeight).Render(contentText)
 
-	// Footer with full width
-	footer := footerStyle.Width(m.width).Render("Navigation: ←/→ Switch tabs | ↑/↓ Navigate | Space/Enter Add/Remove | F Focus mode | ESC Quit")
+	// Footer with full width, led by the focus countdown during a session
+	footerText := i18n.T("%s Switch tabs | %s Focus mode | %s Help | %s Quit",
+		m.keys.describe(actionNextTab), m.keys.describe(actionFocus), m.keys.describe(actionHelp), m.keys.describe(actionQuit))
+	if countdown := m.focusCountdown(); m.focusModeActive && countdown != "" {
+		footerText = "🔒 " + countdown + " | " + footerText
+	}
+	footer := footerStyle.Width(m.width).MaxHeight(1).Render(footerText)
+	if m.commandMode {
+		footer = m.renderCommandPrompt()
+	}
+	if m.pinPrompt != nil {
+		footer = m.renderPINPrompt()
+	}
+
+	// Show the latest message, a DNS bypass, a captive portal, then API errors, above the footer instead of silently showing stale data
+	sections := []string{header, tabs, content}
+	if messageBar := m.renderMessageBar(); messageBar != "" {
+		sections = append(sections, messageBar)
+	}
+	if banner := m.renderBypassBanner(); banner != "" {
+		sections = append(sections, banner)
+	}
+	if banner := m.renderPortalBanner(); banner != "" {
+		sections = append(sections, banner)
+	}
+	if statusBar := m.renderStatusBar(); statusBar != "" {
+		sections = append(sections, statusBar)
+	}
+	sections = append(sections, footer)
 
 	// Combine all elements
-	return docStyle.Render(
-		lipgloss.JoinVertical(
-			lipgloss.Left,
-			header,
-			tabs,
-			content,
-			footer,
-		),
-	)
+	return docStyle.Render(lipgloss.JoinVertical(lipgloss.Left, sections...))
+}
+
+// renderHeader renders the banner, or a one-line title on small terminals, with the focus mode and health indicators
+func (m Model) renderHeader(compact bool) string {
+	if compact {
+		return m.renderCompactHeader()
+	}
+
+	// Render header with banner animation
+	bannerText := ""
+	if m.animationDone {
+		bannerText = "\n" + m.renderBanner() // Add newline to start from 2nd line
+	} else {
+		// Show animated banner starting from 2nd line
+		bannerText = "\n" // Start from 2nd line
+		for i := 0; i <= m.currentLine && i < len(m.bannerLines); i++ {
+			bannerText += m.bannerLines[i] + "\n"
+		}
+		// Add empty lines to maintain height during animation
+		for i := len(m.bannerLines) - m.currentLine - 1; i > 0; i-- {
+			bannerText += "\n"
+		}
+	}
+	headerHeight := lipgloss.Height(headerStyle.Render(m.renderBanner())) + 2 // Add padding for banner
+
+	// Add focus mode indicator to header if active
+	if m.focusModeActive {
+		focusIndicator := lipgloss.NewStyle().
+			Background(currentTheme.Alert).
+			Foreground(currentTheme.OnColor).
+			Bold(true).
+			Padding(0, 1).
+			Render(m.focusIndicatorText())
+
+		// Combine banner with focus and health indicators
+		headerContent := bannerText + "\n" + focusIndicator + "\n" + m.healthIndicator()
+
+		// Use red-tinted header style for focus mode
+		focusHeaderStyle := headerStyle.
+			Background(currentTheme.AlertBackground).
+			Foreground(currentTheme.Alert)
+		return focusHeaderStyle.Width(m.width).Height(headerHeight).Align(lipgloss.Center).Padding(1, 0).Render(headerContent)
+	}
+
+	// Always render header with full height to prevent jiggling
+	return headerStyle.Width(m.width).Height(headerHeight).Align(lipgloss.Center).Padding(1, 0).Render(bannerText + "\n" + m.healthIndicator())
+}
+
+// focusIndicatorText returns the header badge shown while focus mode is active, with the live countdown
+func (m Model) focusIndicatorText() string {
+	text := i18n.T("🔒 FOCUS MODE ACTIVE")
+	if m.focusProfile != "" {
+		text += fmt.Sprintf(" (%s)", m.focusProfile)
+	}
+	if countdown := m.focusCountdown(); countdown != "" {
+		text += " · " + countdown
+	}
+	return text
 }
 
 func (m Model) renderDNSMonitoring() string {
+	searchBar := ""
+	if m.monitoring.searching {
+		searchBar = i18n.T("Search: %s█  (Enter to apply, Esc to clear)", m.monitoring.filter) + "\n"
+	} else if m.monitoring.filter != "" {
+		searchBar = i18n.T("Filter: %s  (/ to edit, Esc to clear)", m.monitoring.filter) + "\n"
+	}
+
+	if len(m.monitoring.dnsQueries) == 0 && len(m.monitoring.allQueries) > 0 {
+		return searchBar + i18n.T(`
+No queries match the filter.
+
+Search by domain, or use client:<address>, user:<name>, is:blocked, or is:allowed.`)
+	}
+
 	if len(m.monitoring.dnsQueries) == 0 {
-		return `
+		return i18n.T(`
 No DNS queries recorded yet.
 
 Try making some web requests to see DNS activity.
 
-Make sure the resolver is running with 'sinkzone resolver'`
+Make sure the resolver is running with 'sinkzone resolver'`)
 	}
 
-	// Reverse the data to show newest entries first (at the top)
-	queries := make([]api.DNSQuery, len(m.monitoring.dnsQueries))
-	copy(queries, m.monitoring.dnsQueries)
-	for i, j := 0, len(queries)-1; i < j; i, j = i+1, j-1 {
-		queries[i], queries[j] = queries[j], queries[i]
+	// Only render the rows inside the viewport
+	queries := m.monitoring.dnsQueries
+	top := min(m.monitoring.scrollTop, len(queries))
+	bottom := min(top+m.maxVisibleQueries(), len(queries))
+
+	// Narrow terminals drop the time column
+	width := m.layout().innerWidth
+	showTime := width >= narrowTableWidth
+	columns := []table.Column{{Title: i18n.T("Domain")}, {Title: i18n.T("Hits"), Width: 6}, {Title: i18n.T("Time"), Width: 8}, {Title: i18n.T("Status"), Width: 8}}
+	if !showTime {
+		columns = []table.Column{{Title: i18n.T("Domain")}, {Title: i18n.T("Hits"), Width: 6}, {Title: i18n.T("Status"), Width: 8}}
 	}
-
-	// Header
-	header := fmt.Sprintf("%-40s %-20s %-10s\n", "Domain", "Time", "Status")
-	header += strings.Repeat("-", 70) + "\n"
+	columns = fitColumns(width, columns)
 
 	// Table rows
-	var rows []string
-	for i, query := range queries {
+	var rows []table.Row
+	for i := top; i < bottom; i++ {
+		query := queries[i]
+
 		// Check if domain is in allowlist
-		isInAllowlist := m.isInAllowlist(query.Domain)
-		status := "BLOCK"
-		if isInAllowlist {
-			status = "ALLOW"
+		status := i18n.T("BLOCK")
+		if m.allowlistMatch(query.Domain) != "" {
+			status = i18n.T("ALLOW")
 		}
-
-		// Truncate domain if too long
-		domain := query.Domain
-		if len(domain) > 38 {
-			domain = domain[:35] + "..."
+		if m.recentlyChanged(query.Domain) {
+			status = "✓ " + status
 		}
 
-		// Check if this row is selected
-		// Since we display newest first (reversed), map cursor position
-		isSelected := i == m.monitoring.tableCursor
-		recentlyChanged := query.Domain == m.lastChangedDomain && time.Since(m.lastChangeTime) < 2*time.Second
-
-		row := formatTableRow(domain, query.Timestamp, status, isSelected, recentlyChanged)
+		domain := markCell(m.monitoring.marked[query.Domain], countedDomain(query))
+		hits := "-" // Resolvers before hit counts
+		if query.Hits > 0 {
+			hits = strconv.Itoa(query.Hits)
+		}
+		row := table.Row{domain, hits, query.Timestamp.Format("15:04:05"), status}
+		if !showTime {
+			row = table.Row{domain, hits, status}
+		}
 		rows = append(rows, row)
 	}
+	highlight := currentTheme.Selected
+	if m.monitoring.tableCursor < len(queries) && m.recentlyChanged(queries[m.monitoring.tableCursor].Domain) {
+		highlight = currentTheme.SelectedChanged
+	}
 
 	// Footer
-	footer := fmt.Sprintf("\nLast updated: %s | Press Space/Enter to add domains to allowlist", m.monitoring.lastUpdate.Format("15:04:05"))
+	position := i18n.T("● Following newest")
+	if m.monitoring.byHits {
+		position = i18n.T("● Most queried first (%s for newest)", m.keys.describe(actionSort))
+	}
+	if m.monitoring.tableCursor > 0 {
+		position = i18n.T("At %d/%d (Home to go to the top)", m.monitoring.tableCursor+1, len(queries))
+		if !m.monitoring.byHits {
+			position = i18n.T("At %d/%d (Home to follow newest)", m.monitoring.tableCursor+1, len(queries))
+		}
+	}
+	footer := "\n" + i18n.T("%s | Rows %d-%d of %d | Last updated: %s",
+		position, top+1, bottom, len(queries), m.monitoring.lastUpdate.Format("15:04:05"))
+	if !showTime {
+		footer = "\n" + i18n.T("Rows %d-%d of %d", top+1, bottom, len(queries))
+	}
+
+	if marked := len(markedDomains(m.monitoring.marked, m.queryDomains())); marked > 0 {
+		footer = "\n" + m.markSummary(marked, i18n.T("allow (or remove)")) + strings.TrimPrefix(footer, "\n")
+	}
+
+	if live := m.followStatus(); live != "" && !m.monitoring.frozen {
+		badge := lipgloss.NewStyle().
+			Background(currentTheme.Success).
+			Foreground(currentTheme.OnColor).
+			Bold(true).
+			Padding(0, 1).
+			Render(live)
+		footer = "\n" + i18n.T("%s %s to stop | %s", badge, m.keys.describe(actionFollow), strings.TrimPrefix(footer, "\n"))
+	}
+
+	if m.monitoring.frozen {
+		badge := lipgloss.NewStyle().
+			Background(currentTheme.Alert).
+			Foreground(currentTheme.OnColor).
+			Bold(true).
+			Padding(0, 1).
+			Render(i18n.T("⏸ PAUSED"))
+		footer = "\n" + i18n.T("%s %d new, %s to resume | %s", badge, m.newPendingQueries(), m.keys.describe(actionPause), strings.TrimPrefix(footer, "\n"))
+	}
 
-	return header + strings.Join(rows, "\n") + footer
+	return searchBar + renderTable(columns, rows, m.monitoring.tableCursor-top, highlight) + footer
 }
 
 func (m Model) renderAllowedDomains() string {
 	if len(m.allowedDomains.domains) == 0 {
-		return `
+		return i18n.T(`
 Allowlist is empty.
 
 Add domains to your allowlist to permit them during focus mode.
 
-Use the Monitoring tab to see which domains are being accessed.`
+Use the Monitoring tab to see which domains are being accessed.`)
 	}
 
-	// Header - use same format as monitoring tab
-	header := fmt.Sprintf("%-40s %-20s %-10s\n", "Domain", "Type", "Status")
-	header += strings.Repeat("-", 70) + "\n"
+	columns := fitColumns(m.layout().innerWidth, []table.Column{{Title: i18n.T("Domain")}, {Title: i18n.T("Type"), Width: 8}})
+
+	// Keep the selected domain inside the rows that fit
+	domains := m.allowedDomains.domains
+	visible := max(m.layout().innerHeight-4, 3) // Account for the table header and rule, and the footer
+	top := max(m.allowedDomains.cursor-visible+1, 0)
+	bottom := min(top+visible, len(domains))
 
 	// Table rows
-	var rows []string
-	for i, domain := range m.allowedDomains.domains {
+	var rows []table.Row
+	for _, domain := range domains[top:bottom] {
 		// Determine domain type
-		domainType := "EXACT"
+		domainType := i18n.T("EXACT")
 		if strings.Contains(domain, "*") {
-			domainType = "WILDCARD"
+			domainType = i18n.T("WILDCARD")
 		}
+		rows = append(rows, table.Row{markCell(m.allowedDomains.marked[domain], domain), domainType})
+	}
+	highlight := currentTheme.Selected
+	if m.allowedDomains.cursor < len(domains) && m.recentlyChanged(domains[m.allowedDomains.cursor]) {
+		highlight = currentTheme.SelectedChanged
+	}
 
-		// Status is always ALLOWED for allowlist
-		status := "ALLOWED"
+	// Footer
+	footer := "\n" + i18n.T("Allowlist (%d domains) | %s to remove domains | %s to mark", len(domains), m.keys.describe(actionToggle), m.keys.describe(actionMark))
+	if marked := len(markedDomains(m.allowedDomains.marked, domains)); marked > 0 {
+		footer = "\n" + m.markSummary(marked, i18n.T("remove them")) + i18n.T("Allowlist (%d domains)", len(domains))
+	}
 
-		// Truncate domain if too long
-		displayDomain := domain
-		if len(displayDomain) > 38 {
-			displayDomain = displayDomain[:35] + "..."
-		}
+	return renderTable(columns, rows, m.allowedDomains.cursor-top, highlight) + footer
+}
 
-		// Check if this row is selected
-		isSelected := i == m.allowedDomains.cursor
-		recentlyChanged := domain == m.lastChangedDomain && time.Since(m.lastChangeTime) < 2*time.Second
+func (m Model) renderStats() string {
+	if m.stats == nil {
+		return i18n.T(`
+Stats are not available.
 
-		// Use a custom format function for allowlist rows
-		row := formatAllowlistRow(displayDomain, domainType, status, isSelected, recentlyChanged)
-		rows = append(rows, row)
+Make sure the resolver is running with 'sinkzone resolver'`)
 	}
 
-	// Footer
-	footer := fmt.Sprintf("\nAllowlist (%d domains) | Press Space/Enter to remove domains", len(m.allowedDomains.domains))
+	dashboard := m.renderQueryStats() + renderPressure(m.stats.Pressure)
+
+	if m.stats.Goal == "" {
+		return dashboard + i18n.T(`
+Focus time today: %s
 
-	return header + strings.Join(rows, "\n") + footer
+No daily goal configured.
+
+Set one in sinkzone.yaml to track streaks:
+
+  daily_goal: 2h`, m.stats.Today) + renderLabelStats(m.stats.Labels) + renderBudgets(m.stats.Budgets)
+	}
+
+	status := i18n.T("In progress")
+	if m.stats.GoalMet {
+		status = i18n.T("Goal met!")
+	}
+
+	return dashboard + i18n.T(`
+Daily goal:      %s
+Focus today:     %s
+Progress:        %s %d%%
+Status:          %s
+
+Current streak:  %d day(s)
+Longest streak:  %d day(s)`,
+		m.stats.Goal,
+		m.stats.Today,
+		progressBar(m.stats.Progress, 30), int(m.stats.Progress*100),
+		status,
+		m.stats.Streak,
+		m.stats.LongestStreak,
+	) + renderLabelStats(m.stats.Labels) + renderBudgets(m.stats.Budgets)
 }
 
-func formatAllowlistRow(domain string, domainType string, status string, isSelected bool, recentlyChanged bool) string {
-	row := fmt.Sprintf("%-40s %-20s %-10s", domain, domainType, status)
+// renderPressure renders the distraction pressure of the running focus session, with a
+// bar full at 4 blocked attempts a minute
+func renderPressure(pressure api.DistractionPressure) string {
+	if !pressure.Active {
+		return ""
+	}
+	level := map[string]string{
+		api.PressureCalm:     i18n.T("calm"),
+		api.PressureElevated: i18n.T("elevated"),
+		api.PressureHigh:     i18n.T("high, take a break or recommit"),
+	}[pressure.Level]
+	return "\n" + i18n.T("Distraction:     %s %.1f blocked/min (%s), peak %.1f", progressBar(min(pressure.PerMinute/4, 1), 30), pressure.PerMinute, level, pressure.Peak) + "\n"
+}
 
-	switch {
-	case isSelected && recentlyChanged:
-		// Combined state: selected and recently changed - use a distinct color
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#059669")). // Green background for selected + recently changed
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	case isSelected:
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#3B82F6")). // Blue background for selected
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	case recentlyChanged:
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#8B5CF6")). // Purple background for recently changed
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	default:
-		return row
+// renderQueryStats renders query totals, the blocked ratio, recent activity, latency, and the busiest domains and clients
+func (m Model) renderQueryStats() string {
+	stats := m.queryStats
+	if stats == nil {
+		return ""
 	}
+
+	blockedRatio := 0.0
+	if stats.Total > 0 {
+		blockedRatio = float64(stats.Blocked) / float64(stats.Total)
+	}
+
+	lines := []string{
+		i18n.T("Queries since %s: %d (%d allowed, %d blocked)", stats.Since.Format("Jan 2 15:04"), stats.Total, stats.Allowed, stats.Blocked),
+		i18n.T("Blocked:         %s %d%%", progressBar(blockedRatio, 30), int(blockedRatio*100)),
+		i18n.T("Last hour:       %s", sparkline(stats.PerMinute)),
+	}
+	if latency := stats.Latency; latency.Count > 0 {
+		lines = append(lines, i18n.T("Latency:         %.1f ms average, %.1f ms p95", latency.Average, latency.P95))
+	}
+
+	columns := []string{renderCounts(i18n.T("Top domains"), stats.TopDomains), renderCounts(i18n.T("Top clients"), stats.TopClients)}
+	return "\n" + strings.Join(lines, "\n") + "\n\n" +
+		lipgloss.JoinHorizontal(lipgloss.Top, columns[0], "    ", columns[1]) + "\n"
 }
 
-func formatTableRow(domain string, timestamp time.Time, status string, isSelected bool, recentlyChanged bool) string {
-	row := fmt.Sprintf("%-40s %-20s %-10s", domain, timestamp.Format("15:04:05"), status)
+// renderCounts renders a top-10 list with bars relative to the busiest entry
+func renderCounts(title string, counts []api.Count) string {
+	rows := []string{title + ":"}
+	if len(counts) == 0 {
+		rows = append(rows, "  "+i18n.T("(none yet)"))
+	}
+	for _, count := range counts {
+		name := count.Name
+		if len(name) > 28 {
+			name = name[:25] + "..."
+		}
+		fraction := float64(count.Count) / float64(counts[0].Count)
+		rows = append(rows, fmt.Sprintf("  %-28s %s %d", name, strings.Repeat("█", int(fraction*15)), count.Count))
+	}
+	return strings.Join(rows, "\n")
+}
 
-	switch {
-	case isSelected && recentlyChanged:
-		// Combined state: selected and recently changed - use a distinct color
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#059669")). // Green background for selected + recently changed
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	case isSelected:
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#3B82F6")). // Blue background for selected
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	case recentlyChanged:
-		return lipgloss.NewStyle().
-			Background(lipgloss.Color("#8B5CF6")). // Purple background for recently changed
-			Foreground(lipgloss.Color("#FFFFFF")). // White text
-			Padding(0, 1).
-			Render(row)
-	default:
-		return row
+// sparkline renders values as a row of block characters scaled to the largest value
+func sparkline(values []int) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+	peak := 0
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+
+	var line strings.Builder
+	for _, value := range values {
+		level := 0
+		if peak > 0 {
+			level = value * (len(levels) - 1) / peak
+		}
+		line.WriteRune(levels[level])
+	}
+	return line.String()
+}
+
+// renderLabelStats lists the focus time per session label
+func renderLabelStats(labels []api.LabelStats) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	rows := []string{"\n\n" + i18n.T("By label:"), fmt.Sprintf("  %-30s %-10s %s", i18n.T("Label"), i18n.T("Today"), i18n.T("Total"))}
+	for _, label := range labels {
+		rows = append(rows, fmt.Sprintf("  %-30s %-10s %s", label.Label, label.Today, label.Total))
+	}
+	return strings.Join(rows, "\n")
+}
+
+// renderBudgets renders the daily time budgets with their use today
+func renderBudgets(budgets []api.BudgetStatus) string {
+	if len(budgets) == 0 {
+		return ""
+	}
+	rows := []string{"\n\n" + i18n.T("Daily budgets:")}
+	for _, budget := range budgets {
+		row := fmt.Sprintf("  %-30s %s %s / %s", budget.Domain, progressBar(budget.Progress, 20), budget.Used, budget.Limit)
+		if budget.Exhausted {
+			row += "  " + i18n.T("used up")
+		}
+		rows = append(rows, row)
 	}
+	return strings.Join(rows, "\n")
+}
+
+// progressBar renders a fraction (clamped to 0..1) as a fixed-width bar
+func progressBar(fraction float64, width int) string {
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+	filled := int(fraction * float64(width))
+	return "[" + strings.Repeat("█", filled) + strings.Repeat("░", width-filled) + "]"
+}
+
+// recentlyChanged reports whether a domain was just added to or removed from the allowlist
+func (m Model) recentlyChanged(domain string) bool {
+	return domain == m.lastChangedDomain && time.Since(m.lastChangeTime) < 2*time.Second
 }
 
 func (m *Model) addToAllowlist(domain string) error {
@@ -922,6 +1672,28 @@ func (m *Model) removeFromAllowlist(domain string) error {
 	return manager.Remove(domain)
 }
 
+// undoAllowlist restores the allowlist from before its last change, as 'sinkzone allowlist
+// undo' does
+func (m *Model) undoAllowlist() {
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		m.notifyError("Could not open the allowlist", err)
+		return
+	}
+	change, err := manager.Undo()
+	if errors.Is(err, allowlist.ErrNothingToUndo) {
+		m.notify(severityInfo, "Nothing to undo")
+		return
+	}
+	if err != nil {
+		m.notifyError("Could not undo", err)
+		return
+	}
+	m.allowedDomains.marked = nil
+	m.loadAllowlistData()
+	m.notify(severitySuccess, fmt.Sprintf("Undid %s", change.Operation))
+}
+
 func (m Model) isInAllowlist(domain string) bool {
 	for _, allowedDomain := range m.allowedDomains.domains {
 		if allowedDomain == domain {
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	miekg "github.com/miekg/dns"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/sinktest"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	m := Model{allowedDomains: AllowedDomainsState{domains: []string{"github.com"}}}
+	query := api.DNSQuery{Domain: "GitHub.com", Client: "192.168.1.20", ClientName: "laptop", User: "alice"}
+	reddit := api.DNSQuery{Domain: "www.reddit.com", Client: "192.168.1.30"}
+
+	tests := []struct {
+		filter  string
+		query   api.DNSQuery
+		matches bool
+	}{
+		{"", query, true},
+		{"hub", query, true},
+		{"HUB", query, true},
+		{"gitlab", query, false},
+		{"client:192.168.1.2", query, true},
+		{"client:LAPTOP", query, true},
+		{"client:phone", query, false},
+		{"user:alice", query, true},
+		{"user:ali", query, false},
+		{"is:allowed", api.DNSQuery{Domain: "github.com"}, true},
+		{"is:blocked", reddit, true},
+		{"is:allowed", reddit, false},
+		{"reddit is:blocked client:192.168.1.30", reddit, true},
+		{"reddit client:192.168.1.20", reddit, false},
+	}
+
+	for _, test := range tests {
+		m.monitoring.filter = test.filter
+		if got := m.matchesFilter(test.query); got != test.matches {
+			t.Errorf("%q on %s: expected %v, got %v", test.filter, test.query.Domain, test.matches, got)
+		}
+	}
+}
+
+func TestUpdateSearch(t *testing.T) {
+	m := Model{monitoring: MonitoringState{
+		searching: true,
+		allQueries: []api.DNSQuery{
+			{Domain: "github.com"},
+			{Domain: "www.reddit.com"},
+			{Domain: "old.reddit.com"},
+		},
+	}}
+
+	for _, key := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune("redx")},
+		{Type: tea.KeyBackspace},
+	} {
+		m, _ = m.updateSearch(key)
+	}
+	var domains []string
+	for _, query := range m.monitoring.dnsQueries {
+		domains = append(domains, query.Domain)
+	}
+	if m.monitoring.filter != "red" || !slices.Equal(domains, []string{"old.reddit.com", "www.reddit.com"}) {
+		t.Errorf("expected the reddit queries, newest first, for %q, got %v", m.monitoring.filter, domains)
+	}
+
+	m, _ = m.updateSearch(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.monitoring.searching || m.monitoring.filter != "red" {
+		t.Errorf("expected Enter to keep the filter and stop editing, got %q (searching %v)", m.monitoring.filter, m.monitoring.searching)
+	}
+
+	m.monitoring.searching = true
+	m, _ = m.updateSearch(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.monitoring.searching || m.monitoring.filter != "" || len(m.monitoring.dnsQueries) != 3 {
+		t.Errorf("expected Esc to clear the filter, got %q with %d queries", m.monitoring.filter, len(m.monitoring.dnsQueries))
+	}
+}
+
+func TestPauseRefresh(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{})
+	keys, _ := newKeyMap(nil)
+	m := Model{apiClient: r.Client, keys: keys, animationDone: true, historySize: 100, refreshInterval: time.Second, width: 120, height: 40}
+
+	tick := func() {
+		t.Helper()
+		m.lastUserActivity = time.Time{}
+		updated, cmd := m.Update(tickMsg(time.Now()))
+		m = updated.(Model)
+		if cmd == nil {
+			t.Fatal("expected every tick to schedule the next one")
+		}
+	}
+	pause := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")}
+	domains := func(queries []api.DNSQuery) []string {
+		var domains []string
+		for _, query := range queries {
+			domains = append(domains, query.Domain)
+		}
+		return domains
+	}
+
+	r.Query(t, "github.com", miekg.TypeA)
+	tick()
+	if got := domains(m.monitoring.dnsQueries); !slices.Equal(got, []string{"github.com"}) {
+		t.Fatalf("expected the tick to show the first query, got %v", got)
+	}
+
+	m, _ = m.updateMonitoring(pause)
+	r.Query(t, "example.com", miekg.TypeA)
+	tick()
+	tick()
+	if got := domains(m.monitoring.dnsQueries); !m.monitoring.frozen || !slices.Equal(got, []string{"github.com"}) {
+		t.Errorf("expected the paused table to keep its rows, got %v", got)
+	}
+	if view := m.renderDNSMonitoring(); m.newPendingQueries() != 1 || !strings.Contains(view, "PAUSED") || !strings.Contains(view, "1 new") {
+		t.Errorf("expected the footer to count 1 new query while paused, got %d in %q", m.newPendingQueries(), view)
+	}
+
+	m, _ = m.updateMonitoring(pause)
+	if got := domains(m.monitoring.dnsQueries); m.monitoring.frozen || m.monitoring.pending != nil || !slices.Equal(got, []string{"example.com", "github.com"}) {
+		t.Errorf("expected resuming to apply the queries fetched while paused, got %v", got)
+	}
+}
@@ -0,0 +1,242 @@
+// Package update replaces the sinkzone binary with the latest GitHub release, verifying
+// the download against the release's checksums.txt.
+package update
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// checksumsAsset is the checksum file goreleaser attaches to every release
+	checksumsAsset = "checksums.txt"
+	// maxDownloadSize bounds a downloaded asset
+	maxDownloadSize = 200 << 20
+)
+
+// LatestReleaseURL is the GitHub API endpoint for the newest release
+var LatestReleaseURL = "https://api.github.com/repos/berbyte/sinkzone/releases/latest"
+
+// ErrNoAsset is returned when a release has no binary for this platform
+var ErrNoAsset = errors.New("the release has no binary for this platform")
+
+// Release is a GitHub release
+type Release struct {
+	TagName string  `json:"tag_name"`
+	URL     string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name        string `json:"name"`
+	DownloadURL string `json:"browser_download_url"`
+}
+
+var httpClient = &http.Client{Timeout: 5 * time.Minute}
+
+// Latest fetches the newest release
+func Latest() (*Release, error) {
+	data, err := get(LatestReleaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the latest release: %w", err)
+	}
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse the latest release: %w", err)
+	}
+	return &release, nil
+}
+
+// AssetName returns the release binary name for a platform, e.g. sinkzone-linux-amd64
+func AssetName(goos, goarch string) string {
+	name := fmt.Sprintf("sinkzone-%s-%s", goos, goarch)
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// asset returns the named asset of the release
+func (r *Release) asset(name string) (*Asset, bool) {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i], true
+		}
+	}
+	return nil, false
+}
+
+// Download fetches this platform's binary from the release and verifies it against the
+// release checksums
+func (r *Release) Download() ([]byte, error) {
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+	binary, ok := r.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("%w (%s)", ErrNoAsset, name)
+	}
+	checksums, ok := r.asset(checksumsAsset)
+	if !ok {
+		return nil, fmt.Errorf("the release has no %s to verify the download with", checksumsAsset)
+	}
+
+	sums, err := get(checksums.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", checksumsAsset, err)
+	}
+	data, err := get(binary.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", name, err)
+	}
+	if err := VerifyChecksum(data, sums, name); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// VerifyChecksum checks data against its SHA-256 entry ("<hex>  <name>") in a checksum file
+func VerifyChecksum(data, checksums []byte, name string) error {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || strings.TrimPrefix(fields[1], "*") != name {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		if !strings.EqualFold(fields[0], hex.EncodeToString(sum[:])) {
+			return fmt.Errorf("checksum mismatch for %s: the download is corrupt or was tampered with", name)
+		}
+		return nil
+	}
+	return fmt.Errorf("no checksum for %s in %s", name, checksumsAsset)
+}
+
+// Replace atomically swaps the executable at path for data. The new binary is written
+// next to it and renamed over it, so a failed update leaves the old one in place. Windows
+// can't overwrite a running executable, so there it is moved aside to path.old first.
+func Replace(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".sinkzone-update-*")
+	if err != nil {
+		return fmt.Errorf("failed to create the new binary next to %s: %w", path, err)
+	}
+	tmpPath := tmp.Name()
+	defer func() {
+		_ = os.Remove(tmpPath) // Already renamed away on success
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write the new binary: %w", err)
+	}
+	// #nosec G302 -- the binary must stay executable for everyone who could run it before
+	if err := os.Chmod(tmpPath, info.Mode().Perm()|0755); err != nil {
+		return fmt.Errorf("failed to make the new binary executable: %w", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		oldPath := path + ".old"
+		_ = os.Remove(oldPath) // Left behind by the previous update
+		if err := os.Rename(path, oldPath); err != nil {
+			return fmt.Errorf("failed to move %s aside: %w", path, err)
+		}
+		if err := os.Rename(tmpPath, path); err != nil {
+			_ = os.Rename(oldPath, path)
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+		return nil
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", path, err)
+	}
+	return nil
+}
+
+// IsNewer reports whether release version latest is newer than current. Versions are
+// compared as major.minor.patch, ignoring a leading "v" and any pre-release suffix;
+// a current version that isn't one (e.g. "dev") is always older.
+func IsNewer(current, latest string) bool {
+	latestParts, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	currentParts, ok := parseVersion(current)
+	if !ok {
+		return true
+	}
+	for i := range latestParts {
+		if latestParts[i] != currentParts[i] {
+			return latestParts[i] > currentParts[i]
+		}
+	}
+	return false
+}
+
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return parts, false
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+func get(url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "sinkzone-self-update")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			fmt.Printf("Warning: failed to close response body: %v\n", closeErr)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDownloadSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxDownloadSize {
+		return nil, fmt.Errorf("%s is larger than %d MB", url, maxDownloadSize>>20)
+	}
+	return data, nil
+}
@@ -0,0 +1,101 @@
+package update
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsNewer(t *testing.T) {
+	for _, tc := range []struct {
+		current, latest string
+		want            bool
+	}{
+		{"v1.2.3", "v1.2.4", true},
+		{"1.2.3", "v1.10.0", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v2.0.0", "v1.9.9", false},
+		{"v1.2.3-rc1", "v1.2.3", false}, // Pre-release suffixes are ignored
+		{"dev", "v0.1.0", true},
+		{"v1.0.0", "nightly", false},
+	} {
+		if got := IsNewer(tc.current, tc.latest); got != tc.want {
+			t.Errorf("IsNewer(%q, %q) = %v, want %v", tc.current, tc.latest, got, tc.want)
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	data := []byte("new binary")
+	sum := sha256.Sum256(data)
+	checksums := []byte(fmt.Sprintf("%s  sinkzone-linux-amd64\n%s  sinkzone-darwin-arm64\n", hex.EncodeToString(sum[:]), "00"))
+
+	if err := VerifyChecksum(data, checksums, "sinkzone-linux-amd64"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := VerifyChecksum(data, checksums, "sinkzone-darwin-arm64"); err == nil {
+		t.Error("expected a checksum mismatch")
+	}
+	if err := VerifyChecksum(data, checksums, "sinkzone-windows-amd64.exe"); err == nil {
+		t.Error("expected an error for a missing checksum")
+	}
+}
+
+func TestDownload(t *testing.T) {
+	binary := []byte("new binary")
+	sum := sha256.Sum256(binary)
+	name := AssetName(runtime.GOOS, runtime.GOARCH)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write(binary) })
+	mux.HandleFunc("/checksums", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(sum[:]), name)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	release := &Release{TagName: "v1.0.0", Assets: []Asset{
+		{Name: name, DownloadURL: server.URL + "/binary"},
+		{Name: checksumsAsset, DownloadURL: server.URL + "/checksums"},
+	}}
+	data, err := release.Download()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != string(binary) {
+		t.Errorf("expected the binary, got %q", data)
+	}
+
+	release.Assets = release.Assets[1:]
+	if _, err := release.Download(); err == nil {
+		t.Error("expected an error for a release without this platform's binary")
+	}
+}
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sinkzone")
+	if err := os.WriteFile(path, []byte("old"), 0700); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+
+	if err := Replace(path, []byte("new")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected the new binary, got %q (%v)", data, err)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("expected no leftover files, got %d entries", len(entries))
+	}
+}
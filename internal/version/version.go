@@ -0,0 +1,69 @@
+// Package version holds the build metadata injected at link time, e.g.
+//
+//	go build -ldflags "-X github.com/berbyte/sinkzone/internal/version.Version=v1.2.3
+//	  -X github.com/berbyte/sinkzone/internal/version.Commit=abc1234
+//	  -X github.com/berbyte/sinkzone/internal/version.Date=2025-01-01T00:00:00Z"
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X; builds without them fall back to the VCS info Go embeds
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info is the build metadata reported by 'sinkzone version' and GET /health
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"` // GOOS/GOARCH
+}
+
+// Get returns the metadata of the running binary
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && build.Main.Version != "" && build.Main.Version != "(devel)" {
+			info.Version = build.Main.Version // Installed with 'go install ...@version'
+		}
+		for _, setting := range build.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// String formats the metadata on one line
+func (i Info) String() string {
+	s := "sinkzone " + i.Version
+	if i.Commit != "" {
+		s += " (" + i.Commit
+		if i.Date != "" {
+			s += ", built " + i.Date
+		}
+		s += ")"
+	}
+	return s + fmt.Sprintf(" %s %s", i.GoVersion, i.Platform)
+}
@@ -0,0 +1,25 @@
+package version
+
+import "testing"
+
+func TestString(t *testing.T) {
+	info := Info{Version: "v1.2.3", Commit: "abc1234", Date: "2025-01-01T00:00:00Z", GoVersion: "go1.24.0", Platform: "linux/amd64"}
+	if got, want := info.String(), "sinkzone v1.2.3 (abc1234, built 2025-01-01T00:00:00Z) go1.24.0 linux/amd64"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+
+	info = Info{Version: "dev", GoVersion: "go1.24.0", Platform: "darwin/arm64"}
+	if got, want := info.String(), "sinkzone dev go1.24.0 darwin/arm64"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestGetPrefersLinkerValues(t *testing.T) {
+	defer func(version, commit, date string) { Version, Commit, Date = version, commit, date }(Version, Commit, Date)
+	Version, Commit, Date = "v9.9.9", "0123456789abcdef0123", "2025-06-01"
+
+	info := Get()
+	if info.Version != "v9.9.9" || info.Commit != "0123456789ab" || info.Date != "2025-06-01" {
+		t.Errorf("expected the linker values with a shortened commit, got %+v", info)
+	}
+}
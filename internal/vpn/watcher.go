@@ -0,0 +1,249 @@
+// Package vpn notices when a VPN connects or disconnects, so the resolver can send queries
+// to the VPN's nameservers while it is up and names only they resolve keep working.
+package vpn
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/sysdns"
+)
+
+// Interface is a network interface that is up, with its addresses
+type Interface struct {
+	Name      string
+	Addresses []netip.Prefix
+}
+
+// Watcher checks the network interfaces for the VPN of a vpn section and calls apply with
+// the nameservers to use while it is up, and with nil once it is down again.
+//
+// Without vpn.upstreams the VPN's nameservers are those the system gained when it came up:
+// the system's nameservers are noted while the VPN is down and compared once it is up. When
+// the VPN is already up as the resolver starts, every nameserver of the system counts.
+type Watcher struct {
+	apply      func(upstreams []config.Upstream, domains []string)
+	interfaces func() ([]Interface, error)
+	resolvers  func() ([]string, error)
+
+	mu      sync.Mutex
+	cfg     *config.VPNConfig
+	changed bool // The config changed since the last check
+
+	current     []config.Upstream // Nameservers applied, nil while the VPN is down
+	seen        string            // Interfaces and addresses at the last check
+	pending     bool              // The VPN is up, but the system has no new nameservers yet
+	baseline    []string          // Nameservers of the system while the VPN was down
+	initialized bool
+}
+
+// NewWatcher creates a watcher for the VPN of cfg, which may be nil to wait for a config
+// that sets one
+func NewWatcher(cfg *config.VPNConfig, apply func(upstreams []config.Upstream, domains []string)) (*Watcher, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &Watcher{
+		apply:      apply,
+		interfaces: Interfaces,
+		resolvers:  sysdns.SystemResolvers,
+		cfg:        cfg,
+	}, nil
+}
+
+// SetConfig switches to the VPN of a changed config; nil stops watching and switches back
+// to the configured nameservers
+func (w *Watcher) SetConfig(cfg *config.VPNConfig) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cfg = cfg
+	w.changed = true
+}
+
+// Run checks the interfaces until the stop channel is closed
+func (w *Watcher) Run(stop <-chan struct{}) {
+	w.mu.Lock()
+	if w.cfg != nil {
+		interval, _ := w.cfg.GetCheckInterval()
+		log.Printf("VPN watcher started (checking every %s)", interval)
+	}
+	w.mu.Unlock()
+
+	for {
+		w.check()
+
+		w.mu.Lock()
+		interval, err := w.cfg.GetCheckInterval()
+		w.mu.Unlock()
+		if err != nil {
+			interval = config.DefaultVPNCheckInterval
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// check switches to the VPN's nameservers when it came up and back when it went down
+func (w *Watcher) check() {
+	w.mu.Lock()
+	cfg, changed := w.cfg, w.changed
+	w.changed = false
+	w.mu.Unlock()
+
+	if cfg == nil {
+		w.down("")
+		return
+	}
+	interfaces, err := w.interfaces()
+	if err != nil {
+		log.Printf("Warning: failed to list network interfaces: %v", err)
+		return
+	}
+	seen := fmt.Sprint(interfaces)
+	if seen == w.seen && !changed && !w.pending && w.initialized {
+		return
+	}
+	w.seen, w.initialized = seen, true
+
+	name := match(cfg, interfaces)
+	if name == "" {
+		w.pending = false
+		w.down("VPN is down, using the configured nameservers again")
+		if len(cfg.Upstreams) == 0 {
+			w.noteBaseline()
+		}
+		return
+	}
+
+	upstreams, _ := cfg.GetUpstreams() // Validated by NewWatcher and config set
+	if len(upstreams) == 0 {
+		upstreams = w.newResolvers()
+		if len(upstreams) == 0 {
+			if !w.pending {
+				log.Printf("Warning: VPN on %s is up, but the system has no new nameservers yet; set vpn.upstreams if it keeps using the same ones", name)
+			}
+			w.pending = true
+			return
+		}
+	}
+	w.pending = false
+	if slices.Equal(upstreams, w.current) && !changed {
+		return
+	}
+	domains, _ := cfg.GetDomains()
+	addresses := make([]string, len(upstreams))
+	for i, upstream := range upstreams {
+		addresses[i] = upstream.String()
+	}
+	if len(domains) > 0 {
+		log.Printf("VPN on %s is up, sending %s to %s", name, strings.Join(domains, ", "), strings.Join(addresses, ", "))
+	} else {
+		log.Printf("VPN on %s is up, using its nameservers %s", name, strings.Join(addresses, ", "))
+	}
+	w.current = upstreams
+	w.apply(upstreams, domains)
+}
+
+// down switches back to the configured nameservers if the VPN's are in use
+func (w *Watcher) down(message string) {
+	if w.current == nil {
+		return
+	}
+	if message != "" {
+		log.Print(message)
+	}
+	w.current = nil
+	w.apply(nil, nil)
+}
+
+// noteBaseline notes the nameservers of the system while the VPN is down
+func (w *Watcher) noteBaseline() {
+	servers, err := w.resolvers()
+	if err != nil {
+		log.Printf("Warning: failed to read the system's nameservers: %v", err)
+		return
+	}
+	w.baseline = servers
+}
+
+// newResolvers returns the nameservers the system gained since the VPN was down, leaving out
+// those on this machine, which may be the resolver itself
+func (w *Watcher) newResolvers() []config.Upstream {
+	servers, err := w.resolvers()
+	if err != nil {
+		log.Printf("Warning: failed to read the system's nameservers: %v", err)
+		return nil
+	}
+	var upstreams []config.Upstream
+	for _, server := range servers {
+		if sysdns.IsLocal(server) || slices.Contains(w.baseline, server) {
+			continue
+		}
+		if upstream, err := config.ParseUpstream(server); err == nil {
+			upstreams = append(upstreams, upstream)
+		}
+	}
+	return upstreams
+}
+
+// match returns the name of the interface the VPN is up on, "" while it is down. An
+// interface counts when its name matches vpn.interfaces and it has an address other than a
+// link-local one (macOS keeps idle utun interfaces with those), or when one of its addresses
+// is in vpn.networks.
+func match(cfg *config.VPNConfig, interfaces []Interface) string {
+	networks, _ := cfg.GetNetworks()
+	for _, iface := range interfaces {
+		named := slices.ContainsFunc(cfg.Interfaces, func(pattern string) bool {
+			ok, _ := path.Match(pattern, iface.Name)
+			return ok
+		})
+		for _, address := range iface.Addresses {
+			if named && !address.Addr().IsLinkLocalUnicast() {
+				return iface.Name
+			}
+			for _, network := range networks {
+				if network.Contains(address.Addr()) {
+					return iface.Name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Interfaces returns the network interfaces that are up, with their addresses
+func Interfaces() ([]Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list network interfaces: %w", err)
+	}
+	var interfaces []Interface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		entry := Interface{Name: iface.Name}
+		for _, addr := range addrs {
+			if prefix, err := netip.ParsePrefix(addr.String()); err == nil {
+				entry.Addresses = append(entry.Addresses, prefix)
+			}
+		}
+		interfaces = append(interfaces, entry)
+	}
+	return interfaces, nil
+}
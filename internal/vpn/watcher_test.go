@@ -0,0 +1,78 @@
+package vpn
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/berbyte/sinkzone/internal/config"
+)
+
+func TestWatcherFollowsVPN(t *testing.T) {
+	var applied [][]config.Upstream
+	watcher, err := NewWatcher(&config.VPNConfig{Interfaces: []string{"utun*"}, Domains: []string{"corp.example.com"}},
+		func(upstreams []config.Upstream, domains []string) {
+			applied = append(applied, upstreams)
+			if upstreams != nil && !slices.Equal(domains, []string{"corp.example.com"}) {
+				t.Errorf("Expected the VPN's domains, got %v", domains)
+			}
+		})
+	if err != nil {
+		t.Fatalf("Failed to create watcher: %v", err)
+	}
+
+	idle := Interface{Name: "utun0", Addresses: []netip.Prefix{netip.MustParsePrefix("fe80::1/64")}}
+	interfaces := []Interface{idle}
+	resolvers := []string{"192.168.1.1", "127.0.0.1"}
+	watcher.interfaces = func() ([]Interface, error) { return interfaces, nil }
+	watcher.resolvers = func() ([]string, error) { return resolvers, nil }
+
+	watcher.check()
+	if len(applied) != 0 {
+		t.Fatalf("Expected nothing while only an idle utun interface is up, got %v", applied)
+	}
+
+	// The interface comes up before the VPN client sets its nameservers
+	interfaces = []Interface{idle, {Name: "utun4", Addresses: []netip.Prefix{netip.MustParsePrefix("10.8.0.2/32")}}}
+	watcher.check()
+	if len(applied) != 0 {
+		t.Fatalf("Expected to wait for the VPN's nameservers, got %v", applied)
+	}
+
+	resolvers = []string{"10.8.0.1", "192.168.1.1", "127.0.0.1"}
+	watcher.check()
+	if len(applied) != 1 || len(applied[0]) != 1 || applied[0][0].Address != "10.8.0.1:53" {
+		t.Fatalf("Expected to switch to the nameserver the VPN added, got %v", applied)
+	}
+
+	watcher.check()
+	if len(applied) != 1 {
+		t.Fatalf("Expected no switch while nothing changes, got %v", applied)
+	}
+
+	interfaces = []Interface{idle}
+	watcher.check()
+	if len(applied) != 2 || applied[1] != nil {
+		t.Fatalf("Expected to switch back once the VPN is down, got %v", applied)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	interfaces := []Interface{
+		{Name: "en0", Addresses: []netip.Prefix{netip.MustParsePrefix("10.8.3.4/16")}},
+		{Name: "wg0", Addresses: []netip.Prefix{netip.MustParsePrefix("100.64.0.2/32")}},
+	}
+	for _, tt := range []struct {
+		cfg  config.VPNConfig
+		want string
+	}{
+		{config.VPNConfig{Interfaces: []string{"wg*"}}, "wg0"},
+		{config.VPNConfig{Interfaces: []string{"tun*"}}, ""},
+		{config.VPNConfig{Networks: []string{"10.8.0.0/16"}}, "en0"},
+		{config.VPNConfig{Networks: []string{"10.9.0.0/16"}}, ""},
+	} {
+		if got := match(&tt.cfg, interfaces); got != tt.want {
+			t.Errorf("match(%+v) = %q, want %q", tt.cfg, got, tt.want)
+		}
+	}
+}
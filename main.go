@@ -9,7 +9,9 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		if message := err.Error(); message != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", message)
+		}
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,42 @@
+package sinkzone
+
+import (
+	"github.com/berbyte/sinkzone/internal/allowlist"
+)
+
+// Allowlist edits allowlist.txt of the sinkzone on this machine, in the data directory
+// ($SINKZONE_CONFIG_DIR or ~/.sinkzone). Entries are domains such as "github.com" or
+// wildcards such as "*.example.com". Changes reach a running resolver with
+// Client.ReloadAllowlist, and can be undone with 'sinkzone allowlist undo'.
+type Allowlist struct {
+	manager *allowlist.Manager
+}
+
+// OpenAllowlist returns the allowlist of this machine
+func OpenAllowlist() (*Allowlist, error) {
+	manager, err := allowlist.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	return &Allowlist{manager: manager}, nil
+}
+
+// List returns the entries of the allowlist
+func (a *Allowlist) List() ([]string, error) {
+	return a.manager.List()
+}
+
+// Add adds domains to the allowlist, skipping ones already on it, and returns the ones added
+func (a *Allowlist) Add(domains ...string) ([]string, error) {
+	return a.manager.AddAll(domains)
+}
+
+// Remove removes domains from the allowlist and returns the ones that were on it
+func (a *Allowlist) Remove(domains ...string) ([]string, error) {
+	return a.manager.RemoveAll(domains)
+}
+
+// Path returns the path of allowlist.txt
+func (a *Allowlist) Path() string {
+	return a.manager.GetPath()
+}
@@ -0,0 +1,197 @@
+// Package sinkzone lets Go programs, such as status bars, bots, and dashboards, talk to a
+// running sinkzone resolver through its HTTP API: read the query log and statistics, follow
+// queries as they happen, start, pause, and end focus sessions, and edit the allowlist.
+//
+// This package is the stable interface to sinkzone: its exported names follow semantic
+// versioning with the module, so they are only removed or changed incompatibly in a new
+// major version. The types are aliases of the resolver's own, which may gain fields and
+// methods in minor versions. Everything under internal/ may change in any release.
+//
+//	client := sinkzone.New("")
+//	state, err := client.Focus()
+//	if err != nil {
+//		return err
+//	}
+//	if state.Enabled {
+//		fmt.Println("Focusing until", state.EndTime.Local().Format("15:04"))
+//	}
+package sinkzone
+
+import (
+	"context"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/api"
+	"github.com/berbyte/sinkzone/internal/config"
+	"github.com/berbyte/sinkzone/internal/version"
+)
+
+type (
+	// Query is a domain looked up through the resolver, with whether it was blocked
+	Query = api.DNSQuery
+	// QueryFilter selects queries from the log; zero values match everything
+	QueryFilter = api.QueryFilter
+	// FocusState is whether focus mode is on, until when, and whether it is paused
+	FocusState = api.FocusModeState
+	// FocusOptions start a focus session with a profile, intensity, label, or end time
+	FocusOptions = api.FocusRequest
+	// Decision is whether a domain is blocked right now, and why
+	Decision = api.Decision
+	// Snooze lets a single blocked domain resolve until a time during the session
+	Snooze = api.Snooze
+	// Session is a focus session that ended, from the session history
+	Session = api.FocusSession
+	// Stats are the focus time of today and the week and the goal progress
+	Stats = api.FocusStats
+	// QueryStats count the queries, blocked queries, and top domains of a period
+	QueryStats = api.QueryStats
+	// Summary is the short state shown by status bars
+	Summary = api.Summary
+	// Health is the resolver's health as reported by /api/health
+	Health = api.ResolverHealth
+	// VersionInfo is the resolver's version, commit, and build date
+	VersionInfo = version.Info
+)
+
+// Client talks to a resolver's HTTP API. It is safe for concurrent use.
+type Client struct {
+	api *api.Client
+}
+
+// Option configures a Client
+type Option func(*options)
+
+type options struct {
+	token    string
+	hasToken bool
+}
+
+// WithToken sends token as the bearer token, needed when api_tokens are set in
+// sinkzone.yaml. An empty token sends none.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token, o.hasToken = token, true
+	}
+}
+
+// New returns a client of the resolver API at baseURL, e.g. "http://127.0.0.1:8080". An empty
+// baseURL means $SINKZONE_API_URL or the default. Like the CLI, it sends $SINKZONE_API_TOKEN, or
+// else the first admin token of the local sinkzone.yaml, unless WithToken is given.
+func New(baseURL string, opts ...Option) *Client {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if baseURL == "" {
+		baseURL = config.DefaultAPIURL()
+	}
+	if !o.hasToken {
+		o.token = config.ClientAPIToken()
+	}
+	return &Client{api: api.NewClientWithToken(baseURL, o.token)}
+}
+
+// Ping checks that the resolver API answers
+func (c *Client) Ping() error {
+	return c.api.HealthCheck()
+}
+
+// Health returns the resolver's health: whether DNS is served, the upstreams, and warnings
+func (c *Client) Health() (*Health, error) {
+	return c.api.GetHealth()
+}
+
+// Version returns the version of the resolver
+func (c *Client) Version() (*VersionInfo, error) {
+	return c.api.GetVersion()
+}
+
+// Queries returns the latest query for each recently seen domain
+func (c *Client) Queries() ([]Query, error) {
+	return c.api.GetQueries(0)
+}
+
+// QueryHistory returns the queries of the log that match filter, newest first
+func (c *Client) QueryHistory(filter QueryFilter) ([]Query, error) {
+	return c.api.GetQueryHistory(filter)
+}
+
+// StreamQueries calls onQuery for every query the resolver records until ctx is cancelled or
+// the stream breaks, which it returns as an error. Queries the resolver skipped because the
+// client fell behind are left out.
+func (c *Client) StreamQueries(ctx context.Context, onQuery func(Query)) error {
+	return c.api.StreamQueries(ctx, onQuery, nil)
+}
+
+// Check returns whether the resolver blocks domain right now, and why
+func (c *Client) Check(domain string) (*Decision, error) {
+	return c.api.GetDecision(domain)
+}
+
+// Focus returns the state of focus mode
+func (c *Client) Focus() (*FocusState, error) {
+	return c.api.GetFocusMode()
+}
+
+// StartFocus starts a focus session of duration, or of the configured default when 0
+func (c *Client) StartFocus(duration time.Duration) error {
+	req := FocusOptions{Enabled: true}
+	if duration > 0 {
+		req.Duration = duration.String()
+	}
+	return c.api.SetFocusModeWithOptions(req)
+}
+
+// StartFocusWithOptions starts a focus session as described by opts; Enabled is implied
+func (c *Client) StartFocusWithOptions(opts FocusOptions) error {
+	opts.Enabled = true
+	return c.api.SetFocusModeWithOptions(opts)
+}
+
+// StopFocus ends the focus session; pin is needed when a focus PIN is set
+func (c *Client) StopFocus(pin string) error {
+	return c.api.SetFocusModeWithOptions(FocusOptions{PIN: pin})
+}
+
+// PauseFocus stops blocking for duration; pin is needed when a focus PIN is set
+func (c *Client) PauseFocus(duration time.Duration, pin string) error {
+	return c.api.PauseFocusMode(duration.String(), pin)
+}
+
+// ResumeFocus ends a pause early
+func (c *Client) ResumeFocus() error {
+	return c.api.ResumeFocusMode()
+}
+
+// SnoozeDomain lets domain resolve for duration during the focus session; pin is needed
+// when a focus PIN is set
+func (c *Client) SnoozeDomain(domain string, duration time.Duration, pin string) (*Snooze, error) {
+	return c.api.SnoozeDomain(api.SnoozeRequest{Domain: domain, Duration: duration.String(), PIN: pin})
+}
+
+// Sessions returns the focus sessions that ended since the given time (all of them when
+// zero), oldest first. The resolver keeps them with the query log.
+func (c *Client) Sessions(since time.Time) ([]Session, error) {
+	return c.api.GetSessions(since)
+}
+
+// Stats returns the focus time of today and this week
+func (c *Client) Stats() (*Stats, error) {
+	return c.api.GetStats()
+}
+
+// QueryStats returns query counts and top domains and clients of the last window, or since
+// the resolver started when 0
+func (c *Client) QueryStats(window time.Duration) (*QueryStats, error) {
+	return c.api.GetQueryStatsSince(window)
+}
+
+// Summary returns the short state shown by status bars
+func (c *Client) Summary() (*Summary, error) {
+	return c.api.GetSummary()
+}
+
+// ReloadAllowlist makes the resolver read allowlist.txt again, e.g. after Allowlist.Add
+func (c *Client) ReloadAllowlist() error {
+	return c.api.ReloadAllowlist()
+}
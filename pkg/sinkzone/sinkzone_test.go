@@ -0,0 +1,73 @@
+package sinkzone
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/berbyte/sinkzone/internal/sinktest"
+)
+
+func TestClient(t *testing.T) {
+	r := sinktest.Start(t, sinktest.Options{Allowlist: []string{"github.com"}})
+	client := New(r.APIURL, WithToken(""))
+
+	queries := make(chan Query, 256)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = client.StreamQueries(ctx, func(query Query) {
+			select {
+			case queries <- query:
+			default:
+			}
+		})
+	}()
+
+	if err := client.StartFocus(time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := client.Focus(); err != nil || !state.Enabled {
+		t.Fatalf("expected focus mode to be on, got %+v (%v)", state, err)
+	}
+	if !r.Blocked(t, "example.com") {
+		t.Error("expected example.com to be blocked")
+	}
+
+	// The stream may connect after the first query, so query again until one is streamed
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for streamed := false; !streamed; {
+		select {
+		case query := <-queries:
+			streamed = query.Domain == "example.com" && query.Blocked
+		case <-ticker.C:
+			r.Blocked(t, "example.com")
+		case <-timeout:
+			t.Fatal("expected the blocked query to be streamed")
+		}
+	}
+
+	// Allow it through the allowlist file and a reload
+	list, err := OpenAllowlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added, err := list.Add("example.com", "github.com"); err != nil || len(added) != 1 {
+		t.Fatalf("expected only example.com to be added, got %v (%v)", added, err)
+	}
+	if err := client.ReloadAllowlist(); err != nil {
+		t.Fatal(err)
+	}
+	if r.Blocked(t, "example.com") {
+		t.Error("expected example.com to resolve once allowed")
+	}
+
+	if err := client.StopFocus(""); err != nil {
+		t.Fatal(err)
+	}
+	if state, err := client.Focus(); err != nil || state.Enabled {
+		t.Fatalf("expected focus mode to be off, got %+v (%v)", state, err)
+	}
+}